@@ -9,6 +9,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // store time zones resolve without zoneinfo in the image
 
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -19,6 +20,7 @@ import (
 	"malaka/internal/config"
 	"malaka/internal/server/container"
 	httpserver "malaka/internal/server/http"
+	"malaka/internal/server/jobs"
 	"malaka/internal/shared/cache"
 	chdb "malaka/internal/shared/clickhouse"
 	"malaka/internal/shared/database"
@@ -36,6 +38,16 @@ const (
 
 	// Worker pool settings
 	maxWorkers = 100
+
+	// Scheduled jobs
+	approvalEscalationSchedule = "*/15 * * * *"
+	loyaltyMaintenanceSchedule = "0 2 * * *"
+	quotationExpirySchedule    = "0 1 * * *"
+	consignmentSettleSchedule  = "0 3 1 * *"
+	marketplaceSyncSchedule    = "*/10 * * * *"
+	salesReconcileSchedule     = "0 5 * * *"
+	contractRenewalSchedule    = "0 6 * * *"
+	vendorEvaluationSchedule   = "0 4 1 * *"
 )
 
 // WorkerPool manages concurrent background tasks
@@ -265,6 +277,112 @@ func main() {
 		}
 	})
 
+	// Start scheduled jobs
+	scheduler := jobs.NewScheduler(zapLogger)
+	if _, err := scheduler.AddJob(approvalEscalationSchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		escalated, err := appContainer.ApprovalService.ProcessEscalations(ctx)
+		if err != nil {
+			zapLogger.Error("Approval escalation job failed", zap.Error(err))
+			return
+		}
+		if escalated > 0 {
+			zapLogger.Info("Escalated overdue approval tasks", zap.Int("count", escalated))
+		}
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule approval escalation job", zap.Error(err))
+	}
+	if _, err := scheduler.AddJob(loyaltyMaintenanceSchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		expired, tierChanges, err := appContainer.LoyaltyService.RunMaintenance(ctx)
+		if err != nil {
+			zapLogger.Error("Loyalty maintenance job failed", zap.Error(err))
+			return
+		}
+		zapLogger.Info("Loyalty maintenance completed", zap.Int("expired_points", expired), zap.Int("tier_changes", tierChanges))
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule loyalty maintenance job", zap.Error(err))
+	}
+	if _, err := scheduler.AddJob(quotationExpirySchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		expired, err := appContainer.SalesQuotationService.ExpireQuotations(ctx)
+		if err != nil {
+			zapLogger.Error("Quotation expiry job failed", zap.Error(err))
+			return
+		}
+		if expired > 0 {
+			zapLogger.Info("Expired sales quotations", zap.Int("count", expired))
+		}
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule quotation expiry job", zap.Error(err))
+	}
+	if _, err := scheduler.AddJob(consignmentSettleSchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		settled, err := appContainer.ConsignmentService.SettlePreviousMonth(ctx)
+		if err != nil {
+			zapLogger.Error("Consignment settlement job failed", zap.Error(err))
+			return
+		}
+		zapLogger.Info("Consignment settlements invoiced", zap.Int("count", settled))
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule consignment settlement job", zap.Error(err))
+	}
+	if _, err := scheduler.AddJob(marketplaceSyncSchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		created, err := appContainer.MarketplaceService.SyncChannels(ctx)
+		if err != nil {
+			zapLogger.Error("Marketplace sync job failed", zap.Error(err))
+			return
+		}
+		if created > 0 {
+			zapLogger.Info("Imported marketplace orders", zap.Int("count", created))
+		}
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule marketplace sync job", zap.Error(err))
+	}
+	if _, err := scheduler.AddJob(salesReconcileSchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		recs, err := appContainer.SalesReconciliationService.ReconcileYesterday(ctx)
+		if err != nil {
+			zapLogger.Error("Sales reconciliation job failed", zap.Error(err))
+			return
+		}
+		zapLogger.Info("Sales reconciled against settlements", zap.Int("count", len(recs)))
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule sales reconciliation job", zap.Error(err))
+	}
+	if _, err := scheduler.AddJob(contractRenewalSchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		run, err := appContainer.ContractService.RunRenewalCycle(ctx)
+		if err != nil {
+			zapLogger.Error("Contract renewal job failed", zap.Error(err))
+			return
+		}
+		zapLogger.Info("Contract renewal completed", zap.Int("renewed", run.Renewed), zap.Int("expired", run.Expired), zap.Int("notified", run.Notified))
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule contract renewal job", zap.Error(err))
+	}
+	if _, err := scheduler.AddJob(vendorEvaluationSchedule, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		run, err := appContainer.VendorEvaluationService.GeneratePreviousMonth(ctx)
+		if err != nil {
+			zapLogger.Error("Vendor evaluation job failed", zap.Error(err))
+			return
+		}
+		zapLogger.Info("Draft vendor evaluations generated", zap.Int("created", run.Created), zap.Int("skipped", run.Skipped))
+	}); err != nil {
+		zapLogger.Fatal("cannot schedule vendor evaluation job", zap.Error(err))
+	}
+	scheduler.Start()
+
 	// Channel to track server errors
 	serverErrors := make(chan error, 1)
 
@@ -307,6 +425,14 @@ func main() {
 		zapLogger.Info("HTTP server stopped gracefully")
 	}
 
+	// Stop scheduled jobs, waiting for running ones to finish
+	select {
+	case <-scheduler.Stop().Done():
+		zapLogger.Info("Job scheduler stopped")
+	case <-ctx.Done():
+		zapLogger.Warn("Scheduled jobs did not finish before shutdown timeout")
+	}
+
 	// Stop worker pool
 	workerPool.Stop()
 	zapLogger.Info("Worker pool stopped")
@@ -16,6 +16,8 @@ type Config struct {
 	ServerAddress string        `mapstructure:"SERVER_ADDRESS"`
 	JWTSecret     string        `mapstructure:"JWT_SECRET"`
 	JWTExpiryHours int          `mapstructure:"JWT_EXPIRY_HOURS"` // Token expiry in hours (default: 48 = 2 days)
+	AccessTokenTTLMinutes int   `mapstructure:"ACCESS_TOKEN_TTL_MINUTES"` // Session access token lifetime (default: 15 minutes)
+	RefreshTokenTTLHours  int   `mapstructure:"REFRESH_TOKEN_TTL_HOURS"`  // Idle lifetime of a login session (default: 720 = 30 days)
 	EncryptionKey string        `mapstructure:"ENCRYPTION_KEY"`
 	RedisAddr     string        `mapstructure:"REDIS_ADDR"`
 	RedisPassword string        `mapstructure:"REDIS_PASSWORD"`
@@ -48,6 +50,9 @@ type Config struct {
 	ClickHouseDatabase string `mapstructure:"CLICKHOUSE_DATABASE"`
 	ClickHouseUsername string `mapstructure:"CLICKHOUSE_USERNAME"`
 	ClickHousePassword string `mapstructure:"CLICKHOUSE_PASSWORD"`
+
+	// Tax Configuration
+	CompanyNPWP string `mapstructure:"COMPANY_NPWP"` // NPWP of the company, the seller on e-Faktur/Coretax files
 }
 
 // GetMediaPath returns the media storage path with default of ./media
@@ -126,3 +131,19 @@ func (c *Config) GetJWTExpiryHours() int {
 	}
 	return c.JWTExpiryHours
 }
+
+// GetAccessTokenTTL returns the lifetime of session access tokens with default of 15 minutes
+func (c *Config) GetAccessTokenTTL() time.Duration {
+	if c.AccessTokenTTLMinutes <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(c.AccessTokenTTLMinutes) * time.Minute
+}
+
+// GetRefreshTokenTTL returns the refresh token lifetime with default of 30 days
+func (c *Config) GetRefreshTokenTTL() time.Duration {
+	if c.RefreshTokenTTLHours <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(c.RefreshTokenTTLHours) * time.Hour
+}
@@ -63,12 +63,18 @@ type TaxTransaction struct {
 	CustomerID       string    `json:"customer_id" db:"customer_id"`
 	SupplierID       string    `json:"supplier_id" db:"supplier_id"`
 	JournalEntryID   *uuid.ID  `json:"journal_entry_id" db:"journal_entry_id"`
+	TaxReturnID      *uuid.ID  `json:"tax_return_id" db:"tax_return_id"`       // Return the transaction is filed in
 	CompanyID        string    `json:"company_id" db:"company_id"`
 	CreatedBy        string    `json:"created_by" db:"created_by"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// IsLocked returns true if the transaction is filed in a tax return and can no longer change
+func (tt *TaxTransaction) IsLocked() bool {
+	return tt.TaxReturnID != nil && !tt.TaxReturnID.IsNil()
+}
+
 // TaxReturn represents a tax return/filing
 type TaxReturn struct {
 	ID              uuid.ID `json:"id" db:"id"`
@@ -77,17 +83,22 @@ type TaxReturn struct {
 	PeriodStart     time.Time `json:"period_start" db:"period_start"`
 	PeriodEnd       time.Time `json:"period_end" db:"period_end"`
 	FilingDate      time.Time `json:"filing_date" db:"filing_date"`
-	DueDate         time.Time `json:"due_date" db:"due_date"`
+	DueDate         time.Time `json:"due_date" db:"due_date"`                 // Filing due date
+	PaymentDueDate  *time.Time `json:"payment_due_date" db:"payment_due_date"`
 	Status          TaxStatus `json:"status" db:"status"`
 	TotalSales      float64   `json:"total_sales" db:"total_sales"`
 	TotalPurchases  float64   `json:"total_purchases" db:"total_purchases"`
 	OutputTax       float64   `json:"output_tax" db:"output_tax"`             // Tax on sales
 	InputTax        float64   `json:"input_tax" db:"input_tax"`               // Tax on purchases
+	OverpaymentBroughtForward float64 `json:"overpayment_brought_forward" db:"overpayment_brought_forward"` // Overpayment of the previous period
+	OverpaymentCarriedForward float64 `json:"overpayment_carried_forward" db:"overpayment_carried_forward"` // Overpayment to the next period
 	TaxPayable      float64   `json:"tax_payable" db:"tax_payable"`           // Net tax to pay
 	TaxPaid         float64   `json:"tax_paid" db:"tax_paid"`
 	PenaltyAmount   float64   `json:"penalty_amount" db:"penalty_amount"`
 	InterestAmount  float64   `json:"interest_amount" db:"interest_amount"`
 	TotalDue        float64   `json:"total_due" db:"total_due"`
+	TransactionCount int      `json:"transaction_count" db:"transaction_count"`
+	JournalEntryID  *uuid.ID  `json:"journal_entry_id" db:"journal_entry_id"`   // Settlement journal
 	SubmittedBy     string    `json:"submitted_by" db:"submitted_by"`
 	SubmittedAt     *time.Time `json:"submitted_at" db:"submitted_at"`
 	PaidAt          *time.Time `json:"paid_at" db:"paid_at"`
@@ -135,11 +146,14 @@ func (tt *TaxTransaction) CalculateTaxAmount(tax *Tax) {
 	tt.TotalAmount = tt.BaseAmount + tt.TaxAmount
 }
 
-// CalculateNetTax calculates net tax payable (output tax - input tax)
+// CalculateNetTax calculates net tax payable (output tax - input tax - overpayment brought
+// forward); an overpayment is carried forward to the next period
 func (tr *TaxReturn) CalculateNetTax() {
-	tr.TaxPayable = tr.OutputTax - tr.InputTax
+	tr.TaxPayable = tr.OutputTax - tr.InputTax - tr.OverpaymentBroughtForward
+	tr.OverpaymentCarriedForward = 0
 	if tr.TaxPayable < 0 {
-		tr.TaxPayable = 0 // No refund handling in this simplified version
+		tr.OverpaymentCarriedForward = -tr.TaxPayable
+		tr.TaxPayable = 0
 	}
 }
 
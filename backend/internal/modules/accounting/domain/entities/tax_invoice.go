@@ -0,0 +1,222 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// Tax invoice kinds; a replacement (faktur pengganti) keeps the NSFP of the faktur it replaces
+const (
+	TaxInvoiceNormal      = "normal"
+	TaxInvoiceReplacement = "replacement"
+)
+
+// Tax invoice statuses
+const (
+	TaxInvoiceActive    = "active"
+	TaxInvoiceReplaced  = "replaced"
+	TaxInvoiceCancelled = "cancelled"
+)
+
+// NSFP range statuses
+const (
+	NSFPRangeActive    = "active"
+	NSFPRangeExhausted = "exhausted"
+)
+
+// TaxInvoiceTransactionCodes are the e-Faktur transaction codes (kode transaksi):
+// 01 deliveries to non-collectors, 02-03 to government collectors, 04 other tax base,
+// 05 deemed tax, 06 other deliveries, 07 exempt-not-collected, 08 exempt, 09 assets, 10 other.
+var TaxInvoiceTransactionCodes = map[string]bool{
+	"01": true, "02": true, "03": true, "04": true, "05": true,
+	"06": true, "07": true, "08": true, "09": true, "10": true,
+}
+
+// DefaultTaxInvoiceTransactionCode is used for fakturs issued without a code.
+const DefaultTaxInvoiceTransactionCode = "01"
+
+var (
+	ErrInvalidNSFPRange       = errors.New("invalid NSFP range")
+	ErrNSFPRangeOverlap       = errors.New("NSFP range overlaps a registered range")
+	ErrNSFPExhausted          = errors.New("no NSFP number left for the year")
+	ErrInvalidTaxInvoice      = errors.New("invalid tax invoice")
+	ErrTaxInvoiceExists       = errors.New("invoice already has a tax invoice")
+	ErrTaxInvoiceNotFound     = errors.New("tax invoice not found")
+	ErrTaxInvoiceNotActive    = errors.New("tax invoice is no longer in force")
+	ErrSalesInvoiceNotFound   = errors.New("sales invoice not found")
+	ErrInvalidInputTaxInvoice = errors.New("invalid input tax invoice file")
+)
+
+// NSFPRange is a range of tax invoice serial numbers (Nomor Seri Faktur Pajak) the tax
+// office allocated for a year. An NSFP reads BBB-YY.SSSSSSSS: branch code, year and serial.
+type NSFPRange struct {
+	ID           uuid.ID   `json:"id" db:"id"`
+	BranchCode   string    `json:"branch_code" db:"branch_code"`
+	TaxYear      int       `json:"tax_year" db:"tax_year"`
+	StartSerial  int64     `json:"start_serial" db:"start_serial"`
+	EndSerial    int64     `json:"end_serial" db:"end_serial"`
+	NextSerial   int64     `json:"next_serial" db:"next_serial"`
+	LetterNumber string    `json:"letter_number" db:"letter_number"`
+	Status       string    `json:"status" db:"status"`
+	CreatedBy    string    `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Remaining returns how many numbers of the range are still unused.
+func (r *NSFPRange) Remaining() int64 {
+	return r.EndSerial - r.NextSerial + 1
+}
+
+// Format returns the NSFP of a serial of the range.
+func (r *NSFPRange) Format(serial int64) string {
+	return FormatNSFP(r.BranchCode, r.TaxYear, serial)
+}
+
+// FormatNSFP returns an NSFP as BBB-YY.SSSSSSSS.
+func FormatNSFP(branchCode string, year int, serial int64) string {
+	return fmt.Sprintf("%s-%02d.%08d", branchCode, year%100, serial)
+}
+
+// ParseNSFP reads an NSFP written as BBB-YY.SSSSSSSS or as its 13 digits, optionally
+// preceded by the transaction code and replacement flag of a full faktur number.
+func ParseNSFP(value string) (branchCode string, yearCode int, serial int64, err error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	switch len(digits) {
+	case 13:
+	case 16:
+		digits = digits[3:]
+	default:
+		return "", 0, 0, fmt.Errorf("%w: %q is not an NSFP", ErrInvalidNSFPRange, value)
+	}
+	yearCode, _ = strconv.Atoi(digits[3:5])
+	serial, _ = strconv.ParseInt(digits[5:], 10, 64)
+	if serial == 0 {
+		return "", 0, 0, fmt.Errorf("%w: %q has no serial", ErrInvalidNSFPRange, value)
+	}
+	return digits[:3], yearCode, serial, nil
+}
+
+// TaxInvoice is a faktur pajak keluaran issued for a sales invoice with PPN.
+type TaxInvoice struct {
+	ID              uuid.ID           `json:"id" db:"id"`
+	FakturNumber    string            `json:"faktur_number" db:"faktur_number"`
+	NSFP            string            `json:"nsfp" db:"nsfp"`
+	NSFPRangeID     uuid.ID           `json:"nsfp_range_id" db:"nsfp_range_id"`
+	TransactionCode string            `json:"transaction_code" db:"transaction_code"`
+	Kind            string            `json:"kind" db:"kind"`
+	ReplacesID      *uuid.ID          `json:"replaces_id,omitempty" db:"replaces_id"`
+	SalesInvoiceID  uuid.ID           `json:"sales_invoice_id" db:"sales_invoice_id"`
+	InvoiceNumber   string            `json:"invoice_number" db:"invoice_number"`
+	FakturDate      time.Time         `json:"faktur_date" db:"faktur_date"`
+	CustomerID      *uuid.ID          `json:"customer_id,omitempty" db:"customer_id"`
+	BuyerTaxID      string            `json:"buyer_tax_id" db:"buyer_tax_id"`
+	BuyerName       string            `json:"buyer_name" db:"buyer_name"`
+	BuyerAddress    string            `json:"buyer_address" db:"buyer_address"`
+	TaxBase         float64           `json:"tax_base" db:"tax_base"`
+	VATAmount       float64           `json:"vat_amount" db:"vat_amount"`
+	LuxuryTaxAmount float64           `json:"luxury_tax_amount" db:"luxury_tax_amount"`
+	Status          string            `json:"status" db:"status"`
+	CancelReason    string            `json:"cancel_reason,omitempty" db:"cancel_reason"`
+	CancelledBy     string            `json:"cancelled_by,omitempty" db:"cancelled_by"`
+	CancelledAt     *time.Time        `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	ExportedAt      *time.Time        `json:"exported_at,omitempty" db:"exported_at"`
+	CreatedBy       string            `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at" db:"updated_at"`
+	Items           []*TaxInvoiceItem `json:"items,omitempty" db:"-"`
+}
+
+// ReplacementFlag returns the e-Faktur replacement flag (FG_PENGGANTI): 1 for a faktur pengganti.
+func (t *TaxInvoice) ReplacementFlag() int {
+	if t.Kind == TaxInvoiceReplacement {
+		return 1
+	}
+	return 0
+}
+
+// FormatFakturNumber returns the full faktur number: transaction code, replacement flag
+// and the NSFP, e.g. 010.000-24.00000001.
+func (t *TaxInvoice) FormatFakturNumber() string {
+	return fmt.Sprintf("%s%d.%s", t.TransactionCode, t.ReplacementFlag(), t.NSFP)
+}
+
+// NSFPDigits returns the 13 digits of the NSFP as e-Faktur files expect them.
+func (t *TaxInvoice) NSFPDigits() string {
+	return strings.NewReplacer("-", "", ".", "").Replace(t.NSFP)
+}
+
+// TaxInvoiceItem is a line (objek faktur) of a tax invoice.
+type TaxInvoiceItem struct {
+	ID           uuid.ID `json:"id" db:"id"`
+	TaxInvoiceID uuid.ID `json:"tax_invoice_id" db:"tax_invoice_id"`
+	LineNumber   int     `json:"line_number" db:"line_number"`
+	ItemCode     string  `json:"item_code" db:"item_code"`
+	ItemName     string  `json:"item_name" db:"item_name"`
+	UnitPrice    float64 `json:"unit_price" db:"unit_price"`
+	Quantity     float64 `json:"quantity" db:"quantity"`
+	TotalPrice   float64 `json:"total_price" db:"total_price"`
+	Discount     float64 `json:"discount" db:"discount"`
+	TaxBase      float64 `json:"tax_base" db:"tax_base"`
+	VATAmount    float64 `json:"vat_amount" db:"vat_amount"`
+}
+
+// TaxInvoiceSource is what a tax invoice is made from: a sales invoice with its buyer
+// and items as they currently are.
+type TaxInvoiceSource struct {
+	SalesInvoiceID uuid.ID
+	InvoiceNumber  string
+	InvoiceDate    time.Time
+	CustomerID     *uuid.ID
+	BuyerTaxID     string
+	BuyerName      string
+	BuyerAddress   string
+	TotalAmount    float64
+	TaxAmount      float64
+	Items          []*TaxInvoiceItem
+}
+
+// InputTaxInvoice is a faktur pajak masukan of a supplier, imported so creditable input
+// VAT can be offset against output VAT.
+type InputTaxInvoice struct {
+	ID               uuid.ID   `json:"id" db:"id"`
+	FakturNumber     string    `json:"faktur_number" db:"faktur_number"`
+	NSFP             string    `json:"nsfp" db:"nsfp"`
+	TransactionCode  string    `json:"transaction_code" db:"transaction_code"`
+	Replacement      bool      `json:"replacement" db:"replacement"`
+	FakturDate       time.Time `json:"faktur_date" db:"faktur_date"`
+	TaxPeriod        int       `json:"tax_period" db:"tax_period"`
+	TaxYear          int       `json:"tax_year" db:"tax_year"`
+	SupplierTaxID    string    `json:"supplier_tax_id" db:"supplier_tax_id"`
+	SupplierName     string    `json:"supplier_name" db:"supplier_name"`
+	SupplierAddress  string    `json:"supplier_address" db:"supplier_address"`
+	SupplierID       *uuid.ID  `json:"supplier_id,omitempty" db:"supplier_id"`
+	TaxBase          float64   `json:"tax_base" db:"tax_base"`
+	VATAmount        float64   `json:"vat_amount" db:"vat_amount"`
+	LuxuryTaxAmount  float64   `json:"luxury_tax_amount" db:"luxury_tax_amount"`
+	Creditable       bool      `json:"creditable" db:"creditable"`
+	Status           string    `json:"status" db:"status"`
+	TaxTransactionID *uuid.ID  `json:"tax_transaction_id,omitempty" db:"tax_transaction_id"`
+	FileName         string    `json:"file_name" db:"file_name"`
+	ImportedBy       string    `json:"imported_by" db:"imported_by"`
+	ImportedAt       time.Time `json:"imported_at" db:"imported_at"`
+}
+
+// InputTaxImportResult summarizes an import of supplier fakturs.
+type InputTaxImportResult struct {
+	Created        int                `json:"created"`
+	Replaced       int                `json:"replaced"`
+	Skipped        int                `json:"skipped"`
+	CreditableVAT  float64            `json:"creditable_vat"`
+	InputTaxes     []*InputTaxInvoice `json:"input_taxes"`
+	SkippedFakturs []string           `json:"skipped_fakturs,omitempty"`
+}
@@ -0,0 +1,237 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+var (
+	ErrTaxNotFound       = errors.New("tax not found")
+	ErrInvalidTaxRate    = errors.New("invalid tax rate version")
+	ErrTaxRateOverlap    = errors.New("tax rate version overlaps a version of the tax")
+	ErrNoTaxRate         = errors.New("no rate of the tax is in force on the date")
+	ErrTaxRuleNotFound   = errors.New("tax determination rule not found")
+	ErrInvalidTaxRule    = errors.New("invalid tax determination rule")
+	ErrNoTaxRule         = errors.New("no tax determination rule matches")
+	ErrInvalidTaxRequest = errors.New("invalid tax calculation request")
+)
+
+// Transaction types tax is determined for
+const (
+	TaxTransactionSale     = "SALE"
+	TaxTransactionPOS      = "POS"
+	TaxTransactionPurchase = "PURCHASE"
+)
+
+// Tax statuses of customers and suppliers
+const (
+	PartyTaxStatusPKP       = "PKP"       // registered for VAT (Pengusaha Kena Pajak)
+	PartyTaxStatusNonPKP    = "NON_PKP"   // not registered for VAT
+	PartyTaxStatusCollector = "COLLECTOR" // government VAT collector (wajib pungut)
+	PartyTaxStatusExempt    = "EXEMPT"    // exempt from VAT
+)
+
+// ValidPartyTaxStatus reports whether s is a tax status of a customer or supplier; empty
+// means not set.
+func ValidPartyTaxStatus(s string) bool {
+	switch s {
+	case "", PartyTaxStatusPKP, PartyTaxStatusNonPKP, PartyTaxStatusCollector, PartyTaxStatusExempt:
+		return true
+	}
+	return false
+}
+
+// TaxRateVersion is the rate of a tax over a date range. The tax is charged on a base of
+// numerator/denominator of the amount: from 2025 PPN is 12% of 11/12 of the price
+// (DPP nilai lain), which keeps the tax at 11% of the price for ordinary goods.
+type TaxRateVersion struct {
+	ID                 uuid.ID    `json:"id" db:"id"`
+	TaxID              uuid.ID    `json:"tax_id" db:"tax_id"`
+	Rate               float64    `json:"rate" db:"rate"` // Percentage rate
+	TaxBaseNumerator   int        `json:"tax_base_numerator" db:"tax_base_numerator"`
+	TaxBaseDenominator int        `json:"tax_base_denominator" db:"tax_base_denominator"`
+	ValidFrom          time.Time  `json:"valid_from" db:"valid_from"`
+	ValidTo            *time.Time `json:"valid_to" db:"valid_to"` // Open-ended when nil
+	Description        string     `json:"description" db:"description"`
+	CreatedBy          string     `json:"created_by" db:"created_by"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Validate checks the rate and tax base of the version, defaulting the base to the full
+// amount.
+func (v *TaxRateVersion) Validate() error {
+	if v.TaxBaseNumerator == 0 && v.TaxBaseDenominator == 0 {
+		v.TaxBaseNumerator, v.TaxBaseDenominator = 1, 1
+	}
+	switch {
+	case v.TaxID.IsNil():
+		return fmt.Errorf("%w: tax_id is required", ErrInvalidTaxRate)
+	case v.Rate < 0 || v.Rate > 100:
+		return fmt.Errorf("%w: rate must be between 0 and 100", ErrInvalidTaxRate)
+	case v.TaxBaseNumerator <= 0 || v.TaxBaseDenominator <= 0 || v.TaxBaseNumerator > v.TaxBaseDenominator:
+		return fmt.Errorf("%w: the tax base must be a fraction of at most the whole amount", ErrInvalidTaxRate)
+	case v.ValidFrom.IsZero():
+		return fmt.Errorf("%w: valid_from is required", ErrInvalidTaxRate)
+	case v.ValidTo != nil && v.ValidTo.Before(v.ValidFrom):
+		return fmt.Errorf("%w: valid_to is before valid_from", ErrInvalidTaxRate)
+	}
+	return nil
+}
+
+// Covers reports whether the version is in force on a date.
+func (v *TaxRateVersion) Covers(date time.Time) bool {
+	day := dateOnly(date)
+	if day.Before(dateOnly(v.ValidFrom)) {
+		return false
+	}
+	return v.ValidTo == nil || !day.After(dateOnly(*v.ValidTo))
+}
+
+// TaxBase returns the base the tax on an amount is charged on.
+func (v *TaxRateVersion) TaxBase(amount float64) float64 {
+	if v.TaxBaseDenominator == 0 {
+		return amount
+	}
+	return amount * float64(v.TaxBaseNumerator) / float64(v.TaxBaseDenominator)
+}
+
+// EffectiveRate returns the tax as a percentage of the whole amount.
+func (v *TaxRateVersion) EffectiveRate() float64 {
+	return math.Round(v.TaxBase(v.Rate)*10000) / 10000
+}
+
+// TaxDeterminationRule picks the tax of a transaction type for customers or suppliers of
+// a tax status buying or selling articles of a classification. An empty status or a nil
+// classification matches any; a nil tax means the transaction is not taxed.
+type TaxDeterminationRule struct {
+	ID               uuid.ID   `json:"id" db:"id"`
+	TransactionType  string    `json:"transaction_type" db:"transaction_type"`
+	PartyTaxStatus   string    `json:"party_tax_status" db:"party_tax_status"`
+	ClassificationID *uuid.ID  `json:"classification_id" db:"classification_id"`
+	TaxID            *uuid.ID  `json:"tax_id" db:"tax_id"`
+	TaxCode          string    `json:"tax_code" db:"tax_code"` // Code of the tax, read only
+	Priority         int       `json:"priority" db:"priority"`
+	Description      string    `json:"description" db:"description"`
+	IsActive         bool      `json:"is_active" db:"is_active"`
+	CreatedBy        string    `json:"created_by" db:"created_by"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Validate checks the transaction type and party tax status of the rule.
+func (r *TaxDeterminationRule) Validate() error {
+	switch r.TransactionType {
+	case TaxTransactionSale, TaxTransactionPOS, TaxTransactionPurchase:
+	default:
+		return fmt.Errorf("%w: transaction_type must be SALE, POS or PURCHASE", ErrInvalidTaxRule)
+	}
+	if !ValidPartyTaxStatus(r.PartyTaxStatus) {
+		return fmt.Errorf("%w: unknown party_tax_status %q", ErrInvalidTaxRule, r.PartyTaxStatus)
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a transaction with a party of a tax status
+// and an article of a classification.
+func (r *TaxDeterminationRule) Matches(transactionType, partyTaxStatus string, classificationID uuid.ID) bool {
+	if !r.IsActive || r.TransactionType != transactionType {
+		return false
+	}
+	if r.PartyTaxStatus != "" && r.PartyTaxStatus != partyTaxStatus {
+		return false
+	}
+	return r.ClassificationID == nil || *r.ClassificationID == classificationID
+}
+
+// specificity counts the conditions the rule sets, so a rule naming the status and the
+// classification wins over a catch-all of the same priority.
+func (r *TaxDeterminationRule) specificity() int {
+	n := 0
+	if r.PartyTaxStatus != "" {
+		n++
+	}
+	if r.ClassificationID != nil {
+		n++
+	}
+	return n
+}
+
+// SelectTaxRule returns the matching rule of the highest priority, the most specific of
+// those on a tie, or nil when none matches.
+func SelectTaxRule(rules []*TaxDeterminationRule, transactionType, partyTaxStatus string, classificationID uuid.ID) *TaxDeterminationRule {
+	var best *TaxDeterminationRule
+	for _, r := range rules {
+		if !r.Matches(transactionType, partyTaxStatus, classificationID) {
+			continue
+		}
+		if best == nil || r.Priority > best.Priority ||
+			(r.Priority == best.Priority && r.specificity() > best.specificity()) {
+			best = r
+		}
+	}
+	return best
+}
+
+// TaxCalculationRequest asks for the tax of the lines of a document. The party is the
+// customer of a sale or POS sale and the supplier of a purchase; line amounts exclude tax.
+type TaxCalculationRequest struct {
+	TransactionType string                    `json:"transaction_type"`
+	PartyID         uuid.ID                   `json:"party_id"` // Nil for walk-in customers
+	Date            time.Time                 `json:"date"`
+	Lines           []TaxCalculationLineInput `json:"lines"`
+}
+
+// TaxCalculationLineInput is a document line to tax.
+type TaxCalculationLineInput struct {
+	ArticleID uuid.ID `json:"article_id"`
+	Amount    float64 `json:"amount"`
+}
+
+// TaxCalculationLine is the tax of a document line. TaxBase is the amount (DPP) and
+// OtherTaxBase the part of it the rate is applied to (DPP nilai lain).
+type TaxCalculationLine struct {
+	ArticleID     uuid.ID  `json:"article_id"`
+	TaxID         *uuid.ID `json:"tax_id"`
+	TaxCode       string   `json:"tax_code"`
+	Rate          float64  `json:"rate"`
+	EffectiveRate float64  `json:"effective_rate"` // Tax as a percentage of the amount
+	TaxBase       float64  `json:"tax_base"`
+	OtherTaxBase  float64  `json:"other_tax_base"`
+	TaxAmount     float64  `json:"tax_amount"`
+}
+
+// TaxCalculation is the tax of a document, line by line.
+type TaxCalculation struct {
+	TransactionType string                `json:"transaction_type"`
+	PartyTaxStatus  string                `json:"party_tax_status"`
+	Date            time.Time             `json:"date"`
+	Lines           []*TaxCalculationLine `json:"lines"`
+	TaxBase         float64               `json:"tax_base"`
+	TaxAmount       float64               `json:"tax_amount"`
+}
+
+// Validate checks the transaction type, date and line amounts of the request.
+func (r *TaxCalculationRequest) Validate() error {
+	switch r.TransactionType {
+	case TaxTransactionSale, TaxTransactionPOS, TaxTransactionPurchase:
+	default:
+		return fmt.Errorf("%w: transaction_type must be SALE, POS or PURCHASE", ErrInvalidTaxRequest)
+	}
+	if r.Date.IsZero() {
+		return fmt.Errorf("%w: date is required", ErrInvalidTaxRequest)
+	}
+	for i, l := range r.Lines {
+		if l.Amount < 0 {
+			return fmt.Errorf("%w: line %d has a negative amount", ErrInvalidTaxRequest, i+1)
+		}
+	}
+	return nil
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
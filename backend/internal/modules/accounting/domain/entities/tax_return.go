@@ -0,0 +1,152 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrTaxReturnNotFound        = errors.New("tax return not found")
+	ErrUnsupportedTaxReturnType = errors.New("tax returns are computed for PPN and WITHHOLDING only")
+	ErrInvalidTaxPeriod         = errors.New("invalid tax period")
+	ErrTaxReturnFiled           = errors.New("tax return of the period is already filed")
+	ErrTaxReturnNotDraft        = errors.New("tax return is not a draft")
+	ErrTaxReturnNotPayable      = errors.New("tax return is not filed or has nothing due")
+	ErrTaxReturnOutdated        = errors.New("tax transactions of the period changed, regenerate the return")
+	ErrInvalidTaxPayment        = errors.New("payment must settle the total due of the return")
+	ErrTaxTransactionNotFound   = errors.New("tax transaction not found")
+	ErrTaxTransactionLocked     = errors.New("tax transaction is filed in a tax return")
+)
+
+// Late filing fines (denda) of a monthly return: Rp500.000 for the VAT return and
+// Rp100.000 for the other monthly returns.
+const (
+	LateFilingFineVAT   = 500000
+	LateFilingFineOther = 100000
+)
+
+// TaxPenaltyPolicy sets the interest charged on tax paid after the payment due date:
+// a rate per month or part of a month late, for at most MaxMonths months.
+type TaxPenaltyPolicy struct {
+	MonthlyInterestRate float64 // percent per month
+	MaxMonths           int
+}
+
+// DefaultTaxPenaltyPolicy charges 0.6% a month for at most 24 months. The Ministry of
+// Finance sets the monthly rate from the reference interest rate.
+var DefaultTaxPenaltyPolicy = TaxPenaltyPolicy{MonthlyInterestRate: 0.6, MaxMonths: 24}
+
+// ReturnTaxTypes returns the tax types whose transactions a monthly return of the given
+// type aggregates.
+func ReturnTaxTypes(taxType TaxType) ([]TaxType, error) {
+	switch taxType {
+	case TaxTypePPN:
+		return []TaxType{TaxTypePPN, TaxTypeVAT}, nil
+	case TaxTypeWithholding:
+		return []TaxType{TaxTypeWithholding, TaxTypePPh}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedTaxReturnType, taxType)
+}
+
+// ReturnTypeOf returns the type of the monthly return transactions of a tax type are
+// filed in.
+func ReturnTypeOf(taxType TaxType) (TaxType, bool) {
+	switch taxType {
+	case TaxTypePPN, TaxTypeVAT:
+		return TaxTypePPN, true
+	case TaxTypeWithholding, TaxTypePPh:
+		return TaxTypeWithholding, true
+	}
+	return "", false
+}
+
+// TaxPeriod returns the first and last day of a monthly tax period.
+func TaxPeriod(year, month int) (time.Time, time.Time, error) {
+	if year < 2000 || month < 1 || month > 12 {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %04d-%02d", ErrInvalidTaxPeriod, year, month)
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1), nil
+}
+
+// TaxPaymentDueDate returns when the tax of a monthly period must be paid: the 15th of
+// the following month.
+func TaxPaymentDueDate(periodStart time.Time) time.Time {
+	return time.Date(periodStart.Year(), periodStart.Month()+1, 15, 0, 0, 0, 0, time.UTC)
+}
+
+// TaxFilingDueDate returns when the monthly return must be filed: the end of the
+// following month for the VAT return, the 20th of the following month for the others.
+func TaxFilingDueDate(taxType TaxType, periodStart time.Time) time.Time {
+	if taxType == TaxTypePPN || taxType == TaxTypeVAT {
+		return time.Date(periodStart.Year(), periodStart.Month()+2, 0, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(periodStart.Year(), periodStart.Month()+1, 20, 0, 0, 0, 0, time.UTC)
+}
+
+// MonthsLate counts the months or parts of a month from a due date to a date, capped at
+// max; it is zero on or before the due date.
+func MonthsLate(due, at time.Time, max int) int {
+	due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+	at = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	if !at.After(due) {
+		return 0
+	}
+	months := (at.Year()-due.Year())*12 + int(at.Month()-due.Month())
+	if due.AddDate(0, months, 0).Before(at) {
+		months++
+	}
+	if months > max {
+		months = max
+	}
+	return months
+}
+
+// ApplyTotals sets the return totals from the tax of the period and the overpayment
+// brought forward: output tax less input tax and the overpayment is payable, a negative
+// balance is carried forward to the next month.
+func (tr *TaxReturn) ApplyTotals(totals *TaxReturnTotals, broughtForward float64) {
+	tr.TotalSales = totals.TotalSales
+	tr.TotalPurchases = totals.TotalPurchases
+	tr.OutputTax = totals.OutputTax
+	tr.InputTax = totals.InputTax
+	tr.TransactionCount = totals.TransactionCount
+	tr.OverpaymentBroughtForward = broughtForward
+	tr.CalculateNetTax()
+	tr.CalculateTotalDue()
+}
+
+// ApplyLateFiling charges the late filing fine when the return is filed after its due date.
+func (tr *TaxReturn) ApplyLateFiling(filedAt time.Time) {
+	tr.PenaltyAmount = 0
+	if MonthsLate(tr.DueDate, filedAt, 1) > 0 {
+		tr.PenaltyAmount = LateFilingFineOther
+		if tr.TaxType == TaxTypePPN || tr.TaxType == TaxTypeVAT {
+			tr.PenaltyAmount = LateFilingFineVAT
+		}
+	}
+	tr.CalculateTotalDue()
+}
+
+// ApplyLatePayment charges interest on the tax payable when it is paid after the payment
+// due date.
+func (tr *TaxReturn) ApplyLatePayment(paidAt time.Time, policy TaxPenaltyPolicy) {
+	due := tr.PaymentDueDate
+	if due == nil {
+		due = &tr.DueDate
+	}
+	months := MonthsLate(*due, paidAt, policy.MaxMonths)
+	tr.InterestAmount = math.Round(tr.TaxPayable * policy.MonthlyInterestRate / 100 * float64(months))
+	tr.CalculateTotalDue()
+}
+
+// TaxReturnTotals are the sums of the unfiled tax transactions of a return period.
+type TaxReturnTotals struct {
+	TotalSales       float64 `json:"total_sales" db:"total_sales"`
+	TotalPurchases   float64 `json:"total_purchases" db:"total_purchases"`
+	OutputTax        float64 `json:"output_tax" db:"output_tax"`
+	InputTax         float64 `json:"input_tax" db:"input_tax"`
+	TransactionCount int     `json:"transaction_count" db:"transaction_count"`
+}
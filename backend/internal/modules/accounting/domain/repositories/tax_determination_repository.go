@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxDeterminationRepository defines the data operations of tax rate versions and tax
+// determination rules, and the lookups determination needs. Get methods return nil when
+// the record does not exist.
+type TaxDeterminationRepository interface {
+	// CreateRateVersion stores a rate version of a tax (ErrTaxNotFound when there is no
+	// such tax). The open-ended version it follows is closed the day before it starts;
+	// one overlapping any other version is refused with ErrTaxRateOverlap.
+	CreateRateVersion(ctx context.Context, v *entities.TaxRateVersion) error
+	ListRateVersions(ctx context.Context, taxID uuid.ID) ([]*entities.TaxRateVersion, error)
+	// GetRateVersion returns the version of a tax in force on a date.
+	GetRateVersion(ctx context.Context, taxID uuid.ID, date time.Time) (*entities.TaxRateVersion, error)
+
+	CreateRule(ctx context.Context, rule *entities.TaxDeterminationRule) error
+	UpdateRule(ctx context.Context, rule *entities.TaxDeterminationRule) error
+	GetRule(ctx context.Context, id uuid.ID) (*entities.TaxDeterminationRule, error)
+	// ListRules returns the rules of a transaction type, of every type when empty.
+	ListRules(ctx context.Context, transactionType string, activeOnly bool) ([]*entities.TaxDeterminationRule, error)
+
+	// GetPartyTaxStatus returns the tax status of the customer of a sale or POS sale or
+	// the supplier of a purchase; empty when it is not set.
+	GetPartyTaxStatus(ctx context.Context, transactionType string, partyID uuid.ID) (string, error)
+	// GetArticleClassifications returns the classification of each article found.
+	GetArticleClassifications(ctx context.Context, articleIDs []uuid.ID) (map[uuid.ID]uuid.ID, error)
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxInvoiceFilter selects tax invoices by faktur date and status.
+type TaxInvoiceFilter struct {
+	From      time.Time
+	To        time.Time
+	Status    string
+	WithItems bool
+}
+
+// TaxInvoiceRepository defines the data operations of NSFP ranges, tax invoices and
+// imported input tax invoices. Get methods return nil when the record does not exist.
+type TaxInvoiceRepository interface {
+	// CreateNSFPRange registers a range; one overlapping a registered range of the same
+	// branch and year is refused with ErrNSFPRangeOverlap.
+	CreateNSFPRange(ctx context.Context, r *entities.NSFPRange) error
+	ListNSFPRanges(ctx context.Context, year int) ([]*entities.NSFPRange, error)
+
+	// GetTaxInvoiceSource returns a sales invoice with its buyer and items.
+	GetTaxInvoiceSource(ctx context.Context, salesInvoiceID uuid.ID) (*entities.TaxInvoiceSource, error)
+	// CreateTaxInvoice stores a normal tax invoice, numbering it with the next NSFP of
+	// the faktur year (ErrNSFPExhausted when none is left). An invoice that already has
+	// a tax invoice in force is refused with ErrTaxInvoiceExists.
+	CreateTaxInvoice(ctx context.Context, inv *entities.TaxInvoice) error
+	// ReplaceTaxInvoice stores a replacement and marks the invoice it replaces replaced;
+	// ErrTaxInvoiceNotActive when that one is no longer in force.
+	ReplaceTaxInvoice(ctx context.Context, original, replacement *entities.TaxInvoice) error
+	// CancelTaxInvoice cancels a tax invoice in force (ErrTaxInvoiceNotActive otherwise).
+	CancelTaxInvoice(ctx context.Context, inv *entities.TaxInvoice) error
+	GetTaxInvoice(ctx context.Context, id uuid.ID) (*entities.TaxInvoice, error)
+	ListTaxInvoices(ctx context.Context, filter TaxInvoiceFilter) ([]*entities.TaxInvoice, error)
+	MarkExported(ctx context.Context, ids []uuid.ID, at time.Time) error
+
+	// ImportInputTaxInvoices stores supplier fakturs and books the creditable VAT as
+	// PURCHASE tax transactions. A faktur already imported is skipped unless the new one
+	// replaces it.
+	ImportInputTaxInvoices(ctx context.Context, invoices []*entities.InputTaxInvoice) (*entities.InputTaxImportResult, error)
+	ListInputTaxInvoices(ctx context.Context, year, period int) ([]*entities.InputTaxInvoice, error)
+}
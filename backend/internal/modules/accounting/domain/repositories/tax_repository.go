@@ -64,6 +64,14 @@ type TaxRepository interface {
 	SubmitReturn(ctx context.Context, returnID uuid.ID, userID string) error
 	PayReturn(ctx context.Context, returnID uuid.ID, paymentAmount float64) error
 	GenerateReturn(ctx context.Context, companyID string, taxType entities.TaxType, periodStart, periodEnd time.Time) (*entities.TaxReturn, error)
+
+	// Tax return computation: returns are computed from the unfiled transactions of a
+	// period, filing locks them and settling records the payment
+	GetReturnByPeriod(ctx context.Context, taxType entities.TaxType, periodStart time.Time) (*entities.TaxReturn, error)
+	GetReturnTotals(ctx context.Context, taxTypes []entities.TaxType, periodStart, periodEnd time.Time) (*entities.TaxReturnTotals, error)
+	FileReturn(ctx context.Context, taxReturn *entities.TaxReturn, taxTypes []entities.TaxType) error
+	SettleReturn(ctx context.Context, taxReturn *entities.TaxReturn) error
+	SetReturnJournalEntry(ctx context.Context, returnID, journalEntryID uuid.ID) error
 	
 	// Reporting operations
 	GetTaxReport(ctx context.Context, companyID string, taxType entities.TaxType, startDate, endDate time.Time) (*entities.TaxReport, error)
@@ -5,10 +5,10 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/shared/uuid"
 
 )
 
@@ -22,7 +22,7 @@ func (m *MockChartOfAccountRepository) Create(ctx context.Context, coa *entities
 	return args.Error(0)
 }
 
-func (m *MockChartOfAccountRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.ChartOfAccount, error) {
+func (m *MockChartOfAccountRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.ChartOfAccount, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*entities.ChartOfAccount), args.Error(1)
 }
@@ -42,7 +42,7 @@ func (m *MockChartOfAccountRepository) Update(ctx context.Context, coa *entities
 	return args.Error(0)
 }
 
-func (m *MockChartOfAccountRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockChartOfAccountRepository) Delete(ctx context.Context, id uuid.ID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
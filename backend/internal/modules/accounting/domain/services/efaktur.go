@@ -0,0 +1,306 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/accounting/domain/entities"
+)
+
+// e-Faktur import CSV headers: one FK row per faktur, followed by its OF (objek faktur) rows.
+// LT rows carry the seller's own address and are left empty.
+var (
+	efakturFKHeader = []string{"FK", "KD_JENIS_TRANSAKSI", "FG_PENGGANTI", "NOMOR_FAKTUR", "MASA_PAJAK", "TAHUN_PAJAK",
+		"TANGGAL_FAKTUR", "NPWP", "NAMA", "ALAMAT_LENGKAP", "JUMLAH_DPP", "JUMLAH_PPN", "JUMLAH_PPNBM", "ID_KETERANGAN_TAMBAHAN",
+		"FG_UANG_MUKA", "UANG_MUKA_DPP", "UANG_MUKA_PPN", "UANG_MUKA_PPNBM", "REFERENSI", "KODE_DOKUMEN_PENDUKUNG"}
+	efakturLTHeader = []string{"LT", "NPWP", "NAMA", "JALAN", "BLOK", "NOMOR", "RT", "RW", "KECAMATAN", "KELURAHAN",
+		"KABUPATEN", "PROPINSI", "KODE_POS", "NOMOR_TELEPON"}
+	efakturOFHeader = []string{"OF", "KODE_OBJEK", "NAMA", "HARGA_SATUAN", "JUMLAH_BARANG", "HARGA_TOTAL", "DISKON", "DPP",
+		"PPN", "TARIF_PPNBM", "PPNBM"}
+)
+
+// efakturNoTaxID is the NPWP e-Faktur expects for buyers without one.
+const efakturNoTaxID = "000000000000000"
+
+// WriteEFakturCSV writes tax invoices in the e-Faktur import CSV layout.
+func WriteEFakturCSV(w io.Writer, invoices []*entities.TaxInvoice) error {
+	cw := csv.NewWriter(w)
+	for _, header := range [][]string{efakturFKHeader, efakturLTHeader, efakturOFHeader} {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, inv := range invoices {
+		buyerTaxID := inv.BuyerTaxID
+		if buyerTaxID == "" {
+			buyerTaxID = efakturNoTaxID
+		}
+		if err := cw.Write([]string{"FK", inv.TransactionCode, strconv.Itoa(inv.ReplacementFlag()), inv.NSFPDigits(),
+			strconv.Itoa(int(inv.FakturDate.Month())), strconv.Itoa(inv.FakturDate.Year()), inv.FakturDate.Format("02/01/2006"),
+			buyerTaxID, inv.BuyerName, inv.BuyerAddress, rupiah(inv.TaxBase), rupiah(inv.VATAmount), rupiah(inv.LuxuryTaxAmount),
+			"", "0", "0", "0", "0", inv.InvoiceNumber, ""}); err != nil {
+			return err
+		}
+		for _, item := range inv.Items {
+			if err := cw.Write([]string{"OF", item.ItemCode, item.ItemName, decimal(item.UnitPrice), decimal(item.Quantity),
+				decimal(item.TotalPrice), decimal(item.Discount), decimal(item.TaxBase), decimal(item.VATAmount), "0", "0"}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Coretax import XML: the bulk file of the seller with a TaxInvoice per faktur.
+type coretaxBulk struct {
+	XMLName          xml.Name          `xml:"TaxInvoiceBulk"`
+	XSI              string            `xml:"xmlns:xsi,attr"`
+	Schema           string            `xml:"xsi:noNamespaceSchemaLocation,attr"`
+	TIN              string            `xml:"TIN"`
+	ListOfTaxInvoice []*coretaxInvoice `xml:"ListOfTaxInvoice>TaxInvoice"`
+}
+
+type coretaxInvoice struct {
+	TaxInvoiceDate      string                `xml:"TaxInvoiceDate"`
+	TaxInvoiceOpt       string                `xml:"TaxInvoiceOpt"`
+	TrxCode             string                `xml:"TrxCode"`
+	AddInfo             string                `xml:"AddInfo"`
+	CustomDoc           string                `xml:"CustomDoc"`
+	RefDesc             string                `xml:"RefDesc"`
+	FacilityStamp       string                `xml:"FacilityStamp"`
+	SellerIDTKU         string                `xml:"SellerIDTKU"`
+	BuyerTin            string                `xml:"BuyerTin"`
+	BuyerDocument       string                `xml:"BuyerDocument"`
+	BuyerCountry        string                `xml:"BuyerCountry"`
+	BuyerDocumentNumber string                `xml:"BuyerDocumentNumber"`
+	BuyerName           string                `xml:"BuyerName"`
+	BuyerAdress         string                `xml:"BuyerAdress"`
+	BuyerEmail          string                `xml:"BuyerEmail"`
+	BuyerIDTKU          string                `xml:"BuyerIDTKU"`
+	GoodServices        []*coretaxGoodService `xml:"ListOfGoodService>GoodService"`
+}
+
+type coretaxGoodService struct {
+	Opt           string `xml:"Opt"`
+	Code          string `xml:"Code"`
+	Name          string `xml:"Name"`
+	Unit          string `xml:"Unit"`
+	Price         string `xml:"Price"`
+	Qty           string `xml:"Qty"`
+	TotalDiscount string `xml:"TotalDiscount"`
+	TaxBase       string `xml:"TaxBase"`
+	OtherTaxBase  string `xml:"OtherTaxBase"`
+	VATRate       string `xml:"VATRate"`
+	VAT           string `xml:"VAT"`
+	STLGRate      string `xml:"STLGRate"`
+	STLG          string `xml:"STLG"`
+}
+
+const (
+	// coretaxGoodsCode is the generic goods code (kode barang) of Coretax
+	coretaxGoodsCode = "000000"
+	// coretaxUnitPiece is the Coretax unit of measure for pieces
+	coretaxUnitPiece = "UM.0018"
+)
+
+// WriteCoretaxXML writes tax invoices in the Coretax import XML layout, made out for the
+// seller NPWP. From 2025 PPN is 12% of a tax base of 11/12 of the price (DPP nilai lain),
+// which keeps the VAT of non-luxury goods at 11% of the price.
+func WriteCoretaxXML(w io.Writer, sellerTaxID string, invoices []*entities.TaxInvoice) error {
+	seller := coretaxTIN(sellerTaxID)
+	bulk := &coretaxBulk{
+		XSI:    "http://www.w3.org/2001/XMLSchema-instance",
+		Schema: "TaxInvoice.xsd",
+		TIN:    seller,
+	}
+	for _, inv := range invoices {
+		buyer := &coretaxInvoice{
+			TaxInvoiceDate: inv.FakturDate.Format("2006-01-02"),
+			TaxInvoiceOpt:  "Normal",
+			TrxCode:        inv.TransactionCode,
+			RefDesc:        inv.InvoiceNumber,
+			SellerIDTKU:    seller + "000000",
+			BuyerTin:       coretaxTIN(inv.BuyerTaxID),
+			BuyerDocument:  "TIN",
+			BuyerCountry:   "IDN",
+			BuyerName:      inv.BuyerName,
+			BuyerAdress:    inv.BuyerAddress,
+		}
+		if inv.BuyerTaxID == "" {
+			buyer.BuyerDocument = "Other ID"
+			buyer.BuyerDocumentNumber = inv.InvoiceNumber
+		}
+		buyer.BuyerIDTKU = buyer.BuyerTin + "000000"
+
+		vatRate, otherTaxBase := 11.0, 1.0
+		if inv.FakturDate.Year() >= 2025 {
+			vatRate, otherTaxBase = 12, 11.0/12
+		}
+		for _, item := range inv.Items {
+			buyer.GoodServices = append(buyer.GoodServices, &coretaxGoodService{
+				Opt:           "A",
+				Code:          coretaxGoodsCode,
+				Name:          item.ItemName,
+				Unit:          coretaxUnitPiece,
+				Price:         decimal(item.UnitPrice),
+				Qty:           decimal(item.Quantity),
+				TotalDiscount: decimal(item.Discount),
+				TaxBase:       decimal(item.TaxBase),
+				OtherTaxBase:  decimal(roundMoney(item.TaxBase * otherTaxBase)),
+				VATRate:       decimal(vatRate),
+				VAT:           decimal(item.VATAmount),
+				STLGRate:      "0",
+				STLG:          "0",
+			})
+		}
+		bulk.ListOfTaxInvoice = append(bulk.ListOfTaxInvoice, buyer)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(bulk); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// coretaxTIN returns an NPWP as the 16 digits Coretax uses; 15-digit NPWPs are
+// prefixed with 0 and a missing one is all zeros.
+func coretaxTIN(taxID string) string {
+	switch len(taxID) {
+	case 0:
+		return strings.Repeat("0", 16)
+	case 15:
+		return "0" + taxID
+	}
+	return taxID
+}
+
+// ParseInputTaxCSV reads the supplier fakturs (FM rows) of an e-Faktur input tax CSV:
+// FM, KD_JENIS_TRANSAKSI, FG_PENGGANTI, NOMOR_FAKTUR, MASA_PAJAK, TAHUN_PAJAK, TANGGAL_FAKTUR,
+// NPWP, NAMA, ALAMAT_LENGKAP, JUMLAH_DPP, JUMLAH_PPN, JUMLAH_PPNBM, IS_CREDITABLE.
+// Files saved with semicolons, as spreadsheets of some locales do, are read as well.
+func ParseInputTaxCSV(r io.Reader) ([]*entities.InputTaxInvoice, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(br.Size())
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if i := bytes.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	cr := csv.NewReader(br)
+	if bytes.Count(first, []byte{';'}) > bytes.Count(first, []byte{','}) {
+		cr.Comma = ';'
+	}
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+
+	var invoices []*entities.InputTaxInvoice
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", entities.ErrInvalidInputTaxInvoice, err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		record[0] = strings.TrimPrefix(record[0], "\ufeff")
+		if !strings.EqualFold(strings.TrimSpace(record[0]), "FM") {
+			continue
+		}
+		if len(record) > 1 && strings.EqualFold(strings.TrimSpace(record[1]), "KD_JENIS_TRANSAKSI") {
+			continue // header
+		}
+		inv, err := parseInputTaxRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", entities.ErrInvalidInputTaxInvoice, line, err)
+		}
+		invoices = append(invoices, inv)
+	}
+	if len(invoices) == 0 {
+		return nil, fmt.Errorf("%w: the file has no FM rows", entities.ErrInvalidInputTaxInvoice)
+	}
+	return invoices, nil
+}
+
+func parseInputTaxRecord(record []string) (*entities.InputTaxInvoice, error) {
+	if len(record) < 13 {
+		return nil, fmt.Errorf("expected at least 13 columns, got %d", len(record))
+	}
+	for i := range record {
+		record[i] = strings.TrimSpace(record[i])
+	}
+
+	inv := &entities.InputTaxInvoice{
+		TransactionCode: record[1],
+		Replacement:     record[2] == "1",
+		SupplierTaxID:   taxIDDigits(record[7]),
+		SupplierName:    record[8],
+		SupplierAddress: record[9],
+		Creditable:      len(record) < 14 || record[13] != "0",
+	}
+	if len(inv.TransactionCode) == 1 {
+		inv.TransactionCode = "0" + inv.TransactionCode // spreadsheets drop the leading zero
+	}
+	if !entities.TaxInvoiceTransactionCodes[inv.TransactionCode] {
+		return nil, fmt.Errorf("unknown transaction code %q", record[1])
+	}
+	branch, year, serial, err := entities.ParseNSFP(record[3])
+	if err != nil {
+		return nil, err
+	}
+	inv.NSFP = entities.FormatNSFP(branch, year, serial)
+	flag := 0
+	if inv.Replacement {
+		flag = 1
+	}
+	inv.FakturNumber = fmt.Sprintf("%s%d.%s", inv.TransactionCode, flag, inv.NSFP)
+
+	if inv.TaxPeriod, err = strconv.Atoi(record[4]); err != nil || inv.TaxPeriod < 1 || inv.TaxPeriod > 12 {
+		return nil, fmt.Errorf("invalid tax period %q", record[4])
+	}
+	if inv.TaxYear, err = strconv.Atoi(record[5]); err != nil || inv.TaxYear < 2000 {
+		return nil, fmt.Errorf("invalid tax year %q", record[5])
+	}
+	if inv.FakturDate, err = time.ParseInLocation("02/01/2006", record[6], time.Local); err != nil {
+		return nil, fmt.Errorf("invalid faktur date %q, expected DD/MM/YYYY", record[6])
+	}
+	if len(inv.SupplierTaxID) != 15 && len(inv.SupplierTaxID) != 16 {
+		return nil, fmt.Errorf("supplier NPWP %q must have 15 or 16 digits", record[7])
+	}
+	amounts := []*float64{&inv.TaxBase, &inv.VATAmount, &inv.LuxuryTaxAmount}
+	for i, amount := range amounts {
+		value, err := strconv.ParseFloat(record[10+i], 64)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid amount %q", record[10+i])
+		}
+		*amount = roundMoney(value)
+	}
+	return inv, nil
+}
+
+// rupiah formats a faktur total in whole rupiah; PPN is rounded down.
+func rupiah(v float64) string {
+	return strconv.FormatFloat(math.Floor(v), 'f', 0, 64)
+}
+
+func decimal(v float64) string {
+	return strconv.FormatFloat(roundMoney(v), 'f', -1, 64)
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/accounting/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxDeterminationService keeps the rate history of taxes and determines the tax of sales,
+// POS sales and purchases: the rules pick the tax from the tax status of the customer or
+// supplier and the classification of each article, and the rate version in force on the
+// document date gives the rate and tax base.
+type TaxDeterminationService struct {
+	repo repositories.TaxDeterminationRepository
+}
+
+// NewTaxDeterminationService creates a new TaxDeterminationService.
+func NewTaxDeterminationService(repo repositories.TaxDeterminationRepository) *TaxDeterminationService {
+	return &TaxDeterminationService{repo: repo}
+}
+
+// --- Rate versions ---
+
+// AddRateVersion adds a rate of a tax from a date, ending the open-ended rate before it.
+func (s *TaxDeterminationService) AddRateVersion(ctx context.Context, v *entities.TaxRateVersion, userID string) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	if v.ID.IsNil() {
+		v.ID = uuid.New()
+	}
+	v.CreatedBy = userID
+	v.CreatedAt = utils.Now()
+	return s.repo.CreateRateVersion(ctx, v)
+}
+
+// ListRateVersions returns the rate history of a tax, the latest first.
+func (s *TaxDeterminationService) ListRateVersions(ctx context.Context, taxID uuid.ID) ([]*entities.TaxRateVersion, error) {
+	return s.repo.ListRateVersions(ctx, taxID)
+}
+
+// --- Determination rules ---
+
+// CreateRule adds a tax determination rule.
+func (s *TaxDeterminationService) CreateRule(ctx context.Context, rule *entities.TaxDeterminationRule, userID string) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	now := utils.Now()
+	rule.ID = uuid.New()
+	rule.CreatedBy = userID
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return err
+	}
+	return s.reloadRule(ctx, rule)
+}
+
+// UpdateRule changes the conditions, tax, priority or activation of a rule.
+func (s *TaxDeterminationService) UpdateRule(ctx context.Context, rule *entities.TaxDeterminationRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	existing, err := s.repo.GetRule(ctx, rule.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return entities.ErrTaxRuleNotFound
+	}
+	rule.CreatedBy = existing.CreatedBy
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = utils.Now()
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return err
+	}
+	return s.reloadRule(ctx, rule)
+}
+
+// GetRule returns a tax determination rule.
+func (s *TaxDeterminationService) GetRule(ctx context.Context, id uuid.ID) (*entities.TaxDeterminationRule, error) {
+	rule, err := s.repo.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, entities.ErrTaxRuleNotFound
+	}
+	return rule, nil
+}
+
+// ListRules returns the rules of a transaction type, of every type when empty.
+func (s *TaxDeterminationService) ListRules(ctx context.Context, transactionType string) ([]*entities.TaxDeterminationRule, error) {
+	return s.repo.ListRules(ctx, transactionType, false)
+}
+
+// reloadRule refreshes the tax code of a stored rule.
+func (s *TaxDeterminationService) reloadRule(ctx context.Context, rule *entities.TaxDeterminationRule) error {
+	stored, err := s.repo.GetRule(ctx, rule.ID)
+	if err != nil || stored == nil {
+		return err
+	}
+	*rule = *stored
+	return nil
+}
+
+// --- Calculation ---
+
+// CalculateTax determines the tax of each line of a document and computes it at the rate
+// in force on the document date. A line is taxed on its tax base (DPP nilai lain when the
+// rate version sets one); a rule without a tax leaves the line untaxed.
+func (s *TaxDeterminationService) CalculateTax(ctx context.Context, req *entities.TaxCalculationRequest) (*entities.TaxCalculation, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	partyTaxStatus := ""
+	if !req.PartyID.IsNil() {
+		status, err := s.repo.GetPartyTaxStatus(ctx, req.TransactionType, req.PartyID)
+		if err != nil {
+			return nil, err
+		}
+		partyTaxStatus = status
+	}
+
+	rules, err := s.repo.ListRules(ctx, req.TransactionType, true)
+	if err != nil {
+		return nil, err
+	}
+	classifications, err := s.lineClassifications(ctx, rules, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	calc := &entities.TaxCalculation{
+		TransactionType: req.TransactionType,
+		PartyTaxStatus:  partyTaxStatus,
+		Date:            req.Date,
+		Lines:           make([]*entities.TaxCalculationLine, 0, len(req.Lines)),
+	}
+	versions := make(map[uuid.ID]*entities.TaxRateVersion)
+	for i, in := range req.Lines {
+		rule := entities.SelectTaxRule(rules, req.TransactionType, partyTaxStatus, classifications[in.ArticleID])
+		if rule == nil {
+			return nil, fmt.Errorf("%w: %s of line %d for tax status %q", entities.ErrNoTaxRule, req.TransactionType, i+1, partyTaxStatus)
+		}
+
+		line := &entities.TaxCalculationLine{ArticleID: in.ArticleID, TaxBase: roundMoney(in.Amount)}
+		if rule.TaxID != nil {
+			version, ok := versions[*rule.TaxID]
+			if !ok {
+				if version, err = s.repo.GetRateVersion(ctx, *rule.TaxID, req.Date); err != nil {
+					return nil, err
+				}
+				if version == nil {
+					return nil, fmt.Errorf("%w: %s on %s", entities.ErrNoTaxRate, rule.TaxCode, req.Date.Format("2006-01-02"))
+				}
+				versions[*rule.TaxID] = version
+			}
+			line.TaxID = rule.TaxID
+			line.TaxCode = rule.TaxCode
+			line.Rate = version.Rate
+			line.EffectiveRate = version.EffectiveRate()
+			line.OtherTaxBase = roundMoney(version.TaxBase(in.Amount))
+			line.TaxAmount = roundMoney(version.TaxBase(in.Amount) * version.Rate / 100)
+		}
+		calc.Lines = append(calc.Lines, line)
+		calc.TaxBase += line.TaxBase
+		calc.TaxAmount += line.TaxAmount
+	}
+	calc.TaxBase = roundMoney(calc.TaxBase)
+	calc.TaxAmount = roundMoney(calc.TaxAmount)
+	return calc, nil
+}
+
+// lineClassifications looks up the article classifications of the lines, only when a
+// rule depends on them.
+func (s *TaxDeterminationService) lineClassifications(ctx context.Context, rules []*entities.TaxDeterminationRule,
+	lines []entities.TaxCalculationLineInput) (map[uuid.ID]uuid.ID, error) {
+	byClassification := false
+	for _, r := range rules {
+		if r.ClassificationID != nil {
+			byClassification = true
+			break
+		}
+	}
+	if !byClassification {
+		return map[uuid.ID]uuid.ID{}, nil
+	}
+	seen := make(map[uuid.ID]bool, len(lines))
+	ids := make([]uuid.ID, 0, len(lines))
+	for _, l := range lines {
+		if !l.ArticleID.IsNil() && !seen[l.ArticleID] {
+			seen[l.ArticleID] = true
+			ids = append(ids, l.ArticleID)
+		}
+	}
+	return s.repo.GetArticleClassifications(ctx, ids)
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockTaxDeterminationRepository is a mock implementation of repositories.TaxDeterminationRepository.
+type MockTaxDeterminationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxDeterminationRepository) CreateRateVersion(ctx context.Context, v *entities.TaxRateVersion) error {
+	args := m.Called(ctx, v)
+	return args.Error(0)
+}
+
+func (m *MockTaxDeterminationRepository) ListRateVersions(ctx context.Context, taxID uuid.ID) ([]*entities.TaxRateVersion, error) {
+	args := m.Called(ctx, taxID)
+	return args.Get(0).([]*entities.TaxRateVersion), args.Error(1)
+}
+
+func (m *MockTaxDeterminationRepository) GetRateVersion(ctx context.Context, taxID uuid.ID, date time.Time) (*entities.TaxRateVersion, error) {
+	args := m.Called(ctx, taxID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxRateVersion), args.Error(1)
+}
+
+func (m *MockTaxDeterminationRepository) CreateRule(ctx context.Context, rule *entities.TaxDeterminationRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockTaxDeterminationRepository) UpdateRule(ctx context.Context, rule *entities.TaxDeterminationRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockTaxDeterminationRepository) GetRule(ctx context.Context, id uuid.ID) (*entities.TaxDeterminationRule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxDeterminationRule), args.Error(1)
+}
+
+func (m *MockTaxDeterminationRepository) ListRules(ctx context.Context, transactionType string, activeOnly bool) ([]*entities.TaxDeterminationRule, error) {
+	args := m.Called(ctx, transactionType, activeOnly)
+	return args.Get(0).([]*entities.TaxDeterminationRule), args.Error(1)
+}
+
+func (m *MockTaxDeterminationRepository) GetPartyTaxStatus(ctx context.Context, transactionType string, partyID uuid.ID) (string, error) {
+	args := m.Called(ctx, transactionType, partyID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaxDeterminationRepository) GetArticleClassifications(ctx context.Context, articleIDs []uuid.ID) (map[uuid.ID]uuid.ID, error) {
+	args := m.Called(ctx, articleIDs)
+	return args.Get(0).(map[uuid.ID]uuid.ID), args.Error(1)
+}
+
+// testPPNRates returns output VAT at 11% until 2024 and 12% on 11/12 of the price from
+// 2025.
+func testPPNRates(ppn uuid.ID) (*entities.TaxRateVersion, *entities.TaxRateVersion) {
+	end2024 := day(2024, 12, 31)
+	return &entities.TaxRateVersion{TaxID: ppn, Rate: 11, TaxBaseNumerator: 1, TaxBaseDenominator: 1, ValidFrom: day(2022, 4, 1), ValidTo: &end2024},
+		&entities.TaxRateVersion{TaxID: ppn, Rate: 12, TaxBaseNumerator: 11, TaxBaseDenominator: 12, ValidFrom: day(2025, 1, 1)}
+}
+
+// testPPNSaleRules charges PPN on every sale but those to exempt customers.
+func testPPNSaleRules(ppn uuid.ID) []*entities.TaxDeterminationRule {
+	return []*entities.TaxDeterminationRule{
+		{TransactionType: entities.TaxTransactionSale, TaxID: &ppn, TaxCode: "PPN-OUT", IsActive: true},
+		{TransactionType: entities.TaxTransactionSale, PartyTaxStatus: entities.PartyTaxStatusExempt, Priority: 10, IsActive: true},
+	}
+}
+
+func TestCalculateTax_UsesTheRateInForceOnTheDocumentDate(t *testing.T) {
+	repo := new(MockTaxDeterminationRepository)
+	svc := NewTaxDeterminationService(repo)
+	ctx := context.Background()
+	ppn := uuid.New()
+	rate2024, rate2025 := testPPNRates(ppn)
+	lines := []entities.TaxCalculationLineInput{{ArticleID: uuid.New(), Amount: 1000000}}
+	repo.On("ListRules", ctx, entities.TaxTransactionSale, true).Return(testPPNSaleRules(ppn), nil).Twice()
+
+	repo.On("GetRateVersion", ctx, ppn, day(2024, 12, 31)).Return(rate2024, nil).Once()
+	calc, err := svc.CalculateTax(ctx, &entities.TaxCalculationRequest{
+		TransactionType: entities.TaxTransactionSale, Date: day(2024, 12, 31), Lines: lines,
+	})
+	require.NoError(t, err)
+	require.Len(t, calc.Lines, 1)
+	assert.Equal(t, ppn, *calc.Lines[0].TaxID)
+	assert.Equal(t, 11.0, calc.Lines[0].Rate)
+	assert.Equal(t, 1000000.0, calc.Lines[0].OtherTaxBase)
+	assert.Equal(t, 110000.0, calc.TaxAmount)
+
+	// From 2025: 12% of a tax base of 11/12 of the price
+	repo.On("GetRateVersion", ctx, ppn, day(2025, 1, 1)).Return(rate2025, nil).Once()
+	calc, err = svc.CalculateTax(ctx, &entities.TaxCalculationRequest{
+		TransactionType: entities.TaxTransactionSale, Date: day(2025, 1, 1), Lines: lines,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "PPN-OUT", calc.Lines[0].TaxCode)
+	assert.Equal(t, 12.0, calc.Lines[0].Rate)
+	assert.Equal(t, 11.0, calc.Lines[0].EffectiveRate)
+	assert.Equal(t, 1000000.0, calc.Lines[0].TaxBase)
+	assert.Equal(t, 916666.67, calc.Lines[0].OtherTaxBase)
+	assert.Equal(t, 110000.0, calc.TaxAmount)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "GetArticleClassifications", mock.Anything, mock.Anything)
+}
+
+func TestCalculateTax_PicksTheRuleOfThePartyTaxStatus(t *testing.T) {
+	repo := new(MockTaxDeterminationRepository)
+	svc := NewTaxDeterminationService(repo)
+	ctx := context.Background()
+	ppn := uuid.New()
+	_, rate2025 := testPPNRates(ppn)
+	exempt, registered := uuid.New(), uuid.New()
+	lines := []entities.TaxCalculationLineInput{{Amount: 500000}}
+	repo.On("ListRules", ctx, entities.TaxTransactionSale, true).Return(testPPNSaleRules(ppn), nil).Twice()
+
+	repo.On("GetPartyTaxStatus", ctx, entities.TaxTransactionSale, exempt).Return(entities.PartyTaxStatusExempt, nil).Once()
+	calc, err := svc.CalculateTax(ctx, &entities.TaxCalculationRequest{
+		TransactionType: entities.TaxTransactionSale, PartyID: exempt, Date: day(2025, 3, 10), Lines: lines,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entities.PartyTaxStatusExempt, calc.PartyTaxStatus)
+	assert.Nil(t, calc.Lines[0].TaxID)
+	assert.Zero(t, calc.TaxAmount)
+	assert.Equal(t, 500000.0, calc.TaxBase)
+	repo.AssertNotCalled(t, "GetRateVersion", mock.Anything, mock.Anything, mock.Anything)
+
+	repo.On("GetPartyTaxStatus", ctx, entities.TaxTransactionSale, registered).Return(entities.PartyTaxStatusPKP, nil).Once()
+	repo.On("GetRateVersion", ctx, ppn, day(2025, 3, 10)).Return(rate2025, nil).Once()
+	calc, err = svc.CalculateTax(ctx, &entities.TaxCalculationRequest{
+		TransactionType: entities.TaxTransactionSale, PartyID: registered, Date: day(2025, 3, 10), Lines: lines,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 55000.0, calc.TaxAmount)
+	repo.AssertExpectations(t)
+}
+
+func TestCalculateTax_ClassificationRuleWinsOnATie(t *testing.T) {
+	repo := new(MockTaxDeterminationRepository)
+	svc := NewTaxDeterminationService(repo)
+	ctx := context.Background()
+	ppn, basicGoods := uuid.New(), uuid.New()
+	_, rate2025 := testPPNRates(ppn)
+	rice, shirt := uuid.New(), uuid.New()
+	rules := append(testPPNSaleRules(ppn), &entities.TaxDeterminationRule{
+		TransactionType: entities.TaxTransactionSale, ClassificationID: &basicGoods, IsActive: true,
+	})
+
+	repo.On("ListRules", ctx, entities.TaxTransactionSale, true).Return(rules, nil).Once()
+	repo.On("GetRateVersion", ctx, ppn, day(2025, 3, 10)).Return(rate2025, nil).Once()
+	repo.On("GetArticleClassifications", ctx, []uuid.ID{rice, shirt}).Return(map[uuid.ID]uuid.ID{rice: basicGoods}, nil).Once()
+	calc, err := svc.CalculateTax(ctx, &entities.TaxCalculationRequest{
+		TransactionType: entities.TaxTransactionSale, Date: day(2025, 3, 10),
+		Lines: []entities.TaxCalculationLineInput{{ArticleID: rice, Amount: 200000}, {ArticleID: shirt, Amount: 300000}},
+	})
+	require.NoError(t, err)
+	assert.Zero(t, calc.Lines[0].TaxAmount)
+	assert.Equal(t, 33000.0, calc.Lines[1].TaxAmount)
+	assert.Equal(t, 33000.0, calc.TaxAmount)
+	assert.Equal(t, 500000.0, calc.TaxBase)
+	repo.AssertExpectations(t)
+}
+
+func TestCalculateTax_FailsWithoutARuleOrRate(t *testing.T) {
+	repo := new(MockTaxDeterminationRepository)
+	svc := NewTaxDeterminationService(repo)
+	ctx := context.Background()
+	ppn := uuid.New()
+
+	repo.On("ListRules", ctx, entities.TaxTransactionPurchase, true).Return([]*entities.TaxDeterminationRule{}, nil).Once()
+	_, err := svc.CalculateTax(ctx, &entities.TaxCalculationRequest{
+		TransactionType: entities.TaxTransactionPurchase, Date: day(2025, 3, 10),
+		Lines: []entities.TaxCalculationLineInput{{Amount: 100}},
+	})
+	assert.ErrorIs(t, err, entities.ErrNoTaxRule)
+
+	repo.On("ListRules", ctx, entities.TaxTransactionSale, true).Return(testPPNSaleRules(ppn), nil).Once()
+	repo.On("GetRateVersion", ctx, ppn, day(2021, 1, 5)).Return(nil, nil).Once()
+	_, err = svc.CalculateTax(ctx, &entities.TaxCalculationRequest{
+		TransactionType: entities.TaxTransactionSale, Date: day(2021, 1, 5),
+		Lines: []entities.TaxCalculationLineInput{{Amount: 100}},
+	})
+	assert.ErrorIs(t, err, entities.ErrNoTaxRate)
+
+	_, err = svc.CalculateTax(ctx, &entities.TaxCalculationRequest{TransactionType: "RENT", Date: day(2025, 3, 10)})
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxRequest)
+	repo.AssertExpectations(t)
+}
+
+func TestAddRateVersion_ValidatesTheTaxBase(t *testing.T) {
+	repo := new(MockTaxDeterminationRepository)
+	svc := NewTaxDeterminationService(repo)
+
+	err := svc.AddRateVersion(context.Background(), &entities.TaxRateVersion{
+		TaxID: uuid.New(), Rate: 12, TaxBaseNumerator: 13, TaxBaseDenominator: 12, ValidFrom: day(2026, 1, 1),
+	}, "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxRate)
+	repo.AssertNotCalled(t, "CreateRateVersion", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/accounting/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxInvoiceService provides business logic for e-Faktur: NSFP ranges, numbering of
+// tax invoices for sales invoices with PPN, replacements and cancellations, exports in
+// the e-Faktur and Coretax import layouts and the import of supplier input tax invoices.
+type TaxInvoiceService struct {
+	repo        repositories.TaxInvoiceRepository
+	sellerTaxID string
+}
+
+// NewTaxInvoiceService creates a new TaxInvoiceService; sellerTaxID is the NPWP of the
+// company, which Coretax files are made out for.
+func NewTaxInvoiceService(repo repositories.TaxInvoiceRepository, sellerTaxID string) *TaxInvoiceService {
+	return &TaxInvoiceService{repo: repo, sellerTaxID: taxIDDigits(sellerTaxID)}
+}
+
+// --- NSFP ranges ---
+
+// RegisterNSFPRange registers the NSFP range from start to end (both as BBB-YY.SSSSSSSS)
+// the tax office allocated.
+func (s *TaxInvoiceService) RegisterNSFPRange(ctx context.Context, start, end, letterNumber, userID string) (*entities.NSFPRange, error) {
+	branch, year, startSerial, err := entities.ParseNSFP(start)
+	if err != nil {
+		return nil, err
+	}
+	endBranch, endYear, endSerial, err := entities.ParseNSFP(end)
+	if err != nil {
+		return nil, err
+	}
+	if branch != endBranch || year != endYear {
+		return nil, fmt.Errorf("%w: start and end must be of the same branch code and year", entities.ErrInvalidNSFPRange)
+	}
+	if startSerial > endSerial {
+		return nil, fmt.Errorf("%w: start is after end", entities.ErrInvalidNSFPRange)
+	}
+
+	rng := &entities.NSFPRange{
+		ID:           uuid.New(),
+		BranchCode:   branch,
+		TaxYear:      2000 + year,
+		StartSerial:  startSerial,
+		EndSerial:    endSerial,
+		NextSerial:   startSerial,
+		LetterNumber: strings.TrimSpace(letterNumber),
+		Status:       entities.NSFPRangeActive,
+		CreatedBy:    userID,
+		CreatedAt:    utils.Now(),
+	}
+	if err := s.repo.CreateNSFPRange(ctx, rng); err != nil {
+		return nil, err
+	}
+	return rng, nil
+}
+
+// ListNSFPRanges returns the NSFP ranges of a year, of every year when zero.
+func (s *TaxInvoiceService) ListNSFPRanges(ctx context.Context, year int) ([]*entities.NSFPRange, error) {
+	return s.repo.ListNSFPRanges(ctx, year)
+}
+
+// --- Output tax invoices ---
+
+// IssueTaxInvoice issues the tax invoice of a sales invoice with PPN, numbered with the
+// next NSFP of the faktur year. The faktur date defaults to the invoice date.
+func (s *TaxInvoiceService) IssueTaxInvoice(ctx context.Context, salesInvoiceID uuid.ID, transactionCode string,
+	fakturDate time.Time, userID string) (*entities.TaxInvoice, error) {
+	if transactionCode == "" {
+		transactionCode = entities.DefaultTaxInvoiceTransactionCode
+	}
+	if !entities.TaxInvoiceTransactionCodes[transactionCode] {
+		return nil, fmt.Errorf("%w: unknown transaction code %q", entities.ErrInvalidTaxInvoice, transactionCode)
+	}
+	src, err := s.repo.GetTaxInvoiceSource(ctx, salesInvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return nil, entities.ErrSalesInvoiceNotFound
+	}
+	if fakturDate.IsZero() {
+		fakturDate = src.InvoiceDate
+	}
+	// A faktur is made when the invoice is, never ahead of it
+	if dateOnly(fakturDate).Before(dateOnly(src.InvoiceDate)) {
+		return nil, fmt.Errorf("%w: faktur date is before the invoice date", entities.ErrInvalidTaxInvoice)
+	}
+
+	inv, err := buildTaxInvoice(src, transactionCode, fakturDate, userID)
+	if err != nil {
+		return nil, err
+	}
+	inv.Kind = entities.TaxInvoiceNormal
+	if err := s.repo.CreateTaxInvoice(ctx, inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// ReplaceTaxInvoice issues a replacement (faktur pengganti) of a tax invoice from the
+// sales invoice and buyer as they are now. It keeps the NSFP of the faktur it replaces;
+// the faktur date defaults to the replaced one's and must be of the same year.
+func (s *TaxInvoiceService) ReplaceTaxInvoice(ctx context.Context, id uuid.ID, fakturDate time.Time, userID string) (*entities.TaxInvoice, error) {
+	original, err := s.GetTaxInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if original.Status != entities.TaxInvoiceActive {
+		return nil, entities.ErrTaxInvoiceNotActive
+	}
+	if fakturDate.IsZero() {
+		fakturDate = original.FakturDate
+	}
+	if fakturDate.Year() != original.FakturDate.Year() {
+		return nil, fmt.Errorf("%w: a replacement must be dated in the year of its NSFP", entities.ErrInvalidTaxInvoice)
+	}
+	src, err := s.repo.GetTaxInvoiceSource(ctx, original.SalesInvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return nil, entities.ErrSalesInvoiceNotFound
+	}
+
+	replacement, err := buildTaxInvoice(src, original.TransactionCode, fakturDate, userID)
+	if err != nil {
+		return nil, err
+	}
+	replacement.Kind = entities.TaxInvoiceReplacement
+	replacement.ReplacesID = &original.ID
+	replacement.NSFP = original.NSFP
+	replacement.NSFPRangeID = original.NSFPRangeID
+	replacement.FakturNumber = replacement.FormatFakturNumber()
+	if err := s.repo.ReplaceTaxInvoice(ctx, original, replacement); err != nil {
+		return nil, err
+	}
+	return replacement, nil
+}
+
+// CancelTaxInvoice cancels (batal) a tax invoice in force. Its NSFP is not used again.
+func (s *TaxInvoiceService) CancelTaxInvoice(ctx context.Context, id uuid.ID, reason, userID string) (*entities.TaxInvoice, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: a cancellation reason is required", entities.ErrInvalidTaxInvoice)
+	}
+	inv, err := s.GetTaxInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != entities.TaxInvoiceActive {
+		return nil, entities.ErrTaxInvoiceNotActive
+	}
+
+	now := utils.Now()
+	inv.Status = entities.TaxInvoiceCancelled
+	inv.CancelReason = reason
+	inv.CancelledBy = userID
+	inv.CancelledAt = &now
+	if err := s.repo.CancelTaxInvoice(ctx, inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// GetTaxInvoice returns a tax invoice with its items.
+func (s *TaxInvoiceService) GetTaxInvoice(ctx context.Context, id uuid.ID) (*entities.TaxInvoice, error) {
+	inv, err := s.repo.GetTaxInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, entities.ErrTaxInvoiceNotFound
+	}
+	return inv, nil
+}
+
+// ListTaxInvoices returns the tax invoices of a faktur date range (the current month by
+// default), of a status when given.
+func (s *TaxInvoiceService) ListTaxInvoices(ctx context.Context, from, to time.Time, status string) ([]*entities.TaxInvoice, error) {
+	from, to = taxInvoicePeriod(from, to)
+	return s.repo.ListTaxInvoices(ctx, repositories.TaxInvoiceFilter{From: from, To: to, Status: status})
+}
+
+// ExportEFaktur returns the tax invoices in force of a faktur date range as an e-Faktur
+// import CSV and marks them exported.
+func (s *TaxInvoiceService) ExportEFaktur(ctx context.Context, from, to time.Time) ([]byte, error) {
+	return s.export(ctx, from, to, WriteEFakturCSV)
+}
+
+// ExportCoretax returns the tax invoices in force of a faktur date range as a Coretax
+// import XML and marks them exported.
+func (s *TaxInvoiceService) ExportCoretax(ctx context.Context, from, to time.Time) ([]byte, error) {
+	if s.sellerTaxID == "" {
+		return nil, fmt.Errorf("%w: the company NPWP is not configured", entities.ErrInvalidTaxInvoice)
+	}
+	return s.export(ctx, from, to, func(w io.Writer, invoices []*entities.TaxInvoice) error {
+		return WriteCoretaxXML(w, s.sellerTaxID, invoices)
+	})
+}
+
+func (s *TaxInvoiceService) export(ctx context.Context, from, to time.Time,
+	write func(io.Writer, []*entities.TaxInvoice) error) ([]byte, error) {
+	from, to = taxInvoicePeriod(from, to)
+	invoices, err := s.repo.ListTaxInvoices(ctx, repositories.TaxInvoiceFilter{
+		From: from, To: to, Status: entities.TaxInvoiceActive, WithItems: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(invoices) == 0 {
+		return nil, fmt.Errorf("%w: no tax invoices to export", entities.ErrInvalidTaxInvoice)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, invoices); err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.ID, len(invoices))
+	for i, inv := range invoices {
+		ids[i] = inv.ID
+	}
+	if err := s.repo.MarkExported(ctx, ids, utils.Now()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// --- Input tax invoices ---
+
+// ImportInputTaxInvoices imports the supplier fakturs of an e-Faktur input tax (FM) CSV.
+// The VAT of creditable fakturs is booked as input tax of their tax period.
+func (s *TaxInvoiceService) ImportInputTaxInvoices(ctx context.Context, r io.Reader, fileName, userID string) (*entities.InputTaxImportResult, error) {
+	invoices, err := ParseInputTaxCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	now := utils.Now()
+	for _, inv := range invoices {
+		inv.ID = uuid.New()
+		inv.Status = entities.TaxInvoiceActive
+		inv.FileName = fileName
+		inv.ImportedBy = userID
+		inv.ImportedAt = now
+	}
+	return s.repo.ImportInputTaxInvoices(ctx, invoices)
+}
+
+// ListInputTaxInvoices returns the input tax invoices of a tax period; zero values select
+// every year or period.
+func (s *TaxInvoiceService) ListInputTaxInvoices(ctx context.Context, year, period int) ([]*entities.InputTaxInvoice, error) {
+	if period < 0 || period > 12 {
+		return nil, fmt.Errorf("%w: tax period must be a month from 1 to 12", entities.ErrInvalidInputTaxInvoice)
+	}
+	return s.repo.ListInputTaxInvoices(ctx, year, period)
+}
+
+// buildTaxInvoice makes a tax invoice of a sales invoice. The tax base and VAT of the
+// invoice are spread over its items in proportion to their price; the last item takes
+// the rounding, so the items always add up to the invoice.
+func buildTaxInvoice(src *entities.TaxInvoiceSource, transactionCode string, fakturDate time.Time, userID string) (*entities.TaxInvoice, error) {
+	if src.TaxAmount <= 0 {
+		return nil, fmt.Errorf("%w: invoice %s has no PPN", entities.ErrInvalidTaxInvoice, src.InvoiceNumber)
+	}
+	if strings.TrimSpace(src.BuyerName) == "" {
+		return nil, fmt.Errorf("%w: invoice %s has no buyer", entities.ErrInvalidTaxInvoice, src.InvoiceNumber)
+	}
+	buyerTaxID := taxIDDigits(src.BuyerTaxID)
+	if buyerTaxID != "" && len(buyerTaxID) != 15 && len(buyerTaxID) != 16 {
+		return nil, fmt.Errorf("%w: buyer NPWP %q must have 15 or 16 digits", entities.ErrInvalidTaxInvoice, src.BuyerTaxID)
+	}
+
+	now := utils.Now()
+	inv := &entities.TaxInvoice{
+		ID:              uuid.New(),
+		TransactionCode: transactionCode,
+		SalesInvoiceID:  src.SalesInvoiceID,
+		InvoiceNumber:   src.InvoiceNumber,
+		FakturDate:      dateOnly(fakturDate),
+		CustomerID:      src.CustomerID,
+		BuyerTaxID:      buyerTaxID,
+		BuyerName:       strings.TrimSpace(src.BuyerName),
+		BuyerAddress:    strings.TrimSpace(src.BuyerAddress),
+		TaxBase:         roundMoney(src.TotalAmount),
+		VATAmount:       roundMoney(src.TaxAmount),
+		Status:          entities.TaxInvoiceActive,
+		CreatedBy:       userID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	var gross float64
+	for _, item := range src.Items {
+		gross += item.TotalPrice
+	}
+	items := src.Items
+	if len(items) == 0 || gross <= 0 {
+		items = []*entities.TaxInvoiceItem{{
+			ItemName: "Invoice " + src.InvoiceNumber, UnitPrice: src.TotalAmount, Quantity: 1, TotalPrice: src.TotalAmount,
+		}}
+		gross = src.TotalAmount
+	}
+
+	baseLeft, vatLeft := inv.TaxBase, inv.VATAmount
+	for i, item := range items {
+		line := &entities.TaxInvoiceItem{
+			ID:         uuid.New(),
+			LineNumber: i + 1,
+			ItemCode:   item.ItemCode,
+			ItemName:   item.ItemName,
+			UnitPrice:  roundMoney(item.UnitPrice),
+			Quantity:   item.Quantity,
+			TotalPrice: roundMoney(item.TotalPrice),
+		}
+		if i == len(items)-1 {
+			line.TaxBase, line.VATAmount = roundMoney(baseLeft), roundMoney(vatLeft)
+		} else {
+			share := item.TotalPrice / gross
+			line.TaxBase = roundMoney(inv.TaxBase * share)
+			line.VATAmount = roundMoney(inv.VATAmount * share)
+			baseLeft -= line.TaxBase
+			vatLeft -= line.VATAmount
+		}
+		// An invoice discount shows as the item discount
+		line.Discount = roundMoney(math.Max(line.TotalPrice-line.TaxBase, 0))
+		inv.Items = append(inv.Items, line)
+	}
+	return inv, nil
+}
+
+// taxInvoicePeriod defaults an open faktur date range to the current month.
+func taxInvoicePeriod(from, to time.Time) (time.Time, time.Time) {
+	if from.IsZero() {
+		now := utils.Now()
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	if to.IsZero() {
+		to = from.AddDate(0, 1, -1)
+	}
+	return dateOnly(from), dateOnly(to)
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// taxIDDigits returns the digits of an NPWP or NIK, which is often written with dots and dashes.
+func taxIDDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/accounting/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// MockTaxInvoiceRepository is a mock implementation of repositories.TaxInvoiceRepository.
+type MockTaxInvoiceRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxInvoiceRepository) CreateNSFPRange(ctx context.Context, rng *entities.NSFPRange) error {
+	args := m.Called(ctx, rng)
+	return args.Error(0)
+}
+
+func (m *MockTaxInvoiceRepository) ListNSFPRanges(ctx context.Context, year int) ([]*entities.NSFPRange, error) {
+	args := m.Called(ctx, year)
+	return args.Get(0).([]*entities.NSFPRange), args.Error(1)
+}
+
+func (m *MockTaxInvoiceRepository) GetTaxInvoiceSource(ctx context.Context, id uuid.ID) (*entities.TaxInvoiceSource, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxInvoiceSource), args.Error(1)
+}
+
+func (m *MockTaxInvoiceRepository) CreateTaxInvoice(ctx context.Context, inv *entities.TaxInvoice) error {
+	args := m.Called(ctx, inv)
+	return args.Error(0)
+}
+
+func (m *MockTaxInvoiceRepository) ReplaceTaxInvoice(ctx context.Context, original, replacement *entities.TaxInvoice) error {
+	args := m.Called(ctx, original, replacement)
+	return args.Error(0)
+}
+
+func (m *MockTaxInvoiceRepository) CancelTaxInvoice(ctx context.Context, inv *entities.TaxInvoice) error {
+	args := m.Called(ctx, inv)
+	return args.Error(0)
+}
+
+func (m *MockTaxInvoiceRepository) GetTaxInvoice(ctx context.Context, id uuid.ID) (*entities.TaxInvoice, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxInvoice), args.Error(1)
+}
+
+func (m *MockTaxInvoiceRepository) ListTaxInvoices(ctx context.Context, filter repositories.TaxInvoiceFilter) ([]*entities.TaxInvoice, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.TaxInvoice), args.Error(1)
+}
+
+func (m *MockTaxInvoiceRepository) MarkExported(ctx context.Context, ids []uuid.ID, at time.Time) error {
+	args := m.Called(ctx, ids, at)
+	return args.Error(0)
+}
+
+func (m *MockTaxInvoiceRepository) ImportInputTaxInvoices(ctx context.Context, invoices []*entities.InputTaxInvoice) (*entities.InputTaxImportResult, error) {
+	args := m.Called(ctx, invoices)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.InputTaxImportResult), args.Error(1)
+}
+
+func (m *MockTaxInvoiceRepository) ListInputTaxInvoices(ctx context.Context, year, period int) ([]*entities.InputTaxInvoice, error) {
+	args := m.Called(ctx, year, period)
+	return args.Get(0).([]*entities.InputTaxInvoice), args.Error(1)
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+}
+
+// taxInvoiceSource returns a sales invoice of 800,000 with 88,000 PPN for two items of
+// 900,000 together, one at a third of the price.
+func taxInvoiceSource(date time.Time) *entities.TaxInvoiceSource {
+	id := uuid.New()
+	return &entities.TaxInvoiceSource{
+		SalesInvoiceID: id,
+		InvoiceNumber:  "INV-" + id.String()[:8],
+		InvoiceDate:    date,
+		BuyerTaxID:     "01.234.567.8-901.000",
+		BuyerName:      "PT Sepatu Jaya",
+		BuyerAddress:   "Jl. Sudirman 1, Jakarta",
+		TotalAmount:    800000,
+		TaxAmount:      88000,
+		Items: []*entities.TaxInvoiceItem{
+			{ItemCode: "SH-01", ItemName: "Sneaker", UnitPrice: 100000, Quantity: 3, TotalPrice: 300000},
+			{ItemCode: "SH-02", ItemName: "Loafer", UnitPrice: 150000, Quantity: 4, TotalPrice: 600000},
+		},
+	}
+}
+
+// testNSFPRange returns an active range of the first serials of branch 000 for the year.
+func testNSFPRange(year int, size int64) *entities.NSFPRange {
+	return &entities.NSFPRange{
+		ID:          uuid.New(),
+		BranchCode:  "000",
+		TaxYear:     year,
+		StartSerial: 1,
+		EndSerial:   size,
+		NextSerial:  1,
+		Status:      entities.NSFPRangeActive,
+	}
+}
+
+// numberedFrom numbers a tax invoice stored with the next NSFP of the range, as the
+// database does.
+func numberedFrom(rng *entities.NSFPRange) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		inv := args.Get(1).(*entities.TaxInvoice)
+		inv.NSFPRangeID = rng.ID
+		inv.NSFP = rng.Format(rng.NextSerial)
+		inv.FakturNumber = inv.FormatFakturNumber()
+		rng.NextSerial++
+	}
+}
+
+// activeTaxInvoicesFrom matches the listing of the tax invoices in force for export
+// from the month.
+func activeTaxInvoicesFrom(month time.Time) interface{} {
+	return mock.MatchedBy(func(f repositories.TaxInvoiceFilter) bool {
+		return f.From.Equal(month) && f.Status == entities.TaxInvoiceActive && f.WithItems
+	})
+}
+
+func TestTaxInvoiceService_RegisterNSFPRange(t *testing.T) {
+	repo := new(MockTaxInvoiceRepository)
+	svc := NewTaxInvoiceService(repo, "01.234.567.8-999.000")
+	ctx := context.Background()
+
+	repo.On("CreateNSFPRange", ctx, mock.AnythingOfType("*entities.NSFPRange")).Return(nil).Once()
+	rng, err := svc.RegisterNSFPRange(ctx, "000-24.00000001", "000-24.00000100", "S-123/PJ/2024", "finance")
+	require.NoError(t, err)
+	assert.Equal(t, "000", rng.BranchCode)
+	assert.Equal(t, 2024, rng.TaxYear)
+	assert.Equal(t, int64(100), rng.Remaining())
+	assert.Equal(t, "S-123/PJ/2024", rng.LetterNumber)
+	assert.Equal(t, entities.NSFPRangeActive, rng.Status)
+
+	repo.On("CreateNSFPRange", ctx, mock.AnythingOfType("*entities.NSFPRange")).Return(entities.ErrNSFPRangeOverlap).Once()
+	_, err = svc.RegisterNSFPRange(ctx, "000-24.00000050", "000-24.00000200", "", "finance")
+	assert.ErrorIs(t, err, entities.ErrNSFPRangeOverlap)
+	_, err = svc.RegisterNSFPRange(ctx, "000-24.00000300", "000-25.00000400", "", "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidNSFPRange)
+	_, err = svc.RegisterNSFPRange(ctx, "000-24.00000400", "000-24.00000300", "", "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidNSFPRange)
+	_, err = svc.RegisterNSFPRange(ctx, "12345", "000-24.00000300", "", "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidNSFPRange)
+	repo.AssertExpectations(t)
+}
+
+func TestTaxInvoiceService_IssueTaxInvoice(t *testing.T) {
+	repo := new(MockTaxInvoiceRepository)
+	svc := NewTaxInvoiceService(repo, "01.234.567.8-999.000")
+	ctx := context.Background()
+	rng := testNSFPRange(2024, 2)
+
+	src := taxInvoiceSource(day(2024, time.March, 5))
+	repo.On("GetTaxInvoiceSource", ctx, src.SalesInvoiceID).Return(src, nil).Twice()
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(nil).Once().Run(numberedFrom(rng))
+	inv, err := svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	require.NoError(t, err)
+	assert.Equal(t, "010.000-24.00000001", inv.FakturNumber)
+	assert.Equal(t, "0002400000001", inv.NSFPDigits())
+	assert.Equal(t, "012345678901000", inv.BuyerTaxID)
+	assert.Equal(t, day(2024, time.March, 5), inv.FakturDate)
+	assert.Equal(t, entities.TaxInvoiceNormal, inv.Kind)
+	assert.Equal(t, rng.ID, inv.NSFPRangeID)
+
+	// The invoice total is below the item prices: the difference shows as discount and
+	// the items add up to the invoice
+	require.Len(t, inv.Items, 2)
+	assert.InDelta(t, 266666.67, inv.Items[0].TaxBase, 0.001)
+	assert.InDelta(t, 29333.33, inv.Items[0].VATAmount, 0.001)
+	assert.InDelta(t, 33333.33, inv.Items[0].Discount, 0.001)
+	assert.InDelta(t, 533333.33, inv.Items[1].TaxBase, 0.001)
+	assert.InDelta(t, 58666.67, inv.Items[1].VATAmount, 0.001)
+	assert.InDelta(t, 66666.67, inv.Items[1].Discount, 0.001)
+
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(entities.ErrTaxInvoiceExists).Once()
+	_, err = svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	assert.ErrorIs(t, err, entities.ErrTaxInvoiceExists)
+
+	next := taxInvoiceSource(day(2024, time.March, 6))
+	repo.On("GetTaxInvoiceSource", ctx, next.SalesInvoiceID).Return(next, nil).Once()
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(nil).Once().Run(numberedFrom(rng))
+	second, err := svc.IssueTaxInvoice(ctx, next.SalesInvoiceID, "04", time.Time{}, "finance")
+	require.NoError(t, err)
+	assert.Equal(t, "040.000-24.00000002", second.FakturNumber)
+
+	last := taxInvoiceSource(day(2024, time.March, 7))
+	repo.On("GetTaxInvoiceSource", ctx, last.SalesInvoiceID).Return(last, nil).Once()
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(entities.ErrNSFPExhausted).Once()
+	_, err = svc.IssueTaxInvoice(ctx, last.SalesInvoiceID, "", time.Time{}, "finance")
+	assert.ErrorIs(t, err, entities.ErrNSFPExhausted)
+	repo.AssertExpectations(t)
+}
+
+func TestTaxInvoiceService_IssueTaxInvoiceValidation(t *testing.T) {
+	repo := new(MockTaxInvoiceRepository)
+	svc := NewTaxInvoiceService(repo, "01.234.567.8-999.000")
+	ctx := context.Background()
+
+	missing := uuid.New()
+	repo.On("GetTaxInvoiceSource", ctx, missing).Return(nil, nil).Once()
+	_, err := svc.IssueTaxInvoice(ctx, missing, "", time.Time{}, "finance")
+	assert.ErrorIs(t, err, entities.ErrSalesInvoiceNotFound)
+
+	src := taxInvoiceSource(day(2024, time.March, 5))
+	repo.On("GetTaxInvoiceSource", ctx, src.SalesInvoiceID).Return(src, nil).Times(4)
+	_, err = svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "99", time.Time{}, "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+	_, err = svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", day(2024, time.March, 4), "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+
+	src.TaxAmount = 0
+	_, err = svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+
+	src.TaxAmount = 88000
+	src.BuyerTaxID = "12345"
+	_, err = svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+	repo.AssertNotCalled(t, "CreateTaxInvoice", mock.Anything, mock.Anything)
+
+	// Buyers without an NPWP get a faktur with an empty tax ID and an invoice without
+	// items a single line
+	src.BuyerTaxID = ""
+	src.Items = nil
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(nil).Once().Run(numberedFrom(testNSFPRange(2024, 100)))
+	inv, err := svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	require.NoError(t, err)
+	assert.Equal(t, "", inv.BuyerTaxID)
+	require.Len(t, inv.Items, 1)
+	assert.Equal(t, 800000.0, inv.Items[0].TaxBase)
+	assert.Equal(t, 88000.0, inv.Items[0].VATAmount)
+	repo.AssertExpectations(t)
+}
+
+func TestTaxInvoiceService_ReplaceAndCancel(t *testing.T) {
+	repo := new(MockTaxInvoiceRepository)
+	svc := NewTaxInvoiceService(repo, "01.234.567.8-999.000")
+	ctx := context.Background()
+	src := taxInvoiceSource(day(2024, time.March, 5))
+	repo.On("GetTaxInvoiceSource", ctx, src.SalesInvoiceID).Return(src, nil).Twice()
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(nil).Once().Run(numberedFrom(testNSFPRange(2024, 100)))
+	original, err := svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	require.NoError(t, err)
+	repo.On("GetTaxInvoice", ctx, original.ID).Return(original, nil).Twice()
+
+	// The buyer's address was wrong: the replacement keeps the NSFP and carries the new one
+	src.BuyerAddress = "Jl. Thamrin 2, Jakarta"
+	repo.On("ReplaceTaxInvoice", ctx, original, mock.AnythingOfType("*entities.TaxInvoice")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*entities.TaxInvoice).Status = entities.TaxInvoiceReplaced
+	}).Once()
+	replacement, err := svc.ReplaceTaxInvoice(ctx, original.ID, time.Time{}, "finance")
+	require.NoError(t, err)
+	assert.Equal(t, "011.000-24.00000001", replacement.FakturNumber)
+	assert.Equal(t, original.NSFP, replacement.NSFP)
+	assert.Equal(t, original.NSFPRangeID, replacement.NSFPRangeID)
+	assert.Equal(t, entities.TaxInvoiceReplacement, replacement.Kind)
+	assert.Equal(t, original.ID, *replacement.ReplacesID)
+	assert.Equal(t, "Jl. Thamrin 2, Jakarta", replacement.BuyerAddress)
+	assert.Equal(t, entities.TaxInvoiceReplaced, original.Status)
+	repo.On("GetTaxInvoice", ctx, replacement.ID).Return(replacement, nil).Times(3)
+
+	_, err = svc.ReplaceTaxInvoice(ctx, original.ID, time.Time{}, "finance")
+	assert.ErrorIs(t, err, entities.ErrTaxInvoiceNotActive)
+	_, err = svc.ReplaceTaxInvoice(ctx, replacement.ID, day(2025, time.January, 2), "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+
+	_, err = svc.CancelTaxInvoice(ctx, replacement.ID, " ", "finance")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+	repo.On("CancelTaxInvoice", ctx, replacement).Return(nil).Once()
+	cancelled, err := svc.CancelTaxInvoice(ctx, replacement.ID, "Order returned", "finance")
+	require.NoError(t, err)
+	assert.Equal(t, entities.TaxInvoiceCancelled, cancelled.Status)
+	assert.Equal(t, "Order returned", cancelled.CancelReason)
+	assert.NotNil(t, cancelled.CancelledAt)
+	_, err = svc.CancelTaxInvoice(ctx, replacement.ID, "Order returned", "finance")
+	assert.ErrorIs(t, err, entities.ErrTaxInvoiceNotActive)
+
+	missing := uuid.New()
+	repo.On("GetTaxInvoice", ctx, missing).Return(nil, nil).Once()
+	_, err = svc.CancelTaxInvoice(ctx, missing, "Order returned", "finance")
+	assert.ErrorIs(t, err, entities.ErrTaxInvoiceNotFound)
+	repo.AssertExpectations(t)
+}
+
+func TestTaxInvoiceService_ExportEFaktur(t *testing.T) {
+	repo := new(MockTaxInvoiceRepository)
+	svc := NewTaxInvoiceService(repo, "01.234.567.8-999.000")
+	ctx := context.Background()
+	src := taxInvoiceSource(day(2024, time.March, 5))
+	repo.On("GetTaxInvoiceSource", ctx, src.SalesInvoiceID).Return(src, nil).Once()
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(nil).Once().Run(numberedFrom(testNSFPRange(2024, 100)))
+	inv, err := svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	require.NoError(t, err)
+
+	repo.On("ListTaxInvoices", ctx, activeTaxInvoicesFrom(day(2024, time.April, 1))).Return([]*entities.TaxInvoice{}, nil).Once()
+	_, err = svc.ExportEFaktur(ctx, day(2024, time.April, 1), day(2024, time.April, 30))
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+
+	repo.On("ListTaxInvoices", ctx, activeTaxInvoicesFrom(day(2024, time.March, 1))).Return([]*entities.TaxInvoice{inv}, nil).Once()
+	repo.On("MarkExported", ctx, []uuid.ID{inv.ID}, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	data, err := svc.ExportEFaktur(ctx, day(2024, time.March, 1), day(2024, time.March, 31))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 6)
+	assert.True(t, strings.HasPrefix(lines[0], "FK,KD_JENIS_TRANSAKSI,FG_PENGGANTI,NOMOR_FAKTUR"))
+	assert.True(t, strings.HasPrefix(lines[1], "LT,"))
+	assert.True(t, strings.HasPrefix(lines[2], "OF,"))
+	assert.Equal(t, "FK,01,0,0002400000001,3,2024,05/03/2024,012345678901000,PT Sepatu Jaya,\"Jl. Sudirman 1, Jakarta\","+
+		"800000,88000,0,,0,0,0,0,"+inv.InvoiceNumber+",", lines[3])
+	assert.Equal(t, "OF,SH-01,Sneaker,100000,3,300000,33333.33,266666.67,29333.33,0,0", lines[4])
+	repo.AssertExpectations(t)
+}
+
+func TestTaxInvoiceService_ExportCoretax(t *testing.T) {
+	repo := new(MockTaxInvoiceRepository)
+	svc := NewTaxInvoiceService(repo, "01.234.567.8-999.000")
+	ctx := context.Background()
+	src := taxInvoiceSource(day(2025, time.February, 3))
+	repo.On("GetTaxInvoiceSource", ctx, src.SalesInvoiceID).Return(src, nil).Once()
+	repo.On("CreateTaxInvoice", ctx, mock.AnythingOfType("*entities.TaxInvoice")).Return(nil).Once().Run(numberedFrom(testNSFPRange(2025, 100)))
+	inv, err := svc.IssueTaxInvoice(ctx, src.SalesInvoiceID, "", time.Time{}, "finance")
+	require.NoError(t, err)
+
+	repo.On("ListTaxInvoices", ctx, activeTaxInvoicesFrom(day(2025, time.February, 1))).Return([]*entities.TaxInvoice{inv}, nil).Once()
+	repo.On("MarkExported", ctx, []uuid.ID{inv.ID}, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	data, err := svc.ExportCoretax(ctx, day(2025, time.February, 1), day(2025, time.February, 28))
+	require.NoError(t, err)
+	xml := string(data)
+	assert.Contains(t, xml, "<TIN>0012345678999000</TIN>")
+	assert.Contains(t, xml, "<SellerIDTKU>0012345678999000000000</SellerIDTKU>")
+	assert.Contains(t, xml, "<BuyerTin>0012345678901000</BuyerTin>")
+	assert.Contains(t, xml, "<TaxInvoiceDate>2025-02-03</TaxInvoiceDate>")
+	// From 2025: 12% of 11/12 of the tax base
+	assert.Contains(t, xml, "<TaxBase>266666.67</TaxBase>")
+	assert.Contains(t, xml, "<OtherTaxBase>244444.45</OtherTaxBase>")
+	assert.Contains(t, xml, "<VATRate>12</VATRate>")
+	assert.Contains(t, xml, "<VAT>29333.33</VAT>")
+
+	unconfigured := NewTaxInvoiceService(repo, "")
+	_, err = unconfigured.ExportCoretax(ctx, day(2025, time.February, 1), day(2025, time.February, 28))
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxInvoice)
+	repo.AssertExpectations(t)
+}
+
+func TestParseInputTaxCSV(t *testing.T) {
+	file := "\ufeffFM,KD_JENIS_TRANSAKSI,FG_PENGGANTI,NOMOR_FAKTUR,MASA_PAJAK,TAHUN_PAJAK,TANGGAL_FAKTUR,NPWP,NAMA," +
+		"ALAMAT_LENGKAP,JUMLAH_DPP,JUMLAH_PPN,JUMLAH_PPNBM,IS_CREDITABLE\n" +
+		"FM,01,0,0012400000123,3,2024,04/03/2024,02.111.222.3-444.000,PT Kulit Prima,\"Jl. Industri 5, Bandung\",5000000,550000,0,1\n" +
+		"FM,1,1,0012400000124,3,2024,05/03/2024,021112223444000,PT Kulit Prima,Bandung,200000,22000,0,0\n"
+	invoices, err := ParseInputTaxCSV(strings.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, invoices, 2)
+
+	first := invoices[0]
+	assert.Equal(t, "010.001-24.00000123", first.FakturNumber)
+	assert.Equal(t, "001-24.00000123", first.NSFP)
+	assert.Equal(t, "021112223444000", first.SupplierTaxID)
+	assert.Equal(t, "Jl. Industri 5, Bandung", first.SupplierAddress)
+	assert.Equal(t, day(2024, time.March, 4), first.FakturDate)
+	assert.Equal(t, 3, first.TaxPeriod)
+	assert.Equal(t, 2024, first.TaxYear)
+	assert.Equal(t, 5000000.0, first.TaxBase)
+	assert.Equal(t, 550000.0, first.VATAmount)
+	assert.True(t, first.Creditable)
+
+	second := invoices[1]
+	assert.Equal(t, "011.001-24.00000124", second.FakturNumber)
+	assert.True(t, second.Replacement)
+	assert.False(t, second.Creditable)
+
+	// Semicolon files from spreadsheets read the same
+	invoices, err = ParseInputTaxCSV(strings.NewReader(
+		"FM;01;0;0012400000125;3;2024;06/03/2024;021112223444000;PT Kulit Prima;Bandung;100000;11000;0;1\n"))
+	require.NoError(t, err)
+	require.Len(t, invoices, 1)
+	assert.Equal(t, 11000.0, invoices[0].VATAmount)
+
+	_, err = ParseInputTaxCSV(strings.NewReader("FM,01,0,0012400000125,3,2024,06/03/2024,12345,PT X,Y,100000,11000,0,1\n"))
+	assert.ErrorIs(t, err, entities.ErrInvalidInputTaxInvoice)
+	_, err = ParseInputTaxCSV(strings.NewReader("FM,01,0,0012400000125,13,2024,06/03/2024,021112223444000,PT X,Y,100000,11000,0,1\n"))
+	assert.ErrorIs(t, err, entities.ErrInvalidInputTaxInvoice)
+	_, err = ParseInputTaxCSV(strings.NewReader("FK,01,0\n"))
+	assert.ErrorIs(t, err, entities.ErrInvalidInputTaxInvoice)
+}
+
+func TestTaxInvoiceService_ImportInputTaxInvoices(t *testing.T) {
+	repo := new(MockTaxInvoiceRepository)
+	svc := NewTaxInvoiceService(repo, "")
+	file := "FM,01,0,0012400000123,3,2024,04/03/2024,021112223444000,PT Kulit Prima,Bandung,5000000,550000,0,1\n"
+
+	var imported []*entities.InputTaxInvoice
+	repo.On("ImportInputTaxInvoices", context.Background(), mock.AnythingOfType("[]*entities.InputTaxInvoice")).Return(
+		&entities.InputTaxImportResult{Created: 1, CreditableVAT: 550000}, nil).Run(func(args mock.Arguments) {
+		imported = args.Get(1).([]*entities.InputTaxInvoice)
+	}).Once()
+	result, err := svc.ImportInputTaxInvoices(context.Background(), bytes.NewBufferString(file), "pm-2024-03.csv", "finance")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 550000.0, result.CreditableVAT)
+	require.Len(t, imported, 1)
+	assert.Equal(t, entities.TaxInvoiceActive, imported[0].Status)
+	assert.Equal(t, "pm-2024-03.csv", imported[0].FileName)
+	assert.Equal(t, "finance", imported[0].ImportedBy)
+	assert.False(t, imported[0].ID.IsNil())
+
+	_, err = svc.ListInputTaxInvoices(context.Background(), 2024, 13)
+	assert.ErrorIs(t, err, entities.ErrInvalidInputTaxInvoice)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "ListInputTaxInvoices", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,572 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockTaxRepository is a mock implementation of repositories.TaxRepository.
+type MockTaxRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxRepository) Create(ctx context.Context, tax *entities.Tax) error {
+	args := m.Called(ctx, tax)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.Tax, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetAll(ctx context.Context) ([]*entities.Tax, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) Update(ctx context.Context, tax *entities.Tax) error {
+	args := m.Called(ctx, tax)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) Delete(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) CreateTransaction(ctx context.Context, transaction *entities.TaxTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) GetTransactionByID(ctx context.Context, id uuid.ID) (*entities.TaxTransaction, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTransactionsByTax(ctx context.Context, taxID uuid.ID) ([]*entities.TaxTransaction, error) {
+	args := m.Called(ctx, taxID)
+	return args.Get(0).([]*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) UpdateTransaction(ctx context.Context, transaction *entities.TaxTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) DeleteTransaction(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) CreateReturn(ctx context.Context, taxReturn *entities.TaxReturn) error {
+	args := m.Called(ctx, taxReturn)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) GetReturnByID(ctx context.Context, id uuid.ID) (*entities.TaxReturn, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetReturnsByCompany(ctx context.Context, companyID string) ([]*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID)
+	return args.Get(0).([]*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) UpdateReturn(ctx context.Context, taxReturn *entities.TaxReturn) error {
+	args := m.Called(ctx, taxReturn)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) DeleteReturn(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) GetByCode(ctx context.Context, taxCode string) (*entities.Tax, error) {
+	args := m.Called(ctx, taxCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetByType(ctx context.Context, taxType entities.TaxType) ([]*entities.Tax, error) {
+	args := m.Called(ctx, taxType)
+	return args.Get(0).([]*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetActiveTaxes(ctx context.Context, companyID string, date time.Time) ([]*entities.Tax, error) {
+	args := m.Called(ctx, companyID, date)
+	return args.Get(0).([]*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTaxByTypeAndCompany(ctx context.Context, companyID string, taxType entities.TaxType) ([]*entities.Tax, error) {
+	args := m.Called(ctx, companyID, taxType)
+	return args.Get(0).([]*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetByCompanyID(ctx context.Context, companyID string) ([]*entities.Tax, error) {
+	args := m.Called(ctx, companyID)
+	return args.Get(0).([]*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetActiveByCompany(ctx context.Context, companyID string) ([]*entities.Tax, error) {
+	args := m.Called(ctx, companyID)
+	return args.Get(0).([]*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTransactionsByPeriod(ctx context.Context, companyID string, startDate, endDate time.Time) ([]*entities.TaxTransaction, error) {
+	args := m.Called(ctx, companyID, startDate, endDate)
+	return args.Get(0).([]*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTransactionsByType(ctx context.Context, companyID string, transactionType string) ([]*entities.TaxTransaction, error) {
+	args := m.Called(ctx, companyID, transactionType)
+	return args.Get(0).([]*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTransactionsByReference(ctx context.Context, referenceType, referenceID string) ([]*entities.TaxTransaction, error) {
+	args := m.Called(ctx, referenceType, referenceID)
+	return args.Get(0).([]*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTransactionsByCustomer(ctx context.Context, customerID string) ([]*entities.TaxTransaction, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTransactionsBySupplier(ctx context.Context, supplierID string) ([]*entities.TaxTransaction, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetReturnByNumber(ctx context.Context, returnNumber string) (*entities.TaxReturn, error) {
+	args := m.Called(ctx, returnNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetReturnsByType(ctx context.Context, companyID string, taxType entities.TaxType) ([]*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID, taxType)
+	return args.Get(0).([]*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetReturnsByStatus(ctx context.Context, companyID string, status entities.TaxStatus) ([]*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID, status)
+	return args.Get(0).([]*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetReturnsByPeriod(ctx context.Context, companyID string, startDate, endDate time.Time) ([]*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID, startDate, endDate)
+	return args.Get(0).([]*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetOverdueReturns(ctx context.Context, companyID string) ([]*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID)
+	return args.Get(0).([]*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetDueReturns(ctx context.Context, companyID string, dueDate time.Time) ([]*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID, dueDate)
+	return args.Get(0).([]*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) CalculateTax(ctx context.Context, taxID uuid.ID, baseAmount float64) (float64, error) {
+	args := m.Called(ctx, taxID, baseAmount)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetApplicableTaxes(ctx context.Context, companyID string, transactionType string, date time.Time) ([]*entities.Tax, error) {
+	args := m.Called(ctx, companyID, transactionType, date)
+	return args.Get(0).([]*entities.Tax), args.Error(1)
+}
+
+func (m *MockTaxRepository) SubmitReturn(ctx context.Context, returnID uuid.ID, userID string) error {
+	args := m.Called(ctx, returnID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) PayReturn(ctx context.Context, returnID uuid.ID, paymentAmount float64) error {
+	args := m.Called(ctx, returnID, paymentAmount)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) GenerateReturn(ctx context.Context, companyID string, taxType entities.TaxType, periodStart, periodEnd time.Time) (*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID, taxType, periodStart, periodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetReturnByPeriod(ctx context.Context, taxType entities.TaxType, periodStart time.Time) (*entities.TaxReturn, error) {
+	args := m.Called(ctx, taxType, periodStart)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetReturnTotals(ctx context.Context, taxTypes []entities.TaxType, periodStart, periodEnd time.Time) (*entities.TaxReturnTotals, error) {
+	args := m.Called(ctx, taxTypes, periodStart, periodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxReturnTotals), args.Error(1)
+}
+
+func (m *MockTaxRepository) FileReturn(ctx context.Context, taxReturn *entities.TaxReturn, taxTypes []entities.TaxType) error {
+	args := m.Called(ctx, taxReturn, taxTypes)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) SettleReturn(ctx context.Context, taxReturn *entities.TaxReturn) error {
+	args := m.Called(ctx, taxReturn)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) SetReturnJournalEntry(ctx context.Context, returnID, journalEntryID uuid.ID) error {
+	args := m.Called(ctx, returnID, journalEntryID)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) GetTaxReport(ctx context.Context, companyID string, taxType entities.TaxType, startDate, endDate time.Time) (*entities.TaxReport, error) {
+	args := m.Called(ctx, companyID, taxType, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TaxReport), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTaxLiabilityReport(ctx context.Context, companyID string, asOfDate time.Time) (map[entities.TaxType]float64, error) {
+	args := m.Called(ctx, companyID, asOfDate)
+	return args.Get(0).(map[entities.TaxType]float64), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTaxComplianceReport(ctx context.Context, companyID string, year int) (map[string]interface{}, error) {
+	args := m.Called(ctx, companyID, year)
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetVATReport(ctx context.Context, companyID string, startDate, endDate time.Time) (map[string]float64, error) {
+	args := m.Called(ctx, companyID, startDate, endDate)
+	return args.Get(0).(map[string]float64), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetWithholdingTaxReport(ctx context.Context, companyID string, startDate, endDate time.Time) ([]*entities.TaxTransaction, error) {
+	args := m.Called(ctx, companyID, startDate, endDate)
+	return args.Get(0).([]*entities.TaxTransaction), args.Error(1)
+}
+
+func (m *MockTaxRepository) CreateTransactionBatch(ctx context.Context, transactions []*entities.TaxTransaction) error {
+	args := m.Called(ctx, transactions)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) UpdateReturnTotals(ctx context.Context, returnID uuid.ID) error {
+	args := m.Called(ctx, returnID)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) ProcessPeriodicReturns(ctx context.Context, companyID string, period time.Time) error {
+	args := m.Called(ctx, companyID, period)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) SyncWithExternalSystem(ctx context.Context, companyID string) error {
+	args := m.Called(ctx, companyID)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) ValidateReturnData(ctx context.Context, returnID uuid.ID) ([]string, error) {
+	args := m.Called(ctx, returnID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTaxHistory(ctx context.Context, companyID string, taxType entities.TaxType) ([]*entities.TaxReturn, error) {
+	args := m.Called(ctx, companyID, taxType)
+	return args.Get(0).([]*entities.TaxReturn), args.Error(1)
+}
+
+func (m *MockTaxRepository) GetTaxTrends(ctx context.Context, companyID string, taxType entities.TaxType, periods int) ([]float64, error) {
+	args := m.Called(ctx, companyID, taxType, periods)
+	return args.Get(0).([]float64), args.Error(1)
+}
+
+// assignReturnID gives a return stored its ID, as the database does.
+func assignReturnID(args mock.Arguments) {
+	args.Get(1).(*entities.TaxReturn).ID = uuid.New()
+}
+
+// MockJournalPoster is a mock implementation of JournalPoster.
+type MockJournalPoster struct {
+	mock.Mock
+}
+
+func (m *MockJournalPoster) CreateJournalFromTransaction(ctx context.Context, req *AutoJournalRequest) (*entities.JournalEntry, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.JournalEntry), args.Error(1)
+}
+
+func taxMonth(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func newTaxServiceAt(repo *MockTaxRepository, now time.Time) *TaxService {
+	s := NewTaxService(repo)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestTaxService_GenerateReturnAggregatesTransactions(t *testing.T) {
+	repo := new(MockTaxRepository)
+	s := newTaxServiceAt(repo, day(2025, 4, 10))
+	march, february := taxMonth(2025, time.March), taxMonth(2025, time.February)
+	ppnTypes := []entities.TaxType{entities.TaxTypePPN, entities.TaxTypeVAT}
+	pphTypes := []entities.TaxType{entities.TaxTypeWithholding, entities.TaxTypePPh}
+
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, march).Return(nil, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, march, march.AddDate(0, 1, -1)).
+		Return(&entities.TaxReturnTotals{TotalSales: 15000000, TotalPurchases: 4000000,
+			OutputTax: 1650000, InputTax: 440000, TransactionCount: 3}, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, february).Return(nil, nil).Once()
+	repo.On("CreateReturn", mock.Anything, mock.AnythingOfType("*entities.TaxReturn")).Return(nil).Once().Run(assignReturnID)
+	ret, err := s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 3, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "SPT-PPN-202503", ret.ReturnNumber)
+	assert.Equal(t, entities.TaxStatusDraft, ret.Status)
+	assert.Equal(t, "2025-03-31", ret.PeriodEnd.Format("2006-01-02"))
+	assert.Equal(t, "2025-04-30", ret.DueDate.Format("2006-01-02"))
+	require.NotNil(t, ret.PaymentDueDate)
+	assert.Equal(t, "2025-04-15", ret.PaymentDueDate.Format("2006-01-02"))
+	assert.Equal(t, 15000000.0, ret.TotalSales)
+	assert.Equal(t, 1650000.0, ret.OutputTax)
+	assert.Equal(t, 440000.0, ret.InputTax)
+	assert.Equal(t, 1210000.0, ret.TaxPayable)
+	assert.Equal(t, 3, ret.TransactionCount)
+	assert.Zero(t, ret.PenaltyAmount)
+	assert.Zero(t, ret.InterestAmount)
+	assert.Equal(t, 1210000.0, ret.TotalDue)
+
+	// A new transaction of the month is picked up by regenerating the draft
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, march).Return(ret, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, march, march.AddDate(0, 1, -1)).
+		Return(&entities.TaxReturnTotals{TotalSales: 15000000, TotalPurchases: 5000000,
+			OutputTax: 1650000, InputTax: 550000, TransactionCount: 4}, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, february).Return(nil, nil).Once()
+	repo.On("UpdateReturn", mock.Anything, ret).Return(nil).Once()
+	again, err := s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 3, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, ret.ID, again.ID)
+	assert.Equal(t, 1100000.0, again.TaxPayable)
+
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypeWithholding, march).Return(nil, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, pphTypes, march, march.AddDate(0, 1, -1)).
+		Return(&entities.TaxReturnTotals{TotalPurchases: 2000000,
+			OutputTax: 40000, TransactionCount: 1}, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypeWithholding, february).Return(nil, nil).Once()
+	repo.On("CreateReturn", mock.Anything, mock.AnythingOfType("*entities.TaxReturn")).Return(nil).Once().Run(assignReturnID)
+	pph, err := s.GenerateReturn(context.Background(), entities.TaxTypeWithholding, 2025, 3, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 40000.0, pph.TaxPayable)
+	assert.Equal(t, "2025-04-20", pph.DueDate.Format("2006-01-02"))
+
+	_, err = s.GenerateReturn(context.Background(), entities.TaxTypeSales, 2025, 3, "user-1")
+	assert.ErrorIs(t, err, entities.ErrUnsupportedTaxReturnType)
+	_, err = s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 13, "user-1")
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxPeriod)
+	repo.AssertExpectations(t)
+}
+
+func TestTaxService_OverpaymentCarriesForward(t *testing.T) {
+	repo := new(MockTaxRepository)
+	s := newTaxServiceAt(repo, day(2025, 2, 10))
+	december, january, february := taxMonth(2024, time.December), taxMonth(2025, time.January), taxMonth(2025, time.February)
+	ppnTypes := []entities.TaxType{entities.TaxTypePPN, entities.TaxTypeVAT}
+	januaryTotals := &entities.TaxReturnTotals{TotalSales: 1000000, TotalPurchases: 3000000,
+		OutputTax: 110000, InputTax: 330000, TransactionCount: 2}
+	februaryTotals := &entities.TaxReturnTotals{TotalSales: 3000000, OutputTax: 330000, TransactionCount: 1}
+
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, january).Return(nil, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, january, january.AddDate(0, 1, -1)).Return(januaryTotals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, december).Return(nil, nil).Once()
+	repo.On("CreateReturn", mock.Anything, mock.AnythingOfType("*entities.TaxReturn")).Return(nil).Once().Run(assignReturnID)
+	jan, err := s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 1, "user-1")
+	require.NoError(t, err)
+	assert.Zero(t, jan.TaxPayable)
+	assert.Equal(t, 220000.0, jan.OverpaymentCarriedForward)
+
+	// The overpayment is brought forward only once the return is filed
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, february).Return(nil, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, february, february.AddDate(0, 1, -1)).Return(februaryTotals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, january).Return(jan, nil).Once()
+	repo.On("CreateReturn", mock.Anything, mock.AnythingOfType("*entities.TaxReturn")).Return(nil).Once().Run(assignReturnID)
+	feb, err := s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 2, "user-1")
+	require.NoError(t, err)
+	assert.Zero(t, feb.OverpaymentBroughtForward)
+	assert.Equal(t, 330000.0, feb.TaxPayable)
+
+	repo.On("GetReturnByID", mock.Anything, jan.ID).Return(jan, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, january, january.AddDate(0, 1, -1)).Return(januaryTotals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, december).Return(nil, nil).Once()
+	repo.On("FileReturn", mock.Anything, jan, ppnTypes).Return(nil).Once()
+	_, err = s.SubmitReturn(context.Background(), jan.ID, "user-1")
+	require.NoError(t, err)
+
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, february).Return(feb, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, february, february.AddDate(0, 1, -1)).Return(februaryTotals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, january).Return(jan, nil).Once()
+	repo.On("UpdateReturn", mock.Anything, feb).Return(nil).Once()
+	feb, err = s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 2, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 220000.0, feb.OverpaymentBroughtForward)
+	assert.Equal(t, 110000.0, feb.TaxPayable)
+	assert.Zero(t, feb.OverpaymentCarriedForward)
+	repo.AssertExpectations(t)
+}
+
+func TestTaxService_SubmitReturnLocksTransactions(t *testing.T) {
+	repo := new(MockTaxRepository)
+	s := newTaxServiceAt(repo, day(2025, 4, 10))
+	march := taxMonth(2025, time.March)
+	ppnTypes := []entities.TaxType{entities.TaxTypePPN, entities.TaxTypeVAT}
+	ppn := &entities.Tax{ID: uuid.New(), TaxType: entities.TaxTypePPN}
+	sale := &entities.TaxTransaction{ID: uuid.New(), TaxID: ppn.ID, TransactionDate: day(2025, 3, 3), TransactionType: "SALE",
+		BaseAmount: 10000000, TaxAmount: 1100000, TotalAmount: 11100000, CompanyID: "1"}
+	totals := &entities.TaxReturnTotals{TotalSales: 10000000, OutputTax: 1100000, TransactionCount: 1}
+
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, march).Return(nil, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, march, march.AddDate(0, 1, -1)).Return(totals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, taxMonth(2025, time.February)).Return(nil, nil).Once()
+	repo.On("CreateReturn", mock.Anything, mock.AnythingOfType("*entities.TaxReturn")).Return(nil).Once().Run(assignReturnID)
+	ret, err := s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 3, "user-1")
+	require.NoError(t, err)
+
+	// Filing locks the transactions of the month to the return
+	repo.On("GetReturnByID", mock.Anything, ret.ID).Return(ret, nil).Times(3)
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, march, march.AddDate(0, 1, -1)).Return(totals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, taxMonth(2025, time.February)).Return(nil, nil).Once()
+	repo.On("FileReturn", mock.Anything, ret, ppnTypes).Return(nil).Run(func(args mock.Arguments) {
+		sale.TaxReturnID = &ret.ID
+	}).Once()
+	filed, err := s.SubmitReturn(context.Background(), ret.ID, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, entities.TaxStatusSubmitted, filed.Status)
+	assert.Equal(t, "user-2", filed.SubmittedBy)
+	assert.Zero(t, filed.PenaltyAmount)
+
+	repo.On("GetTransactionByID", mock.Anything, sale.ID).Return(sale, nil).Twice()
+	edited := *sale
+	edited.TaxAmount = 0
+	assert.ErrorIs(t, s.UpdateTransaction(context.Background(), &edited), entities.ErrTaxTransactionLocked)
+	assert.ErrorIs(t, s.DeleteTransaction(context.Background(), sale.ID), entities.ErrTaxTransactionLocked)
+
+	repo.On("GetByID", mock.Anything, ppn.ID).Return(ppn, nil).Twice()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, march).Return(ret, nil).Once()
+	err = s.CreateTransaction(context.Background(), &entities.TaxTransaction{TaxID: ppn.ID, TransactionDate: day(2025, 3, 28),
+		TransactionType: "SALE", BaseAmount: 1000000, TaxAmount: 110000, CompanyID: "1"})
+	assert.ErrorIs(t, err, entities.ErrTaxReturnFiled)
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, taxMonth(2025, time.April)).Return(nil, nil).Once()
+	repo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*entities.TaxTransaction")).Return(nil).Once()
+	require.NoError(t, s.CreateTransaction(context.Background(), &entities.TaxTransaction{TaxID: ppn.ID,
+		TransactionDate: day(2025, 4, 2), TransactionType: "SALE", BaseAmount: 1000000, TaxAmount: 110000, CompanyID: "1"}))
+
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, march).Return(ret, nil).Once()
+	_, err = s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 3, "user-1")
+	assert.ErrorIs(t, err, entities.ErrTaxReturnFiled)
+	_, err = s.SubmitReturn(context.Background(), ret.ID, "user-2")
+	assert.ErrorIs(t, err, entities.ErrTaxReturnNotDraft)
+	assert.ErrorIs(t, s.DeleteReturn(context.Background(), ret.ID), entities.ErrTaxReturnNotDraft)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "DeleteTransaction", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "DeleteReturn", mock.Anything, mock.Anything)
+}
+
+func TestTaxService_LateFilingAndPayment(t *testing.T) {
+	repo := new(MockTaxRepository)
+	journals := new(MockJournalPoster)
+	s := newTaxServiceAt(repo, day(2025, 5, 2))
+	s.SetJournalPoster(journals)
+	march := taxMonth(2025, time.March)
+	ppnTypes := []entities.TaxType{entities.TaxTypePPN, entities.TaxTypeVAT}
+	totals := &entities.TaxReturnTotals{TotalSales: 10000000, OutputTax: 1000000, TransactionCount: 1}
+
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, march).Return(nil, nil).Once()
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, march, march.AddDate(0, 1, -1)).Return(totals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, taxMonth(2025, time.February)).Return(nil, nil).Once()
+	repo.On("CreateReturn", mock.Anything, mock.AnythingOfType("*entities.TaxReturn")).Return(nil).Once().Run(assignReturnID)
+	ret, err := s.GenerateReturn(context.Background(), entities.TaxTypePPN, 2025, 3, "user-1")
+	require.NoError(t, err)
+
+	repo.On("GetReturnByID", mock.Anything, ret.ID).Return(ret, nil).Times(4)
+	repo.On("GetReturnTotals", mock.Anything, ppnTypes, march, march.AddDate(0, 1, -1)).Return(totals, nil).Once()
+	repo.On("GetReturnByPeriod", mock.Anything, entities.TaxTypePPN, taxMonth(2025, time.February)).Return(nil, nil).Once()
+	repo.On("FileReturn", mock.Anything, ret, ppnTypes).Return(nil).Once()
+	filed, err := s.SubmitReturn(context.Background(), ret.ID, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(entities.LateFilingFineVAT), filed.PenaltyAmount)
+
+	// Paid on 2 June, one month and a part after the 15 April due date: two months of interest
+	_, err = s.PayReturn(context.Background(), ret.ID, &TaxReturnPayment{Amount: 1000, PaymentDate: day(2025, 6, 2)})
+	assert.ErrorIs(t, err, entities.ErrInvalidTaxPayment)
+	entry := &entities.JournalEntry{ID: uuid.New()}
+	repo.On("SettleReturn", mock.Anything, ret).Return(nil).Once()
+	journals.On("CreateJournalFromTransaction", mock.Anything, mock.AnythingOfType("*services.AutoJournalRequest")).Return(entry, nil).Once()
+	repo.On("SetReturnJournalEntry", mock.Anything, ret.ID, entry.ID).Return(nil).Once()
+	paid, err := s.PayReturn(context.Background(), ret.ID, &TaxReturnPayment{PaymentDate: day(2025, 6, 2), PaidBy: "user-3"})
+	require.NoError(t, err)
+	assert.Equal(t, entities.TaxStatusPaid, paid.Status)
+	assert.Equal(t, 12000.0, paid.InterestAmount)
+	assert.Equal(t, 1512000.0, paid.TaxPaid)
+	assert.Zero(t, paid.TotalDue)
+	assert.Equal(t, "2025-06-02", paid.PaidAt.Format("2006-01-02"))
+
+	req := journals.Calls[0].Arguments.Get(1).(*AutoJournalRequest)
+	assert.Equal(t, "ACCOUNTING", req.SourceModule)
+	assert.Equal(t, "TAX_PAYMENT_PPN", req.TransactionType)
+	assert.Equal(t, ret.ID.String(), req.SourceID)
+	assert.Equal(t, 1000000.0, req.TransactionData["tax_payable_amount"])
+	assert.Equal(t, 500000.0, req.TransactionData["penalty_amount"])
+	assert.Equal(t, 12000.0, req.TransactionData["interest_amount"])
+	assert.Equal(t, 1512000.0, req.TransactionData["cash_amount"])
+
+	_, err = s.PayReturn(context.Background(), ret.ID, &TaxReturnPayment{})
+	assert.ErrorIs(t, err, entities.ErrTaxReturnNotPayable)
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
+
+func TestMonthsLate(t *testing.T) {
+	due := day(2025, 4, 15)
+	assert.Equal(t, 0, entities.MonthsLate(due, day(2025, 4, 15), 24))
+	assert.Equal(t, 1, entities.MonthsLate(due, day(2025, 4, 16), 24))
+	assert.Equal(t, 1, entities.MonthsLate(due, day(2025, 5, 15), 24))
+	assert.Equal(t, 2, entities.MonthsLate(due, day(2025, 5, 16), 24))
+	assert.Equal(t, 24, entities.MonthsLate(due, day(2030, 1, 1), 24))
+}
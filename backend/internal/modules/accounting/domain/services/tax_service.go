@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"math"
 	"time"
 
 	"malaka/internal/modules/accounting/domain/entities"
@@ -9,14 +12,35 @@ import (
 	"malaka/internal/shared/uuid"
 )
 
+// Tax return payments are journalled through the auto-journal account mappings of the
+// ACCOUNTING module, one transaction type per return type: TAX_PAYMENT_PPN debits the VAT
+// payable and TAX_PAYMENT_WITHHOLDING the withholding tax payable.
+const (
+	taxJournalSource        = "ACCOUNTING"
+	taxJournalPaymentPrefix = "TAX_PAYMENT_"
+)
+
+// JournalPoster posts journal entries through the auto-journal mappings.
+type JournalPoster interface {
+	CreateJournalFromTransaction(ctx context.Context, req *AutoJournalRequest) (*entities.JournalEntry, error)
+}
+
 // TaxService provides business logic for tax operations.
 type TaxService struct {
-	repo repositories.TaxRepository
+	repo      repositories.TaxRepository
+	journals  JournalPoster
+	penalties entities.TaxPenaltyPolicy
+	now       func() time.Time
 }
 
 // NewTaxService creates a new TaxService.
 func NewTaxService(repo repositories.TaxRepository) *TaxService {
-	return &TaxService{repo: repo}
+	return &TaxService{repo: repo, penalties: entities.DefaultTaxPenaltyPolicy, now: time.Now}
+}
+
+// SetJournalPoster sets where tax return payment journals are posted.
+func (s *TaxService) SetJournalPoster(journals JournalPoster) {
+	s.journals = journals
 }
 
 // --- Tax CRUD ---
@@ -54,6 +78,9 @@ func (s *TaxService) CreateTransaction(ctx context.Context, tx *entities.TaxTran
 	if err := tx.Validate(); err != nil {
 		return err
 	}
+	if err := s.checkPeriodOpen(ctx, tx); err != nil {
+		return err
+	}
 	tx.TotalAmount = tx.BaseAmount + tx.TaxAmount
 	return s.repo.CreateTransaction(ctx, tx)
 }
@@ -70,14 +97,62 @@ func (s *TaxService) GetTransactionsByPeriod(ctx context.Context, startDate, end
 	return s.repo.GetTransactionsByPeriod(ctx, "", startDate, endDate)
 }
 
+// UpdateTransaction updates a transaction that is not filed in a tax return, within a
+// period whose return is not filed.
 func (s *TaxService) UpdateTransaction(ctx context.Context, tx *entities.TaxTransaction) error {
+	if _, err := s.unlockedTransaction(ctx, tx.ID); err != nil {
+		return err
+	}
+	if err := s.checkPeriodOpen(ctx, tx); err != nil {
+		return err
+	}
 	return s.repo.UpdateTransaction(ctx, tx)
 }
 
+// DeleteTransaction deletes a transaction that is not filed in a tax return.
 func (s *TaxService) DeleteTransaction(ctx context.Context, id uuid.ID) error {
+	if _, err := s.unlockedTransaction(ctx, id); err != nil {
+		return err
+	}
 	return s.repo.DeleteTransaction(ctx, id)
 }
 
+func (s *TaxService) unlockedTransaction(ctx context.Context, id uuid.ID) (*entities.TaxTransaction, error) {
+	tx, err := s.repo.GetTransactionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, entities.ErrTaxTransactionNotFound
+	}
+	if tx.IsLocked() {
+		return nil, entities.ErrTaxTransactionLocked
+	}
+	return tx, nil
+}
+
+// checkPeriodOpen refuses a transaction dated in a month whose return of the tax type is
+// already filed.
+func (s *TaxService) checkPeriodOpen(ctx context.Context, tx *entities.TaxTransaction) error {
+	tax, err := s.repo.GetByID(ctx, tx.TaxID)
+	if err != nil || tax == nil {
+		return err
+	}
+	returnType, ok := entities.ReturnTypeOf(tax.TaxType)
+	if !ok {
+		return nil
+	}
+	periodStart := time.Date(tx.TransactionDate.Year(), tx.TransactionDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	ret, err := s.repo.GetReturnByPeriod(ctx, returnType, periodStart)
+	if err != nil {
+		return err
+	}
+	if ret != nil && ret.Status != entities.TaxStatusDraft {
+		return fmt.Errorf("%w: %s", entities.ErrTaxReturnFiled, ret.ReturnNumber)
+	}
+	return nil
+}
+
 // --- Tax Return ---
 
 func (s *TaxService) CreateReturn(ctx context.Context, ret *entities.TaxReturn) error {
@@ -101,20 +176,229 @@ func (s *TaxService) GetReturnsByStatus(ctx context.Context, status entities.Tax
 	return s.repo.GetReturnsByStatus(ctx, "", status)
 }
 
+// UpdateReturn updates a draft tax return; filed returns can no longer change.
 func (s *TaxService) UpdateReturn(ctx context.Context, ret *entities.TaxReturn) error {
+	existing, err := s.draftReturn(ctx, ret.ID)
+	if err != nil {
+		return err
+	}
+	ret.Status = entities.TaxStatusDraft
+	ret.PaymentDueDate = existing.PaymentDueDate
+	ret.OverpaymentBroughtForward = existing.OverpaymentBroughtForward
+	ret.TransactionCount = existing.TransactionCount
+	ret.CreatedBy = existing.CreatedBy
+	ret.CreatedAt = existing.CreatedAt
+	ret.CalculateNetTax()
+	ret.CalculateTotalDue()
 	return s.repo.UpdateReturn(ctx, ret)
 }
 
+// DeleteReturn deletes a draft tax return.
 func (s *TaxService) DeleteReturn(ctx context.Context, id uuid.ID) error {
+	if _, err := s.draftReturn(ctx, id); err != nil {
+		return err
+	}
 	return s.repo.DeleteReturn(ctx, id)
 }
 
-func (s *TaxService) SubmitReturn(ctx context.Context, id uuid.ID, userID string) error {
-	return s.repo.SubmitReturn(ctx, id, userID)
+func (s *TaxService) draftReturn(ctx context.Context, id uuid.ID) (*entities.TaxReturn, error) {
+	ret, err := s.repo.GetReturnByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, entities.ErrTaxReturnNotFound
+	}
+	if ret.Status != entities.TaxStatusDraft {
+		return nil, entities.ErrTaxReturnNotDraft
+	}
+	return ret, nil
+}
+
+// GenerateReturn computes the monthly return of a tax type (PPN or WITHHOLDING) from the
+// unfiled tax transactions of the month, bringing forward the overpayment of the filed
+// return of the previous month. A draft of the month is recomputed; penalties and
+// interest are projected as if the return were filed and paid today.
+func (s *TaxService) GenerateReturn(ctx context.Context, taxType entities.TaxType, year, month int, userID string) (*entities.TaxReturn, error) {
+	if _, err := entities.ReturnTaxTypes(taxType); err != nil {
+		return nil, err
+	}
+	periodStart, periodEnd, err := entities.TaxPeriod(year, month)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := s.repo.GetReturnByPeriod(ctx, taxType, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	if ret != nil && ret.Status != entities.TaxStatusDraft {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaxReturnFiled, ret.ReturnNumber)
+	}
+
+	now := s.now()
+	existing := ret != nil
+	if !existing {
+		paymentDue := entities.TaxPaymentDueDate(periodStart)
+		ret = &entities.TaxReturn{
+			ReturnNumber:   fmt.Sprintf("SPT-%s-%s", taxType, periodStart.Format("200601")),
+			TaxType:        taxType,
+			PeriodStart:    periodStart,
+			PeriodEnd:      periodEnd,
+			DueDate:        entities.TaxFilingDueDate(taxType, periodStart),
+			PaymentDueDate: &paymentDue,
+			Status:         entities.TaxStatusDraft,
+			CompanyID:      "1", // Default company
+			CreatedBy:      userID,
+		}
+	}
+	ret.FilingDate = now
+	if err := s.computeReturn(ctx, ret, now); err != nil {
+		return nil, err
+	}
+	if existing {
+		err = s.repo.UpdateReturn(ctx, ret)
+	} else {
+		err = s.repo.CreateReturn(ctx, ret)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// computeReturn sets the totals of a return from the unfiled transactions of its period
+// and the overpayment brought forward, and the penalties of filing and paying it at a date.
+func (s *TaxService) computeReturn(ctx context.Context, ret *entities.TaxReturn, at time.Time) error {
+	taxTypes, err := entities.ReturnTaxTypes(ret.TaxType)
+	if err != nil {
+		return err
+	}
+	totals, err := s.repo.GetReturnTotals(ctx, taxTypes, ret.PeriodStart, ret.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	broughtForward := 0.0
+	previous, err := s.repo.GetReturnByPeriod(ctx, ret.TaxType, ret.PeriodStart.AddDate(0, -1, 0))
+	if err != nil {
+		return err
+	}
+	if previous != nil && previous.Status != entities.TaxStatusDraft {
+		broughtForward = previous.OverpaymentCarriedForward
+	}
+	ret.ApplyTotals(totals, broughtForward)
+	ret.ApplyLateFiling(at)
+	ret.ApplyLatePayment(at, s.penalties)
+	return nil
 }
 
-func (s *TaxService) PayReturn(ctx context.Context, id uuid.ID, amount float64) error {
-	return s.repo.PayReturn(ctx, id, amount)
+// SubmitReturn files a draft return. Returns of PPN and WITHHOLDING are recomputed from
+// the transactions of the period, which are locked to the return; the late filing fine
+// is charged when filed after the due date.
+func (s *TaxService) SubmitReturn(ctx context.Context, id uuid.ID, userID string) (*entities.TaxReturn, error) {
+	ret, err := s.draftReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	taxTypes, err := entities.ReturnTaxTypes(ret.TaxType)
+	if err != nil {
+		// Returns of other taxes keep the totals they were entered with
+		taxTypes = nil
+		ret.ApplyLateFiling(now)
+	} else if err := s.computeReturn(ctx, ret, now); err != nil {
+		return nil, err
+	}
+	if err := ret.Submit(userID); err != nil {
+		return nil, err
+	}
+	ret.FilingDate = now
+	if err := s.repo.FileReturn(ctx, ret, taxTypes); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// TaxReturnPayment is the payment of a filed tax return. Without an amount the total due
+// is paid; without a date it is paid today.
+type TaxReturnPayment struct {
+	Amount      float64
+	PaymentDate time.Time
+	PaidBy      string
+}
+
+// PayReturn settles a filed return: interest is charged for each month or part of a month
+// paid after the payment due date, the payment must settle the total due, and the
+// settlement journal is posted.
+func (s *TaxService) PayReturn(ctx context.Context, id uuid.ID, payment *TaxReturnPayment) (*entities.TaxReturn, error) {
+	ret, err := s.repo.GetReturnByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, entities.ErrTaxReturnNotFound
+	}
+	paidAt := payment.PaymentDate
+	if paidAt.IsZero() {
+		paidAt = s.now()
+	}
+	ret.ApplyLatePayment(paidAt, s.penalties)
+	if !ret.CanBePaid() {
+		return nil, entities.ErrTaxReturnNotPayable
+	}
+	amount := payment.Amount
+	if amount == 0 {
+		amount = ret.TotalDue
+	}
+	if math.Abs(amount-ret.TotalDue) >= 0.005 {
+		return nil, fmt.Errorf("%w: %.2f due", entities.ErrInvalidTaxPayment, ret.TotalDue)
+	}
+	if err := ret.MarkAsPaid(); err != nil {
+		return nil, err
+	}
+	ret.PaidAt = &paidAt
+	ret.TaxPaid += amount
+	ret.CalculateTotalDue()
+	if err := s.repo.SettleReturn(ctx, ret); err != nil {
+		return nil, err
+	}
+	s.postPaymentJournal(ctx, ret, amount, payment.PaidBy)
+	return ret, nil
+}
+
+// postPaymentJournal posts the settlement journal of a tax return payment. A failure is
+// logged and does not undo the payment, which can be journalled by hand.
+func (s *TaxService) postPaymentJournal(ctx context.Context, ret *entities.TaxReturn, amount float64, paidBy string) {
+	if s.journals == nil {
+		return
+	}
+	transactionType := taxJournalPaymentPrefix + string(ret.TaxType)
+	entry, err := s.journals.CreateJournalFromTransaction(ctx, &AutoJournalRequest{
+		SourceModule:    taxJournalSource,
+		SourceID:        ret.ID.String(),
+		TransactionType: transactionType,
+		TransactionDate: *ret.PaidAt,
+		CompanyID:       "1", // Default company
+		CurrencyCode:    "IDR",
+		ExchangeRate:    1.0,
+		Description:     fmt.Sprintf("Payment of tax return %s", ret.ReturnNumber),
+		Reference:       ret.ReturnNumber,
+		TransactionData: map[string]interface{}{
+			"tax_payable_amount": ret.TaxPayable,
+			"penalty_amount":     ret.PenaltyAmount,
+			"interest_amount":    ret.InterestAmount,
+			"cash_amount":        amount,
+		},
+		CreatedBy: paidBy,
+		AutoPost:  true,
+	})
+	if err != nil || entry == nil {
+		log.Printf("[Accounting] Failed to post %s journal for tax return %s: %v", transactionType, ret.ReturnNumber, err)
+		return
+	}
+	ret.JournalEntryID = &entry.ID
+	if err := s.repo.SetReturnJournalEntry(ctx, ret.ID, entry.ID); err != nil {
+		log.Printf("[Accounting] Failed to link journal %s to tax return %s: %v", entry.ID, ret.ReturnNumber, err)
+	}
 }
 
 // --- Reports ---
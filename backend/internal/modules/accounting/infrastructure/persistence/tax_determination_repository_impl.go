@@ -0,0 +1,194 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/accounting/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxDeterminationRepositoryImpl implements repositories.TaxDeterminationRepository.
+type TaxDeterminationRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewTaxDeterminationRepositoryImpl creates a new TaxDeterminationRepositoryImpl.
+func NewTaxDeterminationRepositoryImpl(db *sqlx.DB) repositories.TaxDeterminationRepository {
+	return &TaxDeterminationRepositoryImpl{db: db}
+}
+
+const taxRateVersionColumns = `id, tax_id, rate, tax_base_numerator, tax_base_denominator, valid_from, valid_to,
+	description, created_by, created_at`
+
+const taxRuleSelect = `SELECT r.id, r.transaction_type, r.party_tax_status, r.classification_id, r.tax_id,
+	COALESCE(t.tax_code, '') AS tax_code, r.priority, r.description, r.is_active, r.created_by, r.created_at, r.updated_at
+	FROM tax_determination_rules r
+	LEFT JOIN taxes t ON t.id = r.tax_id`
+
+// CreateRateVersion stores a rate version, closing the open-ended version it follows.
+func (r *TaxDeterminationRepositoryImpl) CreateRateVersion(ctx context.Context, v *entities.TaxRateVersion) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Versions of a tax are added one at a time under the lock of the tax
+	var taxID uuid.ID
+	err = tx.GetContext(ctx, &taxID, `SELECT id FROM taxes WHERE id = $1 FOR UPDATE`, v.TaxID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.ErrTaxNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tax_rate_versions SET valid_to = $2::date - 1
+		WHERE tax_id = $1 AND valid_to IS NULL AND valid_from < $2::date`, v.TaxID, v.ValidFrom); err != nil {
+		return err
+	}
+	var overlaps bool
+	if err := tx.GetContext(ctx, &overlaps, `SELECT EXISTS (SELECT 1 FROM tax_rate_versions
+		WHERE tax_id = $1 AND valid_from <= COALESCE($3::date, 'infinity'::date)
+			AND COALESCE(valid_to, 'infinity'::date) >= $2::date)`, v.TaxID, v.ValidFrom, v.ValidTo); err != nil {
+		return err
+	}
+	if overlaps {
+		return entities.ErrTaxRateOverlap
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tax_rate_versions (id, tax_id, rate, tax_base_numerator,
+			tax_base_denominator, valid_from, valid_to, description, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		v.ID, v.TaxID, v.Rate, v.TaxBaseNumerator, v.TaxBaseDenominator, v.ValidFrom, v.ValidTo, v.Description,
+		v.CreatedBy, v.CreatedAt); err != nil {
+		return err
+	}
+
+	// The tax master shows the rate in force today
+	if _, err := tx.ExecContext(ctx, `UPDATE taxes SET tax_rate = $2, updated_at = NOW()
+		WHERE id = $1 AND $3::date <= CURRENT_DATE AND COALESCE($4::date, 'infinity'::date) >= CURRENT_DATE`,
+		v.TaxID, v.Rate, v.ValidFrom, v.ValidTo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListRateVersions returns the rate versions of a tax, the latest first.
+func (r *TaxDeterminationRepositoryImpl) ListRateVersions(ctx context.Context, taxID uuid.ID) ([]*entities.TaxRateVersion, error) {
+	versions := []*entities.TaxRateVersion{}
+	err := r.db.SelectContext(ctx, &versions, `SELECT `+taxRateVersionColumns+` FROM tax_rate_versions
+		WHERE tax_id = $1 ORDER BY valid_from DESC`, taxID)
+	return versions, err
+}
+
+// GetRateVersion returns the rate version of a tax in force on a date.
+func (r *TaxDeterminationRepositoryImpl) GetRateVersion(ctx context.Context, taxID uuid.ID, date time.Time) (*entities.TaxRateVersion, error) {
+	v := &entities.TaxRateVersion{}
+	err := r.db.GetContext(ctx, v, `SELECT `+taxRateVersionColumns+` FROM tax_rate_versions
+		WHERE tax_id = $1 AND valid_from <= $2::date AND (valid_to IS NULL OR valid_to >= $2::date)
+		ORDER BY valid_from DESC LIMIT 1`, taxID, date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
+
+// CreateRule stores a tax determination rule.
+func (r *TaxDeterminationRepositoryImpl) CreateRule(ctx context.Context, rule *entities.TaxDeterminationRule) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO tax_determination_rules (id, transaction_type, party_tax_status,
+			classification_id, tax_id, priority, description, is_active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		rule.ID, rule.TransactionType, rule.PartyTaxStatus, rule.ClassificationID, rule.TaxID, rule.Priority,
+		rule.Description, rule.IsActive, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt)
+	return ruleError(err)
+}
+
+// UpdateRule updates the conditions, tax and priority of a rule.
+func (r *TaxDeterminationRepositoryImpl) UpdateRule(ctx context.Context, rule *entities.TaxDeterminationRule) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE tax_determination_rules SET transaction_type = $2, party_tax_status = $3,
+			classification_id = $4, tax_id = $5, priority = $6, description = $7, is_active = $8, updated_at = $9
+		WHERE id = $1`,
+		rule.ID, rule.TransactionType, rule.PartyTaxStatus, rule.ClassificationID, rule.TaxID, rule.Priority,
+		rule.Description, rule.IsActive, rule.UpdatedAt)
+	if err != nil {
+		return ruleError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entities.ErrTaxRuleNotFound
+	}
+	return nil
+}
+
+// GetRule returns a tax determination rule.
+func (r *TaxDeterminationRepositoryImpl) GetRule(ctx context.Context, id uuid.ID) (*entities.TaxDeterminationRule, error) {
+	rule := &entities.TaxDeterminationRule{}
+	err := r.db.GetContext(ctx, rule, taxRuleSelect+` WHERE r.id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return rule, err
+}
+
+// ListRules returns the rules of a transaction type, the highest priority first.
+func (r *TaxDeterminationRepositoryImpl) ListRules(ctx context.Context, transactionType string, activeOnly bool) ([]*entities.TaxDeterminationRule, error) {
+	rules := []*entities.TaxDeterminationRule{}
+	err := r.db.SelectContext(ctx, &rules, taxRuleSelect+`
+		WHERE ($1 = '' OR r.transaction_type = $1) AND (NOT $2 OR r.is_active)
+		ORDER BY r.transaction_type, r.priority DESC, r.created_at`, transactionType, activeOnly)
+	return rules, err
+}
+
+// GetPartyTaxStatus returns the tax status of the customer or supplier of a transaction.
+func (r *TaxDeterminationRepositoryImpl) GetPartyTaxStatus(ctx context.Context, transactionType string, partyID uuid.ID) (string, error) {
+	query := `SELECT tax_status FROM customers WHERE id = $1`
+	if transactionType == entities.TaxTransactionPurchase {
+		query = `SELECT tax_status FROM suppliers WHERE id = $1`
+	}
+	var status string
+	err := r.db.GetContext(ctx, &status, query, partyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return status, err
+}
+
+// GetArticleClassifications returns the classification of each article found.
+func (r *TaxDeterminationRepositoryImpl) GetArticleClassifications(ctx context.Context, articleIDs []uuid.ID) (map[uuid.ID]uuid.ID, error) {
+	classifications := make(map[uuid.ID]uuid.ID, len(articleIDs))
+	if len(articleIDs) == 0 {
+		return classifications, nil
+	}
+	values := make([]string, len(articleIDs))
+	for i, id := range articleIDs {
+		values[i] = id.String()
+	}
+	rows := []struct {
+		ID               uuid.ID `db:"id"`
+		ClassificationID uuid.ID `db:"classification_id"`
+	}{}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT id, classification_id FROM articles
+		WHERE id = ANY($1::uuid[]) AND classification_id IS NOT NULL`, pq.Array(values)); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		classifications[row.ID] = row.ClassificationID
+	}
+	return classifications, nil
+}
+
+// ruleError maps a rule naming a tax or classification that does not exist to ErrInvalidTaxRule.
+func ruleError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return fmt.Errorf("%w: the tax or classification does not exist", entities.ErrInvalidTaxRule)
+	}
+	return err
+}
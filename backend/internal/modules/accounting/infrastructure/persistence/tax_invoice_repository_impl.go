@@ -0,0 +1,390 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/accounting/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxInvoiceRepositoryImpl implements repositories.TaxInvoiceRepository.
+type TaxInvoiceRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewTaxInvoiceRepositoryImpl creates a new TaxInvoiceRepositoryImpl.
+func NewTaxInvoiceRepositoryImpl(db *sqlx.DB) repositories.TaxInvoiceRepository {
+	return &TaxInvoiceRepositoryImpl{db: db}
+}
+
+const nsfpRangeColumns = `id, branch_code, tax_year, start_serial, end_serial, next_serial, letter_number, status,
+	created_by, created_at`
+
+const taxInvoiceColumns = `id, faktur_number, nsfp, nsfp_range_id, transaction_code, kind, replaces_id, sales_invoice_id,
+	invoice_number, faktur_date, customer_id, buyer_tax_id, buyer_name, buyer_address, tax_base, vat_amount, luxury_tax_amount,
+	status, cancel_reason, cancelled_by, cancelled_at, exported_at, created_by, created_at, updated_at`
+
+const inputTaxInvoiceColumns = `id, faktur_number, nsfp, transaction_code, replacement, faktur_date, tax_period, tax_year,
+	supplier_tax_id, supplier_name, supplier_address, supplier_id, tax_base, vat_amount, luxury_tax_amount, creditable, status,
+	tax_transaction_id, file_name, imported_by, imported_at`
+
+// CreateNSFPRange registers an NSFP range.
+func (r *TaxInvoiceRepositoryImpl) CreateNSFPRange(ctx context.Context, rng *entities.NSFPRange) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Ranges of a branch and year are registered one at a time so two overlapping
+	// allocations cannot slip in together
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('nsfp_ranges:' || $1 || ':' || $2::text))`,
+		rng.BranchCode, rng.TaxYear); err != nil {
+		return err
+	}
+	var overlaps bool
+	if err := tx.GetContext(ctx, &overlaps, `SELECT EXISTS (SELECT 1 FROM nsfp_ranges
+		WHERE branch_code = $1 AND tax_year = $2 AND start_serial <= $4 AND end_serial >= $3)`,
+		rng.BranchCode, rng.TaxYear, rng.StartSerial, rng.EndSerial); err != nil {
+		return err
+	}
+	if overlaps {
+		return entities.ErrNSFPRangeOverlap
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO nsfp_ranges (id, branch_code, tax_year, start_serial, end_serial, next_serial,
+			letter_number, status, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		rng.ID, rng.BranchCode, rng.TaxYear, rng.StartSerial, rng.EndSerial, rng.NextSerial, rng.LetterNumber, rng.Status,
+		rng.CreatedBy, rng.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListNSFPRanges returns the NSFP ranges of a year, of every year when zero.
+func (r *TaxInvoiceRepositoryImpl) ListNSFPRanges(ctx context.Context, year int) ([]*entities.NSFPRange, error) {
+	ranges := []*entities.NSFPRange{}
+	err := r.db.SelectContext(ctx, &ranges, `SELECT `+nsfpRangeColumns+` FROM nsfp_ranges
+		WHERE $1 = 0 OR tax_year = $1 ORDER BY tax_year DESC, branch_code, start_serial`, year)
+	return ranges, err
+}
+
+// GetTaxInvoiceSource returns a sales invoice with its buyer and items.
+func (r *TaxInvoiceRepositoryImpl) GetTaxInvoiceSource(ctx context.Context, salesInvoiceID uuid.ID) (*entities.TaxInvoiceSource, error) {
+	src := &entities.TaxInvoiceSource{SalesInvoiceID: salesInvoiceID}
+	err := r.db.QueryRowxContext(ctx, `SELECT COALESCE(si.invoice_number, ''), si.invoice_date, c.id, COALESCE(c.tax_id, ''),
+			COALESCE(c.name, ''), COALESCE(c.address, ''), si.total_amount, si.tax_amount
+		FROM sales_invoices si
+		LEFT JOIN sales_orders so ON so.id = si.sales_order_id
+		LEFT JOIN customers c ON c.id = COALESCE(si.customer_id, so.customer_id)
+		WHERE si.id = $1`, salesInvoiceID).Scan(&src.InvoiceNumber, &src.InvoiceDate, &src.CustomerID, &src.BuyerTaxID,
+		&src.BuyerName, &src.BuyerAddress, &src.TotalAmount, &src.TaxAmount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	src.Items = []*entities.TaxInvoiceItem{}
+	err = r.db.SelectContext(ctx, &src.Items, `SELECT COALESCE(a.code, a.barcode, '') AS item_code,
+			COALESCE(a.name, 'Item') AS item_name, i.unit_price, i.quantity, i.total_price
+		FROM sales_invoice_items i
+		LEFT JOIN articles a ON a.id = i.article_id
+		WHERE i.sales_invoice_id = $1
+		ORDER BY i.created_at, i.id`, salesInvoiceID)
+	return src, err
+}
+
+// CreateTaxInvoice stores a normal tax invoice numbered with the next NSFP of its year.
+func (r *TaxInvoiceRepositoryImpl) CreateTaxInvoice(ctx context.Context, inv *entities.TaxInvoice) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The oldest range of the year with numbers left is used up first
+	rng := &entities.NSFPRange{}
+	err = tx.GetContext(ctx, rng, `SELECT `+nsfpRangeColumns+` FROM nsfp_ranges
+		WHERE tax_year = $1 AND status = 'active' AND next_serial <= end_serial
+		ORDER BY created_at, start_serial LIMIT 1 FOR UPDATE`, inv.FakturDate.Year())
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.ErrNSFPExhausted
+	}
+	if err != nil {
+		return err
+	}
+	serial := rng.NextSerial
+	if _, err := tx.ExecContext(ctx, `UPDATE nsfp_ranges SET next_serial = next_serial + 1,
+			status = CASE WHEN next_serial + 1 > end_serial THEN 'exhausted' ELSE status END
+		WHERE id = $1`, rng.ID); err != nil {
+		return err
+	}
+
+	inv.NSFPRangeID = rng.ID
+	inv.NSFP = rng.Format(serial)
+	inv.FakturNumber = inv.FormatFakturNumber()
+	if err := insertTaxInvoice(ctx, tx, inv); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplaceTaxInvoice stores a replacement and marks the tax invoice it replaces replaced.
+func (r *TaxInvoiceRepositoryImpl) ReplaceTaxInvoice(ctx context.Context, original, replacement *entities.TaxInvoice) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE tax_invoices SET status = 'replaced', updated_at = $2
+		WHERE id = $1 AND status = 'active'`, original.ID, replacement.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entities.ErrTaxInvoiceNotActive
+	}
+	if err := insertTaxInvoice(ctx, tx, replacement); err != nil {
+		return err
+	}
+	original.Status = entities.TaxInvoiceReplaced
+	return tx.Commit()
+}
+
+func insertTaxInvoice(ctx context.Context, tx *sqlx.Tx, inv *entities.TaxInvoice) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO tax_invoices (id, faktur_number, nsfp, nsfp_range_id, transaction_code, kind,
+			replaces_id, sales_invoice_id, invoice_number, faktur_date, customer_id, buyer_tax_id, buyer_name, buyer_address,
+			tax_base, vat_amount, luxury_tax_amount, status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $20)`,
+		inv.ID, inv.FakturNumber, inv.NSFP, inv.NSFPRangeID, inv.TransactionCode, inv.Kind, inv.ReplacesID, inv.SalesInvoiceID,
+		inv.InvoiceNumber, inv.FakturDate, inv.CustomerID, inv.BuyerTaxID, inv.BuyerName, inv.BuyerAddress, inv.TaxBase,
+		inv.VATAmount, inv.LuxuryTaxAmount, inv.Status, inv.CreatedBy, inv.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return entities.ErrTaxInvoiceExists
+		}
+		return err
+	}
+	for _, item := range inv.Items {
+		item.TaxInvoiceID = inv.ID
+		if _, err := tx.ExecContext(ctx, `INSERT INTO tax_invoice_items (id, tax_invoice_id, line_number, item_code, item_name,
+				unit_price, quantity, total_price, discount, tax_base, vat_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			item.ID, item.TaxInvoiceID, item.LineNumber, item.ItemCode, item.ItemName, item.UnitPrice, item.Quantity,
+			item.TotalPrice, item.Discount, item.TaxBase, item.VATAmount); err != nil {
+			return err
+		}
+	}
+	inv.UpdatedAt = inv.CreatedAt
+	return nil
+}
+
+// CancelTaxInvoice cancels a tax invoice in force.
+func (r *TaxInvoiceRepositoryImpl) CancelTaxInvoice(ctx context.Context, inv *entities.TaxInvoice) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE tax_invoices SET status = 'cancelled', cancel_reason = $2, cancelled_by = $3,
+			cancelled_at = $4, updated_at = $4
+		WHERE id = $1 AND status = 'active'`, inv.ID, inv.CancelReason, inv.CancelledBy, inv.CancelledAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entities.ErrTaxInvoiceNotActive
+	}
+	inv.UpdatedAt = *inv.CancelledAt
+	return nil
+}
+
+// GetTaxInvoice returns a tax invoice with its items.
+func (r *TaxInvoiceRepositoryImpl) GetTaxInvoice(ctx context.Context, id uuid.ID) (*entities.TaxInvoice, error) {
+	inv := &entities.TaxInvoice{}
+	err := r.db.GetContext(ctx, inv, `SELECT `+taxInvoiceColumns+` FROM tax_invoices WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadItems(ctx, []*entities.TaxInvoice{inv}); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// ListTaxInvoices returns the tax invoices of a faktur date range.
+func (r *TaxInvoiceRepositoryImpl) ListTaxInvoices(ctx context.Context, filter repositories.TaxInvoiceFilter) ([]*entities.TaxInvoice, error) {
+	invoices := []*entities.TaxInvoice{}
+	err := r.db.SelectContext(ctx, &invoices, `SELECT `+taxInvoiceColumns+` FROM tax_invoices
+		WHERE faktur_date BETWEEN $1 AND $2 AND ($3 = '' OR status = $3)
+		ORDER BY faktur_date, nsfp, created_at`, filter.From, filter.To, filter.Status)
+	if err != nil || !filter.WithItems {
+		return invoices, err
+	}
+	return invoices, r.loadItems(ctx, invoices)
+}
+
+func (r *TaxInvoiceRepositoryImpl) loadItems(ctx context.Context, invoices []*entities.TaxInvoice) error {
+	if len(invoices) == 0 {
+		return nil
+	}
+	byID := make(map[uuid.ID]*entities.TaxInvoice, len(invoices))
+	ids := make([]string, 0, len(invoices))
+	for _, inv := range invoices {
+		inv.Items = []*entities.TaxInvoiceItem{}
+		byID[inv.ID] = inv
+		ids = append(ids, inv.ID.String())
+	}
+	var items []*entities.TaxInvoiceItem
+	if err := r.db.SelectContext(ctx, &items, `SELECT id, tax_invoice_id, line_number, item_code, item_name, unit_price,
+			quantity, total_price, discount, tax_base, vat_amount
+		FROM tax_invoice_items WHERE tax_invoice_id = ANY($1::uuid[]) ORDER BY line_number`, pq.Array(ids)); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if inv := byID[item.TaxInvoiceID]; inv != nil {
+			inv.Items = append(inv.Items, item)
+		}
+	}
+	return nil
+}
+
+// MarkExported records when tax invoices were exported.
+func (r *TaxInvoiceRepositoryImpl) MarkExported(ctx context.Context, ids []uuid.ID, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE tax_invoices SET exported_at = $2 WHERE id = ANY($1::uuid[])`, pq.Array(values), at)
+	return err
+}
+
+// ImportInputTaxInvoices stores supplier fakturs and books their creditable VAT.
+func (r *TaxInvoiceRepositoryImpl) ImportInputTaxInvoices(ctx context.Context, invoices []*entities.InputTaxInvoice) (*entities.InputTaxImportResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &entities.InputTaxImportResult{InputTaxes: []*entities.InputTaxInvoice{}}
+	for _, inv := range invoices {
+		var existing struct {
+			ID               uuid.ID  `db:"id"`
+			TaxTransactionID *uuid.ID `db:"tax_transaction_id"`
+		}
+		err := tx.GetContext(ctx, &existing, `SELECT id, tax_transaction_id FROM input_tax_invoices
+			WHERE nsfp = $1 AND status = 'active' FOR UPDATE`, inv.NSFP)
+		found := err == nil
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if found {
+			if !inv.Replacement {
+				result.Skipped++
+				result.SkippedFakturs = append(result.SkippedFakturs, inv.FakturNumber)
+				continue
+			}
+			// The replaced faktur no longer credits its VAT
+			if _, err := tx.ExecContext(ctx, `UPDATE input_tax_invoices SET status = 'replaced', tax_transaction_id = NULL
+				WHERE id = $1`, existing.ID); err != nil {
+				return nil, err
+			}
+			if existing.TaxTransactionID != nil {
+				if _, err := tx.ExecContext(ctx, `DELETE FROM tax_transactions WHERE id = $1`, *existing.TaxTransactionID); err != nil {
+					return nil, err
+				}
+			}
+			result.Replaced++
+		} else {
+			result.Created++
+		}
+
+		var supplierID uuid.ID
+		err = tx.GetContext(ctx, &supplierID, `SELECT id FROM suppliers
+			WHERE regexp_replace(COALESCE(tax_id, ''), '[^0-9]', '', 'g') = $1 ORDER BY created_at LIMIT 1`, inv.SupplierTaxID)
+		switch {
+		case err == nil:
+			inv.SupplierID = &supplierID
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, err
+		}
+
+		if inv.Creditable && inv.VATAmount > 0 {
+			if err := bookInputTax(ctx, tx, inv); err != nil {
+				return nil, err
+			}
+			if inv.TaxTransactionID != nil {
+				result.CreditableVAT += inv.VATAmount
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO input_tax_invoices (`+inputTaxInvoiceColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`,
+			inv.ID, inv.FakturNumber, inv.NSFP, inv.TransactionCode, inv.Replacement, inv.FakturDate, inv.TaxPeriod, inv.TaxYear,
+			inv.SupplierTaxID, inv.SupplierName, inv.SupplierAddress, inv.SupplierID, inv.TaxBase, inv.VATAmount,
+			inv.LuxuryTaxAmount, inv.Creditable, inv.Status, inv.TaxTransactionID, inv.FileName, inv.ImportedBy,
+			inv.ImportedAt); err != nil {
+			return nil, err
+		}
+		result.InputTaxes = append(result.InputTaxes, inv)
+	}
+	return result, tx.Commit()
+}
+
+// bookInputTax records the creditable VAT of a supplier faktur as a PURCHASE tax
+// transaction of the tax period against the PPN tax in effect on the faktur date.
+func bookInputTax(ctx context.Context, tx *sqlx.Tx, inv *entities.InputTaxInvoice) error {
+	var taxID uuid.ID
+	err := tx.GetContext(ctx, &taxID, `SELECT id FROM taxes
+		WHERE tax_type IN ('PPN', 'VAT') AND is_active AND effective_date <= $1 AND (expiry_date IS NULL OR expiry_date >= $1)
+		ORDER BY tax_type = 'PPN' DESC, effective_date DESC LIMIT 1`, inv.FakturDate)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		log.Printf("[Accounting] No PPN tax in effect on %s; input VAT of faktur %s not booked",
+			inv.FakturDate.Format("2006-01-02"), inv.FakturNumber)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	// Input VAT is credited in the tax period the faktur is reported in
+	period := time.Date(inv.TaxYear, time.Month(inv.TaxPeriod), 1, 0, 0, 0, 0, time.UTC)
+	supplierID := ""
+	if inv.SupplierID != nil {
+		supplierID = inv.SupplierID.String()
+	}
+	id := uuid.New()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tax_transactions (id, tax_id, transaction_date, transaction_type,
+			base_amount, tax_amount, total_amount, reference_type, reference_id, reference_number, supplier_id, created_by)
+		VALUES ($1, $2, $3, 'PURCHASE', $4, $5, $6, 'INPUT_TAX_INVOICE', $7, $8, $9, $10)`,
+		id, taxID, period, inv.TaxBase, inv.VATAmount, inv.TaxBase+inv.VATAmount, inv.ID.String(), inv.FakturNumber,
+		supplierID, inv.ImportedBy); err != nil {
+		return err
+	}
+	inv.TaxTransactionID = &id
+	return nil
+}
+
+// ListInputTaxInvoices returns the input tax invoices of a tax period; zero values select
+// every year or period.
+func (r *TaxInvoiceRepositoryImpl) ListInputTaxInvoices(ctx context.Context, year, period int) ([]*entities.InputTaxInvoice, error) {
+	invoices := []*entities.InputTaxInvoice{}
+	err := r.db.SelectContext(ctx, &invoices, `SELECT `+inputTaxInvoiceColumns+` FROM input_tax_invoices
+		WHERE ($1 = 0 OR tax_year = $1) AND ($2 = 0 OR tax_period = $2)
+		ORDER BY tax_year DESC, tax_period DESC, faktur_date, nsfp`, year, period)
+	return invoices, err
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"malaka/internal/modules/accounting/domain/entities"
 	"malaka/internal/modules/accounting/domain/repositories"
@@ -31,13 +33,28 @@ func (r *TaxRepositoryImpl) Create(ctx context.Context, tax *entities.Tax) error
 	tax.CreatedAt = now
 	tax.UpdatedAt = now
 
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `INSERT INTO taxes (id, tax_code, tax_name, tax_type, tax_rate, description, is_active, effective_date, expiry_date, tax_account_id, expense_account_id, company_id, created_by, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
-	_, err := r.db.ExecContext(ctx, query,
+	if _, err := tx.ExecContext(ctx, query,
 		tax.ID, tax.TaxCode, tax.TaxName, tax.TaxType, tax.TaxRate, tax.Description,
 		tax.IsActive, tax.EffectiveDate, tax.ExpiryDate, tax.TaxAccountID, tax.ExpenseAccountID,
-		tax.CompanyID, tax.CreatedBy, tax.CreatedAt, tax.UpdatedAt)
-	return err
+		tax.CompanyID, tax.CreatedBy, tax.CreatedAt, tax.UpdatedAt); err != nil {
+		return err
+	}
+
+	// The rate of a new tax is its first rate version; later rates are added as versions
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tax_rate_versions (id, tax_id, rate, valid_from, valid_to, description, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, 'Rate of the tax master', $6, $7)`,
+		uuid.New(), tax.ID, tax.TaxRate, tax.EffectiveDate, tax.ExpiryDate, tax.CreatedBy, tax.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *TaxRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.Tax, error) {
@@ -93,7 +110,7 @@ func (r *TaxRepositoryImpl) CreateTransaction(ctx context.Context, tx *entities.
 
 func (r *TaxRepositoryImpl) GetTransactionByID(ctx context.Context, id uuid.ID) (*entities.TaxTransaction, error) {
 	var tx entities.TaxTransaction
-	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE id = $1`
+	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, tax_return_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE id = $1`
 	err := r.db.GetContext(ctx, &tx, query, id)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -103,14 +120,14 @@ func (r *TaxRepositoryImpl) GetTransactionByID(ctx context.Context, id uuid.ID)
 
 func (r *TaxRepositoryImpl) GetTransactionsByTax(ctx context.Context, taxID uuid.ID) ([]*entities.TaxTransaction, error) {
 	var txs []*entities.TaxTransaction
-	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE tax_id = $1 ORDER BY transaction_date DESC`
+	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, tax_return_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE tax_id = $1 ORDER BY transaction_date DESC`
 	err := r.db.SelectContext(ctx, &txs, query, taxID)
 	return txs, err
 }
 
 func (r *TaxRepositoryImpl) UpdateTransaction(ctx context.Context, tx *entities.TaxTransaction) error {
 	tx.UpdatedAt = time.Now()
-	query := `UPDATE tax_transactions SET tax_id=$1, transaction_date=$2, transaction_type=$3, base_amount=$4, tax_amount=$5, total_amount=$6, reference_type=$7, reference_id=$8, reference_number=$9, customer_id=$10, supplier_id=$11, journal_entry_id=$12, company_id=$13, updated_at=$14 WHERE id=$15`
+	query := `UPDATE tax_transactions SET tax_id=$1, transaction_date=$2, transaction_type=$3, base_amount=$4, tax_amount=$5, total_amount=$6, reference_type=$7, reference_id=$8, reference_number=$9, customer_id=$10, supplier_id=$11, journal_entry_id=$12, company_id=$13, updated_at=$14 WHERE id=$15 AND tax_return_id IS NULL`
 	_, err := r.db.ExecContext(ctx, query,
 		tx.TaxID, tx.TransactionDate, tx.TransactionType, tx.BaseAmount, tx.TaxAmount, tx.TotalAmount,
 		tx.ReferenceType, tx.ReferenceID, tx.ReferenceNumber, tx.CustomerID, tx.SupplierID, tx.JournalEntryID,
@@ -119,7 +136,7 @@ func (r *TaxRepositoryImpl) UpdateTransaction(ctx context.Context, tx *entities.
 }
 
 func (r *TaxRepositoryImpl) DeleteTransaction(ctx context.Context, id uuid.ID) error {
-	_, err := r.db.ExecContext(ctx, `DELETE FROM tax_transactions WHERE id = $1`, id)
+	_, err := r.db.ExecContext(ctx, `DELETE FROM tax_transactions WHERE id = $1 AND tax_return_id IS NULL`, id)
 	return err
 }
 
@@ -133,19 +150,20 @@ func (r *TaxRepositoryImpl) CreateReturn(ctx context.Context, ret *entities.TaxR
 	ret.CreatedAt = now
 	ret.UpdatedAt = now
 
-	query := `INSERT INTO tax_returns (id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, submitted_by, submitted_at, paid_at, company_id, created_by, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24)`
+	query := `INSERT INTO tax_returns (id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, submitted_by, submitted_at, paid_at, company_id, created_by, created_at, updated_at, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29)`
 	_, err := r.db.ExecContext(ctx, query,
 		ret.ID, ret.ReturnNumber, ret.TaxType, ret.PeriodStart, ret.PeriodEnd, ret.FilingDate, ret.DueDate,
 		ret.Status, ret.TotalSales, ret.TotalPurchases, ret.OutputTax, ret.InputTax, ret.TaxPayable, ret.TaxPaid,
 		ret.PenaltyAmount, ret.InterestAmount, ret.TotalDue, ret.SubmittedBy, ret.SubmittedAt, ret.PaidAt,
-		ret.CompanyID, ret.CreatedBy, ret.CreatedAt, ret.UpdatedAt)
+		ret.CompanyID, ret.CreatedBy, ret.CreatedAt, ret.UpdatedAt, ret.PaymentDueDate, ret.OverpaymentBroughtForward,
+		ret.OverpaymentCarriedForward, ret.TransactionCount, ret.JournalEntryID)
 	return err
 }
 
 func (r *TaxRepositoryImpl) GetReturnByID(ctx context.Context, id uuid.ID) (*entities.TaxReturn, error) {
 	var ret entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE id = $1`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE id = $1`
 	err := r.db.GetContext(ctx, &ret, query, id)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -155,20 +173,26 @@ func (r *TaxRepositoryImpl) GetReturnByID(ctx context.Context, id uuid.ID) (*ent
 
 func (r *TaxRepositoryImpl) GetReturnsByCompany(ctx context.Context, companyID string) ([]*entities.TaxReturn, error) {
 	var rets []*entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE company_id = $1 ORDER BY due_date DESC`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE company_id = $1 ORDER BY due_date DESC`
 	err := r.db.SelectContext(ctx, &rets, query, companyID)
 	return rets, err
 }
 
 func (r *TaxRepositoryImpl) UpdateReturn(ctx context.Context, ret *entities.TaxReturn) error {
 	ret.UpdatedAt = time.Now()
-	query := `UPDATE tax_returns SET return_number=$1, tax_type=$2, period_start=$3, period_end=$4, filing_date=$5, due_date=$6, status=$7, total_sales=$8, total_purchases=$9, output_tax=$10, input_tax=$11, tax_payable=$12, tax_paid=$13, penalty_amount=$14, interest_amount=$15, total_due=$16, submitted_by=$17, submitted_at=$18, paid_at=$19, company_id=$20, updated_at=$21 WHERE id=$22`
-	_, err := r.db.ExecContext(ctx, query,
-		ret.ReturnNumber, ret.TaxType, ret.PeriodStart, ret.PeriodEnd, ret.FilingDate, ret.DueDate,
+	_, err := r.db.ExecContext(ctx, updateTaxReturnQuery, updateTaxReturnArgs(ret)...)
+	return err
+}
+
+// updateTaxReturnQuery writes every field of a tax return but its creation.
+const updateTaxReturnQuery = `UPDATE tax_returns SET return_number=$1, tax_type=$2, period_start=$3, period_end=$4, filing_date=$5, due_date=$6, status=$7, total_sales=$8, total_purchases=$9, output_tax=$10, input_tax=$11, tax_payable=$12, tax_paid=$13, penalty_amount=$14, interest_amount=$15, total_due=$16, submitted_by=$17, submitted_at=$18, paid_at=$19, company_id=$20, updated_at=$21, payment_due_date=$22, overpayment_brought_forward=$23, overpayment_carried_forward=$24, transaction_count=$25, journal_entry_id=$26 WHERE id=$27`
+
+func updateTaxReturnArgs(ret *entities.TaxReturn) []interface{} {
+	return []interface{}{ret.ReturnNumber, ret.TaxType, ret.PeriodStart, ret.PeriodEnd, ret.FilingDate, ret.DueDate,
 		ret.Status, ret.TotalSales, ret.TotalPurchases, ret.OutputTax, ret.InputTax, ret.TaxPayable, ret.TaxPaid,
 		ret.PenaltyAmount, ret.InterestAmount, ret.TotalDue, ret.SubmittedBy, ret.SubmittedAt, ret.PaidAt,
-		ret.CompanyID, ret.UpdatedAt, ret.ID)
-	return err
+		ret.CompanyID, ret.UpdatedAt, ret.PaymentDueDate, ret.OverpaymentBroughtForward, ret.OverpaymentCarriedForward,
+		ret.TransactionCount, ret.JournalEntryID, ret.ID}
 }
 
 func (r *TaxRepositoryImpl) DeleteReturn(ctx context.Context, id uuid.ID) error {
@@ -221,35 +245,35 @@ func (r *TaxRepositoryImpl) GetActiveByCompany(ctx context.Context, companyID st
 
 func (r *TaxRepositoryImpl) GetTransactionsByPeriod(ctx context.Context, companyID string, startDate, endDate time.Time) ([]*entities.TaxTransaction, error) {
 	var txs []*entities.TaxTransaction
-	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE transaction_date >= $1 AND transaction_date <= $2 ORDER BY transaction_date DESC`
+	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, tax_return_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE transaction_date >= $1 AND transaction_date <= $2 ORDER BY transaction_date DESC`
 	err := r.db.SelectContext(ctx, &txs, query, startDate, endDate)
 	return txs, err
 }
 
 func (r *TaxRepositoryImpl) GetTransactionsByType(ctx context.Context, companyID string, transactionType string) ([]*entities.TaxTransaction, error) {
 	var txs []*entities.TaxTransaction
-	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE transaction_type = $1 ORDER BY transaction_date DESC`
+	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, tax_return_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE transaction_type = $1 ORDER BY transaction_date DESC`
 	err := r.db.SelectContext(ctx, &txs, query, transactionType)
 	return txs, err
 }
 
 func (r *TaxRepositoryImpl) GetTransactionsByReference(ctx context.Context, referenceType, referenceID string) ([]*entities.TaxTransaction, error) {
 	var txs []*entities.TaxTransaction
-	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE reference_type = $1 AND reference_id = $2 ORDER BY transaction_date DESC`
+	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, tax_return_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE reference_type = $1 AND reference_id = $2 ORDER BY transaction_date DESC`
 	err := r.db.SelectContext(ctx, &txs, query, referenceType, referenceID)
 	return txs, err
 }
 
 func (r *TaxRepositoryImpl) GetTransactionsByCustomer(ctx context.Context, customerID string) ([]*entities.TaxTransaction, error) {
 	var txs []*entities.TaxTransaction
-	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE customer_id = $1 ORDER BY transaction_date DESC`
+	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, tax_return_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE customer_id = $1 ORDER BY transaction_date DESC`
 	err := r.db.SelectContext(ctx, &txs, query, customerID)
 	return txs, err
 }
 
 func (r *TaxRepositoryImpl) GetTransactionsBySupplier(ctx context.Context, supplierID string) ([]*entities.TaxTransaction, error) {
 	var txs []*entities.TaxTransaction
-	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE supplier_id = $1 ORDER BY transaction_date DESC`
+	query := `SELECT id, tax_id, transaction_date, COALESCE(transaction_type,'') as transaction_type, base_amount, tax_amount, total_amount, COALESCE(reference_type,'') as reference_type, COALESCE(reference_id,'') as reference_id, COALESCE(reference_number,'') as reference_number, COALESCE(customer_id,'') as customer_id, COALESCE(supplier_id,'') as supplier_id, journal_entry_id, tax_return_id, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_transactions WHERE supplier_id = $1 ORDER BY transaction_date DESC`
 	err := r.db.SelectContext(ctx, &txs, query, supplierID)
 	return txs, err
 }
@@ -258,7 +282,7 @@ func (r *TaxRepositoryImpl) GetTransactionsBySupplier(ctx context.Context, suppl
 
 func (r *TaxRepositoryImpl) GetReturnByNumber(ctx context.Context, returnNumber string) (*entities.TaxReturn, error) {
 	var ret entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE return_number = $1`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE return_number = $1`
 	err := r.db.GetContext(ctx, &ret, query, returnNumber)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -268,35 +292,35 @@ func (r *TaxRepositoryImpl) GetReturnByNumber(ctx context.Context, returnNumber
 
 func (r *TaxRepositoryImpl) GetReturnsByType(ctx context.Context, companyID string, taxType entities.TaxType) ([]*entities.TaxReturn, error) {
 	var rets []*entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE tax_type = $1 ORDER BY due_date DESC`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE tax_type = $1 ORDER BY due_date DESC`
 	err := r.db.SelectContext(ctx, &rets, query, taxType)
 	return rets, err
 }
 
 func (r *TaxRepositoryImpl) GetReturnsByStatus(ctx context.Context, companyID string, status entities.TaxStatus) ([]*entities.TaxReturn, error) {
 	var rets []*entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE status = $1 ORDER BY due_date DESC`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE status = $1 ORDER BY due_date DESC`
 	err := r.db.SelectContext(ctx, &rets, query, status)
 	return rets, err
 }
 
 func (r *TaxRepositoryImpl) GetReturnsByPeriod(ctx context.Context, companyID string, startDate, endDate time.Time) ([]*entities.TaxReturn, error) {
 	var rets []*entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE period_start >= $1 AND period_end <= $2 ORDER BY due_date DESC`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE period_start >= $1 AND period_end <= $2 ORDER BY due_date DESC`
 	err := r.db.SelectContext(ctx, &rets, query, startDate, endDate)
 	return rets, err
 }
 
 func (r *TaxRepositoryImpl) GetOverdueReturns(ctx context.Context, companyID string) ([]*entities.TaxReturn, error) {
 	var rets []*entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE due_date < NOW() AND status NOT IN ('PAID') ORDER BY due_date ASC`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE due_date < NOW() AND status NOT IN ('PAID') ORDER BY due_date ASC`
 	err := r.db.SelectContext(ctx, &rets, query)
 	return rets, err
 }
 
 func (r *TaxRepositoryImpl) GetDueReturns(ctx context.Context, companyID string, dueDate time.Time) ([]*entities.TaxReturn, error) {
 	var rets []*entities.TaxReturn
-	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE due_date <= $1 AND status IN ('DRAFT','SUBMITTED') ORDER BY due_date ASC`
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE due_date <= $1 AND status IN ('DRAFT','SUBMITTED') ORDER BY due_date ASC`
 	err := r.db.SelectContext(ctx, &rets, query, dueDate)
 	return rets, err
 }
@@ -364,6 +388,121 @@ func (r *TaxRepositoryImpl) GenerateReturn(ctx context.Context, companyID string
 	return ret, err
 }
 
+// --- Tax Return Computation ---
+
+// returnTransactionsFilter selects the transactions of taxes of the types $1 dated in the
+// period $2-$3. Output VAT of an invoice whose faktur was cancelled without replacement is
+// not reported, as in the VAT report.
+const returnTransactionsFilter = `t.tax_type = ANY($1) AND tt.transaction_date >= $2 AND tt.transaction_date <= $3
+		AND NOT (tt.transaction_type = 'SALE' AND tt.reference_type = 'SALES_INVOICE' AND EXISTS (SELECT 1 FROM tax_invoices ti
+			WHERE ti.sales_invoice_id::text = tt.reference_id AND ti.status = 'cancelled'
+			AND NOT EXISTS (SELECT 1 FROM tax_invoices a WHERE a.sales_invoice_id = ti.sales_invoice_id AND a.status = 'active')))`
+
+// returnTotalsColumns sums tax transactions into return totals: sales carry output tax,
+// purchases input tax and withholdings the tax withheld from the gross amount.
+const returnTotalsColumns = `COALESCE(SUM(CASE WHEN tt.transaction_type = 'SALE' THEN tt.base_amount END),0) AS total_sales,
+		COALESCE(SUM(CASE WHEN tt.transaction_type IN ('PURCHASE','WITHHOLDING') THEN tt.base_amount END),0) AS total_purchases,
+		COALESCE(SUM(CASE WHEN tt.transaction_type IN ('SALE','WITHHOLDING') THEN tt.tax_amount END),0) AS output_tax,
+		COALESCE(SUM(CASE WHEN tt.transaction_type = 'PURCHASE' THEN tt.tax_amount END),0) AS input_tax,
+		COUNT(*) AS transaction_count`
+
+func taxTypeStrings(taxTypes []entities.TaxType) []string {
+	values := make([]string, len(taxTypes))
+	for i, taxType := range taxTypes {
+		values[i] = string(taxType)
+	}
+	return values
+}
+
+// GetReturnByPeriod returns the return of a tax type starting on a period start, nil when
+// there is none.
+func (r *TaxRepositoryImpl) GetReturnByPeriod(ctx context.Context, taxType entities.TaxType, periodStart time.Time) (*entities.TaxReturn, error) {
+	var ret entities.TaxReturn
+	query := `SELECT id, return_number, tax_type, period_start, period_end, filing_date, due_date, status, total_sales, total_purchases, output_tax, input_tax, tax_payable, tax_paid, penalty_amount, interest_amount, total_due, payment_due_date, overpayment_brought_forward, overpayment_carried_forward, transaction_count, journal_entry_id, COALESCE(submitted_by,'') as submitted_by, submitted_at, paid_at, COALESCE(company_id,'') as company_id, COALESCE(created_by,'') as created_by, created_at, updated_at FROM tax_returns WHERE tax_type = $1 AND period_start = $2`
+	err := r.db.GetContext(ctx, &ret, query, taxType, periodStart)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &ret, err
+}
+
+// GetReturnTotals sums the transactions of a period not filed in a return yet.
+func (r *TaxRepositoryImpl) GetReturnTotals(ctx context.Context, taxTypes []entities.TaxType, periodStart, periodEnd time.Time) (*entities.TaxReturnTotals, error) {
+	var totals entities.TaxReturnTotals
+	query := `SELECT ` + returnTotalsColumns + ` FROM tax_transactions tt JOIN taxes t ON t.id = tt.tax_id
+		WHERE tt.tax_return_id IS NULL AND ` + returnTransactionsFilter
+	if err := r.db.GetContext(ctx, &totals, query, pq.Array(taxTypeStrings(taxTypes)), periodStart, periodEnd); err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// FileReturn files a draft return: the unfiled transactions of its period are locked to
+// it, and the return is refused when they no longer add up to its totals.
+func (r *TaxRepositoryImpl) FileReturn(ctx context.Context, ret *entities.TaxReturn, taxTypes []entities.TaxType) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status entities.TaxStatus
+	err = tx.GetContext(ctx, &status, `SELECT status FROM tax_returns WHERE id = $1 FOR UPDATE`, ret.ID)
+	switch {
+	case err == sql.ErrNoRows:
+		return entities.ErrTaxReturnNotFound
+	case err != nil:
+		return err
+	case status != entities.TaxStatusDraft:
+		return entities.ErrTaxReturnNotDraft
+	}
+
+	if len(taxTypes) > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE tax_transactions tt SET tax_return_id = $4, updated_at = NOW()
+			FROM taxes t WHERE t.id = tt.tax_id AND tt.tax_return_id IS NULL AND `+returnTransactionsFilter,
+			pq.Array(taxTypeStrings(taxTypes)), ret.PeriodStart, ret.PeriodEnd, ret.ID); err != nil {
+			return err
+		}
+		var filed entities.TaxReturnTotals
+		if err := tx.GetContext(ctx, &filed, `SELECT `+returnTotalsColumns+`
+			FROM tax_transactions tt WHERE tt.tax_return_id = $1`, ret.ID); err != nil {
+			return err
+		}
+		if filed.TransactionCount != ret.TransactionCount || math.Abs(filed.OutputTax-ret.OutputTax) >= 0.005 ||
+			math.Abs(filed.InputTax-ret.InputTax) >= 0.005 {
+			return entities.ErrTaxReturnOutdated
+		}
+	}
+
+	ret.UpdatedAt = time.Now()
+	if _, err := tx.ExecContext(ctx, updateTaxReturnQuery, updateTaxReturnArgs(ret)...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SettleReturn records the payment of a filed return.
+func (r *TaxRepositoryImpl) SettleReturn(ctx context.Context, ret *entities.TaxReturn) error {
+	ret.UpdatedAt = time.Now()
+	result, err := r.db.ExecContext(ctx, `UPDATE tax_returns SET status=$1, tax_paid=$2, interest_amount=$3, total_due=$4,
+		paid_at=$5, updated_at=$6 WHERE id=$7 AND status='SUBMITTED'`,
+		ret.Status, ret.TaxPaid, ret.InterestAmount, ret.TotalDue, ret.PaidAt, ret.UpdatedAt, ret.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return entities.ErrTaxReturnNotPayable
+	}
+	return nil
+}
+
+// SetReturnJournalEntry links a return to the journal entry of its payment.
+func (r *TaxRepositoryImpl) SetReturnJournalEntry(ctx context.Context, returnID, journalEntryID uuid.ID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE tax_returns SET journal_entry_id = $1, updated_at = NOW() WHERE id = $2`,
+		journalEntryID, returnID)
+	return err
+}
+
 // --- Reporting ---
 
 func (r *TaxRepositoryImpl) GetTaxReport(ctx context.Context, companyID string, taxType entities.TaxType, startDate, endDate time.Time) (*entities.TaxReport, error) {
@@ -404,7 +543,12 @@ func (r *TaxRepositoryImpl) GetTaxComplianceReport(ctx context.Context, companyI
 
 func (r *TaxRepositoryImpl) GetVATReport(ctx context.Context, companyID string, startDate, endDate time.Time) (map[string]float64, error) {
 	result := make(map[string]float64)
-	query := `SELECT COALESCE(SUM(CASE WHEN transaction_type='SALE' THEN tax_amount ELSE 0 END),0) as output_tax, COALESCE(SUM(CASE WHEN transaction_type='PURCHASE' THEN tax_amount ELSE 0 END),0) as input_tax FROM tax_transactions WHERE transaction_date >= $1 AND transaction_date <= $2`
+	// Output VAT of an invoice whose faktur was cancelled without replacement is not due;
+	// input VAT is booked only for creditable supplier fakturs
+	query := `SELECT COALESCE(SUM(CASE WHEN transaction_type='SALE' THEN tax_amount ELSE 0 END),0) as output_tax, COALESCE(SUM(CASE WHEN transaction_type='PURCHASE' THEN tax_amount ELSE 0 END),0) as input_tax FROM tax_transactions tt WHERE transaction_date >= $1 AND transaction_date <= $2
+		AND NOT (tt.transaction_type = 'SALE' AND tt.reference_type = 'SALES_INVOICE' AND EXISTS (SELECT 1 FROM tax_invoices ti
+			WHERE ti.sales_invoice_id::text = tt.reference_id AND ti.status = 'cancelled'
+			AND NOT EXISTS (SELECT 1 FROM tax_invoices a WHERE a.sales_invoice_id = ti.sales_invoice_id AND a.status = 'active')))`
 	var outputTax, inputTax float64
 	err := r.db.QueryRowContext(ctx, query, startDate, endDate).Scan(&outputTax, &inputTax)
 	if err != nil {
@@ -28,23 +28,29 @@ type TaxResponse struct {
 	UpdatedAt time.Time       `json:"updated_at"`
 }
 
-// TaxRateRequest represents the request structure for creating/updating a TaxRate
+// TaxRateRequest represents the request structure for adding a rate version of a Tax
 type TaxRateRequest struct {
-	TaxID         uuid.ID `json:"tax_id" binding:"required"`
-	Rate          float64   `json:"rate" binding:"required"`
-	EffectiveDate time.Time `json:"effective_date" binding:"required"`
-	IsActive      bool      `json:"is_active"`
+	TaxID              uuid.ID    `json:"tax_id" binding:"required"`
+	Rate               float64    `json:"rate"`
+	TaxBaseNumerator   int        `json:"tax_base_numerator"`
+	TaxBaseDenominator int        `json:"tax_base_denominator"`
+	ValidFrom          time.Time  `json:"valid_from" binding:"required"`
+	ValidTo            *time.Time `json:"valid_to"`
+	Description        string     `json:"description"`
 }
 
-// TaxRateResponse represents the response structure for a TaxRate
+// TaxRateResponse represents the response structure for a rate version of a Tax
 type TaxRateResponse struct {
-	ID            uuid.ID `json:"id"`
-	TaxID         uuid.ID `json:"tax_id"`
-	Rate          float64   `json:"rate"`
-	EffectiveDate time.Time `json:"effective_date"`
-	IsActive      bool      `json:"is_active"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID                 uuid.ID    `json:"id"`
+	TaxID              uuid.ID    `json:"tax_id"`
+	Rate               float64    `json:"rate"`
+	TaxBaseNumerator   int        `json:"tax_base_numerator"`
+	TaxBaseDenominator int        `json:"tax_base_denominator"`
+	EffectiveRate      float64    `json:"effective_rate"`
+	ValidFrom          time.Time  `json:"valid_from"`
+	ValidTo            *time.Time `json:"valid_to"`
+	Description        string     `json:"description"`
+	CreatedAt          time.Time  `json:"created_at"`
 }
 
 // TaxTransactionRequest represents the request structure for recording a TaxTransaction
@@ -115,36 +121,40 @@ func MapTaxRequestToEntity(request *TaxRequest) *entities.Tax {
 	}
 }
 
-// Note: TaxRate entity doesn't exist - tax rates are embedded in Tax entity
-// MapTaxEntityToTaxRateResponse maps a Tax entity to a TaxRateResponse DTO  
-func MapTaxEntityToTaxRateResponse(entity *entities.Tax) *TaxRateResponse {
+// MapTaxRateVersionToResponse maps a TaxRateVersion entity to a TaxRateResponse DTO
+func MapTaxRateVersionToResponse(entity *entities.TaxRateVersion) *TaxRateResponse {
 	if entity == nil {
 		return nil
 	}
 	return &TaxRateResponse{
-		ID:            entity.ID,
-		TaxID:         entity.ID, // Same as ID since rate is embedded
-		Rate:          entity.TaxRate,
-		EffectiveDate: entity.EffectiveDate,
-		IsActive:      entity.IsActive,
-		CreatedAt:     entity.CreatedAt,
-		UpdatedAt:     entity.UpdatedAt,
+		ID:                 entity.ID,
+		TaxID:              entity.TaxID,
+		Rate:               entity.Rate,
+		TaxBaseNumerator:   entity.TaxBaseNumerator,
+		TaxBaseDenominator: entity.TaxBaseDenominator,
+		EffectiveRate:      entity.EffectiveRate(),
+		ValidFrom:          entity.ValidFrom,
+		ValidTo:            entity.ValidTo,
+		Description:        entity.Description,
+		CreatedAt:          entity.CreatedAt,
 	}
 }
 
-// Note: TaxRate entity doesn't exist - commenting out this mapping
-// TODO: Implement separate TaxRate entity if needed for historical rate tracking  
-// func MapTaxRateRequestToEntity(request *TaxRateRequest) *entities.TaxRate {
-//	if request == nil {
-//		return nil  
-//	}
-//	return &entities.TaxRate{
-//		TaxID:         request.TaxID,
-//		Rate:          request.Rate,
-//		EffectiveDate: request.EffectiveDate,
-//		IsActive:      request.IsActive,
-//	}
-// }
+// MapTaxRateRequestToEntity maps a TaxRateRequest DTO to a TaxRateVersion entity
+func MapTaxRateRequestToEntity(request *TaxRateRequest) *entities.TaxRateVersion {
+	if request == nil {
+		return nil
+	}
+	return &entities.TaxRateVersion{
+		TaxID:              request.TaxID,
+		Rate:               request.Rate,
+		TaxBaseNumerator:   request.TaxBaseNumerator,
+		TaxBaseDenominator: request.TaxBaseDenominator,
+		ValidFrom:          request.ValidFrom,
+		ValidTo:            request.ValidTo,
+		Description:        request.Description,
+	}
+}
 
 // MapTaxTransactionEntityToResponse maps a TaxTransaction entity to its response DTO
 func MapTaxTransactionEntityToResponse(entity *entities.TaxTransaction) *TaxTransactionResponse {
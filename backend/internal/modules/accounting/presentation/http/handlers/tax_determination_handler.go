@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/accounting/domain/services"
+	"malaka/internal/modules/accounting/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxDeterminationHandler handles HTTP requests for the rate history of taxes, tax
+// determination rules and tax calculation.
+type TaxDeterminationHandler struct {
+	service *services.TaxDeterminationService
+}
+
+// NewTaxDeterminationHandler creates a new TaxDeterminationHandler.
+func NewTaxDeterminationHandler(service *services.TaxDeterminationService) *TaxDeterminationHandler {
+	return &TaxDeterminationHandler{service: service}
+}
+
+// --- Rate Versions ---
+
+type taxRateRequest struct {
+	Rate               float64 `json:"rate"`
+	TaxBaseNumerator   int     `json:"tax_base_numerator"`
+	TaxBaseDenominator int     `json:"tax_base_denominator"`
+	ValidFrom          string  `json:"valid_from" binding:"required"`
+	ValidTo            string  `json:"valid_to"`
+	Description        string  `json:"description"`
+}
+
+// ListRateVersions handles listing the rate history of a tax.
+func (h *TaxDeterminationHandler) ListRateVersions(c *gin.Context) {
+	taxID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	versions, err := h.service.ListRateVersions(c.Request.Context(), taxID)
+	if err != nil {
+		taxDeterminationError(c, "Failed to retrieve tax rates", err)
+		return
+	}
+	resp := make([]*dto.TaxRateResponse, len(versions))
+	for i, v := range versions {
+		resp[i] = dto.MapTaxRateVersionToResponse(v)
+	}
+	response.Success(c, http.StatusOK, "Tax rates retrieved successfully", resp)
+}
+
+// AddRateVersion handles adding a rate of a tax from a date.
+func (h *TaxDeterminationHandler) AddRateVersion(c *gin.Context) {
+	taxID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	var req taxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	validFrom, ok := optionalDate(c, req.ValidFrom, "valid_from")
+	if !ok {
+		return
+	}
+	validTo, ok := optionalDate(c, req.ValidTo, "valid_to")
+	if !ok {
+		return
+	}
+	v := &entities.TaxRateVersion{
+		TaxID:              taxID,
+		Rate:               req.Rate,
+		TaxBaseNumerator:   req.TaxBaseNumerator,
+		TaxBaseDenominator: req.TaxBaseDenominator,
+		ValidFrom:          validFrom,
+		Description:        req.Description,
+	}
+	if !validTo.IsZero() {
+		v.ValidTo = &validTo
+	}
+	if err := h.service.AddRateVersion(c.Request.Context(), v, c.GetString("user_id")); err != nil {
+		taxDeterminationError(c, "Failed to add tax rate", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Tax rate added successfully", dto.MapTaxRateVersionToResponse(v))
+}
+
+// --- Determination Rules ---
+
+type taxRuleRequest struct {
+	TransactionType  string `json:"transaction_type" binding:"required"`
+	PartyTaxStatus   string `json:"party_tax_status"`
+	ClassificationID string `json:"classification_id"`
+	TaxID            string `json:"tax_id"` // Empty for transactions that are not taxed
+	Priority         int    `json:"priority"`
+	Description      string `json:"description"`
+	IsActive         *bool  `json:"is_active"`
+}
+
+// toRule converts the request to a rule, answering 400 on a malformed ID.
+func (req *taxRuleRequest) toRule(c *gin.Context) (*entities.TaxDeterminationRule, bool) {
+	rule := &entities.TaxDeterminationRule{
+		TransactionType: req.TransactionType,
+		PartyTaxStatus:  req.PartyTaxStatus,
+		Priority:        req.Priority,
+		Description:     req.Description,
+		IsActive:        req.IsActive == nil || *req.IsActive,
+	}
+	var ok bool
+	if rule.ClassificationID, ok = optionalID(c, req.ClassificationID, "classification_id"); !ok {
+		return nil, false
+	}
+	if rule.TaxID, ok = optionalID(c, req.TaxID, "tax_id"); !ok {
+		return nil, false
+	}
+	return rule, true
+}
+
+func optionalID(c *gin.Context, value, field string) (*uuid.ID, bool) {
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid "+field, err)
+		return nil, false
+	}
+	return &id, true
+}
+
+// ListRules handles listing the tax determination rules, of a transaction type when given.
+func (h *TaxDeterminationHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context(), c.Query("transaction_type"))
+	if err != nil {
+		taxDeterminationError(c, "Failed to retrieve tax rules", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax rules retrieved successfully", rules)
+}
+
+// GetRule handles retrieving a tax determination rule.
+func (h *TaxDeterminationHandler) GetRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	rule, err := h.service.GetRule(c.Request.Context(), id)
+	if err != nil {
+		taxDeterminationError(c, "Failed to retrieve tax rule", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax rule retrieved successfully", rule)
+}
+
+// CreateRule handles creating a tax determination rule.
+func (h *TaxDeterminationHandler) CreateRule(c *gin.Context) {
+	var req taxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	rule, ok := req.toRule(c)
+	if !ok {
+		return
+	}
+	if err := h.service.CreateRule(c.Request.Context(), rule, c.GetString("user_id")); err != nil {
+		taxDeterminationError(c, "Failed to create tax rule", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Tax rule created successfully", rule)
+}
+
+// UpdateRule handles updating a tax determination rule.
+func (h *TaxDeterminationHandler) UpdateRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	var req taxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	rule, ok := req.toRule(c)
+	if !ok {
+		return
+	}
+	rule.ID = id
+	if err := h.service.UpdateRule(c.Request.Context(), rule); err != nil {
+		taxDeterminationError(c, "Failed to update tax rule", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax rule updated successfully", rule)
+}
+
+// --- Calculation ---
+
+type taxCalculationRequest struct {
+	TransactionType string `json:"transaction_type" binding:"required"`
+	PartyID         string `json:"party_id"`
+	Date            string `json:"date"`
+	Lines           []struct {
+		ArticleID string  `json:"article_id"`
+		Amount    float64 `json:"amount"`
+	} `json:"lines" binding:"required,min=1"`
+}
+
+// CalculateTax handles determining and computing the tax of document lines; the date
+// defaults to today.
+func (h *TaxDeterminationHandler) CalculateTax(c *gin.Context) {
+	var req taxCalculationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	date, ok := optionalDate(c, req.Date, "date")
+	if !ok {
+		return
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+	calcReq := &entities.TaxCalculationRequest{
+		TransactionType: req.TransactionType,
+		Date:            date,
+		Lines:           make([]entities.TaxCalculationLineInput, len(req.Lines)),
+	}
+	if req.PartyID != "" {
+		partyID, err := uuid.Parse(req.PartyID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid party_id", err)
+			return
+		}
+		calcReq.PartyID = partyID
+	}
+	for i, l := range req.Lines {
+		calcReq.Lines[i].Amount = l.Amount
+		if l.ArticleID == "" {
+			continue
+		}
+		articleID, err := uuid.Parse(l.ArticleID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid article_id", err)
+			return
+		}
+		calcReq.Lines[i].ArticleID = articleID
+	}
+	calc, err := h.service.CalculateTax(c.Request.Context(), calcReq)
+	if err != nil {
+		taxDeterminationError(c, "Failed to calculate tax", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax calculated successfully", calc)
+}
+
+// taxDeterminationError maps tax rate and determination errors to HTTP responses.
+func taxDeterminationError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrTaxNotFound), errors.Is(err, entities.ErrTaxRuleNotFound):
+		response.Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, entities.ErrTaxRateOverlap):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidTaxRate), errors.Is(err, entities.ErrInvalidTaxRule),
+		errors.Is(err, entities.ErrInvalidTaxRequest):
+		response.Error(c, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, entities.ErrNoTaxRule), errors.Is(err, entities.ErrNoTaxRate):
+		response.Error(c, http.StatusUnprocessableEntity, err.Error(), nil)
+	default:
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -218,12 +219,13 @@ type txResponse struct {
 	ReferenceNumber string  `json:"reference_number"`
 	CustomerID      string  `json:"customer_id"`
 	SupplierID      string  `json:"supplier_id"`
+	TaxReturnID     *string `json:"tax_return_id"` // set once filed in a tax return
 	CompanyID       string  `json:"company_id"`
 	CreatedAt       string  `json:"created_at"`
 }
 
 func mapTxToResponse(t *entities.TaxTransaction) *txResponse {
-	return &txResponse{
+	resp := &txResponse{
 		ID:              t.ID.String(),
 		TaxID:           t.TaxID.String(),
 		TransactionDate: t.TransactionDate.Format("2006-01-02"),
@@ -239,6 +241,11 @@ func mapTxToResponse(t *entities.TaxTransaction) *txResponse {
 		CompanyID:       t.CompanyID,
 		CreatedAt:       t.CreatedAt.Format(time.RFC3339),
 	}
+	if t.IsLocked() {
+		s := t.TaxReturnID.String()
+		resp.TaxReturnID = &s
+	}
+	return resp
 }
 
 func (h *TaxHandler) GetAllTransactions(c *gin.Context) {
@@ -309,7 +316,7 @@ func (h *TaxHandler) CreateTransaction(c *gin.Context) {
 	}
 
 	if err := h.service.CreateTransaction(c.Request.Context(), tx); err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to create tax transaction", err)
+		taxReturnError(c, "Failed to create tax transaction", err)
 		return
 	}
 	response.Success(c, http.StatusCreated, "Tax transaction created successfully", mapTxToResponse(tx))
@@ -348,7 +355,7 @@ func (h *TaxHandler) UpdateTransaction(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateTransaction(c.Request.Context(), tx); err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to update tax transaction", err)
+		taxReturnError(c, "Failed to update tax transaction", err)
 		return
 	}
 	response.Success(c, http.StatusOK, "Tax transaction updated successfully", mapTxToResponse(tx))
@@ -361,7 +368,7 @@ func (h *TaxHandler) DeleteTransaction(c *gin.Context) {
 		return
 	}
 	if err := h.service.DeleteTransaction(c.Request.Context(), id); err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to delete tax transaction", err)
+		taxReturnError(c, "Failed to delete tax transaction", err)
 		return
 	}
 	response.Success(c, http.StatusOK, "Tax transaction deleted successfully", nil)
@@ -392,16 +399,21 @@ type returnResponse struct {
 	PeriodEnd      string  `json:"period_end"`
 	FilingDate     string  `json:"filing_date"`
 	DueDate        string  `json:"due_date"`
+	PaymentDueDate *string `json:"payment_due_date"`
 	Status         string  `json:"status"`
 	TotalSales     float64 `json:"total_sales"`
 	TotalPurchases float64 `json:"total_purchases"`
 	OutputTax      float64 `json:"output_tax"`
 	InputTax       float64 `json:"input_tax"`
+	OverpaymentBroughtForward float64 `json:"overpayment_brought_forward"`
+	OverpaymentCarriedForward float64 `json:"overpayment_carried_forward"`
 	TaxPayable     float64 `json:"tax_payable"`
 	TaxPaid        float64 `json:"tax_paid"`
 	PenaltyAmount  float64 `json:"penalty_amount"`
 	InterestAmount float64 `json:"interest_amount"`
 	TotalDue       float64 `json:"total_due"`
+	TransactionCount int   `json:"transaction_count"`
+	JournalEntryID *string `json:"journal_entry_id"`
 	SubmittedBy    string  `json:"submitted_by"`
 	SubmittedAt    *string `json:"submitted_at"`
 	PaidAt         *string `json:"paid_at"`
@@ -428,10 +440,21 @@ func mapReturnToResponse(r *entities.TaxReturn) *returnResponse {
 		PenaltyAmount:  r.PenaltyAmount,
 		InterestAmount: r.InterestAmount,
 		TotalDue:       r.TotalDue,
+		OverpaymentBroughtForward: r.OverpaymentBroughtForward,
+		OverpaymentCarriedForward: r.OverpaymentCarriedForward,
+		TransactionCount: r.TransactionCount,
 		SubmittedBy:    r.SubmittedBy,
 		CompanyID:      r.CompanyID,
 		CreatedAt:      r.CreatedAt.Format(time.RFC3339),
 	}
+	if r.PaymentDueDate != nil {
+		s := r.PaymentDueDate.Format("2006-01-02")
+		resp.PaymentDueDate = &s
+	}
+	if r.JournalEntryID != nil {
+		s := r.JournalEntryID.String()
+		resp.JournalEntryID = &s
+	}
 	if r.SubmittedAt != nil {
 		s := r.SubmittedAt.Format(time.RFC3339)
 		resp.SubmittedAt = &s
@@ -550,7 +573,7 @@ func (h *TaxHandler) UpdateReturn(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateReturn(c.Request.Context(), ret); err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to update tax return", err)
+		taxReturnError(c, "Failed to update tax return", err)
 		return
 	}
 	response.Success(c, http.StatusOK, "Tax return updated successfully", mapReturnToResponse(ret))
@@ -563,30 +586,57 @@ func (h *TaxHandler) DeleteReturn(c *gin.Context) {
 		return
 	}
 	if err := h.service.DeleteReturn(c.Request.Context(), id); err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to delete tax return", err)
+		taxReturnError(c, "Failed to delete tax return", err)
 		return
 	}
 	response.Success(c, http.StatusOK, "Tax return deleted successfully", nil)
 }
 
+type generateReturnRequest struct {
+	TaxType string `json:"tax_type" binding:"required"` // PPN or WITHHOLDING
+	Year    int    `json:"year" binding:"required"`
+	Month   int    `json:"month" binding:"required"`
+}
+
+// GenerateReturn computes the monthly return of a tax type from the tax transactions of
+// the month, recomputing its draft when there is one.
+func (h *TaxHandler) GenerateReturn(c *gin.Context) {
+	var req generateReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	ret, err := h.service.GenerateReturn(c.Request.Context(), entities.TaxType(req.TaxType), req.Year, req.Month,
+		c.GetString("user_id"))
+	if err != nil {
+		taxReturnError(c, "Failed to generate tax return", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax return generated successfully", mapReturnToResponse(ret))
+}
+
+// SubmitReturn files a draft return, locking the tax transactions it reports.
 func (h *TaxHandler) SubmitReturn(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
 		return
 	}
-	userID := c.GetString("userID")
-	if err := h.service.SubmitReturn(c.Request.Context(), id, userID); err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to submit tax return", err)
+	ret, err := h.service.SubmitReturn(c.Request.Context(), id, c.GetString("user_id"))
+	if err != nil {
+		taxReturnError(c, "Failed to submit tax return", err)
 		return
 	}
-	response.Success(c, http.StatusOK, "Tax return submitted successfully", nil)
+	response.Success(c, http.StatusOK, "Tax return submitted successfully", mapReturnToResponse(ret))
 }
 
 type payReturnRequest struct {
-	Amount float64 `json:"amount" binding:"required"`
+	Amount      float64 `json:"amount" binding:"min=0"` // the total due by default
+	PaymentDate string  `json:"payment_date"`           // YYYY-MM-DD, today by default
 }
 
+// PayReturn settles a filed return with its late payment interest and posts the
+// settlement journal.
 func (h *TaxHandler) PayReturn(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -598,9 +648,58 @@ func (h *TaxHandler) PayReturn(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
-	if err := h.service.PayReturn(c.Request.Context(), id, req.Amount); err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to mark tax return as paid", err)
+	payment := &services.TaxReturnPayment{Amount: req.Amount, PaidBy: c.GetString("user_id")}
+	if req.PaymentDate != "" {
+		if payment.PaymentDate, err = time.Parse("2006-01-02", req.PaymentDate); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid payment_date, expected YYYY-MM-DD", err)
+			return
+		}
+	}
+	ret, err := h.service.PayReturn(c.Request.Context(), id, payment)
+	if err != nil {
+		taxReturnError(c, "Failed to mark tax return as paid", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax return marked as paid", mapReturnToResponse(ret))
+}
+
+// taxReturnError maps tax return and transaction errors to HTTP responses.
+func taxReturnError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrTaxReturnNotFound), errors.Is(err, entities.ErrTaxTransactionNotFound):
+		response.Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, entities.ErrTaxReturnFiled), errors.Is(err, entities.ErrTaxReturnNotDraft),
+		errors.Is(err, entities.ErrTaxReturnNotPayable), errors.Is(err, entities.ErrTaxReturnOutdated),
+		errors.Is(err, entities.ErrTaxTransactionLocked):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, entities.ErrUnsupportedTaxReturnType), errors.Is(err, entities.ErrInvalidTaxPeriod),
+		errors.Is(err, entities.ErrInvalidTaxPayment):
+		response.Error(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// --- VAT Report ---
+
+// GetVATReport returns output VAT, creditable input VAT and the net VAT payable of a
+// period (from and to as YYYY-MM-DD, the current month by default).
+func (h *TaxHandler) GetVATReport(c *gin.Context) {
+	from, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+	if from.IsZero() {
+		now := time.Now()
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	if to.IsZero() {
+		to = from.AddDate(0, 1, -1)
+	}
+	report, err := h.service.GetVATReport(c.Request.Context(), from, to)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve VAT report", err)
 		return
 	}
-	response.Success(c, http.StatusOK, "Tax return marked as paid", nil)
+	response.Success(c, http.StatusOK, "VAT report retrieved successfully", report)
 }
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/accounting/domain/services"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// maxInputTaxImportSize limits uploaded input tax files (a year of supplier fakturs fits in 10 MB)
+const maxInputTaxImportSize = 10 << 20
+
+// TaxInvoiceHandler handles HTTP requests for e-Faktur: NSFP ranges, tax invoices and
+// input tax invoices.
+type TaxInvoiceHandler struct {
+	service *services.TaxInvoiceService
+}
+
+// NewTaxInvoiceHandler creates a new TaxInvoiceHandler.
+func NewTaxInvoiceHandler(service *services.TaxInvoiceService) *TaxInvoiceHandler {
+	return &TaxInvoiceHandler{service: service}
+}
+
+// --- NSFP Ranges ---
+
+type nsfpRangeRequest struct {
+	Start        string `json:"start" binding:"required"`
+	End          string `json:"end" binding:"required"`
+	LetterNumber string `json:"letter_number"`
+}
+
+// RegisterNSFPRange handles registering an NSFP range allocated by the tax office.
+func (h *TaxInvoiceHandler) RegisterNSFPRange(c *gin.Context) {
+	var req nsfpRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	rng, err := h.service.RegisterNSFPRange(c.Request.Context(), req.Start, req.End, req.LetterNumber, c.GetString("user_id"))
+	if err != nil {
+		taxInvoiceError(c, "Failed to register NSFP range", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "NSFP range registered successfully", rng)
+}
+
+// ListNSFPRanges handles listing the NSFP ranges, of a year when given.
+func (h *TaxInvoiceHandler) ListNSFPRanges(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("year"))
+	ranges, err := h.service.ListNSFPRanges(c.Request.Context(), year)
+	if err != nil {
+		taxInvoiceError(c, "Failed to retrieve NSFP ranges", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "NSFP ranges retrieved successfully", ranges)
+}
+
+// --- Tax Invoices ---
+
+type issueTaxInvoiceRequest struct {
+	SalesInvoiceID  string `json:"sales_invoice_id" binding:"required"`
+	TransactionCode string `json:"transaction_code"`
+	FakturDate      string `json:"faktur_date"`
+}
+
+type replaceTaxInvoiceRequest struct {
+	FakturDate string `json:"faktur_date"`
+}
+
+type cancelTaxInvoiceRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// IssueTaxInvoice handles issuing the tax invoice of a sales invoice.
+func (h *TaxInvoiceHandler) IssueTaxInvoice(c *gin.Context) {
+	var req issueTaxInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	salesInvoiceID, err := uuid.Parse(req.SalesInvoiceID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid sales_invoice_id", err)
+		return
+	}
+	fakturDate, ok := optionalDate(c, req.FakturDate, "faktur_date")
+	if !ok {
+		return
+	}
+	inv, err := h.service.IssueTaxInvoice(c.Request.Context(), salesInvoiceID, req.TransactionCode, fakturDate, c.GetString("user_id"))
+	if err != nil {
+		taxInvoiceError(c, "Failed to issue tax invoice", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Tax invoice issued successfully", inv)
+}
+
+// ReplaceTaxInvoice handles issuing a replacement (faktur pengganti) of a tax invoice.
+func (h *TaxInvoiceHandler) ReplaceTaxInvoice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	var req replaceTaxInvoiceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+	fakturDate, ok := optionalDate(c, req.FakturDate, "faktur_date")
+	if !ok {
+		return
+	}
+	inv, err := h.service.ReplaceTaxInvoice(c.Request.Context(), id, fakturDate, c.GetString("user_id"))
+	if err != nil {
+		taxInvoiceError(c, "Failed to replace tax invoice", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Tax invoice replaced successfully", inv)
+}
+
+// CancelTaxInvoice handles cancelling a tax invoice.
+func (h *TaxInvoiceHandler) CancelTaxInvoice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	var req cancelTaxInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	inv, err := h.service.CancelTaxInvoice(c.Request.Context(), id, req.Reason, c.GetString("user_id"))
+	if err != nil {
+		taxInvoiceError(c, "Failed to cancel tax invoice", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax invoice cancelled successfully", inv)
+}
+
+// GetTaxInvoice handles retrieving a tax invoice with its items.
+func (h *TaxInvoiceHandler) GetTaxInvoice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	inv, err := h.service.GetTaxInvoice(c.Request.Context(), id)
+	if err != nil {
+		taxInvoiceError(c, "Failed to retrieve tax invoice", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax invoice retrieved successfully", inv)
+}
+
+// ListTaxInvoices handles listing the tax invoices of a faktur date range
+// (from and to as YYYY-MM-DD, the current month by default).
+func (h *TaxInvoiceHandler) ListTaxInvoices(c *gin.Context) {
+	from, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+	invoices, err := h.service.ListTaxInvoices(c.Request.Context(), from, to, c.Query("status"))
+	if err != nil {
+		taxInvoiceError(c, "Failed to retrieve tax invoices", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Tax invoices retrieved successfully", invoices)
+}
+
+// ExportEFaktur handles downloading the tax invoices of a faktur date range as an
+// e-Faktur import CSV.
+func (h *TaxInvoiceHandler) ExportEFaktur(c *gin.Context) {
+	from, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+	data, err := h.service.ExportEFaktur(c.Request.Context(), from, to)
+	if err != nil {
+		taxInvoiceError(c, "Failed to export tax invoices", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=efaktur-%s.csv", time.Now().Format("20060102150405")))
+	c.Data(http.StatusOK, "text/csv", data)
+}
+
+// ExportCoretax handles downloading the tax invoices of a faktur date range as a
+// Coretax import XML.
+func (h *TaxInvoiceHandler) ExportCoretax(c *gin.Context) {
+	from, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+	data, err := h.service.ExportCoretax(c.Request.Context(), from, to)
+	if err != nil {
+		taxInvoiceError(c, "Failed to export tax invoices", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=coretax-%s.xml", time.Now().Format("20060102150405")))
+	c.Data(http.StatusOK, "application/xml", data)
+}
+
+// --- Input Tax Invoices ---
+
+// ImportInputTaxInvoices handles uploading an e-Faktur input tax CSV of supplier fakturs.
+func (h *TaxInvoiceHandler) ImportInputTaxInvoices(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Input tax file is required", err)
+		return
+	}
+	if fileHeader.Size > maxInputTaxImportSize {
+		response.Error(c, http.StatusBadRequest, "Input tax file is too large", nil)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to read input tax file", err)
+		return
+	}
+	defer file.Close()
+
+	result, err := h.service.ImportInputTaxInvoices(c.Request.Context(), file, fileHeader.Filename, c.GetString("user_id"))
+	if err != nil {
+		taxInvoiceError(c, "Failed to import input tax invoices", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Input tax invoices imported successfully", result)
+}
+
+// ListInputTaxInvoices handles listing the input tax invoices, of a tax year and period when given.
+func (h *TaxInvoiceHandler) ListInputTaxInvoices(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("year"))
+	period, _ := strconv.Atoi(c.Query("period"))
+	invoices, err := h.service.ListInputTaxInvoices(c.Request.Context(), year, period)
+	if err != nil {
+		taxInvoiceError(c, "Failed to retrieve input tax invoices", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Input tax invoices retrieved successfully", invoices)
+}
+
+func optionalDate(c *gin.Context, value, field string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid "+field+", expected YYYY-MM-DD", err)
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func dateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	from, ok := optionalDate(c, c.Query("from"), "from")
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to, ok := optionalDate(c, c.Query("to"), "to")
+	return from, to, ok
+}
+
+// taxInvoiceError maps e-Faktur errors to HTTP responses.
+func taxInvoiceError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrTaxInvoiceNotFound), errors.Is(err, entities.ErrSalesInvoiceNotFound):
+		response.Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, entities.ErrTaxInvoiceExists), errors.Is(err, entities.ErrNSFPRangeOverlap),
+		errors.Is(err, entities.ErrTaxInvoiceNotActive):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidNSFPRange), errors.Is(err, entities.ErrNSFPExhausted),
+		errors.Is(err, entities.ErrInvalidTaxInvoice), errors.Is(err, entities.ErrInvalidInputTaxInvoice):
+		response.Error(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
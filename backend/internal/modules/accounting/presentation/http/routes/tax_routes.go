@@ -7,7 +7,8 @@ import (
 )
 
 // RegisterTaxRoutes registers all tax-related routes under the accounting group.
-func RegisterTaxRoutes(accountingGroup *gin.RouterGroup, taxHandler *handlers.TaxHandler, rbacSvc *auth.RBACService) {
+func RegisterTaxRoutes(accountingGroup *gin.RouterGroup, taxHandler *handlers.TaxHandler, taxInvoiceHandler *handlers.TaxInvoiceHandler,
+	taxDeterminationHandler *handlers.TaxDeterminationHandler, rbacSvc *auth.RBACService) {
 	// Tax master data routes
 	taxes := accountingGroup.Group("/taxes")
 	{
@@ -16,8 +17,20 @@ func RegisterTaxRoutes(accountingGroup *gin.RouterGroup, taxHandler *handlers.Ta
 		taxes.POST("/", auth.RequirePermission(rbacSvc, "accounting.tax.create"), taxHandler.CreateTax)
 		taxes.PUT("/:id", auth.RequirePermission(rbacSvc, "accounting.tax.update"), taxHandler.UpdateTax)
 		taxes.DELETE("/:id", auth.RequirePermission(rbacSvc, "accounting.tax.delete"), taxHandler.DeleteTax)
+		taxes.GET("/:id/rates", auth.RequirePermission(rbacSvc, "accounting.tax.read"), taxDeterminationHandler.ListRateVersions)
+		taxes.POST("/:id/rates", auth.RequirePermission(rbacSvc, "accounting.tax.update"), taxDeterminationHandler.AddRateVersion)
 	}
 
+	// Tax determination rules and the tax calculation sales, POS and purchasing use
+	taxRules := accountingGroup.Group("/tax-rules")
+	{
+		taxRules.GET("/", auth.RequirePermission(rbacSvc, "accounting.tax-rule.list"), taxDeterminationHandler.ListRules)
+		taxRules.GET("/:id", auth.RequirePermission(rbacSvc, "accounting.tax-rule.list"), taxDeterminationHandler.GetRule)
+		taxRules.POST("/", auth.RequirePermission(rbacSvc, "accounting.tax-rule.manage"), taxDeterminationHandler.CreateRule)
+		taxRules.PUT("/:id", auth.RequirePermission(rbacSvc, "accounting.tax-rule.manage"), taxDeterminationHandler.UpdateRule)
+	}
+	accountingGroup.POST("/tax-determination/calculate", auth.RequirePermission(rbacSvc, "accounting.tax-rule.list"), taxDeterminationHandler.CalculateTax)
+
 	// Tax transaction routes
 	taxTransactions := accountingGroup.Group("/tax-transactions")
 	{
@@ -34,9 +47,39 @@ func RegisterTaxRoutes(accountingGroup *gin.RouterGroup, taxHandler *handlers.Ta
 		taxReturns.GET("/", auth.RequirePermission(rbacSvc, "accounting.tax-return.list"), taxHandler.GetAllReturns)
 		taxReturns.GET("/:id", auth.RequirePermission(rbacSvc, "accounting.tax-return.read"), taxHandler.GetReturnByID)
 		taxReturns.POST("/", auth.RequirePermission(rbacSvc, "accounting.tax-return.create"), taxHandler.CreateReturn)
+		taxReturns.POST("/generate", auth.RequirePermission(rbacSvc, "accounting.tax-return.generate"), taxHandler.GenerateReturn)
 		taxReturns.PUT("/:id", auth.RequirePermission(rbacSvc, "accounting.tax-return.update"), taxHandler.UpdateReturn)
 		taxReturns.DELETE("/:id", auth.RequirePermission(rbacSvc, "accounting.tax-return.delete"), taxHandler.DeleteReturn)
 		taxReturns.POST("/:id/submit", auth.RequirePermission(rbacSvc, "accounting.tax-return.submit"), taxHandler.SubmitReturn)
 		taxReturns.POST("/:id/pay", auth.RequirePermission(rbacSvc, "accounting.tax-return.pay"), taxHandler.PayReturn)
 	}
+
+	// VAT report: output VAT netted against creditable input VAT
+	accountingGroup.GET("/vat-report", auth.RequirePermission(rbacSvc, "accounting.tax-transaction.list"), taxHandler.GetVATReport)
+
+	// NSFP ranges allocated by the tax office
+	nsfpRanges := accountingGroup.Group("/nsfp-ranges")
+	{
+		nsfpRanges.GET("/", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.list"), taxInvoiceHandler.ListNSFPRanges)
+		nsfpRanges.POST("/", auth.RequirePermission(rbacSvc, "accounting.nsfp.manage"), taxInvoiceHandler.RegisterNSFPRange)
+	}
+
+	// Tax invoice (e-Faktur) routes
+	taxInvoices := accountingGroup.Group("/tax-invoices")
+	{
+		taxInvoices.GET("/", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.list"), taxInvoiceHandler.ListTaxInvoices)
+		taxInvoices.GET("/export/efaktur", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.export"), taxInvoiceHandler.ExportEFaktur)
+		taxInvoices.GET("/export/coretax", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.export"), taxInvoiceHandler.ExportCoretax)
+		taxInvoices.GET("/:id", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.read"), taxInvoiceHandler.GetTaxInvoice)
+		taxInvoices.POST("/", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.issue"), taxInvoiceHandler.IssueTaxInvoice)
+		taxInvoices.POST("/:id/replace", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.issue"), taxInvoiceHandler.ReplaceTaxInvoice)
+		taxInvoices.POST("/:id/cancel", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.cancel"), taxInvoiceHandler.CancelTaxInvoice)
+	}
+
+	// Input tax invoices (faktur pajak masukan) of suppliers
+	inputTaxInvoices := accountingGroup.Group("/input-tax-invoices")
+	{
+		inputTaxInvoices.GET("/", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.list"), taxInvoiceHandler.ListInputTaxInvoices)
+		inputTaxInvoices.POST("/import", auth.RequirePermission(rbacSvc, "accounting.tax-invoice.import"), taxInvoiceHandler.ImportInputTaxInvoices)
+	}
 }
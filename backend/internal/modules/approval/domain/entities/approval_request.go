@@ -0,0 +1,171 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/uuid"
+)
+
+// TaskStatus represents the status of a single approver's task
+type TaskStatus string
+
+const (
+	TaskStatusPending   TaskStatus = "pending"
+	TaskStatusApproved  TaskStatus = "approved"
+	TaskStatusRejected  TaskStatus = "rejected"
+	TaskStatusSkipped   TaskStatus = "skipped"   // Step completed by another approver
+	TaskStatusEscalated TaskStatus = "escalated" // Timed out and moved to the escalation target
+)
+
+// HistoryAction represents an entry in the approval history
+type HistoryAction string
+
+const (
+	HistoryActionSubmitted HistoryAction = "submitted"
+	HistoryActionAssigned  HistoryAction = "assigned"
+	HistoryActionApproved  HistoryAction = "approved"
+	HistoryActionRejected  HistoryAction = "rejected"
+	HistoryActionDelegated HistoryAction = "delegated"
+	HistoryActionEscalated HistoryAction = "escalated"
+	HistoryActionCancelled HistoryAction = "cancelled"
+	HistoryActionCompleted HistoryAction = "completed"
+)
+
+// ApprovalRequest is one run of a document through its approval chain
+type ApprovalRequest struct {
+	ID                  uuid.ID                          `json:"id" db:"id"`
+	WorkflowID          *string                          `json:"workflow_id,omitempty" db:"workflow_id"` // nil = default policy
+	CompanyID           *string                          `json:"company_id,omitempty" db:"company_id"`
+	DocumentType        integration.ApprovalDocumentType `json:"document_type" db:"document_type"`
+	DocumentID          string                           `json:"document_id" db:"document_id"`
+	DocumentNumber      string                           `json:"document_number" db:"document_number"`
+	RequesterID         *string                          `json:"requester_id,omitempty" db:"requester_id"`
+	RequesterEmployeeID *string                          `json:"requester_employee_id,omitempty" db:"requester_employee_id"`
+	Department          string                           `json:"department" db:"department"`
+	Amount              float64                          `json:"amount" db:"amount"`
+	Status              integration.ApprovalStatus       `json:"status" db:"status"`
+	CurrentStepOrder    int                              `json:"current_step_order" db:"current_step_order"`
+	SubmittedAt         time.Time                        `json:"submitted_at" db:"submitted_at"`
+	CompletedAt         *time.Time                       `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt           time.Time                        `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time                        `json:"updated_at" db:"updated_at"`
+}
+
+// ApprovalTask is the decision expected from one approver at one step
+type ApprovalTask struct {
+	ID                 uuid.ID      `json:"id" db:"id"`
+	RequestID          uuid.ID      `json:"request_id" db:"request_id"`
+	StepID             *string      `json:"step_id,omitempty" db:"step_id"`
+	StepOrder          int          `json:"step_order" db:"step_order"`
+	StepName           string       `json:"step_name" db:"step_name"`
+	Mode               ApprovalMode `json:"approval_mode" db:"approval_mode"`
+	ApproverID         string       `json:"approver_id" db:"approver_id"`
+	OriginalApproverID *string      `json:"original_approver_id,omitempty" db:"original_approver_id"` // Set when delegated or escalated
+	Status             TaskStatus   `json:"status" db:"status"`
+	DueAt              *time.Time   `json:"due_at,omitempty" db:"due_at"`
+	ActedAt            *time.Time   `json:"acted_at,omitempty" db:"acted_at"`
+	Comments           string       `json:"comments" db:"comments"`
+	CreatedAt          time.Time    `json:"created_at" db:"created_at"`
+
+	// Document details (loaded by join for approver inboxes)
+	DocumentType   integration.ApprovalDocumentType `json:"document_type,omitempty" db:"document_type"`
+	DocumentID     string                           `json:"document_id,omitempty" db:"document_id"`
+	DocumentNumber string                           `json:"document_number,omitempty" db:"document_number"`
+	Amount         float64                          `json:"amount,omitempty" db:"amount"`
+}
+
+// ApprovalHistory is an audit entry of an approval request
+type ApprovalHistory struct {
+	ID        uuid.ID       `json:"id" db:"id"`
+	RequestID uuid.ID       `json:"request_id" db:"request_id"`
+	TaskID    *string       `json:"task_id,omitempty" db:"task_id"`
+	StepOrder int           `json:"step_order" db:"step_order"`
+	ActorID   *string       `json:"actor_id,omitempty" db:"actor_id"` // nil for system actions (escalation)
+	Action    HistoryAction `json:"action" db:"action"`
+	Comments  string        `json:"comments" db:"comments"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+// ApprovalDelegation routes a user's approval tasks to another user for a period,
+// typically while the delegator is on leave
+type ApprovalDelegation struct {
+	ID           uuid.ID                           `json:"id" db:"id"`
+	DelegatorID  string                            `json:"delegator_id" db:"delegator_id"`
+	DelegateID   string                            `json:"delegate_id" db:"delegate_id"`
+	DocumentType *integration.ApprovalDocumentType `json:"document_type,omitempty" db:"document_type"` // nil = all documents
+	StartDate    time.Time                         `json:"start_date" db:"start_date"`
+	EndDate      time.Time                         `json:"end_date" db:"end_date"`
+	Reason       string                            `json:"reason" db:"reason"`
+	IsActive     bool                              `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time                         `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time                         `json:"updated_at" db:"updated_at"`
+}
+
+// ApprovalRequestDetail is an approval request with its tasks and history
+type ApprovalRequestDetail struct {
+	ApprovalRequest
+	Tasks   []ApprovalTask    `json:"tasks"`
+	History []ApprovalHistory `json:"history"`
+}
+
+// ApprovalRequestFilter represents filtering options for approval requests
+type ApprovalRequestFilter struct {
+	CompanyID    string
+	DocumentType string
+	Status       string
+	RequesterID  string
+	Limit        int
+	Offset       int
+}
+
+// Validate validates the delegation
+func (d *ApprovalDelegation) Validate() error {
+	if d.DelegatorID == "" || d.DelegateID == "" {
+		return fmt.Errorf("delegator and delegate are required")
+	}
+	if d.DelegatorID == d.DelegateID {
+		return fmt.Errorf("cannot delegate approvals to yourself")
+	}
+	if d.StartDate.IsZero() || d.EndDate.IsZero() {
+		return fmt.Errorf("start date and end date are required")
+	}
+	if d.EndDate.Before(d.StartDate) {
+		return fmt.Errorf("end date cannot be before start date")
+	}
+	return nil
+}
+
+// Covers reports whether the delegation applies to a document type on a date
+func (d *ApprovalDelegation) Covers(documentType integration.ApprovalDocumentType, date time.Time) bool {
+	if !d.IsActive {
+		return false
+	}
+	if d.DocumentType != nil && *d.DocumentType != documentType {
+		return false
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(d.StartDate) && !day.After(d.EndDate)
+}
+
+// Document rebuilds the approval document used to evaluate step conditions
+func (r *ApprovalRequest) Document() *integration.ApprovalDocument {
+	doc := &integration.ApprovalDocument{
+		DocumentType:   r.DocumentType,
+		DocumentID:     r.DocumentID,
+		DocumentNumber: r.DocumentNumber,
+		Department:     r.Department,
+		Amount:         r.Amount,
+	}
+	if r.CompanyID != nil {
+		doc.CompanyID = *r.CompanyID
+	}
+	if r.RequesterID != nil {
+		doc.RequesterID = *r.RequesterID
+	}
+	if r.RequesterEmployeeID != nil {
+		doc.RequesterEmployeeID = *r.RequesterEmployeeID
+	}
+	return doc
+}
@@ -0,0 +1,144 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/uuid"
+)
+
+// ApproverType defines how the approvers of a step are resolved
+type ApproverType string
+
+const (
+	ApproverTypeUser       ApproverType = "user"       // A fixed user
+	ApproverTypeRole       ApproverType = "role"       // Every user holding a role
+	ApproverTypeDepartment ApproverType = "department" // Supervisors and above of a department
+	ApproverTypeSupervisor ApproverType = "supervisor" // The requester's direct supervisor (Employee.SupervisorID)
+)
+
+// ApprovalMode defines how many approvers of a step must approve
+type ApprovalMode string
+
+const (
+	ApprovalModeAny ApprovalMode = "any" // First approval completes the step
+	ApprovalModeAll ApprovalMode = "all" // Every resolved approver must approve
+)
+
+// ApprovalWorkflow is an approval chain for one document type. A workflow without company
+// is the default for every company; a company-specific workflow takes precedence.
+type ApprovalWorkflow struct {
+	ID           uuid.ID                          `json:"id" db:"id"`
+	CompanyID    *string                          `json:"company_id,omitempty" db:"company_id"`
+	DocumentType integration.ApprovalDocumentType `json:"document_type" db:"document_type"`
+	Name         string                           `json:"name" db:"name"`
+	Description  string                           `json:"description" db:"description"`
+	IsActive     bool                             `json:"is_active" db:"is_active"`
+	CreatedBy    *string                          `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time                        `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time                        `json:"updated_at" db:"updated_at"`
+
+	Steps []ApprovalStep `json:"steps" db:"-"`
+}
+
+// ApprovalStep is one level of an approval chain. Steps run in ascending StepOrder;
+// steps sharing the same StepOrder run in parallel and must all complete.
+type ApprovalStep struct {
+	ID         uuid.ID      `json:"id" db:"id"`
+	WorkflowID uuid.ID      `json:"workflow_id" db:"workflow_id"`
+	StepOrder  int          `json:"step_order" db:"step_order"`
+	Name       string       `json:"name" db:"name"`
+	Mode       ApprovalMode `json:"approval_mode" db:"approval_mode"`
+
+	ApproverType       ApproverType `json:"approver_type" db:"approver_type"`
+	ApproverUserID     *string      `json:"approver_user_id,omitempty" db:"approver_user_id"`
+	ApproverRole       string       `json:"approver_role,omitempty" db:"approver_role"`
+	ApproverDepartment string       `json:"approver_department,omitempty" db:"approver_department"`
+
+	// Conditions: the step only applies when the document matches
+	MinAmount           *float64 `json:"min_amount,omitempty" db:"min_amount"`
+	MaxAmount           *float64 `json:"max_amount,omitempty" db:"max_amount"`
+	ConditionDepartment string   `json:"condition_department,omitempty" db:"condition_department"`
+
+	// Escalation: pending tasks older than EscalationHours move to the escalation target
+	// (a user, a role, or the approver's own supervisor when neither is set)
+	EscalationHours  int     `json:"escalation_hours" db:"escalation_hours"`
+	EscalateToUserID *string `json:"escalate_to_user_id,omitempty" db:"escalate_to_user_id"`
+	EscalateToRole   string  `json:"escalate_to_role,omitempty" db:"escalate_to_role"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Validate validates the workflow and its steps
+func (w *ApprovalWorkflow) Validate() error {
+	switch w.DocumentType {
+	case integration.ApprovalDocPurchaseRequest, integration.ApprovalDocPurchaseOrder,
+		integration.ApprovalDocLeaveRequest, integration.ApprovalDocPayrollPeriod,
+		integration.ApprovalDocTransferOrder:
+	default:
+		return fmt.Errorf("unsupported document type: %s", w.DocumentType)
+	}
+	if strings.TrimSpace(w.Name) == "" {
+		return fmt.Errorf("workflow name is required")
+	}
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("workflow must have at least one step")
+	}
+	for i := range w.Steps {
+		if err := w.Steps[i].Validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates the step configuration
+func (s *ApprovalStep) Validate() error {
+	if s.StepOrder <= 0 {
+		return fmt.Errorf("step order must be positive")
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("step name is required")
+	}
+	switch s.Mode {
+	case ApprovalModeAny, ApprovalModeAll:
+	default:
+		return fmt.Errorf("invalid approval mode: %s", s.Mode)
+	}
+	switch s.ApproverType {
+	case ApproverTypeUser:
+		if s.ApproverUserID == nil || *s.ApproverUserID == "" {
+			return fmt.Errorf("approver user is required for user steps")
+		}
+	case ApproverTypeRole:
+		if s.ApproverRole == "" {
+			return fmt.Errorf("approver role is required for role steps")
+		}
+	case ApproverTypeDepartment, ApproverTypeSupervisor:
+	default:
+		return fmt.Errorf("invalid approver type: %s", s.ApproverType)
+	}
+	if s.MinAmount != nil && s.MaxAmount != nil && *s.MaxAmount < *s.MinAmount {
+		return fmt.Errorf("max amount cannot be less than min amount")
+	}
+	if s.EscalationHours < 0 {
+		return fmt.Errorf("escalation hours cannot be negative")
+	}
+	return nil
+}
+
+// AppliesTo reports whether the step's conditions match the document
+func (s *ApprovalStep) AppliesTo(doc *integration.ApprovalDocument) bool {
+	if s.MinAmount != nil && doc.Amount < *s.MinAmount {
+		return false
+	}
+	if s.MaxAmount != nil && doc.Amount > *s.MaxAmount {
+		return false
+	}
+	if s.ConditionDepartment != "" && !strings.EqualFold(s.ConditionDepartment, doc.Department) {
+		return false
+	}
+	return true
+}
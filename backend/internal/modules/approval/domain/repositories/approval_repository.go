@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/approval/domain/entities"
+	"malaka/internal/shared/integration"
+)
+
+// ApprovalRepository defines persistence for approval workflows, requests, tasks and history
+type ApprovalRepository interface {
+	// InTransaction runs fn with a repository bound to one database transaction, committed
+	// when fn returns nil and rolled back otherwise
+	InTransaction(ctx context.Context, fn func(repo ApprovalRepository) error) error
+
+	// Workflows (steps are saved together with their workflow)
+	CreateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error
+	GetWorkflowByID(ctx context.Context, id string) (*entities.ApprovalWorkflow, error)
+	ListWorkflows(ctx context.Context, companyID string, documentType string) ([]entities.ApprovalWorkflow, error)
+	UpdateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error
+	DeleteWorkflow(ctx context.Context, id string) error
+	// FindActiveWorkflow returns the company's workflow for a document type, falling back
+	// to the default (company-less) workflow. Returns nil when none is configured.
+	FindActiveWorkflow(ctx context.Context, documentType integration.ApprovalDocumentType, companyID string) (*entities.ApprovalWorkflow, error)
+
+	// Requests
+	CreateRequest(ctx context.Context, request *entities.ApprovalRequest) error
+	UpdateRequest(ctx context.Context, request *entities.ApprovalRequest) error
+	GetRequestByID(ctx context.Context, id string) (*entities.ApprovalRequest, error)
+	// LockRequest retrieves an approval request and locks its row until the transaction ends
+	LockRequest(ctx context.Context, id string) (*entities.ApprovalRequest, error)
+	GetPendingRequest(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) (*entities.ApprovalRequest, error)
+	ListRequests(ctx context.Context, filter *entities.ApprovalRequestFilter) ([]entities.ApprovalRequest, int, error)
+	ListRequestsByDocument(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) ([]entities.ApprovalRequest, error)
+
+	// Tasks
+	CreateTask(ctx context.Context, task *entities.ApprovalTask) error
+	UpdateTask(ctx context.Context, task *entities.ApprovalTask) error
+	ListTasksByRequest(ctx context.Context, requestID string) ([]entities.ApprovalTask, error)
+	ListPendingTasksByApprover(ctx context.Context, approverID string) ([]entities.ApprovalTask, error)
+	ListOverdueTasks(ctx context.Context, now time.Time) ([]entities.ApprovalTask, error)
+
+	// History
+	AddHistory(ctx context.Context, entry *entities.ApprovalHistory) error
+	ListHistory(ctx context.Context, requestID string) ([]entities.ApprovalHistory, error)
+
+	// Delegations
+	CreateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error
+	GetDelegationByID(ctx context.Context, id string) (*entities.ApprovalDelegation, error)
+	ListDelegations(ctx context.Context, userID string) ([]entities.ApprovalDelegation, error)
+	UpdateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error
+	FindActiveDelegation(ctx context.Context, delegatorID string, documentType integration.ApprovalDocumentType, date time.Time) (*entities.ApprovalDelegation, error)
+}
+
+// ApproverDirectory resolves the users behind role, department and reporting-line steps
+type ApproverDirectory interface {
+	// UsersWithRole returns active users holding a role, limited to a company when given
+	UsersWithRole(ctx context.Context, roleName, companyID string) ([]string, error)
+	// DepartmentApprovers returns users of a department holding a role of Supervisor level or above
+	DepartmentApprovers(ctx context.Context, department, companyID string) ([]string, error)
+	// SupervisorOf returns the user of the direct supervisor of an employee (by employee ID
+	// or, when empty, by the employee's user ID). Returns "" when there is none.
+	SupervisorOf(ctx context.Context, employeeID, userID string) (string, error)
+	// IsOnLeave reports whether the user's employee record has approved leave covering the date
+	IsOnLeave(ctx context.Context, userID string, date time.Time) (bool, error)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+
+	"malaka/internal/modules/approval/domain/entities"
+	"malaka/internal/shared/integration"
+)
+
+// ApprovalService is the approval workflow engine. It implements integration.ApprovalEngine
+// for the document modules and manages workflows, approver inboxes and delegations.
+type ApprovalService interface {
+	integration.ApprovalEngine
+
+	// Workflows
+	CreateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error
+	GetWorkflow(ctx context.Context, id string) (*entities.ApprovalWorkflow, error)
+	ListWorkflows(ctx context.Context, companyID, documentType string) ([]entities.ApprovalWorkflow, error)
+	UpdateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error
+	DeleteWorkflow(ctx context.Context, id string) error
+
+	// Requests and history
+	GetRequest(ctx context.Context, id string) (*entities.ApprovalRequestDetail, error)
+	ListRequests(ctx context.Context, filter *entities.ApprovalRequestFilter) ([]entities.ApprovalRequest, int, error)
+	GetDocumentHistory(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) ([]entities.ApprovalRequestDetail, error)
+	ListInbox(ctx context.Context, approverID string) ([]entities.ApprovalTask, error)
+
+	// Delegations
+	CreateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error
+	ListDelegations(ctx context.Context, userID string) ([]entities.ApprovalDelegation, error)
+	RevokeDelegation(ctx context.Context, id, userID string) error
+
+	// ProcessEscalations moves overdue tasks to their escalation targets and returns how many were escalated
+	ProcessEscalations(ctx context.Context) (int, error)
+}
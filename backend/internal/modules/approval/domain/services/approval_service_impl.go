@@ -0,0 +1,1036 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/approval/domain/entities"
+	"malaka/internal/modules/approval/domain/repositories"
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/uuid"
+)
+
+// ApprovalAuthorizer decides approvals for documents without a configured workflow
+// (satisfied by auth.RBACService)
+type ApprovalAuthorizer interface {
+	CanApprove(ctx context.Context, approverID, requesterID string) (bool, error)
+}
+
+type approvalServiceImpl struct {
+	repo       repositories.ApprovalRepository
+	directory  repositories.ApproverDirectory
+	authorizer ApprovalAuthorizer
+	now        func() time.Time
+}
+
+// NewApprovalService creates a new approval workflow engine
+func NewApprovalService(repo repositories.ApprovalRepository, directory repositories.ApproverDirectory, authorizer ApprovalAuthorizer) ApprovalService {
+	return &approvalServiceImpl{
+		repo:       repo,
+		directory:  directory,
+		authorizer: authorizer,
+		now:        time.Now,
+	}
+}
+
+// Workflows
+
+func (s *approvalServiceImpl) CreateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error {
+	if err := workflow.Validate(); err != nil {
+		return err
+	}
+	now := s.now()
+	workflow.ID = uuid.New()
+	workflow.CreatedAt = now
+	workflow.UpdatedAt = now
+	prepareSteps(workflow, now)
+	return s.repo.CreateWorkflow(ctx, workflow)
+}
+
+func (s *approvalServiceImpl) GetWorkflow(ctx context.Context, id string) (*entities.ApprovalWorkflow, error) {
+	workflow, err := s.repo.GetWorkflowByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if workflow == nil {
+		return nil, errors.New("approval workflow not found")
+	}
+	return workflow, nil
+}
+
+func (s *approvalServiceImpl) ListWorkflows(ctx context.Context, companyID, documentType string) ([]entities.ApprovalWorkflow, error) {
+	return s.repo.ListWorkflows(ctx, companyID, documentType)
+}
+
+// UpdateWorkflow replaces the workflow and its steps. Requests already in flight keep
+// their tasks; later steps are read from the updated workflow.
+func (s *approvalServiceImpl) UpdateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error {
+	existing, err := s.GetWorkflow(ctx, workflow.ID.String())
+	if err != nil {
+		return err
+	}
+	if err := workflow.Validate(); err != nil {
+		return err
+	}
+	now := s.now()
+	workflow.CreatedAt = existing.CreatedAt
+	workflow.CreatedBy = existing.CreatedBy
+	workflow.UpdatedAt = now
+	prepareSteps(workflow, now)
+	return s.repo.UpdateWorkflow(ctx, workflow)
+}
+
+func (s *approvalServiceImpl) DeleteWorkflow(ctx context.Context, id string) error {
+	if _, err := s.GetWorkflow(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteWorkflow(ctx, id)
+}
+
+func prepareSteps(workflow *entities.ApprovalWorkflow, now time.Time) {
+	for i := range workflow.Steps {
+		workflow.Steps[i].ID = uuid.New()
+		workflow.Steps[i].WorkflowID = workflow.ID
+		workflow.Steps[i].CreatedAt = now
+	}
+}
+
+// Engine
+
+// inTransaction runs fn against a copy of the service whose repository is bound to one
+// database transaction, so a decision is applied completely or not at all
+func (s *approvalServiceImpl) inTransaction(ctx context.Context, fn func(tx *approvalServiceImpl) error) error {
+	return s.repo.InTransaction(ctx, func(repo repositories.ApprovalRepository) error {
+		tx := *s
+		tx.repo = repo
+		return fn(&tx)
+	})
+}
+
+func (s *approvalServiceImpl) Submit(ctx context.Context, doc *integration.ApprovalDocument) (*integration.ApprovalOutcome, error) {
+	var outcome *integration.ApprovalOutcome
+	err := s.inTransaction(ctx, func(tx *approvalServiceImpl) error {
+		request, err := tx.submit(ctx, doc)
+		if err != nil {
+			return err
+		}
+		outcome, err = tx.outcome(ctx, request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+func (s *approvalServiceImpl) Approve(ctx context.Context, doc *integration.ApprovalDocument, approverID, comments string) (*integration.ApprovalOutcome, error) {
+	if approverID == "" {
+		return nil, errors.New("approver ID is required")
+	}
+
+	var outcome *integration.ApprovalOutcome
+	err := s.inTransaction(ctx, func(tx *approvalServiceImpl) error {
+		var err error
+		outcome, err = tx.approve(ctx, doc, approverID, comments)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+func (s *approvalServiceImpl) approve(ctx context.Context, doc *integration.ApprovalDocument, approverID, comments string) (*integration.ApprovalOutcome, error) {
+	request, err := s.lockedRequest(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != integration.ApprovalStatusPending {
+		return s.outcome(ctx, request)
+	}
+
+	tasks, err := s.repo.ListTasksByRequest(ctx, request.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval tasks: %w", err)
+	}
+	stepTasks := tasksAtStep(tasks, request.CurrentStepOrder)
+	now := s.now()
+
+	if len(stepTasks) == 0 {
+		// Default policy (no workflow) or a step whose approvers could not be resolved
+		if err := s.authorizeOpenStep(ctx, request, approverID); err != nil {
+			return nil, err
+		}
+		if err := s.addHistory(ctx, request, nil, &approverID, entities.HistoryActionApproved, comments); err != nil {
+			return nil, err
+		}
+		if err := s.advance(ctx, request, now); err != nil {
+			return nil, err
+		}
+		return s.outcome(ctx, request)
+	}
+
+	mine := pendingTasksOf(stepTasks, approverID)
+	if len(mine) == 0 {
+		return nil, integration.ErrNotApprover
+	}
+	for i := range mine {
+		mine[i].Status = entities.TaskStatusApproved
+		mine[i].ActedAt = &now
+		mine[i].Comments = comments
+		if err := s.repo.UpdateTask(ctx, &mine[i]); err != nil {
+			return nil, fmt.Errorf("failed to update approval task: %w", err)
+		}
+		taskID := mine[i].ID.String()
+		if err := s.addHistory(ctx, request, &taskID, &approverID, entities.HistoryActionApproved, comments); err != nil {
+			return nil, err
+		}
+	}
+
+	// Re-read the step with the decisions applied
+	stepTasks = replaceTasks(stepTasks, mine)
+	complete, err := s.completeStep(ctx, stepTasks)
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		if err := s.advance(ctx, request, now); err != nil {
+			return nil, err
+		}
+	}
+	return s.outcome(ctx, request)
+}
+
+func (s *approvalServiceImpl) Reject(ctx context.Context, doc *integration.ApprovalDocument, approverID, reason string) (*integration.ApprovalOutcome, error) {
+	if approverID == "" {
+		return nil, errors.New("approver ID is required")
+	}
+
+	var outcome *integration.ApprovalOutcome
+	err := s.inTransaction(ctx, func(tx *approvalServiceImpl) error {
+		var err error
+		outcome, err = tx.reject(ctx, doc, approverID, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+func (s *approvalServiceImpl) reject(ctx context.Context, doc *integration.ApprovalDocument, approverID, reason string) (*integration.ApprovalOutcome, error) {
+	request, err := s.lockedRequest(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != integration.ApprovalStatusPending {
+		return nil, integration.ErrApprovalNotPending
+	}
+
+	tasks, err := s.repo.ListTasksByRequest(ctx, request.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval tasks: %w", err)
+	}
+	stepTasks := tasksAtStep(tasks, request.CurrentStepOrder)
+	now := s.now()
+
+	var taskID *string
+	if len(stepTasks) == 0 {
+		if err := s.authorizeOpenStep(ctx, request, approverID); err != nil {
+			return nil, err
+		}
+	} else {
+		mine := pendingTasksOf(stepTasks, approverID)
+		if len(mine) == 0 {
+			return nil, integration.ErrNotApprover
+		}
+		mine[0].Status = entities.TaskStatusRejected
+		mine[0].ActedAt = &now
+		mine[0].Comments = reason
+		if err := s.repo.UpdateTask(ctx, &mine[0]); err != nil {
+			return nil, fmt.Errorf("failed to update approval task: %w", err)
+		}
+		id := mine[0].ID.String()
+		taskID = &id
+	}
+
+	if err := s.skipPendingTasks(ctx, tasks, taskID); err != nil {
+		return nil, err
+	}
+	if err := s.addHistory(ctx, request, taskID, &approverID, entities.HistoryActionRejected, reason); err != nil {
+		return nil, err
+	}
+	if err := s.finish(ctx, request, integration.ApprovalStatusRejected, now); err != nil {
+		return nil, err
+	}
+	return s.outcome(ctx, request)
+}
+
+func (s *approvalServiceImpl) Cancel(ctx context.Context, documentType integration.ApprovalDocumentType, documentID, actorID, reason string) error {
+	return s.inTransaction(ctx, func(tx *approvalServiceImpl) error {
+		return tx.cancel(ctx, documentType, documentID, actorID, reason)
+	})
+}
+
+func (s *approvalServiceImpl) cancel(ctx context.Context, documentType integration.ApprovalDocumentType, documentID, actorID, reason string) error {
+	request, err := s.repo.GetPendingRequest(ctx, documentType, documentID)
+	if err != nil {
+		return fmt.Errorf("failed to load approval request: %w", err)
+	}
+	if request == nil {
+		return nil
+	}
+	if request, err = s.repo.LockRequest(ctx, request.ID.String()); err != nil {
+		return fmt.Errorf("failed to lock approval request: %w", err)
+	}
+	if request.Status != integration.ApprovalStatusPending {
+		// Decided while we waited for the lock
+		return nil
+	}
+
+	tasks, err := s.repo.ListTasksByRequest(ctx, request.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to load approval tasks: %w", err)
+	}
+	if err := s.skipPendingTasks(ctx, tasks, nil); err != nil {
+		return err
+	}
+	var actor *string
+	if actorID != "" {
+		actor = &actorID
+	}
+	if err := s.addHistory(ctx, request, nil, actor, entities.HistoryActionCancelled, reason); err != nil {
+		return err
+	}
+	return s.finish(ctx, request, integration.ApprovalStatusCancelled, s.now())
+}
+
+// submit starts a new approval request unless one is already pending
+func (s *approvalServiceImpl) submit(ctx context.Context, doc *integration.ApprovalDocument) (*entities.ApprovalRequest, error) {
+	if doc == nil || doc.DocumentType == "" || doc.DocumentID == "" {
+		return nil, errors.New("document type and ID are required")
+	}
+
+	existing, err := s.repo.GetPendingRequest(ctx, doc.DocumentType, doc.DocumentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval request: %w", err)
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	workflow, err := s.repo.FindActiveWorkflow(ctx, doc.DocumentType, doc.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval workflow: %w", err)
+	}
+
+	now := s.now()
+	request := &entities.ApprovalRequest{
+		ID:             uuid.New(),
+		CompanyID:      optionalString(doc.CompanyID),
+		DocumentType:   doc.DocumentType,
+		DocumentID:     doc.DocumentID,
+		DocumentNumber: doc.DocumentNumber,
+		RequesterID:    optionalString(doc.RequesterID),
+		Department:     doc.Department,
+		Amount:         doc.Amount,
+		Status:         integration.ApprovalStatusPending,
+		SubmittedAt:    now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	request.RequesterEmployeeID = optionalString(doc.RequesterEmployeeID)
+	if workflow != nil {
+		workflowID := workflow.ID.String()
+		request.WorkflowID = &workflowID
+	} else {
+		// Default policy: a single step decided by RBAC
+		request.CurrentStepOrder = 1
+	}
+
+	if err := s.repo.CreateRequest(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to create approval request: %w", err)
+	}
+	if err := s.addHistory(ctx, request, nil, request.RequesterID, entities.HistoryActionSubmitted, ""); err != nil {
+		return nil, err
+	}
+
+	if workflow != nil {
+		if err := s.advance(ctx, request, now); err != nil {
+			return nil, err
+		}
+	}
+	return request, nil
+}
+
+// currentRequest returns the pending request of a document, submitting it when none exists.
+// A document whose latest request is already approved returns that request.
+func (s *approvalServiceImpl) currentRequest(ctx context.Context, doc *integration.ApprovalDocument) (*entities.ApprovalRequest, error) {
+	if doc == nil || doc.DocumentType == "" || doc.DocumentID == "" {
+		return nil, errors.New("document type and ID are required")
+	}
+
+	pending, err := s.repo.GetPendingRequest(ctx, doc.DocumentType, doc.DocumentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval request: %w", err)
+	}
+	if pending != nil {
+		return pending, nil
+	}
+
+	previous, err := s.repo.ListRequestsByDocument(ctx, doc.DocumentType, doc.DocumentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval requests: %w", err)
+	}
+	if len(previous) > 0 && previous[0].Status == integration.ApprovalStatusApproved {
+		return &previous[0], nil
+	}
+	return s.submit(ctx, doc)
+}
+
+// lockedRequest returns the current request of a document, locking a pending one so
+// parallel approvers decide one after the other and see each other's decisions
+func (s *approvalServiceImpl) lockedRequest(ctx context.Context, doc *integration.ApprovalDocument) (*entities.ApprovalRequest, error) {
+	request, err := s.currentRequest(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != integration.ApprovalStatusPending {
+		return request, nil
+	}
+	locked, err := s.repo.LockRequest(ctx, request.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock approval request: %w", err)
+	}
+	return locked, nil
+}
+
+// advance activates the next step order with applicable steps, or approves the request
+// when no steps remain
+func (s *approvalServiceImpl) advance(ctx context.Context, request *entities.ApprovalRequest, now time.Time) error {
+	var steps []entities.ApprovalStep
+	if request.WorkflowID != nil {
+		workflow, err := s.repo.GetWorkflowByID(ctx, *request.WorkflowID)
+		if err != nil {
+			return fmt.Errorf("failed to load approval workflow: %w", err)
+		}
+		if workflow != nil {
+			steps = workflow.Steps
+		}
+	}
+
+	doc := request.Document()
+	nextOrder := 0
+	var next []entities.ApprovalStep
+	for _, step := range sortedSteps(steps) {
+		if step.StepOrder <= request.CurrentStepOrder || !step.AppliesTo(doc) {
+			continue
+		}
+		if nextOrder == 0 {
+			nextOrder = step.StepOrder
+		}
+		if step.StepOrder != nextOrder {
+			break
+		}
+		next = append(next, step)
+	}
+
+	if nextOrder == 0 {
+		if err := s.addHistory(ctx, request, nil, nil, entities.HistoryActionCompleted, ""); err != nil {
+			return err
+		}
+		return s.finish(ctx, request, integration.ApprovalStatusApproved, now)
+	}
+
+	request.CurrentStepOrder = nextOrder
+	request.UpdatedAt = now
+	if err := s.repo.UpdateRequest(ctx, request); err != nil {
+		return fmt.Errorf("failed to update approval request: %w", err)
+	}
+
+	// Resolve every parallel step before assigning any: a step nobody can decide must not
+	// drop out of the chain while the others are assigned
+	approvers := make([][]string, len(next))
+	var unresolved []string
+	for i := range next {
+		resolved, err := s.resolveApprovers(ctx, request, &next[i])
+		if err != nil {
+			return err
+		}
+		approvers[i] = resolved
+		if len(resolved) == 0 {
+			unresolved = append(unresolved, next[i].Name)
+		}
+	}
+	if len(unresolved) == len(next) {
+		// Nobody to route to: the step stays open to any authorized approver
+		log.Printf("[Approval] No approvers resolved for step %q of %s %s, falling back to default policy",
+			strings.Join(unresolved, ", "), request.DocumentType, request.DocumentNumber)
+		return nil
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("%w: step %q of %s %s", integration.ErrNoApprovers,
+			strings.Join(unresolved, ", "), request.DocumentType, request.DocumentNumber)
+	}
+
+	for i := range next {
+		if err := s.assign(ctx, request, &next[i], approvers[i], nil, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assign creates tasks for the approvers of a step, routing each through delegations
+func (s *approvalServiceImpl) assign(ctx context.Context, request *entities.ApprovalRequest, step *entities.ApprovalStep, approvers []string, escalatedFrom *string, now time.Time) error {
+	stepID := step.ID.String()
+	var dueAt *time.Time
+	if step.EscalationHours > 0 {
+		due := now.Add(time.Duration(step.EscalationHours) * time.Hour)
+		dueAt = &due
+	}
+
+	assigned := make(map[string]bool, len(approvers))
+	for _, approverID := range approvers {
+		routedTo, reason, err := s.route(ctx, request, approverID, now)
+		if err != nil {
+			return err
+		}
+		if assigned[routedTo] {
+			continue
+		}
+		assigned[routedTo] = true
+
+		task := &entities.ApprovalTask{
+			ID:         uuid.New(),
+			RequestID:  request.ID,
+			StepID:     &stepID,
+			StepOrder:  step.StepOrder,
+			StepName:   step.Name,
+			Mode:       step.Mode,
+			ApproverID: routedTo,
+			Status:     entities.TaskStatusPending,
+			DueAt:      dueAt,
+			CreatedAt:  now,
+		}
+		switch {
+		case escalatedFrom != nil:
+			task.OriginalApproverID = escalatedFrom
+		case routedTo != approverID:
+			original := approverID
+			task.OriginalApproverID = &original
+		}
+		if err := s.repo.CreateTask(ctx, task); err != nil {
+			return fmt.Errorf("failed to create approval task: %w", err)
+		}
+
+		taskID := task.ID.String()
+		if routedTo != approverID {
+			if err := s.addHistory(ctx, request, &taskID, &approverID, entities.HistoryActionDelegated, reason); err != nil {
+				return err
+			}
+		}
+		if err := s.addHistory(ctx, request, &taskID, &task.ApproverID, entities.HistoryActionAssigned, step.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// route applies an active delegation of the approver, or hands the task to the approver's
+// supervisor while the approver is on leave
+func (s *approvalServiceImpl) route(ctx context.Context, request *entities.ApprovalRequest, approverID string, now time.Time) (string, string, error) {
+	delegation, err := s.repo.FindActiveDelegation(ctx, approverID, request.DocumentType, now)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load approval delegation: %w", err)
+	}
+	if delegation != nil && !isRequester(request, delegation.DelegateID) {
+		return delegation.DelegateID, "delegated: " + delegation.Reason, nil
+	}
+
+	onLeave, err := s.directory.IsOnLeave(ctx, approverID, now)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check approver leave: %w", err)
+	}
+	if onLeave {
+		supervisor, err := s.directory.SupervisorOf(ctx, "", approverID)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve approver supervisor: %w", err)
+		}
+		if supervisor != "" && !isRequester(request, supervisor) {
+			return supervisor, "approver on leave", nil
+		}
+	}
+	return approverID, "", nil
+}
+
+// resolveApprovers lists the users who decide a step, excluding the requester
+func (s *approvalServiceImpl) resolveApprovers(ctx context.Context, request *entities.ApprovalRequest, step *entities.ApprovalStep) ([]string, error) {
+	companyID := derefString(request.CompanyID)
+
+	var approvers []string
+	var err error
+	switch step.ApproverType {
+	case entities.ApproverTypeUser:
+		approvers = []string{derefString(step.ApproverUserID)}
+	case entities.ApproverTypeRole:
+		approvers, err = s.directory.UsersWithRole(ctx, step.ApproverRole, companyID)
+	case entities.ApproverTypeDepartment:
+		department := step.ApproverDepartment
+		if department == "" {
+			department = request.Department
+		}
+		if department != "" {
+			approvers, err = s.directory.DepartmentApprovers(ctx, department, companyID)
+		}
+	case entities.ApproverTypeSupervisor:
+		var supervisor string
+		supervisor, err = s.directory.SupervisorOf(ctx, derefString(request.RequesterEmployeeID), derefString(request.RequesterID))
+		if supervisor != "" {
+			approvers = []string{supervisor}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve approvers for step %q: %w", step.Name, err)
+	}
+
+	approvers = excludeRequester(request, approvers)
+	if len(approvers) > 0 {
+		return approvers, nil
+	}
+	return s.escalationTargets(ctx, request, step, "")
+}
+
+// escalationTargets resolves the escalation user or role of a step, or the supervisor of
+// the current approver when the step has neither
+func (s *approvalServiceImpl) escalationTargets(ctx context.Context, request *entities.ApprovalRequest, step *entities.ApprovalStep, currentApproverID string) ([]string, error) {
+	var targets []string
+	switch {
+	case step.EscalateToUserID != nil && *step.EscalateToUserID != "":
+		targets = []string{*step.EscalateToUserID}
+	case step.EscalateToRole != "":
+		users, err := s.directory.UsersWithRole(ctx, step.EscalateToRole, derefString(request.CompanyID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve escalation role: %w", err)
+		}
+		targets = users
+	case currentApproverID != "":
+		supervisor, err := s.directory.SupervisorOf(ctx, "", currentApproverID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve approver supervisor: %w", err)
+		}
+		if supervisor != "" {
+			targets = []string{supervisor}
+		}
+	}
+
+	filtered := targets[:0]
+	for _, target := range excludeRequester(request, targets) {
+		if target != currentApproverID {
+			filtered = append(filtered, target)
+		}
+	}
+	return filtered, nil
+}
+
+// completeStep reports whether every step of the current step order is decided, marking
+// the leftover tasks of decided "any" steps as skipped
+func (s *approvalServiceImpl) completeStep(ctx context.Context, stepTasks []entities.ApprovalTask) (bool, error) {
+	byStep := make(map[string][]entities.ApprovalTask)
+	for _, task := range stepTasks {
+		if task.Status == entities.TaskStatusEscalated {
+			continue
+		}
+		byStep[derefString(task.StepID)] = append(byStep[derefString(task.StepID)], task)
+	}
+
+	complete := true
+	var skip []entities.ApprovalTask
+	for _, tasks := range byStep {
+		approved, pending := 0, 0
+		for _, task := range tasks {
+			switch task.Status {
+			case entities.TaskStatusApproved:
+				approved++
+			case entities.TaskStatusPending:
+				pending++
+			}
+		}
+		mode := tasks[0].Mode
+		switch {
+		case mode == entities.ApprovalModeAny && approved > 0:
+			for _, task := range tasks {
+				if task.Status == entities.TaskStatusPending {
+					skip = append(skip, task)
+				}
+			}
+		case mode == entities.ApprovalModeAll && pending == 0 && approved > 0:
+		default:
+			complete = false
+		}
+	}
+
+	// Decided "any" steps leave the other approvers' inboxes even while parallel steps are open
+	for i := range skip {
+		skip[i].Status = entities.TaskStatusSkipped
+		if err := s.repo.UpdateTask(ctx, &skip[i]); err != nil {
+			return false, fmt.Errorf("failed to update approval task: %w", err)
+		}
+	}
+	return complete, nil
+}
+
+func (s *approvalServiceImpl) authorizeOpenStep(ctx context.Context, request *entities.ApprovalRequest, approverID string) error {
+	if isRequester(request, approverID) {
+		return errors.New("requester cannot approve their own document")
+	}
+	if s.authorizer == nil {
+		return nil
+	}
+	ok, err := s.authorizer.CanApprove(ctx, approverID, derefString(request.RequesterID))
+	if err != nil {
+		return fmt.Errorf("%w: %v", integration.ErrNotApprover, err)
+	}
+	if !ok {
+		return integration.ErrNotApprover
+	}
+	return nil
+}
+
+func (s *approvalServiceImpl) skipPendingTasks(ctx context.Context, tasks []entities.ApprovalTask, exceptTaskID *string) error {
+	for i := range tasks {
+		if tasks[i].Status != entities.TaskStatusPending {
+			continue
+		}
+		if exceptTaskID != nil && tasks[i].ID.String() == *exceptTaskID {
+			continue
+		}
+		tasks[i].Status = entities.TaskStatusSkipped
+		if err := s.repo.UpdateTask(ctx, &tasks[i]); err != nil {
+			return fmt.Errorf("failed to update approval task: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *approvalServiceImpl) finish(ctx context.Context, request *entities.ApprovalRequest, status integration.ApprovalStatus, now time.Time) error {
+	request.Status = status
+	request.CompletedAt = &now
+	request.UpdatedAt = now
+	if err := s.repo.UpdateRequest(ctx, request); err != nil {
+		return fmt.Errorf("failed to update approval request: %w", err)
+	}
+	return nil
+}
+
+func (s *approvalServiceImpl) addHistory(ctx context.Context, request *entities.ApprovalRequest, taskID, actorID *string, action entities.HistoryAction, comments string) error {
+	entry := &entities.ApprovalHistory{
+		ID:        uuid.New(),
+		RequestID: request.ID,
+		TaskID:    taskID,
+		StepOrder: request.CurrentStepOrder,
+		ActorID:   actorID,
+		Action:    action,
+		Comments:  comments,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.AddHistory(ctx, entry); err != nil {
+		return fmt.Errorf("failed to write approval history: %w", err)
+	}
+	return nil
+}
+
+func (s *approvalServiceImpl) outcome(ctx context.Context, request *entities.ApprovalRequest) (*integration.ApprovalOutcome, error) {
+	outcome := &integration.ApprovalOutcome{
+		RequestID:   request.ID.String(),
+		Status:      request.Status,
+		CurrentStep: request.CurrentStepOrder,
+	}
+	if request.Status != integration.ApprovalStatusPending {
+		return outcome, nil
+	}
+
+	tasks, err := s.repo.ListTasksByRequest(ctx, request.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval tasks: %w", err)
+	}
+	for _, task := range tasksAtStep(tasks, request.CurrentStepOrder) {
+		if task.Status == entities.TaskStatusPending {
+			outcome.PendingApprovers = append(outcome.PendingApprovers, task.ApproverID)
+		}
+	}
+	return outcome, nil
+}
+
+// Requests and history
+
+func (s *approvalServiceImpl) GetRequest(ctx context.Context, id string) (*entities.ApprovalRequestDetail, error) {
+	request, err := s.repo.GetRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errors.New("approval request not found")
+	}
+	return s.detail(ctx, request)
+}
+
+func (s *approvalServiceImpl) ListRequests(ctx context.Context, filter *entities.ApprovalRequestFilter) ([]entities.ApprovalRequest, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	return s.repo.ListRequests(ctx, filter)
+}
+
+func (s *approvalServiceImpl) GetDocumentHistory(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) ([]entities.ApprovalRequestDetail, error) {
+	requests, err := s.repo.ListRequestsByDocument(ctx, documentType, documentID)
+	if err != nil {
+		return nil, err
+	}
+	details := make([]entities.ApprovalRequestDetail, 0, len(requests))
+	for i := range requests {
+		detail, err := s.detail(ctx, &requests[i])
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, *detail)
+	}
+	return details, nil
+}
+
+func (s *approvalServiceImpl) detail(ctx context.Context, request *entities.ApprovalRequest) (*entities.ApprovalRequestDetail, error) {
+	tasks, err := s.repo.ListTasksByRequest(ctx, request.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repo.ListHistory(ctx, request.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ApprovalRequestDetail{ApprovalRequest: *request, Tasks: tasks, History: history}, nil
+}
+
+func (s *approvalServiceImpl) ListInbox(ctx context.Context, approverID string) ([]entities.ApprovalTask, error) {
+	if approverID == "" {
+		return nil, errors.New("approver ID is required")
+	}
+	return s.repo.ListPendingTasksByApprover(ctx, approverID)
+}
+
+// Delegations
+
+func (s *approvalServiceImpl) CreateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error {
+	if err := delegation.Validate(); err != nil {
+		return err
+	}
+	now := s.now()
+	delegation.ID = uuid.New()
+	delegation.IsActive = true
+	delegation.CreatedAt = now
+	delegation.UpdatedAt = now
+	return s.repo.CreateDelegation(ctx, delegation)
+}
+
+func (s *approvalServiceImpl) ListDelegations(ctx context.Context, userID string) ([]entities.ApprovalDelegation, error) {
+	return s.repo.ListDelegations(ctx, userID)
+}
+
+// RevokeDelegation deactivates a delegation; only the delegator may revoke it
+func (s *approvalServiceImpl) RevokeDelegation(ctx context.Context, id, userID string) error {
+	delegation, err := s.repo.GetDelegationByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if delegation == nil {
+		return errors.New("approval delegation not found")
+	}
+	if delegation.DelegatorID != userID {
+		return errors.New("only the delegator can revoke a delegation")
+	}
+	delegation.IsActive = false
+	delegation.UpdatedAt = s.now()
+	return s.repo.UpdateDelegation(ctx, delegation)
+}
+
+// Escalation
+
+func (s *approvalServiceImpl) ProcessEscalations(ctx context.Context) (int, error) {
+	now := s.now()
+	overdue, err := s.repo.ListOverdueTasks(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load overdue approval tasks: %w", err)
+	}
+
+	workflows := make(map[string]*entities.ApprovalWorkflow)
+	escalated := 0
+	for i := range overdue {
+		var done bool
+		err := s.inTransaction(ctx, func(tx *approvalServiceImpl) error {
+			var err error
+			done, err = tx.escalate(ctx, &overdue[i], workflows, now)
+			return err
+		})
+		if err != nil {
+			return escalated, err
+		}
+		if done {
+			escalated++
+		}
+	}
+	return escalated, nil
+}
+
+// escalate hands an overdue task to the escalation targets of its step. The request is
+// locked and the task re-read first, so a task decided meanwhile is left alone.
+func (s *approvalServiceImpl) escalate(ctx context.Context, overdue *entities.ApprovalTask, workflows map[string]*entities.ApprovalWorkflow, now time.Time) (bool, error) {
+	request, err := s.repo.LockRequest(ctx, overdue.RequestID.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to lock approval request: %w", err)
+	}
+	if request.Status != integration.ApprovalStatusPending || request.WorkflowID == nil {
+		return false, nil
+	}
+	tasks, err := s.repo.ListTasksByRequest(ctx, request.ID.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to load approval tasks: %w", err)
+	}
+	var task *entities.ApprovalTask
+	for i := range tasks {
+		if tasks[i].ID == overdue.ID && tasks[i].Status == entities.TaskStatusPending {
+			task = &tasks[i]
+		}
+	}
+	if task == nil {
+		return false, nil
+	}
+
+	workflow, ok := workflows[*request.WorkflowID]
+	if !ok {
+		if workflow, err = s.repo.GetWorkflowByID(ctx, *request.WorkflowID); err != nil {
+			return false, err
+		}
+		workflows[*request.WorkflowID] = workflow
+	}
+	step := findStep(workflow, derefString(task.StepID))
+	if step == nil {
+		return false, nil
+	}
+
+	targets, err := s.escalationTargets(ctx, request, step, task.ApproverID)
+	if err != nil {
+		return false, err
+	}
+	if len(targets) == 0 {
+		// Nowhere to escalate: stop retrying this task
+		task.DueAt = nil
+		if err := s.repo.UpdateTask(ctx, task); err != nil {
+			return false, err
+		}
+		log.Printf("[Approval] No escalation target for task %s of %s %s", task.ID, request.DocumentType, request.DocumentNumber)
+		return false, nil
+	}
+
+	task.Status = entities.TaskStatusEscalated
+	task.ActedAt = &now
+	if err := s.repo.UpdateTask(ctx, task); err != nil {
+		return false, err
+	}
+	taskID := task.ID.String()
+	comment := fmt.Sprintf("not decided within %d hours", step.EscalationHours)
+	if err := s.addHistory(ctx, request, &taskID, nil, entities.HistoryActionEscalated, comment); err != nil {
+		return false, err
+	}
+	from := task.ApproverID
+	if err := s.assign(ctx, request, step, targets, &from, now); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Helpers
+
+func sortedSteps(steps []entities.ApprovalStep) []entities.ApprovalStep {
+	sorted := make([]entities.ApprovalStep, len(steps))
+	copy(sorted, steps)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StepOrder < sorted[j].StepOrder })
+	return sorted
+}
+
+func findStep(workflow *entities.ApprovalWorkflow, stepID string) *entities.ApprovalStep {
+	if workflow == nil {
+		return nil
+	}
+	for i := range workflow.Steps {
+		if workflow.Steps[i].ID.String() == stepID {
+			return &workflow.Steps[i]
+		}
+	}
+	return nil
+}
+
+func tasksAtStep(tasks []entities.ApprovalTask, stepOrder int) []entities.ApprovalTask {
+	var result []entities.ApprovalTask
+	for _, task := range tasks {
+		if task.StepOrder == stepOrder {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+func pendingTasksOf(tasks []entities.ApprovalTask, approverID string) []entities.ApprovalTask {
+	var result []entities.ApprovalTask
+	for _, task := range tasks {
+		if task.ApproverID == approverID && task.Status == entities.TaskStatusPending {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+func replaceTasks(tasks, updated []entities.ApprovalTask) []entities.ApprovalTask {
+	for i := range tasks {
+		for _, u := range updated {
+			if tasks[i].ID == u.ID {
+				tasks[i] = u
+			}
+		}
+	}
+	return tasks
+}
+
+func excludeRequester(request *entities.ApprovalRequest, users []string) []string {
+	result := make([]string, 0, len(users))
+	seen := make(map[string]bool, len(users))
+	for _, user := range users {
+		if user == "" || seen[user] || isRequester(request, user) {
+			continue
+		}
+		seen[user] = true
+		result = append(result, user)
+	}
+	return result
+}
+
+func isRequester(request *entities.ApprovalRequest, userID string) bool {
+	return request.RequesterID != nil && *request.RequesterID == userID
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
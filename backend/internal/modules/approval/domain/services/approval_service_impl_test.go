@@ -0,0 +1,873 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/approval/domain/entities"
+	"malaka/internal/modules/approval/domain/repositories"
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/uuid"
+)
+
+// MockApprovalRepository is a mock implementation of repositories.ApprovalRepository.
+// InTransaction runs the callback against the mock itself.
+type MockApprovalRepository struct {
+	mock.Mock
+}
+
+func (m *MockApprovalRepository) InTransaction(ctx context.Context, fn func(repo repositories.ApprovalRepository) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+func (m *MockApprovalRepository) CreateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error {
+	args := m.Called(ctx, workflow)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) GetWorkflowByID(ctx context.Context, id string) (*entities.ApprovalWorkflow, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ApprovalWorkflow), args.Error(1)
+}
+
+func (m *MockApprovalRepository) ListWorkflows(ctx context.Context, companyID string, documentType string) ([]entities.ApprovalWorkflow, error) {
+	args := m.Called(ctx, companyID, documentType)
+	return args.Get(0).([]entities.ApprovalWorkflow), args.Error(1)
+}
+
+func (m *MockApprovalRepository) UpdateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error {
+	args := m.Called(ctx, workflow)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) DeleteWorkflow(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) FindActiveWorkflow(ctx context.Context, documentType integration.ApprovalDocumentType, companyID string) (*entities.ApprovalWorkflow, error) {
+	args := m.Called(ctx, documentType, companyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ApprovalWorkflow), args.Error(1)
+}
+
+func (m *MockApprovalRepository) CreateRequest(ctx context.Context, request *entities.ApprovalRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) UpdateRequest(ctx context.Context, request *entities.ApprovalRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) GetRequestByID(ctx context.Context, id string) (*entities.ApprovalRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ApprovalRequest), args.Error(1)
+}
+
+func (m *MockApprovalRepository) LockRequest(ctx context.Context, id string) (*entities.ApprovalRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ApprovalRequest), args.Error(1)
+}
+
+func (m *MockApprovalRepository) GetPendingRequest(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) (*entities.ApprovalRequest, error) {
+	args := m.Called(ctx, documentType, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ApprovalRequest), args.Error(1)
+}
+
+func (m *MockApprovalRepository) ListRequests(ctx context.Context, filter *entities.ApprovalRequestFilter) ([]entities.ApprovalRequest, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entities.ApprovalRequest), args.Int(1), args.Error(2)
+}
+
+func (m *MockApprovalRepository) ListRequestsByDocument(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) ([]entities.ApprovalRequest, error) {
+	args := m.Called(ctx, documentType, documentID)
+	return args.Get(0).([]entities.ApprovalRequest), args.Error(1)
+}
+
+func (m *MockApprovalRepository) CreateTask(ctx context.Context, task *entities.ApprovalTask) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) UpdateTask(ctx context.Context, task *entities.ApprovalTask) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) ListTasksByRequest(ctx context.Context, requestID string) ([]entities.ApprovalTask, error) {
+	args := m.Called(ctx, requestID)
+	return args.Get(0).([]entities.ApprovalTask), args.Error(1)
+}
+
+func (m *MockApprovalRepository) ListPendingTasksByApprover(ctx context.Context, approverID string) ([]entities.ApprovalTask, error) {
+	args := m.Called(ctx, approverID)
+	return args.Get(0).([]entities.ApprovalTask), args.Error(1)
+}
+
+func (m *MockApprovalRepository) ListOverdueTasks(ctx context.Context, now time.Time) ([]entities.ApprovalTask, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]entities.ApprovalTask), args.Error(1)
+}
+
+func (m *MockApprovalRepository) AddHistory(ctx context.Context, entry *entities.ApprovalHistory) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) ListHistory(ctx context.Context, requestID string) ([]entities.ApprovalHistory, error) {
+	args := m.Called(ctx, requestID)
+	return args.Get(0).([]entities.ApprovalHistory), args.Error(1)
+}
+
+func (m *MockApprovalRepository) CreateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error {
+	args := m.Called(ctx, delegation)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) GetDelegationByID(ctx context.Context, id string) (*entities.ApprovalDelegation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ApprovalDelegation), args.Error(1)
+}
+
+func (m *MockApprovalRepository) ListDelegations(ctx context.Context, userID string) ([]entities.ApprovalDelegation, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entities.ApprovalDelegation), args.Error(1)
+}
+
+func (m *MockApprovalRepository) UpdateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error {
+	args := m.Called(ctx, delegation)
+	return args.Error(0)
+}
+
+func (m *MockApprovalRepository) FindActiveDelegation(ctx context.Context, delegatorID string, documentType integration.ApprovalDocumentType, date time.Time) (*entities.ApprovalDelegation, error) {
+	args := m.Called(ctx, delegatorID, documentType, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ApprovalDelegation), args.Error(1)
+}
+
+// MockApproverDirectory is a mock implementation of ApproverDirectory.
+type MockApproverDirectory struct {
+	mock.Mock
+}
+
+func (m *MockApproverDirectory) UsersWithRole(ctx context.Context, roleName, companyID string) ([]string, error) {
+	args := m.Called(ctx, roleName, companyID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockApproverDirectory) DepartmentApprovers(ctx context.Context, department, companyID string) ([]string, error) {
+	args := m.Called(ctx, department, companyID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockApproverDirectory) SupervisorOf(ctx context.Context, employeeID, userID string) (string, error) {
+	args := m.Called(ctx, employeeID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockApproverDirectory) IsOnLeave(ctx context.Context, userID string, date time.Time) (bool, error) {
+	args := m.Called(ctx, userID, date)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockApprovalAuthorizer is a mock implementation of ApprovalAuthorizer.
+type MockApprovalAuthorizer struct {
+	mock.Mock
+}
+
+func (m *MockApprovalAuthorizer) CanApprove(ctx context.Context, approverID, requesterID string) (bool, error) {
+	args := m.Called(ctx, approverID, requesterID)
+	return args.Bool(0), args.Error(1)
+}
+
+var approvalTestNow = time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+func newTestApprovalService(repo *MockApprovalRepository, directory *MockApproverDirectory, authorizer ApprovalAuthorizer) *approvalServiceImpl {
+	svc := NewApprovalService(repo, directory, authorizer).(*approvalServiceImpl)
+	svc.now = func() time.Time { return approvalTestNow }
+	return svc
+}
+
+func testWorkflow(steps ...entities.ApprovalStep) *entities.ApprovalWorkflow {
+	workflow := &entities.ApprovalWorkflow{
+		ID:           uuid.New(),
+		DocumentType: integration.ApprovalDocPurchaseOrder,
+		Name:         "PO approval",
+		IsActive:     true,
+		Steps:        steps,
+	}
+	prepareSteps(workflow, approvalTestNow)
+	return workflow
+}
+
+func userStep(order int, name, userID string) entities.ApprovalStep {
+	return entities.ApprovalStep{
+		StepOrder:      order,
+		Name:           name,
+		Mode:           entities.ApprovalModeAny,
+		ApproverType:   entities.ApproverTypeUser,
+		ApproverUserID: &userID,
+	}
+}
+
+func testPurchaseOrder(amount float64) *integration.ApprovalDocument {
+	return &integration.ApprovalDocument{
+		DocumentType:   integration.ApprovalDocPurchaseOrder,
+		DocumentID:     "po-1",
+		DocumentNumber: "PO-0001",
+		RequesterID:    "requester",
+		Department:     "Operations",
+		Amount:         amount,
+	}
+}
+
+// pendingRequest is the pending request of testPurchaseOrder at a step of the workflow
+// (or of the default policy when the workflow is nil).
+func pendingRequest(workflow *entities.ApprovalWorkflow, stepOrder int, amount float64) *entities.ApprovalRequest {
+	requester := "requester"
+	request := &entities.ApprovalRequest{
+		ID:               uuid.New(),
+		DocumentType:     integration.ApprovalDocPurchaseOrder,
+		DocumentID:       "po-1",
+		DocumentNumber:   "PO-0001",
+		RequesterID:      &requester,
+		Department:       "Operations",
+		Amount:           amount,
+		Status:           integration.ApprovalStatusPending,
+		CurrentStepOrder: stepOrder,
+		SubmittedAt:      approvalTestNow,
+	}
+	if workflow != nil {
+		workflowID := workflow.ID.String()
+		request.WorkflowID = &workflowID
+	}
+	return request
+}
+
+func pendingTask(request *entities.ApprovalRequest, step *entities.ApprovalStep, approverID string) entities.ApprovalTask {
+	stepID := step.ID.String()
+	return entities.ApprovalTask{
+		ID:         uuid.New(),
+		RequestID:  request.ID,
+		StepID:     &stepID,
+		StepOrder:  step.StepOrder,
+		StepName:   step.Name,
+		Mode:       step.Mode,
+		ApproverID: approverID,
+		Status:     entities.TaskStatusPending,
+	}
+}
+
+func withStatus(task entities.ApprovalTask, status entities.TaskStatus) entities.ApprovalTask {
+	task.Status = status
+	return task
+}
+
+// calledWith returns the second argument of every recorded call of a method.
+func calledWith(repo *MockApprovalRepository, method string) []interface{} {
+	var values []interface{}
+	for _, call := range repo.Calls {
+		if call.Method == method {
+			values = append(values, call.Arguments.Get(1))
+		}
+	}
+	return values
+}
+
+func createdTasks(repo *MockApprovalRepository) []*entities.ApprovalTask {
+	var tasks []*entities.ApprovalTask
+	for _, value := range calledWith(repo, "CreateTask") {
+		tasks = append(tasks, value.(*entities.ApprovalTask))
+	}
+	return tasks
+}
+
+func updatedTasks(repo *MockApprovalRepository) map[string]entities.TaskStatus {
+	statuses := make(map[string]entities.TaskStatus)
+	for _, value := range calledWith(repo, "UpdateTask") {
+		task := value.(*entities.ApprovalTask)
+		statuses[task.ApproverID] = task.Status
+	}
+	return statuses
+}
+
+func historyActions(repo *MockApprovalRepository) []entities.HistoryAction {
+	var actions []entities.HistoryAction
+	for _, value := range calledWith(repo, "AddHistory") {
+		actions = append(actions, value.(*entities.ApprovalHistory).Action)
+	}
+	return actions
+}
+
+func TestApprovalService_SubmitAssignsFirstStep(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	directory := new(MockApproverDirectory)
+	svc := newTestApprovalService(repo, directory, nil)
+	workflow := testWorkflow(userStep(1, "Manager", "manager"), userStep(2, "Director", "director"))
+	doc := testPurchaseOrder(1000)
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, doc.DocumentType, doc.DocumentID).Return(nil, nil).Once()
+	repo.On("FindActiveWorkflow", ctx, doc.DocumentType, "").Return(workflow, nil).Once()
+	repo.On("CreateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+	repo.On("UpdateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	repo.On("FindActiveDelegation", ctx, "manager", doc.DocumentType, approvalTestNow).Return(nil, nil).Once()
+	directory.On("IsOnLeave", ctx, "manager", approvalTestNow).Return(false, nil).Once()
+	repo.On("CreateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Twice()
+	repo.On("ListTasksByRequest", ctx, mock.AnythingOfType("string")).Return([]entities.ApprovalTask{
+		{StepOrder: 1, ApproverID: "manager", Status: entities.TaskStatusPending},
+	}, nil).Once()
+
+	outcome, err := svc.Submit(ctx, doc)
+	require.NoError(t, err)
+	assert.Equal(t, integration.ApprovalStatusPending, outcome.Status)
+	assert.Equal(t, 1, outcome.CurrentStep)
+	assert.Equal(t, []string{"manager"}, outcome.PendingApprovers)
+
+	tasks := createdTasks(repo)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "manager", tasks[0].ApproverID)
+	assert.Equal(t, 1, tasks[0].StepOrder)
+	assert.Equal(t, []entities.HistoryAction{entities.HistoryActionSubmitted, entities.HistoryActionAssigned}, historyActions(repo))
+	repo.AssertExpectations(t)
+	directory.AssertExpectations(t)
+}
+
+func TestApprovalService_ApproveAdvancesToNextStep(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	directory := new(MockApproverDirectory)
+	svc := newTestApprovalService(repo, directory, nil)
+	workflow := testWorkflow(userStep(1, "Manager", "manager"), userStep(2, "Director", "director"))
+	request := pendingRequest(workflow, 1, 1000)
+	manager := pendingTask(request, &workflow.Steps[0], "manager")
+	director := pendingTask(request, &workflow.Steps[1], "director")
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Twice()
+	repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Twice()
+	repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Twice()
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{manager}, nil).Twice()
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+		withStatus(manager, entities.TaskStatusApproved), director,
+	}, nil).Once()
+	repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+	repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+	repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+	repo.On("FindActiveDelegation", ctx, "director", request.DocumentType, approvalTestNow).Return(nil, nil).Once()
+	directory.On("IsOnLeave", ctx, "director", approvalTestNow).Return(false, nil).Once()
+	repo.On("CreateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Twice()
+
+	// The director cannot act before the manager's step is complete
+	_, err := svc.Approve(ctx, testPurchaseOrder(1000), "director", "")
+	assert.ErrorIs(t, err, integration.ErrNotApprover)
+
+	outcome, err := svc.Approve(ctx, testPurchaseOrder(1000), "manager", "ok")
+	require.NoError(t, err)
+	assert.False(t, outcome.IsApproved())
+	assert.Equal(t, 2, outcome.CurrentStep)
+	assert.Equal(t, []string{"director"}, outcome.PendingApprovers)
+
+	assert.Equal(t, map[string]entities.TaskStatus{"manager": entities.TaskStatusApproved}, updatedTasks(repo))
+	tasks := createdTasks(repo)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "director", tasks[0].ApproverID)
+	assert.Equal(t, 2, tasks[0].StepOrder)
+	assert.Equal(t, []entities.HistoryAction{entities.HistoryActionApproved, entities.HistoryActionAssigned}, historyActions(repo))
+	repo.AssertExpectations(t)
+	directory.AssertExpectations(t)
+}
+
+func TestApprovalService_ApproveLastStepCompletesRequest(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+	workflow := testWorkflow(userStep(1, "Manager", "manager"), userStep(2, "Director", "director"))
+	request := pendingRequest(workflow, 2, 1000)
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Once()
+	repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Once()
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+		withStatus(pendingTask(request, &workflow.Steps[0], "manager"), entities.TaskStatusApproved),
+		pendingTask(request, &workflow.Steps[1], "director"),
+	}, nil).Once()
+	repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+	repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+	repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Twice()
+
+	outcome, err := svc.Approve(ctx, testPurchaseOrder(1000), "director", "")
+	require.NoError(t, err)
+	assert.True(t, outcome.IsApproved())
+	assert.Equal(t, integration.ApprovalStatusApproved, request.Status)
+	require.NotNil(t, request.CompletedAt)
+	assert.Equal(t, []entities.HistoryAction{entities.HistoryActionApproved, entities.HistoryActionCompleted}, historyActions(repo))
+	repo.AssertExpectations(t)
+}
+
+func TestApprovalService_SubmitResolvesRoleAndDepartmentApprovers(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	directory := new(MockApproverDirectory)
+	svc := newTestApprovalService(repo, directory, nil)
+
+	// Finance (any) and operations (all) decide in parallel at order 1
+	workflow := testWorkflow(
+		entities.ApprovalStep{StepOrder: 1, Name: "Finance", Mode: entities.ApprovalModeAny, ApproverType: entities.ApproverTypeRole, ApproverRole: "Finance Manager"},
+		entities.ApprovalStep{StepOrder: 1, Name: "Operations", Mode: entities.ApprovalModeAll, ApproverType: entities.ApproverTypeDepartment},
+	)
+	doc := testPurchaseOrder(1000)
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, doc.DocumentType, doc.DocumentID).Return(nil, nil).Once()
+	repo.On("FindActiveWorkflow", ctx, doc.DocumentType, "").Return(workflow, nil).Once()
+	repo.On("CreateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+	repo.On("UpdateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	directory.On("UsersWithRole", ctx, "Finance Manager", "").Return([]string{"finance-1", "finance-2", "requester"}, nil).Once()
+	directory.On("DepartmentApprovers", ctx, "Operations", "").Return([]string{"ops-1", "ops-2"}, nil).Once()
+	for _, approver := range []string{"finance-1", "finance-2", "ops-1", "ops-2"} {
+		repo.On("FindActiveDelegation", ctx, approver, doc.DocumentType, approvalTestNow).Return(nil, nil).Once()
+		directory.On("IsOnLeave", ctx, approver, approvalTestNow).Return(false, nil).Once()
+	}
+	repo.On("CreateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Times(4)
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Times(5)
+	repo.On("ListTasksByRequest", ctx, mock.AnythingOfType("string")).Return([]entities.ApprovalTask{}, nil).Once()
+
+	_, err := svc.Submit(ctx, doc)
+	require.NoError(t, err)
+
+	var approvers []string
+	for _, task := range createdTasks(repo) {
+		approvers = append(approvers, task.ApproverID)
+	}
+	// The requester never approves their own document
+	assert.ElementsMatch(t, []string{"finance-1", "finance-2", "ops-1", "ops-2"}, approvers)
+	repo.AssertExpectations(t)
+	directory.AssertExpectations(t)
+}
+
+func TestApprovalService_SubmitFailsWhenAParallelStepHasNoApprovers(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	directory := new(MockApproverDirectory)
+	svc := newTestApprovalService(repo, directory, nil)
+
+	// Nobody but the requester holds the finance role, while operations can be routed
+	workflow := testWorkflow(
+		entities.ApprovalStep{StepOrder: 1, Name: "Finance", Mode: entities.ApprovalModeAny, ApproverType: entities.ApproverTypeRole, ApproverRole: "Finance Manager"},
+		entities.ApprovalStep{StepOrder: 1, Name: "Operations", Mode: entities.ApprovalModeAll, ApproverType: entities.ApproverTypeDepartment},
+	)
+	doc := testPurchaseOrder(1000)
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, doc.DocumentType, doc.DocumentID).Return(nil, nil).Once()
+	repo.On("FindActiveWorkflow", ctx, doc.DocumentType, "").Return(workflow, nil).Once()
+	repo.On("CreateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Once()
+	repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+	repo.On("UpdateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	directory.On("UsersWithRole", ctx, "Finance Manager", "").Return([]string{"requester"}, nil).Once()
+	directory.On("DepartmentApprovers", ctx, "Operations", "").Return([]string{"ops-1"}, nil).Once()
+
+	outcome, err := svc.Submit(ctx, doc)
+	assert.ErrorIs(t, err, integration.ErrNoApprovers)
+	assert.Nil(t, outcome)
+	repo.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	directory.AssertExpectations(t)
+}
+
+func TestApprovalService_ParallelStepModes(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+	workflow := testWorkflow(
+		entities.ApprovalStep{StepOrder: 1, Name: "Finance", Mode: entities.ApprovalModeAny, ApproverType: entities.ApproverTypeRole, ApproverRole: "Finance Manager"},
+		entities.ApprovalStep{StepOrder: 1, Name: "Operations", Mode: entities.ApprovalModeAll, ApproverType: entities.ApproverTypeDepartment},
+	)
+	request := pendingRequest(workflow, 1, 1000)
+	finance1 := pendingTask(request, &workflow.Steps[0], "finance-1")
+	finance2 := pendingTask(request, &workflow.Steps[0], "finance-2")
+	ops1 := pendingTask(request, &workflow.Steps[1], "ops-1")
+	ops2 := pendingTask(request, &workflow.Steps[1], "ops-2")
+	financeDecided := []entities.ApprovalTask{
+		withStatus(finance1, entities.TaskStatusSkipped), withStatus(finance2, entities.TaskStatusApproved), ops1, ops2,
+	}
+	ops1Decided := []entities.ApprovalTask{
+		financeDecided[0], financeDecided[1], withStatus(ops1, entities.TaskStatusApproved), ops2,
+	}
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Times(3)
+	repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Times(3)
+	repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Times(3)
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{finance1, finance2, ops1, ops2}, nil).Once()
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return(financeDecided, nil).Twice()
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return(ops1Decided, nil).Twice()
+	repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Times(4)
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Times(4)
+	repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+	repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+
+	// One finance approval completes the "any" step and skips the other finance task
+	outcome, err := svc.Approve(ctx, testPurchaseOrder(1000), "finance-2", "")
+	require.NoError(t, err)
+	assert.False(t, outcome.IsApproved())
+	assert.Equal(t, []string{"ops-1", "ops-2"}, outcome.PendingApprovers)
+	assert.Equal(t, map[string]entities.TaskStatus{
+		"finance-1": entities.TaskStatusSkipped,
+		"finance-2": entities.TaskStatusApproved,
+	}, updatedTasks(repo))
+
+	outcome, err = svc.Approve(ctx, testPurchaseOrder(1000), "ops-1", "")
+	require.NoError(t, err)
+	assert.False(t, outcome.IsApproved(), "all operations approvers must approve")
+
+	outcome, err = svc.Approve(ctx, testPurchaseOrder(1000), "ops-2", "")
+	require.NoError(t, err)
+	assert.True(t, outcome.IsApproved())
+	repo.AssertExpectations(t)
+}
+
+func TestApprovalService_AmountThreshold(t *testing.T) {
+	threshold := 50_000_000.0
+	director := userStep(2, "Director", "director")
+	director.MinAmount = &threshold
+	workflow := testWorkflow(userStep(1, "Manager", "manager"), director)
+	ctx := context.Background()
+
+	t.Run("director step does not apply below the threshold", func(t *testing.T) {
+		repo := new(MockApprovalRepository)
+		svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+		request := pendingRequest(workflow, 1, 10_000_000)
+
+		repo.On("InTransaction", ctx).Return(nil).Once()
+		repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Once()
+		repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Once()
+		repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+			pendingTask(request, &workflow.Steps[0], "manager"),
+		}, nil).Once()
+		repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+		repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+		repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+		repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Twice()
+
+		outcome, err := svc.Approve(ctx, testPurchaseOrder(10_000_000), "manager", "")
+		require.NoError(t, err)
+		assert.True(t, outcome.IsApproved())
+		assert.Equal(t, []entities.HistoryAction{entities.HistoryActionApproved, entities.HistoryActionCompleted}, historyActions(repo))
+		repo.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("director approves large orders", func(t *testing.T) {
+		repo := new(MockApprovalRepository)
+		directory := new(MockApproverDirectory)
+		svc := newTestApprovalService(repo, directory, nil)
+		request := pendingRequest(workflow, 1, 75_000_000)
+
+		repo.On("InTransaction", ctx).Return(nil).Once()
+		repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Once()
+		repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Once()
+		repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+			pendingTask(request, &workflow.Steps[0], "manager"),
+		}, nil).Once()
+		repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+		repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+		repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+		repo.On("FindActiveDelegation", ctx, "director", request.DocumentType, approvalTestNow).Return(nil, nil).Once()
+		directory.On("IsOnLeave", ctx, "director", approvalTestNow).Return(false, nil).Once()
+		repo.On("CreateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+		repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Twice()
+		repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+			pendingTask(request, &workflow.Steps[1], "director"),
+		}, nil).Once()
+
+		outcome, err := svc.Approve(ctx, testPurchaseOrder(75_000_000), "manager", "")
+		require.NoError(t, err)
+		assert.False(t, outcome.IsApproved())
+		assert.Equal(t, []string{"director"}, outcome.PendingApprovers)
+		repo.AssertExpectations(t)
+		directory.AssertExpectations(t)
+	})
+}
+
+func TestApprovalService_RejectEndsRequest(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+	workflow := testWorkflow(userStep(1, "Manager", "manager"), userStep(2, "Director", "director"))
+	request := pendingRequest(workflow, 1, 1000)
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Once()
+	repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Once()
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+		pendingTask(request, &workflow.Steps[0], "manager"),
+	}, nil).Once()
+	repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Once()
+	repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+
+	outcome, err := svc.Reject(ctx, testPurchaseOrder(1000), "manager", "over budget")
+	require.NoError(t, err)
+	assert.Equal(t, integration.ApprovalStatusRejected, outcome.Status)
+	assert.Equal(t, map[string]entities.TaskStatus{"manager": entities.TaskStatusRejected}, updatedTasks(repo))
+	assert.Equal(t, []entities.HistoryAction{entities.HistoryActionRejected}, historyActions(repo))
+	repo.AssertExpectations(t)
+}
+
+func TestApprovalService_RejectDecidedRequest(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+	approved := pendingRequest(nil, 1, 1000)
+	approved.Status = integration.ApprovalStatusApproved
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, approved.DocumentType, approved.DocumentID).Return(nil, nil).Once()
+	repo.On("ListRequestsByDocument", ctx, approved.DocumentType, approved.DocumentID).Return([]entities.ApprovalRequest{*approved}, nil).Once()
+
+	_, err := svc.Reject(ctx, testPurchaseOrder(1000), "manager", "too late")
+	assert.ErrorIs(t, err, integration.ErrApprovalNotPending)
+	repo.AssertNotCalled(t, "UpdateRequest", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestApprovalService_SupervisorStepAndDelegation(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	directory := new(MockApproverDirectory)
+	svc := newTestApprovalService(repo, directory, nil)
+	workflow := testWorkflow(entities.ApprovalStep{
+		StepOrder: 1, Name: "Line manager", Mode: entities.ApprovalModeAny, ApproverType: entities.ApproverTypeSupervisor,
+	})
+	doc := testPurchaseOrder(1000)
+	delegation := &entities.ApprovalDelegation{
+		ID:          uuid.New(),
+		DelegatorID: "supervisor",
+		DelegateID:  "deputy",
+		StartDate:   approvalTestNow.AddDate(0, 0, -1).Truncate(24 * time.Hour),
+		EndDate:     approvalTestNow.AddDate(0, 0, 5).Truncate(24 * time.Hour),
+		Reason:      "annual leave",
+		IsActive:    true,
+	}
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, doc.DocumentType, doc.DocumentID).Return(nil, nil).Once()
+	repo.On("FindActiveWorkflow", ctx, doc.DocumentType, "").Return(workflow, nil).Once()
+	repo.On("CreateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+	repo.On("UpdateRequest", ctx, mock.AnythingOfType("*entities.ApprovalRequest")).Return(nil).Once()
+	directory.On("SupervisorOf", ctx, "", "requester").Return("supervisor", nil).Once()
+	repo.On("FindActiveDelegation", ctx, "supervisor", doc.DocumentType, approvalTestNow).Return(delegation, nil).Once()
+	repo.On("CreateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Times(3)
+	repo.On("ListTasksByRequest", ctx, mock.AnythingOfType("string")).Return([]entities.ApprovalTask{
+		{StepOrder: 1, ApproverID: "deputy", Status: entities.TaskStatusPending},
+	}, nil).Once()
+
+	outcome, err := svc.Submit(ctx, doc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deputy"}, outcome.PendingApprovers)
+
+	tasks := createdTasks(repo)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "deputy", tasks[0].ApproverID)
+	require.NotNil(t, tasks[0].OriginalApproverID)
+	assert.Equal(t, "supervisor", *tasks[0].OriginalApproverID)
+	assert.Equal(t, []entities.HistoryAction{
+		entities.HistoryActionSubmitted, entities.HistoryActionDelegated, entities.HistoryActionAssigned,
+	}, historyActions(repo))
+	repo.AssertExpectations(t)
+	directory.AssertExpectations(t)
+}
+
+func TestApprovalService_ProcessEscalations(t *testing.T) {
+	step := userStep(1, "Manager", "manager")
+	step.EscalationHours = 24
+	escalateTo := "director"
+	step.EscalateToUserID = &escalateTo
+	workflow := testWorkflow(step)
+	later := approvalTestNow.Add(25 * time.Hour)
+	ctx := context.Background()
+
+	t.Run("overdue task moves to the escalation target", func(t *testing.T) {
+		repo := new(MockApprovalRepository)
+		directory := new(MockApproverDirectory)
+		svc := newTestApprovalService(repo, directory, nil)
+		svc.now = func() time.Time { return later }
+		request := pendingRequest(workflow, 1, 1000)
+		overdue := pendingTask(request, &workflow.Steps[0], "manager")
+
+		repo.On("ListOverdueTasks", ctx, later).Return([]entities.ApprovalTask{overdue}, nil).Once()
+		repo.On("InTransaction", ctx).Return(nil).Once()
+		repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Once()
+		repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{overdue}, nil).Once()
+		repo.On("GetWorkflowByID", ctx, workflow.ID.String()).Return(workflow, nil).Once()
+		repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+		repo.On("FindActiveDelegation", ctx, "director", request.DocumentType, later).Return(nil, nil).Once()
+		directory.On("IsOnLeave", ctx, "director", later).Return(false, nil).Once()
+		repo.On("CreateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+		repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Twice()
+
+		escalated, err := svc.ProcessEscalations(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, escalated)
+		assert.Equal(t, map[string]entities.TaskStatus{"manager": entities.TaskStatusEscalated}, updatedTasks(repo))
+		tasks := createdTasks(repo)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "director", tasks[0].ApproverID)
+		require.NotNil(t, tasks[0].OriginalApproverID)
+		assert.Equal(t, "manager", *tasks[0].OriginalApproverID)
+		assert.Equal(t, []entities.HistoryAction{entities.HistoryActionEscalated, entities.HistoryActionAssigned}, historyActions(repo))
+		repo.AssertExpectations(t)
+		directory.AssertExpectations(t)
+	})
+
+	t.Run("task decided while waiting for the lock is left alone", func(t *testing.T) {
+		repo := new(MockApprovalRepository)
+		svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+		svc.now = func() time.Time { return later }
+		request := pendingRequest(workflow, 1, 1000)
+		overdue := pendingTask(request, &workflow.Steps[0], "manager")
+
+		repo.On("ListOverdueTasks", ctx, later).Return([]entities.ApprovalTask{overdue}, nil).Once()
+		repo.On("InTransaction", ctx).Return(nil).Once()
+		repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Once()
+		repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+			withStatus(overdue, entities.TaskStatusApproved),
+		}, nil).Once()
+
+		escalated, err := svc.ProcessEscalations(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, escalated)
+		repo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestApprovalService_DefaultPolicyWithoutWorkflow(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	authorizer := new(MockApprovalAuthorizer)
+	svc := newTestApprovalService(repo, new(MockApproverDirectory), authorizer)
+	request := pendingRequest(nil, 1, 1000)
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Times(3)
+	repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Times(3)
+	repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Times(3)
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{}, nil).Times(3)
+	authorizer.On("CanApprove", ctx, "staff", "requester").Return(false, nil).Once()
+	authorizer.On("CanApprove", ctx, "manager", "requester").Return(true, nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Twice()
+	repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+
+	_, err := svc.Approve(ctx, testPurchaseOrder(1000), "requester", "")
+	assert.Error(t, err, "requesters cannot approve their own documents")
+
+	_, err = svc.Approve(ctx, testPurchaseOrder(1000), "staff", "")
+	assert.ErrorIs(t, err, integration.ErrNotApprover)
+
+	outcome, err := svc.Approve(ctx, testPurchaseOrder(1000), "manager", "")
+	require.NoError(t, err)
+	assert.True(t, outcome.IsApproved())
+	assert.Equal(t, []entities.HistoryAction{entities.HistoryActionApproved, entities.HistoryActionCompleted}, historyActions(repo))
+	repo.AssertNotCalled(t, "GetWorkflowByID", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestApprovalService_CancelSkipsPendingTasks(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+	workflow := testWorkflow(userStep(1, "Manager", "manager"))
+	request := pendingRequest(workflow, 1, 1000)
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Once()
+	repo.On("LockRequest", ctx, request.ID.String()).Return(request, nil).Once()
+	repo.On("ListTasksByRequest", ctx, request.ID.String()).Return([]entities.ApprovalTask{
+		pendingTask(request, &workflow.Steps[0], "manager"),
+	}, nil).Once()
+	repo.On("UpdateTask", ctx, mock.AnythingOfType("*entities.ApprovalTask")).Return(nil).Once()
+	repo.On("AddHistory", ctx, mock.AnythingOfType("*entities.ApprovalHistory")).Return(nil).Once()
+	repo.On("UpdateRequest", ctx, request).Return(nil).Once()
+
+	require.NoError(t, svc.Cancel(ctx, integration.ApprovalDocPurchaseOrder, "po-1", "requester", "no longer needed"))
+	assert.Equal(t, integration.ApprovalStatusCancelled, request.Status)
+	assert.Equal(t, map[string]entities.TaskStatus{"manager": entities.TaskStatusSkipped}, updatedTasks(repo))
+	assert.Equal(t, []entities.HistoryAction{entities.HistoryActionCancelled}, historyActions(repo))
+	repo.AssertExpectations(t)
+}
+
+func TestApprovalService_CancelDecidedWhileWaitingForLock(t *testing.T) {
+	repo := new(MockApprovalRepository)
+	svc := newTestApprovalService(repo, new(MockApproverDirectory), nil)
+	request := pendingRequest(nil, 1, 1000)
+	decided := *request
+	decided.Status = integration.ApprovalStatusApproved
+	ctx := context.Background()
+
+	repo.On("InTransaction", ctx).Return(nil).Once()
+	repo.On("GetPendingRequest", ctx, request.DocumentType, request.DocumentID).Return(request, nil).Once()
+	repo.On("LockRequest", ctx, request.ID.String()).Return(&decided, nil).Once()
+
+	require.NoError(t, svc.Cancel(ctx, integration.ApprovalDocPurchaseOrder, "po-1", "requester", "no longer needed"))
+	repo.AssertNotCalled(t, "UpdateRequest", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestApprovalDelegation_Validate(t *testing.T) {
+	delegation := &entities.ApprovalDelegation{
+		ID:          uuid.New(),
+		DelegatorID: "a",
+		DelegateID:  "a",
+		StartDate:   approvalTestNow,
+		EndDate:     approvalTestNow,
+	}
+	assert.Error(t, delegation.Validate())
+
+	delegation.DelegateID = "b"
+	delegation.EndDate = approvalTestNow.AddDate(0, 0, -1)
+	assert.Error(t, delegation.Validate())
+
+	delegation.EndDate = approvalTestNow.AddDate(0, 0, 1)
+	assert.NoError(t, delegation.Validate())
+}
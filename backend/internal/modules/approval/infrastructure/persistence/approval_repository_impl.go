@@ -0,0 +1,465 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/approval/domain/entities"
+	"malaka/internal/modules/approval/domain/repositories"
+	"malaka/internal/shared/integration"
+)
+
+// approvalDB is implemented by both *sqlx.DB and *sqlx.Tx
+type approvalDB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+type approvalRepositoryImpl struct {
+	db   approvalDB
+	conn *sqlx.DB
+}
+
+// NewApprovalRepository creates a new instance of approval repository
+func NewApprovalRepository(db *sqlx.DB) repositories.ApprovalRepository {
+	return &approvalRepositoryImpl{db: db, conn: db}
+}
+
+// InTransaction runs fn with a repository whose statements share one transaction
+func (r *approvalRepositoryImpl) InTransaction(ctx context.Context, fn func(repo repositories.ApprovalRepository) error) error {
+	if _, ok := r.db.(*sqlx.Tx); ok {
+		return fn(r)
+	}
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&approvalRepositoryImpl{db: tx, conn: r.conn}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const approvalWorkflowColumns = `id, company_id::text as company_id, document_type, name, description, is_active,
+	created_by::text as created_by, created_at, updated_at`
+
+const approvalStepColumns = `id, workflow_id, step_order, name, approval_mode, approver_type,
+	approver_user_id::text as approver_user_id, approver_role, approver_department, min_amount, max_amount,
+	condition_department, escalation_hours, escalate_to_user_id::text as escalate_to_user_id, escalate_to_role, created_at`
+
+const approvalRequestColumns = `id, workflow_id::text as workflow_id, company_id::text as company_id, document_type,
+	document_id, document_number, requester_id::text as requester_id, requester_employee_id::text as requester_employee_id,
+	department, amount, status, current_step_order, submitted_at, completed_at, created_at, updated_at`
+
+const approvalTaskColumns = `t.id, t.request_id, t.step_id::text as step_id, t.step_order, t.step_name, t.approval_mode,
+	t.approver_id::text as approver_id, t.original_approver_id::text as original_approver_id, t.status, t.due_at,
+	t.acted_at, t.comments, t.created_at, r.document_type, r.document_id, r.document_number, r.amount`
+
+const approvalDelegationColumns = `id, delegator_id::text as delegator_id, delegate_id::text as delegate_id, document_type,
+	start_date, end_date, reason, is_active, created_at, updated_at`
+
+// CreateWorkflow creates a workflow together with its steps
+func (r *approvalRepositoryImpl) CreateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO approval_workflows (id, company_id, document_type, name, description, is_active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	if _, err := tx.ExecContext(ctx, query,
+		workflow.ID, workflow.CompanyID, workflow.DocumentType, workflow.Name, workflow.Description,
+		workflow.IsActive, workflow.CreatedBy, workflow.CreatedAt, workflow.UpdatedAt); err != nil {
+		return err
+	}
+	if err := insertSteps(ctx, tx, workflow.Steps); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetWorkflowByID retrieves a workflow with its steps
+func (r *approvalRepositoryImpl) GetWorkflowByID(ctx context.Context, id string) (*entities.ApprovalWorkflow, error) {
+	var workflow entities.ApprovalWorkflow
+	query := `SELECT ` + approvalWorkflowColumns + ` FROM approval_workflows WHERE id::text = $1`
+	if err := r.db.GetContext(ctx, &workflow, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.loadSteps(ctx, &workflow); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// ListWorkflows lists the workflows visible to a company (its own plus the defaults)
+func (r *approvalRepositoryImpl) ListWorkflows(ctx context.Context, companyID string, documentType string) ([]entities.ApprovalWorkflow, error) {
+	workflows := []entities.ApprovalWorkflow{}
+	query := `SELECT ` + approvalWorkflowColumns + ` FROM approval_workflows
+		WHERE (company_id IS NULL OR company_id::text = $1) AND ($2 = '' OR document_type = $2)
+		ORDER BY document_type, company_id NULLS FIRST, name`
+	if err := r.db.SelectContext(ctx, &workflows, query, companyID, documentType); err != nil {
+		return nil, err
+	}
+	for i := range workflows {
+		if err := r.loadSteps(ctx, &workflows[i]); err != nil {
+			return nil, err
+		}
+	}
+	return workflows, nil
+}
+
+// UpdateWorkflow updates a workflow and replaces its steps
+func (r *approvalRepositoryImpl) UpdateWorkflow(ctx context.Context, workflow *entities.ApprovalWorkflow) error {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE approval_workflows
+		SET company_id = $2, document_type = $3, name = $4, description = $5, is_active = $6, updated_at = $7
+		WHERE id = $1`
+	result, err := tx.ExecContext(ctx, query,
+		workflow.ID, workflow.CompanyID, workflow.DocumentType, workflow.Name, workflow.Description,
+		workflow.IsActive, workflow.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("approval workflow with id %s not found", workflow.ID.String())
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM approval_workflow_steps WHERE workflow_id = $1`, workflow.ID); err != nil {
+		return err
+	}
+	if err := insertSteps(ctx, tx, workflow.Steps); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteWorkflow deletes a workflow; requests in flight keep their tasks and history
+func (r *approvalRepositoryImpl) DeleteWorkflow(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM approval_workflows WHERE id::text = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("approval workflow with id %s not found", id)
+	}
+	return nil
+}
+
+// FindActiveWorkflow returns the company's active workflow, or the default one
+func (r *approvalRepositoryImpl) FindActiveWorkflow(ctx context.Context, documentType integration.ApprovalDocumentType, companyID string) (*entities.ApprovalWorkflow, error) {
+	var workflow entities.ApprovalWorkflow
+	query := `SELECT ` + approvalWorkflowColumns + ` FROM approval_workflows
+		WHERE document_type = $1 AND is_active AND (company_id IS NULL OR company_id::text = $2)
+		ORDER BY company_id NULLS LAST
+		LIMIT 1`
+	if err := r.db.GetContext(ctx, &workflow, query, documentType, companyID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.loadSteps(ctx, &workflow); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+func (r *approvalRepositoryImpl) loadSteps(ctx context.Context, workflow *entities.ApprovalWorkflow) error {
+	workflow.Steps = []entities.ApprovalStep{}
+	query := `SELECT ` + approvalStepColumns + ` FROM approval_workflow_steps WHERE workflow_id = $1 ORDER BY step_order, created_at, name`
+	return r.db.SelectContext(ctx, &workflow.Steps, query, workflow.ID)
+}
+
+func insertSteps(ctx context.Context, tx *sqlx.Tx, steps []entities.ApprovalStep) error {
+	query := `
+		INSERT INTO approval_workflow_steps (id, workflow_id, step_order, name, approval_mode, approver_type,
+			approver_user_id, approver_role, approver_department, min_amount, max_amount, condition_department,
+			escalation_hours, escalate_to_user_id, escalate_to_role, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, query,
+			step.ID, step.WorkflowID, step.StepOrder, step.Name, step.Mode, step.ApproverType,
+			step.ApproverUserID, step.ApproverRole, step.ApproverDepartment, step.MinAmount, step.MaxAmount,
+			step.ConditionDepartment, step.EscalationHours, step.EscalateToUserID, step.EscalateToRole, step.CreatedAt); err != nil {
+			return fmt.Errorf("failed to save step %q: %w", step.Name, err)
+		}
+	}
+	return nil
+}
+
+// CreateRequest creates an approval request
+func (r *approvalRepositoryImpl) CreateRequest(ctx context.Context, request *entities.ApprovalRequest) error {
+	query := `
+		INSERT INTO approval_requests (id, workflow_id, company_id, document_type, document_id, document_number,
+			requester_id, requester_employee_id, department, amount, status, current_step_order, submitted_at,
+			completed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+	_, err := r.db.ExecContext(ctx, query,
+		request.ID, request.WorkflowID, request.CompanyID, request.DocumentType, request.DocumentID, request.DocumentNumber,
+		request.RequesterID, request.RequesterEmployeeID, request.Department, request.Amount, request.Status,
+		request.CurrentStepOrder, request.SubmittedAt, request.CompletedAt, request.CreatedAt, request.UpdatedAt)
+	return err
+}
+
+// UpdateRequest updates the progress of an approval request
+func (r *approvalRepositoryImpl) UpdateRequest(ctx context.Context, request *entities.ApprovalRequest) error {
+	query := `
+		UPDATE approval_requests
+		SET status = $2, current_step_order = $3, completed_at = $4, updated_at = $5
+		WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query,
+		request.ID, request.Status, request.CurrentStepOrder, request.CompletedAt, request.UpdatedAt)
+	return err
+}
+
+// GetRequestByID retrieves an approval request by ID
+func (r *approvalRepositoryImpl) GetRequestByID(ctx context.Context, id string) (*entities.ApprovalRequest, error) {
+	var request entities.ApprovalRequest
+	query := `SELECT ` + approvalRequestColumns + ` FROM approval_requests WHERE id::text = $1`
+	if err := r.db.GetContext(ctx, &request, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// LockRequest retrieves an approval request with its row locked FOR UPDATE, so concurrent
+// decisions on the request wait for each other
+func (r *approvalRepositoryImpl) LockRequest(ctx context.Context, id string) (*entities.ApprovalRequest, error) {
+	var request entities.ApprovalRequest
+	query := `SELECT ` + approvalRequestColumns + ` FROM approval_requests WHERE id::text = $1 FOR UPDATE`
+	if err := r.db.GetContext(ctx, &request, query, id); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetPendingRequest retrieves the pending approval request of a document
+func (r *approvalRepositoryImpl) GetPendingRequest(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) (*entities.ApprovalRequest, error) {
+	var request entities.ApprovalRequest
+	query := `SELECT ` + approvalRequestColumns + ` FROM approval_requests
+		WHERE document_type = $1 AND document_id = $2 AND status = 'pending'`
+	if err := r.db.GetContext(ctx, &request, query, documentType, documentID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ListRequests lists approval requests with filters
+func (r *approvalRepositoryImpl) ListRequests(ctx context.Context, filter *entities.ApprovalRequestFilter) ([]entities.ApprovalRequest, int, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.CompanyID != "" {
+		add("company_id::text = $%d", filter.CompanyID)
+	}
+	if filter.DocumentType != "" {
+		add("document_type = $%d", filter.DocumentType)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.RequesterID != "" {
+		add("requester_id::text = $%d", filter.RequesterID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM approval_requests`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	requests := []entities.ApprovalRequest{}
+	query := fmt.Sprintf(`SELECT %s FROM approval_requests%s ORDER BY submitted_at DESC LIMIT $%d OFFSET $%d`,
+		approvalRequestColumns, where, len(args)+1, len(args)+2)
+	if err := r.db.SelectContext(ctx, &requests, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// ListRequestsByDocument lists every approval run of a document, newest first
+func (r *approvalRepositoryImpl) ListRequestsByDocument(ctx context.Context, documentType integration.ApprovalDocumentType, documentID string) ([]entities.ApprovalRequest, error) {
+	requests := []entities.ApprovalRequest{}
+	query := `SELECT ` + approvalRequestColumns + ` FROM approval_requests
+		WHERE document_type = $1 AND document_id = $2
+		ORDER BY submitted_at DESC`
+	if err := r.db.SelectContext(ctx, &requests, query, documentType, documentID); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// CreateTask creates an approval task
+func (r *approvalRepositoryImpl) CreateTask(ctx context.Context, task *entities.ApprovalTask) error {
+	query := `
+		INSERT INTO approval_tasks (id, request_id, step_id, step_order, step_name, approval_mode, approver_id,
+			original_approver_id, status, due_at, acted_at, comments, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	_, err := r.db.ExecContext(ctx, query,
+		task.ID, task.RequestID, task.StepID, task.StepOrder, task.StepName, task.Mode, task.ApproverID,
+		task.OriginalApproverID, task.Status, task.DueAt, task.ActedAt, task.Comments, task.CreatedAt)
+	return err
+}
+
+// UpdateTask records the decision on an approval task
+func (r *approvalRepositoryImpl) UpdateTask(ctx context.Context, task *entities.ApprovalTask) error {
+	query := `UPDATE approval_tasks SET status = $2, due_at = $3, acted_at = $4, comments = $5 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, task.ID, task.Status, task.DueAt, task.ActedAt, task.Comments)
+	return err
+}
+
+// ListTasksByRequest lists the tasks of an approval request
+func (r *approvalRepositoryImpl) ListTasksByRequest(ctx context.Context, requestID string) ([]entities.ApprovalTask, error) {
+	tasks := []entities.ApprovalTask{}
+	query := `SELECT ` + approvalTaskColumns + ` FROM approval_tasks t
+		JOIN approval_requests r ON r.id = t.request_id
+		WHERE t.request_id::text = $1
+		ORDER BY t.step_order, t.created_at`
+	if err := r.db.SelectContext(ctx, &tasks, query, requestID); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ListPendingTasksByApprover lists the approver's inbox
+func (r *approvalRepositoryImpl) ListPendingTasksByApprover(ctx context.Context, approverID string) ([]entities.ApprovalTask, error) {
+	tasks := []entities.ApprovalTask{}
+	query := `SELECT ` + approvalTaskColumns + ` FROM approval_tasks t
+		JOIN approval_requests r ON r.id = t.request_id AND r.status = 'pending' AND r.current_step_order = t.step_order
+		WHERE t.approver_id::text = $1 AND t.status = 'pending'
+		ORDER BY t.due_at NULLS LAST, t.created_at`
+	if err := r.db.SelectContext(ctx, &tasks, query, approverID); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ListOverdueTasks lists pending tasks past their escalation deadline
+func (r *approvalRepositoryImpl) ListOverdueTasks(ctx context.Context, now time.Time) ([]entities.ApprovalTask, error) {
+	tasks := []entities.ApprovalTask{}
+	query := `SELECT ` + approvalTaskColumns + ` FROM approval_tasks t
+		JOIN approval_requests r ON r.id = t.request_id AND r.status = 'pending'
+		WHERE t.status = 'pending' AND t.due_at IS NOT NULL AND t.due_at <= $1
+		ORDER BY t.due_at`
+	if err := r.db.SelectContext(ctx, &tasks, query, now); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// AddHistory appends an approval history entry
+func (r *approvalRepositoryImpl) AddHistory(ctx context.Context, entry *entities.ApprovalHistory) error {
+	query := `
+		INSERT INTO approval_history (id, request_id, task_id, step_order, actor_id, action, comments, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.ExecContext(ctx, query,
+		entry.ID, entry.RequestID, entry.TaskID, entry.StepOrder, entry.ActorID, entry.Action, entry.Comments, entry.CreatedAt)
+	return err
+}
+
+// ListHistory lists the history of an approval request in chronological order
+func (r *approvalRepositoryImpl) ListHistory(ctx context.Context, requestID string) ([]entities.ApprovalHistory, error) {
+	history := []entities.ApprovalHistory{}
+	query := `SELECT id, request_id, task_id::text as task_id, step_order, actor_id::text as actor_id, action, comments, created_at
+		FROM approval_history WHERE request_id::text = $1 ORDER BY created_at, id`
+	if err := r.db.SelectContext(ctx, &history, query, requestID); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// CreateDelegation creates an approval delegation
+func (r *approvalRepositoryImpl) CreateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error {
+	query := `
+		INSERT INTO approval_delegations (id, delegator_id, delegate_id, document_type, start_date, end_date, reason, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := r.db.ExecContext(ctx, query,
+		delegation.ID, delegation.DelegatorID, delegation.DelegateID, delegation.DocumentType, delegation.StartDate,
+		delegation.EndDate, delegation.Reason, delegation.IsActive, delegation.CreatedAt, delegation.UpdatedAt)
+	return err
+}
+
+// GetDelegationByID retrieves a delegation by ID
+func (r *approvalRepositoryImpl) GetDelegationByID(ctx context.Context, id string) (*entities.ApprovalDelegation, error) {
+	var delegation entities.ApprovalDelegation
+	query := `SELECT ` + approvalDelegationColumns + ` FROM approval_delegations WHERE id::text = $1`
+	if err := r.db.GetContext(ctx, &delegation, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// ListDelegations lists delegations given or received by a user
+func (r *approvalRepositoryImpl) ListDelegations(ctx context.Context, userID string) ([]entities.ApprovalDelegation, error) {
+	delegations := []entities.ApprovalDelegation{}
+	query := `SELECT ` + approvalDelegationColumns + ` FROM approval_delegations
+		WHERE delegator_id::text = $1 OR delegate_id::text = $1
+		ORDER BY start_date DESC`
+	if err := r.db.SelectContext(ctx, &delegations, query, userID); err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// UpdateDelegation updates a delegation
+func (r *approvalRepositoryImpl) UpdateDelegation(ctx context.Context, delegation *entities.ApprovalDelegation) error {
+	query := `
+		UPDATE approval_delegations
+		SET delegate_id = $2, document_type = $3, start_date = $4, end_date = $5, reason = $6, is_active = $7, updated_at = $8
+		WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query,
+		delegation.ID, delegation.DelegateID, delegation.DocumentType, delegation.StartDate, delegation.EndDate,
+		delegation.Reason, delegation.IsActive, delegation.UpdatedAt)
+	return err
+}
+
+// FindActiveDelegation returns the delegation covering a date, preferring one for the specific document type
+func (r *approvalRepositoryImpl) FindActiveDelegation(ctx context.Context, delegatorID string, documentType integration.ApprovalDocumentType, date time.Time) (*entities.ApprovalDelegation, error) {
+	var delegation entities.ApprovalDelegation
+	query := `SELECT ` + approvalDelegationColumns + ` FROM approval_delegations
+		WHERE delegator_id::text = $1 AND is_active AND $3::date BETWEEN start_date AND end_date
+		  AND (document_type IS NULL OR document_type = $2)
+		ORDER BY document_type NULLS LAST, created_at DESC
+		LIMIT 1`
+	if err := r.db.GetContext(ctx, &delegation, query, delegatorID, documentType, date.Format("2006-01-02")); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delegation, nil
+}
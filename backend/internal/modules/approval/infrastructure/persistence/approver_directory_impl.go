@@ -0,0 +1,91 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/approval/domain/repositories"
+)
+
+type approverDirectoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewApproverDirectory creates an approver directory backed by the RBAC and HR tables
+func NewApproverDirectory(db *sqlx.DB) repositories.ApproverDirectory {
+	return &approverDirectoryImpl{db: db}
+}
+
+// UsersWithRole returns active users holding a role, limited to a company when given
+func (d *approverDirectoryImpl) UsersWithRole(ctx context.Context, roleName, companyID string) ([]string, error) {
+	users := []string{}
+	query := `
+		SELECT DISTINCT u.id::text
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id AND r.is_active = TRUE
+		JOIN users u ON u.id = ur.user_id
+		WHERE r.name = $1
+		  AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+		  AND ($2 = '' OR u.company_id::text = $2)`
+	if err := d.db.SelectContext(ctx, &users, query, roleName, companyID); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// DepartmentApprovers returns users of a department holding a role of Supervisor level (2) or above
+func (d *approverDirectoryImpl) DepartmentApprovers(ctx context.Context, department, companyID string) ([]string, error) {
+	users := []string{}
+	query := `
+		SELECT DISTINCT u.id::text
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+		JOIN roles r ON r.id = ur.role_id AND r.is_active = TRUE AND r.level >= 2
+		WHERE LOWER(u.department) = LOWER($1)
+		  AND ($2 = '' OR u.company_id::text = $2)`
+	if err := d.db.SelectContext(ctx, &users, query, department, companyID); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SupervisorOf returns the user linked to the direct supervisor of an employee
+func (d *approverDirectoryImpl) SupervisorOf(ctx context.Context, employeeID, userID string) (string, error) {
+	if employeeID == "" && userID == "" {
+		return "", nil
+	}
+	var supervisorUserID string
+	query := `
+		SELECT s.user_id::text
+		FROM employees e
+		JOIN employees s ON s.id = e.supervisor_id
+		WHERE s.user_id IS NOT NULL
+		  AND (($1 <> '' AND e.id::text = $1) OR ($1 = '' AND e.user_id::text = $2))
+		LIMIT 1`
+	if err := d.db.GetContext(ctx, &supervisorUserID, query, employeeID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return supervisorUserID, nil
+}
+
+// IsOnLeave reports whether the user has approved leave covering the date
+func (d *approverDirectoryImpl) IsOnLeave(ctx context.Context, userID string, date time.Time) (bool, error) {
+	var onLeave bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM leave_requests lr
+			JOIN employees e ON e.id = lr.employee_id
+			WHERE e.user_id::text = $1 AND lr.status = 'approved'
+			  AND $2::date BETWEEN lr.start_date AND lr.end_date
+		)`
+	if err := d.db.GetContext(ctx, &onLeave, query, userID, date.Format("2006-01-02")); err != nil {
+		return false, err
+	}
+	return onLeave, nil
+}
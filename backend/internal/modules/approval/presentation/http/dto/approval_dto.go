@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"time"
+
+	"malaka/internal/modules/approval/domain/entities"
+	"malaka/internal/shared/integration"
+)
+
+// ApprovalStepRequest represents a step of an approval workflow
+type ApprovalStepRequest struct {
+	StepOrder           int                   `json:"step_order" validate:"required,min=1"`
+	Name                string                `json:"name" validate:"required,min=1,max=255"`
+	Mode                entities.ApprovalMode `json:"approval_mode" validate:"omitempty,oneof=any all"`
+	ApproverType        entities.ApproverType `json:"approver_type" validate:"required,oneof=user role department supervisor"`
+	ApproverUserID      *string               `json:"approver_user_id,omitempty" validate:"omitempty,uuid"`
+	ApproverRole        string                `json:"approver_role" validate:"max=100"`
+	ApproverDepartment  string                `json:"approver_department" validate:"max=100"`
+	MinAmount           *float64              `json:"min_amount,omitempty" validate:"omitempty,min=0"`
+	MaxAmount           *float64              `json:"max_amount,omitempty" validate:"omitempty,min=0"`
+	ConditionDepartment string                `json:"condition_department" validate:"max=100"`
+	EscalationHours     int                   `json:"escalation_hours" validate:"min=0"`
+	EscalateToUserID    *string               `json:"escalate_to_user_id,omitempty" validate:"omitempty,uuid"`
+	EscalateToRole      string                `json:"escalate_to_role" validate:"max=100"`
+}
+
+// ApprovalWorkflowRequest represents the request payload for creating or replacing an approval workflow
+type ApprovalWorkflowRequest struct {
+	DocumentType integration.ApprovalDocumentType `json:"document_type" validate:"required,oneof=purchase_request purchase_order leave_request payroll_period transfer_order"`
+	Name         string                           `json:"name" validate:"required,min=1,max=255"`
+	Description  string                           `json:"description" validate:"max=1000"`
+	Shared       bool                             `json:"shared"` // true = default workflow for every company
+	IsActive     *bool                            `json:"is_active,omitempty"`
+	Steps        []ApprovalStepRequest            `json:"steps" validate:"required,min=1,dive"`
+}
+
+// CreateDelegationRequest represents the request payload for delegating the current user's approvals
+type CreateDelegationRequest struct {
+	DelegateID   string                            `json:"delegate_id" validate:"required,uuid"`
+	DocumentType *integration.ApprovalDocumentType `json:"document_type,omitempty" validate:"omitempty,oneof=purchase_request purchase_order leave_request payroll_period transfer_order"`
+	StartDate    string                            `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate      string                            `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Reason       string                            `json:"reason" validate:"max=1000"`
+}
+
+// ApprovalRequestListResponse represents a page of approval requests
+type ApprovalRequestListResponse struct {
+	Requests []entities.ApprovalRequest `json:"requests"`
+	Total    int                        `json:"total"`
+	Page     int                        `json:"page"`
+	Limit    int                        `json:"limit"`
+}
+
+// ToWorkflowEntity converts the request into a workflow entity
+func (r *ApprovalWorkflowRequest) ToWorkflowEntity() *entities.ApprovalWorkflow {
+	workflow := &entities.ApprovalWorkflow{
+		DocumentType: r.DocumentType,
+		Name:         r.Name,
+		Description:  r.Description,
+		IsActive:     true,
+		Steps:        make([]entities.ApprovalStep, len(r.Steps)),
+	}
+	if r.IsActive != nil {
+		workflow.IsActive = *r.IsActive
+	}
+	for i, step := range r.Steps {
+		mode := step.Mode
+		if mode == "" {
+			mode = entities.ApprovalModeAny
+		}
+		workflow.Steps[i] = entities.ApprovalStep{
+			StepOrder:           step.StepOrder,
+			Name:                step.Name,
+			Mode:                mode,
+			ApproverType:        step.ApproverType,
+			ApproverUserID:      step.ApproverUserID,
+			ApproverRole:        step.ApproverRole,
+			ApproverDepartment:  step.ApproverDepartment,
+			MinAmount:           step.MinAmount,
+			MaxAmount:           step.MaxAmount,
+			ConditionDepartment: step.ConditionDepartment,
+			EscalationHours:     step.EscalationHours,
+			EscalateToUserID:    step.EscalateToUserID,
+			EscalateToRole:      step.EscalateToRole,
+		}
+	}
+	return workflow
+}
+
+// ToDelegationEntity converts the request into a delegation entity for the delegator
+func (r *CreateDelegationRequest) ToDelegationEntity(delegatorID string) (*entities.ApprovalDelegation, error) {
+	startDate, err := time.Parse("2006-01-02", r.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := time.Parse("2006-01-02", r.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ApprovalDelegation{
+		DelegatorID:  delegatorID,
+		DelegateID:   r.DelegateID,
+		DocumentType: r.DocumentType,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Reason:       r.Reason,
+	}, nil
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"malaka/internal/modules/approval/domain/entities"
+	"malaka/internal/modules/approval/domain/services"
+	"malaka/internal/modules/approval/presentation/http/dto"
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+type ApprovalHandler struct {
+	approvalService services.ApprovalService
+	validator       *validator.Validate
+}
+
+// NewApprovalHandler creates a new approval handler
+func NewApprovalHandler(approvalService services.ApprovalService) *ApprovalHandler {
+	return &ApprovalHandler{
+		approvalService: approvalService,
+		validator:       validator.New(),
+	}
+}
+
+// ListWorkflows lists the approval workflows visible to the current company
+// @Summary List approval workflows
+// @Tags Approvals
+// @Produce json
+// @Param document_type query string false "Document type"
+// @Success 200 {object} response.Response{data=[]entities.ApprovalWorkflow}
+// @Router /approvals/workflows [get]
+func (h *ApprovalHandler) ListWorkflows(c *gin.Context) {
+	workflows, err := h.approvalService.ListWorkflows(c.Request.Context(), c.GetString("company_id"), c.Query("document_type"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list approval workflows", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval workflows retrieved successfully", workflows)
+}
+
+// GetWorkflow retrieves an approval workflow with its steps
+// @Summary Get approval workflow
+// @Tags Approvals
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} response.Response{data=entities.ApprovalWorkflow}
+// @Router /approvals/workflows/{id} [get]
+func (h *ApprovalHandler) GetWorkflow(c *gin.Context) {
+	workflowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid workflow ID", err)
+		return
+	}
+
+	workflow, err := h.approvalService.GetWorkflow(c.Request.Context(), workflowID.String())
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Approval workflow not found", err)
+		return
+	}
+	if !h.canAccessWorkflow(c, workflow) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval workflow retrieved successfully", workflow)
+}
+
+// CreateWorkflow creates an approval workflow for the current company (or a shared default)
+// @Summary Create approval workflow
+// @Tags Approvals
+// @Accept json
+// @Produce json
+// @Param request body dto.ApprovalWorkflowRequest true "Workflow data"
+// @Success 201 {object} response.Response{data=entities.ApprovalWorkflow}
+// @Router /approvals/workflows [post]
+func (h *ApprovalHandler) CreateWorkflow(c *gin.Context) {
+	var req dto.ApprovalWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	workflow := req.ToWorkflowEntity()
+	if !req.Shared {
+		if companyID := c.GetString("company_id"); companyID != "" {
+			workflow.CompanyID = &companyID
+		}
+	}
+	if userID := c.GetString("user_id"); userID != "" {
+		workflow.CreatedBy = &userID
+	}
+
+	if err := h.approvalService.CreateWorkflow(c.Request.Context(), workflow); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create approval workflow", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Approval workflow created successfully", workflow)
+}
+
+// UpdateWorkflow replaces an approval workflow and its steps
+// @Summary Update approval workflow
+// @Tags Approvals
+// @Accept json
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Param request body dto.ApprovalWorkflowRequest true "Workflow data"
+// @Success 200 {object} response.Response{data=entities.ApprovalWorkflow}
+// @Router /approvals/workflows/{id} [put]
+func (h *ApprovalHandler) UpdateWorkflow(c *gin.Context) {
+	workflowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid workflow ID", err)
+		return
+	}
+
+	var req dto.ApprovalWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	existing, err := h.approvalService.GetWorkflow(c.Request.Context(), workflowID.String())
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Approval workflow not found", err)
+		return
+	}
+	if !h.canAccessWorkflow(c, existing) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	workflow := req.ToWorkflowEntity()
+	workflow.ID = existing.ID
+	workflow.CompanyID = existing.CompanyID
+
+	if err := h.approvalService.UpdateWorkflow(c.Request.Context(), workflow); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update approval workflow", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval workflow updated successfully", workflow)
+}
+
+// DeleteWorkflow deletes an approval workflow
+// @Summary Delete approval workflow
+// @Tags Approvals
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} response.Response
+// @Router /approvals/workflows/{id} [delete]
+func (h *ApprovalHandler) DeleteWorkflow(c *gin.Context) {
+	workflowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid workflow ID", err)
+		return
+	}
+
+	workflow, err := h.approvalService.GetWorkflow(c.Request.Context(), workflowID.String())
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Approval workflow not found", err)
+		return
+	}
+	if !h.canAccessWorkflow(c, workflow) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	if err := h.approvalService.DeleteWorkflow(c.Request.Context(), workflowID.String()); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to delete approval workflow", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval workflow deleted successfully", nil)
+}
+
+// GetInbox lists the approval tasks waiting for the current user
+// @Summary Get approval inbox
+// @Tags Approvals
+// @Produce json
+// @Success 200 {object} response.Response{data=[]entities.ApprovalTask}
+// @Router /approvals/inbox [get]
+func (h *ApprovalHandler) GetInbox(c *gin.Context) {
+	tasks, err := h.approvalService.ListInbox(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve approval inbox", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval inbox retrieved successfully", tasks)
+}
+
+// ListRequests lists the approval requests of the current company
+// @Summary List approval requests
+// @Tags Approvals
+// @Produce json
+// @Param document_type query string false "Document type"
+// @Param status query string false "Status"
+// @Param requester_id query string false "Requester user ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} response.Response{data=dto.ApprovalRequestListResponse}
+// @Router /approvals/requests [get]
+func (h *ApprovalHandler) ListRequests(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := &entities.ApprovalRequestFilter{
+		CompanyID:    c.GetString("company_id"),
+		DocumentType: c.Query("document_type"),
+		Status:       c.Query("status"),
+		RequesterID:  c.Query("requester_id"),
+		Limit:        limit,
+		Offset:       (page - 1) * limit,
+	}
+
+	requests, total, err := h.approvalService.ListRequests(c.Request.Context(), filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list approval requests", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval requests retrieved successfully", dto.ApprovalRequestListResponse{
+		Requests: requests,
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	})
+}
+
+// GetRequest retrieves an approval request with its tasks and history
+// @Summary Get approval request
+// @Tags Approvals
+// @Produce json
+// @Param id path string true "Approval request ID"
+// @Success 200 {object} response.Response{data=entities.ApprovalRequestDetail}
+// @Router /approvals/requests/{id} [get]
+func (h *ApprovalHandler) GetRequest(c *gin.Context) {
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid approval request ID", err)
+		return
+	}
+
+	detail, err := h.approvalService.GetRequest(c.Request.Context(), requestID.String())
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Approval request not found", err)
+		return
+	}
+	if companyID := c.GetString("company_id"); companyID != "" && detail.CompanyID != nil && *detail.CompanyID != companyID {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval request retrieved successfully", detail)
+}
+
+// GetDocumentHistory retrieves every approval run of a document, newest first
+// @Summary Get document approval history
+// @Tags Approvals
+// @Produce json
+// @Param document_type path string true "Document type"
+// @Param document_id path string true "Document ID"
+// @Success 200 {object} response.Response{data=[]entities.ApprovalRequestDetail}
+// @Router /approvals/documents/{document_type}/{document_id} [get]
+func (h *ApprovalHandler) GetDocumentHistory(c *gin.Context) {
+	documentType := integration.ApprovalDocumentType(c.Param("document_type"))
+	history, err := h.approvalService.GetDocumentHistory(c.Request.Context(), documentType, c.Param("document_id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve approval history", err)
+		return
+	}
+
+	companyID := c.GetString("company_id")
+	visible := make([]entities.ApprovalRequestDetail, 0, len(history))
+	for _, detail := range history {
+		if companyID == "" || detail.CompanyID == nil || *detail.CompanyID == companyID {
+			visible = append(visible, detail)
+		}
+	}
+	response.Success(c, http.StatusOK, "Approval history retrieved successfully", visible)
+}
+
+// ListDelegations lists the delegations given or received by the current user
+// @Summary List approval delegations
+// @Tags Approvals
+// @Produce json
+// @Success 200 {object} response.Response{data=[]entities.ApprovalDelegation}
+// @Router /approvals/delegations [get]
+func (h *ApprovalHandler) ListDelegations(c *gin.Context) {
+	delegations, err := h.approvalService.ListDelegations(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list approval delegations", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval delegations retrieved successfully", delegations)
+}
+
+// CreateDelegation delegates the current user's approvals to another user for a period
+// @Summary Create approval delegation
+// @Tags Approvals
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateDelegationRequest true "Delegation data"
+// @Success 201 {object} response.Response{data=entities.ApprovalDelegation}
+// @Router /approvals/delegations [post]
+func (h *ApprovalHandler) CreateDelegation(c *gin.Context) {
+	var req dto.CreateDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	delegation, err := req.ToDelegationEntity(c.GetString("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delegation dates", err)
+		return
+	}
+
+	if err := h.approvalService.CreateDelegation(c.Request.Context(), delegation); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create approval delegation", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Approval delegation created successfully", delegation)
+}
+
+// RevokeDelegation revokes one of the current user's delegations
+// @Summary Revoke approval delegation
+// @Tags Approvals
+// @Param id path string true "Delegation ID"
+// @Success 200 {object} response.Response
+// @Router /approvals/delegations/{id} [delete]
+func (h *ApprovalHandler) RevokeDelegation(c *gin.Context) {
+	delegationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delegation ID", err)
+		return
+	}
+
+	if err := h.approvalService.RevokeDelegation(c.Request.Context(), delegationID.String(), c.GetString("user_id")); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to revoke approval delegation", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Approval delegation revoked successfully", nil)
+}
+
+func (h *ApprovalHandler) canAccessWorkflow(c *gin.Context, workflow *entities.ApprovalWorkflow) bool {
+	if workflow.CompanyID == nil {
+		return true
+	}
+	return *workflow.CompanyID == c.GetString("company_id")
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"malaka/internal/modules/approval/presentation/http/handlers"
+	"malaka/internal/shared/auth"
+)
+
+// RegisterApprovalRoutes registers the approval workflow routes.
+// Approving and rejecting documents stays on each document's own endpoints.
+func RegisterApprovalRoutes(router *gin.RouterGroup, approvalHandler *handlers.ApprovalHandler, rbacSvc *auth.RBACService) {
+	approvals := router.Group("/approvals")
+	{
+		workflows := approvals.Group("/workflows")
+		{
+			workflows.GET("", auth.RequirePermission(rbacSvc, "approval.workflow.list"), approvalHandler.ListWorkflows)
+			workflows.POST("", auth.RequirePermission(rbacSvc, "approval.workflow.create"), approvalHandler.CreateWorkflow)
+			workflows.GET("/:id", auth.RequirePermission(rbacSvc, "approval.workflow.read"), approvalHandler.GetWorkflow)
+			workflows.PUT("/:id", auth.RequirePermission(rbacSvc, "approval.workflow.update"), approvalHandler.UpdateWorkflow)
+			workflows.DELETE("/:id", auth.RequirePermission(rbacSvc, "approval.workflow.delete"), approvalHandler.DeleteWorkflow)
+		}
+
+		approvals.GET("/inbox", auth.RequirePermission(rbacSvc, "approval.request.list"), approvalHandler.GetInbox)
+		approvals.GET("/requests", auth.RequirePermission(rbacSvc, "approval.request.list"), approvalHandler.ListRequests)
+		approvals.GET("/requests/:id", auth.RequirePermission(rbacSvc, "approval.request.read"), approvalHandler.GetRequest)
+		approvals.GET("/documents/:document_type/:document_id", auth.RequirePermission(rbacSvc, "approval.request.read"), approvalHandler.GetDocumentHistory)
+
+		delegations := approvals.Group("/delegations")
+		{
+			delegations.GET("", auth.RequirePermission(rbacSvc, "approval.delegation.manage"), approvalHandler.ListDelegations)
+			delegations.POST("", auth.RequirePermission(rbacSvc, "approval.delegation.manage"), approvalHandler.CreateDelegation)
+			delegations.DELETE("/:id", auth.RequirePermission(rbacSvc, "approval.delegation.manage"), approvalHandler.RevokeDelegation)
+		}
+	}
+}
@@ -83,6 +83,10 @@ type EventFilter struct {
 	Search    *string    `json:"search,omitempty"`
 	Page      int        `json:"page"`
 	Limit     int        `json:"limit"`
+
+	// Holiday calendar scope, used when listing holiday events
+	CompanyID  string `json:"company_id,omitempty"`
+	RegionCode string `json:"region_code,omitempty"`
 }
 
 // Validate validates the event entity
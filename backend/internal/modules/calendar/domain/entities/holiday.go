@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// HolidayType represents the kind of holiday
+type HolidayType string
+
+const (
+	HolidayTypeNational        HolidayType = "national"
+	HolidayTypeRegional        HolidayType = "regional"
+	HolidayTypeCompany         HolidayType = "company"
+	HolidayTypeCollectiveLeave HolidayType = "collective_leave" // Cuti bersama
+)
+
+// HolidaySource represents where a holiday was entered from
+type HolidaySource string
+
+const (
+	HolidaySourceManual HolidaySource = "manual"
+	HolidaySourceICal   HolidaySource = "ical"
+	HolidaySourceCSV    HolidaySource = "csv"
+)
+
+// DefaultCollectiveLeaveTypeCode is the leave type deducted for cuti bersama when none is given
+const DefaultCollectiveLeaveTypeCode = "ANNUAL"
+
+// HolidayCalendar is a named set of holidays. A calendar without company applies to
+// every company, a calendar without region applies to every region of its company.
+type HolidayCalendar struct {
+	ID          uuid.ID   `json:"id" db:"id"`
+	Code        string    `json:"code" db:"code"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CompanyID   *string   `json:"company_id,omitempty" db:"company_id"`
+	RegionCode  string    `json:"region_code" db:"region_code"`
+	IsActive    bool      `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Holiday is a single non-working day within a holiday calendar
+type Holiday struct {
+	ID                uuid.ID       `json:"id" db:"id"`
+	CalendarID        uuid.ID       `json:"calendar_id" db:"calendar_id"`
+	HolidayDate       time.Time     `json:"holiday_date" db:"holiday_date"`
+	Name              string        `json:"name" db:"name"`
+	Description       string        `json:"description" db:"description"`
+	HolidayType       HolidayType   `json:"holiday_type" db:"holiday_type"`
+	IsCollectiveLeave bool          `json:"is_collective_leave" db:"is_collective_leave"`
+	LeaveTypeCode     string        `json:"leave_type_code" db:"leave_type_code"`
+	Source            HolidaySource `json:"source" db:"source"`
+	CreatedBy         *string       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt         time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at" db:"updated_at"`
+
+	// Scope of the owning calendar (loaded by join)
+	CompanyID  *string `json:"company_id,omitempty" db:"company_id"`
+	RegionCode string  `json:"region_code,omitempty" db:"region_code"`
+}
+
+// HolidayFilter represents filtering options for holidays
+type HolidayFilter struct {
+	CalendarID *string
+	CompanyID  string
+	RegionCode string
+	StartDate  *time.Time
+	EndDate    *time.Time
+}
+
+// Validate validates the holiday calendar entity
+func (c *HolidayCalendar) Validate() error {
+	if c.Code == "" {
+		return fmt.Errorf("calendar code is required")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("calendar name is required")
+	}
+	return nil
+}
+
+// Validate validates the holiday entity
+func (h *Holiday) Validate() error {
+	if h.CalendarID.IsNil() {
+		return fmt.Errorf("calendar_id is required")
+	}
+	if h.HolidayDate.IsZero() {
+		return fmt.Errorf("holiday date is required")
+	}
+	if h.Name == "" {
+		return fmt.Errorf("holiday name is required")
+	}
+
+	validTypes := map[HolidayType]bool{
+		HolidayTypeNational:        true,
+		HolidayTypeRegional:        true,
+		HolidayTypeCompany:         true,
+		HolidayTypeCollectiveLeave: true,
+	}
+	if !validTypes[h.HolidayType] {
+		return fmt.Errorf("invalid holiday type: %s", h.HolidayType)
+	}
+
+	validSources := map[HolidaySource]bool{
+		HolidaySourceManual: true,
+		HolidaySourceICal:   true,
+		HolidaySourceCSV:    true,
+	}
+	if !validSources[h.Source] {
+		return fmt.Errorf("invalid holiday source: %s", h.Source)
+	}
+
+	return nil
+}
+
+// Normalize truncates the date to midnight and fills defaults derived from the type
+func (h *Holiday) Normalize() {
+	h.HolidayDate = time.Date(h.HolidayDate.Year(), h.HolidayDate.Month(), h.HolidayDate.Day(), 0, 0, 0, 0, time.UTC)
+	if h.HolidayType == "" {
+		h.HolidayType = HolidayTypeNational
+	}
+	if h.Source == "" {
+		h.Source = HolidaySourceManual
+	}
+	if h.HolidayType == HolidayTypeCollectiveLeave {
+		h.IsCollectiveLeave = true
+	}
+	if h.IsCollectiveLeave && h.LeaveTypeCode == "" {
+		h.LeaveTypeCode = DefaultCollectiveLeaveTypeCode
+	}
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/calendar/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// HolidayRepository defines the interface for holiday calendar data access
+type HolidayRepository interface {
+	// Holiday calendar operations
+	CreateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error
+	GetCalendarByID(ctx context.Context, id uuid.ID) (*entities.HolidayCalendar, error)
+	GetCalendarByCode(ctx context.Context, code string) (*entities.HolidayCalendar, error)
+	ListCalendars(ctx context.Context, companyID string) ([]entities.HolidayCalendar, error)
+	UpdateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error
+	DeleteCalendar(ctx context.Context, id uuid.ID) error
+
+	// Holiday operations
+	Create(ctx context.Context, holiday *entities.Holiday) error
+	Upsert(ctx context.Context, holiday *entities.Holiday) (bool, error)
+	GetByID(ctx context.Context, id uuid.ID) (*entities.Holiday, error)
+	// GetByDateAndName returns the holiday of a calendar on a date with a name, nil when there is none
+	GetByDateAndName(ctx context.Context, calendarID uuid.ID, date time.Time, name string) (*entities.Holiday, error)
+	Update(ctx context.Context, holiday *entities.Holiday) error
+	Delete(ctx context.Context, id uuid.ID) error
+
+	// List returns holidays of the calendars matching the filter scope
+	List(ctx context.Context, filter *entities.HolidayFilter) ([]entities.Holiday, error)
+}
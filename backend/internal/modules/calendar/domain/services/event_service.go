@@ -17,8 +17,9 @@ type EventService interface {
 
 	// Event listing and filtering
 	ListEvents(ctx context.Context, filter *entities.EventFilter) ([]entities.Event, int, error)
-	GetEventsByDateRange(ctx context.Context, startDate, endDate string, userID *string) ([]entities.Event, error)
-	GetEventsByMonth(ctx context.Context, year, month int, userID *string) ([]entities.Event, error)
+	// The holidays of the company's and region's calendars are included with the events
+	GetEventsByDateRange(ctx context.Context, startDate, endDate, companyID, regionCode string, userID *string) ([]entities.Event, error)
+	GetEventsByMonth(ctx context.Context, year, month int, companyID, regionCode string, userID *string) ([]entities.Event, error)
 	GetUserEvents(ctx context.Context, userID string, filter *entities.EventFilter) ([]entities.Event, int, error)
 
 	// Attendee operations
@@ -7,22 +7,33 @@ import (
 
 	"malaka/internal/modules/calendar/domain/entities"
 	"malaka/internal/modules/calendar/domain/repositories"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/uuid"
 )
 
+// ErrHolidayManagedByCalendar is returned when holiday events are written through the event API
+var ErrHolidayManagedByCalendar = fmt.Errorf("holidays are managed in the holiday calendar")
+
 type eventServiceImpl struct {
-	eventRepo repositories.EventRepository
+	eventRepo     repositories.EventRepository
+	holidayReader integration.HolidayReader
 }
 
-// NewEventService creates a new instance of event service
-func NewEventService(eventRepo repositories.EventRepository) EventService {
+// NewEventService creates a new instance of event service.
+// Holiday events are read from holidayReader; it may be nil when no holiday calendar is configured.
+func NewEventService(eventRepo repositories.EventRepository, holidayReader integration.HolidayReader) EventService {
 	return &eventServiceImpl{
-		eventRepo: eventRepo,
+		eventRepo:     eventRepo,
+		holidayReader: holidayReader,
 	}
 }
 
 // CreateEvent creates a new event with attendees
 func (s *eventServiceImpl) CreateEvent(ctx context.Context, event *entities.Event, attendeeIDs []string) error {
+	if event.EventType == entities.EventTypeHoliday {
+		return ErrHolidayManagedByCalendar
+	}
+
 	// Validate event
 	if err := event.Validate(); err != nil {
 		return fmt.Errorf("invalid event data: %w", err)
@@ -108,8 +119,12 @@ func (s *eventServiceImpl) UpdateEvent(ctx context.Context, event *entities.Even
 		return fmt.Errorf("failed to get existing event: %w", err)
 	}
 
-	// Only the creator can update the event (except for holidays)
-	if existingEvent.CreatedBy != userID && existingEvent.EventType != entities.EventTypeHoliday {
+	if existingEvent.EventType == entities.EventTypeHoliday || event.EventType == entities.EventTypeHoliday {
+		return ErrHolidayManagedByCalendar
+	}
+
+	// Only the creator can update the event
+	if existingEvent.CreatedBy != userID {
 		return fmt.Errorf("access denied: only event creator can update this event")
 	}
 
@@ -201,14 +216,14 @@ func (s *eventServiceImpl) DeleteEvent(ctx context.Context, id uuid.ID, userID u
 		return fmt.Errorf("failed to get existing event: %w", err)
 	}
 
-	// Only the creator can delete the event (except for holidays which should be protected)
-	if existingEvent.CreatedBy != userID {
-		return fmt.Errorf("access denied: only event creator can delete this event")
+	// Holidays live in the holiday calendar
+	if existingEvent.EventType == entities.EventTypeHoliday {
+		return ErrHolidayManagedByCalendar
 	}
 
-	// Prevent deletion of system holidays
-	if existingEvent.EventType == entities.EventTypeHoliday && existingEvent.CreatedBy.String() == "00000000-0000-0000-0000-000000000000" {
-		return fmt.Errorf("system holidays cannot be deleted")
+	// Only the creator can delete the event
+	if existingEvent.CreatedBy != userID {
+		return fmt.Errorf("access denied: only event creator can delete this event")
 	}
 
 	// Delete event (attendees will be cascade deleted)
@@ -236,6 +251,11 @@ func (s *eventServiceImpl) ListEvents(ctx context.Context, filter *entities.Even
 		filter.Limit = 100
 	}
 
+	// Holiday events come from the holiday calendar
+	if filter.EventType != nil && *filter.EventType == entities.EventTypeHoliday {
+		return s.listHolidayEvents(ctx, filter)
+	}
+
 	events, total, err := s.eventRepo.List(ctx, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list events: %w", err)
@@ -244,22 +264,110 @@ func (s *eventServiceImpl) ListEvents(ctx context.Context, filter *entities.Even
 	return events, total, nil
 }
 
+// listHolidayEvents pages holidays of the filter's company/region as holiday events.
+// Without a date range the current year is listed.
+func (s *eventServiceImpl) listHolidayEvents(ctx context.Context, filter *entities.EventFilter) ([]entities.Event, int, error) {
+	if s.holidayReader == nil {
+		return []entities.Event{}, 0, nil
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(now.Year(), 12, 31, 0, 0, 0, 0, time.UTC)
+	if filter.StartDate != nil {
+		start = *filter.StartDate
+	}
+	if filter.EndDate != nil {
+		end = *filter.EndDate
+	}
+
+	holidays, err := s.holidayReader.GetHolidays(ctx, filter.CompanyID, filter.RegionCode, start, end)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list holidays: %w", err)
+	}
+
+	events := holidaysToEvents(holidays)
+	total := len(events)
+	offset := (filter.Page - 1) * filter.Limit
+	if offset >= total {
+		return []entities.Event{}, total, nil
+	}
+	last := offset + filter.Limit
+	if last > total {
+		last = total
+	}
+	return events[offset:last], total, nil
+}
+
+// withHolidays appends the holidays between start and end of the shared calendars and
+// those of the company and region to events
+func (s *eventServiceImpl) withHolidays(ctx context.Context, events []entities.Event, companyID, regionCode string, start, end time.Time) ([]entities.Event, error) {
+	if s.holidayReader == nil {
+		return events, nil
+	}
+
+	holidays, err := s.holidayReader.GetHolidays(ctx, companyID, regionCode, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holidays: %w", err)
+	}
+	return append(events, holidaysToEvents(holidays)...), nil
+}
+
+// parseDatePrefix parses the YYYY-MM-DD part of a date or timestamp string
+func parseDatePrefix(value string) (time.Time, error) {
+	if len(value) > 10 {
+		value = value[:10]
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func holidaysToEvents(holidays []integration.HolidayDTO) []entities.Event {
+	events := make([]entities.Event, 0, len(holidays))
+	for _, h := range holidays {
+		id, _ := uuid.Parse(h.ID)
+		priority := entities.PriorityHigh
+		if h.IsCollectiveLeave {
+			priority = entities.PriorityMedium
+		}
+		events = append(events, entities.Event{
+			ID:            id,
+			Title:         h.Name,
+			Description:   h.Description,
+			StartDateTime: h.Date,
+			EventType:     entities.EventTypeHoliday,
+			Priority:      priority,
+			IsAllDay:      true,
+			Attendees:     []entities.EventAttendee{},
+		})
+	}
+	return events
+}
+
 // GetEventsByDateRange retrieves events within a date range
-func (s *eventServiceImpl) GetEventsByDateRange(ctx context.Context, startDate, endDate string, userID *string) ([]entities.Event, error) {
+func (s *eventServiceImpl) GetEventsByDateRange(ctx context.Context, startDate, endDate, companyID, regionCode string, userID *string) ([]entities.Event, error) {
 	if startDate == "" || endDate == "" {
 		return nil, fmt.Errorf("start date and end date are required")
 	}
 
+	start, err := parseDatePrefix(startDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date format: %w", err)
+	}
+	end, err := parseDatePrefix(endDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date format: %w", err)
+	}
+
 	events, err := s.eventRepo.GetByDateRange(ctx, startDate, endDate, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events by date range: %w", err)
 	}
 
-	return events, nil
+	return s.withHolidays(ctx, events, companyID, regionCode, start, end)
 }
 
 // GetEventsByMonth retrieves events for a specific month
-func (s *eventServiceImpl) GetEventsByMonth(ctx context.Context, year, month int, userID *string) ([]entities.Event, error) {
+func (s *eventServiceImpl) GetEventsByMonth(ctx context.Context, year, month int, companyID, regionCode string, userID *string) ([]entities.Event, error) {
 	if year < 1900 || year > 3000 {
 		return nil, fmt.Errorf("invalid year: %d", year)
 	}
@@ -273,7 +381,8 @@ func (s *eventServiceImpl) GetEventsByMonth(ctx context.Context, year, month int
 		return nil, fmt.Errorf("failed to get events by month: %w", err)
 	}
 
-	return events, nil
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return s.withHolidays(ctx, events, companyID, regionCode, start, start.AddDate(0, 1, -1))
 }
 
 // GetUserEvents retrieves events for a specific user
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/calendar/domain/entities"
+)
+
+// collectiveLeaveMarkers identify cuti bersama entries in imported files
+var collectiveLeaveMarkers = []string{"cuti bersama", "collective leave", "collective_leave"}
+
+func isCollectiveLeaveText(s string) bool {
+	s = strings.ToLower(s)
+	for _, marker := range collectiveLeaveMarkers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseICalHolidays parses VEVENT entries of an iCalendar (RFC 5545) file into holidays.
+// Multi-day events are expanded into one holiday per day (DTEND is exclusive).
+// When year is non-zero only holidays in that year are returned.
+func ParseICalHolidays(r io.Reader, year int) ([]entities.Holiday, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var holidays []entities.Holiday
+	var inEvent bool
+	var summary, description, categories string
+	var start, end time.Time
+
+	for i, line := range lines {
+		name, params, value := splitICalLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent = true
+			summary, description, categories = "", "", ""
+			start, end = time.Time{}, time.Time{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if !inEvent {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", i+1, summary)
+			}
+			if summary == "" {
+				return nil, fmt.Errorf("line %d: event on %s has no SUMMARY", i+1, start.Format("2006-01-02"))
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+
+			collective := isCollectiveLeaveText(categories) || isCollectiveLeaveText(summary)
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				if year != 0 && d.Year() != year {
+					continue
+				}
+				holiday := entities.Holiday{
+					HolidayDate:       d,
+					Name:              summary,
+					Description:       description,
+					HolidayType:       entities.HolidayTypeNational,
+					IsCollectiveLeave: collective,
+					Source:            entities.HolidaySourceICal,
+				}
+				if collective {
+					holiday.HolidayType = entities.HolidayTypeCollectiveLeave
+				}
+				holidays = append(holidays, holiday)
+			}
+		case !inEvent:
+			continue
+		case name == "SUMMARY":
+			summary = unescapeICalText(value)
+		case name == "DESCRIPTION":
+			description = unescapeICalText(value)
+		case name == "CATEGORIES":
+			categories = unescapeICalText(value)
+		case name == "DTSTART":
+			if start, err = parseICalDate(params, value); err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTSTART: %w", i+1, err)
+			}
+		case name == "DTEND":
+			if end, err = parseICalDate(params, value); err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTEND: %w", i+1, err)
+			}
+		}
+	}
+
+	return holidays, nil
+}
+
+// unfoldICalLines joins continuation lines (lines starting with a space or tab)
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar file: %w", err)
+	}
+	return lines, nil
+}
+
+// splitICalLine splits "NAME;PARAM=X:VALUE" into its name, parameters and value
+func splitICalLine(line string) (string, string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), "", ""
+	}
+	head, value := line[:colon], line[colon+1:]
+	name, params := head, ""
+	if semi := strings.Index(head, ";"); semi >= 0 {
+		name, params = head[:semi], head[semi+1:]
+	}
+	return strings.ToUpper(name), strings.ToUpper(params), value
+}
+
+// parseICalDate accepts DATE (20240410) and DATE-TIME (20240410T000000[Z]) values
+func parseICalDate(params, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	isDate := strings.Contains(params, "VALUE=DATE") && !strings.Contains(params, "VALUE=DATE-TIME")
+	if isDate || len(value) == 8 {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t, nil
+	}
+
+	layout := "20060102T150405"
+	if strings.HasSuffix(value, "Z") {
+		layout = "20060102T150405Z"
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func unescapeICalText(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(s))
+}
+
+// ParseCSVHolidays parses a CSV file with the header
+// date,name[,type][,description][,collective_leave][,leave_type_code]
+// Dates use YYYY-MM-DD. When year is non-zero only holidays in that year are returned.
+func ParseCSVHolidays(r io.Reader, year int) ([]entities.Holiday, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("csv file is empty")
+		}
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))] = i
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("csv header must contain a date column")
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("csv header must contain a name column")
+	}
+
+	field := func(record []string, name string) string {
+		if idx, ok := columns[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+
+	var holidays []entities.Holiday
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		dateStr := field(record, "date")
+		if dateStr == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q, expected YYYY-MM-DD", line, dateStr)
+		}
+		if year != 0 && date.Year() != year {
+			continue
+		}
+
+		holiday := entities.Holiday{
+			HolidayDate:   date,
+			Name:          field(record, "name"),
+			Description:   field(record, "description"),
+			HolidayType:   entities.HolidayType(strings.ToLower(field(record, "type"))),
+			LeaveTypeCode: strings.ToUpper(field(record, "leave_type_code")),
+			Source:        entities.HolidaySourceCSV,
+		}
+		if holiday.Name == "" {
+			return nil, fmt.Errorf("line %d: name is required", line)
+		}
+		if v := field(record, "collective_leave"); v != "" {
+			collective, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid collective_leave value %q", line, v)
+			}
+			holiday.IsCollectiveLeave = collective
+		}
+		if holiday.IsCollectiveLeave && holiday.HolidayType == "" {
+			holiday.HolidayType = entities.HolidayTypeCollectiveLeave
+		}
+		holiday.Normalize()
+		switch holiday.HolidayType {
+		case entities.HolidayTypeNational, entities.HolidayTypeRegional, entities.HolidayTypeCompany, entities.HolidayTypeCollectiveLeave:
+		default:
+			return nil, fmt.Errorf("line %d: invalid holiday type %q", line, holiday.HolidayType)
+		}
+
+		holidays = append(holidays, holiday)
+	}
+
+	return holidays, nil
+}
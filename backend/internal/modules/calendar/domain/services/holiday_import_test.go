@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"malaka/internal/modules/calendar/domain/entities"
+	"malaka/internal/shared/events"
+	"malaka/internal/shared/uuid"
+)
+
+// MockHolidayRepository is a mock implementation of repositories.HolidayRepository.
+type MockHolidayRepository struct {
+	mock.Mock
+}
+
+func (m *MockHolidayRepository) CreateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error {
+	args := m.Called(ctx, calendar)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepository) GetCalendarByID(ctx context.Context, id uuid.ID) (*entities.HolidayCalendar, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.HolidayCalendar), args.Error(1)
+}
+
+func (m *MockHolidayRepository) GetCalendarByCode(ctx context.Context, code string) (*entities.HolidayCalendar, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.HolidayCalendar), args.Error(1)
+}
+
+func (m *MockHolidayRepository) ListCalendars(ctx context.Context, companyID string) ([]entities.HolidayCalendar, error) {
+	args := m.Called(ctx, companyID)
+	return args.Get(0).([]entities.HolidayCalendar), args.Error(1)
+}
+
+func (m *MockHolidayRepository) UpdateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error {
+	args := m.Called(ctx, calendar)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepository) DeleteCalendar(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepository) Create(ctx context.Context, holiday *entities.Holiday) error {
+	args := m.Called(ctx, holiday)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepository) Upsert(ctx context.Context, holiday *entities.Holiday) (bool, error) {
+	args := m.Called(ctx, holiday)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockHolidayRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.Holiday, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Holiday), args.Error(1)
+}
+
+func (m *MockHolidayRepository) GetByDateAndName(ctx context.Context, calendarID uuid.ID, date time.Time, name string) (*entities.Holiday, error) {
+	args := m.Called(ctx, calendarID, date, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Holiday), args.Error(1)
+}
+
+func (m *MockHolidayRepository) Update(ctx context.Context, holiday *entities.Holiday) error {
+	args := m.Called(ctx, holiday)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepository) Delete(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepository) List(ctx context.Context, filter *entities.HolidayFilter) ([]entities.Holiday, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entities.Holiday), args.Error(1)
+}
+
+// MockEventBus is a mock implementation of events.EventBus.
+type MockEventBus struct {
+	mock.Mock
+}
+
+func (m *MockEventBus) Publish(ctx context.Context, event events.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockEventBus) PublishAsync(ctx context.Context, event events.Event) {
+	m.Called(ctx, event)
+}
+
+func (m *MockEventBus) Subscribe(eventType string, handler events.EventHandler) {
+	m.Called(eventType, handler)
+}
+
+func (m *MockEventBus) SubscribeAll(handler events.EventHandler) {
+	m.Called(handler)
+}
+
+func (m *MockEventBus) Unsubscribe(eventType string, handler events.EventHandler) {
+	m.Called(eventType, handler)
+}
+
+func TestParseICalHolidays(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20250331",
+		"DTEND;VALUE=DATE:20250402",
+		"SUMMARY:Hari Raya Idul Fitri",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20250402",
+		"SUMMARY:Cuti Bersama Idul",
+		"  Fitri",
+		"CATEGORIES:Cuti Bersama",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20241225T000000Z",
+		"SUMMARY:Hari Raya Natal",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	t.Run("ExpandsMultiDayEventsAndFiltersYear", func(t *testing.T) {
+		holidays, err := ParseICalHolidays(strings.NewReader(ics), 2025)
+		require.NoError(t, err)
+		require.Len(t, holidays, 3)
+
+		assert.Equal(t, "2025-03-31", holidays[0].HolidayDate.Format("2006-01-02"))
+		assert.Equal(t, "2025-04-01", holidays[1].HolidayDate.Format("2006-01-02"))
+		assert.Equal(t, entities.HolidayTypeNational, holidays[0].HolidayType)
+		assert.False(t, holidays[0].IsCollectiveLeave)
+
+		assert.Equal(t, "Cuti Bersama Idul Fitri", holidays[2].Name)
+		assert.True(t, holidays[2].IsCollectiveLeave)
+		assert.Equal(t, entities.HolidayTypeCollectiveLeave, holidays[2].HolidayType)
+		assert.Equal(t, entities.HolidaySourceICal, holidays[2].Source)
+	})
+
+	t.Run("AllYears", func(t *testing.T) {
+		holidays, err := ParseICalHolidays(strings.NewReader(ics), 0)
+		require.NoError(t, err)
+		assert.Len(t, holidays, 4)
+	})
+
+	t.Run("MissingSummary", func(t *testing.T) {
+		_, err := ParseICalHolidays(strings.NewReader("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250101\nEND:VEVENT\n"), 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseCSVHolidays(t *testing.T) {
+	t.Run("ParsesOptionalColumns", func(t *testing.T) {
+		csv := "\ufeffdate,name,type,collective_leave,leave_type_code\n" +
+			"2025-01-01,Tahun Baru,national,,\n" +
+			"2025-04-02,Cuti Bersama Idul Fitri,,true,annual\n" +
+			"2024-12-25,Natal,national,,\n"
+
+		holidays, err := ParseCSVHolidays(strings.NewReader(csv), 2025)
+		require.NoError(t, err)
+		require.Len(t, holidays, 2)
+
+		assert.Equal(t, "Tahun Baru", holidays[0].Name)
+		assert.False(t, holidays[0].IsCollectiveLeave)
+		assert.True(t, holidays[1].IsCollectiveLeave)
+		assert.Equal(t, entities.HolidayTypeCollectiveLeave, holidays[1].HolidayType)
+		assert.Equal(t, "ANNUAL", holidays[1].LeaveTypeCode)
+		assert.Equal(t, entities.HolidaySourceCSV, holidays[1].Source)
+	})
+
+	t.Run("RejectsInvalidRows", func(t *testing.T) {
+		_, err := ParseCSVHolidays(strings.NewReader("date,name\n01/01/2025,Tahun Baru\n"), 0)
+		assert.Error(t, err)
+
+		_, err = ParseCSVHolidays(strings.NewReader("date,name,type\n2025-01-01,Tahun Baru,religious\n"), 0)
+		assert.Error(t, err)
+
+		_, err = ParseCSVHolidays(strings.NewReader("name\nTahun Baru\n"), 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestImportHolidays_RepublishesChangedCollectiveLeave(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockHolidayRepository)
+	bus := new(MockEventBus)
+	service := NewHolidayService(repo, bus)
+
+	calendar := &entities.HolidayCalendar{ID: uuid.New(), Code: "ID-NATIONAL", Name: "Indonesia"}
+	idulFitri := &entities.Holiday{ID: uuid.New(), CalendarID: calendar.ID, Name: "Cuti Bersama Idul Fitri",
+		HolidayDate: time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC), HolidayType: entities.HolidayTypeCollectiveLeave,
+		IsCollectiveLeave: true, LeaveTypeCode: "ANNUAL"}
+	natal := &entities.Holiday{ID: uuid.New(), CalendarID: calendar.ID, Name: "Cuti Bersama Natal",
+		HolidayDate: time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC), HolidayType: entities.HolidayTypeNational}
+	tahunBaru := &entities.Holiday{ID: uuid.New(), CalendarID: calendar.ID, Name: "Tahun Baru",
+		HolidayDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), HolidayType: entities.HolidayTypeNational}
+
+	repo.On("GetCalendarByID", ctx, calendar.ID).Return(calendar, nil).Once()
+	for _, existing := range []*entities.Holiday{idulFitri, natal, tahunBaru} {
+		existing := existing
+		repo.On("GetByDateAndName", ctx, calendar.ID, existing.HolidayDate, existing.Name).Return(existing, nil).Once()
+		repo.On("Upsert", ctx, mock.MatchedBy(func(h *entities.Holiday) bool { return h.Name == existing.Name })).
+			Run(func(args mock.Arguments) { args.Get(1).(*entities.Holiday).ID = existing.ID }).
+			Return(false, nil).Once()
+	}
+	bus.On("Publish", ctx, mock.MatchedBy(func(e *events.CollectiveLeaveRevokedEvent) bool {
+		return e.HolidayID == idulFitri.ID.String()
+	})).Return(nil).Once()
+	bus.On("Publish", ctx, mock.MatchedBy(func(e *events.CollectiveLeaveDeclaredEvent) bool {
+		return e.HolidayID == natal.ID.String() && e.CalendarID == calendar.ID.String() && e.LeaveTypeCode == "ANNUAL"
+	})).Return(nil).Once()
+
+	csv := "date,name,type,collective_leave,leave_type_code\n" +
+		"2025-04-02,Cuti Bersama Idul Fitri,national,false,\n" +
+		"2025-12-26,Cuti Bersama Natal,,true,annual\n" +
+		"2025-01-01,Tahun Baru,national,,\n"
+	result, err := service.ImportHolidays(ctx, calendar.ID, HolidayImportFormatCSV, strings.NewReader(csv), 2025, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Updated)
+	assert.Equal(t, 0, result.Created)
+	repo.AssertExpectations(t)
+	bus.AssertExpectations(t)
+	bus.AssertNumberOfCalls(t, "Publish", 2)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"io"
+
+	"malaka/internal/modules/calendar/domain/entities"
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/uuid"
+)
+
+// Holiday import formats
+const (
+	HolidayImportFormatICal = "ical"
+	HolidayImportFormatCSV  = "csv"
+)
+
+// HolidayImportResult summarizes a holiday file import
+type HolidayImportResult struct {
+	CalendarID string `json:"calendar_id"`
+	Year       int    `json:"year,omitempty"`
+	Total      int    `json:"total"`
+	Created    int    `json:"created"`
+	Updated    int    `json:"updated"`
+}
+
+// HolidayService defines the interface for holiday calendar business logic.
+// It also serves the holiday calendar to other modules through integration.HolidayReader.
+type HolidayService interface {
+	integration.HolidayReader
+
+	// Holiday calendar operations
+	CreateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error
+	GetCalendarByID(ctx context.Context, id uuid.ID) (*entities.HolidayCalendar, error)
+	ListCalendars(ctx context.Context, companyID string) ([]entities.HolidayCalendar, error)
+	UpdateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error
+	DeleteCalendar(ctx context.Context, id uuid.ID) error
+
+	// Holiday operations
+	CreateHoliday(ctx context.Context, holiday *entities.Holiday) error
+	GetHolidayByID(ctx context.Context, id uuid.ID) (*entities.Holiday, error)
+	UpdateHoliday(ctx context.Context, holiday *entities.Holiday) error
+	DeleteHoliday(ctx context.Context, id uuid.ID) error
+	ListHolidays(ctx context.Context, filter *entities.HolidayFilter) ([]entities.Holiday, error)
+
+	// ImportHolidays loads a yearly holiday file (iCalendar or CSV) into a calendar
+	ImportHolidays(ctx context.Context, calendarID uuid.ID, format string, r io.Reader, year int, createdBy *string) (*HolidayImportResult, error)
+}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"malaka/internal/modules/calendar/domain/entities"
+	"malaka/internal/modules/calendar/domain/repositories"
+	"malaka/internal/shared/events"
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/uuid"
+)
+
+type holidayServiceImpl struct {
+	holidayRepo repositories.HolidayRepository
+	eventBus    events.EventBus
+}
+
+// NewHolidayService creates a new instance of holiday service.
+// eventBus may be nil, in which case cuti bersama is not propagated to HR.
+func NewHolidayService(holidayRepo repositories.HolidayRepository, eventBus events.EventBus) HolidayService {
+	return &holidayServiceImpl{
+		holidayRepo: holidayRepo,
+		eventBus:    eventBus,
+	}
+}
+
+// CreateCalendar creates a new holiday calendar
+func (s *holidayServiceImpl) CreateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error {
+	if err := calendar.Validate(); err != nil {
+		return fmt.Errorf("invalid holiday calendar data: %w", err)
+	}
+
+	existing, err := s.holidayRepo.GetCalendarByCode(ctx, calendar.Code)
+	if err != nil {
+		return fmt.Errorf("failed to check calendar code: %w", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("holiday calendar with code %s already exists", calendar.Code)
+	}
+
+	if err := s.holidayRepo.CreateCalendar(ctx, calendar); err != nil {
+		return fmt.Errorf("failed to create holiday calendar: %w", err)
+	}
+	return nil
+}
+
+// GetCalendarByID retrieves a holiday calendar by ID
+func (s *holidayServiceImpl) GetCalendarByID(ctx context.Context, id uuid.ID) (*entities.HolidayCalendar, error) {
+	if id.IsNil() {
+		return nil, fmt.Errorf("calendar ID is required")
+	}
+
+	calendar, err := s.holidayRepo.GetCalendarByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holiday calendar: %w", err)
+	}
+	if calendar == nil {
+		return nil, fmt.Errorf("holiday calendar not found")
+	}
+	return calendar, nil
+}
+
+// ListCalendars lists the calendars visible to a company
+func (s *holidayServiceImpl) ListCalendars(ctx context.Context, companyID string) ([]entities.HolidayCalendar, error) {
+	calendars, err := s.holidayRepo.ListCalendars(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holiday calendars: %w", err)
+	}
+	return calendars, nil
+}
+
+// UpdateCalendar updates a holiday calendar
+func (s *holidayServiceImpl) UpdateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error {
+	if calendar.ID.IsNil() {
+		return fmt.Errorf("calendar ID is required")
+	}
+	if err := calendar.Validate(); err != nil {
+		return fmt.Errorf("invalid holiday calendar data: %w", err)
+	}
+
+	if err := s.holidayRepo.UpdateCalendar(ctx, calendar); err != nil {
+		return fmt.Errorf("failed to update holiday calendar: %w", err)
+	}
+	return nil
+}
+
+// DeleteCalendar deletes a holiday calendar, restoring any cuti bersama leave it deducted
+func (s *holidayServiceImpl) DeleteCalendar(ctx context.Context, id uuid.ID) error {
+	if id.IsNil() {
+		return fmt.Errorf("calendar ID is required")
+	}
+
+	calendarID := id.String()
+	holidays, err := s.holidayRepo.List(ctx, &entities.HolidayFilter{CalendarID: &calendarID})
+	if err != nil {
+		return fmt.Errorf("failed to get calendar holidays: %w", err)
+	}
+
+	if err := s.holidayRepo.DeleteCalendar(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete holiday calendar: %w", err)
+	}
+
+	// Restore the leave only once the holidays are gone
+	for i := range holidays {
+		if holidays[i].IsCollectiveLeave {
+			if err := s.publishCollectiveLeaveRevoked(ctx, &holidays[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CreateHoliday adds a holiday to a calendar
+func (s *holidayServiceImpl) CreateHoliday(ctx context.Context, holiday *entities.Holiday) error {
+	holiday.Normalize()
+	if err := holiday.Validate(); err != nil {
+		return fmt.Errorf("invalid holiday data: %w", err)
+	}
+
+	calendar, err := s.GetCalendarByID(ctx, holiday.CalendarID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.holidayRepo.Create(ctx, holiday); err != nil {
+		return fmt.Errorf("failed to create holiday: %w", err)
+	}
+
+	if holiday.IsCollectiveLeave {
+		return s.publishCollectiveLeaveDeclared(ctx, calendar, holiday)
+	}
+	return nil
+}
+
+// GetHolidayByID retrieves a holiday by ID
+func (s *holidayServiceImpl) GetHolidayByID(ctx context.Context, id uuid.ID) (*entities.Holiday, error) {
+	if id.IsNil() {
+		return nil, fmt.Errorf("holiday ID is required")
+	}
+
+	holiday, err := s.holidayRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holiday: %w", err)
+	}
+	if holiday == nil {
+		return nil, fmt.Errorf("holiday not found")
+	}
+	return holiday, nil
+}
+
+// UpdateHoliday updates a holiday. Changing the date or the cuti bersama flag
+// reverts the leave previously deducted and deducts it again for the new date.
+func (s *holidayServiceImpl) UpdateHoliday(ctx context.Context, holiday *entities.Holiday) error {
+	existing, err := s.GetHolidayByID(ctx, holiday.ID)
+	if err != nil {
+		return err
+	}
+
+	holiday.CalendarID = existing.CalendarID
+	holiday.Source = existing.Source
+	holiday.Normalize()
+	if err := holiday.Validate(); err != nil {
+		return fmt.Errorf("invalid holiday data: %w", err)
+	}
+
+	if err := s.holidayRepo.Update(ctx, holiday); err != nil {
+		return fmt.Errorf("failed to update holiday: %w", err)
+	}
+
+	if !collectiveLeaveChanged(existing, holiday) {
+		return nil
+	}
+	calendar, err := s.GetCalendarByID(ctx, holiday.CalendarID)
+	if err != nil {
+		return err
+	}
+	return s.republishCollectiveLeave(ctx, calendar, existing, holiday)
+}
+
+// collectiveLeaveChanged reports whether an update moves or flips the cuti bersama of a holiday.
+func collectiveLeaveChanged(existing, holiday *entities.Holiday) bool {
+	return !existing.HolidayDate.Equal(holiday.HolidayDate) ||
+		existing.IsCollectiveLeave != holiday.IsCollectiveLeave ||
+		existing.LeaveTypeCode != holiday.LeaveTypeCode
+}
+
+// republishCollectiveLeave reverts the leave deducted for the previous version of a
+// holiday and deducts it again for the current one.
+func (s *holidayServiceImpl) republishCollectiveLeave(ctx context.Context, calendar *entities.HolidayCalendar, existing, holiday *entities.Holiday) error {
+	if existing.IsCollectiveLeave {
+		if err := s.publishCollectiveLeaveRevoked(ctx, existing); err != nil {
+			return err
+		}
+	}
+	if holiday.IsCollectiveLeave {
+		return s.publishCollectiveLeaveDeclared(ctx, calendar, holiday)
+	}
+	return nil
+}
+
+// DeleteHoliday deletes a holiday, restoring any cuti bersama leave it deducted
+func (s *holidayServiceImpl) DeleteHoliday(ctx context.Context, id uuid.ID) error {
+	existing, err := s.GetHolidayByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.holidayRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete holiday: %w", err)
+	}
+
+	if existing.IsCollectiveLeave {
+		return s.publishCollectiveLeaveRevoked(ctx, existing)
+	}
+	return nil
+}
+
+// ListHolidays lists holidays matching the filter
+func (s *holidayServiceImpl) ListHolidays(ctx context.Context, filter *entities.HolidayFilter) ([]entities.Holiday, error) {
+	if filter != nil && filter.StartDate != nil && filter.EndDate != nil && filter.EndDate.Before(*filter.StartDate) {
+		return nil, fmt.Errorf("end date cannot be before start date")
+	}
+
+	holidays, err := s.holidayRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holidays: %w", err)
+	}
+	return holidays, nil
+}
+
+// ImportHolidays loads a yearly holiday file into a calendar. Rows that already exist
+// (same date and name) are updated, so re-importing the same file is safe; a row whose
+// cuti bersama flag or leave type changed has its leave reverted and deducted again.
+func (s *holidayServiceImpl) ImportHolidays(ctx context.Context, calendarID uuid.ID, format string, r io.Reader, year int, createdBy *string) (*HolidayImportResult, error) {
+	calendar, err := s.GetCalendarByID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	var holidays []entities.Holiday
+	switch format {
+	case HolidayImportFormatICal:
+		holidays, err = ParseICalHolidays(r, year)
+	case HolidayImportFormatCSV:
+		holidays, err = ParseCSVHolidays(r, year)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse holiday file: %w", err)
+	}
+
+	result := &HolidayImportResult{CalendarID: calendar.ID.String(), Year: year, Total: len(holidays)}
+	for i := range holidays {
+		holiday := &holidays[i]
+		holiday.CalendarID = calendar.ID
+		holiday.CreatedBy = createdBy
+		if holiday.HolidayType == entities.HolidayTypeNational && calendar.RegionCode != "" {
+			holiday.HolidayType = entities.HolidayTypeRegional
+		}
+		holiday.Normalize()
+		if err := holiday.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q on %s: %w", holiday.Name, holiday.HolidayDate.Format("2006-01-02"), err)
+		}
+
+		existing, err := s.holidayRepo.GetByDateAndName(ctx, calendar.ID, holiday.HolidayDate, holiday.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get holiday %q: %w", holiday.Name, err)
+		}
+		inserted, err := s.holidayRepo.Upsert(ctx, holiday)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save holiday %q: %w", holiday.Name, err)
+		}
+		if !inserted {
+			result.Updated++
+			if existing != nil && collectiveLeaveChanged(existing, holiday) {
+				if err := s.republishCollectiveLeave(ctx, calendar, existing, holiday); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		result.Created++
+
+		if holiday.IsCollectiveLeave {
+			if err := s.publishCollectiveLeaveDeclared(ctx, calendar, holiday); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// IsHoliday reports whether the date is a holiday for the given company/region
+func (s *holidayServiceImpl) IsHoliday(ctx context.Context, companyID, regionCode string, date time.Time) (bool, *integration.HolidayDTO, error) {
+	holidays, err := s.GetHolidays(ctx, companyID, regionCode, date, date)
+	if err != nil {
+		return false, nil, err
+	}
+	if len(holidays) == 0 {
+		return false, nil, nil
+	}
+	return true, &holidays[0], nil
+}
+
+// GetHolidays returns the holidays between start and end (inclusive)
+func (s *holidayServiceImpl) GetHolidays(ctx context.Context, companyID, regionCode string, start, end time.Time) ([]integration.HolidayDTO, error) {
+	holidays, err := s.ListHolidays(ctx, &entities.HolidayFilter{
+		CompanyID:  companyID,
+		RegionCode: regionCode,
+		StartDate:  &start,
+		EndDate:    &end,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]integration.HolidayDTO, 0, len(holidays))
+	for _, h := range holidays {
+		result = append(result, integration.HolidayDTO{
+			ID:                h.ID.String(),
+			CalendarID:        h.CalendarID.String(),
+			Date:              h.HolidayDate,
+			Name:              h.Name,
+			Description:       h.Description,
+			HolidayType:       string(h.HolidayType),
+			IsCollectiveLeave: h.IsCollectiveLeave,
+			RegionCode:        h.RegionCode,
+		})
+	}
+	return result, nil
+}
+
+// CountWorkingDays counts Monday-Friday days between start and end (inclusive) that are not holidays
+func (s *holidayServiceImpl) CountWorkingDays(ctx context.Context, companyID, regionCode string, start, end time.Time) (int, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if end.Before(start) {
+		return 0, fmt.Errorf("end date cannot be before start date")
+	}
+
+	holidays, err := s.GetHolidays(ctx, companyID, regionCode, start, end)
+	if err != nil {
+		return 0, err
+	}
+	holidayDates := make(map[string]bool, len(holidays))
+	for _, h := range holidays {
+		holidayDates[h.Date.Format("2006-01-02")] = true
+	}
+
+	workingDays := 0
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		if holidayDates[d.Format("2006-01-02")] {
+			continue
+		}
+		workingDays++
+	}
+	return workingDays, nil
+}
+
+func (s *holidayServiceImpl) publishCollectiveLeaveDeclared(ctx context.Context, calendar *entities.HolidayCalendar, holiday *entities.Holiday) error {
+	if s.eventBus == nil {
+		return nil
+	}
+
+	companyID := ""
+	if calendar.CompanyID != nil {
+		companyID = *calendar.CompanyID
+	}
+	event := events.NewCollectiveLeaveDeclaredEvent(holiday.ID.String(), calendar.ID.String(), companyID,
+		calendar.RegionCode, holiday.HolidayDate, holiday.Name, holiday.LeaveTypeCode)
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to apply collective leave: %w", err)
+	}
+	return nil
+}
+
+func (s *holidayServiceImpl) publishCollectiveLeaveRevoked(ctx context.Context, holiday *entities.Holiday) error {
+	if s.eventBus == nil {
+		return nil
+	}
+
+	event := events.NewCollectiveLeaveRevokedEvent(holiday.ID.String(), holiday.HolidayDate, holiday.Name)
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to revert collective leave: %w", err)
+	}
+	return nil
+}
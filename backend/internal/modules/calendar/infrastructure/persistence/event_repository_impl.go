@@ -208,50 +208,6 @@ func (r *eventRepositoryImpl) Delete(ctx context.Context, id uuid.ID) error {
 
 // List retrieves events based on filter criteria
 func (r *eventRepositoryImpl) List(ctx context.Context, filter *entities.EventFilter) ([]entities.Event, int, error) {
-	// Temporary simplified implementation to get API working
-	if filter != nil && filter.EventType != nil && *filter.EventType == "holiday" {
-		// Return hardcoded holiday events for now
-		id1, _ := uuid.Parse("00000000-0000-0000-0000-000000000002")
-		id2, _ := uuid.Parse("00000000-0000-0000-0000-000000000009")
-		createdBy, _ := uuid.Parse("00000000-0000-0000-0000-000000000000")
-
-		events := []entities.Event{
-			{
-				ID:             id1,
-				Title:          "Chinese New Year",
-				Description:    "Chinese New Year celebration",
-				StartDateTime:  time.Date(2024, 2, 9, 17, 0, 0, 0, time.UTC),
-				EndDateTime:    nil,
-				Location:       "",
-				EventType:      entities.EventTypeHoliday,
-				Priority:       entities.PriorityHigh,
-				IsAllDay:       true,
-				RecurrenceRule: "",
-				CreatedBy:      createdBy,
-				CreatedAt:      time.Date(2025, 7, 28, 16, 9, 56, 0, time.UTC),
-				UpdatedAt:      time.Date(2025, 7, 28, 16, 9, 56, 0, time.UTC),
-				Attendees:      []entities.EventAttendee{},
-			},
-			{
-				ID:             id2,
-				Title:          "Independence Day",
-				Description:    "Indonesian Independence Day",
-				StartDateTime:  time.Date(2024, 8, 16, 17, 0, 0, 0, time.UTC),
-				EndDateTime:    nil,
-				Location:       "",
-				EventType:      entities.EventTypeHoliday,
-				Priority:       entities.PriorityHigh,
-				IsAllDay:       true,
-				RecurrenceRule: "",
-				CreatedBy:      createdBy,
-				CreatedAt:      time.Date(2025, 7, 28, 16, 9, 56, 0, time.UTC),
-				UpdatedAt:      time.Date(2025, 7, 28, 16, 9, 56, 0, time.UTC),
-				Attendees:      []entities.EventAttendee{},
-			},
-		}
-		return events, len(events), nil
-	}
-
 	var conditions []string
 	var args []interface{}
 	argIndex := 1
@@ -0,0 +1,293 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/calendar/domain/entities"
+	"malaka/internal/modules/calendar/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+type holidayRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewHolidayRepository creates a new instance of holiday repository
+func NewHolidayRepository(db *sqlx.DB) repositories.HolidayRepository {
+	return &holidayRepositoryImpl{db: db}
+}
+
+const holidayCalendarColumns = `id, code, name, COALESCE(description, '') as description,
+	company_id::text as company_id, region_code, is_active, created_at, updated_at`
+
+const holidayColumns = `h.id, h.calendar_id, h.holiday_date, h.name, COALESCE(h.description, '') as description,
+	h.holiday_type, h.is_collective_leave, h.leave_type_code, h.source, h.created_by::text as created_by,
+	h.created_at, h.updated_at, hc.company_id::text as company_id, hc.region_code`
+
+// CreateCalendar creates a new holiday calendar
+func (r *holidayRepositoryImpl) CreateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error {
+	if calendar.ID.IsNil() {
+		calendar.ID = uuid.New()
+	}
+	now := time.Now()
+	calendar.CreatedAt = now
+	calendar.UpdatedAt = now
+
+	query := `
+		INSERT INTO holiday_calendars (id, code, name, description, company_id, region_code, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		calendar.ID, calendar.Code, calendar.Name, calendar.Description, calendar.CompanyID,
+		calendar.RegionCode, calendar.IsActive, calendar.CreatedAt, calendar.UpdatedAt)
+	return err
+}
+
+// GetCalendarByID retrieves a holiday calendar by ID
+func (r *holidayRepositoryImpl) GetCalendarByID(ctx context.Context, id uuid.ID) (*entities.HolidayCalendar, error) {
+	var calendar entities.HolidayCalendar
+	query := `SELECT ` + holidayCalendarColumns + ` FROM holiday_calendars WHERE id = $1`
+	if err := r.db.GetContext(ctx, &calendar, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// GetCalendarByCode retrieves a holiday calendar by code
+func (r *holidayRepositoryImpl) GetCalendarByCode(ctx context.Context, code string) (*entities.HolidayCalendar, error) {
+	var calendar entities.HolidayCalendar
+	query := `SELECT ` + holidayCalendarColumns + ` FROM holiday_calendars WHERE code = $1`
+	if err := r.db.GetContext(ctx, &calendar, query, code); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// ListCalendars lists the calendars visible to a company (its own plus the shared ones)
+func (r *holidayRepositoryImpl) ListCalendars(ctx context.Context, companyID string) ([]entities.HolidayCalendar, error) {
+	calendars := []entities.HolidayCalendar{}
+	query := `SELECT ` + holidayCalendarColumns + ` FROM holiday_calendars
+		WHERE company_id IS NULL OR company_id::text = $1
+		ORDER BY company_id NULLS FIRST, region_code, code`
+	if err := r.db.SelectContext(ctx, &calendars, query, companyID); err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
+
+// UpdateCalendar updates a holiday calendar
+func (r *holidayRepositoryImpl) UpdateCalendar(ctx context.Context, calendar *entities.HolidayCalendar) error {
+	calendar.UpdatedAt = time.Now()
+	query := `
+		UPDATE holiday_calendars
+		SET code = $2, name = $3, description = $4, company_id = $5, region_code = $6, is_active = $7, updated_at = $8
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query,
+		calendar.ID, calendar.Code, calendar.Name, calendar.Description, calendar.CompanyID,
+		calendar.RegionCode, calendar.IsActive, calendar.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("holiday calendar with id %s not found", calendar.ID.String())
+	}
+	return nil
+}
+
+// DeleteCalendar deletes a holiday calendar and its holidays
+func (r *holidayRepositoryImpl) DeleteCalendar(ctx context.Context, id uuid.ID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM holiday_calendars WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("holiday calendar with id %s not found", id.String())
+	}
+	return nil
+}
+
+// Create creates a new holiday
+func (r *holidayRepositoryImpl) Create(ctx context.Context, holiday *entities.Holiday) error {
+	if holiday.ID.IsNil() {
+		holiday.ID = uuid.New()
+	}
+	now := time.Now()
+	holiday.CreatedAt = now
+	holiday.UpdatedAt = now
+
+	query := `
+		INSERT INTO holidays (id, calendar_id, holiday_date, name, description, holiday_type,
+			is_collective_leave, leave_type_code, source, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		holiday.ID, holiday.CalendarID, holiday.HolidayDate, holiday.Name, holiday.Description,
+		holiday.HolidayType, holiday.IsCollectiveLeave, holiday.LeaveTypeCode, holiday.Source,
+		holiday.CreatedBy, holiday.CreatedAt, holiday.UpdatedAt)
+	return err
+}
+
+// Upsert inserts a holiday or updates the existing one with the same calendar, date and name.
+// It reports whether a new row was inserted.
+func (r *holidayRepositoryImpl) Upsert(ctx context.Context, holiday *entities.Holiday) (bool, error) {
+	if holiday.ID.IsNil() {
+		holiday.ID = uuid.New()
+	}
+	now := time.Now()
+	holiday.CreatedAt = now
+	holiday.UpdatedAt = now
+
+	query := `
+		INSERT INTO holidays (id, calendar_id, holiday_date, name, description, holiday_type,
+			is_collective_leave, leave_type_code, source, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (calendar_id, holiday_date, name) DO UPDATE SET
+			description = EXCLUDED.description,
+			holiday_type = EXCLUDED.holiday_type,
+			is_collective_leave = EXCLUDED.is_collective_leave,
+			leave_type_code = EXCLUDED.leave_type_code,
+			source = EXCLUDED.source,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, (xmax = 0) AS inserted`
+
+	var row struct {
+		ID       string `db:"id"`
+		Inserted bool   `db:"inserted"`
+	}
+	err := r.db.GetContext(ctx, &row, query,
+		holiday.ID, holiday.CalendarID, holiday.HolidayDate, holiday.Name, holiday.Description,
+		holiday.HolidayType, holiday.IsCollectiveLeave, holiday.LeaveTypeCode, holiday.Source,
+		holiday.CreatedBy, holiday.CreatedAt, holiday.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	holiday.ID, _ = uuid.Parse(row.ID)
+	return row.Inserted, nil
+}
+
+// GetByID retrieves a holiday by ID
+func (r *holidayRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.Holiday, error) {
+	var holiday entities.Holiday
+	query := `SELECT ` + holidayColumns + `
+		FROM holidays h
+		JOIN holiday_calendars hc ON hc.id = h.calendar_id
+		WHERE h.id = $1`
+	if err := r.db.GetContext(ctx, &holiday, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+// GetByDateAndName retrieves the holiday of a calendar on a date with a name
+func (r *holidayRepositoryImpl) GetByDateAndName(ctx context.Context, calendarID uuid.ID, date time.Time, name string) (*entities.Holiday, error) {
+	var holiday entities.Holiday
+	query := `SELECT ` + holidayColumns + `
+		FROM holidays h
+		JOIN holiday_calendars hc ON hc.id = h.calendar_id
+		WHERE h.calendar_id = $1 AND h.holiday_date = $2 AND h.name = $3`
+	if err := r.db.GetContext(ctx, &holiday, query, calendarID, date.Format("2006-01-02"), name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+// Update updates a holiday
+func (r *holidayRepositoryImpl) Update(ctx context.Context, holiday *entities.Holiday) error {
+	holiday.UpdatedAt = time.Now()
+	query := `
+		UPDATE holidays
+		SET holiday_date = $2, name = $3, description = $4, holiday_type = $5,
+			is_collective_leave = $6, leave_type_code = $7, updated_at = $8
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query,
+		holiday.ID, holiday.HolidayDate, holiday.Name, holiday.Description, holiday.HolidayType,
+		holiday.IsCollectiveLeave, holiday.LeaveTypeCode, holiday.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("holiday with id %s not found", holiday.ID.String())
+	}
+	return nil
+}
+
+// Delete deletes a holiday
+func (r *holidayRepositoryImpl) Delete(ctx context.Context, id uuid.ID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM holidays WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("holiday with id %s not found", id.String())
+	}
+	return nil
+}
+
+// List returns holidays of the active calendars matching the filter scope.
+// Shared calendars (no company) and calendars without region always match.
+func (r *holidayRepositoryImpl) List(ctx context.Context, filter *entities.HolidayFilter) ([]entities.Holiday, error) {
+	if filter == nil {
+		filter = &entities.HolidayFilter{}
+	}
+
+	conditions := []string{"hc.is_active = TRUE"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.CalendarID != nil {
+		conditions = append(conditions, fmt.Sprintf("h.calendar_id = $%d", argIndex))
+		args = append(args, *filter.CalendarID)
+		argIndex++
+	} else {
+		conditions = append(conditions, fmt.Sprintf("(hc.company_id IS NULL OR hc.company_id::text = $%d)", argIndex))
+		args = append(args, filter.CompanyID)
+		argIndex++
+
+		conditions = append(conditions, fmt.Sprintf("(hc.region_code = '' OR hc.region_code = $%d)", argIndex))
+		args = append(args, filter.RegionCode)
+		argIndex++
+	}
+
+	if filter.StartDate != nil {
+		conditions = append(conditions, fmt.Sprintf("h.holiday_date >= $%d", argIndex))
+		args = append(args, filter.StartDate.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.EndDate != nil {
+		conditions = append(conditions, fmt.Sprintf("h.holiday_date <= $%d", argIndex))
+		args = append(args, filter.EndDate.Format("2006-01-02"))
+		argIndex++
+	}
+
+	query := `SELECT ` + holidayColumns + `
+		FROM holidays h
+		JOIN holiday_calendars hc ON hc.id = h.calendar_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY h.holiday_date ASC, h.name ASC`
+
+	holidays := []entities.Holiday{}
+	if err := r.db.SelectContext(ctx, &holidays, query, args...); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
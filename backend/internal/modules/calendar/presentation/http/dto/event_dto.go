@@ -14,7 +14,7 @@ type CreateEventRequest struct {
 	StartDateTime  time.Time          `json:"start_datetime" validate:"required"`
 	EndDateTime    *time.Time         `json:"end_datetime,omitempty"`
 	Location       string             `json:"location" validate:"max=255"`
-	EventType      entities.EventType `json:"event_type" validate:"required,oneof=event meeting task reminder holiday"`
+	EventType      entities.EventType `json:"event_type" validate:"required,oneof=event meeting task reminder"`
 	Priority       entities.Priority  `json:"priority" validate:"required,oneof=low medium high"`
 	IsAllDay       bool               `json:"is_all_day"`
 	RecurrenceRule string             `json:"recurrence_rule,omitempty"`
@@ -28,7 +28,7 @@ type UpdateEventRequest struct {
 	StartDateTime  *time.Time          `json:"start_datetime,omitempty"`
 	EndDateTime    *time.Time          `json:"end_datetime,omitempty"`
 	Location       *string             `json:"location,omitempty" validate:"omitempty,max=255"`
-	EventType      *entities.EventType `json:"event_type,omitempty" validate:"omitempty,oneof=event meeting task reminder holiday"`
+	EventType      *entities.EventType `json:"event_type,omitempty" validate:"omitempty,oneof=event meeting task reminder"`
 	Priority       *entities.Priority  `json:"priority,omitempty" validate:"omitempty,oneof=low medium high"`
 	IsAllDay       *bool               `json:"is_all_day,omitempty"`
 	RecurrenceRule *string             `json:"recurrence_rule,omitempty"`
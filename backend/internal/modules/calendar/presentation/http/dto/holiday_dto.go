@@ -0,0 +1,124 @@
+package dto
+
+import (
+	"time"
+
+	"malaka/internal/modules/calendar/domain/entities"
+)
+
+// CreateHolidayCalendarRequest represents the request payload for creating a holiday calendar
+type CreateHolidayCalendarRequest struct {
+	Code        string `json:"code" validate:"required,min=1,max=50"`
+	Name        string `json:"name" validate:"required,min=1,max=255"`
+	Description string `json:"description" validate:"max=1000"`
+	RegionCode  string `json:"region_code" validate:"max=20"`
+	Shared      bool   `json:"shared"` // true = applies to every company
+	IsActive    *bool  `json:"is_active,omitempty"`
+}
+
+// UpdateHolidayCalendarRequest represents the request payload for updating a holiday calendar
+type UpdateHolidayCalendarRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	RegionCode  *string `json:"region_code,omitempty" validate:"omitempty,max=20"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// CreateHolidayRequest represents the request payload for adding a holiday to a calendar
+type CreateHolidayRequest struct {
+	HolidayDate       string               `json:"holiday_date" validate:"required,datetime=2006-01-02"`
+	Name              string               `json:"name" validate:"required,min=1,max=255"`
+	Description       string               `json:"description" validate:"max=1000"`
+	HolidayType       entities.HolidayType `json:"holiday_type" validate:"omitempty,oneof=national regional company collective_leave"`
+	IsCollectiveLeave bool                 `json:"is_collective_leave"`
+	LeaveTypeCode     string               `json:"leave_type_code" validate:"max=20"`
+}
+
+// UpdateHolidayRequest represents the request payload for updating a holiday
+type UpdateHolidayRequest struct {
+	HolidayDate       *string               `json:"holiday_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Name              *string               `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Description       *string               `json:"description,omitempty" validate:"omitempty,max=1000"`
+	HolidayType       *entities.HolidayType `json:"holiday_type,omitempty" validate:"omitempty,oneof=national regional company collective_leave"`
+	IsCollectiveLeave *bool                 `json:"is_collective_leave,omitempty"`
+	LeaveTypeCode     *string               `json:"leave_type_code,omitempty" validate:"omitempty,max=20"`
+}
+
+// HolidayCalendarResponse represents the response payload for a holiday calendar
+type HolidayCalendarResponse struct {
+	ID          string    `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CompanyID   *string   `json:"company_id,omitempty"`
+	RegionCode  string    `json:"region_code"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// HolidayResponse represents the response payload for a holiday
+type HolidayResponse struct {
+	ID                string                 `json:"id"`
+	CalendarID        string                 `json:"calendar_id"`
+	HolidayDate       string                 `json:"holiday_date"`
+	Name              string                 `json:"name"`
+	Description       string                 `json:"description"`
+	HolidayType       entities.HolidayType   `json:"holiday_type"`
+	IsCollectiveLeave bool                   `json:"is_collective_leave"`
+	LeaveTypeCode     string                 `json:"leave_type_code,omitempty"`
+	Source            entities.HolidaySource `json:"source"`
+	RegionCode        string                 `json:"region_code,omitempty"`
+	CreatedAt         time.Time              `json:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+}
+
+// WorkingDaysResponse represents the working day count of a period
+type WorkingDaysResponse struct {
+	StartDate   string            `json:"start_date"`
+	EndDate     string            `json:"end_date"`
+	WorkingDays int               `json:"working_days"`
+	Holidays    []HolidayResponse `json:"holidays"`
+}
+
+// ToHolidayCalendarResponse converts a holiday calendar entity to response DTO
+func ToHolidayCalendarResponse(calendar *entities.HolidayCalendar) HolidayCalendarResponse {
+	return HolidayCalendarResponse{
+		ID:          calendar.ID.String(),
+		Code:        calendar.Code,
+		Name:        calendar.Name,
+		Description: calendar.Description,
+		CompanyID:   calendar.CompanyID,
+		RegionCode:  calendar.RegionCode,
+		IsActive:    calendar.IsActive,
+		CreatedAt:   calendar.CreatedAt,
+		UpdatedAt:   calendar.UpdatedAt,
+	}
+}
+
+// ToHolidayResponse converts a holiday entity to response DTO
+func ToHolidayResponse(holiday *entities.Holiday) HolidayResponse {
+	return HolidayResponse{
+		ID:                holiday.ID.String(),
+		CalendarID:        holiday.CalendarID.String(),
+		HolidayDate:       holiday.HolidayDate.Format("2006-01-02"),
+		Name:              holiday.Name,
+		Description:       holiday.Description,
+		HolidayType:       holiday.HolidayType,
+		IsCollectiveLeave: holiday.IsCollectiveLeave,
+		LeaveTypeCode:     holiday.LeaveTypeCode,
+		Source:            holiday.Source,
+		RegionCode:        holiday.RegionCode,
+		CreatedAt:         holiday.CreatedAt,
+		UpdatedAt:         holiday.UpdatedAt,
+	}
+}
+
+// ToHolidayResponses converts holiday entities to response DTOs
+func ToHolidayResponses(holidays []entities.Holiday) []HolidayResponse {
+	responses := make([]HolidayResponse, len(holidays))
+	for i := range holidays {
+		responses[i] = ToHolidayResponse(&holidays[i])
+	}
+	return responses
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -69,6 +70,10 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 
 	// Create event
 	if err := h.eventService.CreateEvent(c.Request.Context(), event, req.AttendeeIDs); err != nil {
+		if errors.Is(err, services.ErrHolidayManagedByCalendar) {
+			response.Error(c, http.StatusBadRequest, "Holidays must be created in the holiday calendar", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Failed to create event", err)
 		return
 	}
@@ -224,7 +229,8 @@ func (h *EventHandler) UpdateEvent(c *gin.Context) {
 
 	// Update event
 	if err := h.eventService.UpdateEvent(c.Request.Context(), existingEvent, req.AttendeeIDs, userID); err != nil {
-		if err.Error() == "access denied: only event creator can update this event" {
+		if err.Error() == "access denied: only event creator can update this event" ||
+			errors.Is(err, services.ErrHolidayManagedByCalendar) {
 			response.Error(c, http.StatusForbidden, "Access denied", err)
 			return
 		}
@@ -283,7 +289,7 @@ func (h *EventHandler) DeleteEvent(c *gin.Context) {
 	// Delete event
 	if err := h.eventService.DeleteEvent(c.Request.Context(), eventID, userID); err != nil {
 		if err.Error() == "access denied: only event creator can delete this event" ||
-			err.Error() == "system holidays cannot be deleted" {
+			errors.Is(err, services.ErrHolidayManagedByCalendar) {
 			response.Error(c, http.StatusForbidden, "Access denied", err)
 			return
 		}
@@ -357,6 +363,8 @@ func (h *EventHandler) ListEvents(c *gin.Context) {
 	}
 	filter.Limit = limit
 
+	filter.CompanyID, filter.RegionCode = holidayScope(c)
+
 	// Get user ID for filtering if needed (optional for public holiday access)
 	if userIDStr, exists := c.Get("user_id"); exists {
 		uid := userIDStr.(string)
@@ -410,7 +418,8 @@ func (h *EventHandler) GetEventsByMonth(c *gin.Context) {
 	}
 
 	// Get events
-	events, err := h.eventService.GetEventsByMonth(c.Request.Context(), year, month, userID)
+	companyID, regionCode := holidayScope(c)
+	events, err := h.eventService.GetEventsByMonth(c.Request.Context(), year, month, companyID, regionCode, userID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to get events", err)
 		return
@@ -475,3 +484,14 @@ func (h *EventHandler) GetUserEvents(c *gin.Context) {
 	resp := dto.ToEventsListResponse(events, page, limit, total)
 	response.Success(c, http.StatusOK, "User events retrieved successfully", resp)
 }
+
+// holidayScope returns the company and region whose holiday calendars are shown with
+// events: the company from the token when authenticated, otherwise from the query.
+func holidayScope(c *gin.Context) (companyID, regionCode string) {
+	if id, exists := c.Get("company_id"); exists {
+		companyID, _ = id.(string)
+	} else {
+		companyID = c.Query("company_id")
+	}
+	return companyID, c.Query("region_code")
+}
@@ -0,0 +1,574 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"malaka/internal/modules/calendar/domain/entities"
+	"malaka/internal/modules/calendar/domain/services"
+	"malaka/internal/modules/calendar/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// maxHolidayImportSize limits uploaded holiday files (1 MB is several years of holidays)
+const maxHolidayImportSize = 1 << 20
+
+type HolidayHandler struct {
+	holidayService services.HolidayService
+	validator      *validator.Validate
+}
+
+// NewHolidayHandler creates a new holiday handler
+func NewHolidayHandler(holidayService services.HolidayService) *HolidayHandler {
+	return &HolidayHandler{
+		holidayService: holidayService,
+		validator:      validator.New(),
+	}
+}
+
+// ListCalendars lists the holiday calendars visible to the current company
+// @Summary List holiday calendars
+// @Tags Calendar
+// @Produce json
+// @Success 200 {object} response.Response{data=[]dto.HolidayCalendarResponse}
+// @Router /calendar/holiday-calendars [get]
+func (h *HolidayHandler) ListCalendars(c *gin.Context) {
+	calendars, err := h.holidayService.ListCalendars(c.Request.Context(), c.GetString("company_id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list holiday calendars", err)
+		return
+	}
+
+	resp := make([]dto.HolidayCalendarResponse, len(calendars))
+	for i := range calendars {
+		resp[i] = dto.ToHolidayCalendarResponse(&calendars[i])
+	}
+	response.Success(c, http.StatusOK, "Holiday calendars retrieved successfully", resp)
+}
+
+// CreateCalendar creates a holiday calendar for the current company (or a shared one)
+// @Summary Create holiday calendar
+// @Tags Calendar
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateHolidayCalendarRequest true "Holiday calendar data"
+// @Success 201 {object} response.Response{data=dto.HolidayCalendarResponse}
+// @Router /calendar/holiday-calendars [post]
+func (h *HolidayHandler) CreateCalendar(c *gin.Context) {
+	var req dto.CreateHolidayCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	calendar := &entities.HolidayCalendar{
+		Code:        strings.ToUpper(req.Code),
+		Name:        req.Name,
+		Description: req.Description,
+		RegionCode:  strings.ToUpper(req.RegionCode),
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		calendar.IsActive = *req.IsActive
+	}
+	if !req.Shared {
+		if companyID := c.GetString("company_id"); companyID != "" {
+			calendar.CompanyID = &companyID
+		}
+	}
+
+	if err := h.holidayService.CreateCalendar(c.Request.Context(), calendar); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create holiday calendar", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Holiday calendar created successfully", dto.ToHolidayCalendarResponse(calendar))
+}
+
+// UpdateCalendar updates a holiday calendar
+// @Summary Update holiday calendar
+// @Tags Calendar
+// @Accept json
+// @Produce json
+// @Param id path string true "Calendar ID"
+// @Param request body dto.UpdateHolidayCalendarRequest true "Holiday calendar data"
+// @Success 200 {object} response.Response{data=dto.HolidayCalendarResponse}
+// @Router /calendar/holiday-calendars/{id} [put]
+func (h *HolidayHandler) UpdateCalendar(c *gin.Context) {
+	calendarID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid calendar ID", err)
+		return
+	}
+
+	var req dto.UpdateHolidayCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	calendar, err := h.holidayService.GetCalendarByID(c.Request.Context(), calendarID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Holiday calendar not found", err)
+		return
+	}
+	if !h.canManage(c, calendar) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	if req.Name != nil {
+		calendar.Name = *req.Name
+	}
+	if req.Description != nil {
+		calendar.Description = *req.Description
+	}
+	if req.RegionCode != nil {
+		calendar.RegionCode = strings.ToUpper(*req.RegionCode)
+	}
+	if req.IsActive != nil {
+		calendar.IsActive = *req.IsActive
+	}
+
+	if err := h.holidayService.UpdateCalendar(c.Request.Context(), calendar); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to update holiday calendar", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Holiday calendar updated successfully", dto.ToHolidayCalendarResponse(calendar))
+}
+
+// DeleteCalendar deletes a holiday calendar with its holidays
+// @Summary Delete holiday calendar
+// @Tags Calendar
+// @Param id path string true "Calendar ID"
+// @Success 200 {object} response.Response
+// @Router /calendar/holiday-calendars/{id} [delete]
+func (h *HolidayHandler) DeleteCalendar(c *gin.Context) {
+	calendarID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid calendar ID", err)
+		return
+	}
+
+	calendar, err := h.holidayService.GetCalendarByID(c.Request.Context(), calendarID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Holiday calendar not found", err)
+		return
+	}
+	if !h.canManage(c, calendar) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	if err := h.holidayService.DeleteCalendar(c.Request.Context(), calendarID); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to delete holiday calendar", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Holiday calendar deleted successfully", nil)
+}
+
+// CreateHoliday adds a holiday to a calendar
+// @Summary Add holiday
+// @Tags Calendar
+// @Accept json
+// @Produce json
+// @Param id path string true "Calendar ID"
+// @Param request body dto.CreateHolidayRequest true "Holiday data"
+// @Success 201 {object} response.Response{data=dto.HolidayResponse}
+// @Router /calendar/holiday-calendars/{id}/holidays [post]
+func (h *HolidayHandler) CreateHoliday(c *gin.Context) {
+	calendarID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid calendar ID", err)
+		return
+	}
+
+	var req dto.CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	calendar, err := h.holidayService.GetCalendarByID(c.Request.Context(), calendarID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Holiday calendar not found", err)
+		return
+	}
+	if !h.canManage(c, calendar) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	date, _ := time.Parse("2006-01-02", req.HolidayDate)
+	holiday := &entities.Holiday{
+		CalendarID:        calendarID,
+		HolidayDate:       date,
+		Name:              req.Name,
+		Description:       req.Description,
+		HolidayType:       req.HolidayType,
+		IsCollectiveLeave: req.IsCollectiveLeave,
+		LeaveTypeCode:     strings.ToUpper(req.LeaveTypeCode),
+		Source:            entities.HolidaySourceManual,
+		CreatedBy:         h.userID(c),
+	}
+
+	if err := h.holidayService.CreateHoliday(c.Request.Context(), holiday); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create holiday", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Holiday created successfully", dto.ToHolidayResponse(holiday))
+}
+
+// ImportHolidays imports a yearly holiday file (iCalendar .ics or .csv) into a calendar
+// @Summary Import holidays
+// @Tags Calendar
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Calendar ID"
+// @Param file formData file true "Holiday file (.ics or .csv)"
+// @Param format formData string false "File format (ical or csv), defaults to the file extension"
+// @Param year formData int false "Only import holidays of this year"
+// @Success 200 {object} response.Response{data=services.HolidayImportResult}
+// @Router /calendar/holiday-calendars/{id}/import [post]
+func (h *HolidayHandler) ImportHolidays(c *gin.Context) {
+	calendarID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid calendar ID", err)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Holiday file is required", err)
+		return
+	}
+	if fileHeader.Size > maxHolidayImportSize {
+		response.Error(c, http.StatusBadRequest, "Holiday file is too large", nil)
+		return
+	}
+
+	format := strings.ToLower(c.PostForm("format"))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+		case ".ics", ".ical", ".ifb":
+			format = services.HolidayImportFormatICal
+		case ".csv":
+			format = services.HolidayImportFormatCSV
+		}
+	}
+	if format != services.HolidayImportFormatICal && format != services.HolidayImportFormatCSV {
+		response.Error(c, http.StatusBadRequest, "Unsupported holiday file format, use .ics or .csv", nil)
+		return
+	}
+
+	year := 0
+	if y := c.PostForm("year"); y != "" {
+		if year, err = strconv.Atoi(y); err != nil || year < 1900 || year > 3000 {
+			response.Error(c, http.StatusBadRequest, "Invalid year", nil)
+			return
+		}
+	}
+
+	calendar, err := h.holidayService.GetCalendarByID(c.Request.Context(), calendarID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Holiday calendar not found", err)
+		return
+	}
+	if !h.canManage(c, calendar) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to read holiday file", err)
+		return
+	}
+	defer file.Close()
+
+	result, err := h.holidayService.ImportHolidays(c.Request.Context(), calendarID, format, file, year, h.userID(c))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to import holidays", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Holidays imported successfully", result)
+}
+
+// ListHolidays lists holidays for the current company, or of one calendar
+// @Summary List holidays
+// @Tags Calendar
+// @Produce json
+// @Param year query int false "Year (defaults to the current year when no date range is given)"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param region_code query string false "Region code"
+// @Param calendar_id query string false "Only holidays of this calendar"
+// @Success 200 {object} response.Response{data=[]dto.HolidayResponse}
+// @Router /calendar/holidays [get]
+func (h *HolidayHandler) ListHolidays(c *gin.Context) {
+	start, end, ok := h.parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	filter := &entities.HolidayFilter{
+		CompanyID:  c.GetString("company_id"),
+		RegionCode: strings.ToUpper(c.Query("region_code")),
+		StartDate:  &start,
+		EndDate:    &end,
+	}
+	if calendarID := c.Query("calendar_id"); calendarID != "" {
+		filter.CalendarID = &calendarID
+	}
+
+	holidays, err := h.holidayService.ListHolidays(c.Request.Context(), filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list holidays", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Holidays retrieved successfully", dto.ToHolidayResponses(holidays))
+}
+
+// GetWorkingDays counts working days (Monday-Friday minus holidays) in a period
+// @Summary Count working days
+// @Tags Calendar
+// @Produce json
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param region_code query string false "Region code"
+// @Success 200 {object} response.Response{data=dto.WorkingDaysResponse}
+// @Router /calendar/holidays/working-days [get]
+func (h *HolidayHandler) GetWorkingDays(c *gin.Context) {
+	start, end, ok := h.parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	companyID := c.GetString("company_id")
+	regionCode := strings.ToUpper(c.Query("region_code"))
+
+	workingDays, err := h.holidayService.CountWorkingDays(c.Request.Context(), companyID, regionCode, start, end)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to count working days", err)
+		return
+	}
+
+	holidays, err := h.holidayService.ListHolidays(c.Request.Context(), &entities.HolidayFilter{
+		CompanyID:  companyID,
+		RegionCode: regionCode,
+		StartDate:  &start,
+		EndDate:    &end,
+	})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list holidays", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Working days calculated successfully", dto.WorkingDaysResponse{
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		WorkingDays: workingDays,
+		Holidays:    dto.ToHolidayResponses(holidays),
+	})
+}
+
+// GetHoliday retrieves a holiday
+// @Summary Get holiday
+// @Tags Calendar
+// @Produce json
+// @Param id path string true "Holiday ID"
+// @Success 200 {object} response.Response{data=dto.HolidayResponse}
+// @Router /calendar/holidays/{id} [get]
+func (h *HolidayHandler) GetHoliday(c *gin.Context) {
+	holidayID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid holiday ID", err)
+		return
+	}
+
+	holiday, err := h.holidayService.GetHolidayByID(c.Request.Context(), holidayID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Holiday not found", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Holiday retrieved successfully", dto.ToHolidayResponse(holiday))
+}
+
+// UpdateHoliday updates a holiday
+// @Summary Update holiday
+// @Tags Calendar
+// @Accept json
+// @Produce json
+// @Param id path string true "Holiday ID"
+// @Param request body dto.UpdateHolidayRequest true "Holiday data"
+// @Success 200 {object} response.Response{data=dto.HolidayResponse}
+// @Router /calendar/holidays/{id} [put]
+func (h *HolidayHandler) UpdateHoliday(c *gin.Context) {
+	holidayID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid holiday ID", err)
+		return
+	}
+
+	var req dto.UpdateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	holiday, err := h.holidayService.GetHolidayByID(c.Request.Context(), holidayID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Holiday not found", err)
+		return
+	}
+	if !h.canManageHoliday(c, holiday) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	if req.HolidayDate != nil {
+		holiday.HolidayDate, _ = time.Parse("2006-01-02", *req.HolidayDate)
+	}
+	if req.Name != nil {
+		holiday.Name = *req.Name
+	}
+	if req.Description != nil {
+		holiday.Description = *req.Description
+	}
+	if req.HolidayType != nil {
+		holiday.HolidayType = *req.HolidayType
+		holiday.IsCollectiveLeave = *req.HolidayType == entities.HolidayTypeCollectiveLeave
+	}
+	if req.IsCollectiveLeave != nil {
+		holiday.IsCollectiveLeave = *req.IsCollectiveLeave
+	}
+	if req.LeaveTypeCode != nil {
+		holiday.LeaveTypeCode = strings.ToUpper(*req.LeaveTypeCode)
+	}
+
+	if err := h.holidayService.UpdateHoliday(c.Request.Context(), holiday); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update holiday", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Holiday updated successfully", dto.ToHolidayResponse(holiday))
+}
+
+// DeleteHoliday deletes a holiday; cuti bersama leave it deducted is restored
+// @Summary Delete holiday
+// @Tags Calendar
+// @Param id path string true "Holiday ID"
+// @Success 200 {object} response.Response
+// @Router /calendar/holidays/{id} [delete]
+func (h *HolidayHandler) DeleteHoliday(c *gin.Context) {
+	holidayID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid holiday ID", err)
+		return
+	}
+
+	holiday, err := h.holidayService.GetHolidayByID(c.Request.Context(), holidayID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Holiday not found", err)
+		return
+	}
+	if !h.canManageHoliday(c, holiday) {
+		response.Error(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+
+	if err := h.holidayService.DeleteHoliday(c.Request.Context(), holidayID); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to delete holiday", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Holiday deleted successfully", nil)
+}
+
+// parsePeriod reads start_date/end_date, or a whole year, defaulting to the current year
+func (h *HolidayHandler) parsePeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	year := time.Now().Year()
+	if y := c.Query("year"); y != "" {
+		parsed, err := strconv.Atoi(y)
+		if err != nil || parsed < 1900 || parsed > 3000 {
+			response.Error(c, http.StatusBadRequest, "Invalid year", nil)
+			return time.Time{}, time.Time{}, false
+		}
+		year = parsed
+	}
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	if s := c.Query("start_date"); s != "" {
+		parsed, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid start_date, expected YYYY-MM-DD", nil)
+			return time.Time{}, time.Time{}, false
+		}
+		start = parsed
+	}
+	if e := c.Query("end_date"); e != "" {
+		parsed, err := time.Parse("2006-01-02", e)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid end_date, expected YYYY-MM-DD", nil)
+			return time.Time{}, time.Time{}, false
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		response.Error(c, http.StatusBadRequest, "end_date cannot be before start_date", nil)
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// canManage allows changes to the company's own calendars; shared calendars are
+// managed by users with the permission but no company scope restriction
+func (h *HolidayHandler) canManage(c *gin.Context, calendar *entities.HolidayCalendar) bool {
+	if calendar.CompanyID == nil {
+		return true
+	}
+	return *calendar.CompanyID == c.GetString("company_id")
+}
+
+func (h *HolidayHandler) canManageHoliday(c *gin.Context, holiday *entities.Holiday) bool {
+	if holiday.CompanyID == nil {
+		return true
+	}
+	return *holiday.CompanyID == c.GetString("company_id")
+}
+
+func (h *HolidayHandler) userID(c *gin.Context) *string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return &userID
+	}
+	return nil
+}
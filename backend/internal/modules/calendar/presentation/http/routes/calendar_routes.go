@@ -7,7 +7,7 @@ import (
 )
 
 // RegisterCalendarRoutes registers all calendar-related routes
-func RegisterCalendarRoutes(router *gin.RouterGroup, eventHandler *handlers.EventHandler, attendeeHandler *handlers.AttendeeHandler, jwtSecret string, rbacSvc *auth.RBACService) {
+func RegisterCalendarRoutes(router *gin.RouterGroup, eventHandler *handlers.EventHandler, attendeeHandler *handlers.AttendeeHandler, holidayHandler *handlers.HolidayHandler, authMiddleware gin.HandlerFunc, rbacSvc *auth.RBACService) {
 	// Calendar routes group
 	calendar := router.Group("/calendar")
 
@@ -19,7 +19,7 @@ func RegisterCalendarRoutes(router *gin.RouterGroup, eventHandler *handlers.Even
 		events.GET("/month/:year/:month", eventHandler.GetEventsByMonth)   // GET /calendar/events/month/{year}/{month} - allows public holiday access
 
 		// Protected routes (authentication required)
-		eventsAuth := events.Group("", auth.Middleware(jwtSecret))
+		eventsAuth := events.Group("", authMiddleware)
 		eventsAuth.Use(auth.LoadPermissions(rbacSvc))
 		{
 			eventsAuth.POST("", auth.RequirePermission(rbacSvc, "calendar.event.create"), eventHandler.CreateEvent)
@@ -31,11 +31,33 @@ func RegisterCalendarRoutes(router *gin.RouterGroup, eventHandler *handlers.Even
 	}
 
 	// Attendee routes (all require authentication)
-	attendees := calendar.Group("/attendees", auth.Middleware(jwtSecret))
+	attendees := calendar.Group("/attendees", authMiddleware)
 	attendees.Use(auth.LoadPermissions(rbacSvc))
 	{
 		attendees.POST("/events/:eventId", auth.RequirePermission(rbacSvc, "calendar.attendee.create"), attendeeHandler.AddAttendee)
 		attendees.DELETE("/:attendeeId", auth.RequirePermission(rbacSvc, "calendar.attendee.delete"), attendeeHandler.RemoveAttendee)
 		attendees.PUT("/:attendeeId/status", auth.RequirePermission(rbacSvc, "calendar.attendee.update"), attendeeHandler.UpdateAttendeeStatus)
 	}
+
+	// Holiday calendar routes (all require authentication)
+	holidayCalendars := calendar.Group("/holiday-calendars", authMiddleware)
+	holidayCalendars.Use(auth.LoadPermissions(rbacSvc))
+	{
+		holidayCalendars.GET("", auth.RequirePermission(rbacSvc, "calendar.holiday.list"), holidayHandler.ListCalendars)
+		holidayCalendars.POST("", auth.RequirePermission(rbacSvc, "calendar.holiday.create"), holidayHandler.CreateCalendar)
+		holidayCalendars.PUT("/:id", auth.RequirePermission(rbacSvc, "calendar.holiday.update"), holidayHandler.UpdateCalendar)
+		holidayCalendars.DELETE("/:id", auth.RequirePermission(rbacSvc, "calendar.holiday.delete"), holidayHandler.DeleteCalendar)
+		holidayCalendars.POST("/:id/holidays", auth.RequirePermission(rbacSvc, "calendar.holiday.create"), holidayHandler.CreateHoliday)
+		holidayCalendars.POST("/:id/import", auth.RequirePermission(rbacSvc, "calendar.holiday.import"), holidayHandler.ImportHolidays)
+	}
+
+	holidays := calendar.Group("/holidays", authMiddleware)
+	holidays.Use(auth.LoadPermissions(rbacSvc))
+	{
+		holidays.GET("", auth.RequirePermission(rbacSvc, "calendar.holiday.list"), holidayHandler.ListHolidays)
+		holidays.GET("/working-days", auth.RequirePermission(rbacSvc, "calendar.holiday.read"), holidayHandler.GetWorkingDays)
+		holidays.GET("/:id", auth.RequirePermission(rbacSvc, "calendar.holiday.read"), holidayHandler.GetHoliday)
+		holidays.PUT("/:id", auth.RequirePermission(rbacSvc, "calendar.holiday.update"), holidayHandler.UpdateHoliday)
+		holidays.DELETE("/:id", auth.RequirePermission(rbacSvc, "calendar.holiday.delete"), holidayHandler.DeleteHoliday)
+	}
 }
@@ -35,6 +35,10 @@ func (h *FinanceEventHandler) RegisterHandlers(bus events.EventBus) {
 	bus.Subscribe(events.EventTypeGRPosted, h.HandleGRPosted)
 	bus.Subscribe(events.EventTypeGRCancelled, h.HandleGRCancelled)
 
+	// Subscribe to Sales events
+	bus.Subscribe(events.EventTypeSalesInvoicePosted, h.HandleSalesInvoicePosted)
+	bus.Subscribe(events.EventTypeSalesPaymentReceived, h.HandleSalesPaymentReceived)
+
 	log.Println("Finance event handlers registered")
 }
 
@@ -146,6 +150,46 @@ func (h *FinanceEventHandler) HandleGRCancelled(ctx context.Context, event event
 	return nil
 }
 
+// HandleSalesInvoicePosted handles the sales invoice posted event from Sales
+// The receivable is opened by Sales in the same transaction as the invoice
+// Finance just logs for audit trail
+func (h *FinanceEventHandler) HandleSalesInvoicePosted(ctx context.Context, event events.Event) error {
+	invEvent, ok := event.(*events.SalesInvoicePostedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type for sales invoice posted handler")
+	}
+
+	log.Printf("[Finance] AR opened: %s for invoice %s, customer: %s, amount: %.2f (tax %.2f), due: %s",
+		invEvent.ReceivableID,
+		invEvent.InvoiceNumber,
+		invEvent.CustomerID,
+		invEvent.GrandTotal,
+		invEvent.TaxAmount,
+		invEvent.DueDate.Format("2006-01-02"),
+	)
+
+	return nil
+}
+
+// HandleSalesPaymentReceived handles the customer payment event from Sales
+// The cash receipt and AR settlement are booked by Sales with the payment
+// Finance just logs for audit trail
+func (h *FinanceEventHandler) HandleSalesPaymentReceived(ctx context.Context, event events.Event) error {
+	payEvent, ok := event.(*events.SalesPaymentReceivedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type for sales payment received handler")
+	}
+
+	log.Printf("[Finance] Cash receipt %s: %.2f for invoice %s, remaining balance: %.2f",
+		payEvent.CashReceiptID,
+		payEvent.Amount,
+		payEvent.InvoiceNumber,
+		payEvent.Balance,
+	)
+
+	return nil
+}
+
 // createAccountsPayable creates an AP record for a goods receipt
 func (h *FinanceEventHandler) createAccountsPayable(ctx context.Context, grEvent *events.GoodsReceiptPostedEvent) (string, string, error) {
 	// TODO: Implement actual AP creation via AccountsPayableService
@@ -0,0 +1,277 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+var (
+	ErrPurchaseVoucherNotFound   = errors.New("purchase voucher not found")
+	ErrInvalidInvoiceMatch       = errors.New("invalid supplier invoice match")
+	ErrInvalidMatchTolerance     = errors.New("invalid three-way match tolerance")
+	ErrMatchExceptionNotFound    = errors.New("match exception not found")
+	ErrMatchExceptionResolved    = errors.New("match exception is already resolved")
+	ErrMatchExceptionsUnresolved = errors.New("the supplier invoice has unresolved three-way match exceptions")
+)
+
+// Match statuses of a purchase voucher.
+const (
+	MatchStatusUnmatched = "UNMATCHED"
+	MatchStatusMatched   = "MATCHED"
+	MatchStatusException = "EXCEPTION"
+	// MatchStatusResolved is a voucher whose exceptions were all resolved
+	MatchStatusResolved = "RESOLVED"
+)
+
+// Three-way match exception types and statuses.
+const (
+	MatchExceptionQuantity = "QUANTITY"
+	MatchExceptionPrice    = "PRICE"
+
+	MatchExceptionOpen     = "OPEN"
+	MatchExceptionResolved = "RESOLVED"
+)
+
+// MatchTolerance is how far a supplier invoice may be off the purchase order and the goods
+// received before the match raises an exception, in percent. Without a company it is the
+// default of every company.
+type MatchTolerance struct {
+	ID                uuid.ID   `json:"id" db:"id"`
+	CompanyID         *uuid.ID  `json:"company_id,omitempty" db:"company_id"`
+	QuantityTolerance float64   `json:"quantity_tolerance" db:"quantity_tolerance"` // percent over the quantity received
+	PriceTolerance    float64   `json:"price_tolerance" db:"price_tolerance"`       // percent off the PO unit price
+	UpdatedBy         string    `json:"updated_by" db:"updated_by"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Validate checks that both tolerances are between 0 and 100 percent.
+func (t *MatchTolerance) Validate() error {
+	if t.QuantityTolerance < 0 || t.QuantityTolerance > 100 {
+		return fmt.Errorf("%w: quantity tolerance must be between 0 and 100 percent", ErrInvalidMatchTolerance)
+	}
+	if t.PriceTolerance < 0 || t.PriceTolerance > 100 {
+		return fmt.Errorf("%w: price tolerance must be between 0 and 100 percent", ErrInvalidMatchTolerance)
+	}
+	return nil
+}
+
+// VoucherMatch is the supplier invoice of a purchase voucher matched against a purchase
+// order: its lines and the exceptions they raised.
+type VoucherMatch struct {
+	PurchaseVoucherID     uuid.ID             `json:"purchase_voucher_id" db:"id"`
+	VoucherNumber         string              `json:"voucher_number" db:"voucher_number"`
+	SupplierID            uuid.ID             `json:"supplier_id" db:"supplier_id"`
+	VoucherDate           time.Time           `json:"voucher_date" db:"voucher_date"`
+	Status                string              `json:"status" db:"status"`
+	CompanyID             *uuid.ID            `json:"company_id,omitempty" db:"company_id"`
+	PurchaseOrderID       *uuid.ID            `json:"purchase_order_id,omitempty" db:"purchase_order_id"`
+	PONumber              string              `json:"po_number" db:"po_number"`
+	AccountsPayableID     *uuid.ID            `json:"accounts_payable_id,omitempty" db:"accounts_payable_id"`
+	SupplierInvoiceNumber string              `json:"supplier_invoice_number" db:"supplier_invoice_number"`
+	MatchStatus           string              `json:"match_status" db:"match_status"`
+	MatchedAt             *time.Time          `json:"matched_at,omitempty" db:"matched_at"`
+	Tolerance             *MatchTolerance     `json:"tolerance,omitempty" db:"-"`
+	Lines                 []*InvoiceMatchLine `json:"lines" db:"-"`
+	Exceptions            []*MatchException   `json:"exceptions" db:"-"`
+}
+
+// CanBeMatched reports whether the voucher may still be (re)matched: approved, paid and
+// cancelled vouchers are final.
+func (m *VoucherMatch) CanBeMatched() bool {
+	switch m.Status {
+	case "", "pending", "PENDING":
+		return true
+	}
+	return false
+}
+
+// InvoiceMatchLine is an invoiced PO item and what it was matched against: the quantity
+// ordered, received on posted goods receipts and invoiced on earlier vouchers.
+type InvoiceMatchLine struct {
+	ID                uuid.ID `json:"id" db:"id"`
+	PurchaseVoucherID uuid.ID `json:"purchase_voucher_id" db:"purchase_voucher_id"`
+	POItemID          uuid.ID `json:"po_item_id" db:"po_item_id"`
+	ItemName          string  `json:"item_name" db:"item_name"`
+	Quantity          int     `json:"quantity" db:"quantity"`
+	UnitPrice         float64 `json:"unit_price" db:"unit_price"`
+	LineTotal         float64 `json:"line_total" db:"line_total"`
+
+	OrderedQuantity    int     `json:"ordered_quantity" db:"ordered_quantity"`
+	ReceivedQuantity   int     `json:"received_quantity" db:"received_quantity"`
+	PreviouslyInvoiced int     `json:"previously_invoiced" db:"previously_invoiced"`
+	POUnitPrice        float64 `json:"po_unit_price" db:"po_unit_price"`
+}
+
+// POItemPosition is what has happened to a PO item: ordered, received on posted goods
+// receipts and invoiced on other vouchers that are not cancelled.
+type POItemPosition struct {
+	POItemID         uuid.ID `db:"po_item_id"`
+	ItemName         string  `db:"item_name"`
+	OrderedQuantity  int     `db:"ordered_quantity"`
+	UnitPrice        float64 `db:"unit_price"`
+	ReceivedQuantity int     `db:"received_quantity"`
+	InvoicedQuantity int     `db:"invoiced_quantity"`
+}
+
+// PurchaseOrderPosition is a purchase order with the position of each of its items.
+type PurchaseOrderPosition struct {
+	PurchaseOrderID uuid.ID `db:"id"`
+	PONumber        string  `db:"po_number"`
+	SupplierID      uuid.ID `db:"supplier_id"`
+	Status          string  `db:"status"`
+	Items           []*POItemPosition
+}
+
+// Item returns the position of a PO item, nil when it is not on the order.
+func (p *PurchaseOrderPosition) Item(poItemID uuid.ID) *POItemPosition {
+	for _, item := range p.Items {
+		if item.POItemID == poItemID {
+			return item
+		}
+	}
+	return nil
+}
+
+// MatchException is an invoiced line off the purchase order or the goods received by
+// more than the tolerance. Open exceptions block approval and payment of the voucher.
+type MatchException struct {
+	ID                uuid.ID    `json:"id" db:"id"`
+	PurchaseVoucherID uuid.ID    `json:"purchase_voucher_id" db:"purchase_voucher_id"`
+	VoucherItemID     uuid.ID    `json:"voucher_item_id" db:"voucher_item_id"`
+	POItemID          uuid.ID    `json:"po_item_id" db:"po_item_id"`
+	ExceptionType     string     `json:"exception_type" db:"exception_type"`
+	ExpectedValue     float64    `json:"expected_value" db:"expected_value"`
+	ActualValue       float64    `json:"actual_value" db:"actual_value"`
+	Tolerance         float64    `json:"tolerance" db:"tolerance"`
+	VarianceAmount    float64    `json:"variance_amount" db:"variance_amount"`
+	Message           string     `json:"message" db:"message"`
+	Status            string     `json:"status" db:"status"`
+	ResolutionNotes   string     `json:"resolution_notes" db:"resolution_notes"`
+	ResolvedBy        *string    `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+
+	// Related data
+	VoucherNumber string `json:"voucher_number,omitempty" db:"voucher_number"`
+	ItemName      string `json:"item_name,omitempty" db:"item_name"`
+}
+
+// MatchLine checks an invoiced line against its PO item. The quantity invoiced so far,
+// this line included, may exceed neither the quantity received nor the quantity ordered
+// by more than the quantity tolerance, so goods are only paid for once they arrive; the
+// unit price may be off the PO price either way by at most the price tolerance.
+func MatchLine(line *InvoiceMatchLine, pos *POItemPosition, tol *MatchTolerance) []*MatchException {
+	line.OrderedQuantity = pos.OrderedQuantity
+	line.ReceivedQuantity = pos.ReceivedQuantity
+	line.PreviouslyInvoiced = pos.InvoicedQuantity
+	line.POUnitPrice = pos.UnitPrice
+
+	var exceptions []*MatchException
+	newException := func(exceptionType string, expected, actual, tolerance, variance float64, message string) {
+		exceptions = append(exceptions, &MatchException{
+			ID:                uuid.New(),
+			PurchaseVoucherID: line.PurchaseVoucherID,
+			VoucherItemID:     line.ID,
+			POItemID:          line.POItemID,
+			ExceptionType:     exceptionType,
+			ExpectedValue:     expected,
+			ActualValue:       actual,
+			Tolerance:         tolerance,
+			VarianceAmount:    round2(variance),
+			Message:           message,
+			Status:            MatchExceptionOpen,
+			ItemName:          line.ItemName,
+		})
+	}
+
+	matchable, against := pos.ReceivedQuantity, "received"
+	if pos.OrderedQuantity < matchable {
+		matchable, against = pos.OrderedQuantity, "ordered"
+	}
+	invoiced := pos.InvoicedQuantity + line.Quantity
+	if float64(invoiced) > float64(matchable)*(1+tol.QuantityTolerance/100)+1e-9 {
+		remaining := matchable - pos.InvoicedQuantity
+		if remaining < 0 {
+			remaining = 0
+		}
+		excess := invoiced - matchable
+		if excess > line.Quantity {
+			excess = line.Quantity
+		}
+		newException(MatchExceptionQuantity, float64(remaining), float64(line.Quantity), tol.QuantityTolerance,
+			float64(excess)*line.UnitPrice,
+			fmt.Sprintf("%s: %d invoiced in total against %d %s", line.ItemName, invoiced, matchable, against))
+	}
+
+	if diff := line.UnitPrice - pos.UnitPrice; math.Abs(diff) > 0.005 &&
+		(pos.UnitPrice <= 0 || math.Abs(diff)/pos.UnitPrice*100 > tol.PriceTolerance+1e-9) {
+		newException(MatchExceptionPrice, pos.UnitPrice, line.UnitPrice, tol.PriceTolerance, diff*float64(line.Quantity),
+			fmt.Sprintf("%s: invoiced at %.2f against the PO price of %.2f", line.ItemName, line.UnitPrice, pos.UnitPrice))
+	}
+	return exceptions
+}
+
+// GRIRLine is the GR/IR clearing position of a PO item: what was received on posted goods
+// receipts against what was invoiced on vouchers, both up to the report date.
+type GRIRLine struct {
+	PurchaseOrderID  uuid.ID `json:"purchase_order_id" db:"purchase_order_id"`
+	PONumber         string  `json:"po_number" db:"po_number"`
+	SupplierID       uuid.ID `json:"supplier_id" db:"supplier_id"`
+	SupplierName     string  `json:"supplier_name" db:"supplier_name"`
+	POItemID         uuid.ID `json:"po_item_id" db:"po_item_id"`
+	ItemName         string  `json:"item_name" db:"item_name"`
+	UnitPrice        float64 `json:"unit_price" db:"unit_price"`
+	ReceivedQuantity int     `json:"received_quantity" db:"received_quantity"`
+	InvoicedQuantity int     `json:"invoiced_quantity" db:"invoiced_quantity"`
+	ReceivedAmount   float64 `json:"received_amount" db:"received_amount"`
+	InvoicedAmount   float64 `json:"invoiced_amount" db:"invoiced_amount"`
+	// Balance is the credit balance of the GR/IR clearing account for the item: received
+	// less invoiced, negative when more was invoiced than received
+	Balance float64 `json:"balance"`
+}
+
+// GRIRReport is the GR/IR clearing account report: goods received but not yet invoiced
+// and goods invoiced but not yet received, as of a date.
+type GRIRReport struct {
+	AsOf                     time.Time   `json:"as_of"`
+	ReceivedNotInvoiced      []*GRIRLine `json:"received_not_invoiced"`
+	InvoicedNotReceived      []*GRIRLine `json:"invoiced_not_received"`
+	TotalReceivedNotInvoiced float64     `json:"total_received_not_invoiced"`
+	TotalInvoicedNotReceived float64     `json:"total_invoiced_not_received"`
+	// Balance is the net balance of the clearing account over the lines reported
+	Balance float64 `json:"balance"`
+}
+
+// NewGRIRReport sorts the positions of PO items into goods received but not invoiced and
+// goods invoiced but not received, by quantity. Items invoiced as received are cleared
+// and left out; a price difference on them is resolved through the match exceptions.
+func NewGRIRReport(asOf time.Time, lines []*GRIRLine) *GRIRReport {
+	report := &GRIRReport{AsOf: asOf, ReceivedNotInvoiced: []*GRIRLine{}, InvoicedNotReceived: []*GRIRLine{}}
+	for _, line := range lines {
+		line.Balance = round2(line.ReceivedAmount - line.InvoicedAmount)
+		switch {
+		case line.ReceivedQuantity > line.InvoicedQuantity:
+			report.ReceivedNotInvoiced = append(report.ReceivedNotInvoiced, line)
+			report.TotalReceivedNotInvoiced += line.Balance
+		case line.InvoicedQuantity > line.ReceivedQuantity:
+			report.InvoicedNotReceived = append(report.InvoicedNotReceived, line)
+			report.TotalInvoicedNotReceived -= line.Balance
+		default:
+			continue
+		}
+		report.Balance += line.Balance
+	}
+	report.TotalReceivedNotInvoiced = round2(report.TotalReceivedNotInvoiced)
+	report.TotalInvoicedNotReceived = round2(report.TotalInvoicedNotReceived)
+	report.Balance = round2(report.Balance)
+	return report
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
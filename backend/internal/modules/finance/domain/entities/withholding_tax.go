@@ -0,0 +1,176 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// Income tax articles withheld on purchases.
+const (
+	// WithholdingPPh23 is PPh Pasal 23, withheld on services, rent of movable assets and royalties
+	WithholdingPPh23 = "PPh23"
+	// WithholdingPPh42 is PPh Pasal 4 ayat 2, the final tax on construction and land and building rent
+	WithholdingPPh42 = "PPh4(2)"
+)
+
+// pph23NoTaxIDSurcharge raises the PPh 23 rate of suppliers without an NPWP by 100%.
+const pph23NoTaxIDSurcharge = 2.0
+
+var (
+	ErrWithholdingRuleNotFound = errors.New("withholding rule not found")
+	ErrWithholdingRuleExists   = errors.New("an active withholding rule already exists for this supplier and service type")
+	ErrInvalidWithholdingRule  = errors.New("invalid withholding rule")
+	ErrWithholdingSlipNotFound = errors.New("withholding slip not found")
+	ErrPayableNotFound         = errors.New("accounts payable not found")
+	ErrInvalidSupplierPayment  = errors.New("invalid supplier payment")
+)
+
+// WithholdingRule sets the tax withheld from payments for a service type, for one
+// supplier or, without a supplier, for every supplier of the service type.
+type WithholdingRule struct {
+	ID            uuid.ID   `json:"id" db:"id"`
+	SupplierID    *uuid.ID  `json:"supplier_id,omitempty" db:"supplier_id"`
+	ServiceType   string    `json:"service_type" db:"service_type"`
+	TaxArticle    string    `json:"tax_article" db:"tax_article"`
+	TaxObjectCode string    `json:"tax_object_code" db:"tax_object_code"`
+	Rate          float64   `json:"rate" db:"rate"` // percent of the gross amount
+	Description   string    `json:"description" db:"description"`
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	CreatedBy     string    `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Validate checks the tax article, object code and rate of the rule.
+func (r *WithholdingRule) Validate() error {
+	if r.ServiceType == "" {
+		return fmt.Errorf("%w: service type is required", ErrInvalidWithholdingRule)
+	}
+	if r.TaxArticle != WithholdingPPh23 && r.TaxArticle != WithholdingPPh42 {
+		return fmt.Errorf("%w: tax article must be %s or %s", ErrInvalidWithholdingRule, WithholdingPPh23, WithholdingPPh42)
+	}
+	if r.TaxObjectCode == "" {
+		return fmt.Errorf("%w: tax object code is required", ErrInvalidWithholdingRule)
+	}
+	if r.Rate <= 0 || r.Rate > 100 {
+		return fmt.Errorf("%w: rate must be above 0 and at most 100 percent", ErrInvalidWithholdingRule)
+	}
+	return nil
+}
+
+// EffectiveRate returns the rate withheld from a supplier. PPh 23 is withheld at double
+// the rate from suppliers without an NPWP; PPh 4(2) is final and does not change.
+func (r *WithholdingRule) EffectiveRate(supplierHasTaxID bool) float64 {
+	if r.TaxArticle == WithholdingPPh23 && !supplierHasTaxID {
+		return r.Rate * pph23NoTaxIDSurcharge
+	}
+	return r.Rate
+}
+
+// WithheldAmount returns the tax withheld from a gross amount at a rate in percent,
+// rounded down to the rupiah as withholding slips are.
+func WithheldAmount(gross, rate float64) float64 {
+	return math.Floor(gross*rate/100 + 1e-9)
+}
+
+// SupplierPayable is an open payable with the supplier and invoice details a payment
+// and its withholding slip need.
+type SupplierPayable struct {
+	AccountsPayable
+	InvoiceNumber   string    `json:"invoice_number" db:"invoice_number"`
+	InvoiceDate     time.Time `json:"invoice_date" db:"invoice_date"`
+	SupplierName    string    `json:"supplier_name" db:"supplier_name"`
+	SupplierTaxID   string    `json:"supplier_tax_id" db:"supplier_tax_id"`
+	SupplierAddress string    `json:"supplier_address" db:"supplier_address"`
+	// MatchBlocked is set while a supplier invoice of the payable has unresolved
+	// three-way match exceptions
+	MatchBlocked bool `json:"match_blocked" db:"match_blocked"`
+}
+
+// SupplierPayment is a payment of a payable: the gross amount settles the payable, the
+// supplier receives it net of the tax withheld.
+type SupplierPayment struct {
+	PaymentID         uuid.ID          `json:"payment_id"`
+	AccountsPayableID uuid.ID          `json:"accounts_payable_id"`
+	InvoiceID         uuid.ID          `json:"invoice_id"`
+	SupplierID        uuid.ID          `json:"supplier_id"`
+	CashBankID        uuid.ID          `json:"cash_bank_id"`
+	PaymentDate       time.Time        `json:"payment_date"`
+	PaymentMethod     string           `json:"payment_method"`
+	ServiceType       string           `json:"service_type,omitempty"`
+	GrossAmount       float64          `json:"gross_amount"`
+	WithheldAmount    float64          `json:"withheld_amount"`
+	NetAmount         float64          `json:"net_amount"`
+	PayableBalance    float64          `json:"payable_balance"`
+	Slip              *WithholdingSlip `json:"withholding_slip,omitempty"`
+	CreatedBy         string           `json:"created_by"`
+}
+
+// WithholdingSlip is a bukti potong: the supplier's evidence of the tax withheld from a
+// payment, numbered per tax article and month.
+type WithholdingSlip struct {
+	ID                uuid.ID    `json:"id" db:"id"`
+	SlipNumber        string     `json:"slip_number" db:"slip_number"`
+	TaxArticle        string     `json:"tax_article" db:"tax_article"`
+	TaxYear           int        `json:"tax_year" db:"tax_year"`
+	TaxPeriod         int        `json:"tax_period" db:"tax_period"`
+	Sequence          int        `json:"sequence" db:"sequence"`
+	TaxObjectCode     string     `json:"tax_object_code" db:"tax_object_code"`
+	WithholdingRuleID *uuid.ID   `json:"withholding_rule_id,omitempty" db:"withholding_rule_id"`
+	SupplierID        *uuid.ID   `json:"supplier_id,omitempty" db:"supplier_id"`
+	SupplierName      string     `json:"supplier_name" db:"supplier_name"`
+	SupplierTaxID     string     `json:"supplier_tax_id" db:"supplier_tax_id"`
+	SupplierAddress   string     `json:"supplier_address" db:"supplier_address"`
+	PaymentID         uuid.ID    `json:"payment_id" db:"payment_id"`
+	AccountsPayableID *uuid.ID   `json:"accounts_payable_id,omitempty" db:"accounts_payable_id"`
+	DocumentNumber    string     `json:"document_number" db:"document_number"`
+	DocumentDate      *time.Time `json:"document_date,omitempty" db:"document_date"`
+	SlipDate          time.Time  `json:"slip_date" db:"slip_date"`
+	GrossAmount       float64    `json:"gross_amount" db:"gross_amount"`
+	Rate              float64    `json:"rate" db:"rate"`
+	WithheldAmount    float64    `json:"withheld_amount" db:"withheld_amount"`
+	TaxTransactionID  *uuid.ID   `json:"tax_transaction_id,omitempty" db:"tax_transaction_id"`
+	CreatedBy         string     `json:"created_by" db:"created_by"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SlipNumberPrefix returns the prefix of the slip numbers of a tax article.
+func SlipNumberPrefix(taxArticle string) string {
+	if taxArticle == WithholdingPPh42 {
+		return "BP42"
+	}
+	return "BP23"
+}
+
+// FormatSlipNumber formats the number of a slip, e.g. BP23/2025/03/0001.
+func FormatSlipNumber(taxArticle string, year, period, sequence int) string {
+	return fmt.Sprintf("%s/%04d/%02d/%04d", SlipNumberPrefix(taxArticle), year, period, sequence)
+}
+
+// WithholdingSummary totals the slips of a month per tax article and object code, as
+// reported in the monthly withholding tax return (SPT Masa PPh Unifikasi).
+type WithholdingSummary struct {
+	TaxYear        int     `json:"tax_year" db:"tax_year"`
+	TaxPeriod      int     `json:"tax_period" db:"tax_period"`
+	TaxArticle     string  `json:"tax_article" db:"tax_article"`
+	TaxObjectCode  string  `json:"tax_object_code" db:"tax_object_code"`
+	SlipCount      int     `json:"slip_count" db:"slip_count"`
+	GrossAmount    float64 `json:"gross_amount" db:"gross_amount"`
+	WithheldAmount float64 `json:"withheld_amount" db:"withheld_amount"`
+}
+
+// WithholdingMonth is the withholding of a month: the summary lines with their totals
+// per tax article, ready to file as the tax return of the month.
+type WithholdingMonth struct {
+	TaxYear        int                   `json:"tax_year"`
+	TaxPeriod      int                   `json:"tax_period"`
+	Lines          []*WithholdingSummary `json:"lines"`
+	TotalByArticle map[string]float64    `json:"total_by_article"`
+	GrossAmount    float64               `json:"gross_amount"`
+	WithheldAmount float64               `json:"withheld_amount"`
+	SlipCount      int                   `json:"slip_count"`
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MatchExceptionFilter selects match exceptions; zero values select everything.
+type MatchExceptionFilter struct {
+	Status            string
+	PurchaseVoucherID *uuid.ID
+}
+
+// GRIRFilter selects the PO items of the GR/IR report.
+type GRIRFilter struct {
+	AsOf       time.Time
+	SupplierID *uuid.ID
+}
+
+// ThreeWayMatchRepository defines the interface for matching supplier invoices against
+// purchase orders and goods receipts.
+type ThreeWayMatchRepository interface {
+	// GetTolerance returns the tolerance of the company, or else the default; nil when
+	// there is neither.
+	GetTolerance(ctx context.Context, companyID *uuid.ID) (*entities.MatchTolerance, error)
+	// SaveTolerance creates or replaces the tolerance of its company.
+	SaveTolerance(ctx context.Context, tolerance *entities.MatchTolerance) error
+
+	// GetVoucherMatch retrieves a voucher with its matched lines and exceptions.
+	GetVoucherMatch(ctx context.Context, voucherID uuid.ID) (*entities.VoucherMatch, error)
+	// GetPurchaseOrderPosition retrieves a purchase order with what was received and what
+	// was invoiced of each item, leaving out the invoice of the voucher being matched.
+	GetPurchaseOrderPosition(ctx context.Context, purchaseOrderID, excludeVoucherID uuid.ID) (*entities.PurchaseOrderPosition, error)
+	// SaveMatch replaces the lines and exceptions of a voucher and sets its match status.
+	SaveMatch(ctx context.Context, match *entities.VoucherMatch) error
+
+	GetException(ctx context.Context, id uuid.ID) (*entities.MatchException, error)
+	ListExceptions(ctx context.Context, filter MatchExceptionFilter) ([]*entities.MatchException, error)
+	// ResolveException closes an open exception; once none is left open the voucher is
+	// marked resolved.
+	ResolveException(ctx context.Context, exception *entities.MatchException) error
+	CountOpenExceptions(ctx context.Context, voucherID uuid.ID) (int, error)
+	// IsPayableBlocked tells whether open exceptions hold back the payable: on a voucher
+	// paid through it, or on a voucher billing the purchase order it was received for.
+	IsPayableBlocked(ctx context.Context, payableID uuid.ID) (bool, error)
+
+	// GetGRIRLines returns the received and invoiced quantities and amounts of the PO items
+	// with anything received or invoiced up to the date.
+	GetGRIRLines(ctx context.Context, filter GRIRFilter) ([]*entities.GRIRLine, error)
+}
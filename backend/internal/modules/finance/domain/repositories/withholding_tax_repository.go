@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// WithholdingSlipFilter selects withholding slips; zero values select everything.
+type WithholdingSlipFilter struct {
+	TaxYear    int
+	TaxPeriod  int
+	TaxArticle string
+	SupplierID *uuid.ID
+}
+
+// WithholdingTaxRepository defines the interface for withholding rules, supplier payments
+// and withholding slips.
+type WithholdingTaxRepository interface {
+	CreateRule(ctx context.Context, rule *entities.WithholdingRule) error
+	UpdateRule(ctx context.Context, rule *entities.WithholdingRule) error
+	GetRule(ctx context.Context, id uuid.ID) (*entities.WithholdingRule, error)
+	ListRules(ctx context.Context, activeOnly bool) ([]*entities.WithholdingRule, error)
+	// FindRule returns the active rule of the supplier for the service type, or else the
+	// default of the service type; nil when there is neither.
+	FindRule(ctx context.Context, supplierID uuid.ID, serviceType string) (*entities.WithholdingRule, error)
+
+	GetPayable(ctx context.Context, id uuid.ID) (*entities.SupplierPayable, error)
+	// RecordSupplierPayment saves the payment and settles the payable by the gross amount.
+	// With a slip it numbers the slip and books the tax withheld as a WITHHOLDING tax
+	// transaction, all in one database transaction.
+	RecordSupplierPayment(ctx context.Context, payment *entities.SupplierPayment) error
+
+	GetSlip(ctx context.Context, id uuid.ID) (*entities.WithholdingSlip, error)
+	ListSlips(ctx context.Context, filter WithholdingSlipFilter) ([]*entities.WithholdingSlip, error)
+	// SummarizeSlips totals the slips of a month per tax article and object code.
+	SummarizeSlips(ctx context.Context, year, period int) ([]*entities.WithholdingSummary, error)
+}
@@ -9,9 +9,16 @@ import (
 	"malaka/internal/shared/uuid"
 )
 
+// PayableMatchGate holds back payables whose supplier invoice has unresolved three-way
+// match exceptions.
+type PayableMatchGate interface {
+	CheckPayable(ctx context.Context, payableID uuid.ID) error
+}
+
 // AccountsPayableService provides business logic for accounts payable operations.
 type AccountsPayableService struct {
-	repo repositories.AccountsPayableRepository
+	repo      repositories.AccountsPayableRepository
+	matchGate PayableMatchGate
 }
 
 // NewAccountsPayableService creates a new AccountsPayableService.
@@ -19,6 +26,11 @@ func NewAccountsPayableService(repo repositories.AccountsPayableRepository) *Acc
 	return &AccountsPayableService{repo: repo}
 }
 
+// SetMatchGate sets the three-way match check a payment on a payable must pass.
+func (s *AccountsPayableService) SetMatchGate(gate PayableMatchGate) {
+	s.matchGate = gate
+}
+
 // CreateAccountsPayable creates a new accounts payable record.
 func (s *AccountsPayableService) CreateAccountsPayable(ctx context.Context, ap *entities.AccountsPayable) error {
 	if ap.ID.IsNil() {
@@ -37,7 +49,8 @@ func (s *AccountsPayableService) GetAllAccountsPayable(ctx context.Context) ([]*
 	return s.repo.GetAll(ctx)
 }
 
-// UpdateAccountsPayable updates an existing accounts payable record.
+// UpdateAccountsPayable updates an existing accounts payable record. A change of its paid
+// amount or balance is a payment and has to pass the match gate.
 func (s *AccountsPayableService) UpdateAccountsPayable(ctx context.Context, ap *entities.AccountsPayable) error {
 	// Ensure the accounts payable record exists before updating
 	existingAP, err := s.repo.GetByID(ctx, ap.ID)
@@ -47,6 +60,11 @@ func (s *AccountsPayableService) UpdateAccountsPayable(ctx context.Context, ap *
 	if existingAP == nil {
 		return errors.New("accounts payable not found")
 	}
+	if s.matchGate != nil && (ap.PaidAmount != existingAP.PaidAmount || ap.Balance != existingAP.Balance) {
+		if err := s.matchGate.CheckPayable(ctx, ap.ID); err != nil {
+			return err
+		}
+	}
 	return s.repo.Update(ctx, ap)
 }
 
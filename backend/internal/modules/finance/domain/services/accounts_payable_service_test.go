@@ -1,9 +1,82 @@
 package services
 
 import (
+	"context"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
 )
 
 func TestAccountsPayableService(t *testing.T) {
 	// Placeholder for accounts payable service tests
 }
+
+// MockAccountsPayableRepository is a mock implementation of repositories.AccountsPayableRepository.
+type MockAccountsPayableRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccountsPayableRepository) Create(ctx context.Context, ap *entities.AccountsPayable) error {
+	args := m.Called(ctx, ap)
+	return args.Error(0)
+}
+
+func (m *MockAccountsPayableRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.AccountsPayable, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.AccountsPayable), args.Error(1)
+}
+
+func (m *MockAccountsPayableRepository) GetAll(ctx context.Context) ([]*entities.AccountsPayable, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.AccountsPayable), args.Error(1)
+}
+
+func (m *MockAccountsPayableRepository) Update(ctx context.Context, ap *entities.AccountsPayable) error {
+	args := m.Called(ctx, ap)
+	return args.Error(0)
+}
+
+func (m *MockAccountsPayableRepository) Delete(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestUpdateAccountsPayable_PaymentHeldBackByOpenExceptions(t *testing.T) {
+	repo := new(MockAccountsPayableRepository)
+	matchRepo := new(MockThreeWayMatchRepository)
+	svc := NewAccountsPayableService(repo)
+	svc.SetMatchGate(NewThreeWayMatchService(matchRepo))
+	ctx := context.Background()
+	stored := &entities.AccountsPayable{BaseModel: types.BaseModel{ID: uuid.New()}, Amount: 5000000, Balance: 5000000, Status: "open"}
+
+	repo.On("GetByID", ctx, stored.ID).Return(stored, nil).Times(3)
+	matchRepo.On("IsPayableBlocked", ctx, stored.ID).Return(true, nil).Once()
+
+	// Paying it down through a plain update is refused like a supplier payment
+	paid := *stored
+	paid.PaidAmount, paid.Balance, paid.Status = 2000000, 3000000, "partial"
+	assert.ErrorIs(t, svc.UpdateAccountsPayable(ctx, &paid), entities.ErrMatchExceptionsUnresolved)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	// Other changes are not a payment
+	postponed := *stored
+	postponed.DueDate = stored.DueDate.AddDate(0, 0, 14)
+	repo.On("Update", ctx, &postponed).Return(nil).Once()
+	require.NoError(t, svc.UpdateAccountsPayable(ctx, &postponed))
+
+	// Once the exceptions are resolved the payment goes through
+	matchRepo.On("IsPayableBlocked", ctx, stored.ID).Return(false, nil).Once()
+	repo.On("Update", ctx, &paid).Return(nil).Once()
+	require.NoError(t, svc.UpdateAccountsPayable(ctx, &paid))
+	repo.AssertExpectations(t)
+	matchRepo.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"malaka/internal/modules/finance/domain/entities"
+)
+
+// e-Bupot import CSV header: one row per withholding slip.
+var ebupotCSVHeader = []string{"NO", "NOMOR_BUKTI_POTONG", "JENIS_PPH", "MASA_PAJAK", "TAHUN_PAJAK", "TANGGAL_PEMOTONGAN",
+	"NPWP", "NAMA", "ALAMAT", "KODE_OBJEK_PAJAK", "PENGHASILAN_BRUTO", "TARIF", "PPH_DIPOTONG", "JENIS_DOKUMEN",
+	"NOMOR_DOKUMEN", "TANGGAL_DOKUMEN"}
+
+// ebupotDocumentInvoice is the e-Bupot document type of a supplier invoice.
+const ebupotDocumentInvoice = "Faktur Pajak/Invoice"
+
+// WriteEBupotCSV writes withholding slips in the e-Bupot import CSV layout.
+func WriteEBupotCSV(w io.Writer, slips []*entities.WithholdingSlip) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ebupotCSVHeader); err != nil {
+		return err
+	}
+	for i, slip := range slips {
+		documentDate := ""
+		if slip.DocumentDate != nil {
+			documentDate = slip.DocumentDate.Format("02/01/2006")
+		}
+		if err := cw.Write([]string{strconv.Itoa(i + 1), slip.SlipNumber, slip.TaxArticle, strconv.Itoa(slip.TaxPeriod),
+			strconv.Itoa(slip.TaxYear), slip.SlipDate.Format("02/01/2006"), ebupotTIN(slip.SupplierTaxID), slip.SupplierName,
+			slip.SupplierAddress, slip.TaxObjectCode, wholeRupiah(slip.GrossAmount), formatRate(slip.Rate),
+			wholeRupiah(slip.WithheldAmount), ebupotDocumentInvoice, slip.DocumentNumber, documentDate}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Coretax e-Bupot import XML: the bulk file of the withholder with a Bpu (bukti
+// pemotongan unifikasi) per slip.
+type ebupotBulk struct {
+	XMLName   xml.Name     `xml:"BpuBulk"`
+	XSI       string       `xml:"xmlns:xsi,attr"`
+	Schema    string       `xml:"xsi:noNamespaceSchemaLocation,attr"`
+	TIN       string       `xml:"TIN"`
+	ListOfBpu []*ebupotBpu `xml:"ListOfBpu>Bpu"`
+}
+
+type ebupotBpu struct {
+	TaxPeriodMonth            int    `xml:"TaxPeriodMonth"`
+	TaxPeriodYear             int    `xml:"TaxPeriodYear"`
+	CounterpartOpt            string `xml:"CounterpartOpt"`
+	CounterpartPassport       string `xml:"CounterpartPassport"`
+	CounterpartTin            string `xml:"CounterpartTin"`
+	StatusTaxExemption        string `xml:"StatusTaxExemption"`
+	TaxCertificate            string `xml:"TaxCertificate"`
+	TaxObjectCode             string `xml:"TaxObjectCode"`
+	TaxBase                   string `xml:"TaxBase"`
+	TaxRate                   string `xml:"TaxRate"`
+	Document                  string `xml:"Document"`
+	DocumentNumber            string `xml:"DocumentNumber"`
+	DocumentDate              string `xml:"DocumentDate"`
+	IDPlaceOfBusinessActivity string `xml:"IDPlaceOfBusinessActivity"`
+	GovTreasurerOpt           string `xml:"GovTreasurerOpt"`
+	SP2DNumber                string `xml:"SP2DNumber"`
+	WithholdingDate           string `xml:"WithholdingDate"`
+}
+
+// WriteEBupotXML writes withholding slips in the Coretax e-Bupot import XML layout, made
+// out for the withholder NPWP.
+func WriteEBupotXML(w io.Writer, payerTaxID string, slips []*entities.WithholdingSlip) error {
+	payer := ebupotTIN(payerTaxID)
+	bulk := &ebupotBulk{
+		XSI:    "http://www.w3.org/2001/XMLSchema-instance",
+		Schema: "schema.xsd",
+		TIN:    payer,
+	}
+	for _, slip := range slips {
+		documentDate := slip.SlipDate
+		if slip.DocumentDate != nil {
+			documentDate = *slip.DocumentDate
+		}
+		bulk.ListOfBpu = append(bulk.ListOfBpu, &ebupotBpu{
+			TaxPeriodMonth:            slip.TaxPeriod,
+			TaxPeriodYear:             slip.TaxYear,
+			CounterpartOpt:            "Domestic",
+			CounterpartTin:            ebupotTIN(slip.SupplierTaxID),
+			TaxCertificate:            "N/A",
+			TaxObjectCode:             slip.TaxObjectCode,
+			TaxBase:                   wholeRupiah(slip.GrossAmount),
+			TaxRate:                   formatRate(slip.Rate),
+			Document:                  "CommercialInvoice",
+			DocumentNumber:            slip.DocumentNumber,
+			DocumentDate:              documentDate.Format("2006-01-02"),
+			IDPlaceOfBusinessActivity: payer + "000000",
+			GovTreasurerOpt:           "N/A",
+			WithholdingDate:           slip.SlipDate.Format("2006-01-02"),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(bulk); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// ebupotTIN returns an NPWP as the 16 digits Coretax uses; 15-digit NPWPs are prefixed
+// with 0 and a missing one is all zeros.
+func ebupotTIN(taxID string) string {
+	switch len(taxID) {
+	case 0:
+		return strings.Repeat("0", 16)
+	case 15:
+		return "0" + taxID
+	}
+	return taxID
+}
+
+// wholeRupiah formats an amount as whole rupiah.
+func wholeRupiah(amount float64) string {
+	return strconv.FormatFloat(math.Round(amount), 'f', 0, 64)
+}
+
+// formatRate formats a rate in percent without trailing zeros.
+func formatRate(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', -1, 64)
+}
@@ -9,6 +9,12 @@ import (
 	"malaka/internal/shared/uuid"
 )
 
+// MatchGate holds back vouchers whose supplier invoice has unresolved three-way match
+// exceptions.
+type MatchGate interface {
+	CheckVoucher(ctx context.Context, voucherID uuid.ID) error
+}
+
 type PurchaseVoucherService interface {
 	CreatePurchaseVoucher(ctx context.Context, voucher *entities.PurchaseVoucher) error
 	GetPurchaseVoucherByID(ctx context.Context, id uuid.ID) (*entities.PurchaseVoucher, error)
@@ -17,10 +23,12 @@ type PurchaseVoucherService interface {
 	DeletePurchaseVoucher(ctx context.Context, id uuid.ID) error
 	GetPurchaseVouchersByStatus(ctx context.Context, status string) ([]*entities.PurchaseVoucher, error)
 	ApprovePurchaseVoucher(ctx context.Context, id uuid.ID, approvedBy uuid.ID) error
+	SetMatchGate(gate MatchGate)
 }
 
 type purchaseVoucherService struct {
-	repo repositories.PurchaseVoucherRepository
+	repo      repositories.PurchaseVoucherRepository
+	matchGate MatchGate
 }
 
 func NewPurchaseVoucherService(repo repositories.PurchaseVoucherRepository) PurchaseVoucherService {
@@ -44,7 +52,20 @@ func (s *purchaseVoucherService) GetAllPurchaseVouchers(ctx context.Context) ([]
 	return s.repo.GetAll(ctx)
 }
 
+// UpdatePurchaseVoucher saves a voucher. A change of its status or paid amount has to
+// pass the match gate like an approval does.
 func (s *purchaseVoucherService) UpdatePurchaseVoucher(ctx context.Context, voucher *entities.PurchaseVoucher) error {
+	if s.matchGate != nil {
+		existing, err := s.repo.GetByID(ctx, voucher.ID)
+		if err != nil {
+			return err
+		}
+		if existing.Status != voucher.Status || existing.PaidAmount != voucher.PaidAmount {
+			if err := s.matchGate.CheckVoucher(ctx, voucher.ID); err != nil {
+				return err
+			}
+		}
+	}
 	return s.repo.Update(ctx, voucher)
 }
 
@@ -56,11 +77,22 @@ func (s *purchaseVoucherService) GetPurchaseVouchersByStatus(ctx context.Context
 	return s.repo.GetByStatus(ctx, status)
 }
 
+// SetMatchGate sets the three-way match check a voucher must pass to be approved, paid
+// or otherwise moved on.
+func (s *purchaseVoucherService) SetMatchGate(gate MatchGate) {
+	s.matchGate = gate
+}
+
 func (s *purchaseVoucherService) ApprovePurchaseVoucher(ctx context.Context, id uuid.ID, approvedBy uuid.ID) error {
 	voucher, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if s.matchGate != nil {
+		if err := s.matchGate.CheckVoucher(ctx, id); err != nil {
+			return err
+		}
+	}
 
 	voucher.Status = "approved"
 	voucher.ApprovedBy = approvedBy
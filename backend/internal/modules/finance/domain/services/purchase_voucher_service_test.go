@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// MockPurchaseVoucherRepository is a mock implementation of repositories.PurchaseVoucherRepository.
+type MockPurchaseVoucherRepository struct {
+	mock.Mock
+}
+
+func (m *MockPurchaseVoucherRepository) Create(ctx context.Context, voucher *entities.PurchaseVoucher) error {
+	args := m.Called(ctx, voucher)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseVoucherRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.PurchaseVoucher, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseVoucher), args.Error(1)
+}
+
+func (m *MockPurchaseVoucherRepository) GetAll(ctx context.Context) ([]*entities.PurchaseVoucher, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.PurchaseVoucher), args.Error(1)
+}
+
+func (m *MockPurchaseVoucherRepository) GetBySupplierID(ctx context.Context, supplierID string) ([]*entities.PurchaseVoucher, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]*entities.PurchaseVoucher), args.Error(1)
+}
+
+func (m *MockPurchaseVoucherRepository) GetByStatus(ctx context.Context, status string) ([]*entities.PurchaseVoucher, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]*entities.PurchaseVoucher), args.Error(1)
+}
+
+func (m *MockPurchaseVoucherRepository) GetByVoucherNumber(ctx context.Context, voucherNumber string) (*entities.PurchaseVoucher, error) {
+	args := m.Called(ctx, voucherNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseVoucher), args.Error(1)
+}
+
+func (m *MockPurchaseVoucherRepository) Update(ctx context.Context, voucher *entities.PurchaseVoucher) error {
+	args := m.Called(ctx, voucher)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseVoucherRepository) Delete(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestUpdatePurchaseVoucher_StatusChangeHeldBackByOpenExceptions(t *testing.T) {
+	repo := new(MockPurchaseVoucherRepository)
+	matchRepo := new(MockThreeWayMatchRepository)
+	svc := NewPurchaseVoucherService(repo)
+	svc.SetMatchGate(NewThreeWayMatchService(matchRepo))
+	ctx := context.Background()
+	stored := &entities.PurchaseVoucher{BaseModel: types.BaseModel{ID: uuid.New()}, VoucherNumber: "PV-0001",
+		TotalAmount: 5000000, RemainingAmount: 5000000, Status: "pending"}
+
+	repo.On("GetByID", ctx, stored.ID).Return(stored, nil).Times(3)
+	matchRepo.On("CountOpenExceptions", ctx, stored.ID).Return(1, nil).Twice()
+
+	// Marking it approved or paid through a plain update is refused like an approval
+	approved := *stored
+	approved.Status = "approved"
+	assert.ErrorIs(t, svc.UpdatePurchaseVoucher(ctx, &approved), entities.ErrMatchExceptionsUnresolved)
+	paid := *stored
+	paid.PaidAmount, paid.RemainingAmount = 5000000, 0
+	assert.ErrorIs(t, svc.UpdatePurchaseVoucher(ctx, &paid), entities.ErrMatchExceptionsUnresolved)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	// Other changes do not move it on
+	described := *stored
+	described.Description = "Fabric for the March collection"
+	repo.On("Update", ctx, &described).Return(nil).Once()
+	require.NoError(t, svc.UpdatePurchaseVoucher(ctx, &described))
+	repo.AssertExpectations(t)
+	matchRepo.AssertExpectations(t)
+}
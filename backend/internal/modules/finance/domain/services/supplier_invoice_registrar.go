@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// SupplierInvoiceRegistrar books the invoices suppliers submit through the supplier
+// portal as purchase vouchers and matches them against their purchase orders.
+type SupplierInvoiceRegistrar struct {
+	vouchers PurchaseVoucherService
+	matches  *ThreeWayMatchService
+}
+
+// NewSupplierInvoiceRegistrar creates a new SupplierInvoiceRegistrar.
+func NewSupplierInvoiceRegistrar(vouchers PurchaseVoucherService, matches *ThreeWayMatchService) *SupplierInvoiceRegistrar {
+	return &SupplierInvoiceRegistrar{vouchers: vouchers, matches: matches}
+}
+
+// RegisterSupplierInvoice creates a pending voucher for the invoice, due on its due date
+// or else on its invoice date, and matches its lines. A voucher that cannot be matched is
+// removed again; one that matches outside the tolerance stays, blocked by its exceptions.
+func (r *SupplierInvoiceRegistrar) RegisterSupplierInvoice(ctx context.Context, invoice *integration.SupplierInvoiceDTO) (string, error) {
+	supplierID, err := uuid.Parse(invoice.SupplierID)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid supplier", entities.ErrInvalidInvoiceMatch)
+	}
+	orderID, err := uuid.Parse(invoice.PurchaseOrderID)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid purchase order", entities.ErrInvalidInvoiceMatch)
+	}
+	lines := make([]InvoiceMatchLineInput, 0, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		itemID, err := uuid.Parse(line.POItemID)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid purchase order item %s", entities.ErrInvalidInvoiceMatch, line.POItemID)
+		}
+		lines = append(lines, InvoiceMatchLineInput{POItemID: itemID, Quantity: line.Quantity, UnitPrice: line.UnitPrice})
+	}
+
+	dueDate := invoice.InvoiceDate
+	if invoice.DueDate != nil {
+		dueDate = *invoice.DueDate
+	}
+	now := time.Now()
+	voucher := &entities.PurchaseVoucher{
+		BaseModel:       types.BaseModel{ID: uuid.New(), CreatedAt: now, UpdatedAt: now},
+		VoucherNumber:   supplierInvoiceVoucherNumber(invoice),
+		SupplierID:      supplierID,
+		VoucherDate:     invoice.InvoiceDate,
+		DueDate:         dueDate,
+		TotalAmount:     invoice.TotalAmount,
+		RemainingAmount: invoice.TotalAmount,
+		TaxAmount:       invoice.TaxAmount,
+		Description:     fmt.Sprintf("Supplier invoice %s", invoice.InvoiceNumber),
+	}
+	if err := r.vouchers.CreatePurchaseVoucher(ctx, voucher); err != nil {
+		return "", err
+	}
+
+	_, err = r.matches.MatchInvoice(ctx, &InvoiceMatchRequest{
+		PurchaseVoucherID:     voucher.ID,
+		PurchaseOrderID:       orderID,
+		SupplierInvoiceNumber: invoice.InvoiceNumber,
+		Lines:                 lines,
+	})
+	if err != nil {
+		if delErr := r.vouchers.DeletePurchaseVoucher(ctx, voucher.ID); delErr != nil {
+			return "", fmt.Errorf("%w (voucher %s was not removed: %v)", err, voucher.VoucherNumber, delErr)
+		}
+		return "", err
+	}
+	return voucher.ID.String(), nil
+}
+
+// supplierInvoiceVoucherNumber numbers the voucher of a supplier invoice after the month
+// of the invoice and the invoice ID, so registering an invoice twice fails on the number.
+func supplierInvoiceVoucherNumber(invoice *integration.SupplierInvoiceDTO) string {
+	id := strings.ReplaceAll(invoice.ID, "-", "")
+	if len(id) > 12 {
+		id = id[len(id)-12:]
+	}
+	return fmt.Sprintf("PV-SI-%s-%s", invoice.InvoiceDate.Format("200601"), strings.ToUpper(id))
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// InvoiceMatchRequest matches the supplier invoice of a purchase voucher against a
+// purchase order. Each line bills part or all of the quantity received of a PO item.
+type InvoiceMatchRequest struct {
+	PurchaseVoucherID     uuid.ID
+	PurchaseOrderID       uuid.ID
+	AccountsPayableID     *uuid.ID
+	SupplierInvoiceNumber string
+	CompanyID             *uuid.ID
+	Lines                 []InvoiceMatchLineInput
+}
+
+// InvoiceMatchLineInput is an invoiced PO item.
+type InvoiceMatchLineInput struct {
+	POItemID  uuid.ID
+	Quantity  int
+	UnitPrice float64
+}
+
+// ThreeWayMatchService provides business logic for matching supplier invoices against
+// what was ordered on the purchase order and received on posted goods receipts, within
+// tolerances set per company, and for the GR/IR clearing report.
+type ThreeWayMatchService struct {
+	repo repositories.ThreeWayMatchRepository
+}
+
+// NewThreeWayMatchService creates a new ThreeWayMatchService.
+func NewThreeWayMatchService(repo repositories.ThreeWayMatchRepository) *ThreeWayMatchService {
+	return &ThreeWayMatchService{repo: repo}
+}
+
+// GetTolerance returns the tolerance that applies to a company: its own, or else the
+// default. Without either nothing is tolerated.
+func (s *ThreeWayMatchService) GetTolerance(ctx context.Context, companyID *uuid.ID) (*entities.MatchTolerance, error) {
+	tolerance, err := s.repo.GetTolerance(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if tolerance == nil {
+		tolerance = &entities.MatchTolerance{}
+	}
+	return tolerance, nil
+}
+
+// SetTolerance sets the tolerance of a company, or the default without a company.
+func (s *ThreeWayMatchService) SetTolerance(ctx context.Context, tolerance *entities.MatchTolerance) error {
+	if err := tolerance.Validate(); err != nil {
+		return err
+	}
+	if tolerance.CompanyID != nil && tolerance.CompanyID.IsNil() {
+		tolerance.CompanyID = nil
+	}
+	if tolerance.ID.IsNil() {
+		tolerance.ID = uuid.New()
+	}
+	now := time.Now()
+	tolerance.CreatedAt, tolerance.UpdatedAt = now, now
+	return s.repo.SaveTolerance(ctx, tolerance)
+}
+
+// MatchInvoice records the lines of the supplier invoice of a voucher and matches them
+// against the purchase order and its goods receipts. Matching again replaces the earlier
+// lines and exceptions; lines off by more than the tolerance of the company raise open
+// exceptions that block approval and payment of the voucher.
+func (s *ThreeWayMatchService) MatchInvoice(ctx context.Context, req *InvoiceMatchRequest) (*entities.VoucherMatch, error) {
+	if req.PurchaseOrderID.IsNil() {
+		return nil, fmt.Errorf("%w: purchase order is required", entities.ErrInvalidInvoiceMatch)
+	}
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", entities.ErrInvalidInvoiceMatch)
+	}
+	match, err := s.repo.GetVoucherMatch(ctx, req.PurchaseVoucherID)
+	if err != nil {
+		return nil, err
+	}
+	if !match.CanBeMatched() {
+		return nil, fmt.Errorf("%w: voucher %s is %s", entities.ErrInvalidInvoiceMatch, match.VoucherNumber, match.Status)
+	}
+	order, err := s.repo.GetPurchaseOrderPosition(ctx, req.PurchaseOrderID, match.PurchaseVoucherID)
+	if err != nil {
+		return nil, err
+	}
+	if order.SupplierID != match.SupplierID {
+		return nil, fmt.Errorf("%w: purchase order %s is not from the supplier of the voucher", entities.ErrInvalidInvoiceMatch, order.PONumber)
+	}
+	if order.Status == "draft" || order.Status == "cancelled" {
+		return nil, fmt.Errorf("%w: purchase order %s is %s", entities.ErrInvalidInvoiceMatch, order.PONumber, order.Status)
+	}
+
+	companyID := req.CompanyID
+	if companyID == nil {
+		companyID = match.CompanyID
+	}
+	tolerance, err := s.GetTolerance(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	match.CompanyID = companyID
+	match.PurchaseOrderID = &order.PurchaseOrderID
+	match.PONumber = order.PONumber
+	match.AccountsPayableID = req.AccountsPayableID
+	match.SupplierInvoiceNumber = strings.TrimSpace(req.SupplierInvoiceNumber)
+	match.Tolerance = tolerance
+	match.Lines = make([]*entities.InvoiceMatchLine, 0, len(req.Lines))
+	match.Exceptions = []*entities.MatchException{}
+
+	seen := map[uuid.ID]bool{}
+	for _, input := range req.Lines {
+		pos := order.Item(input.POItemID)
+		if pos == nil {
+			return nil, fmt.Errorf("%w: item %s is not on purchase order %s", entities.ErrInvalidInvoiceMatch, input.POItemID, order.PONumber)
+		}
+		if seen[input.POItemID] {
+			return nil, fmt.Errorf("%w: %s is invoiced twice", entities.ErrInvalidInvoiceMatch, pos.ItemName)
+		}
+		seen[input.POItemID] = true
+		if input.Quantity <= 0 || input.UnitPrice < 0 {
+			return nil, fmt.Errorf("%w: %s needs a quantity above 0 and a unit price of at least 0", entities.ErrInvalidInvoiceMatch, pos.ItemName)
+		}
+		line := &entities.InvoiceMatchLine{
+			ID:                uuid.New(),
+			PurchaseVoucherID: match.PurchaseVoucherID,
+			POItemID:          input.POItemID,
+			ItemName:          pos.ItemName,
+			Quantity:          input.Quantity,
+			UnitPrice:         input.UnitPrice,
+			LineTotal:         math.Round(float64(input.Quantity)*input.UnitPrice*100) / 100,
+		}
+		match.Lines = append(match.Lines, line)
+		match.Exceptions = append(match.Exceptions, entities.MatchLine(line, pos, tolerance)...)
+	}
+
+	now := time.Now()
+	match.MatchedAt = &now
+	match.MatchStatus = entities.MatchStatusMatched
+	if len(match.Exceptions) > 0 {
+		match.MatchStatus = entities.MatchStatusException
+	}
+	for _, exception := range match.Exceptions {
+		exception.CreatedAt = now
+		exception.VoucherNumber = match.VoucherNumber
+	}
+	if err := s.repo.SaveMatch(ctx, match); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// GetVoucherMatch retrieves the match of a voucher with its lines and exceptions.
+func (s *ThreeWayMatchService) GetVoucherMatch(ctx context.Context, voucherID uuid.ID) (*entities.VoucherMatch, error) {
+	return s.repo.GetVoucherMatch(ctx, voucherID)
+}
+
+// ListExceptions lists the match exceptions of a filter.
+func (s *ThreeWayMatchService) ListExceptions(ctx context.Context, filter repositories.MatchExceptionFilter) ([]*entities.MatchException, error) {
+	return s.repo.ListExceptions(ctx, filter)
+}
+
+// ResolveException resolves an open exception, accepting the variance with the reason
+// given. Once every exception of the voucher is resolved it can be approved and paid.
+func (s *ThreeWayMatchService) ResolveException(ctx context.Context, id uuid.ID, notes, resolvedBy string) (*entities.MatchException, error) {
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return nil, fmt.Errorf("%w: resolution notes are required", entities.ErrInvalidInvoiceMatch)
+	}
+	exception, err := s.repo.GetException(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if exception.Status != entities.MatchExceptionOpen {
+		return nil, entities.ErrMatchExceptionResolved
+	}
+	now := time.Now()
+	exception.Status = entities.MatchExceptionResolved
+	exception.ResolutionNotes = notes
+	exception.ResolvedBy = &resolvedBy
+	exception.ResolvedAt = &now
+	if err := s.repo.ResolveException(ctx, exception); err != nil {
+		return nil, err
+	}
+	return exception, nil
+}
+
+// CheckVoucher returns ErrMatchExceptionsUnresolved while the voucher has open match
+// exceptions.
+func (s *ThreeWayMatchService) CheckVoucher(ctx context.Context, voucherID uuid.ID) error {
+	open, err := s.repo.CountOpenExceptions(ctx, voucherID)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return fmt.Errorf("%w: %d open", entities.ErrMatchExceptionsUnresolved, open)
+	}
+	return nil
+}
+
+// CheckPayable returns ErrMatchExceptionsUnresolved while open match exceptions hold back
+// the payable.
+func (s *ThreeWayMatchService) CheckPayable(ctx context.Context, payableID uuid.ID) error {
+	blocked, err := s.repo.IsPayableBlocked(ctx, payableID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return entities.ErrMatchExceptionsUnresolved
+	}
+	return nil
+}
+
+// GRIRReport reports the GR/IR clearing account as of a date, today by default: goods
+// received but not invoiced and goods invoiced but not received.
+func (s *ThreeWayMatchService) GRIRReport(ctx context.Context, filter repositories.GRIRFilter) (*entities.GRIRReport, error) {
+	if filter.AsOf.IsZero() {
+		filter.AsOf = time.Now()
+	}
+	filter.AsOf = time.Date(filter.AsOf.Year(), filter.AsOf.Month(), filter.AsOf.Day(), 0, 0, 0, 0, time.UTC)
+	lines, err := s.repo.GetGRIRLines(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return entities.NewGRIRReport(filter.AsOf, lines), nil
+}
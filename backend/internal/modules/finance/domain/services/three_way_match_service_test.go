@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// MockThreeWayMatchRepository is a mock implementation of repositories.ThreeWayMatchRepository.
+type MockThreeWayMatchRepository struct {
+	mock.Mock
+}
+
+func (m *MockThreeWayMatchRepository) GetTolerance(ctx context.Context, companyID *uuid.ID) (*entities.MatchTolerance, error) {
+	args := m.Called(ctx, companyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.MatchTolerance), args.Error(1)
+}
+
+func (m *MockThreeWayMatchRepository) SaveTolerance(ctx context.Context, tolerance *entities.MatchTolerance) error {
+	args := m.Called(ctx, tolerance)
+	return args.Error(0)
+}
+
+func (m *MockThreeWayMatchRepository) GetVoucherMatch(ctx context.Context, voucherID uuid.ID) (*entities.VoucherMatch, error) {
+	args := m.Called(ctx, voucherID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.VoucherMatch), args.Error(1)
+}
+
+func (m *MockThreeWayMatchRepository) GetPurchaseOrderPosition(ctx context.Context, purchaseOrderID, excludeVoucherID uuid.ID) (*entities.PurchaseOrderPosition, error) {
+	args := m.Called(ctx, purchaseOrderID, excludeVoucherID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseOrderPosition), args.Error(1)
+}
+
+func (m *MockThreeWayMatchRepository) SaveMatch(ctx context.Context, match *entities.VoucherMatch) error {
+	args := m.Called(ctx, match)
+	return args.Error(0)
+}
+
+func (m *MockThreeWayMatchRepository) GetException(ctx context.Context, id uuid.ID) (*entities.MatchException, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.MatchException), args.Error(1)
+}
+
+func (m *MockThreeWayMatchRepository) ListExceptions(ctx context.Context, filter repositories.MatchExceptionFilter) ([]*entities.MatchException, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.MatchException), args.Error(1)
+}
+
+func (m *MockThreeWayMatchRepository) ResolveException(ctx context.Context, exception *entities.MatchException) error {
+	args := m.Called(ctx, exception)
+	return args.Error(0)
+}
+
+func (m *MockThreeWayMatchRepository) CountOpenExceptions(ctx context.Context, voucherID uuid.ID) (int, error) {
+	args := m.Called(ctx, voucherID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockThreeWayMatchRepository) IsPayableBlocked(ctx context.Context, payableID uuid.ID) (bool, error) {
+	args := m.Called(ctx, payableID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockThreeWayMatchRepository) GetGRIRLines(ctx context.Context, filter repositories.GRIRFilter) ([]*entities.GRIRLine, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.GRIRLine), args.Error(1)
+}
+
+// newFabricOrder builds a voucher of a supplier against a PO for 100 m of fabric at 50,000
+// of which 60 m were received, and 200 buttons at 500 all received.
+func newFabricOrder() (*entities.VoucherMatch, *entities.PurchaseOrderPosition) {
+	supplierID := uuid.New()
+	voucher := &entities.VoucherMatch{PurchaseVoucherID: uuid.New(), VoucherNumber: "PV-0001", SupplierID: supplierID,
+		Status: "pending", MatchStatus: entities.MatchStatusUnmatched}
+	order := &entities.PurchaseOrderPosition{
+		PurchaseOrderID: uuid.New(), PONumber: "PO-20250301-0001", SupplierID: supplierID, Status: "received",
+		Items: []*entities.POItemPosition{
+			{POItemID: uuid.New(), ItemName: "Fabric", OrderedQuantity: 100, UnitPrice: 50000, ReceivedQuantity: 60},
+			{POItemID: uuid.New(), ItemName: "Buttons", OrderedQuantity: 200, UnitPrice: 500, ReceivedQuantity: 200},
+		},
+	}
+	return voucher, order
+}
+
+func matchRequest(voucher *entities.VoucherMatch, order *entities.PurchaseOrderPosition, lines ...InvoiceMatchLineInput) *InvoiceMatchRequest {
+	return &InvoiceMatchRequest{
+		PurchaseVoucherID: voucher.PurchaseVoucherID,
+		PurchaseOrderID:   order.PurchaseOrderID,
+		Lines:             lines,
+	}
+}
+
+func TestMatchInvoice_PartialReceiptsAndInvoices(t *testing.T) {
+	repo := new(MockThreeWayMatchRepository)
+	svc := NewThreeWayMatchService(repo)
+	ctx := context.Background()
+	voucher, order := newFabricOrder()
+	fabric, buttons := order.Items[0], order.Items[1]
+
+	repo.On("GetVoucherMatch", ctx, voucher.PurchaseVoucherID).Return(voucher, nil).Times(3)
+	repo.On("GetPurchaseOrderPosition", ctx, order.PurchaseOrderID, voucher.PurchaseVoucherID).Return(order, nil).Times(3)
+	repo.On("GetTolerance", ctx, (*uuid.ID)(nil)).Return(&entities.MatchTolerance{}, nil).Times(3)
+	repo.On("SaveMatch", ctx, voucher).Return(nil).Times(3)
+	repo.On("CountOpenExceptions", ctx, voucher.PurchaseVoucherID).Return(0, nil).Once()
+
+	// Invoicing the 60 m received so far matches; the other 40 m are still on order
+	match, err := svc.MatchInvoice(ctx, matchRequest(voucher, order,
+		InvoiceMatchLineInput{POItemID: fabric.POItemID, Quantity: 60, UnitPrice: 50000},
+		InvoiceMatchLineInput{POItemID: buttons.POItemID, Quantity: 120, UnitPrice: 500},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, entities.MatchStatusMatched, match.MatchStatus)
+	assert.Empty(t, match.Exceptions)
+	require.Len(t, match.Lines, 2)
+	assert.Equal(t, 3000000.0, match.Lines[0].LineTotal)
+	assert.Equal(t, 60, match.Lines[0].ReceivedQuantity)
+	assert.Equal(t, "PO-20250301-0001", match.PONumber)
+	require.NoError(t, svc.CheckVoucher(ctx, match.PurchaseVoucherID))
+
+	// The rest of the buttons on a second invoice, after 120 were invoiced on the first
+	buttons.InvoicedQuantity = 120
+	match, err = svc.MatchInvoice(ctx, matchRequest(voucher, order,
+		InvoiceMatchLineInput{POItemID: buttons.POItemID, Quantity: 80, UnitPrice: 500},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, entities.MatchStatusMatched, match.MatchStatus)
+	assert.Equal(t, 120, match.Lines[0].PreviouslyInvoiced)
+
+	// One more button is more than was received
+	match, err = svc.MatchInvoice(ctx, matchRequest(voucher, order,
+		InvoiceMatchLineInput{POItemID: buttons.POItemID, Quantity: 81, UnitPrice: 500},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, entities.MatchStatusException, match.MatchStatus)
+	require.Len(t, match.Exceptions, 1)
+	assert.Equal(t, entities.MatchExceptionQuantity, match.Exceptions[0].ExceptionType)
+	assert.Equal(t, 80.0, match.Exceptions[0].ExpectedValue)
+	assert.Equal(t, 500.0, match.Exceptions[0].VarianceAmount)
+	repo.AssertExpectations(t)
+}
+
+func TestMatchInvoice_AppliesTheCompanyTolerances(t *testing.T) {
+	repo := new(MockThreeWayMatchRepository)
+	svc := NewThreeWayMatchService(repo)
+	ctx := context.Background()
+	voucher, order := newFabricOrder()
+	fabric := order.Items[0]
+	companyID := uuid.New()
+
+	repo.On("GetVoucherMatch", ctx, voucher.PurchaseVoucherID).Return(voucher, nil).Times(3)
+	repo.On("GetPurchaseOrderPosition", ctx, order.PurchaseOrderID, voucher.PurchaseVoucherID).Return(order, nil).Times(3)
+	repo.On("GetTolerance", ctx, (*uuid.ID)(nil)).Return(&entities.MatchTolerance{}, nil).Once()
+	repo.On("GetTolerance", ctx, &companyID).
+		Return(&entities.MatchTolerance{CompanyID: &companyID, QuantityTolerance: 5, PriceTolerance: 2}, nil).Twice()
+	repo.On("SaveMatch", ctx, voucher).Return(nil).Times(3)
+
+	// Under the default of no tolerance 63 m at 51,000 is off in quantity and price
+	match, err := svc.MatchInvoice(ctx, matchRequest(voucher, order,
+		InvoiceMatchLineInput{POItemID: fabric.POItemID, Quantity: 63, UnitPrice: 51000},
+	))
+	require.NoError(t, err)
+	require.Len(t, match.Exceptions, 2)
+	assert.Equal(t, entities.MatchExceptionQuantity, match.Exceptions[0].ExceptionType)
+	assert.Equal(t, 153000.0, match.Exceptions[0].VarianceAmount)
+	price := match.Exceptions[1]
+	assert.Equal(t, entities.MatchExceptionPrice, price.ExceptionType)
+	assert.Equal(t, 50000.0, price.ExpectedValue)
+	assert.Equal(t, 51000.0, price.ActualValue)
+	assert.Equal(t, 63000.0, price.VarianceAmount)
+
+	// It is within 5% of the 60 m received and 2% of the PO price of the company
+	req := matchRequest(voucher, order, InvoiceMatchLineInput{POItemID: fabric.POItemID, Quantity: 63, UnitPrice: 51000})
+	req.CompanyID = &companyID
+	match, err = svc.MatchInvoice(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, entities.MatchStatusMatched, match.MatchStatus)
+	assert.Empty(t, match.Exceptions)
+	assert.Equal(t, 2.0, match.Tolerance.PriceTolerance)
+
+	// The voucher keeps the company it was matched for
+	match, err = svc.MatchInvoice(ctx, matchRequest(voucher, order,
+		InvoiceMatchLineInput{POItemID: fabric.POItemID, Quantity: 61, UnitPrice: 52000},
+	))
+	require.NoError(t, err)
+	require.Len(t, match.Exceptions, 1)
+	assert.Equal(t, entities.MatchExceptionPrice, match.Exceptions[0].ExceptionType)
+	repo.AssertExpectations(t)
+}
+
+func TestResolveException_UnblocksTheVoucher(t *testing.T) {
+	repo := new(MockThreeWayMatchRepository)
+	svc := NewThreeWayMatchService(repo)
+	ctx := context.Background()
+	voucher, order := newFabricOrder()
+
+	repo.On("GetVoucherMatch", ctx, voucher.PurchaseVoucherID).Return(voucher, nil).Once()
+	repo.On("GetPurchaseOrderPosition", ctx, order.PurchaseOrderID, voucher.PurchaseVoucherID).Return(order, nil).Once()
+	repo.On("GetTolerance", ctx, (*uuid.ID)(nil)).Return(&entities.MatchTolerance{}, nil).Once()
+	repo.On("SaveMatch", ctx, voucher).Return(nil).Once()
+	repo.On("CountOpenExceptions", ctx, voucher.PurchaseVoucherID).Return(1, nil).Once()
+
+	match, err := svc.MatchInvoice(ctx, matchRequest(voucher, order,
+		InvoiceMatchLineInput{POItemID: order.Items[0].POItemID, Quantity: 60, UnitPrice: 55000},
+	))
+	require.NoError(t, err)
+	require.Len(t, match.Exceptions, 1)
+	assert.ErrorIs(t, svc.CheckVoucher(ctx, match.PurchaseVoucherID), entities.ErrMatchExceptionsUnresolved)
+
+	exceptionID := match.Exceptions[0].ID
+	_, err = svc.ResolveException(ctx, exceptionID, " ", "finance-manager")
+	assert.ErrorIs(t, err, entities.ErrInvalidInvoiceMatch)
+
+	open := *match.Exceptions[0]
+	repo.On("GetException", ctx, exceptionID).Return(&open, nil).Once()
+	repo.On("ResolveException", ctx, mock.MatchedBy(func(e *entities.MatchException) bool {
+		return e.ID == exceptionID && e.Status == entities.MatchExceptionResolved &&
+			e.ResolutionNotes == "Price increase agreed by purchasing"
+	})).Return(nil).Once()
+	repo.On("CountOpenExceptions", ctx, voucher.PurchaseVoucherID).Return(0, nil).Once()
+	resolved, err := svc.ResolveException(ctx, exceptionID, "Price increase agreed by purchasing", "finance-manager")
+	require.NoError(t, err)
+	assert.Equal(t, entities.MatchExceptionResolved, resolved.Status)
+	assert.Equal(t, "finance-manager", *resolved.ResolvedBy)
+	assert.NoError(t, svc.CheckVoucher(ctx, match.PurchaseVoucherID))
+
+	repo.On("GetException", ctx, exceptionID).Return(resolved, nil).Once()
+	_, err = svc.ResolveException(ctx, exceptionID, "again", "finance-manager")
+	assert.ErrorIs(t, err, entities.ErrMatchExceptionResolved)
+	repo.AssertExpectations(t)
+}
+
+func TestMatchInvoice_RejectsInvalidMatches(t *testing.T) {
+	repo := new(MockThreeWayMatchRepository)
+	svc := NewThreeWayMatchService(repo)
+	ctx := context.Background()
+	voucher, order := newFabricOrder()
+	fabric := InvoiceMatchLineInput{POItemID: order.Items[0].POItemID, Quantity: 10, UnitPrice: 50000}
+
+	repo.On("GetVoucherMatch", ctx, voucher.PurchaseVoucherID).Return(voucher, nil).Times(4)
+	repo.On("GetPurchaseOrderPosition", ctx, order.PurchaseOrderID, voucher.PurchaseVoucherID).Return(order, nil).Times(3)
+	repo.On("GetTolerance", ctx, (*uuid.ID)(nil)).Return(&entities.MatchTolerance{}, nil).Twice()
+
+	_, err := svc.MatchInvoice(ctx, matchRequest(voucher, order, InvoiceMatchLineInput{POItemID: uuid.New(), Quantity: 1, UnitPrice: 1}))
+	assert.ErrorIs(t, err, entities.ErrInvalidInvoiceMatch)
+
+	_, err = svc.MatchInvoice(ctx, matchRequest(voucher, order, fabric, fabric))
+	assert.ErrorIs(t, err, entities.ErrInvalidInvoiceMatch)
+
+	order.SupplierID = uuid.New()
+	_, err = svc.MatchInvoice(ctx, matchRequest(voucher, order, fabric))
+	assert.ErrorIs(t, err, entities.ErrInvalidInvoiceMatch)
+
+	order.SupplierID = voucher.SupplierID
+	voucher.Status = "approved"
+	_, err = svc.MatchInvoice(ctx, matchRequest(voucher, order, fabric))
+	assert.ErrorIs(t, err, entities.ErrInvalidInvoiceMatch)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "SaveMatch", mock.Anything, mock.Anything)
+}
+
+func TestCheckPayable_HeldBackByOpenExceptions(t *testing.T) {
+	repo := new(MockThreeWayMatchRepository)
+	svc := NewThreeWayMatchService(repo)
+	ctx := context.Background()
+	blocked, clear := uuid.New(), uuid.New()
+
+	repo.On("IsPayableBlocked", ctx, blocked).Return(true, nil).Once()
+	repo.On("IsPayableBlocked", ctx, clear).Return(false, nil).Once()
+
+	assert.ErrorIs(t, svc.CheckPayable(ctx, blocked), entities.ErrMatchExceptionsUnresolved)
+	assert.NoError(t, svc.CheckPayable(ctx, clear))
+	repo.AssertExpectations(t)
+}
+
+func TestGRIRReport_SplitsReceivedAndInvoiced(t *testing.T) {
+	repo := new(MockThreeWayMatchRepository)
+	svc := NewThreeWayMatchService(repo)
+	asOf := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	repo.On("GetGRIRLines", mock.Anything, repositories.GRIRFilter{AsOf: asOf}).Return([]*entities.GRIRLine{
+		{ItemName: "Fabric", ReceivedQuantity: 60, InvoicedQuantity: 40, ReceivedAmount: 3000000, InvoicedAmount: 2000000},
+		{ItemName: "Buttons", ReceivedQuantity: 200, InvoicedQuantity: 200, ReceivedAmount: 100000, InvoicedAmount: 100000},
+		{ItemName: "Zippers", ReceivedQuantity: 0, InvoicedQuantity: 50, ReceivedAmount: 0, InvoicedAmount: 75000},
+	}, nil).Once()
+
+	report, err := svc.GRIRReport(context.Background(), repositories.GRIRFilter{AsOf: time.Date(2025, 3, 31, 15, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.Equal(t, asOf, report.AsOf)
+	require.Len(t, report.ReceivedNotInvoiced, 1)
+	assert.Equal(t, "Fabric", report.ReceivedNotInvoiced[0].ItemName)
+	assert.Equal(t, 1000000.0, report.TotalReceivedNotInvoiced)
+	require.Len(t, report.InvoicedNotReceived, 1)
+	assert.Equal(t, -75000.0, report.InvoicedNotReceived[0].Balance)
+	assert.Equal(t, 75000.0, report.TotalInvoicedNotReceived)
+	assert.Equal(t, 925000.0, report.Balance)
+	repo.AssertExpectations(t)
+}
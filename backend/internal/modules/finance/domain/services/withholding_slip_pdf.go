@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/shared/pdf"
+)
+
+// Column widths in characters of the withholding slip amounts table.
+const (
+	slipObjectCodeWidth = 12
+	slipAmountWidth     = 18
+	slipRateWidth       = 8
+)
+
+// withholdingArticleTitles are the slip titles of the tax articles.
+var withholdingArticleTitles = map[string]string{
+	entities.WithholdingPPh23: "BUKTI PEMOTONGAN PPh PASAL 23",
+	entities.WithholdingPPh42: "BUKTI PEMOTONGAN PPh PASAL 4 AYAT 2",
+}
+
+// renderWithholdingSlipPDF lays out a withholding slip (bukti potong) as a PDF document
+// for the supplier.
+func renderWithholdingSlipPDF(slip *entities.WithholdingSlip, payerTaxID string) []byte {
+	doc := pdf.New()
+	doc.Title(withholdingArticleTitles[slip.TaxArticle])
+	doc.Space(6)
+	doc.Text("Number: " + slip.SlipNumber)
+	doc.Text(fmt.Sprintf("Tax period: %02d/%d", slip.TaxPeriod, slip.TaxYear))
+	doc.Text("Withholding date: " + slip.SlipDate.Format("2 January 2006"))
+	doc.Space(8)
+
+	doc.Bold("Income recipient")
+	doc.Text(slip.SupplierName)
+	if slip.SupplierTaxID != "" {
+		doc.Text("NPWP: " + slip.SupplierTaxID)
+	} else {
+		doc.Text("NPWP: - (rate raised for recipients without an NPWP)")
+	}
+	if slip.SupplierAddress != "" {
+		for _, line := range strings.Split(slip.SupplierAddress, "\n") {
+			doc.Text(line)
+		}
+	}
+	doc.Space(8)
+
+	descriptionWidth := pdf.RowWidth() - slipObjectCodeWidth - 2*slipAmountWidth - slipRateWidth - 3
+	row := func(bold bool, code, description, gross, rate, withheld string) {
+		doc.Row(bold,
+			pdf.Column{Text: code, Width: slipObjectCodeWidth},
+			pdf.Column{Text: description, Width: descriptionWidth},
+			pdf.Column{Text: gross, Width: slipAmountWidth, Right: true},
+			pdf.Column{Text: rate, Width: slipRateWidth, Right: true},
+			pdf.Column{Text: withheld, Width: slipAmountWidth, Right: true},
+		)
+	}
+	doc.Rule()
+	row(true, "Object code", "Document", "Gross income", "Rate", "Tax withheld")
+	doc.Rule()
+	document := slip.DocumentNumber
+	if slip.DocumentDate != nil {
+		document += " (" + slip.DocumentDate.Format("02/01/2006") + ")"
+	}
+	row(false, slip.TaxObjectCode, document, formatAmount(slip.GrossAmount),
+		formatRate(slip.Rate)+"%", formatAmount(slip.WithheldAmount))
+	doc.Rule()
+	doc.Space(12)
+
+	doc.Bold("Withholder")
+	if payerTaxID != "" {
+		doc.Text("NPWP: " + payerTaxID)
+	}
+	return doc.Bytes()
+}
+
+// formatAmount formats an amount the Indonesian way, e.g. 1.250.000,00.
+func formatAmount(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	whole := strconv.FormatInt(cents/100, 10)
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(digit)
+	}
+	return fmt.Sprintf("%s%s,%02d", sign, b.String(), cents%100)
+}
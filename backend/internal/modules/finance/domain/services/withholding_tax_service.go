@@ -0,0 +1,339 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	accounting_services "malaka/internal/modules/accounting/domain/services"
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// Journals posted for supplier payments through the auto-journal account mappings: the
+// payable is debited by the gross amount, cash is credited by the net amount and the
+// withholding tax payable by the tax withheld.
+const (
+	withholdingJournalSource  = "FINANCE"
+	withholdingJournalPayment = "SUPPLIER_PAYMENT"
+)
+
+// JournalPoster posts journal entries through the accounting auto-journal mappings.
+type JournalPoster interface {
+	CreateJournalFromTransaction(ctx context.Context, req *accounting_services.AutoJournalRequest) (*accounting_entities.JournalEntry, error)
+}
+
+// SupplierPaymentRequest is a payment of a payable. Without an amount the whole balance
+// is paid; with a service type the tax of its withholding rule is withheld.
+type SupplierPaymentRequest struct {
+	AccountsPayableID uuid.ID
+	CashBankID        uuid.ID
+	PaymentDate       time.Time
+	PaymentMethod     string
+	Amount            float64
+	ServiceType       string
+	CreatedBy         string
+}
+
+// WithholdingTaxService provides business logic for withholding tax on purchases: the
+// rules by supplier and service type, supplier payments net of the tax withheld and the
+// withholding slips (bukti potong) reported in e-Bupot.
+type WithholdingTaxService struct {
+	repo       repositories.WithholdingTaxRepository
+	journals   JournalPoster
+	payerTaxID string
+}
+
+// NewWithholdingTaxService creates a new WithholdingTaxService; payerTaxID is the NPWP
+// of the company, the withholder on the slips.
+func NewWithholdingTaxService(repo repositories.WithholdingTaxRepository, payerTaxID string) *WithholdingTaxService {
+	return &WithholdingTaxService{repo: repo, payerTaxID: npwpDigits(payerTaxID)}
+}
+
+// SetJournalPoster sets where supplier payment journals are posted.
+func (s *WithholdingTaxService) SetJournalPoster(journals JournalPoster) {
+	s.journals = journals
+}
+
+// CreateRule creates a withholding rule.
+func (s *WithholdingTaxService) CreateRule(ctx context.Context, rule *entities.WithholdingRule) error {
+	rule.ServiceType = strings.TrimSpace(rule.ServiceType)
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if rule.ID.IsNil() {
+		rule.ID = uuid.New()
+	}
+	if rule.SupplierID != nil && rule.SupplierID.IsNil() {
+		rule.SupplierID = nil
+	}
+	now := time.Now()
+	rule.IsActive = true
+	rule.CreatedAt, rule.UpdatedAt = now, now
+	return s.repo.CreateRule(ctx, rule)
+}
+
+// UpdateRule changes the tax article, object code, rate, description and active flag of
+// a withholding rule. Slips issued earlier keep the rate they were issued at.
+func (s *WithholdingTaxService) UpdateRule(ctx context.Context, id uuid.ID, update *entities.WithholdingRule) (*entities.WithholdingRule, error) {
+	rule, err := s.repo.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rule.TaxArticle = update.TaxArticle
+	rule.TaxObjectCode = update.TaxObjectCode
+	rule.Rate = update.Rate
+	rule.Description = update.Description
+	rule.IsActive = update.IsActive
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	rule.UpdatedAt = time.Now()
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// ListRules lists the withholding rules, only the active ones when asked.
+func (s *WithholdingTaxService) ListRules(ctx context.Context, activeOnly bool) ([]*entities.WithholdingRule, error) {
+	return s.repo.ListRules(ctx, activeOnly)
+}
+
+// PaySupplier pays a payable. The payable is settled by the gross amount; with a service
+// type the tax of the supplier's rule is withheld, the supplier is paid the net amount and
+// a withholding slip is issued for the month of the payment.
+func (s *WithholdingTaxService) PaySupplier(ctx context.Context, req *SupplierPaymentRequest) (*entities.SupplierPayment, error) {
+	if req.CashBankID.IsNil() {
+		return nil, fmt.Errorf("%w: cash/bank account is required", entities.ErrInvalidSupplierPayment)
+	}
+	payable, err := s.repo.GetPayable(ctx, req.AccountsPayableID)
+	if err != nil {
+		return nil, err
+	}
+	if payable.Status == "paid" || payable.Balance <= 0 {
+		return nil, fmt.Errorf("%w: the payable is already paid", entities.ErrInvalidSupplierPayment)
+	}
+	if payable.MatchBlocked {
+		return nil, entities.ErrMatchExceptionsUnresolved
+	}
+	gross := req.Amount
+	if gross == 0 {
+		gross = payable.Balance
+	}
+	gross = math.Round(gross*100) / 100
+	if gross <= 0 || gross > payable.Balance+0.005 {
+		return nil, fmt.Errorf("%w: amount must be above 0 and at most the balance of %.2f", entities.ErrInvalidSupplierPayment, payable.Balance)
+	}
+	paymentDate := req.PaymentDate
+	if paymentDate.IsZero() {
+		paymentDate = time.Now()
+	}
+	paymentDate = time.Date(paymentDate.Year(), paymentDate.Month(), paymentDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	payment := &entities.SupplierPayment{
+		PaymentID:         uuid.New(),
+		AccountsPayableID: payable.ID,
+		InvoiceID:         payable.InvoiceID,
+		SupplierID:        payable.SupplierID,
+		CashBankID:        req.CashBankID,
+		PaymentDate:       paymentDate,
+		PaymentMethod:     req.PaymentMethod,
+		ServiceType:       strings.TrimSpace(req.ServiceType),
+		GrossAmount:       gross,
+		CreatedBy:         req.CreatedBy,
+	}
+	if payment.ServiceType != "" {
+		rule, err := s.repo.FindRule(ctx, payable.SupplierID, payment.ServiceType)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("%w: none for service type %q", entities.ErrWithholdingRuleNotFound, payment.ServiceType)
+		}
+		payment.Slip = s.newSlip(payable, payment, rule)
+		if payment.Slip != nil {
+			payment.WithheldAmount = payment.Slip.WithheldAmount
+		}
+	}
+	payment.NetAmount = math.Round((gross-payment.WithheldAmount)*100) / 100
+
+	if err := s.repo.RecordSupplierPayment(ctx, payment); err != nil {
+		return nil, err
+	}
+	s.postJournal(ctx, payment, payable)
+	return payment, nil
+}
+
+// newSlip builds the withholding slip of a payment under a rule, nil when nothing is
+// withheld. The slip is numbered when the payment is recorded.
+func (s *WithholdingTaxService) newSlip(payable *entities.SupplierPayable, payment *entities.SupplierPayment, rule *entities.WithholdingRule) *entities.WithholdingSlip {
+	supplierTaxID := npwpDigits(payable.SupplierTaxID)
+	rate := rule.EffectiveRate(supplierTaxID != "")
+	withheld := entities.WithheldAmount(payment.GrossAmount, rate)
+	if withheld <= 0 {
+		return nil
+	}
+	slip := &entities.WithholdingSlip{
+		ID:                uuid.New(),
+		TaxArticle:        rule.TaxArticle,
+		TaxYear:           payment.PaymentDate.Year(),
+		TaxPeriod:         int(payment.PaymentDate.Month()),
+		TaxObjectCode:     rule.TaxObjectCode,
+		WithholdingRuleID: &rule.ID,
+		SupplierName:      payable.SupplierName,
+		SupplierTaxID:     supplierTaxID,
+		SupplierAddress:   payable.SupplierAddress,
+		PaymentID:         payment.PaymentID,
+		AccountsPayableID: &payment.AccountsPayableID,
+		DocumentNumber:    payable.InvoiceNumber,
+		SlipDate:          payment.PaymentDate,
+		GrossAmount:       payment.GrossAmount,
+		Rate:              rate,
+		WithheldAmount:    withheld,
+		CreatedBy:         payment.CreatedBy,
+		CreatedAt:         time.Now(),
+	}
+	if !payable.SupplierID.IsNil() {
+		supplierID := payable.SupplierID
+		slip.SupplierID = &supplierID
+	}
+	if !payable.InvoiceDate.IsZero() {
+		documentDate := payable.InvoiceDate
+		slip.DocumentDate = &documentDate
+	}
+	return slip
+}
+
+// postJournal posts the journal of a supplier payment. A failure is logged and does not
+// undo the payment, which can be journalled by hand.
+func (s *WithholdingTaxService) postJournal(ctx context.Context, payment *entities.SupplierPayment, payable *entities.SupplierPayable) {
+	if s.journals == nil {
+		return
+	}
+	reference := payable.InvoiceNumber
+	description := "Payment to " + payable.SupplierName
+	if payment.Slip != nil {
+		reference = payment.Slip.SlipNumber
+		description += fmt.Sprintf(" less %s withheld", payment.Slip.TaxArticle)
+	}
+	req := &accounting_services.AutoJournalRequest{
+		SourceModule:    withholdingJournalSource,
+		SourceID:        payment.PaymentID.String(),
+		TransactionType: withholdingJournalPayment,
+		TransactionDate: payment.PaymentDate,
+		CompanyID:       "1", // Default company
+		CurrencyCode:    "IDR",
+		ExchangeRate:    1.0,
+		Description:     description,
+		Reference:       reference,
+		TransactionData: map[string]interface{}{
+			"payable_amount":             payment.GrossAmount,
+			"cash_amount":                payment.NetAmount,
+			"withholding_payable_amount": payment.WithheldAmount,
+		},
+		CreatedBy: payment.CreatedBy,
+		AutoPost:  true,
+	}
+	if _, err := s.journals.CreateJournalFromTransaction(ctx, req); err != nil {
+		log.Printf("[Finance] Failed to post %s journal for payment %s: %v", withholdingJournalPayment, payment.PaymentID, err)
+	}
+}
+
+// GetSlip retrieves a withholding slip by its ID.
+func (s *WithholdingTaxService) GetSlip(ctx context.Context, id uuid.ID) (*entities.WithholdingSlip, error) {
+	return s.repo.GetSlip(ctx, id)
+}
+
+// ListSlips lists the withholding slips of a filter.
+func (s *WithholdingTaxService) ListSlips(ctx context.Context, filter repositories.WithholdingSlipFilter) ([]*entities.WithholdingSlip, error) {
+	return s.repo.ListSlips(ctx, filter)
+}
+
+// SlipPDF renders a withholding slip as a PDF for the supplier.
+func (s *WithholdingTaxService) SlipPDF(ctx context.Context, id uuid.ID) (*entities.WithholdingSlip, []byte, error) {
+	slip, err := s.repo.GetSlip(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return slip, renderWithholdingSlipPDF(slip, s.payerTaxID), nil
+}
+
+// ExportEBupotCSV exports the slips of a month as an e-Bupot import CSV.
+func (s *WithholdingTaxService) ExportEBupotCSV(ctx context.Context, year, period int) ([]byte, error) {
+	slips, err := s.monthSlips(ctx, year, period)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := WriteEBupotCSV(&buf, slips); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportEBupotXML exports the slips of a month as a Coretax e-Bupot (BPPU) import XML.
+func (s *WithholdingTaxService) ExportEBupotXML(ctx context.Context, year, period int) ([]byte, error) {
+	slips, err := s.monthSlips(ctx, year, period)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := WriteEBupotXML(&buf, s.payerTaxID, slips); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *WithholdingTaxService) monthSlips(ctx context.Context, year, period int) ([]*entities.WithholdingSlip, error) {
+	if year <= 0 || period < 1 || period > 12 {
+		return nil, fmt.Errorf("%w: a tax year and a period of 1 to 12 are required", entities.ErrInvalidSupplierPayment)
+	}
+	return s.repo.ListSlips(ctx, repositories.WithholdingSlipFilter{TaxYear: year, TaxPeriod: period})
+}
+
+// MonthlySummary totals the slips of a month per tax article and object code for the
+// withholding tax return of the month.
+func (s *WithholdingTaxService) MonthlySummary(ctx context.Context, year, period int) (*entities.WithholdingMonth, error) {
+	if year <= 0 || period < 1 || period > 12 {
+		return nil, fmt.Errorf("%w: a tax year and a period of 1 to 12 are required", entities.ErrInvalidSupplierPayment)
+	}
+	lines, err := s.repo.SummarizeSlips(ctx, year, period)
+	if err != nil {
+		return nil, err
+	}
+	month := &entities.WithholdingMonth{
+		TaxYear:        year,
+		TaxPeriod:      period,
+		Lines:          lines,
+		TotalByArticle: map[string]float64{},
+	}
+	for _, line := range lines {
+		month.TotalByArticle[line.TaxArticle] += line.WithheldAmount
+		month.GrossAmount += line.GrossAmount
+		month.WithheldAmount += line.WithheldAmount
+		month.SlipCount += line.SlipCount
+	}
+	return month, nil
+}
+
+// npwpDigits returns the digits of an NPWP, empty when it is not a 15 or 16 digit NPWP
+// or is all zeros.
+func npwpDigits(taxID string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, taxID)
+	if (len(digits) != 15 && len(digits) != 16) || strings.Trim(digits, "0") == "" {
+		return ""
+	}
+	return digits
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	accounting_services "malaka/internal/modules/accounting/domain/services"
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// MockWithholdingTaxRepository is a mock implementation of repositories.WithholdingTaxRepository.
+type MockWithholdingTaxRepository struct {
+	mock.Mock
+}
+
+func (m *MockWithholdingTaxRepository) CreateRule(ctx context.Context, rule *entities.WithholdingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockWithholdingTaxRepository) UpdateRule(ctx context.Context, rule *entities.WithholdingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockWithholdingTaxRepository) GetRule(ctx context.Context, id uuid.ID) (*entities.WithholdingRule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.WithholdingRule), args.Error(1)
+}
+
+func (m *MockWithholdingTaxRepository) ListRules(ctx context.Context, activeOnly bool) ([]*entities.WithholdingRule, error) {
+	args := m.Called(ctx, activeOnly)
+	return args.Get(0).([]*entities.WithholdingRule), args.Error(1)
+}
+
+func (m *MockWithholdingTaxRepository) FindRule(ctx context.Context, supplierID uuid.ID, serviceType string) (*entities.WithholdingRule, error) {
+	args := m.Called(ctx, supplierID, serviceType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.WithholdingRule), args.Error(1)
+}
+
+func (m *MockWithholdingTaxRepository) GetPayable(ctx context.Context, id uuid.ID) (*entities.SupplierPayable, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SupplierPayable), args.Error(1)
+}
+
+func (m *MockWithholdingTaxRepository) RecordSupplierPayment(ctx context.Context, payment *entities.SupplierPayment) error {
+	args := m.Called(ctx, payment)
+	return args.Error(0)
+}
+
+func (m *MockWithholdingTaxRepository) GetSlip(ctx context.Context, id uuid.ID) (*entities.WithholdingSlip, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.WithholdingSlip), args.Error(1)
+}
+
+func (m *MockWithholdingTaxRepository) ListSlips(ctx context.Context, filter repositories.WithholdingSlipFilter) ([]*entities.WithholdingSlip, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.WithholdingSlip), args.Error(1)
+}
+
+func (m *MockWithholdingTaxRepository) SummarizeSlips(ctx context.Context, year, period int) ([]*entities.WithholdingSummary, error) {
+	args := m.Called(ctx, year, period)
+	return args.Get(0).([]*entities.WithholdingSummary), args.Error(1)
+}
+
+// MockJournalPoster is a mock implementation of JournalPoster.
+type MockJournalPoster struct {
+	mock.Mock
+}
+
+func (m *MockJournalPoster) CreateJournalFromTransaction(ctx context.Context, req *accounting_services.AutoJournalRequest) (*accounting_entities.JournalEntry, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*accounting_entities.JournalEntry), args.Error(1)
+}
+
+// requests returns the journal requests posted.
+func (m *MockJournalPoster) requests() []*accounting_services.AutoJournalRequest {
+	var requests []*accounting_services.AutoJournalRequest
+	for _, call := range m.Calls {
+		requests = append(requests, call.Arguments.Get(1).(*accounting_services.AutoJournalRequest))
+	}
+	return requests
+}
+
+// slips returns the withholding slips of the payments recorded.
+func (m *MockWithholdingTaxRepository) slips() []*entities.WithholdingSlip {
+	var slips []*entities.WithholdingSlip
+	for _, call := range m.Calls {
+		if call.Method != "RecordSupplierPayment" {
+			continue
+		}
+		if slip := call.Arguments.Get(1).(*entities.SupplierPayment).Slip; slip != nil {
+			slips = append(slips, slip)
+		}
+	}
+	return slips
+}
+
+func newTestWithholdingTaxService(repo *MockWithholdingTaxRepository, journals *MockJournalPoster) *WithholdingTaxService {
+	service := NewWithholdingTaxService(repo, "01.234.567.8-901.000")
+	service.SetJournalPoster(journals)
+	return service
+}
+
+// testRentalRule withholds 2% PPh 23 on equipment rental.
+func testRentalRule() *entities.WithholdingRule {
+	return &entities.WithholdingRule{ID: uuid.New(), ServiceType: "rental", IsActive: true,
+		TaxArticle: entities.WithholdingPPh23, TaxObjectCode: "24-100-01", Rate: 2}
+}
+
+// testBuildingRentRule withholds 10% final PPh 4(2) on building rent.
+func testBuildingRentRule() *entities.WithholdingRule {
+	return &entities.WithholdingRule{ID: uuid.New(), ServiceType: "building-rent", IsActive: true,
+		TaxArticle: entities.WithholdingPPh42, TaxObjectCode: "28-403-01", Rate: 10}
+}
+
+func testSupplierPayable(supplierID uuid.ID, amount float64, supplierTaxID string) *entities.SupplierPayable {
+	return &entities.SupplierPayable{
+		AccountsPayable: entities.AccountsPayable{
+			BaseModel:  types.BaseModel{ID: uuid.New()},
+			InvoiceID:  uuid.New(),
+			SupplierID: supplierID,
+			Amount:     amount,
+			Balance:    amount,
+			Status:     "open",
+		},
+		InvoiceNumber: "INV-SUP-001",
+		InvoiceDate:   time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC),
+		SupplierName:  "PT Sewa Alat",
+		SupplierTaxID: supplierTaxID,
+	}
+}
+
+// testSupplierPayment asks to pay a payable on 10 March 2025.
+func testSupplierPayment(payableID, cashBankID uuid.ID, amount float64, serviceType string) *SupplierPaymentRequest {
+	return &SupplierPaymentRequest{
+		AccountsPayableID: payableID,
+		CashBankID:        cashBankID,
+		PaymentDate:       time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		PaymentMethod:     "transfer",
+		Amount:            amount,
+		ServiceType:       serviceType,
+		CreatedBy:         "user-1",
+	}
+}
+
+// recordedAgainst settles the payables by the gross amount of the payments recorded and
+// numbers their slips in their article and month, as the database does.
+func recordedAgainst(payables ...*entities.SupplierPayable) func(mock.Arguments) {
+	byID := map[uuid.ID]*entities.SupplierPayable{}
+	for _, p := range payables {
+		byID[p.ID] = p
+	}
+	sequences := map[string]int{}
+	return func(args mock.Arguments) {
+		payment := args.Get(1).(*entities.SupplierPayment)
+		payable := byID[payment.AccountsPayableID]
+		payable.PaidAmount += payment.GrossAmount
+		payable.Balance -= payment.GrossAmount
+		payment.PayableBalance = payable.Balance
+		if slip := payment.Slip; slip != nil {
+			key := fmt.Sprintf("%s/%d/%d", slip.TaxArticle, slip.TaxYear, slip.TaxPeriod)
+			sequences[key]++
+			slip.Sequence = sequences[key]
+			slip.SlipNumber = entities.FormatSlipNumber(slip.TaxArticle, slip.TaxYear, slip.TaxPeriod, slip.Sequence)
+		}
+	}
+}
+
+func TestWithholdingTaxService_PaySupplierWithholdsPPh23(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	rental := testRentalRule()
+	payable := testSupplierPayable(uuid.New(), 10_000_000, "01.111.222.3-444.000")
+
+	repo.On("GetPayable", ctx, payable.ID).Return(payable, nil).Once()
+	repo.On("FindRule", ctx, payable.SupplierID, "rental").Return(rental, nil).Once()
+	repo.On("RecordSupplierPayment", ctx, mock.AnythingOfType("*entities.SupplierPayment")).Return(nil).Once().Run(recordedAgainst(payable))
+	journals.On("CreateJournalFromTransaction", ctx, mock.AnythingOfType("*services.AutoJournalRequest")).Return(&accounting_entities.JournalEntry{}, nil).Once()
+	payment, err := service.PaySupplier(ctx, testSupplierPayment(payable.ID, uuid.New(), 0, "rental"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 10_000_000.0, payment.GrossAmount)
+	assert.Equal(t, 200_000.0, payment.WithheldAmount)
+	assert.Equal(t, 9_800_000.0, payment.NetAmount)
+	assert.Equal(t, 0.0, payment.PayableBalance, "the payable is settled by the gross amount")
+	require.NotNil(t, payment.Slip)
+	assert.Equal(t, "BP23/2025/03/0001", payment.Slip.SlipNumber)
+	assert.Equal(t, "24-100-01", payment.Slip.TaxObjectCode)
+	assert.Equal(t, "011112223444000", payment.Slip.SupplierTaxID)
+	assert.Equal(t, "INV-SUP-001", payment.Slip.DocumentNumber)
+	assert.Equal(t, 3, payment.Slip.TaxPeriod)
+	assert.Equal(t, &rental.ID, payment.Slip.WithholdingRuleID)
+
+	requests := journals.requests()
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, withholdingJournalPayment, req.TransactionType)
+	assert.Equal(t, "BP23/2025/03/0001", req.Reference)
+	assert.Equal(t, 10_000_000.0, req.TransactionData["payable_amount"])
+	assert.Equal(t, 9_800_000.0, req.TransactionData["cash_amount"])
+	assert.Equal(t, 200_000.0, req.TransactionData["withholding_payable_amount"])
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
+
+func TestWithholdingTaxService_PaySupplierWithoutNPWP(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	supplier, cashBank := uuid.New(), uuid.New()
+	noTaxID := testSupplierPayable(supplier, 1_000_000, "")
+	zeroTaxID := testSupplierPayable(supplier, 1_000_000, "000000000000000")
+	record := recordedAgainst(noTaxID, zeroTaxID)
+	repo.On("RecordSupplierPayment", ctx, mock.AnythingOfType("*entities.SupplierPayment")).Return(nil).Twice().Run(record)
+	journals.On("CreateJournalFromTransaction", ctx, mock.AnythingOfType("*services.AutoJournalRequest")).Return(&accounting_entities.JournalEntry{}, nil).Twice()
+
+	repo.On("GetPayable", ctx, noTaxID.ID).Return(noTaxID, nil).Once()
+	repo.On("FindRule", ctx, supplier, "rental").Return(testRentalRule(), nil).Once()
+	pph23, err := service.PaySupplier(ctx, testSupplierPayment(noTaxID.ID, cashBank, 0, "rental"))
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, pph23.Slip.Rate, "PPh 23 is doubled without an NPWP")
+	assert.Equal(t, 40_000.0, pph23.WithheldAmount)
+
+	repo.On("GetPayable", ctx, zeroTaxID.ID).Return(zeroTaxID, nil).Once()
+	repo.On("FindRule", ctx, supplier, "building-rent").Return(testBuildingRentRule(), nil).Once()
+	pph42, err := service.PaySupplier(ctx, testSupplierPayment(zeroTaxID.ID, cashBank, 0, "building-rent"))
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, pph42.Slip.Rate, "PPh 4(2) is final and not raised")
+	assert.Equal(t, "BP42/2025/03/0001", pph42.Slip.SlipNumber)
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
+
+func TestWithholdingTaxService_SupplierRuleOverridesDefault(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	supplier := uuid.New()
+	rule := &entities.WithholdingRule{SupplierID: &supplier,
+		ServiceType: "rental", TaxArticle: entities.WithholdingPPh23, TaxObjectCode: "24-100-02", Rate: 1.5}
+	repo.On("CreateRule", ctx, rule).Return(nil).Once()
+	require.NoError(t, service.CreateRule(ctx, rule))
+
+	payable := testSupplierPayable(supplier, 333_333, "011112223444000")
+	repo.On("GetPayable", ctx, payable.ID).Return(payable, nil).Once()
+	repo.On("FindRule", ctx, supplier, "rental").Return(rule, nil).Once()
+	repo.On("RecordSupplierPayment", ctx, mock.AnythingOfType("*entities.SupplierPayment")).Return(nil).Once().Run(recordedAgainst(payable))
+	journals.On("CreateJournalFromTransaction", ctx, mock.AnythingOfType("*services.AutoJournalRequest")).Return(&accounting_entities.JournalEntry{}, nil).Once()
+	payment, err := service.PaySupplier(ctx, testSupplierPayment(payable.ID, uuid.New(), 0, "rental"))
+	require.NoError(t, err)
+	assert.Equal(t, "24-100-02", payment.Slip.TaxObjectCode)
+	assert.Equal(t, 4_999.0, payment.WithheldAmount, "the tax withheld is rounded down to the rupiah")
+	assert.Equal(t, 328_334.0, payment.NetAmount)
+	assert.Equal(t, &supplier, payment.Slip.SupplierID)
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
+
+func TestWithholdingTaxService_PartialPaymentsNumberSlips(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	cashBank := uuid.New()
+	payable := testSupplierPayable(uuid.New(), 3_000_000, "011112223444000")
+
+	repo.On("GetPayable", ctx, payable.ID).Return(payable, nil).Twice()
+	repo.On("FindRule", ctx, payable.SupplierID, "rental").Return(testRentalRule(), nil).Twice()
+	repo.On("RecordSupplierPayment", ctx, mock.AnythingOfType("*entities.SupplierPayment")).Return(nil).Twice().Run(recordedAgainst(payable))
+	journals.On("CreateJournalFromTransaction", ctx, mock.AnythingOfType("*services.AutoJournalRequest")).Return(&accounting_entities.JournalEntry{}, nil).Twice()
+	first, err := service.PaySupplier(ctx, testSupplierPayment(payable.ID, cashBank, 1_000_000, "rental"))
+	require.NoError(t, err)
+	second, err := service.PaySupplier(ctx, testSupplierPayment(payable.ID, cashBank, 0, "rental"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2_000_000.0, first.PayableBalance)
+	assert.Equal(t, 2_000_000.0, second.GrossAmount, "without an amount the balance is paid")
+	assert.Equal(t, "BP23/2025/03/0002", second.Slip.SlipNumber)
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
+
+func TestWithholdingTaxService_PaySupplierWithoutWithholding(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	payable := testSupplierPayable(uuid.New(), 500_000, "")
+
+	repo.On("GetPayable", ctx, payable.ID).Return(payable, nil).Once()
+	repo.On("RecordSupplierPayment", ctx, mock.AnythingOfType("*entities.SupplierPayment")).Return(nil).Once().Run(recordedAgainst(payable))
+	journals.On("CreateJournalFromTransaction", ctx, mock.AnythingOfType("*services.AutoJournalRequest")).Return(&accounting_entities.JournalEntry{}, nil).Once()
+	payment, err := service.PaySupplier(ctx, testSupplierPayment(payable.ID, uuid.New(), 0, ""))
+	require.NoError(t, err)
+	assert.Nil(t, payment.Slip)
+	assert.Equal(t, 500_000.0, payment.NetAmount)
+	assert.Empty(t, repo.slips())
+	repo.AssertNotCalled(t, "FindRule", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
+
+func TestWithholdingTaxService_PaySupplierRejects(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	cashBank := uuid.New()
+	payable := testSupplierPayable(uuid.New(), 500_000, "")
+	repo.On("GetPayable", ctx, payable.ID).Return(payable, nil).Twice()
+
+	_, err := service.PaySupplier(ctx, &SupplierPaymentRequest{AccountsPayableID: payable.ID, CashBankID: cashBank, Amount: 600_000})
+	assert.True(t, errors.Is(err, entities.ErrInvalidSupplierPayment), "more than the balance")
+
+	repo.On("FindRule", ctx, payable.SupplierID, "catering").Return(nil, nil).Once()
+	_, err = service.PaySupplier(ctx, &SupplierPaymentRequest{AccountsPayableID: payable.ID, CashBankID: cashBank, ServiceType: "catering"})
+	assert.True(t, errors.Is(err, entities.ErrWithholdingRuleNotFound))
+
+	_, err = service.PaySupplier(ctx, &SupplierPaymentRequest{AccountsPayableID: payable.ID})
+	assert.True(t, errors.Is(err, entities.ErrInvalidSupplierPayment), "a cash/bank account is required")
+
+	missing := uuid.New()
+	repo.On("GetPayable", ctx, missing).Return(nil, entities.ErrPayableNotFound).Once()
+	_, err = service.PaySupplier(ctx, &SupplierPaymentRequest{AccountsPayableID: missing, CashBankID: cashBank})
+	assert.True(t, errors.Is(err, entities.ErrPayableNotFound))
+	repo.AssertNotCalled(t, "RecordSupplierPayment", mock.Anything, mock.Anything)
+	journals.AssertNotCalled(t, "CreateJournalFromTransaction", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestWithholdingTaxService_CreateRuleValidates(t *testing.T) {
+	repo := new(MockWithholdingTaxRepository)
+	service := newTestWithholdingTaxService(repo, new(MockJournalPoster))
+	err := service.CreateRule(context.Background(), &entities.WithholdingRule{ServiceType: "consulting",
+		TaxArticle: "PPh21", TaxObjectCode: "21-100-01", Rate: 5})
+	assert.True(t, errors.Is(err, entities.ErrInvalidWithholdingRule))
+	repo.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything)
+}
+
+func TestWithholdingTaxService_MonthlySummaryAndExports(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	supplier, cashBank := uuid.New(), uuid.New()
+	payables := []*entities.SupplierPayable{
+		testSupplierPayable(supplier, 10_000_000, "011112223444000"),
+		testSupplierPayable(supplier, 5_000_000, ""),
+		testSupplierPayable(supplier, 20_000_000, "0211112223444000"),
+	}
+	for _, payable := range payables {
+		repo.On("GetPayable", ctx, payable.ID).Return(payable, nil).Once()
+	}
+	repo.On("FindRule", ctx, supplier, "rental").Return(testRentalRule(), nil).Twice()
+	repo.On("FindRule", ctx, supplier, "building-rent").Return(testBuildingRentRule(), nil).Once()
+	repo.On("RecordSupplierPayment", ctx, mock.AnythingOfType("*entities.SupplierPayment")).Return(nil).Times(3).Run(recordedAgainst(payables...))
+	journals.On("CreateJournalFromTransaction", ctx, mock.AnythingOfType("*services.AutoJournalRequest")).Return(&accounting_entities.JournalEntry{}, nil).Times(3)
+	for i, serviceType := range []string{"rental", "rental", "building-rent"} {
+		_, err := service.PaySupplier(ctx, testSupplierPayment(payables[i].ID, cashBank, 0, serviceType))
+		require.NoError(t, err)
+	}
+
+	repo.On("SummarizeSlips", ctx, 2025, 3).Return([]*entities.WithholdingSummary{
+		{TaxYear: 2025, TaxPeriod: 3, TaxArticle: entities.WithholdingPPh23, TaxObjectCode: "24-100-01",
+			SlipCount: 2, GrossAmount: 15_000_000, WithheldAmount: 400_000},
+		{TaxYear: 2025, TaxPeriod: 3, TaxArticle: entities.WithholdingPPh42, TaxObjectCode: "28-403-01",
+			SlipCount: 1, GrossAmount: 20_000_000, WithheldAmount: 2_000_000},
+	}, nil).Once()
+	repo.On("ListSlips", ctx, repositories.WithholdingSlipFilter{TaxYear: 2025, TaxPeriod: 3}).Return(repo.slips(), nil).Twice()
+
+	month, err := service.MonthlySummary(ctx, 2025, 3)
+	require.NoError(t, err)
+	assert.Len(t, month.Lines, 2)
+	assert.Equal(t, 3, month.SlipCount)
+	assert.Equal(t, 400_000.0, month.TotalByArticle[entities.WithholdingPPh23])
+	assert.Equal(t, 2_000_000.0, month.TotalByArticle[entities.WithholdingPPh42])
+	assert.Equal(t, 2_400_000.0, month.WithheldAmount)
+	assert.Equal(t, 35_000_000.0, month.GrossAmount)
+
+	_, err = service.MonthlySummary(ctx, 2025, 13)
+	assert.Error(t, err)
+
+	csvData, err := service.ExportEBupotCSV(ctx, 2025, 3)
+	require.NoError(t, err)
+	rows := strings.Split(strings.TrimSpace(string(csvData)), "\n")
+	require.Len(t, rows, 4)
+	assert.Equal(t, "1,BP23/2025/03/0001,PPh23,3,2025,10/03/2025,0011112223444000,PT Sewa Alat,,24-100-01,10000000,2,200000,"+
+		"Faktur Pajak/Invoice,INV-SUP-001,20/02/2025", rows[1])
+	assert.Contains(t, rows[2], ",0000000000000000,", "suppliers without an NPWP are reported with zeros")
+
+	xmlData, err := service.ExportEBupotXML(ctx, 2025, 3)
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(xmlData, []byte("<TIN>0012345678901000</TIN>")))
+	assert.Equal(t, 3, bytes.Count(xmlData, []byte("<Bpu>")))
+	assert.True(t, bytes.Contains(xmlData, []byte("<TaxObjectCode>28-403-01</TaxObjectCode>")))
+	assert.True(t, bytes.Contains(xmlData, []byte("<WithholdingDate>2025-03-10</WithholdingDate>")))
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
+
+func TestWithholdingTaxService_SlipPDF(t *testing.T) {
+	repo, journals := new(MockWithholdingTaxRepository), new(MockJournalPoster)
+	service := newTestWithholdingTaxService(repo, journals)
+	ctx := context.Background()
+	payable := testSupplierPayable(uuid.New(), 10_000_000, "011112223444000")
+	repo.On("GetPayable", ctx, payable.ID).Return(payable, nil).Once()
+	repo.On("FindRule", ctx, payable.SupplierID, "rental").Return(testRentalRule(), nil).Once()
+	repo.On("RecordSupplierPayment", ctx, mock.AnythingOfType("*entities.SupplierPayment")).Return(nil).Once().Run(recordedAgainst(payable))
+	journals.On("CreateJournalFromTransaction", ctx, mock.AnythingOfType("*services.AutoJournalRequest")).Return(&accounting_entities.JournalEntry{}, nil).Once()
+	payment, err := service.PaySupplier(ctx, testSupplierPayment(payable.ID, uuid.New(), 0, "rental"))
+	require.NoError(t, err)
+
+	repo.On("GetSlip", ctx, payment.Slip.ID).Return(payment.Slip, nil).Once()
+	slip, data, err := service.SlipPDF(ctx, payment.Slip.ID)
+	require.NoError(t, err)
+	assert.Equal(t, payment.Slip.SlipNumber, slip.SlipNumber)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+
+	missing := uuid.New()
+	repo.On("GetSlip", ctx, missing).Return(nil, entities.ErrWithholdingSlipNotFound).Once()
+	_, _, err = service.SlipPDF(ctx, missing)
+	assert.True(t, errors.Is(err, entities.ErrWithholdingSlipNotFound))
+	repo.AssertExpectations(t)
+	journals.AssertExpectations(t)
+}
@@ -0,0 +1,310 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// ThreeWayMatchRepositoryImpl implements repositories.ThreeWayMatchRepository.
+type ThreeWayMatchRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewThreeWayMatchRepositoryImpl creates a new ThreeWayMatchRepositoryImpl.
+func NewThreeWayMatchRepositoryImpl(db *sqlx.DB) repositories.ThreeWayMatchRepository {
+	return &ThreeWayMatchRepositoryImpl{db: db}
+}
+
+// payableMatchBlocked tells whether the payable aliased ap is held back by open match
+// exceptions: on a voucher paid through it, or on a voucher billing the purchase order
+// of a goods receipt it was raised for.
+const payableMatchBlocked = `EXISTS (SELECT 1 FROM three_way_match_exceptions e
+		JOIN purchase_vouchers v ON v.id = e.purchase_voucher_id
+		WHERE e.status = 'OPEN' AND (v.accounts_payable_id = ap.id
+			OR v.purchase_order_id IN (SELECT gr.purchase_order_id FROM goods_receipts gr WHERE gr.ap_id = ap.id)))`
+
+const matchToleranceColumns = `id, company_id, quantity_tolerance, price_tolerance, updated_by, created_at, updated_at`
+
+const matchExceptionColumns = `e.id, e.purchase_voucher_id, e.voucher_item_id, e.po_item_id, e.exception_type,
+	e.expected_value, e.actual_value, e.tolerance, e.variance_amount, e.message, e.status, e.resolution_notes,
+	e.resolved_by, e.resolved_at, e.created_at, v.voucher_number, i.item_name`
+
+const matchExceptionFrom = ` FROM three_way_match_exceptions e
+	JOIN purchase_vouchers v ON v.id = e.purchase_voucher_id
+	JOIN purchase_voucher_items i ON i.id = e.voucher_item_id`
+
+// GetTolerance returns the tolerance of the company, or else the default.
+func (r *ThreeWayMatchRepositoryImpl) GetTolerance(ctx context.Context, companyID *uuid.ID) (*entities.MatchTolerance, error) {
+	var tolerance entities.MatchTolerance
+	err := r.db.GetContext(ctx, &tolerance, `SELECT `+matchToleranceColumns+` FROM three_way_match_tolerances
+		WHERE company_id = $1 OR company_id IS NULL
+		ORDER BY company_id NULLS LAST LIMIT 1`, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tolerance, nil
+}
+
+// SaveTolerance creates or replaces the tolerance of its company.
+func (r *ThreeWayMatchRepositoryImpl) SaveTolerance(ctx context.Context, tolerance *entities.MatchTolerance) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The default has no company, so it cannot be upserted on a unique company_id
+	err = tx.GetContext(ctx, tolerance, `UPDATE three_way_match_tolerances
+		SET quantity_tolerance = $2, price_tolerance = $3, updated_by = $4, updated_at = $5
+		WHERE company_id IS NOT DISTINCT FROM $1
+		RETURNING `+matchToleranceColumns,
+		tolerance.CompanyID, tolerance.QuantityTolerance, tolerance.PriceTolerance, tolerance.UpdatedBy, tolerance.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx, `INSERT INTO three_way_match_tolerances (`+matchToleranceColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			tolerance.ID, tolerance.CompanyID, tolerance.QuantityTolerance, tolerance.PriceTolerance, tolerance.UpdatedBy,
+			tolerance.CreatedAt, tolerance.UpdatedAt)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetVoucherMatch retrieves a voucher with its matched lines and exceptions.
+func (r *ThreeWayMatchRepositoryImpl) GetVoucherMatch(ctx context.Context, voucherID uuid.ID) (*entities.VoucherMatch, error) {
+	var match entities.VoucherMatch
+	err := r.db.GetContext(ctx, &match, `SELECT v.id, v.voucher_number, v.supplier_id, v.voucher_date,
+			COALESCE(v.status, '') AS status, v.company_id, v.purchase_order_id, COALESCE(po.po_number, '') AS po_number,
+			v.accounts_payable_id, v.supplier_invoice_number, v.match_status, v.matched_at
+		FROM purchase_vouchers v
+		LEFT JOIN procurement_purchase_orders po ON po.id = v.purchase_order_id
+		WHERE v.id = $1`, voucherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrPurchaseVoucherNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The position of each line is what was received and invoiced elsewhere at the time
+	// of reading, not at the time of matching
+	match.Lines = []*entities.InvoiceMatchLine{}
+	if err := r.db.SelectContext(ctx, &match.Lines, `SELECT i.id, i.purchase_voucher_id, i.po_item_id, i.item_name,
+			i.quantity, i.unit_price, i.line_total,
+			poi.quantity AS ordered_quantity, poi.unit_price AS po_unit_price,
+			`+receivedQuantitySQL+` AS received_quantity,
+			`+invoicedQuantitySQL+` AS previously_invoiced
+		FROM purchase_voucher_items i
+		JOIN procurement_purchase_order_items poi ON poi.id = i.po_item_id
+		WHERE i.purchase_voucher_id = $1
+		ORDER BY poi.created_at, poi.id`, voucherID); err != nil {
+		return nil, err
+	}
+	match.Exceptions = []*entities.MatchException{}
+	if err := r.db.SelectContext(ctx, &match.Exceptions, `SELECT `+matchExceptionColumns+matchExceptionFrom+`
+		WHERE e.purchase_voucher_id = $1 ORDER BY e.created_at, e.exception_type`, voucherID); err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// receivedQuantitySQL is the quantity of the PO item poi received on posted goods receipts.
+const receivedQuantitySQL = `COALESCE((SELECT SUM(gri.quantity) FROM goods_receipt_items gri
+		JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id
+		WHERE gri.po_item_id = poi.id AND gr.status = 'POSTED'), 0)`
+
+// invoicedQuantitySQL is the quantity of the PO item poi invoiced on vouchers other than
+// $1 that are not cancelled.
+const invoicedQuantitySQL = `COALESCE((SELECT SUM(vi.quantity) FROM purchase_voucher_items vi
+		JOIN purchase_vouchers pv ON pv.id = vi.purchase_voucher_id
+		WHERE vi.po_item_id = poi.id AND pv.id <> $1 AND LOWER(COALESCE(pv.status, '')) <> 'cancelled'), 0)`
+
+// GetPurchaseOrderPosition retrieves a purchase order with what was received and invoiced
+// of each item.
+func (r *ThreeWayMatchRepositoryImpl) GetPurchaseOrderPosition(ctx context.Context, purchaseOrderID, excludeVoucherID uuid.ID) (*entities.PurchaseOrderPosition, error) {
+	var order entities.PurchaseOrderPosition
+	err := r.db.GetContext(ctx, &order, `SELECT id, po_number, supplier_id, status
+		FROM procurement_purchase_orders WHERE id = $1`, purchaseOrderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: purchase order not found", entities.ErrInvalidInvoiceMatch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.SelectContext(ctx, &order.Items, `SELECT poi.id AS po_item_id, poi.item_name,
+			poi.quantity AS ordered_quantity, poi.unit_price,
+			`+receivedQuantitySQL+` AS received_quantity,
+			`+invoicedQuantitySQL+` AS invoiced_quantity
+		FROM procurement_purchase_order_items poi
+		WHERE poi.purchase_order_id = $2
+		ORDER BY poi.created_at, poi.id`, excludeVoucherID, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// SaveMatch replaces the lines and exceptions of a voucher and sets its match status.
+func (r *ThreeWayMatchRepositoryImpl) SaveMatch(ctx context.Context, match *entities.VoucherMatch) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.GetContext(ctx, &status, `SELECT COALESCE(status, '') FROM purchase_vouchers WHERE id = $1 FOR UPDATE`, match.PurchaseVoucherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.ErrPurchaseVoucherNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != match.Status {
+		return entities.ErrInvalidInvoiceMatch
+	}
+
+	// Exceptions go with their lines
+	if _, err := tx.ExecContext(ctx, `DELETE FROM purchase_voucher_items WHERE purchase_voucher_id = $1`, match.PurchaseVoucherID); err != nil {
+		return err
+	}
+	for _, line := range match.Lines {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO purchase_voucher_items (id, purchase_voucher_id, po_item_id, item_name,
+				quantity, unit_price, line_total)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			line.ID, line.PurchaseVoucherID, line.POItemID, line.ItemName, line.Quantity, line.UnitPrice, line.LineTotal); err != nil {
+			return err
+		}
+	}
+	for _, e := range match.Exceptions {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO three_way_match_exceptions (id, purchase_voucher_id, voucher_item_id,
+				po_item_id, exception_type, expected_value, actual_value, tolerance, variance_amount, message, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			e.ID, e.PurchaseVoucherID, e.VoucherItemID, e.POItemID, e.ExceptionType, e.ExpectedValue, e.ActualValue,
+			e.Tolerance, e.VarianceAmount, e.Message, e.Status, e.CreatedAt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE purchase_vouchers SET company_id = $2, purchase_order_id = $3,
+			accounts_payable_id = $4, supplier_invoice_number = $5, match_status = $6, matched_at = $7, updated_at = NOW()
+		WHERE id = $1`,
+		match.PurchaseVoucherID, match.CompanyID, match.PurchaseOrderID, match.AccountsPayableID,
+		match.SupplierInvoiceNumber, match.MatchStatus, match.MatchedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetException retrieves a match exception by its ID.
+func (r *ThreeWayMatchRepositoryImpl) GetException(ctx context.Context, id uuid.ID) (*entities.MatchException, error) {
+	var exception entities.MatchException
+	err := r.db.GetContext(ctx, &exception, `SELECT `+matchExceptionColumns+matchExceptionFrom+` WHERE e.id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrMatchExceptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exception, nil
+}
+
+// ListExceptions lists the match exceptions of a filter, oldest first.
+func (r *ThreeWayMatchRepositoryImpl) ListExceptions(ctx context.Context, filter repositories.MatchExceptionFilter) ([]*entities.MatchException, error) {
+	exceptions := []*entities.MatchException{}
+	err := r.db.SelectContext(ctx, &exceptions, `SELECT `+matchExceptionColumns+matchExceptionFrom+`
+		WHERE ($1 = '' OR e.status = $1) AND ($2::uuid IS NULL OR e.purchase_voucher_id = $2)
+		ORDER BY e.created_at, v.voucher_number, e.exception_type`, filter.Status, filter.PurchaseVoucherID)
+	return exceptions, err
+}
+
+// ResolveException closes an open exception and marks the voucher resolved once none of
+// its exceptions is left open.
+func (r *ThreeWayMatchRepositoryImpl) ResolveException(ctx context.Context, exception *entities.MatchException) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE three_way_match_exceptions
+		SET status = $2, resolution_notes = $3, resolved_by = $4, resolved_at = $5
+		WHERE id = $1 AND status = 'OPEN'`,
+		exception.ID, exception.Status, exception.ResolutionNotes, exception.ResolvedBy, exception.ResolvedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return entities.ErrMatchExceptionResolved
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE purchase_vouchers SET match_status = 'RESOLVED', updated_at = NOW()
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM three_way_match_exceptions
+			WHERE purchase_voucher_id = $1 AND status = 'OPEN')`, exception.PurchaseVoucherID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CountOpenExceptions counts the open exceptions of a voucher.
+func (r *ThreeWayMatchRepositoryImpl) CountOpenExceptions(ctx context.Context, voucherID uuid.ID) (int, error) {
+	var open int
+	err := r.db.GetContext(ctx, &open, `SELECT COUNT(*) FROM three_way_match_exceptions
+		WHERE purchase_voucher_id = $1 AND status = 'OPEN'`, voucherID)
+	return open, err
+}
+
+// IsPayableBlocked tells whether open match exceptions hold back the payable.
+func (r *ThreeWayMatchRepositoryImpl) IsPayableBlocked(ctx context.Context, payableID uuid.ID) (bool, error) {
+	var blocked bool
+	err := r.db.GetContext(ctx, &blocked, `SELECT `+payableMatchBlocked+`
+		FROM accounts_payable ap WHERE ap.id = $1`, payableID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, entities.ErrPayableNotFound
+	}
+	return blocked, err
+}
+
+// GetGRIRLines returns the received and invoiced quantities and amounts of the PO items
+// with anything received or invoiced up to the date. Goods are received on posting and
+// invoiced on the voucher date.
+func (r *ThreeWayMatchRepositoryImpl) GetGRIRLines(ctx context.Context, filter repositories.GRIRFilter) ([]*entities.GRIRLine, error) {
+	lines := []*entities.GRIRLine{}
+	err := r.db.SelectContext(ctx, &lines, `WITH received AS (
+			SELECT gri.po_item_id, SUM(gri.quantity) AS quantity, SUM(gri.line_total) AS amount
+			FROM goods_receipt_items gri
+			JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id
+			WHERE gr.status = 'POSTED' AND gri.po_item_id IS NOT NULL
+				AND COALESCE(gr.posted_at, gr.receipt_date)::date <= $1::date
+			GROUP BY gri.po_item_id
+		), invoiced AS (
+			SELECT vi.po_item_id, SUM(vi.quantity) AS quantity, SUM(vi.line_total) AS amount
+			FROM purchase_voucher_items vi
+			JOIN purchase_vouchers v ON v.id = vi.purchase_voucher_id
+			WHERE LOWER(COALESCE(v.status, '')) <> 'cancelled' AND v.voucher_date <= $1::date
+			GROUP BY vi.po_item_id
+		)
+		SELECT po.id AS purchase_order_id, po.po_number, po.supplier_id, COALESCE(s.name, '') AS supplier_name,
+			poi.id AS po_item_id, poi.item_name, poi.unit_price,
+			COALESCE(rc.quantity, 0) AS received_quantity, COALESCE(iv.quantity, 0) AS invoiced_quantity,
+			COALESCE(rc.amount, 0) AS received_amount, COALESCE(iv.amount, 0) AS invoiced_amount
+		FROM procurement_purchase_order_items poi
+		JOIN procurement_purchase_orders po ON po.id = poi.purchase_order_id
+		LEFT JOIN suppliers s ON s.id = po.supplier_id
+		LEFT JOIN received rc ON rc.po_item_id = poi.id
+		LEFT JOIN invoiced iv ON iv.po_item_id = poi.id
+		WHERE (rc.po_item_id IS NOT NULL OR iv.po_item_id IS NOT NULL)
+			AND ($2::uuid IS NULL OR po.supplier_id = $2)
+		ORDER BY s.name, po.po_number, poi.created_at, poi.id`, filter.AsOf, filter.SupplierID)
+	return lines, err
+}
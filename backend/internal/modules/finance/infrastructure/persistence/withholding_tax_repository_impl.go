@@ -0,0 +1,287 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// WithholdingTaxRepositoryImpl implements repositories.WithholdingTaxRepository.
+type WithholdingTaxRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewWithholdingTaxRepositoryImpl creates a new WithholdingTaxRepositoryImpl.
+func NewWithholdingTaxRepositoryImpl(db *sqlx.DB) repositories.WithholdingTaxRepository {
+	return &WithholdingTaxRepositoryImpl{db: db}
+}
+
+const withholdingRuleColumns = `id, supplier_id, service_type, tax_article, tax_object_code, rate, description, is_active,
+	created_by, created_at, updated_at`
+
+const withholdingSlipColumns = `id, slip_number, tax_article, tax_year, tax_period, sequence, tax_object_code,
+	withholding_rule_id, supplier_id, supplier_name, supplier_tax_id, supplier_address, payment_id, accounts_payable_id,
+	document_number, document_date, slip_date, gross_amount, rate, withheld_amount, tax_transaction_id, created_by, created_at`
+
+// withholdingTaxCodes are the taxes the withholding of each tax article is payable against.
+var withholdingTaxCodes = map[string]string{
+	entities.WithholdingPPh23: "PPH23",
+	entities.WithholdingPPh42: "PPH4-2",
+}
+
+// CreateRule creates a withholding rule.
+func (r *WithholdingTaxRepositoryImpl) CreateRule(ctx context.Context, rule *entities.WithholdingRule) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO withholding_rules (id, supplier_id, service_type, tax_article,
+			tax_object_code, rate, description, is_active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		rule.ID, rule.SupplierID, rule.ServiceType, rule.TaxArticle, rule.TaxObjectCode, rule.Rate, rule.Description,
+		rule.IsActive, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt)
+	return ruleError(err)
+}
+
+// UpdateRule updates a withholding rule.
+func (r *WithholdingTaxRepositoryImpl) UpdateRule(ctx context.Context, rule *entities.WithholdingRule) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE withholding_rules SET tax_article = $2, tax_object_code = $3, rate = $4,
+			description = $5, is_active = $6, updated_at = $7
+		WHERE id = $1`,
+		rule.ID, rule.TaxArticle, rule.TaxObjectCode, rule.Rate, rule.Description, rule.IsActive, rule.UpdatedAt)
+	if err != nil {
+		return ruleError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return entities.ErrWithholdingRuleNotFound
+	}
+	return nil
+}
+
+// ruleError reports a second active rule of a supplier and service type.
+func ruleError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return entities.ErrWithholdingRuleExists
+	}
+	return err
+}
+
+// GetRule retrieves a withholding rule by its ID.
+func (r *WithholdingTaxRepositoryImpl) GetRule(ctx context.Context, id uuid.ID) (*entities.WithholdingRule, error) {
+	var rule entities.WithholdingRule
+	err := r.db.GetContext(ctx, &rule, `SELECT `+withholdingRuleColumns+` FROM withholding_rules WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrWithholdingRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListRules lists the withholding rules by service type, defaults first.
+func (r *WithholdingTaxRepositoryImpl) ListRules(ctx context.Context, activeOnly bool) ([]*entities.WithholdingRule, error) {
+	rules := []*entities.WithholdingRule{}
+	err := r.db.SelectContext(ctx, &rules, `SELECT `+withholdingRuleColumns+` FROM withholding_rules
+		WHERE is_active OR NOT $1
+		ORDER BY service_type, supplier_id NULLS FIRST, created_at`, activeOnly)
+	return rules, err
+}
+
+// FindRule returns the active rule of the supplier for the service type, or else the
+// default of the service type.
+func (r *WithholdingTaxRepositoryImpl) FindRule(ctx context.Context, supplierID uuid.ID, serviceType string) (*entities.WithholdingRule, error) {
+	var rule entities.WithholdingRule
+	err := r.db.GetContext(ctx, &rule, `SELECT `+withholdingRuleColumns+` FROM withholding_rules
+		WHERE is_active AND service_type = $2 AND (supplier_id = $1 OR supplier_id IS NULL)
+		ORDER BY supplier_id NULLS LAST LIMIT 1`, supplierID, serviceType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetPayable retrieves a payable with its invoice and supplier.
+func (r *WithholdingTaxRepositoryImpl) GetPayable(ctx context.Context, id uuid.ID) (*entities.SupplierPayable, error) {
+	var payable entities.SupplierPayable
+	err := r.db.GetContext(ctx, &payable, `SELECT ap.id, ap.invoice_id, COALESCE(ap.supplier_id, i.supplier_id) AS supplier_id,
+			ap.issue_date, ap.due_date, ap.amount, ap.paid_amount, ap.balance, ap.status, ap.created_at, ap.updated_at,
+			COALESCE(i.invoice_number, '') AS invoice_number, COALESCE(i.invoice_date, ap.issue_date) AS invoice_date,
+			COALESCE(s.name, '') AS supplier_name, COALESCE(s.tax_id, '') AS supplier_tax_id,
+			COALESCE(s.address, '') AS supplier_address, `+payableMatchBlocked+` AS match_blocked
+		FROM accounts_payable ap
+		LEFT JOIN invoices i ON i.id = ap.invoice_id
+		LEFT JOIN suppliers s ON s.id = COALESCE(ap.supplier_id, i.supplier_id)
+		WHERE ap.id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrPayableNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payable, nil
+}
+
+// RecordSupplierPayment saves a supplier payment, settles its payable and issues its
+// withholding slip.
+func (r *WithholdingTaxRepositoryImpl) RecordSupplierPayment(ctx context.Context, payment *entities.SupplierPayment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance float64
+	var matchBlocked bool
+	err = tx.QueryRowxContext(ctx, `SELECT ap.balance, `+payableMatchBlocked+`
+		FROM accounts_payable ap WHERE ap.id = $1 FOR UPDATE OF ap`, payment.AccountsPayableID).Scan(&balance, &matchBlocked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.ErrPayableNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if matchBlocked {
+		return entities.ErrMatchExceptionsUnresolved
+	}
+	if payment.GrossAmount > balance+0.005 {
+		return entities.ErrInvalidSupplierPayment
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO payments (id, invoice_id, payment_date, amount, payment_method,
+			cash_bank_id, accounts_payable_id, gross_amount, withholding_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		payment.PaymentID, payment.InvoiceID, payment.PaymentDate, payment.NetAmount, payment.PaymentMethod,
+		payment.CashBankID, payment.AccountsPayableID, payment.GrossAmount, payment.WithheldAmount); err != nil {
+		return err
+	}
+	// The payable is settled by the gross amount: the tax withheld is owed to the state instead
+	if err := tx.GetContext(ctx, &payment.PayableBalance, `UPDATE accounts_payable
+		SET paid_amount = paid_amount + $2, balance = balance - $2,
+			status = CASE WHEN balance - $2 < 0.005 THEN 'paid' ELSE status END, updated_at = NOW()
+		WHERE id = $1 RETURNING balance`, payment.AccountsPayableID, payment.GrossAmount); err != nil {
+		return err
+	}
+
+	if slip := payment.Slip; slip != nil {
+		// Slips of an article and month are numbered one at a time
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('withholding_slips:' || $1 || ':' || $2::text || ':' || $3::text))`,
+			slip.TaxArticle, slip.TaxYear, slip.TaxPeriod); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &slip.Sequence, `SELECT COALESCE(MAX(sequence), 0) + 1 FROM withholding_slips
+			WHERE tax_article = $1 AND tax_year = $2 AND tax_period = $3`, slip.TaxArticle, slip.TaxYear, slip.TaxPeriod); err != nil {
+			return err
+		}
+		slip.SlipNumber = entities.FormatSlipNumber(slip.TaxArticle, slip.TaxYear, slip.TaxPeriod, slip.Sequence)
+		if err := bookWithholdingTax(ctx, tx, slip); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO withholding_slips (`+withholdingSlipColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)`,
+			slip.ID, slip.SlipNumber, slip.TaxArticle, slip.TaxYear, slip.TaxPeriod, slip.Sequence, slip.TaxObjectCode,
+			slip.WithholdingRuleID, slip.SupplierID, slip.SupplierName, slip.SupplierTaxID, slip.SupplierAddress, slip.PaymentID,
+			slip.AccountsPayableID, slip.DocumentNumber, slip.DocumentDate, slip.SlipDate, slip.GrossAmount, slip.Rate,
+			slip.WithheldAmount, slip.TaxTransactionID, slip.CreatedBy, slip.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// bookWithholdingTax records the tax withheld on a slip as a WITHHOLDING tax transaction
+// against the withholding tax of its article in effect on the slip date.
+func bookWithholdingTax(ctx context.Context, tx *sqlx.Tx, slip *entities.WithholdingSlip) error {
+	var taxID uuid.ID
+	err := tx.GetContext(ctx, &taxID, `SELECT id FROM taxes
+		WHERE tax_type IN ('WITHHOLDING', 'PPh') AND is_active AND effective_date <= $1 AND (expiry_date IS NULL OR expiry_date >= $1)
+		ORDER BY tax_code = $2 DESC, effective_date DESC LIMIT 1`, slip.SlipDate, withholdingTaxCodes[slip.TaxArticle])
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		log.Printf("[Finance] No withholding tax in effect on %s; tax withheld on slip %s not booked",
+			slip.SlipDate.Format("2006-01-02"), slip.SlipNumber)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	supplierID := ""
+	if slip.SupplierID != nil {
+		supplierID = slip.SupplierID.String()
+	}
+	id := uuid.New()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tax_transactions (id, tax_id, transaction_date, transaction_type,
+			base_amount, tax_amount, total_amount, reference_type, reference_id, reference_number, supplier_id, created_by)
+		VALUES ($1, $2, $3, 'WITHHOLDING', $4, $5, $4, 'WITHHOLDING_SLIP', $6, $7, $8, $9)`,
+		id, taxID, slip.SlipDate, slip.GrossAmount, slip.WithheldAmount, slip.ID.String(), slip.SlipNumber, supplierID,
+		slip.CreatedBy); err != nil {
+		return err
+	}
+	slip.TaxTransactionID = &id
+	return nil
+}
+
+// GetSlip retrieves a withholding slip by its ID.
+func (r *WithholdingTaxRepositoryImpl) GetSlip(ctx context.Context, id uuid.ID) (*entities.WithholdingSlip, error) {
+	var slip entities.WithholdingSlip
+	err := r.db.GetContext(ctx, &slip, `SELECT `+withholdingSlipColumns+` FROM withholding_slips WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrWithholdingSlipNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &slip, nil
+}
+
+// ListSlips lists the withholding slips of a filter in number order.
+func (r *WithholdingTaxRepositoryImpl) ListSlips(ctx context.Context, filter repositories.WithholdingSlipFilter) ([]*entities.WithholdingSlip, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+	if filter.TaxYear > 0 {
+		add("tax_year = ?", filter.TaxYear)
+	}
+	if filter.TaxPeriod > 0 {
+		add("tax_period = ?", filter.TaxPeriod)
+	}
+	if filter.TaxArticle != "" {
+		add("tax_article = ?", filter.TaxArticle)
+	}
+	if filter.SupplierID != nil {
+		add("supplier_id = ?", *filter.SupplierID)
+	}
+	query := `SELECT ` + withholdingSlipColumns + ` FROM withholding_slips`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY tax_year, tax_period, tax_article, sequence"
+
+	slips := []*entities.WithholdingSlip{}
+	err := r.db.SelectContext(ctx, &slips, query, args...)
+	return slips, err
+}
+
+// SummarizeSlips totals the slips of a month per tax article and object code.
+func (r *WithholdingTaxRepositoryImpl) SummarizeSlips(ctx context.Context, year, period int) ([]*entities.WithholdingSummary, error) {
+	lines := []*entities.WithholdingSummary{}
+	err := r.db.SelectContext(ctx, &lines, `SELECT tax_year, tax_period, tax_article, tax_object_code,
+			COUNT(*) AS slip_count, SUM(gross_amount) AS gross_amount, SUM(withheld_amount) AS withheld_amount
+		FROM withholding_slips
+		WHERE tax_year = $1 AND tax_period = $2
+		GROUP BY tax_year, tax_period, tax_article, tax_object_code
+		ORDER BY tax_article, tax_object_code`, year, period)
+	return lines, err
+}
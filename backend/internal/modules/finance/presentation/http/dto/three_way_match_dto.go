@@ -0,0 +1,32 @@
+package dto
+
+// MatchToleranceRequest represents the request to set the three-way match tolerances of a
+// company, in percent. Without a company it sets those of the user's company; with
+// is_default it sets the default of every company without its own.
+type MatchToleranceRequest struct {
+	CompanyID         string  `json:"company_id"`
+	IsDefault         bool    `json:"is_default"`
+	QuantityTolerance float64 `json:"quantity_tolerance" binding:"min=0,max=100"`
+	PriceTolerance    float64 `json:"price_tolerance" binding:"min=0,max=100"`
+}
+
+// InvoiceMatchRequest represents the request to match the supplier invoice of a purchase
+// voucher against a purchase order and its goods receipts.
+type InvoiceMatchRequest struct {
+	PurchaseOrderID       string                    `json:"purchase_order_id" binding:"required"`
+	AccountsPayableID     string                    `json:"accounts_payable_id"`
+	SupplierInvoiceNumber string                    `json:"supplier_invoice_number"`
+	Lines                 []InvoiceMatchLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// InvoiceMatchLineRequest represents an invoiced PO item.
+type InvoiceMatchLineRequest struct {
+	POItemID  string  `json:"po_item_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	UnitPrice float64 `json:"unit_price" binding:"min=0"`
+}
+
+// ResolveMatchExceptionRequest represents the request to resolve a match exception.
+type ResolveMatchExceptionRequest struct {
+	Notes string `json:"notes" binding:"required"`
+}
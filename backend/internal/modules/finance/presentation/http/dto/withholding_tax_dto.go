@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"malaka/internal/modules/finance/domain/entities"
+)
+
+// WithholdingRuleCreateRequest represents the request to create a withholding rule.
+// Without a supplier the rule is the default of the service type.
+type WithholdingRuleCreateRequest struct {
+	SupplierID    string  `json:"supplier_id"`
+	ServiceType   string  `json:"service_type" binding:"required"`
+	TaxArticle    string  `json:"tax_article" binding:"required"`
+	TaxObjectCode string  `json:"tax_object_code" binding:"required"`
+	Rate          float64 `json:"rate" binding:"required"`
+	Description   string  `json:"description"`
+}
+
+// WithholdingRuleUpdateRequest represents the request to update a withholding rule.
+type WithholdingRuleUpdateRequest struct {
+	TaxArticle    string  `json:"tax_article" binding:"required"`
+	TaxObjectCode string  `json:"tax_object_code" binding:"required"`
+	Rate          float64 `json:"rate" binding:"required"`
+	Description   string  `json:"description"`
+	IsActive      bool    `json:"is_active"`
+}
+
+// SupplierPaymentCreateRequest represents the request to pay a payable, withholding the
+// tax of the service type when given. Without an amount the whole balance is paid.
+type SupplierPaymentCreateRequest struct {
+	AccountsPayableID string  `json:"accounts_payable_id" binding:"required"`
+	CashBankID        string  `json:"cash_bank_id" binding:"required"`
+	PaymentDate       string  `json:"payment_date"` // YYYY-MM-DD, today by default
+	PaymentMethod     string  `json:"payment_method" binding:"required"`
+	Amount            float64 `json:"amount" binding:"min=0"`
+	ServiceType       string  `json:"service_type"`
+}
+
+// ToWithholdingRuleEntity converts WithholdingRuleCreateRequest to entities.WithholdingRule.
+func (req *WithholdingRuleCreateRequest) ToWithholdingRuleEntity() *entities.WithholdingRule {
+	rule := &entities.WithholdingRule{
+		ServiceType:   req.ServiceType,
+		TaxArticle:    req.TaxArticle,
+		TaxObjectCode: req.TaxObjectCode,
+		Rate:          req.Rate,
+		Description:   req.Description,
+	}
+	if supplierID := safeParseUUID(req.SupplierID); !supplierID.IsNil() {
+		rule.SupplierID = &supplierID
+	}
+	return rule
+}
+
+// ToWithholdingRuleEntity converts WithholdingRuleUpdateRequest to entities.WithholdingRule.
+func (req *WithholdingRuleUpdateRequest) ToWithholdingRuleEntity() *entities.WithholdingRule {
+	return &entities.WithholdingRule{
+		TaxArticle:    req.TaxArticle,
+		TaxObjectCode: req.TaxObjectCode,
+		Rate:          req.Rate,
+		Description:   req.Description,
+		IsActive:      req.IsActive,
+	}
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"malaka/internal/modules/finance/domain/entities"
 	"malaka/internal/modules/finance/domain/services"
 	"malaka/internal/modules/finance/presentation/http/dto"
 	"malaka/internal/shared/response"
@@ -103,6 +105,10 @@ func (h *AccountsPayableHandler) UpdateAccountsPayable(c *gin.Context) {
 	accountsPayable.ID = parsedID
 
 	if err := h.service.UpdateAccountsPayable(c.Request.Context(), accountsPayable); err != nil {
+		if errors.Is(err, entities.ErrMatchExceptionsUnresolved) {
+			response.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Failed to update accounts payable", err)
 		return
 	}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"malaka/internal/modules/finance/domain/entities"
 	"malaka/internal/modules/finance/domain/services"
 	"malaka/internal/modules/finance/presentation/http/dto"
 	"malaka/internal/shared/response"
@@ -115,6 +118,10 @@ func (h *PurchaseVoucherHandler) UpdatePurchaseVoucher(c *gin.Context) {
 	voucher.UpdatedAt = time.Now()
 
 	if err := h.service.UpdatePurchaseVoucher(c.Request.Context(), voucher); err != nil {
+		if errors.Is(err, entities.ErrMatchExceptionsUnresolved) {
+			response.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, "Failed to update purchase voucher", err.Error())
 		return
 	}
@@ -178,6 +185,10 @@ func (h *PurchaseVoucherHandler) ApprovePurchaseVoucher(c *gin.Context) {
 	}
 
 	if err := h.service.ApprovePurchaseVoucher(c.Request.Context(), parsedID, parsedApprovedBy); err != nil {
+		if errors.Is(err, entities.ErrMatchExceptionsUnresolved) {
+			response.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, "Failed to approve purchase voucher", err.Error())
 		return
 	}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/modules/finance/domain/services"
+	"malaka/internal/modules/finance/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// ThreeWayMatchHandler handles HTTP requests for matching supplier invoices against
+// purchase orders and goods receipts: tolerances, matches, exceptions and the GR/IR report.
+type ThreeWayMatchHandler struct {
+	service *services.ThreeWayMatchService
+}
+
+// NewThreeWayMatchHandler creates a new ThreeWayMatchHandler.
+func NewThreeWayMatchHandler(service *services.ThreeWayMatchService) *ThreeWayMatchHandler {
+	return &ThreeWayMatchHandler{service: service}
+}
+
+// GetTolerance handles retrieving the tolerances that apply to the user's company, or
+// to the company_id of the query.
+func (h *ThreeWayMatchHandler) GetTolerance(c *gin.Context) {
+	companyID, err := optionalUUID(c.DefaultQuery("company_id", c.GetString("company_id")))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid company_id", err)
+		return
+	}
+	tolerance, err := h.service.GetTolerance(c.Request.Context(), companyID)
+	if err != nil {
+		matchError(c, "Failed to retrieve match tolerance", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Match tolerance retrieved successfully", tolerance)
+}
+
+// SetTolerance handles setting the tolerances of a company or the default.
+func (h *ThreeWayMatchHandler) SetTolerance(c *gin.Context) {
+	var req dto.MatchToleranceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	tolerance := &entities.MatchTolerance{
+		QuantityTolerance: req.QuantityTolerance,
+		PriceTolerance:    req.PriceTolerance,
+		UpdatedBy:         c.GetString("user_id"),
+	}
+	if !req.IsDefault {
+		companyID := req.CompanyID
+		if companyID == "" {
+			companyID = c.GetString("company_id")
+		}
+		id, err := optionalUUID(companyID)
+		if err != nil || id == nil {
+			response.Error(c, http.StatusBadRequest, "A valid company_id is required, or is_default", err)
+			return
+		}
+		tolerance.CompanyID = id
+	}
+	if err := h.service.SetTolerance(c.Request.Context(), tolerance); err != nil {
+		matchError(c, "Failed to set match tolerance", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Match tolerance set successfully", tolerance)
+}
+
+// MatchInvoice handles matching the supplier invoice of a purchase voucher against a
+// purchase order and its goods receipts, with the tolerances of the user's company.
+func (h *ThreeWayMatchHandler) MatchInvoice(c *gin.Context) {
+	voucherID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	var req dto.InvoiceMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	purchaseOrderID, err := uuid.Parse(req.PurchaseOrderID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid purchase_order_id", err)
+		return
+	}
+	payableID, err := optionalUUID(req.AccountsPayableID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid accounts_payable_id", err)
+		return
+	}
+	companyID, _ := optionalUUID(c.GetString("company_id"))
+	match := &services.InvoiceMatchRequest{
+		PurchaseVoucherID:     voucherID,
+		PurchaseOrderID:       purchaseOrderID,
+		AccountsPayableID:     payableID,
+		SupplierInvoiceNumber: req.SupplierInvoiceNumber,
+		CompanyID:             companyID,
+	}
+	for _, line := range req.Lines {
+		poItemID, err := uuid.Parse(line.POItemID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid po_item_id", err)
+			return
+		}
+		match.Lines = append(match.Lines, services.InvoiceMatchLineInput{
+			POItemID:  poItemID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		})
+	}
+
+	result, err := h.service.MatchInvoice(c.Request.Context(), match)
+	if err != nil {
+		matchError(c, "Failed to match supplier invoice", err)
+		return
+	}
+	message := "Supplier invoice matched successfully"
+	if result.MatchStatus == entities.MatchStatusException {
+		message = "Supplier invoice matched with exceptions; it is blocked until they are resolved"
+	}
+	response.Success(c, http.StatusOK, message, result)
+}
+
+// GetVoucherMatch handles retrieving the match of a purchase voucher.
+func (h *ThreeWayMatchHandler) GetVoucherMatch(c *gin.Context) {
+	voucherID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	match, err := h.service.GetVoucherMatch(c.Request.Context(), voucherID)
+	if err != nil {
+		matchError(c, "Failed to retrieve invoice match", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Invoice match retrieved successfully", match)
+}
+
+// ListExceptions handles listing the match exceptions, of a status (OPEN, RESOLVED) and
+// purchase voucher when given.
+func (h *ThreeWayMatchHandler) ListExceptions(c *gin.Context) {
+	filter := repositories.MatchExceptionFilter{Status: c.Query("status")}
+	voucherID, err := optionalUUID(c.Query("purchase_voucher_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid purchase_voucher_id", err)
+		return
+	}
+	filter.PurchaseVoucherID = voucherID
+
+	exceptions, err := h.service.ListExceptions(c.Request.Context(), filter)
+	if err != nil {
+		matchError(c, "Failed to retrieve match exceptions", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Match exceptions retrieved successfully", exceptions)
+}
+
+// ResolveException handles resolving a match exception, accepting the variance.
+func (h *ThreeWayMatchHandler) ResolveException(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	var req dto.ResolveMatchExceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	exception, err := h.service.ResolveException(c.Request.Context(), id, req.Notes, c.GetString("user_id"))
+	if err != nil {
+		matchError(c, "Failed to resolve match exception", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Match exception resolved successfully", exception)
+}
+
+// GetGRIRReport handles the GR/IR clearing report as of a date (as_of, YYYY-MM-DD, today
+// by default), of a supplier when given.
+func (h *ThreeWayMatchHandler) GetGRIRReport(c *gin.Context) {
+	var filter repositories.GRIRFilter
+	if value := c.Query("as_of"); value != "" {
+		asOf, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid as_of, expected YYYY-MM-DD", err)
+			return
+		}
+		filter.AsOf = asOf
+	}
+	supplierID, err := optionalUUID(c.Query("supplier_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid supplier_id", err)
+		return
+	}
+	filter.SupplierID = supplierID
+
+	report, err := h.service.GRIRReport(c.Request.Context(), filter)
+	if err != nil {
+		matchError(c, "Failed to build GR/IR report", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "GR/IR report retrieved successfully", report)
+}
+
+// optionalUUID parses a UUID that may be left out, nil when it is.
+func optionalUUID(value string) (*uuid.ID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// matchError maps three-way match errors to HTTP responses.
+func matchError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrPurchaseVoucherNotFound), errors.Is(err, entities.ErrMatchExceptionNotFound):
+		response.Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, entities.ErrMatchExceptionResolved), errors.Is(err, entities.ErrMatchExceptionsUnresolved):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidInvoiceMatch), errors.Is(err, entities.ErrInvalidMatchTolerance):
+		response.Error(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/finance/domain/entities"
+	"malaka/internal/modules/finance/domain/repositories"
+	"malaka/internal/modules/finance/domain/services"
+	"malaka/internal/modules/finance/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// WithholdingTaxHandler handles HTTP requests for withholding tax: rules, supplier
+// payments and withholding slips.
+type WithholdingTaxHandler struct {
+	service *services.WithholdingTaxService
+}
+
+// NewWithholdingTaxHandler creates a new WithholdingTaxHandler.
+func NewWithholdingTaxHandler(service *services.WithholdingTaxService) *WithholdingTaxHandler {
+	return &WithholdingTaxHandler{service: service}
+}
+
+// CreateRule handles the creation of a withholding rule.
+func (h *WithholdingTaxHandler) CreateRule(c *gin.Context) {
+	var req dto.WithholdingRuleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.SupplierID != "" {
+		if _, err := uuid.Parse(req.SupplierID); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid supplier_id", err)
+			return
+		}
+	}
+
+	rule := req.ToWithholdingRuleEntity()
+	rule.CreatedBy = c.GetString("user_id")
+	if err := h.service.CreateRule(c.Request.Context(), rule); err != nil {
+		withholdingError(c, "Failed to create withholding rule", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Withholding rule created successfully", rule)
+}
+
+// UpdateRule handles the update of a withholding rule.
+func (h *WithholdingTaxHandler) UpdateRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	var req dto.WithholdingRuleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	rule, err := h.service.UpdateRule(c.Request.Context(), id, req.ToWithholdingRuleEntity())
+	if err != nil {
+		withholdingError(c, "Failed to update withholding rule", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Withholding rule updated successfully", rule)
+}
+
+// ListRules handles listing the withholding rules (active=true for the active ones only).
+func (h *WithholdingTaxHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context(), c.Query("active") == "true")
+	if err != nil {
+		withholdingError(c, "Failed to retrieve withholding rules", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Withholding rules retrieved successfully", rules)
+}
+
+// PaySupplier handles paying a payable net of the tax withheld.
+func (h *WithholdingTaxHandler) PaySupplier(c *gin.Context) {
+	var req dto.SupplierPaymentCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	payableID, err := uuid.Parse(req.AccountsPayableID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid accounts_payable_id", err)
+		return
+	}
+	cashBankID, err := uuid.Parse(req.CashBankID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid cash_bank_id", err)
+		return
+	}
+	var paymentDate time.Time
+	if req.PaymentDate != "" {
+		if paymentDate, err = time.Parse("2006-01-02", req.PaymentDate); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid payment_date, expected YYYY-MM-DD", err)
+			return
+		}
+	}
+
+	payment, err := h.service.PaySupplier(c.Request.Context(), &services.SupplierPaymentRequest{
+		AccountsPayableID: payableID,
+		CashBankID:        cashBankID,
+		PaymentDate:       paymentDate,
+		PaymentMethod:     req.PaymentMethod,
+		Amount:            req.Amount,
+		ServiceType:       req.ServiceType,
+		CreatedBy:         c.GetString("user_id"),
+	})
+	if err != nil {
+		withholdingError(c, "Failed to pay supplier", err)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Supplier paid successfully", payment)
+}
+
+// ListSlips handles listing the withholding slips, of a tax year, period, article and
+// supplier when given.
+func (h *WithholdingTaxHandler) ListSlips(c *gin.Context) {
+	filter := repositories.WithholdingSlipFilter{TaxArticle: c.Query("tax_article")}
+	filter.TaxYear, _ = strconv.Atoi(c.Query("year"))
+	filter.TaxPeriod, _ = strconv.Atoi(c.Query("period"))
+	if value := c.Query("supplier_id"); value != "" {
+		supplierID, err := uuid.Parse(value)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid supplier_id", err)
+			return
+		}
+		filter.SupplierID = &supplierID
+	}
+
+	slips, err := h.service.ListSlips(c.Request.Context(), filter)
+	if err != nil {
+		withholdingError(c, "Failed to retrieve withholding slips", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Withholding slips retrieved successfully", slips)
+}
+
+// GetSlip handles retrieving a withholding slip.
+func (h *WithholdingTaxHandler) GetSlip(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	slip, err := h.service.GetSlip(c.Request.Context(), id)
+	if err != nil {
+		withholdingError(c, "Failed to retrieve withholding slip", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Withholding slip retrieved successfully", slip)
+}
+
+// DownloadSlipPDF handles downloading a withholding slip as a PDF.
+func (h *WithholdingTaxHandler) DownloadSlipPDF(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+	slip, data, err := h.service.SlipPDF(c.Request.Context(), id)
+	if err != nil {
+		withholdingError(c, "Failed to render withholding slip", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=bukti-potong-%s-%02d-%04d.pdf",
+		entities.SlipNumberPrefix(slip.TaxArticle), slip.TaxPeriod, slip.Sequence))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// ExportEBupotCSV handles downloading the slips of a month (year and period) as an
+// e-Bupot import CSV.
+func (h *WithholdingTaxHandler) ExportEBupotCSV(c *gin.Context) {
+	year, period := taxMonth(c)
+	data, err := h.service.ExportEBupotCSV(c.Request.Context(), year, period)
+	if err != nil {
+		withholdingError(c, "Failed to export withholding slips", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=ebupot-%04d-%02d.csv", year, period))
+	c.Data(http.StatusOK, "text/csv", data)
+}
+
+// ExportEBupotXML handles downloading the slips of a month (year and period) as a
+// Coretax e-Bupot import XML.
+func (h *WithholdingTaxHandler) ExportEBupotXML(c *gin.Context) {
+	year, period := taxMonth(c)
+	data, err := h.service.ExportEBupotXML(c.Request.Context(), year, period)
+	if err != nil {
+		withholdingError(c, "Failed to export withholding slips", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=ebupot-%04d-%02d.xml", year, period))
+	c.Data(http.StatusOK, "application/xml", data)
+}
+
+// GetMonthlySummary handles the withholding of a month (year and period) per tax
+// article and object code, for the withholding tax return.
+func (h *WithholdingTaxHandler) GetMonthlySummary(c *gin.Context) {
+	year, period := taxMonth(c)
+	summary, err := h.service.MonthlySummary(c.Request.Context(), year, period)
+	if err != nil {
+		withholdingError(c, "Failed to summarize withholding", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Withholding summary retrieved successfully", summary)
+}
+
+// taxMonth reads the tax year and period of the query, the current month by default.
+func taxMonth(c *gin.Context) (int, int) {
+	now := time.Now()
+	year, period := now.Year(), int(now.Month())
+	if value := c.Query("year"); value != "" {
+		year, _ = strconv.Atoi(value)
+	}
+	if value := c.Query("period"); value != "" {
+		period, _ = strconv.Atoi(value)
+	}
+	return year, period
+}
+
+// withholdingError maps withholding tax errors to HTTP responses.
+func withholdingError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrWithholdingRuleNotFound), errors.Is(err, entities.ErrWithholdingSlipNotFound),
+		errors.Is(err, entities.ErrPayableNotFound):
+		response.Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, entities.ErrWithholdingRuleExists), errors.Is(err, entities.ErrMatchExceptionsUnresolved):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidWithholdingRule), errors.Is(err, entities.ErrInvalidSupplierPayment):
+		response.Error(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
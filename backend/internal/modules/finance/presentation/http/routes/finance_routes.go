@@ -8,7 +8,7 @@ import (
 )
 
 // RegisterFinanceRoutes registers the finance routes.
-func RegisterFinanceRoutes(router *gin.RouterGroup, cashBankHandler *handlers.CashBankHandler, paymentHandler *handlers.PaymentHandler, invoiceHandler *handlers.InvoiceHandler, accountsPayableHandler *handlers.AccountsPayableHandler, accountsReceivableHandler *handlers.AccountsReceivableHandler, cashDisbursementHandler *handlers.CashDisbursementHandler, cashReceiptHandler *handlers.CashReceiptHandler, bankTransferHandler *handlers.BankTransferHandler, cashOpeningBalanceHandler *handlers.CashOpeningBalanceHandler, purchaseVoucherHandler *handlers.PurchaseVoucherHandler, expenditureRequestHandler *handlers.ExpenditureRequestHandler, checkClearanceHandler *handlers.CheckClearanceHandler, monthlyClosingHandler *handlers.MonthlyClosingHandler, cashBookHandler *handlers.CashBookHandler, budgetHandler *handlers.BudgetHandler, capexProjectHandler *handlers.CapexProjectHandler, loanFacilityHandler *handlers.LoanFacilityHandler, financialForecastHandler *handlers.FinancialForecastHandler, financeReportHandler *handlers.FinanceReportHandler, rbacSvc *auth.RBACService) {
+func RegisterFinanceRoutes(router *gin.RouterGroup, cashBankHandler *handlers.CashBankHandler, paymentHandler *handlers.PaymentHandler, invoiceHandler *handlers.InvoiceHandler, accountsPayableHandler *handlers.AccountsPayableHandler, accountsReceivableHandler *handlers.AccountsReceivableHandler, cashDisbursementHandler *handlers.CashDisbursementHandler, cashReceiptHandler *handlers.CashReceiptHandler, bankTransferHandler *handlers.BankTransferHandler, cashOpeningBalanceHandler *handlers.CashOpeningBalanceHandler, purchaseVoucherHandler *handlers.PurchaseVoucherHandler, expenditureRequestHandler *handlers.ExpenditureRequestHandler, checkClearanceHandler *handlers.CheckClearanceHandler, monthlyClosingHandler *handlers.MonthlyClosingHandler, cashBookHandler *handlers.CashBookHandler, budgetHandler *handlers.BudgetHandler, capexProjectHandler *handlers.CapexProjectHandler, loanFacilityHandler *handlers.LoanFacilityHandler, financialForecastHandler *handlers.FinancialForecastHandler, financeReportHandler *handlers.FinanceReportHandler, withholdingTaxHandler *handlers.WithholdingTaxHandler, threeWayMatchHandler *handlers.ThreeWayMatchHandler, rbacSvc *auth.RBACService) {
 	finance := router.Group("/finance")
 	finance.Use(auth.RequireModuleAccess(rbacSvc, "finance"))
 	{
@@ -216,5 +216,32 @@ func RegisterFinanceRoutes(router *gin.RouterGroup, cashBankHandler *handlers.Ca
 			financeReports.PUT("/:id", auth.RequirePermission(rbacSvc, "finance.finance-report.update"), financeReportHandler.UpdateFinanceReport)
 			financeReports.DELETE("/:id", auth.RequirePermission(rbacSvc, "finance.finance-report.delete"), financeReportHandler.DeleteFinanceReport)
 		}
+
+		// Withholding tax routes (PPh 23 / 4(2) withheld from supplier payments)
+		withholding := finance.Group("/withholding")
+		{
+			withholding.GET("/rules", auth.RequirePermission(rbacSvc, "finance.withholding.list"), withholdingTaxHandler.ListRules)
+			withholding.POST("/rules", auth.RequirePermission(rbacSvc, "finance.withholding.manage"), withholdingTaxHandler.CreateRule)
+			withholding.PUT("/rules/:id", auth.RequirePermission(rbacSvc, "finance.withholding.manage"), withholdingTaxHandler.UpdateRule)
+			withholding.POST("/supplier-payments", auth.RequirePermission(rbacSvc, "finance.withholding.create"), withholdingTaxHandler.PaySupplier)
+			withholding.GET("/slips", auth.RequirePermission(rbacSvc, "finance.withholding.list"), withholdingTaxHandler.ListSlips)
+			withholding.GET("/slips/export/csv", auth.RequirePermission(rbacSvc, "finance.withholding.export"), withholdingTaxHandler.ExportEBupotCSV)
+			withholding.GET("/slips/export/xml", auth.RequirePermission(rbacSvc, "finance.withholding.export"), withholdingTaxHandler.ExportEBupotXML)
+			withholding.GET("/slips/:id", auth.RequirePermission(rbacSvc, "finance.withholding.read"), withholdingTaxHandler.GetSlip)
+			withholding.GET("/slips/:id/pdf", auth.RequirePermission(rbacSvc, "finance.withholding.read"), withholdingTaxHandler.DownloadSlipPDF)
+			withholding.GET("/summary", auth.RequirePermission(rbacSvc, "finance.withholding.list"), withholdingTaxHandler.GetMonthlySummary)
+		}
+
+		// Three-way match routes (supplier invoice vs purchase order vs goods receipt)
+		threeWayMatch := finance.Group("/three-way-match")
+		{
+			threeWayMatch.GET("/tolerance", auth.RequirePermission(rbacSvc, "finance.three-way-match.read"), threeWayMatchHandler.GetTolerance)
+			threeWayMatch.PUT("/tolerance", auth.RequirePermission(rbacSvc, "finance.three-way-match.manage"), threeWayMatchHandler.SetTolerance)
+			threeWayMatch.POST("/vouchers/:id", auth.RequirePermission(rbacSvc, "finance.three-way-match.create"), threeWayMatchHandler.MatchInvoice)
+			threeWayMatch.GET("/vouchers/:id", auth.RequirePermission(rbacSvc, "finance.three-way-match.read"), threeWayMatchHandler.GetVoucherMatch)
+			threeWayMatch.GET("/exceptions", auth.RequirePermission(rbacSvc, "finance.three-way-match.read"), threeWayMatchHandler.ListExceptions)
+			threeWayMatch.POST("/exceptions/:id/resolve", auth.RequirePermission(rbacSvc, "finance.three-way-match.resolve"), threeWayMatchHandler.ResolveException)
+			threeWayMatch.GET("/gr-ir-report", auth.RequirePermission(rbacSvc, "finance.three-way-match.read"), threeWayMatchHandler.GetGRIRReport)
+		}
 	}
 }
@@ -0,0 +1,74 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"malaka/internal/modules/hr/domain/entities"
+	"malaka/internal/modules/hr/domain/services"
+	"malaka/internal/shared/events"
+)
+
+// HREventHandler handles events from other modules that affect HR
+type HREventHandler struct {
+	leaveService services.LeaveService
+}
+
+// NewHREventHandler creates a new HR event handler
+func NewHREventHandler(leaveService services.LeaveService) *HREventHandler {
+	return &HREventHandler{
+		leaveService: leaveService,
+	}
+}
+
+// RegisterHandlers registers all event handlers with the event bus
+func (h *HREventHandler) RegisterHandlers(bus events.EventBus) {
+	// Subscribe to Calendar events
+	bus.Subscribe(events.EventTypeCollectiveLeaveDeclared, h.HandleCollectiveLeaveDeclared)
+	bus.Subscribe(events.EventTypeCollectiveLeaveRevoked, h.HandleCollectiveLeaveRevoked)
+
+	log.Println("HR event handlers registered")
+}
+
+// HandleCollectiveLeaveDeclared deducts a cuti bersama day from the leave balances
+// of the employees covered by the holiday calendar
+func (h *HREventHandler) HandleCollectiveLeaveDeclared(ctx context.Context, event events.Event) error {
+	leaveEvent, ok := event.(*events.CollectiveLeaveDeclaredEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type for collective leave declared handler")
+	}
+
+	deducted, err := h.leaveService.ApplyCollectiveLeave(ctx, &entities.CollectiveLeaveDeduction{
+		HolidayID:     leaveEvent.HolidayID,
+		LeaveTypeCode: leaveEvent.LeaveTypeCode,
+		Date:          leaveEvent.Date,
+		CompanyID:     leaveEvent.CompanyID,
+		RegionCode:    leaveEvent.RegionCode,
+		Days:          1,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[HR] Collective leave %s on %s: deducted %s from %d leave balances",
+		leaveEvent.Name, leaveEvent.Date.Format("2006-01-02"), leaveEvent.LeaveTypeCode, deducted)
+	return nil
+}
+
+// HandleCollectiveLeaveRevoked restores the leave deducted for a removed cuti bersama day
+func (h *HREventHandler) HandleCollectiveLeaveRevoked(ctx context.Context, event events.Event) error {
+	leaveEvent, ok := event.(*events.CollectiveLeaveRevokedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type for collective leave revoked handler")
+	}
+
+	restored, err := h.leaveService.RevertCollectiveLeave(ctx, leaveEvent.HolidayID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[HR] Collective leave %s on %s revoked: restored %d leave balances",
+		leaveEvent.Name, leaveEvent.Date.Format("2006-01-02"), restored)
+	return nil
+}
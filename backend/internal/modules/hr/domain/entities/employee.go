@@ -31,6 +31,8 @@ type Employee struct {
 	EmploymentStatus string     `json:"employment_status" db:"employment_status"`
 	SupervisorID     *string    `json:"supervisor_id" db:"supervisor_id"`
 	UserID           *string    `json:"user_id,omitempty" db:"user_id"`
+	CompanyID        *string    `json:"company_id,omitempty" db:"company_id"`
+	RegionCode       string     `json:"region_code" db:"region_code"`
 }
 
 // TableName returns the table name for the Employee entity.
@@ -106,3 +106,14 @@ type LeaveRequestWithDetails struct {
 	Attachments     []LeaveAttachment      `json:"attachments,omitempty"`
 	ApprovalHistory []LeaveApprovalHistory `json:"approval_history,omitempty"`
 }
+
+// CollectiveLeaveDeduction describes a cuti bersama day to deduct from leave balances.
+// Empty CompanyID/RegionCode mean every company/region.
+type CollectiveLeaveDeduction struct {
+	HolidayID     string    `json:"holiday_id"`
+	LeaveTypeCode string    `json:"leave_type_code"`
+	Date          time.Time `json:"date"`
+	CompanyID     string    `json:"company_id,omitempty"`
+	RegionCode    string    `json:"region_code,omitempty"`
+	Days          int       `json:"days"`
+}
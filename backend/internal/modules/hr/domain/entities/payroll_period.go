@@ -24,6 +24,20 @@ type PayrollPeriod struct {
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 }
 
+// PayrollWorkingDays is the working day count of a payroll month
+type PayrollWorkingDays struct {
+	PeriodYear   int       `json:"period_year"`
+	PeriodMonth  int       `json:"period_month"`
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	EmployeeID   *uuid.ID  `json:"employee_id,omitempty"`
+	CompanyID    string    `json:"company_id,omitempty"`
+	RegionCode   string    `json:"region_code,omitempty"`
+	WeekdayCount int       `json:"weekday_count"` // Monday-Friday
+	HolidayCount int       `json:"holiday_count"` // Holidays falling on weekdays
+	WorkingDays  int       `json:"working_days"`
+}
+
 // PayrollPeriodStatus constants
 const (
 	PayrollStatusDraft      = "DRAFT"
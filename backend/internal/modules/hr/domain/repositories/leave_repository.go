@@ -11,6 +11,7 @@ type LeaveRepository interface {
 	// Leave Types
 	CreateLeaveType(ctx context.Context, leaveType *entities.LeaveType) error
 	GetLeaveTypeByID(ctx context.Context, id uuid.ID) (*entities.LeaveType, error)
+	GetLeaveTypeByCode(ctx context.Context, code string) (*entities.LeaveType, error)
 	GetAllLeaveTypes(ctx context.Context) ([]*entities.LeaveType, error)
 	UpdateLeaveType(ctx context.Context, leaveType *entities.LeaveType) error
 	DeleteLeaveType(ctx context.Context, id uuid.ID) error
@@ -52,4 +53,8 @@ type LeaveRepository interface {
 	ApproveLeaveRequest(ctx context.Context, requestID uuid.ID, approvedBy uuid.ID, comments *string) error
 	RejectLeaveRequest(ctx context.Context, requestID uuid.ID, rejectedBy uuid.ID, reason string) error
 	CancelLeaveRequest(ctx context.Context, requestID uuid.ID, cancelledBy uuid.ID, reason string) error
+
+	// Collective Leave (cuti bersama)
+	ApplyCollectiveLeave(ctx context.Context, deduction *entities.CollectiveLeaveDeduction) (int, error)
+	RevertCollectiveLeave(ctx context.Context, holidayID string) (int, error)
 }
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"malaka/internal/modules/hr/domain/entities"
+	"time"
 )
 
 type LeaveService interface {
@@ -35,4 +36,9 @@ type LeaveService interface {
 	CalculateLeaveDays(ctx context.Context, startDate, endDate string, autoDeductWeekends, autoDeductHolidays bool) (int, error)
 	ValidateLeaveRequest(ctx context.Context, request *entities.LeaveRequest) error
 	CheckLeaveBalance(ctx context.Context, employeeID, leaveTypeID string, requestedDays int, year int) (bool, error)
+	CalculateEmployeeLeaveDays(ctx context.Context, employeeID string, start, end time.Time) (int, error)
+
+	// Collective Leave (cuti bersama)
+	ApplyCollectiveLeave(ctx context.Context, deduction *entities.CollectiveLeaveDeduction) (int, error)
+	RevertCollectiveLeave(ctx context.Context, holidayID string) (int, error)
 }
\ No newline at end of file
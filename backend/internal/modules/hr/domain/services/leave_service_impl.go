@@ -6,17 +6,27 @@ import (
 	"fmt"
 	"malaka/internal/modules/hr/domain/entities"
 	"malaka/internal/modules/hr/domain/repositories"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/uuid"
 	"time"
 )
 
 type leaveServiceImpl struct {
-	leaveRepo repositories.LeaveRepository
+	leaveRepo      repositories.LeaveRepository
+	employeeRepo   repositories.EmployeeRepository
+	holidayReader  integration.HolidayReader
+	approvalEngine integration.ApprovalEngine
 }
 
-func NewLeaveService(leaveRepo repositories.LeaveRepository) LeaveService {
+// NewLeaveService creates a leave service. Holidays are read from the company holiday
+// calendar through holidayReader; when it is nil only weekends are excluded from leave days.
+// Leave requests are routed through approvalEngine; when it is nil a single approval is final.
+func NewLeaveService(leaveRepo repositories.LeaveRepository, employeeRepo repositories.EmployeeRepository, holidayReader integration.HolidayReader, approvalEngine integration.ApprovalEngine) LeaveService {
 	return &leaveServiceImpl{
-		leaveRepo: leaveRepo,
+		leaveRepo:      leaveRepo,
+		employeeRepo:   employeeRepo,
+		holidayReader:  holidayReader,
+		approvalEngine: approvalEngine,
 	}
 }
 
@@ -77,8 +87,8 @@ func (s *leaveServiceImpl) CreateLeaveRequest(ctx context.Context, request *enti
 		return err
 	}
 
-	// Calculate total days
-	totalDays, err := s.CalculateLeaveDays(ctx, request.StartDate.Format("2006-01-02"), request.EndDate.Format("2006-01-02"), true, true)
+	// Calculate total days against the employee's holiday calendar
+	totalDays, err := s.CalculateEmployeeLeaveDays(ctx, request.EmployeeID.String(), request.StartDate, request.EndDate)
 	if err != nil {
 		return fmt.Errorf("failed to calculate leave days: %w", err)
 	}
@@ -94,7 +104,20 @@ func (s *leaveServiceImpl) CreateLeaveRequest(ctx context.Context, request *enti
 		return errors.New("insufficient leave balance")
 	}
 
-	return s.leaveRepo.CreateLeaveRequest(ctx, request)
+	if err := s.leaveRepo.CreateLeaveRequest(ctx, request); err != nil {
+		return err
+	}
+
+	if s.approvalEngine != nil && request.Status == "pending" {
+		doc, err := s.approvalDocument(ctx, request)
+		if err != nil {
+			return err
+		}
+		if _, err := s.approvalEngine.Submit(ctx, doc); err != nil {
+			return fmt.Errorf("failed to submit leave request for approval: %w", err)
+		}
+	}
+	return nil
 }
 
 func (s *leaveServiceImpl) GetLeaveRequestByID(ctx context.Context, id string) (*entities.LeaveRequestWithDetails, error) {
@@ -174,6 +197,9 @@ func (s *leaveServiceImpl) UpdateLeaveBalance(ctx context.Context, balance *enti
 }
 
 // Leave Approval Actions
+
+// ApproveLeaveRequest records the approval of the acting user. The leave request is only
+// approved once the last step of its approval workflow is complete.
 func (s *leaveServiceImpl) ApproveLeaveRequest(ctx context.Context, requestID string, approvedBy string, comments *string) error {
 	if requestID == "" {
 		return errors.New("leave request ID is required")
@@ -185,11 +211,37 @@ func (s *leaveServiceImpl) ApproveLeaveRequest(ctx context.Context, requestID st
 	if err != nil {
 		return errors.New("invalid leave request ID format")
 	}
-	approverUUID, err := uuid.Parse(approvedBy)
-	if err != nil {
+	if _, err := uuid.Parse(approvedBy); err != nil {
 		return errors.New("invalid approver ID format")
 	}
-	return s.leaveRepo.ApproveLeaveRequest(ctx, requestUUID, approverUUID, comments)
+
+	if s.approvalEngine != nil {
+		request, err := s.pendingLeaveRequest(ctx, requestUUID)
+		if err != nil {
+			return err
+		}
+		doc, err := s.approvalDocument(ctx, request)
+		if err != nil {
+			return err
+		}
+		note := ""
+		if comments != nil {
+			note = *comments
+		}
+		outcome, err := s.approvalEngine.Approve(ctx, doc, approvedBy, note)
+		if err != nil {
+			return err
+		}
+		if !outcome.IsApproved() {
+			return nil
+		}
+	}
+
+	approverEmployeeID, err := s.actorEmployeeID(ctx, approvedBy)
+	if err != nil {
+		return err
+	}
+	return s.leaveRepo.ApproveLeaveRequest(ctx, requestUUID, approverEmployeeID, comments)
 }
 
 func (s *leaveServiceImpl) RejectLeaveRequest(ctx context.Context, requestID string, rejectedBy string, reason string) error {
@@ -206,11 +258,29 @@ func (s *leaveServiceImpl) RejectLeaveRequest(ctx context.Context, requestID str
 	if err != nil {
 		return errors.New("invalid leave request ID format")
 	}
-	rejectorUUID, err := uuid.Parse(rejectedBy)
-	if err != nil {
+	if _, err := uuid.Parse(rejectedBy); err != nil {
 		return errors.New("invalid rejector ID format")
 	}
-	return s.leaveRepo.RejectLeaveRequest(ctx, requestUUID, rejectorUUID, reason)
+
+	if s.approvalEngine != nil {
+		request, err := s.pendingLeaveRequest(ctx, requestUUID)
+		if err != nil {
+			return err
+		}
+		doc, err := s.approvalDocument(ctx, request)
+		if err != nil {
+			return err
+		}
+		if _, err := s.approvalEngine.Reject(ctx, doc, rejectedBy, reason); err != nil {
+			return err
+		}
+	}
+
+	rejectorEmployeeID, err := s.actorEmployeeID(ctx, rejectedBy)
+	if err != nil {
+		return err
+	}
+	return s.leaveRepo.RejectLeaveRequest(ctx, requestUUID, rejectorEmployeeID, reason)
 }
 
 func (s *leaveServiceImpl) CancelLeaveRequest(ctx context.Context, requestID string, cancelledBy string, reason string) error {
@@ -227,11 +297,76 @@ func (s *leaveServiceImpl) CancelLeaveRequest(ctx context.Context, requestID str
 	if err != nil {
 		return errors.New("invalid leave request ID format")
 	}
-	cancellerUUID, err := uuid.Parse(cancelledBy)
-	if err != nil {
+	if _, err := uuid.Parse(cancelledBy); err != nil {
 		return errors.New("invalid canceller ID format")
 	}
-	return s.leaveRepo.CancelLeaveRequest(ctx, requestUUID, cancellerUUID, reason)
+
+	if s.approvalEngine != nil {
+		if err := s.approvalEngine.Cancel(ctx, integration.ApprovalDocLeaveRequest, requestUUID.String(), cancelledBy, reason); err != nil {
+			return err
+		}
+	}
+
+	cancellerEmployeeID, err := s.actorEmployeeID(ctx, cancelledBy)
+	if err != nil {
+		return err
+	}
+	return s.leaveRepo.CancelLeaveRequest(ctx, requestUUID, cancellerEmployeeID, reason)
+}
+
+func (s *leaveServiceImpl) pendingLeaveRequest(ctx context.Context, requestID uuid.ID) (*entities.LeaveRequest, error) {
+	details, err := s.leaveRepo.GetLeaveRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leave request: %w", err)
+	}
+	if details == nil || details.LeaveRequest == nil {
+		return nil, errors.New("leave request not found")
+	}
+	if details.Status != "pending" {
+		return nil, fmt.Errorf("leave request cannot be decided in status %s", details.Status)
+	}
+	return details.LeaveRequest, nil
+}
+
+// approvalDocument describes the leave request to the approval engine. The amount is the
+// number of leave days, so workflows can add a step for long leave.
+func (s *leaveServiceImpl) approvalDocument(ctx context.Context, request *entities.LeaveRequest) (*integration.ApprovalDocument, error) {
+	doc := &integration.ApprovalDocument{
+		DocumentType:        integration.ApprovalDocLeaveRequest,
+		DocumentID:          request.ID.String(),
+		RequesterEmployeeID: request.EmployeeID.String(),
+		Amount:              float64(request.TotalDays),
+	}
+	if s.employeeRepo == nil {
+		return doc, nil
+	}
+	employee, err := s.employeeRepo.GetByID(ctx, request.EmployeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employee: %w", err)
+	}
+	if employee != nil {
+		doc.DocumentNumber = employee.EmployeeCode
+		doc.Department = employee.Department
+		if employee.UserID != nil {
+			doc.RequesterID = *employee.UserID
+		}
+		if employee.CompanyID != nil {
+			doc.CompanyID = *employee.CompanyID
+		}
+	}
+	return doc, nil
+}
+
+// actorEmployeeID maps the acting user to their employee record, which the leave tables
+// reference. IDs without a linked user are taken as employee IDs.
+func (s *leaveServiceImpl) actorEmployeeID(ctx context.Context, actorID string) (uuid.ID, error) {
+	if s.employeeRepo != nil {
+		employee, err := s.employeeRepo.GetByUserID(ctx, actorID)
+		if err == nil && employee != nil {
+			return employee.ID, nil
+		}
+	}
+	return uuid.Parse(actorID)
 }
 
 // Business Logic
@@ -250,6 +385,35 @@ func (s *leaveServiceImpl) CalculateLeaveDays(ctx context.Context, startDate, en
 		return 0, errors.New("end date cannot be before start date")
 	}
 
+	return s.countLeaveDays(ctx, "", "", start, end, autoDeductWeekends, autoDeductHolidays)
+}
+
+// CalculateEmployeeLeaveDays counts leave days for an employee, excluding weekends and the
+// holidays of the employee's company and region
+func (s *leaveServiceImpl) CalculateEmployeeLeaveDays(ctx context.Context, employeeID string, start, end time.Time) (int, error) {
+	if end.Before(start) {
+		return 0, errors.New("end date cannot be before start date")
+	}
+
+	companyID, regionCode, err := s.employeeHolidayScope(ctx, employeeID)
+	if err != nil {
+		return 0, err
+	}
+	return s.countLeaveDays(ctx, companyID, regionCode, start, end, true, true)
+}
+
+func (s *leaveServiceImpl) countLeaveDays(ctx context.Context, companyID, regionCode string, start, end time.Time, autoDeductWeekends, autoDeductHolidays bool) (int, error) {
+	holidays := map[string]bool{}
+	if autoDeductHolidays && s.holidayReader != nil {
+		list, err := s.holidayReader.GetHolidays(ctx, companyID, regionCode, start, end)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get holidays: %w", err)
+		}
+		for _, h := range list {
+			holidays[h.Date.Format("2006-01-02")] = true
+		}
+	}
+
 	totalDays := 0
 	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
 		// Skip weekends if auto deduction is enabled
@@ -257,8 +421,8 @@ func (s *leaveServiceImpl) CalculateLeaveDays(ctx context.Context, startDate, en
 			continue
 		}
 
-		// Skip holidays if auto deduction is enabled (for now, just skip Indonesian public holidays)
-		if autoDeductHolidays && s.isPublicHoliday(d) {
+		// Skip holidays (including cuti bersama, already deducted from the balance)
+		if holidays[d.Format("2006-01-02")] {
 			continue
 		}
 
@@ -268,6 +432,31 @@ func (s *leaveServiceImpl) CalculateLeaveDays(ctx context.Context, startDate, en
 	return totalDays, nil
 }
 
+// employeeHolidayScope returns the company and region whose holiday calendars apply to the employee
+func (s *leaveServiceImpl) employeeHolidayScope(ctx context.Context, employeeID string) (string, string, error) {
+	if s.employeeRepo == nil {
+		return "", "", nil
+	}
+
+	employeeUUID, err := uuid.Parse(employeeID)
+	if err != nil {
+		return "", "", errors.New("invalid employee ID format")
+	}
+	employee, err := s.employeeRepo.GetByID(ctx, employeeUUID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get employee: %w", err)
+	}
+	if employee == nil {
+		return "", "", errors.New("employee not found")
+	}
+
+	companyID := ""
+	if employee.CompanyID != nil {
+		companyID = *employee.CompanyID
+	}
+	return companyID, employee.RegionCode, nil
+}
+
 func (s *leaveServiceImpl) ValidateLeaveRequest(ctx context.Context, request *entities.LeaveRequest) error {
 	if request.EmployeeID.IsNil() {
 		return errors.New("employee ID is required")
@@ -329,20 +518,32 @@ func (s *leaveServiceImpl) CheckLeaveBalance(ctx context.Context, employeeID, le
 	return false, nil
 }
 
-// Helper function to check Indonesian public holidays (simplified version)
-func (s *leaveServiceImpl) isPublicHoliday(date time.Time) bool {
-	// This is a simplified version. In a real application, you would
-	// check against a database of public holidays
-	month := int(date.Month())
-	day := date.Day()
+// Collective Leave (cuti bersama)
+func (s *leaveServiceImpl) ApplyCollectiveLeave(ctx context.Context, deduction *entities.CollectiveLeaveDeduction) (int, error) {
+	if deduction.HolidayID == "" {
+		return 0, errors.New("holiday ID is required")
+	}
+	if deduction.Date.IsZero() {
+		return 0, errors.New("collective leave date is required")
+	}
+	if deduction.Date.Weekday() == time.Saturday || deduction.Date.Weekday() == time.Sunday {
+		// Weekends are not working days, nothing to deduct
+		return 0, nil
+	}
+	if deduction.Days <= 0 {
+		deduction.Days = 1
+	}
 
-	// Some fixed Indonesian holidays
-	holidays := map[string]bool{
-		"01-01": true, // New Year
-		"08-17": true, // Independence Day
-		"12-25": true, // Christmas
+	if _, err := s.leaveRepo.GetLeaveTypeByCode(ctx, deduction.LeaveTypeCode); err != nil {
+		return 0, fmt.Errorf("leave type %s not found: %w", deduction.LeaveTypeCode, err)
 	}
 
-	key := fmt.Sprintf("%02d-%02d", month, day)
-	return holidays[key]
+	return s.leaveRepo.ApplyCollectiveLeave(ctx, deduction)
+}
+
+func (s *leaveServiceImpl) RevertCollectiveLeave(ctx context.Context, holidayID string) (int, error) {
+	if holidayID == "" {
+		return 0, errors.New("holiday ID is required")
+	}
+	return s.leaveRepo.RevertCollectiveLeave(ctx, holidayID)
 }
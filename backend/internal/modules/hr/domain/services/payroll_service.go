@@ -26,7 +26,8 @@ type PayrollService interface {
 
 	// Payroll processing
 	ProcessPayroll(ctx context.Context, year, month int) error
-	ApprovePayroll(ctx context.Context, periodID uuid.ID) error
+	ApprovePayroll(ctx context.Context, periodID uuid.ID, approverID string) (bool, error) // true once the final approval is given
+	GetWorkingDays(ctx context.Context, year, month int, employeeID *uuid.ID) (*entities.PayrollWorkingDays, error)
 
 	// Frontend DTO operations
 	GetPayrollItemsDTO(ctx context.Context, year, month int) ([]*entities.PayrollItemDTO, error)
@@ -7,6 +7,7 @@ import (
 
 	"malaka/internal/modules/hr/domain/entities"
 	"malaka/internal/modules/hr/domain/repositories"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/uuid"
 )
 
@@ -15,18 +16,26 @@ type PayrollServiceImpl struct {
 	payrollPeriodRepo     repositories.PayrollPeriodRepository
 	salaryCalculationRepo repositories.SalaryCalculationRepository
 	employeeRepo          repositories.EmployeeRepository
+	holidayReader         integration.HolidayReader
+	approvalEngine        integration.ApprovalEngine
 }
 
-// NewPayrollService creates a new instance of PayrollService
+// NewPayrollService creates a new instance of PayrollService.
+// Working days are counted against the company holiday calendar read through holidayReader.
+// Payroll approval is routed through approvalEngine; when it is nil a single approval is final.
 func NewPayrollService(
 	payrollPeriodRepo repositories.PayrollPeriodRepository,
 	salaryCalculationRepo repositories.SalaryCalculationRepository,
 	employeeRepo repositories.EmployeeRepository,
+	holidayReader integration.HolidayReader,
+	approvalEngine integration.ApprovalEngine,
 ) PayrollService {
 	return &PayrollServiceImpl{
 		payrollPeriodRepo:     payrollPeriodRepo,
 		salaryCalculationRepo: salaryCalculationRepo,
 		employeeRepo:          employeeRepo,
+		holidayReader:         holidayReader,
+		approvalEngine:        approvalEngine,
 	}
 }
 
@@ -213,29 +222,54 @@ func (s *PayrollServiceImpl) ProcessPayroll(ctx context.Context, year, month int
 	period.TotalNetSalary = totalNet
 	period.TotalDeductions = totalDeductions
 
-	return s.payrollPeriodRepo.Update(ctx, period)
+	if err := s.payrollPeriodRepo.Update(ctx, period); err != nil {
+		return err
+	}
+
+	// Processed payroll waits for approval
+	if s.approvalEngine != nil {
+		if _, err := s.approvalEngine.Submit(ctx, payrollApprovalDocument(period)); err != nil {
+			return fmt.Errorf("failed to submit payroll for approval: %w", err)
+		}
+	}
+	return nil
 }
 
-func (s *PayrollServiceImpl) ApprovePayroll(ctx context.Context, periodID uuid.ID) error {
+// ApprovePayroll records the approval of approverID. The period and its salary calculations
+// are only approved once the last step of the payroll approval workflow is complete.
+func (s *PayrollServiceImpl) ApprovePayroll(ctx context.Context, periodID uuid.ID, approverID string) (bool, error) {
 	// Get the payroll period
 	period, err := s.payrollPeriodRepo.GetByID(ctx, periodID)
 	if err != nil {
-		return fmt.Errorf("payroll period not found: %w", err)
+		return false, fmt.Errorf("payroll period not found: %w", err)
 	}
 
 	if !period.CanBeApproved() {
-		return fmt.Errorf("payroll period cannot be approved in current status: %s", period.Status)
+		return false, fmt.Errorf("payroll period cannot be approved in current status: %s", period.Status)
+	}
+
+	if s.approvalEngine != nil {
+		outcome, err := s.approvalEngine.Approve(ctx, payrollApprovalDocument(period), approverID, "")
+		if err != nil {
+			return false, err
+		}
+		if !outcome.IsApproved() {
+			return false, nil
+		}
 	}
 
 	// Update period status
 	period.Status = entities.PayrollStatusApproved
 	now := time.Now()
 	period.ApprovedAt = &now
+	if approver, err := uuid.Parse(approverID); err == nil {
+		period.ApprovedBy = &approver
+	}
 
 	// Update all salary calculations to approved status
 	calculations, err := s.salaryCalculationRepo.GetByPeriod(ctx, period.PeriodYear, period.PeriodMonth)
 	if err != nil {
-		return fmt.Errorf("failed to get salary calculations: %w", err)
+		return false, fmt.Errorf("failed to get salary calculations: %w", err)
 	}
 
 	for _, calc := range calculations {
@@ -243,12 +277,74 @@ func (s *PayrollServiceImpl) ApprovePayroll(ctx context.Context, periodID uuid.I
 			calc.Status = entities.SalaryStatusApproved
 			err = s.salaryCalculationRepo.Update(ctx, calc)
 			if err != nil {
-				return fmt.Errorf("failed to update salary calculation %s: %w", calc.ID.String(), err)
+				return false, fmt.Errorf("failed to update salary calculation %s: %w", calc.ID.String(), err)
 			}
 		}
 	}
 
-	return s.payrollPeriodRepo.Update(ctx, period)
+	if err := s.payrollPeriodRepo.Update(ctx, period); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// payrollApprovalDocument describes a payroll period to the approval engine
+func payrollApprovalDocument(period *entities.PayrollPeriod) *integration.ApprovalDocument {
+	return &integration.ApprovalDocument{
+		DocumentType:   integration.ApprovalDocPayrollPeriod,
+		DocumentID:     period.ID.String(),
+		DocumentNumber: fmt.Sprintf("PAYROLL-%d-%02d", period.PeriodYear, period.PeriodMonth),
+		Amount:         period.TotalNetSalary,
+	}
+}
+
+// GetWorkingDays counts the working days of a payroll month. When employeeID is given the
+// holidays of the employee's company and region apply, otherwise only the shared calendars.
+func (s *PayrollServiceImpl) GetWorkingDays(ctx context.Context, year, month int, employeeID *uuid.ID) (*entities.PayrollWorkingDays, error) {
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("invalid month: %d", month)
+	}
+
+	period := &entities.PayrollPeriod{PeriodYear: year, PeriodMonth: month}
+	result := &entities.PayrollWorkingDays{
+		PeriodYear:  year,
+		PeriodMonth: month,
+		StartDate:   period.StartDate(),
+		EndDate:     period.EndDate(),
+	}
+
+	if employeeID != nil {
+		employee, err := s.employeeRepo.GetByID(ctx, *employeeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get employee: %w", err)
+		}
+		if employee == nil {
+			return nil, fmt.Errorf("employee not found")
+		}
+		result.EmployeeID = employeeID
+		if employee.CompanyID != nil {
+			result.CompanyID = *employee.CompanyID
+		}
+		result.RegionCode = employee.RegionCode
+	}
+
+	for d := result.StartDate; !d.After(result.EndDate); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			result.WeekdayCount++
+		}
+	}
+	result.WorkingDays = result.WeekdayCount
+
+	if s.holidayReader != nil {
+		workingDays, err := s.holidayReader.CountWorkingDays(ctx, result.CompanyID, result.RegionCode, result.StartDate, result.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count working days: %w", err)
+		}
+		result.WorkingDays = workingDays
+	}
+	result.HolidayCount = result.WeekdayCount - result.WorkingDays
+
+	return result, nil
 }
 
 // Frontend DTO operations
@@ -55,6 +55,25 @@ func (r *leaveRepositoryImpl) GetLeaveTypeByID(ctx context.Context, id uuid.ID)
 	return leaveType, nil
 }
 
+func (r *leaveRepositoryImpl) GetLeaveTypeByCode(ctx context.Context, code string) (*entities.LeaveType, error) {
+	query := `
+		SELECT id, name, code, description, max_days_per_year, requires_approval, is_paid, is_active, created_at, updated_at
+		FROM leave_types WHERE code = $1`
+
+	row := r.db.QueryRowContext(ctx, query, code)
+
+	leaveType := &entities.LeaveType{}
+	err := row.Scan(
+		&leaveType.ID, &leaveType.Name, &leaveType.Code, &leaveType.Description,
+		&leaveType.MaxDaysPerYear, &leaveType.RequiresApproval, &leaveType.IsPaid,
+		&leaveType.IsActive, &leaveType.CreatedAt, &leaveType.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+	return leaveType, nil
+}
+
 func (r *leaveRepositoryImpl) GetAllLeaveTypes(ctx context.Context) ([]*entities.LeaveType, error) {
 	query := `
 		SELECT id, name, code, description, max_days_per_year, requires_approval, is_paid, is_active, created_at, updated_at
@@ -779,3 +798,62 @@ func (r *leaveRepositoryImpl) CancelLeaveRequest(ctx context.Context, requestID
 
 	return tx.Commit()
 }
+
+// Collective Leave (cuti bersama)
+
+// ApplyCollectiveLeave deducts the collective leave day from the balances of the active
+// employees covered by the holiday. Each employee is deducted at most once per holiday.
+func (r *leaveRepositoryImpl) ApplyCollectiveLeave(ctx context.Context, deduction *entities.CollectiveLeaveDeduction) (int, error) {
+	query := `
+		WITH targets AS (
+			SELECT lb.id AS leave_balance_id, lb.employee_id
+			FROM leave_balances lb
+			JOIN employees e ON e.id = lb.employee_id
+			JOIN leave_types lt ON lt.id = lb.leave_type_id
+			WHERE lt.code = $2 AND lb.year = $3
+			  AND COALESCE(e.employment_status, 'ACTIVE') = 'ACTIVE'
+			  AND e.hire_date <= $4
+			  AND ($5 = '' OR e.company_id::text = $5)
+			  AND ($6 = '' OR e.region_code = $6)
+		), inserted AS (
+			INSERT INTO leave_collective_deductions (id, holiday_id, employee_id, leave_balance_id, days, created_at)
+			SELECT gen_random_uuid(), $1, employee_id, leave_balance_id, $7, NOW() FROM targets
+			ON CONFLICT (holiday_id, employee_id) DO NOTHING
+			RETURNING leave_balance_id, days
+		)
+		UPDATE leave_balances lb
+		SET used_days = lb.used_days + i.days, remaining_days = lb.remaining_days - i.days, updated_at = NOW()
+		FROM inserted i
+		WHERE lb.id = i.leave_balance_id`
+
+	result, err := r.db.ExecContext(ctx, query,
+		deduction.HolidayID, deduction.LeaveTypeCode, deduction.Date.Year(), deduction.Date,
+		deduction.CompanyID, deduction.RegionCode, deduction.Days)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
+
+// RevertCollectiveLeave restores the leave deducted for a holiday
+func (r *leaveRepositoryImpl) RevertCollectiveLeave(ctx context.Context, holidayID string) (int, error) {
+	query := `
+		WITH removed AS (
+			DELETE FROM leave_collective_deductions WHERE holiday_id = $1
+			RETURNING leave_balance_id, days
+		)
+		UPDATE leave_balances lb
+		SET used_days = lb.used_days - r.days, remaining_days = lb.remaining_days + r.days, updated_at = NOW()
+		FROM removed r
+		WHERE lb.id = r.leave_balance_id`
+
+	result, err := r.db.ExecContext(ctx, query, holidayID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
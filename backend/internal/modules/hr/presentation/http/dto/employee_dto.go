@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"strings"
 	"time"
 
 	"malaka/internal/modules/hr/domain/entities"
@@ -28,6 +29,7 @@ type EmployeeCreateRequest struct {
 	EmploymentStatus string  `json:"employment_status" binding:"required,oneof=ACTIVE INACTIVE TERMINATED"`
 	SupervisorID     *string `json:"supervisor_id"`
 	UserID           *string `json:"user_id"`
+	RegionCode       string  `json:"region_code"` // Selects regional holiday calendars
 }
 
 // ToEmployeeEntity converts EmployeeCreateRequest to entities.Employee.
@@ -67,6 +69,7 @@ func (req *EmployeeCreateRequest) ToEmployeeEntity() (*entities.Employee, error)
 		EmploymentStatus: req.EmploymentStatus,
 		SupervisorID:     req.SupervisorID,
 		UserID:           req.UserID,
+		RegionCode:       strings.ToUpper(req.RegionCode),
 	}, nil
 }
 
@@ -92,6 +95,7 @@ type EmployeeUpdateRequest struct {
 	EmploymentStatus *string `json:"employment_status" binding:"omitempty,oneof=ACTIVE INACTIVE TERMINATED"`
 	SupervisorID     *string `json:"supervisor_id"`
 	UserID           *string `json:"user_id"`
+	RegionCode       *string `json:"region_code"`
 }
 
 // ToEmployeeEntity converts EmployeeUpdateRequest to entities.Employee.
@@ -172,6 +176,9 @@ func (req *EmployeeUpdateRequest) ToEmployeeEntity(existing *entities.Employee)
 			existing.UserID = nil
 		}
 	}
+	if req.RegionCode != nil {
+		existing.RegionCode = strings.ToUpper(*req.RegionCode)
+	}
 	return existing, nil
 }
 
@@ -199,6 +206,8 @@ type EmployeeResponse struct {
 	EmploymentStatus string     `json:"employment_status"`
 	SupervisorID     *string    `json:"supervisor_id"`
 	UserID           *string    `json:"user_id,omitempty"`
+	CompanyID        *string    `json:"company_id,omitempty"`
+	RegionCode       string     `json:"region_code"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 }
@@ -228,6 +237,8 @@ func FromEmployeeEntity(employee *entities.Employee) *EmployeeResponse {
 		EmploymentStatus: employee.EmploymentStatus,
 		SupervisorID:     employee.SupervisorID,
 		UserID:           employee.UserID,
+		CompanyID:        employee.CompanyID,
+		RegionCode:       employee.RegionCode,
 		CreatedAt:        employee.CreatedAt,
 		UpdatedAt:        employee.UpdatedAt,
 	}
@@ -1,23 +1,32 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/response"
 )
 
 type AttendanceHandler struct {
-	db *sql.DB
+	db            *sql.DB
+	holidayReader integration.HolidayReader
 }
 
 func NewAttendanceHandler(db *sql.DB) *AttendanceHandler {
 	return &AttendanceHandler{db: db}
 }
 
+// SetHolidayReader sets the holiday calendar used to flag attendance on holidays
+func (h *AttendanceHandler) SetHolidayReader(reader integration.HolidayReader) {
+	h.holidayReader = reader
+}
+
 // AttendanceRecord represents the database structure
 type AttendanceRecord struct {
 	ID               string  `json:"id" db:"id"`
@@ -37,6 +46,11 @@ type AttendanceRecord struct {
 	ApprovedAt       *string `json:"approved_at" db:"approved_at"`
 	CreatedAt        string  `json:"created_at" db:"created_at"`
 	UpdatedAt        string  `json:"updated_at" db:"updated_at"`
+	IsHoliday        bool    `json:"is_holiday"`
+	HolidayName      string  `json:"holiday_name,omitempty"`
+
+	companyID  string
+	regionCode string
 }
 
 // GetAttendanceRecords handles GET /api/v1/hr/attendance
@@ -49,13 +63,15 @@ func (h *AttendanceHandler) GetAttendanceRecords(c *gin.Context) {
 	// Query attendance records from database
 	query := `
 		SELECT 
-			id, employee_id, attendance_date, 
-			scheduled_in, scheduled_out, actual_in, actual_out,
-			late_minutes, early_out_minutes, work_hours, overtime_hours,
-			status, remarks, approved_by, approved_at,
-			created_at, updated_at
-		FROM daily_attendance_tracking 
-		ORDER BY attendance_date DESC, created_at DESC
+			dat.id, dat.employee_id, dat.attendance_date, 
+			dat.scheduled_in, dat.scheduled_out, dat.actual_in, dat.actual_out,
+			dat.late_minutes, dat.early_out_minutes, dat.work_hours, dat.overtime_hours,
+			dat.status, dat.remarks, dat.approved_by, dat.approved_at,
+			dat.created_at, dat.updated_at,
+			COALESCE(e.company_id::text, ''), COALESCE(e.region_code, '')
+		FROM daily_attendance_tracking dat
+		LEFT JOIN employees e ON e.id = dat.employee_id
+		ORDER BY dat.attendance_date DESC, dat.created_at DESC
 		LIMIT 100
 	`
 	
@@ -76,6 +92,7 @@ func (h *AttendanceHandler) GetAttendanceRecords(c *gin.Context) {
 			&record.LateMinutes, &record.EarlyOutMinutes, &record.WorkHours, &record.OvertimeHours,
 			&record.Status, &record.Remarks, &record.ApprovedBy, &record.ApprovedAt,
 			&record.CreatedAt, &record.UpdatedAt,
+			&record.companyID, &record.regionCode,
 		)
 		if err != nil {
 			log.Printf("Error scanning attendance record: %v", err)
@@ -90,6 +107,8 @@ func (h *AttendanceHandler) GetAttendanceRecords(c *gin.Context) {
 		return
 	}
 	
+	h.markHolidays(c.Request.Context(), records)
+
 	log.Printf("Successfully fetched %d attendance records", len(records))
 	response.Success(c, http.StatusOK, fmt.Sprintf("Successfully retrieved %d attendance records", len(records)), records)
 }
@@ -119,4 +138,50 @@ func (h *AttendanceHandler) UpdateAttendanceRecord(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Attendance update endpoint ready", map[string]interface{}{
 		"record_id": recordID,
 	})
-}
\ No newline at end of file
+}
+
+// markHolidays flags records that fall on a holiday of the employee's company and region
+func (h *AttendanceHandler) markHolidays(ctx context.Context, records []AttendanceRecord) {
+	if h.holidayReader == nil || len(records) == 0 {
+		return
+	}
+
+	// Records are ordered by date descending
+	end, errEnd := time.Parse("2006-01-02", attendanceDay(records[0].AttendanceDate))
+	start, errStart := time.Parse("2006-01-02", attendanceDay(records[len(records)-1].AttendanceDate))
+	if errEnd != nil || errStart != nil {
+		return
+	}
+
+	holidaysByScope := map[string]map[string]string{}
+	for i := range records {
+		record := &records[i]
+		scope := record.companyID + "|" + record.regionCode
+		holidays, ok := holidaysByScope[scope]
+		if !ok {
+			holidays = map[string]string{}
+			list, err := h.holidayReader.GetHolidays(ctx, record.companyID, record.regionCode, start, end)
+			if err != nil {
+				log.Printf("Error loading holidays for attendance records: %v", err)
+				return
+			}
+			for _, holiday := range list {
+				holidays[holiday.Date.Format("2006-01-02")] = holiday.Name
+			}
+			holidaysByScope[scope] = holidays
+		}
+
+		if name, isHoliday := holidays[attendanceDay(record.AttendanceDate)]; isHoliday {
+			record.IsHoliday = true
+			record.HolidayName = name
+		}
+	}
+}
+
+// attendanceDay returns the YYYY-MM-DD part of a scanned date value
+func attendanceDay(value string) string {
+	if len(value) > 10 {
+		return value[:10]
+	}
+	return value
+}
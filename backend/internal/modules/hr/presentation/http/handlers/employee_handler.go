@@ -34,6 +34,9 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	if companyID := c.GetString("company_id"); companyID != "" {
+		employee.CompanyID = &companyID
+	}
 
 	if err := h.service.CreateEmployee(c.Request.Context(), employee); err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error(), nil)
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 	"time"
 
 	"malaka/internal/modules/hr/domain/entities"
 	"malaka/internal/modules/hr/domain/services"
 	"malaka/internal/modules/hr/presentation/http/dto"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/response"
 	"malaka/internal/shared/uuid"
 
@@ -256,19 +259,32 @@ func (h *LeaveHandler) ApproveLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	// In a real application, you would get the approver ID from the authenticated user context
-	approverID := c.GetHeader("X-User-ID") // Temporary solution
+	approverID := actingUserID(c)
 	if approverID == "" {
 		response.BadRequest(c, "User not authenticated", "Missing user context")
 		return
 	}
 
 	if err := h.leaveService.ApproveLeaveRequest(c.Request.Context(), id, approverID, req.Comments); err != nil {
+		if errors.Is(err, integration.ErrNotApprover) {
+			response.Error(c, http.StatusForbidden, "Not authorized to approve this leave request", err)
+			return
+		}
 		response.InternalServerError(c, "Failed to approve leave request", err.Error())
 		return
 	}
 
-	response.OK(c, "Leave request approved successfully", nil)
+	// Multi-level workflows keep the request pending until the last step is approved
+	leaveRequest, err := h.leaveService.GetLeaveRequestByID(c.Request.Context(), id)
+	if err != nil || leaveRequest == nil || leaveRequest.LeaveRequest == nil {
+		response.OK(c, "Leave request approval recorded", nil)
+		return
+	}
+	if leaveRequest.Status != "approved" {
+		response.OK(c, "Leave request approval recorded, awaiting next approver", dto.ToLeaveRequestResponse(leaveRequest.LeaveRequest))
+		return
+	}
+	response.OK(c, "Leave request approved successfully", dto.ToLeaveRequestResponse(leaveRequest.LeaveRequest))
 }
 
 func (h *LeaveHandler) RejectLeaveRequest(c *gin.Context) {
@@ -279,14 +295,17 @@ func (h *LeaveHandler) RejectLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	// In a real application, you would get the rejector ID from the authenticated user context
-	rejectorID := c.GetHeader("X-User-ID") // Temporary solution
+	rejectorID := actingUserID(c)
 	if rejectorID == "" {
 		response.BadRequest(c, "User not authenticated", "Missing user context")
 		return
 	}
 
 	if err := h.leaveService.RejectLeaveRequest(c.Request.Context(), id, rejectorID, req.Reason); err != nil {
+		if errors.Is(err, integration.ErrNotApprover) {
+			response.Error(c, http.StatusForbidden, "Not authorized to reject this leave request", err)
+			return
+		}
 		response.InternalServerError(c, "Failed to reject leave request", err.Error())
 		return
 	}
@@ -302,8 +321,7 @@ func (h *LeaveHandler) CancelLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	// In a real application, you would get the canceller ID from the authenticated user context
-	cancellerID := c.GetHeader("X-User-ID") // Temporary solution
+	cancellerID := actingUserID(c)
 	if cancellerID == "" {
 		response.BadRequest(c, "User not authenticated", "Missing user context")
 		return
@@ -390,4 +408,13 @@ func (h *LeaveHandler) GetLeaveStatistics(c *gin.Context) {
 	}
 
 	response.OK(c, "Leave statistics retrieved successfully", statistics)
-}
\ No newline at end of file
+}
+
+// actingUserID returns the authenticated user, falling back to the X-User-ID header
+// sent by older clients
+func actingUserID(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return userID
+	}
+	return c.GetHeader("X-User-ID")
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,7 @@ import (
 	"malaka/internal/modules/hr/domain/entities"
 	"malaka/internal/modules/hr/domain/services"
 	"malaka/internal/modules/hr/presentation/http/dto"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/response"
 	"malaka/internal/shared/uuid"
 )
@@ -244,11 +246,19 @@ func (h *PayrollHandler) ApprovePayroll(c *gin.Context) {
 		return
 	}
 
-	err = h.payrollService.ApprovePayroll(c.Request.Context(), id)
+	approved, err := h.payrollService.ApprovePayroll(c.Request.Context(), id, c.GetString("user_id"))
 	if err != nil {
+		if errors.Is(err, integration.ErrNotApprover) {
+			response.Error(c, http.StatusForbidden, "Not authorized to approve this payroll", err)
+			return
+		}
 		response.BadRequest(c, "Failed to approve payroll", err.Error())
 		return
 	}
+	if !approved {
+		response.Success(c, http.StatusOK, "Payroll approval recorded, awaiting next approver", nil)
+		return
+	}
 
 	response.Success(c, http.StatusOK, "Payroll approved successfully", nil)
 }
@@ -282,4 +292,44 @@ func (h *PayrollHandler) GetPayrollItems(c *gin.Context) {
 	}
 
 	response.Success(c, http.StatusOK, "Payroll items retrieved successfully", payrollItems)
-}
\ No newline at end of file
+}
+// GetWorkingDays handles GET /payroll/working-days?year=&month=[&employee_id=]
+func (h *PayrollHandler) GetWorkingDays(c *gin.Context) {
+	yearStr := c.Query("year")
+	monthStr := c.Query("month")
+
+	if yearStr == "" || monthStr == "" {
+		response.BadRequest(c, "Year and month parameters are required", "")
+		return
+	}
+
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		response.BadRequest(c, "Invalid year parameter", err.Error())
+		return
+	}
+
+	month, err := strconv.Atoi(monthStr)
+	if err != nil {
+		response.BadRequest(c, "Invalid month parameter", err.Error())
+		return
+	}
+
+	var employeeID *uuid.ID
+	if employeeIDStr := c.Query("employee_id"); employeeIDStr != "" {
+		id, err := uuid.Parse(employeeIDStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid employee_id parameter", err.Error())
+			return
+		}
+		employeeID = &id
+	}
+
+	workingDays, err := h.payrollService.GetWorkingDays(c.Request.Context(), year, month, employeeID)
+	if err != nil {
+		response.BadRequest(c, "Failed to count working days", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Working days retrieved successfully", workingDays)
+}
@@ -48,6 +48,7 @@ func RegisterHRRoutes(router *gin.RouterGroup, employeeHandler *handlers.Employe
 
 			// Frontend compatibility endpoints
 			payroll.GET("/items", auth.RequirePermission(rbacSvc, "hr.payroll.list"), payrollHandler.GetPayrollItems)
+			payroll.GET("/working-days", auth.RequirePermission(rbacSvc, "hr.payroll.read"), payrollHandler.GetWorkingDays)
 		}
 
 		// Attendance routes
@@ -0,0 +1,365 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// Landed cost errors
+var (
+	ErrLandedCostVoucherNotFound = errors.New("landed cost voucher not found")
+	ErrInvalidLandedCostVoucher  = errors.New("invalid landed cost voucher")
+)
+
+// Landed cost allocation methods: the basis each receipt line gets its share by
+const (
+	LandedCostByValue    = "value"
+	LandedCostByQuantity = "quantity"
+	LandedCostByWeight   = "weight"
+	LandedCostByVolume   = "volume"
+)
+
+// Landed cost charge types
+const (
+	LandedCostFreight   = "freight"
+	LandedCostInsurance = "insurance"
+	LandedCostDuty      = "duty"
+	LandedCostClearance = "clearance"
+	LandedCostOther     = "other"
+)
+
+// LandedCostChargeTypes lists the charge types in the order they are journalled.
+var LandedCostChargeTypes = []string{
+	LandedCostFreight, LandedCostInsurance, LandedCostDuty, LandedCostClearance, LandedCostOther,
+}
+
+// LandedCostBaseCurrency is the currency of the cost layers. Charges in another currency
+// are converted at the voucher's exchange rate before they are allocated.
+const LandedCostBaseCurrency = "IDR"
+
+// Landed cost voucher statuses
+const (
+	LandedCostDraft     = "draft"
+	LandedCostPosted    = "posted"
+	LandedCostCancelled = "cancelled"
+)
+
+// CostLayer is the stock brought in by one posted goods receipt line, at its purchase
+// price plus the landed costs allocated to it since.
+type CostLayer struct {
+	ID                 string    `json:"id" db:"id"`
+	GoodsReceiptID     string    `json:"goods_receipt_id" db:"goods_receipt_id"`
+	GoodsReceiptItemID string    `json:"goods_receipt_item_id" db:"goods_receipt_item_id"`
+	ArticleID          string    `json:"article_id,omitempty" db:"article_id"`
+	WarehouseID        string    `json:"warehouse_id" db:"warehouse_id"`
+	ItemName           string    `json:"item_name" db:"item_name"`
+	ReceivedQuantity   int       `json:"received_quantity" db:"received_quantity"`
+	UnitCost           float64   `json:"unit_cost" db:"unit_cost"`
+	LandedCost         float64   `json:"landed_cost" db:"landed_cost"`
+	ReceivedAt         time.Time `json:"received_at" db:"received_at"`
+}
+
+// PurchaseValue is the layer's value at its purchase price.
+func (l *CostLayer) PurchaseValue() float64 {
+	return float64(l.ReceivedQuantity) * l.UnitCost
+}
+
+// LandedUnitCost is the unit cost including the landed costs allocated to the layer.
+func (l *CostLayer) LandedUnitCost() float64 {
+	if l.ReceivedQuantity == 0 {
+		return l.UnitCost
+	}
+	return l.UnitCost + l.LandedCost/float64(l.ReceivedQuantity)
+}
+
+// LandedCostVoucher attaches additional charges, such as freight, insurance, import duty
+// and clearance fees invoiced separately from the goods, to one or more posted goods
+// receipts and allocates them over the receipt lines. The charges and their total are in
+// the voucher's currency; the allocations and the inventory and COGS amounts are in IDR.
+type LandedCostVoucher struct {
+	types.BaseModel
+	VoucherNumber    string     `json:"voucher_number" db:"voucher_number"`
+	VoucherDate      time.Time  `json:"voucher_date" db:"voucher_date"`
+	AllocationMethod string     `json:"allocation_method" db:"allocation_method"`
+	Currency         string     `json:"currency" db:"currency"`
+	ExchangeRate     float64    `json:"exchange_rate" db:"exchange_rate"` // IDR per unit of the currency
+	TotalAmount      float64    `json:"total_amount" db:"total_amount"`
+	InventoryAmount  float64    `json:"inventory_amount" db:"inventory_amount"`
+	COGSAmount       float64    `json:"cogs_amount" db:"cogs_amount"`
+	Status           string     `json:"status" db:"status"`
+	Notes            string     `json:"notes" db:"notes"`
+	JournalEntryID   *string    `json:"journal_entry_id,omitempty" db:"journal_entry_id"`
+	CreatedBy        string     `json:"created_by" db:"created_by"`
+	PostedBy         *string    `json:"posted_by,omitempty" db:"posted_by"`
+	PostedAt         *time.Time `json:"posted_at,omitempty" db:"posted_at"`
+
+	Charges         []*LandedCostCharge     `json:"charges" db:"-"`
+	GoodsReceiptIDs []string                `json:"goods_receipt_ids" db:"-"`
+	Allocations     []*LandedCostAllocation `json:"allocations" db:"-"`
+}
+
+// LandedCostCharge is one charge invoice carried by a voucher.
+type LandedCostCharge struct {
+	ID            uuid.ID `json:"id" db:"id"`
+	VoucherID     uuid.ID `json:"voucher_id" db:"voucher_id"`
+	ChargeType    string  `json:"charge_type" db:"charge_type"`
+	SupplierID    *string `json:"supplier_id,omitempty" db:"supplier_id"`
+	InvoiceNumber string  `json:"invoice_number" db:"invoice_number"`
+	Description   string  `json:"description" db:"description"`
+	Amount        float64 `json:"amount" db:"amount"`
+}
+
+// LandedCostAllocation is the share of a voucher allocated to one cost layer. When the
+// voucher is posted the share is split between the units still on hand, which is added
+// to inventory, and the units already issued or sold, which is expensed to COGS.
+type LandedCostAllocation struct {
+	ID              uuid.ID `json:"id" db:"id"`
+	VoucherID       uuid.ID `json:"voucher_id" db:"voucher_id"`
+	CostLayerID     string  `json:"cost_layer_id" db:"cost_layer_id"`
+	GoodsReceiptID  string  `json:"goods_receipt_id" db:"goods_receipt_id"`
+	ItemName        string  `json:"item_name" db:"item_name"`
+	Quantity        int     `json:"quantity" db:"quantity"`
+	LineValue       float64 `json:"line_value" db:"line_value"`
+	Weight          float64 `json:"weight" db:"weight"`
+	Volume          float64 `json:"volume" db:"volume"`
+	AllocatedAmount float64 `json:"allocated_amount" db:"allocated_amount"`
+	OnHandQuantity  int     `json:"on_hand_quantity" db:"on_hand_quantity"`
+	InventoryAmount float64 `json:"inventory_amount" db:"inventory_amount"`
+	COGSAmount      float64 `json:"cogs_amount" db:"cogs_amount"`
+}
+
+// Basis returns the allocation's weight under an allocation method.
+func (a *LandedCostAllocation) Basis(method string) float64 {
+	switch method {
+	case LandedCostByQuantity:
+		return float64(a.Quantity)
+	case LandedCostByWeight:
+		return a.Weight
+	case LandedCostByVolume:
+		return a.Volume
+	default:
+		return a.LineValue
+	}
+}
+
+// SplitOnHand splits the allocated amount between the units still on hand and those
+// already gone.
+func (a *LandedCostAllocation) SplitOnHand(onHand int) {
+	if onHand > a.Quantity {
+		onHand = a.Quantity
+	}
+	if onHand < 0 {
+		onHand = 0
+	}
+	a.OnHandQuantity = onHand
+	a.InventoryAmount = a.AllocatedAmount
+	if a.Quantity > 0 {
+		a.InventoryAmount = roundAmount(a.AllocatedAmount * float64(onHand) / float64(a.Quantity))
+	}
+	a.COGSAmount = roundAmount(a.AllocatedAmount - a.InventoryAmount)
+}
+
+// Validate checks the voucher header and charges and totals the charges.
+func (v *LandedCostVoucher) Validate() error {
+	switch v.AllocationMethod {
+	case LandedCostByValue, LandedCostByQuantity, LandedCostByWeight, LandedCostByVolume:
+	default:
+		return fmt.Errorf("%w: allocation method must be value, quantity, weight or volume", ErrInvalidLandedCostVoucher)
+	}
+	if v.VoucherDate.IsZero() {
+		return fmt.Errorf("%w: voucher date is required", ErrInvalidLandedCostVoucher)
+	}
+	if len(v.Charges) == 0 {
+		return fmt.Errorf("%w: at least one charge is required", ErrInvalidLandedCostVoucher)
+	}
+	if len(v.GoodsReceiptIDs) == 0 {
+		return fmt.Errorf("%w: at least one goods receipt is required", ErrInvalidLandedCostVoucher)
+	}
+	seen := make(map[string]bool, len(v.GoodsReceiptIDs))
+	for _, id := range v.GoodsReceiptIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: goods receipt %s is listed twice", ErrInvalidLandedCostVoucher, id)
+		}
+		seen[id] = true
+	}
+
+	total := 0.0
+	for _, charge := range v.Charges {
+		switch charge.ChargeType {
+		case LandedCostFreight, LandedCostInsurance, LandedCostDuty, LandedCostClearance, LandedCostOther:
+		default:
+			return fmt.Errorf("%w: unknown charge type %q", ErrInvalidLandedCostVoucher, charge.ChargeType)
+		}
+		if charge.Amount <= 0 {
+			return fmt.Errorf("%w: charge amounts must be positive", ErrInvalidLandedCostVoucher)
+		}
+		charge.Amount = roundAmount(charge.Amount)
+		total += charge.Amount
+	}
+	v.TotalAmount = roundAmount(total)
+
+	v.Currency = strings.ToUpper(strings.TrimSpace(v.Currency))
+	if v.Currency == "" {
+		v.Currency = LandedCostBaseCurrency
+	}
+	if v.Currency == LandedCostBaseCurrency {
+		v.ExchangeRate = 1
+	} else if v.ExchangeRate <= 0 {
+		return fmt.Errorf("%w: an exchange rate to %s is required for charges in %s", ErrInvalidLandedCostVoucher, LandedCostBaseCurrency, v.Currency)
+	}
+	return nil
+}
+
+// rate returns the exchange rate to IDR, 1 for vouchers saved without one.
+func (v *LandedCostVoucher) rate() float64 {
+	if v.ExchangeRate <= 0 {
+		return 1
+	}
+	return v.ExchangeRate
+}
+
+// BaseAmount is the voucher total converted to IDR.
+func (v *LandedCostVoucher) BaseAmount() float64 {
+	return roundAmount(v.TotalAmount * v.rate())
+}
+
+// ChargeTotals sums the voucher's charges by charge type.
+func (v *LandedCostVoucher) ChargeTotals() map[string]float64 {
+	totals := make(map[string]float64)
+	for _, charge := range v.Charges {
+		totals[charge.ChargeType] = roundAmount(totals[charge.ChargeType] + charge.Amount)
+	}
+	return totals
+}
+
+// BaseChargeTotals sums the voucher's charges by charge type converted to IDR. The
+// rounding difference goes to the last charge type present, so the totals add up to the
+// base amount.
+func (v *LandedCostVoucher) BaseChargeTotals() map[string]float64 {
+	totals := v.ChargeTotals()
+	converted := 0.0
+	last := ""
+	for _, chargeType := range LandedCostChargeTypes {
+		if totals[chargeType] == 0 {
+			continue
+		}
+		totals[chargeType] = roundAmount(totals[chargeType] * v.rate())
+		converted += totals[chargeType]
+		last = chargeType
+	}
+	if last != "" {
+		totals[last] = roundAmount(totals[last] + v.BaseAmount() - converted)
+	}
+	return totals
+}
+
+// Allocate spreads the voucher total, converted to IDR, over its allocations in proportion
+// to their basis under the voucher's allocation method. Amounts are rounded to cents and
+// the rounding difference goes to the last line with a basis, so the allocations sum to
+// the base amount.
+func (v *LandedCostVoucher) Allocate() error {
+	if len(v.Allocations) == 0 {
+		return fmt.Errorf("%w: the goods receipts have no lines to allocate to", ErrInvalidLandedCostVoucher)
+	}
+	sum := 0.0
+	last := -1
+	for i, a := range v.Allocations {
+		basis := a.Basis(v.AllocationMethod)
+		if basis < 0 {
+			return fmt.Errorf("%w: %s of %s is negative", ErrInvalidLandedCostVoucher, v.AllocationMethod, a.ItemName)
+		}
+		if basis > 0 {
+			last = i
+		}
+		sum += basis
+	}
+	if sum == 0 {
+		return fmt.Errorf("%w: the receipt lines have no %s to allocate by", ErrInvalidLandedCostVoucher, v.AllocationMethod)
+	}
+
+	total := v.BaseAmount()
+	allocated := 0.0
+	for i, a := range v.Allocations {
+		a.OnHandQuantity, a.InventoryAmount, a.COGSAmount = 0, 0, 0
+		switch {
+		case i == last:
+			a.AllocatedAmount = roundAmount(total - allocated)
+		case i > last:
+			a.AllocatedAmount = 0
+		default:
+			a.AllocatedAmount = roundAmount(total * a.Basis(v.AllocationMethod) / sum)
+		}
+		allocated += a.AllocatedAmount
+	}
+	return nil
+}
+
+// RemainingByLayer assigns on-hand stock of one article in one warehouse to its cost
+// layers first in, first out: what is left is the newest stock, so the on-hand quantity
+// fills the layers from the newest back. Layers must be ordered oldest first; the result
+// is keyed by layer ID.
+func RemainingByLayer(layers []*CostLayer, onHand int) map[string]int {
+	remaining := make(map[string]int, len(layers))
+	for i := len(layers) - 1; i >= 0; i-- {
+		take := layers[i].ReceivedQuantity
+		if onHand < take {
+			take = onHand
+		}
+		if take < 0 {
+			take = 0
+		}
+		remaining[layers[i].ID] = take
+		onHand -= take
+	}
+	return remaining
+}
+
+// StockValuation is the value of the on-hand stock of an article from its cost layers,
+// landed costs included.
+type StockValuation struct {
+	ArticleID      string  `json:"article_id"`
+	OnHand         int     `json:"on_hand"`
+	FIFOValue      float64 `json:"fifo_value"`
+	AverageCost    float64 `json:"average_cost"`
+	AverageValue   float64 `json:"average_value"`
+	LayerQuantity  int     `json:"layer_quantity"`
+	LayerCostTotal float64 `json:"layer_cost_total"`
+}
+
+// ValueStock values the on-hand stock of an article from its cost layers. Layers are
+// grouped by warehouse and must be ordered oldest first; onHand is keyed by warehouse.
+// Stock without a layer, such as opening balances, is left out of the FIFO value.
+func ValueStock(articleID string, layers []*CostLayer, onHand map[string]int) *StockValuation {
+	valuation := &StockValuation{ArticleID: articleID}
+	byWarehouse := make(map[string][]*CostLayer)
+	for _, layer := range layers {
+		byWarehouse[layer.WarehouseID] = append(byWarehouse[layer.WarehouseID], layer)
+		valuation.LayerQuantity += layer.ReceivedQuantity
+		valuation.LayerCostTotal += layer.PurchaseValue() + layer.LandedCost
+	}
+	for warehouseID, quantity := range onHand {
+		valuation.OnHand += quantity
+		warehouseLayers := byWarehouse[warehouseID]
+		remaining := RemainingByLayer(warehouseLayers, quantity)
+		for _, layer := range warehouseLayers {
+			valuation.FIFOValue += float64(remaining[layer.ID]) * layer.LandedUnitCost()
+		}
+	}
+	if valuation.LayerQuantity > 0 {
+		valuation.AverageCost = valuation.LayerCostTotal / float64(valuation.LayerQuantity)
+	}
+	valuation.FIFOValue = roundAmount(valuation.FIFOValue)
+	valuation.AverageValue = roundAmount(valuation.AverageCost * float64(valuation.OnHand))
+	valuation.LayerCostTotal = roundAmount(valuation.LayerCostTotal)
+	return valuation
+}
+
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
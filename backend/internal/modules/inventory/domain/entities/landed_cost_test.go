@@ -0,0 +1,130 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLandedCostVoucher(method string, charges ...float64) *LandedCostVoucher {
+	voucher := &LandedCostVoucher{
+		VoucherDate:      time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		AllocationMethod: method,
+		GoodsReceiptIDs:  []string{"gr-1", "gr-2"},
+	}
+	for _, amount := range charges {
+		voucher.Charges = append(voucher.Charges, &LandedCostCharge{ChargeType: LandedCostFreight, Amount: amount})
+	}
+	return voucher
+}
+
+func TestLandedCostVoucher_Allocate(t *testing.T) {
+	voucher := newLandedCostVoucher(LandedCostByValue, 700, 300)
+	require.NoError(t, voucher.Validate())
+	assert.Equal(t, 1000.0, voucher.TotalAmount)
+	assert.Equal(t, "IDR", voucher.Currency)
+
+	voucher.Allocations = []*LandedCostAllocation{
+		{ItemName: "Leather", Quantity: 100, LineValue: 1000, Weight: 50},
+		{ItemName: "Sole", Quantity: 100, LineValue: 1000, Weight: 0},
+		{ItemName: "Lace", Quantity: 100, LineValue: 1000, Weight: 25},
+	}
+	require.NoError(t, voucher.Allocate())
+	// Thirds round to cents; the last line takes the difference
+	assert.Equal(t, 333.33, voucher.Allocations[0].AllocatedAmount)
+	assert.Equal(t, 333.33, voucher.Allocations[1].AllocatedAmount)
+	assert.Equal(t, 333.34, voucher.Allocations[2].AllocatedAmount)
+
+	voucher.AllocationMethod = LandedCostByWeight
+	require.NoError(t, voucher.Allocate())
+	assert.Equal(t, 666.67, voucher.Allocations[0].AllocatedAmount)
+	assert.Equal(t, 0.0, voucher.Allocations[1].AllocatedAmount)
+	assert.Equal(t, 333.33, voucher.Allocations[2].AllocatedAmount)
+
+	// Nothing to allocate by
+	voucher.AllocationMethod = LandedCostByVolume
+	assert.ErrorIs(t, voucher.Allocate(), ErrInvalidLandedCostVoucher)
+}
+
+func TestLandedCostVoucher_Validate(t *testing.T) {
+	assert.ErrorIs(t, newLandedCostVoucher("pallets", 100).Validate(), ErrInvalidLandedCostVoucher)
+	assert.ErrorIs(t, newLandedCostVoucher(LandedCostByValue).Validate(), ErrInvalidLandedCostVoucher)
+	assert.ErrorIs(t, newLandedCostVoucher(LandedCostByValue, -5).Validate(), ErrInvalidLandedCostVoucher)
+
+	voucher := newLandedCostVoucher(LandedCostByValue, 100)
+	voucher.GoodsReceiptIDs = []string{"gr-1", "gr-1"}
+	assert.ErrorIs(t, voucher.Validate(), ErrInvalidLandedCostVoucher)
+}
+
+func TestLandedCostVoucher_ConvertsForeignChargesToIDR(t *testing.T) {
+	voucher := newLandedCostVoucher(LandedCostByValue, 100.01)
+	voucher.Currency = "usd"
+	assert.ErrorIs(t, voucher.Validate(), ErrInvalidLandedCostVoucher)
+
+	voucher.ExchangeRate = 16000.5
+	voucher.Charges = append(voucher.Charges, &LandedCostCharge{ChargeType: LandedCostDuty, Amount: 50.02})
+	require.NoError(t, voucher.Validate())
+	assert.Equal(t, "USD", voucher.Currency)
+	assert.Equal(t, 150.03, voucher.TotalAmount)
+	assert.Equal(t, 2400555.02, voucher.BaseAmount())
+
+	// Each charge type converts on its own; the last one takes the rounding difference
+	charges := voucher.BaseChargeTotals()
+	assert.Equal(t, 1600210.01, charges[LandedCostFreight])
+	assert.InDelta(t, voucher.BaseAmount(), charges[LandedCostFreight]+charges[LandedCostDuty], 0.001)
+
+	voucher.Allocations = []*LandedCostAllocation{
+		{ItemName: "Leather", Quantity: 100, LineValue: 3000000},
+		{ItemName: "Sole", Quantity: 100, LineValue: 1000000},
+	}
+	require.NoError(t, voucher.Allocate())
+	assert.Equal(t, 1800416.27, voucher.Allocations[0].AllocatedAmount)
+	assert.Equal(t, 600138.75, voucher.Allocations[1].AllocatedAmount)
+
+	// IDR vouchers always carry a rate of 1
+	voucher = newLandedCostVoucher(LandedCostByValue, 100)
+	voucher.ExchangeRate = 3
+	require.NoError(t, voucher.Validate())
+	assert.Equal(t, 1.0, voucher.ExchangeRate)
+	assert.Equal(t, 100.0, voucher.BaseAmount())
+}
+
+func TestRemainingByLayer_SplitsSoldGoodsToCOGS(t *testing.T) {
+	layers := []*CostLayer{
+		{ID: "older", ReceivedQuantity: 100},
+		{ID: "newer", ReceivedQuantity: 50},
+	}
+	// 70 of the 150 received have been sold: first in, first out, from the older layer
+	remaining := RemainingByLayer(layers, 80)
+	assert.Equal(t, 30, remaining["older"])
+	assert.Equal(t, 50, remaining["newer"])
+
+	allocation := &LandedCostAllocation{Quantity: 100, AllocatedAmount: 1000}
+	allocation.SplitOnHand(remaining["older"])
+	assert.Equal(t, 30, allocation.OnHandQuantity)
+	assert.Equal(t, 300.0, allocation.InventoryAmount)
+	assert.Equal(t, 700.0, allocation.COGSAmount)
+
+	// All sold: the whole charge is expensed
+	allocation.SplitOnHand(RemainingByLayer(layers, 0)["older"])
+	assert.Equal(t, 0.0, allocation.InventoryAmount)
+	assert.Equal(t, 1000.0, allocation.COGSAmount)
+}
+
+func TestValueStock(t *testing.T) {
+	layers := []*CostLayer{
+		{ID: "a1", WarehouseID: "wh-a", ReceivedQuantity: 100, UnitCost: 10, LandedCost: 100},
+		{ID: "a2", WarehouseID: "wh-a", ReceivedQuantity: 100, UnitCost: 12},
+		{ID: "b1", WarehouseID: "wh-b", ReceivedQuantity: 50, UnitCost: 10, LandedCost: 50},
+	}
+	valuation := ValueStock("article-1", layers, map[string]int{"wh-a": 150, "wh-b": 10})
+
+	assert.Equal(t, 160, valuation.OnHand)
+	// wh-a: 100 x 12 + 50 x 11; wh-b: 10 x 11
+	assert.Equal(t, 1860.0, valuation.FIFOValue)
+	// (1100 + 1200 + 550) / 250 received
+	assert.InDelta(t, 11.4, valuation.AverageCost, 0.0001)
+	assert.Equal(t, 1824.0, valuation.AverageValue)
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"malaka/internal/modules/inventory/domain/entities"
+)
+
+// LandedCostFilter narrows the landed cost vouchers listed.
+type LandedCostFilter struct {
+	Status         string
+	GoodsReceiptID string
+	Page           int
+	Limit          int
+}
+
+// LandedCostRepository defines the interface for inventory cost layers and landed cost
+// voucher data operations.
+type LandedCostRepository interface {
+	// Cost layers
+	EnsureCostLayers(ctx context.Context, goodsReceiptID string) error
+	ListCostLayersByReceipts(ctx context.Context, goodsReceiptIDs []string) ([]*entities.CostLayer, error)
+	ListStockCostLayers(ctx context.Context, articleID string) ([]*entities.CostLayer, error)
+	GetOnHandByWarehouse(ctx context.Context, articleID string) (map[string]int, error)
+
+	// Vouchers
+	GetNextVoucherNumber(ctx context.Context) (string, error)
+	Create(ctx context.Context, voucher *entities.LandedCostVoucher) error
+	GetByID(ctx context.Context, id string) (*entities.LandedCostVoucher, error)
+	List(ctx context.Context, filter *LandedCostFilter) ([]*entities.LandedCostVoucher, int, error)
+	Update(ctx context.Context, voucher *entities.LandedCostVoucher) error
+	Cancel(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+
+	// Post adds the voucher's allocations to the cost layers and marks it posted, as one
+	// transaction; it fails if the voucher is no longer a draft.
+	Post(ctx context.Context, voucher *entities.LandedCostVoucher) error
+	SetJournalEntry(ctx context.Context, id, journalEntryID string) error
+}
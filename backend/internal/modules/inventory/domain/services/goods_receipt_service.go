@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 
 	"malaka/internal/modules/inventory/domain/entities"
 	"malaka/internal/modules/inventory/domain/repositories"
@@ -11,7 +12,8 @@ import (
 
 // GoodsReceiptService provides business logic for goods receipt operations.
 type GoodsReceiptService struct {
-	repo repositories.GoodsReceiptRepository
+	repo       repositories.GoodsReceiptRepository
+	costLayers repositories.LandedCostRepository
 }
 
 // NewGoodsReceiptService creates a new GoodsReceiptService.
@@ -19,6 +21,11 @@ func NewGoodsReceiptService(repo repositories.GoodsReceiptRepository) *GoodsRece
 	return &GoodsReceiptService{repo: repo}
 }
 
+// SetCostLayerRepository sets where the cost layers of posted receipts are recorded.
+func (s *GoodsReceiptService) SetCostLayerRepository(costLayers repositories.LandedCostRepository) {
+	s.costLayers = costLayers
+}
+
 // PostGoodsReceiptResult contains the result of posting a GR
 type PostGoodsReceiptResult struct {
 	GoodsReceipt *entities.GoodsReceipt
@@ -104,6 +111,14 @@ func (s *GoodsReceiptService) PostGoodsReceipt(ctx context.Context, id string, u
 		return nil, err
 	}
 
+	// Record the received lines as cost layers for landed costs and valuation; layers
+	// missed here are created when a landed cost voucher first uses the receipt
+	if s.costLayers != nil {
+		if err := s.costLayers.EnsureCostLayers(ctx, gr.ID.String()); err != nil {
+			log.Printf("[Inventory] Failed to record cost layers for GR %s: %v", gr.GRNumber, err)
+		}
+	}
+
 	return gr, nil
 }
 
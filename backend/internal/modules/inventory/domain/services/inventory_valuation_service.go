@@ -10,6 +10,7 @@ import (
 // InventoryValuationService provides business logic for inventory valuation.
 type InventoryValuationService struct {
 	stockMovementRepo repositories.StockMovementRepository
+	costLayers        repositories.LandedCostRepository
 }
 
 // NewInventoryValuationService creates a new InventoryValuationService.
@@ -17,11 +18,23 @@ func NewInventoryValuationService(smRepo repositories.StockMovementRepository) *
 	return &InventoryValuationService{stockMovementRepo: smRepo}
 }
 
-// CalculateFIFOValue calculates the value of stock using the FIFO method.
+// SetCostLayerRepository sets where the cost layers of posted goods receipts, landed
+// costs included, are read from for FIFO and average cost valuation.
+func (s *InventoryValuationService) SetCostLayerRepository(costLayers repositories.LandedCostRepository) {
+	s.costLayers = costLayers
+}
+
+// CalculateFIFOValue calculates the value of stock using the FIFO method: the stock on
+// hand is the newest received, valued at the landed cost of its layers.
 func (s *InventoryValuationService) CalculateFIFOValue(ctx context.Context, articleID string) (float64, error) {
-	// This is a simplified example. A real FIFO calculation would be more complex,
-	// involving tracking purchase prices and quantities over time.
-	return 0.0, errors.New("FIFO calculation not yet implemented")
+	if s.costLayers == nil {
+		return 0.0, errors.New("FIFO calculation not yet implemented")
+	}
+	valuation, err := valueArticle(ctx, s.costLayers, articleID)
+	if err != nil {
+		return 0.0, err
+	}
+	return valuation.FIFOValue, nil
 }
 
 // CalculateLIFOValue calculates the value of stock using the LIFO method.
@@ -30,8 +43,15 @@ func (s *InventoryValuationService) CalculateLIFOValue(ctx context.Context, arti
 	return 0.0, errors.New("LIFO calculation not yet implemented")
 }
 
-// CalculateAverageValue calculates the value of stock using the average cost method.
+// CalculateAverageValue calculates the value of stock using the average cost method,
+// averaged over the landed cost of every layer received.
 func (s *InventoryValuationService) CalculateAverageValue(ctx context.Context, articleID string) (float64, error) {
-	// This is a simplified example. A real average cost calculation would be more complex.
-	return 0.0, errors.New("Average cost calculation not yet implemented")
+	if s.costLayers == nil {
+		return 0.0, errors.New("Average cost calculation not yet implemented")
+	}
+	valuation, err := valueArticle(ctx, s.costLayers, articleID)
+	if err != nil {
+		return 0.0, err
+	}
+	return valuation.AverageValue, nil
 }
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	accounting_services "malaka/internal/modules/accounting/domain/services"
+	"malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/inventory/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// Journals posted for landed cost vouchers through the auto-journal account mappings:
+// inventory is debited with the share of the goods still on hand and COGS with the
+// share of the goods already issued or sold; the accrued charges are credited by type.
+const (
+	landedCostJournalSource = "INVENTORY"
+	landedCostJournalType   = "LANDED_COST"
+)
+
+// JournalPoster posts journal entries through the accounting auto-journal mappings.
+type JournalPoster interface {
+	CreateJournalFromTransaction(ctx context.Context, req *accounting_services.AutoJournalRequest) (*accounting_entities.JournalEntry, error)
+}
+
+// LineMeasure is the shipped weight and volume of a goods receipt line, the basis of
+// allocations by weight or volume.
+type LineMeasure struct {
+	Weight float64
+	Volume float64
+}
+
+// LandedCostService provides business logic for landed cost vouchers: allocating
+// freight, insurance, import duty and clearance charges over posted goods receipts and
+// adding them to the receipts' cost layers.
+type LandedCostService struct {
+	repo     repositories.LandedCostRepository
+	receipts repositories.GoodsReceiptRepository
+	journals JournalPoster
+}
+
+// NewLandedCostService creates a new LandedCostService.
+func NewLandedCostService(repo repositories.LandedCostRepository, receipts repositories.GoodsReceiptRepository) *LandedCostService {
+	return &LandedCostService{repo: repo, receipts: receipts}
+}
+
+// SetJournalPoster sets where landed cost journals are posted.
+func (s *LandedCostService) SetJournalPoster(journals JournalPoster) {
+	s.journals = journals
+}
+
+// CreateVoucher creates a draft voucher and allocates its charges over the lines of its
+// goods receipts. Measures are keyed by goods receipt item ID.
+func (s *LandedCostService) CreateVoucher(ctx context.Context, voucher *entities.LandedCostVoucher, measures map[string]LineMeasure) error {
+	if err := voucher.Validate(); err != nil {
+		return err
+	}
+	if err := s.allocate(ctx, voucher, measures, nil); err != nil {
+		return err
+	}
+
+	number, err := s.repo.GetNextVoucherNumber(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	voucher.ID = uuid.New()
+	voucher.VoucherNumber = number
+	voucher.Status = entities.LandedCostDraft
+	voucher.CreatedAt = now
+	voucher.UpdatedAt = now
+	return s.repo.Create(ctx, voucher)
+}
+
+// GetVoucher retrieves a voucher with its charges, receipts and allocations.
+func (s *LandedCostService) GetVoucher(ctx context.Context, id string) (*entities.LandedCostVoucher, error) {
+	voucher, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if voucher == nil {
+		return nil, entities.ErrLandedCostVoucherNotFound
+	}
+	return voucher, nil
+}
+
+// ListVouchers lists vouchers with the total count for paging.
+func (s *LandedCostService) ListVouchers(ctx context.Context, filter *repositories.LandedCostFilter) ([]*entities.LandedCostVoucher, int, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// UpdateVoucher replaces the header, charges and receipts of a draft voucher and
+// allocates again. Lines without a measure keep the one they had.
+func (s *LandedCostService) UpdateVoucher(ctx context.Context, voucher *entities.LandedCostVoucher, measures map[string]LineMeasure) error {
+	existing, err := s.GetVoucher(ctx, voucher.ID.String())
+	if err != nil {
+		return err
+	}
+	if existing.Status != entities.LandedCostDraft {
+		return fmt.Errorf("%w: only draft vouchers can be changed", entities.ErrInvalidLandedCostVoucher)
+	}
+	if err := voucher.Validate(); err != nil {
+		return err
+	}
+	previous := make(map[string]*entities.LandedCostAllocation, len(existing.Allocations))
+	for _, allocation := range existing.Allocations {
+		previous[allocation.CostLayerID] = allocation
+	}
+	if err := s.allocate(ctx, voucher, measures, previous); err != nil {
+		return err
+	}
+
+	voucher.VoucherNumber = existing.VoucherNumber
+	voucher.Status = existing.Status
+	voucher.CreatedBy = existing.CreatedBy
+	voucher.CreatedAt = existing.CreatedAt
+	voucher.UpdatedAt = time.Now()
+	return s.repo.Update(ctx, voucher)
+}
+
+// CancelVoucher cancels a draft voucher.
+func (s *LandedCostService) CancelVoucher(ctx context.Context, id string) error {
+	if _, err := s.GetVoucher(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Cancel(ctx, id)
+}
+
+// DeleteVoucher deletes a voucher that was never posted.
+func (s *LandedCostService) DeleteVoucher(ctx context.Context, id string) error {
+	if _, err := s.GetVoucher(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// allocate builds the voucher's allocations from the cost layers of its goods receipts
+// and spreads the charges over them.
+func (s *LandedCostService) allocate(ctx context.Context, voucher *entities.LandedCostVoucher, measures map[string]LineMeasure, previous map[string]*entities.LandedCostAllocation) error {
+	for _, receiptID := range voucher.GoodsReceiptIDs {
+		gr, err := s.receipts.GetByID(ctx, receiptID)
+		if err != nil {
+			return err
+		}
+		if gr == nil {
+			return fmt.Errorf("%w: goods receipt %s not found", entities.ErrInvalidLandedCostVoucher, receiptID)
+		}
+		if gr.Status != entities.GoodsReceiptStatusPosted {
+			return fmt.Errorf("%w: goods receipt %s is not posted", entities.ErrInvalidLandedCostVoucher, gr.GRNumber)
+		}
+		switch gr.ProcurementType {
+		case entities.ProcurementTypeOfficeSupply, entities.ProcurementTypeService, entities.ProcurementTypeAsset:
+			return fmt.Errorf("%w: goods receipt %s is not a stock receipt", entities.ErrInvalidLandedCostVoucher, gr.GRNumber)
+		}
+		if err := s.repo.EnsureCostLayers(ctx, receiptID); err != nil {
+			return err
+		}
+	}
+
+	layers, err := s.repo.ListCostLayersByReceipts(ctx, voucher.GoodsReceiptIDs)
+	if err != nil {
+		return err
+	}
+	voucher.Allocations = make([]*entities.LandedCostAllocation, 0, len(layers))
+	for _, layer := range layers {
+		allocation := &entities.LandedCostAllocation{
+			CostLayerID:    layer.ID,
+			GoodsReceiptID: layer.GoodsReceiptID,
+			ItemName:       layer.ItemName,
+			Quantity:       layer.ReceivedQuantity,
+			LineValue:      layer.PurchaseValue(),
+		}
+		if measure, ok := measures[layer.GoodsReceiptItemID]; ok {
+			allocation.Weight, allocation.Volume = measure.Weight, measure.Volume
+		} else if old := previous[layer.ID]; old != nil {
+			allocation.Weight, allocation.Volume = old.Weight, old.Volume
+		}
+		voucher.Allocations = append(voucher.Allocations, allocation)
+	}
+	return voucher.Allocate()
+}
+
+// PostVoucher adds the voucher's allocations to the cost layers of its receipts and
+// posts its journal. Each allocation is split first in, first out between the units of
+// its layer still on hand, whose cost goes to inventory, and the units already issued
+// or sold, whose cost goes straight to COGS.
+func (s *LandedCostService) PostVoucher(ctx context.Context, id, userID string) (*entities.LandedCostVoucher, error) {
+	voucher, err := s.GetVoucher(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if voucher.Status != entities.LandedCostDraft {
+		return nil, fmt.Errorf("%w: only draft vouchers can be posted", entities.ErrInvalidLandedCostVoucher)
+	}
+
+	layers, err := s.repo.ListCostLayersByReceipts(ctx, voucher.GoodsReceiptIDs)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := s.remainingByLayer(ctx, layers)
+	if err != nil {
+		return nil, err
+	}
+	layerByID := make(map[string]*entities.CostLayer, len(layers))
+	for _, layer := range layers {
+		layerByID[layer.ID] = layer
+	}
+
+	inventory, cogs := 0.0, 0.0
+	for _, allocation := range voucher.Allocations {
+		layer := layerByID[allocation.CostLayerID]
+		if layer == nil {
+			return nil, fmt.Errorf("%w: cost layer of %s no longer exists", entities.ErrInvalidLandedCostVoucher, allocation.ItemName)
+		}
+		onHand := allocation.Quantity
+		if layer.ArticleID != "" {
+			// Lines without an article are not stocked, so nothing of them is sold
+			onHand = remaining[layer.ID]
+		}
+		allocation.SplitOnHand(onHand)
+		inventory += allocation.InventoryAmount
+		cogs += allocation.COGSAmount
+	}
+
+	now := time.Now()
+	voucher.InventoryAmount = math.Round(inventory*100) / 100
+	voucher.COGSAmount = math.Round(cogs*100) / 100
+	voucher.Status = entities.LandedCostPosted
+	voucher.PostedBy = &userID
+	voucher.PostedAt = &now
+	if err := s.repo.Post(ctx, voucher); err != nil {
+		return nil, err
+	}
+
+	s.postJournal(ctx, voucher)
+	return voucher, nil
+}
+
+// remainingByLayer works out how much of each layer's article is still on hand in its
+// warehouse.
+func (s *LandedCostService) remainingByLayer(ctx context.Context, layers []*entities.CostLayer) (map[string]int, error) {
+	remaining := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, layer := range layers {
+		if layer.ArticleID == "" || seen[layer.ArticleID] {
+			continue
+		}
+		seen[layer.ArticleID] = true
+
+		stock, err := s.repo.ListStockCostLayers(ctx, layer.ArticleID)
+		if err != nil {
+			return nil, err
+		}
+		onHand, err := s.repo.GetOnHandByWarehouse(ctx, layer.ArticleID)
+		if err != nil {
+			return nil, err
+		}
+		byWarehouse := make(map[string][]*entities.CostLayer)
+		for _, l := range stock {
+			byWarehouse[l.WarehouseID] = append(byWarehouse[l.WarehouseID], l)
+		}
+		for warehouseID, warehouseLayers := range byWarehouse {
+			for layerID, quantity := range entities.RemainingByLayer(warehouseLayers, onHand[warehouseID]) {
+				remaining[layerID] = quantity
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// postJournal posts the journal of a posted voucher in IDR, with the charges converted at
+// the voucher's exchange rate as they were for the allocation. A failure is logged rather
+// than undoing the posting, which can be journalled by hand.
+func (s *LandedCostService) postJournal(ctx context.Context, voucher *entities.LandedCostVoucher) {
+	if s.journals == nil || voucher.TotalAmount == 0 {
+		return
+	}
+	charges := voucher.BaseChargeTotals()
+	data := map[string]interface{}{
+		"inventory_amount": voucher.InventoryAmount,
+		"cogs_amount":      voucher.COGSAmount,
+	}
+	for _, chargeType := range entities.LandedCostChargeTypes {
+		data[chargeType+"_amount"] = charges[chargeType]
+	}
+
+	var invoices []string
+	for _, charge := range voucher.Charges {
+		if charge.InvoiceNumber != "" {
+			invoices = append(invoices, charge.InvoiceNumber)
+		}
+	}
+	description := "Landed cost " + voucher.VoucherNumber
+	if len(invoices) > 0 {
+		description += " (" + strings.Join(invoices, ", ") + ")"
+	}
+	if voucher.Currency != entities.LandedCostBaseCurrency {
+		description += fmt.Sprintf(" - %s %.2f at %g", voucher.Currency, voucher.TotalAmount, voucher.ExchangeRate)
+	}
+
+	req := &accounting_services.AutoJournalRequest{
+		SourceModule:    landedCostJournalSource,
+		SourceID:        voucher.ID.String(),
+		TransactionType: landedCostJournalType,
+		TransactionDate: voucher.VoucherDate,
+		CompanyID:       "1", // Default company
+		CurrencyCode:    entities.LandedCostBaseCurrency,
+		ExchangeRate:    1.0,
+		Description:     description,
+		Reference:       voucher.VoucherNumber,
+		TransactionData: data,
+		CreatedBy:       *voucher.PostedBy,
+		AutoPost:        true,
+	}
+	entry, err := s.journals.CreateJournalFromTransaction(ctx, req)
+	if err != nil {
+		log.Printf("[Inventory] Failed to post %s journal for voucher %s: %v", landedCostJournalType, voucher.VoucherNumber, err)
+		return
+	}
+	if entry == nil {
+		return
+	}
+	journalEntryID := entry.ID.String()
+	if err := s.repo.SetJournalEntry(ctx, voucher.ID.String(), journalEntryID); err != nil {
+		log.Printf("[Inventory] Failed to link journal entry to voucher %s: %v", voucher.VoucherNumber, err)
+		return
+	}
+	voucher.JournalEntryID = &journalEntryID
+}
+
+// ListCostLayers lists the cost layers of a goods receipt.
+func (s *LandedCostService) ListCostLayers(ctx context.Context, goodsReceiptID string) ([]*entities.CostLayer, error) {
+	return s.repo.ListCostLayersByReceipts(ctx, []string{goodsReceiptID})
+}
+
+// GetArticleValuation values the on-hand stock of an article from its cost layers,
+// landed costs included.
+func (s *LandedCostService) GetArticleValuation(ctx context.Context, articleID string) (*entities.StockValuation, error) {
+	return valueArticle(ctx, s.repo, articleID)
+}
+
+// valueArticle values an article's on-hand stock from its cost layers.
+func valueArticle(ctx context.Context, repo repositories.LandedCostRepository, articleID string) (*entities.StockValuation, error) {
+	layers, err := repo.ListStockCostLayers(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	onHand, err := repo.GetOnHandByWarehouse(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	return entities.ValueStock(articleID, layers, onHand), nil
+}
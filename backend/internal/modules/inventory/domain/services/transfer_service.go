@@ -8,6 +8,7 @@ import (
 
 	"malaka/internal/modules/inventory/domain/entities"
 	"malaka/internal/modules/inventory/domain/repositories"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/uuid"
 )
 
@@ -22,6 +23,7 @@ type TransferService struct {
 	transferOrderRepo repositories.TransferOrderRepository
 	transferItemRepo  repositories.TransferItemRepository
 	stockService      *StockService
+	approval          integration.ApprovalEngine // Optional: multi-level approval workflows
 }
 
 // NewTransferService creates a new TransferService.
@@ -33,6 +35,11 @@ func NewTransferService(toRepo repositories.TransferOrderRepository, tiRepo repo
 	}
 }
 
+// SetApprovalEngine routes transfer approvals through the approval workflow engine.
+func (s *TransferService) SetApprovalEngine(engine integration.ApprovalEngine) {
+	s.approval = engine
+}
+
 // CreateTransferOrder creates a new transfer order as draft (no stock movement).
 func (s *TransferService) CreateTransferOrder(ctx context.Context, to *entities.TransferOrder, items []*entities.TransferItem) error {
 	if to.ID.IsNil() {
@@ -61,7 +68,8 @@ func (s *TransferService) CreateTransferOrder(ctx context.Context, to *entities.
 	return nil
 }
 
-// ApproveTransferOrder transitions draft/pending → approved.
+// ApproveTransferOrder records the approval of approvedBy and transitions draft/pending → approved
+// once the approval workflow is complete; until then the order is kept pending.
 func (s *TransferService) ApproveTransferOrder(ctx context.Context, id string, approvedBy string) (*entities.TransferOrder, error) {
 	to, err := s.transferOrderRepo.GetByID(ctx, id)
 	if err != nil {
@@ -74,6 +82,24 @@ func (s *TransferService) ApproveTransferOrder(ctx context.Context, id string, a
 		return nil, fmt.Errorf("cannot approve transfer with status %s", to.Status)
 	}
 
+	// The order waits in pending until the last step of its approval workflow is approved
+	if s.approval != nil {
+		outcome, err := s.approval.Approve(ctx, transferApprovalDocument(to), approvedBy, "")
+		if err != nil {
+			return nil, err
+		}
+		if !outcome.IsApproved() {
+			if to.Status == entities.TransferStatusDraft {
+				to.Status = entities.TransferStatusPending
+				to.UpdatedAt = time.Now()
+				if err := s.transferOrderRepo.Update(ctx, to); err != nil {
+					return nil, err
+				}
+			}
+			return to, nil
+		}
+	}
+
 	now := time.Now()
 	to.Status = entities.TransferStatusApproved
 	to.ApprovedBy = &approvedBy
@@ -218,6 +244,12 @@ func (s *TransferService) CancelTransferOrder(ctx context.Context, id string, ca
 		return nil, fmt.Errorf("cannot cancel transfer with status %s", to.Status)
 	}
 
+	if s.approval != nil {
+		if err := s.approval.Cancel(ctx, integration.ApprovalDocTransferOrder, to.ID.String(), cancelledBy, reason); err != nil {
+			return nil, err
+		}
+	}
+
 	// If cancelling while in_transit, reverse the stock OUT movements
 	if to.Status == entities.TransferStatusInTransit {
 		items, err := s.transferItemRepo.GetByTransferOrderID(ctx, id)
@@ -287,3 +319,15 @@ func (s *TransferService) DeleteTransferOrder(ctx context.Context, id string) er
 	}
 	return s.transferOrderRepo.Delete(ctx, id)
 }
+
+// transferApprovalDocument describes a transfer order to the approval engine
+func transferApprovalDocument(to *entities.TransferOrder) *integration.ApprovalDocument {
+	doc := &integration.ApprovalDocument{
+		DocumentType: integration.ApprovalDocTransferOrder,
+		DocumentID:   to.ID.String(),
+	}
+	if to.CreatedBy != nil {
+		doc.RequesterID = *to.CreatedBy
+	}
+	return doc
+}
@@ -0,0 +1,378 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/inventory/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// LandedCostRepositoryImpl implements repositories.LandedCostRepository.
+type LandedCostRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewLandedCostRepositoryImpl creates a new LandedCostRepositoryImpl.
+func NewLandedCostRepositoryImpl(db *sqlx.DB) *LandedCostRepositoryImpl {
+	return &LandedCostRepositoryImpl{db: db}
+}
+
+const costLayerQuery = `
+	SELECT id, goods_receipt_id, goods_receipt_item_id, COALESCE(article_id::text, '') AS article_id,
+		warehouse_id, item_name, received_quantity, unit_cost, landed_cost, received_at
+	FROM inventory_cost_layers`
+
+// EnsureCostLayers creates the cost layers of a posted goods receipt that has none yet.
+func (r *LandedCostRepositoryImpl) EnsureCostLayers(ctx context.Context, goodsReceiptID string) error {
+	query := `
+		INSERT INTO inventory_cost_layers (goods_receipt_id, goods_receipt_item_id, article_id, warehouse_id, item_name,
+			received_quantity, unit_cost, received_at)
+		SELECT gr.id, gri.id, gri.article_id, gr.warehouse_id, COALESCE(gri.item_name, ''),
+			gri.quantity, COALESCE(gri.unit_price, 0), COALESCE(gr.posted_at, gr.receipt_date, gr.created_at)
+		FROM goods_receipt_items gri
+		JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id
+		WHERE gr.id = $1 AND gr.status = $2 AND gr.warehouse_id IS NOT NULL AND gri.quantity > 0
+		ON CONFLICT (goods_receipt_item_id) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, goodsReceiptID, entities.GoodsReceiptStatusPosted)
+	return err
+}
+
+// ListCostLayersByReceipts lists the cost layers of the given goods receipts.
+func (r *LandedCostRepositoryImpl) ListCostLayersByReceipts(ctx context.Context, goodsReceiptIDs []string) ([]*entities.CostLayer, error) {
+	layers := []*entities.CostLayer{}
+	if len(goodsReceiptIDs) == 0 {
+		return layers, nil
+	}
+	query := costLayerQuery + ` WHERE goods_receipt_id::text = ANY($1) ORDER BY received_at, goods_receipt_id, item_name, id`
+	if err := r.db.SelectContext(ctx, &layers, query, pq.Array(goodsReceiptIDs)); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// ListStockCostLayers lists the cost layers of an article in every warehouse, oldest
+// first within each warehouse.
+func (r *LandedCostRepositoryImpl) ListStockCostLayers(ctx context.Context, articleID string) ([]*entities.CostLayer, error) {
+	layers := []*entities.CostLayer{}
+	query := costLayerQuery + ` WHERE article_id = $1 ORDER BY warehouse_id, received_at, id`
+	if err := r.db.SelectContext(ctx, &layers, query, articleID); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// GetOnHandByWarehouse returns the on-hand quantity of an article by warehouse.
+func (r *LandedCostRepositoryImpl) GetOnHandByWarehouse(ctx context.Context, articleID string) (map[string]int, error) {
+	var rows []struct {
+		WarehouseID string `db:"warehouse_id"`
+		Quantity    int    `db:"quantity"`
+	}
+	query := `SELECT warehouse_id, SUM(quantity) AS quantity FROM stock_balances WHERE article_id = $1 GROUP BY warehouse_id`
+	if err := r.db.SelectContext(ctx, &rows, query, articleID); err != nil {
+		return nil, err
+	}
+	onHand := make(map[string]int, len(rows))
+	for _, row := range rows {
+		onHand[row.WarehouseID] = row.Quantity
+	}
+	return onHand, nil
+}
+
+// GetNextVoucherNumber generates the next landed cost voucher number.
+func (r *LandedCostRepositoryImpl) GetNextVoucherNumber(ctx context.Context) (string, error) {
+	prefix := fmt.Sprintf("LCV-%s-", time.Now().Format("200601"))
+
+	query := `
+		SELECT COALESCE(MAX(CAST(SUBSTRING(voucher_number FROM '\d+$') AS INTEGER)), 0) + 1
+		FROM landed_cost_vouchers
+		WHERE voucher_number LIKE $1
+	`
+
+	var nextNum int
+	if err := r.db.QueryRowContext(ctx, query, prefix+"%").Scan(&nextNum); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%04d", prefix, nextNum), nil
+}
+
+// Create creates a landed cost voucher with its charges, receipts and allocations.
+func (r *LandedCostRepositoryImpl) Create(ctx context.Context, voucher *entities.LandedCostVoucher) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO landed_cost_vouchers (id, voucher_number, voucher_date, allocation_method, currency, exchange_rate,
+			total_amount, status, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		voucher.ID, voucher.VoucherNumber, voucher.VoucherDate, voucher.AllocationMethod, voucher.Currency,
+		voucher.ExchangeRate, voucher.TotalAmount, voucher.Status, voucher.Notes, voucher.CreatedBy, voucher.CreatedAt, voucher.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertVoucherLines(ctx, tx, voucher); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertVoucherLines inserts the charges, receipts and allocations of a voucher.
+func insertVoucherLines(ctx context.Context, tx *sqlx.Tx, voucher *entities.LandedCostVoucher) error {
+	for _, charge := range voucher.Charges {
+		if charge.ID.IsNil() {
+			charge.ID = uuid.New()
+		}
+		charge.VoucherID = voucher.ID
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO landed_cost_charges (id, voucher_id, charge_type, supplier_id, invoice_number, description, amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			charge.ID, charge.VoucherID, charge.ChargeType, charge.SupplierID, charge.InvoiceNumber,
+			charge.Description, charge.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, receiptID := range voucher.GoodsReceiptIDs {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO landed_cost_voucher_receipts (voucher_id, goods_receipt_id) VALUES ($1, $2)`,
+			voucher.ID, receiptID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, allocation := range voucher.Allocations {
+		if allocation.ID.IsNil() {
+			allocation.ID = uuid.New()
+		}
+		allocation.VoucherID = voucher.ID
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO landed_cost_allocations (id, voucher_id, cost_layer_id, goods_receipt_id, item_name, quantity,
+				line_value, weight, volume, allocated_amount, on_hand_quantity, inventory_amount, cogs_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			allocation.ID, allocation.VoucherID, allocation.CostLayerID, allocation.GoodsReceiptID, allocation.ItemName,
+			allocation.Quantity, allocation.LineValue, allocation.Weight, allocation.Volume, allocation.AllocatedAmount,
+			allocation.OnHandQuantity, allocation.InventoryAmount, allocation.COGSAmount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const landedCostVoucherQuery = `
+	SELECT id, voucher_number, voucher_date, allocation_method, currency, exchange_rate, total_amount, inventory_amount,
+		cogs_amount, status, notes, journal_entry_id::text AS journal_entry_id, created_by, posted_by, posted_at,
+		created_at, updated_at
+	FROM landed_cost_vouchers`
+
+// GetByID retrieves a landed cost voucher with its charges, receipts and allocations.
+func (r *LandedCostRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.LandedCostVoucher, error) {
+	var voucher entities.LandedCostVoucher
+	err := r.db.GetContext(ctx, &voucher, landedCostVoucherQuery+` WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	voucher.Charges = []*entities.LandedCostCharge{}
+	err = r.db.SelectContext(ctx, &voucher.Charges, `
+		SELECT id, voucher_id, charge_type, supplier_id::text AS supplier_id, invoice_number, description, amount
+		FROM landed_cost_charges WHERE voucher_id = $1 ORDER BY charge_type, invoice_number`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher.GoodsReceiptIDs = []string{}
+	err = r.db.SelectContext(ctx, &voucher.GoodsReceiptIDs, `
+		SELECT vr.goods_receipt_id FROM landed_cost_voucher_receipts vr
+		JOIN goods_receipts gr ON gr.id = vr.goods_receipt_id
+		WHERE vr.voucher_id = $1 ORDER BY gr.gr_number`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher.Allocations = []*entities.LandedCostAllocation{}
+	err = r.db.SelectContext(ctx, &voucher.Allocations, `
+		SELECT a.id, a.voucher_id, a.cost_layer_id, a.goods_receipt_id, a.item_name, a.quantity, a.line_value,
+			a.weight, a.volume, a.allocated_amount, a.on_hand_quantity, a.inventory_amount, a.cogs_amount
+		FROM landed_cost_allocations a
+		JOIN inventory_cost_layers l ON l.id = a.cost_layer_id
+		WHERE a.voucher_id = $1
+		ORDER BY l.received_at, l.goods_receipt_id, l.item_name, l.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// List lists landed cost vouchers, newest first, with the total count for paging.
+func (r *LandedCostRepositoryImpl) List(ctx context.Context, filter *repositories.LandedCostFilter) ([]*entities.LandedCostVoucher, int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.GoodsReceiptID != "" {
+		args = append(args, filter.GoodsReceiptID)
+		conditions = append(conditions, fmt.Sprintf(
+			"id IN (SELECT voucher_id FROM landed_cost_voucher_receipts WHERE goods_receipt_id = $%d)", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM landed_cost_vouchers`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	page := filter.Page
+	if page <= 0 {
+		page = 1
+	}
+	args = append(args, limit, (page-1)*limit)
+	query := landedCostVoucherQuery + where +
+		fmt.Sprintf(" ORDER BY voucher_date DESC, voucher_number DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	vouchers := []*entities.LandedCostVoucher{}
+	if err := r.db.SelectContext(ctx, &vouchers, query, args...); err != nil {
+		return nil, 0, err
+	}
+	return vouchers, total, nil
+}
+
+// Update replaces the header and lines of a draft voucher.
+func (r *LandedCostRepositoryImpl) Update(ctx context.Context, voucher *entities.LandedCostVoucher) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE landed_cost_vouchers
+		SET voucher_date = $2, allocation_method = $3, currency = $4, exchange_rate = $5, total_amount = $6, notes = $7,
+			updated_at = $8
+		WHERE id = $1 AND status = $9`,
+		voucher.ID, voucher.VoucherDate, voucher.AllocationMethod, voucher.Currency, voucher.ExchangeRate,
+		voucher.TotalAmount, voucher.Notes, voucher.UpdatedAt, entities.LandedCostDraft)
+	if err != nil {
+		return err
+	}
+	if err := expectDraft(result); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"landed_cost_allocations", "landed_cost_voucher_receipts", "landed_cost_charges"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE voucher_id = $1`, voucher.ID); err != nil {
+			return err
+		}
+	}
+	if err := insertVoucherLines(ctx, tx, voucher); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Cancel cancels a draft voucher.
+func (r *LandedCostRepositoryImpl) Cancel(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE landed_cost_vouchers SET status = $2, updated_at = $3 WHERE id = $1 AND status = $4`,
+		id, entities.LandedCostCancelled, time.Now(), entities.LandedCostDraft)
+	if err != nil {
+		return err
+	}
+	return expectDraft(result)
+}
+
+// Delete deletes a draft or cancelled voucher; posted vouchers are kept.
+func (r *LandedCostRepositoryImpl) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM landed_cost_vouchers WHERE id = $1 AND status <> $2`, id, entities.LandedCostPosted)
+	if err != nil {
+		return err
+	}
+	return expectDraft(result)
+}
+
+// Post adds each allocation to its cost layer, records the on-hand split and marks the
+// voucher posted.
+func (r *LandedCostRepositoryImpl) Post(ctx context.Context, voucher *entities.LandedCostVoucher) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE landed_cost_vouchers
+		SET status = $2, inventory_amount = $3, cogs_amount = $4, posted_by = $5, posted_at = $6, updated_at = $6
+		WHERE id = $1 AND status = $7`,
+		voucher.ID, entities.LandedCostPosted, voucher.InventoryAmount, voucher.COGSAmount, voucher.PostedBy,
+		voucher.PostedAt, entities.LandedCostDraft)
+	if err != nil {
+		return err
+	}
+	if err := expectDraft(result); err != nil {
+		return err
+	}
+
+	for _, allocation := range voucher.Allocations {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE landed_cost_allocations
+			SET on_hand_quantity = $2, inventory_amount = $3, cogs_amount = $4
+			WHERE id = $1`,
+			allocation.ID, allocation.OnHandQuantity, allocation.InventoryAmount, allocation.COGSAmount)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE inventory_cost_layers SET landed_cost = landed_cost + $2, updated_at = $3 WHERE id = $1`,
+			allocation.CostLayerID, allocation.AllocatedAmount, voucher.PostedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetJournalEntry links a posted voucher to its journal entry.
+func (r *LandedCostRepositoryImpl) SetJournalEntry(ctx context.Context, id, journalEntryID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE landed_cost_vouchers SET journal_entry_id = $2, updated_at = $3 WHERE id = $1`,
+		id, journalEntryID, time.Now())
+	return err
+}
+
+// expectDraft reports a voucher that was not in the state the statement required.
+func expectDraft(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: only draft vouchers can be changed", entities.ErrInvalidLandedCostVoucher)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// LandedCostVoucherRequest represents the request body for creating or editing a
+// landed cost voucher.
+type LandedCostVoucherRequest struct {
+	VoucherDate      time.Time                  `json:"voucher_date" binding:"required"`
+	AllocationMethod string                     `json:"allocation_method" binding:"required,oneof=value quantity weight volume"`
+	Currency         string                     `json:"currency"`
+	ExchangeRate     float64                    `json:"exchange_rate" binding:"min=0"`
+	Notes            string                     `json:"notes"`
+	GoodsReceiptIDs  []string                   `json:"goods_receipt_ids" binding:"required,min=1"`
+	Charges          []LandedCostChargeRequest  `json:"charges" binding:"required,min=1,dive"`
+	Measures         []LandedCostMeasureRequest `json:"measures" binding:"dive"`
+}
+
+// LandedCostChargeRequest is a charge invoice carried by a landed cost voucher.
+type LandedCostChargeRequest struct {
+	ChargeType    string  `json:"charge_type" binding:"required,oneof=freight insurance duty clearance other"`
+	SupplierID    *string `json:"supplier_id"`
+	InvoiceNumber string  `json:"invoice_number"`
+	Description   string  `json:"description"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// LandedCostMeasureRequest is the shipped weight and volume of a goods receipt line,
+// needed when allocating by weight or volume.
+type LandedCostMeasureRequest struct {
+	GoodsReceiptItemID string  `json:"goods_receipt_item_id" binding:"required"`
+	Weight             float64 `json:"weight" binding:"min=0"`
+	Volume             float64 `json:"volume" binding:"min=0"`
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/inventory/domain/repositories"
+	"malaka/internal/modules/inventory/domain/services"
+	"malaka/internal/modules/inventory/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// LandedCostHandler handles HTTP requests for landed cost vouchers and the cost layers
+// they are allocated to.
+type LandedCostHandler struct {
+	service *services.LandedCostService
+}
+
+// NewLandedCostHandler creates a new LandedCostHandler.
+func NewLandedCostHandler(service *services.LandedCostService) *LandedCostHandler {
+	return &LandedCostHandler{service: service}
+}
+
+// CreateVoucher handles creating a draft landed cost voucher.
+func (h *LandedCostHandler) CreateVoucher(c *gin.Context) {
+	var req dto.LandedCostVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	voucher, measures := toLandedCostVoucher(&req)
+	voucher.CreatedBy = c.GetString("user_id")
+	if err := h.service.CreateVoucher(c.Request.Context(), voucher, measures); err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.Created(c, "Landed cost voucher created successfully", voucher)
+}
+
+// ListVouchers handles listing landed cost vouchers, by status or goods receipt.
+func (h *LandedCostHandler) ListVouchers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit > 100 {
+		limit = 100
+	}
+	filter := &repositories.LandedCostFilter{
+		Status:         c.Query("status"),
+		GoodsReceiptID: c.Query("goods_receipt_id"),
+		Page:           page,
+		Limit:          limit,
+	}
+
+	vouchers, total, err := h.service.ListVouchers(c.Request.Context(), filter)
+	if err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Landed cost vouchers retrieved successfully", map[string]interface{}{
+		"vouchers": vouchers,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetVoucher handles retrieving a landed cost voucher with its allocations.
+func (h *LandedCostHandler) GetVoucher(c *gin.Context) {
+	voucher, err := h.service.GetVoucher(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.OK(c, "Landed cost voucher retrieved successfully", voucher)
+}
+
+// UpdateVoucher handles editing a draft landed cost voucher.
+func (h *LandedCostHandler) UpdateVoucher(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid voucher ID", nil)
+		return
+	}
+	var req dto.LandedCostVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	voucher, measures := toLandedCostVoucher(&req)
+	voucher.ID = id
+	if err := h.service.UpdateVoucher(c.Request.Context(), voucher, measures); err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.OK(c, "Landed cost voucher updated successfully", voucher)
+}
+
+// CancelVoucher handles cancelling a draft landed cost voucher.
+func (h *LandedCostHandler) CancelVoucher(c *gin.Context) {
+	if err := h.service.CancelVoucher(c.Request.Context(), c.Param("id")); err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.OK(c, "Landed cost voucher cancelled successfully", nil)
+}
+
+// DeleteVoucher handles deleting a landed cost voucher that was never posted.
+func (h *LandedCostHandler) DeleteVoucher(c *gin.Context) {
+	if err := h.service.DeleteVoucher(c.Request.Context(), c.Param("id")); err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.OK(c, "Landed cost voucher deleted successfully", nil)
+}
+
+// PostVoucher handles posting a landed cost voucher to the cost layers and the ledger.
+func (h *LandedCostHandler) PostVoucher(c *gin.Context) {
+	voucher, err := h.service.PostVoucher(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.OK(c, "Landed cost voucher posted successfully", voucher)
+}
+
+// ListCostLayers handles listing the cost layers of a goods receipt.
+func (h *LandedCostHandler) ListCostLayers(c *gin.Context) {
+	goodsReceiptID := c.Query("goods_receipt_id")
+	if goodsReceiptID == "" {
+		response.BadRequest(c, "goods_receipt_id is required", nil)
+		return
+	}
+
+	layers, err := h.service.ListCostLayers(c.Request.Context(), goodsReceiptID)
+	if err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.OK(c, "Cost layers retrieved successfully", layers)
+}
+
+// GetArticleValuation handles valuing the on-hand stock of an article at landed cost.
+func (h *LandedCostHandler) GetArticleValuation(c *gin.Context) {
+	valuation, err := h.service.GetArticleValuation(c.Request.Context(), c.Param("articleId"))
+	if err != nil {
+		landedCostError(c, err)
+		return
+	}
+
+	response.OK(c, "Stock valuation retrieved successfully", valuation)
+}
+
+// toLandedCostVoucher maps a voucher request to the voucher and its line measures.
+func toLandedCostVoucher(req *dto.LandedCostVoucherRequest) (*entities.LandedCostVoucher, map[string]services.LineMeasure) {
+	voucher := &entities.LandedCostVoucher{
+		VoucherDate:      req.VoucherDate,
+		AllocationMethod: req.AllocationMethod,
+		Currency:         req.Currency,
+		ExchangeRate:     req.ExchangeRate,
+		Notes:            req.Notes,
+		GoodsReceiptIDs:  req.GoodsReceiptIDs,
+	}
+	for _, charge := range req.Charges {
+		voucher.Charges = append(voucher.Charges, &entities.LandedCostCharge{
+			ChargeType:    charge.ChargeType,
+			SupplierID:    charge.SupplierID,
+			InvoiceNumber: charge.InvoiceNumber,
+			Description:   charge.Description,
+			Amount:        charge.Amount,
+		})
+	}
+	measures := make(map[string]services.LineMeasure, len(req.Measures))
+	for _, measure := range req.Measures {
+		measures[measure.GoodsReceiptItemID] = services.LineMeasure{Weight: measure.Weight, Volume: measure.Volume}
+	}
+	return voucher, measures
+}
+
+// landedCostError maps landed cost errors to HTTP responses.
+func landedCostError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrLandedCostVoucherNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidLandedCostVoucher):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
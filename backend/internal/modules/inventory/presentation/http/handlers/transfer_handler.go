@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"malaka/internal/modules/inventory/domain/services"
 	"malaka/internal/modules/inventory/presentation/http/dto"
 	notification_services "malaka/internal/modules/notifications/domain/services"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/response"
 	"malaka/internal/shared/types"
 	"malaka/internal/shared/uuid"
@@ -402,10 +404,20 @@ func (h *TransferHandler) ApproveTransferOrder(c *gin.Context) {
 
 	order, err := h.service.ApproveTransferOrder(c.Request.Context(), id, approverID)
 	if err != nil {
+		if errors.Is(err, integration.ErrNotApprover) {
+			response.Forbidden(c, err.Error(), nil)
+			return
+		}
 		response.BadRequest(c, err.Error(), nil)
 		return
 	}
 
+	// Multi-level workflows keep the order pending until the last step is approved
+	if order.Status != entities.TransferStatusApproved {
+		response.OK(c, "Transfer order approval recorded, awaiting next approver", order)
+		return
+	}
+
 	// Notify transfer creator
 	if h.notificationService != nil && order.CreatedBy != nil {
 		go func() {
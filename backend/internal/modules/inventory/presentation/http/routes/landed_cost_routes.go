@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/inventory/presentation/http/handlers"
+	"malaka/internal/shared/auth"
+)
+
+// RegisterLandedCostRoutes registers the landed cost voucher routes and the cost layer
+// and valuation views they feed.
+func RegisterLandedCostRoutes(router *gin.RouterGroup, handler *handlers.LandedCostHandler, rbacSvc *auth.RBACService) {
+	inventory := router.Group("/inventory")
+	inventory.Use(auth.RequireModuleAccess(rbacSvc, "inventory"))
+	{
+		landedCosts := inventory.Group("/landed-costs")
+		{
+			landedCosts.POST("/", auth.RequirePermission(rbacSvc, "inventory.landed-cost.create"), handler.CreateVoucher)
+			landedCosts.GET("/", auth.RequirePermission(rbacSvc, "inventory.landed-cost.list"), handler.ListVouchers)
+			landedCosts.GET("/cost-layers", auth.RequirePermission(rbacSvc, "inventory.landed-cost.read"), handler.ListCostLayers)
+			landedCosts.GET("/valuation/:articleId", auth.RequirePermission(rbacSvc, "inventory.landed-cost.read"), handler.GetArticleValuation)
+			landedCosts.GET("/:id", auth.RequirePermission(rbacSvc, "inventory.landed-cost.read"), handler.GetVoucher)
+			landedCosts.PUT("/:id", auth.RequirePermission(rbacSvc, "inventory.landed-cost.update"), handler.UpdateVoucher)
+			landedCosts.DELETE("/:id", auth.RequirePermission(rbacSvc, "inventory.landed-cost.delete"), handler.DeleteVoucher)
+			landedCosts.POST("/:id/cancel", auth.RequirePermission(rbacSvc, "inventory.landed-cost.update"), handler.CancelVoucher)
+			landedCosts.POST("/:id/post", auth.RequirePermission(rbacSvc, "inventory.landed-cost.post"), handler.PostVoucher)
+		}
+	}
+}
@@ -20,11 +20,17 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// PasswordValidator checks new passwords against the password policy
+type PasswordValidator interface {
+	ValidatePassword(ctx context.Context, password string, personalInfo ...string) error
+}
+
 // InvitationService handles invitation business logic
 type InvitationService struct {
 	invitationRepo repositories.InvitationRepository
 	userRepo       userRepositories.UserRepository
 	emailService   *email.EmailService
+	passwords      PasswordValidator
 	frontendURL    string
 	logoURL        string
 	expiryHours    int
@@ -222,6 +228,11 @@ func (s *InvitationService) ValidateInvitation(ctx context.Context, token string
 	}, nil
 }
 
+// SetPasswordValidator enforces the password policy when an invitation is accepted
+func (s *InvitationService) SetPasswordValidator(passwords PasswordValidator) {
+	s.passwords = passwords
+}
+
 // AcceptInvitation accepts an invitation and creates the user
 func (s *InvitationService) AcceptInvitation(ctx context.Context, req *entities.AcceptInvitationRequest) (*userEntities.User, error) {
 	// Validate the invitation first
@@ -239,6 +250,12 @@ func (s *InvitationService) AcceptInvitation(ctx context.Context, req *entities.
 		return nil, fmt.Errorf("failed to get invitation: %w", err)
 	}
 
+	if s.passwords != nil {
+		if err := s.passwords.ValidatePassword(ctx, req.Password, invitation.Email); err != nil {
+			return nil, err
+		}
+	}
+
 	// Hash the password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -12,6 +12,9 @@ type Customer struct {
 	ContactPerson string  `json:"contact_person" db:"contact_person"`
 	Email         string  `json:"email" db:"email"`
 	Phone         string  `json:"phone" db:"phone"`
+	Address       string  `json:"address" db:"address"`
+	TaxID         string  `json:"tax_id" db:"tax_id"`         // NPWP, or NIK for buyers without one
+	TaxStatus     string  `json:"tax_status" db:"tax_status"` // PKP, NON_PKP, COLLECTOR or EXEMPT
 	CompanyID     uuid.ID `json:"company_id" db:"company_id"`
 	Status        string  `json:"status" db:"status"`
 }
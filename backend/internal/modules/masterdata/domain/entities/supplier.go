@@ -16,6 +16,7 @@ type Supplier struct {
 	Email         string  `json:"email" db:"email"`
 	Website       string  `json:"website" db:"website"`
 	TaxID         string  `json:"tax_id" db:"tax_id"`
+	TaxStatus     string  `json:"tax_status" db:"tax_status"` // PKP, NON_PKP, COLLECTOR or EXEMPT
 	PaymentTerms  string  `json:"payment_terms" db:"payment_terms"`
 	CreditLimit   float64 `json:"credit_limit" db:"credit_limit"`
 	Status        string  `json:"status" db:"status"`
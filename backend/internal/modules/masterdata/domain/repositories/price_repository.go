@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"malaka/internal/modules/masterdata/domain/entities"
 	"malaka/internal/shared/uuid"
@@ -14,4 +15,6 @@ type PriceRepository interface {
 	GetAll(ctx context.Context) ([]*entities.Price, error)
 	Update(ctx context.Context, price *entities.Price) error
 	Delete(ctx context.Context, id uuid.ID) error
+	// GetEffective returns the latest price of an article in effect at a time, or nil.
+	GetEffective(ctx context.Context, articleID uuid.ID, at time.Time) (*entities.Price, error)
 }
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"malaka/internal/modules/masterdata/domain/entities"
 	"malaka/internal/modules/masterdata/domain/repositories"
@@ -32,6 +33,12 @@ func (s *PriceService) GetPriceByID(ctx context.Context, id uuid.ID) (*entities.
 	return s.repo.GetByID(ctx, id)
 }
 
+// GetEffectivePrice retrieves the price of an article in effect at a time, or nil if
+// the article has no price yet.
+func (s *PriceService) GetEffectivePrice(ctx context.Context, articleID uuid.ID, at time.Time) (*entities.Price, error) {
+	return s.repo.GetEffective(ctx, articleID, at)
+}
+
 // GetAllPrices retrieves all prices.
 func (s *PriceService) GetAllPrices(ctx context.Context) ([]*entities.Price, error) {
 	return s.repo.GetAll(ctx)
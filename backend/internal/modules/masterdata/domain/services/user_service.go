@@ -17,6 +17,20 @@ type UserService struct {
 	repo           repositories.UserRepository
 	jwtSecret      string
 	jwtExpiryHours int
+	sessions       *auth.SessionService
+	mfa            *auth.MFAService
+	passwords      *auth.PasswordService
+}
+
+// ErrUserDisabled is returned when a user that is not active tries to sign in.
+var ErrUserDisabled = errors.New("user account is disabled")
+
+// LoginResult is either an issued token pair or, when a second factor is needed,
+// a challenge to complete with a TOTP or recovery code.
+type LoginResult struct {
+	Tokens        *auth.TokenPair
+	Challenge     *auth.MFAChallenge
+	RecoveryCodes []string // set once, when enrollment is completed during login
 }
 
 // NewUserService creates a new UserService.
@@ -27,8 +41,32 @@ func NewUserService(repo repositories.UserRepository, jwtSecret string, jwtExpir
 	return &UserService{repo: repo, jwtSecret: jwtSecret, jwtExpiryHours: jwtExpiryHours}
 }
 
+// SetSessionService enables server-side sessions with short-lived access tokens and
+// rotating refresh tokens. Without it, login issues a single long-lived JWT.
+func (s *UserService) SetSessionService(sessions *auth.SessionService) {
+	s.sessions = sessions
+}
+
+// SetMFAService enables the two-factor login step for users with 2FA enabled or
+// required by their role.
+func (s *UserService) SetMFAService(mfa *auth.MFAService) {
+	s.mfa = mfa
+}
+
+// SetPasswordService enables password policy checks on new users and account
+// lockout after repeated failed logins.
+func (s *UserService) SetPasswordService(passwords *auth.PasswordService) {
+	s.passwords = passwords
+}
+
 // CreateUser creates a new user.
 func (s *UserService) CreateUser(ctx context.Context, user *entities.User) error {
+	if s.passwords != nil {
+		if err := s.passwords.ValidatePassword(ctx, user.Password, user.Username, user.Email); err != nil {
+			return err
+		}
+	}
+
 	// Hash the password before saving
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
@@ -64,7 +102,14 @@ func (s *UserService) UpdateUser(ctx context.Context, user *entities.User) error
 	if existingUser == nil {
 		return errors.New("user not found")
 	}
-	return s.repo.Update(ctx, user)
+	if err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+	// Disabling a user ends their sessions immediately
+	if isActiveUser(existingUser) && !isActiveUser(user) && s.sessions != nil {
+		return s.sessions.RevokeUser(ctx, user.ID.String())
+	}
+	return nil
 }
 
 // DeleteUser deletes a user by its ID.
@@ -77,25 +122,236 @@ func (s *UserService) DeleteUser(ctx context.Context, id uuid.ID) error {
 	if existingUser == nil {
 		return errors.New("user not found")
 	}
+	if s.sessions != nil {
+		if err := s.sessions.RevokeUser(ctx, id.String()); err != nil {
+			return err
+		}
+	}
 	return s.repo.Delete(ctx, id)
 }
 
-// AuthenticateUser authenticates a user by email and password and returns a JWT token.
-func (s *UserService) AuthenticateUser(ctx context.Context, email, password string) (string, error) {
+// Login checks the user's password. Users without a second factor get a token pair
+// right away; the others get an MFA challenge to complete with CompleteMFALogin, or
+// with BeginMFAEnrollment and ConfirmMFAEnrollment when their role requires 2FA
+// they have not set up yet.
+func (s *UserService) Login(ctx context.Context, email, password string, meta auth.SessionMetadata) (*LoginResult, error) {
+	user, err := s.verifyCredentials(ctx, email, password, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.mfa != nil {
+		userID := user.ID.String()
+		enabled, err := s.mfa.IsEnabled(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		required := false
+		if !enabled {
+			if required, err = s.mfa.IsRequired(ctx, userID); err != nil {
+				return nil, err
+			}
+		}
+		if enabled || required {
+			challenge, err := s.mfa.IssueChallenge(userID, !enabled)
+			if err != nil {
+				return nil, err
+			}
+			return &LoginResult{Challenge: challenge}, nil
+		}
+	}
+
+	tokens, err := s.issueTokens(ctx, user, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// CompleteMFALogin finishes a login with the challenge token and a TOTP or recovery code.
+func (s *UserService) CompleteMFALogin(ctx context.Context, challengeToken, code string, meta auth.SessionMetadata) (*auth.TokenPair, error) {
+	user, enroll, err := s.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if enroll {
+		return nil, auth.ErrMFANotEnrolled
+	}
+	if err := s.mfa.Verify(ctx, user.ID.String(), code); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user, meta)
+}
+
+// BeginMFAEnrollment starts TOTP enrollment for a user whose role requires 2FA,
+// authenticated only by the enrollment challenge from Login.
+func (s *UserService) BeginMFAEnrollment(ctx context.Context, challengeToken string) (*auth.MFAEnrollment, error) {
+	user, enroll, err := s.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !enroll {
+		return nil, auth.ErrMFAAlreadyEnabled
+	}
+	return s.mfa.BeginEnrollment(ctx, user.ID.String(), user.Email)
+}
+
+// ConfirmMFAEnrollment activates the enrollment started with BeginMFAEnrollment and
+// completes the login. The recovery codes are only returned this once.
+func (s *UserService) ConfirmMFAEnrollment(ctx context.Context, challengeToken, code string, meta auth.SessionMetadata) (*LoginResult, error) {
+	user, enroll, err := s.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !enroll {
+		return nil, auth.ErrMFAAlreadyEnabled
+	}
+	codes, err := s.mfa.ConfirmEnrollment(ctx, user.ID.String(), code)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.issueTokens(ctx, user, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens, RecoveryCodes: codes}, nil
+}
+
+// RefreshSession rotates a refresh token and returns a new token pair.
+func (s *UserService) RefreshSession(ctx context.Context, refreshToken string, meta auth.SessionMetadata) (*auth.TokenPair, error) {
+	if s.sessions == nil {
+		return nil, errors.New("refresh tokens are not enabled")
+	}
+	return s.sessions.Refresh(ctx, refreshToken, meta, s)
+}
+
+// Logout terminates the session the access token belongs to.
+func (s *UserService) Logout(ctx context.Context, userID, sessionID string) error {
+	if s.sessions == nil || sessionID == "" {
+		return nil
+	}
+	return s.sessions.RevokeSession(ctx, userID, sessionID, auth.SessionRevokedLogout)
+}
+
+// LogoutAll terminates every session of the user.
+func (s *UserService) LogoutAll(ctx context.Context, userID string) error {
+	if s.sessions == nil {
+		return nil
+	}
+	return s.sessions.RevokeUserSessions(ctx, userID, auth.SessionRevokedLogout)
+}
+
+// LoadTokenSubject implements auth.SubjectLoader. Returns nil for missing or disabled users.
+func (s *UserService) LoadTokenSubject(ctx context.Context, userID string) (*auth.TokenSubject, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, nil
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !isActiveUser(user) {
+		return nil, nil
+	}
+	return tokenSubject(user), nil
+}
+
+// UnlockUser clears a lockout caused by failed logins.
+func (s *UserService) UnlockUser(ctx context.Context, id uuid.ID, actorID string) error {
+	existingUser, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existingUser == nil {
+		return errors.New("user not found")
+	}
+	if s.passwords == nil {
+		return nil
+	}
+	return s.passwords.UnlockAccount(ctx, id.String(), actorID)
+}
+
+// VerifyCredentials checks a user's email and password without signing them in, as when
+// a supervisor approves a void on a cashier's till. Wrong passwords count towards the
+// account lockout.
+func (s *UserService) VerifyCredentials(ctx context.Context, email, password string) (*entities.User, error) {
+	return s.verifyCredentials(ctx, email, password, auth.SessionMetadata{})
+}
+
+func (s *UserService) verifyCredentials(ctx context.Context, email, password string, meta auth.SessionMetadata) (*entities.User, error) {
 	user, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if user == nil {
-		return "", errors.New("invalid credentials")
+		if s.passwords != nil {
+			_ = s.passwords.RecordFailedLogin(ctx, "", email, meta)
+		}
+		return nil, errors.New("invalid credentials")
+	}
+
+	// A locked account is rejected even with the right password
+	if s.passwords != nil {
+		if err := s.passwords.CheckLockout(ctx, user.ID.String()); err != nil {
+			return nil, err
+		}
 	}
 
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", errors.New("invalid credentials")
+		if s.passwords != nil {
+			if err := s.passwords.RecordFailedLogin(ctx, user.ID.String(), email, meta); err != nil {
+				return nil, err
+			}
+		}
+		return nil, errors.New("invalid credentials")
+	}
+	if !isActiveUser(user) {
+		return nil, ErrUserDisabled
+	}
+	if s.passwords != nil {
+		if err := s.passwords.RecordSuccessfulLogin(ctx, user.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// challengeUser resolves the user of an MFA challenge, rejecting users disabled since the password step.
+func (s *UserService) challengeUser(ctx context.Context, challengeToken string) (*entities.User, bool, error) {
+	if s.mfa == nil {
+		return nil, false, auth.ErrInvalidMFAChallenge
+	}
+	userID, enroll, err := s.mfa.ParseChallenge(challengeToken)
+	if err != nil {
+		return nil, false, err
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, false, auth.ErrInvalidMFAChallenge
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, false, auth.ErrInvalidMFAChallenge
+	}
+	if !isActiveUser(user) {
+		return nil, false, ErrUserDisabled
+	}
+	return user, enroll, nil
+}
+
+// issueTokens opens a login session. Without a session service only a legacy
+// long-lived access token is returned.
+func (s *UserService) issueTokens(ctx context.Context, user *entities.User, meta auth.SessionMetadata) (*auth.TokenPair, error) {
+	if s.sessions != nil {
+		return s.sessions.StartSession(ctx, tokenSubject(user), meta)
 	}
 
 	// Create token with configurable expiry (default 48 hours = 2 days)
-	t, err := auth.NewJWT(
+	token, err := auth.NewJWT(
 		user.ID.String(),
 		user.CompanyID,
 		user.Email,
@@ -104,9 +360,27 @@ func (s *UserService) AuthenticateUser(ctx context.Context, email, password stri
 		s.jwtExpiryHours,
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	expiresIn := time.Duration(s.jwtExpiryHours) * time.Hour
+	return &auth.TokenPair{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(expiresIn / time.Second),
+		ExpiresAt:   time.Now().Add(expiresIn),
+	}, nil
+}
 
-	return t, nil
+func tokenSubject(user *entities.User) *auth.TokenSubject {
+	return &auth.TokenSubject{
+		UserID:    user.ID.String(),
+		CompanyID: user.CompanyID,
+		Email:     user.Email,
+		Role:      user.Role,
+	}
 }
 
+// isActiveUser treats an empty status as active for users created before statuses existed.
+func isActiveUser(user *entities.User) bool {
+	return user.Status == "" || user.Status == "active"
+}
@@ -22,18 +22,18 @@ func NewCustomerRepositoryImpl(db *sqlx.DB) *CustomerRepositoryImpl {
 
 // Create creates a new customer in the database.
 func (r *CustomerRepositoryImpl) Create(ctx context.Context, customer *entities.Customer) error {
-	query := `INSERT INTO customers (id, name, contact_person, email, phone, company_id, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, err := r.db.ExecContext(ctx, query, customer.ID, customer.Name, customer.ContactPerson, customer.Email, customer.Phone, customer.CompanyID, customer.Status, customer.CreatedAt, customer.UpdatedAt)
+	query := `INSERT INTO customers (id, name, contact_person, email, phone, address, tax_id, tax_status, company_id, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err := r.db.ExecContext(ctx, query, customer.ID, customer.Name, customer.ContactPerson, customer.Email, customer.Phone, customer.Address, customer.TaxID, customer.TaxStatus, customer.CompanyID, customer.Status, customer.CreatedAt, customer.UpdatedAt)
 	return err
 }
 
 // GetByID retrieves a customer by its ID from the database.
 func (r *CustomerRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.Customer, error) {
-	query := `SELECT id, name, contact_person, email, phone, company_id, status, created_at, updated_at FROM customers WHERE id = $1`
+	query := `SELECT id, name, contact_person, email, phone, COALESCE(address, ''), tax_id, tax_status, company_id, status, created_at, updated_at FROM customers WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	customer := &entities.Customer{}
-	err := row.Scan(&customer.ID, &customer.Name, &customer.ContactPerson, &customer.Email, &customer.Phone, &customer.CompanyID, &customer.Status, &customer.CreatedAt, &customer.UpdatedAt)
+	err := row.Scan(&customer.ID, &customer.Name, &customer.ContactPerson, &customer.Email, &customer.Phone, &customer.Address, &customer.TaxID, &customer.TaxStatus, &customer.CompanyID, &customer.Status, &customer.CreatedAt, &customer.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // Customer not found
 	}
@@ -42,14 +42,14 @@ func (r *CustomerRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*enti
 
 // Update updates an existing customer in the database.
 func (r *CustomerRepositoryImpl) Update(ctx context.Context, customer *entities.Customer) error {
-	query := `UPDATE customers SET name = $1, contact_person = $2, email = $3, phone = $4, company_id = $5, status = $6, updated_at = $7 WHERE id = $8`
-	_, err := r.db.ExecContext(ctx, query, customer.Name, customer.ContactPerson, customer.Email, customer.Phone, customer.CompanyID, customer.Status, customer.UpdatedAt, customer.ID)
+	query := `UPDATE customers SET name = $1, contact_person = $2, email = $3, phone = $4, address = $5, tax_id = $6, tax_status = $7, company_id = $8, status = $9, updated_at = $10 WHERE id = $11`
+	_, err := r.db.ExecContext(ctx, query, customer.Name, customer.ContactPerson, customer.Email, customer.Phone, customer.Address, customer.TaxID, customer.TaxStatus, customer.CompanyID, customer.Status, customer.UpdatedAt, customer.ID)
 	return err
 }
 
 // GetAll retrieves all customers from the database.
 func (r *CustomerRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Customer, error) {
-	query := `SELECT id, name, contact_person, email, phone, company_id, status, created_at, updated_at FROM customers ORDER BY created_at DESC`
+	query := `SELECT id, name, contact_person, email, phone, COALESCE(address, ''), tax_id, tax_status, company_id, status, created_at, updated_at FROM customers ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -59,7 +59,7 @@ func (r *CustomerRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Custom
 	var customers []*entities.Customer
 	for rows.Next() {
 		customer := &entities.Customer{}
-		err := rows.Scan(&customer.ID, &customer.Name, &customer.ContactPerson, &customer.Email, &customer.Phone, &customer.CompanyID, &customer.Status, &customer.CreatedAt, &customer.UpdatedAt)
+		err := rows.Scan(&customer.ID, &customer.Name, &customer.ContactPerson, &customer.Email, &customer.Phone, &customer.Address, &customer.TaxID, &customer.TaxStatus, &customer.CompanyID, &customer.Status, &customer.CreatedAt, &customer.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -100,7 +100,7 @@ func (r *CustomerRepositoryImpl) GetAllWithPagination(ctx context.Context, limit
 	limitIndex := len(args) + 1
 	offsetIndex := len(args) + 2
 	
-	query := fmt.Sprintf(`SELECT id, name, contact_person, email, phone, company_id, status, created_at, updated_at 
+	query := fmt.Sprintf(`SELECT id, name, contact_person, email, phone, COALESCE(address, ''), tax_id, tax_status, company_id, status, created_at, updated_at 
 			  FROM customers %s 
 			  ORDER BY created_at DESC 
 			  LIMIT $%d OFFSET $%d`, whereClause, limitIndex, offsetIndex)
@@ -117,7 +117,7 @@ func (r *CustomerRepositoryImpl) GetAllWithPagination(ctx context.Context, limit
 	var customers []*entities.Customer
 	for rows.Next() {
 		customer := &entities.Customer{}
-		err := rows.Scan(&customer.ID, &customer.Name, &customer.ContactPerson, &customer.Email, &customer.Phone, &customer.CompanyID, &customer.Status, &customer.CreatedAt, &customer.UpdatedAt)
+		err := rows.Scan(&customer.ID, &customer.Name, &customer.ContactPerson, &customer.Email, &customer.Phone, &customer.Address, &customer.TaxID, &customer.TaxStatus, &customer.CompanyID, &customer.Status, &customer.CreatedAt, &customer.UpdatedAt)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -3,6 +3,7 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"malaka/internal/modules/masterdata/domain/entities"
@@ -73,3 +74,17 @@ func (r *PriceRepositoryImpl) Delete(ctx context.Context, id uuid.ID) error {
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// GetEffective retrieves the latest price of an article in effect at a time.
+func (r *PriceRepositoryImpl) GetEffective(ctx context.Context, articleID uuid.ID, at time.Time) (*entities.Price, error) {
+	query := `SELECT id, article_id, COALESCE(company_id::text, '') as company_id, amount, currency, effective_date, created_at, updated_at FROM prices
+		WHERE article_id = $1 AND effective_date <= $2 ORDER BY effective_date DESC LIMIT 1`
+	row := r.db.QueryRowContext(ctx, query, articleID, at)
+
+	price := &entities.Price{}
+	err := row.Scan(&price.ID, &price.ArticleID, &price.CompanyID, &price.Amount, &price.Currency, &price.EffectiveDate, &price.CreatedAt, &price.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // No price in effect
+	}
+	return price, err
+}
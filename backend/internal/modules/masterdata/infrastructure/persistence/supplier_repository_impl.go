@@ -21,8 +21,8 @@ func NewSupplierRepositoryImpl(db *sqlx.DB) *SupplierRepositoryImpl {
 
 // Create creates a new supplier in the database.
 func (r *SupplierRepositoryImpl) Create(ctx context.Context, supplier *entities.Supplier) error {
-	query := `INSERT INTO suppliers (id, code, name, address, contact, contact_person, phone, email, website, tax_id, payment_terms, credit_limit, status, company_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+	query := `INSERT INTO suppliers (id, code, name, address, contact, contact_person, phone, email, website, tax_id, tax_status, payment_terms, credit_limit, status, company_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
 	var companyID interface{} = nil
 	if supplier.CompanyID != "" {
 		companyID = supplier.CompanyID
@@ -30,7 +30,7 @@ func (r *SupplierRepositoryImpl) Create(ctx context.Context, supplier *entities.
 	_, err := r.db.ExecContext(ctx, query,
 		supplier.ID, supplier.Code, supplier.Name, supplier.Address, supplier.Contact,
 		supplier.ContactPerson, supplier.Phone, supplier.Email, supplier.Website,
-		supplier.TaxID, supplier.PaymentTerms, supplier.CreditLimit, supplier.Status,
+		supplier.TaxID, supplier.TaxStatus, supplier.PaymentTerms, supplier.CreditLimit, supplier.Status,
 		companyID, supplier.CreatedAt, supplier.UpdatedAt,
 	)
 	return err
@@ -41,7 +41,7 @@ func (r *SupplierRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*enti
 	query := `SELECT id, COALESCE(code, '') as code, name, COALESCE(address, '') as address,
 		COALESCE(contact, '') as contact, COALESCE(contact_person, '') as contact_person,
 		COALESCE(phone, '') as phone, COALESCE(email, '') as email,
-		COALESCE(website, '') as website, COALESCE(tax_id, '') as tax_id,
+		COALESCE(website, '') as website, COALESCE(tax_id, '') as tax_id, tax_status,
 		COALESCE(payment_terms, '') as payment_terms, COALESCE(credit_limit, 0) as credit_limit,
 		COALESCE(status, 'active') as status, COALESCE(company_id::text, '') as company_id,
 		created_at, updated_at
@@ -52,7 +52,7 @@ func (r *SupplierRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*enti
 	err := row.Scan(
 		&supplier.ID, &supplier.Code, &supplier.Name, &supplier.Address,
 		&supplier.Contact, &supplier.ContactPerson, &supplier.Phone, &supplier.Email,
-		&supplier.Website, &supplier.TaxID, &supplier.PaymentTerms, &supplier.CreditLimit,
+		&supplier.Website, &supplier.TaxID, &supplier.TaxStatus, &supplier.PaymentTerms, &supplier.CreditLimit,
 		&supplier.Status, &supplier.CompanyID, &supplier.CreatedAt, &supplier.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -64,9 +64,9 @@ func (r *SupplierRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*enti
 // Update updates an existing supplier in the database.
 func (r *SupplierRepositoryImpl) Update(ctx context.Context, supplier *entities.Supplier) error {
 	query := `UPDATE suppliers SET code = $1, name = $2, address = $3, contact = $4,
-		contact_person = $5, phone = $6, email = $7, website = $8, tax_id = $9,
-		payment_terms = $10, credit_limit = $11, status = $12, company_id = $13, updated_at = $14
-		WHERE id = $15`
+		contact_person = $5, phone = $6, email = $7, website = $8, tax_id = $9, tax_status = $10,
+		payment_terms = $11, credit_limit = $12, status = $13, company_id = $14, updated_at = $15
+		WHERE id = $16`
 	var companyID interface{} = nil
 	if supplier.CompanyID != "" {
 		companyID = supplier.CompanyID
@@ -74,7 +74,7 @@ func (r *SupplierRepositoryImpl) Update(ctx context.Context, supplier *entities.
 	_, err := r.db.ExecContext(ctx, query,
 		supplier.Code, supplier.Name, supplier.Address, supplier.Contact,
 		supplier.ContactPerson, supplier.Phone, supplier.Email, supplier.Website,
-		supplier.TaxID, supplier.PaymentTerms, supplier.CreditLimit, supplier.Status,
+		supplier.TaxID, supplier.TaxStatus, supplier.PaymentTerms, supplier.CreditLimit, supplier.Status,
 		companyID, supplier.UpdatedAt, supplier.ID,
 	)
 	return err
@@ -85,7 +85,7 @@ func (r *SupplierRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Suppli
 	query := `SELECT id, COALESCE(code, '') as code, name, COALESCE(address, '') as address,
 		COALESCE(contact, '') as contact, COALESCE(contact_person, '') as contact_person,
 		COALESCE(phone, '') as phone, COALESCE(email, '') as email,
-		COALESCE(website, '') as website, COALESCE(tax_id, '') as tax_id,
+		COALESCE(website, '') as website, COALESCE(tax_id, '') as tax_id, tax_status,
 		COALESCE(payment_terms, '') as payment_terms, COALESCE(credit_limit, 0) as credit_limit,
 		COALESCE(status, 'active') as status, COALESCE(company_id::text, '') as company_id,
 		created_at, updated_at
@@ -102,7 +102,7 @@ func (r *SupplierRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Suppli
 		err := rows.Scan(
 			&supplier.ID, &supplier.Code, &supplier.Name, &supplier.Address,
 			&supplier.Contact, &supplier.ContactPerson, &supplier.Phone, &supplier.Email,
-			&supplier.Website, &supplier.TaxID, &supplier.PaymentTerms, &supplier.CreditLimit,
+			&supplier.Website, &supplier.TaxID, &supplier.TaxStatus, &supplier.PaymentTerms, &supplier.CreditLimit,
 			&supplier.Status, &supplier.CompanyID, &supplier.CreatedAt, &supplier.UpdatedAt,
 		)
 		if err != nil {
@@ -11,6 +11,9 @@ type CreateCustomerRequest struct {
 	ContactPerson string `json:"contact_person"`
 	Email         string `json:"email" binding:"required,email"`
 	Phone         string `json:"phone"`
+	Address       string `json:"address"`
+	TaxID         string `json:"tax_id"`
+	TaxStatus     string `json:"tax_status" binding:"omitempty,oneof=PKP NON_PKP COLLECTOR EXEMPT"`
 	CompanyID     string `json:"company_id" binding:"required"`
 	Status        string `json:"status" binding:"required,oneof=active inactive"`
 }
@@ -22,6 +25,9 @@ func (r *CreateCustomerRequest) ToEntity() *entities.Customer {
 		ContactPerson: r.ContactPerson,
 		Email:         r.Email,
 		Phone:         r.Phone,
+		Address:       r.Address,
+		TaxID:         r.TaxID,
+		TaxStatus:     r.TaxStatus,
 		Status:        r.Status,
 	}
 
@@ -46,6 +52,9 @@ type UpdateCustomerRequest struct {
 	ContactPerson string `json:"contact_person"`
 	Email         string `json:"email" binding:"omitempty,email"`
 	Phone         string `json:"phone"`
+	Address       string `json:"address"`
+	TaxID         string `json:"tax_id"`
+	TaxStatus     string `json:"tax_status" binding:"omitempty,oneof=PKP NON_PKP COLLECTOR EXEMPT"`
 	CompanyID     string `json:"company_id"`
 	Status        string `json:"status" binding:"omitempty,oneof=active inactive"`
 }
@@ -60,6 +69,11 @@ func (r *UpdateCustomerRequest) ApplyToEntity(customer *entities.Customer) {
 		customer.Email = r.Email
 	}
 	customer.Phone = r.Phone
+	customer.Address = r.Address
+	customer.TaxID = r.TaxID
+	if r.TaxStatus != "" {
+		customer.TaxStatus = r.TaxStatus
+	}
 	if r.CompanyID != "" {
 		if id, err := uuid.Parse(r.CompanyID); err == nil {
 			customer.CompanyID = id
@@ -77,6 +91,9 @@ type CustomerResponse struct {
 	ContactPerson string `json:"contact_person"`
 	Email         string `json:"email"`
 	Phone         string `json:"phone"`
+	Address       string `json:"address"`
+	TaxID         string `json:"tax_id"`
+	TaxStatus     string `json:"tax_status"`
 	CompanyID     string `json:"company_id"`
 	Status        string `json:"status"`
 	CreatedAt     string `json:"created_at"`
@@ -91,6 +108,9 @@ func CustomerResponseFromEntity(customer *entities.Customer) *CustomerResponse {
 		ContactPerson: customer.ContactPerson,
 		Email:         customer.Email,
 		Phone:         customer.Phone,
+		Address:       customer.Address,
+		TaxID:         customer.TaxID,
+		TaxStatus:     customer.TaxStatus,
 		CompanyID:     customer.CompanyID.String(),
 		Status:        customer.Status,
 		CreatedAt:     customer.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -15,6 +15,7 @@ type CreateSupplierRequest struct {
 	Email         string  `json:"email"`
 	Website       string  `json:"website"`
 	TaxID         string  `json:"tax_id"`
+	TaxStatus     string  `json:"tax_status" binding:"omitempty,oneof=PKP NON_PKP COLLECTOR EXEMPT"`
 	PaymentTerms  string  `json:"payment_terms"`
 	CreditLimit   float64 `json:"credit_limit"`
 	Status        string  `json:"status"`
@@ -37,6 +38,7 @@ func (r *CreateSupplierRequest) ToEntity() *entities.Supplier {
 		Email:         r.Email,
 		Website:       r.Website,
 		TaxID:         r.TaxID,
+		TaxStatus:     r.TaxStatus,
 		PaymentTerms:  r.PaymentTerms,
 		CreditLimit:   r.CreditLimit,
 		Status:        status,
@@ -55,6 +57,7 @@ type UpdateSupplierRequest struct {
 	Email         string  `json:"email"`
 	Website       string  `json:"website"`
 	TaxID         string  `json:"tax_id"`
+	TaxStatus     string  `json:"tax_status" binding:"omitempty,oneof=PKP NON_PKP COLLECTOR EXEMPT"`
 	PaymentTerms  string  `json:"payment_terms"`
 	CreditLimit   float64 `json:"credit_limit"`
 	Status        string  `json:"status"`
@@ -74,6 +77,9 @@ func (r *UpdateSupplierRequest) ApplyToEntity(supplier *entities.Supplier) {
 	supplier.Email = r.Email
 	supplier.Website = r.Website
 	supplier.TaxID = r.TaxID
+	if r.TaxStatus != "" {
+		supplier.TaxStatus = r.TaxStatus
+	}
 	supplier.PaymentTerms = r.PaymentTerms
 	supplier.CreditLimit = r.CreditLimit
 	if r.Status != "" {
@@ -96,6 +102,7 @@ type SupplierResponse struct {
 	Email         string  `json:"email"`
 	Website       string  `json:"website"`
 	TaxID         string  `json:"tax_id"`
+	TaxStatus     string  `json:"tax_status"`
 	PaymentTerms  string  `json:"payment_terms"`
 	CreditLimit   float64 `json:"credit_limit"`
 	Status        string  `json:"status"`
@@ -117,6 +124,7 @@ func SupplierResponseFromEntity(supplier *entities.Supplier) *SupplierResponse {
 		Email:         supplier.Email,
 		Website:       supplier.Website,
 		TaxID:         supplier.TaxID,
+		TaxStatus:     supplier.TaxStatus,
 		PaymentTerms:  supplier.PaymentTerms,
 		CreditLimit:   supplier.CreditLimit,
 		Status:        supplier.Status,
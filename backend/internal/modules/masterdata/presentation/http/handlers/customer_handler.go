@@ -36,6 +36,9 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 		ContactPerson: req.ContactPerson,
 		Email:         req.Email,
 		Phone:         req.Phone,
+		Address:       req.Address,
+		TaxID:         req.TaxID,
+		TaxStatus:     req.TaxStatus,
 		CompanyID:     uuid.MustParse(req.CompanyID),
 		Status:        req.Status,
 	}
@@ -148,6 +151,15 @@ func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
 	if req.Phone != "" {
 		customer.Phone = req.Phone
 	}
+	if req.Address != "" {
+		customer.Address = req.Address
+	}
+	if req.TaxID != "" {
+		customer.TaxID = req.TaxID
+	}
+	if req.TaxStatus != "" {
+		customer.TaxStatus = req.TaxStatus
+	}
 	if req.CompanyID != "" {
 		customer.CompanyID = uuid.MustParse(req.CompanyID)
 	}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"malaka/internal/modules/masterdata/domain/entities"
 	"malaka/internal/modules/masterdata/domain/services"
 	"malaka/internal/modules/masterdata/presentation/http/dto"
+	"malaka/internal/shared/auth"
 	"malaka/internal/shared/response"
 	"malaka/internal/shared/uuid"
 )
@@ -22,7 +24,7 @@ func NewUserHandler(service *services.UserService) *UserHandler {
 	return &UserHandler{service: service}
 }
 
-// Login authenticates a user and returns a JWT token.
+// Login authenticates a user and returns an access token and, when sessions are enabled, a refresh token.
 func (h *UserHandler) Login(c *gin.Context) {
 	var req struct {
 		Email    string `json:"email"`
@@ -34,13 +36,173 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.AuthenticateUser(c.Request.Context(), req.Email, req.Password)
+	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, sessionMetadata(c))
 	if err != nil {
+		if errors.Is(err, services.ErrUserDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+			return
+		}
+		if errors.Is(err, auth.ErrAccountLocked) {
+			c.JSON(http.StatusLocked, gin.H{"error": "Account is temporarily locked after too many failed login attempts"})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	if result.Challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":        true,
+			"enrollment_required": result.Challenge.EnrollmentRequired,
+			"mfa_token":           result.Challenge.Token,
+			"expires_in":          result.Challenge.ExpiresIn,
+			"expires_at":          result.Challenge.ExpiresAt,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse(result.Tokens))
+}
+
+// VerifyMFA completes a login with the MFA challenge token and a TOTP or recovery code.
+func (h *UserHandler) VerifyMFA(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.service.CompleteMFALogin(c.Request.Context(), req.MFAToken, req.Code, sessionMetadata(c))
+	if err != nil {
+		mfaLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// BeginMFAEnrollment starts TOTP enrollment during login for users whose role requires 2FA.
+func (h *UserHandler) BeginMFAEnrollment(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.service.BeginMFAEnrollment(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		mfaLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmMFAEnrollment activates the enrollment with a first code and completes the login.
+func (h *UserHandler) ConfirmMFAEnrollment(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.ConfirmMFAEnrollment(c.Request.Context(), req.MFAToken, req.Code, sessionMetadata(c))
+	if err != nil {
+		mfaLoginError(c, err)
+		return
+	}
+	body := tokenResponse(result.Tokens)
+	body["recovery_codes"] = result.RecoveryCodes
+	c.JSON(http.StatusOK, body)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. The presented refresh token is invalidated.
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.service.RefreshSession(c.Request.Context(), req.RefreshToken, sessionMetadata(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please sign in again"})
+		case errors.Is(err, auth.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// Logout terminates the current login session.
+func (h *UserHandler) Logout(c *gin.Context) {
+	if err := h.service.Logout(c.Request.Context(), auth.GetUserID(c), auth.GetSessionID(c)); err != nil {
+		response.InternalServerError(c, "Failed to log out", err.Error())
+		return
+	}
+	response.OK(c, "Logged out successfully", nil)
+}
+
+// LogoutAll terminates every login session of the current user.
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	if err := h.service.LogoutAll(c.Request.Context(), auth.GetUserID(c)); err != nil {
+		response.InternalServerError(c, "Failed to log out", err.Error())
+		return
+	}
+	response.OK(c, "Logged out of all sessions", nil)
+}
+
+func mfaLoginError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidMFAChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired two-factor challenge, please sign in again"})
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+	case errors.Is(err, auth.ErrMFALocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed attempts, try again later"})
+	case errors.Is(err, services.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+	case errors.Is(err, auth.ErrMFANotEnrolled), errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify two-factor authentication"})
+	}
+}
+
+func sessionMetadata(c *gin.Context) auth.SessionMetadata {
+	return auth.SessionMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// tokenResponse keeps the legacy "token" field next to the token pair for existing clients.
+func tokenResponse(tokens *auth.TokenPair) gin.H {
+	body := gin.H{
+		"token":        tokens.AccessToken,
+		"access_token": tokens.AccessToken,
+		"token_type":   tokens.TokenType,
+		"expires_in":   tokens.ExpiresIn,
+		"expires_at":   tokens.ExpiresAt,
+	}
+	if tokens.RefreshToken != "" {
+		body["refresh_token"] = tokens.RefreshToken
+		body["session_id"] = tokens.SessionID
+	}
+	return body
 }
 
 // CreateUser handles the creation of a new user.
@@ -77,6 +239,11 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	}
 
 	if err := h.service.CreateUser(c.Request.Context(), user); err != nil {
+		var policyErr *auth.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			response.BadRequest(c, "Password does not meet the policy", policyErr.Violations)
+			return
+		}
 		response.InternalServerError(c, "Failed to create user", err.Error())
 		return
 	}
@@ -215,4 +382,23 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	}
 
 	response.Success(c, http.StatusOK, "User deleted successfully", nil)
-}
\ No newline at end of file
+}
+// UnlockUser clears a lockout caused by too many failed logins.
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err.Error())
+		return
+	}
+
+	if err := h.service.UnlockUser(c.Request.Context(), id, auth.GetUserID(c)); err != nil {
+		if err.Error() == "user not found" {
+			response.NotFound(c, "User not found", nil)
+			return
+		}
+		response.InternalServerError(c, "Failed to unlock user", err.Error())
+		return
+	}
+
+	response.OK(c, "User unlocked successfully", nil)
+}
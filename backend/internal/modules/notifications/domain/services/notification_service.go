@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"malaka/internal/modules/notifications/domain/entities"
 	"malaka/internal/modules/notifications/domain/repositories"
@@ -435,6 +436,55 @@ func (s *NotificationService) NotifyRFQResponseAccepted(ctx context.Context, cre
 	)
 }
 
+// --- Contract Notifications ---
+
+// NotifyContractExpiring notifies the contract owner that the notice period of the contract
+// has started
+func (s *NotificationService) NotifyContractExpiring(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string, endDate time.Time, autoRenewal bool) error {
+	msg := fmt.Sprintf("Contract %s expires on %s", contractNumber, endDate.Format("2006-01-02"))
+	if autoRenewal {
+		msg = fmt.Sprintf("Contract %s renews automatically on %s unless it is terminated before then", contractNumber, endDate.Format("2006-01-02"))
+	}
+	return s.SendNotification(
+		ctx,
+		ownerID,
+		"Contract Expiring",
+		msg,
+		entities.NotificationTypeProcurement,
+		WithPriority(entities.NotificationPriorityHigh),
+		WithActionURL(fmt.Sprintf("/procurement/contracts/%s", contractID)),
+		WithReference("contract", contractID),
+	)
+}
+
+// NotifyContractRenewed notifies the contract owner that the contract renewed automatically
+func (s *NotificationService) NotifyContractRenewed(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string, newEndDate time.Time) error {
+	return s.SendNotification(
+		ctx,
+		ownerID,
+		"Contract Renewed",
+		fmt.Sprintf("Contract %s has been renewed automatically until %s", contractNumber, newEndDate.Format("2006-01-02")),
+		entities.NotificationTypeProcurement,
+		WithPriority(entities.NotificationPriorityNormal),
+		WithActionURL(fmt.Sprintf("/procurement/contracts/%s", contractID)),
+		WithReference("contract", contractID),
+	)
+}
+
+// NotifyContractExpired notifies the contract owner that the contract has expired
+func (s *NotificationService) NotifyContractExpired(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string) error {
+	return s.SendNotification(
+		ctx,
+		ownerID,
+		"Contract Expired",
+		fmt.Sprintf("Contract %s has expired and can no longer be called off", contractNumber),
+		entities.NotificationTypeProcurement,
+		WithPriority(entities.NotificationPriorityHigh),
+		WithActionURL(fmt.Sprintf("/procurement/contracts/%s", contractID)),
+		WithReference("contract", contractID),
+	)
+}
+
 // --- Stock Transfer Notifications ---
 
 // NotifyTransferApproved notifies the transfer creator that the transfer was approved.
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"errors"
 	"time"
 
 	"malaka/internal/shared/types"
@@ -27,11 +28,32 @@ type Contract struct {
 	SignedBy        *string    `json:"signed_by,omitempty" db:"signed_by"`
 	SignedDate      *time.Time `json:"signed_date,omitempty" db:"signed_date"`
 	Attachments     []string   `json:"attachments,omitempty" db:"attachments"`
+	// OwnerID is the user responsible for the contract, told when it is about to expire
+	OwnerID *string `json:"owner_id,omitempty" db:"owner_id"`
+	// ExpiryNotifiedAt is when the owner was told of the coming expiry of the current term
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty" db:"expiry_notified_at"`
 
 	// Related data for API responses
-	SupplierName string `json:"supplier_name,omitempty" db:"supplier_name"`
+	SupplierName string          `json:"supplier_name,omitempty" db:"supplier_name"`
+	Items        []*ContractItem `json:"items,omitempty" db:"-"`
 }
 
+// Contract call-off errors
+var (
+	ErrContractNotFound      = errors.New("contract not found")
+	ErrContractItemNotFound  = errors.New("contract item not found")
+	ErrInvalidContractItem   = errors.New("invalid contract item")
+	ErrContractNotCallable   = errors.New("call-offs need an active framework contract in its term")
+	ErrInvalidCallOff        = errors.New("invalid call-off")
+	ErrContractCapExceeded   = errors.New("call-off exceeds the contract cap")
+	ErrCallOffItemsFixed     = errors.New("the items of a call-off follow its contract")
+	ErrContractItemCalledOff = errors.New("contract item has been called off")
+)
+
+// DefaultContractNoticeDays is how long before the end of a contract without a notice
+// period its owner is told of the coming expiry.
+const DefaultContractNoticeDays = 30
+
 // ContractStatus constants
 const (
 	ContractStatusDraft      = "draft"
@@ -92,6 +114,35 @@ func (c *Contract) DaysUntilExpiry() int {
 	return int(time.Until(c.EndDate).Hours() / 24)
 }
 
+// NoticeDays returns the notice period of the contract, or the default without one.
+func (c *Contract) NoticeDays() int {
+	if c.NoticePeriod == nil || *c.NoticePeriod <= 0 {
+		return DefaultContractNoticeDays
+	}
+	return *c.NoticePeriod
+}
+
+// CanAutoRenew checks if the contract renews itself at the end of its term.
+func (c *Contract) CanAutoRenew() bool {
+	return c.AutoRenewal && c.RenewalPeriod != nil && *c.RenewalPeriod > 0 && c.CanBeRenewed()
+}
+
+// NextTermEnd returns the end date of the next term, a renewal period after the current
+// end date.
+func (c *Contract) NextTermEnd() time.Time {
+	if c.RenewalPeriod == nil {
+		return c.EndDate
+	}
+	return c.EndDate.AddDate(0, *c.RenewalPeriod, 0)
+}
+
+// IsCallableOn checks if purchase orders can be called off the contract on a date.
+func (c *Contract) IsCallableOn(date time.Time) bool {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, c.EndDate.Location())
+	return c.Status == ContractStatusActive && c.ContractType == ContractTypeFramework &&
+		!day.Before(c.StartDate) && !day.After(c.EndDate)
+}
+
 // IsExpiringSoon checks if the contract is expiring within the notice period.
 func (c *Contract) IsExpiringSoon() bool {
 	if c.NoticePeriod == nil {
@@ -0,0 +1,176 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"malaka/internal/shared/types"
+)
+
+// ContractItem is a line of a contract: the agreed unit price of an item and, optionally,
+// the most that may be called off of it in quantity or value over the contract.
+type ContractItem struct {
+	types.BaseModel
+	ContractID  string   `json:"contract_id" db:"contract_id"`
+	ItemName    string   `json:"item_name" db:"item_name"`
+	Description *string  `json:"description,omitempty" db:"description"`
+	Unit        string   `json:"unit" db:"unit"`
+	UnitPrice   float64  `json:"unit_price" db:"unit_price"`
+	QuantityCap *int     `json:"quantity_cap,omitempty" db:"quantity_cap"`
+	ValueCap    *float64 `json:"value_cap,omitempty" db:"value_cap"`
+
+	// Consumption by the call-offs that are not cancelled
+	ConsumedQuantity  int      `json:"consumed_quantity" db:"consumed_quantity"`
+	ConsumedValue     float64  `json:"consumed_value" db:"consumed_value"`
+	RemainingQuantity *int     `json:"remaining_quantity,omitempty" db:"-"`
+	RemainingValue    *float64 `json:"remaining_value,omitempty" db:"-"`
+}
+
+// Validate checks the agreed price and caps of the item.
+func (i *ContractItem) Validate() error {
+	if i.ItemName == "" {
+		return fmt.Errorf("%w: item name is required", ErrInvalidContractItem)
+	}
+	if i.UnitPrice < 0 {
+		return fmt.Errorf("%w: unit price cannot be negative", ErrInvalidContractItem)
+	}
+	if i.QuantityCap != nil && *i.QuantityCap <= 0 {
+		return fmt.Errorf("%w: quantity cap must be above 0", ErrInvalidContractItem)
+	}
+	if i.ValueCap != nil && *i.ValueCap <= 0 {
+		return fmt.Errorf("%w: value cap must be above 0", ErrInvalidContractItem)
+	}
+	return nil
+}
+
+// SetRemaining sets what is left of the caps of the item after its consumption.
+func (i *ContractItem) SetRemaining() {
+	i.RemainingQuantity, i.RemainingValue = nil, nil
+	if i.QuantityCap != nil {
+		remaining := *i.QuantityCap - i.ConsumedQuantity
+		i.RemainingQuantity = &remaining
+	}
+	if i.ValueCap != nil {
+		remaining := round2(*i.ValueCap - i.ConsumedValue)
+		i.RemainingValue = &remaining
+	}
+}
+
+// CallOffRequest raises a purchase order against a framework contract. Lines are priced at
+// the agreed unit price unless a lower price is given.
+type CallOffRequest struct {
+	Lines                []CallOffLineInput `json:"lines"`
+	ExpectedDeliveryDate *time.Time         `json:"expected_delivery_date,omitempty"`
+	DeliveryAddress      string             `json:"delivery_address"`
+	ProcurementType      ProcurementType    `json:"procurement_type,omitempty"`
+	Notes                string             `json:"notes,omitempty"`
+}
+
+// CallOffLineInput calls off a quantity of a contract item.
+type CallOffLineInput struct {
+	ContractItemID string   `json:"contract_item_id"`
+	Quantity       int      `json:"quantity"`
+	UnitPrice      *float64 `json:"unit_price,omitempty"`
+}
+
+// ContractCallOffLine records a quantity of a contract item called off on a purchase order.
+// The amount is the quantity at the unit price, before discount and tax.
+type ContractCallOffLine struct {
+	ID              string    `json:"id" db:"id"`
+	ContractID      string    `json:"contract_id" db:"contract_id"`
+	ContractItemID  string    `json:"contract_item_id" db:"contract_item_id"`
+	PurchaseOrderID string    `json:"purchase_order_id" db:"purchase_order_id"`
+	POItemID        string    `json:"po_item_id" db:"po_item_id"`
+	Quantity        int       `json:"quantity" db:"quantity"`
+	UnitPrice       float64   `json:"unit_price" db:"unit_price"`
+	Amount          float64   `json:"amount" db:"amount"`
+	CreatedBy       *string   `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+
+	// Related data
+	ItemName string `json:"item_name,omitempty" db:"item_name"`
+	PONumber string `json:"po_number,omitempty" db:"po_number"`
+	POStatus string `json:"po_status,omitempty" db:"po_status"`
+}
+
+// ContractConsumption is how much of a contract has been called off: per item against its
+// caps, and in total against the contract value when it has one.
+type ContractConsumption struct {
+	ContractID     string                 `json:"contract_id"`
+	ContractNumber string                 `json:"contract_number"`
+	Currency       string                 `json:"currency"`
+	Value          float64                `json:"value"`
+	ConsumedValue  float64                `json:"consumed_value"`
+	RemainingValue *float64               `json:"remaining_value,omitempty"`
+	Utilization    float64                `json:"utilization"`
+	Items          []*ContractItem        `json:"items"`
+	CallOffs       []*ContractCallOffLine `json:"call_offs"`
+}
+
+// NewContractConsumption sums the consumption of the items of a contract. A contract value
+// of 0 leaves the total uncapped.
+func NewContractConsumption(contract *Contract, items []*ContractItem, lines []*ContractCallOffLine) *ContractConsumption {
+	c := &ContractConsumption{
+		ContractID:     contract.ID.String(),
+		ContractNumber: contract.ContractNumber,
+		Currency:       contract.Currency,
+		Value:          contract.Value,
+		Items:          items,
+		CallOffs:       lines,
+	}
+	for _, item := range items {
+		item.SetRemaining()
+		c.ConsumedValue += item.ConsumedValue
+	}
+	c.ConsumedValue = round2(c.ConsumedValue)
+	if contract.Value > 0 {
+		remaining := round2(contract.Value - c.ConsumedValue)
+		c.RemainingValue = &remaining
+		c.Utilization = round2(c.ConsumedValue / contract.Value * 100)
+	}
+	return c
+}
+
+// CheckCallOff checks that calling off the lines keeps each contract item within its caps
+// and the contract within its value. Items must carry their consumption so far, and
+// consumedValue is that of the whole contract.
+func CheckCallOff(contract *Contract, items []*ContractItem, consumedValue float64, lines []*ContractCallOffLine) error {
+	byID := make(map[string]*ContractItem, len(items))
+	for _, item := range items {
+		byID[item.ID.String()] = item
+	}
+	quantities := make(map[string]int)
+	values := make(map[string]float64)
+	var total float64
+	for _, line := range lines {
+		quantities[line.ContractItemID] += line.Quantity
+		values[line.ContractItemID] += line.Amount
+		total += line.Amount
+	}
+	for _, line := range lines {
+		item, ok := byID[line.ContractItemID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrContractItemNotFound, line.ContractItemID)
+		}
+		if item.QuantityCap != nil && item.ConsumedQuantity+quantities[item.ID.String()] > *item.QuantityCap {
+			return fmt.Errorf("%w: %s has %d of %d %s left", ErrContractCapExceeded,
+				item.ItemName, *item.QuantityCap-item.ConsumedQuantity, *item.QuantityCap, item.Unit)
+		}
+		if item.ValueCap != nil && round2(item.ConsumedValue+values[item.ID.String()]) > *item.ValueCap {
+			return fmt.Errorf("%w: %s has %.2f of %.2f %s left", ErrContractCapExceeded,
+				item.ItemName, round2(*item.ValueCap-item.ConsumedValue), *item.ValueCap, contract.Currency)
+		}
+	}
+	if contract.Value > 0 && round2(consumedValue+total) > contract.Value {
+		return fmt.Errorf("%w: contract %s has %.2f of %.2f %s left", ErrContractCapExceeded,
+			contract.ContractNumber, round2(contract.Value-consumedValue), contract.Value, contract.Currency)
+	}
+	return nil
+}
+
+// ContractRenewalRun is the outcome of a run of the contract renewal job.
+type ContractRenewalRun struct {
+	Renewed  int `json:"renewed"`
+	Expired  int `json:"expired"`
+	Notified int `json:"notified"`
+}
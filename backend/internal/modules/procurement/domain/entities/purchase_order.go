@@ -69,6 +69,8 @@ type PurchaseOrder struct {
 	ExpenseAccountID     *uuid.ID                   `json:"expense_account_id,omitempty" db:"expense_account_id"`
 	// BudgetCommitmentID references the budget commitment created when PO is approved
 	BudgetCommitmentID   *uuid.ID                   `json:"budget_commitment_id,omitempty" db:"budget_commitment_id"`
+	// ContractID references the framework contract the order is a call-off of
+	ContractID           *uuid.ID                   `json:"contract_id,omitempty" db:"contract_id"`
 	CreatedBy            uuid.ID                    `json:"created_by" db:"created_by"`
 	CreatedByName        string                     `json:"created_by_name" db:"created_by_name"`
 	CreatedByPosition    string                     `json:"created_by_position" db:"created_by_position"`
@@ -99,6 +101,7 @@ type PurchaseOrderItem struct {
 	LineTotal          float64   `json:"line_total" db:"line_total"`
 	ReceivedQuantity   int       `json:"received_quantity" db:"received_quantity"`
 	Currency           string    `json:"currency" db:"currency"`
+	ContractItemID     *uuid.ID  `json:"contract_item_id,omitempty" db:"contract_item_id"`
 	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -0,0 +1,207 @@
+package entities
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"malaka/internal/shared/types"
+)
+
+// RFQ award errors
+var (
+	ErrRFQNotFound         = errors.New("RFQ not found")
+	ErrRFQResponseNotFound = errors.New("response not found")
+	ErrRFQNotAwardable     = errors.New("only published RFQs can be awarded")
+	ErrInvalidRFQAward     = errors.New("invalid RFQ award")
+	ErrInvalidBidWeights   = errors.New("invalid bid scoring weights")
+	ErrNoExchangeRate      = errors.New("no exchange rate for currency")
+)
+
+// RFQBaseCurrency is the currency bids are compared in.
+const RFQBaseCurrency = "IDR"
+
+// NeutralVendorScore stands in for the vendor evaluation score (1-5) of a supplier that
+// has no approved evaluation, so new suppliers are neither favored nor penalized.
+const NeutralVendorScore = 3.0
+
+// BidWeights are the weights of price, delivery time and vendor evaluation in the score
+// of a bid. They are relative: only their proportions matter.
+type BidWeights struct {
+	Price    float64 `json:"price"`
+	Delivery float64 `json:"delivery"`
+	Vendor   float64 `json:"vendor"`
+}
+
+// DefaultBidWeights favors price, with delivery time and vendor evaluation as tie breakers.
+func DefaultBidWeights() BidWeights {
+	return BidWeights{Price: 0.6, Delivery: 0.2, Vendor: 0.2}
+}
+
+// Normalize scales the weights to sum to 1.
+func (w BidWeights) Normalize() (BidWeights, error) {
+	if w.Price < 0 || w.Delivery < 0 || w.Vendor < 0 {
+		return w, ErrInvalidBidWeights
+	}
+	sum := w.Price + w.Delivery + w.Vendor
+	if sum == 0 {
+		return w, ErrInvalidBidWeights
+	}
+	return BidWeights{Price: w.Price / sum, Delivery: w.Delivery / sum, Vendor: w.Vendor / sum}, nil
+}
+
+// BidComparison is the bid comparison matrix of an RFQ: the line-priced bids of each
+// item, converted to the base currency and ranked by score.
+type BidComparison struct {
+	RFQID        string               `json:"rfq_id"`
+	RFQNumber    string               `json:"rfq_number"`
+	Status       string               `json:"status"`
+	BaseCurrency string               `json:"base_currency"`
+	Weights      BidWeights           `json:"weights"`
+	Items        []*BidComparisonItem `json:"items"`
+}
+
+// Item returns the comparison of an RFQ item.
+func (c *BidComparison) Item(rfqItemID string) *BidComparisonItem {
+	for _, item := range c.Items {
+		if item.RFQItemID == rfqItemID {
+			return item
+		}
+	}
+	return nil
+}
+
+// BidComparisonItem is the row of an RFQ item in the comparison matrix.
+type BidComparisonItem struct {
+	RFQItemID   string     `json:"rfq_item_id"`
+	ItemName    string     `json:"item_name"`
+	Quantity    int        `json:"quantity"`
+	Unit        string     `json:"unit"`
+	TargetPrice float64    `json:"target_price"`
+	Bids        []*ItemBid `json:"bids"`
+}
+
+// Recommended returns the highest-ranked bid, nil when nobody bid.
+func (i *BidComparisonItem) Recommended() *ItemBid {
+	if len(i.Bids) == 0 {
+		return nil
+	}
+	return i.Bids[0]
+}
+
+// Bid returns the bid of a response.
+func (i *BidComparisonItem) Bid(responseID string) *ItemBid {
+	for _, bid := range i.Bids {
+		if bid.ResponseID == responseID {
+			return bid
+		}
+	}
+	return nil
+}
+
+// ItemBid is the bid of a supplier response for an RFQ item. Scores are out of 100.
+type ItemBid struct {
+	ResponseID      string  `json:"response_id"`
+	SupplierID      string  `json:"supplier_id"`
+	SupplierName    string  `json:"supplier_name"`
+	Currency        string  `json:"currency"`
+	ExchangeRate    float64 `json:"exchange_rate"`
+	UnitPrice       float64 `json:"unit_price"`
+	TotalPrice      float64 `json:"total_price"`
+	UnitPriceBase   float64 `json:"unit_price_base"`
+	TotalPriceBase  float64 `json:"total_price_base"`
+	DeliveryTime    int     `json:"delivery_time"`
+	VendorScore     float64 `json:"vendor_score"`
+	VendorEvaluated bool    `json:"vendor_evaluated"`
+	PriceScore      float64 `json:"price_score"`
+	DeliveryScore   float64 `json:"delivery_score"`
+	VendorPoints    float64 `json:"vendor_points"`
+	Score           float64 `json:"score"`
+	Rank            int     `json:"rank"`
+}
+
+// ScoreBids scores the bids for an item and sorts them by rank. The cheapest unit price
+// and the fastest delivery score 100, others in proportion; delivery counts the order day
+// so that same-day delivery does not divide by zero. Ties go to the cheaper, then faster bid.
+func ScoreBids(bids []*ItemBid, w BidWeights) {
+	if len(bids) == 0 {
+		return
+	}
+	minPrice, minDelivery := bids[0].UnitPriceBase, bids[0].DeliveryTime
+	for _, b := range bids[1:] {
+		minPrice = math.Min(minPrice, b.UnitPriceBase)
+		if b.DeliveryTime < minDelivery {
+			minDelivery = b.DeliveryTime
+		}
+	}
+	for _, b := range bids {
+		b.PriceScore = 100
+		if b.UnitPriceBase > 0 {
+			b.PriceScore = round2(minPrice / b.UnitPriceBase * 100)
+		}
+		b.DeliveryScore = round2(float64(minDelivery+1) / float64(b.DeliveryTime+1) * 100)
+		b.VendorPoints = round2(b.VendorScore / 5 * 100)
+		b.Score = round2(w.Price*b.PriceScore + w.Delivery*b.DeliveryScore + w.Vendor*b.VendorPoints)
+	}
+	sort.SliceStable(bids, func(i, j int) bool {
+		if bids[i].Score != bids[j].Score {
+			return bids[i].Score > bids[j].Score
+		}
+		if bids[i].UnitPriceBase != bids[j].UnitPriceBase {
+			return bids[i].UnitPriceBase < bids[j].UnitPriceBase
+		}
+		return bids[i].DeliveryTime < bids[j].DeliveryTime
+	})
+	for i, b := range bids {
+		b.Rank = i + 1
+	}
+}
+
+// RFQAwardRequest awards an RFQ: either the whole RFQ to one response, or each listed
+// item to a response. Without either, every item goes to its highest-ranked bid.
+type RFQAwardRequest struct {
+	ResponseID      string              `json:"response_id"`
+	Items           []RFQItemAwardInput `json:"items"`
+	Weights         *BidWeights         `json:"weights"`
+	DeliveryAddress string              `json:"delivery_address"`
+	PaymentTerms    string              `json:"payment_terms"`
+}
+
+// RFQItemAwardInput awards an RFQ item to a response.
+type RFQItemAwardInput struct {
+	RFQItemID  string `json:"rfq_item_id"`
+	ResponseID string `json:"response_id"`
+}
+
+// RFQAward records an RFQ item awarded to a supplier and the purchase order raised for it.
+type RFQAward struct {
+	types.BaseModel
+	RFQID           string    `json:"rfq_id" db:"rfq_id"`
+	RFQItemID       string    `json:"rfq_item_id" db:"rfq_item_id"`
+	RFQResponseID   string    `json:"rfq_response_id" db:"rfq_response_id"`
+	SupplierID      string    `json:"supplier_id" db:"supplier_id"`
+	Quantity        int       `json:"quantity" db:"quantity"`
+	UnitPrice       float64   `json:"unit_price" db:"unit_price"`
+	Currency        string    `json:"currency" db:"currency"`
+	Score           float64   `json:"score" db:"score"`
+	PurchaseOrderID string    `json:"purchase_order_id" db:"purchase_order_id"`
+	AwardedBy       string    `json:"awarded_by" db:"awarded_by"`
+	AwardedAt       time.Time `json:"awarded_at" db:"awarded_at"`
+
+	// Related data
+	ItemName     string `json:"item_name,omitempty" db:"item_name"`
+	SupplierName string `json:"supplier_name,omitempty" db:"supplier_name"`
+	PONumber     string `json:"po_number,omitempty" db:"po_number"`
+}
+
+// RFQAwardResult is the outcome of awarding an RFQ.
+type RFQAwardResult struct {
+	RFQ            *RFQ             `json:"rfq"`
+	Awards         []*RFQAward      `json:"awards"`
+	PurchaseOrders []*PurchaseOrder `json:"purchase_orders"`
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,381 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supplier portal errors
+var (
+	ErrSupplierAccountNotFound = errors.New("supplier portal account not found")
+	ErrNoSupplierAccount       = errors.New("user has no active supplier portal account")
+	ErrInvalidSupplierAccount  = errors.New("invalid supplier portal account")
+	ErrPurchaseOrderNotFound   = errors.New("purchase order not found")
+	ErrInvalidOrderAction      = errors.New("invalid purchase order action")
+	ErrInvalidRFQResponse      = errors.New("invalid RFQ response")
+	ErrShippingNoticeNotFound  = errors.New("advance shipping notice not found")
+	ErrInvalidShippingNotice   = errors.New("invalid advance shipping notice")
+	ErrSupplierInvoiceNotFound = errors.New("supplier invoice not found")
+	ErrInvalidSupplierInvoice  = errors.New("invalid supplier invoice")
+)
+
+// SupplierRole is the legacy role of supplier portal users, which keeps them to the portal.
+const SupplierRole = "supplier"
+
+// Supplier portal account statuses
+const (
+	SupplierAccountActive   = "active"
+	SupplierAccountDisabled = "disabled"
+)
+
+// Advance shipping notice statuses
+const (
+	ShippingNoticeSubmitted = "submitted"
+	ShippingNoticeReceived  = "received"
+	ShippingNoticeCancelled = "cancelled"
+)
+
+// Supplier invoice statuses
+const (
+	SupplierInvoiceSubmitted  = "submitted"
+	SupplierInvoiceRegistered = "registered"
+	SupplierInvoiceRejected   = "rejected"
+)
+
+// Payment statuses of a supplier invoice as the supplier sees them
+const (
+	SupplierPaymentAwaitingReview = "awaiting_review"
+	SupplierPaymentRejected       = "rejected"
+	SupplierPaymentOnHold         = "on_hold"
+	SupplierPaymentUnpaid         = "unpaid"
+	SupplierPaymentPartial        = "partially_paid"
+	SupplierPaymentPaid           = "paid"
+)
+
+// SupplierPortalAccount links a user to the supplier they act for in the supplier portal.
+type SupplierPortalAccount struct {
+	ID           string    `json:"id" db:"id"`
+	SupplierID   string    `json:"supplier_id" db:"supplier_id"`
+	SupplierName string    `json:"supplier_name" db:"supplier_name"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	Username     string    `json:"username" db:"username"`
+	Email        string    `json:"email" db:"email"`
+	FullName     string    `json:"full_name" db:"full_name"`
+	Status       string    `json:"status" db:"status"`
+	CreatedBy    string    `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewSupplierUser is the login created with a supplier portal account. The password is
+// hashed before it is stored.
+type NewSupplierUser struct {
+	Username  string
+	Email     string
+	FullName  string
+	Phone     string
+	Password  string
+	CompanyID string
+}
+
+// Validate checks the login details of the new supplier user.
+func (u *NewSupplierUser) Validate() error {
+	if strings.TrimSpace(u.Username) == "" {
+		return fmt.Errorf("%w: username is required", ErrInvalidSupplierAccount)
+	}
+	if !strings.Contains(u.Email, "@") {
+		return fmt.Errorf("%w: a valid email is required", ErrInvalidSupplierAccount)
+	}
+	if u.Password == "" {
+		return fmt.Errorf("%w: password is required", ErrInvalidSupplierAccount)
+	}
+	return nil
+}
+
+// VisibleToSupplier reports whether a purchase order has been sent to its supplier, so it
+// shows in their portal. Orders still in draft or approval never do.
+func (po *PurchaseOrder) VisibleToSupplier() bool {
+	switch po.Status {
+	case PurchaseOrderStatusSent, PurchaseOrderStatusConfirmed, PurchaseOrderStatusShipped, PurchaseOrderStatusReceived:
+		return true
+	case PurchaseOrderStatusCancelled:
+		return po.SentAt != nil
+	}
+	return false
+}
+
+// Item returns the line of the order with the given ID, nil when it has none.
+func (po *PurchaseOrder) Item(id string) *PurchaseOrderItem {
+	for i := range po.Items {
+		if po.Items[i].ID.String() == id {
+			return &po.Items[i]
+		}
+	}
+	return nil
+}
+
+// PortalPurchaseOrder is a purchase order as listed in the supplier portal.
+type PortalPurchaseOrder struct {
+	ID                   string     `json:"id" db:"id"`
+	PONumber             string     `json:"po_number" db:"po_number"`
+	OrderDate            time.Time  `json:"order_date" db:"order_date"`
+	ExpectedDeliveryDate *time.Time `json:"expected_delivery_date,omitempty" db:"expected_delivery_date"`
+	Currency             string     `json:"currency" db:"currency"`
+	TotalAmount          float64    `json:"total_amount" db:"total_amount"`
+	Status               string     `json:"status" db:"status"`
+	PaymentStatus        string     `json:"payment_status" db:"payment_status"`
+	SentAt               *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	ConfirmedAt          *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	AcknowledgedAt       *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+}
+
+// PurchaseOrderAcknowledgement records that a supplier has seen a purchase order.
+type PurchaseOrderAcknowledgement struct {
+	PurchaseOrderID string    `json:"purchase_order_id" db:"purchase_order_id"`
+	SupplierID      string    `json:"supplier_id" db:"supplier_id"`
+	AcknowledgedBy  string    `json:"acknowledged_by" db:"acknowledged_by"`
+	AcknowledgedAt  time.Time `json:"acknowledged_at" db:"acknowledged_at"`
+	Note            string    `json:"note" db:"note"`
+}
+
+// PortalPurchaseOrderDetail is a purchase order with its lines and the supplier's
+// acknowledgement, as shown in the supplier portal.
+type PortalPurchaseOrderDetail struct {
+	*PurchaseOrder
+	Acknowledgement *PurchaseOrderAcknowledgement `json:"acknowledgement,omitempty"`
+}
+
+// PortalRFQ is an RFQ a supplier is invited to, as listed in the supplier portal.
+type PortalRFQ struct {
+	ID               string     `json:"id" db:"id"`
+	RFQNumber        string     `json:"rfq_number" db:"rfq_number"`
+	Title            string     `json:"title" db:"title"`
+	Description      string     `json:"description" db:"description"`
+	Status           string     `json:"status" db:"status"`
+	Priority         string     `json:"priority" db:"priority"`
+	DueDate          *time.Time `json:"due_date,omitempty" db:"due_date"`
+	PublishedAt      *time.Time `json:"published_at,omitempty" db:"published_at"`
+	InvitationStatus string     `json:"invitation_status" db:"invitation_status"`
+	RespondedAt      *time.Time `json:"responded_at,omitempty" db:"responded_at"`
+}
+
+// ForSupplier returns a copy of the RFQ that keeps only the invitation and response of
+// one supplier, so suppliers never see who else was invited or what they bid.
+func (r *RFQ) ForSupplier(supplierID string) *RFQ {
+	scoped := *r
+	scoped.Suppliers = nil
+	for _, s := range r.Suppliers {
+		if s.SupplierID == supplierID {
+			scoped.Suppliers = append(scoped.Suppliers, s)
+		}
+	}
+	scoped.Responses = nil
+	for _, response := range r.Responses {
+		if response.SupplierID == supplierID {
+			scoped.Responses = append(scoped.Responses, response)
+		}
+	}
+	return &scoped
+}
+
+// AdvanceShippingNotice announces a shipment against a purchase order. Receiving it
+// creates a draft goods receipt pre-filled with its lines.
+type AdvanceShippingNotice struct {
+	ID              string     `json:"id" db:"id"`
+	ASNNumber       string     `json:"asn_number" db:"asn_number"`
+	SupplierID      string     `json:"supplier_id" db:"supplier_id"`
+	SupplierName    string     `json:"supplier_name,omitempty" db:"supplier_name"`
+	PurchaseOrderID string     `json:"purchase_order_id" db:"purchase_order_id"`
+	PONumber        string     `json:"po_number,omitempty" db:"po_number"`
+	ShipDate        time.Time  `json:"ship_date" db:"ship_date"`
+	ExpectedArrival *time.Time `json:"expected_arrival,omitempty" db:"expected_arrival"`
+	Carrier         string     `json:"carrier" db:"carrier"`
+	TrackingNumber  string     `json:"tracking_number" db:"tracking_number"`
+	Notes           string     `json:"notes" db:"notes"`
+	Status          string     `json:"status" db:"status"`
+	GoodsReceiptID  *string    `json:"goods_receipt_id,omitempty" db:"goods_receipt_id"`
+	SubmittedBy     *string    `json:"submitted_by,omitempty" db:"submitted_by"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	Items           []*ASNItem `json:"items" db:"-"`
+}
+
+// ASNItem is a quantity of a purchase order line in a shipment.
+type ASNItem struct {
+	ID       string `json:"id" db:"id"`
+	ASNID    string `json:"asn_id" db:"asn_id"`
+	POItemID string `json:"po_item_id" db:"po_item_id"`
+	ItemName string `json:"item_name" db:"item_name"`
+	Unit     string `json:"unit" db:"unit"`
+	Quantity int    `json:"quantity" db:"quantity"`
+}
+
+// Prepare checks the notice against its purchase order, which must be confirmed or
+// already shipping, and copies the item details from the order lines. No line may ship
+// more than is still to be received.
+func (n *AdvanceShippingNotice) Prepare(order *PurchaseOrder) error {
+	if order.Status != PurchaseOrderStatusConfirmed && order.Status != PurchaseOrderStatusShipped {
+		return fmt.Errorf("%w: purchase order %s is %s, only confirmed orders can be shipped", ErrInvalidShippingNotice, order.PONumber, order.Status)
+	}
+	if n.ShipDate.IsZero() {
+		return fmt.Errorf("%w: ship date is required", ErrInvalidShippingNotice)
+	}
+	if n.ExpectedArrival != nil && n.ExpectedArrival.Before(n.ShipDate) {
+		return fmt.Errorf("%w: expected arrival is before the ship date", ErrInvalidShippingNotice)
+	}
+	if len(n.Items) == 0 {
+		return fmt.Errorf("%w: at least one item is required", ErrInvalidShippingNotice)
+	}
+	seen := map[string]bool{}
+	for _, item := range n.Items {
+		line := order.Item(item.POItemID)
+		if line == nil {
+			return fmt.Errorf("%w: item %s is not on purchase order %s", ErrInvalidShippingNotice, item.POItemID, order.PONumber)
+		}
+		if seen[item.POItemID] {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidShippingNotice, line.ItemName)
+		}
+		seen[item.POItemID] = true
+		outstanding := line.Quantity - line.ReceivedQuantity
+		if item.Quantity <= 0 || item.Quantity > outstanding {
+			return fmt.Errorf("%w: %s needs a quantity between 1 and the %d still to be received", ErrInvalidShippingNotice, line.ItemName, outstanding)
+		}
+		item.ItemName = line.ItemName
+		item.Unit = line.Unit
+	}
+	n.PurchaseOrderID = order.ID.String()
+	n.PONumber = order.PONumber
+	return nil
+}
+
+// SupplierInvoice is an invoice a supplier submitted through the portal against one of
+// their purchase orders. Registering it books it in Finance as a purchase voucher matched
+// against the order and its goods receipts.
+type SupplierInvoice struct {
+	ID                string                 `json:"id" db:"id"`
+	SupplierID        string                 `json:"supplier_id" db:"supplier_id"`
+	SupplierName      string                 `json:"supplier_name,omitempty" db:"supplier_name"`
+	PurchaseOrderID   string                 `json:"purchase_order_id" db:"purchase_order_id"`
+	PONumber          string                 `json:"po_number,omitempty" db:"po_number"`
+	InvoiceNumber     string                 `json:"invoice_number" db:"invoice_number"`
+	InvoiceDate       time.Time              `json:"invoice_date" db:"invoice_date"`
+	DueDate           *time.Time             `json:"due_date,omitempty" db:"due_date"`
+	Currency          string                 `json:"currency" db:"currency"`
+	Subtotal          float64                `json:"subtotal" db:"subtotal"`
+	TaxAmount         float64                `json:"tax_amount" db:"tax_amount"`
+	TotalAmount       float64                `json:"total_amount" db:"total_amount"`
+	AttachmentKey     string                 `json:"attachment_key,omitempty" db:"attachment_key"`
+	AttachmentName    string                 `json:"attachment_name,omitempty" db:"attachment_name"`
+	AttachmentURL     string                 `json:"attachment_url,omitempty" db:"-"`
+	Status            string                 `json:"status" db:"status"`
+	PurchaseVoucherID *string                `json:"purchase_voucher_id,omitempty" db:"purchase_voucher_id"`
+	RejectionReason   string                 `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	SubmittedBy       *string                `json:"submitted_by,omitempty" db:"submitted_by"`
+	ReviewedBy        *string                `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt        *time.Time             `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt         time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at" db:"updated_at"`
+	Lines             []*SupplierInvoiceLine `json:"lines" db:"-"`
+}
+
+// SupplierInvoiceLine bills a quantity of a purchase order line.
+type SupplierInvoiceLine struct {
+	ID                string  `json:"id" db:"id"`
+	SupplierInvoiceID string  `json:"supplier_invoice_id" db:"supplier_invoice_id"`
+	POItemID          string  `json:"po_item_id" db:"po_item_id"`
+	ItemName          string  `json:"item_name" db:"item_name"`
+	Quantity          int     `json:"quantity" db:"quantity"`
+	UnitPrice         float64 `json:"unit_price" db:"unit_price"`
+	LineTotal         float64 `json:"line_total" db:"line_total"`
+}
+
+// Prepare checks the invoice against its purchase order, which must have been confirmed
+// by the supplier, and totals its lines. The currency is the order's.
+func (i *SupplierInvoice) Prepare(order *PurchaseOrder) error {
+	switch order.Status {
+	case PurchaseOrderStatusConfirmed, PurchaseOrderStatusShipped, PurchaseOrderStatusReceived:
+	default:
+		return fmt.Errorf("%w: purchase order %s is %s, only confirmed orders can be invoiced", ErrInvalidSupplierInvoice, order.PONumber, order.Status)
+	}
+	i.InvoiceNumber = strings.TrimSpace(i.InvoiceNumber)
+	if i.InvoiceNumber == "" {
+		return fmt.Errorf("%w: invoice number is required", ErrInvalidSupplierInvoice)
+	}
+	if i.InvoiceDate.IsZero() {
+		return fmt.Errorf("%w: invoice date is required", ErrInvalidSupplierInvoice)
+	}
+	if i.DueDate != nil && i.DueDate.Before(i.InvoiceDate) {
+		return fmt.Errorf("%w: due date is before the invoice date", ErrInvalidSupplierInvoice)
+	}
+	if i.TaxAmount < 0 {
+		return fmt.Errorf("%w: tax amount cannot be negative", ErrInvalidSupplierInvoice)
+	}
+	if len(i.Lines) == 0 {
+		return fmt.Errorf("%w: at least one line is required", ErrInvalidSupplierInvoice)
+	}
+	seen := map[string]bool{}
+	i.Subtotal = 0
+	for _, line := range i.Lines {
+		item := order.Item(line.POItemID)
+		if item == nil {
+			return fmt.Errorf("%w: item %s is not on purchase order %s", ErrInvalidSupplierInvoice, line.POItemID, order.PONumber)
+		}
+		if seen[line.POItemID] {
+			return fmt.Errorf("%w: %s is invoiced twice", ErrInvalidSupplierInvoice, item.ItemName)
+		}
+		seen[line.POItemID] = true
+		if line.Quantity <= 0 || line.UnitPrice < 0 {
+			return fmt.Errorf("%w: %s needs a quantity above 0 and a unit price of at least 0", ErrInvalidSupplierInvoice, item.ItemName)
+		}
+		line.ItemName = item.ItemName
+		line.LineTotal = round2(float64(line.Quantity) * line.UnitPrice)
+		i.Subtotal += line.LineTotal
+	}
+	i.Subtotal = round2(i.Subtotal)
+	i.TotalAmount = round2(i.Subtotal + i.TaxAmount)
+	i.PurchaseOrderID = order.ID.String()
+	i.PONumber = order.PONumber
+	i.Currency = order.Currency
+	return nil
+}
+
+// SupplierPaymentStatus is where the payment of a supplier invoice stands: its review,
+// the purchase voucher it was booked as and what has been paid on it.
+type SupplierPaymentStatus struct {
+	SupplierInvoiceID string     `json:"supplier_invoice_id" db:"supplier_invoice_id"`
+	InvoiceNumber     string     `json:"invoice_number" db:"invoice_number"`
+	InvoiceDate       time.Time  `json:"invoice_date" db:"invoice_date"`
+	PONumber          string     `json:"po_number" db:"po_number"`
+	Currency          string     `json:"currency" db:"currency"`
+	TotalAmount       float64    `json:"total_amount" db:"total_amount"`
+	InvoiceStatus     string     `json:"invoice_status" db:"invoice_status"`
+	RejectionReason   string     `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	VoucherNumber     *string    `json:"voucher_number,omitempty" db:"voucher_number"`
+	VoucherStatus     *string    `json:"voucher_status,omitempty" db:"voucher_status"`
+	MatchStatus       *string    `json:"match_status,omitempty" db:"match_status"`
+	DueDate           *time.Time `json:"due_date,omitempty" db:"due_date"`
+	PaidAmount        float64    `json:"paid_amount" db:"paid_amount"`
+	OutstandingAmount float64    `json:"outstanding_amount" db:"outstanding_amount"`
+	PaymentStatus     string     `json:"payment_status" db:"-"`
+}
+
+// Derive sets the payment status from the review of the invoice and its voucher. A
+// voucher whose invoice did not match its order is on hold until the exception is solved.
+func (p *SupplierPaymentStatus) Derive() {
+	switch {
+	case p.InvoiceStatus == SupplierInvoiceRejected:
+		p.PaymentStatus = SupplierPaymentRejected
+	case p.InvoiceStatus != SupplierInvoiceRegistered || p.VoucherNumber == nil:
+		p.PaymentStatus = SupplierPaymentAwaitingReview
+	case p.MatchStatus != nil && *p.MatchStatus == "EXCEPTION":
+		p.PaymentStatus = SupplierPaymentOnHold
+	case p.OutstandingAmount <= 0 && p.PaidAmount > 0:
+		p.PaymentStatus = SupplierPaymentPaid
+	case p.PaidAmount > 0:
+		p.PaymentStatus = SupplierPaymentPartial
+	default:
+		p.PaymentStatus = SupplierPaymentUnpaid
+	}
+}
@@ -35,6 +35,12 @@ type VendorEvaluation struct {
 	Recommendation string  `json:"recommendation" db:"recommendation"`
 	ActionItems    *string `json:"action_items,omitempty" db:"action_items"`
 
+	// Generated evaluations are scored from the KPIs of the period with the weights in
+	// force when they were generated
+	Generated bool           `json:"generated" db:"generated"`
+	KPIs      *VendorKPIs    `json:"kpis,omitempty" db:"kpis"`
+	Weights   ScoringWeights `json:"weights" db:"-"`
+
 	// Related data for API responses
 	SupplierName  string `json:"supplier_name,omitempty" db:"supplier_name"`
 	EvaluatorName string `json:"evaluator_name,omitempty" db:"evaluator_name"`
@@ -92,13 +98,33 @@ func (ve *VendorEvaluation) CanBeApproved() bool {
 	return ve.Status == VEStatusCompleted
 }
 
-// CalculateOverallScore calculates the weighted average overall score.
+// CalculateOverallScore calculates the weighted average overall score, with the standard
+// weights when the evaluation has none.
 func (ve *VendorEvaluation) CalculateOverallScore() {
-	ve.OverallScore = float64(ve.QualityScore)*QualityWeight +
-		float64(ve.DeliveryScore)*DeliveryWeight +
-		float64(ve.PriceScore)*PriceWeight +
-		float64(ve.ServiceScore)*ServiceWeight +
-		float64(ve.ComplianceScore)*ComplianceWeight
+	if ve.Weights.IsZero() {
+		ve.Weights = DefaultScoringWeights()
+	}
+	ve.OverallScore = round2(float64(ve.QualityScore)*ve.Weights.Quality +
+		float64(ve.DeliveryScore)*ve.Weights.Delivery +
+		float64(ve.PriceScore)*ve.Weights.Price +
+		float64(ve.ServiceScore)*ve.Weights.Service +
+		float64(ve.ComplianceScore)*ve.Weights.Compliance)
+}
+
+// ApplyKPIs scores the evaluation from the KPIs of its period. Service is not measured,
+// so it is left neutral for the reviewer.
+func (ve *VendorEvaluation) ApplyKPIs(kpis *VendorKPIs) {
+	kpis.ComputeRates()
+	ve.KPIs = kpis
+	var quality, delivery, price, compliance string
+	ve.QualityScore, quality = kpis.QualityScore()
+	ve.DeliveryScore, delivery = kpis.DeliveryScore()
+	ve.PriceScore, price = kpis.PriceScore()
+	ve.ComplianceScore, compliance = kpis.ComplianceScore()
+	ve.ServiceScore = NeutralScore
+	service := "Not measured; to be scored on review"
+	ve.QualityComments, ve.DeliveryComments, ve.PriceComments = &quality, &delivery, &price
+	ve.ServiceComments, ve.ComplianceComments = &service, &compliance
 }
 
 // DetermineRecommendation determines the recommendation based on overall score.
@@ -0,0 +1,291 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Vendor scoring errors
+var (
+	ErrVendorEvaluationNotFound = errors.New("vendor evaluation not found")
+	ErrInvalidScoringWeights    = errors.New("invalid scoring weights")
+	ErrInvalidEvaluationPeriod  = errors.New("invalid evaluation period")
+)
+
+// NeutralScore is the score of a criterion with no data to score it from.
+const NeutralScore = 3
+
+// ScoringWeights are the weights of the evaluation criteria in the overall score. They
+// add up to 1.
+type ScoringWeights struct {
+	Quality    float64    `json:"quality" db:"quality_weight"`
+	Delivery   float64    `json:"delivery" db:"delivery_weight"`
+	Price      float64    `json:"price" db:"price_weight"`
+	Service    float64    `json:"service" db:"service_weight"`
+	Compliance float64    `json:"compliance" db:"compliance_weight"`
+	UpdatedBy  string     `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// DefaultScoringWeights returns the standard weights of the criteria.
+func DefaultScoringWeights() ScoringWeights {
+	return ScoringWeights{
+		Quality:    QualityWeight,
+		Delivery:   DeliveryWeight,
+		Price:      PriceWeight,
+		Service:    ServiceWeight,
+		Compliance: ComplianceWeight,
+	}
+}
+
+// IsZero reports whether no weight is set.
+func (w ScoringWeights) IsZero() bool {
+	return w.Quality == 0 && w.Delivery == 0 && w.Price == 0 && w.Service == 0 && w.Compliance == 0
+}
+
+// Validate checks that each weight is between 0 and 1 and that they add up to 1.
+func (w ScoringWeights) Validate() error {
+	for _, weight := range []float64{w.Quality, w.Delivery, w.Price, w.Service, w.Compliance} {
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("%w: each weight must be between 0 and 1", ErrInvalidScoringWeights)
+		}
+	}
+	if sum := w.Quality + w.Delivery + w.Price + w.Service + w.Compliance; math.Abs(sum-1) > 0.0001 {
+		return fmt.Errorf("%w: weights add up to %.4f, not 1", ErrInvalidScoringWeights, sum)
+	}
+	return nil
+}
+
+// VendorKPIs are the measures of a supplier's performance over an evaluation period:
+//   - deliveries: purchase orders whose last posted goods receipt fell in the period,
+//     on time when it was no later than the promised delivery date;
+//   - inspections: incoming QC of the supplier's purchase orders tested in the period,
+//     where a conditional pass counts as half a pass;
+//   - returns: quantity returned to the supplier against the quantity received from it;
+//   - price: what was ordered in the period at the PO price against the agreed contract
+//     price or awarded RFQ price of the same lines;
+//   - invoices: supplier invoices matched in the period, clean when the three-way match
+//     raised no exceptions.
+type VendorKPIs struct {
+	SupplierID   string `json:"supplier_id" db:"supplier_id"`
+	SupplierName string `json:"supplier_name,omitempty" db:"supplier_name"`
+
+	Deliveries       int     `json:"deliveries" db:"deliveries"`
+	OnTimeDeliveries int     `json:"on_time_deliveries" db:"on_time_deliveries"`
+	AverageDaysLate  float64 `json:"average_days_late" db:"average_days_late"`
+
+	Inspections            int `json:"inspections" db:"inspections"`
+	PassedInspections      int `json:"passed_inspections" db:"passed_inspections"`
+	ConditionalInspections int `json:"conditional_inspections" db:"conditional_inspections"`
+
+	ReceivedQuantity int `json:"received_quantity" db:"received_quantity"`
+	ReturnedQuantity int `json:"returned_quantity" db:"returned_quantity"`
+
+	ReferenceAmount float64 `json:"reference_amount" db:"reference_amount"`
+	OrderedAmount   float64 `json:"ordered_amount" db:"ordered_amount"`
+
+	MatchedInvoices int `json:"matched_invoices" db:"matched_invoices"`
+	CleanInvoices   int `json:"clean_invoices" db:"clean_invoices"`
+
+	// Rates in percent, nil when there is nothing to measure
+	OnTimeRate       *float64 `json:"on_time_rate,omitempty" db:"-"`
+	QCPassRate       *float64 `json:"qc_pass_rate,omitempty" db:"-"`
+	ReturnRate       *float64 `json:"return_rate,omitempty" db:"-"`
+	PriceVariance    *float64 `json:"price_variance,omitempty" db:"-"`
+	InvoiceMatchRate *float64 `json:"invoice_match_rate,omitempty" db:"-"`
+}
+
+// ComputeRates sets the rates of the KPIs from their counts and amounts.
+func (k *VendorKPIs) ComputeRates() {
+	k.OnTimeRate = percent(float64(k.OnTimeDeliveries), float64(k.Deliveries))
+	k.QCPassRate = percent(float64(k.PassedInspections)+float64(k.ConditionalInspections)/2, float64(k.Inspections))
+	k.ReturnRate = percent(float64(k.ReturnedQuantity), float64(k.ReceivedQuantity))
+	k.PriceVariance = percent(k.OrderedAmount-k.ReferenceAmount, k.ReferenceAmount)
+	k.InvoiceMatchRate = percent(float64(k.CleanInvoices), float64(k.MatchedInvoices))
+}
+
+// percent is part of whole in percent, nil when whole is not positive.
+func percent(part, whole float64) *float64 {
+	if whole <= 0 {
+		return nil
+	}
+	rate := round2(part / whole * 100)
+	return &rate
+}
+
+// scoreAtLeast scores a rate where higher is better: 5 at or above the first threshold,
+// down to 1 below the last.
+func scoreAtLeast(rate float64, thresholds [4]float64) int {
+	for i, threshold := range thresholds {
+		if rate >= threshold {
+			return 5 - i
+		}
+	}
+	return 1
+}
+
+// scoreAtMost scores a rate where lower is better: 5 at or below the first threshold,
+// down to 1 above the last.
+func scoreAtMost(rate float64, thresholds [4]float64) int {
+	for i, threshold := range thresholds {
+		if rate <= threshold {
+			return 5 - i
+		}
+	}
+	return 1
+}
+
+// DeliveryScore scores the on-time delivery rate.
+func (k *VendorKPIs) DeliveryScore() (int, string) {
+	if k.OnTimeRate == nil {
+		return NeutralScore, "No deliveries received in the period"
+	}
+	return scoreAtLeast(*k.OnTimeRate, [4]float64{95, 90, 80, 70}),
+		fmt.Sprintf("%d of %d deliveries on time (%.2f%%), late ones %.1f days late on average",
+			k.OnTimeDeliveries, k.Deliveries, *k.OnTimeRate, k.AverageDaysLate)
+}
+
+// QualityScore scores the incoming QC pass rate and the return rate, averaging the two
+// when both are known.
+func (k *VendorKPIs) QualityScore() (int, string) {
+	var scores []int
+	var notes []string
+	if k.QCPassRate != nil {
+		scores = append(scores, scoreAtLeast(*k.QCPassRate, [4]float64{98, 95, 90, 80}))
+		notes = append(notes, fmt.Sprintf("QC pass rate %.2f%% over %d inspections", *k.QCPassRate, k.Inspections))
+	}
+	if k.ReturnRate != nil {
+		scores = append(scores, scoreAtMost(*k.ReturnRate, [4]float64{1, 2, 5, 10}))
+		notes = append(notes, fmt.Sprintf("%d of %d received returned (%.2f%%)", k.ReturnedQuantity, k.ReceivedQuantity, *k.ReturnRate))
+	}
+	switch len(scores) {
+	case 0:
+		return NeutralScore, "No incoming inspections or receipts in the period"
+	case 1:
+		return scores[0], notes[0]
+	}
+	return int(math.Round(float64(scores[0]+scores[1]) / 2)), notes[0] + "; " + notes[1]
+}
+
+// PriceScore scores the variance of the prices ordered at against the agreed ones, where
+// paying less than agreed scores best.
+func (k *VendorKPIs) PriceScore() (int, string) {
+	if k.PriceVariance == nil {
+		return NeutralScore, "No orders priced against a contract or RFQ in the period"
+	}
+	return scoreAtMost(*k.PriceVariance, [4]float64{0, 2, 5, 10}),
+		fmt.Sprintf("Ordered %.2f against %.2f agreed (%+.2f%%)", k.OrderedAmount, k.ReferenceAmount, *k.PriceVariance)
+}
+
+// ComplianceScore scores the share of supplier invoices that matched without exceptions.
+func (k *VendorKPIs) ComplianceScore() (int, string) {
+	if k.InvoiceMatchRate == nil {
+		return NeutralScore, "No supplier invoices matched in the period"
+	}
+	return scoreAtLeast(*k.InvoiceMatchRate, [4]float64{98, 95, 90, 80}),
+		fmt.Sprintf("%d of %d invoices matched without exceptions (%.2f%%)", k.CleanInvoices, k.MatchedInvoices, *k.InvoiceMatchRate)
+}
+
+// HasActivity reports whether the supplier had anything to measure in the period.
+func (k *VendorKPIs) HasActivity() bool {
+	return k.Deliveries > 0 || k.Inspections > 0 || k.ReceivedQuantity > 0 || k.ReturnedQuantity > 0 ||
+		k.ReferenceAmount > 0 || k.MatchedInvoices > 0
+}
+
+// VendorEvaluationRun is the outcome of a run of the evaluation generator.
+type VendorEvaluationRun struct {
+	PeriodStart time.Time           `json:"period_start"`
+	PeriodEnd   time.Time           `json:"period_end"`
+	Created     int                 `json:"created"`
+	Skipped     int                 `json:"skipped"`
+	Evaluations []*VendorEvaluation `json:"evaluations"`
+}
+
+// Scorecard trends
+const (
+	ScorecardTrendImproving = "improving"
+	ScorecardTrendDeclining = "declining"
+	ScorecardTrendStable    = "stable"
+)
+
+// scorecardTrendMargin is how much the overall score must move between the last two
+// periods to count as a change.
+const scorecardTrendMargin = 0.25
+
+// SupplierScorecard is a supplier's evaluations over time, oldest period first.
+type SupplierScorecard struct {
+	SupplierID     string           `json:"supplier_id"`
+	SupplierName   string           `json:"supplier_name"`
+	Periods        []ScorecardPoint `json:"periods"`
+	AverageScore   float64          `json:"average_score"`
+	LatestScore    float64          `json:"latest_score"`
+	Trend          string           `json:"trend"`
+	Recommendation string           `json:"recommendation,omitempty"`
+}
+
+// ScorecardPoint is the evaluation of a supplier for one period and its change on the
+// period before.
+type ScorecardPoint struct {
+	EvaluationID     string      `json:"evaluation_id"`
+	EvaluationNumber string      `json:"evaluation_number"`
+	PeriodStart      time.Time   `json:"period_start"`
+	PeriodEnd        time.Time   `json:"period_end"`
+	Status           string      `json:"status"`
+	Generated        bool        `json:"generated"`
+	QualityScore     int         `json:"quality_score"`
+	DeliveryScore    int         `json:"delivery_score"`
+	PriceScore       int         `json:"price_score"`
+	ServiceScore     int         `json:"service_score"`
+	ComplianceScore  int         `json:"compliance_score"`
+	OverallScore     float64     `json:"overall_score"`
+	Change           *float64    `json:"change,omitempty"`
+	KPIs             *VendorKPIs `json:"kpis,omitempty"`
+}
+
+// NewSupplierScorecard builds the scorecard of a supplier from its evaluations, sorted by
+// period.
+func NewSupplierScorecard(supplierID string, evaluations []*VendorEvaluation) *SupplierScorecard {
+	card := &SupplierScorecard{SupplierID: supplierID, Periods: []ScorecardPoint{}, Trend: ScorecardTrendStable}
+	var total float64
+	for i, ve := range evaluations {
+		point := ScorecardPoint{
+			EvaluationID:     ve.ID.String(),
+			EvaluationNumber: ve.EvaluationNumber,
+			PeriodStart:      ve.EvaluationPeriodStart,
+			PeriodEnd:        ve.EvaluationPeriodEnd,
+			Status:           ve.Status,
+			Generated:        ve.Generated,
+			QualityScore:     ve.QualityScore,
+			DeliveryScore:    ve.DeliveryScore,
+			PriceScore:       ve.PriceScore,
+			ServiceScore:     ve.ServiceScore,
+			ComplianceScore:  ve.ComplianceScore,
+			OverallScore:     ve.OverallScore,
+			KPIs:             ve.KPIs,
+		}
+		if i > 0 {
+			change := round2(ve.OverallScore - evaluations[i-1].OverallScore)
+			point.Change = &change
+		}
+		card.Periods = append(card.Periods, point)
+		card.SupplierName = ve.SupplierName
+		total += ve.OverallScore
+	}
+	if n := len(evaluations); n > 0 {
+		latest := evaluations[n-1]
+		card.AverageScore = round2(total / float64(n))
+		card.LatestScore = latest.OverallScore
+		card.Recommendation = latest.Recommendation
+		if change := card.Periods[n-1].Change; change != nil {
+			switch {
+			case *change >= scorecardTrendMargin:
+				card.Trend = ScorecardTrendImproving
+			case *change <= -scorecardTrendMargin:
+				card.Trend = ScorecardTrendDeclining
+			}
+		}
+	}
+	return card
+}
@@ -36,6 +36,19 @@ type ContractRepository interface {
 
 	// Number generation
 	GetNextContractNumber(ctx context.Context) (string, error)
+
+	// Items, with what the call-offs that are not cancelled consumed of them
+	GetItems(ctx context.Context, contractID string) ([]*entities.ContractItem, error)
+	GetItem(ctx context.Context, id string) (*entities.ContractItem, error)
+	CreateItem(ctx context.Context, item *entities.ContractItem) error
+	UpdateItem(ctx context.Context, item *entities.ContractItem) error
+	DeleteItem(ctx context.Context, id string) error
+
+	// Call-offs
+	GetCallOffLines(ctx context.Context, contractID string) ([]*entities.ContractCallOffLine, error)
+	// SaveCallOff records the call-off lines of a purchase order. The contract is locked and
+	// the lines checked against its caps again, so concurrent call-offs cannot overrun them.
+	SaveCallOff(ctx context.Context, contract *entities.Contract, lines []*entities.ContractCallOffLine) error
 }
 
 // ContractStats holds statistics for contracts.
@@ -59,6 +59,10 @@ type RFQRepository interface {
 	CreateResponseItem(ctx context.Context, item *entities.RFQResponseItem) error
 	GetResponseItems(ctx context.Context, responseID string) ([]*entities.RFQResponseItem, error)
 
+	// RFQ Award operations
+	SaveAwards(ctx context.Context, rfqID string, awards []*entities.RFQAward) error
+	GetAwards(ctx context.Context, rfqID string) ([]*entities.RFQAward, error)
+
 	// Statistics and analytics
 	GetRFQStats(ctx context.Context) (map[string]interface{}, error)
 }
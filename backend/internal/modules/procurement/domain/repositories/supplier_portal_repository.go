@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+
+	"malaka/internal/modules/procurement/domain/entities"
+)
+
+// ShippingNoticeFilter contains filter options for advance shipping notice queries
+type ShippingNoticeFilter struct {
+	SupplierID      string
+	PurchaseOrderID string
+	Status          string
+}
+
+// SupplierInvoiceFilter contains filter options for supplier invoice queries
+type SupplierInvoiceFilter struct {
+	SupplierID string
+	Status     string
+}
+
+// ShippingNoticeReceipt receives an advance shipping notice into a warehouse
+type ShippingNoticeReceipt struct {
+	ASNID       string
+	WarehouseID string
+	ReceivedBy  string
+}
+
+// SupplierPortalRepository defines the interface for supplier portal data access. Every
+// query for portal data takes the supplier it is scoped to.
+type SupplierPortalRepository interface {
+	// CreateAccount creates the user of a portal account, with the Supplier role and a
+	// hashed password, and links it to its supplier
+	CreateAccount(ctx context.Context, user *entities.NewSupplierUser, account *entities.SupplierPortalAccount) error
+
+	// GetAccount retrieves a portal account by ID, nil when it does not exist
+	GetAccount(ctx context.Context, id string) (*entities.SupplierPortalAccount, error)
+
+	// GetActiveAccountByUser retrieves the active portal account of a user, nil when it has none
+	GetActiveAccountByUser(ctx context.Context, userID string) (*entities.SupplierPortalAccount, error)
+
+	// ListAccounts lists the portal accounts, of one supplier when given
+	ListAccounts(ctx context.Context, supplierID string) ([]*entities.SupplierPortalAccount, error)
+
+	// SetAccountStatus enables or disables a portal account and its user
+	SetAccountStatus(ctx context.Context, id, status string) error
+
+	// ListActiveUserIDs lists the users of the active portal accounts of a supplier
+	ListActiveUserIDs(ctx context.Context, supplierID string) ([]string, error)
+
+	// ListPurchaseOrders lists the purchase orders sent to a supplier
+	ListPurchaseOrders(ctx context.Context, supplierID, status string) ([]*entities.PortalPurchaseOrder, error)
+
+	// GetAcknowledgement retrieves the supplier acknowledgement of a purchase order, nil when there is none
+	GetAcknowledgement(ctx context.Context, purchaseOrderID string) (*entities.PurchaseOrderAcknowledgement, error)
+
+	// SaveAcknowledgement records the supplier acknowledgement of a purchase order; the first one stands
+	SaveAcknowledgement(ctx context.Context, ack *entities.PurchaseOrderAcknowledgement) error
+
+	// GetNextASNNumber generates the next advance shipping notice number
+	GetNextASNNumber(ctx context.Context) (string, error)
+
+	// CreateShippingNotice creates an advance shipping notice with its items
+	CreateShippingNotice(ctx context.Context, notice *entities.AdvanceShippingNotice) error
+
+	// GetShippingNotice retrieves an advance shipping notice with its items, nil when it does not exist
+	GetShippingNotice(ctx context.Context, id string) (*entities.AdvanceShippingNotice, error)
+
+	// ListShippingNotices lists advance shipping notices, newest first
+	ListShippingNotices(ctx context.Context, filter ShippingNoticeFilter) ([]*entities.AdvanceShippingNotice, error)
+
+	// ReceiveShippingNotice creates a draft goods receipt from a submitted notice, priced
+	// at its purchase order, and marks the notice received. It returns the goods receipt ID.
+	ReceiveShippingNotice(ctx context.Context, receipt ShippingNoticeReceipt) (string, error)
+
+	// ListInvitedRFQs lists the RFQs a supplier is invited to, except drafts
+	ListInvitedRFQs(ctx context.Context, supplierID string) ([]*entities.PortalRFQ, error)
+
+	// GetRFQInvitation retrieves the invitation of a supplier to an RFQ, nil when it is not invited
+	GetRFQInvitation(ctx context.Context, rfqID, supplierID string) (*entities.RFQSupplier, error)
+
+	// CreateInvoice creates a supplier invoice with its lines
+	CreateInvoice(ctx context.Context, invoice *entities.SupplierInvoice) error
+
+	// GetInvoice retrieves a supplier invoice with its lines, nil when it does not exist
+	GetInvoice(ctx context.Context, id string) (*entities.SupplierInvoice, error)
+
+	// GetInvoiceByNumber retrieves the invoice of a supplier by its number, nil when it does not exist
+	GetInvoiceByNumber(ctx context.Context, supplierID, invoiceNumber string) (*entities.SupplierInvoice, error)
+
+	// ListInvoices lists supplier invoices, newest first
+	ListInvoices(ctx context.Context, filter SupplierInvoiceFilter) ([]*entities.SupplierInvoice, error)
+
+	// SetInvoiceAttachment sets the uploaded document of a supplier invoice
+	SetInvoiceAttachment(ctx context.Context, id, key, name string) error
+
+	// UpdateInvoiceReview saves the outcome of the review of a supplier invoice
+	UpdateInvoiceReview(ctx context.Context, invoice *entities.SupplierInvoice) error
+
+	// ListPaymentStatus lists the invoices of a supplier with the vouchers they were booked as
+	ListPaymentStatus(ctx context.Context, supplierID string) ([]*entities.SupplierPaymentStatus, error)
+}
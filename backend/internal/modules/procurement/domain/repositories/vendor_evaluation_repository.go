@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"malaka/internal/modules/procurement/domain/entities"
 )
@@ -38,6 +39,20 @@ type VendorEvaluationRepository interface {
 
 	// Number generation
 	GetNextEvaluationNumber(ctx context.Context) (string, error)
+
+	// Scoring
+	GetScoringWeights(ctx context.Context) (*entities.ScoringWeights, error)
+	SaveScoringWeights(ctx context.Context, weights *entities.ScoringWeights) error
+	GetVendorKPIs(ctx context.Context, filter VendorKPIFilter) ([]*entities.VendorKPIs, error)
+	HasGeneratedEvaluation(ctx context.Context, supplierID string, periodStart, periodEnd time.Time) (bool, error)
+	GetSupplierTrend(ctx context.Context, supplierID string, from, to *time.Time) ([]*entities.VendorEvaluation, error)
+}
+
+// VendorKPIFilter selects the period, and optionally the supplier, to measure KPIs over.
+type VendorKPIFilter struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	SupplierID  string
 }
 
 // VendorEvaluationStats holds statistics for vendor evaluations.
@@ -14,14 +14,44 @@ import (
 
 // ContractService provides business logic for contract operations.
 type ContractService struct {
-	repo repositories.ContractRepository
+	repo     repositories.ContractRepository
+	orders   PurchaseOrderCreator // Optional: raises purchase orders for call-offs
+	notifier ContractNotifier     // Optional: tells owners of expiring, expired and renewed contracts
 }
 
+// PurchaseOrderCreator raises purchase orders, and deletes drafts that failed to be recorded
+// as call-offs.
+type PurchaseOrderCreator interface {
+	Create(ctx context.Context, order *entities.PurchaseOrder) error
+	Delete(ctx context.Context, id string) error
+}
+
+// ContractNotifier notifies contract owners of the end of a contract term.
+type ContractNotifier interface {
+	NotifyContractExpiring(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string, endDate time.Time, autoRenewal bool) error
+	NotifyContractRenewed(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string, newEndDate time.Time) error
+	NotifyContractExpired(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string) error
+}
+
+// contractNoticeHorizon bounds how far ahead the renewal job looks for contracts whose
+// notice period has started.
+const contractNoticeHorizon = 366
+
 // NewContractService creates a new ContractService.
 func NewContractService(repo repositories.ContractRepository) *ContractService {
 	return &ContractService{repo: repo}
 }
 
+// SetPurchaseOrderCreator sets the purchase order service used to raise call-offs
+func (s *ContractService) SetPurchaseOrderCreator(orders PurchaseOrderCreator) {
+	s.orders = orders
+}
+
+// SetNotifier sets the notifier of contract owners
+func (s *ContractService) SetNotifier(notifier ContractNotifier) {
+	s.notifier = notifier
+}
+
 // Create creates a new contract.
 func (s *ContractService) Create(ctx context.Context, contract *entities.Contract) error {
 	if contract.ID.IsNil() {
@@ -62,7 +92,10 @@ func (s *ContractService) GetByID(ctx context.Context, id string) (*entities.Con
 		return nil, err
 	}
 	if contract == nil {
-		return nil, errors.New("contract not found")
+		return nil, entities.ErrContractNotFound
+	}
+	if contract.Items, err = s.repo.GetItems(ctx, id); err != nil {
+		return nil, err
 	}
 	return contract, nil
 }
@@ -88,7 +121,7 @@ func (s *ContractService) Update(ctx context.Context, contract *entities.Contrac
 		return err
 	}
 	if existing == nil {
-		return errors.New("contract not found")
+		return entities.ErrContractNotFound
 	}
 
 	// Can only update draft contracts (or some fields of active contracts)
@@ -101,6 +134,10 @@ func (s *ContractService) Update(ctx context.Context, contract *entities.Contrac
 		return errors.New("end date cannot be before start date")
 	}
 
+	// A new end date starts a new notice period
+	if !contract.EndDate.Equal(existing.EndDate) {
+		contract.ExpiryNotifiedAt = nil
+	}
 	contract.UpdatedAt = utils.Now()
 
 	return s.repo.Update(ctx, contract)
@@ -113,7 +150,7 @@ func (s *ContractService) Delete(ctx context.Context, id string) error {
 		return err
 	}
 	if existing == nil {
-		return errors.New("contract not found")
+		return entities.ErrContractNotFound
 	}
 
 	// Can only delete draft contracts
@@ -131,7 +168,7 @@ func (s *ContractService) Activate(ctx context.Context, id string) (*entities.Co
 		return nil, err
 	}
 	if contract == nil {
-		return nil, errors.New("contract not found")
+		return nil, entities.ErrContractNotFound
 	}
 
 	if !contract.CanBeActivated() {
@@ -155,7 +192,7 @@ func (s *ContractService) Terminate(ctx context.Context, id string, reason strin
 		return nil, err
 	}
 	if contract == nil {
-		return nil, errors.New("contract not found")
+		return nil, entities.ErrContractNotFound
 	}
 
 	if !contract.CanBeTerminated() {
@@ -179,7 +216,7 @@ func (s *ContractService) Renew(ctx context.Context, id string, newEndDate time.
 		return nil, err
 	}
 	if contract == nil {
-		return nil, errors.New("contract not found")
+		return nil, entities.ErrContractNotFound
 	}
 
 	if !contract.CanBeRenewed() {
@@ -194,6 +231,7 @@ func (s *ContractService) Renew(ctx context.Context, id string, newEndDate time.
 	// Update end date and set status to renewed then active
 	contract.EndDate = newEndDate
 	contract.Status = entities.ContractStatusActive
+	contract.ExpiryNotifiedAt = nil
 	contract.UpdatedAt = utils.Now()
 
 	if err := s.repo.Update(ctx, contract); err != nil {
@@ -221,30 +259,367 @@ func (s *ContractService) GetStats(ctx context.Context) (*repositories.ContractS
 	return s.repo.GetStats(ctx)
 }
 
-// ExpireContracts marks all contracts past their end date as expired.
-// This can be called by a background job.
+// ExpireContracts marks all contracts past their end date as expired, except those that
+// renew automatically, which are renewed for another renewal period instead. Owners are
+// notified of both. This can be called by a background job.
 func (s *ContractService) ExpireContracts(ctx context.Context) (int, error) {
+	_, expired, err := s.expireOrRenew(ctx)
+	return expired, err
+}
+
+// RunRenewalCycle is the daily contract job: it renews or expires the contracts past their
+// end date, then tells the owners of contracts whose notice period has started, once per
+// term.
+func (s *ContractService) RunRenewalCycle(ctx context.Context) (*entities.ContractRenewalRun, error) {
+	run := &entities.ContractRenewalRun{}
+	var err error
+	if run.Renewed, run.Expired, err = s.expireOrRenew(ctx); err != nil {
+		return run, err
+	}
+	run.Notified, err = s.NotifyExpiring(ctx)
+	return run, err
+}
+
+// expireOrRenew renews or expires the active contracts past their end date.
+func (s *ContractService) expireOrRenew(ctx context.Context) (renewed, expired int, err error) {
 	// Get all active contracts
 	filter := &repositories.ContractFilter{
 		Status: entities.ContractStatusActive,
+		Page:   1,
 		Limit:  1000,
 	}
 	contracts, _, err := s.repo.GetAll(ctx, filter)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
-	count := 0
 	for _, contract := range contracts {
-		if contract.IsExpired() {
-			contract.Status = entities.ContractStatusExpired
+		if !contract.IsExpired() {
+			continue
+		}
+		if contract.CanAutoRenew() {
+			// Catch up on terms missed while the job was not running
+			for contract.IsExpired() {
+				contract.EndDate = contract.NextTermEnd()
+			}
+			contract.ExpiryNotifiedAt = nil
 			contract.UpdatedAt = utils.Now()
 			if err := s.repo.Update(ctx, contract); err != nil {
 				continue // Log error but continue with other contracts
 			}
-			count++
+			renewed++
+			s.notifyOwner(contract, func(owner uuid.ID) error {
+				return s.notifier.NotifyContractRenewed(ctx, owner, contract.ID.String(), contract.ContractNumber, contract.EndDate)
+			})
+			continue
 		}
+
+		contract.Status = entities.ContractStatusExpired
+		contract.UpdatedAt = utils.Now()
+		if err := s.repo.Update(ctx, contract); err != nil {
+			continue // Log error but continue with other contracts
+		}
+		expired++
+		s.notifyOwner(contract, func(owner uuid.ID) error {
+			return s.notifier.NotifyContractExpired(ctx, owner, contract.ID.String(), contract.ContractNumber)
+		})
+	}
+
+	return renewed, expired, nil
+}
+
+// NotifyExpiring tells the owners of active contracts whose notice period (30 days without
+// one) has started that they are about to expire or renew, once per term, and returns how
+// many were told.
+func (s *ContractService) NotifyExpiring(ctx context.Context) (int, error) {
+	if s.notifier == nil {
+		return 0, nil
+	}
+	contracts, err := s.repo.GetExpiring(ctx, contractNoticeHorizon)
+	if err != nil {
+		return 0, err
 	}
 
+	count := 0
+	for _, contract := range contracts {
+		if contract.ExpiryNotifiedAt != nil || contract.DaysUntilExpiry() > contract.NoticeDays() {
+			continue
+		}
+		if !s.notifyOwner(contract, func(owner uuid.ID) error {
+			return s.notifier.NotifyContractExpiring(ctx, owner, contract.ID.String(), contract.ContractNumber, contract.EndDate, contract.CanAutoRenew())
+		}) {
+			continue
+		}
+		now := utils.Now()
+		contract.ExpiryNotifiedAt = &now
+		if err := s.repo.Update(ctx, contract); err != nil {
+			continue
+		}
+		count++
+	}
 	return count, nil
 }
+
+// notifyOwner notifies the owner of a contract when it has one and a notifier is set, and
+// reports whether the owner was notified.
+func (s *ContractService) notifyOwner(contract *entities.Contract, notify func(owner uuid.ID) error) bool {
+	if s.notifier == nil || contract.OwnerID == nil {
+		return false
+	}
+	owner, err := uuid.Parse(*contract.OwnerID)
+	if err != nil {
+		return false
+	}
+	return notify(owner) == nil
+}
+
+// GetItems retrieves the items of a contract with their consumption.
+func (s *ContractService) GetItems(ctx context.Context, contractID string) ([]*entities.ContractItem, error) {
+	if _, err := s.getContract(ctx, contractID); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.GetItems(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item.SetRemaining()
+	}
+	return items, nil
+}
+
+// AddItem adds an item with its agreed price and caps to a draft or active contract.
+func (s *ContractService) AddItem(ctx context.Context, item *entities.ContractItem) error {
+	contract, err := s.getContract(ctx, item.ContractID)
+	if err != nil {
+		return err
+	}
+	if err := checkItemsEditable(contract); err != nil {
+		return err
+	}
+	if item.Unit == "" {
+		item.Unit = "pcs"
+	}
+	if err := item.Validate(); err != nil {
+		return err
+	}
+	if item.ID.IsNil() {
+		item.ID = uuid.New()
+	}
+	item.CreatedAt = utils.Now()
+	item.UpdatedAt = item.CreatedAt
+	return s.repo.CreateItem(ctx, item)
+}
+
+// UpdateItem updates the agreed price and caps of a contract item. Caps cannot go below
+// what has already been called off; a new price applies to later call-offs only.
+func (s *ContractService) UpdateItem(ctx context.Context, item *entities.ContractItem) error {
+	existing, err := s.repo.GetItem(ctx, item.ID.String())
+	if err != nil {
+		return err
+	}
+	if existing.ContractID != item.ContractID {
+		return entities.ErrContractItemNotFound
+	}
+	contract, err := s.getContract(ctx, item.ContractID)
+	if err != nil {
+		return err
+	}
+	if err := checkItemsEditable(contract); err != nil {
+		return err
+	}
+	if err := item.Validate(); err != nil {
+		return err
+	}
+	if item.QuantityCap != nil && *item.QuantityCap < existing.ConsumedQuantity {
+		return fmt.Errorf("%w: %d %s have been called off already", entities.ErrInvalidContractItem, existing.ConsumedQuantity, existing.Unit)
+	}
+	if item.ValueCap != nil && *item.ValueCap < existing.ConsumedValue {
+		return fmt.Errorf("%w: %.2f has been called off already", entities.ErrInvalidContractItem, existing.ConsumedValue)
+	}
+	item.ConsumedQuantity, item.ConsumedValue = existing.ConsumedQuantity, existing.ConsumedValue
+	item.CreatedAt = existing.CreatedAt
+	item.UpdatedAt = utils.Now()
+	if err := s.repo.UpdateItem(ctx, item); err != nil {
+		return err
+	}
+	item.SetRemaining()
+	return nil
+}
+
+// DeleteItem deletes a contract item that has never been called off.
+func (s *ContractService) DeleteItem(ctx context.Context, contractID, itemID string) error {
+	existing, err := s.repo.GetItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	if existing.ContractID != contractID {
+		return entities.ErrContractItemNotFound
+	}
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return err
+	}
+	if err := checkItemsEditable(contract); err != nil {
+		return err
+	}
+	return s.repo.DeleteItem(ctx, itemID)
+}
+
+// GetConsumption reports how much of a contract has been called off, per item against its
+// caps and in total against the contract value, with the call-off lines.
+func (s *ContractService) GetConsumption(ctx context.Context, contractID string) (*entities.ContractConsumption, error) {
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.GetItems(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.repo.GetCallOffLines(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	return entities.NewContractConsumption(contract, items, lines), nil
+}
+
+// CallOff raises a draft purchase order against an active framework contract, in its term.
+// Lines are priced at the agreed unit price of their contract item, or lower when a lower
+// price is given, and must fit within the remaining caps of the items and the contract
+// value. The order is removed again when the call-off cannot be recorded.
+func (s *ContractService) CallOff(ctx context.Context, contractID string, req *entities.CallOffRequest, createdBy string) (*entities.PurchaseOrder, error) {
+	if s.orders == nil {
+		return nil, fmt.Errorf("purchase order service not configured")
+	}
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	if !contract.IsCallableOn(utils.Now()) {
+		return nil, entities.ErrContractNotCallable
+	}
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", entities.ErrInvalidCallOff)
+	}
+	items, err := s.repo.GetItems(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*entities.ContractItem, len(items))
+	var consumed float64
+	for _, item := range items {
+		byID[item.ID.String()] = item
+		consumed += item.ConsumedValue
+	}
+
+	supplierID, err := uuid.Parse(contract.SupplierID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid supplier of contract %s: %w", contract.ContractNumber, err)
+	}
+	createdByID, _ := uuid.Parse(createdBy)
+	po := entities.NewPurchaseOrder(supplierID, createdByID)
+	po.ContractID = &contract.ID
+	po.Currency = contract.Currency
+	if contract.PaymentTerms != nil {
+		po.PaymentTerms = *contract.PaymentTerms
+	}
+	po.DeliveryAddress = req.DeliveryAddress
+	po.ExpectedDeliveryDate = req.ExpectedDeliveryDate
+	if req.ProcurementType != "" {
+		if !entities.IsValidProcurementType(req.ProcurementType) {
+			return nil, fmt.Errorf("%w: unknown procurement type %s", entities.ErrInvalidCallOff, req.ProcurementType)
+		}
+		po.ProcurementType = req.ProcurementType
+	}
+	po.Notes = req.Notes
+	if po.Notes == "" {
+		po.Notes = fmt.Sprintf("Call-off against %s", contract.ContractNumber)
+	}
+
+	now := utils.Now()
+	var createdByRef *string
+	if createdBy != "" {
+		createdByRef = &createdBy
+	}
+	lines := make([]*entities.ContractCallOffLine, 0, len(req.Lines))
+	seen := make(map[string]bool, len(req.Lines))
+	for _, in := range req.Lines {
+		item, ok := byID[in.ContractItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not on contract %s", entities.ErrContractItemNotFound, in.ContractItemID, contract.ContractNumber)
+		}
+		if seen[in.ContractItemID] {
+			return nil, fmt.Errorf("%w: %s is called off twice", entities.ErrInvalidCallOff, item.ItemName)
+		}
+		seen[in.ContractItemID] = true
+		if in.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: %s needs a quantity above 0", entities.ErrInvalidCallOff, item.ItemName)
+		}
+		price := item.UnitPrice
+		if in.UnitPrice != nil {
+			if *in.UnitPrice < 0 || *in.UnitPrice > item.UnitPrice {
+				return nil, fmt.Errorf("%w: %s is agreed at %.2f, it cannot be ordered above it", entities.ErrInvalidCallOff, item.ItemName, item.UnitPrice)
+			}
+			price = *in.UnitPrice
+		}
+
+		poItem := entities.NewPurchaseOrderItem(po.ID)
+		poItem.ItemName = item.ItemName
+		if item.Description != nil {
+			poItem.Description = *item.Description
+		}
+		poItem.Quantity = in.Quantity
+		poItem.Unit = item.Unit
+		poItem.UnitPrice = price
+		poItem.Currency = contract.Currency
+		poItem.ContractItemID = &item.ID
+		po.Items = append(po.Items, *poItem)
+
+		lines = append(lines, &entities.ContractCallOffLine{
+			ID:              uuid.New().String(),
+			ContractID:      contract.ID.String(),
+			ContractItemID:  in.ContractItemID,
+			PurchaseOrderID: po.ID.String(),
+			POItemID:        poItem.ID.String(),
+			Quantity:        in.Quantity,
+			UnitPrice:       price,
+			Amount:          roundAmount(float64(in.Quantity) * price),
+			CreatedBy:       createdByRef,
+			CreatedAt:       now,
+			ItemName:        item.ItemName,
+		})
+	}
+	if err := entities.CheckCallOff(contract, items, consumed, lines); err != nil {
+		return nil, err
+	}
+
+	if err := s.orders.Create(ctx, po); err != nil {
+		return nil, fmt.Errorf("failed to create purchase order: %w", err)
+	}
+	if err := s.repo.SaveCallOff(ctx, contract, lines); err != nil {
+		_ = s.orders.Delete(ctx, po.ID.String())
+		return nil, err
+	}
+	return po, nil
+}
+
+// getContract retrieves a contract without its items.
+func (s *ContractService) getContract(ctx context.Context, id string) (*entities.Contract, error) {
+	contract, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if contract == nil {
+		return nil, entities.ErrContractNotFound
+	}
+	return contract, nil
+}
+
+// checkItemsEditable allows the items of draft and active contracts to be changed.
+func checkItemsEditable(contract *entities.Contract) error {
+	if contract.Status != entities.ContractStatusDraft && contract.Status != entities.ContractStatusActive {
+		return fmt.Errorf("%w: contract %s is %s", entities.ErrInvalidContractItem, contract.ContractNumber, contract.Status)
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/procurement/domain/entities"
+	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// MockContractRepository is a mock implementation of repositories.ContractRepository.
+type MockContractRepository struct {
+	mock.Mock
+}
+
+func (m *MockContractRepository) Create(ctx context.Context, contract *entities.Contract) error {
+	args := m.Called(ctx, contract)
+	return args.Error(0)
+}
+
+func (m *MockContractRepository) GetByID(ctx context.Context, id string) (*entities.Contract, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Contract), args.Error(1)
+}
+
+func (m *MockContractRepository) GetAll(ctx context.Context, filter *repositories.ContractFilter) ([]*entities.Contract, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.Contract), args.Int(1), args.Error(2)
+}
+
+func (m *MockContractRepository) Update(ctx context.Context, contract *entities.Contract) error {
+	args := m.Called(ctx, contract)
+	return args.Error(0)
+}
+
+func (m *MockContractRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockContractRepository) GetExpiring(ctx context.Context, days int) ([]*entities.Contract, error) {
+	args := m.Called(ctx, days)
+	return args.Get(0).([]*entities.Contract), args.Error(1)
+}
+
+func (m *MockContractRepository) GetBySupplierID(ctx context.Context, supplierID string) ([]*entities.Contract, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]*entities.Contract), args.Error(1)
+}
+
+func (m *MockContractRepository) GetStats(ctx context.Context) (*repositories.ContractStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repositories.ContractStats), args.Error(1)
+}
+
+func (m *MockContractRepository) GetNextContractNumber(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockContractRepository) GetItems(ctx context.Context, contractID string) ([]*entities.ContractItem, error) {
+	args := m.Called(ctx, contractID)
+	return args.Get(0).([]*entities.ContractItem), args.Error(1)
+}
+
+func (m *MockContractRepository) GetItem(ctx context.Context, id string) (*entities.ContractItem, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ContractItem), args.Error(1)
+}
+
+func (m *MockContractRepository) CreateItem(ctx context.Context, item *entities.ContractItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockContractRepository) UpdateItem(ctx context.Context, item *entities.ContractItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockContractRepository) DeleteItem(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockContractRepository) GetCallOffLines(ctx context.Context, contractID string) ([]*entities.ContractCallOffLine, error) {
+	args := m.Called(ctx, contractID)
+	return args.Get(0).([]*entities.ContractCallOffLine), args.Error(1)
+}
+
+func (m *MockContractRepository) SaveCallOff(ctx context.Context, contract *entities.Contract, lines []*entities.ContractCallOffLine) error {
+	args := m.Called(ctx, contract, lines)
+	return args.Error(0)
+}
+
+// MockContractNotifier is a mock implementation of ContractNotifier.
+type MockContractNotifier struct {
+	mock.Mock
+}
+
+func (m *MockContractNotifier) NotifyContractExpiring(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string, endDate time.Time, autoRenewal bool) error {
+	args := m.Called(ctx, ownerID, contractID, contractNumber, endDate, autoRenewal)
+	return args.Error(0)
+}
+
+func (m *MockContractNotifier) NotifyContractRenewed(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string, newEndDate time.Time) error {
+	args := m.Called(ctx, ownerID, contractID, contractNumber, newEndDate)
+	return args.Error(0)
+}
+
+func (m *MockContractNotifier) NotifyContractExpired(ctx context.Context, ownerID uuid.ID, contractID, contractNumber string) error {
+	args := m.Called(ctx, ownerID, contractID, contractNumber)
+	return args.Error(0)
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// newFrameworkContract builds an active framework contract worth 10,000,000 with fabric
+// agreed at 50,000 per meter, capped at 100 meters.
+func newFrameworkContract() (*entities.Contract, *entities.ContractItem) {
+	terms := "Net 30"
+	contract := &entities.Contract{
+		BaseModel:      types.BaseModel{ID: uuid.New()},
+		ContractNumber: "CTR-2025-00001",
+		SupplierID:     uuid.New().String(),
+		ContractType:   entities.ContractTypeFramework,
+		Status:         entities.ContractStatusActive,
+		StartDate:      today().AddDate(0, -1, 0),
+		EndDate:        today().AddDate(0, 6, 0),
+		Value:          10000000,
+		Currency:       "IDR",
+		PaymentTerms:   &terms,
+	}
+	quantityCap := 100
+	fabric := &entities.ContractItem{
+		BaseModel:   types.BaseModel{ID: uuid.New()},
+		ContractID:  contract.ID.String(),
+		ItemName:    "Fabric",
+		Unit:        "m",
+		UnitPrice:   50000,
+		QuantityCap: &quantityCap,
+	}
+	return contract, fabric
+}
+
+// withConsumption copies a contract item with the quantity given called off already.
+func withConsumption(item *entities.ContractItem, quantity int) []*entities.ContractItem {
+	consumed := *item
+	consumed.ConsumedQuantity = quantity
+	consumed.ConsumedValue = float64(quantity) * item.UnitPrice
+	return []*entities.ContractItem{&consumed}
+}
+
+// recordedCallOffLines appends the lines of each call-off saved to lines.
+func recordedCallOffLines(lines *[]*entities.ContractCallOffLine) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		*lines = append(*lines, args.Get(2).([]*entities.ContractCallOffLine)...)
+	}
+}
+
+func TestCallOff_UsesAgreedPricesAndTracksConsumption(t *testing.T) {
+	repo := new(MockContractRepository)
+	orders := new(MockPurchaseOrderRepository)
+	svc := NewContractService(repo)
+	svc.SetPurchaseOrderCreator(orders)
+	ctx := context.Background()
+	contract, fabric := newFrameworkContract()
+	var lines []*entities.ContractCallOffLine
+
+	repo.On("GetByID", ctx, contract.ID.String()).Return(contract, nil).Times(3)
+	repo.On("GetItems", ctx, contract.ID.String()).Return(withConsumption(fabric, 0), nil).Once()
+	repo.On("GetItems", ctx, contract.ID.String()).Return(withConsumption(fabric, 40), nil).Twice()
+	orders.On("Create", ctx, mock.AnythingOfType("*entities.PurchaseOrder")).Return(nil).Twice()
+	repo.On("SaveCallOff", ctx, contract, mock.AnythingOfType("[]*entities.ContractCallOffLine")).Return(nil).
+		Run(recordedCallOffLines(&lines)).Twice()
+
+	po, err := svc.CallOff(ctx, contract.ID.String(), &entities.CallOffRequest{
+		Lines: []entities.CallOffLineInput{{ContractItemID: fabric.ID.String(), Quantity: 40}},
+	}, uuid.New().String())
+	require.NoError(t, err)
+	orders.AssertCalled(t, "Create", ctx, po)
+	require.NotNil(t, po.ContractID)
+	assert.Equal(t, contract.ID, *po.ContractID)
+	assert.Equal(t, "Net 30", po.PaymentTerms)
+	require.Len(t, po.Items, 1)
+	assert.Equal(t, 50000.0, po.Items[0].UnitPrice)
+	assert.Equal(t, fabric.ID, *po.Items[0].ContractItemID)
+	require.Len(t, lines, 1)
+	assert.Equal(t, po.ID.String(), lines[0].PurchaseOrderID)
+	assert.Equal(t, 2000000.0, lines[0].Amount)
+
+	repo.On("GetCallOffLines", ctx, contract.ID.String()).Return(lines, nil).Once()
+	consumption, err := svc.GetConsumption(ctx, contract.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2000000.0, consumption.ConsumedValue)
+	require.NotNil(t, consumption.RemainingValue)
+	assert.Equal(t, 8000000.0, *consumption.RemainingValue)
+	assert.Equal(t, 20.0, consumption.Utilization)
+	require.Len(t, consumption.Items, 1)
+	assert.Equal(t, 40, consumption.Items[0].ConsumedQuantity)
+	assert.Equal(t, 60, *consumption.Items[0].RemainingQuantity)
+
+	// A lower negotiated price is allowed
+	lower := 45000.0
+	po, err = svc.CallOff(ctx, contract.ID.String(), &entities.CallOffRequest{
+		Lines: []entities.CallOffLineInput{{ContractItemID: fabric.ID.String(), Quantity: 10, UnitPrice: &lower}},
+	}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 45000.0, po.Items[0].UnitPrice)
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+}
+
+func TestCallOff_EnforcesCapsAndPrices(t *testing.T) {
+	repo := new(MockContractRepository)
+	orders := new(MockPurchaseOrderRepository)
+	svc := NewContractService(repo)
+	svc.SetPurchaseOrderCreator(orders)
+	ctx := context.Background()
+	contract, fabric := newFrameworkContract()
+	line := func(qty int, price *float64) *entities.CallOffRequest {
+		return &entities.CallOffRequest{Lines: []entities.CallOffLineInput{{ContractItemID: fabric.ID.String(), Quantity: qty, UnitPrice: price}}}
+	}
+
+	// Every call-off reads the contract; those still callable read its items as well
+	repo.On("GetByID", ctx, contract.ID.String()).Return(contract, nil).Times(6)
+	repo.On("GetItems", ctx, contract.ID.String()).Return(withConsumption(fabric, 0), nil).Times(4)
+
+	_, err := svc.CallOff(ctx, contract.ID.String(), line(101, nil), "")
+	assert.ErrorIs(t, err, entities.ErrContractCapExceeded)
+
+	higher := 55000.0
+	_, err = svc.CallOff(ctx, contract.ID.String(), line(1, &higher), "")
+	assert.ErrorIs(t, err, entities.ErrInvalidCallOff)
+
+	// The contract value caps the total across items: 61 m at 50,000 is above 3,000,000
+	contract.Value = 3000000
+	_, err = svc.CallOff(ctx, contract.ID.String(), line(61, nil), "")
+	assert.ErrorIs(t, err, entities.ErrContractCapExceeded)
+
+	_, err = svc.CallOff(ctx, contract.ID.String(), &entities.CallOffRequest{
+		Lines: []entities.CallOffLineInput{{ContractItemID: uuid.New().String(), Quantity: 1}},
+	}, "")
+	assert.ErrorIs(t, err, entities.ErrContractItemNotFound)
+
+	contract.ContractType = entities.ContractTypeSupply
+	_, err = svc.CallOff(ctx, contract.ID.String(), line(1, nil), "")
+	assert.ErrorIs(t, err, entities.ErrContractNotCallable)
+
+	contract.ContractType = entities.ContractTypeFramework
+	contract.EndDate = today().AddDate(0, 0, -1)
+	_, err = svc.CallOff(ctx, contract.ID.String(), line(1, nil), "")
+	assert.ErrorIs(t, err, entities.ErrContractNotCallable)
+
+	orders.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "SaveCallOff", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestCallOff_CancelledOrdersReleaseTheirConsumption(t *testing.T) {
+	repo := new(MockContractRepository)
+	orders := new(MockPurchaseOrderRepository)
+	svc := NewContractService(repo)
+	svc.SetPurchaseOrderCreator(orders)
+	ctx := context.Background()
+	contract, fabric := newFrameworkContract()
+	all := &entities.CallOffRequest{Lines: []entities.CallOffLineInput{{ContractItemID: fabric.ID.String(), Quantity: 100}}}
+
+	// The repository stops counting the 100 m once their order is cancelled
+	repo.On("GetByID", ctx, contract.ID.String()).Return(contract, nil).Times(3)
+	repo.On("GetItems", ctx, contract.ID.String()).Return(withConsumption(fabric, 0), nil).Once()
+	repo.On("GetItems", ctx, contract.ID.String()).Return(withConsumption(fabric, 100), nil).Once()
+	repo.On("GetItems", ctx, contract.ID.String()).Return(withConsumption(fabric, 0), nil).Once()
+	orders.On("Create", ctx, mock.AnythingOfType("*entities.PurchaseOrder")).Return(nil).Twice()
+	repo.On("SaveCallOff", ctx, contract, mock.AnythingOfType("[]*entities.ContractCallOffLine")).Return(nil).Twice()
+
+	_, err := svc.CallOff(ctx, contract.ID.String(), all, "")
+	require.NoError(t, err)
+	_, err = svc.CallOff(ctx, contract.ID.String(), all, "")
+	assert.ErrorIs(t, err, entities.ErrContractCapExceeded)
+
+	_, err = svc.CallOff(ctx, contract.ID.String(), all, "")
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+}
+
+func TestRunRenewalCycle_RenewsExpiresAndNotifiesOwners(t *testing.T) {
+	owner := uuid.New()
+	ownerID := owner.String()
+	twelve, notice := 12, 30
+	newContract := func(number string, end time.Time) *entities.Contract {
+		return &entities.Contract{
+			BaseModel:      types.BaseModel{ID: uuid.New()},
+			ContractNumber: number,
+			ContractType:   entities.ContractTypeFramework,
+			Status:         entities.ContractStatusActive,
+			StartDate:      end.AddDate(-1, 0, 0),
+			EndDate:        end,
+			NoticePeriod:   &notice,
+			OwnerID:        &ownerID,
+		}
+	}
+	renewing := newContract("CTR-RENEW", today().AddDate(0, 0, -1))
+	renewing.AutoRenewal = true
+	renewing.RenewalPeriod = &twelve
+	lapsing := newContract("CTR-LAPSE", today().AddDate(0, 0, -1))
+	expiring := newContract("CTR-SOON", today().AddDate(0, 0, 10))
+	later := newContract("CTR-LATER", today().AddDate(0, 0, 60))
+	renewedEnd := today().AddDate(0, 0, -1).AddDate(0, 12, 0)
+
+	repo := new(MockContractRepository)
+	notifier := new(MockContractNotifier)
+	svc := NewContractService(repo)
+	svc.SetNotifier(notifier)
+	ctx := context.Background()
+	active := mock.MatchedBy(func(filter *repositories.ContractFilter) bool {
+		return filter.Status == entities.ContractStatusActive
+	})
+	repo.On("GetAll", ctx, active).Return([]*entities.Contract{renewing, lapsing, expiring, later}, 4, nil).Once()
+	repo.On("GetAll", ctx, active).Return([]*entities.Contract{renewing, expiring, later}, 3, nil).Once()
+	repo.On("GetExpiring", ctx, contractNoticeHorizon).Return([]*entities.Contract{renewing, expiring, later}, nil).Twice()
+	repo.On("Update", ctx, mock.AnythingOfType("*entities.Contract")).Return(nil).Times(3)
+	notifier.On("NotifyContractRenewed", ctx, owner, renewing.ID.String(), "CTR-RENEW", renewedEnd).Return(nil).Once()
+	notifier.On("NotifyContractExpired", ctx, owner, lapsing.ID.String(), "CTR-LAPSE").Return(nil).Once()
+	notifier.On("NotifyContractExpiring", ctx, owner, expiring.ID.String(), "CTR-SOON", expiring.EndDate, false).Return(nil).Once()
+
+	run, err := svc.RunRenewalCycle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Renewed)
+	assert.Equal(t, 1, run.Expired)
+	assert.Equal(t, 1, run.Notified)
+
+	assert.Equal(t, entities.ContractStatusActive, renewing.Status)
+	assert.Equal(t, renewedEnd, renewing.EndDate)
+	assert.Equal(t, entities.ContractStatusExpired, lapsing.Status)
+	assert.NotNil(t, expiring.ExpiryNotifiedAt)
+	assert.Nil(t, later.ExpiryNotifiedAt)
+
+	// Owners are told once per term
+	run, err = svc.RunRenewalCycle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Notified)
+	repo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
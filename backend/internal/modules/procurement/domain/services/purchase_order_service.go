@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
 	"malaka/internal/modules/procurement/domain/entities"
 	"malaka/internal/modules/procurement/domain/repositories"
 	"malaka/internal/shared/auth"
@@ -21,6 +23,20 @@ type PurchaseOrderService struct {
 	budgetReader integration.BudgetReader  // Optional: for budget availability checks
 	budgetWriter integration.BudgetWriter  // Optional: for budget commitments
 	eventBus     events.EventBus           // Optional: for event-driven integration
+	approval     integration.ApprovalEngine // Optional: multi-level approval workflows
+	taxes        TaxCalculator              // Optional: determines item tax from the tax rules
+	suppliers    PurchaseOrderSentNotifier  // Optional: tells the supplier's portal users of sent orders
+}
+
+// PurchaseOrderSentNotifier notifies a supplier of a purchase order sent to them.
+type PurchaseOrderSentNotifier interface {
+	NotifyPurchaseOrderSent(ctx context.Context, order *entities.PurchaseOrder) error
+}
+
+// TaxCalculator determines the tax of document lines from the tax determination rules
+// and computes it at the rate in force on the document date.
+type TaxCalculator interface {
+	CalculateTax(ctx context.Context, req *accounting_entities.TaxCalculationRequest) (*accounting_entities.TaxCalculation, error)
 }
 
 // NewPurchaseOrderService creates a new purchase order service
@@ -41,6 +57,19 @@ func (s *PurchaseOrderService) WithEventBus(bus events.EventBus) *PurchaseOrderS
 	return s
 }
 
+// WithApprovalEngine routes submissions and approvals through the approval workflow engine
+func (s *PurchaseOrderService) WithApprovalEngine(engine integration.ApprovalEngine) *PurchaseOrderService {
+	s.approval = engine
+	return s
+}
+
+// WithTaxCalculator sets the tax of the items from the tax rules for the supplier and the
+// rate in force on the order date, in place of the tax percentage they carry
+func (s *PurchaseOrderService) WithTaxCalculator(taxes TaxCalculator) *PurchaseOrderService {
+	s.taxes = taxes
+	return s
+}
+
 // Create creates a new purchase order
 func (s *PurchaseOrderService) Create(ctx context.Context, order *entities.PurchaseOrder) error {
 	// Generate PO number
@@ -69,6 +98,10 @@ func (s *PurchaseOrderService) Create(ctx context.Context, order *entities.Purch
 	order.CreatedAt = now
 	order.UpdatedAt = now
 
+	if err := s.applyTax(ctx, order, itemPointers(order.Items)); err != nil {
+		return err
+	}
+
 	// Process items
 	for i := range order.Items {
 		if order.Items[i].ID.IsNil() {
@@ -115,6 +148,9 @@ func (s *PurchaseOrderService) Update(ctx context.Context, order *entities.Purch
 	}
 
 	// Recalculate totals
+	if err := s.applyTax(ctx, order, itemPointers(order.Items)); err != nil {
+		return err
+	}
 	order.CalculateTotals()
 	order.UpdatedAt = time.Now()
 
@@ -161,6 +197,12 @@ func (s *PurchaseOrderService) SubmitForApproval(ctx context.Context, id string)
 		return nil, err
 	}
 
+	if s.approval != nil {
+		if _, err := s.approval.Submit(ctx, purchaseOrderApprovalDocument(order)); err != nil {
+			return nil, fmt.Errorf("failed to submit purchase order for approval: %w", err)
+		}
+	}
+
 	return order, nil
 }
 
@@ -178,46 +220,60 @@ func (s *PurchaseOrderService) Approve(ctx context.Context, id, approverID strin
 		return nil, errors.New("can only approve pending orders")
 	}
 
-	// RBAC Check: Approver must be authorized
-	// We check if approver has higher authority than the creator
-	canApprove, err := s.rbac.CanApprove(ctx, approverID, order.CreatedBy.String())
-	if err != nil {
-		return nil, fmt.Errorf("rbac check failed: %w", err)
-	}
-	if !canApprove {
-		return nil, errors.New("approver does not have sufficient authority")
+	// RBAC Check: without an approval engine the approver must have higher authority
+	// than the creator; the engine applies the same check when no workflow is configured
+	if s.approval == nil {
+		canApprove, err := s.rbac.CanApprove(ctx, approverID, order.CreatedBy.String())
+		if err != nil {
+			return nil, fmt.Errorf("rbac check failed: %w", err)
+		}
+		if !canApprove {
+			return nil, errors.New("approver does not have sufficient authority")
+		}
 	}
 
-	// Budget Check: If budget integration is configured, check availability and commit
+	// Budget Check: availability is verified before any approval is recorded
+	var result *integration.BudgetAvailabilityResult
 	if s.budgetReader != nil && order.ExpenseAccountID != nil {
-		// Check budget availability
-		result, err := s.budgetReader.CheckAvailability(ctx, order.ExpenseAccountID.String(), order.TotalAmount)
+		availability, err := s.budgetReader.CheckAvailability(ctx, order.ExpenseAccountID.String(), order.TotalAmount)
 		if err != nil {
 			return nil, fmt.Errorf("budget check failed: %w", err)
 		}
-		if !result.IsAvailable {
+		if !availability.IsAvailable {
 			return nil, fmt.Errorf("insufficient budget: requested %.2f, available %.2f (shortfall: %.2f)",
-				order.TotalAmount, result.AvailableAmount, result.ShortfallAmount)
+				order.TotalAmount, availability.AvailableAmount, availability.ShortfallAmount)
 		}
+		result = availability
+	}
 
-		// Commit budget if writer is available
-		if s.budgetWriter != nil {
-			commitResult, err := s.budgetWriter.CommitBudget(ctx, &integration.BudgetCommitmentRequest{
-				BudgetID:        result.BudgetID,
-				AccountID:       order.ExpenseAccountID.String(),
-				Amount:          order.TotalAmount,
-				ReferenceType:   "PURCHASE_ORDER",
-				ReferenceID:     order.ID.String(),
-				ReferenceNumber: order.PONumber,
-				Description:     fmt.Sprintf("Budget commitment for PO %s - %s", order.PONumber, order.SupplierName),
-				CommittedBy:     approverID,
-			})
-			if err != nil {
-				return nil, fmt.Errorf("budget commitment failed: %w", err)
-			}
-			commitmentID, _ := uuid.Parse(commitResult.CommitmentID)
-			order.BudgetCommitmentID = &commitmentID
+	// The order stays pending until the last step of its approval workflow is approved
+	if s.approval != nil {
+		outcome, err := s.approval.Approve(ctx, purchaseOrderApprovalDocument(order), approverID, "")
+		if err != nil {
+			return nil, err
+		}
+		if !outcome.IsApproved() {
+			return order, nil
+		}
+	}
+
+	// Commit budget on final approval if writer is available
+	if result != nil && s.budgetWriter != nil {
+		commitResult, err := s.budgetWriter.CommitBudget(ctx, &integration.BudgetCommitmentRequest{
+			BudgetID:        result.BudgetID,
+			AccountID:       order.ExpenseAccountID.String(),
+			Amount:          order.TotalAmount,
+			ReferenceType:   "PURCHASE_ORDER",
+			ReferenceID:     order.ID.String(),
+			ReferenceNumber: order.PONumber,
+			Description:     fmt.Sprintf("Budget commitment for PO %s - %s", order.PONumber, order.SupplierName),
+			CommittedBy:     approverID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("budget commitment failed: %w", err)
 		}
+		commitmentID, _ := uuid.Parse(commitResult.CommitmentID)
+		order.BudgetCommitmentID = &commitmentID
 	}
 
 	now := time.Now()
@@ -274,6 +330,12 @@ func (s *PurchaseOrderService) Approve(ctx context.Context, id, approverID strin
 	return order, nil
 }
 
+// WithSupplierNotifier notifies suppliers of the orders sent to them
+func (s *PurchaseOrderService) WithSupplierNotifier(notifier PurchaseOrderSentNotifier) *PurchaseOrderService {
+	s.suppliers = notifier
+	return s
+}
+
 // Send marks a purchase order as sent to supplier
 func (s *PurchaseOrderService) Send(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
 	order, err := s.repo.GetByID(ctx, id)
@@ -298,6 +360,12 @@ func (s *PurchaseOrderService) Send(ctx context.Context, id string) (*entities.P
 		return nil, err
 	}
 
+	if s.suppliers != nil {
+		if err := s.suppliers.NotifyPurchaseOrderSent(ctx, order); err != nil {
+			log.Printf("Warning: failed to notify supplier of purchase order %s: %v", order.PONumber, err)
+		}
+	}
+
 	return order, nil
 }
 
@@ -408,6 +476,12 @@ func (s *PurchaseOrderService) Cancel(ctx context.Context, id, reason string) (*
 		return nil, errors.New("cannot cancel received or already cancelled orders")
 	}
 
+	if s.approval != nil {
+		if err := s.approval.Cancel(ctx, integration.ApprovalDocPurchaseOrder, order.ID.String(), "", reason); err != nil {
+			return nil, err
+		}
+	}
+
 	now := time.Now()
 	order.Status = entities.PurchaseOrderStatusCancelled
 	order.CancelledAt = &now
@@ -421,6 +495,17 @@ func (s *PurchaseOrderService) Cancel(ctx context.Context, id, reason string) (*
 	return order, nil
 }
 
+// purchaseOrderApprovalDocument describes a purchase order to the approval engine
+func purchaseOrderApprovalDocument(order *entities.PurchaseOrder) *integration.ApprovalDocument {
+	return &integration.ApprovalDocument{
+		DocumentType:   integration.ApprovalDocPurchaseOrder,
+		DocumentID:     order.ID.String(),
+		DocumentNumber: order.PONumber,
+		RequesterID:    order.CreatedBy.String(),
+		Amount:         order.TotalAmount,
+	}
+}
+
 // GetStats retrieves purchase order statistics
 func (s *PurchaseOrderService) GetStats(ctx context.Context) (*repositories.PurchaseOrderStats, error) {
 	return s.repo.GetStats(ctx)
@@ -439,11 +524,17 @@ func (s *PurchaseOrderService) AddItem(ctx context.Context, orderID string, item
 	if order.Status != entities.PurchaseOrderStatusDraft {
 		return errors.New("can only add items to draft orders")
 	}
+	if order.ContractID != nil {
+		return entities.ErrCallOffItemsFixed
+	}
 
 	if item.ID.IsNil() {
 		item.ID = uuid.New()
 	}
 	item.PurchaseOrderID = order.ID
+	if err := s.applyTax(ctx, order, []*entities.PurchaseOrderItem{item}); err != nil {
+		return err
+	}
 	item.CalculateLineTotal()
 
 	now := time.Now()
@@ -474,6 +565,9 @@ func (s *PurchaseOrderService) DeleteItem(ctx context.Context, orderID, itemID s
 	if order.Status != entities.PurchaseOrderStatusDraft {
 		return errors.New("can only delete items from draft orders")
 	}
+	if order.ContractID != nil {
+		return entities.ErrCallOffItemsFixed
+	}
 
 	if err := s.repo.DeleteItem(ctx, orderID, itemID); err != nil {
 		return err
@@ -491,3 +585,41 @@ func (s *PurchaseOrderService) DeleteItem(ctx context.Context, orderID, itemID s
 
 	return s.repo.Update(ctx, order)
 }
+
+// applyTax sets the tax percentage of items to the tax the rules determine for the
+// supplier of the order on the order date, as a percentage of the discounted line.
+func (s *PurchaseOrderService) applyTax(ctx context.Context, order *entities.PurchaseOrder, items []*entities.PurchaseOrderItem) error {
+	if s.taxes == nil || len(items) == 0 {
+		return nil
+	}
+	date := order.OrderDate
+	if date.IsZero() {
+		date = time.Now()
+	}
+	req := &accounting_entities.TaxCalculationRequest{
+		TransactionType: accounting_entities.TaxTransactionPurchase,
+		PartyID:         order.SupplierID,
+		Date:            date,
+		Lines:           make([]accounting_entities.TaxCalculationLineInput, len(items)),
+	}
+	for i, item := range items {
+		subtotal := float64(item.Quantity) * item.UnitPrice
+		req.Lines[i].Amount = subtotal - subtotal*item.DiscountPercentage/100
+	}
+	calc, err := s.taxes.CalculateTax(ctx, req)
+	if err != nil {
+		return err
+	}
+	for i, line := range calc.Lines {
+		items[i].TaxPercentage = line.EffectiveRate
+	}
+	return nil
+}
+
+func itemPointers(items []entities.PurchaseOrderItem) []*entities.PurchaseOrderItem {
+	ptrs := make([]*entities.PurchaseOrderItem, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return ptrs
+}
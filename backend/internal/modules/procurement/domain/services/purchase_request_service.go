@@ -8,14 +8,16 @@ import (
 
 	"malaka/internal/modules/procurement/domain/entities"
 	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/utils"
 	"malaka/internal/shared/uuid"
 )
 
 // PurchaseRequestService provides business logic for purchase request operations.
 type PurchaseRequestService struct {
-	repo   repositories.PurchaseRequestRepository
-	poRepo repositories.PurchaseOrderRepository
+	repo     repositories.PurchaseRequestRepository
+	poRepo   repositories.PurchaseOrderRepository
+	approval integration.ApprovalEngine // Optional: multi-level approval workflows
 }
 
 // NewPurchaseRequestService creates a new PurchaseRequestService.
@@ -28,6 +30,11 @@ func (s *PurchaseRequestService) SetPurchaseOrderRepository(poRepo repositories.
 	s.poRepo = poRepo
 }
 
+// SetApprovalEngine routes submissions and decisions through the approval workflow engine.
+func (s *PurchaseRequestService) SetApprovalEngine(engine integration.ApprovalEngine) {
+	s.approval = engine
+}
+
 // Create creates a new purchase request.
 func (s *PurchaseRequestService) Create(ctx context.Context, pr *entities.PurchaseRequest) error {
 	if pr.ID.IsNil() {
@@ -157,10 +164,17 @@ func (s *PurchaseRequestService) Submit(ctx context.Context, id string) (*entiti
 		return nil, err
 	}
 
+	if s.approval != nil {
+		if _, err := s.approval.Submit(ctx, purchaseRequestApprovalDocument(pr)); err != nil {
+			return nil, fmt.Errorf("failed to submit purchase request for approval: %w", err)
+		}
+	}
+
 	return pr, nil
 }
 
-// Approve approves a purchase request.
+// Approve records the approval of approverID and approves the purchase request once its
+// approval workflow is complete. Check the returned status to tell the two apart.
 func (s *PurchaseRequestService) Approve(ctx context.Context, id string, approverID string) (*entities.PurchaseRequest, error) {
 	pr, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -174,6 +188,17 @@ func (s *PurchaseRequestService) Approve(ctx context.Context, id string, approve
 		return nil, errors.New("purchase request cannot be approved in current status")
 	}
 
+	// The request stays pending until the last step of its approval workflow is approved
+	if s.approval != nil {
+		outcome, err := s.approval.Approve(ctx, purchaseRequestApprovalDocument(pr), approverID, "")
+		if err != nil {
+			return nil, err
+		}
+		if !outcome.IsApproved() {
+			return pr, nil
+		}
+	}
+
 	now := utils.Now()
 	pr.Status = entities.PRStatusApproved
 	approverUUID, _ := uuid.Parse(approverID)
@@ -189,7 +214,7 @@ func (s *PurchaseRequestService) Approve(ctx context.Context, id string, approve
 }
 
 // Reject rejects a purchase request.
-func (s *PurchaseRequestService) Reject(ctx context.Context, id string, reason string) (*entities.PurchaseRequest, error) {
+func (s *PurchaseRequestService) Reject(ctx context.Context, id, rejectorID, reason string) (*entities.PurchaseRequest, error) {
 	pr, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -202,6 +227,12 @@ func (s *PurchaseRequestService) Reject(ctx context.Context, id string, reason s
 		return nil, errors.New("purchase request cannot be rejected in current status")
 	}
 
+	if s.approval != nil {
+		if _, err := s.approval.Reject(ctx, purchaseRequestApprovalDocument(pr), rejectorID, reason); err != nil {
+			return nil, err
+		}
+	}
+
 	pr.Status = entities.PRStatusRejected
 	pr.RejectionReason = &reason
 	pr.UpdatedAt = utils.Now()
@@ -213,8 +244,8 @@ func (s *PurchaseRequestService) Reject(ctx context.Context, id string, reason s
 	return pr, nil
 }
 
-// Cancel cancels a purchase request.
-func (s *PurchaseRequestService) Cancel(ctx context.Context, id string) (*entities.PurchaseRequest, error) {
+// Cancel cancels a purchase request and withdraws it from approval.
+func (s *PurchaseRequestService) Cancel(ctx context.Context, id, actorID string) (*entities.PurchaseRequest, error) {
 	pr, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -227,6 +258,12 @@ func (s *PurchaseRequestService) Cancel(ctx context.Context, id string) (*entiti
 		return nil, errors.New("purchase request is already cancelled")
 	}
 
+	if s.approval != nil {
+		if err := s.approval.Cancel(ctx, integration.ApprovalDocPurchaseRequest, pr.ID.String(), actorID, "purchase request cancelled"); err != nil {
+			return nil, err
+		}
+	}
+
 	pr.Status = entities.PRStatusCancelled
 	pr.UpdatedAt = utils.Now()
 
@@ -237,6 +274,18 @@ func (s *PurchaseRequestService) Cancel(ctx context.Context, id string) (*entiti
 	return pr, nil
 }
 
+// purchaseRequestApprovalDocument describes a purchase request to the approval engine
+func purchaseRequestApprovalDocument(pr *entities.PurchaseRequest) *integration.ApprovalDocument {
+	return &integration.ApprovalDocument{
+		DocumentType:   integration.ApprovalDocPurchaseRequest,
+		DocumentID:     pr.ID.String(),
+		DocumentNumber: pr.RequestNumber,
+		RequesterID:    pr.RequesterID.String(),
+		Department:     pr.Department,
+		Amount:         pr.TotalAmount,
+	}
+}
+
 // GetStats retrieves purchase request statistics.
 func (s *PurchaseRequestService) GetStats(ctx context.Context) (*repositories.PurchaseRequestStats, error) {
 	return s.repo.GetStats(ctx)
@@ -3,17 +3,35 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
 	"malaka/internal/modules/procurement/domain/entities"
 	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/events"
+	"malaka/internal/shared/types"
 	"malaka/internal/shared/utils"
 	"malaka/internal/shared/uuid"
 )
 
 // RFQService handles RFQ business logic
 type RFQService struct {
-	repo   repositories.RFQRepository
-	poRepo repositories.PurchaseOrderRepository
+	repo          repositories.RFQRepository
+	poRepo        repositories.PurchaseOrderRepository
+	exchangeRates ExchangeRateSource // Optional: converts foreign-currency bids for comparison
+	vendorScores  VendorScoreSource  // Optional: vendor evaluation scores for bid scoring
+	eventBus      events.EventBus    // Optional: publishes RFQ closed on award
+}
+
+// ExchangeRateSource provides the latest exchange rates, in IDR per unit of currency
+type ExchangeRateSource interface {
+	GetLatestRates() ([]accounting_entities.ExchangeRateData, error)
+}
+
+// VendorScoreSource provides the average overall score (1-5) of the approved evaluations
+// of a supplier, 0 when it has none
+type VendorScoreSource interface {
+	GetSupplierAverageScore(ctx context.Context, supplierID string) (float64, error)
 }
 
 // NewRFQService creates a new RFQ service
@@ -28,6 +46,21 @@ func (s *RFQService) SetPurchaseOrderRepository(poRepo repositories.PurchaseOrde
 	s.poRepo = poRepo
 }
 
+// SetExchangeRateSource sets the exchange rates used to compare bids in other currencies
+func (s *RFQService) SetExchangeRateSource(rates ExchangeRateSource) {
+	s.exchangeRates = rates
+}
+
+// SetVendorScoreSource sets the vendor evaluation scores used in bid scoring
+func (s *RFQService) SetVendorScoreSource(scores VendorScoreSource) {
+	s.vendorScores = scores
+}
+
+// SetEventBus sets the event bus for cross-module communication
+func (s *RFQService) SetEventBus(bus events.EventBus) {
+	s.eventBus = bus
+}
+
 // Create creates a new RFQ with items and optional supplier invitations
 func (s *RFQService) Create(ctx context.Context, rfq *entities.RFQ) error {
 	// Validate request
@@ -567,6 +600,359 @@ func (s *RFQService) ConvertToPO(ctx context.Context, responseID, createdBy, del
 	return po, nil
 }
 
+// CompareBids builds the bid comparison matrix of an RFQ: for each item, the line prices
+// of the open responses converted to IDR, scored on price, delivery time and the vendor
+// evaluation of the supplier with the given weights (the defaults when nil), best first.
+// Responses without line prices cannot be compared per item and are left out.
+func (s *RFQService) CompareBids(ctx context.Context, rfqID string, weights *entities.BidWeights) (*entities.BidComparison, error) {
+	rfq, err := s.repo.GetByID(ctx, rfqID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RFQ: %w", err)
+	}
+	if rfq == nil {
+		return nil, entities.ErrRFQNotFound
+	}
+	return s.compareBids(ctx, rfq, weights)
+}
+
+func (s *RFQService) compareBids(ctx context.Context, rfq *entities.RFQ, weights *entities.BidWeights) (*entities.BidComparison, error) {
+	w := entities.DefaultBidWeights()
+	if weights != nil {
+		w = *weights
+	}
+	w, err := w.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &entities.BidComparison{
+		RFQID:        rfq.ID.String(),
+		RFQNumber:    rfq.RFQNumber,
+		Status:       rfq.Status,
+		BaseCurrency: entities.RFQBaseCurrency,
+		Weights:      w,
+		Items:        make([]*entities.BidComparisonItem, 0, len(rfq.Items)),
+	}
+	var rates map[string]float64
+	vendorScores := make(map[string]float64)
+
+	for _, rfqItem := range rfq.Items {
+		item := &entities.BidComparisonItem{
+			RFQItemID:   rfqItem.ID.String(),
+			ItemName:    rfqItem.ItemName,
+			Quantity:    rfqItem.Quantity,
+			Unit:        rfqItem.Unit,
+			TargetPrice: rfqItem.TargetPrice,
+			Bids:        []*entities.ItemBid{},
+		}
+		for _, resp := range rfq.Responses {
+			if resp.Status == "rejected" {
+				continue
+			}
+			var line *entities.RFQResponseItem
+			for _, ri := range resp.ResponseItems {
+				if ri.RFQItemID == item.RFQItemID && ri.UnitPrice > 0 {
+					line = ri
+					break
+				}
+			}
+			if line == nil {
+				continue
+			}
+
+			currency := strings.ToUpper(resp.Currency)
+			if currency == "" {
+				currency = entities.RFQBaseCurrency
+			}
+			rate := 1.0
+			if currency != entities.RFQBaseCurrency {
+				if rates == nil {
+					if rates, err = s.latestRates(); err != nil {
+						return nil, err
+					}
+				}
+				if rate = rates[currency]; rate <= 0 {
+					return nil, fmt.Errorf("%w %s", entities.ErrNoExchangeRate, currency)
+				}
+			}
+
+			score, ok := vendorScores[resp.SupplierID]
+			if !ok {
+				if score, err = s.vendorScore(ctx, resp.SupplierID); err != nil {
+					return nil, err
+				}
+				vendorScores[resp.SupplierID] = score
+			}
+
+			totalPrice := line.TotalPrice
+			if totalPrice == 0 {
+				totalPrice = line.UnitPrice * float64(rfqItem.Quantity)
+			}
+			deliveryTime := line.DeliveryTime
+			if deliveryTime == 0 {
+				deliveryTime = resp.DeliveryTime
+			}
+			item.Bids = append(item.Bids, &entities.ItemBid{
+				ResponseID:      resp.ID.String(),
+				SupplierID:      resp.SupplierID,
+				SupplierName:    resp.SupplierName,
+				Currency:        currency,
+				ExchangeRate:    rate,
+				UnitPrice:       line.UnitPrice,
+				TotalPrice:      totalPrice,
+				UnitPriceBase:   roundAmount(line.UnitPrice * rate),
+				TotalPriceBase:  roundAmount(totalPrice * rate),
+				DeliveryTime:    deliveryTime,
+				VendorScore:     scoreOrNeutral(score),
+				VendorEvaluated: score > 0,
+			})
+		}
+		entities.ScoreBids(item.Bids, w)
+		cmp.Items = append(cmp.Items, item)
+	}
+	return cmp, nil
+}
+
+// latestRates returns the latest middle rates by currency.
+func (s *RFQService) latestRates() (map[string]float64, error) {
+	rates := make(map[string]float64)
+	if s.exchangeRates == nil {
+		return rates, nil
+	}
+	data, err := s.exchangeRates.GetLatestRates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	for _, r := range data {
+		rates[strings.ToUpper(r.Currency)] = r.MiddleRate
+	}
+	return rates, nil
+}
+
+// vendorScore returns the average approved evaluation score of a supplier, 0 when unknown.
+func (s *RFQService) vendorScore(ctx context.Context, supplierID string) (float64, error) {
+	if s.vendorScores == nil {
+		return 0, nil
+	}
+	score, err := s.vendorScores.GetSupplierAverageScore(ctx, supplierID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get vendor score: %w", err)
+	}
+	return score, nil
+}
+
+func scoreOrNeutral(score float64) float64 {
+	if score <= 0 {
+		return entities.NeutralVendorScore
+	}
+	return score
+}
+
+func roundAmount(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
+
+// Award awards the items of a published RFQ, per item or the whole RFQ to one response
+// (every item to its best bid when neither is given), raises one draft purchase order per
+// winning supplier at the quoted prices and closes the RFQ. Items left out are not ordered.
+func (s *RFQService) Award(ctx context.Context, rfqID string, req *entities.RFQAwardRequest, awardedBy string) (*entities.RFQAwardResult, error) {
+	if s.poRepo == nil {
+		return nil, fmt.Errorf("purchase order repository not configured")
+	}
+	rfq, err := s.repo.GetByID(ctx, rfqID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RFQ: %w", err)
+	}
+	if rfq == nil {
+		return nil, entities.ErrRFQNotFound
+	}
+	if rfq.Status != "published" {
+		return nil, entities.ErrRFQNotAwardable
+	}
+
+	cmp, err := s.compareBids(ctx, rfq, req.Weights)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectAwardedBids(rfq, cmp, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// One purchase order per winning supplier, in RFQ item order
+	now := utils.Now()
+	createdBy, _ := uuid.Parse(awardedBy)
+	orders := make(map[string]*entities.PurchaseOrder)
+	var pending []*entities.PurchaseOrder
+	var awards []*entities.RFQAward
+
+	for _, rfqItem := range rfq.Items {
+		bid, ok := selected[rfqItem.ID.String()]
+		if !ok {
+			continue
+		}
+		po, ok := orders[bid.SupplierID]
+		if !ok {
+			supplierID, _ := uuid.Parse(bid.SupplierID)
+			po = entities.NewPurchaseOrder(supplierID, createdBy)
+			po.Currency = bid.Currency
+			po.DeliveryAddress = req.DeliveryAddress
+			po.PaymentTerms = req.PaymentTerms
+			po.Notes = fmt.Sprintf("Awarded from %s", rfq.RFQNumber)
+			orders[bid.SupplierID] = po
+			pending = append(pending, po)
+		}
+		poItem := entities.NewPurchaseOrderItem(po.ID)
+		poItem.ItemName = rfqItem.ItemName
+		poItem.Description = rfqItem.Description
+		poItem.Specification = rfqItem.Specification
+		poItem.Quantity = rfqItem.Quantity
+		poItem.Unit = rfqItem.Unit
+		poItem.UnitPrice = bid.UnitPrice
+		poItem.Currency = bid.Currency
+		poItem.CalculateLineTotal()
+		po.Items = append(po.Items, *poItem)
+
+		// The order is expected when its slowest item is delivered
+		expected := po.OrderDate.AddDate(0, 0, bid.DeliveryTime)
+		if bid.DeliveryTime > 0 && (po.ExpectedDeliveryDate == nil || expected.After(*po.ExpectedDeliveryDate)) {
+			po.ExpectedDeliveryDate = &expected
+		}
+
+		awards = append(awards, &entities.RFQAward{
+			BaseModel:       types.BaseModel{ID: uuid.New(), CreatedAt: now, UpdatedAt: now},
+			RFQID:           rfq.ID.String(),
+			RFQItemID:       rfqItem.ID.String(),
+			RFQResponseID:   bid.ResponseID,
+			SupplierID:      bid.SupplierID,
+			Quantity:        rfqItem.Quantity,
+			UnitPrice:       bid.UnitPrice,
+			Currency:        bid.Currency,
+			Score:           bid.Score,
+			PurchaseOrderID: po.ID.String(),
+			AwardedBy:       awardedBy,
+			AwardedAt:       now,
+			ItemName:        rfqItem.ItemName,
+			SupplierName:    bid.SupplierName,
+		})
+	}
+
+	// Orders raised before a failure are removed again, so a failed award can be retried
+	var created []*entities.PurchaseOrder
+	rollback := func() {
+		for _, po := range created {
+			_ = s.poRepo.Delete(ctx, po.ID.String())
+		}
+	}
+	for _, po := range pending {
+		if po.PONumber, err = s.poRepo.GetNextPONumber(ctx); err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to generate PO number: %w", err)
+		}
+		po.CalculateTotals()
+		if err := s.poRepo.Create(ctx, po); err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to create purchase order: %w", err)
+		}
+		created = append(created, po)
+	}
+	for _, a := range awards {
+		a.PONumber = orders[a.SupplierID].PONumber
+	}
+
+	if err := s.repo.SaveAwards(ctx, rfq.ID.String(), awards); err != nil {
+		rollback()
+		return nil, err
+	}
+
+	if s.eventBus != nil {
+		eventAwards := make([]events.RFQAwardEventData, len(awards))
+		for i, a := range awards {
+			eventAwards[i] = events.RFQAwardEventData{
+				RFQItemID:       a.RFQItemID,
+				SupplierID:      a.SupplierID,
+				ResponseID:      a.RFQResponseID,
+				PurchaseOrderID: a.PurchaseOrderID,
+				Quantity:        a.Quantity,
+				UnitPrice:       a.UnitPrice,
+				Currency:        a.Currency,
+				Score:           a.Score,
+			}
+		}
+		poIDs := make([]string, len(created))
+		for i, po := range created {
+			poIDs[i] = po.ID.String()
+		}
+		s.eventBus.PublishAsync(ctx, events.NewRFQClosedEvent(rfq.ID.String(), rfq.RFQNumber, awardedBy, eventAwards, poIDs))
+	}
+
+	closed, err := s.repo.GetByID(ctx, rfq.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RFQ: %w", err)
+	}
+	return &entities.RFQAwardResult{RFQ: closed, Awards: awards, PurchaseOrders: created}, nil
+}
+
+// selectAwardedBids resolves the winning bid of each awarded item.
+func selectAwardedBids(rfq *entities.RFQ, cmp *entities.BidComparison, req *entities.RFQAwardRequest) (map[string]*entities.ItemBid, error) {
+	selected := make(map[string]*entities.ItemBid)
+	switch {
+	case req.ResponseID != "" && len(req.Items) > 0:
+		return nil, fmt.Errorf("%w: give either response_id or items", entities.ErrInvalidRFQAward)
+
+	case req.ResponseID != "":
+		found := false
+		for _, resp := range rfq.Responses {
+			if resp.ID.String() == req.ResponseID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, entities.ErrRFQResponseNotFound
+		}
+		for _, item := range cmp.Items {
+			bid := item.Bid(req.ResponseID)
+			if bid == nil {
+				return nil, fmt.Errorf("%w: the response has no price for %s", entities.ErrInvalidRFQAward, item.ItemName)
+			}
+			selected[item.RFQItemID] = bid
+		}
+
+	case len(req.Items) > 0:
+		for _, in := range req.Items {
+			item := cmp.Item(in.RFQItemID)
+			if item == nil {
+				return nil, fmt.Errorf("%w: item %s is not in the RFQ", entities.ErrInvalidRFQAward, in.RFQItemID)
+			}
+			if _, dup := selected[in.RFQItemID]; dup {
+				return nil, fmt.Errorf("%w: %s is awarded twice", entities.ErrInvalidRFQAward, item.ItemName)
+			}
+			bid := item.Bid(in.ResponseID)
+			if bid == nil {
+				return nil, fmt.Errorf("%w: response %s has no price for %s", entities.ErrInvalidRFQAward, in.ResponseID, item.ItemName)
+			}
+			selected[in.RFQItemID] = bid
+		}
+
+	default:
+		for _, item := range cmp.Items {
+			if bid := item.Recommended(); bid != nil {
+				selected[item.RFQItemID] = bid
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: there are no bids to award", entities.ErrInvalidRFQAward)
+	}
+	return selected, nil
+}
+
+// GetAwards retrieves the awards of an RFQ
+func (s *RFQService) GetAwards(ctx context.Context, rfqID string) ([]*entities.RFQAward, error) {
+	return s.repo.GetAwards(ctx, rfqID)
+}
+
 // GetStats retrieves RFQ statistics
 func (s *RFQService) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	return s.repo.GetRFQStats(ctx)
@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/modules/procurement/domain/entities"
+	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// MockRFQRepository is a mock implementation of repositories.RFQRepository.
+type MockRFQRepository struct {
+	mock.Mock
+}
+
+func (m *MockRFQRepository) Create(ctx context.Context, rfq *entities.RFQ) error {
+	args := m.Called(ctx, rfq)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GetByID(ctx context.Context, id string) (*entities.RFQ, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RFQ), args.Error(1)
+}
+
+func (m *MockRFQRepository) GetAll(ctx context.Context, filter *repositories.RFQFilter) ([]*entities.RFQ, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.RFQ), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRFQRepository) Update(ctx context.Context, rfq *entities.RFQ) error {
+	args := m.Called(ctx, rfq)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GetByRFQNumber(ctx context.Context, rfqNumber string) (*entities.RFQ, error) {
+	args := m.Called(ctx, rfqNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RFQ), args.Error(1)
+}
+
+func (m *MockRFQRepository) PublishRFQ(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) CloseRFQ(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) CancelRFQ(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GenerateRFQNumber(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRFQRepository) CreateItem(ctx context.Context, item *entities.RFQItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GetRFQItems(ctx context.Context, rfqID string) ([]*entities.RFQItem, error) {
+	args := m.Called(ctx, rfqID)
+	return args.Get(0).([]*entities.RFQItem), args.Error(1)
+}
+
+func (m *MockRFQRepository) UpdateItem(ctx context.Context, item *entities.RFQItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) DeleteItem(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) InviteSupplier(ctx context.Context, rfqSupplier *entities.RFQSupplier) error {
+	args := m.Called(ctx, rfqSupplier)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GetRFQSuppliers(ctx context.Context, rfqID string) ([]*entities.RFQSupplier, error) {
+	args := m.Called(ctx, rfqID)
+	return args.Get(0).([]*entities.RFQSupplier), args.Error(1)
+}
+
+func (m *MockRFQRepository) RemoveSupplier(ctx context.Context, rfqID, supplierID string) error {
+	args := m.Called(ctx, rfqID, supplierID)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) UpdateSupplierStatus(ctx context.Context, rfqID, supplierID, status string) error {
+	args := m.Called(ctx, rfqID, supplierID, status)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) CreateResponse(ctx context.Context, response *entities.RFQResponse) error {
+	args := m.Called(ctx, response)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GetRFQResponses(ctx context.Context, rfqID string) ([]*entities.RFQResponse, error) {
+	args := m.Called(ctx, rfqID)
+	return args.Get(0).([]*entities.RFQResponse), args.Error(1)
+}
+
+func (m *MockRFQRepository) GetResponseBySupplier(ctx context.Context, rfqID, supplierID string) (*entities.RFQResponse, error) {
+	args := m.Called(ctx, rfqID, supplierID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RFQResponse), args.Error(1)
+}
+
+func (m *MockRFQRepository) GetResponseByID(ctx context.Context, id string) (*entities.RFQResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RFQResponse), args.Error(1)
+}
+
+func (m *MockRFQRepository) UpdateResponse(ctx context.Context, response *entities.RFQResponse) error {
+	args := m.Called(ctx, response)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) AcceptResponse(ctx context.Context, responseID string) error {
+	args := m.Called(ctx, responseID)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) RejectResponse(ctx context.Context, responseID, reason string) error {
+	args := m.Called(ctx, responseID, reason)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) CreateResponseItem(ctx context.Context, item *entities.RFQResponseItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GetResponseItems(ctx context.Context, responseID string) ([]*entities.RFQResponseItem, error) {
+	args := m.Called(ctx, responseID)
+	return args.Get(0).([]*entities.RFQResponseItem), args.Error(1)
+}
+
+func (m *MockRFQRepository) SaveAwards(ctx context.Context, rfqID string, awards []*entities.RFQAward) error {
+	args := m.Called(ctx, rfqID, awards)
+	return args.Error(0)
+}
+
+func (m *MockRFQRepository) GetAwards(ctx context.Context, rfqID string) ([]*entities.RFQAward, error) {
+	args := m.Called(ctx, rfqID)
+	return args.Get(0).([]*entities.RFQAward), args.Error(1)
+}
+
+func (m *MockRFQRepository) GetRFQStats(ctx context.Context) (map[string]interface{}, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
+// MockPurchaseOrderRepository is a mock implementation of repositories.PurchaseOrderRepository.
+type MockPurchaseOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockPurchaseOrderRepository) Create(ctx context.Context, order *entities.PurchaseOrder) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) GetByID(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) GetByPONumber(ctx context.Context, poNumber string) (*entities.PurchaseOrder, error) {
+	args := m.Called(ctx, poNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) GetAll(ctx context.Context, filter repositories.PurchaseOrderFilter) (*repositories.PurchaseOrderListResult, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repositories.PurchaseOrderListResult), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) Update(ctx context.Context, order *entities.PurchaseOrder) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) GetStats(ctx context.Context) (*repositories.PurchaseOrderStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repositories.PurchaseOrderStats), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) AddItem(ctx context.Context, item *entities.PurchaseOrderItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) UpdateItem(ctx context.Context, item *entities.PurchaseOrderItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) DeleteItem(ctx context.Context, orderID, itemID string) error {
+	args := m.Called(ctx, orderID, itemID)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) GetItemsByOrderID(ctx context.Context, orderID string) ([]*entities.PurchaseOrderItem, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).([]*entities.PurchaseOrderItem), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) GetNextPONumber(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+// MockExchangeRateSource is a mock implementation of ExchangeRateSource.
+type MockExchangeRateSource struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateSource) GetLatestRates() ([]accounting_entities.ExchangeRateData, error) {
+	args := m.Called()
+	return args.Get(0).([]accounting_entities.ExchangeRateData), args.Error(1)
+}
+
+// MockVendorScoreSource is a mock implementation of VendorScoreSource.
+type MockVendorScoreSource struct {
+	mock.Mock
+}
+
+func (m *MockVendorScoreSource) GetSupplierAverageScore(ctx context.Context, supplierID string) (float64, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+// newBidRFQ builds a published RFQ for fabric and buttons with a local bid in IDR and a
+// cheaper but slower bid in USD for both items.
+func newBidRFQ() (*entities.RFQ, *entities.RFQResponse, *entities.RFQResponse) {
+	base := func() types.BaseModel { return types.BaseModel{ID: uuid.New()} }
+	rfq := &entities.RFQ{BaseModel: base(), RFQNumber: "RFQ-20250301-0001", Status: "published"}
+	fabric := &entities.RFQItem{BaseModel: base(), ItemName: "Fabric", Quantity: 100, Unit: "m"}
+	buttons := &entities.RFQItem{BaseModel: base(), ItemName: "Buttons", Quantity: 1000, Unit: "pcs"}
+	rfq.Items = []*entities.RFQItem{fabric, buttons}
+
+	local := &entities.RFQResponse{BaseModel: base(), SupplierID: uuid.New().String(), SupplierName: "Tekstil Jaya",
+		Currency: "IDR", DeliveryTime: 7, Status: "submitted"}
+	local.ResponseItems = []*entities.RFQResponseItem{
+		{RFQItemID: fabric.ID.String(), UnitPrice: 50000, TotalPrice: 5000000},
+		{RFQItemID: buttons.ID.String(), UnitPrice: 500, TotalPrice: 500000, DeliveryTime: 3},
+	}
+	imported := &entities.RFQResponse{BaseModel: base(), SupplierID: uuid.New().String(), SupplierName: "Global Fabrics",
+		Currency: "usd", DeliveryTime: 30, Status: "submitted"}
+	imported.ResponseItems = []*entities.RFQResponseItem{
+		{RFQItemID: fabric.ID.String(), UnitPrice: 2.5},
+		{RFQItemID: buttons.ID.String(), UnitPrice: 0.05},
+	}
+	rfq.Responses = []*entities.RFQResponse{local, imported}
+	return rfq, local, imported
+}
+
+// usdRates are the latest rates with USD at IDR 16,000.
+func usdRates() []accounting_entities.ExchangeRateData {
+	return []accounting_entities.ExchangeRateData{{Currency: "USD", MiddleRate: 16000}}
+}
+
+// savedAwardsOf records the awards saved for the RFQ and closes it as the database does.
+func savedAwardsOf(rfq *entities.RFQ, awards *[]*entities.RFQAward) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		*awards = args.Get(2).([]*entities.RFQAward)
+		rfq.Status = "closed"
+	}
+}
+
+func TestCompareBids_NormalizesCurrencyAndScores(t *testing.T) {
+	repo := new(MockRFQRepository)
+	rates := new(MockExchangeRateSource)
+	scores := new(MockVendorScoreSource)
+	svc := NewRFQService(repo)
+	svc.SetExchangeRateSource(rates)
+	svc.SetVendorScoreSource(scores)
+	ctx := context.Background()
+	rfq, local, imported := newBidRFQ()
+
+	repo.On("GetByID", ctx, rfq.ID.String()).Return(rfq, nil).Times(3)
+	rates.On("GetLatestRates").Return(usdRates(), nil).Twice()
+	scores.On("GetSupplierAverageScore", ctx, local.SupplierID).Return(4.5, nil).Twice()
+	scores.On("GetSupplierAverageScore", ctx, imported.SupplierID).Return(0.0, nil).Twice()
+
+	cmp, err := svc.CompareBids(ctx, rfq.ID.String(), nil)
+	require.NoError(t, err)
+	require.Len(t, cmp.Items, 2)
+
+	fabric := cmp.Items[0]
+	require.Len(t, fabric.Bids, 2)
+	importedBid := fabric.Bid(imported.ID.String())
+	assert.Equal(t, "USD", importedBid.Currency)
+	assert.Equal(t, 40000.0, importedBid.UnitPriceBase)
+	assert.Equal(t, 4000000.0, importedBid.TotalPriceBase)
+	assert.Equal(t, 100.0, importedBid.PriceScore)
+	assert.Equal(t, entities.NeutralVendorScore, importedBid.VendorScore)
+	assert.False(t, importedBid.VendorEvaluated)
+
+	localBid := fabric.Bid(local.ID.String())
+	assert.Equal(t, 80.0, localBid.PriceScore)
+	assert.Equal(t, 100.0, localBid.DeliveryScore)
+	assert.Equal(t, 90.0, localBid.VendorPoints)
+	// 0.6*80 + 0.2*100 + 0.2*90 against 0.6*100 + 0.2*8/31*100 + 0.2*60
+	assert.Equal(t, 86.0, localBid.Score)
+	assert.Equal(t, 77.16, importedBid.Score)
+	assert.Equal(t, local.ID.String(), fabric.Recommended().ResponseID)
+
+	// With price alone the cheaper import wins
+	cmp, err = svc.CompareBids(ctx, rfq.ID.String(), &entities.BidWeights{Price: 1})
+	require.NoError(t, err)
+	assert.Equal(t, imported.ID.String(), cmp.Items[0].Recommended().ResponseID)
+
+	_, err = svc.CompareBids(ctx, rfq.ID.String(), &entities.BidWeights{})
+	assert.ErrorIs(t, err, entities.ErrInvalidBidWeights)
+	repo.AssertExpectations(t)
+	rates.AssertExpectations(t)
+	scores.AssertExpectations(t)
+}
+
+func TestCompareBids_FailsWithoutExchangeRate(t *testing.T) {
+	repo := new(MockRFQRepository)
+	rates := new(MockExchangeRateSource)
+	svc := NewRFQService(repo)
+	svc.SetExchangeRateSource(rates)
+	ctx := context.Background()
+	rfq, _, _ := newBidRFQ()
+
+	repo.On("GetByID", ctx, rfq.ID.String()).Return(rfq, nil).Once()
+	rates.On("GetLatestRates").Return([]accounting_entities.ExchangeRateData{{Currency: "EUR", MiddleRate: 17500}}, nil).Once()
+
+	_, err := svc.CompareBids(ctx, rfq.ID.String(), nil)
+	assert.ErrorIs(t, err, entities.ErrNoExchangeRate)
+	repo.AssertExpectations(t)
+	rates.AssertExpectations(t)
+}
+
+func TestAward_PerItemRaisesOnePOPerSupplier(t *testing.T) {
+	repo := new(MockRFQRepository)
+	orders := new(MockPurchaseOrderRepository)
+	rates := new(MockExchangeRateSource)
+	svc := NewRFQService(repo)
+	svc.SetPurchaseOrderRepository(orders)
+	svc.SetExchangeRateSource(rates)
+	ctx := context.Background()
+	rfq, local, imported := newBidRFQ()
+	var awards []*entities.RFQAward
+
+	repo.On("GetByID", ctx, rfq.ID.String()).Return(rfq, nil).Times(3)
+	rates.On("GetLatestRates").Return(usdRates(), nil).Once()
+	orders.On("GetNextPONumber", ctx).Return("PO-001", nil).Once()
+	orders.On("GetNextPONumber", ctx).Return("PO-002", nil).Once()
+	orders.On("Create", ctx, mock.AnythingOfType("*entities.PurchaseOrder")).Return(nil).Twice()
+	repo.On("SaveAwards", ctx, rfq.ID.String(), mock.AnythingOfType("[]*entities.RFQAward")).Return(nil).
+		Run(savedAwardsOf(rfq, &awards)).Once()
+
+	result, err := svc.Award(ctx, rfq.ID.String(), &entities.RFQAwardRequest{
+		Items: []entities.RFQItemAwardInput{
+			{RFQItemID: rfq.Items[0].ID.String(), ResponseID: imported.ID.String()},
+			{RFQItemID: rfq.Items[1].ID.String(), ResponseID: local.ID.String()},
+		},
+		DeliveryAddress: "Gudang Bandung",
+		PaymentTerms:    "NET30",
+	}, uuid.New().String())
+	require.NoError(t, err)
+
+	require.Len(t, result.PurchaseOrders, 2)
+	importPO, localPO := result.PurchaseOrders[0], result.PurchaseOrders[1]
+	assert.Equal(t, imported.SupplierID, importPO.SupplierID.String())
+	assert.Equal(t, "USD", importPO.Currency)
+	require.Len(t, importPO.Items, 1)
+	assert.Equal(t, 2.5, importPO.Items[0].UnitPrice)
+	assert.Equal(t, 250.0, importPO.TotalAmount)
+	assert.Equal(t, 500000.0, localPO.TotalAmount)
+	assert.Equal(t, "Gudang Bandung", localPO.DeliveryAddress)
+	require.NotNil(t, importPO.ExpectedDeliveryDate)
+	assert.Equal(t, importPO.OrderDate.AddDate(0, 0, 30), *importPO.ExpectedDeliveryDate)
+
+	assert.Equal(t, "PO-001", importPO.PONumber)
+	assert.Equal(t, "closed", result.RFQ.Status)
+
+	require.Len(t, awards, 2)
+	assert.Equal(t, importPO.ID.String(), awards[0].PurchaseOrderID)
+	assert.Equal(t, "PO-001", awards[0].PONumber)
+	assert.Equal(t, "PO-002", awards[1].PONumber)
+
+	_, err = svc.Award(ctx, rfq.ID.String(), &entities.RFQAwardRequest{}, uuid.New().String())
+	assert.ErrorIs(t, err, entities.ErrRFQNotAwardable)
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+	rates.AssertExpectations(t)
+}
+
+func TestAward_WholeRFQToOneResponse(t *testing.T) {
+	repo := new(MockRFQRepository)
+	orders := new(MockPurchaseOrderRepository)
+	rates := new(MockExchangeRateSource)
+	svc := NewRFQService(repo)
+	svc.SetPurchaseOrderRepository(orders)
+	svc.SetExchangeRateSource(rates)
+	ctx := context.Background()
+	rfq, local, _ := newBidRFQ()
+	var awards []*entities.RFQAward
+
+	repo.On("GetByID", ctx, rfq.ID.String()).Return(rfq, nil).Twice()
+	rates.On("GetLatestRates").Return(usdRates(), nil).Once()
+	orders.On("GetNextPONumber", ctx).Return("PO-001", nil).Once()
+	orders.On("Create", ctx, mock.AnythingOfType("*entities.PurchaseOrder")).Return(nil).Once()
+	repo.On("SaveAwards", ctx, rfq.ID.String(), mock.AnythingOfType("[]*entities.RFQAward")).Return(nil).
+		Run(savedAwardsOf(rfq, &awards)).Once()
+
+	result, err := svc.Award(ctx, rfq.ID.String(), &entities.RFQAwardRequest{ResponseID: local.ID.String()}, uuid.New().String())
+	require.NoError(t, err)
+	assert.Len(t, awards, 2)
+	require.Len(t, result.PurchaseOrders, 1)
+	assert.Len(t, result.PurchaseOrders[0].Items, 2)
+	assert.Equal(t, 5500000.0, result.PurchaseOrders[0].TotalAmount)
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+	rates.AssertExpectations(t)
+}
+
+func TestAward_EveryItemToItsBestBid(t *testing.T) {
+	repo := new(MockRFQRepository)
+	orders := new(MockPurchaseOrderRepository)
+	rates := new(MockExchangeRateSource)
+	svc := NewRFQService(repo)
+	svc.SetPurchaseOrderRepository(orders)
+	svc.SetExchangeRateSource(rates)
+	ctx := context.Background()
+	rfq, local, imported := newBidRFQ()
+	var awards []*entities.RFQAward
+
+	repo.On("GetByID", ctx, rfq.ID.String()).Return(rfq, nil).Twice()
+	rates.On("GetLatestRates").Return(usdRates(), nil).Once()
+	orders.On("GetNextPONumber", ctx).Return("PO-001", nil).Once()
+	orders.On("GetNextPONumber", ctx).Return("PO-002", nil).Once()
+	orders.On("Create", ctx, mock.AnythingOfType("*entities.PurchaseOrder")).Return(nil).Twice()
+	repo.On("SaveAwards", ctx, rfq.ID.String(), mock.AnythingOfType("[]*entities.RFQAward")).Return(nil).
+		Run(savedAwardsOf(rfq, &awards)).Once()
+
+	// On price alone the imported fabric (IDR 40,000/m) and the local buttons (IDR 500
+	// against 800)
+	result, err := svc.Award(ctx, rfq.ID.String(), &entities.RFQAwardRequest{Weights: &entities.BidWeights{Price: 1}}, uuid.New().String())
+	require.NoError(t, err)
+	assert.Len(t, result.PurchaseOrders, 2)
+	require.Len(t, awards, 2)
+	assert.Equal(t, imported.ID.String(), awards[0].RFQResponseID)
+	assert.Equal(t, local.ID.String(), awards[1].RFQResponseID)
+	assert.Equal(t, 100.0, awards[1].Score)
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+	rates.AssertExpectations(t)
+}
+
+func TestAward_RejectsAResponseAndItemsTogether(t *testing.T) {
+	repo := new(MockRFQRepository)
+	orders := new(MockPurchaseOrderRepository)
+	rates := new(MockExchangeRateSource)
+	svc := NewRFQService(repo)
+	svc.SetPurchaseOrderRepository(orders)
+	svc.SetExchangeRateSource(rates)
+	ctx := context.Background()
+	rfq, local, _ := newBidRFQ()
+
+	repo.On("GetByID", ctx, rfq.ID.String()).Return(rfq, nil).Once()
+	rates.On("GetLatestRates").Return(usdRates(), nil).Once()
+
+	_, err := svc.Award(ctx, rfq.ID.String(), &entities.RFQAwardRequest{
+		ResponseID: local.ID.String(),
+		Items:      []entities.RFQItemAwardInput{{RFQItemID: rfq.Items[0].ID.String(), ResponseID: local.ID.String()}},
+	}, uuid.New().String())
+	assert.ErrorIs(t, err, entities.ErrInvalidRFQAward)
+	repo.AssertExpectations(t)
+	rates.AssertExpectations(t)
+	orders.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "SaveAwards", mock.Anything, mock.Anything, mock.Anything)
+}
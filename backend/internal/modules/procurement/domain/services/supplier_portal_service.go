@@ -0,0 +1,607 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/procurement/domain/entities"
+	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/integration"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// SupplierPortalService handles the supplier portal: the accounts suppliers log in with and
+// what they do there. Portal methods take the supplier of the signed-in account and only
+// ever reach that supplier's data; records of other suppliers read as not found.
+type SupplierPortalService struct {
+	repo      repositories.SupplierPortalRepository
+	orders    PortalPurchaseOrders
+	rfqs      PortalRFQs
+	passwords PasswordValidator                    // Optional: password policy for new supplier users
+	notifier  SupplierNotifier                     // Optional: notifies supplier users of new orders
+	invoices  integration.SupplierInvoiceRegistrar // Optional: books supplier invoices in Finance
+	sessions  UserRevoker                          // Optional: ends the sessions of disabled supplier users
+}
+
+// PortalPurchaseOrders drives the purchase orders suppliers act on
+type PortalPurchaseOrders interface {
+	GetByID(ctx context.Context, id string) (*entities.PurchaseOrder, error)
+	Confirm(ctx context.Context, id string) (*entities.PurchaseOrder, error)
+	Ship(ctx context.Context, id string) (*entities.PurchaseOrder, error)
+}
+
+// PortalRFQs reads RFQs and records supplier responses
+type PortalRFQs interface {
+	GetByID(ctx context.Context, id string) (*entities.RFQ, error)
+	SubmitResponse(ctx context.Context, response *entities.RFQResponse) error
+}
+
+// PasswordValidator checks a password against the password policy
+type PasswordValidator interface {
+	ValidatePassword(ctx context.Context, password string, personalInfo ...string) error
+}
+
+// SupplierNotifier notifies a supplier user of a purchase order sent to them
+type SupplierNotifier interface {
+	NotifyNewPurchaseOrder(ctx context.Context, vendorUserID uuid.ID, poNumber, buyerName string) error
+}
+
+// UserRevoker ends every session of a disabled user and rejects the tokens issued to them
+type UserRevoker interface {
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+// NewSupplierPortalService creates a new supplier portal service
+func NewSupplierPortalService(repo repositories.SupplierPortalRepository, orders PortalPurchaseOrders, rfqs PortalRFQs) *SupplierPortalService {
+	return &SupplierPortalService{repo: repo, orders: orders, rfqs: rfqs}
+}
+
+// SetPasswordValidator sets the password policy for new supplier users
+func (s *SupplierPortalService) SetPasswordValidator(passwords PasswordValidator) {
+	s.passwords = passwords
+}
+
+// SetNotifier sets the notifier for purchase orders sent to suppliers
+func (s *SupplierPortalService) SetNotifier(notifier SupplierNotifier) {
+	s.notifier = notifier
+}
+
+// SetInvoiceRegistrar sets the registrar that books supplier invoices in Finance
+func (s *SupplierPortalService) SetInvoiceRegistrar(invoices integration.SupplierInvoiceRegistrar) {
+	s.invoices = invoices
+}
+
+// SetUserRevoker sets the revoker that signs disabled supplier users out
+func (s *SupplierPortalService) SetUserRevoker(sessions UserRevoker) {
+	s.sessions = sessions
+}
+
+// CreateAccount creates a supplier user with the Supplier role and links it to the supplier.
+func (s *SupplierPortalService) CreateAccount(ctx context.Context, supplierID string, user *entities.NewSupplierUser, createdBy string) (*entities.SupplierPortalAccount, error) {
+	if supplierID == "" {
+		return nil, fmt.Errorf("%w: supplier is required", entities.ErrInvalidSupplierAccount)
+	}
+	user.Username = strings.TrimSpace(user.Username)
+	user.Email = strings.TrimSpace(user.Email)
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+	if s.passwords != nil {
+		if err := s.passwords.ValidatePassword(ctx, user.Password, user.Username, user.Email); err != nil {
+			return nil, fmt.Errorf("%w: %v", entities.ErrInvalidSupplierAccount, err)
+		}
+	}
+	hashed, err := utils.HashPassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hashed
+
+	now := time.Now()
+	account := &entities.SupplierPortalAccount{
+		ID:         uuid.New().String(),
+		SupplierID: supplierID,
+		Status:     entities.SupplierAccountActive,
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateAccount(ctx, user, account); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAccount(ctx, account.ID)
+}
+
+// ListAccounts lists the portal accounts, of one supplier when given.
+func (s *SupplierPortalService) ListAccounts(ctx context.Context, supplierID string) ([]*entities.SupplierPortalAccount, error) {
+	return s.repo.ListAccounts(ctx, supplierID)
+}
+
+// SetAccountStatus enables or disables a portal account. A disabled account can no longer
+// log in or use the portal, and its user is signed out of the sessions already open.
+func (s *SupplierPortalService) SetAccountStatus(ctx context.Context, id, status string) (*entities.SupplierPortalAccount, error) {
+	if status != entities.SupplierAccountActive && status != entities.SupplierAccountDisabled {
+		return nil, fmt.Errorf("%w: status must be active or disabled", entities.ErrInvalidSupplierAccount)
+	}
+	if err := s.repo.SetAccountStatus(ctx, id, status); err != nil {
+		return nil, err
+	}
+	account, err := s.repo.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status == entities.SupplierAccountDisabled && s.sessions != nil && account != nil {
+		if err := s.sessions.RevokeUser(ctx, account.UserID); err != nil {
+			return nil, fmt.Errorf("failed to end the sessions of %s: %w", account.Username, err)
+		}
+	}
+	return account, nil
+}
+
+// ResolveAccount returns the active portal account of a user, which scopes everything
+// they do in the portal.
+func (s *SupplierPortalService) ResolveAccount(ctx context.Context, userID string) (*entities.SupplierPortalAccount, error) {
+	account, err := s.repo.GetActiveAccountByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, entities.ErrNoSupplierAccount
+	}
+	return account, nil
+}
+
+// NotifyPurchaseOrderSent notifies the users of the supplier of a purchase order that it
+// has been sent to them.
+func (s *SupplierPortalService) NotifyPurchaseOrderSent(ctx context.Context, order *entities.PurchaseOrder) error {
+	if s.notifier == nil {
+		return nil
+	}
+	userIDs, err := s.repo.ListActiveUserIDs(ctx, order.SupplierID.String())
+	if err != nil {
+		return err
+	}
+	for _, id := range userIDs {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		if err := s.notifier.NotifyNewPurchaseOrder(ctx, userID, order.PONumber, order.CreatedByName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPurchaseOrders lists the purchase orders sent to the supplier.
+func (s *SupplierPortalService) ListPurchaseOrders(ctx context.Context, supplierID, status string) ([]*entities.PortalPurchaseOrder, error) {
+	return s.repo.ListPurchaseOrders(ctx, supplierID, status)
+}
+
+// GetPurchaseOrder retrieves a purchase order of the supplier with its acknowledgement.
+func (s *SupplierPortalService) GetPurchaseOrder(ctx context.Context, supplierID, id string) (*entities.PortalPurchaseOrderDetail, error) {
+	order, err := s.supplierOrder(ctx, supplierID, id)
+	if err != nil {
+		return nil, err
+	}
+	ack, err := s.repo.GetAcknowledgement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.PortalPurchaseOrderDetail{PurchaseOrder: order, Acknowledgement: ack}, nil
+}
+
+// AcknowledgePurchaseOrder records that the supplier has received a purchase order,
+// without committing to it yet.
+func (s *SupplierPortalService) AcknowledgePurchaseOrder(ctx context.Context, supplierID, id, userID, note string) (*entities.PortalPurchaseOrderDetail, error) {
+	order, err := s.supplierOrder(ctx, supplierID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == entities.PurchaseOrderStatusCancelled {
+		return nil, fmt.Errorf("%w: purchase order %s is cancelled", entities.ErrInvalidOrderAction, order.PONumber)
+	}
+	if err := s.acknowledge(ctx, supplierID, id, userID, note); err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(ctx, supplierID, id)
+}
+
+// ConfirmPurchaseOrder confirms a sent purchase order on behalf of the supplier, which also
+// acknowledges it.
+func (s *SupplierPortalService) ConfirmPurchaseOrder(ctx context.Context, supplierID, id, userID string) (*entities.PortalPurchaseOrderDetail, error) {
+	order, err := s.supplierOrder(ctx, supplierID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != entities.PurchaseOrderStatusSent {
+		return nil, fmt.Errorf("%w: purchase order %s is %s, only sent orders can be confirmed", entities.ErrInvalidOrderAction, order.PONumber, order.Status)
+	}
+	if _, err := s.orders.Confirm(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.acknowledge(ctx, supplierID, id, userID, ""); err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(ctx, supplierID, id)
+}
+
+func (s *SupplierPortalService) acknowledge(ctx context.Context, supplierID, id, userID, note string) error {
+	return s.repo.SaveAcknowledgement(ctx, &entities.PurchaseOrderAcknowledgement{
+		PurchaseOrderID: id,
+		SupplierID:      supplierID,
+		AcknowledgedBy:  userID,
+		AcknowledgedAt:  time.Now(),
+		Note:            strings.TrimSpace(note),
+	})
+}
+
+// supplierOrder retrieves a purchase order that was sent to the supplier. Orders of other
+// suppliers, and orders not sent yet, are not found.
+func (s *SupplierPortalService) supplierOrder(ctx context.Context, supplierID, id string) (*entities.PurchaseOrder, error) {
+	order, err := s.orders.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.SupplierID.String() != supplierID || !order.VisibleToSupplier() {
+		return nil, entities.ErrPurchaseOrderNotFound
+	}
+	return order, nil
+}
+
+// SubmitShippingNotice records an advance shipping notice against a purchase order of the
+// supplier. The first notice of a confirmed order marks the order shipped.
+func (s *SupplierPortalService) SubmitShippingNotice(ctx context.Context, supplierID, userID string, notice *entities.AdvanceShippingNotice) error {
+	order, err := s.supplierOrder(ctx, supplierID, notice.PurchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if err := notice.Prepare(order); err != nil {
+		return err
+	}
+
+	number, err := s.repo.GetNextASNNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate ASN number: %w", err)
+	}
+	now := time.Now()
+	notice.ID = uuid.New().String()
+	notice.ASNNumber = number
+	notice.SupplierID = supplierID
+	notice.Status = entities.ShippingNoticeSubmitted
+	notice.GoodsReceiptID = nil
+	notice.SubmittedBy = nil
+	if userID != "" {
+		notice.SubmittedBy = &userID
+	}
+	notice.CreatedAt, notice.UpdatedAt = now, now
+	for _, item := range notice.Items {
+		item.ID = uuid.New().String()
+		item.ASNID = notice.ID
+	}
+	if err := s.repo.CreateShippingNotice(ctx, notice); err != nil {
+		return err
+	}
+
+	if order.Status == entities.PurchaseOrderStatusConfirmed {
+		if _, err := s.orders.Ship(ctx, order.ID.String()); err != nil {
+			log.Printf("Warning: failed to mark purchase order %s shipped for %s: %v", order.PONumber, notice.ASNNumber, err)
+		}
+	}
+	return nil
+}
+
+// ListShippingNotices lists advance shipping notices. Portal callers always pass their
+// supplier in the filter.
+func (s *SupplierPortalService) ListShippingNotices(ctx context.Context, filter repositories.ShippingNoticeFilter) ([]*entities.AdvanceShippingNotice, error) {
+	return s.repo.ListShippingNotices(ctx, filter)
+}
+
+// GetSupplierShippingNotice retrieves an advance shipping notice of the supplier.
+func (s *SupplierPortalService) GetSupplierShippingNotice(ctx context.Context, supplierID, id string) (*entities.AdvanceShippingNotice, error) {
+	notice, err := s.GetShippingNotice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if notice.SupplierID != supplierID {
+		return nil, entities.ErrShippingNoticeNotFound
+	}
+	return notice, nil
+}
+
+// GetShippingNotice retrieves an advance shipping notice.
+func (s *SupplierPortalService) GetShippingNotice(ctx context.Context, id string) (*entities.AdvanceShippingNotice, error) {
+	notice, err := s.repo.GetShippingNotice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if notice == nil {
+		return nil, entities.ErrShippingNoticeNotFound
+	}
+	return notice, nil
+}
+
+// ReceiveShippingNotice creates a draft goods receipt in a warehouse pre-filled with the
+// lines of a submitted notice, for the warehouse to check and post.
+func (s *SupplierPortalService) ReceiveShippingNotice(ctx context.Context, id, warehouseID, receivedBy string) (*entities.AdvanceShippingNotice, error) {
+	if warehouseID == "" {
+		return nil, fmt.Errorf("%w: warehouse is required", entities.ErrInvalidShippingNotice)
+	}
+	_, err := s.repo.ReceiveShippingNotice(ctx, repositories.ShippingNoticeReceipt{
+		ASNID:       id,
+		WarehouseID: warehouseID,
+		ReceivedBy:  receivedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetShippingNotice(ctx, id)
+}
+
+// ListRFQs lists the RFQs the supplier is invited to.
+func (s *SupplierPortalService) ListRFQs(ctx context.Context, supplierID string) ([]*entities.PortalRFQ, error) {
+	return s.repo.ListInvitedRFQs(ctx, supplierID)
+}
+
+// GetRFQ retrieves an RFQ the supplier is invited to, without the invitations and
+// responses of other suppliers.
+func (s *SupplierPortalService) GetRFQ(ctx context.Context, supplierID, rfqID string) (*entities.RFQ, error) {
+	rfq, err := s.invitedRFQ(ctx, supplierID, rfqID)
+	if err != nil {
+		return nil, err
+	}
+	return rfq.ForSupplier(supplierID), nil
+}
+
+// RespondToRFQ submits the supplier's response to an RFQ it is invited to. Lines are
+// priced per RFQ item; the total defaults to the sum of the lines.
+func (s *SupplierPortalService) RespondToRFQ(ctx context.Context, supplierID, rfqID string, response *entities.RFQResponse) (*entities.RFQResponse, error) {
+	rfq, err := s.invitedRFQ(ctx, supplierID, rfqID)
+	if err != nil {
+		return nil, err
+	}
+	if rfq.Status != "published" {
+		return nil, fmt.Errorf("%w: RFQ %s is %s and no longer takes responses", entities.ErrInvalidRFQResponse, rfq.RFQNumber, rfq.Status)
+	}
+	for _, response := range rfq.Responses {
+		if response.SupplierID == supplierID {
+			return nil, fmt.Errorf("%w: a response to RFQ %s was already submitted", entities.ErrInvalidRFQResponse, rfq.RFQNumber)
+		}
+	}
+
+	items := make(map[string]*entities.RFQItem, len(rfq.Items))
+	for _, item := range rfq.Items {
+		items[item.ID.String()] = item
+	}
+	var total float64
+	seen := map[string]bool{}
+	for _, line := range response.ResponseItems {
+		item, ok := items[line.RFQItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: item %s is not on RFQ %s", entities.ErrInvalidRFQResponse, line.RFQItemID, rfq.RFQNumber)
+		}
+		if seen[line.RFQItemID] {
+			return nil, fmt.Errorf("%w: %s is priced twice", entities.ErrInvalidRFQResponse, item.ItemName)
+		}
+		seen[line.RFQItemID] = true
+		if line.UnitPrice < 0 {
+			return nil, fmt.Errorf("%w: %s needs a unit price of at least 0", entities.ErrInvalidRFQResponse, item.ItemName)
+		}
+		if line.TotalPrice == 0 {
+			line.TotalPrice = line.UnitPrice * float64(item.Quantity)
+		}
+		total += line.TotalPrice
+	}
+	if response.TotalAmount == 0 {
+		response.TotalAmount = total
+	}
+	if response.TotalAmount <= 0 {
+		return nil, fmt.Errorf("%w: total amount must be above 0", entities.ErrInvalidRFQResponse)
+	}
+
+	response.RFQID = rfqID
+	response.SupplierID = supplierID
+	if err := s.rfqs.SubmitResponse(ctx, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// invitedRFQ retrieves an RFQ the supplier is invited to. RFQs it is not invited to, and
+// drafts, are not found.
+func (s *SupplierPortalService) invitedRFQ(ctx context.Context, supplierID, rfqID string) (*entities.RFQ, error) {
+	invitation, err := s.repo.GetRFQInvitation(ctx, rfqID, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, entities.ErrRFQNotFound
+	}
+	rfq, err := s.rfqs.GetByID(ctx, rfqID)
+	if err != nil {
+		return nil, err
+	}
+	if rfq == nil || rfq.Status == "draft" {
+		return nil, entities.ErrRFQNotFound
+	}
+	return rfq, nil
+}
+
+// SubmitInvoice records an invoice of the supplier against one of its confirmed purchase
+// orders, for Finance to review. Invoice numbers are unique per supplier.
+func (s *SupplierPortalService) SubmitInvoice(ctx context.Context, supplierID, userID string, invoice *entities.SupplierInvoice) error {
+	order, err := s.supplierOrder(ctx, supplierID, invoice.PurchaseOrderID)
+	if err != nil {
+		return err
+	}
+	if err := invoice.Prepare(order); err != nil {
+		return err
+	}
+	existing, err := s.repo.GetInvoiceByNumber(ctx, supplierID, invoice.InvoiceNumber)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: invoice %s was already submitted", entities.ErrInvalidSupplierInvoice, invoice.InvoiceNumber)
+	}
+
+	now := time.Now()
+	invoice.ID = uuid.New().String()
+	invoice.SupplierID = supplierID
+	invoice.Status = entities.SupplierInvoiceSubmitted
+	invoice.PurchaseVoucherID = nil
+	invoice.SubmittedBy = nil
+	if userID != "" {
+		invoice.SubmittedBy = &userID
+	}
+	invoice.CreatedAt, invoice.UpdatedAt = now, now
+	for _, line := range invoice.Lines {
+		line.ID = uuid.New().String()
+		line.SupplierInvoiceID = invoice.ID
+	}
+	return s.repo.CreateInvoice(ctx, invoice)
+}
+
+// AttachInvoiceDocument sets the uploaded document of an invoice of the supplier while it
+// awaits review.
+func (s *SupplierPortalService) AttachInvoiceDocument(ctx context.Context, supplierID, id, key, name string) (*entities.SupplierInvoice, error) {
+	invoice, err := s.GetSupplierInvoice(ctx, supplierID, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != entities.SupplierInvoiceSubmitted {
+		return nil, fmt.Errorf("%w: invoice %s has been reviewed", entities.ErrInvalidSupplierInvoice, invoice.InvoiceNumber)
+	}
+	if err := s.repo.SetInvoiceAttachment(ctx, id, key, name); err != nil {
+		return nil, err
+	}
+	return s.GetInvoice(ctx, id)
+}
+
+// ListInvoices lists supplier invoices. Portal callers always pass their supplier in the filter.
+func (s *SupplierPortalService) ListInvoices(ctx context.Context, filter repositories.SupplierInvoiceFilter) ([]*entities.SupplierInvoice, error) {
+	return s.repo.ListInvoices(ctx, filter)
+}
+
+// GetSupplierInvoice retrieves an invoice of the supplier.
+func (s *SupplierPortalService) GetSupplierInvoice(ctx context.Context, supplierID, id string) (*entities.SupplierInvoice, error) {
+	invoice, err := s.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.SupplierID != supplierID {
+		return nil, entities.ErrSupplierInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// GetInvoice retrieves a supplier invoice.
+func (s *SupplierPortalService) GetInvoice(ctx context.Context, id string) (*entities.SupplierInvoice, error) {
+	invoice, err := s.repo.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, entities.ErrSupplierInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// RegisterInvoice books a submitted invoice in Finance as a purchase voucher matched
+// against its purchase order.
+func (s *SupplierPortalService) RegisterInvoice(ctx context.Context, id, reviewedBy string) (*entities.SupplierInvoice, error) {
+	if s.invoices == nil {
+		return nil, fmt.Errorf("invoice registration is not configured")
+	}
+	invoice, err := s.reviewableInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dto := &integration.SupplierInvoiceDTO{
+		ID:              invoice.ID,
+		SupplierID:      invoice.SupplierID,
+		PurchaseOrderID: invoice.PurchaseOrderID,
+		InvoiceNumber:   invoice.InvoiceNumber,
+		InvoiceDate:     invoice.InvoiceDate,
+		DueDate:         invoice.DueDate,
+		Currency:        invoice.Currency,
+		Subtotal:        invoice.Subtotal,
+		TaxAmount:       invoice.TaxAmount,
+		TotalAmount:     invoice.TotalAmount,
+	}
+	for _, line := range invoice.Lines {
+		dto.Lines = append(dto.Lines, integration.SupplierInvoiceLineDTO{
+			POItemID:  line.POItemID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		})
+	}
+	voucherID, err := s.invoices.RegisterSupplierInvoice(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.Status = entities.SupplierInvoiceRegistered
+	invoice.PurchaseVoucherID = &voucherID
+	if err := s.saveReview(ctx, invoice, reviewedBy); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// RejectInvoice rejects a submitted invoice with the reason shown to the supplier.
+func (s *SupplierPortalService) RejectInvoice(ctx context.Context, id, reviewedBy, reason string) (*entities.SupplierInvoice, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required", entities.ErrInvalidSupplierInvoice)
+	}
+	invoice, err := s.reviewableInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	invoice.Status = entities.SupplierInvoiceRejected
+	invoice.RejectionReason = reason
+	if err := s.saveReview(ctx, invoice, reviewedBy); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (s *SupplierPortalService) reviewableInvoice(ctx context.Context, id string) (*entities.SupplierInvoice, error) {
+	invoice, err := s.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != entities.SupplierInvoiceSubmitted {
+		return nil, fmt.Errorf("%w: invoice %s is already %s", entities.ErrInvalidSupplierInvoice, invoice.InvoiceNumber, invoice.Status)
+	}
+	return invoice, nil
+}
+
+func (s *SupplierPortalService) saveReview(ctx context.Context, invoice *entities.SupplierInvoice, reviewedBy string) error {
+	now := time.Now()
+	invoice.ReviewedBy = nil
+	if reviewedBy != "" {
+		invoice.ReviewedBy = &reviewedBy
+	}
+	invoice.ReviewedAt = &now
+	invoice.UpdatedAt = now
+	return s.repo.UpdateInvoiceReview(ctx, invoice)
+}
+
+// GetPaymentStatus lists the invoices of the supplier with where their payment stands.
+func (s *SupplierPortalService) GetPaymentStatus(ctx context.Context, supplierID string) ([]*entities.SupplierPaymentStatus, error) {
+	statuses, err := s.repo.ListPaymentStatus(ctx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		status.Derive()
+	}
+	return statuses, nil
+}
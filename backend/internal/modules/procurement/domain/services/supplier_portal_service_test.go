@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/procurement/domain/entities"
+	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// MockSupplierPortalRepository is a mock implementation of repositories.SupplierPortalRepository.
+type MockSupplierPortalRepository struct {
+	mock.Mock
+}
+
+func (m *MockSupplierPortalRepository) CreateAccount(ctx context.Context, user *entities.NewSupplierUser, account *entities.SupplierPortalAccount) error {
+	args := m.Called(ctx, user, account)
+	return args.Error(0)
+}
+
+func (m *MockSupplierPortalRepository) GetAccount(ctx context.Context, id string) (*entities.SupplierPortalAccount, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SupplierPortalAccount), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) GetActiveAccountByUser(ctx context.Context, userID string) (*entities.SupplierPortalAccount, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SupplierPortalAccount), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) ListAccounts(ctx context.Context, supplierID string) ([]*entities.SupplierPortalAccount, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]*entities.SupplierPortalAccount), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) SetAccountStatus(ctx context.Context, id, status string) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockSupplierPortalRepository) ListActiveUserIDs(ctx context.Context, supplierID string) ([]string, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) ListPurchaseOrders(ctx context.Context, supplierID, status string) ([]*entities.PortalPurchaseOrder, error) {
+	args := m.Called(ctx, supplierID, status)
+	return args.Get(0).([]*entities.PortalPurchaseOrder), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) GetAcknowledgement(ctx context.Context, purchaseOrderID string) (*entities.PurchaseOrderAcknowledgement, error) {
+	args := m.Called(ctx, purchaseOrderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseOrderAcknowledgement), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) SaveAcknowledgement(ctx context.Context, ack *entities.PurchaseOrderAcknowledgement) error {
+	args := m.Called(ctx, ack)
+	return args.Error(0)
+}
+
+func (m *MockSupplierPortalRepository) GetNextASNNumber(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) CreateShippingNotice(ctx context.Context, notice *entities.AdvanceShippingNotice) error {
+	args := m.Called(ctx, notice)
+	return args.Error(0)
+}
+
+func (m *MockSupplierPortalRepository) GetShippingNotice(ctx context.Context, id string) (*entities.AdvanceShippingNotice, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.AdvanceShippingNotice), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) ListShippingNotices(ctx context.Context, filter repositories.ShippingNoticeFilter) ([]*entities.AdvanceShippingNotice, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.AdvanceShippingNotice), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) ReceiveShippingNotice(ctx context.Context, receipt repositories.ShippingNoticeReceipt) (string, error) {
+	args := m.Called(ctx, receipt)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) ListInvitedRFQs(ctx context.Context, supplierID string) ([]*entities.PortalRFQ, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]*entities.PortalRFQ), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) GetRFQInvitation(ctx context.Context, rfqID, supplierID string) (*entities.RFQSupplier, error) {
+	args := m.Called(ctx, rfqID, supplierID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RFQSupplier), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) CreateInvoice(ctx context.Context, invoice *entities.SupplierInvoice) error {
+	args := m.Called(ctx, invoice)
+	return args.Error(0)
+}
+
+func (m *MockSupplierPortalRepository) GetInvoice(ctx context.Context, id string) (*entities.SupplierInvoice, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SupplierInvoice), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) GetInvoiceByNumber(ctx context.Context, supplierID, invoiceNumber string) (*entities.SupplierInvoice, error) {
+	args := m.Called(ctx, supplierID, invoiceNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SupplierInvoice), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) ListInvoices(ctx context.Context, filter repositories.SupplierInvoiceFilter) ([]*entities.SupplierInvoice, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.SupplierInvoice), args.Error(1)
+}
+
+func (m *MockSupplierPortalRepository) SetInvoiceAttachment(ctx context.Context, id, key, name string) error {
+	args := m.Called(ctx, id, key, name)
+	return args.Error(0)
+}
+
+func (m *MockSupplierPortalRepository) UpdateInvoiceReview(ctx context.Context, invoice *entities.SupplierInvoice) error {
+	args := m.Called(ctx, invoice)
+	return args.Error(0)
+}
+
+func (m *MockSupplierPortalRepository) ListPaymentStatus(ctx context.Context, supplierID string) ([]*entities.SupplierPaymentStatus, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]*entities.SupplierPaymentStatus), args.Error(1)
+}
+
+// MockPortalPurchaseOrders is a mock implementation of PortalPurchaseOrders.
+type MockPortalPurchaseOrders struct {
+	mock.Mock
+}
+
+func (m *MockPortalPurchaseOrders) GetByID(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPortalPurchaseOrders) Confirm(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPortalPurchaseOrders) Ship(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PurchaseOrder), args.Error(1)
+}
+
+// movesTo has the order moved to the status given.
+func movesTo(order *entities.PurchaseOrder, status entities.PurchaseOrderStatus) func(mock.Arguments) {
+	return func(mock.Arguments) {
+		order.Status = status
+	}
+}
+
+// MockPortalRFQs is a mock implementation of PortalRFQs.
+type MockPortalRFQs struct {
+	mock.Mock
+}
+
+func (m *MockPortalRFQs) GetByID(ctx context.Context, id string) (*entities.RFQ, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RFQ), args.Error(1)
+}
+
+func (m *MockPortalRFQs) SubmitResponse(ctx context.Context, response *entities.RFQResponse) error {
+	args := m.Called(ctx, response)
+	return args.Error(0)
+}
+
+func newPortalOrder(supplierID uuid.ID, status entities.PurchaseOrderStatus) *entities.PurchaseOrder {
+	return &entities.PurchaseOrder{
+		ID:         uuid.New(),
+		PONumber:   "PO-2026-0001",
+		SupplierID: supplierID,
+		Status:     status,
+		Items: []entities.PurchaseOrderItem{
+			{ID: uuid.New(), ItemName: "Leather", Unit: "sqft", Quantity: 100, ReceivedQuantity: 40, UnitPrice: 25000},
+		},
+	}
+}
+
+func TestSupplierPortalService_PurchaseOrdersAreIsolated(t *testing.T) {
+	ctx := context.Background()
+	supplierA, supplierB := uuid.New(), uuid.New()
+	sent := newPortalOrder(supplierA, entities.PurchaseOrderStatusSent)
+	draft := newPortalOrder(supplierA, entities.PurchaseOrderStatusDraft)
+	orders := new(MockPortalPurchaseOrders)
+	repo := new(MockSupplierPortalRepository)
+	svc := NewSupplierPortalService(repo, orders, new(MockPortalRFQs))
+	orders.On("GetByID", ctx, sent.ID.String()).Return(sent, nil).Times(5)
+	orders.On("GetByID", ctx, draft.ID.String()).Return(draft, nil).Once()
+
+	// Another supplier's order and an unsent order read as not found
+	_, err := svc.GetPurchaseOrder(ctx, supplierB.String(), sent.ID.String())
+	assert.ErrorIs(t, err, entities.ErrPurchaseOrderNotFound)
+	_, err = svc.ConfirmPurchaseOrder(ctx, supplierB.String(), sent.ID.String(), "user-b")
+	assert.ErrorIs(t, err, entities.ErrPurchaseOrderNotFound)
+	_, err = svc.GetPurchaseOrder(ctx, supplierA.String(), draft.ID.String())
+	assert.ErrorIs(t, err, entities.ErrPurchaseOrderNotFound)
+	orders.AssertNotCalled(t, "Confirm", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "GetAcknowledgement", mock.Anything, mock.Anything)
+
+	orders.On("Confirm", ctx, sent.ID.String()).Return(sent, nil).Run(movesTo(sent, entities.PurchaseOrderStatusConfirmed)).Once()
+	repo.On("SaveAcknowledgement", ctx, mock.MatchedBy(func(ack *entities.PurchaseOrderAcknowledgement) bool {
+		return ack.PurchaseOrderID == sent.ID.String() && ack.SupplierID == supplierA.String() && ack.AcknowledgedBy == "user-a"
+	})).Return(nil).Once()
+	repo.On("GetAcknowledgement", ctx, sent.ID.String()).Return(&entities.PurchaseOrderAcknowledgement{
+		PurchaseOrderID: sent.ID.String(), SupplierID: supplierA.String(), AcknowledgedBy: "user-a",
+	}, nil).Once()
+	detail, err := svc.ConfirmPurchaseOrder(ctx, supplierA.String(), sent.ID.String(), "user-a")
+	require.NoError(t, err)
+	require.NotNil(t, detail.Acknowledgement)
+	assert.Equal(t, "user-a", detail.Acknowledgement.AcknowledgedBy)
+
+	// A confirmed order cannot be confirmed again
+	_, err = svc.ConfirmPurchaseOrder(ctx, supplierA.String(), sent.ID.String(), "user-a")
+	assert.ErrorIs(t, err, entities.ErrInvalidOrderAction)
+	orders.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestSupplierPortalService_SubmitShippingNotice(t *testing.T) {
+	ctx := context.Background()
+	supplier := uuid.New()
+	order := newPortalOrder(supplier, entities.PurchaseOrderStatusSent)
+	orders := new(MockPortalPurchaseOrders)
+	repo := new(MockSupplierPortalRepository)
+	svc := NewSupplierPortalService(repo, orders, new(MockPortalRFQs))
+	orders.On("GetByID", ctx, order.ID.String()).Return(order, nil).Times(4)
+	notice := func(quantity int) *entities.AdvanceShippingNotice {
+		return &entities.AdvanceShippingNotice{
+			PurchaseOrderID: order.ID.String(),
+			ShipDate:        time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+			Items:           []*entities.ASNItem{{POItemID: order.Items[0].ID.String(), Quantity: quantity}},
+		}
+	}
+
+	// Only confirmed orders ship
+	err := svc.SubmitShippingNotice(ctx, supplier.String(), "user-a", notice(10))
+	assert.ErrorIs(t, err, entities.ErrInvalidShippingNotice)
+
+	order.Status = entities.PurchaseOrderStatusConfirmed
+	// 60 of the 100 ordered are still to be received
+	err = svc.SubmitShippingNotice(ctx, supplier.String(), "user-a", notice(61))
+	assert.ErrorIs(t, err, entities.ErrInvalidShippingNotice)
+	repo.AssertNotCalled(t, "CreateShippingNotice", mock.Anything, mock.Anything)
+
+	repo.On("GetNextASNNumber", ctx).Return("ASN-202610-0001", nil).Once()
+	repo.On("GetNextASNNumber", ctx).Return("ASN-202610-0002", nil).Once()
+	repo.On("CreateShippingNotice", ctx, mock.AnythingOfType("*entities.AdvanceShippingNotice")).Return(nil).Twice()
+	orders.On("Ship", ctx, order.ID.String()).Return(order, nil).Run(movesTo(order, entities.PurchaseOrderStatusShipped)).Once()
+	asn := notice(60)
+	require.NoError(t, svc.SubmitShippingNotice(ctx, supplier.String(), "user-a", asn))
+	assert.Equal(t, "ASN-202610-0001", asn.ASNNumber)
+	assert.Equal(t, entities.ShippingNoticeSubmitted, asn.Status)
+	assert.Equal(t, "Leather", asn.Items[0].ItemName)
+	repo.AssertCalled(t, "CreateShippingNotice", ctx, asn)
+
+	// A second notice against the now shipping order does not ship it again
+	require.NoError(t, svc.SubmitShippingNotice(ctx, supplier.String(), "user-a", notice(5)))
+	orders.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestSupplierPortalService_RFQsAreScopedToTheSupplier(t *testing.T) {
+	ctx := context.Background()
+	itemID := uuid.New()
+	rfq := &entities.RFQ{
+		BaseModel: types.BaseModel{ID: uuid.New()},
+		RFQNumber: "RFQ-2026-0001",
+		Status:    "published",
+		Items:     []*entities.RFQItem{{BaseModel: types.BaseModel{ID: itemID}, ItemName: "Sole", Quantity: 200}},
+		Suppliers: []*entities.RFQSupplier{{SupplierID: "supplier-a"}, {SupplierID: "supplier-b"}},
+		Responses: []*entities.RFQResponse{{SupplierID: "supplier-b", TotalAmount: 1000000}},
+	}
+	rfqID := rfq.ID.String()
+	rfqs := new(MockPortalRFQs)
+	repo := new(MockSupplierPortalRepository)
+	svc := NewSupplierPortalService(repo, new(MockPortalPurchaseOrders), rfqs)
+	// Only the RFQs a supplier was invited to are read
+	repo.On("GetRFQInvitation", ctx, rfqID, "supplier-a").
+		Return(&entities.RFQSupplier{RFQID: rfqID, SupplierID: "supplier-a"}, nil).Twice()
+	repo.On("GetRFQInvitation", ctx, rfqID, "supplier-b").
+		Return(&entities.RFQSupplier{RFQID: rfqID, SupplierID: "supplier-b"}, nil).Once()
+	repo.On("GetRFQInvitation", ctx, rfqID, "supplier-c").Return(nil, nil).Once()
+	rfqs.On("GetByID", ctx, rfqID).Return(rfq, nil).Times(3)
+
+	scoped, err := svc.GetRFQ(ctx, "supplier-a", rfqID)
+	require.NoError(t, err)
+	require.Len(t, scoped.Suppliers, 1)
+	assert.Equal(t, "supplier-a", scoped.Suppliers[0].SupplierID)
+	assert.Empty(t, scoped.Responses)
+
+	_, err = svc.GetRFQ(ctx, "supplier-c", rfqID)
+	assert.ErrorIs(t, err, entities.ErrRFQNotFound)
+
+	// Supplier B already answered
+	_, err = svc.RespondToRFQ(ctx, "supplier-b", rfqID, &entities.RFQResponse{
+		ResponseItems: []*entities.RFQResponseItem{{RFQItemID: itemID.String(), UnitPrice: 4500}},
+	})
+	assert.ErrorIs(t, err, entities.ErrInvalidRFQResponse)
+	rfqs.AssertNotCalled(t, "SubmitResponse", mock.Anything, mock.Anything)
+
+	// The supplier is taken from the account, not the response
+	rfqs.On("SubmitResponse", ctx, mock.MatchedBy(func(response *entities.RFQResponse) bool {
+		return response.RFQID == rfqID && response.SupplierID == "supplier-a"
+	})).Return(nil).Once()
+	response, err := svc.RespondToRFQ(ctx, "supplier-a", rfqID, &entities.RFQResponse{
+		SupplierID:    "supplier-b",
+		ResponseItems: []*entities.RFQResponseItem{{RFQItemID: itemID.String(), UnitPrice: 4500}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "supplier-a", response.SupplierID)
+	assert.InDelta(t, 900000, response.TotalAmount, 0.001)
+	rfqs.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+// MockUserRevoker is a mock implementation of UserRevoker.
+type MockUserRevoker struct {
+	mock.Mock
+}
+
+func (m *MockUserRevoker) RevokeUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestSupplierPortalService_DisablingAnAccountSignsItsUserOut(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockSupplierPortalRepository)
+	sessions := new(MockUserRevoker)
+	svc := NewSupplierPortalService(repo, new(MockPortalPurchaseOrders), new(MockPortalRFQs))
+	svc.SetUserRevoker(sessions)
+	account := &entities.SupplierPortalAccount{ID: uuid.New().String(), UserID: uuid.New().String(), Username: "tekstil-jaya"}
+
+	disabled := *account
+	disabled.Status = entities.SupplierAccountDisabled
+	repo.On("SetAccountStatus", ctx, account.ID, entities.SupplierAccountDisabled).Return(nil).Once()
+	repo.On("GetAccount", ctx, account.ID).Return(&disabled, nil).Once()
+	sessions.On("RevokeUser", ctx, account.UserID).Return(nil).Once()
+	got, err := svc.SetAccountStatus(ctx, account.ID, entities.SupplierAccountDisabled)
+	require.NoError(t, err)
+	assert.Equal(t, entities.SupplierAccountDisabled, got.Status)
+
+	// Enabling it again leaves the user to sign in anew
+	active := *account
+	active.Status = entities.SupplierAccountActive
+	repo.On("SetAccountStatus", ctx, account.ID, entities.SupplierAccountActive).Return(nil).Once()
+	repo.On("GetAccount", ctx, account.ID).Return(&active, nil).Once()
+	_, err = svc.SetAccountStatus(ctx, account.ID, entities.SupplierAccountActive)
+	require.NoError(t, err)
+
+	_, err = svc.SetAccountStatus(ctx, account.ID, "locked")
+	assert.ErrorIs(t, err, entities.ErrInvalidSupplierAccount)
+	repo.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"malaka/internal/modules/procurement/domain/entities"
 	"malaka/internal/modules/procurement/domain/repositories"
@@ -41,6 +42,15 @@ func (s *VendorEvaluationService) Create(ctx context.Context, evaluation *entiti
 		return errors.New("all scores must be between 1 and 5")
 	}
 
+	// Score with the configured weights
+	if evaluation.Weights.IsZero() {
+		weights, err := s.repo.GetScoringWeights(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get scoring weights: %w", err)
+		}
+		evaluation.Weights = *weights
+	}
+
 	// Calculate overall score and determine recommendation
 	evaluation.CalculateOverallScore()
 	evaluation.DetermineRecommendation()
@@ -62,7 +72,7 @@ func (s *VendorEvaluationService) GetByID(ctx context.Context, id string) (*enti
 		return nil, err
 	}
 	if evaluation == nil {
-		return nil, errors.New("vendor evaluation not found")
+		return nil, entities.ErrVendorEvaluationNotFound
 	}
 	return evaluation, nil
 }
@@ -88,7 +98,7 @@ func (s *VendorEvaluationService) Update(ctx context.Context, evaluation *entiti
 		return err
 	}
 	if existing == nil {
-		return errors.New("vendor evaluation not found")
+		return entities.ErrVendorEvaluationNotFound
 	}
 
 	// Can only update draft evaluations
@@ -117,7 +127,7 @@ func (s *VendorEvaluationService) Delete(ctx context.Context, id string) error {
 		return err
 	}
 	if existing == nil {
-		return errors.New("vendor evaluation not found")
+		return entities.ErrVendorEvaluationNotFound
 	}
 
 	// Can only delete draft evaluations
@@ -135,7 +145,7 @@ func (s *VendorEvaluationService) Complete(ctx context.Context, id string) (*ent
 		return nil, err
 	}
 	if evaluation == nil {
-		return nil, errors.New("vendor evaluation not found")
+		return nil, entities.ErrVendorEvaluationNotFound
 	}
 
 	if !evaluation.CanBeCompleted() {
@@ -163,7 +173,7 @@ func (s *VendorEvaluationService) Approve(ctx context.Context, id string) (*enti
 		return nil, err
 	}
 	if evaluation == nil {
-		return nil, errors.New("vendor evaluation not found")
+		return nil, entities.ErrVendorEvaluationNotFound
 	}
 
 	if !evaluation.CanBeApproved() {
@@ -194,3 +204,122 @@ func (s *VendorEvaluationService) GetSupplierAverageScore(ctx context.Context, s
 func (s *VendorEvaluationService) GetStats(ctx context.Context) (*repositories.VendorEvaluationStats, error) {
 	return s.repo.GetStats(ctx)
 }
+
+// GetScoringWeights retrieves the weights of the evaluation criteria.
+func (s *VendorEvaluationService) GetScoringWeights(ctx context.Context) (*entities.ScoringWeights, error) {
+	return s.repo.GetScoringWeights(ctx)
+}
+
+// SetScoringWeights sets the weights of the evaluation criteria. They apply to evaluations
+// created from then on; existing evaluations keep the weights they were scored with.
+func (s *VendorEvaluationService) SetScoringWeights(ctx context.Context, weights *entities.ScoringWeights) error {
+	if err := weights.Validate(); err != nil {
+		return err
+	}
+	return s.repo.SaveScoringWeights(ctx, weights)
+}
+
+// GetVendorKPIs measures the KPIs of the suppliers with activity in a period, of one
+// supplier when the filter has it.
+func (s *VendorEvaluationService) GetVendorKPIs(ctx context.Context, filter repositories.VendorKPIFilter) ([]*entities.VendorKPIs, error) {
+	if err := validateEvaluationPeriod(filter.PeriodStart, filter.PeriodEnd); err != nil {
+		return nil, err
+	}
+	kpis, err := s.repo.GetVendorKPIs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range kpis {
+		k.ComputeRates()
+	}
+	return kpis, nil
+}
+
+// GenerateEvaluations creates a draft evaluation for each supplier with activity in a
+// period, of one supplier when supplierID is given, scored from its KPIs with the
+// configured weights. Suppliers that already have a generated evaluation for the period
+// are skipped. The evaluator is left empty for evaluations generated by the scheduler.
+func (s *VendorEvaluationService) GenerateEvaluations(ctx context.Context, periodStart, periodEnd time.Time, supplierID, evaluatorID string) (*entities.VendorEvaluationRun, error) {
+	if err := validateEvaluationPeriod(periodStart, periodEnd); err != nil {
+		return nil, err
+	}
+	weights, err := s.repo.GetScoringWeights(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scoring weights: %w", err)
+	}
+	kpis, err := s.repo.GetVendorKPIs(ctx, repositories.VendorKPIFilter{
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		SupplierID:  supplierID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure vendor KPIs: %w", err)
+	}
+
+	run := &entities.VendorEvaluationRun{
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Evaluations: []*entities.VendorEvaluation{},
+	}
+	for _, k := range kpis {
+		if !k.HasActivity() {
+			continue
+		}
+		exists, err := s.repo.HasGeneratedEvaluation(ctx, k.SupplierID, periodStart, periodEnd)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			run.Skipped++
+			continue
+		}
+
+		evaluation := &entities.VendorEvaluation{
+			SupplierID:            k.SupplierID,
+			EvaluationPeriodStart: periodStart,
+			EvaluationPeriodEnd:   periodEnd,
+			EvaluatorID:           evaluatorID,
+			Status:                entities.VEStatusDraft,
+			Generated:             true,
+			Weights:               *weights,
+			SupplierName:          k.SupplierName,
+		}
+		evaluation.ApplyKPIs(k)
+		if err := s.Create(ctx, evaluation); err != nil {
+			return nil, fmt.Errorf("failed to create evaluation for supplier %s: %w", k.SupplierID, err)
+		}
+		run.Created++
+		run.Evaluations = append(run.Evaluations, evaluation)
+	}
+	return run, nil
+}
+
+// GeneratePreviousMonth generates the draft evaluations of the previous calendar month,
+// for the scheduler.
+func (s *VendorEvaluationService) GeneratePreviousMonth(ctx context.Context) (*entities.VendorEvaluationRun, error) {
+	now := utils.Now()
+	periodStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+	periodEnd := periodStart.AddDate(0, 1, -1)
+	return s.GenerateEvaluations(ctx, periodStart, periodEnd, "", "")
+}
+
+// GetSupplierScorecard retrieves the scorecard of a supplier: its evaluations by period,
+// of the periods within from and to when given, and the trend of its overall score.
+func (s *VendorEvaluationService) GetSupplierScorecard(ctx context.Context, supplierID string, from, to *time.Time) (*entities.SupplierScorecard, error) {
+	evaluations, err := s.repo.GetSupplierTrend(ctx, supplierID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return entities.NewSupplierScorecard(supplierID, evaluations), nil
+}
+
+// validateEvaluationPeriod checks that a period has both dates, in order.
+func validateEvaluationPeriod(periodStart, periodEnd time.Time) error {
+	if periodStart.IsZero() || periodEnd.IsZero() {
+		return fmt.Errorf("%w: period start and end are required", entities.ErrInvalidEvaluationPeriod)
+	}
+	if periodEnd.Before(periodStart) {
+		return fmt.Errorf("%w: period ends before it starts", entities.ErrInvalidEvaluationPeriod)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/procurement/domain/entities"
+	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// MockVendorEvaluationRepository is a mock implementation of repositories.VendorEvaluationRepository.
+type MockVendorEvaluationRepository struct {
+	mock.Mock
+}
+
+func (m *MockVendorEvaluationRepository) Create(ctx context.Context, evaluation *entities.VendorEvaluation) error {
+	args := m.Called(ctx, evaluation)
+	return args.Error(0)
+}
+
+func (m *MockVendorEvaluationRepository) GetByID(ctx context.Context, id string) (*entities.VendorEvaluation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.VendorEvaluation), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) GetAll(ctx context.Context, filter *repositories.VendorEvaluationFilter) ([]*entities.VendorEvaluation, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.VendorEvaluation), args.Int(1), args.Error(2)
+}
+
+func (m *MockVendorEvaluationRepository) Update(ctx context.Context, evaluation *entities.VendorEvaluation) error {
+	args := m.Called(ctx, evaluation)
+	return args.Error(0)
+}
+
+func (m *MockVendorEvaluationRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockVendorEvaluationRepository) GetBySupplierID(ctx context.Context, supplierID string) ([]*entities.VendorEvaluation, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).([]*entities.VendorEvaluation), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) GetSupplierAverageScore(ctx context.Context, supplierID string) (float64, error) {
+	args := m.Called(ctx, supplierID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) GetStats(ctx context.Context) (*repositories.VendorEvaluationStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repositories.VendorEvaluationStats), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) GetNextEvaluationNumber(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) GetScoringWeights(ctx context.Context) (*entities.ScoringWeights, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ScoringWeights), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) SaveScoringWeights(ctx context.Context, weights *entities.ScoringWeights) error {
+	args := m.Called(ctx, weights)
+	return args.Error(0)
+}
+
+func (m *MockVendorEvaluationRepository) GetVendorKPIs(ctx context.Context, filter repositories.VendorKPIFilter) ([]*entities.VendorKPIs, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entities.VendorKPIs), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) HasGeneratedEvaluation(ctx context.Context, supplierID string, periodStart, periodEnd time.Time) (bool, error) {
+	args := m.Called(ctx, supplierID, periodStart, periodEnd)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockVendorEvaluationRepository) GetSupplierTrend(ctx context.Context, supplierID string, from, to *time.Time) ([]*entities.VendorEvaluation, error) {
+	args := m.Called(ctx, supplierID, from, to)
+	return args.Get(0).([]*entities.VendorEvaluation), args.Error(1)
+}
+
+func TestVendorEvaluationService_GenerateEvaluations(t *testing.T) {
+	repo := new(MockVendorEvaluationRepository)
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	repo.On("GetScoringWeights", mock.Anything).
+		Return(&entities.ScoringWeights{Quality: 0.4, Delivery: 0.3, Price: 0.1, Service: 0.1, Compliance: 0.1}, nil).Once()
+	repo.On("GetVendorKPIs", mock.Anything, repositories.VendorKPIFilter{PeriodStart: start, PeriodEnd: end}).Return([]*entities.VendorKPIs{
+		{
+			SupplierID: "supplier-a", Deliveries: 10, OnTimeDeliveries: 9, AverageDaysLate: 2,
+			Inspections: 4, PassedInspections: 4, ReceivedQuantity: 200, ReturnedQuantity: 10,
+			ReferenceAmount: 1000, OrderedAmount: 1030,
+		},
+		{SupplierID: "supplier-b", Deliveries: 1, OnTimeDeliveries: 1},
+		{SupplierID: "supplier-c"},
+	}, nil).Once()
+	repo.On("HasGeneratedEvaluation", mock.Anything, "supplier-a", start, end).Return(false, nil).Once()
+	repo.On("HasGeneratedEvaluation", mock.Anything, "supplier-b", start, end).Return(true, nil).Once()
+	repo.On("GetNextEvaluationNumber", mock.Anything).Return("VE-2026-00001", nil).Once()
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*entities.VendorEvaluation")).Return(nil).Once()
+	svc := NewVendorEvaluationService(repo)
+
+	run, err := svc.GenerateEvaluations(context.Background(), start, end, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Created)
+	assert.Equal(t, 1, run.Skipped)
+	require.Len(t, run.Evaluations, 1)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "HasGeneratedEvaluation", mock.Anything, "supplier-c", mock.Anything, mock.Anything)
+
+	ve := run.Evaluations[0]
+	repo.AssertCalled(t, "Create", mock.Anything, ve)
+	assert.Equal(t, "supplier-a", ve.SupplierID)
+	assert.Equal(t, "VE-2026-00001", ve.EvaluationNumber)
+	assert.True(t, ve.Generated)
+	assert.Equal(t, entities.VEStatusDraft, ve.Status)
+	assert.Equal(t, 4, ve.DeliveryScore)   // 90% on time
+	assert.Equal(t, 4, ve.QualityScore)    // QC 100% (5) and 5% returned (3)
+	assert.Equal(t, 3, ve.PriceScore)      // 3% over the agreed prices
+	assert.Equal(t, 3, ve.ComplianceScore) // no invoices matched
+	assert.Equal(t, entities.NeutralScore, ve.ServiceScore)
+	assert.InDelta(t, 3.7, ve.OverallScore, 0.001)
+	assert.Equal(t, entities.VERecommendationApproved, ve.Recommendation)
+	require.NotNil(t, ve.KPIs.ReturnRate)
+	assert.InDelta(t, 5.0, *ve.KPIs.ReturnRate, 0.001)
+	assert.Equal(t, 0.4, ve.Weights.Quality)
+}
+
+func TestVendorEvaluationService_GenerateEvaluationsRejectsPeriod(t *testing.T) {
+	repo := new(MockVendorEvaluationRepository)
+	svc := NewVendorEvaluationService(repo)
+	start := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	_, err := svc.GenerateEvaluations(context.Background(), start, start.AddDate(0, 0, -1), "", "")
+	assert.ErrorIs(t, err, entities.ErrInvalidEvaluationPeriod)
+	repo.AssertNotCalled(t, "GetVendorKPIs", mock.Anything, mock.Anything)
+}
+
+func TestVendorEvaluationService_SetScoringWeights(t *testing.T) {
+	repo := new(MockVendorEvaluationRepository)
+	svc := NewVendorEvaluationService(repo)
+
+	err := svc.SetScoringWeights(context.Background(), &entities.ScoringWeights{Quality: 0.5, Delivery: 0.5, Price: 0.2})
+	assert.ErrorIs(t, err, entities.ErrInvalidScoringWeights)
+	repo.AssertNotCalled(t, "SaveScoringWeights", mock.Anything, mock.Anything)
+
+	weights := &entities.ScoringWeights{Quality: 0.3, Delivery: 0.3, Price: 0.2, Service: 0.1, Compliance: 0.1}
+	repo.On("SaveScoringWeights", mock.Anything, weights).Return(nil).Once()
+	require.NoError(t, svc.SetScoringWeights(context.Background(), weights))
+	repo.AssertExpectations(t)
+}
+
+func TestVendorEvaluationService_GetSupplierScorecard(t *testing.T) {
+	var trend []*entities.VendorEvaluation
+	for i, score := range []float64{3.0, 3.2, 3.6} {
+		ve := &entities.VendorEvaluation{
+			EvaluationPeriodStart: time.Date(2026, time.Month(6+i), 1, 0, 0, 0, 0, time.UTC),
+			OverallScore:          score,
+			Recommendation:        entities.VERecommendationApproved,
+			SupplierName:          "PT Kain",
+		}
+		ve.ID = uuid.New()
+		trend = append(trend, ve)
+	}
+	repo := new(MockVendorEvaluationRepository)
+	repo.On("GetSupplierTrend", mock.Anything, "supplier-a", (*time.Time)(nil), (*time.Time)(nil)).Return(trend, nil).Once()
+	svc := NewVendorEvaluationService(repo)
+
+	card, err := svc.GetSupplierScorecard(context.Background(), "supplier-a", nil, nil)
+	require.NoError(t, err)
+	require.Len(t, card.Periods, 3)
+	assert.Nil(t, card.Periods[0].Change)
+	assert.InDelta(t, 0.4, *card.Periods[2].Change, 0.001)
+	assert.InDelta(t, 3.27, card.AverageScore, 0.001)
+	assert.Equal(t, 3.6, card.LatestScore)
+	assert.Equal(t, entities.ScorecardTrendImproving, card.Trend)
+	assert.Equal(t, "PT Kain", card.SupplierName)
+	repo.AssertExpectations(t)
+}
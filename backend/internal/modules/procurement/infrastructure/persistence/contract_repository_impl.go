@@ -36,8 +36,8 @@ func (r *ContractRepositoryImpl) Create(ctx context.Context, contract *entities.
 			id, contract_number, title, description, supplier_id, contract_type,
 			status, start_date, end_date, value, currency, payment_terms,
 			terms_conditions, auto_renewal, renewal_period, notice_period,
-			signed_by, signed_date, attachments, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+			signed_by, signed_date, attachments, created_at, updated_at, owner_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 	_, err = r.db.ExecContext(ctx, query,
 		contract.ID, contract.ContractNumber, contract.Title, contract.Description,
@@ -45,7 +45,7 @@ func (r *ContractRepositoryImpl) Create(ctx context.Context, contract *entities.
 		contract.EndDate, contract.Value, contract.Currency, contract.PaymentTerms,
 		contract.TermsConditions, contract.AutoRenewal, contract.RenewalPeriod,
 		contract.NoticePeriod, contract.SignedBy, contract.SignedDate, attachmentsJSON,
-		contract.CreatedAt, contract.UpdatedAt,
+		contract.CreatedAt, contract.UpdatedAt, contract.OwnerID,
 	)
 	return err
 }
@@ -58,7 +58,7 @@ func (r *ContractRepositoryImpl) GetByID(ctx context.Context, id string) (*entit
 			c.contract_type, c.status, c.start_date, c.end_date, c.value,
 			c.currency, c.payment_terms, c.terms_conditions, c.auto_renewal,
 			c.renewal_period, c.notice_period, c.signed_by, c.signed_date,
-			c.attachments, c.created_at, c.updated_at,
+			c.attachments, c.created_at, c.updated_at, c.owner_id, c.expiry_notified_at,
 			COALESCE(s.name, '') as supplier_name
 		FROM contracts c
 		LEFT JOIN suppliers s ON c.supplier_id = s.id
@@ -66,9 +66,9 @@ func (r *ContractRepositoryImpl) GetByID(ctx context.Context, id string) (*entit
 	`
 
 	contract := &entities.Contract{}
-	var description, paymentTerms, termsConditions, signedBy sql.NullString
+	var description, paymentTerms, termsConditions, signedBy, ownerID sql.NullString
 	var renewalPeriod, noticePeriod sql.NullInt64
-	var signedDate sql.NullTime
+	var signedDate, expiryNotifiedAt sql.NullTime
 	var attachmentsJSON []byte
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -77,7 +77,8 @@ func (r *ContractRepositoryImpl) GetByID(ctx context.Context, id string) (*entit
 		&contract.StartDate, &contract.EndDate, &contract.Value, &contract.Currency,
 		&paymentTerms, &termsConditions, &contract.AutoRenewal, &renewalPeriod,
 		&noticePeriod, &signedBy, &signedDate, &attachmentsJSON,
-		&contract.CreatedAt, &contract.UpdatedAt, &contract.SupplierName,
+		&contract.CreatedAt, &contract.UpdatedAt, &ownerID, &expiryNotifiedAt,
+		&contract.SupplierName,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -102,6 +103,12 @@ func (r *ContractRepositoryImpl) GetByID(ctx context.Context, id string) (*entit
 	if signedDate.Valid {
 		contract.SignedDate = &signedDate.Time
 	}
+	if ownerID.Valid {
+		contract.OwnerID = &ownerID.String
+	}
+	if expiryNotifiedAt.Valid {
+		contract.ExpiryNotifiedAt = &expiryNotifiedAt.Time
+	}
 	if renewalPeriod.Valid {
 		period := int(renewalPeriod.Int64)
 		contract.RenewalPeriod = &period
@@ -133,7 +140,7 @@ func (r *ContractRepositoryImpl) GetAll(ctx context.Context, filter *repositorie
 			c.contract_type, c.status, c.start_date, c.end_date, c.value,
 			c.currency, c.payment_terms, c.terms_conditions, c.auto_renewal,
 			c.renewal_period, c.notice_period, c.signed_by, c.signed_date,
-			c.attachments, c.created_at, c.updated_at,
+			c.attachments, c.created_at, c.updated_at, c.owner_id, c.expiry_notified_at,
 			COALESCE(s.name, '') as supplier_name
 		FROM contracts c
 		LEFT JOIN suppliers s ON c.supplier_id = s.id
@@ -201,9 +208,9 @@ func (r *ContractRepositoryImpl) GetAll(ctx context.Context, filter *repositorie
 	var contracts []*entities.Contract
 	for rows.Next() {
 		contract := &entities.Contract{}
-		var description, paymentTerms, termsConditions, signedBy sql.NullString
+		var description, paymentTerms, termsConditions, signedBy, ownerID sql.NullString
 		var renewalPeriod, noticePeriod sql.NullInt64
-		var signedDate sql.NullTime
+		var signedDate, expiryNotifiedAt sql.NullTime
 		var attachmentsJSON []byte
 
 		err := rows.Scan(
@@ -212,7 +219,8 @@ func (r *ContractRepositoryImpl) GetAll(ctx context.Context, filter *repositorie
 			&contract.StartDate, &contract.EndDate, &contract.Value, &contract.Currency,
 			&paymentTerms, &termsConditions, &contract.AutoRenewal, &renewalPeriod,
 			&noticePeriod, &signedBy, &signedDate, &attachmentsJSON,
-			&contract.CreatedAt, &contract.UpdatedAt, &contract.SupplierName,
+			&contract.CreatedAt, &contract.UpdatedAt, &ownerID, &expiryNotifiedAt,
+			&contract.SupplierName,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -233,6 +241,12 @@ func (r *ContractRepositoryImpl) GetAll(ctx context.Context, filter *repositorie
 		if signedDate.Valid {
 			contract.SignedDate = &signedDate.Time
 		}
+		if ownerID.Valid {
+			contract.OwnerID = &ownerID.String
+		}
+		if expiryNotifiedAt.Valid {
+			contract.ExpiryNotifiedAt = &expiryNotifiedAt.Time
+		}
 		if renewalPeriod.Valid {
 			period := int(renewalPeriod.Int64)
 			contract.RenewalPeriod = &period
@@ -264,7 +278,8 @@ func (r *ContractRepositoryImpl) Update(ctx context.Context, contract *entities.
 			start_date = $6, end_date = $7, value = $8, currency = $9,
 			payment_terms = $10, terms_conditions = $11, auto_renewal = $12,
 			renewal_period = $13, notice_period = $14, signed_by = $15,
-			signed_date = $16, attachments = $17, updated_at = $18
+			signed_date = $16, attachments = $17, updated_at = $18,
+			owner_id = $19, expiry_notified_at = $20
 		WHERE id = $1
 	`
 	_, err = r.db.ExecContext(ctx, query,
@@ -273,6 +288,7 @@ func (r *ContractRepositoryImpl) Update(ctx context.Context, contract *entities.
 		contract.Currency, contract.PaymentTerms, contract.TermsConditions,
 		contract.AutoRenewal, contract.RenewalPeriod, contract.NoticePeriod,
 		contract.SignedBy, contract.SignedDate, attachmentsJSON, contract.UpdatedAt,
+		contract.OwnerID, contract.ExpiryNotifiedAt,
 	)
 	return err
 }
@@ -292,7 +308,7 @@ func (r *ContractRepositoryImpl) GetExpiring(ctx context.Context, days int) ([]*
 			c.contract_type, c.status, c.start_date, c.end_date, c.value,
 			c.currency, c.payment_terms, c.terms_conditions, c.auto_renewal,
 			c.renewal_period, c.notice_period, c.signed_by, c.signed_date,
-			c.attachments, c.created_at, c.updated_at,
+			c.attachments, c.created_at, c.updated_at, c.owner_id, c.expiry_notified_at,
 			COALESCE(s.name, '') as supplier_name
 		FROM contracts c
 		LEFT JOIN suppliers s ON c.supplier_id = s.id
@@ -309,9 +325,9 @@ func (r *ContractRepositoryImpl) GetExpiring(ctx context.Context, days int) ([]*
 	var contracts []*entities.Contract
 	for rows.Next() {
 		contract := &entities.Contract{}
-		var description, paymentTerms, termsConditions, signedBy sql.NullString
+		var description, paymentTerms, termsConditions, signedBy, ownerID sql.NullString
 		var renewalPeriod, noticePeriod sql.NullInt64
-		var signedDate sql.NullTime
+		var signedDate, expiryNotifiedAt sql.NullTime
 		var attachmentsJSON []byte
 
 		err := rows.Scan(
@@ -320,7 +336,8 @@ func (r *ContractRepositoryImpl) GetExpiring(ctx context.Context, days int) ([]*
 			&contract.StartDate, &contract.EndDate, &contract.Value, &contract.Currency,
 			&paymentTerms, &termsConditions, &contract.AutoRenewal, &renewalPeriod,
 			&noticePeriod, &signedBy, &signedDate, &attachmentsJSON,
-			&contract.CreatedAt, &contract.UpdatedAt, &contract.SupplierName,
+			&contract.CreatedAt, &contract.UpdatedAt, &ownerID, &expiryNotifiedAt,
+			&contract.SupplierName,
 		)
 		if err != nil {
 			return nil, err
@@ -341,6 +358,12 @@ func (r *ContractRepositoryImpl) GetExpiring(ctx context.Context, days int) ([]*
 		if signedDate.Valid {
 			contract.SignedDate = &signedDate.Time
 		}
+		if ownerID.Valid {
+			contract.OwnerID = &ownerID.String
+		}
+		if expiryNotifiedAt.Valid {
+			contract.ExpiryNotifiedAt = &expiryNotifiedAt.Time
+		}
 		if renewalPeriod.Valid {
 			period := int(renewalPeriod.Int64)
 			contract.RenewalPeriod = &period
@@ -415,3 +438,157 @@ func (r *ContractRepositoryImpl) GetNextContractNumber(ctx context.Context) (str
 
 	return fmt.Sprintf("%s%05d", prefix, nextNum), nil
 }
+
+// contractItemsQuery selects contract items with the quantity and value called off of them
+// on purchase orders that are not cancelled.
+const contractItemsQuery = `
+	SELECT
+		ci.id, ci.contract_id, ci.item_name, ci.description, ci.unit, ci.unit_price,
+		ci.quantity_cap, ci.value_cap, ci.created_at, ci.updated_at,
+		COALESCE(SUM(l.quantity) FILTER (WHERE po.status <> 'cancelled'), 0) AS consumed_quantity,
+		COALESCE(SUM(l.amount) FILTER (WHERE po.status <> 'cancelled'), 0) AS consumed_value
+	FROM contract_items ci
+	LEFT JOIN contract_call_off_lines l ON l.contract_item_id = ci.id
+	LEFT JOIN procurement_purchase_orders po ON po.id = l.purchase_order_id
+`
+
+// GetItems retrieves the items of a contract with their consumption.
+func (r *ContractRepositoryImpl) GetItems(ctx context.Context, contractID string) ([]*entities.ContractItem, error) {
+	items := []*entities.ContractItem{}
+	query := contractItemsQuery + ` WHERE ci.contract_id = $1 GROUP BY ci.id ORDER BY ci.created_at, ci.item_name`
+	if err := r.db.SelectContext(ctx, &items, query, contractID); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetItem retrieves a contract item with its consumption.
+func (r *ContractRepositoryImpl) GetItem(ctx context.Context, id string) (*entities.ContractItem, error) {
+	item := &entities.ContractItem{}
+	err := r.db.GetContext(ctx, item, contractItemsQuery+` WHERE ci.id = $1 GROUP BY ci.id`, id)
+	if err == sql.ErrNoRows {
+		return nil, entities.ErrContractItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// CreateItem adds an item to a contract.
+func (r *ContractRepositoryImpl) CreateItem(ctx context.Context, item *entities.ContractItem) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO contract_items (
+			id, contract_id, item_name, description, unit, unit_price,
+			quantity_cap, value_cap, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, item.ID, item.ContractID, item.ItemName, item.Description, item.Unit, item.UnitPrice,
+		item.QuantityCap, item.ValueCap, item.CreatedAt, item.UpdatedAt)
+	return err
+}
+
+// UpdateItem updates the price and caps of a contract item.
+func (r *ContractRepositoryImpl) UpdateItem(ctx context.Context, item *entities.ContractItem) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE contract_items SET
+			item_name = $2, description = $3, unit = $4, unit_price = $5,
+			quantity_cap = $6, value_cap = $7, updated_at = $8
+		WHERE id = $1
+	`, item.ID, item.ItemName, item.Description, item.Unit, item.UnitPrice,
+		item.QuantityCap, item.ValueCap, item.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return entities.ErrContractItemNotFound
+	}
+	return nil
+}
+
+// DeleteItem deletes a contract item that has never been called off.
+func (r *ContractRepositoryImpl) DeleteItem(ctx context.Context, id string) error {
+	var calledOff bool
+	if err := r.db.GetContext(ctx, &calledOff,
+		`SELECT EXISTS (SELECT 1 FROM contract_call_off_lines WHERE contract_item_id = $1)`, id); err != nil {
+		return err
+	}
+	if calledOff {
+		return entities.ErrContractItemCalledOff
+	}
+	result, err := r.db.ExecContext(ctx, `DELETE FROM contract_items WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return entities.ErrContractItemNotFound
+	}
+	return nil
+}
+
+// GetCallOffLines retrieves the call-off lines of a contract, newest first, with the status
+// of their purchase orders.
+func (r *ContractRepositoryImpl) GetCallOffLines(ctx context.Context, contractID string) ([]*entities.ContractCallOffLine, error) {
+	lines := []*entities.ContractCallOffLine{}
+	err := r.db.SelectContext(ctx, &lines, `
+		SELECT
+			l.id, l.contract_id, l.contract_item_id, l.purchase_order_id, l.po_item_id,
+			l.quantity, l.unit_price, l.amount, l.created_by, l.created_at,
+			ci.item_name, COALESCE(po.po_number, '') AS po_number, COALESCE(po.status, '') AS po_status
+		FROM contract_call_off_lines l
+		JOIN contract_items ci ON ci.id = l.contract_item_id
+		LEFT JOIN procurement_purchase_orders po ON po.id = l.purchase_order_id
+		WHERE l.contract_id = $1
+		ORDER BY l.created_at DESC, ci.item_name
+	`, contractID)
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// SaveCallOff records the call-off lines of a purchase order against a contract.
+func (r *ContractRepositoryImpl) SaveCallOff(ctx context.Context, contract *entities.Contract, lines []*entities.ContractCallOffLine) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.GetContext(ctx, &status, `SELECT status FROM contracts WHERE id = $1 FOR UPDATE`, contract.ID)
+	if err == sql.ErrNoRows {
+		return entities.ErrContractNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != entities.ContractStatusActive {
+		return entities.ErrContractNotCallable
+	}
+
+	items := []*entities.ContractItem{}
+	query := contractItemsQuery + ` WHERE ci.contract_id = $1 GROUP BY ci.id`
+	if err := tx.SelectContext(ctx, &items, query, contract.ID); err != nil {
+		return err
+	}
+	var consumed float64
+	for _, item := range items {
+		consumed += item.ConsumedValue
+	}
+	if err := entities.CheckCallOff(contract, items, consumed, lines); err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO contract_call_off_lines (
+				id, contract_id, contract_item_id, purchase_order_id, po_item_id,
+				quantity, unit_price, amount, created_by, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, l.ID, l.ContractID, l.ContractItemID, l.PurchaseOrderID, l.POItemID,
+			l.Quantity, l.UnitPrice, l.Amount, l.CreatedBy, l.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -30,9 +30,10 @@ func (r *PurchaseOrderRepositoryImpl) Create(ctx context.Context, order *entitie
 			id, po_number, supplier_id, purchase_request_id, order_date,
 			expected_delivery_date, delivery_address, payment_terms, currency,
 			subtotal, discount_amount, tax_amount, shipping_cost, total_amount,
-			status, payment_status, notes, created_by, expense_account_id, created_at, updated_at
+			status, payment_status, notes, created_by, expense_account_id, created_at, updated_at,
+			contract_id
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22
 		)
 	`
 
@@ -58,6 +59,7 @@ func (r *PurchaseOrderRepositoryImpl) Create(ctx context.Context, order *entitie
 		order.ExpenseAccountID,
 		order.CreatedAt,
 		order.UpdatedAt,
+		order.ContractID,
 	)
 
 	if err != nil {
@@ -83,7 +85,7 @@ func (r *PurchaseOrderRepositoryImpl) GetByID(ctx context.Context, id string) (*
 			po.subtotal, po.discount_amount, po.tax_amount, po.shipping_cost, po.total_amount,
 			po.status, po.payment_status, COALESCE(po.notes, '') as notes, po.created_by, po.approved_by, po.approved_at,
 			po.sent_at, po.confirmed_at, po.received_at, po.cancelled_at, COALESCE(po.cancel_reason, '') as cancel_reason,
-			po.created_at, po.updated_at,
+			po.created_at, po.updated_at, po.contract_id,
 			po.expense_account_id, po.budget_commitment_id,
 			COALESCE(s.name, '') as supplier_name,
 			COALESCE(e.employee_name, u.username, '') as created_by_name,
@@ -125,7 +127,7 @@ func (r *PurchaseOrderRepositoryImpl) GetByPONumber(ctx context.Context, poNumbe
 			po.subtotal, po.discount_amount, po.tax_amount, po.shipping_cost, po.total_amount,
 			po.status, po.payment_status, COALESCE(po.notes, '') as notes, po.created_by, po.approved_by, po.approved_at,
 			po.sent_at, po.confirmed_at, po.received_at, po.cancelled_at, COALESCE(po.cancel_reason, '') as cancel_reason,
-			po.created_at, po.updated_at,
+			po.created_at, po.updated_at, po.contract_id,
 			COALESCE(s.name, '') as supplier_name,
 			COALESCE(e.employee_name, u.username, '') as created_by_name,
 			COALESCE(e.position, 'Staff') as created_by_position
@@ -161,7 +163,7 @@ func (r *PurchaseOrderRepositoryImpl) GetAll(ctx context.Context, filter reposit
 			po.subtotal, po.discount_amount, po.tax_amount, po.shipping_cost, po.total_amount,
 			po.status, po.payment_status, COALESCE(po.notes, '') as notes, po.created_by, po.approved_by, po.approved_at,
 			po.sent_at, po.confirmed_at, po.received_at, po.cancelled_at, COALESCE(po.cancel_reason, '') as cancel_reason,
-			po.created_at, po.updated_at,
+			po.created_at, po.updated_at, po.contract_id,
 			COALESCE(s.name, '') as supplier_name,
 			COALESCE(e.employee_name, u.username, '') as created_by_name,
 			COALESCE(e.position, 'Staff') as created_by_position
@@ -366,9 +368,9 @@ func (r *PurchaseOrderRepositoryImpl) AddItem(ctx context.Context, item *entitie
 		INSERT INTO procurement_purchase_order_items (
 			id, purchase_order_id, item_name, description, specification,
 			quantity, unit, unit_price, discount_percentage, tax_percentage,
-			line_total, received_quantity, currency, created_at, updated_at
+			line_total, received_quantity, currency, created_at, updated_at, contract_item_id
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
 		)
 	`
 
@@ -388,6 +390,7 @@ func (r *PurchaseOrderRepositoryImpl) AddItem(ctx context.Context, item *entitie
 		item.Currency,
 		item.CreatedAt,
 		item.UpdatedAt,
+		item.ContractItemID,
 	)
 
 	if err != nil {
@@ -457,7 +460,7 @@ func (r *PurchaseOrderRepositoryImpl) GetItemsByOrderID(ctx context.Context, ord
 		SELECT
 			id, purchase_order_id, item_name, COALESCE(description, '') as description, COALESCE(specification, '') as specification,
 			quantity, unit, unit_price, discount_percentage, tax_percentage,
-			line_total, received_quantity, currency, created_at, updated_at
+			line_total, received_quantity, currency, created_at, updated_at, contract_item_id
 		FROM procurement_purchase_order_items
 		WHERE purchase_order_id = $1
 		ORDER BY created_at ASC
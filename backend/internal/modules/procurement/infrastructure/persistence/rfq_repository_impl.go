@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"malaka/internal/modules/procurement/domain/entities"
 	"malaka/internal/modules/procurement/domain/repositories"
@@ -565,6 +566,80 @@ func (r *RFQRepositoryImpl) GetResponseItems(ctx context.Context, responseID str
 	return items, err
 }
 
+// SaveAwards records the awards of an RFQ and closes it: awarded responses are accepted and
+// the other open responses rejected. The RFQ is locked so that it is awarded only once.
+func (r *RFQRepositoryImpl) SaveAwards(ctx context.Context, rfqID string, awards []*entities.RFQAward) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.GetContext(ctx, &status, `SELECT status FROM procurement_rfqs WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, rfqID)
+	if err == sql.ErrNoRows {
+		return entities.ErrRFQNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != "published" {
+		return entities.ErrRFQNotAwardable
+	}
+
+	responseIDs := make([]string, 0, len(awards))
+	for _, a := range awards {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO procurement_rfq_awards (
+				id, rfq_id, rfq_item_id, rfq_response_id, supplier_id, quantity, unit_price,
+				currency, score, purchase_order_id, awarded_by, awarded_at, created_at, updated_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, a.ID, a.RFQID, a.RFQItemID, a.RFQResponseID, a.SupplierID, a.Quantity, a.UnitPrice,
+			a.Currency, a.Score, a.PurchaseOrderID, a.AwardedBy, a.AwardedAt, a.CreatedAt, a.UpdatedAt); err != nil {
+			return err
+		}
+		responseIDs = append(responseIDs, a.RFQResponseID)
+	}
+
+	now := utils.Now()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE procurement_rfq_responses
+		SET status = CASE WHEN id = ANY($2::uuid[]) THEN 'accepted' ELSE 'rejected' END, updated_at = $3
+		WHERE rfq_id = $1 AND (id = ANY($2::uuid[]) OR status IN ('submitted', 'under_review'))
+	`, rfqID, pq.Array(responseIDs), now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE procurement_rfqs
+		SET status = 'closed', closed_at = $2, updated_at = $2
+		WHERE id = $1
+	`, rfqID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetAwards retrieves the awards of an RFQ
+func (r *RFQRepositoryImpl) GetAwards(ctx context.Context, rfqID string) ([]*entities.RFQAward, error) {
+	query := `
+		SELECT a.id, a.rfq_id, a.rfq_item_id, a.rfq_response_id, a.supplier_id, a.quantity, a.unit_price,
+			   a.currency, a.score, COALESCE(a.purchase_order_id::text, '') as purchase_order_id, a.awarded_by, a.awarded_at, a.created_at, a.updated_at,
+			   COALESCE(i.item_name, '') as item_name, COALESCE(s.name, '') as supplier_name,
+			   COALESCE(po.po_number, '') as po_number
+		FROM procurement_rfq_awards a
+		LEFT JOIN procurement_rfq_items i ON a.rfq_item_id = i.id
+		LEFT JOIN suppliers s ON a.supplier_id = s.id
+		LEFT JOIN procurement_purchase_orders po ON a.purchase_order_id = po.id
+		WHERE a.rfq_id = $1
+		ORDER BY i.created_at ASC
+	`
+
+	awards := []*entities.RFQAward{}
+	err := r.db.SelectContext(ctx, &awards, query, rfqID)
+	return awards, err
+}
+
 // GetRFQStats retrieves RFQ statistics
 func (r *RFQRepositoryImpl) GetRFQStats(ctx context.Context) (map[string]interface{}, error) {
 	query := `
@@ -0,0 +1,629 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/procurement/domain/entities"
+	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/shared/uuid"
+)
+
+// SupplierPortalRepositoryImpl implements repositories.SupplierPortalRepository.
+type SupplierPortalRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewSupplierPortalRepositoryImpl creates a new SupplierPortalRepositoryImpl.
+func NewSupplierPortalRepositoryImpl(db *sqlx.DB) *SupplierPortalRepositoryImpl {
+	return &SupplierPortalRepositoryImpl{db: db}
+}
+
+const supplierAccountQuery = `
+	SELECT a.id, a.supplier_id, COALESCE(s.name, '') AS supplier_name, a.user_id,
+		u.username, u.email, COALESCE(u.full_name, '') AS full_name, a.status, a.created_by,
+		a.created_at, a.updated_at
+	FROM supplier_portal_accounts a
+	JOIN users u ON u.id = a.user_id
+	LEFT JOIN suppliers s ON s.id = a.supplier_id`
+
+// CreateAccount creates the user of a portal account and links it to its supplier.
+func (r *SupplierPortalRepositoryImpl) CreateAccount(ctx context.Context, user *entities.NewSupplierUser, account *entities.SupplierPortalAccount) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM suppliers WHERE id = $1)`, account.SupplierID); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: supplier not found", entities.ErrInvalidSupplierAccount)
+	}
+	if err := tx.GetContext(ctx, &exists,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(username) = LOWER($1) OR LOWER(email) = LOWER($2))`,
+		user.Username, user.Email); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: username or email is already in use", entities.ErrInvalidSupplierAccount)
+	}
+
+	var roleID string
+	err = tx.GetContext(ctx, &roleID, `SELECT id FROM roles WHERE name = 'Supplier' AND is_active`)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: the Supplier role is missing", entities.ErrInvalidSupplierAccount)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO users (username, password, email, full_name, phone, company_id, role, role_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5,
+			COALESCE(NULLIF($6, '')::uuid, (SELECT company_id FROM users WHERE id = NULLIF($7, '')::uuid)),
+			$8, $9, 'active', $10, $10)
+		RETURNING id`,
+		user.Username, user.Password, user.Email, user.FullName, user.Phone,
+		user.CompanyID, account.CreatedBy, entities.SupplierRole, roleID, account.CreatedAt,
+	).Scan(&account.UserID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_roles (user_id, role_id, assigned_by)
+		VALUES ($1, $2, NULLIF($3, '')::uuid)
+		ON CONFLICT (user_id, role_id) DO NOTHING`,
+		account.UserID, roleID, account.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO supplier_portal_accounts (id, supplier_id, user_id, status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		account.ID, account.SupplierID, account.UserID, account.Status, account.CreatedBy,
+		account.CreatedAt, account.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAccount retrieves a portal account by ID.
+func (r *SupplierPortalRepositoryImpl) GetAccount(ctx context.Context, id string) (*entities.SupplierPortalAccount, error) {
+	var account entities.SupplierPortalAccount
+	err := r.db.GetContext(ctx, &account, supplierAccountQuery+` WHERE a.id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetActiveAccountByUser retrieves the active portal account of an active user.
+func (r *SupplierPortalRepositoryImpl) GetActiveAccountByUser(ctx context.Context, userID string) (*entities.SupplierPortalAccount, error) {
+	var account entities.SupplierPortalAccount
+	query := supplierAccountQuery + ` WHERE a.user_id = $1 AND a.status = $2 AND COALESCE(u.status, 'active') = 'active'`
+	err := r.db.GetContext(ctx, &account, query, userID, entities.SupplierAccountActive)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListAccounts lists the portal accounts, of one supplier when given.
+func (r *SupplierPortalRepositoryImpl) ListAccounts(ctx context.Context, supplierID string) ([]*entities.SupplierPortalAccount, error) {
+	accounts := []*entities.SupplierPortalAccount{}
+	query := supplierAccountQuery + ` WHERE ($1 = '' OR a.supplier_id::text = $1) ORDER BY supplier_name, u.username`
+	if err := r.db.SelectContext(ctx, &accounts, query, supplierID); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// SetAccountStatus enables or disables a portal account together with its user.
+func (r *SupplierPortalRepositoryImpl) SetAccountStatus(ctx context.Context, id, status string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.GetContext(ctx, &userID, `
+		UPDATE supplier_portal_accounts SET status = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING user_id`, id, status)
+	if err == sql.ErrNoRows {
+		return entities.ErrSupplierAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	userStatus := "active"
+	if status != entities.SupplierAccountActive {
+		userStatus = "inactive"
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET status = $2, updated_at = NOW() WHERE id = $1`, userID, userStatus); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListActiveUserIDs lists the users of the active portal accounts of a supplier.
+func (r *SupplierPortalRepositoryImpl) ListActiveUserIDs(ctx context.Context, supplierID string) ([]string, error) {
+	ids := []string{}
+	err := r.db.SelectContext(ctx, &ids,
+		`SELECT user_id FROM supplier_portal_accounts WHERE supplier_id = $1 AND status = $2`,
+		supplierID, entities.SupplierAccountActive)
+	return ids, err
+}
+
+// ListPurchaseOrders lists the purchase orders sent to a supplier, newest first.
+func (r *SupplierPortalRepositoryImpl) ListPurchaseOrders(ctx context.Context, supplierID, status string) ([]*entities.PortalPurchaseOrder, error) {
+	orders := []*entities.PortalPurchaseOrder{}
+	query := `
+		SELECT po.id, po.po_number, po.order_date, po.expected_delivery_date, po.currency,
+			po.total_amount, po.status, po.payment_status, po.sent_at, po.confirmed_at,
+			ack.acknowledged_at
+		FROM procurement_purchase_orders po
+		LEFT JOIN supplier_po_acknowledgements ack ON ack.purchase_order_id = po.id
+		WHERE po.supplier_id = $1
+			AND (po.status IN ('sent', 'confirmed', 'shipped', 'received')
+				OR (po.status = 'cancelled' AND po.sent_at IS NOT NULL))
+			AND ($2 = '' OR po.status = $2)
+		ORDER BY po.order_date DESC, po.po_number DESC`
+	if err := r.db.SelectContext(ctx, &orders, query, supplierID, status); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetAcknowledgement retrieves the supplier acknowledgement of a purchase order.
+func (r *SupplierPortalRepositoryImpl) GetAcknowledgement(ctx context.Context, purchaseOrderID string) (*entities.PurchaseOrderAcknowledgement, error) {
+	var ack entities.PurchaseOrderAcknowledgement
+	err := r.db.GetContext(ctx, &ack, `
+		SELECT purchase_order_id, supplier_id, COALESCE(acknowledged_by::text, '') AS acknowledged_by,
+			acknowledged_at, note
+		FROM supplier_po_acknowledgements
+		WHERE purchase_order_id = $1`, purchaseOrderID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ack, nil
+}
+
+// SaveAcknowledgement records the supplier acknowledgement of a purchase order. An order
+// that was already acknowledged keeps its first acknowledgement.
+func (r *SupplierPortalRepositoryImpl) SaveAcknowledgement(ctx context.Context, ack *entities.PurchaseOrderAcknowledgement) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO supplier_po_acknowledgements (purchase_order_id, supplier_id, acknowledged_by, acknowledged_at, note)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5)
+		ON CONFLICT (purchase_order_id) DO NOTHING`,
+		ack.PurchaseOrderID, ack.SupplierID, ack.AcknowledgedBy, ack.AcknowledgedAt, ack.Note)
+	return err
+}
+
+// GetNextASNNumber generates the next advance shipping notice number.
+func (r *SupplierPortalRepositoryImpl) GetNextASNNumber(ctx context.Context) (string, error) {
+	prefix := fmt.Sprintf("ASN-%s-", time.Now().Format("200601"))
+
+	query := `
+		SELECT COALESCE(MAX(CAST(SUBSTRING(asn_number FROM '\d+$') AS INTEGER)), 0) + 1
+		FROM advance_shipping_notices
+		WHERE asn_number LIKE $1
+	`
+
+	var nextNum int
+	if err := r.db.QueryRowContext(ctx, query, prefix+"%").Scan(&nextNum); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%04d", prefix, nextNum), nil
+}
+
+// CreateShippingNotice creates an advance shipping notice with its items.
+func (r *SupplierPortalRepositoryImpl) CreateShippingNotice(ctx context.Context, notice *entities.AdvanceShippingNotice) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO advance_shipping_notices (
+			id, asn_number, supplier_id, purchase_order_id, ship_date, expected_arrival,
+			carrier, tracking_number, notes, status, submitted_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		notice.ID, notice.ASNNumber, notice.SupplierID, notice.PurchaseOrderID, notice.ShipDate,
+		notice.ExpectedArrival, notice.Carrier, notice.TrackingNumber, notice.Notes, notice.Status,
+		notice.SubmittedBy, notice.CreatedAt, notice.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range notice.Items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO advance_shipping_notice_items (id, asn_id, po_item_id, item_name, unit, quantity)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			item.ID, notice.ID, item.POItemID, item.ItemName, item.Unit, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const shippingNoticeQuery = `
+	SELECT n.id, n.asn_number, n.supplier_id, COALESCE(s.name, '') AS supplier_name,
+		n.purchase_order_id, COALESCE(po.po_number, '') AS po_number, n.ship_date,
+		n.expected_arrival, n.carrier, n.tracking_number, n.notes, n.status,
+		n.goods_receipt_id, n.submitted_by, n.created_at, n.updated_at
+	FROM advance_shipping_notices n
+	LEFT JOIN suppliers s ON s.id = n.supplier_id
+	LEFT JOIN procurement_purchase_orders po ON po.id = n.purchase_order_id`
+
+// GetShippingNotice retrieves an advance shipping notice with its items.
+func (r *SupplierPortalRepositoryImpl) GetShippingNotice(ctx context.Context, id string) (*entities.AdvanceShippingNotice, error) {
+	var notice entities.AdvanceShippingNotice
+	err := r.db.GetContext(ctx, &notice, shippingNoticeQuery+` WHERE n.id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	notice.Items = []*entities.ASNItem{}
+	err = r.db.SelectContext(ctx, &notice.Items, `
+		SELECT id, asn_id, po_item_id, item_name, unit, quantity
+		FROM advance_shipping_notice_items
+		WHERE asn_id = $1
+		ORDER BY item_name`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &notice, nil
+}
+
+// ListShippingNotices lists advance shipping notices, newest first.
+func (r *SupplierPortalRepositoryImpl) ListShippingNotices(ctx context.Context, filter repositories.ShippingNoticeFilter) ([]*entities.AdvanceShippingNotice, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.SupplierID != "" {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("n.supplier_id = $%d", len(args)))
+	}
+	if filter.PurchaseOrderID != "" {
+		args = append(args, filter.PurchaseOrderID)
+		conditions = append(conditions, fmt.Sprintf("n.purchase_order_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("n.status = $%d", len(args)))
+	}
+
+	query := shippingNoticeQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY n.created_at DESC"
+
+	notices := []*entities.AdvanceShippingNotice{}
+	if err := r.db.SelectContext(ctx, &notices, query, args...); err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
+
+// ReceiveShippingNotice creates a draft goods receipt from a submitted notice and marks
+// the notice received. The receipt lines carry the shipped quantities at the PO prices.
+func (r *SupplierPortalRepositoryImpl) ReceiveShippingNotice(ctx context.Context, receipt repositories.ShippingNoticeReceipt) (string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var notice struct {
+		Status          string `db:"status"`
+		ASNNumber       string `db:"asn_number"`
+		PurchaseOrderID string `db:"purchase_order_id"`
+		PONumber        string `db:"po_number"`
+		SupplierID      string `db:"supplier_id"`
+		SupplierName    string `db:"supplier_name"`
+		Currency        string `db:"currency"`
+		PaymentTerms    string `db:"payment_terms"`
+		ProcurementType string `db:"procurement_type"`
+	}
+	err = tx.GetContext(ctx, &notice, `
+		SELECT n.status, n.asn_number, n.purchase_order_id, po.po_number, n.supplier_id,
+			COALESCE(s.name, '') AS supplier_name, po.currency, COALESCE(po.payment_terms, '') AS payment_terms,
+			COALESCE(NULLIF(po.procurement_type, ''), 'RAW_MATERIAL') AS procurement_type
+		FROM advance_shipping_notices n
+		JOIN procurement_purchase_orders po ON po.id = n.purchase_order_id
+		LEFT JOIN suppliers s ON s.id = n.supplier_id
+		WHERE n.id = $1
+		FOR UPDATE OF n`, receipt.ASNID)
+	if err == sql.ErrNoRows {
+		return "", entities.ErrShippingNoticeNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if notice.Status != entities.ShippingNoticeSubmitted {
+		return "", fmt.Errorf("%w: notice %s is %s", entities.ErrInvalidShippingNotice, notice.ASNNumber, notice.Status)
+	}
+
+	var warehouseName string
+	err = tx.GetContext(ctx, &warehouseName, `SELECT name FROM warehouses WHERE id = $1`, receipt.WarehouseID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: warehouse not found", entities.ErrInvalidShippingNotice)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var lines []struct {
+		POItemID    string  `db:"po_item_id"`
+		ItemName    string  `db:"item_name"`
+		Description string  `db:"description"`
+		Unit        string  `db:"unit"`
+		Quantity    int     `db:"quantity"`
+		UnitPrice   float64 `db:"unit_price"`
+	}
+	err = tx.SelectContext(ctx, &lines, `
+		SELECT i.po_item_id, i.item_name, COALESCE(poi.description, '') AS description, i.unit,
+			i.quantity, poi.unit_price
+		FROM advance_shipping_notice_items i
+		JOIN procurement_purchase_order_items poi ON poi.id = i.po_item_id
+		WHERE i.asn_id = $1
+		ORDER BY i.item_name`, receipt.ASNID)
+	if err != nil {
+		return "", err
+	}
+	var total float64
+	for _, line := range lines {
+		total += float64(line.Quantity) * line.UnitPrice
+	}
+
+	var grNumber string
+	if err := tx.GetContext(ctx, &grNumber, `SELECT generate_gr_number()`); err != nil {
+		return "", err
+	}
+	grID := uuid.New()
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO goods_receipts (
+			id, purchase_order_id, receipt_date, warehouse_id,
+			gr_number, status, supplier_id, supplier_name, total_amount,
+			currency, procurement_type, notes, received_by,
+			po_number, warehouse_name, payment_terms,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, 'DRAFT', $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid, $13, $14, $15, $16, $16)`,
+		grID, notice.PurchaseOrderID, now, receipt.WarehouseID,
+		grNumber, notice.SupplierID, notice.SupplierName, total,
+		notice.Currency, notice.ProcurementType, fmt.Sprintf("Pre-filled from shipping notice %s", notice.ASNNumber), receipt.ReceivedBy,
+		notice.PONumber, warehouseName, notice.PaymentTerms, now)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range lines {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO goods_receipt_items (id, goods_receipt_id, item_name, description, quantity, unit, unit_price, line_total, po_item_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`,
+			uuid.New(), grID, line.ItemName, line.Description, line.Quantity, line.Unit,
+			line.UnitPrice, float64(line.Quantity)*line.UnitPrice, line.POItemID, now)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE advance_shipping_notices
+		SET status = $2, goods_receipt_id = $3, updated_at = $4
+		WHERE id = $1`, receipt.ASNID, entities.ShippingNoticeReceived, grID, now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return grID.String(), nil
+}
+
+// ListInvitedRFQs lists the RFQs a supplier is invited to, except drafts.
+func (r *SupplierPortalRepositoryImpl) ListInvitedRFQs(ctx context.Context, supplierID string) ([]*entities.PortalRFQ, error) {
+	rfqs := []*entities.PortalRFQ{}
+	err := r.db.SelectContext(ctx, &rfqs, `
+		SELECT q.id, q.rfq_number, q.title, COALESCE(q.description, '') AS description, q.status,
+			q.priority, q.due_date, q.published_at, rs.status AS invitation_status, rs.responded_at
+		FROM rfq_suppliers rs
+		JOIN rfqs q ON q.id = rs.rfq_id
+		WHERE rs.supplier_id = $1 AND q.status <> 'draft'
+		ORDER BY COALESCE(q.published_at, q.created_at) DESC`, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	return rfqs, nil
+}
+
+// GetRFQInvitation retrieves the invitation of a supplier to an RFQ.
+func (r *SupplierPortalRepositoryImpl) GetRFQInvitation(ctx context.Context, rfqID, supplierID string) (*entities.RFQSupplier, error) {
+	var invitation entities.RFQSupplier
+	err := r.db.GetContext(ctx, &invitation, `
+		SELECT id, rfq_id, supplier_id, invited_at, responded_at, status, created_at, updated_at
+		FROM rfq_suppliers
+		WHERE rfq_id = $1 AND supplier_id = $2`, rfqID, supplierID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// CreateInvoice creates a supplier invoice with its lines.
+func (r *SupplierPortalRepositoryImpl) CreateInvoice(ctx context.Context, invoice *entities.SupplierInvoice) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO supplier_invoices (
+			id, supplier_id, purchase_order_id, invoice_number, invoice_date, due_date, currency,
+			subtotal, tax_amount, total_amount, status, submitted_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		invoice.ID, invoice.SupplierID, invoice.PurchaseOrderID, invoice.InvoiceNumber,
+		invoice.InvoiceDate, invoice.DueDate, invoice.Currency, invoice.Subtotal, invoice.TaxAmount,
+		invoice.TotalAmount, invoice.Status, invoice.SubmittedBy, invoice.CreatedAt, invoice.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range invoice.Lines {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO supplier_invoice_lines (id, supplier_invoice_id, po_item_id, item_name, quantity, unit_price, line_total)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			line.ID, invoice.ID, line.POItemID, line.ItemName, line.Quantity, line.UnitPrice, line.LineTotal)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const supplierInvoiceQuery = `
+	SELECT i.id, i.supplier_id, COALESCE(s.name, '') AS supplier_name, i.purchase_order_id,
+		COALESCE(po.po_number, '') AS po_number, i.invoice_number, i.invoice_date, i.due_date,
+		i.currency, i.subtotal, i.tax_amount, i.total_amount, i.attachment_key, i.attachment_name,
+		i.status, i.purchase_voucher_id, i.rejection_reason, i.submitted_by, i.reviewed_by,
+		i.reviewed_at, i.created_at, i.updated_at
+	FROM supplier_invoices i
+	LEFT JOIN suppliers s ON s.id = i.supplier_id
+	LEFT JOIN procurement_purchase_orders po ON po.id = i.purchase_order_id`
+
+// GetInvoice retrieves a supplier invoice with its lines.
+func (r *SupplierPortalRepositoryImpl) GetInvoice(ctx context.Context, id string) (*entities.SupplierInvoice, error) {
+	return r.getInvoice(ctx, supplierInvoiceQuery+` WHERE i.id = $1`, id)
+}
+
+// GetInvoiceByNumber retrieves the invoice of a supplier by its number.
+func (r *SupplierPortalRepositoryImpl) GetInvoiceByNumber(ctx context.Context, supplierID, invoiceNumber string) (*entities.SupplierInvoice, error) {
+	return r.getInvoice(ctx, supplierInvoiceQuery+` WHERE i.supplier_id = $1 AND i.invoice_number = $2`, supplierID, invoiceNumber)
+}
+
+func (r *SupplierPortalRepositoryImpl) getInvoice(ctx context.Context, query string, args ...interface{}) (*entities.SupplierInvoice, error) {
+	var invoice entities.SupplierInvoice
+	err := r.db.GetContext(ctx, &invoice, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.Lines = []*entities.SupplierInvoiceLine{}
+	err = r.db.SelectContext(ctx, &invoice.Lines, `
+		SELECT id, supplier_invoice_id, po_item_id, item_name, quantity, unit_price, line_total
+		FROM supplier_invoice_lines
+		WHERE supplier_invoice_id = $1
+		ORDER BY item_name`, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// ListInvoices lists supplier invoices, newest first.
+func (r *SupplierPortalRepositoryImpl) ListInvoices(ctx context.Context, filter repositories.SupplierInvoiceFilter) ([]*entities.SupplierInvoice, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.SupplierID != "" {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("i.supplier_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("i.status = $%d", len(args)))
+	}
+
+	query := supplierInvoiceQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY i.created_at DESC"
+
+	invoices := []*entities.SupplierInvoice{}
+	if err := r.db.SelectContext(ctx, &invoices, query, args...); err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+// SetInvoiceAttachment sets the uploaded document of a supplier invoice.
+func (r *SupplierPortalRepositoryImpl) SetInvoiceAttachment(ctx context.Context, id, key, name string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE supplier_invoices SET attachment_key = $2, attachment_name = $3, updated_at = NOW()
+		WHERE id = $1`, id, key, name)
+	return err
+}
+
+// UpdateInvoiceReview saves the outcome of the review of a supplier invoice.
+func (r *SupplierPortalRepositoryImpl) UpdateInvoiceReview(ctx context.Context, invoice *entities.SupplierInvoice) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE supplier_invoices
+		SET status = $2, purchase_voucher_id = $3, rejection_reason = $4, reviewed_by = $5,
+			reviewed_at = $6, updated_at = $7
+		WHERE id = $1`,
+		invoice.ID, invoice.Status, invoice.PurchaseVoucherID, invoice.RejectionReason,
+		invoice.ReviewedBy, invoice.ReviewedAt, invoice.UpdatedAt)
+	return err
+}
+
+// ListPaymentStatus lists the invoices of a supplier with the vouchers they were booked as.
+func (r *SupplierPortalRepositoryImpl) ListPaymentStatus(ctx context.Context, supplierID string) ([]*entities.SupplierPaymentStatus, error) {
+	statuses := []*entities.SupplierPaymentStatus{}
+	err := r.db.SelectContext(ctx, &statuses, `
+		SELECT i.id AS supplier_invoice_id, i.invoice_number, i.invoice_date,
+			COALESCE(po.po_number, '') AS po_number, i.currency, i.total_amount,
+			i.status AS invoice_status, i.rejection_reason,
+			pv.voucher_number, pv.status AS voucher_status, pv.match_status,
+			COALESCE(pv.due_date, i.due_date) AS due_date,
+			COALESCE(pv.paid_amount, 0) AS paid_amount,
+			COALESCE(pv.remaining_amount, i.total_amount) AS outstanding_amount
+		FROM supplier_invoices i
+		LEFT JOIN procurement_purchase_orders po ON po.id = i.purchase_order_id
+		LEFT JOIN purchase_vouchers pv ON pv.id = i.purchase_voucher_id
+		WHERE i.supplier_id = $1
+		ORDER BY i.invoice_date DESC, i.created_at DESC`, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
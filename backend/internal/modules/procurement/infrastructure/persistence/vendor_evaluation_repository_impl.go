@@ -3,6 +3,7 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -30,10 +31,16 @@ func (r *VendorEvaluationRepositoryImpl) Create(ctx context.Context, evaluation
 			evaluator_id, status, quality_score, delivery_score, price_score, service_score,
 			compliance_score, overall_score, quality_comments, delivery_comments, price_comments,
 			service_comments, compliance_comments, overall_comments, recommendation, action_items,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
+			created_at, updated_at, generated, kpis, quality_weight, delivery_weight, price_weight,
+			service_weight, compliance_weight
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23,
+			$24, $25, $26, $27, $28, $29, $30)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	kpis, err := marshalEvaluationKPIs(evaluation.KPIs)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query,
 		evaluation.ID, evaluation.EvaluationNumber, evaluation.SupplierID,
 		evaluation.EvaluationPeriodStart, evaluation.EvaluationPeriodEnd,
 		evaluation.EvaluatorID, evaluation.Status, evaluation.QualityScore,
@@ -42,6 +49,8 @@ func (r *VendorEvaluationRepositoryImpl) Create(ctx context.Context, evaluation
 		evaluation.DeliveryComments, evaluation.PriceComments, evaluation.ServiceComments,
 		evaluation.ComplianceComments, evaluation.OverallComments, evaluation.Recommendation,
 		evaluation.ActionItems, evaluation.CreatedAt, evaluation.UpdatedAt,
+		evaluation.Generated, kpis, evaluation.Weights.Quality, evaluation.Weights.Delivery,
+		evaluation.Weights.Price, evaluation.Weights.Service, evaluation.Weights.Compliance,
 	)
 	return err
 }
@@ -51,11 +60,13 @@ func (r *VendorEvaluationRepositoryImpl) GetByID(ctx context.Context, id string)
 	query := `
 		SELECT
 			ve.id, ve.evaluation_number, ve.supplier_id, ve.evaluation_period_start,
-			ve.evaluation_period_end, ve.evaluator_id, ve.status, ve.quality_score,
+			ve.evaluation_period_end, COALESCE(ve.evaluator_id::text, ''), ve.status, ve.quality_score,
 			ve.delivery_score, ve.price_score, ve.service_score, ve.compliance_score,
 			ve.overall_score, ve.quality_comments, ve.delivery_comments, ve.price_comments,
 			ve.service_comments, ve.compliance_comments, ve.overall_comments,
 			ve.recommendation, ve.action_items, ve.created_at, ve.updated_at,
+			ve.generated, ve.kpis, ve.quality_weight, ve.delivery_weight, ve.price_weight,
+			ve.service_weight, ve.compliance_weight,
 			COALESCE(s.name, '') as supplier_name,
 			COALESCE(u.full_name, '') as evaluator_name
 		FROM vendor_evaluations ve
@@ -67,6 +78,7 @@ func (r *VendorEvaluationRepositoryImpl) GetByID(ctx context.Context, id string)
 	evaluation := &entities.VendorEvaluation{}
 	var qualityComments, deliveryComments, priceComments, serviceComments sql.NullString
 	var complianceComments, overallComments, actionItems sql.NullString
+	var kpis []byte
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&evaluation.ID, &evaluation.EvaluationNumber, &evaluation.SupplierID,
@@ -77,6 +89,8 @@ func (r *VendorEvaluationRepositoryImpl) GetByID(ctx context.Context, id string)
 		&deliveryComments, &priceComments, &serviceComments, &complianceComments,
 		&overallComments, &evaluation.Recommendation, &actionItems,
 		&evaluation.CreatedAt, &evaluation.UpdatedAt,
+		&evaluation.Generated, &kpis, &evaluation.Weights.Quality, &evaluation.Weights.Delivery,
+		&evaluation.Weights.Price, &evaluation.Weights.Service, &evaluation.Weights.Compliance,
 		&evaluation.SupplierName, &evaluation.EvaluatorName,
 	)
 	if err == sql.ErrNoRows {
@@ -108,6 +122,9 @@ func (r *VendorEvaluationRepositoryImpl) GetByID(ctx context.Context, id string)
 	if actionItems.Valid {
 		evaluation.ActionItems = &actionItems.String
 	}
+	if evaluation.KPIs, err = unmarshalEvaluationKPIs(kpis); err != nil {
+		return nil, err
+	}
 
 	return evaluation, nil
 }
@@ -121,11 +138,13 @@ func (r *VendorEvaluationRepositoryImpl) GetAll(ctx context.Context, filter *rep
 	baseQuery := `
 		SELECT
 			ve.id, ve.evaluation_number, ve.supplier_id, ve.evaluation_period_start,
-			ve.evaluation_period_end, ve.evaluator_id, ve.status, ve.quality_score,
+			ve.evaluation_period_end, COALESCE(ve.evaluator_id::text, ''), ve.status, ve.quality_score,
 			ve.delivery_score, ve.price_score, ve.service_score, ve.compliance_score,
 			ve.overall_score, ve.quality_comments, ve.delivery_comments, ve.price_comments,
 			ve.service_comments, ve.compliance_comments, ve.overall_comments,
 			ve.recommendation, ve.action_items, ve.created_at, ve.updated_at,
+			ve.generated, ve.kpis, ve.quality_weight, ve.delivery_weight, ve.price_weight,
+			ve.service_weight, ve.compliance_weight,
 			COALESCE(s.name, '') as supplier_name,
 			COALESCE(u.full_name, '') as evaluator_name
 		FROM vendor_evaluations ve
@@ -209,6 +228,7 @@ func (r *VendorEvaluationRepositoryImpl) GetAll(ctx context.Context, filter *rep
 		evaluation := &entities.VendorEvaluation{}
 		var qualityComments, deliveryComments, priceComments, serviceComments sql.NullString
 		var complianceComments, overallComments, actionItems sql.NullString
+		var kpis []byte
 
 		err := rows.Scan(
 			&evaluation.ID, &evaluation.EvaluationNumber, &evaluation.SupplierID,
@@ -219,6 +239,8 @@ func (r *VendorEvaluationRepositoryImpl) GetAll(ctx context.Context, filter *rep
 			&deliveryComments, &priceComments, &serviceComments, &complianceComments,
 			&overallComments, &evaluation.Recommendation, &actionItems,
 			&evaluation.CreatedAt, &evaluation.UpdatedAt,
+			&evaluation.Generated, &kpis, &evaluation.Weights.Quality, &evaluation.Weights.Delivery,
+			&evaluation.Weights.Price, &evaluation.Weights.Service, &evaluation.Weights.Compliance,
 			&evaluation.SupplierName, &evaluation.EvaluatorName,
 		)
 		if err != nil {
@@ -246,6 +268,9 @@ func (r *VendorEvaluationRepositoryImpl) GetAll(ctx context.Context, filter *rep
 		if actionItems.Valid {
 			evaluation.ActionItems = &actionItems.String
 		}
+		if evaluation.KPIs, err = unmarshalEvaluationKPIs(kpis); err != nil {
+			return nil, 0, err
+		}
 
 		evaluations = append(evaluations, evaluation)
 	}
@@ -261,7 +286,8 @@ func (r *VendorEvaluationRepositoryImpl) Update(ctx context.Context, evaluation
 			service_score = $6, compliance_score = $7, overall_score = $8,
 			quality_comments = $9, delivery_comments = $10, price_comments = $11,
 			service_comments = $12, compliance_comments = $13, overall_comments = $14,
-			recommendation = $15, action_items = $16, updated_at = $17
+			recommendation = $15, action_items = $16, updated_at = $17,
+			evaluator_id = COALESCE(NULLIF($18, '')::uuid, evaluator_id)
 		WHERE id = $1
 	`
 	_, err := r.db.ExecContext(ctx, query,
@@ -270,7 +296,7 @@ func (r *VendorEvaluationRepositoryImpl) Update(ctx context.Context, evaluation
 		evaluation.OverallScore, evaluation.QualityComments, evaluation.DeliveryComments,
 		evaluation.PriceComments, evaluation.ServiceComments, evaluation.ComplianceComments,
 		evaluation.OverallComments, evaluation.Recommendation, evaluation.ActionItems,
-		evaluation.UpdatedAt,
+		evaluation.UpdatedAt, evaluation.EvaluatorID,
 	)
 	return err
 }
@@ -358,3 +384,203 @@ func (r *VendorEvaluationRepositoryImpl) GetNextEvaluationNumber(ctx context.Con
 
 	return fmt.Sprintf("%s%05d", prefix, nextNum), nil
 }
+
+// marshalEvaluationKPIs encodes the KPIs of a generated evaluation, nil when it has none.
+func marshalEvaluationKPIs(kpis *entities.VendorKPIs) ([]byte, error) {
+	if kpis == nil {
+		return nil, nil
+	}
+	return json.Marshal(kpis)
+}
+
+// unmarshalEvaluationKPIs decodes the KPIs of a generated evaluation.
+func unmarshalEvaluationKPIs(data []byte) (*entities.VendorKPIs, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	kpis := &entities.VendorKPIs{}
+	if err := json.Unmarshal(data, kpis); err != nil {
+		return nil, fmt.Errorf("failed to decode evaluation KPIs: %w", err)
+	}
+	return kpis, nil
+}
+
+// GetScoringWeights retrieves the weights of the evaluation criteria.
+func (r *VendorEvaluationRepositoryImpl) GetScoringWeights(ctx context.Context) (*entities.ScoringWeights, error) {
+	weights := &entities.ScoringWeights{}
+	err := r.db.GetContext(ctx, weights, `
+		SELECT quality_weight, delivery_weight, price_weight, service_weight, compliance_weight,
+			updated_by, updated_at
+		FROM vendor_scoring_weights
+		LIMIT 1
+	`)
+	if err == sql.ErrNoRows {
+		defaults := entities.DefaultScoringWeights()
+		return &defaults, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
+
+// SaveScoringWeights replaces the weights of the evaluation criteria.
+func (r *VendorEvaluationRepositoryImpl) SaveScoringWeights(ctx context.Context, weights *entities.ScoringWeights) error {
+	args := []interface{}{weights.Quality, weights.Delivery, weights.Price, weights.Service, weights.Compliance, weights.UpdatedBy}
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE vendor_scoring_weights SET
+			quality_weight = $1, delivery_weight = $2, price_weight = $3, service_weight = $4,
+			compliance_weight = $5, updated_by = $6, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, args...).Scan(&weights.UpdatedAt)
+	if err != sql.ErrNoRows {
+		return err
+	}
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO vendor_scoring_weights (quality_weight, delivery_weight, price_weight, service_weight,
+			compliance_weight, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING updated_at
+	`, args...).Scan(&weights.UpdatedAt)
+}
+
+// vendorKPIsQuery measures the KPIs of each supplier with activity between $1 and $2, of
+// supplier $3 when it is not empty. Goods receipts and incoming QC reach the supplier
+// through the purchase order they were raised against.
+const vendorKPIsQuery = `
+	WITH delivered AS (
+		SELECT po.supplier_id, po.id, po.expected_delivery_date::date AS promised,
+			MAX(COALESCE(gr.posted_at, gr.receipt_date))::date AS delivered_on
+		FROM procurement_purchase_orders po
+		JOIN procurement_purchase_order_items poi ON poi.purchase_order_id = po.id
+		JOIN goods_receipt_items gri ON gri.po_item_id = poi.id
+		JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id AND gr.status = 'POSTED'
+		WHERE po.expected_delivery_date IS NOT NULL
+		GROUP BY po.supplier_id, po.id, po.expected_delivery_date
+	), deliveries AS (
+		SELECT supplier_id, COUNT(*) AS deliveries,
+			COUNT(*) FILTER (WHERE delivered_on <= promised) AS on_time_deliveries,
+			COALESCE(AVG(delivered_on - promised) FILTER (WHERE delivered_on > promised), 0) AS average_days_late
+		FROM delivered
+		WHERE delivered_on BETWEEN $1::date AND $2::date
+		GROUP BY supplier_id
+	), received AS (
+		SELECT po.supplier_id, SUM(gri.quantity) AS quantity
+		FROM goods_receipt_items gri
+		JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id
+		JOIN procurement_purchase_order_items poi ON poi.id = gri.po_item_id
+		JOIN procurement_purchase_orders po ON po.id = poi.purchase_order_id
+		WHERE gr.status = 'POSTED' AND COALESCE(gr.posted_at, gr.receipt_date)::date BETWEEN $1::date AND $2::date
+		GROUP BY po.supplier_id
+	), returned AS (
+		SELECT rs.supplier_id, SUM(rsi.quantity) AS quantity
+		FROM return_suppliers rs
+		JOIN return_supplier_items rsi ON rsi.return_supplier_id = rs.id
+		WHERE LOWER(COALESCE(rs.status, 'draft')) NOT IN ('draft', 'cancelled', 'rejected')
+			AND rs.return_date::date BETWEEN $1::date AND $2::date
+		GROUP BY rs.supplier_id
+	), inspections AS (
+		SELECT po.supplier_id, COUNT(*) AS inspections,
+			COUNT(*) FILTER (WHERE qc.status = 'passed') AS passed,
+			COUNT(*) FILTER (WHERE qc.status = 'conditional') AS conditional
+		FROM quality_controls qc
+		JOIN procurement_purchase_orders po ON po.id::text = qc.reference_id
+		WHERE qc.type = 'incoming' AND qc.reference_type = 'purchase_order'
+			AND qc.status IN ('passed', 'failed', 'conditional')
+			AND qc.test_date BETWEEN $1::date AND $2::date
+		GROUP BY po.supplier_id
+	), prices AS (
+		SELECT po.supplier_id, SUM(poi.quantity * ref.unit_price) AS reference_amount,
+			SUM(poi.quantity * poi.unit_price) AS ordered_amount
+		FROM procurement_purchase_order_items poi
+		JOIN procurement_purchase_orders po ON po.id = poi.purchase_order_id
+		JOIN LATERAL (
+			SELECT unit_price FROM (
+				SELECT ci.unit_price, 1 AS priority FROM contract_items ci WHERE ci.id = poi.contract_item_id
+				UNION ALL
+				SELECT a.unit_price, 2 FROM procurement_rfq_awards a
+				JOIN procurement_rfq_items ri ON ri.id = a.rfq_item_id
+				WHERE a.purchase_order_id = po.id AND ri.item_name = poi.item_name
+			) refs
+			ORDER BY priority
+			LIMIT 1
+		) ref ON TRUE
+		WHERE po.status NOT IN ('draft', 'cancelled') AND po.order_date::date BETWEEN $1::date AND $2::date
+		GROUP BY po.supplier_id
+	), invoices AS (
+		SELECT po.supplier_id, COUNT(*) AS matched,
+			COUNT(*) FILTER (WHERE v.match_status = 'MATCHED') AS clean
+		FROM purchase_vouchers v
+		JOIN procurement_purchase_orders po ON po.id = v.purchase_order_id
+		WHERE v.match_status IN ('MATCHED', 'EXCEPTION', 'RESOLVED')
+			AND v.matched_at::date BETWEEN $1::date AND $2::date
+		GROUP BY po.supplier_id
+	)
+	SELECT s.id AS supplier_id, s.name AS supplier_name,
+		COALESCE(d.deliveries, 0) AS deliveries,
+		COALESCE(d.on_time_deliveries, 0) AS on_time_deliveries,
+		ROUND(COALESCE(d.average_days_late, 0), 2) AS average_days_late,
+		COALESCE(i.inspections, 0) AS inspections,
+		COALESCE(i.passed, 0) AS passed_inspections,
+		COALESCE(i.conditional, 0) AS conditional_inspections,
+		COALESCE(rc.quantity, 0) AS received_quantity,
+		COALESCE(rt.quantity, 0) AS returned_quantity,
+		COALESCE(p.reference_amount, 0) AS reference_amount,
+		COALESCE(p.ordered_amount, 0) AS ordered_amount,
+		COALESCE(inv.matched, 0) AS matched_invoices,
+		COALESCE(inv.clean, 0) AS clean_invoices
+	FROM suppliers s
+	LEFT JOIN deliveries d ON d.supplier_id = s.id
+	LEFT JOIN inspections i ON i.supplier_id = s.id
+	LEFT JOIN received rc ON rc.supplier_id = s.id
+	LEFT JOIN returned rt ON rt.supplier_id = s.id
+	LEFT JOIN prices p ON p.supplier_id = s.id
+	LEFT JOIN invoices inv ON inv.supplier_id = s.id
+	WHERE ($3 = '' OR s.id::text = $3)
+		AND (d.supplier_id IS NOT NULL OR i.supplier_id IS NOT NULL OR rc.supplier_id IS NOT NULL
+			OR rt.supplier_id IS NOT NULL OR p.supplier_id IS NOT NULL OR inv.supplier_id IS NOT NULL)
+	ORDER BY s.name`
+
+// GetVendorKPIs measures the KPIs of the suppliers with activity in a period.
+func (r *VendorEvaluationRepositoryImpl) GetVendorKPIs(ctx context.Context, filter repositories.VendorKPIFilter) ([]*entities.VendorKPIs, error) {
+	kpis := []*entities.VendorKPIs{}
+	if err := r.db.SelectContext(ctx, &kpis, vendorKPIsQuery, filter.PeriodStart, filter.PeriodEnd, filter.SupplierID); err != nil {
+		return nil, err
+	}
+	return kpis, nil
+}
+
+// HasGeneratedEvaluation reports whether a supplier already has a generated evaluation for
+// a period.
+func (r *VendorEvaluationRepositoryImpl) HasGeneratedEvaluation(ctx context.Context, supplierID string, periodStart, periodEnd time.Time) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM vendor_evaluations
+			WHERE supplier_id = $1 AND generated
+				AND evaluation_period_start = $2::date AND evaluation_period_end = $3::date
+		)
+	`, supplierID, periodStart, periodEnd).Scan(&exists)
+	return exists, err
+}
+
+// GetSupplierTrend retrieves the evaluations of a supplier by period, oldest first, of the
+// periods starting from and ending by the dates when given.
+func (r *VendorEvaluationRepositoryImpl) GetSupplierTrend(ctx context.Context, supplierID string, from, to *time.Time) ([]*entities.VendorEvaluation, error) {
+	evaluations, err := r.GetBySupplierID(ctx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	trend := make([]*entities.VendorEvaluation, 0, len(evaluations))
+	for i := len(evaluations) - 1; i >= 0; i-- {
+		ve := evaluations[i]
+		if from != nil && ve.EvaluationPeriodStart.Before(*from) {
+			continue
+		}
+		if to != nil && ve.EvaluationPeriodEnd.After(*to) {
+			continue
+		}
+		trend = append(trend, ve)
+	}
+	return trend, nil
+}
@@ -17,6 +17,8 @@ type CreateContractRequest struct {
 	AutoRenewal     bool      `json:"auto_renewal"`
 	RenewalPeriod   int       `json:"renewal_period"`
 	NoticePeriod    int       `json:"notice_period"`
+	// OwnerID is told when the contract is about to expire; the creator by default
+	OwnerID string `json:"owner_id"`
 }
 
 // UpdateContractRequest represents the request body for updating a contract.
@@ -35,6 +37,7 @@ type UpdateContractRequest struct {
 	NoticePeriod    int       `json:"notice_period"`
 	SignedBy        string    `json:"signed_by"`
 	SignedDate      *time.Time `json:"signed_date"`
+	OwnerID         string     `json:"owner_id"`
 }
 
 // TerminateContractRequest represents the request body for terminating a contract.
@@ -52,3 +55,31 @@ type ContractListResponse struct {
 	Data       interface{} `json:"data"`
 	Pagination Pagination  `json:"pagination"`
 }
+
+// ContractItemRequest represents the request body for adding or updating a contract item.
+type ContractItemRequest struct {
+	ItemName    string   `json:"item_name" binding:"required"`
+	Description string   `json:"description"`
+	Unit        string   `json:"unit"`
+	UnitPrice   float64  `json:"unit_price" binding:"gte=0"`
+	QuantityCap *int     `json:"quantity_cap" binding:"omitempty,gt=0"`
+	ValueCap    *float64 `json:"value_cap" binding:"omitempty,gt=0"`
+}
+
+// CallOffRequest represents the request body for raising a purchase order against a
+// framework contract.
+type CallOffRequest struct {
+	Lines                []CallOffLineRequest `json:"lines" binding:"required,min=1,dive"`
+	ExpectedDeliveryDate *time.Time           `json:"expected_delivery_date"`
+	DeliveryAddress      string               `json:"delivery_address"`
+	ProcurementType      string               `json:"procurement_type"`
+	Notes                string               `json:"notes"`
+}
+
+// CallOffLineRequest represents a contract item called off. Without a unit price the agreed
+// price applies.
+type CallOffLineRequest struct {
+	ContractItemID string   `json:"contract_item_id" binding:"required"`
+	Quantity       int      `json:"quantity" binding:"required,min=1"`
+	UnitPrice      *float64 `json:"unit_price" binding:"omitempty,gte=0"`
+}
@@ -83,6 +83,7 @@ type PurchaseOrderResponse struct {
 	SupplierID           string                      `json:"supplier_id"`
 	SupplierName         string                      `json:"supplier_name"`
 	PurchaseRequestID    *string                     `json:"purchase_request_id,omitempty"`
+	ContractID           *string                     `json:"contract_id,omitempty"`
 	OrderDate            time.Time                   `json:"order_date"`
 	ExpectedDeliveryDate *time.Time                  `json:"expected_delivery_date,omitempty"`
 	DeliveryAddress      string                      `json:"delivery_address"`
@@ -126,6 +127,7 @@ type PurchaseOrderItemResponse struct {
 	LineTotal          float64   `json:"line_total"`
 	ReceivedQuantity   int       `json:"received_quantity"`
 	Currency           string    `json:"currency"`
+	ContractItemID     *string   `json:"contract_item_id,omitempty"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 }
@@ -150,12 +152,20 @@ func ToPurchaseOrderResponse(order *entities.PurchaseOrder) *PurchaseOrderRespon
 		approvedByStr = &str
 	}
 
+	// Convert ContractID from *uuid.ID to *string
+	var contractIDStr *string
+	if order.ContractID != nil && !order.ContractID.IsNil() {
+		str := order.ContractID.String()
+		contractIDStr = &str
+	}
+
 	response := &PurchaseOrderResponse{
 		ID:                   order.ID.String(),
 		PONumber:             order.PONumber,
 		SupplierID:           order.SupplierID.String(),
 		SupplierName:         order.SupplierName,
 		PurchaseRequestID:    purchaseRequestIDStr,
+		ContractID:           contractIDStr,
 		OrderDate:            order.OrderDate,
 		ExpectedDeliveryDate: order.ExpectedDeliveryDate,
 		DeliveryAddress:      order.DeliveryAddress,
@@ -184,6 +194,11 @@ func ToPurchaseOrderResponse(order *entities.PurchaseOrder) *PurchaseOrderRespon
 	}
 
 	for _, item := range order.Items {
+		var contractItemIDStr *string
+		if item.ContractItemID != nil && !item.ContractItemID.IsNil() {
+			str := item.ContractItemID.String()
+			contractItemIDStr = &str
+		}
 		response.Items = append(response.Items, PurchaseOrderItemResponse{
 			ID:                 item.ID.String(),
 			PurchaseOrderID:    item.PurchaseOrderID.String(),
@@ -198,6 +213,7 @@ func ToPurchaseOrderResponse(order *entities.PurchaseOrder) *PurchaseOrderRespon
 			LineTotal:          item.LineTotal,
 			ReceivedQuantity:   item.ReceivedQuantity,
 			Currency:           item.Currency,
+			ContractItemID:     contractItemIDStr,
 			CreatedAt:          item.CreatedAt,
 			UpdatedAt:          item.UpdatedAt,
 		})
@@ -338,3 +338,69 @@ func (r *SubmitResponseRequest) ToEntity(rfqID string) *entities.RFQResponse {
 
 	return response
 }
+
+// AwardRFQRequest represents the request to award an RFQ, either whole to one response
+// or per item; without either every item goes to its best-scored bid
+type AwardRFQRequest struct {
+	ResponseID      string                `json:"response_id"`
+	Items           []AwardRFQItemRequest `json:"items" binding:"omitempty,dive"`
+	Weights         *BidWeightsRequest    `json:"weights"`
+	DeliveryAddress string                `json:"delivery_address" binding:"required"`
+	PaymentTerms    string                `json:"payment_terms" binding:"required"`
+}
+
+// AwardRFQItemRequest represents an RFQ item awarded to a response
+type AwardRFQItemRequest struct {
+	RFQItemID  string `json:"rfq_item_id" binding:"required"`
+	ResponseID string `json:"response_id" binding:"required"`
+}
+
+// BidWeightsRequest represents the relative weights of price, delivery time and vendor
+// evaluation in bid scores
+type BidWeightsRequest struct {
+	Price    float64 `json:"price"`
+	Delivery float64 `json:"delivery"`
+	Vendor   float64 `json:"vendor"`
+}
+
+// ToEntity converts the weights to the entity
+func (w *BidWeightsRequest) ToEntity() *entities.BidWeights {
+	if w == nil {
+		return nil
+	}
+	return &entities.BidWeights{Price: w.Price, Delivery: w.Delivery, Vendor: w.Vendor}
+}
+
+// ToEntity converts the award request to the entity
+func (r *AwardRFQRequest) ToEntity() *entities.RFQAwardRequest {
+	req := &entities.RFQAwardRequest{
+		ResponseID:      r.ResponseID,
+		Weights:         r.Weights.ToEntity(),
+		DeliveryAddress: r.DeliveryAddress,
+		PaymentTerms:    r.PaymentTerms,
+	}
+	for _, item := range r.Items {
+		req.Items = append(req.Items, entities.RFQItemAwardInput{RFQItemID: item.RFQItemID, ResponseID: item.ResponseID})
+	}
+	return req
+}
+
+// RFQAwardResultResponse represents the outcome of awarding an RFQ
+type RFQAwardResultResponse struct {
+	RFQ            *RFQResponse             `json:"rfq"`
+	Awards         []*entities.RFQAward     `json:"awards"`
+	PurchaseOrders []*PurchaseOrderResponse `json:"purchase_orders"`
+}
+
+// ToRFQAwardResultResponse converts an award result to the response DTO
+func ToRFQAwardResultResponse(result *entities.RFQAwardResult) *RFQAwardResultResponse {
+	resp := &RFQAwardResultResponse{
+		RFQ:            ToRFQResponse(result.RFQ),
+		Awards:         result.Awards,
+		PurchaseOrders: make([]*PurchaseOrderResponse, len(result.PurchaseOrders)),
+	}
+	for i, po := range result.PurchaseOrders {
+		resp.PurchaseOrders[i] = ToPurchaseOrderResponse(po)
+	}
+	return resp
+}
@@ -0,0 +1,94 @@
+package dto
+
+import "time"
+
+// CreateSupplierAccountRequest represents the request body for creating a supplier portal
+// account: a login for a user of the supplier.
+type CreateSupplierAccountRequest struct {
+	SupplierID string `json:"supplier_id" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	FullName   string `json:"full_name" binding:"required"`
+	Phone      string `json:"phone"`
+	Password   string `json:"password" binding:"required"`
+}
+
+// SupplierAccountStatusRequest represents the request body for enabling or disabling a
+// supplier portal account.
+type SupplierAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active disabled"`
+}
+
+// AcknowledgePurchaseOrderRequest represents the request body for acknowledging a
+// purchase order in the supplier portal.
+type AcknowledgePurchaseOrderRequest struct {
+	Note string `json:"note"`
+}
+
+// CreateShippingNoticeRequest represents the request body for submitting an advance
+// shipping notice against a purchase order.
+type CreateShippingNoticeRequest struct {
+	PurchaseOrderID string                      `json:"purchase_order_id" binding:"required"`
+	ShipDate        time.Time                   `json:"ship_date" binding:"required"`
+	ExpectedArrival *time.Time                  `json:"expected_arrival"`
+	Carrier         string                      `json:"carrier"`
+	TrackingNumber  string                      `json:"tracking_number"`
+	Notes           string                      `json:"notes"`
+	Items           []ShippingNoticeItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// ShippingNoticeItemRequest is a shipped quantity of a purchase order line.
+type ShippingNoticeItemRequest struct {
+	POItemID string `json:"po_item_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+// ReceiveShippingNoticeRequest represents the request body for creating the goods receipt
+// of an advance shipping notice.
+type ReceiveShippingNoticeRequest struct {
+	WarehouseID string `json:"warehouse_id" binding:"required"`
+}
+
+// SupplierRFQResponseRequest represents the request body for answering an RFQ in the
+// supplier portal. The total defaults to the sum of the item prices.
+type SupplierRFQResponseRequest struct {
+	TotalAmount     float64                          `json:"total_amount" binding:"min=0"`
+	Currency        string                           `json:"currency"`
+	DeliveryTime    int                              `json:"delivery_time" binding:"min=0"`
+	ValidityPeriod  int                              `json:"validity_period" binding:"min=0"`
+	TermsConditions string                           `json:"terms_conditions"`
+	Notes           string                           `json:"notes"`
+	Items           []SupplierRFQResponseItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// SupplierRFQResponseItemRequest is the price of an RFQ item.
+type SupplierRFQResponseItemRequest struct {
+	RFQItemID    string  `json:"rfq_item_id" binding:"required"`
+	UnitPrice    float64 `json:"unit_price" binding:"min=0"`
+	TotalPrice   float64 `json:"total_price" binding:"min=0"`
+	DeliveryTime int     `json:"delivery_time" binding:"min=0"`
+	Notes        string  `json:"notes"`
+}
+
+// CreateSupplierInvoiceRequest represents the request body for submitting an invoice
+// against a purchase order. The document is uploaded separately.
+type CreateSupplierInvoiceRequest struct {
+	PurchaseOrderID string                       `json:"purchase_order_id" binding:"required"`
+	InvoiceNumber   string                       `json:"invoice_number" binding:"required"`
+	InvoiceDate     time.Time                    `json:"invoice_date" binding:"required"`
+	DueDate         *time.Time                   `json:"due_date"`
+	TaxAmount       float64                      `json:"tax_amount" binding:"min=0"`
+	Lines           []SupplierInvoiceLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// SupplierInvoiceLineRequest is an invoiced quantity of a purchase order line.
+type SupplierInvoiceLineRequest struct {
+	POItemID  string  `json:"po_item_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	UnitPrice float64 `json:"unit_price" binding:"min=0"`
+}
+
+// RejectSupplierInvoiceRequest represents the request body for rejecting a supplier invoice.
+type RejectSupplierInvoiceRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -55,3 +55,21 @@ type VendorEvaluationListResponse struct {
 	Data       interface{} `json:"data"`
 	Pagination Pagination  `json:"pagination"`
 }
+
+// GenerateVendorEvaluationsRequest represents the request body for generating draft
+// evaluations of a period, of one supplier when supplier_id is given.
+type GenerateVendorEvaluationsRequest struct {
+	PeriodStart time.Time `json:"period_start" binding:"required"`
+	PeriodEnd   time.Time `json:"period_end" binding:"required"`
+	SupplierID  string    `json:"supplier_id"`
+}
+
+// ScoringWeightsRequest represents the request body for setting the weights of the
+// evaluation criteria. They must add up to 1.
+type ScoringWeightsRequest struct {
+	Quality    float64 `json:"quality" binding:"min=0,max=1"`
+	Delivery   float64 `json:"delivery" binding:"min=0,max=1"`
+	Price      float64 `json:"price" binding:"min=0,max=1"`
+	Service    float64 `json:"service" binding:"min=0,max=1"`
+	Compliance float64 `json:"compliance" binding:"min=0,max=1"`
+}
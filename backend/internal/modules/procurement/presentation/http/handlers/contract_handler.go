@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +12,7 @@ import (
 	"malaka/internal/modules/procurement/domain/services"
 	"malaka/internal/modules/procurement/presentation/http/dto"
 	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
 )
 
 // ContractHandler handles HTTP requests for contract operations.
@@ -59,6 +62,12 @@ func (h *ContractHandler) Create(c *gin.Context) {
 	if contract.Currency == "" {
 		contract.Currency = "IDR"
 	}
+	if req.OwnerID == "" {
+		req.OwnerID = c.GetString("user_id")
+	}
+	if req.OwnerID != "" {
+		contract.OwnerID = &req.OwnerID
+	}
 
 	if err := h.service.Create(c.Request.Context(), contract); err != nil {
 		response.InternalServerError(c, err.Error(), nil)
@@ -175,6 +184,9 @@ func (h *ContractHandler) Update(c *gin.Context) {
 	if req.SignedDate != nil {
 		existing.SignedDate = req.SignedDate
 	}
+	if req.OwnerID != "" {
+		existing.OwnerID = &req.OwnerID
+	}
 
 	if err := h.service.Update(c.Request.Context(), existing); err != nil {
 		response.InternalServerError(c, err.Error(), nil)
@@ -264,3 +276,138 @@ func (h *ContractHandler) GetStats(c *gin.Context) {
 
 	response.OK(c, "Contract statistics retrieved successfully", stats)
 }
+
+// GetItems handles retrieving the items of a contract with what is left of their caps.
+func (h *ContractHandler) GetItems(c *gin.Context) {
+	items, err := h.service.GetItems(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		contractError(c, err)
+		return
+	}
+
+	response.OK(c, "Contract items retrieved successfully", items)
+}
+
+// AddItem handles adding an item with its agreed price and caps to a contract.
+func (h *ContractHandler) AddItem(c *gin.Context) {
+	var req dto.ContractItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	item := contractItemFromRequest(&req)
+	item.ContractID = c.Param("id")
+	if err := h.service.AddItem(c.Request.Context(), item); err != nil {
+		contractError(c, err)
+		return
+	}
+
+	response.Created(c, "Contract item added successfully", item)
+}
+
+// UpdateItem handles updating the agreed price and caps of a contract item.
+func (h *ContractHandler) UpdateItem(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		response.BadRequest(c, "Invalid item ID", nil)
+		return
+	}
+	var req dto.ContractItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	item := contractItemFromRequest(&req)
+	item.ID = itemID
+	item.ContractID = c.Param("id")
+	if err := h.service.UpdateItem(c.Request.Context(), item); err != nil {
+		contractError(c, err)
+		return
+	}
+
+	response.OK(c, "Contract item updated successfully", item)
+}
+
+// DeleteItem handles deleting a contract item that has never been called off.
+func (h *ContractHandler) DeleteItem(c *gin.Context) {
+	if err := h.service.DeleteItem(c.Request.Context(), c.Param("id"), c.Param("itemId")); err != nil {
+		contractError(c, err)
+		return
+	}
+
+	response.OK(c, "Contract item deleted successfully", nil)
+}
+
+// GetConsumption handles retrieving how much of a contract has been called off.
+func (h *ContractHandler) GetConsumption(c *gin.Context) {
+	consumption, err := h.service.GetConsumption(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		contractError(c, err)
+		return
+	}
+
+	response.OK(c, "Contract consumption retrieved successfully", consumption)
+}
+
+// CallOff handles raising a purchase order against a framework contract.
+func (h *ContractHandler) CallOff(c *gin.Context) {
+	var req dto.CallOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	callOff := &entities.CallOffRequest{
+		ExpectedDeliveryDate: req.ExpectedDeliveryDate,
+		DeliveryAddress:      req.DeliveryAddress,
+		ProcurementType:      entities.ProcurementType(req.ProcurementType),
+		Notes:                req.Notes,
+	}
+	for _, line := range req.Lines {
+		callOff.Lines = append(callOff.Lines, entities.CallOffLineInput{
+			ContractItemID: line.ContractItemID,
+			Quantity:       line.Quantity,
+			UnitPrice:      line.UnitPrice,
+		})
+	}
+
+	order, err := h.service.CallOff(c.Request.Context(), c.Param("id"), callOff, c.GetString("user_id"))
+	if err != nil {
+		contractError(c, err)
+		return
+	}
+
+	response.Created(c, "Call-off purchase order created successfully", dto.ToPurchaseOrderResponse(order))
+}
+
+// contractItemFromRequest converts a contract item request to an entity.
+func contractItemFromRequest(req *dto.ContractItemRequest) *entities.ContractItem {
+	item := &entities.ContractItem{
+		ItemName:    req.ItemName,
+		Unit:        req.Unit,
+		UnitPrice:   req.UnitPrice,
+		QuantityCap: req.QuantityCap,
+		ValueCap:    req.ValueCap,
+	}
+	if req.Description != "" {
+		item.Description = &req.Description
+	}
+	return item
+}
+
+// contractError maps contract item and call-off errors to HTTP responses.
+func contractError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrContractNotFound), errors.Is(err, entities.ErrContractItemNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrContractNotCallable), errors.Is(err, entities.ErrContractCapExceeded),
+		errors.Is(err, entities.ErrContractItemCalledOff):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidContractItem), errors.Is(err, entities.ErrInvalidCallOff):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -21,6 +23,7 @@ import (
 	"malaka/internal/modules/procurement/domain/repositories"
 	"malaka/internal/modules/procurement/domain/services"
 	"malaka/internal/modules/procurement/presentation/http/dto"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/response"
 	"malaka/internal/shared/uuid"
 )
@@ -339,10 +342,20 @@ func (h *PurchaseOrderHandler) Approve(c *gin.Context) {
 
 	order, err := h.service.Approve(c.Request.Context(), id, approverID)
 	if err != nil {
+		if errors.Is(err, integration.ErrNotApprover) {
+			response.Forbidden(c, err.Error(), nil)
+			return
+		}
 		response.BadRequest(c, err.Error(), nil)
 		return
 	}
 
+	// Multi-level workflows keep the order pending until the last step is approved
+	if order.Status != procurement_entities.PurchaseOrderStatusApproved {
+		response.OK(c, "Purchase order approval recorded, awaiting next approver", dto.ToPurchaseOrderResponse(order))
+		return
+	}
+
 	// Notify PO creator asynchronously
 	if h.notificationService != nil {
 		go func() {
@@ -569,6 +582,10 @@ func (h *PurchaseOrderHandler) AddItem(c *gin.Context) {
 
 	entityItem := item.ToEntity()
 	if err := h.service.AddItem(c.Request.Context(), orderID, entityItem); err != nil {
+		if errors.Is(err, procurement_entities.ErrCallOffItemsFixed) {
+			response.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
@@ -598,6 +615,10 @@ func (h *PurchaseOrderHandler) DeleteItem(c *gin.Context) {
 	}
 
 	if err := h.service.DeleteItem(c.Request.Context(), orderID, itemID); err != nil {
+		if errors.Is(err, procurement_entities.ErrCallOffItemsFixed) {
+			response.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
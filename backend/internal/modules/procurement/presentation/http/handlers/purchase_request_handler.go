@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"strconv"
 
@@ -14,6 +15,7 @@ import (
 	"malaka/internal/modules/procurement/domain/repositories"
 	procurement_services "malaka/internal/modules/procurement/domain/services"
 	"malaka/internal/modules/procurement/presentation/http/dto"
+	"malaka/internal/shared/integration"
 	"malaka/internal/shared/response"
 	"malaka/internal/shared/utils"
 	"malaka/internal/shared/uuid"
@@ -306,10 +308,20 @@ func (h *PurchaseRequestHandler) Approve(c *gin.Context) {
 
 	pr, err := h.service.Approve(c.Request.Context(), id, approverID)
 	if err != nil {
+		if errors.Is(err, integration.ErrNotApprover) {
+			response.Forbidden(c, err.Error(), nil)
+			return
+		}
 		response.BadRequest(c, err.Error(), nil)
 		return
 	}
 
+	// Multi-level workflows keep the request pending until the last step is approved
+	if pr.Status != entities.PRStatusApproved {
+		response.OK(c, "Purchase request approval recorded, awaiting next approver", pr)
+		return
+	}
+
 	// Notify requester asynchronously
 	if h.notificationService != nil {
 		go func() {
@@ -335,8 +347,12 @@ func (h *PurchaseRequestHandler) Reject(c *gin.Context) {
 	}
 
 	rejectorID := c.GetString("user_id")
-	pr, err := h.service.Reject(c.Request.Context(), id, req.Reason)
+	pr, err := h.service.Reject(c.Request.Context(), id, rejectorID, req.Reason)
 	if err != nil {
+		if errors.Is(err, integration.ErrNotApprover) {
+			response.Forbidden(c, err.Error(), nil)
+			return
+		}
 		response.BadRequest(c, err.Error(), nil)
 		return
 	}
@@ -359,7 +375,7 @@ func (h *PurchaseRequestHandler) Reject(c *gin.Context) {
 // Cancel handles cancelling a purchase request.
 func (h *PurchaseRequestHandler) Cancel(c *gin.Context) {
 	id := c.Param("id")
-	pr, err := h.service.Cancel(c.Request.Context(), id)
+	pr, err := h.service.Cancel(c.Request.Context(), id, c.GetString("user_id"))
 	if err != nil {
 		response.BadRequest(c, err.Error(), nil)
 		return
@@ -3,7 +3,9 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -509,6 +511,101 @@ func (h *RFQHandler) ConvertResponseToPO(c *gin.Context) {
 	response.Created(c, "Response converted to Purchase Order successfully", dto.ToPurchaseOrderResponse(po))
 }
 
+// CompareBids handles retrieving the bid comparison matrix of an RFQ. The scoring weights
+// default to the standard ones and can be overridden with the price, delivery and vendor
+// query parameters.
+func (h *RFQHandler) CompareBids(c *gin.Context) {
+	weights := entities.DefaultBidWeights()
+	var err error
+	if weights.Price, err = queryWeight(c, "price", weights.Price); err != nil {
+		response.BadRequest(c, "Invalid price weight", nil)
+		return
+	}
+	if weights.Delivery, err = queryWeight(c, "delivery", weights.Delivery); err != nil {
+		response.BadRequest(c, "Invalid delivery weight", nil)
+		return
+	}
+	if weights.Vendor, err = queryWeight(c, "vendor", weights.Vendor); err != nil {
+		response.BadRequest(c, "Invalid vendor weight", nil)
+		return
+	}
+
+	comparison, err := h.service.CompareBids(c.Request.Context(), c.Param("id"), &weights)
+	if err != nil {
+		rfqAwardError(c, err)
+		return
+	}
+
+	response.OK(c, "Bid comparison retrieved successfully", comparison)
+}
+
+func queryWeight(c *gin.Context, name string, def float64) (float64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// Award handles awarding an RFQ and raising purchase orders for the winning suppliers
+func (h *RFQHandler) Award(c *gin.Context) {
+	var req dto.AwardRFQRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	// Get user ID from auth context
+	awardedBy := c.GetString("user_id")
+	if awardedBy == "" {
+		var err error
+		awardedBy, err = h.getDefaultUserID()
+		if err != nil {
+			response.InternalServerError(c, "Failed to get default user: "+err.Error(), nil)
+			return
+		}
+		if awardedBy == "" {
+			response.BadRequest(c, "Authentication required", nil)
+			return
+		}
+	}
+
+	result, err := h.service.Award(c.Request.Context(), c.Param("id"), req.ToEntity(), awardedBy)
+	if err != nil {
+		rfqAwardError(c, err)
+		return
+	}
+
+	response.Created(c, "RFQ awarded successfully", dto.ToRFQAwardResultResponse(result))
+}
+
+// GetAwards handles retrieving the awards of an RFQ
+func (h *RFQHandler) GetAwards(c *gin.Context) {
+	awards, err := h.service.GetAwards(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+
+	response.OK(c, "RFQ awards retrieved successfully", awards)
+}
+
+// rfqAwardError maps bid comparison and award errors to HTTP responses
+func rfqAwardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrRFQNotFound), errors.Is(err, entities.ErrRFQResponseNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrRFQNotAwardable):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidRFQAward), errors.Is(err, entities.ErrInvalidBidWeights):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrNoExchangeRate):
+		response.Error(c, http.StatusUnprocessableEntity, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
+
 // GetStats handles retrieving RFQ statistics
 func (h *RFQHandler) GetStats(c *gin.Context) {
 	stats, err := h.service.GetStats(c.Request.Context())
@@ -0,0 +1,496 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/procurement/domain/entities"
+	"malaka/internal/modules/procurement/domain/repositories"
+	"malaka/internal/modules/procurement/domain/services"
+	"malaka/internal/modules/procurement/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/storage"
+)
+
+// maxInvoiceDocumentSize caps the size of an uploaded supplier invoice document.
+const maxInvoiceDocumentSize = 10 * 1024 * 1024
+
+// invoiceDocumentURLExpiry is how long the download link of an invoice document is valid.
+const invoiceDocumentURLExpiry = 15 * time.Minute
+
+// SupplierPortalHandler handles HTTP requests for the supplier portal and for the
+// internal review of what suppliers submit through it.
+type SupplierPortalHandler struct {
+	service *services.SupplierPortalService
+	storage storage.StorageService
+}
+
+// NewSupplierPortalHandler creates a new SupplierPortalHandler.
+func NewSupplierPortalHandler(service *services.SupplierPortalService, storageService storage.StorageService) *SupplierPortalHandler {
+	return &SupplierPortalHandler{service: service, storage: storageService}
+}
+
+// RequireSupplierAccount resolves the portal account of the authenticated user and puts
+// its supplier in the context. Users without an active account are refused.
+func (h *SupplierPortalHandler) RequireSupplierAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		account, err := h.service.ResolveAccount(c.Request.Context(), c.GetString("user_id"))
+		if err != nil {
+			supplierPortalError(c, err)
+			c.Abort()
+			return
+		}
+		c.Set("supplier_id", account.SupplierID)
+		c.Next()
+	}
+}
+
+// CreateAccount handles creating a portal account for a supplier.
+func (h *SupplierPortalHandler) CreateAccount(c *gin.Context) {
+	var req dto.CreateSupplierAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	user := &entities.NewSupplierUser{
+		Username:  req.Username,
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Phone:     req.Phone,
+		Password:  req.Password,
+		CompanyID: c.GetString("company_id"),
+	}
+	account, err := h.service.CreateAccount(c.Request.Context(), req.SupplierID, user, c.GetString("user_id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.Created(c, "Supplier account created successfully", account)
+}
+
+// ListAccounts handles listing supplier portal accounts, optionally of one supplier.
+func (h *SupplierPortalHandler) ListAccounts(c *gin.Context) {
+	accounts, err := h.service.ListAccounts(c.Request.Context(), c.Query("supplier_id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Supplier accounts retrieved successfully", accounts)
+}
+
+// SetAccountStatus handles enabling or disabling a supplier portal account.
+func (h *SupplierPortalHandler) SetAccountStatus(c *gin.Context) {
+	var req dto.SupplierAccountStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	account, err := h.service.SetAccountStatus(c.Request.Context(), c.Param("id"), req.Status)
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Supplier account updated successfully", account)
+}
+
+// ListPurchaseOrders handles listing the purchase orders sent to the supplier.
+func (h *SupplierPortalHandler) ListPurchaseOrders(c *gin.Context) {
+	orders, err := h.service.ListPurchaseOrders(c.Request.Context(), c.GetString("supplier_id"), c.Query("status"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Purchase orders retrieved successfully", orders)
+}
+
+// GetPurchaseOrder handles retrieving a purchase order of the supplier.
+func (h *SupplierPortalHandler) GetPurchaseOrder(c *gin.Context) {
+	order, err := h.service.GetPurchaseOrder(c.Request.Context(), c.GetString("supplier_id"), c.Param("id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Purchase order retrieved successfully", order)
+}
+
+// AcknowledgePurchaseOrder handles the supplier acknowledging receipt of a purchase order.
+func (h *SupplierPortalHandler) AcknowledgePurchaseOrder(c *gin.Context) {
+	var req dto.AcknowledgePurchaseOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+	}
+
+	order, err := h.service.AcknowledgePurchaseOrder(c.Request.Context(), c.GetString("supplier_id"), c.Param("id"), c.GetString("user_id"), req.Note)
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Purchase order acknowledged successfully", order)
+}
+
+// ConfirmPurchaseOrder handles the supplier confirming a purchase order.
+func (h *SupplierPortalHandler) ConfirmPurchaseOrder(c *gin.Context) {
+	order, err := h.service.ConfirmPurchaseOrder(c.Request.Context(), c.GetString("supplier_id"), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Purchase order confirmed successfully", order)
+}
+
+// CreateShippingNotice handles the supplier submitting an advance shipping notice.
+func (h *SupplierPortalHandler) CreateShippingNotice(c *gin.Context) {
+	var req dto.CreateShippingNoticeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	notice := &entities.AdvanceShippingNotice{
+		PurchaseOrderID: req.PurchaseOrderID,
+		ShipDate:        req.ShipDate,
+		ExpectedArrival: req.ExpectedArrival,
+		Carrier:         req.Carrier,
+		TrackingNumber:  req.TrackingNumber,
+		Notes:           req.Notes,
+	}
+	for _, item := range req.Items {
+		notice.Items = append(notice.Items, &entities.ASNItem{POItemID: item.POItemID, Quantity: item.Quantity})
+	}
+	if err := h.service.SubmitShippingNotice(c.Request.Context(), c.GetString("supplier_id"), c.GetString("user_id"), notice); err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.Created(c, "Shipping notice submitted successfully", notice)
+}
+
+// ListSupplierShippingNotices handles listing the shipping notices of the supplier.
+func (h *SupplierPortalHandler) ListSupplierShippingNotices(c *gin.Context) {
+	notices, err := h.service.ListShippingNotices(c.Request.Context(), repositories.ShippingNoticeFilter{
+		SupplierID:      c.GetString("supplier_id"),
+		PurchaseOrderID: c.Query("purchase_order_id"),
+		Status:          c.Query("status"),
+	})
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Shipping notices retrieved successfully", notices)
+}
+
+// GetSupplierShippingNotice handles retrieving a shipping notice of the supplier.
+func (h *SupplierPortalHandler) GetSupplierShippingNotice(c *gin.Context) {
+	notice, err := h.service.GetSupplierShippingNotice(c.Request.Context(), c.GetString("supplier_id"), c.Param("id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Shipping notice retrieved successfully", notice)
+}
+
+// ListRFQs handles listing the RFQs the supplier is invited to.
+func (h *SupplierPortalHandler) ListRFQs(c *gin.Context) {
+	rfqs, err := h.service.ListRFQs(c.Request.Context(), c.GetString("supplier_id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "RFQs retrieved successfully", rfqs)
+}
+
+// GetRFQ handles retrieving an RFQ the supplier is invited to.
+func (h *SupplierPortalHandler) GetRFQ(c *gin.Context) {
+	rfq, err := h.service.GetRFQ(c.Request.Context(), c.GetString("supplier_id"), c.Param("id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "RFQ retrieved successfully", dto.ToRFQResponse(rfq))
+}
+
+// RespondToRFQ handles the supplier submitting its response to an RFQ.
+func (h *SupplierPortalHandler) RespondToRFQ(c *gin.Context) {
+	var req dto.SupplierRFQResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	supplierID := c.GetString("supplier_id")
+	rfqID := c.Param("id")
+	currency := req.Currency
+	if currency == "" {
+		currency = "IDR"
+	}
+	rfqResponse := entities.NewRFQResponse(rfqID, supplierID, req.TotalAmount, currency)
+	rfqResponse.DeliveryTime = req.DeliveryTime
+	rfqResponse.ValidityPeriod = req.ValidityPeriod
+	rfqResponse.TermsConditions = req.TermsConditions
+	rfqResponse.Notes = req.Notes
+	for _, itemReq := range req.Items {
+		item := entities.NewRFQResponseItem("", itemReq.RFQItemID, itemReq.UnitPrice, itemReq.TotalPrice)
+		item.DeliveryTime = itemReq.DeliveryTime
+		item.Notes = itemReq.Notes
+		rfqResponse.ResponseItems = append(rfqResponse.ResponseItems, item)
+	}
+
+	submitted, err := h.service.RespondToRFQ(c.Request.Context(), supplierID, rfqID, rfqResponse)
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.Created(c, "RFQ response submitted successfully", submitted)
+}
+
+// CreateInvoice handles the supplier submitting an invoice against a purchase order.
+func (h *SupplierPortalHandler) CreateInvoice(c *gin.Context) {
+	var req dto.CreateSupplierInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	invoice := &entities.SupplierInvoice{
+		PurchaseOrderID: req.PurchaseOrderID,
+		InvoiceNumber:   req.InvoiceNumber,
+		InvoiceDate:     req.InvoiceDate,
+		DueDate:         req.DueDate,
+		TaxAmount:       req.TaxAmount,
+	}
+	for _, line := range req.Lines {
+		invoice.Lines = append(invoice.Lines, &entities.SupplierInvoiceLine{
+			POItemID:  line.POItemID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		})
+	}
+	if err := h.service.SubmitInvoice(c.Request.Context(), c.GetString("supplier_id"), c.GetString("user_id"), invoice); err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.Created(c, "Invoice submitted successfully", invoice)
+}
+
+// UploadInvoiceDocument handles uploading the document of an invoice of the supplier.
+func (h *SupplierPortalHandler) UploadInvoiceDocument(c *gin.Context) {
+	if h.storage == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Document storage is not configured", nil)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file uploaded", nil)
+		return
+	}
+	if header.Size > maxInvoiceDocumentSize {
+		response.BadRequest(c, "File exceeds 10MB limit", nil)
+		return
+	}
+
+	supplierID := c.GetString("supplier_id")
+	id := c.Param("id")
+	if _, err := h.service.GetSupplierInvoice(c.Request.Context(), supplierID, id); err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	result, err := h.storage.UploadWithMetadata(c.Request.Context(), header)
+	if err != nil {
+		response.InternalServerError(c, "Failed to upload document: "+err.Error(), nil)
+		return
+	}
+
+	invoice, err := h.service.AttachInvoiceDocument(c.Request.Context(), supplierID, id, result.ObjectKey, header.Filename)
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	h.withDocumentURL(c, invoice)
+	response.OK(c, "Invoice document uploaded successfully", invoice)
+}
+
+// ListSupplierInvoices handles listing the invoices of the supplier.
+func (h *SupplierPortalHandler) ListSupplierInvoices(c *gin.Context) {
+	invoices, err := h.service.ListInvoices(c.Request.Context(), repositories.SupplierInvoiceFilter{
+		SupplierID: c.GetString("supplier_id"),
+		Status:     c.Query("status"),
+	})
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Invoices retrieved successfully", invoices)
+}
+
+// GetSupplierInvoice handles retrieving an invoice of the supplier.
+func (h *SupplierPortalHandler) GetSupplierInvoice(c *gin.Context) {
+	invoice, err := h.service.GetSupplierInvoice(c.Request.Context(), c.GetString("supplier_id"), c.Param("id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	h.withDocumentURL(c, invoice)
+	response.OK(c, "Invoice retrieved successfully", invoice)
+}
+
+// GetPaymentStatus handles retrieving the payment status of the supplier's invoices.
+func (h *SupplierPortalHandler) GetPaymentStatus(c *gin.Context) {
+	statuses, err := h.service.GetPaymentStatus(c.Request.Context(), c.GetString("supplier_id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Payment status retrieved successfully", statuses)
+}
+
+// ListShippingNotices handles listing the shipping notices of all suppliers.
+func (h *SupplierPortalHandler) ListShippingNotices(c *gin.Context) {
+	notices, err := h.service.ListShippingNotices(c.Request.Context(), repositories.ShippingNoticeFilter{
+		SupplierID:      c.Query("supplier_id"),
+		PurchaseOrderID: c.Query("purchase_order_id"),
+		Status:          c.Query("status"),
+	})
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Shipping notices retrieved successfully", notices)
+}
+
+// GetShippingNotice handles retrieving a shipping notice.
+func (h *SupplierPortalHandler) GetShippingNotice(c *gin.Context) {
+	notice, err := h.service.GetShippingNotice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Shipping notice retrieved successfully", notice)
+}
+
+// ReceiveShippingNotice handles creating the draft goods receipt of a shipping notice.
+func (h *SupplierPortalHandler) ReceiveShippingNotice(c *gin.Context) {
+	var req dto.ReceiveShippingNoticeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	notice, err := h.service.ReceiveShippingNotice(c.Request.Context(), c.Param("id"), req.WarehouseID, c.GetString("user_id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Shipping notice received successfully", notice)
+}
+
+// ListInvoices handles listing the invoices submitted by suppliers.
+func (h *SupplierPortalHandler) ListInvoices(c *gin.Context) {
+	invoices, err := h.service.ListInvoices(c.Request.Context(), repositories.SupplierInvoiceFilter{
+		SupplierID: c.Query("supplier_id"),
+		Status:     c.Query("status"),
+	})
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Supplier invoices retrieved successfully", invoices)
+}
+
+// GetInvoice handles retrieving a supplier invoice.
+func (h *SupplierPortalHandler) GetInvoice(c *gin.Context) {
+	invoice, err := h.service.GetInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	h.withDocumentURL(c, invoice)
+	response.OK(c, "Supplier invoice retrieved successfully", invoice)
+}
+
+// RegisterInvoice handles booking a supplier invoice as a purchase voucher.
+func (h *SupplierPortalHandler) RegisterInvoice(c *gin.Context) {
+	invoice, err := h.service.RegisterInvoice(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Supplier invoice registered successfully", invoice)
+}
+
+// RejectInvoice handles rejecting a supplier invoice.
+func (h *SupplierPortalHandler) RejectInvoice(c *gin.Context) {
+	var req dto.RejectSupplierInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	invoice, err := h.service.RejectInvoice(c.Request.Context(), c.Param("id"), c.GetString("user_id"), req.Reason)
+	if err != nil {
+		supplierPortalError(c, err)
+		return
+	}
+
+	response.OK(c, "Supplier invoice rejected successfully", invoice)
+}
+
+// withDocumentURL sets a short-lived download link for the invoice document, if any.
+func (h *SupplierPortalHandler) withDocumentURL(c *gin.Context, invoice *entities.SupplierInvoice) {
+	if h.storage == nil || invoice.AttachmentKey == "" {
+		return
+	}
+	if url, err := h.storage.GenerateDownloadURL(c.Request.Context(), invoice.AttachmentKey, invoiceDocumentURLExpiry); err == nil {
+		invoice.AttachmentURL = url
+	}
+}
+
+// supplierPortalError maps supplier portal errors to HTTP responses.
+func supplierPortalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrNoSupplierAccount):
+		response.Forbidden(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrSupplierAccountNotFound), errors.Is(err, entities.ErrPurchaseOrderNotFound),
+		errors.Is(err, entities.ErrShippingNoticeNotFound), errors.Is(err, entities.ErrRFQNotFound),
+		errors.Is(err, entities.ErrSupplierInvoiceNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidSupplierAccount), errors.Is(err, entities.ErrInvalidOrderAction),
+		errors.Is(err, entities.ErrInvalidShippingNotice), errors.Is(err, entities.ErrInvalidRFQResponse),
+		errors.Is(err, entities.ErrInvalidSupplierInvoice):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
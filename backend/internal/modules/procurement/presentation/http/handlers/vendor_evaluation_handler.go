@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -225,6 +228,10 @@ func (h *VendorEvaluationHandler) Update(c *gin.Context) {
 	if req.ActionItems != "" {
 		existing.ActionItems = &req.ActionItems
 	}
+	// Generated evaluations are assigned to whoever reviews them first
+	if existing.EvaluatorID == "" {
+		existing.EvaluatorID = c.GetString("user_id")
+	}
 
 	if err := h.service.Update(c.Request.Context(), existing); err != nil {
 		response.InternalServerError(c, err.Error(), nil)
@@ -305,3 +312,127 @@ func (h *VendorEvaluationHandler) GetStats(c *gin.Context) {
 
 	response.OK(c, "Vendor evaluation statistics retrieved successfully", stats)
 }
+
+// Generate handles generating draft evaluations of a period from the suppliers' KPIs,
+// with the user as their evaluator.
+func (h *VendorEvaluationHandler) Generate(c *gin.Context) {
+	var req dto.GenerateVendorEvaluationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	run, err := h.service.GenerateEvaluations(c.Request.Context(), req.PeriodStart, req.PeriodEnd, req.SupplierID, c.GetString("user_id"))
+	if err != nil {
+		vendorEvaluationError(c, "Failed to generate vendor evaluations", err)
+		return
+	}
+
+	response.Created(c, "Vendor evaluations generated successfully", run)
+}
+
+// GetKPIs handles measuring the KPIs of the suppliers over a period (period_start and
+// period_end, YYYY-MM-DD), of a supplier when supplier_id is given.
+func (h *VendorEvaluationHandler) GetKPIs(c *gin.Context) {
+	filter := repositories.VendorKPIFilter{SupplierID: c.Query("supplier_id")}
+	var err error
+	if filter.PeriodStart, err = time.Parse("2006-01-02", c.Query("period_start")); err != nil {
+		response.BadRequest(c, "Invalid period_start, expected YYYY-MM-DD", nil)
+		return
+	}
+	if filter.PeriodEnd, err = time.Parse("2006-01-02", c.Query("period_end")); err != nil {
+		response.BadRequest(c, "Invalid period_end, expected YYYY-MM-DD", nil)
+		return
+	}
+
+	kpis, err := h.service.GetVendorKPIs(c.Request.Context(), filter)
+	if err != nil {
+		vendorEvaluationError(c, "Failed to measure vendor KPIs", err)
+		return
+	}
+
+	response.OK(c, "Vendor KPIs retrieved successfully", kpis)
+}
+
+// GetWeights handles retrieving the weights of the evaluation criteria.
+func (h *VendorEvaluationHandler) GetWeights(c *gin.Context) {
+	weights, err := h.service.GetScoringWeights(c.Request.Context())
+	if err != nil {
+		vendorEvaluationError(c, "Failed to retrieve scoring weights", err)
+		return
+	}
+
+	response.OK(c, "Scoring weights retrieved successfully", weights)
+}
+
+// SetWeights handles setting the weights of the evaluation criteria.
+func (h *VendorEvaluationHandler) SetWeights(c *gin.Context) {
+	var req dto.ScoringWeightsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	weights := &entities.ScoringWeights{
+		Quality:    req.Quality,
+		Delivery:   req.Delivery,
+		Price:      req.Price,
+		Service:    req.Service,
+		Compliance: req.Compliance,
+		UpdatedBy:  c.GetString("user_id"),
+	}
+	if err := h.service.SetScoringWeights(c.Request.Context(), weights); err != nil {
+		vendorEvaluationError(c, "Failed to set scoring weights", err)
+		return
+	}
+
+	response.OK(c, "Scoring weights set successfully", weights)
+}
+
+// GetSupplierScorecard handles retrieving the scorecard of a supplier over time, of the
+// periods within from and to (YYYY-MM-DD) when given.
+func (h *VendorEvaluationHandler) GetSupplierScorecard(c *gin.Context) {
+	from, err := optionalDateQuery(c, "from")
+	if err != nil {
+		response.BadRequest(c, "Invalid from, expected YYYY-MM-DD", nil)
+		return
+	}
+	to, err := optionalDateQuery(c, "to")
+	if err != nil {
+		response.BadRequest(c, "Invalid to, expected YYYY-MM-DD", nil)
+		return
+	}
+
+	scorecard, err := h.service.GetSupplierScorecard(c.Request.Context(), c.Param("supplierId"), from, to)
+	if err != nil {
+		vendorEvaluationError(c, "Failed to retrieve supplier scorecard", err)
+		return
+	}
+
+	response.OK(c, "Supplier scorecard retrieved successfully", scorecard)
+}
+
+// optionalDateQuery parses a YYYY-MM-DD query parameter that may be left out, nil when it is.
+func optionalDateQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// vendorEvaluationError maps vendor evaluation errors to HTTP responses.
+func vendorEvaluationError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrVendorEvaluationNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidScoringWeights), errors.Is(err, entities.ErrInvalidEvaluationPeriod):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
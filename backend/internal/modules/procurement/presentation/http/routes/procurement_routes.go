@@ -42,6 +42,9 @@ func RegisterProcurementRoutes(
 			rfqs.POST("/:id/publish", auth.RequirePermission(rbacSvc, "procurement.rfq.publish"), rfqHandler.Publish)
 			rfqs.POST("/:id/close", auth.RequirePermission(rbacSvc, "procurement.rfq.close"), rfqHandler.Close)
 			rfqs.POST("/:id/cancel", auth.RequirePermission(rbacSvc, "procurement.rfq.cancel"), rfqHandler.Cancel)
+			rfqs.GET("/:id/comparison", auth.RequirePermission(rbacSvc, "procurement.rfq.read"), rfqHandler.CompareBids)
+			rfqs.POST("/:id/award", auth.RequirePermission(rbacSvc, "procurement.rfq.award"), rfqHandler.Award)
+			rfqs.GET("/:id/awards", auth.RequirePermission(rbacSvc, "procurement.rfq.read"), rfqHandler.GetAwards)
 
 			// RFQ Items
 			rfqs.POST("/:id/items", auth.RequirePermission(rbacSvc, "procurement.rfq.update"), rfqHandler.AddItem)
@@ -111,6 +114,12 @@ func RegisterProcurementRoutes(
 			contracts.POST("/:id/activate", auth.RequirePermission(rbacSvc, "procurement.contract.activate"), contractHandler.Activate)
 			contracts.POST("/:id/terminate", auth.RequirePermission(rbacSvc, "procurement.contract.terminate"), contractHandler.Terminate)
 			contracts.POST("/:id/renew", auth.RequirePermission(rbacSvc, "procurement.contract.renew"), contractHandler.Renew)
+			contracts.GET("/:id/items", auth.RequirePermission(rbacSvc, "procurement.contract.read"), contractHandler.GetItems)
+			contracts.POST("/:id/items", auth.RequirePermission(rbacSvc, "procurement.contract.update"), contractHandler.AddItem)
+			contracts.PUT("/:id/items/:itemId", auth.RequirePermission(rbacSvc, "procurement.contract.update"), contractHandler.UpdateItem)
+			contracts.DELETE("/:id/items/:itemId", auth.RequirePermission(rbacSvc, "procurement.contract.update"), contractHandler.DeleteItem)
+			contracts.GET("/:id/consumption", auth.RequirePermission(rbacSvc, "procurement.contract.read"), contractHandler.GetConsumption)
+			contracts.POST("/:id/call-offs", auth.RequirePermission(rbacSvc, "procurement.contract.call-off"), contractHandler.CallOff)
 		}
 
 		// Vendor Evaluations routes
@@ -119,8 +128,13 @@ func RegisterProcurementRoutes(
 			vendorEvaluations.POST("/", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.create"), vendorEvaluationHandler.Create)
 			vendorEvaluations.GET("/", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.list"), vendorEvaluationHandler.GetAll)
 			vendorEvaluations.GET("/stats", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.list"), vendorEvaluationHandler.GetStats)
+			vendorEvaluations.POST("/generate", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.generate"), vendorEvaluationHandler.Generate)
+			vendorEvaluations.GET("/kpis", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.list"), vendorEvaluationHandler.GetKPIs)
+			vendorEvaluations.GET("/weights", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.read"), vendorEvaluationHandler.GetWeights)
+			vendorEvaluations.PUT("/weights", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.configure"), vendorEvaluationHandler.SetWeights)
 			vendorEvaluations.GET("/supplier/:supplierId", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.list"), vendorEvaluationHandler.GetBySupplierID)
 			vendorEvaluations.GET("/supplier/:supplierId/score", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.read"), vendorEvaluationHandler.GetSupplierScore)
+			vendorEvaluations.GET("/supplier/:supplierId/scorecard", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.read"), vendorEvaluationHandler.GetSupplierScorecard)
 			vendorEvaluations.GET("/:id", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.read"), vendorEvaluationHandler.GetByID)
 			vendorEvaluations.PUT("/:id", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.update"), vendorEvaluationHandler.Update)
 			vendorEvaluations.DELETE("/:id", auth.RequirePermission(rbacSvc, "procurement.vendor-evaluation.delete"), vendorEvaluationHandler.Delete)
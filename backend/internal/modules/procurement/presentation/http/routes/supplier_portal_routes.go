@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/procurement/presentation/http/handlers"
+	"malaka/internal/shared/auth"
+)
+
+// RegisterSupplierPortalRoutes registers the supplier portal routes, scoped to the
+// supplier of the signed-in portal account, and the internal routes that manage portal
+// accounts and review what suppliers submit.
+func RegisterSupplierPortalRoutes(
+	router *gin.RouterGroup,
+	handler *handlers.SupplierPortalHandler,
+	rbacSvc *auth.RBACService,
+) {
+	portal := router.Group("/supplier-portal")
+	portal.Use(auth.RequireModuleAccess(rbacSvc, "supplier-portal"), handler.RequireSupplierAccount())
+	{
+		purchaseOrders := portal.Group("/purchase-orders")
+		{
+			purchaseOrders.GET("/", auth.RequirePermission(rbacSvc, "supplier-portal.purchase-order.list"), handler.ListPurchaseOrders)
+			purchaseOrders.GET("/:id", auth.RequirePermission(rbacSvc, "supplier-portal.purchase-order.read"), handler.GetPurchaseOrder)
+			purchaseOrders.POST("/:id/acknowledge", auth.RequirePermission(rbacSvc, "supplier-portal.purchase-order.confirm"), handler.AcknowledgePurchaseOrder)
+			purchaseOrders.POST("/:id/confirm", auth.RequirePermission(rbacSvc, "supplier-portal.purchase-order.confirm"), handler.ConfirmPurchaseOrder)
+		}
+
+		shippingNotices := portal.Group("/shipping-notices")
+		{
+			shippingNotices.POST("/", auth.RequirePermission(rbacSvc, "supplier-portal.shipping-notice.create"), handler.CreateShippingNotice)
+			shippingNotices.GET("/", auth.RequirePermission(rbacSvc, "supplier-portal.shipping-notice.list"), handler.ListSupplierShippingNotices)
+			shippingNotices.GET("/:id", auth.RequirePermission(rbacSvc, "supplier-portal.shipping-notice.list"), handler.GetSupplierShippingNotice)
+		}
+
+		rfqs := portal.Group("/rfqs")
+		{
+			rfqs.GET("/", auth.RequirePermission(rbacSvc, "supplier-portal.rfq.list"), handler.ListRFQs)
+			rfqs.GET("/:id", auth.RequirePermission(rbacSvc, "supplier-portal.rfq.list"), handler.GetRFQ)
+			rfqs.POST("/:id/responses", auth.RequirePermission(rbacSvc, "supplier-portal.rfq.respond"), handler.RespondToRFQ)
+		}
+
+		invoices := portal.Group("/invoices")
+		{
+			invoices.POST("/", auth.RequirePermission(rbacSvc, "supplier-portal.invoice.create"), handler.CreateInvoice)
+			invoices.GET("/", auth.RequirePermission(rbacSvc, "supplier-portal.invoice.list"), handler.ListSupplierInvoices)
+			invoices.GET("/:id", auth.RequirePermission(rbacSvc, "supplier-portal.invoice.list"), handler.GetSupplierInvoice)
+			invoices.POST("/:id/document", auth.RequirePermission(rbacSvc, "supplier-portal.invoice.create"), handler.UploadInvoiceDocument)
+		}
+
+		portal.GET("/payments", auth.RequirePermission(rbacSvc, "supplier-portal.invoice.list"), handler.GetPaymentStatus)
+	}
+
+	procurement := router.Group("/procurement")
+	procurement.Use(auth.RequireModuleAccess(rbacSvc, "procurement"))
+	{
+		accounts := procurement.Group("/supplier-accounts")
+		{
+			accounts.POST("/", auth.RequirePermission(rbacSvc, "procurement.supplier-account.manage"), handler.CreateAccount)
+			accounts.GET("/", auth.RequirePermission(rbacSvc, "procurement.supplier-account.manage"), handler.ListAccounts)
+			accounts.PUT("/:id/status", auth.RequirePermission(rbacSvc, "procurement.supplier-account.manage"), handler.SetAccountStatus)
+		}
+
+		shippingNotices := procurement.Group("/shipping-notices")
+		{
+			shippingNotices.GET("/", auth.RequirePermission(rbacSvc, "procurement.shipping-notice.receive"), handler.ListShippingNotices)
+			shippingNotices.GET("/:id", auth.RequirePermission(rbacSvc, "procurement.shipping-notice.receive"), handler.GetShippingNotice)
+			shippingNotices.POST("/:id/receive", auth.RequirePermission(rbacSvc, "procurement.shipping-notice.receive"), handler.ReceiveShippingNotice)
+		}
+
+		invoices := procurement.Group("/supplier-invoices")
+		{
+			invoices.GET("/", auth.RequirePermission(rbacSvc, "procurement.supplier-invoice.review"), handler.ListInvoices)
+			invoices.GET("/:id", auth.RequirePermission(rbacSvc, "procurement.supplier-invoice.review"), handler.GetInvoice)
+			invoices.POST("/:id/register", auth.RequirePermission(rbacSvc, "procurement.supplier-invoice.review"), handler.RegisterInvoice)
+			invoices.POST("/:id/reject", auth.RequirePermission(rbacSvc, "procurement.supplier-invoice.review"), handler.RejectInvoice)
+		}
+	}
+}
@@ -160,9 +160,11 @@ type SecuritySettings struct {
 
 // TwoFactorAuth represents 2FA settings
 type TwoFactorAuth struct {
-	Enabled     bool     `json:"enabled"`
-	Method      string   `json:"method"`
-	BackupCodes []string `json:"backup_codes,omitempty"`
+	Enabled                bool       `json:"enabled"`
+	Method                 string     `json:"method"`
+	Required               bool       `json:"required"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
 }
 
 // LoginSession represents an active login session
@@ -170,6 +172,9 @@ type LoginSession struct {
 	ID         string    `json:"id"`
 	Device     string    `json:"device"`
 	Location   string    `json:"location"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
 	LastActive time.Time `json:"last_active"`
 	Current    bool      `json:"current"`
 }
@@ -264,14 +269,26 @@ type ChangePasswordRequest struct {
 	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword"`
 }
 
-// Enable2FARequest represents a request to enable 2FA
+// Enable2FARequest represents a request to start 2FA enrollment
 type Enable2FARequest struct {
-	Method string `json:"method" binding:"required,oneof=sms email authenticator"`
+	Method string `json:"method" binding:"required,oneof=authenticator"`
 }
 
-// Enable2FAResponse represents the response when enabling 2FA
+// Enable2FAResponse represents the response when starting 2FA enrollment.
+// 2FA stays inactive until a code is confirmed.
 type Enable2FAResponse struct {
-	QRCode      *string  `json:"qr_code,omitempty"`
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"` // PNG data URL
+}
+
+// Verify2FARequest carries a code from the authenticator app or a recovery code
+type Verify2FARequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse returns one-time recovery codes; they are shown only once
+type RecoveryCodesResponse struct {
 	BackupCodes []string `json:"backup_codes"`
 }
 
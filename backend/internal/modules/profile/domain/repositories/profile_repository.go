@@ -19,15 +19,9 @@ type ProfileRepository interface {
 	UpdateUserSettings(ctx context.Context, userID string, category string, settings map[string]string) error
 
 	// Security operations
-	ChangePassword(ctx context.Context, userID string, currentPassword, newPassword string) error
 	GetSecuritySettings(ctx context.Context, userID string) (*entities.SecuritySettings, error)
 	UpdateSecuritySettings(ctx context.Context, userID string, settings *entities.SecuritySettings) error
-	EnableTwoFactorAuth(ctx context.Context, userID string, method string) (*entities.Enable2FAResponse, error)
-	DisableTwoFactorAuth(ctx context.Context, userID string, password string) error
-
-	// Session operations
-	GetLoginSessions(ctx context.Context, userID string) ([]entities.LoginSession, error)
-	TerminateSession(ctx context.Context, userID string, sessionID string) error
+	VerifyPassword(ctx context.Context, userID string, password string) error
 
 	// Activity operations
 	GetAccountActivity(ctx context.Context, userID string, limit int) ([]entities.AccountActivity, error)
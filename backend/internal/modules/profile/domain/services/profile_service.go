@@ -5,19 +5,45 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"malaka/internal/modules/profile/domain/entities"
 	"malaka/internal/modules/profile/domain/repositories"
+	"malaka/internal/shared/auth"
+	"malaka/internal/shared/uuid"
 )
 
+// SessionManager lists and terminates the login sessions of a user
+type SessionManager interface {
+	ListSessions(ctx context.Context, userID string) ([]auth.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID, reason string) error
+}
+
+// TwoFactorManager manages the TOTP enrollment and recovery codes of a user
+type TwoFactorManager interface {
+	Status(ctx context.Context, userID string) (*auth.MFAStatus, error)
+	BeginEnrollment(ctx context.Context, userID, accountName string) (*auth.MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID string) error
+}
+
+// PasswordManager applies the password policy and history when a password changes
+type PasswordManager interface {
+	ChangePassword(ctx context.Context, userID, newPassword string, meta auth.SessionMetadata) error
+}
+
 // ProfileService provides profile-related business logic
 type ProfileService struct {
-	repo repositories.ProfileRepository
+	repo      repositories.ProfileRepository
+	sessions  SessionManager
+	mfa       TwoFactorManager
+	passwords PasswordManager
 }
 
 // NewProfileService creates a new ProfileService
-func NewProfileService(repo repositories.ProfileRepository) *ProfileService {
-	return &ProfileService{repo: repo}
+func NewProfileService(repo repositories.ProfileRepository, sessions SessionManager, mfa TwoFactorManager, passwords PasswordManager) *ProfileService {
+	return &ProfileService{repo: repo, sessions: sessions, mfa: mfa, passwords: passwords}
 }
 
 // GetProfile retrieves the current user's profile
@@ -119,16 +145,42 @@ func (s *ProfileService) UpdatePrivacySettings(ctx context.Context, userID strin
 	return settings, nil
 }
 
-// GetSecuritySettings retrieves security settings
-func (s *ProfileService) GetSecuritySettings(ctx context.Context, userID string) (*entities.SecuritySettings, error) {
+// GetSecuritySettings retrieves security settings, flagging the caller's own session as current
+func (s *ProfileService) GetSecuritySettings(ctx context.Context, userID, currentSessionID string) (*entities.SecuritySettings, error) {
 	if userID == "" {
 		return nil, errors.New("user ID is required")
 	}
-	return s.repo.GetSecuritySettings(ctx, userID)
+
+	settings, err := s.repo.GetSecuritySettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.GetLoginSessions(ctx, userID, currentSessionID)
+	if err == nil {
+		settings.LoginSessions = sessions
+	}
+
+	// 2FA state comes from the enrollment itself, not from stored preferences
+	settings.TwoFactorAuth = entities.TwoFactorAuth{}
+	if s.mfa != nil {
+		status, err := s.mfa.Status(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get 2FA status: %w", err)
+		}
+		settings.TwoFactorAuth = entities.TwoFactorAuth{
+			Enabled:                status.Enabled,
+			Method:                 status.Method,
+			Required:               status.Required,
+			EnabledAt:              status.EnabledAt,
+			RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+		}
+	}
+	return settings, nil
 }
 
 // UpdateSecuritySettings updates security settings
-func (s *ProfileService) UpdateSecuritySettings(ctx context.Context, userID string, settings *entities.SecuritySettings) (*entities.SecuritySettings, error) {
+func (s *ProfileService) UpdateSecuritySettings(ctx context.Context, userID, currentSessionID string, settings *entities.SecuritySettings) (*entities.SecuritySettings, error) {
 	if userID == "" {
 		return nil, errors.New("user ID is required")
 	}
@@ -138,7 +190,37 @@ func (s *ProfileService) UpdateSecuritySettings(ctx context.Context, userID stri
 		return nil, fmt.Errorf("failed to update security settings: %w", err)
 	}
 
-	return s.repo.GetSecuritySettings(ctx, userID)
+	return s.GetSecuritySettings(ctx, userID, currentSessionID)
+}
+
+// GetLoginSessions retrieves the user's active login sessions
+func (s *ProfileService) GetLoginSessions(ctx context.Context, userID, currentSessionID string) ([]entities.LoginSession, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if s.sessions == nil {
+		return []entities.LoginSession{}, nil
+	}
+
+	sessions, err := s.sessions.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login sessions: %w", err)
+	}
+
+	result := make([]entities.LoginSession, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, entities.LoginSession{
+			ID:         session.ID,
+			Device:     session.Device,
+			Location:   session.IPAddress,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastActive: session.LastSeenAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return result, nil
 }
 
 // GetAppearanceSettings retrieves appearance settings
@@ -199,8 +281,9 @@ func (s *ProfileService) UpdateLanguageSettings(ctx context.Context, userID stri
 	return settings, nil
 }
 
-// ChangePassword changes the user's password
-func (s *ProfileService) ChangePassword(ctx context.Context, userID string, req *entities.ChangePasswordRequest) error {
+// ChangePassword changes the user's password after checking the current one. The new
+// password must satisfy the password policy and must not be a recent password.
+func (s *ProfileService) ChangePassword(ctx context.Context, userID string, req *entities.ChangePasswordRequest, meta auth.SessionMetadata) error {
 	if userID == "" {
 		return errors.New("user ID is required")
 	}
@@ -208,26 +291,82 @@ func (s *ProfileService) ChangePassword(ctx context.Context, userID string, req
 	if req.NewPassword != req.ConfirmPassword {
 		return errors.New("new password and confirmation do not match")
 	}
-
-	if len(req.NewPassword) < 8 {
-		return errors.New("password must be at least 8 characters")
+	if s.passwords == nil {
+		return errors.New("password changes are not available")
 	}
 
-	return s.repo.ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword)
+	if err := s.repo.VerifyPassword(ctx, userID, req.CurrentPassword); err != nil {
+		return errors.New("current password is incorrect")
+	}
+	if err := s.passwords.ChangePassword(ctx, userID, req.NewPassword, meta); err != nil {
+		return err
+	}
+	s.logSecurityActivity(ctx, userID, "password_changed")
+	return nil
 }
 
-// EnableTwoFactorAuth enables 2FA for the user
+// EnableTwoFactorAuth starts TOTP enrollment and returns the secret and QR code for the
+// authenticator app. 2FA is activated by ConfirmTwoFactorAuth.
 func (s *ProfileService) EnableTwoFactorAuth(ctx context.Context, userID string, method string) (*entities.Enable2FAResponse, error) {
 	if userID == "" {
 		return nil, errors.New("user ID is required")
 	}
-
-	validMethods := map[string]bool{"sms": true, "email": true, "authenticator": true}
-	if !validMethods[method] {
+	if method != auth.MFAMethodAuthenticator {
 		return nil, errors.New("invalid 2FA method")
 	}
+	if s.mfa == nil {
+		return nil, errors.New("two-factor authentication is not available")
+	}
 
-	return s.repo.EnableTwoFactorAuth(ctx, userID, method)
+	accountName := userID
+	if profile, err := s.repo.GetProfile(ctx, userID); err == nil && profile != nil && profile.Email != "" {
+		accountName = profile.Email
+	}
+
+	enrollment, err := s.mfa.BeginEnrollment(ctx, userID, accountName)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.Enable2FAResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURL: enrollment.OTPAuthURL,
+		QRCode:     enrollment.QRCode,
+	}, nil
+}
+
+// ConfirmTwoFactorAuth activates 2FA with a first code from the authenticator app
+// and returns the one-time recovery codes
+func (s *ProfileService) ConfirmTwoFactorAuth(ctx context.Context, userID string, code string) (*entities.RecoveryCodesResponse, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if s.mfa == nil {
+		return nil, errors.New("two-factor authentication is not available")
+	}
+
+	codes, err := s.mfa.ConfirmEnrollment(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	s.logSecurityActivity(ctx, userID, "2fa_enabled")
+	return &entities.RecoveryCodesResponse{BackupCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after verifying a current code
+func (s *ProfileService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) (*entities.RecoveryCodesResponse, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if s.mfa == nil {
+		return nil, errors.New("two-factor authentication is not available")
+	}
+
+	codes, err := s.mfa.RegenerateRecoveryCodes(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	s.logSecurityActivity(ctx, userID, "2fa_recovery_codes_regenerated")
+	return &entities.RecoveryCodesResponse{BackupCodes: codes}, nil
 }
 
 // DisableTwoFactorAuth disables 2FA for the user
@@ -238,7 +377,17 @@ func (s *ProfileService) DisableTwoFactorAuth(ctx context.Context, userID string
 	if password == "" {
 		return errors.New("password is required to disable 2FA")
 	}
-	return s.repo.DisableTwoFactorAuth(ctx, userID, password)
+	if s.mfa == nil {
+		return errors.New("two-factor authentication is not available")
+	}
+	if err := s.repo.VerifyPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	if err := s.mfa.Disable(ctx, userID); err != nil {
+		return err
+	}
+	s.logSecurityActivity(ctx, userID, "2fa_disabled")
+	return nil
 }
 
 // TerminateSession terminates a login session
@@ -249,7 +398,30 @@ func (s *ProfileService) TerminateSession(ctx context.Context, userID string, se
 	if sessionID == "" {
 		return errors.New("session ID is required")
 	}
-	return s.repo.TerminateSession(ctx, userID, sessionID)
+	if s.sessions == nil {
+		return auth.ErrSessionNotFound
+	}
+
+	if err := s.sessions.RevokeSession(ctx, userID, sessionID, auth.SessionRevokedTerminated); err != nil {
+		return err
+	}
+
+	return s.repo.LogActivity(ctx, userID, &entities.AccountActivity{
+		ID:        uuid.New().String(),
+		Action:    "session_terminated",
+		Timestamp: time.Now(),
+		Device:    "API",
+	})
+}
+
+// logSecurityActivity records a security change; a failure to log does not undo the change
+func (s *ProfileService) logSecurityActivity(ctx context.Context, userID, action string) {
+	_ = s.repo.LogActivity(ctx, userID, &entities.AccountActivity{
+		ID:        uuid.New().String(),
+		Action:    action,
+		Timestamp: time.Now(),
+		Device:    "API",
+	})
 }
 
 // GetProfileStats retrieves profile statistics
@@ -426,44 +426,12 @@ func (r *ProfileRepositoryImpl) UpdateUserSettings(ctx context.Context, userID s
 	return tx.Commit()
 }
 
-// ChangePassword changes the user's password
-func (r *ProfileRepositoryImpl) ChangePassword(ctx context.Context, userID string, currentPassword, newPassword string) error {
-	// Get current password hash
-	var currentHash string
-	err := r.db.GetContext(ctx, &currentHash, "SELECT password FROM users WHERE id = $1", userID)
-	if err != nil {
-		return fmt.Errorf("failed to get current password: %w", err)
-	}
-
-	// Verify current password
-	if err := bcrypt.CompareHashAndPassword([]byte(currentHash), []byte(currentPassword)); err != nil {
-		return errors.New("current password is incorrect")
-	}
-
-	// Hash new password
-	newHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return fmt.Errorf("failed to hash new password: %w", err)
-	}
-
-	// Update password
-	_, err = r.db.ExecContext(ctx,
-		"UPDATE users SET password = $1, updated_at = $2 WHERE id = $3",
-		string(newHash), time.Now(), userID)
-	if err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
-	}
-
-	return nil
-}
-
 // GetSecuritySettings retrieves security settings
 func (r *ProfileRepositoryImpl) GetSecuritySettings(ctx context.Context, userID string) (*entities.SecuritySettings, error) {
 	settings := &entities.SecuritySettings{
 		TwoFactorAuth: entities.TwoFactorAuth{
-			Enabled:     false,
-			Method:      "",
-			BackupCodes: []string{},
+			Enabled: false,
+			Method:  "",
 		},
 		LoginSessions:    []entities.LoginSession{},
 		PasswordSettings: entities.PasswordSettings{},
@@ -478,12 +446,6 @@ func (r *ProfileRepositoryImpl) GetSecuritySettings(ctx context.Context, userID
 		}
 	}
 
-	// Get login sessions
-	sessions, err := r.GetLoginSessions(ctx, userID)
-	if err == nil {
-		settings.LoginSessions = sessions
-	}
-
 	// Get account activity
 	activity, err := r.GetAccountActivity(ctx, userID, 10)
 	if err == nil {
@@ -514,45 +476,8 @@ func (r *ProfileRepositoryImpl) UpdateSecuritySettings(ctx context.Context, user
 	})
 }
 
-// EnableTwoFactorAuth enables 2FA
-func (r *ProfileRepositoryImpl) EnableTwoFactorAuth(ctx context.Context, userID string, method string) (*entities.Enable2FAResponse, error) {
-	// Generate backup codes
-	backupCodes := make([]string, 10)
-	for i := range backupCodes {
-		backupCodes[i] = uuid.New().String()[:8]
-	}
-
-	// Store 2FA settings
-	twoFa := entities.TwoFactorAuth{
-		Enabled:     true,
-		Method:      method,
-		BackupCodes: backupCodes,
-	}
-	twoFaJSON, _ := json.Marshal(twoFa)
-
-	err := r.UpdateUserSettings(ctx, userID, "security", map[string]string{
-		"two_factor_auth": string(twoFaJSON),
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	response := &entities.Enable2FAResponse{
-		BackupCodes: backupCodes,
-	}
-
-	// For authenticator, we would generate a QR code URL
-	if method == "authenticator" {
-		qrCode := "otpauth://totp/MalakaERP:" + userID + "?secret=BASE32SECRETHERE&issuer=MalakaERP"
-		response.QRCode = &qrCode
-	}
-
-	return response, nil
-}
-
-// DisableTwoFactorAuth disables 2FA
-func (r *ProfileRepositoryImpl) DisableTwoFactorAuth(ctx context.Context, userID string, password string) error {
-	// Verify password first
+// VerifyPassword checks the user's current password
+func (r *ProfileRepositoryImpl) VerifyPassword(ctx context.Context, userID string, password string) error {
 	var currentHash string
 	err := r.db.GetContext(ctx, &currentHash, "SELECT password FROM users WHERE id = $1", userID)
 	if err != nil {
@@ -562,46 +487,7 @@ func (r *ProfileRepositoryImpl) DisableTwoFactorAuth(ctx context.Context, userID
 	if err := bcrypt.CompareHashAndPassword([]byte(currentHash), []byte(password)); err != nil {
 		return errors.New("password is incorrect")
 	}
-
-	// Disable 2FA
-	twoFa := entities.TwoFactorAuth{
-		Enabled:     false,
-		Method:      "",
-		BackupCodes: []string{},
-	}
-	twoFaJSON, _ := json.Marshal(twoFa)
-
-	return r.UpdateUserSettings(ctx, userID, "security", map[string]string{
-		"two_factor_auth": string(twoFaJSON),
-	})
-}
-
-// GetLoginSessions retrieves active login sessions
-func (r *ProfileRepositoryImpl) GetLoginSessions(ctx context.Context, userID string) ([]entities.LoginSession, error) {
-	// In a real implementation, this would query a sessions table
-	// For now, return a placeholder
-	return []entities.LoginSession{
-		{
-			ID:         uuid.New().String(),
-			Device:     "Current Browser",
-			Location:   "Jakarta, Indonesia",
-			LastActive: time.Now(),
-			Current:    true,
-		},
-	}, nil
-}
-
-// TerminateSession terminates a login session
-func (r *ProfileRepositoryImpl) TerminateSession(ctx context.Context, userID string, sessionID string) error {
-	// In a real implementation, this would delete/invalidate the session
-	// For now, just log the activity
-	return r.LogActivity(ctx, userID, &entities.AccountActivity{
-		ID:        uuid.New().String(),
-		Action:    "session_terminated",
-		Timestamp: time.Now(),
-		Location:  "Jakarta, Indonesia",
-		Device:    "API",
-	})
+	return nil
 }
 
 // GetAccountActivity retrieves account activity
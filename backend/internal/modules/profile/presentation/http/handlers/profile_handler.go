@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -222,7 +223,7 @@ func (h *ProfileHandler) GetSecuritySettings(c *gin.Context) {
 		return
 	}
 
-	settings, err := h.service.GetSecuritySettings(c.Request.Context(), userID)
+	settings, err := h.service.GetSecuritySettings(c.Request.Context(), userID, auth.GetSessionID(c))
 	if err != nil {
 		response.InternalServerError(c, err.Error(), nil)
 		return
@@ -245,7 +246,7 @@ func (h *ProfileHandler) UpdateSecuritySettings(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.UpdateSecuritySettings(c.Request.Context(), userID, &settings)
+	result, err := h.service.UpdateSecuritySettings(c.Request.Context(), userID, auth.GetSessionID(c), &settings)
 	if err != nil {
 		response.InternalServerError(c, err.Error(), nil)
 		return
@@ -348,9 +349,15 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	err := h.service.ChangePassword(c.Request.Context(), userID, &req)
+	meta := auth.SessionMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	err := h.service.ChangePassword(c.Request.Context(), userID, &req, meta)
 	if err != nil {
-		if err.Error() == "current password is incorrect" {
+		var policyErr *auth.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			response.BadRequest(c, "New password does not meet the password policy", policyErr.Violations)
+			return
+		}
+		if err.Error() == "current password is incorrect" || errors.Is(err, auth.ErrPasswordReused) {
 			response.BadRequest(c, err.Error(), nil)
 			return
 		}
@@ -377,13 +384,63 @@ func (h *ProfileHandler) EnableTwoFactorAuth(c *gin.Context) {
 
 	result, err := h.service.EnableTwoFactorAuth(c.Request.Context(), userID, req.Method)
 	if err != nil {
+		if errors.Is(err, auth.ErrMFAAlreadyEnabled) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
 
+	response.OK(c, "Scan the QR code and confirm with a code from your authenticator app", result)
+}
+
+// ConfirmTwoFactorAuth handles POST /api/v1/profile/security/2fa/confirm
+func (h *ProfileHandler) ConfirmTwoFactorAuth(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == "" {
+		response.Unauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	var req entities.Verify2FARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Verification code is required", nil)
+		return
+	}
+
+	result, err := h.service.ConfirmTwoFactorAuth(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
 	response.OK(c, "Two-factor authentication enabled successfully", result)
 }
 
+// RegenerateRecoveryCodes handles POST /api/v1/profile/security/2fa/recovery-codes
+func (h *ProfileHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == "" {
+		response.Unauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	var req entities.Verify2FARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Verification code is required", nil)
+		return
+	}
+
+	result, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	response.OK(c, "Recovery codes regenerated successfully", result)
+}
+
 // DisableTwoFactorAuth handles POST /api/v1/profile/security/2fa/disable
 func (h *ProfileHandler) DisableTwoFactorAuth(c *gin.Context) {
 	userID := auth.GetUserID(c)
@@ -406,6 +463,10 @@ func (h *ProfileHandler) DisableTwoFactorAuth(c *gin.Context) {
 			response.BadRequest(c, err.Error(), nil)
 			return
 		}
+		if errors.Is(err, auth.ErrMFARequiredByPolicy) {
+			response.Forbidden(c, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
@@ -413,6 +474,34 @@ func (h *ProfileHandler) DisableTwoFactorAuth(c *gin.Context) {
 	response.OK(c, "Two-factor authentication disabled successfully", nil)
 }
 
+func twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidMFACode), errors.Is(err, auth.ErrMFANotEnrolled), errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, auth.ErrMFALocked):
+		response.Error(c, http.StatusTooManyRequests, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
+
+// GetLoginSessions handles GET /api/v1/profile/security/sessions
+func (h *ProfileHandler) GetLoginSessions(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == "" {
+		response.Unauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	sessions, err := h.service.GetLoginSessions(c.Request.Context(), userID, auth.GetSessionID(c))
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+
+	response.OK(c, "Login sessions retrieved successfully", sessions)
+}
+
 // TerminateSession handles DELETE /api/v1/profile/security/sessions/:sessionId
 func (h *ProfileHandler) TerminateSession(c *gin.Context) {
 	userID := auth.GetUserID(c)
@@ -429,6 +518,10 @@ func (h *ProfileHandler) TerminateSession(c *gin.Context) {
 
 	err := h.service.TerminateSession(c.Request.Context(), userID, sessionID)
 	if err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			response.NotFound(c, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
@@ -0,0 +1,305 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// ConsignmentWarehouseType is the warehouse type holding our stock at a department store.
+const ConsignmentWarehouseType = "consignment"
+
+// Consignment transfer directions: out to the store, or returned to one of our warehouses.
+const (
+	ConsignmentTransferOut    = "out"
+	ConsignmentTransferReturn = "return"
+)
+
+// Statuses of consignment locations, sell-through reports and settlements. A posted
+// sell-through report waits for the next settlement of its location.
+const (
+	ConsignmentLocationActive   = "active"
+	ConsignmentLocationInactive = "inactive"
+	SellThroughPosted           = "posted"
+	SellThroughSettled          = "settled"
+	SettlementInvoiced          = "invoiced"
+)
+
+// ConsignmentAgingBuckets are the upper bounds (in days) of the stock aging buckets;
+// older stock falls in the last, open-ended bucket.
+var ConsignmentAgingBuckets = []int{30, 60, 90}
+
+var (
+	// ErrConsignmentLocationNotFound is returned when a consignment location does not exist.
+	ErrConsignmentLocationNotFound = errors.New("consignment location not found")
+	// ErrInvalidConsignmentLocation wraps validation errors of consignment locations.
+	ErrInvalidConsignmentLocation = errors.New("invalid consignment location")
+	// ErrConsignmentLocationExists is returned when a depstore already has a consignment location.
+	ErrConsignmentLocationExists = errors.New("department store already has a consignment location")
+	// ErrConsignmentTransferNotFound is returned when a consignment transfer does not exist.
+	ErrConsignmentTransferNotFound = errors.New("consignment transfer not found")
+	// ErrInvalidConsignmentTransfer wraps validation errors of consignment transfers.
+	ErrInvalidConsignmentTransfer = errors.New("invalid consignment transfer")
+	// ErrInsufficientConsignmentStock is returned when more is sold or returned than is consigned.
+	ErrInsufficientConsignmentStock = errors.New("insufficient consignment stock")
+	// ErrSellThroughNotFound is returned when a sell-through report does not exist.
+	ErrSellThroughNotFound = errors.New("sell-through report not found")
+	// ErrInvalidSellThrough wraps validation errors of sell-through reports.
+	ErrInvalidSellThrough = errors.New("invalid sell-through report")
+	// ErrSettlementNotFound is returned when a consignment settlement does not exist.
+	ErrSettlementNotFound = errors.New("consignment settlement not found")
+	// ErrNothingToSettle is returned when a location has no unsettled sell-through.
+	ErrNothingToSettle = errors.New("no unsettled sell-through to settle")
+)
+
+// ConsignmentLocation ties a department store to the warehouse holding our consigned
+// stock there and to the customer its settlements are invoiced to.
+type ConsignmentLocation struct {
+	ID             uuid.ID   `json:"id" db:"id"`
+	DepstoreID     uuid.ID   `json:"depstore_id" db:"depstore_id"`
+	WarehouseID    uuid.ID   `json:"warehouse_id" db:"warehouse_id"`
+	CustomerID     uuid.ID   `json:"customer_id" db:"customer_id"`
+	CommissionRate *float64  `json:"commission_rate,omitempty" db:"commission_rate"` // overrides the depstore's rate
+	Status         string    `json:"status" db:"status"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+
+	DepstoreCode           string  `json:"depstore_code" db:"depstore_code"`
+	DepstoreName           string  `json:"depstore_name" db:"depstore_name"`
+	DepstoreCommissionRate float64 `json:"depstore_commission_rate" db:"depstore_commission_rate"`
+	PaymentTerms           string  `json:"payment_terms" db:"payment_terms"`
+	WarehouseCode          string  `json:"warehouse_code" db:"warehouse_code"`
+}
+
+// Commission returns the store's commission in percent of its sales.
+func (l *ConsignmentLocation) Commission() float64 {
+	if l.CommissionRate != nil {
+		return *l.CommissionRate
+	}
+	return l.DepstoreCommissionRate
+}
+
+// DueDays returns the payment term of settlement invoices, read from the depstore's
+// payment terms ("30", "NET 45", "60 hari"), or DefaultInvoiceDueDays.
+func (l *ConsignmentLocation) DueDays() int {
+	digits := strings.FieldsFunc(l.PaymentTerms, func(r rune) bool { return r < '0' || r > '9' })
+	if len(digits) > 0 {
+		if days, err := strconv.Atoi(digits[0]); err == nil && days > 0 {
+			return days
+		}
+	}
+	return DefaultInvoiceDueDays
+}
+
+// ConsignmentTransfer moves stock between one of our warehouses and a consignment location.
+type ConsignmentTransfer struct {
+	ID              uuid.ID                    `json:"id" db:"id"`
+	TransferNumber  string                     `json:"transfer_number" db:"transfer_number"`
+	LocationID      uuid.ID                    `json:"location_id" db:"location_id"`
+	Direction       string                     `json:"direction" db:"direction"`
+	FromWarehouseID uuid.ID                    `json:"from_warehouse_id" db:"from_warehouse_id"`
+	ToWarehouseID   uuid.ID                    `json:"to_warehouse_id" db:"to_warehouse_id"`
+	TransferDate    time.Time                  `json:"transfer_date" db:"transfer_date"`
+	Notes           string                     `json:"notes" db:"notes"`
+	CreatedBy       string                     `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt       time.Time                  `json:"created_at" db:"created_at"`
+	Items           []*ConsignmentTransferItem `json:"items,omitempty" db:"-"`
+}
+
+// ConsignmentTransferItem is the quantity of an article in a consignment transfer.
+type ConsignmentTransferItem struct {
+	ID         uuid.ID `json:"id" db:"id"`
+	TransferID uuid.ID `json:"transfer_id" db:"transfer_id"`
+	ArticleID  uuid.ID `json:"article_id" db:"article_id"`
+	Quantity   int     `json:"quantity" db:"quantity"`
+	UnitPrice  float64 `json:"unit_price" db:"unit_price"` // consigned (retail) price
+}
+
+// ConsignmentLot is the stock of one transfer item still at the store. Sales and
+// returns consume lots first in, first out, which dates the remaining stock for aging.
+type ConsignmentLot struct {
+	ID                uuid.ID   `json:"id" db:"id"`
+	LocationID        uuid.ID   `json:"location_id" db:"location_id"`
+	ArticleID         uuid.ID   `json:"article_id" db:"article_id"`
+	TransferItemID    uuid.ID   `json:"transfer_item_id" db:"transfer_item_id"`
+	ReceivedDate      time.Time `json:"received_date" db:"received_date"`
+	Quantity          int       `json:"quantity" db:"quantity"`
+	RemainingQuantity int       `json:"remaining_quantity" db:"remaining_quantity"`
+	UnitPrice         float64   `json:"unit_price" db:"unit_price"`
+	ArticleCode       string    `json:"article_code,omitempty" db:"article_code"`
+	ArticleName       string    `json:"article_name,omitempty" db:"article_name"`
+}
+
+// LotConsumption is the quantity taken from one lot.
+type LotConsumption struct {
+	Lot      *ConsignmentLot
+	Quantity int
+}
+
+// ConsumeLots takes quantity from the lots, oldest first, and returns what was taken
+// from each. The lots are only changed when they hold enough stock.
+func ConsumeLots(lots []*ConsignmentLot, quantity int) ([]LotConsumption, error) {
+	sorted := make([]*ConsignmentLot, len(lots))
+	copy(sorted, lots)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ReceivedDate.Before(sorted[j].ReceivedDate) })
+
+	var consumed []LotConsumption
+	left := quantity
+	for _, lot := range sorted {
+		if left == 0 {
+			break
+		}
+		if lot.RemainingQuantity <= 0 {
+			continue
+		}
+		take := lot.RemainingQuantity
+		if take > left {
+			take = left
+		}
+		consumed = append(consumed, LotConsumption{Lot: lot, Quantity: take})
+		left -= take
+	}
+	if left > 0 {
+		return nil, fmt.Errorf("%w: %d on consignment, %d requested", ErrInsufficientConsignmentStock, quantity-left, quantity)
+	}
+	for _, c := range consumed {
+		c.Lot.RemainingQuantity -= c.Quantity
+	}
+	return consumed, nil
+}
+
+// ConsumedValue returns the consigned value of the consumed quantities.
+func ConsumedValue(consumed []LotConsumption) float64 {
+	var value float64
+	for _, c := range consumed {
+		value += float64(c.Quantity) * c.Lot.UnitPrice
+	}
+	return value
+}
+
+// ConsignmentSellThrough is a sell-through report of a department store: what the store
+// sold of our consigned stock in a period. It is stored as a consignment sale.
+type ConsignmentSellThrough struct {
+	ID               uuid.ID                       `json:"id" db:"id"`
+	ReportNumber     string                        `json:"report_number" db:"report_number"`
+	LocationID       uuid.ID                       `json:"location_id" db:"location_id"`
+	DepstoreID       uuid.ID                       `json:"depstore_id" db:"consignee_id"`
+	PeriodStart      time.Time                     `json:"period_start" db:"period_start"`
+	PeriodEnd        time.Time                     `json:"period_end" db:"period_end"`
+	GrossAmount      float64                       `json:"gross_amount" db:"total_amount"`
+	CommissionRate   float64                       `json:"commission_rate" db:"commission_rate"`
+	CommissionAmount float64                       `json:"commission_amount" db:"commission_amount"`
+	NetAmount        float64                       `json:"net_amount" db:"net_amount"`
+	Status           string                        `json:"status" db:"status"`
+	SettlementID     *uuid.ID                      `json:"settlement_id,omitempty" db:"settlement_id"`
+	SourceFile       string                        `json:"source_file,omitempty" db:"source_file"`
+	CreatedBy        string                        `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt        time.Time                     `json:"created_at" db:"created_at"`
+	Items            []*ConsignmentSellThroughItem `json:"items,omitempty" db:"-"`
+}
+
+// ConsignmentSellThroughItem is the quantity of an article sold by the store. Without a
+// reported price the article is valued at its consigned price.
+type ConsignmentSellThroughItem struct {
+	ID               uuid.ID `json:"id" db:"id"`
+	SellThroughID    uuid.ID `json:"sell_through_id" db:"sell_through_id"`
+	ArticleID        uuid.ID `json:"article_id" db:"article_id"`
+	Barcode          string  `json:"barcode,omitempty" db:"barcode"`
+	Quantity         int     `json:"quantity" db:"quantity"`
+	UnitPrice        float64 `json:"unit_price" db:"unit_price"`
+	GrossAmount      float64 `json:"gross_amount" db:"gross_amount"`
+	CommissionAmount float64 `json:"commission_amount" db:"commission_amount"`
+	NetAmount        float64 `json:"net_amount" db:"net_amount"`
+}
+
+// ConsignmentSettlement bills a department store for the net sell-through of a period,
+// i.e. its sales less its commission, with a sales invoice.
+type ConsignmentSettlement struct {
+	ID               uuid.ID   `json:"id" db:"id"`
+	SettlementNumber string    `json:"settlement_number" db:"settlement_number"`
+	LocationID       uuid.ID   `json:"location_id" db:"location_id"`
+	SalesInvoiceID   uuid.ID   `json:"sales_invoice_id" db:"sales_invoice_id"`
+	InvoiceNumber    string    `json:"invoice_number" db:"invoice_number"`
+	PeriodStart      time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd        time.Time `json:"period_end" db:"period_end"`
+	GrossAmount      float64   `json:"gross_amount" db:"gross_amount"`
+	CommissionAmount float64   `json:"commission_amount" db:"commission_amount"`
+	NetAmount        float64   `json:"net_amount" db:"net_amount"`
+	TaxAmount        float64   `json:"tax_amount" db:"tax_amount"`
+	GrandTotal       float64   `json:"grand_total" db:"grand_total"`
+	Status           string    `json:"status" db:"status"`
+	CreatedBy        string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	SellThroughIDs   []uuid.ID `json:"sell_through_ids,omitempty" db:"-"`
+}
+
+// SettlementPosting is a settlement with the sales invoice billing it.
+type SettlementPosting struct {
+	Settlement   *ConsignmentSettlement
+	Invoice      *SalesInvoice
+	Items        []*SalesInvoiceItem
+	ReceivableID uuid.ID
+}
+
+// ConsignmentAging is the consigned stock of an article at a location by age.
+type ConsignmentAging struct {
+	LocationID  uuid.ID   `json:"location_id"`
+	ArticleID   uuid.ID   `json:"article_id"`
+	ArticleCode string    `json:"article_code"`
+	ArticleName string    `json:"article_name"`
+	Quantity    int       `json:"quantity"`
+	Value       float64   `json:"value"`
+	OldestDate  time.Time `json:"oldest_date"`
+	Buckets     []int     `json:"buckets"` // quantities per ConsignmentAgingBuckets, plus the older rest
+}
+
+// AgeConsignmentLots groups the remaining stock of the lots by location and article and
+// spreads it over the aging buckets as of the given date.
+func AgeConsignmentLots(lots []*ConsignmentLot, asOf time.Time) []*ConsignmentAging {
+	type key struct{ location, article uuid.ID }
+	byKey := map[key]*ConsignmentAging{}
+	var aging []*ConsignmentAging
+	for _, lot := range lots {
+		if lot.RemainingQuantity <= 0 {
+			continue
+		}
+		k := key{lot.LocationID, lot.ArticleID}
+		a := byKey[k]
+		if a == nil {
+			a = &ConsignmentAging{
+				LocationID:  lot.LocationID,
+				ArticleID:   lot.ArticleID,
+				ArticleCode: lot.ArticleCode,
+				ArticleName: lot.ArticleName,
+				OldestDate:  lot.ReceivedDate,
+				Buckets:     make([]int, len(ConsignmentAgingBuckets)+1),
+			}
+			byKey[k] = a
+			aging = append(aging, a)
+		}
+		a.Quantity += lot.RemainingQuantity
+		a.Value += float64(lot.RemainingQuantity) * lot.UnitPrice
+		if lot.ReceivedDate.Before(a.OldestDate) {
+			a.OldestDate = lot.ReceivedDate
+		}
+
+		days := int(asOf.Sub(lot.ReceivedDate).Hours() / 24)
+		bucket := len(ConsignmentAgingBuckets)
+		for i, limit := range ConsignmentAgingBuckets {
+			if days <= limit {
+				bucket = i
+				break
+			}
+		}
+		a.Buckets[bucket] += lot.RemainingQuantity
+	}
+
+	// Oldest stock first, as that is what needs attention
+	sort.SliceStable(aging, func(i, j int) bool { return aging[i].OldestDate.Before(aging[j].OldestDate) })
+	return aging
+}
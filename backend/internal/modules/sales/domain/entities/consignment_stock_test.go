@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/shared/uuid"
+)
+
+func TestConsignmentLocation_CommissionAndDueDays(t *testing.T) {
+	loc := &ConsignmentLocation{DepstoreCommissionRate: 25}
+	assert.Equal(t, 25.0, loc.Commission())
+	override := 30.0
+	loc.CommissionRate = &override
+	assert.Equal(t, 30.0, loc.Commission())
+
+	for terms, days := range map[string]int{"": DefaultInvoiceDueDays, "45": 45, "NET 60": 60, "14 hari": 14, "COD": DefaultInvoiceDueDays} {
+		loc.PaymentTerms = terms
+		assert.Equal(t, days, loc.DueDays(), terms)
+	}
+}
+
+func TestConsumeLots(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := &ConsignmentLot{ReceivedDate: day.AddDate(0, 1, 0), RemainingQuantity: 5, UnitPrice: 120}
+	older := &ConsignmentLot{ReceivedDate: day, RemainingQuantity: 3, UnitPrice: 100}
+	empty := &ConsignmentLot{ReceivedDate: day.AddDate(0, 0, -10), RemainingQuantity: 0, UnitPrice: 90}
+	lots := []*ConsignmentLot{newer, older, empty}
+
+	_, err := ConsumeLots(lots, 9)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInsufficientConsignmentStock))
+	assert.Equal(t, 5, newer.RemainingQuantity, "lots are left alone when short")
+	assert.Equal(t, 3, older.RemainingQuantity)
+
+	consumed, err := ConsumeLots(lots, 4)
+	require.NoError(t, err)
+	require.Len(t, consumed, 2)
+	assert.Same(t, older, consumed[0].Lot)
+	assert.Equal(t, 3, consumed[0].Quantity)
+	assert.Equal(t, 1, consumed[1].Quantity)
+	assert.Equal(t, 420.0, ConsumedValue(consumed))
+	assert.Equal(t, 0, older.RemainingQuantity)
+	assert.Equal(t, 4, newer.RemainingQuantity)
+}
+
+func TestAgeConsignmentLots(t *testing.T) {
+	asOf := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	location, article := uuid.New(), uuid.New()
+	lot := func(daysOld, remaining int) *ConsignmentLot {
+		return &ConsignmentLot{LocationID: location, ArticleID: article, ReceivedDate: asOf.AddDate(0, 0, -daysOld),
+			RemainingQuantity: remaining, UnitPrice: 10}
+	}
+	other := &ConsignmentLot{LocationID: location, ArticleID: uuid.New(), ReceivedDate: asOf.AddDate(0, 0, -5), RemainingQuantity: 1}
+
+	aging := AgeConsignmentLots([]*ConsignmentLot{other, lot(10, 2), lot(45, 3), lot(90, 4), lot(200, 5), lot(20, 0)}, asOf)
+	require.Len(t, aging, 2)
+	a := aging[0]
+	assert.Equal(t, article, a.ArticleID, "oldest stock first")
+	assert.Equal(t, 14, a.Quantity)
+	assert.Equal(t, 140.0, a.Value)
+	assert.Equal(t, asOf.AddDate(0, 0, -200), a.OldestDate)
+	assert.Equal(t, []int{2, 3, 4, 5}, a.Buckets)
+}
@@ -0,0 +1,184 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// Loyalty member statuses.
+const (
+	LoyaltyMemberActive    = "active"
+	LoyaltyMemberSuspended = "suspended"
+)
+
+// Points ledger entry types. Earn and refund entries add points, the others take
+// points away from the oldest unexpired earn entries first.
+const (
+	LoyaltyEntryEarn    = "earn"
+	LoyaltyEntryRedeem  = "redeem"
+	LoyaltyEntryExpire  = "expire"
+	LoyaltyEntryReverse = "reverse" // earned points taken back after a return
+	LoyaltyEntryRefund  = "refund"  // redeemed points given back after a return or failed sale
+	LoyaltyEntryAdjust  = "adjust"
+)
+
+// Ways points can be redeemed at POS.
+const (
+	// LoyaltyRedeemAsDiscount lowers the transaction total by the value of the points.
+	LoyaltyRedeemAsDiscount = "discount"
+	// LoyaltyRedeemAsPayment keeps the total and uses the points as a tender.
+	LoyaltyRedeemAsPayment = "payment"
+)
+
+// Ledger sources.
+const (
+	LoyaltySourcePOS         = "pos_transaction"
+	LoyaltySourceSalesReturn = "sales_return"
+	LoyaltySourceManual      = "manual"
+	LoyaltySourceExpiry      = "expiry"
+)
+
+var (
+	// ErrLoyaltyMemberNotFound is returned when no member matches an ID, phone or card.
+	ErrLoyaltyMemberNotFound = errors.New("loyalty member not found")
+	// ErrLoyaltyMemberInactive is returned when a suspended member is used at checkout.
+	ErrLoyaltyMemberInactive = errors.New("loyalty member is not active")
+	// ErrInsufficientPoints is returned when a member does not have enough points.
+	ErrInsufficientPoints = errors.New("insufficient loyalty points")
+	// ErrInvalidPointsRedemption is returned when a redemption breaks the program rules.
+	ErrInvalidPointsRedemption = errors.New("invalid loyalty points redemption")
+	// ErrLoyaltyMemberExists is returned when a phone or card is already enrolled.
+	ErrLoyaltyMemberExists = errors.New("a loyalty member with this phone or card already exists")
+	// ErrLoyaltyTierNotFound is returned when a membership tier does not exist.
+	ErrLoyaltyTierNotFound = errors.New("loyalty tier not found")
+	// ErrInvalidLoyaltySetup wraps validation errors of the program, tiers, members and prices.
+	ErrInvalidLoyaltySetup = errors.New("invalid loyalty setup")
+)
+
+// LoyaltyProgram holds the earning and redemption rules of the loyalty program.
+type LoyaltyProgram struct {
+	// EarnSpendUnit is the spend that earns one base point, e.g. 10000 for 1 point per Rp10.000.
+	EarnSpendUnit float64 `json:"earn_spend_unit" db:"earn_spend_unit"`
+	// PointValue is the amount one point is worth when redeemed.
+	PointValue float64 `json:"point_value" db:"point_value"`
+	// PointsExpiryMonths is how long earned points stay valid, 0 means they never expire.
+	PointsExpiryMonths int `json:"points_expiry_months" db:"points_expiry_months"`
+	// TierReviewMonths is the rolling window of qualifying spend for tiers, and the
+	// minimum time a member keeps a tier before being downgraded.
+	TierReviewMonths int `json:"tier_review_months" db:"tier_review_months"`
+	MinRedeemPoints  int `json:"min_redeem_points" db:"min_redeem_points"`
+	// MaxRedeemRate is the largest share of a transaction that can be paid with points.
+	MaxRedeemRate float64 `json:"max_redeem_rate" db:"max_redeem_rate"`
+	// CategoryMultipliers multiplies the points earned on articles of a classification.
+	CategoryMultipliers LoyaltyMultipliers `json:"category_multipliers" db:"category_multipliers"`
+	UpdatedAt           time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultLoyaltyProgram returns the rules used until the program is configured.
+func DefaultLoyaltyProgram() *LoyaltyProgram {
+	return &LoyaltyProgram{
+		EarnSpendUnit:       10000,
+		PointValue:          100,
+		PointsExpiryMonths:  12,
+		TierReviewMonths:    12,
+		MinRedeemPoints:     100,
+		MaxRedeemRate:       0.5,
+		CategoryMultipliers: LoyaltyMultipliers{},
+	}
+}
+
+// LoyaltyMultipliers maps classification IDs to points multipliers. It is stored as JSONB.
+type LoyaltyMultipliers map[string]float64
+
+// Scan implements the sql.Scanner interface
+func (m *LoyaltyMultipliers) Scan(value interface{}) error {
+	*m = LoyaltyMultipliers{}
+	data := jsonBytes(value)
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, m)
+}
+
+// Value implements the driver.Valuer interface
+func (m LoyaltyMultipliers) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+// LoyaltyTier is a membership level. Members reach a tier when their qualifying spend
+// over the review window reaches MinSpend.
+type LoyaltyTier struct {
+	types.BaseModel
+	Code           string  `json:"code" db:"code"`
+	Name           string  `json:"name" db:"name"`
+	Rank           int     `json:"rank" db:"rank"` // higher ranks are better tiers
+	MinSpend       float64 `json:"min_spend" db:"min_spend"`
+	EarnMultiplier float64 `json:"earn_multiplier" db:"earn_multiplier"`
+	// MemberDiscountRate is taken off list prices of articles without a member price.
+	MemberDiscountRate float64 `json:"member_discount_rate" db:"member_discount_rate"`
+	IsActive           bool    `json:"is_active" db:"is_active"`
+}
+
+// LoyaltyMember is a loyalty program member, identified at POS by phone, card barcode
+// or member number.
+type LoyaltyMember struct {
+	types.BaseModel
+	MemberNumber   string     `json:"member_number" db:"member_number"`
+	CardBarcode    string     `json:"card_barcode,omitempty" db:"card_barcode"`
+	Phone          string     `json:"phone" db:"phone"`
+	Name           string     `json:"name" db:"name"`
+	Email          string     `json:"email,omitempty" db:"email"`
+	CustomerID     string     `json:"customer_id,omitempty" db:"customer_id"` // optional link to a master data customer
+	TierID         uuid.ID    `json:"tier_id" db:"tier_id"`
+	TierName       string     `json:"tier_name,omitempty" db:"tier_name"`
+	TierSince      time.Time  `json:"tier_since" db:"tier_since"`
+	PointsBalance  int        `json:"points_balance" db:"points_balance"`
+	LifetimePoints int        `json:"lifetime_points" db:"lifetime_points"`
+	Status         string     `json:"status" db:"status"`
+	JoinedAt       time.Time  `json:"joined_at" db:"joined_at"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty" db:"last_activity_at"`
+}
+
+// LoyaltyMemberPrice is a member-only price of an article for a tier and every tier ranked above it.
+type LoyaltyMemberPrice struct {
+	types.BaseModel
+	TierID    uuid.ID `json:"tier_id" db:"tier_id"`
+	ArticleID uuid.ID `json:"article_id" db:"article_id"`
+	Price     float64 `json:"price" db:"price"`
+}
+
+// LoyaltyPointsEntry is a line of a member's points ledger. Points are positive for
+// earn, refund and positive adjust entries and negative otherwise.
+type LoyaltyPointsEntry struct {
+	ID        uuid.ID `json:"id" db:"id"`
+	MemberID  uuid.ID `json:"member_id" db:"member_id"`
+	EntryType string  `json:"entry_type" db:"entry_type"`
+	Points    int     `json:"points" db:"points"`
+	// RemainingPoints is what is left of a positive entry after redemptions and expiry.
+	RemainingPoints int    `json:"remaining_points" db:"remaining_points"`
+	SourceType      string `json:"source_type" db:"source_type"`
+	SourceID        string `json:"source_id,omitempty" db:"source_id"`
+	// ReferenceID is the POS transaction a return entry relates to.
+	ReferenceID string `json:"reference_id,omitempty" db:"reference_id"`
+	// SourceAmount is the value of the source document, used for proportional reversals.
+	SourceAmount float64 `json:"source_amount" db:"source_amount"`
+	// SpendAmount is the qualifying spend for tiers.
+	SpendAmount float64    `json:"spend_amount" db:"spend_amount"`
+	Description string     `json:"description,omitempty" db:"description"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// LoyaltyEarnLine is a sold line that earns points.
+type LoyaltyEarnLine struct {
+	ArticleID string  `json:"article_id"`
+	Amount    float64 `json:"amount"` // net amount paid for the line
+}
@@ -0,0 +1,203 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// Marketplace channel statuses.
+const (
+	ChannelActive   = "active"
+	ChannelInactive = "inactive"
+)
+
+// Channel order statuses, as adapters report them in channel-neutral terms. Only paid
+// orders are fulfilled; unpaid ones are picked up again once the channel reports them
+// paid. Shipped is only pushed to channels, once an order is handed to the courier.
+const (
+	ChannelOrderUnpaid    = "unpaid"
+	ChannelOrderPaid      = "paid"
+	ChannelOrderCancelled = "cancelled"
+	ChannelOrderShipped   = "shipped"
+)
+
+// Statuses of online orders imported from marketplace channels. Online orders entered
+// by hand keep using PENDING.
+const (
+	OnlineOrderPending   = "PENDING"
+	OnlineOrderReserved  = "RESERVED"
+	OnlineOrderShipped   = "SHIPPED"
+	OnlineOrderCancelled = "CANCELLED"
+)
+
+var (
+	// ErrChannelNotFound is returned when a marketplace channel does not exist.
+	ErrChannelNotFound = errors.New("marketplace channel not found")
+	// ErrInvalidChannel is returned for an invalid marketplace channel.
+	ErrInvalidChannel = errors.New("invalid marketplace channel")
+	// ErrChannelExists is returned when a channel code is already in use.
+	ErrChannelExists = errors.New("marketplace channel already exists")
+	// ErrUnknownAdapter is returned for a channel adapter that is not registered.
+	ErrUnknownAdapter = errors.New("unknown channel adapter")
+	// ErrInvalidWebhook is returned for a webhook notification the adapter rejects.
+	ErrInvalidWebhook = errors.New("invalid webhook notification")
+	// ErrInvalidChannelOrder is returned for a channel order that cannot be imported.
+	ErrInvalidChannelOrder = errors.New("invalid channel order")
+	// ErrUnmappedSKU is returned for a channel SKU without an article mapping.
+	ErrUnmappedSKU = errors.New("channel SKU is not mapped to an article")
+	// ErrOnlineOrderNotFound is returned when an online order does not exist.
+	ErrOnlineOrderNotFound = errors.New("online order not found")
+	// ErrOnlineOrderStatus is returned when an online order is not in a status the operation needs.
+	ErrOnlineOrderStatus = errors.New("online order status does not allow this operation")
+)
+
+// secretSettingMarkers mark the channel settings that are credentials.
+var secretSettingMarkers = []string{"secret", "token", "password", "key"}
+
+// ChannelSettings holds the adapter's configuration of a channel, e.g. shop ID, API
+// credentials or a directory. It is stored as JSONB.
+type ChannelSettings map[string]string
+
+// Redacted returns the settings with credentials masked.
+func (s ChannelSettings) Redacted() ChannelSettings {
+	redacted := make(ChannelSettings, len(s))
+	for k, v := range s {
+		redacted[k] = v
+		for _, marker := range secretSettingMarkers {
+			if strings.Contains(strings.ToLower(k), marker) && v != "" {
+				redacted[k] = "********"
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// Scan implements the sql.Scanner interface
+func (s *ChannelSettings) Scan(value interface{}) error {
+	*s = ChannelSettings{}
+	data := jsonBytes(value)
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, s)
+}
+
+// Value implements the driver.Valuer interface
+func (s ChannelSettings) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(s)
+}
+
+// MarketplaceChannel is a shop on a marketplace we sell through. Its adapter talks to
+// the marketplace; its orders are booked to the channel's customer and fulfilled from
+// its warehouse.
+type MarketplaceChannel struct {
+	ID           uuid.ID         `json:"id" db:"id"`
+	Code         string          `json:"code" db:"code"`
+	Name         string          `json:"name" db:"name"`
+	Adapter      string          `json:"adapter" db:"adapter"`
+	WarehouseID  uuid.ID         `json:"warehouse_id" db:"warehouse_id"`
+	CustomerID   uuid.ID         `json:"customer_id" db:"customer_id"`
+	Settings     ChannelSettings `json:"settings" db:"settings"`
+	Status       string          `json:"status" db:"status"`
+	LastPulledAt *time.Time      `json:"last_pulled_at,omitempty" db:"last_pulled_at"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// ChannelOrder is an order as a channel adapter delivers it.
+type ChannelOrder struct {
+	ExternalOrderID string              `json:"external_order_id"`
+	Status          string              `json:"status"`
+	OrderedAt       time.Time           `json:"ordered_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	BuyerName       string              `json:"buyer_name"`
+	BuyerPhone      string              `json:"buyer_phone"`
+	ShippingAddress string              `json:"shipping_address"`
+	ShippingFee     float64             `json:"shipping_fee"`
+	TrackingNumber  string              `json:"tracking_number"` // airway bill when the channel books the courier
+	Items           []*ChannelOrderItem `json:"items"`
+}
+
+// ChannelOrderItem is a line of a channel order, identified by the channel's SKU.
+type ChannelOrderItem struct {
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// ChannelSKUMapping maps a channel's SKU to one of our articles by its barcode.
+type ChannelSKUMapping struct {
+	ID          uuid.ID   `json:"id" db:"id"`
+	ChannelID   uuid.ID   `json:"channel_id" db:"channel_id"`
+	ChannelSKU  string    `json:"channel_sku" db:"channel_sku"`
+	Barcode     string    `json:"barcode" db:"barcode"`
+	ArticleID   uuid.ID   `json:"article_id" db:"article_id"`
+	ArticleCode string    `json:"article_code,omitempty" db:"article_code"`
+	ArticleName string    `json:"article_name,omitempty" db:"article_name"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// OnlineOrderItem is an article of an online order.
+type OnlineOrderItem struct {
+	ID            uuid.ID `json:"id" db:"id"`
+	OnlineOrderID uuid.ID `json:"online_order_id" db:"online_order_id"`
+	ArticleID     uuid.ID `json:"article_id" db:"article_id"`
+	ChannelSKU    string  `json:"channel_sku" db:"channel_sku"`
+	Quantity      int     `json:"quantity" db:"quantity"`
+	UnitPrice     float64 `json:"unit_price" db:"unit_price"`
+	TotalPrice    float64 `json:"total_price" db:"total_price"`
+}
+
+// ChannelStatusUpdate tells a channel what happened to one of its orders.
+type ChannelStatusUpdate struct {
+	ExternalOrderID string `json:"external_order_id"`
+	Status          string `json:"status"` // ChannelOrderShipped or ChannelOrderCancelled
+	TrackingNumber  string `json:"tracking_number,omitempty"`
+}
+
+// ChannelStockLevel is the quantity of a channel SKU we can sell.
+type ChannelStockLevel struct {
+	SKU       string `json:"sku" db:"channel_sku"`
+	Available int    `json:"available" db:"available"`
+}
+
+// ChannelPrice is the selling price of a channel SKU.
+type ChannelPrice struct {
+	SKU   string  `json:"sku" db:"channel_sku"`
+	Price float64 `json:"price" db:"price"`
+}
+
+// ChannelImportResult sums up an import of channel orders.
+type ChannelImportResult struct {
+	Created    int                   `json:"created"`
+	Duplicates int                   `json:"duplicates"`
+	Cancelled  int                   `json:"cancelled"`
+	Skipped    int                   `json:"skipped"` // not yet paid
+	Failed     []*ChannelImportError `json:"failed,omitempty"`
+}
+
+// ChannelImportError is a channel order that could not be imported.
+type ChannelImportError struct {
+	ExternalOrderID string `json:"external_order_id"`
+	Error           string `json:"error"`
+}
+
+// OnlineOrderShipment hands an online order to shipping: a shipment with the courier and
+// a goods issue from the channel's warehouse.
+type OnlineOrderShipment struct {
+	OnlineOrderID  uuid.ID
+	ShipmentID     uuid.ID
+	GoodsIssueID   uuid.ID
+	CourierID      uuid.ID
+	TrackingNumber string
+	ShippedAt      time.Time
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelSettingsRedacted(t *testing.T) {
+	settings := ChannelSettings{"shop_id": "42", "API_Key": "k", "access_token": "t", "partner_secret": ""}
+	redacted := settings.Redacted()
+	assert.Equal(t, "42", redacted["shop_id"])
+	assert.Equal(t, "********", redacted["API_Key"])
+	assert.Equal(t, "********", redacted["access_token"])
+	assert.Equal(t, "", redacted["partner_secret"], "empty credentials stay empty")
+	assert.Equal(t, "k", settings["API_Key"], "the original is not changed")
+}
+
+func TestChannelSettingsScanValue(t *testing.T) {
+	value, err := ChannelSettings{"dir": "/srv/shop"}.Value()
+	require.NoError(t, err)
+
+	var settings ChannelSettings
+	require.NoError(t, settings.Scan(value))
+	assert.Equal(t, "/srv/shop", settings["dir"])
+
+	require.NoError(t, settings.Scan(nil))
+	assert.Empty(t, settings)
+}
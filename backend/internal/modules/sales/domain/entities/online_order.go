@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
 )
 
 // OnlineOrder represents an online marketplace order entity.
@@ -15,4 +16,15 @@ type OnlineOrder struct {
 	TotalAmount   float64   `json:"total_amount"`
 	Status        string    `json:"status"`
 	CustomerID    string    `json:"customer_id"`
+
+	// Orders imported from a marketplace channel
+	ChannelID       *uuid.ID           `json:"channel_id,omitempty"`
+	WarehouseID     *uuid.ID           `json:"warehouse_id,omitempty"`
+	BuyerName       string             `json:"buyer_name,omitempty"`
+	BuyerPhone      string             `json:"buyer_phone,omitempty"`
+	ShippingAddress string             `json:"shipping_address,omitempty"`
+	ShippingFee     float64            `json:"shipping_fee,omitempty"`
+	TrackingNumber  string             `json:"tracking_number,omitempty"`
+	ShipmentID      *uuid.ID           `json:"shipment_id,omitempty"`
+	Items           []*OnlineOrderItem `json:"items,omitempty"`
 }
@@ -0,0 +1,225 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// Sales order statuses along the order-to-cash flow. An open order is confirmed, which
+// reserves its stock, delivered in one or more deliveries, invoiced per delivery and
+// paid once every invoice is settled.
+const (
+	SalesOrderDraft              = "draft"
+	SalesOrderPending            = "pending"
+	SalesOrderConfirmed          = "confirmed"
+	SalesOrderPartiallyDelivered = "partially_delivered"
+	SalesOrderDelivered          = "delivered"
+	SalesOrderInvoiced           = "invoiced"
+	SalesOrderPaid               = "paid"
+)
+
+// Delivery statuses. A posted delivery is waiting to be invoiced.
+const (
+	DeliveryPosted   = "posted"
+	DeliveryInvoiced = "invoiced"
+)
+
+// Payment statuses of sales invoices.
+const (
+	InvoiceUnpaid        = "unpaid"
+	InvoicePartiallyPaid = "partially_paid"
+	InvoicePaid          = "paid"
+)
+
+// Stock reservation statuses. A reservation is closed once fully delivered.
+const (
+	ReservationOpen   = "open"
+	ReservationClosed = "closed"
+)
+
+// Document types of the order-to-cash document flow.
+const (
+	DocumentQuotation   = "sales_quotation"
+	DocumentSalesOrder  = "sales_order"
+	DocumentDelivery    = "sales_delivery"
+	DocumentShipment    = "shipment"
+	DocumentGoodsIssue  = "goods_issue"
+	DocumentInvoice     = "sales_invoice"
+	DocumentReceivable  = "accounts_receivable"
+	DocumentPayment     = "sales_payment"
+	DocumentCashReceipt = "cash_receipt"
+)
+
+// DefaultInvoiceDueDays is the payment term of sales invoices without a due date.
+const DefaultInvoiceDueDays = 30
+
+var (
+	// ErrSalesOrderNotFound is returned when a sales order does not exist.
+	ErrSalesOrderNotFound = errors.New("sales order not found")
+	// ErrInvalidSalesOrder is returned when an order cannot enter the flow as it is.
+	ErrInvalidSalesOrder = errors.New("invalid sales order")
+	// ErrSalesOrderStatus is returned when a step is not allowed in the order's status.
+	ErrSalesOrderStatus = errors.New("action not allowed in the sales order's status")
+	// ErrInsufficientStock is returned when an order cannot be reserved or delivered from stock.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidDelivery wraps validation errors of deliveries.
+	ErrInvalidDelivery = errors.New("invalid delivery")
+	// ErrDeliveryNotFound is returned when a delivery does not exist.
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	// ErrDeliveryInvoiced is returned when a delivery is invoiced twice.
+	ErrDeliveryInvoiced = errors.New("delivery has already been invoiced")
+	// ErrSalesInvoiceNotFound is returned when a sales invoice does not exist.
+	ErrSalesInvoiceNotFound = errors.New("sales invoice not found")
+	// ErrInvalidInvoice wraps validation errors of sales invoices.
+	ErrInvalidInvoice = errors.New("invalid sales invoice")
+	// ErrInvalidPayment wraps validation errors of customer payments.
+	ErrInvalidPayment = errors.New("invalid payment")
+	// ErrUnknownDocument is returned for a document type outside the order-to-cash flow.
+	ErrUnknownDocument = errors.New("unknown document type")
+	// ErrDocumentNotFound is returned when a document is not part of any sales order.
+	ErrDocumentNotFound = errors.New("document not found")
+)
+
+// SalesOrderLine is a sales order item with its progress through the flow.
+type SalesOrderLine struct {
+	SalesOrderItem
+	WarehouseID *uuid.ID `json:"warehouse_id,omitempty"`
+	Reserved    int      `json:"reserved"` // still reserved, i.e. not yet delivered
+	Delivered   int      `json:"delivered"`
+	Invoiced    int      `json:"invoiced"`
+}
+
+// Open returns the quantity still to be delivered.
+func (l *SalesOrderLine) Open() int {
+	return l.Quantity - l.Delivered
+}
+
+// StockReservation holds stock of a warehouse for a confirmed sales order line.
+type StockReservation struct {
+	ID                uuid.ID   `json:"id" db:"id"`
+	SalesOrderID      uuid.ID   `json:"sales_order_id" db:"sales_order_id"`
+	SalesOrderItemID  uuid.ID   `json:"sales_order_item_id" db:"sales_order_item_id"`
+	ArticleID         uuid.ID   `json:"article_id" db:"article_id"`
+	WarehouseID       uuid.ID   `json:"warehouse_id" db:"warehouse_id"`
+	Quantity          int       `json:"quantity" db:"quantity"`
+	DeliveredQuantity int       `json:"delivered_quantity" db:"delivered_quantity"`
+	Status            string    `json:"status" db:"status"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SalesDelivery is a (partial) delivery of a sales order. Posting it ships the goods
+// with a courier and issues them from the reserving warehouse.
+type SalesDelivery struct {
+	types.BaseModel
+	DeliveryNumber string               `json:"delivery_number" db:"delivery_number"`
+	SalesOrderID   uuid.ID              `json:"sales_order_id" db:"sales_order_id"`
+	CustomerID     string               `json:"customer_id" db:"customer_id"`
+	WarehouseID    uuid.ID              `json:"warehouse_id" db:"warehouse_id"`
+	ShipmentID     uuid.ID              `json:"shipment_id" db:"shipment_id"`
+	GoodsIssueID   uuid.ID              `json:"goods_issue_id" db:"goods_issue_id"`
+	CourierID      uuid.ID              `json:"courier_id" db:"courier_id"`
+	TrackingNumber string               `json:"tracking_number" db:"tracking_number"`
+	DeliveryDate   time.Time            `json:"delivery_date" db:"delivery_date"`
+	Status         string               `json:"status" db:"status"`
+	SalesInvoiceID *uuid.ID             `json:"sales_invoice_id,omitempty" db:"sales_invoice_id"`
+	CreatedBy      string               `json:"created_by,omitempty" db:"created_by"`
+	Items          []*SalesDeliveryItem `json:"items,omitempty" db:"-"`
+}
+
+// SalesDeliveryItem is the quantity of a sales order line in a delivery.
+type SalesDeliveryItem struct {
+	ID               uuid.ID `json:"id" db:"id"`
+	DeliveryID       uuid.ID `json:"delivery_id" db:"delivery_id"`
+	SalesOrderItemID uuid.ID `json:"sales_order_item_id" db:"sales_order_item_id"`
+	ArticleID        uuid.ID `json:"article_id" db:"article_id"`
+	Quantity         int     `json:"quantity" db:"quantity"`
+	UnitPrice        float64 `json:"unit_price" db:"unit_price"`   // net of line discounts
+	TotalPrice       float64 `json:"total_price" db:"total_price"` // before tax
+}
+
+// SalesPayment is a customer payment against a sales invoice, booked as a cash receipt.
+type SalesPayment struct {
+	types.BaseModel
+	SalesInvoiceID uuid.ID   `json:"sales_invoice_id" db:"sales_invoice_id"`
+	CashReceiptID  uuid.ID   `json:"cash_receipt_id" db:"cash_receipt_id"`
+	CashBankID     *uuid.ID  `json:"cash_bank_id,omitempty" db:"cash_bank_id"`
+	PaymentDate    time.Time `json:"payment_date" db:"payment_date"`
+	Amount         float64   `json:"amount" db:"amount"`
+	PaymentMethod  string    `json:"payment_method" db:"payment_method"`
+	Reference      string    `json:"reference" db:"reference"`
+	CreatedBy      string    `json:"created_by,omitempty" db:"created_by"`
+}
+
+// InvoicePosting is a sales invoice for a delivery together with its receivable.
+type InvoicePosting struct {
+	Invoice      *SalesInvoice
+	Items        []*SalesInvoiceItem
+	DeliveryID   uuid.ID
+	ReceivableID uuid.ID
+	PostedBy     string
+}
+
+// DocumentLink identifies a document of the order-to-cash flow.
+type DocumentLink struct {
+	Type   string    `json:"type"`
+	ID     uuid.ID   `json:"id"`
+	Number string    `json:"number,omitempty"`
+	Status string    `json:"status,omitempty"`
+	Amount float64   `json:"amount,omitempty"`
+	Date   time.Time `json:"date"`
+
+	// ParentType and ParentID name the document this one was created from
+	ParentType string   `json:"-"`
+	ParentID   *uuid.ID `json:"-"`
+}
+
+// DocumentFlow is a document with the documents it was created from, nearest first,
+// and the documents created from it.
+type DocumentFlow struct {
+	Document   DocumentLink   `json:"document"`
+	Upstream   []DocumentLink `json:"upstream"`
+	Downstream []DocumentLink `json:"downstream"`
+}
+
+// OrderDocuments are all documents of a sales order, from its quotation to the cash
+// receipts of its payments.
+type OrderDocuments []DocumentLink
+
+// Flow returns the document flow of one of the documents, or nil if it is not part of
+// the order.
+func (d OrderDocuments) Flow(docType string, id uuid.ID) *DocumentFlow {
+	find := func(t string, id uuid.ID) *DocumentLink {
+		for i := range d {
+			if d[i].Type == t && d[i].ID == id {
+				return &d[i]
+			}
+		}
+		return nil
+	}
+
+	doc := find(docType, id)
+	if doc == nil {
+		return nil
+	}
+	flow := &DocumentFlow{Document: *doc, Upstream: []DocumentLink{}, Downstream: []DocumentLink{}}
+	for parent := doc; parent.ParentID != nil; {
+		if parent = find(parent.ParentType, *parent.ParentID); parent == nil {
+			break
+		}
+		flow.Upstream = append(flow.Upstream, *parent)
+	}
+
+	// Documents are listed parents first, so one pass collects all descendants
+	descendant := map[string]bool{doc.Type + doc.ID.String(): true}
+	for _, link := range d {
+		if link.ParentID != nil && descendant[link.ParentType+link.ParentID.String()] {
+			descendant[link.Type+link.ID.String()] = true
+			flow.Downstream = append(flow.Downstream, link)
+		}
+	}
+	return flow
+}
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/shared/uuid"
+)
+
+func TestOrderDocuments_Flow(t *testing.T) {
+	link := func(docType string, parent *DocumentLink) DocumentLink {
+		l := DocumentLink{Type: docType, ID: uuid.New()}
+		if parent != nil {
+			l.ParentType = parent.Type
+			l.ParentID = &parent.ID
+		}
+		return l
+	}
+	quotation := link(DocumentQuotation, nil)
+	order := link(DocumentSalesOrder, &quotation)
+	delivery := link(DocumentDelivery, &order)
+	shipment := link(DocumentShipment, &delivery)
+	invoice := link(DocumentInvoice, &delivery)
+	other := link(DocumentDelivery, &order)
+	payment := link(DocumentPayment, &invoice)
+	receipt := link(DocumentCashReceipt, &payment)
+	documents := OrderDocuments{quotation, order, delivery, other, shipment, invoice, payment, receipt}
+
+	flow := documents.Flow(DocumentInvoice, invoice.ID)
+	require.NotNil(t, flow)
+	assert.Equal(t, invoice.ID, flow.Document.ID)
+	require.Len(t, flow.Upstream, 3)
+	assert.Equal(t, []string{DocumentDelivery, DocumentSalesOrder, DocumentQuotation},
+		[]string{flow.Upstream[0].Type, flow.Upstream[1].Type, flow.Upstream[2].Type})
+	require.Len(t, flow.Downstream, 2)
+	assert.Equal(t, payment.ID, flow.Downstream[0].ID)
+	assert.Equal(t, receipt.ID, flow.Downstream[1].ID)
+
+	// The order leads to every document but the quotation
+	flow = documents.Flow(DocumentSalesOrder, order.ID)
+	require.NotNil(t, flow)
+	assert.Len(t, flow.Upstream, 1)
+	assert.Len(t, flow.Downstream, 6)
+
+	flow = documents.Flow(DocumentDelivery, other.ID)
+	require.NotNil(t, flow)
+	assert.Empty(t, flow.Downstream)
+
+	assert.Nil(t, documents.Flow(DocumentInvoice, uuid.New()))
+}
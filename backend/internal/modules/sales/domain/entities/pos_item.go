@@ -11,6 +11,17 @@ type PosItem struct {
 	PosTransactionID uuid.ID `json:"pos_transaction_id" db:"pos_transaction_id"`
 	ArticleID        uuid.ID `json:"article_id" db:"article_id"`
 	Quantity         int     `json:"quantity" db:"quantity"`
+	ListPrice        float64 `json:"list_price,omitempty" db:"list_price"` // price before member pricing
 	UnitPrice        float64 `json:"unit_price" db:"unit_price"`
-	TotalPrice       float64 `json:"total_price" db:"total_price"`
+	DiscountAmount   float64 `json:"discount_amount" db:"discount_amount"`
+	TotalPrice       float64 `json:"total_price" db:"total_price"` // net of DiscountAmount
+
+	// Lines scanned and voided before payment are kept for audit but not sold.
+	Voided         bool   `json:"voided,omitempty" db:"voided"`
+	VoidReason     string `json:"void_reason,omitempty" db:"void_reason"`
+	VoidApprovedBy string `json:"void_approved_by,omitempty" db:"void_approved_by"`
+	// ReturnedQuantity is the quantity refunded or exchanged so far. On the lines of a
+	// refund, OriginalItemID is the sold line and Quantity and TotalPrice are negative.
+	ReturnedQuantity int    `json:"returned_quantity,omitempty" db:"returned_quantity"`
+	OriginalItemID   string `json:"original_item_id,omitempty" db:"original_item_id"`
 }
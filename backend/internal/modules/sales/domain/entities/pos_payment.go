@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// POS payment methods. A transaction paid with more than one method has the
+// PosPaymentSplit method and one payment per tender.
+const (
+	// PosPaymentCash is the payment method counted in the cash drawer.
+	PosPaymentCash     = "cash"
+	PosPaymentCard     = "card"
+	PosPaymentQRIS     = "qris"
+	PosPaymentEWallet  = "e_wallet"
+	PosPaymentTransfer = "transfer"
+	// PosPaymentGiftVoucher is a gift voucher or store credit note, identified by its reference.
+	PosPaymentGiftVoucher = "gift_voucher"
+	// PosPaymentLoyaltyPoints is the value of loyalty points redeemed as payment.
+	PosPaymentLoyaltyPoints = "loyalty_points"
+	PosPaymentSplit         = "split"
+)
+
+// POS transaction statuses.
+const (
+	PosStatusCompleted = "completed"
+	PosStatusVoided    = "voided"
+)
+
+// POS transaction types. Refunds and exchanges refer to the original receipt.
+const (
+	PosTypeSale     = "sale"
+	PosTypeRefund   = "refund"
+	PosTypeExchange = "exchange"
+)
+
+var (
+	// ErrPosTransactionNotFound is returned when a POS transaction or receipt does not exist.
+	ErrPosTransactionNotFound = errors.New("POS transaction not found")
+	// ErrPosTransactionVoided is returned when a voided transaction is voided or refunded.
+	ErrPosTransactionVoided = errors.New("POS transaction is voided")
+	// ErrPosTransactionLocked is returned when a completed or voided transaction is deleted.
+	ErrPosTransactionLocked = errors.New("completed POS transactions cannot be deleted, void or refund them instead")
+	// ErrInvalidTender wraps payment errors, such as an unknown method or a short payment.
+	ErrInvalidTender = errors.New("invalid payment")
+	// ErrSupervisorOverrideRequired is returned when a void is made without a supervisor's approval.
+	ErrSupervisorOverrideRequired = errors.New("supervisor override required")
+	// ErrInvalidSupervisorOverride is returned when the supervisor credentials are wrong or
+	// the supervisor may not approve overrides.
+	ErrInvalidSupervisorOverride = errors.New("invalid supervisor override")
+	// ErrInvalidPosVoid wraps the reasons a transaction cannot be voided.
+	ErrInvalidPosVoid = errors.New("POS transaction cannot be voided")
+	// ErrInvalidPosRefund wraps the reasons a refund or exchange cannot be made.
+	ErrInvalidPosRefund = errors.New("invalid POS refund")
+)
+
+// PosPayment is one tender of a POS transaction. Refunds paid out to the customer have
+// a negative amount.
+type PosPayment struct {
+	ID               uuid.ID `json:"id" db:"id"`
+	PosTransactionID uuid.ID `json:"pos_transaction_id" db:"pos_transaction_id"`
+	PaymentMethod    string  `json:"payment_method" db:"payment_method"`
+	// Amount is the part of the transaction settled with this tender.
+	Amount float64 `json:"amount" db:"amount"`
+	// Tendered and Change are set on cash payments.
+	Tendered  float64   `json:"tendered,omitempty" db:"tendered"`
+	Change    float64   `json:"change,omitempty" db:"change_amount"`
+	Reference string    `json:"reference,omitempty" db:"reference"` // card approval code, QRIS or voucher reference
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// PosTender is a payment offered at the till, before change is worked out.
+type PosTender struct {
+	PaymentMethod string
+	Amount        float64
+	Reference     string
+}
+
+// SupervisorOverride is the sign-in a supervisor gives at the till to approve a void.
+type SupervisorOverride struct {
+	Email    string
+	Password string
+}
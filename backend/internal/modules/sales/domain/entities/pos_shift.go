@@ -0,0 +1,160 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// POS shift statuses.
+const (
+	PosShiftOpen   = "open"
+	PosShiftClosed = "closed"
+)
+
+// Cash drawer movement types.
+const (
+	PosCashIn  = "cash_in"
+	PosCashOut = "cash_out"
+)
+
+// POS shift report types. An X report can be printed at any time during a shift; the
+// Z report is produced once, when the shift is closed.
+const (
+	PosReportX = "X"
+	PosReportZ = "Z"
+)
+
+// DefaultPosTimezone is the time zone of terminals registered without one (WIB).
+const DefaultPosTimezone = "Asia/Jakarta"
+
+var (
+	// ErrPosTerminalNotFound is returned when a terminal does not exist.
+	ErrPosTerminalNotFound = errors.New("POS terminal not found")
+	// ErrPosTerminalInactive is returned when a shift is opened on a disabled terminal.
+	ErrPosTerminalInactive = errors.New("POS terminal is not active")
+	// ErrPosShiftNotFound is returned when a shift does not exist.
+	ErrPosShiftNotFound = errors.New("POS shift not found")
+	// ErrNoOpenPosShift is returned when a sale is made on a terminal without an open shift.
+	ErrNoOpenPosShift = errors.New("no open shift on this POS terminal")
+	// ErrPosShiftAlreadyOpen is returned when a terminal or cashier already has an open shift.
+	ErrPosShiftAlreadyOpen = errors.New("a shift is already open on this terminal or for this cashier")
+	// ErrPosShiftClosed is returned when a closed shift is changed.
+	ErrPosShiftClosed = errors.New("POS shift is closed")
+	// ErrPosShiftCashierMismatch is returned when a cashier uses another cashier's shift.
+	ErrPosShiftCashierMismatch = errors.New("POS shift belongs to another cashier")
+	// ErrInvalidPosShift wraps validation errors of terminals, shifts and cash movements.
+	ErrInvalidPosShift = errors.New("invalid POS shift operation")
+)
+
+// PosTerminal is a till registered to a store. Sales made on it take stock from the
+// store's warehouse.
+type PosTerminal struct {
+	types.BaseModel
+	Code          string  `json:"code" db:"code"`
+	Name          string  `json:"name" db:"name"`
+	StoreName     string  `json:"store_name" db:"store_name"`
+	WarehouseID   uuid.ID `json:"warehouse_id" db:"warehouse_id"`
+	WarehouseName string  `json:"warehouse_name,omitempty" db:"warehouse_name"`
+	Timezone      string  `json:"timezone" db:"timezone"` // IANA time zone of the store, e.g. Asia/Makassar
+	IsActive      bool    `json:"is_active" db:"is_active"`
+}
+
+// PosShift is a cashier's session on a terminal, from opening the cash drawer with a
+// float to the blind count at close.
+type PosShift struct {
+	types.BaseModel
+	TerminalID   uuid.ID `json:"terminal_id" db:"terminal_id"`
+	TerminalCode string  `json:"terminal_code,omitempty" db:"terminal_code"`
+	// WarehouseID is the terminal's warehouse when the shift was opened.
+	WarehouseID  uuid.ID    `json:"warehouse_id" db:"warehouse_id"`
+	StoreName    string     `json:"store_name,omitempty" db:"store_name"`
+	Timezone     string     `json:"timezone,omitempty" db:"store_timezone"` // time zone of the terminal's store
+	CashierID    uuid.ID    `json:"cashier_id" db:"cashier_id"`
+	Status       string     `json:"status" db:"status"`
+	OpenedAt     time.Time  `json:"opened_at" db:"opened_at"`
+	OpeningFloat float64    `json:"opening_float" db:"opening_float"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	ClosedBy     string     `json:"closed_by,omitempty" db:"closed_by"`
+	// ZNumber is the terminal's running Z report number, set at close.
+	ZNumber      int      `json:"z_number,omitempty" db:"z_number"`
+	ExpectedCash *float64 `json:"expected_cash,omitempty" db:"expected_cash"`
+	CountedCash  *float64 `json:"counted_cash,omitempty" db:"counted_cash"`
+	CashVariance *float64 `json:"cash_variance,omitempty" db:"cash_variance"`
+	Notes        string   `json:"notes,omitempty" db:"notes"`
+	// ZReport is the report frozen at close.
+	ZReport *PosShiftReport `json:"z_report,omitempty" db:"z_report"`
+}
+
+// PosCashMovement is cash put into or taken out of the drawer outside of sales, such as
+// change top-ups or bank drops.
+type PosCashMovement struct {
+	ID           uuid.ID   `json:"id" db:"id"`
+	ShiftID      uuid.ID   `json:"shift_id" db:"shift_id"`
+	MovementType string    `json:"movement_type" db:"movement_type"`
+	Amount       float64   `json:"amount" db:"amount"`
+	Reason       string    `json:"reason" db:"reason"`
+	CreatedBy    string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// PosPaymentTotal is the sales total of one payment method in a shift.
+type PosPaymentTotal struct {
+	PaymentMethod    string  `json:"payment_method" db:"payment_method"`
+	TransactionCount int     `json:"transaction_count" db:"transaction_count"`
+	Amount           float64 `json:"amount" db:"amount"`
+}
+
+// PosShiftSales holds the sales totals of a shift.
+type PosShiftSales struct {
+	TransactionCount int               `json:"transaction_count"`
+	GrossSales       float64           `json:"gross_sales"`
+	DiscountTotal    float64           `json:"discount_total"`
+	TaxTotal         float64           `json:"tax_total"`
+	NetSales         float64           `json:"net_sales"`
+	Payments         []PosPaymentTotal `json:"payments"`
+}
+
+// PosShiftReport is an X or Z report of a shift.
+type PosShiftReport struct {
+	ReportType   string     `json:"report_type"`
+	ShiftID      uuid.ID    `json:"shift_id"`
+	TerminalID   uuid.ID    `json:"terminal_id"`
+	TerminalCode string     `json:"terminal_code"`
+	StoreName    string     `json:"store_name"`
+	CashierID    uuid.ID    `json:"cashier_id"`
+	ZNumber      int        `json:"z_number,omitempty"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	GeneratedAt  time.Time  `json:"generated_at"`
+	PosShiftSales
+	OpeningFloat float64 `json:"opening_float"`
+	CashSales    float64 `json:"cash_sales"`
+	CashIn       float64 `json:"cash_in"`
+	CashOut      float64 `json:"cash_out"`
+	ExpectedCash float64 `json:"expected_cash"`
+	// CountedCash and Variance are only set on Z reports; variance is counted minus expected.
+	CountedCash *float64 `json:"counted_cash,omitempty"`
+	Variance    *float64 `json:"variance,omitempty"`
+}
+
+// Scan implements the sql.Scanner interface
+func (r *PosShiftReport) Scan(value interface{}) error {
+	data := jsonBytes(value)
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, r)
+}
+
+// Value implements the driver.Valuer interface
+func (r *PosShiftReport) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
@@ -0,0 +1,170 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// Catalog entities sent to POS terminals for offline selling.
+const (
+	PosSyncArticle   = "article"
+	PosSyncPrice     = "price"
+	PosSyncBarcode   = "barcode"
+	PosSyncPromotion = "promotion"
+)
+
+// Catalog change operations.
+const (
+	PosSyncUpsert = "upsert"
+	PosSyncDelete = "delete"
+)
+
+// Results of an uploaded offline transaction. Rejected uploads can be sent again.
+const (
+	PosUploadAccepted = "accepted"
+	PosUploadRejected = "rejected"
+)
+
+// Conflicts found when an offline transaction is booked. The sale is kept as the
+// customer paid it; conflicts are flagged for review at HQ.
+const (
+	// PosConflictPrice means the terminal sold at a price other than the current price.
+	PosConflictPrice = "price_changed"
+	// PosConflictStock means the store's warehouse did not have the stock sold.
+	PosConflictStock = "insufficient_stock"
+	// PosConflictShiftClosed means the sale was booked in a shift closed before it arrived.
+	PosConflictShiftClosed = "shift_closed"
+)
+
+// ErrInvalidPosSync wraps validation errors of catalog downloads and transaction uploads.
+var ErrInvalidPosSync = errors.New("invalid POS sync request")
+
+// PosSyncChange is a version of a catalog entity. Versions increase with every change,
+// so a terminal downloads the changes after the last version it has.
+type PosSyncChange struct {
+	Version    int64     `json:"version" db:"version"`
+	EntityType string    `json:"entity_type" db:"entity_type"`
+	EntityID   uuid.ID   `json:"entity_id" db:"entity_id"`
+	Operation  string    `json:"operation" db:"operation"`
+	ChangedAt  time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// PosCatalogArticle is an article as the terminal needs it to sell offline.
+type PosCatalogArticle struct {
+	ID               uuid.ID   `json:"id" db:"id"`
+	Code             string    `json:"code" db:"code"`
+	Name             string    `json:"name" db:"name"`
+	Brand            string    `json:"brand" db:"brand"`
+	Category         string    `json:"category" db:"category"`
+	ClassificationID uuid.ID   `json:"classification_id" db:"classification_id"`
+	ColorID          uuid.ID   `json:"color_id" db:"color_id"`
+	SizeID           uuid.ID   `json:"size_id" db:"size_id"`
+	Barcode          string    `json:"barcode" db:"barcode"`
+	Price            float64   `json:"price" db:"price"`
+	Status           string    `json:"status" db:"status"`
+	ThumbnailURL     string    `json:"thumbnail_url,omitempty" db:"thumbnail_url"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PosCatalogPrice is a dated price of an article.
+type PosCatalogPrice struct {
+	ID            uuid.ID   `json:"id" db:"id"`
+	ArticleID     uuid.ID   `json:"article_id" db:"article_id"`
+	Amount        float64   `json:"amount" db:"amount"`
+	Currency      string    `json:"currency" db:"currency"`
+	EffectiveDate time.Time `json:"effective_date" db:"effective_date"`
+}
+
+// PosCatalogBarcode is an additional barcode of an article.
+type PosCatalogBarcode struct {
+	ID        uuid.ID `json:"id" db:"id"`
+	ArticleID uuid.ID `json:"article_id" db:"article_id"`
+	Code      string  `json:"code" db:"code"`
+}
+
+// PosSyncDeletion is a catalog entity the terminal should drop.
+type PosSyncDeletion struct {
+	EntityType string  `json:"entity_type"`
+	EntityID   uuid.ID `json:"entity_id"`
+}
+
+// PosCatalogDelta is a page of catalog changes. The terminal sends Cursor back as the
+// version it has and asks again while HasMore is set.
+type PosCatalogDelta struct {
+	Since      int64                `json:"since"`
+	Cursor     int64                `json:"cursor"`
+	HasMore    bool                 `json:"has_more"`
+	Articles   []*PosCatalogArticle `json:"articles"`
+	Prices     []*PosCatalogPrice   `json:"prices"`
+	Barcodes   []*PosCatalogBarcode `json:"barcodes"`
+	Promotions []*Promotion         `json:"promotions"`
+	Deleted    []PosSyncDeletion    `json:"deleted"`
+}
+
+// PosSyncConflict is a difference between an offline sale and the server's data.
+type PosSyncConflict struct {
+	Type         string  `json:"type"`
+	ArticleID    string  `json:"article_id,omitempty"`
+	ShiftID      string  `json:"shift_id,omitempty"`
+	OfflinePrice float64 `json:"offline_price,omitempty"`
+	CurrentPrice float64 `json:"current_price,omitempty"`
+	Quantity     int     `json:"quantity,omitempty"`
+	Available    int     `json:"available,omitempty"`
+}
+
+// PosSyncConflicts is stored as JSONB.
+type PosSyncConflicts []PosSyncConflict
+
+// Scan implements the sql.Scanner interface
+func (c *PosSyncConflicts) Scan(value interface{}) error {
+	*c = nil
+	data := jsonBytes(value)
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, c)
+}
+
+// Value implements the driver.Valuer interface
+func (c PosSyncConflicts) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// PosSyncUpload is the result of an offline transaction uploaded by a terminal, keyed by
+// the ID the terminal gave the transaction. Accepted transactions are booked under that ID.
+type PosSyncUpload struct {
+	ClientTransactionID uuid.ID          `json:"client_transaction_id" db:"client_transaction_id"`
+	TerminalID          uuid.ID          `json:"terminal_id" db:"terminal_id"`
+	ReceiptNumber       string           `json:"receipt_number,omitempty" db:"receipt_number"`
+	Status              string           `json:"status" db:"status"`
+	Conflicts           PosSyncConflicts `json:"conflicts,omitempty" db:"conflicts"`
+	Error               string           `json:"error,omitempty" db:"error"`
+	Attempts            int              `json:"attempts" db:"attempts"`
+	SoldAt              time.Time        `json:"sold_at" db:"sold_at"`
+	ReceivedAt          time.Time        `json:"received_at" db:"received_at"`
+}
+
+// PosTerminalSyncStatus shows how far a terminal is behind the catalog and how its
+// offline uploads went.
+type PosTerminalSyncStatus struct {
+	TerminalID          uuid.ID    `json:"terminal_id" db:"terminal_id"`
+	TerminalCode        string     `json:"terminal_code" db:"terminal_code"`
+	TerminalName        string     `json:"terminal_name" db:"terminal_name"`
+	StoreName           string     `json:"store_name" db:"store_name"`
+	ClientVersion       string     `json:"client_version,omitempty" db:"client_version"`
+	LastDownloadVersion int64      `json:"last_download_version" db:"last_download_version"`
+	LastDownloadAt      *time.Time `json:"last_download_at,omitempty" db:"last_download_at"`
+	LastUploadAt        *time.Time `json:"last_upload_at,omitempty" db:"last_upload_at"`
+	AcceptedCount       int        `json:"accepted_count" db:"accepted_count"`
+	RejectedCount       int        `json:"rejected_count" db:"rejected_count"`
+	ConflictCount       int        `json:"conflict_count" db:"conflict_count"`
+	// VersionsBehind is the number of catalog changes the terminal has not downloaded.
+	VersionsBehind int64 `json:"versions_behind" db:"-"`
+}
@@ -29,4 +29,35 @@ type PosTransaction struct {
 	CommissionRate   float64   `json:"commission_rate,omitempty" db:"commission_rate"`
 	CommissionAmount float64   `json:"commission_amount,omitempty" db:"commission_amount"`
 	Notes            string    `json:"notes,omitempty" db:"notes"`
+	CustomerID       string    `json:"customer_id,omitempty" db:"customer_id"`
+	TerminalID       string    `json:"terminal_id,omitempty" db:"terminal_id"`
+	ShiftID          string    `json:"shift_id,omitempty" db:"shift_id"`
+
+	// Receipt and lifecycle. Refunds and exchanges point to the original transaction.
+	ReceiptNumber         string     `json:"receipt_number,omitempty" db:"receipt_number"`
+	Status                string     `json:"status,omitempty" db:"status"`
+	TransactionType       string     `json:"transaction_type,omitempty" db:"transaction_type"`
+	OriginalTransactionID string     `json:"original_transaction_id,omitempty" db:"original_transaction_id"`
+	AmountTendered        float64    `json:"amount_tendered,omitempty" db:"amount_tendered"`
+	ChangeAmount          float64    `json:"change_amount,omitempty" db:"change_amount"`
+	VoidedAt              *time.Time `json:"voided_at,omitempty" db:"voided_at"`
+	VoidedBy              string     `json:"voided_by,omitempty" db:"voided_by"`
+	VoidApprovedBy        string     `json:"void_approved_by,omitempty" db:"void_approved_by"`
+	VoidReason            string     `json:"void_reason,omitempty" db:"void_reason"`
+
+	// Loyalty member of the sale and the points earned and redeemed on it
+	MemberID             string  `json:"member_id,omitempty" db:"member_id"`
+	MemberIdentifier     string  `json:"member_identifier,omitempty" db:"-"` // phone or card scanned at checkout
+	PointsEarned         int     `json:"points_earned,omitempty" db:"points_earned"`
+	PointsRedeemed       int     `json:"points_redeemed,omitempty" db:"points_redeemed"`
+	PointsValue          float64 `json:"points_value,omitempty" db:"points_value"`
+	PointsRedemptionMode string  `json:"points_redemption_mode,omitempty" db:"points_redemption_mode"`
+
+	// Tenders offered at checkout and the payments recorded for them
+	Tenders  []PosTender   `json:"-" db:"-"`
+	Payments []*PosPayment `json:"payments,omitempty" db:"-"`
+
+	// Voucher codes entered at checkout and the promotions the engine applied
+	VoucherCodes      []string           `json:"voucher_codes,omitempty" db:"-"`
+	AppliedPromotions []AppliedPromotion `json:"applied_promotions,omitempty" db:"-"`
 }
@@ -1,18 +1,215 @@
 package entities
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
 )
 
+// Promotion types supported by the promotion engine.
+const (
+	// PromotionTypePercentage takes DiscountRate off every eligible line.
+	PromotionTypePercentage = "percentage"
+	// PromotionTypeFixedAmount takes DiscountAmount off the eligible lines, spread by value.
+	PromotionTypeFixedAmount = "fixed_amount"
+	// PromotionTypeBuyXGetY discounts GetQuantity of every BuyQuantity+GetQuantity eligible
+	// units by DiscountRate (1 = free). The cheapest units are discounted.
+	PromotionTypeBuyXGetY = "buy_x_get_y"
+	// PromotionTypeBundle sells every BundleQuantity eligible units for BundlePrice.
+	PromotionTypeBundle = "bundle"
+	// PromotionTypeTieredSpend applies the highest tier whose MinSpend the eligible lines reach.
+	PromotionTypeTieredSpend = "tiered_spend"
+)
+
+// Redemption sources.
+const (
+	PromotionSourcePOS        = "pos_transaction"
+	PromotionSourceSalesOrder = "sales_order"
+)
+
+// ErrPromotionExhausted is returned when a promotion ran out of redemptions or budget
+// between evaluation and redemption.
+var ErrPromotionExhausted = errors.New("promotion redemption limit or budget exhausted")
+
+// ErrInvalidPromotion is returned when a promotion lacks the settings its type needs.
+var ErrInvalidPromotion = errors.New("invalid promotion")
+
+// ErrVoucherNotApplicable is returned at checkout when an entered voucher code does not
+// match a promotion that applies to the cart.
+var ErrVoucherNotApplicable = errors.New("voucher code is not applicable")
+
 // Promotion represents a promotion/discount entity.
 type Promotion struct {
 	types.BaseModel
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	StartDate   time.Time `json:"start_date"`
-	EndDate     time.Time `json:"end_date"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
 	DiscountRate float64   `json:"discount_rate"`
-	MinPurchase float64   `json:"min_purchase"`
+	MinPurchase  float64   `json:"min_purchase"`
+
+	// Code is a voucher code; promotions with a code only apply when the code is entered.
+	Code           string              `json:"code,omitempty"`
+	PromotionType  string              `json:"promotion_type"`
+	Priority       int                 `json:"priority"`  // higher priorities are evaluated first
+	Stackable      bool                `json:"stackable"` // non-stackable promotions never combine with others
+	IsActive       bool                `json:"is_active"`
+	DiscountAmount float64             `json:"discount_amount"`
+	MaxDiscount    float64             `json:"max_discount"` // 0 means no cap
+	BuyQuantity    int                 `json:"buy_quantity"`
+	GetQuantity    int                 `json:"get_quantity"`
+	BundleQuantity int                 `json:"bundle_quantity"`
+	BundlePrice    float64             `json:"bundle_price"`
+	Tiers          PromotionTiers      `json:"tiers,omitempty"`
+	Conditions     PromotionConditions `json:"conditions"`
+
+	MaxRedemptions  int     `json:"max_redemptions"` // 0 means unlimited
+	RedemptionCount int     `json:"redemption_count"`
+	Budget          float64 `json:"budget"` // total discount the promotion may give, 0 means unlimited
+	BudgetUsed      float64 `json:"budget_used"`
+}
+
+// PromotionTier is one step of a tiered spend promotion. Either DiscountRate or
+// DiscountAmount is applied.
+type PromotionTier struct {
+	MinSpend       float64 `json:"min_spend"`
+	DiscountRate   float64 `json:"discount_rate,omitempty"`
+	DiscountAmount float64 `json:"discount_amount,omitempty"`
+}
+
+// PromotionTiers is stored as JSONB.
+type PromotionTiers []PromotionTier
+
+// Scan implements the sql.Scanner interface
+func (t *PromotionTiers) Scan(value interface{}) error {
+	*t = nil
+	data := jsonBytes(value)
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, t)
+}
+
+// Value implements the driver.Valuer interface
+func (t PromotionTiers) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+// PromotionConditions restricts where and to what a promotion applies. Empty lists
+// match everything.
+type PromotionConditions struct {
+	ArticleIDs        []string `json:"article_ids,omitempty"`
+	Brands            []string `json:"brands,omitempty"`
+	ClassificationIDs []string `json:"classification_ids,omitempty"`
+	SizeIDs           []string `json:"size_ids,omitempty"`
+	StoreIDs          []string `json:"store_ids,omitempty"`
+	CustomerIDs       []string `json:"customer_ids,omitempty"`
+	PaymentMethods    []string `json:"payment_methods,omitempty"`
+	// Happy hour: weekdays (0 = Sunday) and a daily "15:04" window, in the cart's time zone
+	DaysOfWeek []int  `json:"days_of_week,omitempty"`
+	StartTime  string `json:"start_time,omitempty"`
+	EndTime    string `json:"end_time,omitempty"`
+}
+
+// Scan implements the sql.Scanner interface
+func (c *PromotionConditions) Scan(value interface{}) error {
+	*c = PromotionConditions{}
+	data := jsonBytes(value)
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, c)
+}
+
+// Value implements the driver.Valuer interface
+func (c PromotionConditions) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func jsonBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return nil
+}
+
+// PromotionRedemption records a promotion applied to a POS transaction or sales order.
+type PromotionRedemption struct {
+	ID             uuid.ID   `json:"id" db:"id"`
+	PromotionID    uuid.ID   `json:"promotion_id" db:"promotion_id"`
+	SourceType     string    `json:"source_type" db:"source_type"`
+	SourceID       uuid.ID   `json:"source_id" db:"source_id"`
+	CustomerID     string    `json:"customer_id,omitempty" db:"customer_id"`
+	VoucherCode    string    `json:"voucher_code,omitempty" db:"voucher_code"`
+	DiscountAmount float64   `json:"discount_amount" db:"discount_amount"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// PromotionCart is the input of the promotion engine.
+type PromotionCart struct {
+	CustomerID    string              `json:"customer_id,omitempty"`
+	StoreID       string              `json:"store_id,omitempty"`
+	PaymentMethod string              `json:"payment_method,omitempty"`
+	VoucherCodes  []string            `json:"voucher_codes,omitempty"`
+	At            time.Time           `json:"at"`
+	Timezone      string              `json:"timezone,omitempty"` // store's time zone for happy hours, server time when empty
+	Lines         []PromotionCartLine `json:"lines"`
+}
+
+// PromotionCartLine is an article in the cart. Brand, classification and size are
+// looked up from the article when left empty.
+type PromotionCartLine struct {
+	ArticleID        string  `json:"article_id"`
+	Brand            string  `json:"brand,omitempty"`
+	ClassificationID string  `json:"classification_id,omitempty"`
+	SizeID           string  `json:"size_id,omitempty"`
+	Quantity         int     `json:"quantity"`
+	UnitPrice        float64 `json:"unit_price"`
+}
+
+// PromotionLineDiscount is the part of a promotion's discount allocated to one cart line.
+type PromotionLineDiscount struct {
+	LineIndex int     `json:"line_index"`
+	ArticleID string  `json:"article_id"`
+	Amount    float64 `json:"amount"`
+}
+
+// AppliedPromotion is a promotion the engine applied to the cart.
+type AppliedPromotion struct {
+	PromotionID   uuid.ID                 `json:"promotion_id"`
+	Name          string                  `json:"name"`
+	PromotionType string                  `json:"promotion_type"`
+	VoucherCode   string                  `json:"voucher_code,omitempty"`
+	Discount      float64                 `json:"discount"`
+	Allocations   []PromotionLineDiscount `json:"allocations"`
+}
+
+// PromotionEvaluatedLine is a cart line after all promotions.
+type PromotionEvaluatedLine struct {
+	ArticleID string  `json:"article_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Gross     float64 `json:"gross"`
+	Discount  float64 `json:"discount"`
+	Net       float64 `json:"net"`
+}
+
+// PromotionEvaluation is the result of running the promotion engine on a cart.
+type PromotionEvaluation struct {
+	Subtotal             float64                  `json:"subtotal"`
+	TotalDiscount        float64                  `json:"total_discount"`
+	Total                float64                  `json:"total"`
+	Applied              []AppliedPromotion       `json:"applied_promotions"`
+	Lines                []PromotionEvaluatedLine `json:"lines"`
+	RejectedVoucherCodes []string                 `json:"rejected_voucher_codes,omitempty"` // entered codes that did not apply
 }
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
 )
 
 // SalesInvoice represents a sales invoice entity.
@@ -14,4 +15,17 @@ type SalesInvoice struct {
 	TotalAmount  float64   `json:"total_amount"`
 	TaxAmount    float64   `json:"tax_amount"`
 	GrandTotal   float64   `json:"grand_total"`
+
+	// Invoices of the order-to-cash flow bill one delivery and track their payment
+	InvoiceNumber string     `json:"invoice_number,omitempty"`
+	CustomerID    string     `json:"customer_id,omitempty"`
+	DeliveryID    *uuid.ID   `json:"delivery_id,omitempty"`
+	DueDate       *time.Time `json:"due_date,omitempty"`
+	PaidAmount    float64    `json:"paid_amount"`
+	Status        string     `json:"status,omitempty"`
+}
+
+// Balance returns the amount still to be paid.
+func (i *SalesInvoice) Balance() float64 {
+	return i.GrandTotal - i.PaidAmount
 }
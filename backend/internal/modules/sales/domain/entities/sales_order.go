@@ -4,13 +4,24 @@ import (
 	"time"
 
 	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
 )
 
 // SalesOrder represents a sales order entity.
 type SalesOrder struct {
 	types.BaseModel
-	CustomerID  string    `json:"customer_id"`
-	OrderDate   time.Time `json:"order_date"`
-	Status      string    `json:"status"`
-	TotalAmount float64   `json:"total_amount"`
+	CustomerID     string    `json:"customer_id"`
+	OrderDate      time.Time `json:"order_date"`
+	Status         string    `json:"status"`
+	Subtotal       float64   `json:"subtotal"`
+	DiscountAmount float64   `json:"discount_amount"`
+	TaxAmount      float64   `json:"tax_amount"`
+	TotalAmount    float64   `json:"total_amount"`
+
+	// QuotationID links an order converted from a quotation back to it
+	QuotationID *uuid.ID `json:"quotation_id,omitempty"`
+
+	// Voucher codes entered at checkout and the promotions the engine applied
+	VoucherCodes      []string           `json:"voucher_codes,omitempty"`
+	AppliedPromotions []AppliedPromotion `json:"applied_promotions,omitempty"`
 }
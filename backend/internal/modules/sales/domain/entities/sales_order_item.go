@@ -7,9 +7,10 @@ import (
 // SalesOrderItem represents a sales order item entity.
 type SalesOrderItem struct {
 	types.BaseModel
-	SalesOrderID string  `json:"sales_order_id"`
-	ArticleID    string  `json:"article_id"`
-	Quantity     int     `json:"quantity"`
-	UnitPrice    float64 `json:"unit_price"`
-	TotalPrice   float64 `json:"total_price"`
+	SalesOrderID   string  `json:"sales_order_id"`
+	ArticleID      string  `json:"article_id"`
+	Quantity       int     `json:"quantity"`
+	UnitPrice      float64 `json:"unit_price"`
+	DiscountAmount float64 `json:"discount_amount"`
+	TotalPrice     float64 `json:"total_price"` // net of DiscountAmount
 }
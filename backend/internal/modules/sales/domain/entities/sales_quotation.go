@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"malaka/internal/shared/types"
+	"malaka/internal/shared/uuid"
+)
+
+// Quotation statuses. A quotation is drafted, sent to the customer and accepted, or
+// expires when its validity date passes. Revising a quotation supersedes it with the
+// next version; an accepted quotation is converted into a sales order once.
+const (
+	QuotationDraft      = "draft"
+	QuotationSent       = "sent"
+	QuotationAccepted   = "accepted"
+	QuotationExpired    = "expired"
+	QuotationSuperseded = "superseded"
+	QuotationConverted  = "converted"
+)
+
+// Defaults of new quotations.
+const (
+	// QuotationDefaultValidityDays is how long a quotation is valid without a validity date.
+	QuotationDefaultValidityDays = 30
+	// QuotationDefaultTaxRate is the PPN rate in percent used without a tax rate.
+	QuotationDefaultTaxRate = 11.0
+)
+
+var (
+	// ErrQuotationNotFound is returned when a quotation does not exist.
+	ErrQuotationNotFound = errors.New("quotation not found")
+	// ErrInvalidQuotation wraps validation errors of quotations and their lines.
+	ErrInvalidQuotation = errors.New("invalid quotation")
+	// ErrQuotationStatus is returned when an action is not allowed in the quotation's status.
+	ErrQuotationStatus = errors.New("action not allowed in the quotation's status")
+	// ErrQuotationExpired is returned when a quotation is used after its validity date.
+	ErrQuotationExpired = errors.New("quotation has expired")
+	// ErrQuotationNoPrice is returned when a line has no price and the article has none.
+	ErrQuotationNoPrice = errors.New("article has no price")
+)
+
+// SalesQuotation is a version of a price offer to a customer. Revisions share the
+// quotation number and count up the version.
+type SalesQuotation struct {
+	types.BaseModel
+	QuotationNumber   string     `json:"quotation_number" db:"quotation_number"`
+	Version           int        `json:"version" db:"version"`
+	PreviousVersionID *uuid.ID   `json:"previous_version_id,omitempty" db:"previous_version_id"`
+	CustomerID        string     `json:"customer_id" db:"customer_id"`
+	QuotationDate     time.Time  `json:"quotation_date" db:"quotation_date"`
+	ValidUntil        time.Time  `json:"valid_until" db:"valid_until"`
+	Status            string     `json:"status" db:"status"`
+	Currency          string     `json:"currency" db:"currency"`
+	PaymentTerms      string     `json:"payment_terms" db:"payment_terms"`
+	Notes             string     `json:"notes" db:"notes"`
+	Subtotal          float64    `json:"subtotal" db:"subtotal"`               // before discounts
+	DiscountAmount    float64    `json:"discount_amount" db:"discount_amount"` // line discounts
+	TaxRate           float64    `json:"tax_rate" db:"tax_rate"`               // percent
+	TaxAmount         float64    `json:"tax_amount" db:"tax_amount"`
+	TotalAmount       float64    `json:"total_amount" db:"total_amount"` // net of discounts, with tax
+	SentAt            *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	SentTo            string     `json:"sent_to,omitempty" db:"sent_to"`
+	AcceptedAt        *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	SalesOrderID      *uuid.ID   `json:"sales_order_id,omitempty" db:"sales_order_id"`
+	CreatedBy         string     `json:"created_by" db:"created_by"`
+
+	Items []*SalesQuotationItem `json:"items,omitempty" db:"-"`
+}
+
+// SalesQuotationItem is a line of a quotation.
+type SalesQuotationItem struct {
+	types.BaseModel
+	QuotationID     uuid.ID `json:"quotation_id" db:"quotation_id"`
+	LineNumber      int     `json:"line_number" db:"line_number"`
+	ArticleID       uuid.ID `json:"article_id" db:"article_id"`
+	Description     string  `json:"description" db:"description"`
+	Quantity        int     `json:"quantity" db:"quantity"`
+	UnitPrice       float64 `json:"unit_price" db:"unit_price"`
+	DiscountPercent float64 `json:"discount_percent" db:"discount_percent"`
+	DiscountAmount  float64 `json:"discount_amount" db:"discount_amount"`
+	TotalPrice      float64 `json:"total_price" db:"total_price"` // net of DiscountAmount, before tax
+}
+
+// IsExpiredAt reports whether the quotation's validity date has passed at a time. A
+// quotation is valid through the whole of its validity date.
+func (q *SalesQuotation) IsExpiredAt(at time.Time) bool {
+	validUntil := time.Date(q.ValidUntil.Year(), q.ValidUntil.Month(), q.ValidUntil.Day(), 0, 0, 0, 0, q.ValidUntil.Location())
+	return !at.Before(validUntil.AddDate(0, 0, 1))
+}
+
+// SalesQuotationFilter narrows down quotation lists. Only the latest version of each
+// quotation is listed unless AllVersions is set.
+type SalesQuotationFilter struct {
+	Status      string
+	CustomerID  string
+	Search      string // quotation number
+	AllVersions bool
+}
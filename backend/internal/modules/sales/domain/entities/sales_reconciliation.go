@@ -0,0 +1,203 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+// Settlement sources. POS sources are the payment methods settled by a provider: card
+// payments by the bank through its EDC settlement, QRIS and e-wallet payments by their
+// payout files. Marketplace payouts settle the orders of a channel, net of its fees.
+const (
+	SettlementCard        = PosPaymentCard
+	SettlementQRIS        = PosPaymentQRIS
+	SettlementEWallet     = PosPaymentEWallet
+	SettlementMarketplace = "marketplace"
+)
+
+// PosSettlementSources are the POS payment methods reconciled against settlements.
+var PosSettlementSources = []string{SettlementCard, SettlementQRIS, SettlementEWallet}
+
+// How a sale or a settlement line came out of reconciliation.
+const (
+	ReconMatched = "matched"
+	// ReconAmountMismatch is a sale matched by reference to a settlement of another amount.
+	ReconAmountMismatch = "amount_mismatch"
+	// ReconMissingSettlement is a POS payment no settlement line accounts for.
+	ReconMissingSettlement = "missing_settlement"
+	// ReconUnexpectedSettlement is a settlement line no sale accounts for.
+	ReconUnexpectedSettlement = "unexpected_settlement"
+	// ReconMissingPayout is a shipped channel order still unpaid after MarketplacePayoutDays.
+	ReconMissingPayout = "missing_payout"
+)
+
+// Reconciliation statuses.
+const (
+	ReconciliationReconciled    = "reconciled"
+	ReconciliationDiscrepancies = "discrepancies"
+)
+
+// Discrepancy statuses.
+const (
+	DiscrepancyOpen     = "open"
+	DiscrepancyResolved = "resolved"
+)
+
+// Statuses of the sales_rekonsiliasi summary kept for each reconciliation.
+const (
+	RekonsiliasiReconciled = "Reconciled"
+	RekonsiliasiDisputed   = "Disputed"
+)
+
+// MarketplacePayoutDays is how long after ordering a shipped channel order is expected
+// to be paid out.
+const MarketplacePayoutDays = 14
+
+var (
+	// ErrInvalidSettlementImport wraps the reasons a settlement file cannot be imported.
+	ErrInvalidSettlementImport = errors.New("invalid settlement file")
+	// ErrSettlementImportExists is returned when the same settlement file is imported twice.
+	ErrSettlementImportExists = errors.New("settlement file already imported")
+	// ErrSettlementImportNotFound is returned when a settlement import does not exist.
+	ErrSettlementImportNotFound = errors.New("settlement import not found")
+	// ErrInvalidReconciliation wraps the reasons a reconciliation cannot be run.
+	ErrInvalidReconciliation = errors.New("invalid reconciliation")
+	// ErrReconciliationNotFound is returned when a reconciliation does not exist.
+	ErrReconciliationNotFound = errors.New("reconciliation not found")
+	// ErrReconciliationLocked is returned when a reconciliation with resolved discrepancies is run again.
+	ErrReconciliationLocked = errors.New("reconciliation has resolved discrepancies and cannot be run again")
+	// ErrDiscrepancyNotFound is returned when a discrepancy does not exist.
+	ErrDiscrepancyNotFound = errors.New("reconciliation discrepancy not found")
+	// ErrDiscrepancyResolved is returned when a discrepancy is resolved twice.
+	ErrDiscrepancyResolved = errors.New("reconciliation discrepancy is already resolved")
+)
+
+// SettlementImport is a settlement or payout file of a provider: a bank's EDC
+// settlement, a QRIS or e-wallet payout, or a marketplace channel's payout report.
+type SettlementImport struct {
+	ID          uuid.ID           `json:"id" db:"id"`
+	Source      string            `json:"source" db:"source"`
+	Provider    string            `json:"provider" db:"provider"`
+	ChannelID   *uuid.ID          `json:"channel_id,omitempty" db:"channel_id"`
+	FileName    string            `json:"file_name" db:"file_name"`
+	FileHash    string            `json:"-" db:"file_hash"`
+	LineCount   int               `json:"line_count" db:"line_count"`
+	GrossAmount float64           `json:"gross_amount" db:"gross_amount"`
+	FeeAmount   float64           `json:"fee_amount" db:"fee_amount"`
+	NetAmount   float64           `json:"net_amount" db:"net_amount"`
+	ImportedBy  string            `json:"imported_by" db:"imported_by"`
+	ImportedAt  time.Time         `json:"imported_at" db:"imported_at"`
+	Lines       []*SettlementLine `json:"lines,omitempty" db:"-"`
+}
+
+// SettlementLine is a settled sale of a settlement file. Its fee is the provider's MDR
+// or, for marketplaces, the channel's commission and service fees.
+type SettlementLine struct {
+	ID              uuid.ID   `json:"id" db:"id"`
+	ImportID        uuid.ID   `json:"import_id" db:"import_id"`
+	Source          string    `json:"source" db:"source"`
+	ChannelID       *uuid.ID  `json:"channel_id,omitempty" db:"channel_id"`
+	LineNumber      int       `json:"line_number" db:"line_number"`
+	TransactionDate time.Time `json:"transaction_date" db:"transaction_date"`
+	SettlementDate  time.Time `json:"settlement_date" db:"settlement_date"`
+	Reference       string    `json:"reference,omitempty" db:"reference"`
+	ExternalOrderID string    `json:"external_order_id,omitempty" db:"external_order_id"`
+	GrossAmount     float64   `json:"gross_amount" db:"gross_amount"`
+	FeeAmount       float64   `json:"fee_amount" db:"fee_amount"`
+	NetAmount       float64   `json:"net_amount" db:"net_amount"`
+	// ReconciliationLineID is set once the line has been reconciled.
+	ReconciliationLineID *uuid.ID `json:"reconciliation_line_id,omitempty" db:"reconciliation_line_id"`
+}
+
+// ReconciliationPayment is a POS payment to be settled by a provider.
+type ReconciliationPayment struct {
+	ID            uuid.ID   `db:"id"`
+	ReceiptNumber string    `db:"receipt_number"`
+	Reference     string    `db:"reference"`
+	Amount        float64   `db:"amount"`
+	PaidAt        time.Time `db:"paid_at"`
+}
+
+// ReconciliationOrder is a channel order to be paid out by the marketplace.
+type ReconciliationOrder struct {
+	ID          uuid.ID   `db:"id"`
+	OrderID     string    `db:"order_id"`
+	Status      string    `db:"status"`
+	OrderDate   time.Time `db:"order_date"`
+	TotalAmount float64   `db:"total_amount"`
+}
+
+// SalesReconciliation compares the sales of a business day paid through a source with
+// what the provider settled: POS payments of a method against settlement lines of the
+// day, or a channel's orders against the payouts made on the day.
+type SalesReconciliation struct {
+	ID           uuid.ID   `json:"id" db:"id"`
+	BusinessDate time.Time `json:"business_date" db:"business_date"`
+	Source       string    `json:"source" db:"source"`
+	ChannelID    *uuid.ID  `json:"channel_id,omitempty" db:"channel_id"`
+	// ExpectedAmount is what our sales say should be settled; SettledAmount is the
+	// gross the provider settled, of which FeeAmount was kept and NetAmount paid.
+	ExpectedAmount float64 `json:"expected_amount" db:"expected_amount"`
+	SettledAmount  float64 `json:"settled_amount" db:"settled_amount"`
+	FeeAmount      float64 `json:"fee_amount" db:"fee_amount"`
+	NetAmount      float64 `json:"net_amount" db:"net_amount"`
+	// MDRRate is the fee as a percentage of the gross settled.
+	MDRRate        float64                      `json:"mdr_rate" db:"mdr_rate"`
+	Difference     float64                      `json:"difference" db:"difference"` // settled less expected
+	MatchedCount   int                          `json:"matched_count" db:"matched_count"`
+	UnmatchedCount int                          `json:"unmatched_count" db:"unmatched_count"`
+	Status         string                       `json:"status" db:"status"`
+	RekonsiliasiID *uuid.ID                     `json:"rekonsiliasi_id,omitempty" db:"rekonsiliasi_id"`
+	CreatedBy      string                       `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time                    `json:"created_at" db:"created_at"`
+	Lines          []*ReconciliationLine        `json:"lines,omitempty" db:"-"`
+	Discrepancies  []*ReconciliationDiscrepancy `json:"discrepancies,omitempty" db:"-"`
+	// SettlementLinks maps the settlement lines reconciled to their reconciliation line.
+	SettlementLinks map[uuid.ID]uuid.ID `json:"-" db:"-"`
+}
+
+// ReconciliationLine is a sale, a settlement line or both, as matched.
+type ReconciliationLine struct {
+	ID               uuid.ID  `json:"id" db:"id"`
+	ReconciliationID uuid.ID  `json:"reconciliation_id" db:"reconciliation_id"`
+	MatchStatus      string   `json:"match_status" db:"match_status"`
+	PosPaymentID     *uuid.ID `json:"pos_payment_id,omitempty" db:"pos_payment_id"`
+	OnlineOrderID    *uuid.ID `json:"online_order_id,omitempty" db:"online_order_id"`
+	// Reference is the card approval code, QRIS reference or channel order ID.
+	Reference      string  `json:"reference" db:"reference"`
+	ExpectedAmount float64 `json:"expected_amount" db:"expected_amount"`
+	SettledAmount  float64 `json:"settled_amount" db:"settled_amount"`
+	FeeAmount      float64 `json:"fee_amount" db:"fee_amount"`
+	NetAmount      float64 `json:"net_amount" db:"net_amount"`
+	FeeRate        float64 `json:"fee_rate" db:"fee_rate"`
+	Difference     float64 `json:"difference" db:"difference"`
+}
+
+// ReconciliationDiscrepancy is an unmatched or mismatched line for finance to resolve.
+type ReconciliationDiscrepancy struct {
+	ID               uuid.ID    `json:"id" db:"id"`
+	ReconciliationID uuid.ID    `json:"reconciliation_id" db:"reconciliation_id"`
+	LineID           uuid.ID    `json:"line_id" db:"line_id"`
+	BusinessDate     time.Time  `json:"business_date" db:"business_date"`
+	Source           string     `json:"source" db:"source"`
+	Type             string     `json:"type" db:"discrepancy_type"`
+	Reference        string     `json:"reference" db:"reference"`
+	Amount           float64    `json:"amount" db:"amount"` // settled less expected
+	Status           string     `json:"status" db:"status"`
+	Resolution       string     `json:"resolution,omitempty" db:"resolution"`
+	ResolvedBy       string     `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsPosSettlementSource tells whether a source settles POS payments.
+func IsPosSettlementSource(source string) bool {
+	for _, s := range PosSettlementSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
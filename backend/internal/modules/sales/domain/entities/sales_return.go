@@ -9,8 +9,9 @@ import (
 // SalesReturn represents a sales return entity.
 type SalesReturn struct {
 	types.BaseModel
-	SalesInvoiceID string    `json:"sales_invoice_id"`
-	ReturnDate     time.Time `json:"return_date"`
-	Reason         string    `json:"reason"`
-	TotalAmount    float64   `json:"total_amount"`
+	SalesInvoiceID   string    `json:"sales_invoice_id,omitempty"`
+	PosTransactionID string    `json:"pos_transaction_id,omitempty"` // set for returns of POS sales
+	ReturnDate       time.Time `json:"return_date"`
+	Reason           string    `json:"reason"`
+	TotalAmount      float64   `json:"total_amount"`
 }
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// ConsignmentRepository defines the data operations of consignment stock at department
+// stores. Methods posting documents run in one transaction; Get methods return nil
+// when the document does not exist.
+type ConsignmentRepository interface {
+	// CreateLocation stores a location together with its consignment warehouse.
+	CreateLocation(ctx context.Context, loc *entities.ConsignmentLocation) error
+	GetLocation(ctx context.Context, id uuid.ID) (*entities.ConsignmentLocation, error)
+	ListLocations(ctx context.Context) ([]*entities.ConsignmentLocation, error)
+
+	NextTransferNumber(ctx context.Context, at time.Time) (string, error)
+	NextSellThroughNumber(ctx context.Context, at time.Time) (string, error)
+	NextSettlementNumber(ctx context.Context, at time.Time) (string, error)
+	NextInvoiceNumber(ctx context.Context, at time.Time) (string, error)
+
+	// GetArticlePrices returns the selling prices of the articles.
+	GetArticlePrices(ctx context.Context, articleIDs []uuid.ID) (map[uuid.ID]float64, error)
+	// ResolveArticles maps article codes and barcodes to article IDs; unknown keys are left out.
+	ResolveArticles(ctx context.Context, keys []string) (map[string]uuid.ID, error)
+	// ListOpenLots returns the lots with stock left, of one location or of all when locationID is nil.
+	ListOpenLots(ctx context.Context, locationID *uuid.ID) ([]*entities.ConsignmentLot, error)
+
+	// CreateTransfer posts a transfer. Transfers out check the stock available in the
+	// source warehouse and open lots; returns consume lots first in, first out.
+	CreateTransfer(ctx context.Context, t *entities.ConsignmentTransfer) error
+	GetTransfer(ctx context.Context, id uuid.ID) (*entities.ConsignmentTransfer, error)
+	ListTransfers(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentTransfer, error)
+
+	// CreateSellThrough posts a sell-through report, consuming the sold stock's lots.
+	CreateSellThrough(ctx context.Context, st *entities.ConsignmentSellThrough) error
+	GetSellThrough(ctx context.Context, id uuid.ID) (*entities.ConsignmentSellThrough, error)
+	ListSellThroughs(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSellThrough, error)
+	// ListUnsettled returns the posted reports of a location whose period ends on or before until, with their items.
+	ListUnsettled(ctx context.Context, locationID uuid.ID, until time.Time) ([]*entities.ConsignmentSellThrough, error)
+	// ListLocationsToSettle returns the active locations with unsettled reports ending on or before until.
+	ListLocationsToSettle(ctx context.Context, until time.Time) ([]uuid.ID, error)
+
+	// CreateSettlement stores a settlement with its invoice and receivable and marks its
+	// reports settled; it fails with ErrNothingToSettle when one was settled meanwhile.
+	CreateSettlement(ctx context.Context, p *entities.SettlementPosting) error
+	GetSettlement(ctx context.Context, id uuid.ID) (*entities.ConsignmentSettlement, error)
+	ListSettlements(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSettlement, error)
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// LoyaltyRepository defines the interface for loyalty program data operations.
+type LoyaltyRepository interface {
+	GetProgram(ctx context.Context) (*entities.LoyaltyProgram, error)
+	SaveProgram(ctx context.Context, program *entities.LoyaltyProgram) error
+
+	CreateTier(ctx context.Context, tier *entities.LoyaltyTier) error
+	UpdateTier(ctx context.Context, tier *entities.LoyaltyTier) error
+	DeleteTier(ctx context.Context, id uuid.ID) error
+	GetTierByID(ctx context.Context, id uuid.ID) (*entities.LoyaltyTier, error)
+	// GetTiers returns the tiers ordered by rank.
+	GetTiers(ctx context.Context) ([]*entities.LoyaltyTier, error)
+
+	CreateMember(ctx context.Context, member *entities.LoyaltyMember) error
+	UpdateMember(ctx context.Context, member *entities.LoyaltyMember) error
+	GetMemberByID(ctx context.Context, id uuid.ID) (*entities.LoyaltyMember, error)
+	// FindMember finds a member by phone, card barcode or member number.
+	FindMember(ctx context.Context, identifier string) (*entities.LoyaltyMember, error)
+	ListMembers(ctx context.Context, search string, limit, offset int) ([]*entities.LoyaltyMember, int, error)
+	// ListMemberIDs returns the IDs of active members after the given ID, for batch jobs.
+	ListMemberIDs(ctx context.Context, after uuid.ID, limit int) ([]uuid.ID, error)
+	SetMemberTier(ctx context.Context, memberID, tierID uuid.ID, since time.Time) error
+
+	// UpsertMemberPrice creates or replaces the member price of an article for a tier.
+	UpsertMemberPrice(ctx context.Context, price *entities.LoyaltyMemberPrice) error
+	DeleteMemberPrice(ctx context.Context, id uuid.ID) error
+	GetMemberPrices(ctx context.Context, tierID uuid.ID) ([]*entities.LoyaltyMemberPrice, error)
+	// GetBestMemberPrice returns the lowest member price of an article over the given tiers.
+	GetBestMemberPrice(ctx context.Context, articleID uuid.ID, tierIDs []uuid.ID) (*entities.LoyaltyMemberPrice, error)
+
+	// PostEntry adds a ledger entry and updates the member's balance. Negative entries
+	// consume the oldest unexpired positive entries first and fail with
+	// entities.ErrInsufficientPoints when the balance is too low.
+	PostEntry(ctx context.Context, entry *entities.LoyaltyPointsEntry) error
+	GetLedger(ctx context.Context, memberID uuid.ID, limit, offset int) ([]*entities.LoyaltyPointsEntry, int, error)
+	// GetEntriesByReference returns the entries of a source document and of the
+	// documents referencing it, such as returns of a POS transaction.
+	GetEntriesByReference(ctx context.Context, sourceID string) ([]*entities.LoyaltyPointsEntry, error)
+	// ExpirePoints posts expire entries for positive entries that expired by at and
+	// returns the number of points expired.
+	ExpirePoints(ctx context.Context, at time.Time) (int, error)
+	// GetQualifyingSpend returns the member's qualifying spend since the given time.
+	GetQualifyingSpend(ctx context.Context, memberID uuid.ID, since time.Time) (float64, error)
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MarketplaceRepository defines the data operations of marketplace channels and the
+// online orders imported from them. Get methods return nil when the record does not exist.
+type MarketplaceRepository interface {
+	CreateChannel(ctx context.Context, ch *entities.MarketplaceChannel) error
+	GetChannel(ctx context.Context, id uuid.ID) (*entities.MarketplaceChannel, error)
+	GetChannelByCode(ctx context.Context, code string) (*entities.MarketplaceChannel, error)
+	ListChannels(ctx context.Context) ([]*entities.MarketplaceChannel, error)
+	// SetLastPulled records up to when the channel's orders have been pulled.
+	SetLastPulled(ctx context.Context, id uuid.ID, at time.Time) error
+
+	// ResolveBarcodes maps barcodes, of the barcodes table or of articles, to article IDs;
+	// unknown barcodes are left out.
+	ResolveBarcodes(ctx context.Context, barcodes []string) (map[string]uuid.ID, error)
+	// SaveSKUMappings creates or replaces the mappings of the channel SKUs.
+	SaveSKUMappings(ctx context.Context, mappings []*entities.ChannelSKUMapping) error
+	ListSKUMappings(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelSKUMapping, error)
+	// GetSKUMappings returns the mappings of the given SKUs of a channel by SKU.
+	GetSKUMappings(ctx context.Context, channelID uuid.ID, skus []string) (map[string]*entities.ChannelSKUMapping, error)
+
+	GetOrder(ctx context.Context, id uuid.ID) (*entities.OnlineOrder, error)
+	GetOrderByExternalID(ctx context.Context, channelID uuid.ID, externalID string) (*entities.OnlineOrder, error)
+	// CreateOrder stores an online order with its items and reserves their stock in the
+	// order's warehouse. It returns false, storing nothing, when the channel's order was
+	// imported meanwhile.
+	CreateOrder(ctx context.Context, order *entities.OnlineOrder) (bool, error)
+	// CancelOrder cancels an order that has not been shipped and releases its reservations.
+	CancelOrder(ctx context.Context, id uuid.ID) error
+	// ShipOrder stores the order's shipment and goods issue, consumes its reservations
+	// and marks it shipped.
+	ShipOrder(ctx context.Context, s *entities.OnlineOrderShipment) error
+
+	// ListStockLevels returns the stock of the channel's warehouse, less reservations, of its mapped SKUs.
+	ListStockLevels(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelStockLevel, error)
+	// ListPrices returns the selling prices of the channel's mapped SKUs.
+	ListPrices(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelPrice, error)
+}
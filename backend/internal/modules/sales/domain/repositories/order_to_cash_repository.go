@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// OrderToCashRepository defines the data operations of the order-to-cash flow. Every
+// step writes the documents of all modules involved in one transaction.
+type OrderToCashRepository interface {
+	// GetOrderLines returns the items of a sales order with their reserved, delivered
+	// and invoiced quantities.
+	GetOrderLines(ctx context.Context, orderID uuid.ID) ([]*entities.SalesOrderLine, error)
+
+	// Confirm moves an open order to confirmed and reserves its stock. It fails with
+	// ErrSalesOrderStatus if the order is no longer open and with ErrInsufficientStock
+	// if the warehouse cannot cover a reservation.
+	Confirm(ctx context.Context, orderID uuid.ID, reservations []*entities.StockReservation) error
+
+	NextDeliveryNumber(ctx context.Context, at time.Time) (string, error)
+	// CreateDelivery stores a delivery with its shipment and goods issue, consumes the
+	// reservations and returns the new order status. It fails with ErrInvalidDelivery
+	// if a line would be delivered beyond its ordered quantity.
+	CreateDelivery(ctx context.Context, delivery *entities.SalesDelivery) (string, error)
+	GetDelivery(ctx context.Context, id uuid.ID) (*entities.SalesDelivery, error)
+	ListDeliveries(ctx context.Context, orderID uuid.ID) ([]*entities.SalesDelivery, error)
+
+	NextInvoiceNumber(ctx context.Context, at time.Time) (string, error)
+	// CreateInvoice stores the invoice of a delivery with its receivable and output tax
+	// and returns the new order status. It fails with ErrDeliveryInvoiced if the
+	// delivery has been invoiced before.
+	CreateInvoice(ctx context.Context, posting *entities.InvoicePosting) (string, error)
+	GetInvoice(ctx context.Context, id uuid.ID) (*entities.SalesInvoice, error)
+
+	// RecordPayment books a payment as cash receipt, settles the invoice and its
+	// receivable and returns the updated invoice. It fails with ErrInvalidPayment if
+	// the payment exceeds the open balance.
+	RecordPayment(ctx context.Context, payment *entities.SalesPayment) (*entities.SalesInvoice, error)
+
+	// FindOrder returns the sales order a document of the flow belongs to, or nil.
+	FindOrder(ctx context.Context, docType string, id uuid.ID) (*uuid.ID, error)
+	// GetOrderDocuments returns all documents of a sales order, parents first.
+	GetOrderDocuments(ctx context.Context, orderID uuid.ID) (entities.OrderDocuments, error)
+}
@@ -14,4 +14,7 @@ type PosItemRepository interface {
 	GetByPosTransactionID(ctx context.Context, posTransactionID uuid.ID) ([]*entities.PosItem, error)
 	Update(ctx context.Context, item *entities.PosItem) error
 	Delete(ctx context.Context, id uuid.ID) error
+	// AddReturnedQuantity adds to the quantity returned on a sold line. It fails with
+	// ErrInvalidPosRefund when more would be returned than was sold.
+	AddReturnedQuantity(ctx context.Context, id uuid.ID, quantity int) error
 }
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// PosShiftRepository defines the interface for POS shift and cash drawer data operations.
+type PosShiftRepository interface {
+	Create(ctx context.Context, shift *entities.PosShift) error
+	GetByID(ctx context.Context, id uuid.ID) (*entities.PosShift, error)
+	// GetOpenByTerminal returns the open shift of a terminal, or nil if there is none.
+	GetOpenByTerminal(ctx context.Context, terminalID uuid.ID) (*entities.PosShift, error)
+	// GetOpenByCashier returns the open shift of a cashier, or nil if there is none.
+	GetOpenByCashier(ctx context.Context, cashierID uuid.ID) (*entities.PosShift, error)
+	// List returns shifts newest first, optionally filtered by terminal and status.
+	List(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosShift, int, error)
+	// Close stores the count and Z report of an open shift and gives it the terminal's
+	// next Z number. It fails with entities.ErrPosShiftClosed if the shift was closed.
+	Close(ctx context.Context, shift *entities.PosShift) error
+
+	// AddCashMovement records a cash movement. It fails with entities.ErrPosShiftClosed
+	// if the shift is not open.
+	AddCashMovement(ctx context.Context, movement *entities.PosCashMovement) error
+	GetCashMovements(ctx context.Context, shiftID uuid.ID) ([]*entities.PosCashMovement, error)
+	// GetSales returns the sales totals of a shift by payment method.
+	GetSales(ctx context.Context, shiftID uuid.ID) (*entities.PosShiftSales, error)
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// PosSyncRepository defines the data operations of the offline POS sync.
+type PosSyncRepository interface {
+	// GetChanges returns up to limit catalog changes after a version, oldest first.
+	GetChanges(ctx context.Context, since int64, limit int) ([]*entities.PosSyncChange, error)
+	// LatestVersion returns the version of the last catalog change.
+	LatestVersion(ctx context.Context) (int64, error)
+	GetArticles(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogArticle, error)
+	GetPrices(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogPrice, error)
+	GetBarcodes(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogBarcode, error)
+	GetPromotions(ctx context.Context, ids []uuid.ID) ([]*entities.Promotion, error)
+	// CurrentPrice returns the price of an article at a time: the latest dated price in
+	// effect, or the article's own price. It returns false if the article does not exist.
+	CurrentPrice(ctx context.Context, articleID uuid.ID, at time.Time) (float64, bool, error)
+
+	// GetUpload returns the result of an uploaded transaction, or nil if it was never sent.
+	GetUpload(ctx context.Context, clientTransactionID uuid.ID) (*entities.PosSyncUpload, error)
+	// SaveUpload stores the result of an upload, replacing the result of an earlier attempt.
+	SaveUpload(ctx context.Context, upload *entities.PosSyncUpload) error
+	// ListUploads returns uploads newest first, optionally filtered by terminal and status.
+	ListUploads(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosSyncUpload, int, error)
+
+	// RecordDownload stores the catalog version a terminal downloaded.
+	RecordDownload(ctx context.Context, terminalID uuid.ID, version int64, clientVersion string) error
+	// RecordUpload stores the time of a terminal's last upload.
+	RecordUpload(ctx context.Context, terminalID uuid.ID, clientVersion string) error
+	// GetStatuses returns the sync status of every terminal.
+	GetStatuses(ctx context.Context) ([]*entities.PosTerminalSyncStatus, error)
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// PosTerminalRepository defines the interface for POS terminal data operations.
+type PosTerminalRepository interface {
+	Create(ctx context.Context, terminal *entities.PosTerminal) error
+	GetByID(ctx context.Context, id uuid.ID) (*entities.PosTerminal, error)
+	GetAll(ctx context.Context) ([]*entities.PosTerminal, error)
+	Update(ctx context.Context, terminal *entities.PosTerminal) error
+	Delete(ctx context.Context, id uuid.ID) error
+	// NextReceiptNumber takes the next number from the terminal's receipt counter.
+	NextReceiptNumber(ctx context.Context, terminalID uuid.ID) (string, error)
+}
@@ -14,4 +14,10 @@ type PosTransactionRepository interface {
 	GetAll(ctx context.Context) ([]*entities.PosTransaction, error)
 	Update(ctx context.Context, pt *entities.PosTransaction) error
 	Delete(ctx context.Context, id uuid.ID) error
+	GetByReceiptNumber(ctx context.Context, receiptNumber string) (*entities.PosTransaction, error)
+	// Void marks a completed transaction voided. It fails with ErrPosTransactionVoided
+	// when the transaction was voided already.
+	Void(ctx context.Context, pt *entities.PosTransaction) error
+	CreatePayment(ctx context.Context, payment *entities.PosPayment) error
+	GetPayments(ctx context.Context, posTransactionID uuid.ID) ([]*entities.PosPayment, error)
 }
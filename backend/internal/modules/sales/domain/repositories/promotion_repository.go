@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"malaka/internal/modules/sales/domain/entities"
 )
@@ -13,4 +14,15 @@ type PromotionRepository interface {
 	GetAll(ctx context.Context) ([]*entities.Promotion, error)
 	Update(ctx context.Context, promo *entities.Promotion) error
 	Delete(ctx context.Context, id string) error
+
+	// GetActive returns the active promotions whose date range includes at.
+	GetActive(ctx context.Context, at time.Time) ([]*entities.Promotion, error)
+	// RecordRedemption stores a redemption and adds it to the promotion's redemption
+	// count and budget. Returns entities.ErrPromotionExhausted when the limit or budget
+	// does not allow it.
+	RecordRedemption(ctx context.Context, redemption *entities.PromotionRedemption) error
+	// ReleaseRedemptions removes the redemptions of a source document and gives the
+	// redemption count and budget back to the promotions.
+	ReleaseRedemptions(ctx context.Context, sourceType, sourceID string) error
+	GetRedemptions(ctx context.Context, promotionID string) ([]*entities.PromotionRedemption, error)
 }
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// SalesQuotationRepository defines the data operations of sales quotations.
+type SalesQuotationRepository interface {
+	// NextNumber returns a new quotation number.
+	NextNumber(ctx context.Context, at time.Time) (string, error)
+	// Create stores a quotation version with its items.
+	Create(ctx context.Context, q *entities.SalesQuotation) error
+	// GetByID returns a quotation version with its items, or nil.
+	GetByID(ctx context.Context, id uuid.ID) (*entities.SalesQuotation, error)
+	List(ctx context.Context, filter entities.SalesQuotationFilter, limit, offset int) ([]*entities.SalesQuotation, int, error)
+	// GetVersions returns every version of a quotation number, oldest first, without items.
+	GetVersions(ctx context.Context, quotationNumber string) ([]*entities.SalesQuotation, error)
+	// Update stores the header of a draft and replaces its items.
+	Update(ctx context.Context, q *entities.SalesQuotation) error
+	// UpdateStatus stores the status fields of a quotation if it is still in one of the
+	// given statuses. It returns false if it was not.
+	UpdateStatus(ctx context.Context, q *entities.SalesQuotation, from ...string) (bool, error)
+	// Revise supersedes a sent or expired quotation with its next version. It returns
+	// entities.ErrQuotationStatus if the quotation was revised or accepted meanwhile.
+	Revise(ctx context.Context, previous, next *entities.SalesQuotation) error
+	Delete(ctx context.Context, id uuid.ID) error
+	// ExpireSent marks sent quotations valid until before a date as expired.
+	ExpireSent(ctx context.Context, before time.Time) (int, error)
+}
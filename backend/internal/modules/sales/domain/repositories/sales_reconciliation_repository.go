@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// SalesReconciliationRepository defines the data operations of settlement imports and
+// the daily reconciliation of sales against them. Days are business days in local time.
+// Get methods return nil when the record does not exist.
+type SalesReconciliationRepository interface {
+	// CreateImport stores a settlement file with its lines; the same file (by hash) is
+	// refused with ErrSettlementImportExists.
+	CreateImport(ctx context.Context, imp *entities.SettlementImport) error
+	GetImport(ctx context.Context, id uuid.ID) (*entities.SettlementImport, error)
+	ListImports(ctx context.Context, source string) ([]*entities.SettlementImport, error)
+
+	// ListPosPayments returns the payments of a method on completed POS transactions of the day.
+	ListPosPayments(ctx context.Context, method string, day time.Time) ([]*entities.ReconciliationPayment, error)
+	// ListSettlementLines returns the settlement lines of a POS source for sales of the day.
+	ListSettlementLines(ctx context.Context, source string, day time.Time) ([]*entities.SettlementLine, error)
+	// ListPayoutLines returns the payout lines of a channel paid out on the day.
+	ListPayoutLines(ctx context.Context, channelID uuid.ID, day time.Time) ([]*entities.SettlementLine, error)
+	// GetChannelOrders returns the channel's orders with the given channel order IDs by ID.
+	GetChannelOrders(ctx context.Context, channelID uuid.ID, externalIDs []string) (map[string]*entities.ReconciliationOrder, error)
+	// ListUnpaidChannelOrders returns the shipped orders of a channel ordered on the day
+	// that no payout has been reconciled against.
+	ListUnpaidChannelOrders(ctx context.Context, channelID uuid.ID, day time.Time) ([]*entities.ReconciliationOrder, error)
+
+	// SaveReconciliation stores a reconciliation with its lines and discrepancies, links
+	// the settlement lines reconciled and keeps its sales_rekonsiliasi summary. It
+	// replaces an earlier reconciliation of the same day and source, unless one of its
+	// discrepancies was resolved (ErrReconciliationLocked).
+	SaveReconciliation(ctx context.Context, rec *entities.SalesReconciliation) error
+	GetReconciliation(ctx context.Context, id uuid.ID) (*entities.SalesReconciliation, error)
+	ListReconciliations(ctx context.Context, from, to time.Time) ([]*entities.SalesReconciliation, error)
+
+	GetDiscrepancy(ctx context.Context, id uuid.ID) (*entities.ReconciliationDiscrepancy, error)
+	ListDiscrepancies(ctx context.Context, status string) ([]*entities.ReconciliationDiscrepancy, error)
+	// ResolveDiscrepancy resolves an open discrepancy; once all discrepancies of its
+	// reconciliation are resolved, the reconciliation is reconciled.
+	ResolveDiscrepancy(ctx context.Context, d *entities.ReconciliationDiscrepancy) error
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SellThroughLine is one line of a department store's sell-through report.
+type SellThroughLine struct {
+	Line      int
+	Article   string // article code
+	Barcode   string
+	Quantity  int
+	UnitPrice float64 // zero when the store did not report a price
+}
+
+// Key returns what identifies the line's article, preferring the barcode.
+func (l SellThroughLine) Key() string {
+	if l.Barcode != "" {
+		return l.Barcode
+	}
+	return l.Article
+}
+
+// sellThroughColumns maps the header names stores use to the report's columns.
+var sellThroughColumns = map[string]string{
+	"article":       "article",
+	"article_code":  "article",
+	"sku":           "article",
+	"barcode":       "barcode",
+	"ean":           "barcode",
+	"quantity":      "quantity",
+	"qty":           "quantity",
+	"quantity_sold": "quantity",
+	"price":         "price",
+	"unit_price":    "price",
+}
+
+// ParseSellThroughCSV parses a sell-through CSV file with the header
+// article|barcode,quantity[,price]; either an article or a barcode column is required.
+// Comma and semicolon separated files are accepted, and prices may use a decimal comma.
+func ParseSellThroughCSV(r io.Reader) ([]SellThroughLine, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv file: %w", err)
+	}
+	content := strings.TrimPrefix(string(data), "\ufeff")
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	firstLine, _, _ := strings.Cut(content, "\n")
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("csv file is empty")
+		}
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(col)), " ", "_")
+		if column, ok := sellThroughColumns[name]; ok {
+			if _, dup := columns[column]; !dup {
+				columns[column] = i
+			}
+		}
+	}
+	_, hasArticle := columns["article"]
+	_, hasBarcode := columns["barcode"]
+	if !hasArticle && !hasBarcode {
+		return nil, fmt.Errorf("csv header must contain an article or a barcode column")
+	}
+	if _, ok := columns["quantity"]; !ok {
+		return nil, fmt.Errorf("csv header must contain a quantity column")
+	}
+
+	field := func(record []string, name string) string {
+		if idx, ok := columns[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+
+	var lines []SellThroughLine
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		l := SellThroughLine{Line: line, Article: field(record, "article"), Barcode: field(record, "barcode")}
+		if l.Key() == "" {
+			continue
+		}
+		qty := field(record, "quantity")
+		if l.Quantity, err = strconv.Atoi(qty); err != nil || l.Quantity <= 0 {
+			return nil, fmt.Errorf("line %d: invalid quantity %q, expected a positive whole number", line, qty)
+		}
+		if price := field(record, "price"); price != "" {
+			if l.UnitPrice, err = parseReportedPrice(price); err != nil || l.UnitPrice < 0 {
+				return nil, fmt.Errorf("line %d: invalid price %q", line, price)
+			}
+		}
+		lines = append(lines, l)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("csv file has no sell-through lines")
+	}
+	return lines, nil
+}
+
+// parseReportedPrice accepts prices like 125000, 125.000, 125,000, 125.000,50 and
+// 125,000.50. A single kind of separator followed by groups of three digits groups
+// thousands, as rupiah prices rarely have cents; otherwise it is the decimal separator.
+func parseReportedPrice(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(s, "Rp"), "IDR"))
+	s = strings.ReplaceAll(s, " ", "")
+	hasDot, hasComma := strings.Contains(s, "."), strings.Contains(s, ",")
+	switch {
+	case hasDot && hasComma:
+		if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case hasDot || hasComma:
+		sep := "."
+		if hasComma {
+			sep = ","
+		}
+		groups := strings.Split(s, sep)
+		thousands := true
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				thousands = false
+			}
+		}
+		if thousands {
+			s = strings.Join(groups, "")
+		} else {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	}
+	return strconv.ParseFloat(s, 64)
+}
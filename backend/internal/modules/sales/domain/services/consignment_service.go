@@ -0,0 +1,592 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	inventory_entities "malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// ConsignmentService keeps track of our stock consigned to department stores: transfers
+// to and from their consignment warehouses, the stores' sell-through reports, the
+// settlement invoices billing their sales less commission, and the age of the stock.
+type ConsignmentService struct {
+	repo  repositories.ConsignmentRepository
+	stock StockMover
+	taxes TaxCalculator
+}
+
+// NewConsignmentService creates a new ConsignmentService.
+func NewConsignmentService(repo repositories.ConsignmentRepository, stock StockMover) *ConsignmentService {
+	return &ConsignmentService{repo: repo, stock: stock}
+}
+
+// SetTaxCalculator enables determining the tax of settlement invoices from the tax rules
+// and the rate in force on the invoice date.
+func (s *ConsignmentService) SetTaxCalculator(taxes TaxCalculator) {
+	s.taxes = taxes
+}
+
+// CreateLocation opens a consignment location, with its own warehouse, at a department store.
+func (s *ConsignmentService) CreateLocation(ctx context.Context, loc *entities.ConsignmentLocation) (*entities.ConsignmentLocation, error) {
+	if loc.DepstoreID.IsNil() {
+		return nil, fmt.Errorf("%w: department store is required", entities.ErrInvalidConsignmentLocation)
+	}
+	if loc.CustomerID.IsNil() {
+		return nil, fmt.Errorf("%w: the customer settlements are invoiced to is required", entities.ErrInvalidConsignmentLocation)
+	}
+	if loc.CommissionRate != nil && (*loc.CommissionRate < 0 || *loc.CommissionRate > 100) {
+		return nil, fmt.Errorf("%w: the commission rate must be between 0 and 100 percent", entities.ErrInvalidConsignmentLocation)
+	}
+
+	now := utils.Now()
+	loc.ID = uuid.New()
+	loc.WarehouseID = uuid.New()
+	loc.Status = entities.ConsignmentLocationActive
+	loc.CreatedAt, loc.UpdatedAt = now, now
+	if err := s.repo.CreateLocation(ctx, loc); err != nil {
+		return nil, err
+	}
+	return s.GetLocation(ctx, loc.ID)
+}
+
+// GetLocation returns a consignment location.
+func (s *ConsignmentService) GetLocation(ctx context.Context, id uuid.ID) (*entities.ConsignmentLocation, error) {
+	loc, err := s.repo.GetLocation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		return nil, entities.ErrConsignmentLocationNotFound
+	}
+	return loc, nil
+}
+
+// ListLocations returns all consignment locations.
+func (s *ConsignmentService) ListLocations(ctx context.Context) ([]*entities.ConsignmentLocation, error) {
+	return s.repo.ListLocations(ctx)
+}
+
+// TransferOut consigns stock of one of our warehouses to a location. Items without a
+// price are consigned at the article's selling price.
+func (s *ConsignmentService) TransferOut(ctx context.Context, t *entities.ConsignmentTransfer) (*entities.ConsignmentTransfer, error) {
+	loc, err := s.activeLocation(ctx, t.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if t.FromWarehouseID.IsNil() || t.FromWarehouseID == loc.WarehouseID {
+		return nil, fmt.Errorf("%w: a source warehouse other than the consignment warehouse is required", entities.ErrInvalidConsignmentTransfer)
+	}
+	if err := validateTransferItems(t.Items); err != nil {
+		return nil, err
+	}
+	t.Direction = entities.ConsignmentTransferOut
+	t.ToWarehouseID = loc.WarehouseID
+
+	var unpriced []uuid.ID
+	for _, item := range t.Items {
+		if item.UnitPrice <= 0 {
+			unpriced = append(unpriced, item.ArticleID)
+		}
+	}
+	if len(unpriced) > 0 {
+		prices, err := s.repo.GetArticlePrices(ctx, unpriced)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range t.Items {
+			if item.UnitPrice <= 0 {
+				price, ok := prices[item.ArticleID]
+				if !ok {
+					return nil, fmt.Errorf("%w: article %s not found", entities.ErrInvalidConsignmentTransfer, item.ArticleID)
+				}
+				item.UnitPrice = price
+			}
+		}
+	}
+	for _, item := range t.Items {
+		item.UnitPrice = roundMoney(item.UnitPrice)
+	}
+	return s.postTransfer(ctx, t)
+}
+
+// ReturnToWarehouse takes consigned stock back from a location into one of our
+// warehouses. The oldest stock is returned first, at the price it was consigned at.
+func (s *ConsignmentService) ReturnToWarehouse(ctx context.Context, t *entities.ConsignmentTransfer) (*entities.ConsignmentTransfer, error) {
+	loc, err := s.GetLocation(ctx, t.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if t.ToWarehouseID.IsNil() || t.ToWarehouseID == loc.WarehouseID {
+		return nil, fmt.Errorf("%w: a destination warehouse other than the consignment warehouse is required", entities.ErrInvalidConsignmentTransfer)
+	}
+	if err := validateTransferItems(t.Items); err != nil {
+		return nil, err
+	}
+	t.Direction = entities.ConsignmentTransferReturn
+	t.FromWarehouseID = loc.WarehouseID
+
+	quantities := map[uuid.ID]int{}
+	for _, item := range t.Items {
+		quantities[item.ArticleID] += item.Quantity
+	}
+	prices, err := s.lotPrices(ctx, loc.ID, quantities)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range t.Items {
+		item.UnitPrice = prices[item.ArticleID]
+	}
+	return s.postTransfer(ctx, t)
+}
+
+// GetTransfer returns a consignment transfer with its items.
+func (s *ConsignmentService) GetTransfer(ctx context.Context, id uuid.ID) (*entities.ConsignmentTransfer, error) {
+	t, err := s.repo.GetTransfer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, entities.ErrConsignmentTransferNotFound
+	}
+	return t, nil
+}
+
+// ListTransfers returns the transfers of a location, latest first.
+func (s *ConsignmentService) ListTransfers(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentTransfer, error) {
+	if _, err := s.GetLocation(ctx, locationID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTransfers(ctx, locationID)
+}
+
+// ImportSellThrough posts a sell-through report uploaded as CSV (see ParseSellThroughCSV).
+// Articles are matched by barcode or article code; lines without a price are valued at
+// the consigned price of the stock sold. The store's commission is taken off each line.
+func (s *ConsignmentService) ImportSellThrough(ctx context.Context, locationID uuid.ID, periodStart, periodEnd time.Time,
+	r io.Reader, fileName, userID string) (*entities.ConsignmentSellThrough, error) {
+	loc, err := s.activeLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	if periodStart.IsZero() || periodEnd.IsZero() {
+		return nil, fmt.Errorf("%w: the report period is required", entities.ErrInvalidSellThrough)
+	}
+	if periodEnd.Before(periodStart) {
+		return nil, fmt.Errorf("%w: the period ends before it starts", entities.ErrInvalidSellThrough)
+	}
+
+	lines, err := ParseSellThroughCSV(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidSellThrough, err)
+	}
+	keys := make([]string, 0, len(lines))
+	for _, l := range lines {
+		keys = append(keys, l.Key())
+	}
+	articles, err := s.repo.ResolveArticles(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	quantities := map[uuid.ID]int{}
+	items := make([]*entities.ConsignmentSellThroughItem, 0, len(lines))
+	for _, l := range lines {
+		articleID, ok := articles[l.Key()]
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("line %d (%s)", l.Line, l.Key()))
+			continue
+		}
+		quantities[articleID] += l.Quantity
+		items = append(items, &entities.ConsignmentSellThroughItem{
+			ID:        uuid.New(),
+			ArticleID: articleID,
+			Barcode:   l.Barcode,
+			Quantity:  l.Quantity,
+			UnitPrice: l.UnitPrice,
+		})
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown articles on %s", entities.ErrInvalidSellThrough, strings.Join(unknown, ", "))
+	}
+
+	// Pricing from the lots also checks that the store holds what it reports as sold
+	prices, err := s.lotPrices(ctx, loc.ID, quantities)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := s.repo.NextSellThroughNumber(ctx, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	st := &entities.ConsignmentSellThrough{
+		ID:             uuid.New(),
+		ReportNumber:   number,
+		LocationID:     loc.ID,
+		DepstoreID:     loc.DepstoreID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		CommissionRate: loc.Commission(),
+		Status:         entities.SellThroughPosted,
+		SourceFile:     fileName,
+		CreatedBy:      userID,
+		CreatedAt:      utils.Now(),
+		Items:          items,
+	}
+	for _, item := range st.Items {
+		item.SellThroughID = st.ID
+		if item.UnitPrice <= 0 {
+			item.UnitPrice = prices[item.ArticleID]
+		}
+		item.UnitPrice = roundMoney(item.UnitPrice)
+		item.GrossAmount = roundMoney(item.UnitPrice * float64(item.Quantity))
+		item.CommissionAmount = roundMoney(item.GrossAmount * st.CommissionRate / 100)
+		item.NetAmount = roundMoney(item.GrossAmount - item.CommissionAmount)
+		st.GrossAmount += item.GrossAmount
+		st.CommissionAmount += item.CommissionAmount
+		st.NetAmount += item.NetAmount
+	}
+	st.GrossAmount = roundMoney(st.GrossAmount)
+	st.CommissionAmount = roundMoney(st.CommissionAmount)
+	st.NetAmount = roundMoney(st.NetAmount)
+
+	if err := s.repo.CreateSellThrough(ctx, st); err != nil {
+		return nil, err
+	}
+
+	for _, item := range st.Items {
+		s.moveStock(ctx, item.ArticleID, loc.WarehouseID, item.Quantity, "out", periodEnd, st.ID, st.ReportNumber)
+	}
+	return st, nil
+}
+
+// GetSellThrough returns a sell-through report with its items.
+func (s *ConsignmentService) GetSellThrough(ctx context.Context, id uuid.ID) (*entities.ConsignmentSellThrough, error) {
+	st, err := s.repo.GetSellThrough(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, entities.ErrSellThroughNotFound
+	}
+	return st, nil
+}
+
+// ListSellThroughs returns the sell-through reports of a location, latest first.
+func (s *ConsignmentService) ListSellThroughs(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSellThrough, error) {
+	if _, err := s.GetLocation(ctx, locationID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSellThroughs(ctx, locationID)
+}
+
+// Settle bills a location's unsettled sell-through up to the given date (today when
+// zero) with one sales invoice over the net amount, plus PPN, due after the
+// department store's payment terms.
+func (s *ConsignmentService) Settle(ctx context.Context, locationID uuid.ID, until time.Time, userID string) (*entities.ConsignmentSettlement, error) {
+	loc, err := s.GetLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	now := utils.Now()
+	if until.IsZero() {
+		until = now
+	}
+	reports, err := s.repo.ListUnsettled(ctx, locationID, until)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, entities.ErrNothingToSettle
+	}
+
+	number, err := s.repo.NextSettlementNumber(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	invoiceNumber, err := s.repo.NextInvoiceNumber(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	settlement := &entities.ConsignmentSettlement{
+		ID:               uuid.New(),
+		SettlementNumber: number,
+		LocationID:       loc.ID,
+		InvoiceNumber:    invoiceNumber,
+		PeriodStart:      reports[0].PeriodStart,
+		PeriodEnd:        reports[0].PeriodEnd,
+		Status:           entities.SettlementInvoiced,
+		CreatedBy:        userID,
+		CreatedAt:        now,
+	}
+
+	type articleTotal struct {
+		quantity int
+		net      float64
+	}
+	totals := map[uuid.ID]*articleTotal{}
+	var articleIDs []uuid.ID
+	for _, st := range reports {
+		settlement.SellThroughIDs = append(settlement.SellThroughIDs, st.ID)
+		if st.PeriodStart.Before(settlement.PeriodStart) {
+			settlement.PeriodStart = st.PeriodStart
+		}
+		if st.PeriodEnd.After(settlement.PeriodEnd) {
+			settlement.PeriodEnd = st.PeriodEnd
+		}
+		settlement.GrossAmount += st.GrossAmount
+		settlement.CommissionAmount += st.CommissionAmount
+		settlement.NetAmount += st.NetAmount
+		for _, item := range st.Items {
+			total := totals[item.ArticleID]
+			if total == nil {
+				total = &articleTotal{}
+				totals[item.ArticleID] = total
+				articleIDs = append(articleIDs, item.ArticleID)
+			}
+			total.quantity += item.Quantity
+			total.net += item.NetAmount
+		}
+	}
+	settlement.GrossAmount = roundMoney(settlement.GrossAmount)
+	settlement.CommissionAmount = roundMoney(settlement.CommissionAmount)
+	settlement.NetAmount = roundMoney(settlement.NetAmount)
+	invoiceDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if s.taxes != nil {
+		lines := make([]accounting_entities.TaxCalculationLineInput, 0, len(articleIDs))
+		for _, articleID := range articleIDs {
+			lines = append(lines, accounting_entities.TaxCalculationLineInput{ArticleID: articleID, Amount: totals[articleID].net})
+		}
+		if len(lines) == 0 {
+			lines = append(lines, accounting_entities.TaxCalculationLineInput{Amount: settlement.NetAmount})
+		}
+		calc, err := calculateTax(ctx, s.taxes, accounting_entities.TaxTransactionSale, loc.CustomerID.String(), invoiceDate, lines)
+		if err != nil {
+			return nil, err
+		}
+		settlement.TaxAmount = calc.TaxAmount
+	} else {
+		settlement.TaxAmount = roundMoney(settlement.NetAmount * entities.QuotationDefaultTaxRate / 100)
+	}
+	settlement.GrandTotal = roundMoney(settlement.NetAmount + settlement.TaxAmount)
+
+	dueDate := invoiceDate.AddDate(0, 0, loc.DueDays())
+	invoice := &entities.SalesInvoice{
+		InvoiceDate:   invoiceDate,
+		TotalAmount:   settlement.NetAmount,
+		TaxAmount:     settlement.TaxAmount,
+		GrandTotal:    settlement.GrandTotal,
+		InvoiceNumber: invoiceNumber,
+		CustomerID:    loc.CustomerID.String(),
+		DueDate:       &dueDate,
+		Status:        entities.InvoiceUnpaid,
+	}
+	invoice.ID = uuid.New()
+	invoice.CreatedAt, invoice.UpdatedAt = now, now
+	settlement.SalesInvoiceID = invoice.ID
+
+	items := make([]*entities.SalesInvoiceItem, 0, len(articleIDs))
+	for _, articleID := range articleIDs {
+		total := totals[articleID]
+		item := &entities.SalesInvoiceItem{
+			SalesInvoiceID: invoice.ID.String(),
+			ArticleID:      articleID.String(),
+			Quantity:       total.quantity,
+			UnitPrice:      roundMoney(total.net / float64(total.quantity)),
+			TotalPrice:     roundMoney(total.net),
+		}
+		item.ID = uuid.New()
+		item.CreatedAt, item.UpdatedAt = now, now
+		items = append(items, item)
+	}
+
+	if err := s.repo.CreateSettlement(ctx, &entities.SettlementPosting{
+		Settlement:   settlement,
+		Invoice:      invoice,
+		Items:        items,
+		ReceivableID: uuid.New(),
+	}); err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+// SettleDue settles the sell-through of every active location up to the given date. A
+// location that fails is logged and left for the next run.
+func (s *ConsignmentService) SettleDue(ctx context.Context, until time.Time) (int, error) {
+	locationIDs, err := s.repo.ListLocationsToSettle(ctx, until)
+	if err != nil {
+		return 0, err
+	}
+	settled := 0
+	for _, id := range locationIDs {
+		settlement, err := s.Settle(ctx, id, until, "")
+		if err != nil {
+			if !errors.Is(err, entities.ErrNothingToSettle) {
+				log.Printf("[Sales] Failed to settle consignment location %s: %v", id, err)
+			}
+			continue
+		}
+		log.Printf("[Sales] Consignment settlement %s invoiced as %s", settlement.SettlementNumber, settlement.InvoiceNumber)
+		settled++
+	}
+	return settled, nil
+}
+
+// SettlePreviousMonth settles the sell-through reported up to the end of last month.
+func (s *ConsignmentService) SettlePreviousMonth(ctx context.Context) (int, error) {
+	now := utils.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return s.SettleDue(ctx, monthStart.AddDate(0, 0, -1))
+}
+
+// GetSettlement returns a consignment settlement.
+func (s *ConsignmentService) GetSettlement(ctx context.Context, id uuid.ID) (*entities.ConsignmentSettlement, error) {
+	settlement, err := s.repo.GetSettlement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if settlement == nil {
+		return nil, entities.ErrSettlementNotFound
+	}
+	return settlement, nil
+}
+
+// ListSettlements returns the settlements of a location, latest first.
+func (s *ConsignmentService) ListSettlements(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSettlement, error) {
+	if _, err := s.GetLocation(ctx, locationID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSettlements(ctx, locationID)
+}
+
+// StockAging returns the consigned stock by age as of the given date (today when zero),
+// of one location or of all when locationID is nil.
+func (s *ConsignmentService) StockAging(ctx context.Context, locationID *uuid.ID, asOf time.Time) ([]*entities.ConsignmentAging, error) {
+	if locationID != nil {
+		if _, err := s.GetLocation(ctx, *locationID); err != nil {
+			return nil, err
+		}
+	}
+	if asOf.IsZero() {
+		asOf = utils.Now()
+	}
+	lots, err := s.repo.ListOpenLots(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	return entities.AgeConsignmentLots(lots, asOf), nil
+}
+
+func (s *ConsignmentService) activeLocation(ctx context.Context, id uuid.ID) (*entities.ConsignmentLocation, error) {
+	loc, err := s.GetLocation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if loc.Status != entities.ConsignmentLocationActive {
+		return nil, fmt.Errorf("%w: location is %s", entities.ErrInvalidConsignmentLocation, loc.Status)
+	}
+	return loc, nil
+}
+
+// lotPrices returns the average consigned price of the oldest stock of each article at a
+// location, checking the location holds the quantities.
+func (s *ConsignmentService) lotPrices(ctx context.Context, locationID uuid.ID, quantities map[uuid.ID]int) (map[uuid.ID]float64, error) {
+	lots, err := s.repo.ListOpenLots(ctx, &locationID)
+	if err != nil {
+		return nil, err
+	}
+	byArticle := map[uuid.ID][]*entities.ConsignmentLot{}
+	for _, lot := range lots {
+		// Copies, as this only prices the stock; the repository consumes it
+		copied := *lot
+		byArticle[lot.ArticleID] = append(byArticle[lot.ArticleID], &copied)
+	}
+
+	articleIDs := make([]uuid.ID, 0, len(quantities))
+	for articleID := range quantities {
+		articleIDs = append(articleIDs, articleID)
+	}
+	sort.Slice(articleIDs, func(i, j int) bool { return articleIDs[i].String() < articleIDs[j].String() })
+
+	prices := make(map[uuid.ID]float64, len(quantities))
+	for _, articleID := range articleIDs {
+		consumed, err := entities.ConsumeLots(byArticle[articleID], quantities[articleID])
+		if err != nil {
+			return nil, fmt.Errorf("%w (article %s)", err, articleID)
+		}
+		prices[articleID] = roundMoney(entities.ConsumedValue(consumed) / float64(quantities[articleID]))
+	}
+	return prices, nil
+}
+
+func (s *ConsignmentService) postTransfer(ctx context.Context, t *entities.ConsignmentTransfer) (*entities.ConsignmentTransfer, error) {
+	now := utils.Now()
+	if t.TransferDate.IsZero() {
+		t.TransferDate = now
+	}
+	number, err := s.repo.NextTransferNumber(ctx, t.TransferDate)
+	if err != nil {
+		return nil, err
+	}
+	t.ID = uuid.New()
+	t.TransferNumber = number
+	t.CreatedAt = now
+	for _, item := range t.Items {
+		item.ID = uuid.New()
+		item.TransferID = t.ID
+	}
+	if err := s.repo.CreateTransfer(ctx, t); err != nil {
+		return nil, err
+	}
+
+	for _, item := range t.Items {
+		s.moveStock(ctx, item.ArticleID, t.FromWarehouseID, item.Quantity, "out", t.TransferDate, t.ID, t.TransferNumber)
+		s.moveStock(ctx, item.ArticleID, t.ToWarehouseID, item.Quantity, "in", t.TransferDate, t.ID, t.TransferNumber)
+	}
+	return t, nil
+}
+
+// moveStock records a stock movement of a posted consignment document. The document
+// stands once posted; a failed movement is logged for correction.
+func (s *ConsignmentService) moveStock(ctx context.Context, articleID, warehouseID uuid.ID, quantity int, movementType string,
+	at time.Time, referenceID uuid.ID, number string) {
+	if err := s.stock.RecordStockMovement(ctx, &inventory_entities.StockMovement{
+		ArticleID:    articleID,
+		WarehouseID:  warehouseID,
+		Quantity:     quantity,
+		MovementType: movementType,
+		MovementDate: at,
+		ReferenceID:  referenceID,
+	}); err != nil {
+		log.Printf("[Sales] Failed to record stock movement of consignment document %s, article %s: %v", number, articleID, err)
+	}
+}
+
+func validateTransferItems(items []*entities.ConsignmentTransferItem) error {
+	if len(items) == 0 {
+		return fmt.Errorf("%w: a transfer needs at least one item", entities.ErrInvalidConsignmentTransfer)
+	}
+	for i, item := range items {
+		if item.ArticleID.IsNil() {
+			return fmt.Errorf("%w: item %d has no article", entities.ErrInvalidConsignmentTransfer, i+1)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: item %d needs a positive quantity", entities.ErrInvalidConsignmentTransfer, i+1)
+		}
+	}
+	return nil
+}
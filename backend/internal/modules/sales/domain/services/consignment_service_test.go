@@ -0,0 +1,516 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockConsignmentRepository is a mock implementation of repositories.ConsignmentRepository.
+type MockConsignmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockConsignmentRepository) CreateLocation(ctx context.Context, loc *entities.ConsignmentLocation) error {
+	args := m.Called(ctx, loc)
+	return args.Error(0)
+}
+
+func (m *MockConsignmentRepository) GetLocation(ctx context.Context, id uuid.ID) (*entities.ConsignmentLocation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ConsignmentLocation), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ListLocations(ctx context.Context) ([]*entities.ConsignmentLocation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.ConsignmentLocation), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) NextTransferNumber(ctx context.Context, at time.Time) (string, error) {
+	args := m.Called(ctx, at)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) NextSellThroughNumber(ctx context.Context, at time.Time) (string, error) {
+	args := m.Called(ctx, at)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) NextSettlementNumber(ctx context.Context, at time.Time) (string, error) {
+	args := m.Called(ctx, at)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) NextInvoiceNumber(ctx context.Context, at time.Time) (string, error) {
+	args := m.Called(ctx, at)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) GetArticlePrices(ctx context.Context, articleIDs []uuid.ID) (map[uuid.ID]float64, error) {
+	args := m.Called(ctx, articleIDs)
+	return args.Get(0).(map[uuid.ID]float64), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ResolveArticles(ctx context.Context, keys []string) (map[string]uuid.ID, error) {
+	args := m.Called(ctx, keys)
+	return args.Get(0).(map[string]uuid.ID), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ListOpenLots(ctx context.Context, locationID *uuid.ID) ([]*entities.ConsignmentLot, error) {
+	args := m.Called(ctx, locationID)
+	return args.Get(0).([]*entities.ConsignmentLot), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) CreateTransfer(ctx context.Context, t *entities.ConsignmentTransfer) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockConsignmentRepository) GetTransfer(ctx context.Context, id uuid.ID) (*entities.ConsignmentTransfer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ConsignmentTransfer), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ListTransfers(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentTransfer, error) {
+	args := m.Called(ctx, locationID)
+	return args.Get(0).([]*entities.ConsignmentTransfer), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) CreateSellThrough(ctx context.Context, st *entities.ConsignmentSellThrough) error {
+	args := m.Called(ctx, st)
+	return args.Error(0)
+}
+
+func (m *MockConsignmentRepository) GetSellThrough(ctx context.Context, id uuid.ID) (*entities.ConsignmentSellThrough, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ConsignmentSellThrough), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ListSellThroughs(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSellThrough, error) {
+	args := m.Called(ctx, locationID)
+	return args.Get(0).([]*entities.ConsignmentSellThrough), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ListUnsettled(ctx context.Context, locationID uuid.ID, until time.Time) ([]*entities.ConsignmentSellThrough, error) {
+	args := m.Called(ctx, locationID, until)
+	return args.Get(0).([]*entities.ConsignmentSellThrough), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ListLocationsToSettle(ctx context.Context, until time.Time) ([]uuid.ID, error) {
+	args := m.Called(ctx, until)
+	return args.Get(0).([]uuid.ID), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) CreateSettlement(ctx context.Context, p *entities.SettlementPosting) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockConsignmentRepository) GetSettlement(ctx context.Context, id uuid.ID) (*entities.ConsignmentSettlement, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ConsignmentSettlement), args.Error(1)
+}
+
+func (m *MockConsignmentRepository) ListSettlements(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSettlement, error) {
+	args := m.Called(ctx, locationID)
+	return args.Get(0).([]*entities.ConsignmentSettlement), args.Error(1)
+}
+
+// testConsignmentLocation returns an active location at a store taking 20% commission
+// on 45 days' terms.
+func testConsignmentLocation() *entities.ConsignmentLocation {
+	return &entities.ConsignmentLocation{
+		ID:                     uuid.New(),
+		DepstoreID:             uuid.New(),
+		WarehouseID:            uuid.New(),
+		CustomerID:             uuid.New(),
+		Status:                 entities.ConsignmentLocationActive,
+		DepstoreCommissionRate: 20,
+		PaymentTerms:           "NET 45",
+	}
+}
+
+func testConsignmentLot(locationID, articleID uuid.ID, received time.Time, quantity int, unitPrice float64) *entities.ConsignmentLot {
+	return &entities.ConsignmentLot{
+		ID:                uuid.New(),
+		LocationID:        locationID,
+		ArticleID:         articleID,
+		ReceivedDate:      received,
+		Quantity:          quantity,
+		RemainingQuantity: quantity,
+		UnitPrice:         unitPrice,
+	}
+}
+
+// testConsignmentLots returns the lots of 10 shirts consigned at 100 and 5 pants at 200
+// a location received in August.
+func testConsignmentLots(locationID, shirt, pants uuid.ID) []*entities.ConsignmentLot {
+	august := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	return []*entities.ConsignmentLot{
+		testConsignmentLot(locationID, shirt, august, 10, 100),
+		testConsignmentLot(locationID, pants, august, 5, 200),
+	}
+}
+
+func TestConsignmentService_CreateLocation(t *testing.T) {
+	repo := new(MockConsignmentRepository)
+	service := NewConsignmentService(repo, new(MockStockMover))
+	ctx := context.Background()
+
+	repo.On("CreateLocation", ctx, mock.AnythingOfType("*entities.ConsignmentLocation")).Return(nil).Once()
+	repo.On("GetLocation", ctx, mock.AnythingOfType("uuid.ID")).
+		Return(&entities.ConsignmentLocation{Status: entities.ConsignmentLocationActive}, nil).Once()
+	_, err := service.CreateLocation(ctx, &entities.ConsignmentLocation{DepstoreID: uuid.New(), CustomerID: uuid.New()})
+	require.NoError(t, err)
+	stored := repo.Calls[0].Arguments.Get(1).(*entities.ConsignmentLocation)
+	assert.False(t, stored.ID.IsNil())
+	assert.False(t, stored.WarehouseID.IsNil(), "every location gets its own warehouse")
+	assert.Equal(t, entities.ConsignmentLocationActive, stored.Status)
+	assert.Equal(t, stored.ID, repo.Calls[1].Arguments.Get(1))
+
+	_, err = service.CreateLocation(ctx, &entities.ConsignmentLocation{DepstoreID: uuid.New()})
+	assert.True(t, errors.Is(err, entities.ErrInvalidConsignmentLocation))
+
+	rate := 120.0
+	_, err = service.CreateLocation(ctx, &entities.ConsignmentLocation{DepstoreID: uuid.New(), CustomerID: uuid.New(), CommissionRate: &rate})
+	assert.True(t, errors.Is(err, entities.ErrInvalidConsignmentLocation))
+	repo.AssertExpectations(t)
+}
+
+func TestConsignmentService_TransferOut(t *testing.T) {
+	repo, stock := new(MockConsignmentRepository), new(MockStockMover)
+	service := NewConsignmentService(repo, stock)
+	ctx := context.Background()
+	location, warehouse, shirt, pants := testConsignmentLocation(), uuid.New(), uuid.New(), uuid.New()
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Times(5)
+
+	repo.On("NextTransferNumber", ctx, mock.AnythingOfType("time.Time")).Return("CT-0001", nil).Once()
+	repo.On("CreateTransfer", ctx, mock.AnythingOfType("*entities.ConsignmentTransfer")).Return(nil).Once()
+	stock.On("RecordStockMovement", ctx, mock.AnythingOfType("*entities.StockMovement")).Return(nil).Twice()
+	transfer, err := service.TransferOut(ctx, &entities.ConsignmentTransfer{
+		LocationID:      location.ID,
+		FromWarehouseID: warehouse,
+		Items:           []*entities.ConsignmentTransferItem{{ArticleID: shirt, Quantity: 4, UnitPrice: 110.004}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entities.ConsignmentTransferOut, transfer.Direction)
+	assert.Equal(t, location.WarehouseID, transfer.ToWarehouseID)
+	assert.Equal(t, 110.0, transfer.Items[0].UnitPrice)
+	assert.Equal(t, "CT-0001", transfer.TransferNumber)
+
+	movements := stock.movements()
+	require.Len(t, movements, 2)
+	assert.Equal(t, "out", movements[0].MovementType)
+	assert.Equal(t, warehouse, movements[0].WarehouseID)
+	assert.Equal(t, "in", movements[1].MovementType)
+	assert.Equal(t, location.WarehouseID, movements[1].WarehouseID)
+
+	// Items without a price are consigned at the selling price
+	repo.On("GetArticlePrices", ctx, []uuid.ID{pants}).Return(map[uuid.ID]float64{pants: 200}, nil).Once()
+	repo.On("NextTransferNumber", ctx, mock.AnythingOfType("time.Time")).Return("CT-0002", nil).Once()
+	repo.On("CreateTransfer", ctx, mock.AnythingOfType("*entities.ConsignmentTransfer")).Return(nil).Once()
+	stock.On("RecordStockMovement", ctx, mock.AnythingOfType("*entities.StockMovement")).Return(nil).Twice()
+	transfer, err = service.TransferOut(ctx, &entities.ConsignmentTransfer{
+		LocationID:      location.ID,
+		FromWarehouseID: warehouse,
+		Items:           []*entities.ConsignmentTransferItem{{ArticleID: pants, Quantity: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, transfer.Items[0].UnitPrice)
+
+	_, err = service.TransferOut(ctx, &entities.ConsignmentTransfer{
+		LocationID:      location.ID,
+		FromWarehouseID: location.WarehouseID,
+		Items:           []*entities.ConsignmentTransferItem{{ArticleID: shirt, Quantity: 1}},
+	})
+	assert.True(t, errors.Is(err, entities.ErrInvalidConsignmentTransfer))
+
+	unknown := uuid.New()
+	repo.On("GetArticlePrices", ctx, []uuid.ID{unknown}).Return(map[uuid.ID]float64{}, nil).Once()
+	_, err = service.TransferOut(ctx, &entities.ConsignmentTransfer{
+		LocationID:      location.ID,
+		FromWarehouseID: warehouse,
+		Items:           []*entities.ConsignmentTransferItem{{ArticleID: unknown, Quantity: 1}},
+	})
+	assert.True(t, errors.Is(err, entities.ErrInvalidConsignmentTransfer), "unknown articles have no price")
+
+	location.Status = entities.ConsignmentLocationInactive
+	_, err = service.TransferOut(ctx, &entities.ConsignmentTransfer{
+		LocationID:      location.ID,
+		FromWarehouseID: warehouse,
+		Items:           []*entities.ConsignmentTransferItem{{ArticleID: shirt, Quantity: 1}},
+	})
+	assert.True(t, errors.Is(err, entities.ErrInvalidConsignmentLocation))
+	repo.AssertExpectations(t)
+	stock.AssertExpectations(t)
+}
+
+func TestConsignmentService_ReturnToWarehouse(t *testing.T) {
+	repo, stock := new(MockConsignmentRepository), new(MockStockMover)
+	service := NewConsignmentService(repo, stock)
+	ctx := context.Background()
+	location, warehouse, shirt, pants := testConsignmentLocation(), uuid.New(), uuid.New(), uuid.New()
+	lots := testConsignmentLots(location.ID, shirt, pants)
+	september := testConsignmentLot(location.ID, shirt, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), 10, 130)
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Twice()
+
+	// Returns take the oldest stock first: 10 at 100, then 2 at 130
+	repo.On("ListOpenLots", ctx, &location.ID).Return(append(lots, september), nil).Once()
+	repo.On("NextTransferNumber", ctx, mock.AnythingOfType("time.Time")).Return("CT-0001", nil).Once()
+	repo.On("CreateTransfer", ctx, mock.AnythingOfType("*entities.ConsignmentTransfer")).Return(nil).Once()
+	stock.On("RecordStockMovement", ctx, mock.AnythingOfType("*entities.StockMovement")).Return(nil).Twice()
+	transfer, err := service.ReturnToWarehouse(ctx, &entities.ConsignmentTransfer{
+		LocationID:    location.ID,
+		ToWarehouseID: warehouse,
+		Items:         []*entities.ConsignmentTransferItem{{ArticleID: shirt, Quantity: 12}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entities.ConsignmentTransferReturn, transfer.Direction)
+	assert.Equal(t, location.WarehouseID, transfer.FromWarehouseID)
+	assert.Equal(t, 105.0, transfer.Items[0].UnitPrice)
+	movements := stock.movements()
+	require.Len(t, movements, 2)
+	assert.Equal(t, location.WarehouseID, movements[0].WarehouseID)
+	assert.Equal(t, warehouse, movements[1].WarehouseID)
+
+	september.RemainingQuantity = 8
+	repo.On("ListOpenLots", ctx, &location.ID).Return([]*entities.ConsignmentLot{september, lots[1]}, nil).Once()
+	_, err = service.ReturnToWarehouse(ctx, &entities.ConsignmentTransfer{
+		LocationID:    location.ID,
+		ToWarehouseID: warehouse,
+		Items:         []*entities.ConsignmentTransferItem{{ArticleID: shirt, Quantity: 9}},
+	})
+	assert.True(t, errors.Is(err, entities.ErrInsufficientConsignmentStock))
+	repo.AssertExpectations(t)
+	stock.AssertExpectations(t)
+}
+
+func TestConsignmentService_ImportSellThrough(t *testing.T) {
+	repo, stock := new(MockConsignmentRepository), new(MockStockMover)
+	service := NewConsignmentService(repo, stock)
+	ctx := context.Background()
+	location, shirt, pants := testConsignmentLocation(), uuid.New(), uuid.New()
+	lots := testConsignmentLots(location.ID, shirt, pants)
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Times(4)
+
+	repo.On("ResolveArticles", ctx, []string{"8990000000017", "PANTS-01"}).
+		Return(map[string]uuid.ID{"8990000000017": shirt, "PANTS-01": pants}, nil).Once()
+	repo.On("ListOpenLots", ctx, &location.ID).Return(lots, nil).Once()
+	repo.On("NextSellThroughNumber", ctx, end).Return("CST-0001", nil).Once()
+	repo.On("CreateSellThrough", ctx, mock.AnythingOfType("*entities.ConsignmentSellThrough")).Return(nil).Once()
+	stock.On("RecordStockMovement", ctx, mock.AnythingOfType("*entities.StockMovement")).Return(nil).Twice()
+	st, err := service.ImportSellThrough(ctx, location.ID, start, end,
+		strings.NewReader("barcode;article;qty;price\n8990000000017;;3;\n;PANTS-01;2;Rp 250.000\n"), "report.csv", "")
+	require.NoError(t, err)
+	assert.Equal(t, "CST-0001", st.ReportNumber)
+	assert.Equal(t, location.DepstoreID, st.DepstoreID)
+	assert.Equal(t, 20.0, st.CommissionRate)
+	require.Len(t, st.Items, 2)
+	shirtLine, pantsLine := st.Items[0], st.Items[1]
+	assert.Equal(t, shirt, shirtLine.ArticleID)
+	assert.Equal(t, 100.0, shirtLine.UnitPrice, "unpriced lines are valued at the consigned price")
+	assert.Equal(t, 300.0, shirtLine.GrossAmount)
+	assert.Equal(t, 60.0, shirtLine.CommissionAmount)
+	assert.Equal(t, 240.0, shirtLine.NetAmount)
+	assert.Equal(t, 250000.0, pantsLine.UnitPrice)
+	assert.Equal(t, 500300.0, st.GrossAmount)
+	assert.Equal(t, 100060.0, st.CommissionAmount)
+	assert.Equal(t, 400240.0, st.NetAmount)
+
+	movements := stock.movements()
+	require.Len(t, movements, 2)
+	assert.Equal(t, "out", movements[0].MovementType)
+	assert.Equal(t, location.WarehouseID, movements[0].WarehouseID)
+	assert.Equal(t, 3, movements[0].Quantity)
+
+	repo.On("ResolveArticles", ctx, []string{"SHIRT-01", "HAT-01"}).Return(map[string]uuid.ID{"SHIRT-01": shirt}, nil).Once()
+	_, err = service.ImportSellThrough(ctx, location.ID, start, end, strings.NewReader("sku,qty\nSHIRT-01,1\nHAT-01,2\n"), "", "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, entities.ErrInvalidSellThrough))
+	assert.Contains(t, err.Error(), "line 3 (HAT-01)")
+
+	lots[1].RemainingQuantity = 3
+	repo.On("ResolveArticles", ctx, []string{"PANTS-01"}).Return(map[string]uuid.ID{"PANTS-01": pants}, nil).Once()
+	repo.On("ListOpenLots", ctx, &location.ID).Return(lots, nil).Once()
+	_, err = service.ImportSellThrough(ctx, location.ID, start, end, strings.NewReader("sku,qty\nPANTS-01,4\n"), "", "")
+	assert.True(t, errors.Is(err, entities.ErrInsufficientConsignmentStock), "only 3 pants are left")
+
+	_, err = service.ImportSellThrough(ctx, location.ID, end, start, strings.NewReader("sku,qty\nSHIRT-01,1\n"), "", "")
+	assert.True(t, errors.Is(err, entities.ErrInvalidSellThrough))
+	repo.AssertExpectations(t)
+	stock.AssertExpectations(t)
+}
+
+func TestConsignmentService_Settle(t *testing.T) {
+	repo, stock := new(MockConsignmentRepository), new(MockStockMover)
+	service := NewConsignmentService(repo, stock)
+	ctx := context.Background()
+	location, shirt, pants := testConsignmentLocation(), uuid.New(), uuid.New()
+	month := func(m time.Month) (time.Time, time.Time) {
+		start := time.Date(2026, m, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	}
+
+	// Two shirts are sold in August, three and a pair of pants in September and one
+	// more shirt in October
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Times(3)
+	repo.On("ResolveArticles", ctx, mock.AnythingOfType("[]string")).
+		Return(map[string]uuid.ID{"SHIRT-01": shirt, "PANTS-01": pants}, nil).Times(3)
+	repo.On("ListOpenLots", ctx, &location.ID).Return(testConsignmentLots(location.ID, shirt, pants), nil).Times(3)
+	repo.On("NextSellThroughNumber", ctx, mock.AnythingOfType("time.Time")).Return("CST-0001", nil).Times(3)
+	repo.On("CreateSellThrough", ctx, mock.AnythingOfType("*entities.ConsignmentSellThrough")).Return(nil).Times(3)
+	stock.On("RecordStockMovement", ctx, mock.AnythingOfType("*entities.StockMovement")).Return(nil).Times(4)
+	start, end := month(time.August)
+	august, err := service.ImportSellThrough(ctx, location.ID, start, end, strings.NewReader("sku,qty\nSHIRT-01,2\n"), "", "")
+	require.NoError(t, err)
+	start, end = month(time.September)
+	september, err := service.ImportSellThrough(ctx, location.ID, start, end, strings.NewReader("sku,qty\nSHIRT-01,3\nPANTS-01,1\n"), "", "")
+	require.NoError(t, err)
+	start, end = month(time.October)
+	october, err := service.ImportSellThrough(ctx, location.ID, start, end, strings.NewReader("sku,qty\nSHIRT-01,1\n"), "", "")
+	require.NoError(t, err)
+
+	until := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	posting := &entities.SettlementPosting{}
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Once()
+	repo.On("ListUnsettled", ctx, location.ID, until).Return([]*entities.ConsignmentSellThrough{august, september}, nil).Once()
+	repo.On("NextSettlementNumber", ctx, mock.AnythingOfType("time.Time")).Return("CSM-0001", nil).Once()
+	repo.On("NextInvoiceNumber", ctx, mock.AnythingOfType("time.Time")).Return("INV-0001", nil).Once()
+	repo.On("CreateSettlement", ctx, mock.AnythingOfType("*entities.SettlementPosting")).
+		Return(nil).Once().
+		Run(func(args mock.Arguments) {
+			*posting = *args.Get(1).(*entities.SettlementPosting)
+		})
+	settlement, err := service.Settle(ctx, location.ID, until, "")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.ID{august.ID, september.ID}, settlement.SellThroughIDs)
+	assert.Equal(t, august.PeriodStart, settlement.PeriodStart)
+	assert.Equal(t, september.PeriodEnd, settlement.PeriodEnd)
+	assert.Equal(t, 700.0, settlement.GrossAmount)
+	assert.Equal(t, 140.0, settlement.CommissionAmount)
+	assert.Equal(t, 560.0, settlement.NetAmount)
+	assert.Equal(t, 61.6, settlement.TaxAmount)
+	assert.Equal(t, 621.6, settlement.GrandTotal)
+
+	assert.Equal(t, settlement, posting.Settlement)
+	assert.Equal(t, location.CustomerID.String(), posting.Invoice.CustomerID)
+	assert.Empty(t, posting.Invoice.SalesOrderID)
+	assert.Equal(t, settlement.InvoiceNumber, posting.Invoice.InvoiceNumber)
+	assert.Equal(t, posting.Invoice.InvoiceDate.AddDate(0, 0, 45), *posting.Invoice.DueDate)
+	require.Len(t, posting.Items, 2)
+	assert.Equal(t, shirt.String(), posting.Items[0].ArticleID)
+	assert.Equal(t, 5, posting.Items[0].Quantity)
+	assert.Equal(t, 80.0, posting.Items[0].UnitPrice)
+	assert.Equal(t, 400.0, posting.Items[0].TotalPrice)
+	assert.Equal(t, 160.0, posting.Items[1].TotalPrice)
+
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Once()
+	repo.On("ListUnsettled", ctx, location.ID, until).Return([]*entities.ConsignmentSellThrough{}, nil).Once()
+	_, err = service.Settle(ctx, location.ID, until, "")
+	assert.True(t, errors.Is(err, entities.ErrNothingToSettle))
+
+	until = time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	repo.On("ListLocationsToSettle", ctx, until).Return([]uuid.ID{location.ID}, nil).Once()
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Once()
+	repo.On("ListUnsettled", ctx, location.ID, until).Return([]*entities.ConsignmentSellThrough{october}, nil).Once()
+	repo.On("NextSettlementNumber", ctx, mock.AnythingOfType("time.Time")).Return("CSM-0002", nil).Once()
+	repo.On("NextInvoiceNumber", ctx, mock.AnythingOfType("time.Time")).Return("INV-0002", nil).Once()
+	repo.On("CreateSettlement", ctx, mock.AnythingOfType("*entities.SettlementPosting")).
+		Return(nil).Once().
+		Run(func(args mock.Arguments) {
+			*posting = *args.Get(1).(*entities.SettlementPosting)
+		})
+	settled, err := service.SettleDue(ctx, until)
+	require.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, []uuid.ID{october.ID}, posting.Settlement.SellThroughIDs)
+	repo.AssertExpectations(t)
+	stock.AssertExpectations(t)
+}
+
+func TestConsignmentService_StockAging(t *testing.T) {
+	repo := new(MockConsignmentRepository)
+	service := NewConsignmentService(repo, new(MockStockMover))
+	ctx := context.Background()
+	location, shirt, pants := testConsignmentLocation(), uuid.New(), uuid.New()
+	lots := testConsignmentLots(location.ID, shirt, pants)
+	lots[1].RemainingQuantity = 0
+
+	repo.On("GetLocation", ctx, location.ID).Return(location, nil).Once()
+	repo.On("ListOpenLots", ctx, &location.ID).Return(lots, nil).Once()
+	aging, err := service.StockAging(ctx, &location.ID, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, aging, 1, "sold out articles are not aged")
+	assert.Equal(t, shirt, aging[0].ArticleID)
+	assert.Equal(t, []int{0, 0, 10, 0}, aging[0].Buckets)
+	assert.Equal(t, 1000.0, aging[0].Value)
+
+	missing := uuid.New()
+	repo.On("GetLocation", ctx, missing).Return(nil, nil).Once()
+	_, err = service.StockAging(ctx, &missing, time.Time{})
+	assert.True(t, errors.Is(err, entities.ErrConsignmentLocationNotFound))
+	repo.AssertExpectations(t)
+}
+
+func TestParseSellThroughCSV(t *testing.T) {
+	lines, err := ParseSellThroughCSV(strings.NewReader("\ufeffArticle Code,EAN,Quantity Sold,Unit Price\nSHIRT-01,,2,\"125,000\"\n,,,\nPANTS-01,899001,1,99.5\n"))
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, SellThroughLine{Line: 2, Article: "SHIRT-01", Quantity: 2, UnitPrice: 125000}, lines[0])
+	assert.Equal(t, "899001", lines[1].Key())
+	assert.Equal(t, 4, lines[1].Line)
+	assert.Equal(t, 99.5, lines[1].UnitPrice)
+
+	for name, csv := range map[string]string{
+		"no quantity column": "article,price\nSHIRT-01,100\n",
+		"no article column":  "qty\n1\n",
+		"zero quantity":      "article,qty\nSHIRT-01,0\n",
+		"bad price":          "article,qty,price\nSHIRT-01,1,abc\n",
+		"no lines":           "article,qty\n",
+	} {
+		_, err := ParseSellThroughCSV(strings.NewReader(csv))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseReportedPrice(t *testing.T) {
+	for input, want := range map[string]float64{
+		"125000":     125000,
+		"125.000":    125000,
+		"1.250.000":  1250000,
+		"125,000":    125000,
+		"125.000,50": 125000.5,
+		"125,000.50": 125000.5,
+		"99,5":       99.5,
+		"Rp 125.000": 125000,
+		"IDR125000":  125000,
+		"12.5":       12.5,
+	} {
+		got, err := parseReportedPrice(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+}
@@ -0,0 +1,728 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// loyaltyReviewBatchSize is the number of members loaded per batch by the tier review job.
+const loyaltyReviewBatchSize = 500
+
+// LoyaltyService provides business logic for the customer loyalty program.
+type LoyaltyService struct {
+	repo     repositories.LoyaltyRepository
+	articles ArticleLookup
+}
+
+// NewLoyaltyService creates a new LoyaltyService. articles may be nil, in which case
+// category multipliers are not applied.
+func NewLoyaltyService(repo repositories.LoyaltyRepository, articles ArticleLookup) *LoyaltyService {
+	return &LoyaltyService{repo: repo, articles: articles}
+}
+
+// GetProgram returns the loyalty program rules.
+func (s *LoyaltyService) GetProgram(ctx context.Context) (*entities.LoyaltyProgram, error) {
+	program, err := s.repo.GetProgram(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if program == nil {
+		return entities.DefaultLoyaltyProgram(), nil
+	}
+	return program, nil
+}
+
+// UpdateProgram validates and saves the loyalty program rules.
+func (s *LoyaltyService) UpdateProgram(ctx context.Context, program *entities.LoyaltyProgram) error {
+	switch {
+	case program.EarnSpendUnit <= 0:
+		return fmt.Errorf("%w: earn spend unit must be greater than zero", entities.ErrInvalidLoyaltySetup)
+	case program.PointValue <= 0:
+		return fmt.Errorf("%w: point value must be greater than zero", entities.ErrInvalidLoyaltySetup)
+	case program.MaxRedeemRate <= 0 || program.MaxRedeemRate > 1:
+		return fmt.Errorf("%w: max redeem rate must be between 0 and 1", entities.ErrInvalidLoyaltySetup)
+	case program.PointsExpiryMonths < 0 || program.TierReviewMonths < 0 || program.MinRedeemPoints < 0:
+		return fmt.Errorf("%w: months and minimum points cannot be negative", entities.ErrInvalidLoyaltySetup)
+	}
+	for classificationID, multiplier := range program.CategoryMultipliers {
+		if multiplier < 0 {
+			return fmt.Errorf("%w: multiplier of classification %s cannot be negative", entities.ErrInvalidLoyaltySetup, classificationID)
+		}
+	}
+	program.UpdatedAt = utils.Now()
+	return s.repo.SaveProgram(ctx, program)
+}
+
+// CreateTier creates a new membership tier.
+func (s *LoyaltyService) CreateTier(ctx context.Context, tier *entities.LoyaltyTier) error {
+	if tier.ID.IsNil() {
+		tier.ID = uuid.New()
+	}
+	if tier.EarnMultiplier == 0 {
+		tier.EarnMultiplier = 1
+	}
+	if err := validateLoyaltyTier(tier); err != nil {
+		return err
+	}
+	return s.repo.CreateTier(ctx, tier)
+}
+
+// UpdateTier updates an existing membership tier.
+func (s *LoyaltyService) UpdateTier(ctx context.Context, tier *entities.LoyaltyTier) error {
+	existing, err := s.repo.GetTierByID(ctx, tier.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return entities.ErrLoyaltyTierNotFound
+	}
+	if err := validateLoyaltyTier(tier); err != nil {
+		return err
+	}
+	return s.repo.UpdateTier(ctx, tier)
+}
+
+// DeleteTier deletes a membership tier.
+func (s *LoyaltyService) DeleteTier(ctx context.Context, id uuid.ID) error {
+	existing, err := s.repo.GetTierByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return entities.ErrLoyaltyTierNotFound
+	}
+	return s.repo.DeleteTier(ctx, id)
+}
+
+// GetTiers retrieves all membership tiers ordered by rank.
+func (s *LoyaltyService) GetTiers(ctx context.Context) ([]*entities.LoyaltyTier, error) {
+	return s.repo.GetTiers(ctx)
+}
+
+// EnrollMember registers a new member in the lowest active tier.
+func (s *LoyaltyService) EnrollMember(ctx context.Context, member *entities.LoyaltyMember) error {
+	member.Phone = normalizeMemberIdentifier(member.Phone)
+	member.CardBarcode = normalizeMemberIdentifier(member.CardBarcode)
+	if member.Phone == "" && member.CardBarcode == "" {
+		return fmt.Errorf("%w: a phone number or card barcode is required", entities.ErrInvalidLoyaltySetup)
+	}
+	if err := s.checkIdentifiersFree(ctx, member); err != nil {
+		return err
+	}
+
+	tiers, err := s.activeTiers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tiers) == 0 {
+		return fmt.Errorf("%w: no active loyalty tier is configured", entities.ErrInvalidLoyaltySetup)
+	}
+
+	now := utils.Now()
+	if member.ID.IsNil() {
+		member.ID = uuid.New()
+	}
+	member.MemberNumber = fmt.Sprintf("M%s%08d", now.Format("0601"), rand.IntN(100000000))
+	if member.CardBarcode == "" {
+		member.CardBarcode = member.MemberNumber
+	}
+	member.TierID = tiers[0].ID
+	member.TierName = tiers[0].Name
+	member.TierSince = now
+	member.Status = entities.LoyaltyMemberActive
+	member.JoinedAt = now
+	member.PointsBalance = 0
+	member.LifetimePoints = 0
+	return s.repo.CreateMember(ctx, member)
+}
+
+// UpdateMember updates the contact details and status of a member.
+func (s *LoyaltyService) UpdateMember(ctx context.Context, member *entities.LoyaltyMember) error {
+	existing, err := s.repo.GetMemberByID(ctx, member.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return entities.ErrLoyaltyMemberNotFound
+	}
+	member.Phone = normalizeMemberIdentifier(member.Phone)
+	member.CardBarcode = normalizeMemberIdentifier(member.CardBarcode)
+	if member.CardBarcode == "" {
+		member.CardBarcode = existing.CardBarcode
+	}
+	if err := s.checkIdentifiersFree(ctx, member); err != nil {
+		return err
+	}
+	if member.Status == "" {
+		member.Status = existing.Status
+	}
+	if member.Status != entities.LoyaltyMemberActive && member.Status != entities.LoyaltyMemberSuspended {
+		return fmt.Errorf("%w: invalid member status %q", entities.ErrInvalidLoyaltySetup, member.Status)
+	}
+	return s.repo.UpdateMember(ctx, member)
+}
+
+// GetMember retrieves a member by ID.
+func (s *LoyaltyService) GetMember(ctx context.Context, id uuid.ID) (*entities.LoyaltyMember, error) {
+	member, err := s.repo.GetMemberByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, entities.ErrLoyaltyMemberNotFound
+	}
+	return member, nil
+}
+
+// FindMember looks up a member by phone, card barcode or member number.
+func (s *LoyaltyService) FindMember(ctx context.Context, identifier string) (*entities.LoyaltyMember, error) {
+	identifier = normalizeMemberIdentifier(identifier)
+	if identifier == "" {
+		return nil, entities.ErrLoyaltyMemberNotFound
+	}
+	member, err := s.repo.FindMember(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, entities.ErrLoyaltyMemberNotFound
+	}
+	return member, nil
+}
+
+// ListMembers lists members, optionally filtered by name, phone, card or member number.
+func (s *LoyaltyService) ListMembers(ctx context.Context, search string, limit, offset int) ([]*entities.LoyaltyMember, int, error) {
+	return s.repo.ListMembers(ctx, strings.TrimSpace(search), limit, offset)
+}
+
+// GetLedger retrieves the points ledger of a member, newest first.
+func (s *LoyaltyService) GetLedger(ctx context.Context, memberID uuid.ID, limit, offset int) ([]*entities.LoyaltyPointsEntry, int, error) {
+	return s.repo.GetLedger(ctx, memberID, limit, offset)
+}
+
+// AdjustPoints posts a manual correction to a member's points.
+func (s *LoyaltyService) AdjustPoints(ctx context.Context, memberID uuid.ID, points int, description string, adjustedBy string) (*entities.LoyaltyPointsEntry, error) {
+	if points == 0 {
+		return nil, fmt.Errorf("%w: points must not be zero", entities.ErrInvalidLoyaltySetup)
+	}
+	if strings.TrimSpace(description) == "" {
+		return nil, fmt.Errorf("%w: a description is required for manual adjustments", entities.ErrInvalidLoyaltySetup)
+	}
+	if _, err := s.GetMember(ctx, memberID); err != nil {
+		return nil, err
+	}
+	program, err := s.GetProgram(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := utils.Now()
+	entry := &entities.LoyaltyPointsEntry{
+		ID:          uuid.New(),
+		MemberID:    memberID,
+		EntryType:   entities.LoyaltyEntryAdjust,
+		Points:      points,
+		SourceType:  entities.LoyaltySourceManual,
+		SourceID:    adjustedBy,
+		Description: description,
+		ExpiresAt:   pointsExpiry(program, now, points),
+		CreatedAt:   now,
+	}
+	if err := s.repo.PostEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// SetMemberPrice sets the member-only price of an article for a tier.
+func (s *LoyaltyService) SetMemberPrice(ctx context.Context, price *entities.LoyaltyMemberPrice) error {
+	if price.Price < 0 {
+		return fmt.Errorf("%w: member price cannot be negative", entities.ErrInvalidLoyaltySetup)
+	}
+	tier, err := s.repo.GetTierByID(ctx, price.TierID)
+	if err != nil {
+		return err
+	}
+	if tier == nil {
+		return entities.ErrLoyaltyTierNotFound
+	}
+	if price.ID.IsNil() {
+		price.ID = uuid.New()
+	}
+	return s.repo.UpsertMemberPrice(ctx, price)
+}
+
+// DeleteMemberPrice removes a member price.
+func (s *LoyaltyService) DeleteMemberPrice(ctx context.Context, id uuid.ID) error {
+	return s.repo.DeleteMemberPrice(ctx, id)
+}
+
+// GetMemberPrices retrieves the member prices of a tier.
+func (s *LoyaltyService) GetMemberPrices(ctx context.Context, tierID uuid.ID) ([]*entities.LoyaltyMemberPrice, error) {
+	return s.repo.GetMemberPrices(ctx, tierID)
+}
+
+// ResolveMember finds the member of a checkout by ID or by phone, card barcode or
+// member number, and checks that the membership is active.
+func (s *LoyaltyService) ResolveMember(ctx context.Context, memberID, identifier string) (*entities.LoyaltyMember, error) {
+	var member *entities.LoyaltyMember
+	if memberID != "" {
+		id, err := uuid.Parse(memberID)
+		if err != nil {
+			return nil, entities.ErrLoyaltyMemberNotFound
+		}
+		if member, err = s.GetMember(ctx, id); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if member, err = s.FindMember(ctx, identifier); err != nil {
+			return nil, err
+		}
+	}
+	if member.Status != entities.LoyaltyMemberActive {
+		return nil, entities.ErrLoyaltyMemberInactive
+	}
+	return member, nil
+}
+
+// MemberUnitPrice returns the price a member pays for an article: the lowest member
+// price of the member's tier and the tiers below it, or else the list price less the
+// tier's member discount. It never returns more than the list price.
+func (s *LoyaltyService) MemberUnitPrice(ctx context.Context, member *entities.LoyaltyMember, articleID uuid.ID, listPrice float64) (float64, error) {
+	tiers, err := s.activeTiers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var memberTier *entities.LoyaltyTier
+	for _, tier := range tiers {
+		if tier.ID == member.TierID {
+			memberTier = tier
+		}
+	}
+	if memberTier == nil {
+		return listPrice, nil
+	}
+
+	var tierIDs []uuid.ID
+	for _, tier := range tiers {
+		if tier.Rank <= memberTier.Rank {
+			tierIDs = append(tierIDs, tier.ID)
+		}
+	}
+	price, err := s.repo.GetBestMemberPrice(ctx, articleID, tierIDs)
+	if err != nil {
+		return 0, err
+	}
+	if price != nil {
+		return math.Min(price.Price, listPrice), nil
+	}
+	return roundMoney(listPrice * (1 - clampRate(memberTier.MemberDiscountRate))), nil
+}
+
+// RedemptionValue checks a points redemption against the program rules and the
+// member's balance and returns the amount the points are worth.
+func (s *LoyaltyService) RedemptionValue(ctx context.Context, member *entities.LoyaltyMember, points int, payable float64) (float64, error) {
+	program, err := s.GetProgram(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if points <= 0 {
+		return 0, fmt.Errorf("%w: points must be greater than zero", entities.ErrInvalidPointsRedemption)
+	}
+	if points < program.MinRedeemPoints {
+		return 0, fmt.Errorf("%w: at least %d points must be redeemed", entities.ErrInvalidPointsRedemption, program.MinRedeemPoints)
+	}
+	if points > member.PointsBalance {
+		return 0, entities.ErrInsufficientPoints
+	}
+	value := roundMoney(float64(points) * program.PointValue)
+	if limit := roundMoney(payable * program.MaxRedeemRate); value > limit {
+		return 0, fmt.Errorf("%w: points can pay at most %.2f of this transaction", entities.ErrInvalidPointsRedemption, limit)
+	}
+	return value, nil
+}
+
+// RedeemPoints takes redeemed points from a member's balance.
+func (s *LoyaltyService) RedeemPoints(ctx context.Context, memberID uuid.ID, points int, value float64, sourceType string, sourceID uuid.ID, sourceAmount float64) error {
+	return s.repo.PostEntry(ctx, &entities.LoyaltyPointsEntry{
+		ID:           uuid.New(),
+		MemberID:     memberID,
+		EntryType:    entities.LoyaltyEntryRedeem,
+		Points:       -points,
+		SourceType:   sourceType,
+		SourceID:     sourceID.String(),
+		SourceAmount: sourceAmount,
+		Description:  fmt.Sprintf("Redeemed for %.2f", value),
+		CreatedAt:    utils.Now(),
+	})
+}
+
+// RefundRedemption gives back points redeemed for a sale that was not completed.
+func (s *LoyaltyService) RefundRedemption(ctx context.Context, memberID uuid.ID, points int, sourceType string, sourceID uuid.ID) error {
+	program, err := s.GetProgram(ctx)
+	if err != nil {
+		return err
+	}
+	now := utils.Now()
+	return s.repo.PostEntry(ctx, &entities.LoyaltyPointsEntry{
+		ID:          uuid.New(),
+		MemberID:    memberID,
+		EntryType:   entities.LoyaltyEntryRefund,
+		Points:      points,
+		SourceType:  sourceType,
+		SourceID:    sourceID.String(),
+		Description: "Redemption cancelled",
+		ExpiresAt:   pointsExpiry(program, now, points),
+		CreatedAt:   now,
+	})
+}
+
+// CalculatePoints returns the points a member earns on the sold lines and the
+// qualifying spend. Lines are multiplied by their classification's multiplier and the
+// total by the member's tier multiplier.
+func (s *LoyaltyService) CalculatePoints(ctx context.Context, member *entities.LoyaltyMember, lines []entities.LoyaltyEarnLine) (int, float64, error) {
+	program, err := s.GetProgram(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	tier, err := s.repo.GetTierByID(ctx, member.TierID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	spend, weighted := 0.0, 0.0
+	for _, line := range lines {
+		if line.Amount <= 0 {
+			continue
+		}
+		spend += line.Amount
+		weighted += line.Amount * s.categoryMultiplier(ctx, program, line.ArticleID)
+	}
+	tierMultiplier := 1.0
+	if tier != nil && tier.EarnMultiplier > 0 {
+		tierMultiplier = tier.EarnMultiplier
+	}
+	return earnedPoints(program, weighted, tierMultiplier), roundMoney(spend), nil
+}
+
+// EarnPoints credits earned points to a member and upgrades the member's tier when the
+// new qualifying spend reaches a higher tier.
+func (s *LoyaltyService) EarnPoints(ctx context.Context, memberID uuid.ID, points int, spend float64, sourceType string, sourceID uuid.ID, sourceAmount float64) error {
+	program, err := s.GetProgram(ctx)
+	if err != nil {
+		return err
+	}
+	now := utils.Now()
+	entry := &entities.LoyaltyPointsEntry{
+		ID:           uuid.New(),
+		MemberID:     memberID,
+		EntryType:    entities.LoyaltyEntryEarn,
+		Points:       points,
+		SourceType:   sourceType,
+		SourceID:     sourceID.String(),
+		SourceAmount: sourceAmount,
+		SpendAmount:  spend,
+		ExpiresAt:    pointsExpiry(program, now, points),
+		CreatedAt:    now,
+	}
+	if err := s.repo.PostEntry(ctx, entry); err != nil {
+		return err
+	}
+	_, err = s.reviewMemberTier(ctx, program, memberID, now, false)
+	return err
+}
+
+// ReverseForReturn takes back the points earned on a POS transaction and gives back the
+// points redeemed on it, in proportion to the returned amount. Amounts returned before
+// are taken into account, so the whole transaction is never reversed more than once.
+func (s *LoyaltyService) ReverseForReturn(ctx context.Context, posTransactionID string, returnID uuid.ID, returnAmount float64) error {
+	entries, err := s.repo.GetEntriesByReference(ctx, posTransactionID)
+	if err != nil {
+		return err
+	}
+
+	var memberID uuid.ID
+	earned, redeemed, earnedSpend, sourceAmount := 0, 0, 0.0, 0.0
+	returned := make(map[string]float64)
+	for _, e := range entries {
+		switch {
+		case e.SourceID == posTransactionID && e.EntryType == entities.LoyaltyEntryEarn:
+			earned += e.Points
+			earnedSpend += e.SpendAmount
+		case e.SourceID == posTransactionID && e.EntryType == entities.LoyaltyEntryRedeem:
+			redeemed -= e.Points
+		case e.SourceType == entities.LoyaltySourceSalesReturn:
+			returned[e.SourceID] = e.SourceAmount
+		default:
+			continue
+		}
+		memberID = e.MemberID
+		if e.SourceID == posTransactionID {
+			sourceAmount = math.Max(sourceAmount, e.SourceAmount)
+		}
+	}
+	if memberID.IsNil() || sourceAmount <= 0 || (earned == 0 && redeemed == 0) {
+		return nil // not a member transaction
+	}
+	if _, done := returned[returnID.String()]; done {
+		return nil
+	}
+
+	alreadyReturned := 0.0
+	for _, amount := range returned {
+		alreadyReturned += amount
+	}
+	amount := math.Min(returnAmount, sourceAmount-alreadyReturned)
+	if amount <= 0 {
+		return nil
+	}
+	ratio := amount / sourceAmount
+
+	member, err := s.repo.GetMemberByID(ctx, memberID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return entities.ErrLoyaltyMemberNotFound
+	}
+	program, err := s.GetProgram(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := utils.Now()
+	if refund := int(math.Round(float64(redeemed) * ratio)); refund > 0 {
+		if err := s.repo.PostEntry(ctx, &entities.LoyaltyPointsEntry{
+			ID:           uuid.New(),
+			MemberID:     memberID,
+			EntryType:    entities.LoyaltyEntryRefund,
+			Points:       refund,
+			SourceType:   entities.LoyaltySourceSalesReturn,
+			SourceID:     returnID.String(),
+			ReferenceID:  posTransactionID,
+			SourceAmount: amount,
+			Description:  "Redeemed points returned",
+			ExpiresAt:    pointsExpiry(program, now, refund),
+			CreatedAt:    now,
+		}); err != nil {
+			return err
+		}
+		member.PointsBalance += refund
+	}
+
+	if reverse := int(math.Round(float64(earned) * ratio)); reverse > 0 {
+		description := "Earned points reversed"
+		// Points already spent cannot be taken back
+		if reverse > member.PointsBalance {
+			description = fmt.Sprintf("Earned points reversed, %d points already spent", reverse-member.PointsBalance)
+			reverse = member.PointsBalance
+		}
+		if err := s.repo.PostEntry(ctx, &entities.LoyaltyPointsEntry{
+			ID:           uuid.New(),
+			MemberID:     memberID,
+			EntryType:    entities.LoyaltyEntryReverse,
+			Points:       -reverse,
+			SourceType:   entities.LoyaltySourceSalesReturn,
+			SourceID:     returnID.String(),
+			ReferenceID:  posTransactionID,
+			SourceAmount: amount,
+			SpendAmount:  -roundMoney(earnedSpend * ratio),
+			Description:  description,
+			CreatedAt:    now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpirePoints expires the points whose validity ended by at.
+func (s *LoyaltyService) ExpirePoints(ctx context.Context, at time.Time) (int, error) {
+	return s.repo.ExpirePoints(ctx, at)
+}
+
+// ReviewTiers moves every active member to the tier their qualifying spend earns.
+// Upgrades apply at once; downgrades only after the member held the tier for a full
+// review window. It returns the number of members whose tier changed.
+func (s *LoyaltyService) ReviewTiers(ctx context.Context, at time.Time) (int, error) {
+	program, err := s.GetProgram(ctx)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	var after uuid.ID
+	for {
+		ids, err := s.repo.ListMemberIDs(ctx, after, loyaltyReviewBatchSize)
+		if err != nil {
+			return changed, err
+		}
+		for _, id := range ids {
+			moved, err := s.reviewMemberTier(ctx, program, id, at, true)
+			if err != nil {
+				return changed, err
+			}
+			if moved {
+				changed++
+			}
+		}
+		if len(ids) < loyaltyReviewBatchSize {
+			return changed, nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// RunMaintenance expires points and reviews tiers. It is run by the nightly loyalty job.
+func (s *LoyaltyService) RunMaintenance(ctx context.Context) (expired int, tierChanges int, err error) {
+	now := utils.Now()
+	if expired, err = s.ExpirePoints(ctx, now); err != nil {
+		return 0, 0, fmt.Errorf("failed to expire loyalty points: %w", err)
+	}
+	if tierChanges, err = s.ReviewTiers(ctx, now); err != nil {
+		return expired, tierChanges, fmt.Errorf("failed to review loyalty tiers: %w", err)
+	}
+	return expired, tierChanges, nil
+}
+
+func (s *LoyaltyService) reviewMemberTier(ctx context.Context, program *entities.LoyaltyProgram, memberID uuid.ID, at time.Time, allowDowngrade bool) (bool, error) {
+	member, err := s.repo.GetMemberByID(ctx, memberID)
+	if err != nil || member == nil {
+		return false, err
+	}
+	tiers, err := s.activeTiers(ctx)
+	if err != nil || len(tiers) == 0 {
+		return false, err
+	}
+	spend, err := s.repo.GetQualifyingSpend(ctx, memberID, at.AddDate(0, -program.TierReviewMonths, 0))
+	if err != nil {
+		return false, err
+	}
+
+	target := qualifyingTier(tiers, spend)
+	current := tiers[0]
+	for _, tier := range tiers {
+		if tier.ID == member.TierID {
+			current = tier
+		}
+	}
+	if target.ID == member.TierID {
+		return false, nil
+	}
+	if target.Rank < current.Rank {
+		heldSince := at.AddDate(0, -program.TierReviewMonths, 0)
+		if !allowDowngrade || member.TierSince.After(heldSince) {
+			return false, nil
+		}
+	}
+	if err := s.repo.SetMemberTier(ctx, memberID, target.ID, at); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkIdentifiersFree fails with entities.ErrLoyaltyMemberExists when the phone or card
+// of the member is used by another member.
+func (s *LoyaltyService) checkIdentifiersFree(ctx context.Context, member *entities.LoyaltyMember) error {
+	for _, identifier := range []string{member.Phone, member.CardBarcode} {
+		if identifier == "" {
+			continue
+		}
+		existing, err := s.repo.FindMember(ctx, identifier)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != member.ID {
+			return entities.ErrLoyaltyMemberExists
+		}
+	}
+	return nil
+}
+
+func (s *LoyaltyService) activeTiers(ctx context.Context) ([]*entities.LoyaltyTier, error) {
+	tiers, err := s.repo.GetTiers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := tiers[:0]
+	for _, tier := range tiers {
+		if tier.IsActive {
+			active = append(active, tier)
+		}
+	}
+	return active, nil
+}
+
+func (s *LoyaltyService) categoryMultiplier(ctx context.Context, program *entities.LoyaltyProgram, articleID string) float64 {
+	if s.articles == nil || len(program.CategoryMultipliers) == 0 {
+		return 1
+	}
+	id, err := uuid.Parse(articleID)
+	if err != nil {
+		return 1
+	}
+	article, err := s.articles.GetArticleByID(ctx, id)
+	if err != nil || article == nil || article.ClassificationID.IsNil() {
+		return 1
+	}
+	if multiplier, ok := program.CategoryMultipliers[article.ClassificationID.String()]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+// qualifyingTier returns the highest tier whose minimum spend is reached. tiers must be
+// ordered by rank and not empty.
+func qualifyingTier(tiers []*entities.LoyaltyTier, spend float64) *entities.LoyaltyTier {
+	target := tiers[0]
+	for _, tier := range tiers {
+		if spend >= tier.MinSpend && tier.Rank >= target.Rank {
+			target = tier
+		}
+	}
+	return target
+}
+
+// earnedPoints converts a multiplied spend to whole points.
+func earnedPoints(program *entities.LoyaltyProgram, weightedSpend, tierMultiplier float64) int {
+	if program.EarnSpendUnit <= 0 || weightedSpend <= 0 {
+		return 0
+	}
+	// The small epsilon keeps float noise from dropping a point at exact multiples
+	return int(math.Floor(weightedSpend*tierMultiplier/program.EarnSpendUnit + 1e-9))
+}
+
+func pointsExpiry(program *entities.LoyaltyProgram, from time.Time, points int) *time.Time {
+	if points <= 0 || program.PointsExpiryMonths <= 0 {
+		return nil
+	}
+	expiry := from.AddDate(0, program.PointsExpiryMonths, 0)
+	return &expiry
+}
+
+func validateLoyaltyTier(tier *entities.LoyaltyTier) error {
+	switch {
+	case strings.TrimSpace(tier.Code) == "" || strings.TrimSpace(tier.Name) == "":
+		return fmt.Errorf("%w: tier code and name are required", entities.ErrInvalidLoyaltySetup)
+	case tier.MinSpend < 0:
+		return fmt.Errorf("%w: minimum spend cannot be negative", entities.ErrInvalidLoyaltySetup)
+	case tier.EarnMultiplier < 0:
+		return fmt.Errorf("%w: earn multiplier cannot be negative", entities.ErrInvalidLoyaltySetup)
+	case tier.MemberDiscountRate < 0 || tier.MemberDiscountRate > 1:
+		return fmt.Errorf("%w: member discount rate must be between 0 and 1", entities.ErrInvalidLoyaltySetup)
+	}
+	return nil
+}
+
+// normalizeMemberIdentifier strips the spaces and dashes cashiers type in phone and card numbers.
+func normalizeMemberIdentifier(identifier string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(identifier))
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockLoyaltyRepository is a mock implementation of repositories.LoyaltyRepository.
+type MockLoyaltyRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyRepository) GetProgram(ctx context.Context) (*entities.LoyaltyProgram, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.LoyaltyProgram), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) SaveProgram(ctx context.Context, program *entities.LoyaltyProgram) error {
+	args := m.Called(ctx, program)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) CreateTier(ctx context.Context, tier *entities.LoyaltyTier) error {
+	args := m.Called(ctx, tier)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) UpdateTier(ctx context.Context, tier *entities.LoyaltyTier) error {
+	args := m.Called(ctx, tier)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) DeleteTier(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) GetTierByID(ctx context.Context, id uuid.ID) (*entities.LoyaltyTier, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.LoyaltyTier), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) GetTiers(ctx context.Context) ([]*entities.LoyaltyTier, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.LoyaltyTier), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) CreateMember(ctx context.Context, member *entities.LoyaltyMember) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) UpdateMember(ctx context.Context, member *entities.LoyaltyMember) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) GetMemberByID(ctx context.Context, id uuid.ID) (*entities.LoyaltyMember, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.LoyaltyMember), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) FindMember(ctx context.Context, identifier string) (*entities.LoyaltyMember, error) {
+	args := m.Called(ctx, identifier)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.LoyaltyMember), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) ListMembers(ctx context.Context, search string, limit, offset int) ([]*entities.LoyaltyMember, int, error) {
+	args := m.Called(ctx, search, limit, offset)
+	return args.Get(0).([]*entities.LoyaltyMember), args.Int(1), args.Error(2)
+}
+
+func (m *MockLoyaltyRepository) ListMemberIDs(ctx context.Context, after uuid.ID, limit int) ([]uuid.ID, error) {
+	args := m.Called(ctx, after, limit)
+	return args.Get(0).([]uuid.ID), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) SetMemberTier(ctx context.Context, memberID, tierID uuid.ID, since time.Time) error {
+	args := m.Called(ctx, memberID, tierID, since)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) UpsertMemberPrice(ctx context.Context, price *entities.LoyaltyMemberPrice) error {
+	args := m.Called(ctx, price)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) DeleteMemberPrice(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) GetMemberPrices(ctx context.Context, tierID uuid.ID) ([]*entities.LoyaltyMemberPrice, error) {
+	args := m.Called(ctx, tierID)
+	return args.Get(0).([]*entities.LoyaltyMemberPrice), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) GetBestMemberPrice(ctx context.Context, articleID uuid.ID, tierIDs []uuid.ID) (*entities.LoyaltyMemberPrice, error) {
+	args := m.Called(ctx, articleID, tierIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.LoyaltyMemberPrice), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) PostEntry(ctx context.Context, entry *entities.LoyaltyPointsEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyRepository) GetLedger(ctx context.Context, memberID uuid.ID, limit, offset int) ([]*entities.LoyaltyPointsEntry, int, error) {
+	args := m.Called(ctx, memberID, limit, offset)
+	return args.Get(0).([]*entities.LoyaltyPointsEntry), args.Int(1), args.Error(2)
+}
+
+func (m *MockLoyaltyRepository) GetEntriesByReference(ctx context.Context, sourceID string) ([]*entities.LoyaltyPointsEntry, error) {
+	args := m.Called(ctx, sourceID)
+	return args.Get(0).([]*entities.LoyaltyPointsEntry), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) ExpirePoints(ctx context.Context, at time.Time) (int, error) {
+	args := m.Called(ctx, at)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLoyaltyRepository) GetQualifyingSpend(ctx context.Context, memberID uuid.ID, since time.Time) (float64, error) {
+	args := m.Called(ctx, memberID, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+// postedEntries returns the ledger entries passed to PostEntry, in order.
+func (m *MockLoyaltyRepository) postedEntries() []*entities.LoyaltyPointsEntry {
+	var entries []*entities.LoyaltyPointsEntry
+	for _, call := range m.Calls {
+		if call.Method == "PostEntry" {
+			entries = append(entries, call.Arguments.Get(1).(*entities.LoyaltyPointsEntry))
+		}
+	}
+	return entries
+}
+
+// newTestLoyaltyService returns a service with Member, Silver and Gold tiers for the
+// tests to return from GetTiers. Tests leaving GetProgram empty get the default program.
+func newTestLoyaltyService() (*LoyaltyService, *MockLoyaltyRepository, []*entities.LoyaltyTier) {
+	var tiers []*entities.LoyaltyTier
+	for i, t := range []struct {
+		code     string
+		minSpend float64
+		earn     float64
+		discount float64
+	}{{"MEMBER", 0, 1, 0}, {"SILVER", 5000000, 1.25, 0.02}, {"GOLD", 15000000, 1.5, 0.05}} {
+		tier := &entities.LoyaltyTier{Code: t.code, Name: t.code, Rank: i, MinSpend: t.minSpend, EarnMultiplier: t.earn, MemberDiscountRate: t.discount, IsActive: true}
+		tier.ID = uuid.New()
+		tiers = append(tiers, tier)
+	}
+
+	repo := new(MockLoyaltyRepository)
+	return NewLoyaltyService(repo, nil), repo, tiers
+}
+
+func testLoyaltyMember(tier *entities.LoyaltyTier, balance int) *entities.LoyaltyMember {
+	member := &entities.LoyaltyMember{
+		Name:          "Siti",
+		Phone:         "081234567890",
+		TierID:        tier.ID,
+		TierSince:     time.Now(),
+		Status:        entities.LoyaltyMemberActive,
+		PointsBalance: balance,
+	}
+	member.ID = uuid.New()
+	return member
+}
+
+func TestLoyaltyService_EnrollMember(t *testing.T) {
+	svc, repo, tiers := newTestLoyaltyService()
+	ctx := context.Background()
+	repo.On("FindMember", ctx, "081234567890").Return(nil, nil).Once()
+	repo.On("GetTiers", ctx).Return(tiers, nil).Once()
+	repo.On("CreateMember", ctx, mock.AnythingOfType("*entities.LoyaltyMember")).Return(nil).Once()
+
+	member := &entities.LoyaltyMember{Name: "Siti", Phone: "0812-3456 7890"}
+	require.NoError(t, svc.EnrollMember(ctx, member))
+	assert.Equal(t, "081234567890", member.Phone)
+	assert.Equal(t, member.MemberNumber, member.CardBarcode)
+	assert.Equal(t, tiers[0].ID, member.TierID)
+	assert.Equal(t, entities.LoyaltyMemberActive, member.Status)
+
+	repo.On("FindMember", ctx, "081234567890").Return(member, nil).Twice()
+	found, err := svc.FindMember(ctx, "0812 3456 7890")
+	require.NoError(t, err)
+	assert.Equal(t, member.ID, found.ID)
+
+	err = svc.EnrollMember(ctx, &entities.LoyaltyMember{Name: "Other", Phone: "081234567890"})
+	assert.ErrorIs(t, err, entities.ErrLoyaltyMemberExists)
+	repo.AssertExpectations(t)
+}
+
+func TestLoyaltyService_CalculatePointsWithMultipliers(t *testing.T) {
+	svc, repo, tiers := newTestLoyaltyService()
+	ctx := context.Background()
+	member := testLoyaltyMember(tiers[0], 0)
+	repo.On("GetProgram", ctx).Return(nil, nil).Twice()
+	repo.On("GetTierByID", ctx, tiers[0].ID).Return(tiers[0], nil).Once()
+	repo.On("GetTierByID", ctx, tiers[2].ID).Return(tiers[2], nil).Once()
+
+	points, spend, err := svc.CalculatePoints(ctx, member, []entities.LoyaltyEarnLine{{Amount: 250000}, {Amount: 99999}})
+	require.NoError(t, err)
+	assert.Equal(t, 34, points)
+	assert.Equal(t, 349999.0, spend)
+
+	// Gold members earn one and a half times the base points
+	member.TierID = tiers[2].ID
+	points, _, err = svc.CalculatePoints(ctx, member, []entities.LoyaltyEarnLine{{Amount: 100000}})
+	require.NoError(t, err)
+	assert.Equal(t, 15, points)
+	repo.AssertExpectations(t)
+}
+
+func TestLoyaltyService_RedemptionRules(t *testing.T) {
+	svc, repo, tiers := newTestLoyaltyService()
+	ctx := context.Background()
+	member := testLoyaltyMember(tiers[0], 500)
+	repo.On("GetProgram", ctx).Return(nil, nil).Times(4)
+
+	value, err := svc.RedemptionValue(ctx, member, 200, 100000)
+	require.NoError(t, err)
+	assert.Equal(t, 20000.0, value)
+
+	_, err = svc.RedemptionValue(ctx, member, 50, 100000)
+	assert.ErrorIs(t, err, entities.ErrInvalidPointsRedemption, "below the minimum")
+
+	_, err = svc.RedemptionValue(ctx, member, 600, 1000000)
+	assert.ErrorIs(t, err, entities.ErrInsufficientPoints)
+
+	_, err = svc.RedemptionValue(ctx, member, 400, 60000)
+	assert.ErrorIs(t, err, entities.ErrInvalidPointsRedemption, "over half of the transaction")
+	repo.AssertExpectations(t)
+}
+
+func TestLoyaltyService_MemberUnitPrice(t *testing.T) {
+	svc, repo, tiers := newTestLoyaltyService()
+	ctx := context.Background()
+	member := testLoyaltyMember(tiers[0], 0)
+	articleID, otherID := uuid.New(), uuid.New()
+	allTiers := []uuid.ID{tiers[0].ID, tiers[1].ID, tiers[2].ID}
+	repo.On("GetTiers", ctx).Return(tiers, nil).Times(3)
+
+	silverPrice := &entities.LoyaltyMemberPrice{TierID: tiers[1].ID, ArticleID: articleID, Price: 180000}
+	repo.On("GetTierByID", ctx, tiers[1].ID).Return(tiers[1], nil).Once()
+	repo.On("UpsertMemberPrice", ctx, silverPrice).Return(nil).Once()
+	require.NoError(t, svc.SetMemberPrice(ctx, silverPrice))
+	assert.False(t, silverPrice.ID.IsNil())
+
+	// The base tier has neither a member price nor a discount
+	repo.On("GetBestMemberPrice", ctx, articleID, []uuid.ID{tiers[0].ID}).Return(nil, nil).Once()
+	price, err := svc.MemberUnitPrice(ctx, member, articleID, 200000)
+	require.NoError(t, err)
+	assert.Equal(t, 200000.0, price)
+
+	// Gold inherits the member prices of the tiers below it
+	member.TierID = tiers[2].ID
+	repo.On("GetBestMemberPrice", ctx, articleID, allTiers).Return(silverPrice, nil).Once()
+	price, err = svc.MemberUnitPrice(ctx, member, articleID, 200000)
+	require.NoError(t, err)
+	assert.Equal(t, 180000.0, price)
+
+	// Articles without a member price get the tier discount
+	repo.On("GetBestMemberPrice", ctx, otherID, allTiers).Return(nil, nil).Once()
+	price, err = svc.MemberUnitPrice(ctx, member, otherID, 200000)
+	require.NoError(t, err)
+	assert.Equal(t, 190000.0, price)
+	repo.AssertExpectations(t)
+}
+
+func TestLoyaltyService_TierUpgradeAndDowngrade(t *testing.T) {
+	svc, repo, tiers := newTestLoyaltyService()
+	ctx := context.Background()
+	member := testLoyaltyMember(tiers[0], 0)
+	repo.On("GetProgram", ctx).Return(nil, nil).Once()
+	repo.On("PostEntry", ctx, mock.AnythingOfType("*entities.LoyaltyPointsEntry")).Return(nil).Once()
+	repo.On("GetMemberByID", ctx, member.ID).Return(member, nil).Once()
+	repo.On("GetTiers", ctx).Return(tiers, nil).Once()
+	repo.On("GetQualifyingSpend", ctx, member.ID, mock.AnythingOfType("time.Time")).Return(6000000.0, nil).Once()
+	repo.On("SetMemberTier", ctx, member.ID, tiers[1].ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	require.NoError(t, svc.EarnPoints(ctx, member.ID, 600, 6000000, entities.LoyaltySourcePOS, uuid.New(), 6000000))
+	entry := repo.postedEntries()[0]
+	assert.Equal(t, 600, entry.Points)
+	assert.Equal(t, 6000000.0, entry.SpendAmount)
+	require.NotNil(t, entry.ExpiresAt)
+	repo.AssertExpectations(t)
+
+	// Upgraded at once; with no spend since, a review inside the window keeps the tier
+	member.TierID, member.TierSince = tiers[1].ID, time.Now()
+	repo.On("GetProgram", ctx).Return(nil, nil).Twice()
+	repo.On("ListMemberIDs", ctx, uuid.Nil, loyaltyReviewBatchSize).Return([]uuid.ID{member.ID}, nil).Twice()
+	repo.On("GetMemberByID", ctx, member.ID).Return(member, nil).Twice()
+	repo.On("GetTiers", ctx).Return(tiers, nil).Twice()
+	repo.On("GetQualifyingSpend", ctx, member.ID, mock.AnythingOfType("time.Time")).Return(0.0, nil).Twice()
+	changed, err := svc.ReviewTiers(ctx, time.Now().AddDate(0, 6, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	// After a full window without spend the member drops back
+	repo.On("SetMemberTier", ctx, member.ID, tiers[0].ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	changed, err = svc.ReviewTiers(ctx, time.Now().AddDate(1, 1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	repo.AssertExpectations(t)
+}
+
+func TestLoyaltyService_ReverseForReturn(t *testing.T) {
+	svc, repo, tiers := newTestLoyaltyService()
+	ctx := context.Background()
+	member := testLoyaltyMember(tiers[0], 40)
+	saleID := uuid.New().String()
+	sale := []*entities.LoyaltyPointsEntry{
+		{MemberID: member.ID, EntryType: entities.LoyaltyEntryRedeem, Points: -200, SourceType: entities.LoyaltySourcePOS, SourceID: saleID, SourceAmount: 400000},
+		{MemberID: member.ID, EntryType: entities.LoyaltyEntryEarn, Points: 40, SourceType: entities.LoyaltySourcePOS, SourceID: saleID, SourceAmount: 400000, SpendAmount: 400000},
+	}
+	repo.On("GetMemberByID", ctx, member.ID).Return(member, nil).Twice()
+	repo.On("GetProgram", ctx).Return(nil, nil).Twice()
+	repo.On("PostEntry", ctx, mock.AnythingOfType("*entities.LoyaltyPointsEntry")).Return(nil).Times(4)
+
+	// Returning a quarter of the sale gives back a quarter of the redeemed points and
+	// takes back a quarter of the earned ones
+	returnID := uuid.New()
+	repo.On("GetEntriesByReference", ctx, saleID).Return(sale, nil).Once()
+	require.NoError(t, svc.ReverseForReturn(ctx, saleID, returnID, 100000))
+	posted := repo.postedEntries()
+	require.Len(t, posted, 2)
+	assert.Equal(t, entities.LoyaltyEntryRefund, posted[0].EntryType)
+	assert.Equal(t, 50, posted[0].Points)
+	assert.Equal(t, entities.LoyaltyEntryReverse, posted[1].EntryType)
+	assert.Equal(t, -10, posted[1].Points)
+	assert.Equal(t, -100000.0, posted[1].SpendAmount)
+	for _, entry := range posted {
+		assert.Equal(t, returnID.String(), entry.SourceID)
+		assert.Equal(t, saleID, entry.ReferenceID)
+		assert.Equal(t, 100000.0, entry.SourceAmount)
+	}
+
+	// The same return is not reversed twice
+	withReturn := append(append([]*entities.LoyaltyPointsEntry{}, sale...), posted...)
+	repo.On("GetEntriesByReference", ctx, saleID).Return(withReturn, nil).Once()
+	require.NoError(t, svc.ReverseForReturn(ctx, saleID, returnID, 100000))
+	assert.Len(t, repo.postedEntries(), 2)
+
+	// Returns never reverse more than the sale
+	repo.On("GetEntriesByReference", ctx, saleID).Return(withReturn, nil).Once()
+	require.NoError(t, svc.ReverseForReturn(ctx, saleID, uuid.New(), 900000))
+	posted = repo.postedEntries()[2:]
+	require.Len(t, posted, 2)
+	assert.Equal(t, 150, posted[0].Points)
+	assert.Equal(t, -30, posted[1].Points)
+	assert.Equal(t, 300000.0, posted[1].SourceAmount)
+	assert.Equal(t, -300000.0, posted[1].SpendAmount)
+	repo.AssertExpectations(t)
+}
+
+func TestLoyaltyService_ExpirePoints(t *testing.T) {
+	svc, repo, _ := newTestLoyaltyService()
+	ctx := context.Background()
+	memberID := uuid.New()
+	repo.On("GetProgram", ctx).Return(nil, nil).Once()
+	repo.On("PostEntry", ctx, mock.AnythingOfType("*entities.LoyaltyPointsEntry")).Return(nil).Once()
+	repo.On("GetMemberByID", ctx, memberID).Return(nil, nil).Once()
+
+	// Earned points expire after the program's expiry months
+	require.NoError(t, svc.EarnPoints(ctx, memberID, 100, 1000000, entities.LoyaltySourcePOS, uuid.New(), 1000000))
+	entry := repo.postedEntries()[0]
+	require.NotNil(t, entry.ExpiresAt)
+	assert.Equal(t, entry.CreatedAt.AddDate(0, 12, 0), *entry.ExpiresAt)
+
+	at := time.Now().AddDate(0, 12, 1)
+	repo.On("ExpirePoints", ctx, at).Return(100, nil).Once()
+	expired, err := svc.ExpirePoints(ctx, at)
+	require.NoError(t, err)
+	assert.Equal(t, 100, expired)
+	repo.AssertExpectations(t)
+}
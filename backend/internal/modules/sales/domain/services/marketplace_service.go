@@ -0,0 +1,590 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	inventory_entities "malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// ChannelAdapter connects a marketplace channel: it takes in the channel's orders, by
+// pulling them or from webhook notifications, and pushes order statuses, stock and
+// prices back. Adapters speak the channel's API and translate to channel-neutral
+// entities; SKUs are the channel's own.
+type ChannelAdapter interface {
+	// PullOrders returns the orders created or changed since the given time (all when zero).
+	PullOrders(ctx context.Context, since time.Time) ([]*entities.ChannelOrder, error)
+	// ParseWebhook verifies a webhook notification and returns the orders it carries.
+	// Notifications that do not come from the channel fail with ErrInvalidWebhook.
+	ParseWebhook(ctx context.Context, headers map[string]string, body []byte) ([]*entities.ChannelOrder, error)
+	PushOrderStatus(ctx context.Context, update *entities.ChannelStatusUpdate) error
+	PushStock(ctx context.Context, levels []*entities.ChannelStockLevel) error
+	PushPrices(ctx context.Context, prices []*entities.ChannelPrice) error
+}
+
+// ChannelAdapterFactory creates the adapter of a channel from its settings.
+type ChannelAdapterFactory func(channel *entities.MarketplaceChannel) (ChannelAdapter, error)
+
+// MarketplaceService imports the orders of marketplace channels as online orders,
+// reserving their stock, hands them to shipping and keeps the channels informed of
+// order statuses, stock and prices. Each channel names the adapter it uses; adapters
+// are registered by name.
+type MarketplaceService struct {
+	repo     repositories.MarketplaceRepository
+	stock    StockMover
+	adapters map[string]ChannelAdapterFactory
+}
+
+// NewMarketplaceService creates a new MarketplaceService.
+func NewMarketplaceService(repo repositories.MarketplaceRepository, stock StockMover) *MarketplaceService {
+	return &MarketplaceService{repo: repo, stock: stock, adapters: map[string]ChannelAdapterFactory{}}
+}
+
+// RegisterAdapter makes an adapter available to channels under the given name.
+func (s *MarketplaceService) RegisterAdapter(name string, factory ChannelAdapterFactory) {
+	s.adapters[name] = factory
+}
+
+// Adapters returns the names of the registered adapters.
+func (s *MarketplaceService) Adapters() []string {
+	names := make([]string, 0, len(s.adapters))
+	for name := range s.adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreateChannel sets up a marketplace channel. Its adapter must be registered and
+// accept the channel's settings.
+func (s *MarketplaceService) CreateChannel(ctx context.Context, ch *entities.MarketplaceChannel) (*entities.MarketplaceChannel, error) {
+	ch.Code = strings.TrimSpace(ch.Code)
+	ch.Name = strings.TrimSpace(ch.Name)
+	if ch.Code == "" || ch.Name == "" {
+		return nil, fmt.Errorf("%w: code and name are required", entities.ErrInvalidChannel)
+	}
+	if ch.WarehouseID.IsNil() || ch.CustomerID.IsNil() {
+		return nil, fmt.Errorf("%w: the fulfilling warehouse and the customer orders are booked to are required", entities.ErrInvalidChannel)
+	}
+	if ch.Settings == nil {
+		ch.Settings = entities.ChannelSettings{}
+	}
+	if _, err := s.adapter(ch); err != nil {
+		return nil, err
+	}
+
+	now := utils.Now()
+	ch.ID = uuid.New()
+	ch.Status = entities.ChannelActive
+	ch.CreatedAt, ch.UpdatedAt = now, now
+	if err := s.repo.CreateChannel(ctx, ch); err != nil {
+		return nil, err
+	}
+	return redactChannel(ch), nil
+}
+
+// GetChannel returns a marketplace channel, without its credentials.
+func (s *MarketplaceService) GetChannel(ctx context.Context, id uuid.ID) (*entities.MarketplaceChannel, error) {
+	ch, err := s.channel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return redactChannel(ch), nil
+}
+
+// ListChannels returns the marketplace channels, without their credentials.
+func (s *MarketplaceService) ListChannels(ctx context.Context) ([]*entities.MarketplaceChannel, error) {
+	channels, err := s.repo.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, ch := range channels {
+		channels[i] = redactChannel(ch)
+	}
+	return channels, nil
+}
+
+// SaveSKUMappings maps channel SKUs to our articles by barcode, replacing earlier
+// mappings of the same SKUs.
+func (s *MarketplaceService) SaveSKUMappings(ctx context.Context, channelID uuid.ID, mappings []*entities.ChannelSKUMapping) ([]*entities.ChannelSKUMapping, error) {
+	if _, err := s.channel(ctx, channelID); err != nil {
+		return nil, err
+	}
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("%w: no SKU mappings given", entities.ErrInvalidChannel)
+	}
+
+	seen := map[string]bool{}
+	barcodes := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		m.ChannelSKU = strings.TrimSpace(m.ChannelSKU)
+		m.Barcode = strings.TrimSpace(m.Barcode)
+		if m.ChannelSKU == "" || m.Barcode == "" {
+			return nil, fmt.Errorf("%w: SKU mappings need a channel SKU and a barcode", entities.ErrInvalidChannel)
+		}
+		if seen[m.ChannelSKU] {
+			return nil, fmt.Errorf("%w: SKU %s is mapped twice", entities.ErrInvalidChannel, m.ChannelSKU)
+		}
+		seen[m.ChannelSKU] = true
+		barcodes = append(barcodes, m.Barcode)
+	}
+	articles, err := s.repo.ResolveBarcodes(ctx, barcodes)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	now := utils.Now()
+	for _, m := range mappings {
+		articleID, ok := articles[m.Barcode]
+		if !ok {
+			unknown = append(unknown, m.Barcode)
+			continue
+		}
+		m.ID = uuid.New()
+		m.ChannelID = channelID
+		m.ArticleID = articleID
+		m.UpdatedAt = now
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown barcodes %s", entities.ErrInvalidChannel, strings.Join(unknown, ", "))
+	}
+	if err := s.repo.SaveSKUMappings(ctx, mappings); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// ListSKUMappings returns the SKU mappings of a channel.
+func (s *MarketplaceService) ListSKUMappings(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelSKUMapping, error) {
+	if _, err := s.channel(ctx, channelID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSKUMappings(ctx, channelID)
+}
+
+// PullOrders imports the orders the channel's adapter reports since the last pull. The
+// pull is only recorded when every order was imported, so failed orders come again;
+// importing is idempotent.
+func (s *MarketplaceService) PullOrders(ctx context.Context, channelID uuid.ID) (*entities.ChannelImportResult, error) {
+	ch, err := s.activeChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	adapter, err := s.adapter(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	pulledAt := utils.Now()
+	var since time.Time
+	if ch.LastPulledAt != nil {
+		since = *ch.LastPulledAt
+	}
+	orders, err := adapter.PullOrders(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull orders of channel %s: %w", ch.Code, err)
+	}
+	result := s.importOrders(ctx, ch, orders)
+	if len(result.Failed) == 0 {
+		if err := s.repo.SetLastPulled(ctx, ch.ID, pulledAt); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// ReceiveWebhook imports the orders of a webhook notification sent by a channel.
+func (s *MarketplaceService) ReceiveWebhook(ctx context.Context, channelCode string, headers map[string]string, body []byte) (*entities.ChannelImportResult, error) {
+	ch, err := s.repo.GetChannelByCode(ctx, channelCode)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, entities.ErrChannelNotFound
+	}
+	if ch.Status != entities.ChannelActive {
+		return nil, fmt.Errorf("%w: channel is %s", entities.ErrInvalidChannel, ch.Status)
+	}
+	adapter, err := s.adapter(ch)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := adapter.ParseWebhook(ctx, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	return s.importOrders(ctx, ch, orders), nil
+}
+
+// GetOrder returns an online order with its items.
+func (s *MarketplaceService) GetOrder(ctx context.Context, id uuid.ID) (*entities.OnlineOrder, error) {
+	order, err := s.repo.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, entities.ErrOnlineOrderNotFound
+	}
+	return order, nil
+}
+
+// ShipOrder hands a reserved channel order to shipping with the courier, issuing its
+// goods from the channel's warehouse, and tells the channel. Without a tracking number
+// the airway bill the channel booked is used.
+func (s *MarketplaceService) ShipOrder(ctx context.Context, id, courierID uuid.ID, trackingNumber string) (*entities.OnlineOrder, error) {
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.ChannelID == nil || order.WarehouseID == nil {
+		return nil, fmt.Errorf("%w: only marketplace channel orders are shipped here", entities.ErrOnlineOrderStatus)
+	}
+	if order.Status != entities.OnlineOrderReserved {
+		return nil, fmt.Errorf("%w: order is %s", entities.ErrOnlineOrderStatus, order.Status)
+	}
+	if courierID.IsNil() {
+		return nil, fmt.Errorf("%w: a courier is required", entities.ErrInvalidChannelOrder)
+	}
+	if trackingNumber = strings.TrimSpace(trackingNumber); trackingNumber == "" {
+		trackingNumber = order.TrackingNumber
+	}
+	if trackingNumber == "" {
+		return nil, fmt.Errorf("%w: a tracking number is required", entities.ErrInvalidChannelOrder)
+	}
+
+	shipment := &entities.OnlineOrderShipment{
+		OnlineOrderID:  order.ID,
+		ShipmentID:     uuid.New(),
+		GoodsIssueID:   uuid.New(),
+		CourierID:      courierID,
+		TrackingNumber: trackingNumber,
+		ShippedAt:      utils.Now(),
+	}
+	if err := s.repo.ShipOrder(ctx, shipment); err != nil {
+		return nil, err
+	}
+	order.Status = entities.OnlineOrderShipped
+	order.TrackingNumber = trackingNumber
+	order.ShipmentID = &shipment.ShipmentID
+
+	for _, item := range order.Items {
+		if err := s.stock.RecordStockMovement(ctx, &inventory_entities.StockMovement{
+			ArticleID:    item.ArticleID,
+			WarehouseID:  *order.WarehouseID,
+			Quantity:     item.Quantity,
+			MovementType: "out",
+			MovementDate: shipment.ShippedAt,
+			ReferenceID:  shipment.GoodsIssueID,
+		}); err != nil {
+			log.Printf("[Sales] Failed to record stock movement of online order %s, article %s: %v", order.OrderID, item.ArticleID, err)
+		}
+	}
+	s.pushStatus(ctx, *order.ChannelID, &entities.ChannelStatusUpdate{
+		ExternalOrderID: order.OrderID,
+		Status:          entities.ChannelOrderShipped,
+		TrackingNumber:  trackingNumber,
+	})
+	return order, nil
+}
+
+// CancelOrder cancels a channel order we cannot fulfil, releasing its stock, and tells
+// the channel.
+func (s *MarketplaceService) CancelOrder(ctx context.Context, id uuid.ID) (*entities.OnlineOrder, error) {
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.ChannelID == nil {
+		return nil, fmt.Errorf("%w: only marketplace channel orders are cancelled here", entities.ErrOnlineOrderStatus)
+	}
+	if order.Status != entities.OnlineOrderReserved {
+		return nil, fmt.Errorf("%w: order is %s", entities.ErrOnlineOrderStatus, order.Status)
+	}
+	if err := s.repo.CancelOrder(ctx, order.ID); err != nil {
+		return nil, err
+	}
+	order.Status = entities.OnlineOrderCancelled
+	s.pushStatus(ctx, *order.ChannelID, &entities.ChannelStatusUpdate{
+		ExternalOrderID: order.OrderID,
+		Status:          entities.ChannelOrderCancelled,
+	})
+	return order, nil
+}
+
+// PushStock sends the channel the stock available of its mapped SKUs and returns how
+// many SKUs were sent.
+func (s *MarketplaceService) PushStock(ctx context.Context, channelID uuid.ID) (int, error) {
+	ch, err := s.activeChannel(ctx, channelID)
+	if err != nil {
+		return 0, err
+	}
+	adapter, err := s.adapter(ch)
+	if err != nil {
+		return 0, err
+	}
+	levels, err := s.repo.ListStockLevels(ctx, ch.ID)
+	if err != nil {
+		return 0, err
+	}
+	if len(levels) == 0 {
+		return 0, nil
+	}
+	for _, level := range levels {
+		// Overbooked stock is reported as sold out
+		if level.Available < 0 {
+			level.Available = 0
+		}
+	}
+	if err := adapter.PushStock(ctx, levels); err != nil {
+		return 0, fmt.Errorf("failed to push stock to channel %s: %w", ch.Code, err)
+	}
+	return len(levels), nil
+}
+
+// PushPrices sends the channel the selling prices of its mapped SKUs and returns how
+// many SKUs were sent.
+func (s *MarketplaceService) PushPrices(ctx context.Context, channelID uuid.ID) (int, error) {
+	ch, err := s.activeChannel(ctx, channelID)
+	if err != nil {
+		return 0, err
+	}
+	adapter, err := s.adapter(ch)
+	if err != nil {
+		return 0, err
+	}
+	prices, err := s.repo.ListPrices(ctx, ch.ID)
+	if err != nil {
+		return 0, err
+	}
+	if len(prices) == 0 {
+		return 0, nil
+	}
+	if err := adapter.PushPrices(ctx, prices); err != nil {
+		return 0, fmt.Errorf("failed to push prices to channel %s: %w", ch.Code, err)
+	}
+	return len(prices), nil
+}
+
+// SyncChannels pulls the orders of every active channel and pushes its stock. A channel
+// that fails is logged and left for the next run; it returns the orders created.
+func (s *MarketplaceService) SyncChannels(ctx context.Context) (int, error) {
+	channels, err := s.repo.ListChannels(ctx)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, ch := range channels {
+		if ch.Status != entities.ChannelActive {
+			continue
+		}
+		result, err := s.PullOrders(ctx, ch.ID)
+		if err != nil {
+			log.Printf("[Sales] Failed to pull orders of channel %s: %v", ch.Code, err)
+			continue
+		}
+		created += result.Created
+		for _, failed := range result.Failed {
+			log.Printf("[Sales] Failed to import order %s of channel %s: %s", failed.ExternalOrderID, ch.Code, failed.Error)
+		}
+		if _, err := s.PushStock(ctx, ch.ID); err != nil {
+			log.Printf("[Sales] Failed to push stock to channel %s: %v", ch.Code, err)
+		}
+	}
+	return created, nil
+}
+
+// importOrders imports channel orders one by one; an order that fails does not stop the others.
+func (s *MarketplaceService) importOrders(ctx context.Context, ch *entities.MarketplaceChannel, orders []*entities.ChannelOrder) *entities.ChannelImportResult {
+	result := &entities.ChannelImportResult{}
+	for _, co := range orders {
+		if err := s.importOrder(ctx, ch, co, result); err != nil {
+			result.Failed = append(result.Failed, &entities.ChannelImportError{ExternalOrderID: co.ExternalOrderID, Error: err.Error()})
+		}
+	}
+	return result
+}
+
+func (s *MarketplaceService) importOrder(ctx context.Context, ch *entities.MarketplaceChannel, co *entities.ChannelOrder,
+	result *entities.ChannelImportResult) error {
+	co.ExternalOrderID = strings.TrimSpace(co.ExternalOrderID)
+	if co.ExternalOrderID == "" {
+		return fmt.Errorf("%w: order has no channel order ID", entities.ErrInvalidChannelOrder)
+	}
+
+	existing, err := s.repo.GetOrderByExternalID(ctx, ch.ID, co.ExternalOrderID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if co.Status != entities.ChannelOrderCancelled || existing.Status == entities.OnlineOrderCancelled {
+			result.Duplicates++
+			return nil
+		}
+		if existing.Status != entities.OnlineOrderReserved {
+			return fmt.Errorf("%w: the channel cancelled an order that is already %s", entities.ErrOnlineOrderStatus, existing.Status)
+		}
+		if err := s.repo.CancelOrder(ctx, existing.ID); err != nil {
+			return err
+		}
+		result.Cancelled++
+		return nil
+	}
+
+	switch co.Status {
+	case entities.ChannelOrderPaid:
+	case entities.ChannelOrderUnpaid, entities.ChannelOrderCancelled:
+		result.Skipped++
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown status %q", entities.ErrInvalidChannelOrder, co.Status)
+	}
+
+	order, err := s.buildOrder(ctx, ch, co)
+	if err != nil {
+		return err
+	}
+	// Orders the warehouse cannot cover fail and come again with the next pull, so they
+	// are imported once stock arrives or can be cancelled on the channel
+	created, err := s.repo.CreateOrder(ctx, order)
+	if err != nil {
+		return err
+	}
+	if !created {
+		result.Duplicates++
+		return nil
+	}
+	result.Created++
+	return nil
+}
+
+// buildOrder turns a paid channel order into an online order of the channel's customer,
+// fulfilled from the channel's warehouse.
+func (s *MarketplaceService) buildOrder(ctx context.Context, ch *entities.MarketplaceChannel, co *entities.ChannelOrder) (*entities.OnlineOrder, error) {
+	if len(co.Items) == 0 {
+		return nil, fmt.Errorf("%w: order has no items", entities.ErrInvalidChannelOrder)
+	}
+	skus := make([]string, 0, len(co.Items))
+	for i, item := range co.Items {
+		if strings.TrimSpace(item.SKU) == "" || item.Quantity <= 0 || item.UnitPrice < 0 {
+			return nil, fmt.Errorf("%w: item %d needs a SKU, a positive quantity and a price", entities.ErrInvalidChannelOrder, i+1)
+		}
+		item.SKU = strings.TrimSpace(item.SKU)
+		skus = append(skus, item.SKU)
+	}
+	mappings, err := s.repo.GetSKUMappings(ctx, ch.ID, skus)
+	if err != nil {
+		return nil, err
+	}
+	var unmapped []string
+	for _, sku := range skus {
+		if mappings[sku] == nil {
+			unmapped = append(unmapped, sku)
+		}
+	}
+	if len(unmapped) > 0 {
+		return nil, fmt.Errorf("%w: %s", entities.ErrUnmappedSKU, strings.Join(unmapped, ", "))
+	}
+
+	now := utils.Now()
+	orderedAt := co.OrderedAt
+	if orderedAt.IsZero() {
+		orderedAt = now
+	}
+	channelID, warehouseID := ch.ID, ch.WarehouseID
+	order := &entities.OnlineOrder{
+		Marketplace:     ch.Code,
+		OrderID:         co.ExternalOrderID,
+		OrderDate:       orderedAt,
+		Status:          entities.OnlineOrderReserved,
+		CustomerID:      ch.CustomerID.String(),
+		ChannelID:       &channelID,
+		WarehouseID:     &warehouseID,
+		BuyerName:       co.BuyerName,
+		BuyerPhone:      co.BuyerPhone,
+		ShippingAddress: co.ShippingAddress,
+		ShippingFee:     roundMoney(co.ShippingFee),
+		TrackingNumber:  strings.TrimSpace(co.TrackingNumber),
+	}
+	order.ID = uuid.New()
+	order.CreatedAt, order.UpdatedAt = now, now
+	for _, item := range co.Items {
+		line := &entities.OnlineOrderItem{
+			ID:            uuid.New(),
+			OnlineOrderID: order.ID,
+			ArticleID:     mappings[item.SKU].ArticleID,
+			ChannelSKU:    item.SKU,
+			Quantity:      item.Quantity,
+			UnitPrice:     roundMoney(item.UnitPrice),
+		}
+		line.TotalPrice = roundMoney(line.UnitPrice * float64(line.Quantity))
+		order.TotalAmount += line.TotalPrice
+		order.Items = append(order.Items, line)
+	}
+	order.TotalAmount = roundMoney(order.TotalAmount + order.ShippingFee)
+	return order, nil
+}
+
+// pushStatus tells a channel about one of its orders. Our side of the order stands;
+// a failed push is logged for follow-up on the channel.
+func (s *MarketplaceService) pushStatus(ctx context.Context, channelID uuid.ID, update *entities.ChannelStatusUpdate) {
+	ch, err := s.channel(ctx, channelID)
+	if err == nil {
+		var adapter ChannelAdapter
+		if adapter, err = s.adapter(ch); err == nil {
+			err = adapter.PushOrderStatus(ctx, update)
+		}
+	}
+	if err != nil {
+		log.Printf("[Sales] Failed to push status %s of order %s to channel %s: %v", update.Status, update.ExternalOrderID, channelID, err)
+	}
+}
+
+func (s *MarketplaceService) channel(ctx context.Context, id uuid.ID) (*entities.MarketplaceChannel, error) {
+	ch, err := s.repo.GetChannel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, entities.ErrChannelNotFound
+	}
+	return ch, nil
+}
+
+func (s *MarketplaceService) activeChannel(ctx context.Context, id uuid.ID) (*entities.MarketplaceChannel, error) {
+	ch, err := s.channel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Status != entities.ChannelActive {
+		return nil, fmt.Errorf("%w: channel is %s", entities.ErrInvalidChannel, ch.Status)
+	}
+	return ch, nil
+}
+
+func (s *MarketplaceService) adapter(ch *entities.MarketplaceChannel) (ChannelAdapter, error) {
+	factory, ok := s.adapters[ch.Adapter]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", entities.ErrUnknownAdapter, ch.Adapter)
+	}
+	adapter, err := factory(ch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidChannel, err)
+	}
+	return adapter, nil
+}
+
+// redactChannel returns a copy of the channel with its credentials masked.
+func redactChannel(ch *entities.MarketplaceChannel) *entities.MarketplaceChannel {
+	redacted := *ch
+	redacted.Settings = ch.Settings.Redacted()
+	return &redacted
+}
@@ -0,0 +1,508 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockMarketplaceRepository is a mock implementation of repositories.MarketplaceRepository.
+type MockMarketplaceRepository struct {
+	mock.Mock
+}
+
+func (m *MockMarketplaceRepository) CreateChannel(ctx context.Context, ch *entities.MarketplaceChannel) error {
+	args := m.Called(ctx, ch)
+	return args.Error(0)
+}
+
+func (m *MockMarketplaceRepository) GetChannel(ctx context.Context, id uuid.ID) (*entities.MarketplaceChannel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.MarketplaceChannel), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) GetChannelByCode(ctx context.Context, code string) (*entities.MarketplaceChannel, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.MarketplaceChannel), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) ListChannels(ctx context.Context) ([]*entities.MarketplaceChannel, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.MarketplaceChannel), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) SetLastPulled(ctx context.Context, id uuid.ID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockMarketplaceRepository) ResolveBarcodes(ctx context.Context, barcodes []string) (map[string]uuid.ID, error) {
+	args := m.Called(ctx, barcodes)
+	return args.Get(0).(map[string]uuid.ID), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) SaveSKUMappings(ctx context.Context, mappings []*entities.ChannelSKUMapping) error {
+	args := m.Called(ctx, mappings)
+	return args.Error(0)
+}
+
+func (m *MockMarketplaceRepository) ListSKUMappings(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelSKUMapping, error) {
+	args := m.Called(ctx, channelID)
+	return args.Get(0).([]*entities.ChannelSKUMapping), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) GetSKUMappings(ctx context.Context, channelID uuid.ID, skus []string) (map[string]*entities.ChannelSKUMapping, error) {
+	args := m.Called(ctx, channelID, skus)
+	return args.Get(0).(map[string]*entities.ChannelSKUMapping), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) GetOrder(ctx context.Context, id uuid.ID) (*entities.OnlineOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.OnlineOrder), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) GetOrderByExternalID(ctx context.Context, channelID uuid.ID, externalID string) (*entities.OnlineOrder, error) {
+	args := m.Called(ctx, channelID, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.OnlineOrder), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) CreateOrder(ctx context.Context, order *entities.OnlineOrder) (bool, error) {
+	args := m.Called(ctx, order)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) CancelOrder(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockMarketplaceRepository) ShipOrder(ctx context.Context, s *entities.OnlineOrderShipment) error {
+	args := m.Called(ctx, s)
+	return args.Error(0)
+}
+
+func (m *MockMarketplaceRepository) ListStockLevels(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelStockLevel, error) {
+	args := m.Called(ctx, channelID)
+	return args.Get(0).([]*entities.ChannelStockLevel), args.Error(1)
+}
+
+func (m *MockMarketplaceRepository) ListPrices(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelPrice, error) {
+	args := m.Called(ctx, channelID)
+	return args.Get(0).([]*entities.ChannelPrice), args.Error(1)
+}
+
+// MockChannelAdapter is a mock implementation of ChannelAdapter.
+type MockChannelAdapter struct {
+	mock.Mock
+}
+
+func (m *MockChannelAdapter) PullOrders(ctx context.Context, since time.Time) ([]*entities.ChannelOrder, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ChannelOrder), args.Error(1)
+}
+
+func (m *MockChannelAdapter) ParseWebhook(ctx context.Context, headers map[string]string, body []byte) ([]*entities.ChannelOrder, error) {
+	args := m.Called(ctx, headers, body)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ChannelOrder), args.Error(1)
+}
+
+func (m *MockChannelAdapter) PushOrderStatus(ctx context.Context, update *entities.ChannelStatusUpdate) error {
+	args := m.Called(ctx, update)
+	return args.Error(0)
+}
+
+func (m *MockChannelAdapter) PushStock(ctx context.Context, levels []*entities.ChannelStockLevel) error {
+	args := m.Called(ctx, levels)
+	return args.Error(0)
+}
+
+func (m *MockChannelAdapter) PushPrices(ctx context.Context, prices []*entities.ChannelPrice) error {
+	args := m.Called(ctx, prices)
+	return args.Error(0)
+}
+
+// newTestMarketplaceService returns a marketplace service whose "fake" channels talk
+// through adapter.
+func newTestMarketplaceService(repo *MockMarketplaceRepository, adapter *MockChannelAdapter, stock *MockStockMover) *MarketplaceService {
+	service := NewMarketplaceService(repo, stock)
+	service.RegisterAdapter("fake", func(ch *entities.MarketplaceChannel) (ChannelAdapter, error) {
+		return adapter, nil
+	})
+	return service
+}
+
+// testMarketplaceChannel returns an active channel served by the fake adapter.
+func testMarketplaceChannel() *entities.MarketplaceChannel {
+	ch := &entities.MarketplaceChannel{
+		Code:        "SHOPEE-MAIN",
+		Name:        "Shopee main store",
+		Adapter:     "fake",
+		WarehouseID: uuid.New(),
+		CustomerID:  uuid.New(),
+		Status:      entities.ChannelActive,
+		Settings:    entities.ChannelSettings{"shop_id": "42", "api_secret": "s3cret"},
+	}
+	ch.ID = uuid.New()
+	return ch
+}
+
+// testSKUMappings maps the channel's SKU-1 to an article.
+func testSKUMappings(ch *entities.MarketplaceChannel, article uuid.ID) map[string]*entities.ChannelSKUMapping {
+	return map[string]*entities.ChannelSKUMapping{
+		"SKU-1": {ChannelID: ch.ID, ChannelSKU: "SKU-1", Barcode: "8991234567890", ArticleID: article},
+	}
+}
+
+// testChannelOnlineOrder returns paid order O-1 of the channel as imported: two of the
+// article reserved in the channel's warehouse.
+func testChannelOnlineOrder(ch *entities.MarketplaceChannel, article uuid.ID) *entities.OnlineOrder {
+	channelID, warehouseID := ch.ID, ch.WarehouseID
+	order := &entities.OnlineOrder{
+		Marketplace:    ch.Code,
+		OrderID:        "O-1",
+		Status:         entities.OnlineOrderReserved,
+		CustomerID:     ch.CustomerID.String(),
+		ChannelID:      &channelID,
+		WarehouseID:    &warehouseID,
+		TrackingNumber: "SPX-O-1",
+		ShippingFee:    10000,
+		TotalAmount:    310000,
+	}
+	order.ID = uuid.New()
+	order.Items = []*entities.OnlineOrderItem{{
+		ID:            uuid.New(),
+		OnlineOrderID: order.ID,
+		ArticleID:     article,
+		ChannelSKU:    "SKU-1",
+		Quantity:      2,
+		UnitPrice:     150000,
+		TotalPrice:    300000,
+	}}
+	return order
+}
+
+func paidChannelOrder(id string) *entities.ChannelOrder {
+	return &entities.ChannelOrder{
+		ExternalOrderID: id,
+		Status:          entities.ChannelOrderPaid,
+		OrderedAt:       time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		BuyerName:       "Budi",
+		ShippingFee:     10000,
+		TrackingNumber:  "SPX-" + id,
+		Items:           []*entities.ChannelOrderItem{{SKU: "SKU-1", Quantity: 2, UnitPrice: 150000}},
+	}
+}
+
+func TestMarketplaceService_CreateChannel(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch := testMarketplaceChannel()
+
+	repo.On("CreateChannel", ctx, mock.AnythingOfType("*entities.MarketplaceChannel")).Return(nil).Once()
+	created, err := service.CreateChannel(ctx, &entities.MarketplaceChannel{
+		Code:        " LAZADA ",
+		Name:        "Lazada",
+		Adapter:     "fake",
+		WarehouseID: uuid.New(),
+		CustomerID:  uuid.New(),
+		Settings:    entities.ChannelSettings{"api_secret": "s3cret"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "LAZADA", created.Code)
+	assert.Equal(t, entities.ChannelActive, created.Status)
+	assert.Equal(t, "********", created.Settings["api_secret"])
+	stored := repo.Calls[0].Arguments.Get(1).(*entities.MarketplaceChannel)
+	assert.Equal(t, "s3cret", stored.Settings["api_secret"], "stored settings are not masked")
+
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Once()
+	got, err := service.GetChannel(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "42", got.Settings["shop_id"])
+	assert.Equal(t, "********", got.Settings["api_secret"])
+	assert.Equal(t, "s3cret", ch.Settings["api_secret"])
+
+	_, err = service.CreateChannel(ctx, &entities.MarketplaceChannel{
+		Code: "TOKO", Name: "Tokopedia", Adapter: "tokopedia", WarehouseID: uuid.New(), CustomerID: uuid.New(),
+	})
+	assert.True(t, errors.Is(err, entities.ErrUnknownAdapter))
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
+
+func TestMarketplaceService_SaveSKUMappings(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch, article := testMarketplaceChannel(), uuid.New()
+
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Once()
+	repo.On("ResolveBarcodes", ctx, []string{"8991234567890"}).Return(map[string]uuid.ID{"8991234567890": article}, nil).Once()
+	repo.On("SaveSKUMappings", ctx, mock.AnythingOfType("[]*entities.ChannelSKUMapping")).Return(nil).Once()
+	mappings, err := service.SaveSKUMappings(ctx, ch.ID, []*entities.ChannelSKUMapping{
+		{ChannelSKU: " SKU-1 ", Barcode: "8991234567890"},
+	})
+	require.NoError(t, err)
+	require.Len(t, mappings, 1)
+	assert.Equal(t, "SKU-1", mappings[0].ChannelSKU)
+	assert.Equal(t, ch.ID, mappings[0].ChannelID)
+	assert.Equal(t, article, mappings[0].ArticleID)
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
+
+func TestMarketplaceService_SaveSKUMappingsRejectsUnknownBarcode(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch := testMarketplaceChannel()
+
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Once()
+	repo.On("ResolveBarcodes", ctx, []string{"0000"}).Return(map[string]uuid.ID{}, nil).Once()
+	_, err := service.SaveSKUMappings(ctx, ch.ID, []*entities.ChannelSKUMapping{
+		{ChannelSKU: "SKU-2", Barcode: "0000"},
+	})
+	assert.True(t, errors.Is(err, entities.ErrInvalidChannel))
+	repo.AssertNotCalled(t, "SaveSKUMappings", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
+
+func TestMarketplaceService_PullOrdersIsIdempotent(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch, article := testMarketplaceChannel(), uuid.New()
+	recordPull := func(args mock.Arguments) {
+		at := args.Get(2).(time.Time)
+		ch.LastPulledAt = &at
+	}
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Twice()
+
+	var order *entities.OnlineOrder
+	unpaid := &entities.ChannelOrder{ExternalOrderID: "O-2", Status: entities.ChannelOrderUnpaid}
+	adapter.On("PullOrders", ctx, time.Time{}).Return([]*entities.ChannelOrder{paidChannelOrder("O-1"), unpaid}, nil).Once()
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-1").Return(nil, nil).Once()
+	repo.On("GetSKUMappings", ctx, ch.ID, []string{"SKU-1"}).Return(testSKUMappings(ch, article), nil).Once()
+	repo.On("CreateOrder", ctx, mock.AnythingOfType("*entities.OnlineOrder")).
+		Return(true, nil).Once().
+		Run(func(args mock.Arguments) {
+			order = args.Get(1).(*entities.OnlineOrder)
+		})
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-2").Return(nil, nil).Once()
+	repo.On("SetLastPulled", ctx, ch.ID, mock.AnythingOfType("time.Time")).Return(nil).Once().Run(recordPull)
+	result, err := service.PullOrders(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Empty(t, result.Failed)
+	require.NotNil(t, ch.LastPulledAt)
+	firstPull := *ch.LastPulledAt
+
+	require.NotNil(t, order)
+	assert.Equal(t, "O-1", order.OrderID)
+	assert.Equal(t, entities.OnlineOrderReserved, order.Status)
+	assert.Equal(t, "SHOPEE-MAIN", order.Marketplace)
+	assert.Equal(t, ch.CustomerID.String(), order.CustomerID)
+	assert.Equal(t, ch.WarehouseID, *order.WarehouseID)
+	assert.Equal(t, 310000.0, order.TotalAmount)
+	require.Len(t, order.Items, 1)
+	assert.Equal(t, article, order.Items[0].ArticleID)
+	assert.Equal(t, 300000.0, order.Items[0].TotalPrice)
+
+	// The second pull starts where the first ended and finds the order imported
+	adapter.On("PullOrders", ctx, firstPull).Return([]*entities.ChannelOrder{paidChannelOrder("O-1")}, nil).Once()
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-1").Return(order, nil).Once()
+	repo.On("SetLastPulled", ctx, ch.ID, mock.AnythingOfType("time.Time")).Return(nil).Once().Run(recordPull)
+	result, err = service.PullOrders(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Created)
+	assert.Equal(t, 1, result.Duplicates)
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
+
+func TestMarketplaceService_PullOrdersKeepsFailedOrdersForRetry(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch, article := testMarketplaceChannel(), uuid.New()
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Twice()
+	repo.On("GetSKUMappings", ctx, ch.ID, []string{"SKU-1"}).Return(testSKUMappings(ch, article), nil).Twice()
+
+	unmapped := paidChannelOrder("O-1")
+	unmapped.Items = append(unmapped.Items, &entities.ChannelOrderItem{SKU: "SKU-9", Quantity: 1, UnitPrice: 1000})
+	adapter.On("PullOrders", ctx, time.Time{}).Return([]*entities.ChannelOrder{unmapped, paidChannelOrder("O-2")}, nil).Once()
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-1").Return(nil, nil).Once()
+	repo.On("GetSKUMappings", ctx, ch.ID, []string{"SKU-1", "SKU-9"}).Return(testSKUMappings(ch, article), nil).Once()
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-2").Return(nil, nil).Once()
+	repo.On("CreateOrder", ctx, mock.AnythingOfType("*entities.OnlineOrder")).Return(true, nil).Once()
+	result, err := service.PullOrders(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, "O-1", result.Failed[0].ExternalOrderID)
+	assert.Contains(t, result.Failed[0].Error, "SKU-9")
+
+	// Orders the warehouse cannot cover fail too
+	adapter.On("PullOrders", ctx, time.Time{}).Return([]*entities.ChannelOrder{paidChannelOrder("O-2"), paidChannelOrder("O-3")}, nil).Once()
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-2").Return(testChannelOnlineOrder(ch, article), nil).Once()
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-3").Return(nil, nil).Once()
+	repo.On("CreateOrder", ctx, mock.AnythingOfType("*entities.OnlineOrder")).Return(false, entities.ErrInsufficientStock).Once()
+	result, err = service.PullOrders(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Duplicates)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, "O-3", result.Failed[0].ExternalOrderID)
+
+	repo.AssertNotCalled(t, "SetLastPulled", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
+
+func TestMarketplaceService_ChannelCancellation(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch := testMarketplaceChannel()
+	order := testChannelOnlineOrder(ch, uuid.New())
+	headers := map[string]string{"X-Token": "ok"}
+	repo.On("GetChannelByCode", ctx, "SHOPEE-MAIN").Return(ch, nil).Times(3)
+
+	cancelled := paidChannelOrder("O-1")
+	cancelled.Status = entities.ChannelOrderCancelled
+	adapter.On("ParseWebhook", ctx, headers, []byte(nil)).Return([]*entities.ChannelOrder{cancelled}, nil).Twice()
+	repo.On("GetOrderByExternalID", ctx, ch.ID, "O-1").Return(order, nil).Twice()
+	repo.On("CancelOrder", ctx, order.ID).Return(nil).Once().Run(func(args mock.Arguments) {
+		order.Status = entities.OnlineOrderCancelled
+	})
+	result, err := service.ReceiveWebhook(ctx, "SHOPEE-MAIN", headers, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Cancelled)
+
+	result, err = service.ReceiveWebhook(ctx, "SHOPEE-MAIN", headers, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Duplicates)
+
+	adapter.On("ParseWebhook", ctx, map[string]string{}, []byte(nil)).Return(nil, entities.ErrInvalidWebhook).Once()
+	_, err = service.ReceiveWebhook(ctx, "SHOPEE-MAIN", map[string]string{}, nil)
+	assert.True(t, errors.Is(err, entities.ErrInvalidWebhook))
+
+	repo.On("GetChannelByCode", ctx, "LAZADA").Return(nil, nil).Once()
+	_, err = service.ReceiveWebhook(ctx, "LAZADA", nil, nil)
+	assert.True(t, errors.Is(err, entities.ErrChannelNotFound))
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
+
+func TestMarketplaceService_ShipOrder(t *testing.T) {
+	repo, adapter, stock := new(MockMarketplaceRepository), new(MockChannelAdapter), new(MockStockMover)
+	service := newTestMarketplaceService(repo, adapter, stock)
+	ctx := context.Background()
+	ch := testMarketplaceChannel()
+	order := testChannelOnlineOrder(ch, uuid.New())
+	repo.On("GetOrder", ctx, order.ID).Return(order, nil).Times(3)
+
+	var shipment *entities.OnlineOrderShipment
+	repo.On("ShipOrder", ctx, mock.AnythingOfType("*entities.OnlineOrderShipment")).
+		Return(nil).Once().
+		Run(func(args mock.Arguments) {
+			shipment = args.Get(1).(*entities.OnlineOrderShipment)
+		})
+	stock.On("RecordStockMovement", ctx, mock.AnythingOfType("*entities.StockMovement")).Return(nil).Once()
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Once()
+	adapter.On("PushOrderStatus", ctx, mock.MatchedBy(func(update *entities.ChannelStatusUpdate) bool {
+		return update.ExternalOrderID == "O-1" && update.Status == entities.ChannelOrderShipped
+	})).Return(nil).Once()
+	shipped, err := service.ShipOrder(ctx, order.ID, uuid.New(), "")
+	require.NoError(t, err)
+	assert.Equal(t, entities.OnlineOrderShipped, shipped.Status)
+	assert.Equal(t, "SPX-O-1", shipped.TrackingNumber, "the channel's airway bill is used")
+	require.NotNil(t, shipment)
+	assert.Equal(t, order.ID, shipment.OnlineOrderID)
+
+	movements := stock.movements()
+	require.Len(t, movements, 1)
+	assert.Equal(t, "out", movements[0].MovementType)
+	assert.Equal(t, 2, movements[0].Quantity)
+	assert.Equal(t, ch.WarehouseID, movements[0].WarehouseID)
+	assert.Equal(t, shipment.GoodsIssueID, movements[0].ReferenceID)
+
+	_, err = service.ShipOrder(ctx, order.ID, uuid.New(), "")
+	assert.True(t, errors.Is(err, entities.ErrOnlineOrderStatus))
+	_, err = service.CancelOrder(ctx, order.ID)
+	assert.True(t, errors.Is(err, entities.ErrOnlineOrderStatus))
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+	stock.AssertExpectations(t)
+}
+
+func TestMarketplaceService_CancelOrderPushesStatus(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch := testMarketplaceChannel()
+	order := testChannelOnlineOrder(ch, uuid.New())
+
+	repo.On("GetOrder", ctx, order.ID).Return(order, nil).Once()
+	repo.On("CancelOrder", ctx, order.ID).Return(nil).Once()
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Once()
+	adapter.On("PushOrderStatus", ctx, mock.MatchedBy(func(update *entities.ChannelStatusUpdate) bool {
+		return update.ExternalOrderID == "O-1" && update.Status == entities.ChannelOrderCancelled
+	})).Return(nil).Once()
+	cancelled, err := service.CancelOrder(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.OnlineOrderCancelled, cancelled.Status)
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
+
+func TestMarketplaceService_PushStockClampsOverbooked(t *testing.T) {
+	repo, adapter := new(MockMarketplaceRepository), new(MockChannelAdapter)
+	service := newTestMarketplaceService(repo, adapter, new(MockStockMover))
+	ctx := context.Background()
+	ch := testMarketplaceChannel()
+	repo.On("GetChannel", ctx, ch.ID).Return(ch, nil).Twice()
+
+	levels := []*entities.ChannelStockLevel{{SKU: "SKU-1", Available: 5}, {SKU: "SKU-2", Available: -3}}
+	repo.On("ListStockLevels", ctx, ch.ID).Return(levels, nil).Once()
+	adapter.On("PushStock", ctx, levels).Return(nil).Once()
+	count, err := service.PushStock(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	pushed := adapter.Calls[0].Arguments.Get(1).([]*entities.ChannelStockLevel)
+	assert.Equal(t, 5, pushed[0].Available)
+	assert.Equal(t, 0, pushed[1].Available)
+
+	ch.Status = entities.ChannelInactive
+	_, err = service.PushStock(ctx, ch.ID)
+	assert.True(t, errors.Is(err, entities.ErrInvalidChannel))
+	repo.AssertExpectations(t)
+	adapter.AssertExpectations(t)
+}
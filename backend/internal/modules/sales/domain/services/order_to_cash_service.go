@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	inventory_entities "malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/events"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// StockMover records stock moving in or out of a warehouse.
+type StockMover interface {
+	RecordStockMovement(ctx context.Context, sm *inventory_entities.StockMovement) error
+}
+
+// OrderToCashService drives a sales order through confirmation, delivery, invoicing and
+// payment, keeping the shipment, goods issue, receivable, tax and cash documents of the
+// other modules in step with it.
+type OrderToCashService struct {
+	repo     repositories.OrderToCashRepository
+	orders   repositories.SalesOrderRepository
+	stock    StockMover
+	eventBus events.EventBus // Optional: for event-driven integration
+	taxes    TaxCalculator   // Optional: determines invoice tax from the tax rules
+}
+
+// NewOrderToCashService creates a new OrderToCashService.
+func NewOrderToCashService(repo repositories.OrderToCashRepository, orders repositories.SalesOrderRepository, stock StockMover) *OrderToCashService {
+	return &OrderToCashService{repo: repo, orders: orders, stock: stock}
+}
+
+// WithEventBus adds event bus for cross-module communication
+func (s *OrderToCashService) WithEventBus(bus events.EventBus) *OrderToCashService {
+	s.eventBus = bus
+	return s
+}
+
+// SetTaxCalculator enables determining the tax of invoices from the tax rules and the
+// rate in force on the invoice date.
+func (s *OrderToCashService) SetTaxCalculator(taxes TaxCalculator) {
+	s.taxes = taxes
+}
+
+// GetOrderLines returns the lines of a sales order with their progress through the flow.
+func (s *OrderToCashService) GetOrderLines(ctx context.Context, orderID uuid.ID) ([]*entities.SalesOrderLine, error) {
+	if _, err := s.getOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderLines(ctx, orderID)
+}
+
+// ConfirmOrder confirms an open sales order and reserves its items in a warehouse.
+func (s *OrderToCashService) ConfirmOrder(ctx context.Context, orderID, warehouseID uuid.ID, userID string) (*entities.SalesOrder, error) {
+	if warehouseID.IsNil() {
+		return nil, fmt.Errorf("%w: warehouse is required", entities.ErrInvalidSalesOrder)
+	}
+	so, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if so.Status != entities.SalesOrderDraft && so.Status != entities.SalesOrderPending {
+		return nil, fmt.Errorf("%w: order is %s", entities.ErrSalesOrderStatus, so.Status)
+	}
+
+	lines, err := s.repo.GetOrderLines(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: order has no items", entities.ErrInvalidSalesOrder)
+	}
+
+	now := utils.Now()
+	reservations := make([]*entities.StockReservation, 0, len(lines))
+	items := make([]events.SalesItemEventData, 0, len(lines))
+	for _, line := range lines {
+		articleID, err := uuid.Parse(line.ArticleID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: item %s has an invalid article", entities.ErrInvalidSalesOrder, line.ID)
+		}
+		reservations = append(reservations, &entities.StockReservation{
+			ID:               uuid.New(),
+			SalesOrderID:     orderID,
+			SalesOrderItemID: line.ID,
+			ArticleID:        articleID,
+			WarehouseID:      warehouseID,
+			Quantity:         line.Quantity,
+			Status:           entities.ReservationOpen,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		})
+		items = append(items, events.SalesItemEventData{
+			SalesOrderItemID: line.ID.String(),
+			ArticleID:        line.ArticleID,
+			Quantity:         line.Quantity,
+			UnitPrice:        line.UnitPrice,
+			LineTotal:        line.TotalPrice,
+		})
+	}
+	if err := s.repo.Confirm(ctx, orderID, reservations); err != nil {
+		return nil, err
+	}
+	so.Status = entities.SalesOrderConfirmed
+
+	s.publish(ctx, events.NewSalesOrderConfirmedEvent(
+		so.ID.String(), so.CustomerID, warehouseID.String(), so.TotalAmount, userID, items,
+	))
+	return so, nil
+}
+
+// DeliverOrder posts a delivery of some or all open quantities of a confirmed order. It
+// ships the goods with the courier and issues them from the reserving warehouse.
+func (s *OrderToCashService) DeliverOrder(ctx context.Context, delivery *entities.SalesDelivery) (*entities.SalesDelivery, error) {
+	if delivery.CourierID.IsNil() {
+		return nil, fmt.Errorf("%w: courier is required", entities.ErrInvalidDelivery)
+	}
+	if len(delivery.Items) == 0 {
+		return nil, fmt.Errorf("%w: delivery has no items", entities.ErrInvalidDelivery)
+	}
+	so, err := s.getOrder(ctx, delivery.SalesOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if so.Status != entities.SalesOrderConfirmed && so.Status != entities.SalesOrderPartiallyDelivered {
+		return nil, fmt.Errorf("%w: order is %s", entities.ErrSalesOrderStatus, so.Status)
+	}
+
+	lines, err := s.repo.GetOrderLines(ctx, so.ID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.ID]*entities.SalesOrderLine, len(lines))
+	for _, line := range lines {
+		byID[line.ID] = line
+		if line.WarehouseID != nil {
+			delivery.WarehouseID = *line.WarehouseID
+		}
+	}
+	if delivery.WarehouseID.IsNil() {
+		return nil, fmt.Errorf("%w: order has no stock reserved", entities.ErrSalesOrderStatus)
+	}
+
+	now := utils.Now()
+	delivery.ID = uuid.New()
+	delivered := make(map[uuid.ID]int, len(delivery.Items))
+	for _, item := range delivery.Items {
+		line, ok := byID[item.SalesOrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: item %s is not on the order", entities.ErrInvalidDelivery, item.SalesOrderItemID)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive", entities.ErrInvalidDelivery)
+		}
+		delivered[line.ID] += item.Quantity
+		if delivered[line.ID] > line.Open() {
+			return nil, fmt.Errorf("%w: only %d of item %s are still to be delivered", entities.ErrInvalidDelivery, line.Open(), line.ID)
+		}
+		articleID, err := uuid.Parse(line.ArticleID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: item %s has an invalid article", entities.ErrInvalidDelivery, line.ID)
+		}
+
+		// Deliveries carry the net line price, so invoicing all of them bills the order total
+		unitPrice := line.TotalPrice / float64(line.Quantity)
+		item.ID = uuid.New()
+		item.DeliveryID = delivery.ID
+		item.ArticleID = articleID
+		item.UnitPrice = roundMoney(unitPrice)
+		item.TotalPrice = roundMoney(unitPrice * float64(item.Quantity))
+	}
+
+	number, err := s.repo.NextDeliveryNumber(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	delivery.DeliveryNumber = number
+	delivery.CustomerID = so.CustomerID
+	delivery.ShipmentID = uuid.New()
+	delivery.GoodsIssueID = uuid.New()
+	delivery.Status = entities.DeliveryPosted
+	if delivery.DeliveryDate.IsZero() {
+		delivery.DeliveryDate = now
+	}
+	if delivery.TrackingNumber == "" {
+		delivery.TrackingNumber = number
+	}
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	orderStatus, err := s.repo.CreateDelivery(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	// The delivery stands once posted; a failed stock movement is logged for correction
+	// rather than failing a delivery that would be posted again on retry.
+	items := make([]events.SalesItemEventData, 0, len(delivery.Items))
+	for _, item := range delivery.Items {
+		if err := s.stock.RecordStockMovement(ctx, &inventory_entities.StockMovement{
+			ArticleID:    item.ArticleID,
+			WarehouseID:  delivery.WarehouseID,
+			Quantity:     item.Quantity,
+			MovementType: "out",
+			MovementDate: delivery.DeliveryDate,
+			ReferenceID:  delivery.ID,
+		}); err != nil {
+			log.Printf("[Sales] Failed to record stock movement of delivery %s, article %s: %v", delivery.DeliveryNumber, item.ArticleID, err)
+		}
+		items = append(items, events.SalesItemEventData{
+			SalesOrderItemID: item.SalesOrderItemID.String(),
+			ArticleID:        item.ArticleID.String(),
+			Quantity:         item.Quantity,
+			UnitPrice:        item.UnitPrice,
+			LineTotal:        item.TotalPrice,
+		})
+	}
+
+	s.publish(ctx, events.NewSalesDeliveryPostedEvent(
+		delivery.ID.String(), delivery.DeliveryNumber, so.ID.String(), so.CustomerID,
+		delivery.ShipmentID.String(), delivery.GoodsIssueID.String(), delivery.WarehouseID.String(),
+		delivery.DeliveryDate, orderStatus, items,
+	))
+	return delivery, nil
+}
+
+// GetDelivery returns a delivery with its items.
+func (s *OrderToCashService) GetDelivery(ctx context.Context, id uuid.ID) (*entities.SalesDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, entities.ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// ListDeliveries returns the deliveries of a sales order.
+func (s *OrderToCashService) ListDeliveries(ctx context.Context, orderID uuid.ID) ([]*entities.SalesDelivery, error) {
+	if _, err := s.getOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, orderID)
+}
+
+// InvoiceDelivery bills a delivery at the order's tax rate. The invoice opens an
+// accounts receivable for the customer and books its output VAT. Without a due date
+// the invoice is due DefaultInvoiceDueDays after the invoice date.
+func (s *OrderToCashService) InvoiceDelivery(ctx context.Context, deliveryID uuid.ID, invoiceDate time.Time, dueDate *time.Time, userID string) (*entities.SalesInvoice, error) {
+	delivery, err := s.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != entities.DeliveryPosted {
+		return nil, entities.ErrDeliveryInvoiced
+	}
+	so, err := s.getOrder(ctx, delivery.SalesOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := utils.Now()
+	if invoiceDate.IsZero() {
+		invoiceDate = now
+	}
+	if dueDate == nil {
+		due := invoiceDate.AddDate(0, 0, entities.DefaultInvoiceDueDays)
+		dueDate = &due
+	} else if dueDate.Before(invoiceDate) {
+		return nil, fmt.Errorf("%w: due date is before the invoice date", entities.ErrInvalidInvoice)
+	}
+
+	number, err := s.repo.NextInvoiceNumber(ctx, invoiceDate)
+	if err != nil {
+		return nil, err
+	}
+	invoice := &entities.SalesInvoice{
+		SalesOrderID:  so.ID.String(),
+		InvoiceDate:   invoiceDate,
+		InvoiceNumber: number,
+		CustomerID:    so.CustomerID,
+		DeliveryID:    &delivery.ID,
+		DueDate:       dueDate,
+		Status:        entities.InvoiceUnpaid,
+	}
+	invoice.ID = uuid.New()
+	invoice.CreatedAt = now
+	invoice.UpdatedAt = now
+
+	items := make([]*entities.SalesInvoiceItem, 0, len(delivery.Items))
+	taxLines := make([]accounting_entities.TaxCalculationLineInput, 0, len(delivery.Items))
+	for _, item := range delivery.Items {
+		invoiceItem := &entities.SalesInvoiceItem{
+			SalesInvoiceID: invoice.ID.String(),
+			ArticleID:      item.ArticleID.String(),
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice,
+			TotalPrice:     item.TotalPrice,
+		}
+		invoiceItem.ID = uuid.New()
+		invoiceItem.CreatedAt = now
+		invoiceItem.UpdatedAt = now
+		items = append(items, invoiceItem)
+		taxLines = append(taxLines, accounting_entities.TaxCalculationLineInput{ArticleID: item.ArticleID, Amount: item.TotalPrice})
+		invoice.TotalAmount += item.TotalPrice
+	}
+	invoice.TotalAmount = roundMoney(invoice.TotalAmount)
+	if s.taxes != nil {
+		calc, err := calculateTax(ctx, s.taxes, accounting_entities.TaxTransactionSale, so.CustomerID, invoiceDate, taxLines)
+		if err != nil {
+			return nil, err
+		}
+		invoice.TaxAmount = calc.TaxAmount
+	} else {
+		invoice.TaxAmount = roundMoney(invoice.TotalAmount * orderTaxRate(so) / 100)
+	}
+	invoice.GrandTotal = roundMoney(invoice.TotalAmount + invoice.TaxAmount)
+
+	posting := &entities.InvoicePosting{
+		Invoice:      invoice,
+		Items:        items,
+		DeliveryID:   delivery.ID,
+		ReceivableID: uuid.New(),
+		PostedBy:     userID,
+	}
+	if _, err := s.repo.CreateInvoice(ctx, posting); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, events.NewSalesInvoicePostedEvent(
+		invoice.ID.String(), invoice.InvoiceNumber, so.ID.String(), delivery.ID.String(), so.CustomerID,
+		posting.ReceivableID.String(), invoice.TotalAmount, invoice.TaxAmount, invoice.GrandTotal, *dueDate, userID,
+	))
+	return invoice, nil
+}
+
+// GetInvoice returns a sales invoice.
+func (s *OrderToCashService) GetInvoice(ctx context.Context, id uuid.ID) (*entities.SalesInvoice, error) {
+	invoice, err := s.repo.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, entities.ErrSalesInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// ReceivePayment records a customer payment against a sales invoice. The payment is
+// booked as cash receipt and settles the invoice's receivable; the order is paid once
+// all of it is delivered and every invoice settled.
+func (s *OrderToCashService) ReceivePayment(ctx context.Context, payment *entities.SalesPayment) (*entities.SalesInvoice, error) {
+	if payment.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", entities.ErrInvalidPayment)
+	}
+	payment.Amount = roundMoney(payment.Amount)
+
+	now := utils.Now()
+	payment.ID = uuid.New()
+	payment.CashReceiptID = uuid.New()
+	if payment.PaymentDate.IsZero() {
+		payment.PaymentDate = now
+	}
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+
+	invoice, err := s.repo.RecordPayment(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, events.NewSalesPaymentReceivedEvent(
+		payment.ID.String(), invoice.ID.String(), invoice.InvoiceNumber, invoice.SalesOrderID, invoice.CustomerID,
+		payment.CashReceiptID.String(), payment.Amount, invoice.Balance(), payment.PaymentDate, payment.CreatedBy,
+	))
+	return invoice, nil
+}
+
+// GetDocumentFlow returns the documents a document of the order-to-cash flow was created
+// from and the documents created from it.
+func (s *OrderToCashService) GetDocumentFlow(ctx context.Context, docType string, id uuid.ID) (*entities.DocumentFlow, error) {
+	switch docType {
+	case entities.DocumentQuotation, entities.DocumentSalesOrder, entities.DocumentDelivery, entities.DocumentShipment,
+		entities.DocumentGoodsIssue, entities.DocumentInvoice, entities.DocumentReceivable, entities.DocumentPayment,
+		entities.DocumentCashReceipt:
+	default:
+		return nil, fmt.Errorf("%w: %s", entities.ErrUnknownDocument, docType)
+	}
+
+	orderID, err := s.repo.FindOrder(ctx, docType, id)
+	if err != nil {
+		return nil, err
+	}
+	if orderID == nil {
+		return nil, entities.ErrDocumentNotFound
+	}
+	documents, err := s.repo.GetOrderDocuments(ctx, *orderID)
+	if err != nil {
+		return nil, err
+	}
+	flow := documents.Flow(docType, id)
+	if flow == nil {
+		return nil, entities.ErrDocumentNotFound
+	}
+	return flow, nil
+}
+
+func (s *OrderToCashService) getOrder(ctx context.Context, id uuid.ID) (*entities.SalesOrder, error) {
+	so, err := s.orders.GetByID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+	if so == nil {
+		return nil, entities.ErrSalesOrderNotFound
+	}
+	return so, nil
+}
+
+func (s *OrderToCashService) publish(ctx context.Context, event events.Event) {
+	if s.eventBus != nil {
+		s.eventBus.PublishAsync(ctx, event)
+	}
+}
+
+// orderTaxRate returns the PPN rate in percent of an order. Orders carrying tax, such as
+// those converted from a quotation, keep their rate; others are invoiced at the
+// current PPN rate.
+func orderTaxRate(so *entities.SalesOrder) float64 {
+	net := so.TotalAmount - so.TaxAmount
+	if so.TaxAmount > 0 && net > 0 {
+		return effectiveTaxRate(so.TaxAmount, net)
+	}
+	return entities.QuotationDefaultTaxRate
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	inventory_entities "malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/events"
+	"malaka/internal/shared/uuid"
+)
+
+// MockOrderToCashRepository is a mock implementation of repositories.OrderToCashRepository.
+type MockOrderToCashRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderToCashRepository) GetOrderLines(ctx context.Context, orderID uuid.ID) ([]*entities.SalesOrderLine, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).([]*entities.SalesOrderLine), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) Confirm(ctx context.Context, orderID uuid.ID, reservations []*entities.StockReservation) error {
+	args := m.Called(ctx, orderID, reservations)
+	return args.Error(0)
+}
+
+func (m *MockOrderToCashRepository) NextDeliveryNumber(ctx context.Context, at time.Time) (string, error) {
+	args := m.Called(ctx, at)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) CreateDelivery(ctx context.Context, d *entities.SalesDelivery) (string, error) {
+	args := m.Called(ctx, d)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) GetDelivery(ctx context.Context, id uuid.ID) (*entities.SalesDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SalesDelivery), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) ListDeliveries(ctx context.Context, orderID uuid.ID) ([]*entities.SalesDelivery, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).([]*entities.SalesDelivery), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) NextInvoiceNumber(ctx context.Context, at time.Time) (string, error) {
+	args := m.Called(ctx, at)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) CreateInvoice(ctx context.Context, p *entities.InvoicePosting) (string, error) {
+	args := m.Called(ctx, p)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) GetInvoice(ctx context.Context, id uuid.ID) (*entities.SalesInvoice, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SalesInvoice), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) RecordPayment(ctx context.Context, p *entities.SalesPayment) (*entities.SalesInvoice, error) {
+	args := m.Called(ctx, p)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SalesInvoice), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) FindOrder(ctx context.Context, docType string, id uuid.ID) (*uuid.ID, error) {
+	args := m.Called(ctx, docType, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uuid.ID), args.Error(1)
+}
+
+func (m *MockOrderToCashRepository) GetOrderDocuments(ctx context.Context, orderID uuid.ID) (entities.OrderDocuments, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(entities.OrderDocuments), args.Error(1)
+}
+
+// MockStockMover is a mock implementation of StockMover.
+type MockStockMover struct {
+	mock.Mock
+}
+
+func (m *MockStockMover) RecordStockMovement(ctx context.Context, sm *inventory_entities.StockMovement) error {
+	args := m.Called(ctx, sm)
+	return args.Error(0)
+}
+
+// movements returns the stock movements recorded so far.
+func (m *MockStockMover) movements() []*inventory_entities.StockMovement {
+	var movements []*inventory_entities.StockMovement
+	for _, call := range m.Calls {
+		movements = append(movements, call.Arguments.Get(1).(*inventory_entities.StockMovement))
+	}
+	return movements
+}
+
+// MockEventBus is a mock implementation of events.EventBus.
+type MockEventBus struct {
+	mock.Mock
+}
+
+func (m *MockEventBus) Publish(ctx context.Context, event events.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockEventBus) PublishAsync(ctx context.Context, event events.Event) {
+	m.Called(ctx, event)
+}
+
+func (m *MockEventBus) Subscribe(eventType string, handler events.EventHandler) {
+	m.Called(eventType, handler)
+}
+
+func (m *MockEventBus) SubscribeAll(handler events.EventHandler) {
+	m.Called(handler)
+}
+
+func (m *MockEventBus) Unsubscribe(eventType string, handler events.EventHandler) {
+	m.Called(eventType, handler)
+}
+
+// published returns the events published so far.
+func (m *MockEventBus) published() []events.Event {
+	var published []events.Event
+	for _, call := range m.Calls {
+		if call.Method == "PublishAsync" {
+			published = append(published, call.Arguments.Get(1).(events.Event))
+		}
+	}
+	return published
+}
+
+// lastEvent returns the event published last.
+func (m *MockEventBus) lastEvent() events.Event {
+	published := m.published()
+	if len(published) == 0 {
+		return nil
+	}
+	return published[len(published)-1]
+}
+
+// testSalesOrder returns a pending order of 10 pieces at 100 less a 100 discount,
+// converted from a quotation with 11% PPN, and its line.
+func testSalesOrder() (*entities.SalesOrder, *entities.SalesOrderLine) {
+	so := &entities.SalesOrder{
+		CustomerID:  uuid.New().String(),
+		Status:      entities.SalesOrderPending,
+		TaxAmount:   99,
+		TotalAmount: 999,
+	}
+	so.ID = uuid.New()
+	line := &entities.SalesOrderLine{SalesOrderItem: entities.SalesOrderItem{
+		SalesOrderID:   so.ID.String(),
+		ArticleID:      uuid.New().String(),
+		Quantity:       10,
+		UnitPrice:      100,
+		DiscountAmount: 100,
+		TotalPrice:     900,
+	}}
+	line.ID = uuid.New()
+	return so, line
+}
+
+// testPostedDelivery returns a posted delivery of some pieces of an order line at the
+// net line price.
+func testPostedDelivery(so *entities.SalesOrder, line *entities.SalesOrderLine, quantity int) *entities.SalesDelivery {
+	delivery := &entities.SalesDelivery{
+		SalesOrderID: so.ID,
+		CustomerID:   so.CustomerID,
+		Status:       entities.DeliveryPosted,
+		Items: []*entities.SalesDeliveryItem{{
+			SalesOrderItemID: line.ID,
+			ArticleID:        uuid.MustParse(line.ArticleID),
+			Quantity:         quantity,
+			UnitPrice:        90,
+			TotalPrice:       90 * float64(quantity),
+		}},
+	}
+	delivery.ID = uuid.New()
+	return delivery
+}
+
+func TestOrderToCashService_ConfirmOrder(t *testing.T) {
+	repo, orders, bus := new(MockOrderToCashRepository), new(MockSalesOrderRepository), new(MockEventBus)
+	service := NewOrderToCashService(repo, orders, new(MockStockMover)).WithEventBus(bus)
+	ctx := context.Background()
+	so, line := testSalesOrder()
+	warehouseID, missing := uuid.New(), uuid.New()
+
+	var reservations []*entities.StockReservation
+	orders.On("GetByID", ctx, so.ID.String()).Return(so, nil).Twice()
+	repo.On("GetOrderLines", ctx, so.ID).Return([]*entities.SalesOrderLine{line}, nil).Once()
+	repo.On("Confirm", ctx, so.ID, mock.AnythingOfType("[]*entities.StockReservation")).
+		Return(nil).Once().
+		Run(func(args mock.Arguments) {
+			reservations = args.Get(2).([]*entities.StockReservation)
+		})
+	bus.On("PublishAsync", ctx, mock.AnythingOfType("*events.SalesOrderConfirmedEvent")).Return().Once()
+	confirmed, err := service.ConfirmOrder(ctx, so.ID, warehouseID, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, entities.SalesOrderConfirmed, confirmed.Status)
+
+	require.Len(t, reservations, 1)
+	rsv := reservations[0]
+	assert.Equal(t, line.ID, rsv.SalesOrderItemID)
+	assert.Equal(t, warehouseID, rsv.WarehouseID)
+	assert.Equal(t, 10, rsv.Quantity)
+	assert.Equal(t, entities.ReservationOpen, rsv.Status)
+
+	event := bus.lastEvent().(*events.SalesOrderConfirmedEvent)
+	assert.Equal(t, events.EventTypeSalesOrderConfirmed, event.EventType())
+	assert.Equal(t, warehouseID.String(), event.WarehouseID)
+	assert.Len(t, event.Items, 1)
+
+	// A confirmed order is no longer open
+	_, err = service.ConfirmOrder(ctx, so.ID, warehouseID, "user-1")
+	assert.True(t, errors.Is(err, entities.ErrSalesOrderStatus))
+
+	orders.On("GetByID", ctx, missing.String()).Return(nil, nil).Once()
+	_, err = service.ConfirmOrder(ctx, missing, warehouseID, "user-1")
+	assert.True(t, errors.Is(err, entities.ErrSalesOrderNotFound))
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+	bus.AssertExpectations(t)
+}
+
+func TestOrderToCashService_DeliverOrder_Partial(t *testing.T) {
+	repo, orders, stock, bus := new(MockOrderToCashRepository), new(MockSalesOrderRepository), new(MockStockMover), new(MockEventBus)
+	service := NewOrderToCashService(repo, orders, stock).WithEventBus(bus)
+	ctx := context.Background()
+	so, line := testSalesOrder()
+	orders.On("GetByID", ctx, so.ID.String()).Return(so, nil).Times(3)
+
+	// Deliveries need a confirmed order
+	_, err := service.DeliverOrder(ctx, &entities.SalesDelivery{
+		SalesOrderID: so.ID,
+		CourierID:    uuid.New(),
+		Items:        []*entities.SalesDeliveryItem{{SalesOrderItemID: line.ID, Quantity: 1}},
+	})
+	assert.True(t, errors.Is(err, entities.ErrSalesOrderStatus))
+
+	warehouseID := uuid.New()
+	so.Status = entities.SalesOrderConfirmed
+	line.WarehouseID = &warehouseID
+	line.Reserved = line.Quantity
+	repo.On("GetOrderLines", ctx, so.ID).Return([]*entities.SalesOrderLine{line}, nil).Twice()
+	repo.On("NextDeliveryNumber", ctx, mock.AnythingOfType("time.Time")).Return("DO-2026-000001", nil).Once()
+	repo.On("CreateDelivery", ctx, mock.AnythingOfType("*entities.SalesDelivery")).
+		Return(entities.SalesOrderPartiallyDelivered, nil).Once().
+		Run(func(args mock.Arguments) {
+			line.Delivered += 4
+			line.Reserved -= 4
+		})
+	stock.On("RecordStockMovement", ctx, mock.AnythingOfType("*entities.StockMovement")).Return(nil).Once()
+	bus.On("PublishAsync", ctx, mock.AnythingOfType("*events.SalesDeliveryPostedEvent")).Return().Once()
+	delivery, err := service.DeliverOrder(ctx, &entities.SalesDelivery{
+		SalesOrderID: so.ID,
+		CourierID:    uuid.New(),
+		Items:        []*entities.SalesDeliveryItem{{SalesOrderItemID: line.ID, Quantity: 4}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "DO-2026-000001", delivery.DeliveryNumber)
+	assert.Equal(t, delivery.DeliveryNumber, delivery.TrackingNumber)
+	assert.Equal(t, warehouseID, delivery.WarehouseID)
+	assert.Equal(t, entities.DeliveryPosted, delivery.Status)
+	assert.False(t, delivery.ShipmentID.IsNil())
+	assert.False(t, delivery.GoodsIssueID.IsNil())
+	// Net line price: 900 for 10 pieces
+	assert.Equal(t, 90.0, delivery.Items[0].UnitPrice)
+	assert.Equal(t, 360.0, delivery.Items[0].TotalPrice)
+
+	movements := stock.movements()
+	require.Len(t, movements, 1)
+	assert.Equal(t, "out", movements[0].MovementType)
+	assert.Equal(t, 4, movements[0].Quantity)
+	assert.Equal(t, warehouseID, movements[0].WarehouseID)
+	assert.Equal(t, delivery.ID, movements[0].ReferenceID)
+
+	event := bus.lastEvent().(*events.SalesDeliveryPostedEvent)
+	assert.Equal(t, entities.SalesOrderPartiallyDelivered, event.OrderStatus)
+	assert.Equal(t, delivery.ShipmentID.String(), event.ShipmentID)
+
+	// Only 6 pieces are left to deliver
+	so.Status = entities.SalesOrderPartiallyDelivered
+	_, err = service.DeliverOrder(ctx, &entities.SalesDelivery{
+		SalesOrderID: so.ID,
+		CourierID:    uuid.New(),
+		Items:        []*entities.SalesDeliveryItem{{SalesOrderItemID: line.ID, Quantity: 7}},
+	})
+	assert.True(t, errors.Is(err, entities.ErrInvalidDelivery))
+
+	_, err = service.DeliverOrder(ctx, &entities.SalesDelivery{
+		SalesOrderID: so.ID,
+		Items:        []*entities.SalesDeliveryItem{{SalesOrderItemID: line.ID, Quantity: 1}},
+	})
+	assert.True(t, errors.Is(err, entities.ErrInvalidDelivery), "courier is required")
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+	stock.AssertExpectations(t)
+	bus.AssertExpectations(t)
+}
+
+func TestOrderToCashService_InvoiceDelivery(t *testing.T) {
+	repo, orders, bus := new(MockOrderToCashRepository), new(MockSalesOrderRepository), new(MockEventBus)
+	service := NewOrderToCashService(repo, orders, new(MockStockMover)).WithEventBus(bus)
+	ctx := context.Background()
+	so, line := testSalesOrder()
+	so.Status = entities.SalesOrderPartiallyDelivered
+	delivery := testPostedDelivery(so, line, 4)
+
+	posting := &entities.InvoicePosting{}
+	repo.On("GetDelivery", ctx, delivery.ID).Return(delivery, nil).Once()
+	orders.On("GetByID", ctx, so.ID.String()).Return(so, nil).Once()
+	repo.On("NextInvoiceNumber", ctx, mock.AnythingOfType("time.Time")).Return("INV-2026-000001", nil).Once()
+	repo.On("CreateInvoice", ctx, mock.AnythingOfType("*entities.InvoicePosting")).
+		Return(entities.SalesOrderInvoiced, nil).Once().
+		Run(func(args mock.Arguments) {
+			*posting = *args.Get(1).(*entities.InvoicePosting)
+			delivery.Status = entities.DeliveryInvoiced
+		})
+	bus.On("PublishAsync", ctx, mock.AnythingOfType("*events.SalesInvoicePostedEvent")).Return().Once()
+	invoiceDate := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	invoice, err := service.InvoiceDelivery(ctx, delivery.ID, invoiceDate, nil, "user-1")
+	require.NoError(t, err)
+
+	// The order carries 99 tax on 900, i.e. 11%
+	assert.Equal(t, 360.0, invoice.TotalAmount)
+	assert.Equal(t, 39.6, invoice.TaxAmount)
+	assert.Equal(t, 399.6, invoice.GrandTotal)
+	assert.Equal(t, entities.InvoiceUnpaid, invoice.Status)
+	assert.Equal(t, so.CustomerID, invoice.CustomerID)
+	assert.Equal(t, delivery.ID, *invoice.DeliveryID)
+	assert.Equal(t, invoiceDate.AddDate(0, 0, entities.DefaultInvoiceDueDays), *invoice.DueDate)
+
+	assert.Equal(t, invoice, posting.Invoice)
+	assert.False(t, posting.ReceivableID.IsNil())
+	require.Len(t, posting.Items, 1)
+	assert.Equal(t, 4, posting.Items[0].Quantity)
+
+	event := bus.lastEvent().(*events.SalesInvoicePostedEvent)
+	assert.Equal(t, posting.ReceivableID.String(), event.ReceivableID)
+	assert.Equal(t, 399.6, event.GrandTotal)
+
+	repo.On("GetDelivery", ctx, delivery.ID).Return(delivery, nil).Once()
+	_, err = service.InvoiceDelivery(ctx, delivery.ID, invoiceDate, nil, "user-1")
+	assert.True(t, errors.Is(err, entities.ErrDeliveryInvoiced))
+
+	other := testPostedDelivery(so, line, 2)
+	repo.On("GetDelivery", ctx, other.ID).Return(other, nil).Once()
+	orders.On("GetByID", ctx, so.ID.String()).Return(so, nil).Once()
+	dueDate := invoiceDate.AddDate(0, 0, -1)
+	_, err = service.InvoiceDelivery(ctx, other.ID, invoiceDate, &dueDate, "user-1")
+	assert.True(t, errors.Is(err, entities.ErrInvalidInvoice))
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+	bus.AssertExpectations(t)
+}
+
+func TestOrderToCashService_ReceivePayment(t *testing.T) {
+	repo, bus := new(MockOrderToCashRepository), new(MockEventBus)
+	service := NewOrderToCashService(repo, new(MockSalesOrderRepository), new(MockStockMover)).WithEventBus(bus)
+	ctx := context.Background()
+	invoice := &entities.SalesInvoice{InvoiceNumber: "INV-2026-000001", TotalAmount: 360, TaxAmount: 39.6, GrandTotal: 399.6, Status: entities.InvoiceUnpaid}
+	invoice.ID = uuid.New()
+
+	_, err := service.ReceivePayment(ctx, &entities.SalesPayment{SalesInvoiceID: invoice.ID})
+	assert.True(t, errors.Is(err, entities.ErrInvalidPayment))
+
+	partial := *invoice
+	partial.PaidAmount = 200
+	partial.Status = entities.InvoicePartiallyPaid
+	repo.On("RecordPayment", ctx, mock.MatchedBy(func(p *entities.SalesPayment) bool {
+		return p.SalesInvoiceID == invoice.ID && p.Amount == 200
+	})).Return(&partial, nil).Once()
+	bus.On("PublishAsync", ctx, mock.AnythingOfType("*events.SalesPaymentReceivedEvent")).Return().Twice()
+	received, err := service.ReceivePayment(ctx, &entities.SalesPayment{SalesInvoiceID: invoice.ID, Amount: 200})
+	require.NoError(t, err)
+	assert.Equal(t, entities.InvoicePartiallyPaid, received.Status)
+	assert.InDelta(t, 199.6, received.Balance(), moneyTolerance)
+
+	event := bus.lastEvent().(*events.SalesPaymentReceivedEvent)
+	assert.Equal(t, 200.0, event.Amount)
+	assert.InDelta(t, 199.6, event.Balance, moneyTolerance)
+	payment := repo.Calls[len(repo.Calls)-1].Arguments.Get(1).(*entities.SalesPayment)
+	assert.False(t, payment.CashReceiptID.IsNil())
+	assert.Equal(t, payment.CashReceiptID.String(), event.CashReceiptID)
+
+	paid := partial
+	paid.PaidAmount = 399.6
+	paid.Status = entities.InvoicePaid
+	repo.On("RecordPayment", ctx, mock.AnythingOfType("*entities.SalesPayment")).Return(&paid, nil).Once()
+	received, err = service.ReceivePayment(ctx, &entities.SalesPayment{SalesInvoiceID: invoice.ID, Amount: 199.6})
+	require.NoError(t, err)
+	assert.Equal(t, entities.InvoicePaid, received.Status)
+	repo.AssertExpectations(t)
+	bus.AssertExpectations(t)
+}
+
+func TestOrderTaxRate(t *testing.T) {
+	assert.Equal(t, 11.0, orderTaxRate(&entities.SalesOrder{TotalAmount: 1110, TaxAmount: 110}))
+	assert.Equal(t, entities.QuotationDefaultTaxRate, orderTaxRate(&entities.SalesOrder{TotalAmount: 1000}))
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	masterdata_entities "malaka/internal/modules/masterdata/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// WarehouseLookup resolves the warehouse a POS terminal sells from.
+type WarehouseLookup interface {
+	GetWarehouseByID(ctx context.Context, id uuid.ID) (*masterdata_entities.Warehouse, error)
+}
+
+// PosShiftService manages POS terminals, cashier shifts and cash drawer sessions.
+type PosShiftService struct {
+	terminals  repositories.PosTerminalRepository
+	shifts     repositories.PosShiftRepository
+	warehouses WarehouseLookup
+}
+
+// NewPosShiftService creates a new PosShiftService.
+func NewPosShiftService(terminals repositories.PosTerminalRepository, shifts repositories.PosShiftRepository, warehouses WarehouseLookup) *PosShiftService {
+	return &PosShiftService{terminals: terminals, shifts: shifts, warehouses: warehouses}
+}
+
+// CreateTerminal registers a new POS terminal to a store warehouse.
+func (s *PosShiftService) CreateTerminal(ctx context.Context, terminal *entities.PosTerminal) error {
+	if err := s.validateTerminal(ctx, terminal); err != nil {
+		return err
+	}
+	if terminal.ID.IsNil() {
+		terminal.ID = uuid.New()
+	}
+	terminal.CreatedAt = utils.Now()
+	terminal.UpdatedAt = terminal.CreatedAt
+	return s.terminals.Create(ctx, terminal)
+}
+
+// GetTerminals retrieves all POS terminals.
+func (s *PosShiftService) GetTerminals(ctx context.Context) ([]*entities.PosTerminal, error) {
+	return s.terminals.GetAll(ctx)
+}
+
+// GetTerminal retrieves a POS terminal by its ID.
+func (s *PosShiftService) GetTerminal(ctx context.Context, id uuid.ID) (*entities.PosTerminal, error) {
+	terminal, err := s.terminals.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if terminal == nil {
+		return nil, entities.ErrPosTerminalNotFound
+	}
+	return terminal, nil
+}
+
+// UpdateTerminal updates a POS terminal. A terminal cannot move to another warehouse
+// while a shift is open on it.
+func (s *PosShiftService) UpdateTerminal(ctx context.Context, terminal *entities.PosTerminal) error {
+	existing, err := s.GetTerminal(ctx, terminal.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateTerminal(ctx, terminal); err != nil {
+		return err
+	}
+	if terminal.WarehouseID != existing.WarehouseID || !terminal.IsActive {
+		open, err := s.shifts.GetOpenByTerminal(ctx, terminal.ID)
+		if err != nil {
+			return err
+		}
+		if open != nil {
+			return fmt.Errorf("%w: close the open shift before moving or disabling the terminal", entities.ErrInvalidPosShift)
+		}
+	}
+	terminal.UpdatedAt = utils.Now()
+	return s.terminals.Update(ctx, terminal)
+}
+
+// DeleteTerminal deletes a POS terminal that has no open shift.
+func (s *PosShiftService) DeleteTerminal(ctx context.Context, id uuid.ID) error {
+	if _, err := s.GetTerminal(ctx, id); err != nil {
+		return err
+	}
+	open, err := s.shifts.GetOpenByTerminal(ctx, id)
+	if err != nil {
+		return err
+	}
+	if open != nil {
+		return fmt.Errorf("%w: the terminal has an open shift", entities.ErrInvalidPosShift)
+	}
+	return s.terminals.Delete(ctx, id)
+}
+
+// OpenShift opens a cashier shift on a terminal with the cash float put in the drawer.
+// A terminal and a cashier can only have one open shift at a time.
+func (s *PosShiftService) OpenShift(ctx context.Context, terminalID, cashierID uuid.ID, openingFloat float64, notes string) (*entities.PosShift, error) {
+	if openingFloat < 0 {
+		return nil, fmt.Errorf("%w: opening float cannot be negative", entities.ErrInvalidPosShift)
+	}
+	terminal, err := s.GetTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if !terminal.IsActive {
+		return nil, entities.ErrPosTerminalInactive
+	}
+	open, err := s.shifts.GetOpenByTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if open == nil {
+		if open, err = s.shifts.GetOpenByCashier(ctx, cashierID); err != nil {
+			return nil, err
+		}
+	}
+	if open != nil {
+		return nil, entities.ErrPosShiftAlreadyOpen
+	}
+
+	now := utils.Now()
+	shift := &entities.PosShift{
+		TerminalID:   terminal.ID,
+		TerminalCode: terminal.Code,
+		WarehouseID:  terminal.WarehouseID,
+		StoreName:    terminal.StoreName,
+		CashierID:    cashierID,
+		Status:       entities.PosShiftOpen,
+		OpenedAt:     now,
+		OpeningFloat: roundMoney(openingFloat),
+		Notes:        notes,
+	}
+	shift.ID = uuid.New()
+	shift.CreatedAt = now
+	shift.UpdatedAt = now
+	if err := s.shifts.Create(ctx, shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// GetShift retrieves a shift by its ID.
+func (s *PosShiftService) GetShift(ctx context.Context, id uuid.ID) (*entities.PosShift, error) {
+	shift, err := s.shifts.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if shift == nil {
+		return nil, entities.ErrPosShiftNotFound
+	}
+	return shift, nil
+}
+
+// ListShifts lists shifts newest first, optionally filtered by terminal and status.
+func (s *PosShiftService) ListShifts(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosShift, int, error) {
+	return s.shifts.List(ctx, terminalID, status, limit, offset)
+}
+
+// ShiftForSale returns the open shift a cashier sells on at a terminal.
+func (s *PosShiftService) ShiftForSale(ctx context.Context, terminalID, cashierID uuid.ID) (*entities.PosShift, error) {
+	shift, err := s.shifts.GetOpenByTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if shift == nil {
+		return nil, entities.ErrNoOpenPosShift
+	}
+	if shift.CashierID != cashierID {
+		return nil, entities.ErrPosShiftCashierMismatch
+	}
+	return shift, nil
+}
+
+// NextReceiptNumber takes the next receipt number of a terminal.
+func (s *PosShiftService) NextReceiptNumber(ctx context.Context, terminalID uuid.ID) (string, error) {
+	return s.terminals.NextReceiptNumber(ctx, terminalID)
+}
+
+// RecordCashMovement records cash put into or taken out of the drawer of an open shift.
+func (s *PosShiftService) RecordCashMovement(ctx context.Context, shiftID uuid.ID, movementType string, amount float64, reason, createdBy string) (*entities.PosCashMovement, error) {
+	if movementType != entities.PosCashIn && movementType != entities.PosCashOut {
+		return nil, fmt.Errorf("%w: unknown cash movement type %q", entities.ErrInvalidPosShift, movementType)
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be greater than zero", entities.ErrInvalidPosShift)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", entities.ErrInvalidPosShift)
+	}
+	if _, err := s.GetShift(ctx, shiftID); err != nil {
+		return nil, err
+	}
+	movement := &entities.PosCashMovement{
+		ID:           uuid.New(),
+		ShiftID:      shiftID,
+		MovementType: movementType,
+		Amount:       roundMoney(amount),
+		Reason:       reason,
+		CreatedBy:    createdBy,
+		CreatedAt:    utils.Now(),
+	}
+	if err := s.shifts.AddCashMovement(ctx, movement); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// GetCashMovements retrieves the cash movements of a shift.
+func (s *PosShiftService) GetCashMovements(ctx context.Context, shiftID uuid.ID) ([]*entities.PosCashMovement, error) {
+	return s.shifts.GetCashMovements(ctx, shiftID)
+}
+
+// XReport returns the running totals of a shift. Closed shifts return their Z report.
+func (s *PosShiftService) XReport(ctx context.Context, shiftID uuid.ID) (*entities.PosShiftReport, error) {
+	shift, err := s.GetShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.Status == entities.PosShiftClosed && shift.ZReport != nil {
+		return shift.ZReport, nil
+	}
+	return s.buildReport(ctx, shift, entities.PosReportX, utils.Now())
+}
+
+// CloseShift closes a shift with the cash the cashier counted in the drawer. The count
+// is blind: the expected amount is only worked out here, and the Z report with the
+// variance is returned once the shift is closed.
+func (s *PosShiftService) CloseShift(ctx context.Context, shiftID uuid.ID, countedCash float64, notes, closedBy string) (*entities.PosShift, error) {
+	if countedCash < 0 {
+		return nil, fmt.Errorf("%w: counted cash cannot be negative", entities.ErrInvalidPosShift)
+	}
+	shift, err := s.GetShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.Status != entities.PosShiftOpen {
+		return nil, entities.ErrPosShiftClosed
+	}
+
+	now := utils.Now()
+	report, err := s.buildReport(ctx, shift, entities.PosReportZ, now)
+	if err != nil {
+		return nil, err
+	}
+	counted := roundMoney(countedCash)
+	variance := roundMoney(counted - report.ExpectedCash)
+	report.ClosedAt = &now
+	report.CountedCash = &counted
+	report.Variance = &variance
+
+	shift.Status = entities.PosShiftClosed
+	shift.ClosedAt = &now
+	shift.ClosedBy = closedBy
+	shift.ExpectedCash = &report.ExpectedCash
+	shift.CountedCash = &counted
+	shift.CashVariance = &variance
+	if notes != "" {
+		shift.Notes = notes
+	}
+	shift.ZReport = report
+	shift.UpdatedAt = now
+	if err := s.shifts.Close(ctx, shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+func (s *PosShiftService) buildReport(ctx context.Context, shift *entities.PosShift, reportType string, at time.Time) (*entities.PosShiftReport, error) {
+	sales, err := s.shifts.GetSales(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.shifts.GetCashMovements(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	return buildShiftReport(shift, sales, movements, reportType, at), nil
+}
+
+// buildShiftReport works out the cash the drawer should hold: the opening float plus
+// cash sales and cash put in, less cash taken out.
+func buildShiftReport(shift *entities.PosShift, sales *entities.PosShiftSales, movements []*entities.PosCashMovement, reportType string, at time.Time) *entities.PosShiftReport {
+	report := &entities.PosShiftReport{
+		ReportType:    reportType,
+		ShiftID:       shift.ID,
+		TerminalID:    shift.TerminalID,
+		TerminalCode:  shift.TerminalCode,
+		StoreName:     shift.StoreName,
+		CashierID:     shift.CashierID,
+		OpenedAt:      shift.OpenedAt,
+		GeneratedAt:   at,
+		PosShiftSales: *sales,
+		OpeningFloat:  shift.OpeningFloat,
+	}
+	for _, payment := range sales.Payments {
+		if strings.EqualFold(payment.PaymentMethod, entities.PosPaymentCash) {
+			report.CashSales += payment.Amount
+		}
+	}
+	for _, movement := range movements {
+		switch movement.MovementType {
+		case entities.PosCashIn:
+			report.CashIn += movement.Amount
+		case entities.PosCashOut:
+			report.CashOut += movement.Amount
+		}
+	}
+	report.CashSales = roundMoney(report.CashSales)
+	report.CashIn = roundMoney(report.CashIn)
+	report.CashOut = roundMoney(report.CashOut)
+	report.ExpectedCash = roundMoney(report.OpeningFloat + report.CashSales + report.CashIn - report.CashOut)
+	return report
+}
+
+func (s *PosShiftService) validateTerminal(ctx context.Context, terminal *entities.PosTerminal) error {
+	if strings.TrimSpace(terminal.Code) == "" || strings.TrimSpace(terminal.Name) == "" || strings.TrimSpace(terminal.StoreName) == "" {
+		return fmt.Errorf("%w: terminal code, name and store are required", entities.ErrInvalidPosShift)
+	}
+	if terminal.Timezone == "" {
+		terminal.Timezone = entities.DefaultPosTimezone
+	}
+	if _, err := time.LoadLocation(terminal.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown time zone %q", entities.ErrInvalidPosShift, terminal.Timezone)
+	}
+	if s.warehouses == nil {
+		return nil
+	}
+	warehouse, err := s.warehouses.GetWarehouseByID(ctx, terminal.WarehouseID)
+	if err != nil || warehouse == nil {
+		return fmt.Errorf("%w: warehouse not found", entities.ErrInvalidPosShift)
+	}
+	if warehouse.Status != "" && warehouse.Status != masterdata_entities.WarehouseStatusActive {
+		return fmt.Errorf("%w: warehouse %s is not active", entities.ErrInvalidPosShift, warehouse.Code)
+	}
+	terminal.WarehouseName = warehouse.Name
+	return nil
+}
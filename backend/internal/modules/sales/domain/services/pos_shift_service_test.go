@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockPosTerminalRepository is a mock implementation of repositories.PosTerminalRepository.
+type MockPosTerminalRepository struct {
+	mock.Mock
+}
+
+func (m *MockPosTerminalRepository) Create(ctx context.Context, terminal *entities.PosTerminal) error {
+	args := m.Called(ctx, terminal)
+	return args.Error(0)
+}
+
+func (m *MockPosTerminalRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.PosTerminal, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PosTerminal), args.Error(1)
+}
+
+func (m *MockPosTerminalRepository) GetAll(ctx context.Context) ([]*entities.PosTerminal, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.PosTerminal), args.Error(1)
+}
+
+func (m *MockPosTerminalRepository) Update(ctx context.Context, terminal *entities.PosTerminal) error {
+	args := m.Called(ctx, terminal)
+	return args.Error(0)
+}
+
+func (m *MockPosTerminalRepository) Delete(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPosTerminalRepository) NextReceiptNumber(ctx context.Context, terminalID uuid.ID) (string, error) {
+	args := m.Called(ctx, terminalID)
+	return args.String(0), args.Error(1)
+}
+
+// MockPosShiftRepository is a mock implementation of repositories.PosShiftRepository.
+type MockPosShiftRepository struct {
+	mock.Mock
+}
+
+func (m *MockPosShiftRepository) Create(ctx context.Context, shift *entities.PosShift) error {
+	args := m.Called(ctx, shift)
+	return args.Error(0)
+}
+
+func (m *MockPosShiftRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.PosShift, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PosShift), args.Error(1)
+}
+
+func (m *MockPosShiftRepository) GetOpenByTerminal(ctx context.Context, terminalID uuid.ID) (*entities.PosShift, error) {
+	args := m.Called(ctx, terminalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PosShift), args.Error(1)
+}
+
+func (m *MockPosShiftRepository) GetOpenByCashier(ctx context.Context, cashierID uuid.ID) (*entities.PosShift, error) {
+	args := m.Called(ctx, cashierID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PosShift), args.Error(1)
+}
+
+func (m *MockPosShiftRepository) List(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosShift, int, error) {
+	args := m.Called(ctx, terminalID, status, limit, offset)
+	return args.Get(0).([]*entities.PosShift), args.Int(1), args.Error(2)
+}
+
+func (m *MockPosShiftRepository) Close(ctx context.Context, shift *entities.PosShift) error {
+	args := m.Called(ctx, shift)
+	return args.Error(0)
+}
+
+func (m *MockPosShiftRepository) AddCashMovement(ctx context.Context, movement *entities.PosCashMovement) error {
+	args := m.Called(ctx, movement)
+	return args.Error(0)
+}
+
+func (m *MockPosShiftRepository) GetCashMovements(ctx context.Context, shiftID uuid.ID) ([]*entities.PosCashMovement, error) {
+	args := m.Called(ctx, shiftID)
+	return args.Get(0).([]*entities.PosCashMovement), args.Error(1)
+}
+
+func (m *MockPosShiftRepository) GetSales(ctx context.Context, shiftID uuid.ID) (*entities.PosShiftSales, error) {
+	args := m.Called(ctx, shiftID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PosShiftSales), args.Error(1)
+}
+
+// newTestPosShiftService returns a service and one active terminal for the tests to
+// return from the terminal repository.
+func newTestPosShiftService() (*PosShiftService, *MockPosTerminalRepository, *MockPosShiftRepository, *entities.PosTerminal) {
+	terminal := &entities.PosTerminal{Code: "JKT-01", Name: "Till 1", StoreName: "Jakarta Store", WarehouseID: uuid.New(), IsActive: true}
+	terminal.ID = uuid.New()
+	terminals := new(MockPosTerminalRepository)
+	shifts := new(MockPosShiftRepository)
+	return NewPosShiftService(terminals, shifts, nil), terminals, shifts, terminal
+}
+
+func testPosShift(terminal *entities.PosTerminal, cashierID uuid.ID, openingFloat float64) *entities.PosShift {
+	shift := &entities.PosShift{
+		TerminalID:   terminal.ID,
+		TerminalCode: terminal.Code,
+		WarehouseID:  terminal.WarehouseID,
+		CashierID:    cashierID,
+		Status:       entities.PosShiftOpen,
+		OpenedAt:     time.Now(),
+		OpeningFloat: openingFloat,
+	}
+	shift.ID = uuid.New()
+	return shift
+}
+
+func TestPosShiftService_OpenShift(t *testing.T) {
+	svc, terminals, shifts, terminal := newTestPosShiftService()
+	ctx := context.Background()
+	cashierID := uuid.New()
+
+	terminals.On("GetByID", ctx, terminal.ID).Return(terminal, nil).Twice()
+	shifts.On("GetOpenByTerminal", ctx, terminal.ID).Return(nil, nil).Once()
+	shifts.On("GetOpenByCashier", ctx, cashierID).Return(nil, nil).Once()
+	shifts.On("Create", ctx, mock.AnythingOfType("*entities.PosShift")).Return(nil).Once()
+	shift, err := svc.OpenShift(ctx, terminal.ID, cashierID, 500000, "")
+	require.NoError(t, err)
+	assert.Equal(t, entities.PosShiftOpen, shift.Status)
+	assert.Equal(t, terminal.WarehouseID, shift.WarehouseID)
+	assert.Equal(t, 500000.0, shift.OpeningFloat)
+
+	// One open shift per terminal
+	shifts.On("GetOpenByTerminal", ctx, terminal.ID).Return(shift, nil).Once()
+	_, err = svc.OpenShift(ctx, terminal.ID, uuid.New(), 0, "")
+	assert.ErrorIs(t, err, entities.ErrPosShiftAlreadyOpen)
+
+	// and per cashier
+	other := &entities.PosTerminal{Code: "JKT-02", Name: "Till 2", StoreName: "Jakarta Store", WarehouseID: terminal.WarehouseID, IsActive: true}
+	terminals.On("Create", ctx, other).Return(nil).Once()
+	require.NoError(t, svc.CreateTerminal(ctx, other))
+	terminals.On("GetByID", ctx, other.ID).Return(other, nil).Once()
+	shifts.On("GetOpenByTerminal", ctx, other.ID).Return(nil, nil).Once()
+	shifts.On("GetOpenByCashier", ctx, cashierID).Return(shift, nil).Once()
+	_, err = svc.OpenShift(ctx, other.ID, cashierID, 0, "")
+	assert.ErrorIs(t, err, entities.ErrPosShiftAlreadyOpen)
+
+	_, err = svc.OpenShift(ctx, other.ID, uuid.New(), -1, "")
+	assert.ErrorIs(t, err, entities.ErrInvalidPosShift)
+	terminals.AssertExpectations(t)
+	shifts.AssertExpectations(t)
+}
+
+func TestPosShiftService_ShiftForSale(t *testing.T) {
+	svc, _, shifts, terminal := newTestPosShiftService()
+	ctx := context.Background()
+	cashierID := uuid.New()
+	shift := testPosShift(terminal, cashierID, 0)
+
+	shifts.On("GetOpenByTerminal", ctx, terminal.ID).Return(nil, nil).Once()
+	_, err := svc.ShiftForSale(ctx, terminal.ID, cashierID)
+	assert.ErrorIs(t, err, entities.ErrNoOpenPosShift)
+
+	shifts.On("GetOpenByTerminal", ctx, terminal.ID).Return(shift, nil).Twice()
+	found, err := svc.ShiftForSale(ctx, terminal.ID, cashierID)
+	require.NoError(t, err)
+	assert.Equal(t, shift.ID, found.ID)
+
+	_, err = svc.ShiftForSale(ctx, terminal.ID, uuid.New())
+	assert.ErrorIs(t, err, entities.ErrPosShiftCashierMismatch)
+	shifts.AssertExpectations(t)
+}
+
+func TestPosShiftService_RecordCashMovement(t *testing.T) {
+	svc, _, shifts, terminal := newTestPosShiftService()
+	ctx := context.Background()
+	shift := testPosShift(terminal, uuid.New(), 0)
+	shifts.On("GetByID", ctx, shift.ID).Return(shift, nil).Twice()
+
+	_, err := svc.RecordCashMovement(ctx, shift.ID, "float", 1000, "top-up", "")
+	assert.ErrorIs(t, err, entities.ErrInvalidPosShift)
+	_, err = svc.RecordCashMovement(ctx, shift.ID, entities.PosCashIn, 0, "top-up", "")
+	assert.ErrorIs(t, err, entities.ErrInvalidPosShift)
+	_, err = svc.RecordCashMovement(ctx, shift.ID, entities.PosCashOut, 1000, " ", "")
+	assert.ErrorIs(t, err, entities.ErrInvalidPosShift)
+	shifts.AssertNotCalled(t, "AddCashMovement", mock.Anything, mock.Anything)
+
+	shifts.On("AddCashMovement", ctx, mock.AnythingOfType("*entities.PosCashMovement")).Return(nil).Once()
+	movement, err := svc.RecordCashMovement(ctx, shift.ID, entities.PosCashOut, 1000000, "bank drop", "")
+	require.NoError(t, err)
+	assert.Equal(t, shift.ID, movement.ShiftID)
+	assert.Equal(t, 1000000.0, movement.Amount)
+
+	// The repository refuses movements once the shift is closed
+	shifts.On("AddCashMovement", ctx, mock.AnythingOfType("*entities.PosCashMovement")).Return(entities.ErrPosShiftClosed).Once()
+	_, err = svc.RecordCashMovement(ctx, shift.ID, entities.PosCashIn, 1000, "late", "")
+	assert.ErrorIs(t, err, entities.ErrPosShiftClosed)
+	shifts.AssertExpectations(t)
+}
+
+func TestPosShiftService_CloseShiftWithZReport(t *testing.T) {
+	svc, _, shifts, terminal := newTestPosShiftService()
+	ctx := context.Background()
+	shift := testPosShift(terminal, uuid.New(), 500000)
+
+	shifts.On("GetByID", ctx, shift.ID).Return(shift, nil).Times(4)
+	shifts.On("GetSales", ctx, shift.ID).Return(&entities.PosShiftSales{
+		TransactionCount: 3,
+		NetSales:         2750000,
+		Payments: []entities.PosPaymentTotal{
+			{PaymentMethod: "cash", TransactionCount: 2, Amount: 1500000},
+			{PaymentMethod: "card", TransactionCount: 1, Amount: 1250000},
+		},
+	}, nil).Twice()
+	shifts.On("GetCashMovements", ctx, shift.ID).Return([]*entities.PosCashMovement{
+		{ShiftID: shift.ID, MovementType: entities.PosCashIn, Amount: 200000, Reason: "change top-up"},
+		{ShiftID: shift.ID, MovementType: entities.PosCashOut, Amount: 1000000, Reason: "bank drop"},
+	}, nil).Twice()
+
+	x, err := svc.XReport(ctx, shift.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.PosReportX, x.ReportType)
+	assert.Equal(t, 1200000.0, x.ExpectedCash) // 500000 + 1500000 + 200000 - 1000000
+	assert.Nil(t, x.Variance)
+
+	// The repository numbers the Z report when it closes the shift
+	shifts.On("Close", ctx, shift).Return(nil).Run(func(args mock.Arguments) {
+		closing := args.Get(1).(*entities.PosShift)
+		closing.ZNumber = 1
+		closing.ZReport.ZNumber = 1
+	}).Once()
+	closed, err := svc.CloseShift(ctx, shift.ID, 1195000, "", "supervisor")
+	require.NoError(t, err)
+	assert.Equal(t, entities.PosShiftClosed, closed.Status)
+	assert.Equal(t, "supervisor", closed.ClosedBy)
+	require.NotNil(t, closed.ZReport)
+	assert.Equal(t, entities.PosReportZ, closed.ZReport.ReportType)
+	assert.Equal(t, 1, closed.ZReport.ZNumber)
+	assert.Equal(t, 1500000.0, closed.ZReport.CashSales)
+	assert.Equal(t, 1195000.0, *closed.ZReport.CountedCash)
+	assert.Equal(t, -5000.0, *closed.ZReport.Variance)
+	assert.Equal(t, -5000.0, *closed.CashVariance)
+
+	_, err = svc.CloseShift(ctx, shift.ID, 0, "", "")
+	assert.ErrorIs(t, err, entities.ErrPosShiftClosed)
+
+	report, err := svc.XReport(ctx, shift.ID)
+	require.NoError(t, err)
+	assert.Same(t, closed.ZReport, report)
+	shifts.AssertExpectations(t)
+}
+
+func TestBuildShiftReport(t *testing.T) {
+	shift := &entities.PosShift{OpeningFloat: 100000}
+	sales := &entities.PosShiftSales{Payments: []entities.PosPaymentTotal{
+		{PaymentMethod: "Cash", Amount: 50000.004},
+		{PaymentMethod: entities.PosPaymentLoyaltyPoints, Amount: 10000},
+	}}
+	report := buildShiftReport(shift, sales, nil, entities.PosReportX, time.Now())
+	assert.Equal(t, 50000.0, report.CashSales)
+	assert.Equal(t, 150000.0, report.ExpectedCash)
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	inventory_entities "malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// Page sizes of catalog downloads and the largest batch of offline transactions.
+const (
+	posSyncDefaultLimit = 500
+	posSyncMaxLimit     = 2000
+	posSyncMaxBatch     = 200
+)
+
+// StockLookup returns the stock of an article in a warehouse.
+type StockLookup interface {
+	GetStockBalance(ctx context.Context, articleID, warehouseID uuid.ID) (*inventory_entities.StockBalance, error)
+}
+
+// PosOfflineSale is a sale made on a terminal while it was offline. The transaction ID
+// is the one the terminal generated; uploading the same sale again has no effect.
+type PosOfflineSale struct {
+	Transaction *entities.PosTransaction
+	Items       []*entities.PosItem
+}
+
+// PosSyncService keeps POS terminals able to sell offline: it hands out catalog changes
+// since the version a terminal has and books the sales it made while offline.
+type PosSyncService struct {
+	repo         repositories.PosSyncRepository
+	shifts       *PosShiftService
+	transactions *PosTransactionService
+	stock        StockLookup
+}
+
+// NewPosSyncService creates a new PosSyncService.
+func NewPosSyncService(repo repositories.PosSyncRepository, shifts *PosShiftService, transactions *PosTransactionService, stock StockLookup) *PosSyncService {
+	return &PosSyncService{repo: repo, shifts: shifts, transactions: transactions, stock: stock}
+}
+
+// DownloadCatalog returns the articles, prices, barcodes and promotions changed after
+// the version a terminal has, up to limit changes. Entities changed more than once in
+// the page are sent once, as they are now.
+func (s *PosSyncService) DownloadCatalog(ctx context.Context, terminalID uuid.ID, since int64, limit int, clientVersion string) (*entities.PosCatalogDelta, error) {
+	if since < 0 {
+		return nil, fmt.Errorf("%w: the version cursor cannot be negative", entities.ErrInvalidPosSync)
+	}
+	if limit <= 0 {
+		limit = posSyncDefaultLimit
+	} else if limit > posSyncMaxLimit {
+		limit = posSyncMaxLimit
+	}
+	if _, err := s.activeTerminal(ctx, terminalID); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.repo.GetChanges(ctx, since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	delta := &entities.PosCatalogDelta{
+		Since:      since,
+		Cursor:     since,
+		Articles:   []*entities.PosCatalogArticle{},
+		Prices:     []*entities.PosCatalogPrice{},
+		Barcodes:   []*entities.PosCatalogBarcode{},
+		Promotions: []*entities.Promotion{},
+		Deleted:    []entities.PosSyncDeletion{},
+	}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		delta.HasMore = true
+	}
+
+	upserts, deletions := latestChanges(changes)
+	delta.Deleted = append(delta.Deleted, deletions...)
+	if delta.Articles, err = s.repo.GetArticles(ctx, upserts[entities.PosSyncArticle]); err != nil {
+		return nil, err
+	}
+	if delta.Prices, err = s.repo.GetPrices(ctx, upserts[entities.PosSyncPrice]); err != nil {
+		return nil, err
+	}
+	if delta.Barcodes, err = s.repo.GetBarcodes(ctx, upserts[entities.PosSyncBarcode]); err != nil {
+		return nil, err
+	}
+	if delta.Promotions, err = s.repo.GetPromotions(ctx, upserts[entities.PosSyncPromotion]); err != nil {
+		return nil, err
+	}
+	if len(changes) > 0 {
+		delta.Cursor = changes[len(changes)-1].Version
+	}
+
+	if err := s.repo.RecordDownload(ctx, terminalID, delta.Cursor, clientVersion); err != nil {
+		return nil, err
+	}
+	return delta, nil
+}
+
+// latestChanges keeps the last change of every entity in a page of changes. It returns
+// the IDs to send by entity type and the entities to drop.
+func latestChanges(changes []*entities.PosSyncChange) (map[string][]uuid.ID, []entities.PosSyncDeletion) {
+	type key struct {
+		entityType string
+		id         uuid.ID
+	}
+	last := make(map[key]*entities.PosSyncChange, len(changes))
+	for _, change := range changes {
+		last[key{change.EntityType, change.EntityID}] = change
+	}
+
+	upserts := make(map[string][]uuid.ID)
+	deletions := []entities.PosSyncDeletion{}
+	for _, change := range changes {
+		if last[key{change.EntityType, change.EntityID}] != change {
+			continue
+		}
+		if change.Operation == entities.PosSyncDelete {
+			deletions = append(deletions, entities.PosSyncDeletion{EntityType: change.EntityType, EntityID: change.EntityID})
+		} else {
+			upserts[change.EntityType] = append(upserts[change.EntityType], change.EntityID)
+		}
+	}
+	return upserts, deletions
+}
+
+// UploadTransactions books a batch of sales a terminal made while offline and returns
+// the result of each. Sales uploaded before are not booked again. A sale that cannot be
+// booked is rejected with the reason and can be sent again; the rest of the batch is
+// still booked. Sales at another price than the current one or without the stock in the
+// store's warehouse are booked with the conflict flagged for review.
+func (s *PosSyncService) UploadTransactions(ctx context.Context, terminalID uuid.ID, sales []*PosOfflineSale, clientVersion string) ([]*entities.PosSyncUpload, error) {
+	if len(sales) == 0 {
+		return nil, fmt.Errorf("%w: no transactions to upload", entities.ErrInvalidPosSync)
+	}
+	if len(sales) > posSyncMaxBatch {
+		return nil, fmt.Errorf("%w: at most %d transactions can be uploaded at once", entities.ErrInvalidPosSync, posSyncMaxBatch)
+	}
+	terminal, err := s.activeTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*entities.PosSyncUpload, 0, len(sales))
+	for _, sale := range sales {
+		result, err := s.uploadTransaction(ctx, terminal, sale)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if err := s.repo.RecordUpload(ctx, terminalID, clientVersion); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *PosSyncService) uploadTransaction(ctx context.Context, terminal *entities.PosTerminal, sale *PosOfflineSale) (*entities.PosSyncUpload, error) {
+	pt := sale.Transaction
+	if pt == nil || pt.ID.IsNil() {
+		return nil, fmt.Errorf("%w: every transaction needs its client transaction ID", entities.ErrInvalidPosSync)
+	}
+	previous, err := s.repo.GetUpload(ctx, pt.ID)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.Status == entities.PosUploadAccepted {
+		return previous, nil
+	}
+
+	upload := &entities.PosSyncUpload{
+		ClientTransactionID: pt.ID,
+		TerminalID:          terminal.ID,
+		ReceiptNumber:       pt.ReceiptNumber,
+		SoldAt:              pt.TransactionDate,
+		ReceivedAt:          utils.Now(),
+	}
+
+	// A sale booked by an attempt whose result was lost is not booked again
+	existing, err := s.transactions.GetPosTransactionByID(ctx, pt.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		upload.Status = entities.PosUploadAccepted
+		upload.ReceiptNumber = existing.ReceiptNumber
+		if previous != nil {
+			upload.Conflicts = previous.Conflicts
+		}
+		return upload, s.repo.SaveUpload(ctx, upload)
+	}
+
+	pt.TerminalID = terminal.ID.String()
+	conflicts, err := s.findConflicts(ctx, terminal.WarehouseID, pt, sale.Items)
+	if err != nil {
+		return nil, err
+	}
+	shift, err := s.transactions.ImportOfflineTransaction(ctx, pt, sale.Items)
+	if err != nil {
+		upload.Status = entities.PosUploadRejected
+		upload.Error = err.Error()
+	} else {
+		// The shift's Z report was printed without the sale
+		if shift.Status == entities.PosShiftClosed {
+			conflicts = append(conflicts, entities.PosSyncConflict{Type: entities.PosConflictShiftClosed, ShiftID: shift.ID.String()})
+		}
+		upload.Status = entities.PosUploadAccepted
+		upload.ReceiptNumber = pt.ReceiptNumber
+		upload.Conflicts = conflicts
+	}
+	return upload, s.repo.SaveUpload(ctx, upload)
+}
+
+// findConflicts compares the lines of an offline sale with the current prices and with
+// the stock of the store's warehouse.
+func (s *PosSyncService) findConflicts(ctx context.Context, warehouseID uuid.ID, pt *entities.PosTransaction, items []*entities.PosItem) (entities.PosSyncConflicts, error) {
+	var conflicts entities.PosSyncConflicts
+	quantities := make(map[uuid.ID]int)
+	var articles []uuid.ID
+	for _, item := range items {
+		if item.Voided {
+			continue
+		}
+		if _, seen := quantities[item.ArticleID]; !seen {
+			articles = append(articles, item.ArticleID)
+		}
+		quantities[item.ArticleID] += item.Quantity
+
+		price, found, err := s.repo.CurrentPrice(ctx, item.ArticleID, pt.TransactionDate)
+		if err != nil {
+			return nil, err
+		}
+		offline := item.ListPrice
+		if offline == 0 {
+			offline = item.UnitPrice
+		}
+		if found && math.Abs(price-offline) > moneyTolerance {
+			conflicts = append(conflicts, entities.PosSyncConflict{
+				Type:         entities.PosConflictPrice,
+				ArticleID:    item.ArticleID.String(),
+				OfflinePrice: offline,
+				CurrentPrice: price,
+			})
+		}
+	}
+
+	if s.stock == nil {
+		return conflicts, nil
+	}
+	for _, articleID := range articles {
+		balance, err := s.stock.GetStockBalance(ctx, articleID, warehouseID)
+		if err != nil {
+			return nil, err
+		}
+		available := 0
+		if balance != nil {
+			available = balance.Quantity
+		}
+		if available < quantities[articleID] {
+			conflicts = append(conflicts, entities.PosSyncConflict{
+				Type:      entities.PosConflictStock,
+				ArticleID: articleID.String(),
+				Quantity:  quantities[articleID],
+				Available: available,
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+// ListUploads lists uploaded offline transactions newest first, optionally filtered by
+// terminal and status.
+func (s *PosSyncService) ListUploads(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosSyncUpload, int, error) {
+	return s.repo.ListUploads(ctx, terminalID, status, limit, offset)
+}
+
+// GetSyncStatuses returns every terminal's sync status with the number of catalog
+// changes it has not downloaded yet, most behind first.
+func (s *PosSyncService) GetSyncStatuses(ctx context.Context) ([]*entities.PosTerminalSyncStatus, error) {
+	latest, err := s.repo.LatestVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := s.repo.GetStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		status.VersionsBehind = latest - status.LastDownloadVersion
+		if status.VersionsBehind < 0 {
+			status.VersionsBehind = 0
+		}
+	}
+	return statuses, nil
+}
+
+func (s *PosSyncService) activeTerminal(ctx context.Context, terminalID uuid.ID) (*entities.PosTerminal, error) {
+	terminal, err := s.shifts.GetTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if !terminal.IsActive {
+		return nil, entities.ErrPosTerminalInactive
+	}
+	return terminal, nil
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	inventory_entities "malaka/internal/modules/inventory/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockPosSyncRepository is a mock implementation of repositories.PosSyncRepository.
+type MockPosSyncRepository struct {
+	mock.Mock
+}
+
+func (m *MockPosSyncRepository) GetChanges(ctx context.Context, since int64, limit int) ([]*entities.PosSyncChange, error) {
+	args := m.Called(ctx, since, limit)
+	return args.Get(0).([]*entities.PosSyncChange), args.Error(1)
+}
+
+func (m *MockPosSyncRepository) LatestVersion(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPosSyncRepository) GetArticles(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogArticle, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*entities.PosCatalogArticle), args.Error(1)
+}
+
+func (m *MockPosSyncRepository) GetPrices(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogPrice, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*entities.PosCatalogPrice), args.Error(1)
+}
+
+func (m *MockPosSyncRepository) GetBarcodes(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogBarcode, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*entities.PosCatalogBarcode), args.Error(1)
+}
+
+func (m *MockPosSyncRepository) GetPromotions(ctx context.Context, ids []uuid.ID) ([]*entities.Promotion, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*entities.Promotion), args.Error(1)
+}
+
+func (m *MockPosSyncRepository) CurrentPrice(ctx context.Context, articleID uuid.ID, at time.Time) (float64, bool, error) {
+	args := m.Called(ctx, articleID, at)
+	return args.Get(0).(float64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockPosSyncRepository) GetUpload(ctx context.Context, clientTransactionID uuid.ID) (*entities.PosSyncUpload, error) {
+	args := m.Called(ctx, clientTransactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PosSyncUpload), args.Error(1)
+}
+
+func (m *MockPosSyncRepository) SaveUpload(ctx context.Context, upload *entities.PosSyncUpload) error {
+	args := m.Called(ctx, upload)
+	return args.Error(0)
+}
+
+func (m *MockPosSyncRepository) ListUploads(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosSyncUpload, int, error) {
+	args := m.Called(ctx, terminalID, status, limit, offset)
+	return args.Get(0).([]*entities.PosSyncUpload), args.Int(1), args.Error(2)
+}
+
+func (m *MockPosSyncRepository) RecordDownload(ctx context.Context, terminalID uuid.ID, version int64, clientVersion string) error {
+	args := m.Called(ctx, terminalID, version, clientVersion)
+	return args.Error(0)
+}
+
+func (m *MockPosSyncRepository) RecordUpload(ctx context.Context, terminalID uuid.ID, clientVersion string) error {
+	args := m.Called(ctx, terminalID, clientVersion)
+	return args.Error(0)
+}
+
+func (m *MockPosSyncRepository) GetStatuses(ctx context.Context) ([]*entities.PosTerminalSyncStatus, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.PosTerminalSyncStatus), args.Error(1)
+}
+
+// MockStockLookup is a mock implementation of StockLookup.
+type MockStockLookup struct {
+	mock.Mock
+}
+
+func (m *MockStockLookup) GetStockBalance(ctx context.Context, articleID, warehouseID uuid.ID) (*inventory_entities.StockBalance, error) {
+	args := m.Called(ctx, articleID, warehouseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventory_entities.StockBalance), args.Error(1)
+}
+
+func testSyncChange(version int64, id uuid.ID) *entities.PosSyncChange {
+	return &entities.PosSyncChange{Version: version, EntityType: entities.PosSyncArticle, EntityID: id, Operation: entities.PosSyncUpsert}
+}
+
+func testCatalogArticles(ids ...uuid.ID) []*entities.PosCatalogArticle {
+	articles := []*entities.PosCatalogArticle{}
+	for _, id := range ids {
+		article := &entities.PosCatalogArticle{}
+		article.ID = id
+		articles = append(articles, article)
+	}
+	return articles
+}
+
+func TestLatestChanges(t *testing.T) {
+	shoe, sandal, price := uuid.New(), uuid.New(), uuid.New()
+	changes := []*entities.PosSyncChange{
+		{Version: 1, EntityType: entities.PosSyncArticle, EntityID: shoe, Operation: entities.PosSyncUpsert},
+		{Version: 2, EntityType: entities.PosSyncArticle, EntityID: sandal, Operation: entities.PosSyncUpsert},
+		{Version: 3, EntityType: entities.PosSyncPrice, EntityID: price, Operation: entities.PosSyncUpsert},
+		{Version: 4, EntityType: entities.PosSyncArticle, EntityID: shoe, Operation: entities.PosSyncUpsert},
+		{Version: 5, EntityType: entities.PosSyncArticle, EntityID: sandal, Operation: entities.PosSyncDelete},
+	}
+
+	upserts, deletions := latestChanges(changes)
+	assert.Equal(t, []uuid.ID{shoe}, upserts[entities.PosSyncArticle])
+	assert.Equal(t, []uuid.ID{price}, upserts[entities.PosSyncPrice])
+	assert.Equal(t, []entities.PosSyncDeletion{{EntityType: entities.PosSyncArticle, EntityID: sandal}}, deletions)
+}
+
+func TestPosSyncService_DownloadCatalog(t *testing.T) {
+	shifts, terminals, _, terminal := newTestPosShiftService()
+	repo := new(MockPosSyncRepository)
+	svc := NewPosSyncService(repo, shifts, nil, nil)
+	ctx := context.Background()
+	terminals.On("GetByID", ctx, terminal.ID).Return(terminal, nil).Times(4)
+	repo.On("GetPrices", ctx, mock.Anything).Return([]*entities.PosCatalogPrice{}, nil).Times(3)
+	repo.On("GetBarcodes", ctx, mock.Anything).Return([]*entities.PosCatalogBarcode{}, nil).Times(3)
+	repo.On("GetPromotions", ctx, mock.Anything).Return([]*entities.Promotion{}, nil).Times(3)
+
+	// One more change than the page is read to tell whether more are waiting
+	first, second := uuid.New(), uuid.New()
+	repo.On("GetChanges", ctx, int64(0), 3).Return([]*entities.PosSyncChange{
+		testSyncChange(1, first), testSyncChange(2, second), testSyncChange(3, first),
+	}, nil).Once()
+	repo.On("GetArticles", ctx, []uuid.ID{first, second}).Return(testCatalogArticles(first, second), nil).Once()
+	repo.On("RecordDownload", ctx, terminal.ID, int64(2), "1.4.0").Return(nil).Once()
+
+	delta, err := svc.DownloadCatalog(ctx, terminal.ID, 0, 2, "1.4.0")
+	require.NoError(t, err)
+	assert.True(t, delta.HasMore)
+	assert.Equal(t, int64(2), delta.Cursor)
+	assert.Len(t, delta.Articles, 2)
+
+	repo.On("GetChanges", ctx, int64(2), 3).Return([]*entities.PosSyncChange{testSyncChange(3, first)}, nil).Once()
+	repo.On("GetArticles", ctx, []uuid.ID{first}).Return(testCatalogArticles(first), nil).Once()
+	repo.On("RecordDownload", ctx, terminal.ID, int64(3), "1.4.0").Return(nil).Once()
+
+	delta, err = svc.DownloadCatalog(ctx, terminal.ID, delta.Cursor, 2, "1.4.0")
+	require.NoError(t, err)
+	assert.False(t, delta.HasMore)
+	assert.Equal(t, int64(3), delta.Cursor)
+	require.Len(t, delta.Articles, 1)
+	assert.Equal(t, first, delta.Articles[0].ID)
+
+	// Nothing changed since: the cursor stays where it is
+	repo.On("GetChanges", ctx, int64(3), posSyncDefaultLimit+1).Return([]*entities.PosSyncChange{}, nil).Once()
+	repo.On("GetArticles", ctx, []uuid.ID(nil)).Return([]*entities.PosCatalogArticle{}, nil).Once()
+	repo.On("RecordDownload", ctx, terminal.ID, int64(3), "").Return(nil).Once()
+
+	delta, err = svc.DownloadCatalog(ctx, terminal.ID, delta.Cursor, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), delta.Cursor)
+	assert.Empty(t, delta.Articles)
+
+	_, err = svc.DownloadCatalog(ctx, terminal.ID, -1, 0, "")
+	assert.ErrorIs(t, err, entities.ErrInvalidPosSync)
+
+	terminal.IsActive = false
+	_, err = svc.DownloadCatalog(ctx, terminal.ID, 0, 0, "")
+	assert.ErrorIs(t, err, entities.ErrPosTerminalInactive)
+	terminals.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestPosSyncService_FindConflicts(t *testing.T) {
+	repo := new(MockPosSyncRepository)
+	stock := new(MockStockLookup)
+	svc := NewPosSyncService(repo, nil, nil, stock)
+	ctx := context.Background()
+	warehouseID := uuid.New()
+	shoe, sandal, sock := uuid.New(), uuid.New(), uuid.New()
+
+	pt := &entities.PosTransaction{TransactionDate: time.Now()}
+	repo.On("CurrentPrice", ctx, shoe, pt.TransactionDate).Return(450000.0, true, nil).Once()
+	repo.On("CurrentPrice", ctx, sandal, pt.TransactionDate).Return(150000.0, true, nil).Twice()
+	repo.On("CurrentPrice", ctx, sock, pt.TransactionDate).Return(25000.0, true, nil).Once()
+	stock.On("GetStockBalance", ctx, shoe, warehouseID).Return(&inventory_entities.StockBalance{ArticleID: shoe, WarehouseID: warehouseID, Quantity: 5}, nil).Once()
+	stock.On("GetStockBalance", ctx, sandal, warehouseID).Return(&inventory_entities.StockBalance{ArticleID: sandal, WarehouseID: warehouseID, Quantity: 1}, nil).Once()
+	stock.On("GetStockBalance", ctx, sock, warehouseID).Return(nil, nil).Once()
+
+	items := []*entities.PosItem{
+		// Sold at the old price before the price change reached the terminal
+		{ArticleID: shoe, Quantity: 1, ListPrice: 400000, UnitPrice: 400000},
+		// Discounted by a promotion, listed at the current price
+		{ArticleID: sandal, Quantity: 1, ListPrice: 150000, UnitPrice: 120000},
+		{ArticleID: sandal, Quantity: 1, UnitPrice: 150000},
+		{ArticleID: sock, Quantity: 2, UnitPrice: 25000},
+		{ArticleID: sock, Quantity: 9, UnitPrice: 10000, Voided: true},
+	}
+
+	conflicts, err := svc.findConflicts(ctx, warehouseID, pt, items)
+	require.NoError(t, err)
+	assert.Equal(t, entities.PosSyncConflicts{
+		{Type: entities.PosConflictPrice, ArticleID: shoe.String(), OfflinePrice: 400000, CurrentPrice: 450000},
+		{Type: entities.PosConflictStock, ArticleID: sandal.String(), Quantity: 2, Available: 1},
+		{Type: entities.PosConflictStock, ArticleID: sock.String(), Quantity: 2, Available: 0},
+	}, conflicts)
+	repo.AssertExpectations(t)
+	stock.AssertExpectations(t)
+}
+
+func TestPosSyncService_UploadTransactions_AlreadyAccepted(t *testing.T) {
+	shifts, terminals, _, terminal := newTestPosShiftService()
+	repo := new(MockPosSyncRepository)
+	svc := NewPosSyncService(repo, shifts, nil, nil)
+	ctx := context.Background()
+	terminals.On("GetByID", ctx, terminal.ID).Return(terminal, nil).Twice()
+
+	pt := &entities.PosTransaction{ReceiptNumber: "JKT-01-000042"}
+	pt.ID = uuid.New()
+	accepted := &entities.PosSyncUpload{ClientTransactionID: pt.ID, TerminalID: terminal.ID, ReceiptNumber: pt.ReceiptNumber, Status: entities.PosUploadAccepted, Attempts: 1}
+	repo.On("GetUpload", ctx, pt.ID).Return(accepted, nil).Once()
+	repo.On("RecordUpload", ctx, terminal.ID, "").Return(nil).Once()
+
+	results, err := svc.UploadTransactions(ctx, terminal.ID, []*PosOfflineSale{{Transaction: pt}}, "")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Same(t, accepted, results[0])
+	repo.AssertNotCalled(t, "SaveUpload", mock.Anything, mock.Anything)
+
+	_, err = svc.UploadTransactions(ctx, terminal.ID, nil, "")
+	assert.ErrorIs(t, err, entities.ErrInvalidPosSync)
+
+	_, err = svc.UploadTransactions(ctx, terminal.ID, []*PosOfflineSale{{Transaction: &entities.PosTransaction{}}}, "")
+	assert.ErrorIs(t, err, entities.ErrInvalidPosSync)
+	terminals.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestPosSyncService_GetSyncStatuses(t *testing.T) {
+	repo := new(MockPosSyncRepository)
+	svc := NewPosSyncService(repo, nil, nil, nil)
+	ctx := context.Background()
+	repo.On("LatestVersion", ctx).Return(int64(10), nil).Once()
+	repo.On("GetStatuses", ctx).Return([]*entities.PosTerminalSyncStatus{
+		{TerminalCode: "JKT-01", LastDownloadVersion: 10},
+		{TerminalCode: "BDG-01", LastDownloadVersion: 4},
+		{TerminalCode: "SBY-01"},
+	}, nil).Once()
+
+	statuses, err := svc.GetSyncStatuses(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), statuses[0].VersionsBehind)
+	assert.Equal(t, int64(6), statuses[1].VersionsBehind)
+	assert.Equal(t, int64(10), statuses[2].VersionsBehind)
+	repo.AssertExpectations(t)
+}
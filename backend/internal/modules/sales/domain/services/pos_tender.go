@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"malaka/internal/modules/sales/domain/entities"
+)
+
+// moneyTolerance absorbs rounding when tenders are compared with the amount due.
+const moneyTolerance = 0.005
+
+// posTenderMethods are the methods a cashier can take payment with. Loyalty points are
+// redeemed with PointsRedeemed, not offered as a tender.
+var posTenderMethods = map[string]bool{
+	entities.PosPaymentCash:        true,
+	entities.PosPaymentCard:        true,
+	entities.PosPaymentQRIS:        true,
+	entities.PosPaymentEWallet:     true,
+	entities.PosPaymentTransfer:    true,
+	entities.PosPaymentGiftVoucher: true,
+}
+
+// normalizeTender lower-cases the method and checks the tender can be taken.
+func normalizeTender(tender entities.PosTender) (entities.PosTender, error) {
+	tender.PaymentMethod = strings.ToLower(strings.TrimSpace(tender.PaymentMethod))
+	tender.Reference = strings.TrimSpace(tender.Reference)
+	if tender.PaymentMethod == entities.PosPaymentLoyaltyPoints {
+		return tender, fmt.Errorf("%w: loyalty points are redeemed with points_redeemed", entities.ErrInvalidTender)
+	}
+	if !posTenderMethods[tender.PaymentMethod] {
+		return tender, fmt.Errorf("%w: unknown payment method %q", entities.ErrInvalidTender, tender.PaymentMethod)
+	}
+	if tender.Amount <= 0 {
+		return tender, fmt.Errorf("%w: %s amount must be positive", entities.ErrInvalidTender, tender.PaymentMethod)
+	}
+	if tender.PaymentMethod == entities.PosPaymentGiftVoucher && tender.Reference == "" {
+		return tender, fmt.Errorf("%w: gift voucher number is required", entities.ErrInvalidTender)
+	}
+	return tender, nil
+}
+
+// settleTenders matches the tenders offered against the amount due. Non-cash tenders are
+// taken at their amount and may not exceed what is due; cash pays the rest and any cash
+// over the rest is given back as change. All cash tenders are recorded as one payment.
+// It returns the payments and the amount tendered and the change.
+func settleTenders(due float64, tenders []entities.PosTender) ([]*entities.PosPayment, float64, float64, error) {
+	var payments []*entities.PosPayment
+	var nonCash, cash float64
+	for _, tender := range tenders {
+		tender, err := normalizeTender(tender)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if tender.PaymentMethod == entities.PosPaymentCash {
+			cash += tender.Amount
+			continue
+		}
+		nonCash += tender.Amount
+		payments = append(payments, &entities.PosPayment{
+			PaymentMethod: tender.PaymentMethod,
+			Amount:        roundMoney(tender.Amount),
+			Reference:     tender.Reference,
+		})
+	}
+	nonCash, cash = roundMoney(nonCash), roundMoney(cash)
+
+	if nonCash > due+moneyTolerance {
+		return nil, 0, 0, fmt.Errorf("%w: non-cash payments of %.2f exceed the %.2f due", entities.ErrInvalidTender, nonCash, due)
+	}
+	cashDue := roundMoney(due - nonCash)
+	if cash+moneyTolerance < cashDue {
+		return nil, 0, 0, fmt.Errorf("%w: payment is short by %.2f", entities.ErrInvalidTender, roundMoney(cashDue-cash))
+	}
+	change := 0.0
+	if cash > 0 {
+		if cashDue <= 0 {
+			return nil, 0, 0, fmt.Errorf("%w: no cash is due", entities.ErrInvalidTender)
+		}
+		change = roundMoney(cash - cashDue)
+		payments = append([]*entities.PosPayment{{
+			PaymentMethod: entities.PosPaymentCash,
+			Amount:        cashDue,
+			Tendered:      cash,
+			Change:        change,
+		}}, payments...)
+	}
+	return payments, roundMoney(nonCash + cash), change, nil
+}
+
+// payoutTenders records how a refund is paid back to the customer. The tenders must add
+// up to the amount; without tenders the refund is paid in cash. Payouts are recorded as
+// negative payments.
+func payoutTenders(amount float64, tenders []entities.PosTender) ([]*entities.PosPayment, error) {
+	if len(tenders) == 0 {
+		tenders = []entities.PosTender{{PaymentMethod: entities.PosPaymentCash, Amount: amount}}
+	}
+	var payments []*entities.PosPayment
+	total := 0.0
+	for _, tender := range tenders {
+		tender, err := normalizeTender(tender)
+		if err != nil {
+			return nil, err
+		}
+		total += tender.Amount
+		payments = append(payments, &entities.PosPayment{
+			PaymentMethod: tender.PaymentMethod,
+			Amount:        -roundMoney(tender.Amount),
+			Reference:     tender.Reference,
+		})
+	}
+	if diff := roundMoney(total - amount); diff > moneyTolerance || diff < -moneyTolerance {
+		return nil, fmt.Errorf("%w: refund payments of %.2f do not match the %.2f to refund", entities.ErrInvalidTender, roundMoney(total), amount)
+	}
+	return payments, nil
+}
+
+// tenderMethodOf names the payment method of a sale from the tenders offered, before
+// they are settled, so promotions can be conditioned on how the customer pays.
+func tenderMethodOf(pt *entities.PosTransaction) string {
+	if len(pt.Tenders) == 0 {
+		if method := strings.ToLower(strings.TrimSpace(pt.PaymentMethod)); method != "" {
+			return method
+		}
+		return entities.PosPaymentCash
+	}
+	payments := make([]*entities.PosPayment, len(pt.Tenders))
+	for i, tender := range pt.Tenders {
+		payments[i] = &entities.PosPayment{PaymentMethod: strings.ToLower(strings.TrimSpace(tender.PaymentMethod))}
+	}
+	return paymentMethodOf(payments, entities.PosPaymentCash)
+}
+
+// paymentMethodOf names the payment method of a transaction from its payments.
+func paymentMethodOf(payments []*entities.PosPayment, fallback string) string {
+	method := ""
+	for _, payment := range payments {
+		if method != "" && method != payment.PaymentMethod {
+			return entities.PosPaymentSplit
+		}
+		method = payment.PaymentMethod
+	}
+	if method == "" {
+		return fallback
+	}
+	return method
+}
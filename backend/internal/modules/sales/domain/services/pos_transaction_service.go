@@ -3,40 +3,302 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	accounting_services "malaka/internal/modules/accounting/domain/services"
 	inventory_entities "malaka/internal/modules/inventory/domain/entities"
 	inventory_services "malaka/internal/modules/inventory/domain/services"
+	masterdata_entities "malaka/internal/modules/masterdata/domain/entities"
 	"malaka/internal/modules/sales/domain/entities"
 	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/auth"
 	"malaka/internal/shared/utils"
 	"malaka/internal/shared/uuid"
 )
 
-// Main warehouse ID - Gudang Pusat Jakarta
-var mainWarehouseID = uuid.MustParse("815eefd0-2291-44ac-9f9f-a2c932818315")
+// Journals posted for POS transactions through the auto-journal account mappings.
+const (
+	posJournalSource = "POS"
+	posJournalSale   = "POS_SALE"
+	posJournalReturn = "POS_RETURN"
+)
+
+// posOverridePermission lets a user approve voids on any till.
+const posOverridePermission = "sales.pos-transaction.override"
+
+// CredentialVerifier checks the email and password a supervisor enters at the till.
+type CredentialVerifier interface {
+	VerifyCredentials(ctx context.Context, email, password string) (*masterdata_entities.User, error)
+}
+
+// PermissionLookup returns the permissions of a user.
+type PermissionLookup interface {
+	GetUserPermissions(ctx context.Context, userID string) (*auth.UserPermissionSet, error)
+}
+
+// JournalPoster posts a journal for a transaction through the account mappings.
+type JournalPoster interface {
+	CreateJournalFromTransaction(ctx context.Context, req *accounting_services.AutoJournalRequest) (*accounting_entities.JournalEntry, error)
+}
 
 // PosTransactionService provides business logic for POS transactions.
 type PosTransactionService struct {
 	repo         repositories.PosTransactionRepository
 	itemRepo     repositories.PosItemRepository
 	stockService *inventory_services.StockService
+	shifts       *PosShiftService
+	promotions   *PromotionService
+	loyalty      *LoyaltyService
+	credentials  CredentialVerifier
+	permissions  PermissionLookup
+	journals     JournalPoster
+	taxes        TaxCalculator
 }
 
 // NewPosTransactionService creates a new PosTransactionService.
-func NewPosTransactionService(repo repositories.PosTransactionRepository, itemRepo repositories.PosItemRepository, stockService *inventory_services.StockService) *PosTransactionService {
+func NewPosTransactionService(repo repositories.PosTransactionRepository, itemRepo repositories.PosItemRepository, stockService *inventory_services.StockService, shifts *PosShiftService) *PosTransactionService {
 	return &PosTransactionService{
 		repo:         repo,
 		itemRepo:     itemRepo,
 		stockService: stockService,
+		shifts:       shifts,
 	}
 }
 
-// CreatePosTransaction creates a new POS transaction and records stock movements.
-func (s *PosTransactionService) CreatePosTransaction(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem) error {
+// SetPromotionService enables promotion evaluation at checkout.
+func (s *PosTransactionService) SetPromotionService(promotions *PromotionService) {
+	s.promotions = promotions
+}
+
+// SetLoyaltyService enables member pricing, points redemption and points earning at checkout.
+func (s *PosTransactionService) SetLoyaltyService(loyalty *LoyaltyService) {
+	s.loyalty = loyalty
+}
+
+// SetSupervisorOverride enables voids. Voids need a user with the override permission,
+// either the cashier or a supervisor who signs in at the till.
+func (s *PosTransactionService) SetSupervisorOverride(credentials CredentialVerifier, permissions PermissionLookup) {
+	s.credentials = credentials
+	s.permissions = permissions
+}
+
+// SetJournalPoster enables posting sales, voids and refunds to the general ledger.
+func (s *PosTransactionService) SetJournalPoster(journals JournalPoster) {
+	s.journals = journals
+}
+
+// SetTaxCalculator enables determining the tax of sales from the tax rules in place of
+// the tax amount the terminal sends.
+func (s *PosTransactionService) SetTaxCalculator(taxes TaxCalculator) {
+	s.taxes = taxes
+}
+
+// CreatePosTransaction creates a new POS transaction in the cashier's open shift on the
+// terminal and takes the stock out of the terminal's warehouse. Member prices and
+// active promotions are applied to the items and loyalty points are redeemed before
+// the transaction is saved; points are earned once it is saved. Lines voided before
+// payment need a supervisor override and are kept on the receipt but not sold. The
+// tenders must cover the total; cash over the total is given back as change.
+func (s *PosTransactionService) CreatePosTransaction(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem, override *entities.SupervisorOverride) (err error) {
 	if pt.ID.IsNil() {
 		pt.ID = uuid.New() // Generate a UUID v7
 	}
 
+	terminalID, err := uuid.Parse(pt.TerminalID)
+	if err != nil {
+		return fmt.Errorf("%w: a valid POS terminal is required", entities.ErrInvalidPosShift)
+	}
+	shift, err := s.shifts.ShiftForSale(ctx, terminalID, pt.CashierID)
+	if err != nil {
+		return err
+	}
+	pt.ShiftID = shift.ID.String()
+	if pt.Location == "" {
+		pt.Location = shift.StoreName
+	}
+
+	var voided []*entities.PosItem
+	sold := make([]*entities.PosItem, 0, len(items))
+	for _, item := range items {
+		if item.Voided {
+			voided = append(voided, item)
+		} else {
+			sold = append(sold, item)
+		}
+	}
+	if len(sold) == 0 {
+		return fmt.Errorf("%w: every line of the sale is voided", entities.ErrInvalidPosVoid)
+	}
+	if len(voided) > 0 {
+		approver, err := s.authorizeOverride(ctx, pt.CashierID.String(), override)
+		if err != nil {
+			return err
+		}
+		for _, item := range voided {
+			item.VoidApprovedBy = approver
+		}
+	}
+
+	var member *entities.LoyaltyMember
+	if s.loyalty != nil && (pt.MemberID != "" || pt.MemberIdentifier != "") {
+		if member, err = s.applyMemberPricing(ctx, pt, sold); err != nil {
+			return err
+		}
+	} else if pt.PointsRedeemed > 0 {
+		return fmt.Errorf("%w: a loyalty member is required", entities.ErrInvalidPointsRedemption)
+	}
+
+	if s.promotions != nil {
+		if err := s.applyPromotions(ctx, pt, sold, shift); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil && len(pt.AppliedPromotions) > 0 {
+				_ = s.promotions.Release(ctx, entities.PromotionSourcePOS, pt.ID)
+			}
+		}()
+	}
+
+	var spend float64
+	if member != nil {
+		if err := s.redeemPoints(ctx, pt, sold, member); err != nil {
+			return err
+		}
+		if pt.PointsRedeemed > 0 {
+			defer func() {
+				if err != nil {
+					_ = s.loyalty.RefundRedemption(ctx, member.ID, pt.PointsRedeemed, entities.LoyaltySourcePOS, pt.ID)
+				}
+			}()
+		}
+		if pt.PointsEarned, spend, err = s.loyalty.CalculatePoints(ctx, member, earnLines(pt, sold)); err != nil {
+			return err
+		}
+	}
+
+	if s.taxes != nil {
+		if err := s.applyTax(ctx, pt, sold); err != nil {
+			return err
+		}
+	}
+
+	if err := settlePayments(pt); err != nil {
+		return err
+	}
+	if pt.ReceiptNumber, err = s.shifts.NextReceiptNumber(ctx, terminalID); err != nil {
+		return err
+	}
+	pt.Status = entities.PosStatusCompleted
+	pt.TransactionType = entities.PosTypeSale
+	pt.PaymentStatus = "paid"
+
+	if err := s.saveSale(ctx, pt, items, shift.WarehouseID); err != nil {
+		return err
+	}
+
+	if member != nil && (pt.PointsEarned > 0 || spend > 0) {
+		if err := s.loyalty.EarnPoints(ctx, member.ID, pt.PointsEarned, spend, entities.LoyaltySourcePOS, pt.ID, pt.TotalAmount); err != nil {
+			return err
+		}
+	}
+	s.postJournal(ctx, posJournalSale, pt, pt.TransactionDate, "POS sale "+pt.ReceiptNumber, saleJournalData(pt))
+	return nil
+}
+
+// ImportOfflineTransaction books a sale a terminal made while it was offline and
+// returns the shift it was booked in. The sale is kept as the customer paid it: prices,
+// discounts and totals come from the terminal and promotions are not evaluated again.
+// It is booked in the shift the terminal recorded, even when that shift was closed
+// since. Points cannot be redeemed offline, as that needs the member's live balance,
+// but members earn points on offline sales.
+func (s *PosTransactionService) ImportOfflineTransaction(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem) (*entities.PosShift, error) {
+	terminalID, err := uuid.Parse(pt.TerminalID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: a valid POS terminal is required", entities.ErrInvalidPosShift)
+	}
+	shift, err := s.offlineShift(ctx, terminalID, pt)
+	if err != nil {
+		return nil, err
+	}
+	pt.ShiftID = shift.ID.String()
+	if pt.Location == "" {
+		pt.Location = shift.StoreName
+	}
+	if pt.PointsRedeemed > 0 {
+		return nil, fmt.Errorf("%w: points cannot be redeemed offline", entities.ErrInvalidPointsRedemption)
+	}
+
+	sold := make([]*entities.PosItem, 0, len(items))
+	for _, item := range items {
+		if !item.Voided {
+			sold = append(sold, item)
+		}
+	}
+	if len(sold) == 0 {
+		return nil, fmt.Errorf("%w: every line of the sale is voided", entities.ErrInvalidPosVoid)
+	}
+
+	var member *entities.LoyaltyMember
+	var spend float64
+	if s.loyalty != nil && (pt.MemberID != "" || pt.MemberIdentifier != "") {
+		if member, err = s.loyalty.ResolveMember(ctx, pt.MemberID, pt.MemberIdentifier); err != nil {
+			return nil, err
+		}
+		pt.MemberID = member.ID.String()
+		if pt.PointsEarned, spend, err = s.loyalty.CalculatePoints(ctx, member, earnLines(pt, sold)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := settlePayments(pt); err != nil {
+		return nil, err
+	}
+	if pt.ReceiptNumber == "" {
+		if pt.ReceiptNumber, err = s.shifts.NextReceiptNumber(ctx, terminalID); err != nil {
+			return nil, err
+		}
+	}
+	pt.Status = entities.PosStatusCompleted
+	pt.TransactionType = entities.PosTypeSale
+	pt.PaymentStatus = "paid"
+
+	if err := s.saveSale(ctx, pt, items, shift.WarehouseID); err != nil {
+		return nil, err
+	}
+	if member != nil && (pt.PointsEarned > 0 || spend > 0) {
+		if err := s.loyalty.EarnPoints(ctx, member.ID, pt.PointsEarned, spend, entities.LoyaltySourcePOS, pt.ID, pt.TotalAmount); err != nil {
+			return nil, err
+		}
+	}
+	s.postJournal(ctx, posJournalSale, pt, pt.TransactionDate, "POS sale "+pt.ReceiptNumber, saleJournalData(pt))
+	return shift, nil
+}
+
+// offlineShift returns the shift an offline sale was made in: the shift the terminal
+// recorded on the sale, open or closed, or else the open shift on the terminal.
+func (s *PosTransactionService) offlineShift(ctx context.Context, terminalID uuid.ID, pt *entities.PosTransaction) (*entities.PosShift, error) {
+	if pt.ShiftID == "" {
+		return s.shifts.ShiftForSale(ctx, terminalID, pt.CashierID)
+	}
+	shiftID, err := uuid.Parse(pt.ShiftID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid shift ID %q", entities.ErrInvalidPosShift, pt.ShiftID)
+	}
+	shift, err := s.shifts.GetShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.TerminalID != terminalID {
+		return nil, fmt.Errorf("%w: the shift belongs to another terminal", entities.ErrInvalidPosShift)
+	}
+	return shift, nil
+}
+
+// saveSale saves a sale with its lines and payments and takes the lines that were not
+// voided out of the store's warehouse.
+func (s *PosTransactionService) saveSale(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem, warehouseID uuid.ID) error {
 	// Create the POS transaction
 	if err := s.repo.Create(ctx, pt); err != nil {
 		return err
@@ -51,21 +313,231 @@ func (s *PosTransactionService) CreatePosTransaction(ctx context.Context, pt *en
 		if err := s.itemRepo.Create(ctx, item); err != nil {
 			return err
 		}
+		if item.Voided {
+			continue
+		}
 
-		// Record stock movement out of warehouse (using main warehouse)
-		outMovement := &inventory_entities.StockMovement{
-			ArticleID:    item.ArticleID,
-			WarehouseID:  mainWarehouseID,
-			Quantity:     item.Quantity,
-			MovementType: "out",
-			MovementDate: utils.Now(),
-			ReferenceID:  pt.ID,
+		// Record stock movement out of the store's warehouse
+		if err := s.moveStock(ctx, item.ArticleID, warehouseID, item.Quantity, "out", pt.ID); err != nil {
+			return err
 		}
-		if err := s.stockService.RecordStockMovement(ctx, outMovement); err != nil {
+	}
+	return s.savePayments(ctx, pt)
+}
+
+// settlePayments records the tenders of a sale. A sale without tenders is paid in full
+// with its payment method. Points redeemed as payment are recorded as a payment too.
+func settlePayments(pt *entities.PosTransaction) error {
+	due := pt.TotalAmount
+	var points *entities.PosPayment
+	if pt.PointsRedemptionMode == entities.LoyaltyRedeemAsPayment && pt.PointsValue > 0 {
+		due = roundMoney(due - pt.PointsValue)
+		points = &entities.PosPayment{PaymentMethod: entities.PosPaymentLoyaltyPoints, Amount: pt.PointsValue}
+	}
+
+	var payments []*entities.PosPayment
+	if len(pt.Tenders) > 0 {
+		var err error
+		if payments, pt.AmountTendered, pt.ChangeAmount, err = settleTenders(due, pt.Tenders); err != nil {
+			return err
+		}
+	} else if due > 0 {
+		method := strings.ToLower(strings.TrimSpace(pt.PaymentMethod))
+		if method == "" {
+			method = entities.PosPaymentCash
+		}
+		payments = []*entities.PosPayment{{PaymentMethod: method, Amount: due}}
+		pt.AmountTendered = due
+	}
+	if points != nil {
+		payments = append(payments, points)
+	}
+	pt.Payments = payments
+	pt.PaymentMethod = paymentMethodOf(payments, entities.PosPaymentCash)
+	return nil
+}
+
+// savePayments saves the payments of a transaction.
+func (s *PosTransactionService) savePayments(ctx context.Context, pt *entities.PosTransaction) error {
+	for _, payment := range pt.Payments {
+		payment.ID = uuid.New()
+		payment.PosTransactionID = pt.ID
+		payment.CreatedAt = utils.Now()
+		if err := s.repo.CreatePayment(ctx, payment); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// moveStock records an article moving in or out of a warehouse for a POS transaction.
+func (s *PosTransactionService) moveStock(ctx context.Context, articleID, warehouseID uuid.ID, quantity int, movementType string, referenceID uuid.ID) error {
+	return s.stockService.RecordStockMovement(ctx, &inventory_entities.StockMovement{
+		ArticleID:    articleID,
+		WarehouseID:  warehouseID,
+		Quantity:     quantity,
+		MovementType: movementType,
+		MovementDate: utils.Now(),
+		ReferenceID:  referenceID,
+	})
+}
+
+// applyMemberPricing resolves the loyalty member of the transaction, fills in the
+// customer details and replaces the item prices with the member's prices.
+func (s *PosTransactionService) applyMemberPricing(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem) (*entities.LoyaltyMember, error) {
+	member, err := s.loyalty.ResolveMember(ctx, pt.MemberID, pt.MemberIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	pt.MemberID = member.ID.String()
+	if pt.CustomerName == "" {
+		pt.CustomerName = member.Name
+	}
+	if pt.CustomerPhone == "" {
+		pt.CustomerPhone = member.Phone
+	}
+	if pt.CustomerID == "" {
+		pt.CustomerID = member.CustomerID
+	}
+
+	repriced := false
+	subtotal := 0.0
+	for _, item := range items {
+		item.ListPrice = item.UnitPrice
+		price, err := s.loyalty.MemberUnitPrice(ctx, member, item.ArticleID, item.ListPrice)
+		if err != nil {
+			return nil, err
+		}
+		if price < item.UnitPrice {
+			item.UnitPrice = price
+			item.TotalPrice = roundMoney(price * float64(item.Quantity))
+			repriced = true
+		}
+		subtotal += item.TotalPrice
+	}
+	if repriced {
+		pt.Subtotal = roundMoney(subtotal)
+		pt.TotalAmount = roundMoney(pt.Subtotal - pt.DiscountAmount + pt.TaxAmount)
+	}
+	return member, nil
+}
+
+// redeemPoints checks the points the member wants to redeem and takes them from the
+// member's balance. Points redeemed as a discount are spread over the items and lower
+// the total; points redeemed as payment leave the total as it is.
+func (s *PosTransactionService) redeemPoints(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem, member *entities.LoyaltyMember) error {
+	if pt.PointsRedeemed == 0 {
+		return nil
+	}
+	if pt.PointsRedemptionMode == "" {
+		pt.PointsRedemptionMode = entities.LoyaltyRedeemAsDiscount
+	}
+	if pt.PointsRedemptionMode != entities.LoyaltyRedeemAsDiscount && pt.PointsRedemptionMode != entities.LoyaltyRedeemAsPayment {
+		return fmt.Errorf("%w: unknown redemption mode %q", entities.ErrInvalidPointsRedemption, pt.PointsRedemptionMode)
+	}
+
+	value, err := s.loyalty.RedemptionValue(ctx, member, pt.PointsRedeemed, pt.TotalAmount)
+	if err != nil {
+		return err
+	}
+	if pt.PointsRedemptionMode == entities.LoyaltyRedeemAsDiscount {
+		net := make([]float64, len(items))
+		all := make([]int, len(items))
+		for i, item := range items {
+			net[i] = item.TotalPrice
+			all[i] = i
+		}
+		discounts := make([]float64, len(items))
+		spreadDiscount(discounts, value, all, net)
+		discounts = roundAllocations(discounts, value, net)
+		for i, item := range items {
+			item.DiscountAmount = roundMoney(item.DiscountAmount + discounts[i])
+			item.TotalPrice = roundMoney(item.TotalPrice - discounts[i])
+		}
+		pt.DiscountAmount = roundMoney(pt.DiscountAmount + value)
+		pt.TotalAmount = roundMoney(pt.TotalAmount - value)
+	}
+	pt.PointsValue = value
+	return s.loyalty.RedeemPoints(ctx, member.ID, pt.PointsRedeemed, value, entities.LoyaltySourcePOS, pt.ID, pt.TotalAmount)
+}
+
+// earnLines returns the item amounts that earn points. The share of the transaction
+// paid with points does not earn points.
+func earnLines(pt *entities.PosTransaction, items []*entities.PosItem) []entities.LoyaltyEarnLine {
+	paid := 1.0
+	if pt.PointsRedemptionMode == entities.LoyaltyRedeemAsPayment && pt.TotalAmount > 0 {
+		paid = 1 - pt.PointsValue/pt.TotalAmount
+	}
+	lines := make([]entities.LoyaltyEarnLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, entities.LoyaltyEarnLine{
+			ArticleID: item.ArticleID.String(),
+			Amount:    roundMoney(item.TotalPrice * paid),
+		})
+	}
+	return lines
+}
+
+// applyPromotions runs the promotion engine over the items sold at the store (the
+// shift's warehouse, on the store's clock), allocates the discounts to the lines and
+// redeems the applied promotions. The tax is scaled down with the discounted base.
+func (s *PosTransactionService) applyPromotions(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem, shift *entities.PosShift) error {
+	cart := &entities.PromotionCart{
+		CustomerID:    pt.CustomerID,
+		StoreID:       shift.WarehouseID.String(),
+		PaymentMethod: tenderMethodOf(pt),
+		VoucherCodes:  pt.VoucherCodes,
+		At:            pt.TransactionDate,
+		Timezone:      shift.Timezone,
+	}
+	for _, item := range items {
+		cart.Lines = append(cart.Lines, entities.PromotionCartLine{
+			ArticleID: item.ArticleID.String(),
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	eval, err := s.promotions.Checkout(ctx, cart, entities.PromotionSourcePOS, pt.ID)
+	if err != nil {
+		return err
+	}
+	if len(eval.Applied) == 0 {
+		return nil
+	}
+
+	for i, item := range items {
+		item.DiscountAmount = eval.Lines[i].Discount
+		item.TotalPrice = eval.Lines[i].Net
+	}
+	// The terminal taxed the undiscounted lines
+	if eval.Subtotal > 0 {
+		pt.TaxAmount = roundMoney(pt.TaxAmount * eval.Total / eval.Subtotal)
+	}
+	pt.Subtotal = eval.Subtotal
+	pt.DiscountAmount = eval.TotalDiscount
+	pt.TotalAmount = roundMoney(eval.Total + pt.TaxAmount)
+	pt.AppliedPromotions = eval.Applied
+	return nil
+}
 
+// applyTax sets the tax of the sale to the tax the rules determine on the lines net of
+// their discounts.
+func (s *PosTransactionService) applyTax(ctx context.Context, pt *entities.PosTransaction, items []*entities.PosItem) error {
+	date := pt.TransactionDate
+	if date.IsZero() {
+		date = utils.Now()
+	}
+	lines := make([]accounting_entities.TaxCalculationLineInput, len(items))
+	for i, item := range items {
+		lines[i] = accounting_entities.TaxCalculationLineInput{ArticleID: item.ArticleID, Amount: item.TotalPrice}
+	}
+	calc, err := calculateTax(ctx, s.taxes, accounting_entities.TaxTransactionPOS, pt.CustomerID, date, lines)
+	if err != nil {
+		return err
+	}
+	pt.TaxAmount = calc.TaxAmount
+	pt.TotalAmount = roundMoney(pt.Subtotal - pt.DiscountAmount + pt.TaxAmount)
 	return nil
 }
 
@@ -98,9 +570,25 @@ func (s *PosTransactionService) GetPosTransactionByIDWithItems(ctx context.Conte
 	if err != nil {
 		return nil, err
 	}
+	if pt.Payments, err = s.repo.GetPayments(ctx, id); err != nil {
+		return nil, err
+	}
 	return &PosTransactionDetail{PosTransaction: pt, Items: items}, nil
 }
 
+// GetPosTransactionByReceiptNumber retrieves a transaction with its line items by its
+// receipt number.
+func (s *PosTransactionService) GetPosTransactionByReceiptNumber(ctx context.Context, receiptNumber string) (*PosTransactionDetail, error) {
+	pt, err := s.repo.GetByReceiptNumber(ctx, strings.TrimSpace(receiptNumber))
+	if err != nil {
+		return nil, err
+	}
+	if pt == nil {
+		return nil, nil
+	}
+	return s.GetPosTransactionByIDWithItems(ctx, pt.ID)
+}
+
 // UpdatePosTransaction updates an existing POS transaction.
 func (s *PosTransactionService) UpdatePosTransaction(ctx context.Context, pt *entities.PosTransaction) error {
 	// Ensure the POS transaction exists before updating
@@ -114,7 +602,8 @@ func (s *PosTransactionService) UpdatePosTransaction(ctx context.Context, pt *en
 	return s.repo.Update(ctx, pt)
 }
 
-// DeletePosTransaction deletes a POS transaction by its ID.
+// DeletePosTransaction deletes a POS transaction by its ID. Completed and voided
+// transactions are kept; they are voided or refunded instead.
 func (s *PosTransactionService) DeletePosTransaction(ctx context.Context, id uuid.ID) error {
 	// Ensure the POS transaction exists before deleting
 	existingPT, err := s.repo.GetByID(ctx, id)
@@ -124,5 +613,8 @@ func (s *PosTransactionService) DeletePosTransaction(ctx context.Context, id uui
 	if existingPT == nil {
 		return errors.New("POS transaction not found")
 	}
+	if existingPT.Status == entities.PosStatusCompleted || existingPT.Status == entities.PosStatusVoided {
+		return entities.ErrPosTransactionLocked
+	}
 	return s.repo.Delete(ctx, id)
 }
@@ -1,9 +1,402 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	masterdata_entities "malaka/internal/modules/masterdata/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/auth"
+	"malaka/internal/shared/uuid"
 )
 
 func TestPosTransactionService(t *testing.T) {
 	// Placeholder for POS transaction service tests
 }
+
+// MockCredentialVerifier is a mock implementation of CredentialVerifier.
+type MockCredentialVerifier struct {
+	mock.Mock
+}
+
+func (m *MockCredentialVerifier) VerifyCredentials(ctx context.Context, email, password string) (*masterdata_entities.User, error) {
+	args := m.Called(ctx, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*masterdata_entities.User), args.Error(1)
+}
+
+// MockPermissionLookup is a mock implementation of PermissionLookup.
+type MockPermissionLookup struct {
+	mock.Mock
+}
+
+func (m *MockPermissionLookup) GetUserPermissions(ctx context.Context, userID string) (*auth.UserPermissionSet, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.UserPermissionSet), args.Error(1)
+}
+
+func testPermissionSet(userID string, codes ...string) *auth.UserPermissionSet {
+	ps := auth.NewUserPermissionSet(userID)
+	for _, code := range codes {
+		ps.Permissions[code] = true
+	}
+	return ps
+}
+
+func TestSettleTenders(t *testing.T) {
+	t.Run("cash gives change", func(t *testing.T) {
+		payments, tendered, change, err := settleTenders(85000, []entities.PosTender{{PaymentMethod: "cash", Amount: 100000}})
+		require.NoError(t, err)
+		require.Len(t, payments, 1)
+		assert.Equal(t, 85000.0, payments[0].Amount)
+		assert.Equal(t, 100000.0, payments[0].Tendered)
+		assert.Equal(t, 15000.0, payments[0].Change)
+		assert.Equal(t, 100000.0, tendered)
+		assert.Equal(t, 15000.0, change)
+	})
+
+	t.Run("split over card, QRIS and cash", func(t *testing.T) {
+		payments, tendered, change, err := settleTenders(250000, []entities.PosTender{
+			{PaymentMethod: "card", Amount: 150000, Reference: "APR123"},
+			{PaymentMethod: "QRIS", Amount: 50000},
+			{PaymentMethod: "cash", Amount: 20000},
+			{PaymentMethod: "cash", Amount: 50000},
+		})
+		require.NoError(t, err)
+		require.Len(t, payments, 3)
+		assert.Equal(t, entities.PosPaymentCash, payments[0].PaymentMethod)
+		assert.Equal(t, 50000.0, payments[0].Amount)
+		assert.Equal(t, 20000.0, payments[0].Change)
+		assert.Equal(t, entities.PosPaymentQRIS, payments[2].PaymentMethod)
+		assert.Equal(t, 270000.0, tendered)
+		assert.Equal(t, 20000.0, change)
+		assert.Equal(t, entities.PosPaymentSplit, paymentMethodOf(payments, ""))
+	})
+
+	t.Run("short payment", func(t *testing.T) {
+		_, _, _, err := settleTenders(100000, []entities.PosTender{{PaymentMethod: "card", Amount: 60000}, {PaymentMethod: "cash", Amount: 30000}})
+		assert.ErrorIs(t, err, entities.ErrInvalidTender)
+	})
+
+	t.Run("card cannot be overpaid", func(t *testing.T) {
+		_, _, _, err := settleTenders(100000, []entities.PosTender{{PaymentMethod: "card", Amount: 120000}})
+		assert.ErrorIs(t, err, entities.ErrInvalidTender)
+	})
+
+	t.Run("gift voucher needs its number", func(t *testing.T) {
+		_, _, _, err := settleTenders(100000, []entities.PosTender{{PaymentMethod: "gift_voucher", Amount: 100000}})
+		assert.ErrorIs(t, err, entities.ErrInvalidTender)
+	})
+
+	t.Run("loyalty points are not a tender", func(t *testing.T) {
+		_, _, _, err := settleTenders(100000, []entities.PosTender{{PaymentMethod: "loyalty_points", Amount: 100000}})
+		assert.ErrorIs(t, err, entities.ErrInvalidTender)
+	})
+}
+
+func TestSettlePayments_PointsAsPayment(t *testing.T) {
+	pt := &entities.PosTransaction{
+		TotalAmount:          200000,
+		PointsRedemptionMode: entities.LoyaltyRedeemAsPayment,
+		PointsValue:          50000,
+		Tenders:              []entities.PosTender{{PaymentMethod: "e_wallet", Amount: 150000}},
+	}
+	require.NoError(t, settlePayments(pt))
+	require.Len(t, pt.Payments, 2)
+	assert.Equal(t, entities.PosPaymentLoyaltyPoints, pt.Payments[1].PaymentMethod)
+	assert.Equal(t, 50000.0, pt.Payments[1].Amount)
+	assert.Equal(t, entities.PosPaymentSplit, pt.PaymentMethod)
+
+	legacy := &entities.PosTransaction{TotalAmount: 75000, PaymentMethod: "Card"}
+	require.NoError(t, settlePayments(legacy))
+	require.Len(t, legacy.Payments, 1)
+	assert.Equal(t, "card", legacy.PaymentMethod)
+	assert.Equal(t, 75000.0, legacy.Payments[0].Amount)
+}
+
+func TestPayoutTenders(t *testing.T) {
+	payments, err := payoutTenders(40000, nil)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, entities.PosPaymentCash, payments[0].PaymentMethod)
+	assert.Equal(t, -40000.0, payments[0].Amount)
+
+	_, err = payoutTenders(40000, []entities.PosTender{{PaymentMethod: "card", Amount: 30000}})
+	assert.ErrorIs(t, err, entities.ErrInvalidTender)
+}
+
+func TestReturnLines(t *testing.T) {
+	shirt := &entities.PosItem{ArticleID: uuid.New(), Quantity: 2, UnitPrice: 50000, TotalPrice: 100000}
+	shirt.ID = uuid.New()
+	jeans := &entities.PosItem{ArticleID: uuid.New(), Quantity: 1, UnitPrice: 100000, TotalPrice: 100000, ReturnedQuantity: 1}
+	jeans.ID = uuid.New()
+	scannedByMistake := &entities.PosItem{ArticleID: uuid.New(), Quantity: 1, UnitPrice: 30000, TotalPrice: 30000, Voided: true}
+	scannedByMistake.ID = uuid.New()
+	sold := []*entities.PosItem{shirt, jeans, scannedByMistake}
+
+	original := &entities.PosTransaction{
+		ReceiptNumber:        "JKT-01-000001",
+		TransactionType:      entities.PosTypeSale,
+		TotalAmount:          220000,
+		TaxAmount:            20000,
+		PointsRedemptionMode: entities.LoyaltyRedeemAsPayment,
+		PointsValue:          22000,
+	}
+
+	returned, value, err := returnLines(original, sold, []PosReturnLine{{ItemID: shirt.ID, Quantity: 1}})
+	require.NoError(t, err)
+	require.Len(t, returned, 1)
+	assert.Equal(t, -1, returned[0].Quantity)
+	assert.Equal(t, -50000.0, returned[0].TotalPrice)
+	assert.Equal(t, shirt.ID.String(), returned[0].OriginalItemID)
+	assert.Equal(t, 50000.0, value.Net)
+	assert.Equal(t, 55000.0, value.Gross)
+	assert.Equal(t, 5000.0, value.Tax)
+	assert.Equal(t, 5500.0, value.Points)
+
+	_, _, err = returnLines(original, sold, []PosReturnLine{{ItemID: shirt.ID, Quantity: 1}, {ItemID: shirt.ID, Quantity: 2}})
+	assert.ErrorIs(t, err, entities.ErrInvalidPosRefund, "more shirts returned than sold")
+
+	_, _, err = returnLines(original, sold, []PosReturnLine{{ItemID: jeans.ID, Quantity: 1}})
+	assert.ErrorIs(t, err, entities.ErrInvalidPosRefund, "jeans were returned already")
+
+	_, _, err = returnLines(original, sold, []PosReturnLine{{ItemID: scannedByMistake.ID, Quantity: 1}})
+	assert.ErrorIs(t, err, entities.ErrInvalidPosRefund, "voided lines were not sold")
+}
+
+func TestRefundJournalData_Balances(t *testing.T) {
+	value := posReturnValue{Net: 100000, Gross: 110000, Tax: 10000, Points: 11000}
+
+	// Exchange for a cheaper item: part of the credit is paid back in cash
+	payments, err := payoutTenders(39000, nil)
+	require.NoError(t, err)
+	payments = append(payments, &entities.PosPayment{PaymentMethod: entities.PosPaymentLoyaltyPoints, Amount: -11000})
+	returnData, saleData := refundJournalData(value, 60000, payments)
+	assert.Equal(t, 60000.0, returnData["exchange_amount"])
+	assert.Equal(t, value.Gross, returnData["exchange_amount"].(float64)+returnData["cash_amount"].(float64)+returnData["loyalty_points_amount"].(float64))
+	assert.Equal(t, 100000.0, returnData["net_amount"])
+	assert.Equal(t, 60000.0, saleData["exchange_amount"])
+	assert.NotContains(t, saleData, "cash_amount")
+}
+
+func TestPosTransactionService_AuthorizeOverride(t *testing.T) {
+	ctx := context.Background()
+	cashierID := uuid.New().String()
+	supervisor := &masterdata_entities.User{Email: "spv@malaka.test"}
+	supervisor.ID = uuid.New()
+	clerk := &masterdata_entities.User{Email: "clerk@malaka.test"}
+	clerk.ID = uuid.New()
+
+	svc := &PosTransactionService{}
+	_, err := svc.authorizeOverride(ctx, cashierID, nil)
+	assert.ErrorIs(t, err, entities.ErrSupervisorOverrideRequired, "overrides not set up")
+
+	credentials := new(MockCredentialVerifier)
+	credentials.On("VerifyCredentials", ctx, supervisor.Email, "wrong").Return(nil, errors.New("invalid credentials"))
+	credentials.On("VerifyCredentials", ctx, supervisor.Email, "secret").Return(supervisor, nil)
+	credentials.On("VerifyCredentials", ctx, clerk.Email, "secret").Return(clerk, nil)
+	permissions := new(MockPermissionLookup)
+	permissions.On("GetUserPermissions", ctx, cashierID).Return(testPermissionSet(cashierID), nil)
+	permissions.On("GetUserPermissions", ctx, clerk.ID.String()).Return(testPermissionSet(clerk.ID.String()), nil)
+	permissions.On("GetUserPermissions", ctx, supervisor.ID.String()).Return(testPermissionSet(supervisor.ID.String(), posOverridePermission), nil)
+	svc.SetSupervisorOverride(credentials, permissions)
+
+	_, err = svc.authorizeOverride(ctx, cashierID, nil)
+	assert.ErrorIs(t, err, entities.ErrSupervisorOverrideRequired)
+
+	_, err = svc.authorizeOverride(ctx, cashierID, &entities.SupervisorOverride{Email: supervisor.Email, Password: "wrong"})
+	assert.ErrorIs(t, err, entities.ErrInvalidSupervisorOverride)
+
+	_, err = svc.authorizeOverride(ctx, cashierID, &entities.SupervisorOverride{Email: clerk.Email, Password: "secret"})
+	assert.ErrorIs(t, err, entities.ErrInvalidSupervisorOverride, "clerk may not approve voids")
+
+	approver, err := svc.authorizeOverride(ctx, cashierID, &entities.SupervisorOverride{Email: supervisor.Email, Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, supervisor.ID.String(), approver)
+
+	approver, err = svc.authorizeOverride(ctx, supervisor.ID.String(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, supervisor.ID.String(), approver, "supervisors approve their own voids")
+	credentials.AssertExpectations(t)
+	permissions.AssertExpectations(t)
+}
+
+// MockPromotionRepository is a mock implementation of repositories.PromotionRepository.
+type MockPromotionRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromotionRepository) Create(ctx context.Context, promo *entities.Promotion) error {
+	args := m.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) GetByID(ctx context.Context, id string) (*entities.Promotion, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetAll(ctx context.Context) ([]*entities.Promotion, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) Update(ctx context.Context, promo *entities.Promotion) error {
+	args := m.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) GetActive(ctx context.Context, at time.Time) ([]*entities.Promotion, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]*entities.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) RecordRedemption(ctx context.Context, redemption *entities.PromotionRedemption) error {
+	args := m.Called(ctx, redemption)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) ReleaseRedemptions(ctx context.Context, sourceType, sourceID string) error {
+	args := m.Called(ctx, sourceType, sourceID)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) GetRedemptions(ctx context.Context, promotionID string) ([]*entities.PromotionRedemption, error) {
+	args := m.Called(ctx, promotionID)
+	return args.Get(0).([]*entities.PromotionRedemption), args.Error(1)
+}
+
+func testPosSale() (*entities.PosTransaction, []*entities.PosItem) {
+	pt := &entities.PosTransaction{TransactionDate: promoTestNow, Location: "Grand Indonesia"}
+	pt.ID = uuid.New()
+	items := []*entities.PosItem{{ArticleID: uuid.New(), Quantity: 2, UnitPrice: 100000, TotalPrice: 200000}}
+	return pt, items
+}
+
+func TestPosTransactionService_ApplyPromotionsScopedToStore(t *testing.T) {
+	ctx := context.Background()
+	shift := &entities.PosShift{WarehouseID: uuid.New()}
+	promo := testPromotion(entities.PromotionTypePercentage)
+	promo.DiscountRate = 0.1
+	promo.Conditions.StoreIDs = []string{shift.WarehouseID.String()}
+
+	mockRepo := new(MockPromotionRepository)
+	mockRepo.On("GetActive", ctx, promoTestNow).Return([]*entities.Promotion{promo}, nil)
+	mockRepo.On("RecordRedemption", ctx, mock.AnythingOfType("*entities.PromotionRedemption")).Return(nil).Once()
+	svc := &PosTransactionService{}
+	svc.SetPromotionService(NewPromotionService(mockRepo, nil))
+
+	// The promotion is scoped to the shift's warehouse, not the free-text location
+	pt, items := testPosSale()
+	require.NoError(t, svc.applyPromotions(ctx, pt, items, shift))
+	assert.Equal(t, 20000.0, pt.DiscountAmount)
+	assert.Equal(t, 20000.0, items[0].DiscountAmount)
+	assert.Equal(t, 180000.0, items[0].TotalPrice)
+
+	pt, items = testPosSale()
+	require.NoError(t, svc.applyPromotions(ctx, pt, items, &entities.PosShift{WarehouseID: uuid.New()}))
+	assert.Zero(t, pt.DiscountAmount)
+	assert.Empty(t, pt.AppliedPromotions)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPosTransactionService_ApplyPromotionsByTenderMethod(t *testing.T) {
+	ctx := context.Background()
+	promo := testPromotion(entities.PromotionTypePercentage)
+	promo.DiscountRate = 0.1
+	promo.Conditions.PaymentMethods = []string{entities.PosPaymentCard}
+
+	mockRepo := new(MockPromotionRepository)
+	mockRepo.On("GetActive", ctx, promoTestNow).Return([]*entities.Promotion{promo}, nil)
+	mockRepo.On("RecordRedemption", ctx, mock.AnythingOfType("*entities.PromotionRedemption")).Return(nil).Once()
+	svc := &PosTransactionService{}
+	svc.SetPromotionService(NewPromotionService(mockRepo, nil))
+
+	// Split-tender sales leave payment_method empty: it comes from the tenders
+	pt, items := testPosSale()
+	pt.Tenders = []entities.PosTender{{PaymentMethod: "Card", Amount: 100000}, {PaymentMethod: "card", Amount: 80000}}
+	require.NoError(t, svc.applyPromotions(ctx, pt, items, &entities.PosShift{}))
+	assert.Equal(t, 20000.0, pt.DiscountAmount)
+
+	// Card and cash together are a split payment, not a card payment
+	pt, items = testPosSale()
+	pt.Tenders = []entities.PosTender{{PaymentMethod: "card", Amount: 100000}, {PaymentMethod: "cash", Amount: 100000}}
+	require.NoError(t, svc.applyPromotions(ctx, pt, items, &entities.PosShift{}))
+	assert.Zero(t, pt.DiscountAmount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPosTransactionService_ApplyPromotionsRecomputesTax(t *testing.T) {
+	ctx := context.Background()
+	promo := testPromotion(entities.PromotionTypePercentage)
+	promo.DiscountRate = 0.1
+
+	mockRepo := new(MockPromotionRepository)
+	mockRepo.On("GetActive", ctx, promoTestNow).Return([]*entities.Promotion{promo}, nil).Once()
+	mockRepo.On("RecordRedemption", ctx, mock.AnythingOfType("*entities.PromotionRedemption")).Return(nil).Once()
+	svc := &PosTransactionService{}
+	svc.SetPromotionService(NewPromotionService(mockRepo, nil))
+
+	// The terminal charged 11% VAT on the undiscounted 200,000
+	pt, items := testPosSale()
+	pt.Subtotal = 200000
+	pt.TaxAmount = 22000
+	pt.TotalAmount = 222000
+	require.NoError(t, svc.applyPromotions(ctx, pt, items, &entities.PosShift{}))
+	assert.Equal(t, 20000.0, pt.DiscountAmount)
+	assert.Equal(t, 19800.0, pt.TaxAmount)
+	assert.Equal(t, 199800.0, pt.TotalAmount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPosTransactionService_OfflineShift(t *testing.T) {
+	shifts, _, shiftRepo, terminal := newTestPosShiftService()
+	svc := &PosTransactionService{shifts: shifts}
+	ctx := context.Background()
+	cashierID := uuid.New()
+
+	// A sale made before the shift was closed is booked in that shift, whoever is
+	// selling on the terminal now
+	closed := testPosShift(terminal, cashierID, 0)
+	closed.Status = entities.PosShiftClosed
+	shiftRepo.On("GetByID", ctx, closed.ID).Return(closed, nil).Once()
+	pt := &entities.PosTransaction{CashierID: cashierID, ShiftID: closed.ID.String()}
+	shift, err := svc.offlineShift(ctx, terminal.ID, pt)
+	require.NoError(t, err)
+	assert.Same(t, closed, shift)
+	shiftRepo.AssertNotCalled(t, "GetOpenByTerminal", mock.Anything, mock.Anything)
+
+	// The shift must be one of the terminal's
+	other := testPosShift(terminal, cashierID, 0)
+	other.TerminalID = uuid.New()
+	shiftRepo.On("GetByID", ctx, other.ID).Return(other, nil).Once()
+	_, err = svc.offlineShift(ctx, terminal.ID, &entities.PosTransaction{CashierID: cashierID, ShiftID: other.ID.String()})
+	assert.ErrorIs(t, err, entities.ErrInvalidPosShift)
+
+	// Sales without a recorded shift go to the cashier's open shift on the terminal
+	open := testPosShift(terminal, cashierID, 0)
+	shiftRepo.On("GetOpenByTerminal", ctx, terminal.ID).Return(open, nil).Once()
+	shift, err = svc.offlineShift(ctx, terminal.ID, &entities.PosTransaction{CashierID: cashierID})
+	require.NoError(t, err)
+	assert.Same(t, open, shift)
+	shiftRepo.AssertExpectations(t)
+}
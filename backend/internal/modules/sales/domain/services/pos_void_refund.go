@@ -0,0 +1,463 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	accounting_services "malaka/internal/modules/accounting/domain/services"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// PosReturnLine is a sold line brought back on a refund or exchange.
+type PosReturnLine struct {
+	ItemID   uuid.ID
+	Quantity int
+}
+
+// PosRefundRequest is a refund or exchange against an original receipt. New items make
+// it an exchange: the customer pays the difference with Tenders or, when the returned
+// items are worth more, is paid back with Tenders. Refunds without tenders are paid in
+// cash.
+type PosRefundRequest struct {
+	ReceiptNumber string
+	TerminalID    uuid.ID
+	CashierID     uuid.ID
+	Reason        string
+	ReturnLines   []PosReturnLine
+	NewItems      []*entities.PosItem
+	Tenders       []entities.PosTender
+}
+
+// posReturnValue is what the returned lines of a receipt are worth. Gross is the amount
+// refunded, of which Tax is tax and Points was paid with loyalty points.
+type posReturnValue struct {
+	Net    float64
+	Gross  float64
+	Tax    float64
+	Points float64
+}
+
+// VoidPosTransaction voids a sale while its shift is still open, with a supervisor's
+// approval. The stock goes back to the shift's warehouse, promotions and loyalty points
+// are reversed and a return journal is posted. Sales from a closed shift are refunded
+// instead.
+func (s *PosTransactionService) VoidPosTransaction(ctx context.Context, id uuid.ID, reason, voidedBy string, override *entities.SupervisorOverride) (*entities.PosTransaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required", entities.ErrInvalidPosVoid)
+	}
+	pt, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pt == nil {
+		return nil, entities.ErrPosTransactionNotFound
+	}
+	if pt.Status == entities.PosStatusVoided {
+		return nil, entities.ErrPosTransactionVoided
+	}
+	if pt.TransactionType != entities.PosTypeSale {
+		return nil, fmt.Errorf("%w: only sales can be voided", entities.ErrInvalidPosVoid)
+	}
+	shiftID, err := uuid.Parse(pt.ShiftID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the sale was not made in a shift, refund it instead", entities.ErrInvalidPosVoid)
+	}
+	shift, err := s.shifts.GetShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.Status != entities.PosShiftOpen {
+		return nil, fmt.Errorf("%w: the shift is closed, refund the sale instead", entities.ErrInvalidPosVoid)
+	}
+	items, err := s.itemRepo.GetByPosTransactionID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ReturnedQuantity > 0 {
+			return nil, fmt.Errorf("%w: part of the sale was refunded already", entities.ErrInvalidPosVoid)
+		}
+	}
+
+	approver, err := s.authorizeOverride(ctx, voidedBy, override)
+	if err != nil {
+		return nil, err
+	}
+	now := utils.Now()
+	pt.Status = entities.PosStatusVoided
+	pt.VoidedAt = &now
+	pt.VoidedBy = voidedBy
+	pt.VoidApprovedBy = approver
+	pt.VoidReason = reason
+	if err := s.repo.Void(ctx, pt); err != nil {
+		return nil, err
+	}
+
+	// Put the stock back into the store's warehouse
+	for _, item := range items {
+		if item.Voided || item.Quantity <= 0 {
+			continue
+		}
+		if err := s.moveStock(ctx, item.ArticleID, shift.WarehouseID, item.Quantity, "in", pt.ID); err != nil {
+			return nil, err
+		}
+	}
+	if s.promotions != nil {
+		if err := s.promotions.Release(ctx, entities.PromotionSourcePOS, pt.ID); err != nil {
+			return nil, err
+		}
+	}
+	if s.loyalty != nil && pt.MemberID != "" {
+		if err := s.loyalty.ReverseForReturn(ctx, pt.ID.String(), uuid.New(), pt.TotalAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	if pt.Payments, err = s.repo.GetPayments(ctx, pt.ID); err != nil {
+		return nil, err
+	}
+	s.postJournal(ctx, posJournalReturn, pt, now, "Void of POS receipt "+pt.ReceiptNumber, saleJournalData(pt))
+	return pt, nil
+}
+
+// RefundPosTransaction refunds or exchanges items of an original receipt in the cashier's
+// open shift on the terminal. The returned items go back into the shift's warehouse and
+// the new items of an exchange go out of it, loyalty points are reversed in proportion
+// and the refund is posted to the ledger. It returns the refund receipt.
+func (s *PosTransactionService) RefundPosTransaction(ctx context.Context, req *PosRefundRequest) (detail *PosTransactionDetail, err error) {
+	if len(req.ReturnLines) == 0 {
+		return nil, fmt.Errorf("%w: no items to return", entities.ErrInvalidPosRefund)
+	}
+	original, err := s.repo.GetByReceiptNumber(ctx, strings.TrimSpace(req.ReceiptNumber))
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, entities.ErrPosTransactionNotFound
+	}
+	if original.Status == entities.PosStatusVoided {
+		return nil, entities.ErrPosTransactionVoided
+	}
+	if original.TransactionType == entities.PosTypeRefund {
+		return nil, fmt.Errorf("%w: a refund receipt cannot be refunded", entities.ErrInvalidPosRefund)
+	}
+	shift, err := s.shifts.ShiftForSale(ctx, req.TerminalID, req.CashierID)
+	if err != nil {
+		return nil, err
+	}
+	soldItems, err := s.itemRepo.GetByPosTransactionID(ctx, original.ID)
+	if err != nil {
+		return nil, err
+	}
+	returned, value, err := returnLines(original, soldItems, req.ReturnLines)
+	if err != nil {
+		return nil, err
+	}
+
+	newTotal := 0.0
+	for _, item := range req.NewItems {
+		if item.Quantity <= 0 || item.UnitPrice <= 0 {
+			return nil, fmt.Errorf("%w: exchange items need a quantity and a price", entities.ErrInvalidPosRefund)
+		}
+		if item.TotalPrice == 0 {
+			item.TotalPrice = roundMoney(item.UnitPrice * float64(item.Quantity))
+		}
+		newTotal += item.TotalPrice
+	}
+	newTotal = roundMoney(newTotal)
+
+	refund := &entities.PosTransaction{
+		TransactionDate:       utils.Now(),
+		TotalAmount:           roundMoney(newTotal - value.Gross),
+		TaxAmount:             -value.Tax,
+		CashierID:             req.CashierID,
+		CustomerID:            original.CustomerID,
+		CustomerName:          original.CustomerName,
+		CustomerPhone:         original.CustomerPhone,
+		MemberID:              original.MemberID,
+		TerminalID:            req.TerminalID.String(),
+		ShiftID:               shift.ID.String(),
+		Location:              shift.StoreName,
+		Notes:                 strings.TrimSpace(req.Reason),
+		Status:                entities.PosStatusCompleted,
+		TransactionType:       entities.PosTypeRefund,
+		OriginalTransactionID: original.ID.String(),
+		PaymentStatus:         "paid",
+	}
+	refund.ID = uuid.New()
+	refund.Subtotal = roundMoney(refund.TotalAmount - refund.TaxAmount)
+	if len(req.NewItems) > 0 {
+		refund.TransactionType = entities.PosTypeExchange
+	}
+
+	// The customer pays what the new items cost over the returned items, or is paid back
+	// what the returned items are worth over the new items
+	credit := roundMoney(value.Gross - value.Points)
+	due := roundMoney(newTotal - credit)
+	var payments []*entities.PosPayment
+	switch {
+	case due > 0:
+		if payments, refund.AmountTendered, refund.ChangeAmount, err = settleTenders(due, req.Tenders); err != nil {
+			return nil, err
+		}
+	case due < 0:
+		if payments, err = payoutTenders(-due, req.Tenders); err != nil {
+			return nil, err
+		}
+	case len(req.Tenders) > 0:
+		return nil, fmt.Errorf("%w: nothing is due", entities.ErrInvalidTender)
+	}
+	if value.Points > 0 {
+		payments = append(payments, &entities.PosPayment{PaymentMethod: entities.PosPaymentLoyaltyPoints, Amount: -value.Points})
+	}
+	refund.Payments = payments
+	refund.PaymentMethod = paymentMethodOf(payments, entities.PosPaymentCash)
+	if refund.ReceiptNumber, err = s.shifts.NextReceiptNumber(ctx, req.TerminalID); err != nil {
+		return nil, err
+	}
+
+	// Book the returned quantities on the original lines first, so the same items cannot
+	// be refunded twice. They are given back if the refund cannot be saved.
+	booked, saved := 0, false
+	defer func() {
+		if err != nil && !saved {
+			for _, item := range returned[:booked] {
+				if itemID, parseErr := uuid.Parse(item.OriginalItemID); parseErr == nil {
+					_ = s.itemRepo.AddReturnedQuantity(ctx, itemID, item.Quantity)
+				}
+			}
+		}
+	}()
+	for _, item := range returned {
+		itemID, err := uuid.Parse(item.OriginalItemID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.itemRepo.AddReturnedQuantity(ctx, itemID, -item.Quantity); err != nil {
+			return nil, err
+		}
+		booked++
+	}
+	if err := s.repo.Create(ctx, refund); err != nil {
+		return nil, err
+	}
+	saved = true
+
+	items := append(returned, req.NewItems...)
+	for _, item := range items {
+		item.PosTransactionID = refund.ID
+		if item.ID.IsNil() {
+			item.ID = uuid.New()
+		}
+		if err := s.itemRepo.Create(ctx, item); err != nil {
+			return nil, err
+		}
+		if item.Quantity < 0 {
+			err = s.moveStock(ctx, item.ArticleID, shift.WarehouseID, -item.Quantity, "in", refund.ID)
+		} else {
+			err = s.moveStock(ctx, item.ArticleID, shift.WarehouseID, item.Quantity, "out", refund.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.savePayments(ctx, refund); err != nil {
+		return nil, err
+	}
+	if s.loyalty != nil && original.MemberID != "" {
+		if err := s.loyalty.ReverseForReturn(ctx, original.ID.String(), refund.ID, value.Gross); err != nil {
+			return nil, err
+		}
+	}
+
+	returnData, saleData := refundJournalData(value, newTotal, payments)
+	description := fmt.Sprintf("POS refund %s of receipt %s", refund.ReceiptNumber, original.ReceiptNumber)
+	s.postJournal(ctx, posJournalReturn, refund, refund.TransactionDate, description, returnData)
+	if newTotal > 0 {
+		description = fmt.Sprintf("POS exchange %s of receipt %s", refund.ReceiptNumber, original.ReceiptNumber)
+		s.postJournal(ctx, posJournalSale, refund, refund.TransactionDate, description, saleData)
+	}
+	return &PosTransactionDetail{PosTransaction: refund, Items: items}, nil
+}
+
+// returnLines prices the lines returned from an original transaction. Each line is
+// refunded at what was paid for it: its net price plus its share of the tax and of any
+// other adjustment to the receipt total. The share paid with loyalty points is given
+// back as points. Return lines have negative quantities and prices.
+func returnLines(original *entities.PosTransaction, soldItems []*entities.PosItem, lines []PosReturnLine) ([]*entities.PosItem, posReturnValue, error) {
+	var value posReturnValue
+	byID := make(map[uuid.ID]*entities.PosItem, len(soldItems))
+	soldNet := 0.0
+	for _, item := range soldItems {
+		if item.Voided || item.Quantity <= 0 {
+			continue
+		}
+		byID[item.ID] = item
+		soldNet += item.TotalPrice
+	}
+
+	// Exchange receipts sell the new items at their price, without tax
+	factor := 1.0
+	if original.TransactionType != entities.PosTypeExchange && soldNet > 0 {
+		factor = original.TotalAmount / soldNet
+	}
+
+	requested := make(map[uuid.ID]int, len(lines))
+	returned := make([]*entities.PosItem, 0, len(lines))
+	for _, line := range lines {
+		item := byID[line.ItemID]
+		if item == nil {
+			return nil, value, fmt.Errorf("%w: item %s is not on receipt %s", entities.ErrInvalidPosRefund, line.ItemID, original.ReceiptNumber)
+		}
+		if line.Quantity <= 0 {
+			return nil, value, fmt.Errorf("%w: return quantity must be positive", entities.ErrInvalidPosRefund)
+		}
+		requested[item.ID] += line.Quantity
+		if left := item.Quantity - item.ReturnedQuantity; requested[item.ID] > left {
+			return nil, value, fmt.Errorf("%w: only %d of item %s can still be returned", entities.ErrInvalidPosRefund, left, item.ID)
+		}
+
+		share := float64(line.Quantity) / float64(item.Quantity)
+		net := roundMoney(item.TotalPrice * share)
+		returned = append(returned, &entities.PosItem{
+			ArticleID:      item.ArticleID,
+			Quantity:       -line.Quantity,
+			ListPrice:      item.ListPrice,
+			UnitPrice:      item.UnitPrice,
+			DiscountAmount: -roundMoney(item.DiscountAmount * share),
+			TotalPrice:     -net,
+			OriginalItemID: item.ID.String(),
+		})
+		value.Net += net
+		value.Gross += roundMoney(net * factor)
+	}
+
+	value.Net = roundMoney(value.Net)
+	value.Gross = roundMoney(value.Gross)
+	if original.TotalAmount > 0 {
+		value.Tax = roundMoney(value.Gross * original.TaxAmount / original.TotalAmount)
+		if original.PointsRedemptionMode == entities.LoyaltyRedeemAsPayment {
+			value.Points = roundMoney(value.Gross * original.PointsValue / original.TotalAmount)
+		}
+	}
+	return returned, value, nil
+}
+
+// saleJournalData returns the amounts of a sale for its journal: the total, net and tax
+// amounts, the discount and the amount taken with each payment method.
+func saleJournalData(pt *entities.PosTransaction) map[string]interface{} {
+	data := map[string]interface{}{
+		"total_amount":    pt.TotalAmount,
+		"net_amount":      roundMoney(pt.TotalAmount - pt.TaxAmount),
+		"tax_amount":      pt.TaxAmount,
+		"discount_amount": pt.DiscountAmount,
+	}
+	for _, payment := range pt.Payments {
+		addJournalAmount(data, payment.PaymentMethod+"_amount", payment.Amount)
+	}
+	return data
+}
+
+// refundJournalData splits a refund or exchange into the amounts of its return journal
+// and of the sale journal of its new items. The credit for the returned items used to
+// pay for new items is booked on both as the exchange amount.
+func refundJournalData(value posReturnValue, newTotal float64, payments []*entities.PosPayment) (map[string]interface{}, map[string]interface{}) {
+	exchanged := math.Min(roundMoney(value.Gross-value.Points), newTotal)
+	returnData := map[string]interface{}{
+		"total_amount":    value.Gross,
+		"net_amount":      roundMoney(value.Gross - value.Tax),
+		"tax_amount":      value.Tax,
+		"exchange_amount": exchanged,
+	}
+	saleData := map[string]interface{}{
+		"total_amount":    newTotal,
+		"net_amount":      newTotal,
+		"exchange_amount": exchanged,
+	}
+	for _, payment := range payments {
+		if payment.Amount < 0 {
+			addJournalAmount(returnData, payment.PaymentMethod+"_amount", -payment.Amount)
+		} else {
+			addJournalAmount(saleData, payment.PaymentMethod+"_amount", payment.Amount)
+		}
+	}
+	return returnData, saleData
+}
+
+func addJournalAmount(data map[string]interface{}, key string, amount float64) {
+	current, _ := data[key].(float64)
+	data[key] = roundMoney(current + amount)
+}
+
+// postJournal posts a POS journal through the account mappings. A journal that cannot be
+// posted is logged and does not undo the transaction.
+func (s *PosTransactionService) postJournal(ctx context.Context, transactionType string, pt *entities.PosTransaction, at time.Time, description string, data map[string]interface{}) {
+	if s.journals == nil {
+		return
+	}
+	req := &accounting_services.AutoJournalRequest{
+		SourceModule:    posJournalSource,
+		SourceID:        pt.ID.String(),
+		TransactionType: transactionType,
+		TransactionDate: at,
+		CompanyID:       "1", // Default company
+		CurrencyCode:    "IDR",
+		ExchangeRate:    1.0,
+		Description:     description,
+		Reference:       pt.ReceiptNumber,
+		TransactionData: data,
+		CreatedBy:       pt.CashierID.String(),
+		AutoPost:        true,
+	}
+	if _, err := s.journals.CreateJournalFromTransaction(ctx, req); err != nil {
+		log.Printf("[POS] Failed to post %s journal for receipt %s: %v", transactionType, pt.ReceiptNumber, err)
+	}
+}
+
+// authorizeOverride returns the user approving a void: the actor when they hold the
+// override permission, otherwise the supervisor who signs in at the till.
+func (s *PosTransactionService) authorizeOverride(ctx context.Context, actorID string, override *entities.SupervisorOverride) (string, error) {
+	if s.permissions == nil {
+		return "", fmt.Errorf("%w: supervisor overrides are not set up", entities.ErrSupervisorOverrideRequired)
+	}
+	if actorID != "" {
+		allowed, err := s.canOverride(ctx, actorID)
+		if err != nil {
+			return "", err
+		}
+		if allowed {
+			return actorID, nil
+		}
+	}
+	if override == nil || strings.TrimSpace(override.Email) == "" || override.Password == "" || s.credentials == nil {
+		return "", entities.ErrSupervisorOverrideRequired
+	}
+
+	supervisor, err := s.credentials.VerifyCredentials(ctx, strings.TrimSpace(override.Email), override.Password)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", entities.ErrInvalidSupervisorOverride, err)
+	}
+	allowed, err := s.canOverride(ctx, supervisor.ID.String())
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", fmt.Errorf("%w: %s may not approve voids", entities.ErrInvalidSupervisorOverride, supervisor.Email)
+	}
+	return supervisor.ID.String(), nil
+}
+
+func (s *PosTransactionService) canOverride(ctx context.Context, userID string) (bool, error) {
+	permissions, err := s.permissions.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return permissions != nil && permissions.HasPermission(posOverridePermission), nil
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+)
+
+// EvaluatePromotions applies promotions to a cart and allocates every discount to
+// the cart lines.
+//
+// Promotions are evaluated by descending priority. Each one works on the line
+// amounts left by the promotions before it, so stacked discounts compound and a
+// line never goes below zero. A non-stackable promotion only applies when nothing
+// has been applied yet, and nothing is applied after it.
+func EvaluatePromotions(promotions []*entities.Promotion, cart *entities.PromotionCart) *entities.PromotionEvaluation {
+	result := &entities.PromotionEvaluation{
+		Applied: []entities.AppliedPromotion{},
+		Lines:   make([]entities.PromotionEvaluatedLine, len(cart.Lines)),
+	}
+
+	net := make([]float64, len(cart.Lines))
+	for i, line := range cart.Lines {
+		gross := roundMoney(float64(line.Quantity) * line.UnitPrice)
+		net[i] = gross
+		result.Subtotal += gross
+		result.Lines[i] = entities.PromotionEvaluatedLine{
+			ArticleID: line.ArticleID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+			Gross:     gross,
+		}
+	}
+	result.Subtotal = roundMoney(result.Subtotal)
+
+	codes := make(map[string]string, len(cart.VoucherCodes))
+	for _, code := range cart.VoucherCodes {
+		if normalized := normalizeVoucherCode(code); normalized != "" {
+			codes[normalized] = code
+		}
+	}
+	usedCodes := make(map[string]bool)
+
+	candidates := make([]*entities.Promotion, 0, len(promotions))
+	for _, promo := range promotions {
+		if promotionApplies(promo, cart, codes) {
+			candidates = append(candidates, promo)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority > candidates[j].Priority
+		}
+		return candidates[i].ID.String() < candidates[j].ID.String()
+	})
+
+	for _, promo := range candidates {
+		if !promo.Stackable && len(result.Applied) > 0 {
+			continue
+		}
+
+		eligible := eligibleLines(promo, cart, net)
+		if len(eligible) == 0 {
+			continue
+		}
+		eligibleSubtotal := 0.0
+		for _, i := range eligible {
+			eligibleSubtotal += net[i]
+		}
+		if eligibleSubtotal < promo.MinPurchase {
+			continue
+		}
+
+		discounts := promotionDiscounts(promo, cart, eligible, net, eligibleSubtotal)
+		discounts = capDiscounts(promo, discounts, net)
+
+		applied := entities.AppliedPromotion{
+			PromotionID:   promo.ID,
+			Name:          promo.Name,
+			PromotionType: promo.PromotionType,
+			Allocations:   []entities.PromotionLineDiscount{},
+		}
+		for i, amount := range discounts {
+			if amount <= 0 {
+				continue
+			}
+			net[i] = roundMoney(net[i] - amount)
+			applied.Discount += amount
+			applied.Allocations = append(applied.Allocations, entities.PromotionLineDiscount{
+				LineIndex: i,
+				ArticleID: cart.Lines[i].ArticleID,
+				Amount:    amount,
+			})
+		}
+		applied.Discount = roundMoney(applied.Discount)
+		if applied.Discount <= 0 {
+			continue
+		}
+		if promo.Code != "" {
+			normalized := normalizeVoucherCode(promo.Code)
+			applied.VoucherCode = codes[normalized]
+			usedCodes[normalized] = true
+		}
+		result.Applied = append(result.Applied, applied)
+		result.TotalDiscount += applied.Discount
+
+		if !promo.Stackable {
+			break
+		}
+	}
+
+	for i := range result.Lines {
+		result.Lines[i].Net = net[i]
+		result.Lines[i].Discount = roundMoney(result.Lines[i].Gross - net[i])
+	}
+	result.TotalDiscount = roundMoney(result.TotalDiscount)
+	result.Total = roundMoney(result.Subtotal - result.TotalDiscount)
+
+	for normalized, code := range codes {
+		if !usedCodes[normalized] {
+			result.RejectedVoucherCodes = append(result.RejectedVoucherCodes, code)
+		}
+	}
+	sort.Strings(result.RejectedVoucherCodes)
+	return result
+}
+
+// promotionApplies checks the cart-level conditions of a promotion.
+func promotionApplies(promo *entities.Promotion, cart *entities.PromotionCart, codes map[string]string) bool {
+	if !promo.IsActive || cart.At.Before(promo.StartDate) || cart.At.After(promo.EndDate) {
+		return false
+	}
+	if promo.Code != "" {
+		if _, ok := codes[normalizeVoucherCode(promo.Code)]; !ok {
+			return false
+		}
+	}
+	if promo.MaxRedemptions > 0 && promo.RedemptionCount >= promo.MaxRedemptions {
+		return false
+	}
+	if promo.Budget > 0 && promo.Budget-promo.BudgetUsed <= 0 {
+		return false
+	}
+
+	cond := promo.Conditions
+	if !matchesAny(cond.StoreIDs, cart.StoreID) ||
+		!matchesAny(cond.CustomerIDs, cart.CustomerID) ||
+		!matchesAny(cond.PaymentMethods, cart.PaymentMethod) {
+		return false
+	}
+	return withinHappyHour(cond, storeTime(cart))
+}
+
+// eligibleLines returns the cart lines within the promotion's article scope that still have a value.
+func eligibleLines(promo *entities.Promotion, cart *entities.PromotionCart, net []float64) []int {
+	cond := promo.Conditions
+	var eligible []int
+	for i, line := range cart.Lines {
+		if line.Quantity <= 0 || net[i] <= 0 {
+			continue
+		}
+		if matchesAny(cond.ArticleIDs, line.ArticleID) &&
+			matchesAny(cond.Brands, line.Brand) &&
+			matchesAny(cond.ClassificationIDs, line.ClassificationID) &&
+			matchesAny(cond.SizeIDs, line.SizeID) {
+			eligible = append(eligible, i)
+		}
+	}
+	return eligible
+}
+
+// promotionDiscounts computes the uncapped discount of each cart line.
+func promotionDiscounts(promo *entities.Promotion, cart *entities.PromotionCart, eligible []int, net []float64, eligibleSubtotal float64) []float64 {
+	discounts := make([]float64, len(net))
+
+	switch promo.PromotionType {
+	case entities.PromotionTypePercentage, "":
+		for _, i := range eligible {
+			discounts[i] = net[i] * clampRate(promo.DiscountRate)
+		}
+
+	case entities.PromotionTypeFixedAmount:
+		spreadDiscount(discounts, math.Min(promo.DiscountAmount, eligibleSubtotal), eligible, net)
+
+	case entities.PromotionTypeTieredSpend:
+		var tier *entities.PromotionTier
+		for i := range promo.Tiers {
+			t := &promo.Tiers[i]
+			if eligibleSubtotal >= t.MinSpend && (tier == nil || t.MinSpend > tier.MinSpend) {
+				tier = t
+			}
+		}
+		if tier == nil {
+			break
+		}
+		if tier.DiscountRate > 0 {
+			for _, i := range eligible {
+				discounts[i] = net[i] * clampRate(tier.DiscountRate)
+			}
+		} else {
+			spreadDiscount(discounts, math.Min(tier.DiscountAmount, eligibleSubtotal), eligible, net)
+		}
+
+	case entities.PromotionTypeBuyXGetY:
+		groupSize := promo.BuyQuantity + promo.GetQuantity
+		if promo.BuyQuantity <= 0 || promo.GetQuantity <= 0 {
+			break
+		}
+		rate := 1.0
+		if promo.DiscountRate > 0 {
+			rate = clampRate(promo.DiscountRate)
+		}
+		units := expandUnits(cart, eligible, net)
+		free := (len(units) / groupSize) * promo.GetQuantity
+		// The cheapest units are the discounted ones
+		sort.SliceStable(units, func(a, b int) bool { return units[a].price < units[b].price })
+		for _, u := range units[:free] {
+			discounts[u.line] += u.price * rate
+		}
+
+	case entities.PromotionTypeBundle:
+		if promo.BundleQuantity <= 0 {
+			break
+		}
+		units := expandUnits(cart, eligible, net)
+		// Bundles are filled with the most expensive units first
+		sort.SliceStable(units, func(a, b int) bool { return units[a].price > units[b].price })
+		for start := 0; start+promo.BundleQuantity <= len(units); start += promo.BundleQuantity {
+			group := units[start : start+promo.BundleQuantity]
+			value := 0.0
+			for _, u := range group {
+				value += u.price
+			}
+			if value <= promo.BundlePrice {
+				continue
+			}
+			saving := value - promo.BundlePrice
+			for _, u := range group {
+				discounts[u.line] += saving * u.price / value
+			}
+		}
+	}
+
+	return discounts
+}
+
+// capDiscounts rounds the line discounts and limits the total to the promotion's
+// maximum discount and remaining budget.
+func capDiscounts(promo *entities.Promotion, discounts, net []float64) []float64 {
+	total := 0.0
+	for i := range discounts {
+		discounts[i] = math.Min(math.Max(discounts[i], 0), net[i])
+		total += discounts[i]
+	}
+
+	limit := total
+	if promo.MaxDiscount > 0 {
+		limit = math.Min(limit, promo.MaxDiscount)
+	}
+	if promo.Budget > 0 {
+		limit = math.Min(limit, promo.Budget-promo.BudgetUsed)
+	}
+	limit = roundMoney(limit)
+
+	if total > 0 && limit < total {
+		for i := range discounts {
+			discounts[i] = discounts[i] * limit / total
+		}
+	}
+	return roundAllocations(discounts, limit, net)
+}
+
+// roundAllocations rounds each discount to cents and puts the rounding difference on
+// the largest discount, so the allocations add up to the rounded target.
+func roundAllocations(discounts []float64, target float64, net []float64) []float64 {
+	sum := 0.0
+	largest := -1
+	for i := range discounts {
+		discounts[i] = roundMoney(discounts[i])
+		sum += discounts[i]
+		if discounts[i] > 0 && (largest < 0 || discounts[i] > discounts[largest]) {
+			largest = i
+		}
+	}
+	if diff := roundMoney(target - sum); largest >= 0 && diff != 0 {
+		discounts[largest] = roundMoney(math.Max(math.Min(discounts[largest]+diff, net[largest]), 0))
+	}
+	return discounts
+}
+
+// spreadDiscount distributes an amount over the eligible lines in proportion to their value.
+func spreadDiscount(discounts []float64, amount float64, eligible []int, net []float64) {
+	if amount <= 0 {
+		return
+	}
+	total := 0.0
+	for _, i := range eligible {
+		total += net[i]
+	}
+	if total <= 0 {
+		return
+	}
+	for _, i := range eligible {
+		discounts[i] += amount * net[i] / total
+	}
+}
+
+type cartUnit struct {
+	line  int
+	price float64
+}
+
+// expandUnits lists every eligible unit at its current price.
+func expandUnits(cart *entities.PromotionCart, eligible []int, net []float64) []cartUnit {
+	var units []cartUnit
+	for _, i := range eligible {
+		qty := cart.Lines[i].Quantity
+		price := net[i] / float64(qty)
+		for n := 0; n < qty; n++ {
+			units = append(units, cartUnit{line: i, price: price})
+		}
+	}
+	return units
+}
+
+// storeTime returns the time of the cart on the store's clock. Happy hours are local
+// times, and a store in WITA or WIT runs ahead of a server in WIB.
+func storeTime(cart *entities.PromotionCart) time.Time {
+	if cart.Timezone == "" {
+		return cart.At
+	}
+	loc, err := time.LoadLocation(cart.Timezone)
+	if err != nil {
+		return cart.At
+	}
+	return cart.At.In(loc)
+}
+
+// withinHappyHour checks the weekday and daily time window of a promotion.
+func withinHappyHour(cond entities.PromotionConditions, at time.Time) bool {
+	if len(cond.DaysOfWeek) > 0 {
+		found := false
+		for _, day := range cond.DaysOfWeek {
+			if time.Weekday(day) == at.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if cond.StartTime == "" || cond.EndTime == "" {
+		return true
+	}
+	now := at.Format("15:04")
+	if cond.StartTime <= cond.EndTime {
+		return now >= cond.StartTime && now < cond.EndTime
+	}
+	// Windows that cross midnight, e.g. 22:00-02:00
+	return now >= cond.StartTime || now < cond.EndTime
+}
+
+func matchesAny(allowed []string, value string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(a, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func clampRate(rate float64) float64 {
+	return math.Min(math.Max(rate, 0), 1)
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+var promoTestNow = time.Date(2024, 6, 14, 18, 30, 0, 0, time.UTC) // a Friday
+
+func testPromotion(promotionType string) *entities.Promotion {
+	promo := &entities.Promotion{
+		Name:          promotionType,
+		StartDate:     promoTestNow.AddDate(0, 0, -7),
+		EndDate:       promoTestNow.AddDate(0, 0, 7),
+		PromotionType: promotionType,
+		Stackable:     true,
+		IsActive:      true,
+	}
+	promo.ID = uuid.New()
+	return promo
+}
+
+func testCart(lines ...entities.PromotionCartLine) *entities.PromotionCart {
+	return &entities.PromotionCart{At: promoTestNow, Lines: lines}
+}
+
+func assertAllocationsBalance(t *testing.T, eval *entities.PromotionEvaluation) {
+	t.Helper()
+	lineTotal := 0.0
+	for _, line := range eval.Lines {
+		assert.InDelta(t, line.Gross-line.Discount, line.Net, 0.001)
+		lineTotal += line.Discount
+	}
+	for _, applied := range eval.Applied {
+		sum := 0.0
+		for _, a := range applied.Allocations {
+			sum += a.Amount
+		}
+		assert.InDelta(t, applied.Discount, sum, 0.001, applied.Name)
+	}
+	assert.InDelta(t, eval.TotalDiscount, lineTotal, 0.001)
+	assert.InDelta(t, eval.Subtotal-eval.TotalDiscount, eval.Total, 0.001)
+}
+
+func TestEvaluatePromotions_PercentageScopedByBrand(t *testing.T) {
+	promo := testPromotion(entities.PromotionTypePercentage)
+	promo.DiscountRate = 0.2
+	promo.Conditions.Brands = []string{"Nike"}
+
+	eval := EvaluatePromotions([]*entities.Promotion{promo}, testCart(
+		entities.PromotionCartLine{ArticleID: "a", Brand: "nike", Quantity: 2, UnitPrice: 500000},
+		entities.PromotionCartLine{ArticleID: "b", Brand: "Adidas", Quantity: 1, UnitPrice: 300000},
+	))
+
+	require.Len(t, eval.Applied, 1)
+	assert.Equal(t, 200000.0, eval.Lines[0].Discount)
+	assert.Equal(t, 0.0, eval.Lines[1].Discount)
+	assert.Equal(t, 1100000.0, eval.Total)
+	assertAllocationsBalance(t, eval)
+}
+
+func TestEvaluatePromotions_BuyXGetYDiscountsCheapestUnits(t *testing.T) {
+	promo := testPromotion(entities.PromotionTypeBuyXGetY)
+	promo.BuyQuantity = 2
+	promo.GetQuantity = 1
+
+	eval := EvaluatePromotions([]*entities.Promotion{promo}, testCart(
+		entities.PromotionCartLine{ArticleID: "a", Quantity: 2, UnitPrice: 300000},
+		entities.PromotionCartLine{ArticleID: "b", Quantity: 1, UnitPrice: 100000},
+		entities.PromotionCartLine{ArticleID: "c", Quantity: 2, UnitPrice: 200000},
+	))
+
+	// Five units make one group of three, so only the cheapest unit is free
+	require.Len(t, eval.Applied, 1)
+	assert.Equal(t, 100000.0, eval.TotalDiscount)
+	assert.Equal(t, 100000.0, eval.Lines[1].Discount)
+	assertAllocationsBalance(t, eval)
+}
+
+func TestEvaluatePromotions_BundlePrice(t *testing.T) {
+	promo := testPromotion(entities.PromotionTypeBundle)
+	promo.BundleQuantity = 3
+	promo.BundlePrice = 100000
+	promo.Conditions.ArticleIDs = []string{"socks"}
+
+	eval := EvaluatePromotions([]*entities.Promotion{promo}, testCart(
+		entities.PromotionCartLine{ArticleID: "socks", Quantity: 4, UnitPrice: 45000},
+		entities.PromotionCartLine{ArticleID: "shoe", Quantity: 1, UnitPrice: 800000},
+	))
+
+	// Three socks sell for 100,000; the fourth stays at full price
+	assert.Equal(t, 35000.0, eval.TotalDiscount)
+	assert.Equal(t, 145000.0, eval.Lines[0].Net)
+	assertAllocationsBalance(t, eval)
+}
+
+func TestEvaluatePromotions_TieredSpendPicksHighestTierReached(t *testing.T) {
+	promo := testPromotion(entities.PromotionTypeTieredSpend)
+	promo.Tiers = entities.PromotionTiers{
+		{MinSpend: 500000, DiscountAmount: 50000},
+		{MinSpend: 1000000, DiscountAmount: 150000},
+		{MinSpend: 2000000, DiscountRate: 0.2},
+	}
+
+	eval := EvaluatePromotions([]*entities.Promotion{promo}, testCart(
+		entities.PromotionCartLine{ArticleID: "a", Quantity: 1, UnitPrice: 700000},
+		entities.PromotionCartLine{ArticleID: "b", Quantity: 1, UnitPrice: 500000},
+	))
+
+	assert.Equal(t, 150000.0, eval.TotalDiscount)
+	assert.Equal(t, 87500.0, eval.Lines[0].Discount)
+	assert.Equal(t, 62500.0, eval.Lines[1].Discount)
+	assertAllocationsBalance(t, eval)
+}
+
+func TestEvaluatePromotions_StackingAndPriority(t *testing.T) {
+	exclusive := testPromotion(entities.PromotionTypePercentage)
+	exclusive.Name = "exclusive"
+	exclusive.DiscountRate = 0.5
+	exclusive.Stackable = false
+	exclusive.Priority = 1
+
+	first := testPromotion(entities.PromotionTypePercentage)
+	first.Name = "first"
+	first.DiscountRate = 0.1
+	first.Priority = 10
+
+	second := testPromotion(entities.PromotionTypeFixedAmount)
+	second.Name = "second"
+	second.DiscountAmount = 10000
+	second.Priority = 5
+
+	cart := func() *entities.PromotionCart {
+		return testCart(entities.PromotionCartLine{ArticleID: "a", Quantity: 1, UnitPrice: 200000})
+	}
+
+	// Stackable promotions compound in priority order and the exclusive one is skipped
+	eval := EvaluatePromotions([]*entities.Promotion{exclusive, second, first}, cart())
+	require.Len(t, eval.Applied, 2)
+	assert.Equal(t, "first", eval.Applied[0].Name)
+	assert.Equal(t, "second", eval.Applied[1].Name)
+	assert.Equal(t, 170000.0, eval.Total)
+
+	// A non-stackable promotion with the top priority blocks everything after it
+	exclusive.Priority = 20
+	eval = EvaluatePromotions([]*entities.Promotion{exclusive, second, first}, cart())
+	require.Len(t, eval.Applied, 1)
+	assert.Equal(t, "exclusive", eval.Applied[0].Name)
+	assert.Equal(t, 100000.0, eval.Total)
+	assertAllocationsBalance(t, eval)
+}
+
+func TestEvaluatePromotions_VoucherCodes(t *testing.T) {
+	promo := testPromotion(entities.PromotionTypeFixedAmount)
+	promo.Code = "LEBARAN50"
+	promo.DiscountAmount = 50000
+	promo.MinPurchase = 300000
+
+	line := entities.PromotionCartLine{ArticleID: "a", Quantity: 1, UnitPrice: 400000}
+
+	eval := EvaluatePromotions([]*entities.Promotion{promo}, testCart(line))
+	assert.Empty(t, eval.Applied, "voucher promotions need the code")
+
+	cart := testCart(line)
+	cart.VoucherCodes = []string{" lebaran50 ", "UNKNOWN"}
+	eval = EvaluatePromotions([]*entities.Promotion{promo}, cart)
+	require.Len(t, eval.Applied, 1)
+	assert.Equal(t, " lebaran50 ", eval.Applied[0].VoucherCode)
+	assert.Equal(t, []string{"UNKNOWN"}, eval.RejectedVoucherCodes)
+
+	// Below the minimum purchase the code is rejected too
+	cart = testCart(entities.PromotionCartLine{ArticleID: "a", Quantity: 1, UnitPrice: 200000})
+	cart.VoucherCodes = []string{"LEBARAN50"}
+	eval = EvaluatePromotions([]*entities.Promotion{promo}, cart)
+	assert.Empty(t, eval.Applied)
+	assert.Equal(t, []string{"LEBARAN50"}, eval.RejectedVoucherCodes)
+}
+
+func TestEvaluatePromotions_HappyHourAndCartConditions(t *testing.T) {
+	promo := testPromotion(entities.PromotionTypePercentage)
+	promo.DiscountRate = 0.1
+	promo.Conditions.DaysOfWeek = []int{int(time.Friday), int(time.Saturday)}
+	promo.Conditions.StartTime = "17:00"
+	promo.Conditions.EndTime = "19:00"
+	promo.Conditions.PaymentMethods = []string{"qris"}
+
+	cart := testCart(entities.PromotionCartLine{ArticleID: "a", Quantity: 1, UnitPrice: 100000})
+	cart.PaymentMethod = "QRIS"
+	assert.Len(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, 1)
+
+	cart.At = promoTestNow.Add(time.Hour)
+	assert.Empty(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, "outside the time window")
+
+	cart.At = promoTestNow.AddDate(0, 0, 1)
+	assert.Len(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, 1, "saturday")
+
+	cart.At = promoTestNow.AddDate(0, 0, 2)
+	assert.Empty(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, "sunday")
+
+	cart.At = promoTestNow
+	cart.PaymentMethod = "cash"
+	assert.Empty(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, "payment method")
+
+	// The window is on the store's clock: 11:30 UTC is 18:30 in Jakarta and 19:30 in Makassar
+	cart.PaymentMethod = "qris"
+	cart.At = time.Date(2024, 6, 14, 11, 30, 0, 0, time.UTC)
+	assert.Empty(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, "server clock")
+	cart.Timezone = "Asia/Jakarta"
+	assert.Len(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, 1, "WIB store")
+	cart.Timezone = "Asia/Makassar"
+	assert.Empty(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied, "WITA store")
+
+	// Windows crossing midnight
+	assert.True(t, withinHappyHour(entities.PromotionConditions{StartTime: "22:00", EndTime: "02:00"}, time.Date(2024, 6, 14, 1, 0, 0, 0, time.UTC)))
+	assert.False(t, withinHappyHour(entities.PromotionConditions{StartTime: "22:00", EndTime: "02:00"}, time.Date(2024, 6, 14, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestEvaluatePromotions_CapsAndLimits(t *testing.T) {
+	promo := testPromotion(entities.PromotionTypePercentage)
+	promo.DiscountRate = 0.3
+	promo.MaxDiscount = 100000
+	promo.Budget = 1000000
+	promo.BudgetUsed = 960000
+
+	cart := testCart(
+		entities.PromotionCartLine{ArticleID: "a", Quantity: 1, UnitPrice: 333333},
+		entities.PromotionCartLine{ArticleID: "b", Quantity: 1, UnitPrice: 166667},
+	)
+
+	// The remaining budget is lower than the per-transaction cap
+	eval := EvaluatePromotions([]*entities.Promotion{promo}, cart)
+	assert.Equal(t, 40000.0, eval.TotalDiscount)
+	assertAllocationsBalance(t, eval)
+
+	promo.BudgetUsed = 0
+	eval = EvaluatePromotions([]*entities.Promotion{promo}, cart)
+	assert.Equal(t, 100000.0, eval.TotalDiscount)
+	assertAllocationsBalance(t, eval)
+
+	promo.MaxRedemptions = 10
+	promo.RedemptionCount = 10
+	assert.Empty(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied)
+
+	promo.RedemptionCount = 0
+	promo.IsActive = false
+	assert.Empty(t, EvaluatePromotions([]*entities.Promotion{promo}, cart).Applied)
+}
@@ -3,20 +3,31 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
+	masterdata_entities "malaka/internal/modules/masterdata/domain/entities"
 	"malaka/internal/modules/sales/domain/entities"
 	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/utils"
 	"malaka/internal/shared/uuid"
 )
 
+// ArticleLookup resolves the brand, classification and size of sold articles.
+type ArticleLookup interface {
+	GetArticleByID(ctx context.Context, id uuid.ID) (*masterdata_entities.Article, error)
+}
+
 // PromotionService provides business logic for promotion operations.
 type PromotionService struct {
-	repo repositories.PromotionRepository
+	repo     repositories.PromotionRepository
+	articles ArticleLookup
 }
 
-// NewPromotionService creates a new PromotionService.
-func NewPromotionService(repo repositories.PromotionRepository) *PromotionService {
-	return &PromotionService{repo: repo}
+// NewPromotionService creates a new PromotionService. articles may be nil, in which
+// case brand, classification and size scopes only match cart lines that carry them.
+func NewPromotionService(repo repositories.PromotionRepository, articles ArticleLookup) *PromotionService {
+	return &PromotionService{repo: repo, articles: articles}
 }
 
 // CreatePromotion creates a new promotion.
@@ -24,6 +35,12 @@ func (s *PromotionService) CreatePromotion(ctx context.Context, promo *entities.
 	if promo.ID.IsNil() {
 		promo.ID = uuid.New()
 	}
+	if promo.PromotionType == "" {
+		promo.PromotionType = entities.PromotionTypePercentage
+	}
+	if err := validatePromotion(promo); err != nil {
+		return err
+	}
 	return s.repo.Create(ctx, promo)
 }
 
@@ -47,6 +64,12 @@ func (s *PromotionService) UpdatePromotion(ctx context.Context, promo *entities.
 	if existingPromo == nil {
 		return errors.New("promotion not found")
 	}
+	if promo.PromotionType == "" {
+		promo.PromotionType = existingPromo.PromotionType
+	}
+	if err := validatePromotion(promo); err != nil {
+		return err
+	}
 	return s.repo.Update(ctx, promo)
 }
 
@@ -62,3 +85,134 @@ func (s *PromotionService) DeletePromotion(ctx context.Context, id string) error
 	}
 	return s.repo.Delete(ctx, id)
 }
+
+// GetPromotionRedemptions retrieves the redemptions of a promotion.
+func (s *PromotionService) GetPromotionRedemptions(ctx context.Context, id string) ([]*entities.PromotionRedemption, error) {
+	return s.repo.GetRedemptions(ctx, id)
+}
+
+// Evaluate runs the active promotions against a cart without redeeming them.
+func (s *PromotionService) Evaluate(ctx context.Context, cart *entities.PromotionCart) (*entities.PromotionEvaluation, error) {
+	if cart.At.IsZero() {
+		cart.At = utils.Now()
+	}
+	s.enrichCart(ctx, cart)
+
+	promotions, err := s.repo.GetActive(ctx, cart.At)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active promotions: %w", err)
+	}
+	return EvaluatePromotions(promotions, cart), nil
+}
+
+// Checkout evaluates a cart at checkout and redeems the applied promotions against the
+// source document. Voucher codes that do not apply fail the checkout so the cashier can
+// remove them.
+func (s *PromotionService) Checkout(ctx context.Context, cart *entities.PromotionCart, sourceType string, sourceID uuid.ID) (*entities.PromotionEvaluation, error) {
+	eval, err := s.Evaluate(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	if len(eval.RejectedVoucherCodes) > 0 {
+		return nil, fmt.Errorf("%w: %s", entities.ErrVoucherNotApplicable, strings.Join(eval.RejectedVoucherCodes, ", "))
+	}
+	if err := s.Redeem(ctx, eval, sourceType, sourceID, cart.CustomerID); err != nil {
+		return nil, err
+	}
+	return eval, nil
+}
+
+// Redeem records the promotions of an evaluation against a POS transaction or sales
+// order. When one of them is exhausted in the meantime, the redemptions already made
+// are released and entities.ErrPromotionExhausted is returned.
+func (s *PromotionService) Redeem(ctx context.Context, eval *entities.PromotionEvaluation, sourceType string, sourceID uuid.ID, customerID string) error {
+	for _, applied := range eval.Applied {
+		redemption := &entities.PromotionRedemption{
+			ID:             uuid.New(),
+			PromotionID:    applied.PromotionID,
+			SourceType:     sourceType,
+			SourceID:       sourceID,
+			CustomerID:     customerID,
+			VoucherCode:    applied.VoucherCode,
+			DiscountAmount: applied.Discount,
+			CreatedAt:      utils.Now(),
+		}
+		if err := s.repo.RecordRedemption(ctx, redemption); err != nil {
+			if releaseErr := s.Release(ctx, sourceType, sourceID); releaseErr != nil {
+				return fmt.Errorf("%w (release failed: %v)", err, releaseErr)
+			}
+			if errors.Is(err, entities.ErrPromotionExhausted) {
+				return fmt.Errorf("promotion %q: %w", applied.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Release gives back the redemptions recorded for a POS transaction or sales order.
+func (s *PromotionService) Release(ctx context.Context, sourceType string, sourceID uuid.ID) error {
+	return s.repo.ReleaseRedemptions(ctx, sourceType, sourceID.String())
+}
+
+// enrichCart fills in the brand, classification and size of cart lines from the article master.
+func (s *PromotionService) enrichCart(ctx context.Context, cart *entities.PromotionCart) {
+	if s.articles == nil {
+		return
+	}
+	for i := range cart.Lines {
+		line := &cart.Lines[i]
+		if line.Brand != "" && line.ClassificationID != "" && line.SizeID != "" {
+			continue
+		}
+		articleID, err := uuid.Parse(line.ArticleID)
+		if err != nil {
+			continue
+		}
+		article, err := s.articles.GetArticleByID(ctx, articleID)
+		if err != nil || article == nil {
+			continue
+		}
+		if line.Brand == "" {
+			line.Brand = article.Brand
+		}
+		if line.ClassificationID == "" && !article.ClassificationID.IsNil() {
+			line.ClassificationID = article.ClassificationID.String()
+		}
+		if line.SizeID == "" && !article.SizeID.IsNil() {
+			line.SizeID = article.SizeID.String()
+		}
+	}
+}
+
+// validatePromotion checks that a promotion has the settings its type needs.
+func validatePromotion(promo *entities.Promotion) error {
+	if promo.EndDate.Before(promo.StartDate) {
+		return fmt.Errorf("%w: end date must not be before start date", entities.ErrInvalidPromotion)
+	}
+	switch promo.PromotionType {
+	case entities.PromotionTypePercentage:
+		if promo.DiscountRate <= 0 || promo.DiscountRate > 1 {
+			return fmt.Errorf("%w: percentage promotions need a discount rate between 0 and 1", entities.ErrInvalidPromotion)
+		}
+	case entities.PromotionTypeFixedAmount:
+		if promo.DiscountAmount <= 0 {
+			return fmt.Errorf("%w: fixed amount promotions need a discount amount", entities.ErrInvalidPromotion)
+		}
+	case entities.PromotionTypeBuyXGetY:
+		if promo.BuyQuantity <= 0 || promo.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buy X get Y promotions need buy and get quantities", entities.ErrInvalidPromotion)
+		}
+	case entities.PromotionTypeBundle:
+		if promo.BundleQuantity < 2 || promo.BundlePrice <= 0 {
+			return fmt.Errorf("%w: bundle promotions need a bundle quantity of at least 2 and a bundle price", entities.ErrInvalidPromotion)
+		}
+	case entities.PromotionTypeTieredSpend:
+		if len(promo.Tiers) == 0 {
+			return fmt.Errorf("%w: tiered spend promotions need at least one tier", entities.ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: unknown promotion type %q", entities.ErrInvalidPromotion, promo.PromotionType)
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ type SalesOrderService struct {
 	repo         repositories.SalesOrderRepository
 	itemRepo     repositories.SalesOrderItemRepository
 	stockService *inventory_services.StockService
+	promotions   *PromotionService
 }
 
 // NewSalesOrderService creates a new SalesOrderService.
@@ -28,12 +29,36 @@ func NewSalesOrderService(repo repositories.SalesOrderRepository, itemRepo repos
 	}
 }
 
-// CreateSalesOrder creates a new sales order and records stock movements.
-func (s *SalesOrderService) CreateSalesOrder(ctx context.Context, so *entities.SalesOrder, items []*entities.SalesOrderItem) error {
+// SetPromotionService enables promotion evaluation at checkout.
+func (s *SalesOrderService) SetPromotionService(promotions *PromotionService) {
+	s.promotions = promotions
+}
+
+// CreateSalesOrder creates a new sales order and records stock movements. Open orders
+// (draft or pending) take no stock yet: they reserve it on confirmation and issue it
+// on delivery through the OrderToCashService. Active promotions are applied to the
+// items before the order is saved, except on orders converted from a quotation, which
+// keep the quoted prices.
+func (s *SalesOrderService) CreateSalesOrder(ctx context.Context, so *entities.SalesOrder, items []*entities.SalesOrderItem) (err error) {
 	if so.ID.IsNil() {
 		so.ID = uuid.New()
 	}
 
+	if s.promotions != nil && len(items) > 0 && so.QuotationID == nil {
+		if err := s.applyPromotions(ctx, so, items); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil && len(so.AppliedPromotions) > 0 {
+				_ = s.promotions.Release(ctx, entities.PromotionSourceSalesOrder, so.ID)
+			}
+		}()
+	}
+
+	if so.Subtotal == 0 {
+		so.Subtotal = so.TotalAmount + so.DiscountAmount - so.TaxAmount
+	}
+
 	// Create the sales order
 	if err := s.repo.Create(ctx, so); err != nil {
 		return err
@@ -48,6 +73,9 @@ func (s *SalesOrderService) CreateSalesOrder(ctx context.Context, so *entities.S
 		if err := s.itemRepo.Create(ctx, item); err != nil {
 			return err
 		}
+		if so.Status == entities.SalesOrderDraft || so.Status == entities.SalesOrderPending {
+			continue
+		}
 
 		// Record stock movement out of warehouse
 		articleID, _ := uuid.Parse(item.ArticleID)
@@ -69,6 +97,41 @@ func (s *SalesOrderService) CreateSalesOrder(ctx context.Context, so *entities.S
 	return nil
 }
 
+// applyPromotions runs the promotion engine over the items, allocates the discounts to
+// the lines and redeems the applied promotions.
+func (s *SalesOrderService) applyPromotions(ctx context.Context, so *entities.SalesOrder, items []*entities.SalesOrderItem) error {
+	cart := &entities.PromotionCart{
+		CustomerID:   so.CustomerID,
+		VoucherCodes: so.VoucherCodes,
+		At:           so.OrderDate,
+	}
+	for _, item := range items {
+		cart.Lines = append(cart.Lines, entities.PromotionCartLine{
+			ArticleID: item.ArticleID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	eval, err := s.promotions.Checkout(ctx, cart, entities.PromotionSourceSalesOrder, so.ID)
+	if err != nil {
+		return err
+	}
+	if len(eval.Applied) == 0 {
+		return nil
+	}
+
+	for i, item := range items {
+		item.DiscountAmount = eval.Lines[i].Discount
+		item.TotalPrice = eval.Lines[i].Net
+	}
+	so.Subtotal = eval.Subtotal
+	so.DiscountAmount = eval.TotalDiscount
+	so.TotalAmount = eval.Total
+	so.AppliedPromotions = eval.Applied
+	return nil
+}
+
 func (s *SalesOrderService) GetAllSalesOrders(ctx context.Context) ([]*entities.SalesOrder, error) {
 	return s.repo.GetAll(ctx)
 }
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	masterdata_entities "malaka/internal/modules/masterdata/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/pdf"
+)
+
+// Column widths in characters of the quotation lines table.
+const (
+	quotationNoWidth       = 3
+	quotationQtyWidth      = 5
+	quotationAmountWidth   = 15
+	quotationDiscountWidth = 13
+)
+
+// renderQuotationPDF lays out a quotation as a PDF document for the customer.
+func renderQuotationPDF(q *entities.SalesQuotation, customer *masterdata_entities.Customer) []byte {
+	doc := pdf.New()
+	title := "QUOTATION " + q.QuotationNumber
+	if q.Version > 1 {
+		title += fmt.Sprintf(" (revision %d)", q.Version)
+	}
+	doc.Title(title)
+	doc.Space(6)
+	doc.Text("Date: " + q.QuotationDate.Format("2 January 2006"))
+	doc.Text("Valid until: " + q.ValidUntil.Format("2 January 2006"))
+	if q.PaymentTerms != "" {
+		doc.Text("Payment terms: " + q.PaymentTerms)
+	}
+	doc.Space(8)
+
+	doc.Bold("To")
+	if customer != nil {
+		doc.Text(customer.Name)
+		if customer.ContactPerson != "" {
+			doc.Text("Attn. " + customer.ContactPerson)
+		}
+		if customer.Phone != "" {
+			doc.Text(customer.Phone)
+		}
+		if customer.Email != "" {
+			doc.Text(customer.Email)
+		}
+	} else {
+		doc.Text("Customer " + q.CustomerID)
+	}
+	doc.Space(8)
+
+	descriptionWidth := pdf.RowWidth() - quotationNoWidth - quotationQtyWidth - 2*quotationAmountWidth - quotationDiscountWidth - 5
+	row := func(bold bool, no, description, qty, price, discount, total string) {
+		doc.Row(bold,
+			pdf.Column{Text: no, Width: quotationNoWidth, Right: true},
+			pdf.Column{Text: description, Width: descriptionWidth},
+			pdf.Column{Text: qty, Width: quotationQtyWidth, Right: true},
+			pdf.Column{Text: price, Width: quotationAmountWidth, Right: true},
+			pdf.Column{Text: discount, Width: quotationDiscountWidth, Right: true},
+			pdf.Column{Text: total, Width: quotationAmountWidth, Right: true},
+		)
+	}
+	doc.Rule()
+	row(true, "No", "Description", "Qty", "Unit price", "Discount", "Amount")
+	doc.Rule()
+	for _, item := range q.Items {
+		row(false, strconv.Itoa(item.LineNumber), item.Description, strconv.Itoa(item.Quantity),
+			formatAmount(item.UnitPrice), formatAmount(item.DiscountAmount), formatAmount(item.TotalPrice))
+	}
+	doc.Rule()
+
+	labelWidth := pdf.RowWidth() - quotationAmountWidth - 1
+	total := func(bold bool, label string, amount float64) {
+		doc.Row(bold,
+			pdf.Column{Text: label, Width: labelWidth, Right: true},
+			pdf.Column{Text: formatAmount(amount), Width: quotationAmountWidth, Right: true},
+		)
+	}
+	total(false, "Subtotal", q.Subtotal)
+	if q.DiscountAmount > 0 {
+		total(false, "Discount", -q.DiscountAmount)
+	}
+	total(false, fmt.Sprintf("PPN %s%%", strconv.FormatFloat(q.TaxRate, 'f', -1, 64)), q.TaxAmount)
+	total(true, "Total "+q.Currency, q.TotalAmount)
+
+	if q.Notes != "" {
+		doc.Space(12)
+		doc.Bold("Notes")
+		for _, line := range strings.Split(q.Notes, "\n") {
+			doc.Text(line)
+		}
+	}
+	return doc.Bytes()
+}
+
+// formatAmount formats an amount the Indonesian way, e.g. 1.250.000,00.
+func formatAmount(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	whole := strconv.FormatInt(cents/100, 10)
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(digit)
+	}
+	return fmt.Sprintf("%s%s,%02d", sign, b.String(), cents%100)
+}
@@ -0,0 +1,525 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	masterdata_entities "malaka/internal/modules/masterdata/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/email"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// quotationOrderStatus is the status of sales orders converted from quotations.
+const quotationOrderStatus = "draft"
+
+// PriceLookup returns the price of an article in effect at a time.
+type PriceLookup interface {
+	GetEffectivePrice(ctx context.Context, articleID uuid.ID, at time.Time) (*masterdata_entities.Price, error)
+}
+
+// CustomerLookup returns a customer.
+type CustomerLookup interface {
+	GetCustomerByID(ctx context.Context, id uuid.ID) (*masterdata_entities.Customer, error)
+}
+
+// QuotationMailer sends quotations to customers.
+type QuotationMailer interface {
+	SendQuotationEmail(to string, data email.QuotationEmailData, pdf []byte) error
+}
+
+// SalesQuotationService manages quotations: pricing their lines, sending them to the
+// customer, revisions and the conversion of accepted quotations into sales orders.
+type SalesQuotationService struct {
+	repo      repositories.SalesQuotationRepository
+	orders    *SalesOrderService
+	prices    PriceLookup
+	articles  ArticleLookup
+	customers CustomerLookup
+	mailer    QuotationMailer
+	logoURL   string
+	taxes     TaxCalculator
+}
+
+// NewSalesQuotationService creates a new SalesQuotationService. Lines without a unit
+// price are priced from prices, falling back to the article's own price.
+func NewSalesQuotationService(repo repositories.SalesQuotationRepository, orders *SalesOrderService, prices PriceLookup, articles ArticleLookup) *SalesQuotationService {
+	return &SalesQuotationService{repo: repo, orders: orders, prices: prices, articles: articles}
+}
+
+// SetMailer enables emailing quotations to the customer's address.
+func (s *SalesQuotationService) SetMailer(mailer QuotationMailer, customers CustomerLookup, logoURL string) {
+	s.mailer = mailer
+	s.customers = customers
+	s.logoURL = logoURL
+}
+
+// SetTaxCalculator enables determining the tax of quotations from the tax rules and the
+// rate in force on the quotation date, in place of the tax rate the quotation carries.
+func (s *SalesQuotationService) SetTaxCalculator(taxes TaxCalculator) {
+	s.taxes = taxes
+}
+
+// CreateQuotation prices and stores the first version of a new quotation as a draft.
+func (s *SalesQuotationService) CreateQuotation(ctx context.Context, q *entities.SalesQuotation) error {
+	now := utils.Now()
+	if q.QuotationDate.IsZero() {
+		q.QuotationDate = now
+	}
+	if err := s.prepare(ctx, q); err != nil {
+		return err
+	}
+
+	number, err := s.repo.NextNumber(ctx, q.QuotationDate)
+	if err != nil {
+		return err
+	}
+	q.ID = uuid.New()
+	q.QuotationNumber = number
+	q.Version = 1
+	q.Status = entities.QuotationDraft
+	q.CreatedAt = now
+	q.UpdatedAt = now
+	s.assignItems(q, now)
+	return s.repo.Create(ctx, q)
+}
+
+// GetQuotation returns a quotation version with its lines.
+func (s *SalesQuotationService) GetQuotation(ctx context.Context, id uuid.ID) (*entities.SalesQuotation, error) {
+	q, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q == nil {
+		return nil, entities.ErrQuotationNotFound
+	}
+	return q, nil
+}
+
+// ListQuotations lists quotations newest first.
+func (s *SalesQuotationService) ListQuotations(ctx context.Context, filter entities.SalesQuotationFilter, limit, offset int) ([]*entities.SalesQuotation, int, error) {
+	return s.repo.List(ctx, filter, limit, offset)
+}
+
+// GetVersions returns every version of a quotation, oldest first.
+func (s *SalesQuotationService) GetVersions(ctx context.Context, id uuid.ID) ([]*entities.SalesQuotation, error) {
+	q, err := s.GetQuotation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetVersions(ctx, q.QuotationNumber)
+}
+
+// UpdateQuotation replaces the header and lines of a draft.
+func (s *SalesQuotationService) UpdateQuotation(ctx context.Context, q *entities.SalesQuotation) error {
+	existing, err := s.GetQuotation(ctx, q.ID)
+	if err != nil {
+		return err
+	}
+	if existing.Status != entities.QuotationDraft {
+		return fmt.Errorf("%w: only drafts can be edited, revise the quotation instead", entities.ErrQuotationStatus)
+	}
+	if q.QuotationDate.IsZero() {
+		q.QuotationDate = existing.QuotationDate
+	}
+	if err := s.prepare(ctx, q); err != nil {
+		return err
+	}
+
+	now := utils.Now()
+	q.QuotationNumber = existing.QuotationNumber
+	q.Version = existing.Version
+	q.Status = existing.Status
+	q.CreatedAt = existing.CreatedAt
+	q.CreatedBy = existing.CreatedBy
+	q.UpdatedAt = now
+	s.assignItems(q, now)
+	return s.repo.Update(ctx, q)
+}
+
+// DeleteQuotation deletes a draft. Sent quotations are kept as the record of the offer.
+func (s *SalesQuotationService) DeleteQuotation(ctx context.Context, id uuid.ID) error {
+	q, err := s.GetQuotation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if q.Status != entities.QuotationDraft {
+		return fmt.Errorf("%w: only drafts can be deleted", entities.ErrQuotationStatus)
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// SendQuotation emails the quotation PDF to the customer and marks the quotation sent.
+// to overrides the customer's email address. A sent quotation can be sent again.
+func (s *SalesQuotationService) SendQuotation(ctx context.Context, id uuid.ID, to string) (*entities.SalesQuotation, error) {
+	if s.mailer == nil {
+		return nil, fmt.Errorf("%w: email is not configured", entities.ErrInvalidQuotation)
+	}
+	q, err := s.GetQuotation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.Status != entities.QuotationDraft && q.Status != entities.QuotationSent {
+		return nil, fmt.Errorf("%w: a %s quotation cannot be sent", entities.ErrQuotationStatus, q.Status)
+	}
+	now := utils.Now()
+	if q.IsExpiredAt(now) {
+		return nil, fmt.Errorf("%w: it was valid until %s, revise it with a new validity date", entities.ErrQuotationExpired, q.ValidUntil.Format("2006-01-02"))
+	}
+
+	customer, err := s.customer(ctx, q.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if to == "" && customer != nil {
+		to = customer.Email
+	}
+	if to == "" {
+		return nil, fmt.Errorf("%w: the customer has no email address", entities.ErrInvalidQuotation)
+	}
+
+	data := email.QuotationEmailData{
+		QuotationNumber: q.QuotationNumber,
+		Version:         q.Version,
+		TotalAmount:     q.Currency + " " + formatAmount(q.TotalAmount),
+		ValidUntil:      q.ValidUntil.Format("2 January 2006"),
+		LogoURL:         s.logoURL,
+	}
+	if customer != nil {
+		data.RecipientName = customer.ContactPerson
+		if data.RecipientName == "" {
+			data.RecipientName = customer.Name
+		}
+	}
+	if err := s.mailer.SendQuotationEmail(to, data, renderQuotationPDF(q, customer)); err != nil {
+		return nil, err
+	}
+
+	previous := q.Status
+	q.Status = entities.QuotationSent
+	q.SentAt = &now
+	q.SentTo = to
+	q.UpdatedAt = now
+	ok, err := s.repo.UpdateStatus(ctx, q, previous)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: the quotation was changed meanwhile", entities.ErrQuotationStatus)
+	}
+	return q, nil
+}
+
+// RenderPDF returns the quotation as a PDF document.
+func (s *SalesQuotationService) RenderPDF(ctx context.Context, id uuid.ID) (*entities.SalesQuotation, []byte, error) {
+	q, err := s.GetQuotation(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	customer, err := s.customer(ctx, q.CustomerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return q, renderQuotationPDF(q, customer), nil
+}
+
+// AcceptQuotation records that the customer accepted a sent quotation. A quotation
+// past its validity date is marked expired instead.
+func (s *SalesQuotationService) AcceptQuotation(ctx context.Context, id uuid.ID) (*entities.SalesQuotation, error) {
+	q, err := s.GetQuotation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.Status != entities.QuotationSent {
+		return nil, fmt.Errorf("%w: only sent quotations can be accepted", entities.ErrQuotationStatus)
+	}
+	now := utils.Now()
+	if q.IsExpiredAt(now) {
+		q.Status = entities.QuotationExpired
+		q.UpdatedAt = now
+		if _, err := s.repo.UpdateStatus(ctx, q, entities.QuotationSent); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: it was valid until %s", entities.ErrQuotationExpired, q.ValidUntil.Format("2006-01-02"))
+	}
+
+	q.Status = entities.QuotationAccepted
+	q.AcceptedAt = &now
+	q.UpdatedAt = now
+	ok, err := s.repo.UpdateStatus(ctx, q, entities.QuotationSent)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: the quotation was changed meanwhile", entities.ErrQuotationStatus)
+	}
+	return q, nil
+}
+
+// ReviseQuotation supersedes a sent or expired quotation with a new draft version of
+// it. The draft starts as a copy of the lines and terms and is edited before sending.
+func (s *SalesQuotationService) ReviseQuotation(ctx context.Context, id uuid.ID, createdBy string) (*entities.SalesQuotation, error) {
+	previous, err := s.GetQuotation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status != entities.QuotationSent && previous.Status != entities.QuotationExpired {
+		return nil, fmt.Errorf("%w: only sent or expired quotations can be revised", entities.ErrQuotationStatus)
+	}
+
+	now := utils.Now()
+	next := &entities.SalesQuotation{
+		QuotationNumber:   previous.QuotationNumber,
+		Version:           previous.Version + 1,
+		PreviousVersionID: &previous.ID,
+		CustomerID:        previous.CustomerID,
+		QuotationDate:     now,
+		ValidUntil:        previous.ValidUntil,
+		Status:            entities.QuotationDraft,
+		Currency:          previous.Currency,
+		PaymentTerms:      previous.PaymentTerms,
+		Notes:             previous.Notes,
+		Subtotal:          previous.Subtotal,
+		DiscountAmount:    previous.DiscountAmount,
+		TaxRate:           previous.TaxRate,
+		TaxAmount:         previous.TaxAmount,
+		TotalAmount:       previous.TotalAmount,
+		CreatedBy:         createdBy,
+	}
+	// An expired quotation is revised to make a new offer; it gets a new validity period
+	if previous.IsExpiredAt(now) {
+		next.ValidUntil = now.AddDate(0, 0, entities.QuotationDefaultValidityDays)
+	}
+	next.ID = uuid.New()
+	next.CreatedAt = now
+	next.UpdatedAt = now
+	for _, item := range previous.Items {
+		line := *item
+		next.Items = append(next.Items, &line)
+	}
+	s.assignItems(next, now)
+
+	if err := s.repo.Revise(ctx, previous, next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// ConvertToSalesOrder creates a sales order with the lines and prices of an accepted
+// quotation. The order links back to the quotation and the quotation to the order; a
+// quotation is converted once.
+func (s *SalesQuotationService) ConvertToSalesOrder(ctx context.Context, id uuid.ID) (*entities.SalesOrder, error) {
+	q, err := s.GetQuotation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.Status != entities.QuotationAccepted {
+		return nil, fmt.Errorf("%w: only accepted quotations can be converted", entities.ErrQuotationStatus)
+	}
+
+	now := utils.Now()
+	so := &entities.SalesOrder{
+		CustomerID:     q.CustomerID,
+		OrderDate:      now,
+		Status:         quotationOrderStatus,
+		Subtotal:       q.Subtotal,
+		DiscountAmount: q.DiscountAmount,
+		TaxAmount:      q.TaxAmount,
+		TotalAmount:    q.TotalAmount,
+		QuotationID:    &q.ID,
+	}
+	so.ID = uuid.New()
+	so.CreatedAt = now
+	so.UpdatedAt = now
+	items := make([]*entities.SalesOrderItem, 0, len(q.Items))
+	for _, line := range q.Items {
+		item := &entities.SalesOrderItem{
+			SalesOrderID:   so.ID.String(),
+			ArticleID:      line.ArticleID.String(),
+			Quantity:       line.Quantity,
+			UnitPrice:      line.UnitPrice,
+			DiscountAmount: line.DiscountAmount,
+			TotalPrice:     line.TotalPrice,
+		}
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		items = append(items, item)
+	}
+
+	// Claim the quotation first so two conversions cannot both create an order
+	q.Status = entities.QuotationConverted
+	q.SalesOrderID = &so.ID
+	q.UpdatedAt = now
+	ok, err := s.repo.UpdateStatus(ctx, q, entities.QuotationAccepted)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: the quotation was converted or changed meanwhile", entities.ErrQuotationStatus)
+	}
+
+	if err := s.orders.CreateSalesOrder(ctx, so, items); err != nil {
+		q.Status = entities.QuotationAccepted
+		q.SalesOrderID = nil
+		if _, undoErr := s.repo.UpdateStatus(ctx, q, entities.QuotationConverted); undoErr != nil {
+			return nil, fmt.Errorf("%w (and the quotation could not be reopened: %v)", err, undoErr)
+		}
+		return nil, err
+	}
+	return so, nil
+}
+
+// ExpireQuotations marks sent quotations past their validity date as expired. It
+// returns the number of quotations expired.
+func (s *SalesQuotationService) ExpireQuotations(ctx context.Context) (int, error) {
+	now := utils.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return s.repo.ExpireSent(ctx, today)
+}
+
+// prepare validates a quotation and prices its lines.
+func (s *SalesQuotationService) prepare(ctx context.Context, q *entities.SalesQuotation) error {
+	if _, err := uuid.Parse(q.CustomerID); err != nil {
+		return fmt.Errorf("%w: invalid customer ID", entities.ErrInvalidQuotation)
+	}
+	if q.ValidUntil.IsZero() {
+		q.ValidUntil = q.QuotationDate.AddDate(0, 0, entities.QuotationDefaultValidityDays)
+	}
+	if q.IsExpiredAt(q.QuotationDate) {
+		return fmt.Errorf("%w: the validity date is before the quotation date", entities.ErrInvalidQuotation)
+	}
+	if q.Currency == "" {
+		q.Currency = "IDR"
+	}
+	if q.TaxRate < 0 || q.TaxRate > 100 {
+		return fmt.Errorf("%w: the tax rate must be between 0 and 100 percent", entities.ErrInvalidQuotation)
+	}
+	if len(q.Items) == 0 {
+		return fmt.Errorf("%w: a quotation needs at least one line", entities.ErrInvalidQuotation)
+	}
+
+	for i, item := range q.Items {
+		if item.ArticleID.IsNil() {
+			return fmt.Errorf("%w: line %d has no article", entities.ErrInvalidQuotation, i+1)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: line %d needs a positive quantity", entities.ErrInvalidQuotation, i+1)
+		}
+		if item.UnitPrice < 0 || item.DiscountAmount < 0 || item.DiscountPercent < 0 || item.DiscountPercent > 100 {
+			return fmt.Errorf("%w: line %d has a negative price or an invalid discount", entities.ErrInvalidQuotation, i+1)
+		}
+		if err := s.priceItem(ctx, q.QuotationDate, item); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	if err := calculateQuotation(q); err != nil {
+		return err
+	}
+	return s.determineTax(ctx, q)
+}
+
+// determineTax replaces the tax of a priced quotation with the tax the rules determine
+// for its customer and lines; the tax rate becomes the tax as a percentage of the net.
+func (s *SalesQuotationService) determineTax(ctx context.Context, q *entities.SalesQuotation) error {
+	if s.taxes == nil {
+		return nil
+	}
+	lines := make([]accounting_entities.TaxCalculationLineInput, len(q.Items))
+	for i, item := range q.Items {
+		lines[i] = accounting_entities.TaxCalculationLineInput{ArticleID: item.ArticleID, Amount: item.TotalPrice}
+	}
+	calc, err := calculateTax(ctx, s.taxes, accounting_entities.TaxTransactionSale, q.CustomerID, q.QuotationDate, lines)
+	if err != nil {
+		return err
+	}
+	net := roundMoney(q.Subtotal - q.DiscountAmount)
+	q.TaxAmount = calc.TaxAmount
+	q.TaxRate = effectiveTaxRate(calc.TaxAmount, net)
+	q.TotalAmount = roundMoney(net + q.TaxAmount)
+	return nil
+}
+
+// priceItem fills in the unit price and description of a line from the master data.
+func (s *SalesQuotationService) priceItem(ctx context.Context, at time.Time, item *entities.SalesQuotationItem) error {
+	if item.UnitPrice > 0 && item.Description != "" {
+		return nil
+	}
+	if item.UnitPrice == 0 && s.prices != nil {
+		price, err := s.prices.GetEffectivePrice(ctx, item.ArticleID, at)
+		if err != nil {
+			return err
+		}
+		if price != nil {
+			item.UnitPrice = price.Amount
+		}
+	}
+	if s.articles != nil {
+		article, err := s.articles.GetArticleByID(ctx, item.ArticleID)
+		if err != nil {
+			return err
+		}
+		if article == nil {
+			return fmt.Errorf("%w: article %s does not exist", entities.ErrInvalidQuotation, item.ArticleID)
+		}
+		if item.UnitPrice == 0 {
+			item.UnitPrice = article.Price
+		}
+		if item.Description == "" {
+			item.Description = strings.TrimSpace(article.Code + " " + article.Name)
+		}
+	}
+	if item.UnitPrice <= 0 {
+		return fmt.Errorf("%w: %s", entities.ErrQuotationNoPrice, item.ArticleID)
+	}
+	return nil
+}
+
+// calculateQuotation works out the line and quotation totals. A line's discount is
+// its discount amount, or its discount percent of the line value.
+func calculateQuotation(q *entities.SalesQuotation) error {
+	q.Subtotal, q.DiscountAmount = 0, 0
+	for i, item := range q.Items {
+		gross := roundMoney(item.UnitPrice * float64(item.Quantity))
+		if item.DiscountAmount == 0 && item.DiscountPercent > 0 {
+			item.DiscountAmount = roundMoney(gross * item.DiscountPercent / 100)
+		}
+		if item.DiscountAmount > gross {
+			return fmt.Errorf("%w: the discount of line %d is more than its value", entities.ErrInvalidQuotation, i+1)
+		}
+		item.TotalPrice = roundMoney(gross - item.DiscountAmount)
+		q.Subtotal += gross
+		q.DiscountAmount += item.DiscountAmount
+	}
+	q.Subtotal = roundMoney(q.Subtotal)
+	q.DiscountAmount = roundMoney(q.DiscountAmount)
+	q.TaxAmount = roundMoney((q.Subtotal - q.DiscountAmount) * q.TaxRate / 100)
+	q.TotalAmount = roundMoney(q.Subtotal - q.DiscountAmount + q.TaxAmount)
+	return nil
+}
+
+// assignItems gives the lines of a quotation version their IDs and line numbers.
+func (s *SalesQuotationService) assignItems(q *entities.SalesQuotation, now time.Time) {
+	for i, item := range q.Items {
+		item.ID = uuid.New()
+		item.QuotationID = q.ID
+		item.LineNumber = i + 1
+		item.CreatedAt = now
+		item.UpdatedAt = now
+	}
+}
+
+func (s *SalesQuotationService) customer(ctx context.Context, customerID string) (*masterdata_entities.Customer, error) {
+	if s.customers == nil {
+		return nil, nil
+	}
+	id, err := uuid.Parse(customerID)
+	if err != nil {
+		return nil, nil
+	}
+	return s.customers.GetCustomerByID(ctx, id)
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	masterdata_entities "malaka/internal/modules/masterdata/domain/entities"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/email"
+	"malaka/internal/shared/uuid"
+)
+
+// MockSalesQuotationRepository is a mock implementation of repositories.SalesQuotationRepository.
+type MockSalesQuotationRepository struct {
+	mock.Mock
+}
+
+func (m *MockSalesQuotationRepository) NextNumber(ctx context.Context, at time.Time) (string, error) {
+	args := m.Called(ctx, at)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSalesQuotationRepository) Create(ctx context.Context, q *entities.SalesQuotation) error {
+	args := m.Called(ctx, q)
+	return args.Error(0)
+}
+
+func (m *MockSalesQuotationRepository) GetByID(ctx context.Context, id uuid.ID) (*entities.SalesQuotation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SalesQuotation), args.Error(1)
+}
+
+func (m *MockSalesQuotationRepository) List(ctx context.Context, filter entities.SalesQuotationFilter, limit, offset int) ([]*entities.SalesQuotation, int, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	return args.Get(0).([]*entities.SalesQuotation), args.Int(1), args.Error(2)
+}
+
+func (m *MockSalesQuotationRepository) GetVersions(ctx context.Context, quotationNumber string) ([]*entities.SalesQuotation, error) {
+	args := m.Called(ctx, quotationNumber)
+	return args.Get(0).([]*entities.SalesQuotation), args.Error(1)
+}
+
+func (m *MockSalesQuotationRepository) Update(ctx context.Context, q *entities.SalesQuotation) error {
+	args := m.Called(ctx, q)
+	return args.Error(0)
+}
+
+func (m *MockSalesQuotationRepository) UpdateStatus(ctx context.Context, q *entities.SalesQuotation, from ...string) (bool, error) {
+	args := m.Called(ctx, q, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSalesQuotationRepository) Revise(ctx context.Context, previous, next *entities.SalesQuotation) error {
+	args := m.Called(ctx, previous, next)
+	return args.Error(0)
+}
+
+func (m *MockSalesQuotationRepository) Delete(ctx context.Context, id uuid.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSalesQuotationRepository) ExpireSent(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(ctx, before)
+	return args.Int(0), args.Error(1)
+}
+
+// MockPriceLookup is a mock implementation of PriceLookup.
+type MockPriceLookup struct {
+	mock.Mock
+}
+
+func (m *MockPriceLookup) GetEffectivePrice(ctx context.Context, articleID uuid.ID, at time.Time) (*masterdata_entities.Price, error) {
+	args := m.Called(ctx, articleID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*masterdata_entities.Price), args.Error(1)
+}
+
+// MockArticleLookup is a mock implementation of ArticleLookup.
+type MockArticleLookup struct {
+	mock.Mock
+}
+
+func (m *MockArticleLookup) GetArticleByID(ctx context.Context, id uuid.ID) (*masterdata_entities.Article, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*masterdata_entities.Article), args.Error(1)
+}
+
+// MockCustomerLookup is a mock implementation of CustomerLookup.
+type MockCustomerLookup struct {
+	mock.Mock
+}
+
+func (m *MockCustomerLookup) GetCustomerByID(ctx context.Context, id uuid.ID) (*masterdata_entities.Customer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*masterdata_entities.Customer), args.Error(1)
+}
+
+// MockQuotationMailer is a mock implementation of QuotationMailer.
+type MockQuotationMailer struct {
+	mock.Mock
+}
+
+func (m *MockQuotationMailer) SendQuotationEmail(to string, data email.QuotationEmailData, pdf []byte) error {
+	args := m.Called(to, data, pdf)
+	return args.Error(0)
+}
+
+// MockSalesOrderRepository is a mock implementation of repositories.SalesOrderRepository.
+type MockSalesOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockSalesOrderRepository) Create(ctx context.Context, so *entities.SalesOrder) error {
+	args := m.Called(ctx, so)
+	return args.Error(0)
+}
+
+func (m *MockSalesOrderRepository) GetAll(ctx context.Context) ([]*entities.SalesOrder, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.SalesOrder), args.Error(1)
+}
+
+func (m *MockSalesOrderRepository) GetByID(ctx context.Context, id string) (*entities.SalesOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SalesOrder), args.Error(1)
+}
+
+func (m *MockSalesOrderRepository) Update(ctx context.Context, so *entities.SalesOrder) error {
+	args := m.Called(ctx, so)
+	return args.Error(0)
+}
+
+func (m *MockSalesOrderRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// newTestQuotationService returns a quotation service that mails quotations through
+// mailer.
+func newTestQuotationService(repo *MockSalesQuotationRepository, prices *MockPriceLookup, articles *MockArticleLookup, customers *MockCustomerLookup, mailer *MockQuotationMailer) *SalesQuotationService {
+	svc := NewSalesQuotationService(repo, nil, prices, articles)
+	svc.SetMailer(mailer, customers, "")
+	return svc
+}
+
+func testQuotationCustomer() *masterdata_entities.Customer {
+	customer := &masterdata_entities.Customer{Name: "PT Sepatu Nusantara", ContactPerson: "Budi", Email: "budi@sepatu.co.id"}
+	customer.ID = uuid.New()
+	return customer
+}
+
+// testQuotation returns a new quotation for ten sneakers, to be priced from the price
+// list, and four sandals at a quoted price.
+func testQuotation(customerID, sneaker, sandal uuid.ID) *entities.SalesQuotation {
+	return &entities.SalesQuotation{
+		CustomerID: customerID.String(),
+		TaxRate:    entities.QuotationDefaultTaxRate,
+		Items: []*entities.SalesQuotationItem{
+			{ArticleID: sneaker, Quantity: 10, DiscountPercent: 10},
+			{ArticleID: sandal, Quantity: 4, UnitPrice: 140000, Description: "Sandal Kulit (special)"},
+		},
+	}
+}
+
+func testSneakerPrice(sneaker uuid.ID) *masterdata_entities.Price {
+	return &masterdata_entities.Price{ArticleID: sneaker, Amount: 450000, Currency: "IDR"}
+}
+
+func testSneakerArticle() *masterdata_entities.Article {
+	return &masterdata_entities.Article{Code: "SNK-01", Name: "Sneaker Putih", Price: 400000}
+}
+
+// storedQuotation returns the copy of a quotation the repository hands out in a status.
+func storedQuotation(q *entities.SalesQuotation, status string) *entities.SalesQuotation {
+	stored := *q
+	stored.Status = status
+	return &stored
+}
+
+// quotationMovedTo matches a quotation being moved to a status.
+func quotationMovedTo(status string) interface{} {
+	return mock.MatchedBy(func(q *entities.SalesQuotation) bool {
+		return q.Status == status
+	})
+}
+
+func TestSalesQuotationService_CreateQuotation(t *testing.T) {
+	repo, prices, articles := new(MockSalesQuotationRepository), new(MockPriceLookup), new(MockArticleLookup)
+	svc := NewSalesQuotationService(repo, nil, prices, articles)
+	ctx := context.Background()
+	customer, sneaker, sandal := uuid.New(), uuid.New(), uuid.New()
+	prices.On("GetEffectivePrice", ctx, sneaker, mock.AnythingOfType("time.Time")).Return(testSneakerPrice(sneaker), nil).Once()
+	articles.On("GetArticleByID", ctx, sneaker).Return(testSneakerArticle(), nil).Once()
+	repo.On("NextNumber", ctx, mock.AnythingOfType("time.Time")).Return("QT-2026-000001", nil).Once()
+	repo.On("Create", ctx, mock.AnythingOfType("*entities.SalesQuotation")).Return(nil).Once()
+
+	q := testQuotation(customer, sneaker, sandal)
+	require.NoError(t, svc.CreateQuotation(ctx, q))
+	assert.Equal(t, entities.QuotationDraft, q.Status)
+	assert.Equal(t, 1, q.Version)
+	assert.Equal(t, "QT-2026-000001", q.QuotationNumber)
+	assert.Equal(t, q.QuotationDate.AddDate(0, 0, entities.QuotationDefaultValidityDays), q.ValidUntil)
+
+	// The sneaker is priced from the price list, the sandal keeps the quoted price
+	sneakerLine, sandalLine := q.Items[0], q.Items[1]
+	assert.Equal(t, 450000.0, sneakerLine.UnitPrice)
+	assert.Equal(t, "SNK-01 Sneaker Putih", sneakerLine.Description)
+	assert.Equal(t, 450000.0, sneakerLine.DiscountAmount)
+	assert.Equal(t, 4050000.0, sneakerLine.TotalPrice)
+	assert.Equal(t, 560000.0, sandalLine.TotalPrice)
+	assert.Equal(t, 2, sandalLine.LineNumber)
+
+	assert.Equal(t, 5060000.0, q.Subtotal)
+	assert.Equal(t, 450000.0, q.DiscountAmount)
+	assert.Equal(t, 507100.0, q.TaxAmount)
+	assert.Equal(t, 5117100.0, q.TotalAmount)
+	repo.AssertExpectations(t)
+	prices.AssertExpectations(t)
+	articles.AssertExpectations(t)
+}
+
+func TestSalesQuotationService_CreateQuotation_Invalid(t *testing.T) {
+	repo, prices, articles := new(MockSalesQuotationRepository), new(MockPriceLookup), new(MockArticleLookup)
+	svc := NewSalesQuotationService(repo, nil, prices, articles)
+	ctx := context.Background()
+	customer, sandal, unknown := uuid.New(), uuid.New(), uuid.New()
+
+	prices.On("GetEffectivePrice", ctx, unknown, mock.AnythingOfType("time.Time")).Return(nil, nil).Once()
+	articles.On("GetArticleByID", ctx, unknown).Return(nil, nil).Once()
+	q := &entities.SalesQuotation{CustomerID: customer.String(), Items: []*entities.SalesQuotationItem{{ArticleID: unknown, Quantity: 1}}}
+	assert.ErrorIs(t, svc.CreateQuotation(ctx, q), entities.ErrInvalidQuotation)
+
+	q = &entities.SalesQuotation{CustomerID: "not-a-customer", Items: []*entities.SalesQuotationItem{{ArticleID: sandal, Quantity: 1}}}
+	assert.ErrorIs(t, svc.CreateQuotation(ctx, q), entities.ErrInvalidQuotation)
+
+	prices.On("GetEffectivePrice", ctx, sandal, mock.AnythingOfType("time.Time")).Return(nil, nil).Once()
+	articles.On("GetArticleByID", ctx, sandal).Return(&masterdata_entities.Article{Code: "SDL-02", Name: "Sandal Kulit", Price: 150000}, nil).Once()
+	q = &entities.SalesQuotation{CustomerID: customer.String(), Items: []*entities.SalesQuotationItem{{ArticleID: sandal, Quantity: 1, DiscountAmount: 200000}}}
+	assert.ErrorIs(t, svc.CreateQuotation(ctx, q), entities.ErrInvalidQuotation)
+
+	q = &entities.SalesQuotation{
+		CustomerID:    customer.String(),
+		QuotationDate: time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC),
+		ValidUntil:    time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+		Items:         []*entities.SalesQuotationItem{{ArticleID: sandal, Quantity: 1}},
+	}
+	assert.ErrorIs(t, svc.CreateQuotation(ctx, q), entities.ErrInvalidQuotation)
+
+	// Without an article lookup a line needs a price list price
+	prices.On("GetEffectivePrice", ctx, sandal, mock.AnythingOfType("time.Time")).Return(nil, nil).Once()
+	unpriced := NewSalesQuotationService(repo, nil, prices, nil)
+	q = &entities.SalesQuotation{CustomerID: customer.String(), Items: []*entities.SalesQuotationItem{{ArticleID: sandal, Quantity: 1}}}
+	assert.ErrorIs(t, unpriced.CreateQuotation(ctx, q), entities.ErrQuotationNoPrice)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	prices.AssertExpectations(t)
+	articles.AssertExpectations(t)
+}
+
+func TestSalesQuotationService_SendAndAccept(t *testing.T) {
+	repo, prices, articles := new(MockSalesQuotationRepository), new(MockPriceLookup), new(MockArticleLookup)
+	customers, mailer := new(MockCustomerLookup), new(MockQuotationMailer)
+	svc := newTestQuotationService(repo, prices, articles, customers, mailer)
+	ctx := context.Background()
+	customer, sneaker, sandal := testQuotationCustomer(), uuid.New(), uuid.New()
+	prices.On("GetEffectivePrice", ctx, sneaker, mock.AnythingOfType("time.Time")).Return(testSneakerPrice(sneaker), nil).Once()
+	articles.On("GetArticleByID", ctx, sneaker).Return(testSneakerArticle(), nil).Once()
+	repo.On("NextNumber", ctx, mock.AnythingOfType("time.Time")).Return("QT-2026-000001", nil).Once()
+	repo.On("Create", ctx, mock.AnythingOfType("*entities.SalesQuotation")).Return(nil).Once()
+	q := testQuotation(customer.ID, sneaker, sandal)
+	require.NoError(t, svc.CreateQuotation(ctx, q))
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationDraft), nil).Once()
+	_, err := svc.AcceptQuotation(ctx, q.ID)
+	assert.ErrorIs(t, err, entities.ErrQuotationStatus)
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationDraft), nil).Once()
+	customers.On("GetCustomerByID", ctx, customer.ID).Return(customer, nil).Once()
+	mailer.On("SendQuotationEmail", "budi@sepatu.co.id", mock.AnythingOfType("email.QuotationEmailData"), mock.Anything).Return(nil).Once()
+	repo.On("UpdateStatus", ctx, quotationMovedTo(entities.QuotationSent), []string{entities.QuotationDraft}).Return(true, nil).Once()
+	sent, err := svc.SendQuotation(ctx, q.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, entities.QuotationSent, sent.Status)
+	assert.Equal(t, "budi@sepatu.co.id", sent.SentTo)
+	data := mailer.Calls[0].Arguments.Get(1).(email.QuotationEmailData)
+	assert.Equal(t, "Budi", data.RecipientName)
+	assert.Equal(t, "IDR 5.117.100,00", data.TotalAmount)
+	pdf := mailer.Calls[0].Arguments.Get(2).([]byte)
+	assert.True(t, len(pdf) > 0 && string(pdf[:5]) == "%PDF-")
+
+	// Sent quotations are not edited in place
+	edit := testQuotation(customer.ID, sneaker, sandal)
+	edit.ID = q.ID
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationSent), nil).Once()
+	assert.ErrorIs(t, svc.UpdateQuotation(ctx, edit), entities.ErrQuotationStatus)
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationSent), nil).Once()
+	assert.ErrorIs(t, svc.DeleteQuotation(ctx, q.ID), entities.ErrQuotationStatus)
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationSent), nil).Once()
+	repo.On("UpdateStatus", ctx, quotationMovedTo(entities.QuotationAccepted), []string{entities.QuotationSent}).Return(true, nil).Once()
+	accepted, err := svc.AcceptQuotation(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.QuotationAccepted, accepted.Status)
+	assert.NotNil(t, accepted.AcceptedAt)
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationAccepted), nil).Once()
+	_, err = svc.ReviseQuotation(ctx, q.ID, "")
+	assert.ErrorIs(t, err, entities.ErrQuotationStatus)
+	repo.AssertExpectations(t)
+	prices.AssertExpectations(t)
+	articles.AssertExpectations(t)
+	customers.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+}
+
+func TestSalesQuotationService_AcceptExpired(t *testing.T) {
+	repo, customers, mailer := new(MockSalesQuotationRepository), new(MockCustomerLookup), new(MockQuotationMailer)
+	svc := NewSalesQuotationService(repo, nil, nil, nil)
+	svc.SetMailer(mailer, customers, "")
+	ctx := context.Background()
+	customer := testQuotationCustomer()
+	q := testQuotation(customer.ID, uuid.New(), uuid.New())
+	q.Items = q.Items[1:]
+	repo.On("NextNumber", ctx, mock.AnythingOfType("time.Time")).Return("QT-2026-000001", nil).Once()
+	repo.On("Create", ctx, mock.AnythingOfType("*entities.SalesQuotation")).Return(nil).Once()
+	require.NoError(t, svc.CreateQuotation(ctx, q))
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationDraft), nil).Once()
+	customers.On("GetCustomerByID", ctx, customer.ID).Return(customer, nil).Once()
+	mailer.On("SendQuotationEmail", "purchasing@sepatu.co.id", mock.AnythingOfType("email.QuotationEmailData"), mock.Anything).Return(nil).Once()
+	repo.On("UpdateStatus", ctx, quotationMovedTo(entities.QuotationSent), []string{entities.QuotationDraft}).Return(true, nil).Once()
+	_, err := svc.SendQuotation(ctx, q.ID, "purchasing@sepatu.co.id")
+	require.NoError(t, err)
+
+	// The quotation is marked expired instead of accepted
+	lapsed := storedQuotation(q, entities.QuotationSent)
+	lapsed.ValidUntil = time.Now().AddDate(0, 0, -1)
+	repo.On("GetByID", ctx, q.ID).Return(lapsed, nil).Once()
+	repo.On("UpdateStatus", ctx, quotationMovedTo(entities.QuotationExpired), []string{entities.QuotationSent}).Return(true, nil).Once()
+	_, err = svc.AcceptQuotation(ctx, q.ID)
+	assert.ErrorIs(t, err, entities.ErrQuotationExpired)
+	repo.AssertExpectations(t)
+	customers.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+}
+
+func TestSalesQuotationService_ReviseQuotation(t *testing.T) {
+	repo, prices, articles := new(MockSalesQuotationRepository), new(MockPriceLookup), new(MockArticleLookup)
+	customers, mailer := new(MockCustomerLookup), new(MockQuotationMailer)
+	svc := newTestQuotationService(repo, prices, articles, customers, mailer)
+	ctx := context.Background()
+	customer, sneaker, sandal := testQuotationCustomer(), uuid.New(), uuid.New()
+	prices.On("GetEffectivePrice", ctx, sneaker, mock.AnythingOfType("time.Time")).Return(testSneakerPrice(sneaker), nil).Once()
+	articles.On("GetArticleByID", ctx, sneaker).Return(testSneakerArticle(), nil).Once()
+	repo.On("NextNumber", ctx, mock.AnythingOfType("time.Time")).Return("QT-2026-000001", nil).Once()
+	repo.On("Create", ctx, mock.AnythingOfType("*entities.SalesQuotation")).Return(nil).Once()
+	q := testQuotation(customer.ID, sneaker, sandal)
+	require.NoError(t, svc.CreateQuotation(ctx, q))
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationDraft), nil).Once()
+	_, err := svc.ReviseQuotation(ctx, q.ID, "")
+	assert.ErrorIs(t, err, entities.ErrQuotationStatus)
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationDraft), nil).Once()
+	customers.On("GetCustomerByID", ctx, customer.ID).Return(customer, nil).Once()
+	mailer.On("SendQuotationEmail", customer.Email, mock.AnythingOfType("email.QuotationEmailData"), mock.Anything).Return(nil).Once()
+	repo.On("UpdateStatus", ctx, quotationMovedTo(entities.QuotationSent), []string{entities.QuotationDraft}).Return(true, nil).Once()
+	_, err = svc.SendQuotation(ctx, q.ID, "")
+	require.NoError(t, err)
+
+	repo.On("ExpireSent", ctx, mock.AnythingOfType("time.Time")).Return(1, nil).Once()
+	expired, err := svc.ExpireQuotations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	before := repo.Calls[len(repo.Calls)-1].Arguments.Get(1).(time.Time)
+	assert.Equal(t, 0, before.Hour(), "quotations valid until today are not expired yet")
+
+	previous := storedQuotation(q, entities.QuotationExpired)
+	previous.ValidUntil = time.Now().AddDate(0, 0, -3)
+	repo.On("GetByID", ctx, q.ID).Return(previous, nil).Once()
+	repo.On("Revise", ctx, previous, mock.AnythingOfType("*entities.SalesQuotation")).Return(nil).Once()
+	revision, err := svc.ReviseQuotation(ctx, q.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, q.QuotationNumber, revision.QuotationNumber)
+	assert.Equal(t, 2, revision.Version)
+	assert.Equal(t, entities.QuotationDraft, revision.Status)
+	assert.Equal(t, q.ID, *revision.PreviousVersionID)
+	assert.False(t, revision.IsExpiredAt(time.Now()))
+	require.Len(t, revision.Items, 2)
+	assert.NotEqual(t, q.Items[0].ID, revision.Items[0].ID)
+	assert.Equal(t, revision.ID, revision.Items[0].QuotationID)
+
+	repo.On("GetByID", ctx, revision.ID).Return(storedQuotation(revision, entities.QuotationDraft), nil).Once()
+	repo.On("GetVersions", ctx, q.QuotationNumber).Return([]*entities.SalesQuotation{previous, revision}, nil).Once()
+	versions, err := svc.GetVersions(ctx, revision.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, q.ID, versions[0].ID)
+
+	// The draft revision can be edited; its lines are priced already
+	repo.On("GetByID", ctx, revision.ID).Return(storedQuotation(revision, entities.QuotationDraft), nil).Once()
+	repo.On("Update", ctx, revision).Return(nil).Once()
+	revision.Items[0].Quantity = 20
+	revision.Items[0].UnitPrice = 440000
+	revision.Items[0].DiscountAmount = 0
+	require.NoError(t, svc.UpdateQuotation(ctx, revision))
+	assert.Equal(t, 2, revision.Version)
+	assert.Equal(t, 8800000.0-880000.0, revision.Items[0].TotalPrice)
+	repo.AssertExpectations(t)
+	prices.AssertExpectations(t)
+	articles.AssertExpectations(t)
+	customers.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+}
+
+func TestSalesQuotationService_ConvertToSalesOrder(t *testing.T) {
+	repo, orders := new(MockSalesQuotationRepository), new(MockSalesOrderRepository)
+	svc := NewSalesQuotationService(repo, NewSalesOrderService(orders, nil, nil), nil, nil)
+	ctx := context.Background()
+	q := testQuotation(uuid.New(), uuid.New(), uuid.New())
+	q.Items = q.Items[1:]
+	repo.On("NextNumber", ctx, mock.AnythingOfType("time.Time")).Return("QT-2026-000001", nil).Once()
+	repo.On("Create", ctx, mock.AnythingOfType("*entities.SalesQuotation")).Return(nil).Once()
+	require.NoError(t, svc.CreateQuotation(ctx, q))
+
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationSent), nil).Once()
+	_, err := svc.ConvertToSalesOrder(ctx, q.ID)
+	assert.ErrorIs(t, err, entities.ErrQuotationStatus)
+
+	// The quotation is reopened when the order cannot be created
+	repo.On("GetByID", ctx, q.ID).Return(storedQuotation(q, entities.QuotationAccepted), nil).Once()
+	repo.On("UpdateStatus", ctx, quotationMovedTo(entities.QuotationConverted), []string{entities.QuotationAccepted}).Return(true, nil).Once()
+	orders.On("Create", ctx, mock.AnythingOfType("*entities.SalesOrder")).Return(errors.New("database is down")).Once()
+	repo.On("UpdateStatus", ctx, mock.MatchedBy(func(reopened *entities.SalesQuotation) bool {
+		return reopened.Status == entities.QuotationAccepted && reopened.SalesOrderID == nil
+	}), []string{entities.QuotationConverted}).Return(true, nil).Once()
+	_, err = svc.ConvertToSalesOrder(ctx, q.ID)
+	assert.EqualError(t, err, "database is down")
+	order := orders.Calls[0].Arguments.Get(1).(*entities.SalesOrder)
+	assert.Equal(t, q.ID, *order.QuotationID)
+	assert.Equal(t, q.TotalAmount, order.TotalAmount)
+	repo.AssertExpectations(t)
+	orders.AssertExpectations(t)
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "0,00", formatAmount(0))
+	assert.Equal(t, "999,50", formatAmount(999.5))
+	assert.Equal(t, "1.250.000,00", formatAmount(1250000))
+	assert.Equal(t, "-45.000,25", formatAmount(-45000.25))
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/repositories"
+	"malaka/internal/shared/utils"
+	"malaka/internal/shared/uuid"
+)
+
+// SalesReconciliationService reconciles each day's sales with what was settled for
+// them: POS card, QRIS and e-wallet payments against the settlement files of the bank
+// or provider, and marketplace channel orders against the channel's payout reports.
+// Settled lines are matched by reference, then by amount; fees and MDR are worked out
+// and whatever does not match becomes a discrepancy for finance to resolve.
+type SalesReconciliationService struct {
+	repo     repositories.SalesReconciliationRepository
+	channels repositories.MarketplaceRepository
+}
+
+// NewSalesReconciliationService creates a new SalesReconciliationService.
+func NewSalesReconciliationService(repo repositories.SalesReconciliationRepository, channels repositories.MarketplaceRepository) *SalesReconciliationService {
+	return &SalesReconciliationService{repo: repo, channels: channels}
+}
+
+// ImportSettlement stores a settlement or payout file uploaded as CSV (see
+// ParseSettlementCSV). Marketplace payouts belong to a channel and need the channel's
+// order ID on every line; the same file cannot be imported twice.
+func (s *SalesReconciliationService) ImportSettlement(ctx context.Context, source, provider string, channelID *uuid.ID,
+	r io.Reader, fileName, userID string) (*entities.SettlementImport, error) {
+	provider = strings.TrimSpace(provider)
+	switch {
+	case source == entities.SettlementMarketplace:
+		if channelID == nil {
+			return nil, fmt.Errorf("%w: marketplace payouts need a channel", entities.ErrInvalidSettlementImport)
+		}
+		ch, err := s.channels.GetChannel(ctx, *channelID)
+		if err != nil {
+			return nil, err
+		}
+		if ch == nil {
+			return nil, entities.ErrChannelNotFound
+		}
+		if provider == "" {
+			provider = ch.Code
+		}
+	case entities.IsPosSettlementSource(source):
+		channelID = nil
+		if provider == "" {
+			return nil, fmt.Errorf("%w: the bank or provider that settled is required", entities.ErrInvalidSettlementImport)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown source %q", entities.ErrInvalidSettlementImport, source)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidSettlementImport, err)
+	}
+	fileLines, err := ParseSettlementCSV(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidSettlementImport, err)
+	}
+	hash := sha256.Sum256(data)
+
+	imp := &entities.SettlementImport{
+		ID:         uuid.New(),
+		Source:     source,
+		Provider:   provider,
+		ChannelID:  channelID,
+		FileName:   fileName,
+		FileHash:   hex.EncodeToString(hash[:]),
+		ImportedBy: userID,
+		ImportedAt: utils.Now(),
+	}
+	var missingOrders []string
+	for _, fl := range fileLines {
+		if source == entities.SettlementMarketplace && fl.OrderID == "" {
+			missingOrders = append(missingOrders, fmt.Sprintf("line %d", fl.Line))
+			continue
+		}
+		imp.Lines = append(imp.Lines, &entities.SettlementLine{
+			ID:              uuid.New(),
+			ImportID:        imp.ID,
+			Source:          source,
+			ChannelID:       channelID,
+			LineNumber:      fl.Line,
+			TransactionDate: fl.TransactionDate,
+			SettlementDate:  fl.SettlementDate,
+			Reference:       fl.Reference,
+			ExternalOrderID: fl.OrderID,
+			GrossAmount:     fl.GrossAmount,
+			FeeAmount:       fl.FeeAmount,
+			NetAmount:       fl.NetAmount,
+		})
+		imp.GrossAmount += fl.GrossAmount
+		imp.FeeAmount += fl.FeeAmount
+		imp.NetAmount += fl.NetAmount
+	}
+	if len(missingOrders) > 0 {
+		return nil, fmt.Errorf("%w: no order ID on %s", entities.ErrInvalidSettlementImport, strings.Join(missingOrders, ", "))
+	}
+	imp.LineCount = len(imp.Lines)
+	imp.GrossAmount = roundMoney(imp.GrossAmount)
+	imp.FeeAmount = roundMoney(imp.FeeAmount)
+	imp.NetAmount = roundMoney(imp.NetAmount)
+
+	if err := s.repo.CreateImport(ctx, imp); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// GetImport returns a settlement import with its lines.
+func (s *SalesReconciliationService) GetImport(ctx context.Context, id uuid.ID) (*entities.SettlementImport, error) {
+	imp, err := s.repo.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp == nil {
+		return nil, entities.ErrSettlementImportNotFound
+	}
+	return imp, nil
+}
+
+// ListImports returns the settlement imports, of a source when given.
+func (s *SalesReconciliationService) ListImports(ctx context.Context, source string) ([]*entities.SettlementImport, error) {
+	return s.repo.ListImports(ctx, source)
+}
+
+// Reconcile reconciles the sales of a day paid through a POS source, or the payouts a
+// channel made on the day, replacing an earlier reconciliation of the same day.
+func (s *SalesReconciliationService) Reconcile(ctx context.Context, day time.Time, source string, channelID *uuid.ID, userID string) (*entities.SalesReconciliation, error) {
+	if day.IsZero() {
+		return nil, fmt.Errorf("%w: the business date is required", entities.ErrInvalidReconciliation)
+	}
+	day = businessDay(day)
+	if day.After(businessDay(utils.Now())) {
+		return nil, fmt.Errorf("%w: %s has not happened yet", entities.ErrInvalidReconciliation, day.Format("2006-01-02"))
+	}
+
+	var rec *entities.SalesReconciliation
+	var err error
+	switch {
+	case entities.IsPosSettlementSource(source):
+		rec, err = s.reconcilePos(ctx, day, source)
+	case source == entities.SettlementMarketplace:
+		if channelID == nil {
+			return nil, fmt.Errorf("%w: marketplace reconciliation needs a channel", entities.ErrInvalidReconciliation)
+		}
+		rec, err = s.reconcileChannel(ctx, day, *channelID)
+	default:
+		return nil, fmt.Errorf("%w: unknown source %q", entities.ErrInvalidReconciliation, source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rec.Lines) == 0 {
+		return nil, fmt.Errorf("%w: no sales or settlements of %s on %s", entities.ErrInvalidReconciliation, source, day.Format("2006-01-02"))
+	}
+
+	rec.CreatedBy = userID
+	if err := s.repo.SaveReconciliation(ctx, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// ReconcileDay reconciles every POS source and active channel for a day. Sources with
+// nothing to reconcile are skipped and one that fails is logged; it returns the
+// reconciliations made.
+func (s *SalesReconciliationService) ReconcileDay(ctx context.Context, day time.Time, userID string) ([]*entities.SalesReconciliation, error) {
+	channels, err := s.channels.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type target struct {
+		source    string
+		channelID *uuid.ID
+		name      string
+	}
+	var targets []target
+	for _, source := range entities.PosSettlementSources {
+		targets = append(targets, target{source: source, name: source})
+	}
+	for _, ch := range channels {
+		if ch.Status == entities.ChannelActive {
+			id := ch.ID
+			targets = append(targets, target{source: entities.SettlementMarketplace, channelID: &id, name: ch.Code})
+		}
+	}
+
+	var reconciled []*entities.SalesReconciliation
+	for _, t := range targets {
+		rec, err := s.Reconcile(ctx, day, t.source, t.channelID, userID)
+		if err != nil {
+			if !errors.Is(err, entities.ErrInvalidReconciliation) {
+				log.Printf("[Sales] Failed to reconcile %s of %s: %v", t.name, day.Format("2006-01-02"), err)
+			}
+			continue
+		}
+		reconciled = append(reconciled, rec)
+	}
+	return reconciled, nil
+}
+
+// ReconcileYesterday reconciles yesterday's sales; the daily job runs it once the
+// providers' settlement files are in.
+func (s *SalesReconciliationService) ReconcileYesterday(ctx context.Context) ([]*entities.SalesReconciliation, error) {
+	return s.ReconcileDay(ctx, utils.Now().AddDate(0, 0, -1), "system")
+}
+
+// GetReconciliation returns a reconciliation with its lines and discrepancies.
+func (s *SalesReconciliationService) GetReconciliation(ctx context.Context, id uuid.ID) (*entities.SalesReconciliation, error) {
+	rec, err := s.repo.GetReconciliation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, entities.ErrReconciliationNotFound
+	}
+	return rec, nil
+}
+
+// ListReconciliations returns the reconciliations of the business days in the range.
+func (s *SalesReconciliationService) ListReconciliations(ctx context.Context, from, to time.Time) ([]*entities.SalesReconciliation, error) {
+	if to.IsZero() {
+		to = utils.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: the range ends before it starts", entities.ErrInvalidReconciliation)
+	}
+	return s.repo.ListReconciliations(ctx, businessDay(from), businessDay(to))
+}
+
+// ListDiscrepancies returns the discrepancies, of a status when given.
+func (s *SalesReconciliationService) ListDiscrepancies(ctx context.Context, status string) ([]*entities.ReconciliationDiscrepancy, error) {
+	return s.repo.ListDiscrepancies(ctx, status)
+}
+
+// ResolveDiscrepancy records how finance resolved a discrepancy.
+func (s *SalesReconciliationService) ResolveDiscrepancy(ctx context.Context, id uuid.ID, resolution, userID string) (*entities.ReconciliationDiscrepancy, error) {
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" {
+		return nil, fmt.Errorf("%w: a resolution is required", entities.ErrInvalidReconciliation)
+	}
+	d, err := s.repo.GetDiscrepancy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, entities.ErrDiscrepancyNotFound
+	}
+	if d.Status != entities.DiscrepancyOpen {
+		return nil, entities.ErrDiscrepancyResolved
+	}
+
+	now := utils.Now()
+	d.Status = entities.DiscrepancyResolved
+	d.Resolution = resolution
+	d.ResolvedBy = userID
+	d.ResolvedAt = &now
+	if err := s.repo.ResolveDiscrepancy(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *SalesReconciliationService) reconcilePos(ctx context.Context, day time.Time, source string) (*entities.SalesReconciliation, error) {
+	payments, err := s.repo.ListPosPayments(ctx, source, day)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.repo.ListSettlementLines(ctx, source, day)
+	if err != nil {
+		return nil, err
+	}
+	b := newReconciliationBuilder(day, source, nil)
+	b.matchPayments(payments, lines)
+	return b.finish(), nil
+}
+
+func (s *SalesReconciliationService) reconcileChannel(ctx context.Context, day time.Time, channelID uuid.ID) (*entities.SalesReconciliation, error) {
+	ch, err := s.channels.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, entities.ErrChannelNotFound
+	}
+	lines, err := s.repo.ListPayoutLines(ctx, ch.ID, day)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(lines))
+	for _, l := range lines {
+		ids = append(ids, l.ExternalOrderID)
+	}
+	orders, err := s.repo.GetChannelOrders(ctx, ch.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+	unpaid, err := s.repo.ListUnpaidChannelOrders(ctx, ch.ID, day.AddDate(0, 0, -entities.MarketplacePayoutDays))
+	if err != nil {
+		return nil, err
+	}
+	b := newReconciliationBuilder(day, entities.SettlementMarketplace, &ch.ID)
+	b.matchPayouts(orders, lines, unpaid)
+	return b.finish(), nil
+}
+
+// reconciliationBuilder adds up the lines of a reconciliation as they are matched.
+type reconciliationBuilder struct {
+	rec *entities.SalesReconciliation
+}
+
+func newReconciliationBuilder(day time.Time, source string, channelID *uuid.ID) *reconciliationBuilder {
+	return &reconciliationBuilder{rec: &entities.SalesReconciliation{
+		ID:              uuid.New(),
+		BusinessDate:    day,
+		Source:          source,
+		ChannelID:       channelID,
+		CreatedAt:       utils.Now(),
+		SettlementLinks: map[uuid.ID]uuid.ID{},
+	}}
+}
+
+// matchPayments matches settlement lines to POS payments by reference, then the lines
+// left to payments of the same amount.
+func (b *reconciliationBuilder) matchPayments(payments []*entities.ReconciliationPayment, lines []*entities.SettlementLine) {
+	byReference := map[string][]int{}
+	for i, p := range payments {
+		if ref := normalizeReference(p.Reference); ref != "" {
+			byReference[ref] = append(byReference[ref], i)
+		}
+	}
+	settled := make([]*entities.SettlementLine, len(payments))
+
+	var rest []*entities.SettlementLine
+	for _, l := range lines {
+		matched := false
+		for _, i := range byReference[normalizeReference(l.Reference)] {
+			if settled[i] == nil {
+				settled[i], matched = l, true
+				break
+			}
+		}
+		if !matched {
+			rest = append(rest, l)
+		}
+	}
+	var unexpected []*entities.SettlementLine
+	for _, l := range rest {
+		matched := false
+		for i, p := range payments {
+			if settled[i] == nil && sameAmount(p.Amount, l.GrossAmount) {
+				settled[i], matched = l, true
+				break
+			}
+		}
+		if !matched {
+			unexpected = append(unexpected, l)
+		}
+	}
+
+	for i, p := range payments {
+		id := p.ID
+		ref := p.Reference
+		if ref == "" {
+			ref = p.ReceiptNumber
+		}
+		l := settled[i]
+		switch {
+		case l == nil:
+			b.add(entities.ReconMissingSettlement, ref, p.Amount, nil, &id, nil)
+		case sameAmount(p.Amount, l.GrossAmount):
+			b.add(entities.ReconMatched, ref, p.Amount, []*entities.SettlementLine{l}, &id, nil)
+		default:
+			b.add(entities.ReconAmountMismatch, ref, p.Amount, []*entities.SettlementLine{l}, &id, nil)
+		}
+	}
+	for _, l := range unexpected {
+		b.add(entities.ReconUnexpectedSettlement, l.Reference, 0, []*entities.SettlementLine{l}, nil, nil)
+	}
+}
+
+// matchPayouts matches payout lines to channel orders by order ID; an order paid out in
+// several lines (e.g. an adjustment) is compared with their total. Shipped orders still
+// unpaid after the payout term are reported missing.
+func (b *reconciliationBuilder) matchPayouts(orders map[string]*entities.ReconciliationOrder, lines []*entities.SettlementLine,
+	unpaid []*entities.ReconciliationOrder) {
+	var ids []string
+	byOrder := map[string][]*entities.SettlementLine{}
+	for _, l := range lines {
+		if _, seen := byOrder[l.ExternalOrderID]; !seen {
+			ids = append(ids, l.ExternalOrderID)
+		}
+		byOrder[l.ExternalOrderID] = append(byOrder[l.ExternalOrderID], l)
+	}
+
+	for _, externalID := range ids {
+		settled := byOrder[externalID]
+		order := orders[externalID]
+		if order == nil {
+			b.add(entities.ReconUnexpectedSettlement, externalID, 0, settled, nil, nil)
+			continue
+		}
+		// A cancelled order should not have been paid out
+		expected := order.TotalAmount
+		if order.Status == entities.OnlineOrderCancelled {
+			expected = 0
+		}
+		gross := 0.0
+		for _, l := range settled {
+			gross += l.GrossAmount
+		}
+		id := order.ID
+		status := entities.ReconMatched
+		if !sameAmount(gross, expected) {
+			status = entities.ReconAmountMismatch
+		}
+		b.add(status, externalID, expected, settled, nil, &id)
+	}
+	for _, order := range unpaid {
+		id := order.ID
+		b.add(entities.ReconMissingPayout, order.OrderID, order.TotalAmount, nil, nil, &id)
+	}
+}
+
+func (b *reconciliationBuilder) add(status, reference string, expected float64, settled []*entities.SettlementLine,
+	posPaymentID, onlineOrderID *uuid.ID) {
+	line := &entities.ReconciliationLine{
+		ID:               uuid.New(),
+		ReconciliationID: b.rec.ID,
+		MatchStatus:      status,
+		PosPaymentID:     posPaymentID,
+		OnlineOrderID:    onlineOrderID,
+		Reference:        reference,
+		ExpectedAmount:   roundMoney(expected),
+	}
+	for _, l := range settled {
+		line.SettledAmount += l.GrossAmount
+		line.FeeAmount += l.FeeAmount
+		line.NetAmount += l.NetAmount
+		b.rec.SettlementLinks[l.ID] = line.ID
+	}
+	line.SettledAmount = roundMoney(line.SettledAmount)
+	line.FeeAmount = roundMoney(line.FeeAmount)
+	line.NetAmount = roundMoney(line.NetAmount)
+	line.FeeRate = feeRate(line.FeeAmount, line.SettledAmount)
+	line.Difference = roundMoney(line.SettledAmount - line.ExpectedAmount)
+	b.rec.Lines = append(b.rec.Lines, line)
+
+	if status == entities.ReconMatched {
+		b.rec.MatchedCount++
+		return
+	}
+	b.rec.UnmatchedCount++
+	b.rec.Discrepancies = append(b.rec.Discrepancies, &entities.ReconciliationDiscrepancy{
+		ID:               uuid.New(),
+		ReconciliationID: b.rec.ID,
+		LineID:           line.ID,
+		BusinessDate:     b.rec.BusinessDate,
+		Source:           b.rec.Source,
+		Type:             status,
+		Reference:        reference,
+		Amount:           line.Difference,
+		Status:           entities.DiscrepancyOpen,
+		CreatedAt:        b.rec.CreatedAt,
+	})
+}
+
+func (b *reconciliationBuilder) finish() *entities.SalesReconciliation {
+	rec := b.rec
+	for _, line := range rec.Lines {
+		rec.ExpectedAmount += line.ExpectedAmount
+		rec.SettledAmount += line.SettledAmount
+		rec.FeeAmount += line.FeeAmount
+		rec.NetAmount += line.NetAmount
+	}
+	rec.ExpectedAmount = roundMoney(rec.ExpectedAmount)
+	rec.SettledAmount = roundMoney(rec.SettledAmount)
+	rec.FeeAmount = roundMoney(rec.FeeAmount)
+	rec.NetAmount = roundMoney(rec.NetAmount)
+	rec.MDRRate = feeRate(rec.FeeAmount, rec.SettledAmount)
+	rec.Difference = roundMoney(rec.SettledAmount - rec.ExpectedAmount)
+	rec.Status = entities.ReconciliationReconciled
+	if rec.UnmatchedCount > 0 {
+		rec.Status = entities.ReconciliationDiscrepancies
+	}
+	return rec
+}
+
+// feeRate returns the fee as a percentage of the gross settled.
+func feeRate(fee, gross float64) float64 {
+	if gross == 0 {
+		return 0
+	}
+	return roundMoney(fee / gross * 100)
+}
+
+func sameAmount(a, b float64) bool {
+	return roundMoney(a) == roundMoney(b)
+}
+
+// normalizeReference lets references match regardless of case, spacing and leading zeros
+// some terminals pad approval codes with.
+func normalizeReference(ref string) string {
+	ref = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(ref), " ", ""))
+	if trimmed := strings.TrimLeft(ref, "0"); trimmed != "" {
+		return trimmed
+	}
+	return ref
+}
+
+// businessDay returns the start of the local day of t.
+func businessDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
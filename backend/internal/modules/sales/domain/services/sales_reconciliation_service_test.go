@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MockSalesReconciliationRepository is a mock implementation of repositories.SalesReconciliationRepository.
+type MockSalesReconciliationRepository struct {
+	mock.Mock
+}
+
+func (m *MockSalesReconciliationRepository) CreateImport(ctx context.Context, imp *entities.SettlementImport) error {
+	args := m.Called(ctx, imp)
+	return args.Error(0)
+}
+
+func (m *MockSalesReconciliationRepository) GetImport(ctx context.Context, id uuid.ID) (*entities.SettlementImport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SettlementImport), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ListImports(ctx context.Context, source string) ([]*entities.SettlementImport, error) {
+	args := m.Called(ctx, source)
+	return args.Get(0).([]*entities.SettlementImport), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ListPosPayments(ctx context.Context, method string, day time.Time) ([]*entities.ReconciliationPayment, error) {
+	args := m.Called(ctx, method, day)
+	return args.Get(0).([]*entities.ReconciliationPayment), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ListSettlementLines(ctx context.Context, source string, day time.Time) ([]*entities.SettlementLine, error) {
+	args := m.Called(ctx, source, day)
+	return args.Get(0).([]*entities.SettlementLine), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ListPayoutLines(ctx context.Context, channelID uuid.ID, day time.Time) ([]*entities.SettlementLine, error) {
+	args := m.Called(ctx, channelID, day)
+	return args.Get(0).([]*entities.SettlementLine), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) GetChannelOrders(ctx context.Context, channelID uuid.ID, externalIDs []string) (map[string]*entities.ReconciliationOrder, error) {
+	args := m.Called(ctx, channelID, externalIDs)
+	return args.Get(0).(map[string]*entities.ReconciliationOrder), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ListUnpaidChannelOrders(ctx context.Context, channelID uuid.ID, day time.Time) ([]*entities.ReconciliationOrder, error) {
+	args := m.Called(ctx, channelID, day)
+	return args.Get(0).([]*entities.ReconciliationOrder), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) SaveReconciliation(ctx context.Context, rec *entities.SalesReconciliation) error {
+	args := m.Called(ctx, rec)
+	return args.Error(0)
+}
+
+func (m *MockSalesReconciliationRepository) GetReconciliation(ctx context.Context, id uuid.ID) (*entities.SalesReconciliation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.SalesReconciliation), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ListReconciliations(ctx context.Context, from, to time.Time) ([]*entities.SalesReconciliation, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]*entities.SalesReconciliation), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) GetDiscrepancy(ctx context.Context, id uuid.ID) (*entities.ReconciliationDiscrepancy, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ReconciliationDiscrepancy), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ListDiscrepancies(ctx context.Context, status string) ([]*entities.ReconciliationDiscrepancy, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]*entities.ReconciliationDiscrepancy), args.Error(1)
+}
+
+func (m *MockSalesReconciliationRepository) ResolveDiscrepancy(ctx context.Context, d *entities.ReconciliationDiscrepancy) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+func settlementLine(reference string, gross, fee float64) *entities.SettlementLine {
+	return &entities.SettlementLine{ID: uuid.New(), Reference: reference, GrossAmount: gross, FeeAmount: fee, NetAmount: gross - fee}
+}
+
+func payoutLine(orderID string, gross, fee float64) *entities.SettlementLine {
+	l := settlementLine("", gross, fee)
+	l.ExternalOrderID = orderID
+	return l
+}
+
+func linesByStatus(rec *entities.SalesReconciliation) map[string][]*entities.ReconciliationLine {
+	byStatus := map[string][]*entities.ReconciliationLine{}
+	for _, l := range rec.Lines {
+		byStatus[l.MatchStatus] = append(byStatus[l.MatchStatus], l)
+	}
+	return byStatus
+}
+
+func TestParseSettlementCSV(t *testing.T) {
+	t.Run("semicolon file with decimal comma and summed fees", func(t *testing.T) {
+		csv := "Tanggal;Approval Code;Nominal;MDR;Admin Fee\n" +
+			"05/03/2025;001234;150.000,00;1.500,00;500\n" +
+			"05/03/2025;001235;(20.000,00);0;0\n"
+
+		lines, err := ParseSettlementCSV(strings.NewReader(csv))
+		require.NoError(t, err)
+		require.Len(t, lines, 2)
+		assert.Equal(t, 2, lines[0].Line)
+		assert.Equal(t, "001234", lines[0].Reference)
+		assert.Equal(t, 150000.0, lines[0].GrossAmount)
+		assert.Equal(t, 2000.0, lines[0].FeeAmount)
+		assert.Equal(t, 148000.0, lines[0].NetAmount)
+		assert.Equal(t, lines[0].TransactionDate, lines[0].SettlementDate)
+		assert.Equal(t, -20000.0, lines[1].GrossAmount)
+	})
+
+	t.Run("fee is worked out from gross and net", func(t *testing.T) {
+		csv := "\ufefforder_id,order_date,payout_date,amount,payout\nINV-1,2025-03-01,2025-03-15,100000,95500\n"
+
+		lines, err := ParseSettlementCSV(strings.NewReader(csv))
+		require.NoError(t, err)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "INV-1", lines[0].OrderID)
+		assert.Equal(t, 4500.0, lines[0].FeeAmount)
+		assert.Equal(t, 15, lines[0].SettlementDate.Day())
+		assert.Equal(t, 1, lines[0].TransactionDate.Day())
+	})
+
+	t.Run("gross is worked out from net and a negative fee", func(t *testing.T) {
+		lines, err := ParseSettlementCSV(strings.NewReader("date,net,fee\n2025-03-01,99000,-1000\n"))
+		require.NoError(t, err)
+		require.Len(t, lines, 1)
+		assert.Equal(t, 100000.0, lines[0].GrossAmount)
+		assert.Equal(t, 1000.0, lines[0].FeeAmount)
+	})
+
+	t.Run("rejects files without a date or amount", func(t *testing.T) {
+		_, err := ParseSettlementCSV(strings.NewReader("reference,amount\nA,1000\n"))
+		assert.Error(t, err)
+		_, err = ParseSettlementCSV(strings.NewReader("date,reference\n2025-03-01,A\n"))
+		assert.Error(t, err)
+		_, err = ParseSettlementCSV(strings.NewReader("date,amount\nyesterday,1000\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestSalesReconciliationService_ImportSettlement(t *testing.T) {
+	ctx := context.Background()
+	csv := "date,reference,amount,mdr\n2025-03-01,A1,100000,1000\n2025-03-01,A2,50000,500\n"
+
+	t.Run("stores the lines with their totals once", func(t *testing.T) {
+		repo := new(MockSalesReconciliationRepository)
+		repo.On("CreateImport", mock.Anything, mock.AnythingOfType("*entities.SettlementImport")).Return(nil).Once()
+		repo.On("CreateImport", mock.Anything, mock.AnythingOfType("*entities.SettlementImport")).Return(entities.ErrSettlementImportExists).Once()
+		svc := NewSalesReconciliationService(repo, new(MockMarketplaceRepository))
+
+		imp, err := svc.ImportSettlement(ctx, entities.SettlementCard, " BCA ", nil, strings.NewReader(csv), "bca.csv", "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "BCA", imp.Provider)
+		assert.Equal(t, 2, imp.LineCount)
+		assert.Equal(t, 150000.0, imp.GrossAmount)
+		assert.Equal(t, 1500.0, imp.FeeAmount)
+		assert.Equal(t, 148500.0, imp.NetAmount)
+		assert.Len(t, imp.FileHash, 64)
+		assert.Equal(t, imp.ID, imp.Lines[0].ImportID)
+
+		_, err = svc.ImportSettlement(ctx, entities.SettlementCard, "BCA", nil, strings.NewReader(csv), "bca-again.csv", "user-1")
+		assert.ErrorIs(t, err, entities.ErrSettlementImportExists)
+		again := repo.Calls[1].Arguments.Get(1).(*entities.SettlementImport)
+		assert.Equal(t, imp.FileHash, again.FileHash)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("validates source, provider and channel", func(t *testing.T) {
+		repo, channels := new(MockSalesReconciliationRepository), new(MockMarketplaceRepository)
+		svc := NewSalesReconciliationService(repo, channels)
+
+		_, err := svc.ImportSettlement(ctx, "cash", "BCA", nil, strings.NewReader(csv), "f.csv", "user-1")
+		assert.ErrorIs(t, err, entities.ErrInvalidSettlementImport)
+		_, err = svc.ImportSettlement(ctx, entities.SettlementQRIS, "", nil, strings.NewReader(csv), "f.csv", "user-1")
+		assert.ErrorIs(t, err, entities.ErrInvalidSettlementImport)
+		_, err = svc.ImportSettlement(ctx, entities.SettlementMarketplace, "", nil, strings.NewReader(csv), "f.csv", "user-1")
+		assert.ErrorIs(t, err, entities.ErrInvalidSettlementImport)
+		missing := uuid.New()
+		channels.On("GetChannel", ctx, missing).Return(nil, nil).Once()
+		_, err = svc.ImportSettlement(ctx, entities.SettlementMarketplace, "", &missing, strings.NewReader(csv), "f.csv", "user-1")
+		assert.ErrorIs(t, err, entities.ErrChannelNotFound)
+		repo.AssertNotCalled(t, "CreateImport", mock.Anything, mock.Anything)
+		channels.AssertExpectations(t)
+	})
+
+	t.Run("marketplace payouts need order IDs and default to the channel code", func(t *testing.T) {
+		ch := &entities.MarketplaceChannel{ID: uuid.New(), Code: "tokopedia", Status: entities.ChannelActive}
+		repo, channels := new(MockSalesReconciliationRepository), new(MockMarketplaceRepository)
+		repo.On("CreateImport", mock.Anything, mock.AnythingOfType("*entities.SettlementImport")).Return(nil).Once()
+		channels.On("GetChannel", ctx, ch.ID).Return(ch, nil).Twice()
+		svc := NewSalesReconciliationService(repo, channels)
+
+		_, err := svc.ImportSettlement(ctx, entities.SettlementMarketplace, "", &ch.ID, strings.NewReader(csv), "f.csv", "user-1")
+		assert.ErrorIs(t, err, entities.ErrInvalidSettlementImport)
+
+		imp, err := svc.ImportSettlement(ctx, entities.SettlementMarketplace, "", &ch.ID,
+			strings.NewReader("order_id,payout_date,amount,commission\nTK-1,2025-03-15,100000,5000\n"), "f.csv", "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "tokopedia", imp.Provider)
+		assert.Equal(t, &ch.ID, imp.Lines[0].ChannelID)
+		assert.Equal(t, "TK-1", imp.Lines[0].ExternalOrderID)
+		repo.AssertExpectations(t)
+		channels.AssertExpectations(t)
+	})
+}
+
+func TestSalesReconciliationService_ReconcilePos(t *testing.T) {
+	ctx := context.Background()
+	day := time.Now().AddDate(0, 0, -1)
+	repo := new(MockSalesReconciliationRepository)
+	svc := NewSalesReconciliationService(repo, new(MockMarketplaceRepository))
+
+	byRef := &entities.ReconciliationPayment{ID: uuid.New(), ReceiptNumber: "R-1", Reference: "1234", Amount: 100000}
+	byAmount := &entities.ReconciliationPayment{ID: uuid.New(), ReceiptNumber: "R-2", Amount: 75000}
+	mismatch := &entities.ReconciliationPayment{ID: uuid.New(), ReceiptNumber: "R-3", Reference: "AB 77", Amount: 50000}
+	unsettled := &entities.ReconciliationPayment{ID: uuid.New(), ReceiptNumber: "R-4", Amount: 20000}
+	repo.On("ListPosPayments", ctx, entities.SettlementCard, businessDay(day)).
+		Return([]*entities.ReconciliationPayment{byRef, byAmount, mismatch, unsettled}, nil).Once()
+	repo.On("ListSettlementLines", ctx, entities.SettlementCard, businessDay(day)).
+		Return([]*entities.SettlementLine{
+			settlementLine("001234", 100000, 1800),
+			settlementLine("", 75000, 1350),
+			settlementLine("ab77", 49000, 900),
+			settlementLine("9999", 30000, 540),
+		}, nil).Once()
+	repo.On("SaveReconciliation", ctx, mock.AnythingOfType("*entities.SalesReconciliation")).Return(nil).Once()
+
+	rec, err := svc.Reconcile(ctx, day, entities.SettlementCard, nil, "user-1")
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	repo.AssertCalled(t, "SaveReconciliation", mock.Anything, rec)
+	assert.Equal(t, "user-1", rec.CreatedBy)
+	assert.Equal(t, businessDay(day), rec.BusinessDate)
+	assert.Nil(t, rec.ChannelID)
+
+	lines := linesByStatus(rec)
+	require.Len(t, lines[entities.ReconMatched], 2)
+	assert.Equal(t, &byRef.ID, lines[entities.ReconMatched][0].PosPaymentID)
+	assert.Equal(t, 1.8, lines[entities.ReconMatched][0].FeeRate)
+	assert.Equal(t, &byAmount.ID, lines[entities.ReconMatched][1].PosPaymentID)
+	assert.Equal(t, "R-2", lines[entities.ReconMatched][1].Reference)
+
+	require.Len(t, lines[entities.ReconAmountMismatch], 1)
+	assert.Equal(t, -1000.0, lines[entities.ReconAmountMismatch][0].Difference)
+	require.Len(t, lines[entities.ReconMissingSettlement], 1)
+	assert.Equal(t, &unsettled.ID, lines[entities.ReconMissingSettlement][0].PosPaymentID)
+	assert.Equal(t, -20000.0, lines[entities.ReconMissingSettlement][0].Difference)
+	require.Len(t, lines[entities.ReconUnexpectedSettlement], 1)
+	assert.Equal(t, 30000.0, lines[entities.ReconUnexpectedSettlement][0].Difference)
+
+	assert.Equal(t, 2, rec.MatchedCount)
+	assert.Equal(t, 3, rec.UnmatchedCount)
+	assert.Len(t, rec.Discrepancies, 3)
+	assert.Len(t, rec.SettlementLinks, 4)
+	assert.Equal(t, entities.ReconciliationDiscrepancies, rec.Status)
+	assert.Equal(t, 245000.0, rec.ExpectedAmount)
+	assert.Equal(t, 254000.0, rec.SettledAmount)
+	assert.Equal(t, 4590.0, rec.FeeAmount)
+	assert.Equal(t, 1.81, rec.MDRRate)
+	assert.Equal(t, 9000.0, rec.Difference)
+}
+
+func TestSalesReconciliationService_ReconcileChannel(t *testing.T) {
+	ctx := context.Background()
+	day := time.Now().AddDate(0, 0, -1)
+	repo, channels := new(MockSalesReconciliationRepository), new(MockMarketplaceRepository)
+	ch := &entities.MarketplaceChannel{ID: uuid.New(), Code: "shopee", Status: entities.ChannelActive}
+	svc := NewSalesReconciliationService(repo, channels)
+
+	paid := &entities.ReconciliationOrder{ID: uuid.New(), OrderID: "SP-1", Status: entities.OnlineOrderShipped, TotalAmount: 200000}
+	adjusted := &entities.ReconciliationOrder{ID: uuid.New(), OrderID: "SP-2", Status: entities.OnlineOrderShipped, TotalAmount: 100000}
+	cancelled := &entities.ReconciliationOrder{ID: uuid.New(), OrderID: "SP-3", Status: entities.OnlineOrderCancelled, TotalAmount: 80000}
+	channels.On("GetChannel", ctx, ch.ID).Return(ch, nil).Once()
+	repo.On("ListPayoutLines", ctx, ch.ID, businessDay(day)).
+		Return([]*entities.SettlementLine{
+			payoutLine("SP-1", 200000, 10000),
+			payoutLine("SP-2", 90000, 4500),
+			payoutLine("SP-2", 10000, 500),
+			payoutLine("SP-3", 80000, 4000),
+			payoutLine("SP-9", 5000, 0),
+		}, nil).Once()
+	repo.On("GetChannelOrders", ctx, ch.ID, []string{"SP-1", "SP-2", "SP-2", "SP-3", "SP-9"}).
+		Return(map[string]*entities.ReconciliationOrder{"SP-1": paid, "SP-2": adjusted, "SP-3": cancelled}, nil).Once()
+	late := &entities.ReconciliationOrder{ID: uuid.New(), OrderID: "SP-0", Status: entities.OnlineOrderShipped, TotalAmount: 60000}
+	orderDay := businessDay(day).AddDate(0, 0, -entities.MarketplacePayoutDays)
+	repo.On("ListUnpaidChannelOrders", ctx, ch.ID, orderDay).Return([]*entities.ReconciliationOrder{late}, nil).Once()
+	repo.On("SaveReconciliation", ctx, mock.AnythingOfType("*entities.SalesReconciliation")).Return(nil).Once()
+
+	rec, err := svc.Reconcile(ctx, day, entities.SettlementMarketplace, &ch.ID, "user-1")
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	channels.AssertExpectations(t)
+	assert.Equal(t, &ch.ID, rec.ChannelID)
+
+	lines := linesByStatus(rec)
+	require.Len(t, lines[entities.ReconMatched], 2)
+	assert.Equal(t, 5.0, lines[entities.ReconMatched][0].FeeRate)
+	assert.Equal(t, 100000.0, lines[entities.ReconMatched][1].SettledAmount)
+	assert.Equal(t, 95000.0, lines[entities.ReconMatched][1].NetAmount)
+
+	require.Len(t, lines[entities.ReconAmountMismatch], 1)
+	assert.Equal(t, &cancelled.ID, lines[entities.ReconAmountMismatch][0].OnlineOrderID)
+	assert.Equal(t, 0.0, lines[entities.ReconAmountMismatch][0].ExpectedAmount)
+	require.Len(t, lines[entities.ReconUnexpectedSettlement], 1)
+	assert.Equal(t, "SP-9", lines[entities.ReconUnexpectedSettlement][0].Reference)
+	require.Len(t, lines[entities.ReconMissingPayout], 1)
+	assert.Equal(t, &late.ID, lines[entities.ReconMissingPayout][0].OnlineOrderID)
+	assert.Equal(t, -60000.0, lines[entities.ReconMissingPayout][0].Difference)
+
+	assert.Equal(t, 3, rec.UnmatchedCount)
+	assert.Len(t, rec.SettlementLinks, 5)
+}
+
+func TestSalesReconciliationService_ReconcileValidation(t *testing.T) {
+	ctx := context.Background()
+	repo, channels := new(MockSalesReconciliationRepository), new(MockMarketplaceRepository)
+	svc := NewSalesReconciliationService(repo, channels)
+	yesterday := time.Now().AddDate(0, 0, -1)
+
+	_, err := svc.Reconcile(ctx, time.Time{}, entities.SettlementCard, nil, "user-1")
+	assert.ErrorIs(t, err, entities.ErrInvalidReconciliation)
+	_, err = svc.Reconcile(ctx, time.Now().AddDate(0, 0, 2), entities.SettlementCard, nil, "user-1")
+	assert.ErrorIs(t, err, entities.ErrInvalidReconciliation)
+	_, err = svc.Reconcile(ctx, yesterday, "cash", nil, "user-1")
+	assert.ErrorIs(t, err, entities.ErrInvalidReconciliation)
+	_, err = svc.Reconcile(ctx, yesterday, entities.SettlementMarketplace, nil, "user-1")
+	assert.ErrorIs(t, err, entities.ErrInvalidReconciliation)
+	missing := uuid.New()
+	channels.On("GetChannel", ctx, missing).Return(nil, nil).Once()
+	_, err = svc.Reconcile(ctx, yesterday, entities.SettlementMarketplace, &missing, "user-1")
+	assert.ErrorIs(t, err, entities.ErrChannelNotFound)
+
+	// Nothing sold or settled
+	repo.On("ListPosPayments", ctx, entities.SettlementQRIS, businessDay(yesterday)).Return([]*entities.ReconciliationPayment(nil), nil).Once()
+	repo.On("ListSettlementLines", ctx, entities.SettlementQRIS, businessDay(yesterday)).Return([]*entities.SettlementLine(nil), nil).Once()
+	_, err = svc.Reconcile(ctx, yesterday, entities.SettlementQRIS, nil, "user-1")
+	assert.ErrorIs(t, err, entities.ErrInvalidReconciliation)
+	repo.AssertExpectations(t)
+	channels.AssertExpectations(t)
+	repo.AssertNotCalled(t, "SaveReconciliation", mock.Anything, mock.Anything)
+}
+
+func TestSalesReconciliationService_ReconcileDay(t *testing.T) {
+	ctx := context.Background()
+	repo, channels := new(MockSalesReconciliationRepository), new(MockMarketplaceRepository)
+	active := &entities.MarketplaceChannel{ID: uuid.New(), Code: "lazada", Status: entities.ChannelActive}
+	paused := &entities.MarketplaceChannel{ID: uuid.New(), Code: "blibli", Status: "inactive"}
+	anyDay := mock.AnythingOfType("time.Time")
+	channels.On("ListChannels", ctx).Return([]*entities.MarketplaceChannel{active, paused}, nil).Once()
+	channels.On("GetChannel", ctx, active.ID).Return(active, nil).Once()
+	for _, source := range []string{entities.SettlementCard, entities.SettlementEWallet} {
+		repo.On("ListPosPayments", ctx, source, anyDay).Return([]*entities.ReconciliationPayment(nil), nil).Once()
+		repo.On("ListSettlementLines", ctx, source, anyDay).Return([]*entities.SettlementLine(nil), nil).Once()
+	}
+	repo.On("ListPosPayments", ctx, entities.SettlementQRIS, anyDay).
+		Return([]*entities.ReconciliationPayment{{ID: uuid.New(), Reference: "Q1", Amount: 25000}}, nil).Once()
+	repo.On("ListSettlementLines", ctx, entities.SettlementQRIS, anyDay).
+		Return([]*entities.SettlementLine{settlementLine("Q1", 25000, 175)}, nil).Once()
+	repo.On("ListPayoutLines", ctx, active.ID, anyDay).Return([]*entities.SettlementLine{payoutLine("LZ-1", 40000, 0)}, nil).Once()
+	repo.On("GetChannelOrders", ctx, active.ID, []string{"LZ-1"}).Return(map[string]*entities.ReconciliationOrder{}, nil).Once()
+	repo.On("ListUnpaidChannelOrders", ctx, active.ID, anyDay).Return([]*entities.ReconciliationOrder(nil), nil).Once()
+	repo.On("SaveReconciliation", ctx, mock.AnythingOfType("*entities.SalesReconciliation")).Return(nil).Twice()
+	svc := NewSalesReconciliationService(repo, channels)
+
+	recs, err := svc.ReconcileYesterday(ctx)
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, entities.SettlementQRIS, recs[0].Source)
+	assert.Equal(t, entities.ReconciliationReconciled, recs[0].Status)
+	assert.Equal(t, 0.7, recs[0].MDRRate)
+	assert.Equal(t, "system", recs[0].CreatedBy)
+	assert.Equal(t, &active.ID, recs[1].ChannelID)
+	repo.AssertExpectations(t)
+	channels.AssertExpectations(t)
+	repo.AssertNotCalled(t, "ListPayoutLines", mock.Anything, paused.ID, mock.Anything)
+}
+
+func TestSalesReconciliationService_ResolveDiscrepancy(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockSalesReconciliationRepository)
+	svc := NewSalesReconciliationService(repo, new(MockMarketplaceRepository))
+	yesterday := time.Now().AddDate(0, 0, -1)
+	repo.On("ListPosPayments", ctx, entities.SettlementEWallet, businessDay(yesterday)).
+		Return([]*entities.ReconciliationPayment{{ID: uuid.New(), ReceiptNumber: "R-1", Amount: 30000}}, nil).Once()
+	repo.On("ListSettlementLines", ctx, entities.SettlementEWallet, businessDay(yesterday)).Return([]*entities.SettlementLine(nil), nil).Once()
+	repo.On("SaveReconciliation", ctx, mock.AnythingOfType("*entities.SalesReconciliation")).Return(nil).Once()
+
+	rec, err := svc.Reconcile(ctx, yesterday, entities.SettlementEWallet, nil, "user-1")
+	require.NoError(t, err)
+	require.Len(t, rec.Discrepancies, 1)
+	d := rec.Discrepancies[0]
+	assert.Equal(t, entities.ReconMissingSettlement, d.Type)
+	assert.Equal(t, -30000.0, d.Amount)
+	assert.Equal(t, entities.DiscrepancyOpen, d.Status)
+	_, err = svc.ResolveDiscrepancy(ctx, d.ID, "  ", "finance-1")
+	assert.ErrorIs(t, err, entities.ErrInvalidReconciliation)
+	missing := uuid.New()
+	repo.On("GetDiscrepancy", ctx, missing).Return(nil, nil).Once()
+	_, err = svc.ResolveDiscrepancy(ctx, missing, "Settled next day", "finance-1")
+	assert.ErrorIs(t, err, entities.ErrDiscrepancyNotFound)
+
+	repo.On("GetDiscrepancy", ctx, d.ID).Return(d, nil).Twice()
+	repo.On("ResolveDiscrepancy", ctx, d).Return(nil).Once()
+
+	resolved, err := svc.ResolveDiscrepancy(ctx, d.ID, "Settled next day", "finance-1")
+	require.NoError(t, err)
+	assert.Equal(t, entities.DiscrepancyResolved, resolved.Status)
+	assert.Equal(t, "finance-1", resolved.ResolvedBy)
+	assert.NotNil(t, resolved.ResolvedAt)
+
+	_, err = svc.ResolveDiscrepancy(ctx, d.ID, "Again", "finance-1")
+	assert.ErrorIs(t, err, entities.ErrDiscrepancyResolved)
+	repo.AssertExpectations(t)
+}
@@ -11,7 +11,8 @@ import (
 
 // SalesReturnService provides business logic for sales return operations.
 type SalesReturnService struct {
-	repo repositories.SalesReturnRepository
+	repo    repositories.SalesReturnRepository
+	loyalty *LoyaltyService
 }
 
 // NewSalesReturnService creates a new SalesReturnService.
@@ -19,12 +20,28 @@ func NewSalesReturnService(repo repositories.SalesReturnRepository) *SalesReturn
 	return &SalesReturnService{repo: repo}
 }
 
-// CreateSalesReturn creates a new sales return.
+// SetLoyaltyService enables the reversal of loyalty points on returns of POS sales.
+func (s *SalesReturnService) SetLoyaltyService(loyalty *LoyaltyService) {
+	s.loyalty = loyalty
+}
+
+// CreateSalesReturn creates a new sales return. For returns of a POS sale made by a
+// loyalty member, the points earned and redeemed on the sale are reversed in
+// proportion to the returned amount.
 func (s *SalesReturnService) CreateSalesReturn(ctx context.Context, sr *entities.SalesReturn) error {
+	if sr.SalesInvoiceID == "" && sr.PosTransactionID == "" {
+		return errors.New("a sales invoice or POS transaction is required")
+	}
 	if sr.ID.IsNil() {
 		sr.ID = uuid.New()
 	}
-	return s.repo.Create(ctx, sr)
+	if err := s.repo.Create(ctx, sr); err != nil {
+		return err
+	}
+	if s.loyalty != nil && sr.PosTransactionID != "" {
+		return s.loyalty.ReverseForReturn(ctx, sr.PosTransactionID, sr.ID, sr.TotalAmount)
+	}
+	return nil
 }
 
 // GetAllSalesReturns retrieves all sales returns.
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SettlementFileLine is one line of a settlement or payout file.
+type SettlementFileLine struct {
+	Line            int
+	TransactionDate time.Time
+	SettlementDate  time.Time
+	Reference       string
+	OrderID         string
+	GrossAmount     float64
+	FeeAmount       float64
+	NetAmount       float64
+}
+
+// settlementColumns maps the header names banks, payment providers and marketplaces
+// use to the file's columns. Every fee column (MDR, commission, service fee) is added up.
+var settlementColumns = map[string]string{
+	"date":               "date",
+	"transaction_date":   "date",
+	"trx_date":           "date",
+	"order_date":         "date",
+	"tanggal":            "date",
+	"tanggal_transaksi":  "date",
+	"settlement_date":    "settlement_date",
+	"payout_date":        "settlement_date",
+	"settled_at":         "settlement_date",
+	"tanggal_settlement": "settlement_date",
+	"reference":          "reference",
+	"ref":                "reference",
+	"approval_code":      "reference",
+	"auth_code":          "reference",
+	"rrn":                "reference",
+	"transaction_id":     "reference",
+	"order_id":           "order",
+	"order_no":           "order",
+	"order_number":       "order",
+	"external_order_id":  "order",
+	"no_pesanan":         "order",
+	"gross":              "gross",
+	"gross_amount":       "gross",
+	"amount":             "gross",
+	"transaction_amount": "gross",
+	"nominal":            "gross",
+	"fee":                "fee",
+	"fee_amount":         "fee",
+	"mdr":                "fee",
+	"mdr_amount":         "fee",
+	"commission":         "fee",
+	"service_fee":        "fee",
+	"admin_fee":          "fee",
+	"net":                "net",
+	"net_amount":         "net",
+	"payout":             "net",
+	"payout_amount":      "net",
+	"settled_amount":     "net",
+}
+
+// settlementDateLayouts are the date formats accepted in settlement files.
+var settlementDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+	"02-01-2006",
+	"20060102",
+}
+
+// ParseSettlementCSV parses a settlement or payout CSV file. It needs a date column (the
+// transaction or the settlement date) and the gross or net amount; a missing amount is
+// worked out from the other two. Comma and semicolon separated files are accepted, and
+// amounts may use a decimal comma.
+func ParseSettlementCSV(r io.Reader) ([]SettlementFileLine, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv file: %w", err)
+	}
+	content := strings.TrimPrefix(string(data), "\ufeff")
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	firstLine, _, _ := strings.Cut(content, "\n")
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("csv file is empty")
+		}
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := map[string][]int{}
+	for i, col := range header {
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(col)), " ", "_")
+		if column, ok := settlementColumns[name]; ok {
+			columns[column] = append(columns[column], i)
+		}
+	}
+	if len(columns["date"]) == 0 && len(columns["settlement_date"]) == 0 {
+		return nil, fmt.Errorf("csv header must contain a transaction or a settlement date column")
+	}
+	if len(columns["gross"]) == 0 && len(columns["net"]) == 0 {
+		return nil, fmt.Errorf("csv header must contain a gross or a net amount column")
+	}
+
+	field := func(record []string, name string) string {
+		if idx := columns[name]; len(idx) > 0 && idx[0] < len(record) {
+			return strings.TrimSpace(record[idx[0]])
+		}
+		return ""
+	}
+	amount := func(record []string, name string, line int) (float64, bool, error) {
+		total, found := 0.0, false
+		for _, idx := range columns[name] {
+			if idx >= len(record) || strings.TrimSpace(record[idx]) == "" {
+				continue
+			}
+			v, err := parseSettlementAmount(record[idx])
+			if err != nil {
+				return 0, false, fmt.Errorf("line %d: invalid %s amount %q", line, name, record[idx])
+			}
+			total += v
+			found = true
+		}
+		return total, found, nil
+	}
+
+	var lines []SettlementFileLine
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		l := SettlementFileLine{Line: line, Reference: field(record, "reference"), OrderID: field(record, "order")}
+		if value := field(record, "date"); value != "" {
+			if l.TransactionDate, err = parseSettlementDate(value); err != nil {
+				return nil, fmt.Errorf("line %d: invalid date %q", line, value)
+			}
+		}
+		if value := field(record, "settlement_date"); value != "" {
+			if l.SettlementDate, err = parseSettlementDate(value); err != nil {
+				return nil, fmt.Errorf("line %d: invalid settlement date %q", line, value)
+			}
+		}
+		if l.TransactionDate.IsZero() {
+			l.TransactionDate = l.SettlementDate
+		}
+		if l.SettlementDate.IsZero() {
+			l.SettlementDate = l.TransactionDate
+		}
+		if l.TransactionDate.IsZero() {
+			return nil, fmt.Errorf("line %d: date is missing", line)
+		}
+
+		gross, hasGross, err := amount(record, "gross", line)
+		if err != nil {
+			return nil, err
+		}
+		fee, hasFee, err := amount(record, "fee", line)
+		if err != nil {
+			return nil, err
+		}
+		net, hasNet, err := amount(record, "net", line)
+		if err != nil {
+			return nil, err
+		}
+		// Providers report fees as positive or negative deductions
+		if fee < 0 {
+			fee = -fee
+		}
+		switch {
+		case hasGross && hasNet && !hasFee:
+			fee = gross - net
+		case hasGross && !hasNet:
+			net = gross - fee
+		case !hasGross && hasNet:
+			gross = net + fee
+		case !hasGross && !hasNet:
+			return nil, fmt.Errorf("line %d: amount is missing", line)
+		}
+		l.GrossAmount, l.FeeAmount, l.NetAmount = roundMoney(gross), roundMoney(fee), roundMoney(net)
+		lines = append(lines, l)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("csv file has no settlement lines")
+	}
+	return lines, nil
+}
+
+// parseSettlementAmount parses an amount like the prices of sell-through reports,
+// allowing a sign or accounting parentheses for deductions.
+func parseSettlementAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s, negative = s[1:len(s)-1], true
+	}
+	if strings.HasPrefix(s, "-") {
+		s, negative = s[1:], !negative
+	}
+	v, err := parseReportedPrice(s)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+func parseSettlementDate(s string) (time.Time, error) {
+	for _, layout := range settlementDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format")
+}
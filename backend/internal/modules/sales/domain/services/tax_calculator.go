@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	accounting_entities "malaka/internal/modules/accounting/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// TaxCalculator determines the tax of document lines from the tax determination rules
+// and computes it at the rate in force on the document date.
+type TaxCalculator interface {
+	CalculateTax(ctx context.Context, req *accounting_entities.TaxCalculationRequest) (*accounting_entities.TaxCalculation, error)
+}
+
+// calculateTax asks for the tax of lines sold to a customer, who is empty for walk-in
+// sales. Line amounts exclude tax.
+func calculateTax(ctx context.Context, taxes TaxCalculator, transactionType, customerID string, date time.Time,
+	lines []accounting_entities.TaxCalculationLineInput) (*accounting_entities.TaxCalculation, error) {
+	req := &accounting_entities.TaxCalculationRequest{TransactionType: transactionType, Date: date, Lines: lines}
+	if id, err := uuid.Parse(customerID); err == nil {
+		req.PartyID = id
+	}
+	return taxes.CalculateTax(ctx, req)
+}
+
+// effectiveTaxRate returns a tax amount as a percentage of the net amount it is charged on.
+func effectiveTaxRate(taxAmount, net float64) float64 {
+	if net <= 0 {
+		return 0
+	}
+	return math.Round(taxAmount/net*10000) / 100
+}
@@ -2,20 +2,164 @@ package external
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/shared/utils"
 )
 
-// MarketplaceAPIService defines the interface for marketplace integration.
-type MarketplaceAPIService interface {
-	SyncOrder(ctx context.Context, orderID string) error
+// FileAdapterName is the name channels use for the file adapter.
+const FileAdapterName = "file"
+
+// FileSignatureHeader carries the HMAC-SHA256 of a webhook body, hex encoded, when the
+// channel has a webhook secret.
+const FileSignatureHeader = "X-Signature"
+
+// FileChannelAdapter is a channel adapter working on a directory instead of a
+// marketplace API, for testing and for channels exported by hand. Orders are read
+// from <dir>/orders/*.json, each file holding an order or an array of orders in
+// channel-neutral form; status updates, stock and prices are written to <dir>/outbox.
+//
+// Settings: "dir" (required) and "webhook_secret" (optional).
+type FileChannelAdapter struct {
+	channel string
+	dir     string
+	secret  string
+}
+
+// NewFileChannelAdapter creates the file adapter of a channel.
+func NewFileChannelAdapter(ch *entities.MarketplaceChannel) (services.ChannelAdapter, error) {
+	dir := strings.TrimSpace(ch.Settings["dir"])
+	if dir == "" {
+		return nil, fmt.Errorf("file adapter needs a dir setting")
+	}
+	return &FileChannelAdapter{channel: ch.Code, dir: dir, secret: ch.Settings["webhook_secret"]}, nil
+}
+
+// PullOrders reads the orders created or changed since the given time.
+func (a *FileChannelAdapter) PullOrders(ctx context.Context, since time.Time) ([]*entities.ChannelOrder, error) {
+	files, err := filepath.Glob(filepath.Join(a.dir, "orders", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var orders []*entities.ChannelOrder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseChannelOrders(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		for _, order := range parsed {
+			changed := order.UpdatedAt
+			if changed.IsZero() {
+				changed = order.OrderedAt
+			}
+			if since.IsZero() || !changed.Before(since) {
+				orders = append(orders, order)
+			}
+		}
+	}
+	return orders, nil
+}
+
+// ParseWebhook reads the orders of a notification, checking its signature when the
+// channel has a webhook secret.
+func (a *FileChannelAdapter) ParseWebhook(ctx context.Context, headers map[string]string, body []byte) ([]*entities.ChannelOrder, error) {
+	if a.secret != "" {
+		signature, err := hex.DecodeString(header(headers, FileSignatureHeader))
+		if err != nil || !hmac.Equal(signature, sign(a.secret, body)) {
+			return nil, fmt.Errorf("%w: bad signature", entities.ErrInvalidWebhook)
+		}
+	}
+	orders, err := parseChannelOrders(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidWebhook, err)
+	}
+	return orders, nil
+}
+
+// PushOrderStatus writes the status update of an order to the outbox.
+func (a *FileChannelAdapter) PushOrderStatus(ctx context.Context, update *entities.ChannelStatusUpdate) error {
+	return a.writeOutbox("status-"+update.ExternalOrderID, update)
+}
+
+// PushStock writes the stock levels to the outbox.
+func (a *FileChannelAdapter) PushStock(ctx context.Context, levels []*entities.ChannelStockLevel) error {
+	return a.writeOutbox("stock", levels)
 }
 
-// MockMarketplaceAPIService is a mock implementation of MarketplaceAPIService.
-type MockMarketplaceAPIService struct{}
+// PushPrices writes the prices to the outbox.
+func (a *FileChannelAdapter) PushPrices(ctx context.Context, prices []*entities.ChannelPrice) error {
+	return a.writeOutbox("prices", prices)
+}
+
+func (a *FileChannelAdapter) writeOutbox(name string, v interface{}) error {
+	outbox := filepath.Join(a.dir, "outbox")
+	if err := os.MkdirAll(outbox, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := fmt.Sprintf("%s-%s.json", utils.Now().UTC().Format("20060102T150405.000000000"), safeFileName(name))
+	return os.WriteFile(filepath.Join(outbox, file), data, 0o644)
+}
 
-// SyncOrder mocks syncing an order with a marketplace.
-func (m *MockMarketplaceAPIService) SyncOrder(ctx context.Context, orderID string) error {
-	fmt.Printf("Syncing order %s with marketplace\n", orderID)
-	return nil
+// parseChannelOrders reads an order or an array of orders.
+func parseChannelOrders(data []byte) ([]*entities.ChannelOrder, error) {
+	data = []byte(strings.TrimSpace(string(data)))
+	if len(data) > 0 && data[0] == '[' {
+		var orders []*entities.ChannelOrder
+		if err := json.Unmarshal(data, &orders); err != nil {
+			return nil, err
+		}
+		return orders, nil
+	}
+	var order entities.ChannelOrder
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, err
+	}
+	return []*entities.ChannelOrder{&order}, nil
 }
 
+// header looks a header up regardless of its case.
+func header(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func sign(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// safeFileName keeps an external order ID from escaping the outbox.
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -1,9 +1,102 @@
 package external
 
 import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"malaka/internal/modules/sales/domain/entities"
 )
 
-func TestMarketplaceAPI(t *testing.T) {
-	// Placeholder for marketplace API tests
+func newFileAdapter(t *testing.T, settings entities.ChannelSettings) (*FileChannelAdapter, string) {
+	dir := t.TempDir()
+	settings["dir"] = dir
+	adapter, err := NewFileChannelAdapter(&entities.MarketplaceChannel{Code: "SHOP", Settings: settings})
+	require.NoError(t, err)
+	return adapter.(*FileChannelAdapter), dir
+}
+
+func TestNewFileChannelAdapterNeedsDir(t *testing.T) {
+	_, err := NewFileChannelAdapter(&entities.MarketplaceChannel{Code: "SHOP", Settings: entities.ChannelSettings{}})
+	assert.Error(t, err)
+}
+
+func TestFileChannelAdapterPullOrders(t *testing.T) {
+	adapter, dir := newFileAdapter(t, entities.ChannelSettings{})
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "orders"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders", "a.json"), []byte(`[
+		{"external_order_id": "O-1", "status": "paid", "ordered_at": "2026-01-01T10:00:00Z",
+		 "items": [{"sku": "SKU-1", "quantity": 2, "unit_price": 100000}]},
+		{"external_order_id": "O-2", "status": "paid", "ordered_at": "2026-01-01T10:00:00Z",
+		 "updated_at": "2026-01-03T10:00:00Z"}
+	]`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders", "b.json"),
+		[]byte(`{"external_order_id": "O-3", "status": "unpaid", "ordered_at": "2026-01-04T10:00:00Z"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders", "notes.txt"), []byte("ignored"), 0o644))
+
+	orders, err := adapter.PullOrders(context.Background(), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+	assert.Equal(t, "SKU-1", orders[0].Items[0].SKU)
+	assert.Equal(t, 2, orders[0].Items[0].Quantity)
+
+	orders, err = adapter.PullOrders(context.Background(), time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, "O-2", orders[0].ExternalOrderID)
+	assert.Equal(t, "O-3", orders[1].ExternalOrderID)
+}
+
+func TestFileChannelAdapterParseWebhookChecksSignature(t *testing.T) {
+	adapter, _ := newFileAdapter(t, entities.ChannelSettings{"webhook_secret": "s3cret"})
+	body := []byte(`{"external_order_id": "O-1", "status": "paid"}`)
+
+	_, err := adapter.ParseWebhook(context.Background(), map[string]string{}, body)
+	assert.True(t, errors.Is(err, entities.ErrInvalidWebhook))
+	_, err = adapter.ParseWebhook(context.Background(), map[string]string{"X-Signature": "00"}, body)
+	assert.True(t, errors.Is(err, entities.ErrInvalidWebhook))
+
+	signature := hex.EncodeToString(sign("s3cret", body))
+	orders, err := adapter.ParseWebhook(context.Background(), map[string]string{"x-signature": signature}, body)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "O-1", orders[0].ExternalOrderID)
+}
+
+func TestFileChannelAdapterParseWebhookRejectsMalformedBody(t *testing.T) {
+	adapter, _ := newFileAdapter(t, entities.ChannelSettings{})
+	_, err := adapter.ParseWebhook(context.Background(), nil, []byte("not json"))
+	assert.True(t, errors.Is(err, entities.ErrInvalidWebhook))
+}
+
+func TestFileChannelAdapterPushesToOutbox(t *testing.T) {
+	adapter, dir := newFileAdapter(t, entities.ChannelSettings{})
+	ctx := context.Background()
+	require.NoError(t, adapter.PushOrderStatus(ctx, &entities.ChannelStatusUpdate{
+		ExternalOrderID: "../O-1", Status: entities.ChannelOrderShipped, TrackingNumber: "JNE-1"}))
+	require.NoError(t, adapter.PushStock(ctx, []*entities.ChannelStockLevel{{SKU: "SKU-1", Available: 4}}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "outbox", "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	var stock []*entities.ChannelStockLevel
+	for _, file := range files {
+		if strings.HasSuffix(file, "-stock.json") {
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(data, &stock))
+		}
+	}
+	require.Len(t, stock, 1)
+	assert.Equal(t, 4, stock[0].Available)
 }
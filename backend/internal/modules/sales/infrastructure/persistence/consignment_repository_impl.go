@@ -0,0 +1,499 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+const consignmentLocationQuery = `SELECT l.id, l.depstore_id, l.warehouse_id, l.customer_id, l.commission_rate, l.status,
+		l.created_at, l.updated_at, d.code AS depstore_code, d.name AS depstore_name,
+		COALESCE(d.commission_rate, 0) AS depstore_commission_rate, COALESCE(d.payment_terms, '') AS payment_terms,
+		COALESCE(w.code, '') AS warehouse_code
+	FROM consignment_locations l
+	JOIN depstores d ON d.id = l.depstore_id
+	JOIN warehouses w ON w.id = l.warehouse_id`
+
+const consignmentTransferColumns = `id, transfer_number, location_id, direction, from_warehouse_id, to_warehouse_id,
+	transfer_date, notes, COALESCE(created_by::text, '') AS created_by, created_at`
+
+const sellThroughColumns = `id, report_number, location_id, consignee_id, period_start, period_end, total_amount,
+	commission_rate, commission_amount, net_amount, status, settlement_id, source_file,
+	COALESCE(created_by::text, '') AS created_by, created_at`
+
+const sellThroughItemColumns = `id, sell_through_id, article_id, barcode, quantity, unit_price, gross_amount,
+	commission_amount, net_amount`
+
+const settlementQuery = `SELECT s.id, s.settlement_number, s.location_id, s.sales_invoice_id, COALESCE(i.invoice_number, '') AS invoice_number,
+		s.period_start, s.period_end, s.gross_amount, s.commission_amount, s.net_amount, s.tax_amount, s.grand_total,
+		s.status, COALESCE(s.created_by::text, '') AS created_by, s.created_at
+	FROM consignment_settlements s JOIN sales_invoices i ON i.id = s.sales_invoice_id`
+
+// ConsignmentRepositoryImpl implements repositories.ConsignmentRepository.
+type ConsignmentRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewConsignmentRepositoryImpl creates a new ConsignmentRepositoryImpl.
+func NewConsignmentRepositoryImpl(db *sqlx.DB) *ConsignmentRepositoryImpl {
+	return &ConsignmentRepositoryImpl{db: db}
+}
+
+// CreateLocation stores a location together with its consignment warehouse, named after
+// the department store.
+func (r *ConsignmentRepositoryImpl) CreateLocation(ctx context.Context, loc *entities.ConsignmentLocation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO warehouses (id, code, name, type, address, city, phone, manager, status,
+			company_id, created_at, updated_at)
+		SELECT $1, 'CSG-' || d.code, 'Consignment ' || d.name, $2, d.address, d.city, d.phone, d.contact_person, 'active',
+			d.company_id, $3, $3
+		FROM depstores d WHERE d.id = $4`,
+		loc.WarehouseID, entities.ConsignmentWarehouseType, loc.CreatedAt, loc.DepstoreID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: the consignment warehouse code is already in use", entities.ErrInvalidConsignmentLocation)
+		}
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("%w: department store not found", entities.ErrInvalidConsignmentLocation)
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO consignment_locations (id, depstore_id, warehouse_id, customer_id,
+			commission_rate, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		loc.ID, loc.DepstoreID, loc.WarehouseID, loc.CustomerID, loc.CommissionRate, loc.Status, loc.CreatedAt, loc.UpdatedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return entities.ErrConsignmentLocationExists
+			case "23503":
+				return fmt.Errorf("%w: customer not found", entities.ErrInvalidConsignmentLocation)
+			}
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetLocation returns a consignment location.
+func (r *ConsignmentRepositoryImpl) GetLocation(ctx context.Context, id uuid.ID) (*entities.ConsignmentLocation, error) {
+	var loc entities.ConsignmentLocation
+	if err := r.db.GetContext(ctx, &loc, consignmentLocationQuery+` WHERE l.id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// ListLocations returns all consignment locations by depstore name.
+func (r *ConsignmentRepositoryImpl) ListLocations(ctx context.Context) ([]*entities.ConsignmentLocation, error) {
+	locations := []*entities.ConsignmentLocation{}
+	err := r.db.SelectContext(ctx, &locations, consignmentLocationQuery+` ORDER BY d.name`)
+	return locations, err
+}
+
+// NextTransferNumber returns a new consignment transfer number such as CT-2026-000042.
+func (r *ConsignmentRepositoryImpl) NextTransferNumber(ctx context.Context, at time.Time) (string, error) {
+	return r.nextNumber(ctx, "consignment_transfer_number_seq", "CT", at)
+}
+
+// NextSellThroughNumber returns a new sell-through report number such as CST-2026-000042.
+func (r *ConsignmentRepositoryImpl) NextSellThroughNumber(ctx context.Context, at time.Time) (string, error) {
+	return r.nextNumber(ctx, "consignment_sell_through_number_seq", "CST", at)
+}
+
+// NextSettlementNumber returns a new settlement number such as CSM-2026-000042.
+func (r *ConsignmentRepositoryImpl) NextSettlementNumber(ctx context.Context, at time.Time) (string, error) {
+	return r.nextNumber(ctx, "consignment_settlement_number_seq", "CSM", at)
+}
+
+// NextInvoiceNumber returns a new sales invoice number, shared with the order-to-cash flow.
+func (r *ConsignmentRepositoryImpl) NextInvoiceNumber(ctx context.Context, at time.Time) (string, error) {
+	return r.nextNumber(ctx, "sales_invoice_number_seq", "INV", at)
+}
+
+func (r *ConsignmentRepositoryImpl) nextNumber(ctx context.Context, sequence, prefix string, at time.Time) (string, error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, `SELECT nextval('`+sequence+`')`); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, at.Year(), seq), nil
+}
+
+// GetArticlePrices returns the selling prices of the articles.
+func (r *ConsignmentRepositoryImpl) GetArticlePrices(ctx context.Context, articleIDs []uuid.ID) (map[uuid.ID]float64, error) {
+	prices := make(map[uuid.ID]float64, len(articleIDs))
+	if len(articleIDs) == 0 {
+		return prices, nil
+	}
+	query, args, err := sqlx.In(`SELECT id, price FROM articles WHERE id IN (?)`, articleIDs)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID    uuid.ID `db:"id"`
+		Price float64 `db:"price"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		prices[row.ID] = row.Price
+	}
+	return prices, nil
+}
+
+// ResolveArticles maps article codes and barcodes, of the article or of the barcodes
+// table, to article IDs.
+func (r *ConsignmentRepositoryImpl) ResolveArticles(ctx context.Context, keys []string) (map[string]uuid.ID, error) {
+	articles := make(map[string]uuid.ID, len(keys))
+	if len(keys) == 0 {
+		return articles, nil
+	}
+	var rows []struct {
+		Key       string  `db:"key"`
+		ArticleID uuid.ID `db:"article_id"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT key, article_id FROM (
+			SELECT b.code AS key, b.article_id, 1 AS rank FROM barcodes b WHERE b.code = ANY($1)
+			UNION ALL
+			SELECT a.barcode, a.id, 2 FROM articles a WHERE a.barcode = ANY($1)
+			UNION ALL
+			SELECT a.code, a.id, 3 FROM articles a WHERE a.code = ANY($1)
+		) k ORDER BY rank`, pq.Array(keys)); err != nil {
+		return nil, err
+	}
+	// Barcodes come first and win over an article code that happens to be equal
+	for _, row := range rows {
+		if _, ok := articles[row.Key]; !ok {
+			articles[row.Key] = row.ArticleID
+		}
+	}
+	return articles, nil
+}
+
+// ListOpenLots returns the lots with stock left, oldest first.
+func (r *ConsignmentRepositoryImpl) ListOpenLots(ctx context.Context, locationID *uuid.ID) ([]*entities.ConsignmentLot, error) {
+	lots := []*entities.ConsignmentLot{}
+	err := r.db.SelectContext(ctx, &lots, `SELECT l.id, l.location_id, l.article_id, l.transfer_item_id, l.received_date,
+			l.quantity, l.remaining_quantity, l.unit_price, COALESCE(a.code, '') AS article_code, a.name AS article_name
+		FROM consignment_lots l JOIN articles a ON a.id = l.article_id
+		WHERE l.remaining_quantity > 0 AND ($1::uuid IS NULL OR l.location_id = $1)
+		ORDER BY l.received_date, l.id`, locationID)
+	return lots, err
+}
+
+// CreateTransfer posts a consignment transfer.
+func (r *ConsignmentRepositoryImpl) CreateTransfer(ctx context.Context, t *entities.ConsignmentTransfer) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO consignment_transfers (id, transfer_number, location_id, direction,
+			from_warehouse_id, to_warehouse_id, transfer_date, notes, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::uuid, $10)`,
+		t.ID, t.TransferNumber, t.LocationID, t.Direction, t.FromWarehouseID, t.ToWarehouseID, t.TransferDate, t.Notes,
+		t.CreatedBy, t.CreatedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return fmt.Errorf("%w: warehouse not found", entities.ErrInvalidConsignmentTransfer)
+		}
+		return err
+	}
+
+	for _, item := range t.Items {
+		if t.Direction == entities.ConsignmentTransferOut {
+			if err := checkAvailable(ctx, tx, item.ArticleID, t.FromWarehouseID, item.Quantity); err != nil {
+				return err
+			}
+		} else if _, err := consumeLots(ctx, tx, t.LocationID, item.ArticleID, item.Quantity); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO consignment_transfer_items (id, transfer_id, article_id, quantity, unit_price)
+			VALUES ($1, $2, $3, $4, $5)`, item.ID, t.ID, item.ArticleID, item.Quantity, item.UnitPrice); err != nil {
+			return err
+		}
+		if t.Direction == entities.ConsignmentTransferOut {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO consignment_lots (id, location_id, article_id, transfer_item_id,
+					received_date, quantity, remaining_quantity, unit_price)
+				VALUES ($1, $2, $3, $4, $5, $6, $6, $7)`,
+				uuid.New(), t.LocationID, item.ArticleID, item.ID, t.TransferDate, item.Quantity, item.UnitPrice); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// checkAvailable checks a warehouse holds the quantity beyond what sales orders reserved.
+func checkAvailable(ctx context.Context, tx *sqlx.Tx, articleID, warehouseID uuid.ID, quantity int) error {
+	// Locking the balance serialises withdrawals of the article from the warehouse
+	var onHand int
+	err := tx.GetContext(ctx, &onHand, `SELECT quantity FROM stock_balances
+		WHERE article_id = $1 AND warehouse_id = $2 FOR UPDATE`, articleID, warehouseID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	var reserved int
+	if err := tx.GetContext(ctx, &reserved, `SELECT COALESCE(SUM(quantity - delivered_quantity), 0) FROM stock_reservations
+		WHERE article_id = $1 AND warehouse_id = $2 AND status = 'open'`, articleID, warehouseID); err != nil {
+		return err
+	}
+	if available := onHand - reserved; available < quantity {
+		return fmt.Errorf("%w: %d of article %s available, %d requested", entities.ErrInsufficientStock, available, articleID, quantity)
+	}
+	return nil
+}
+
+// consumeLots takes quantity of an article from the location's lots, oldest first.
+func consumeLots(ctx context.Context, tx *sqlx.Tx, locationID, articleID uuid.ID, quantity int) ([]entities.LotConsumption, error) {
+	lots := []*entities.ConsignmentLot{}
+	if err := tx.SelectContext(ctx, &lots, `SELECT id, location_id, article_id, transfer_item_id, received_date, quantity,
+			remaining_quantity, unit_price
+		FROM consignment_lots
+		WHERE location_id = $1 AND article_id = $2 AND remaining_quantity > 0
+		ORDER BY received_date, id FOR UPDATE`, locationID, articleID); err != nil {
+		return nil, err
+	}
+	consumed, err := entities.ConsumeLots(lots, quantity)
+	if err != nil {
+		return nil, fmt.Errorf("%w (article %s)", err, articleID)
+	}
+	for _, c := range consumed {
+		if _, err := tx.ExecContext(ctx, `UPDATE consignment_lots SET remaining_quantity = $2 WHERE id = $1`,
+			c.Lot.ID, c.Lot.RemainingQuantity); err != nil {
+			return nil, err
+		}
+	}
+	return consumed, nil
+}
+
+// GetTransfer returns a consignment transfer with its items.
+func (r *ConsignmentRepositoryImpl) GetTransfer(ctx context.Context, id uuid.ID) (*entities.ConsignmentTransfer, error) {
+	var t entities.ConsignmentTransfer
+	if err := r.db.GetContext(ctx, &t, `SELECT `+consignmentTransferColumns+` FROM consignment_transfers WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	t.Items = []*entities.ConsignmentTransferItem{}
+	if err := r.db.SelectContext(ctx, &t.Items, `SELECT id, transfer_id, article_id, quantity, unit_price
+		FROM consignment_transfer_items WHERE transfer_id = $1 ORDER BY id`, id); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTransfers returns the transfers of a location, latest first, without their items.
+func (r *ConsignmentRepositoryImpl) ListTransfers(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentTransfer, error) {
+	transfers := []*entities.ConsignmentTransfer{}
+	err := r.db.SelectContext(ctx, &transfers, `SELECT `+consignmentTransferColumns+` FROM consignment_transfers
+		WHERE location_id = $1 ORDER BY transfer_date DESC, transfer_number DESC`, locationID)
+	return transfers, err
+}
+
+// CreateSellThrough posts a sell-through report as a consignment sale and consumes the
+// sold stock's lots.
+func (r *ConsignmentRepositoryImpl) CreateSellThrough(ctx context.Context, st *entities.ConsignmentSellThrough) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO consignment_sales (id, consignee_id, sales_date, total_amount, status,
+			location_id, report_number, period_start, period_end, commission_rate, commission_amount, net_amount,
+			source_file, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $3, $9, $10, $11, $12, NULLIF($13, '')::uuid, $14, $14)`,
+		st.ID, st.DepstoreID, st.PeriodEnd, st.GrossAmount, st.Status, st.LocationID, st.ReportNumber, st.PeriodStart,
+		st.CommissionRate, st.CommissionAmount, st.NetAmount, st.SourceFile, st.CreatedBy, st.CreatedAt); err != nil {
+		return err
+	}
+
+	for _, item := range st.Items {
+		if _, err := consumeLots(ctx, tx, st.LocationID, item.ArticleID, item.Quantity); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO consignment_sales_items (id, sell_through_id, article_id, barcode, quantity,
+				unit_price, gross_amount, commission_amount, net_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			item.ID, st.ID, item.ArticleID, item.Barcode, item.Quantity, item.UnitPrice, item.GrossAmount,
+			item.CommissionAmount, item.NetAmount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetSellThrough returns a sell-through report with its items.
+func (r *ConsignmentRepositoryImpl) GetSellThrough(ctx context.Context, id uuid.ID) (*entities.ConsignmentSellThrough, error) {
+	var st entities.ConsignmentSellThrough
+	if err := r.db.GetContext(ctx, &st, `SELECT `+sellThroughColumns+` FROM consignment_sales
+		WHERE id = $1 AND location_id IS NOT NULL`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	reports := []*entities.ConsignmentSellThrough{&st}
+	if err := r.loadSellThroughItems(ctx, reports); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// ListSellThroughs returns the sell-through reports of a location, latest first, without their items.
+func (r *ConsignmentRepositoryImpl) ListSellThroughs(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSellThrough, error) {
+	reports := []*entities.ConsignmentSellThrough{}
+	err := r.db.SelectContext(ctx, &reports, `SELECT `+sellThroughColumns+` FROM consignment_sales
+		WHERE location_id = $1 ORDER BY period_end DESC, report_number DESC`, locationID)
+	return reports, err
+}
+
+// ListUnsettled returns the posted reports of a location up to a date, oldest first, with their items.
+func (r *ConsignmentRepositoryImpl) ListUnsettled(ctx context.Context, locationID uuid.ID, until time.Time) ([]*entities.ConsignmentSellThrough, error) {
+	reports := []*entities.ConsignmentSellThrough{}
+	if err := r.db.SelectContext(ctx, &reports, `SELECT `+sellThroughColumns+` FROM consignment_sales
+		WHERE location_id = $1 AND status = $2 AND period_end <= $3::date
+		ORDER BY period_end, report_number`, locationID, entities.SellThroughPosted, until); err != nil {
+		return nil, err
+	}
+	if err := r.loadSellThroughItems(ctx, reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (r *ConsignmentRepositoryImpl) loadSellThroughItems(ctx context.Context, reports []*entities.ConsignmentSellThrough) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	ids := make([]uuid.ID, len(reports))
+	byID := make(map[uuid.ID]*entities.ConsignmentSellThrough, len(reports))
+	for i, st := range reports {
+		ids[i] = st.ID
+		st.Items = []*entities.ConsignmentSellThroughItem{}
+		byID[st.ID] = st
+	}
+	query, args, err := sqlx.In(`SELECT `+sellThroughItemColumns+` FROM consignment_sales_items
+		WHERE sell_through_id IN (?) ORDER BY id`, ids)
+	if err != nil {
+		return err
+	}
+	var items []*entities.ConsignmentSellThroughItem
+	if err := r.db.SelectContext(ctx, &items, r.db.Rebind(query), args...); err != nil {
+		return err
+	}
+	for _, item := range items {
+		byID[item.SellThroughID].Items = append(byID[item.SellThroughID].Items, item)
+	}
+	return nil
+}
+
+// ListLocationsToSettle returns the active locations with unsettled reports up to a date.
+func (r *ConsignmentRepositoryImpl) ListLocationsToSettle(ctx context.Context, until time.Time) ([]uuid.ID, error) {
+	ids := []uuid.ID{}
+	err := r.db.SelectContext(ctx, &ids, `SELECT DISTINCT l.id FROM consignment_locations l
+		JOIN consignment_sales s ON s.location_id = l.id
+		WHERE l.status = $1 AND s.status = $2 AND s.period_end <= $3::date`,
+		entities.ConsignmentLocationActive, entities.SellThroughPosted, until)
+	return ids, err
+}
+
+// CreateSettlement stores a settlement with its sales invoice, receivable and output VAT,
+// and marks its reports settled.
+func (r *ConsignmentRepositoryImpl) CreateSettlement(ctx context.Context, p *entities.SettlementPosting) error {
+	settlement, inv := p.Settlement, p.Invoice
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sales_invoices (id, sales_order_id, invoice_date, total_amount, tax_amount,
+			grand_total, invoice_number, customer_id, due_date, paid_amount, status, created_at, updated_at)
+		VALUES ($1, NULL, $2, $3, $4, $5, $6, $7::uuid, $8, 0, $9, $10, $11)`,
+		inv.ID, inv.InvoiceDate, inv.TotalAmount, inv.TaxAmount, inv.GrandTotal, inv.InvoiceNumber, inv.CustomerID,
+		inv.DueDate, inv.Status, inv.CreatedAt, inv.UpdatedAt); err != nil {
+		return err
+	}
+	if err := postInvoiceLines(ctx, tx, inv, p.Items, p.ReceivableID, settlement.CreatedBy); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO consignment_settlements (id, settlement_number, location_id, sales_invoice_id,
+			period_start, period_end, gross_amount, commission_amount, net_amount, tax_amount, grand_total, status,
+			created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, '')::uuid, $14)`,
+		settlement.ID, settlement.SettlementNumber, settlement.LocationID, settlement.SalesInvoiceID, settlement.PeriodStart,
+		settlement.PeriodEnd, settlement.GrossAmount, settlement.CommissionAmount, settlement.NetAmount, settlement.TaxAmount,
+		settlement.GrandTotal, settlement.Status, settlement.CreatedBy, settlement.CreatedAt); err != nil {
+		return err
+	}
+
+	// Reports settled concurrently are no longer posted and roll this settlement back
+	res, err := tx.ExecContext(ctx, `UPDATE consignment_sales SET status = $2, settlement_id = $3, updated_at = NOW()
+		WHERE id = ANY($1::uuid[]) AND status = $4`,
+		pq.Array(uuidStrings(settlement.SellThroughIDs)), entities.SellThroughSettled, settlement.ID, entities.SellThroughPosted)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if int(n) != len(settlement.SellThroughIDs) {
+		return fmt.Errorf("%w: a report was settled meanwhile", entities.ErrNothingToSettle)
+	}
+	return tx.Commit()
+}
+
+// GetSettlement returns a consignment settlement with the reports it settled.
+func (r *ConsignmentRepositoryImpl) GetSettlement(ctx context.Context, id uuid.ID) (*entities.ConsignmentSettlement, error) {
+	var settlement entities.ConsignmentSettlement
+	if err := r.db.GetContext(ctx, &settlement, settlementQuery+` WHERE s.id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.db.SelectContext(ctx, &settlement.SellThroughIDs, `SELECT id FROM consignment_sales
+		WHERE settlement_id = $1 ORDER BY period_end`, id); err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// ListSettlements returns the settlements of a location, latest first.
+func (r *ConsignmentRepositoryImpl) ListSettlements(ctx context.Context, locationID uuid.ID) ([]*entities.ConsignmentSettlement, error) {
+	settlements := []*entities.ConsignmentSettlement{}
+	err := r.db.SelectContext(ctx, &settlements, settlementQuery+` WHERE s.location_id = $1
+		ORDER BY s.period_end DESC, s.settlement_number DESC`, locationID)
+	return settlements, err
+}
+
+func uuidStrings(ids []uuid.ID) []string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return s
+}
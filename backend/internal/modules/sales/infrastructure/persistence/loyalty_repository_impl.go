@@ -0,0 +1,430 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+const loyaltyMemberColumns = `m.id, m.member_number, COALESCE(m.card_barcode, '') AS card_barcode, COALESCE(m.phone, '') AS phone,
+	m.name, COALESCE(m.email, '') AS email, COALESCE(m.customer_id::text, '') AS customer_id,
+	m.tier_id, COALESCE(t.name, '') AS tier_name, m.tier_since, m.points_balance, m.lifetime_points,
+	m.status, m.joined_at, m.last_activity_at, m.created_at, m.updated_at`
+
+const loyaltyEntryColumns = `id, member_id, entry_type, points, remaining_points, source_type,
+	COALESCE(source_id, '') AS source_id, COALESCE(reference_id, '') AS reference_id,
+	source_amount, spend_amount, COALESCE(description, '') AS description, expires_at, created_at`
+
+// expireBatchSize is the number of expired ledger entries handled per transaction.
+const expireBatchSize = 500
+
+// LoyaltyRepositoryImpl implements repositories.LoyaltyRepository.
+type LoyaltyRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewLoyaltyRepositoryImpl creates a new LoyaltyRepositoryImpl.
+func NewLoyaltyRepositoryImpl(db *sqlx.DB) *LoyaltyRepositoryImpl {
+	return &LoyaltyRepositoryImpl{db: db}
+}
+
+// GetProgram retrieves the loyalty program rules, or nil when they were never saved.
+func (r *LoyaltyRepositoryImpl) GetProgram(ctx context.Context) (*entities.LoyaltyProgram, error) {
+	var program entities.LoyaltyProgram
+	query := `SELECT earn_spend_unit, point_value, points_expiry_months, tier_review_months, min_redeem_points,
+		max_redeem_rate, category_multipliers, updated_at FROM loyalty_program WHERE id = 1`
+	if err := r.db.GetContext(ctx, &program, query); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &program, nil
+}
+
+// SaveProgram stores the loyalty program rules.
+func (r *LoyaltyRepositoryImpl) SaveProgram(ctx context.Context, program *entities.LoyaltyProgram) error {
+	query := `INSERT INTO loyalty_program (id, earn_spend_unit, point_value, points_expiry_months, tier_review_months,
+			min_redeem_points, max_redeem_rate, category_multipliers, updated_at)
+		VALUES (1, $1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET earn_spend_unit = EXCLUDED.earn_spend_unit, point_value = EXCLUDED.point_value,
+			points_expiry_months = EXCLUDED.points_expiry_months, tier_review_months = EXCLUDED.tier_review_months,
+			min_redeem_points = EXCLUDED.min_redeem_points, max_redeem_rate = EXCLUDED.max_redeem_rate,
+			category_multipliers = EXCLUDED.category_multipliers, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, query, program.EarnSpendUnit, program.PointValue, program.PointsExpiryMonths,
+		program.TierReviewMonths, program.MinRedeemPoints, program.MaxRedeemRate, program.CategoryMultipliers, program.UpdatedAt)
+	return err
+}
+
+// CreateTier creates a new membership tier.
+func (r *LoyaltyRepositoryImpl) CreateTier(ctx context.Context, tier *entities.LoyaltyTier) error {
+	query := `INSERT INTO loyalty_tiers (id, code, name, rank, min_spend, earn_multiplier, member_discount_rate, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())`
+	_, err := r.db.ExecContext(ctx, query, tier.ID, tier.Code, tier.Name, tier.Rank, tier.MinSpend, tier.EarnMultiplier, tier.MemberDiscountRate, tier.IsActive)
+	return err
+}
+
+// UpdateTier updates a membership tier.
+func (r *LoyaltyRepositoryImpl) UpdateTier(ctx context.Context, tier *entities.LoyaltyTier) error {
+	query := `UPDATE loyalty_tiers SET code = $1, name = $2, rank = $3, min_spend = $4, earn_multiplier = $5,
+		member_discount_rate = $6, is_active = $7, updated_at = NOW() WHERE id = $8`
+	_, err := r.db.ExecContext(ctx, query, tier.Code, tier.Name, tier.Rank, tier.MinSpend, tier.EarnMultiplier, tier.MemberDiscountRate, tier.IsActive, tier.ID)
+	return err
+}
+
+// DeleteTier deletes a membership tier.
+func (r *LoyaltyRepositoryImpl) DeleteTier(ctx context.Context, id uuid.ID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM loyalty_tiers WHERE id = $1`, id)
+	return err
+}
+
+// GetTierByID retrieves a membership tier by its ID.
+func (r *LoyaltyRepositoryImpl) GetTierByID(ctx context.Context, id uuid.ID) (*entities.LoyaltyTier, error) {
+	var tier entities.LoyaltyTier
+	query := `SELECT id, code, name, rank, min_spend, earn_multiplier, member_discount_rate, is_active, created_at, updated_at
+		FROM loyalty_tiers WHERE id = $1`
+	if err := r.db.GetContext(ctx, &tier, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tier, nil
+}
+
+// GetTiers retrieves all membership tiers ordered by rank.
+func (r *LoyaltyRepositoryImpl) GetTiers(ctx context.Context) ([]*entities.LoyaltyTier, error) {
+	tiers := []*entities.LoyaltyTier{}
+	query := `SELECT id, code, name, rank, min_spend, earn_multiplier, member_discount_rate, is_active, created_at, updated_at
+		FROM loyalty_tiers ORDER BY rank, min_spend`
+	if err := r.db.SelectContext(ctx, &tiers, query); err != nil {
+		return nil, err
+	}
+	return tiers, nil
+}
+
+// CreateMember creates a new loyalty member.
+func (r *LoyaltyRepositoryImpl) CreateMember(ctx context.Context, member *entities.LoyaltyMember) error {
+	query := `INSERT INTO loyalty_members (id, member_number, card_barcode, phone, name, email, customer_id, tier_id, tier_since,
+			points_balance, lifetime_points, status, joined_at, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, '')::uuid, $8, $9, 0, 0, $10, $11, NOW(), NOW())`
+	_, err := r.db.ExecContext(ctx, query, member.ID, member.MemberNumber, member.CardBarcode, member.Phone, member.Name,
+		member.Email, member.CustomerID, member.TierID, member.TierSince, member.Status, member.JoinedAt)
+	return err
+}
+
+// UpdateMember updates the contact details and status of a member. Tier and points
+// are only changed through SetMemberTier and PostEntry.
+func (r *LoyaltyRepositoryImpl) UpdateMember(ctx context.Context, member *entities.LoyaltyMember) error {
+	query := `UPDATE loyalty_members SET card_barcode = NULLIF($1, ''), phone = NULLIF($2, ''), name = $3, email = NULLIF($4, ''),
+		customer_id = NULLIF($5, '')::uuid, status = $6, updated_at = NOW() WHERE id = $7`
+	_, err := r.db.ExecContext(ctx, query, member.CardBarcode, member.Phone, member.Name, member.Email, member.CustomerID, member.Status, member.ID)
+	return err
+}
+
+func (r *LoyaltyRepositoryImpl) getMember(ctx context.Context, where string, arg interface{}) (*entities.LoyaltyMember, error) {
+	var member entities.LoyaltyMember
+	query := `SELECT ` + loyaltyMemberColumns + ` FROM loyalty_members m LEFT JOIN loyalty_tiers t ON t.id = m.tier_id WHERE ` + where
+	if err := r.db.GetContext(ctx, &member, query, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMemberByID retrieves a member by its ID.
+func (r *LoyaltyRepositoryImpl) GetMemberByID(ctx context.Context, id uuid.ID) (*entities.LoyaltyMember, error) {
+	return r.getMember(ctx, `m.id = $1`, id)
+}
+
+// FindMember finds a member by phone, card barcode or member number.
+func (r *LoyaltyRepositoryImpl) FindMember(ctx context.Context, identifier string) (*entities.LoyaltyMember, error) {
+	return r.getMember(ctx, `(m.phone = $1 OR m.card_barcode = $1 OR m.member_number = $1) LIMIT 1`, identifier)
+}
+
+// ListMembers lists members with an optional search over name, phone, card and member number.
+func (r *LoyaltyRepositoryImpl) ListMembers(ctx context.Context, search string, limit, offset int) ([]*entities.LoyaltyMember, int, error) {
+	where := `TRUE`
+	args := []interface{}{}
+	if search != "" {
+		where = `(m.name ILIKE $1 OR m.phone ILIKE $1 OR m.card_barcode ILIKE $1 OR m.member_number ILIKE $1)`
+		args = append(args, "%"+search+"%")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM loyalty_members m WHERE `+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	members := []*entities.LoyaltyMember{}
+	query := fmt.Sprintf(`SELECT %s FROM loyalty_members m LEFT JOIN loyalty_tiers t ON t.id = m.tier_id
+		WHERE %s ORDER BY m.name LIMIT $%d OFFSET $%d`, loyaltyMemberColumns, where, len(args)+1, len(args)+2)
+	if err := r.db.SelectContext(ctx, &members, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, err
+	}
+	return members, total, nil
+}
+
+// ListMemberIDs returns the IDs of active members after the given ID.
+func (r *LoyaltyRepositoryImpl) ListMemberIDs(ctx context.Context, after uuid.ID, limit int) ([]uuid.ID, error) {
+	ids := []uuid.ID{}
+	query := `SELECT id FROM loyalty_members WHERE status = 'active' AND id > $1 ORDER BY id LIMIT $2`
+	if err := r.db.SelectContext(ctx, &ids, query, after, limit); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// SetMemberTier moves a member to a tier.
+func (r *LoyaltyRepositoryImpl) SetMemberTier(ctx context.Context, memberID, tierID uuid.ID, since time.Time) error {
+	query := `UPDATE loyalty_members SET tier_id = $1, tier_since = $2, updated_at = NOW() WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, tierID, since, memberID)
+	return err
+}
+
+// UpsertMemberPrice creates or replaces the member price of an article for a tier.
+func (r *LoyaltyRepositoryImpl) UpsertMemberPrice(ctx context.Context, price *entities.LoyaltyMemberPrice) error {
+	query := `INSERT INTO loyalty_member_prices (id, tier_id, article_id, price, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (tier_id, article_id) DO UPDATE SET price = EXCLUDED.price, updated_at = NOW()
+		RETURNING id, created_at, updated_at`
+	return r.db.QueryRowxContext(ctx, query, price.ID, price.TierID, price.ArticleID, price.Price).
+		Scan(&price.ID, &price.CreatedAt, &price.UpdatedAt)
+}
+
+// DeleteMemberPrice removes a member price.
+func (r *LoyaltyRepositoryImpl) DeleteMemberPrice(ctx context.Context, id uuid.ID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM loyalty_member_prices WHERE id = $1`, id)
+	return err
+}
+
+// GetMemberPrices retrieves the member prices of a tier.
+func (r *LoyaltyRepositoryImpl) GetMemberPrices(ctx context.Context, tierID uuid.ID) ([]*entities.LoyaltyMemberPrice, error) {
+	prices := []*entities.LoyaltyMemberPrice{}
+	query := `SELECT id, tier_id, article_id, price, created_at, updated_at FROM loyalty_member_prices WHERE tier_id = $1 ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &prices, query, tierID); err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// GetBestMemberPrice returns the lowest member price of an article over the given tiers.
+func (r *LoyaltyRepositoryImpl) GetBestMemberPrice(ctx context.Context, articleID uuid.ID, tierIDs []uuid.ID) (*entities.LoyaltyMemberPrice, error) {
+	if len(tierIDs) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In(`SELECT id, tier_id, article_id, price, created_at, updated_at FROM loyalty_member_prices
+		WHERE article_id = ? AND tier_id IN (?) ORDER BY price LIMIT 1`, articleID, tierIDs)
+	if err != nil {
+		return nil, err
+	}
+	var price entities.LoyaltyMemberPrice
+	if err := r.db.GetContext(ctx, &price, r.db.Rebind(query), args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &price, nil
+}
+
+// PostEntry adds a ledger entry and updates the member's balance in one transaction.
+func (r *LoyaltyRepositoryImpl) PostEntry(ctx context.Context, entry *entities.LoyaltyPointsEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the member so concurrent checkouts cannot spend the same points
+	var balance int
+	if err := tx.GetContext(ctx, &balance, `SELECT points_balance FROM loyalty_members WHERE id = $1 FOR UPDATE`, entry.MemberID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.ErrLoyaltyMemberNotFound
+		}
+		return err
+	}
+
+	lifetime := 0
+	if entry.Points > 0 {
+		entry.RemainingPoints = entry.Points
+		if entry.EntryType == entities.LoyaltyEntryEarn {
+			lifetime = entry.Points
+		}
+	} else {
+		entry.RemainingPoints = 0
+		if err := consumePoints(ctx, tx, entry.MemberID, -entry.Points, balance); err != nil {
+			return err
+		}
+	}
+
+	if err := insertLoyaltyEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE loyalty_members SET points_balance = points_balance + $1, lifetime_points = lifetime_points + $2,
+		last_activity_at = $3, updated_at = NOW() WHERE id = $4`, entry.Points, lifetime, entry.CreatedAt, entry.MemberID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// consumePoints takes points from the member's positive entries, oldest expiry first.
+func consumePoints(ctx context.Context, tx *sqlx.Tx, memberID uuid.ID, points, balance int) error {
+	if points > balance {
+		return entities.ErrInsufficientPoints
+	}
+	rows, err := tx.QueryxContext(ctx, `SELECT id, remaining_points FROM loyalty_points_ledger
+		WHERE member_id = $1 AND remaining_points > 0
+		ORDER BY expires_at NULLS LAST, created_at FOR UPDATE`, memberID)
+	if err != nil {
+		return err
+	}
+	type lot struct {
+		ID        uuid.ID `db:"id"`
+		Remaining int     `db:"remaining_points"`
+	}
+	var lots []lot
+	for rows.Next() {
+		var l lot
+		if err := rows.StructScan(&l); err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, l := range lots {
+		if points == 0 {
+			break
+		}
+		take := l.Remaining
+		if take > points {
+			take = points
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE loyalty_points_ledger SET remaining_points = remaining_points - $1 WHERE id = $2`, take, l.ID); err != nil {
+			return err
+		}
+		points -= take
+	}
+	if points > 0 {
+		return entities.ErrInsufficientPoints
+	}
+	return nil
+}
+
+func insertLoyaltyEntry(ctx context.Context, tx *sqlx.Tx, entry *entities.LoyaltyPointsEntry) error {
+	query := `INSERT INTO loyalty_points_ledger (id, member_id, entry_type, points, remaining_points, source_type, source_id,
+			reference_id, source_amount, spend_amount, description, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10, NULLIF($11, ''), $12, $13)`
+	_, err := tx.ExecContext(ctx, query, entry.ID, entry.MemberID, entry.EntryType, entry.Points, entry.RemainingPoints,
+		entry.SourceType, entry.SourceID, entry.ReferenceID, entry.SourceAmount, entry.SpendAmount, entry.Description,
+		entry.ExpiresAt, entry.CreatedAt)
+	return err
+}
+
+// GetLedger retrieves a member's ledger entries, newest first.
+func (r *LoyaltyRepositoryImpl) GetLedger(ctx context.Context, memberID uuid.ID, limit, offset int) ([]*entities.LoyaltyPointsEntry, int, error) {
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM loyalty_points_ledger WHERE member_id = $1`, memberID); err != nil {
+		return nil, 0, err
+	}
+	entries := []*entities.LoyaltyPointsEntry{}
+	query := `SELECT ` + loyaltyEntryColumns + ` FROM loyalty_points_ledger WHERE member_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+	if err := r.db.SelectContext(ctx, &entries, query, memberID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// GetEntriesByReference returns the entries of a source document and of the documents referencing it.
+func (r *LoyaltyRepositoryImpl) GetEntriesByReference(ctx context.Context, sourceID string) ([]*entities.LoyaltyPointsEntry, error) {
+	entries := []*entities.LoyaltyPointsEntry{}
+	query := `SELECT ` + loyaltyEntryColumns + ` FROM loyalty_points_ledger WHERE source_id = $1 OR reference_id = $1 ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &entries, query, sourceID); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ExpirePoints posts expire entries for the remaining points of expired entries.
+func (r *LoyaltyRepositoryImpl) ExpirePoints(ctx context.Context, at time.Time) (int, error) {
+	expired := 0
+	for {
+		n, done, err := r.expireBatch(ctx, at)
+		expired += n
+		if err != nil || done {
+			return expired, err
+		}
+	}
+}
+
+func (r *LoyaltyRepositoryImpl) expireBatch(ctx context.Context, at time.Time) (int, bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	type lot struct {
+		ID        uuid.ID `db:"id"`
+		MemberID  uuid.ID `db:"member_id"`
+		Remaining int     `db:"remaining_points"`
+	}
+	var lots []lot
+	err = tx.SelectContext(ctx, &lots, `SELECT id, member_id, remaining_points FROM loyalty_points_ledger
+		WHERE remaining_points > 0 AND expires_at <= $1
+		ORDER BY member_id, expires_at LIMIT $2 FOR UPDATE SKIP LOCKED`, at, expireBatchSize)
+	if err != nil {
+		return 0, false, err
+	}
+
+	expired := 0
+	for _, l := range lots {
+		if _, err := tx.ExecContext(ctx, `UPDATE loyalty_points_ledger SET remaining_points = 0 WHERE id = $1`, l.ID); err != nil {
+			return 0, false, err
+		}
+		entry := &entities.LoyaltyPointsEntry{
+			ID:          uuid.New(),
+			MemberID:    l.MemberID,
+			EntryType:   entities.LoyaltyEntryExpire,
+			Points:      -l.Remaining,
+			SourceType:  entities.LoyaltySourceExpiry,
+			ReferenceID: l.ID.String(),
+			Description: "Points expired",
+			CreatedAt:   at,
+		}
+		if err := insertLoyaltyEntry(ctx, tx, entry); err != nil {
+			return 0, false, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE loyalty_members SET points_balance = points_balance - $1, updated_at = NOW() WHERE id = $2`,
+			l.Remaining, l.MemberID); err != nil {
+			return 0, false, err
+		}
+		expired += l.Remaining
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return expired, len(lots) < expireBatchSize, nil
+}
+
+// GetQualifyingSpend returns the member's qualifying spend since the given time.
+func (r *LoyaltyRepositoryImpl) GetQualifyingSpend(ctx context.Context, memberID uuid.ID, since time.Time) (float64, error) {
+	var spend float64
+	query := `SELECT COALESCE(SUM(spend_amount), 0) FROM loyalty_points_ledger WHERE member_id = $1 AND created_at >= $2`
+	err := r.db.GetContext(ctx, &spend, query, memberID, since)
+	return spend, err
+}
@@ -0,0 +1,404 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// MarketplaceRepositoryImpl implements repositories.MarketplaceRepository.
+type MarketplaceRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewMarketplaceRepositoryImpl creates a new MarketplaceRepositoryImpl.
+func NewMarketplaceRepositoryImpl(db *sqlx.DB) *MarketplaceRepositoryImpl {
+	return &MarketplaceRepositoryImpl{db: db}
+}
+
+const marketplaceChannelColumns = `id, code, name, adapter, warehouse_id, customer_id, settings, status, last_pulled_at,
+	created_at, updated_at`
+
+// CreateChannel stores a marketplace channel.
+func (r *MarketplaceRepositoryImpl) CreateChannel(ctx context.Context, ch *entities.MarketplaceChannel) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO marketplace_channels (`+marketplaceChannelColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		ch.ID, ch.Code, ch.Name, ch.Adapter, ch.WarehouseID, ch.CustomerID, ch.Settings, ch.Status, ch.LastPulledAt,
+		ch.CreatedAt, ch.UpdatedAt)
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23505":
+			return fmt.Errorf("%w: code %s is already in use", entities.ErrChannelExists, ch.Code)
+		case "23503":
+			return fmt.Errorf("%w: warehouse or customer not found", entities.ErrInvalidChannel)
+		}
+	}
+	return err
+}
+
+// GetChannel returns a marketplace channel.
+func (r *MarketplaceRepositoryImpl) GetChannel(ctx context.Context, id uuid.ID) (*entities.MarketplaceChannel, error) {
+	return r.getChannel(ctx, `id = $1`, id)
+}
+
+// GetChannelByCode returns the marketplace channel with the given code.
+func (r *MarketplaceRepositoryImpl) GetChannelByCode(ctx context.Context, code string) (*entities.MarketplaceChannel, error) {
+	return r.getChannel(ctx, `code = $1`, code)
+}
+
+func (r *MarketplaceRepositoryImpl) getChannel(ctx context.Context, where string, arg interface{}) (*entities.MarketplaceChannel, error) {
+	var ch entities.MarketplaceChannel
+	if err := r.db.GetContext(ctx, &ch, `SELECT `+marketplaceChannelColumns+` FROM marketplace_channels WHERE `+where, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// ListChannels returns the marketplace channels by code.
+func (r *MarketplaceRepositoryImpl) ListChannels(ctx context.Context) ([]*entities.MarketplaceChannel, error) {
+	channels := []*entities.MarketplaceChannel{}
+	err := r.db.SelectContext(ctx, &channels, `SELECT `+marketplaceChannelColumns+` FROM marketplace_channels ORDER BY code`)
+	return channels, err
+}
+
+// SetLastPulled records up to when the channel's orders have been pulled.
+func (r *MarketplaceRepositoryImpl) SetLastPulled(ctx context.Context, id uuid.ID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE marketplace_channels SET last_pulled_at = $2, updated_at = NOW() WHERE id = $1`, id, at)
+	return err
+}
+
+// ResolveBarcodes maps barcodes, of the barcodes table or of articles, to article IDs.
+func (r *MarketplaceRepositoryImpl) ResolveBarcodes(ctx context.Context, barcodes []string) (map[string]uuid.ID, error) {
+	articles := make(map[string]uuid.ID, len(barcodes))
+	if len(barcodes) == 0 {
+		return articles, nil
+	}
+	var rows []struct {
+		Barcode   string  `db:"barcode"`
+		ArticleID uuid.ID `db:"article_id"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT barcode, article_id FROM (
+			SELECT b.code AS barcode, b.article_id, 1 AS rank FROM barcodes b WHERE b.code = ANY($1)
+			UNION ALL
+			SELECT a.barcode, a.id, 2 FROM articles a WHERE a.barcode = ANY($1)
+		) k ORDER BY rank`, pq.Array(barcodes)); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if _, ok := articles[row.Barcode]; !ok {
+			articles[row.Barcode] = row.ArticleID
+		}
+	}
+	return articles, nil
+}
+
+// SaveSKUMappings creates or replaces the mappings of the channel SKUs.
+func (r *MarketplaceRepositoryImpl) SaveSKUMappings(ctx context.Context, mappings []*entities.ChannelSKUMapping) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range mappings {
+		// A replaced mapping keeps its ID
+		if err := tx.GetContext(ctx, &m.ID, `INSERT INTO marketplace_sku_mappings (id, channel_id, channel_sku, barcode,
+				article_id, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (channel_id, channel_sku) DO UPDATE
+			SET barcode = EXCLUDED.barcode, article_id = EXCLUDED.article_id, updated_at = EXCLUDED.updated_at
+			RETURNING id`, m.ID, m.ChannelID, m.ChannelSKU, m.Barcode, m.ArticleID, m.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const skuMappingColumns = `m.id, m.channel_id, m.channel_sku, m.barcode, m.article_id, COALESCE(a.code, '') AS article_code,
+	a.name AS article_name, m.updated_at`
+
+// ListSKUMappings returns the SKU mappings of a channel by SKU.
+func (r *MarketplaceRepositoryImpl) ListSKUMappings(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelSKUMapping, error) {
+	mappings := []*entities.ChannelSKUMapping{}
+	err := r.db.SelectContext(ctx, &mappings, `SELECT `+skuMappingColumns+`
+		FROM marketplace_sku_mappings m JOIN articles a ON a.id = m.article_id
+		WHERE m.channel_id = $1 ORDER BY m.channel_sku`, channelID)
+	return mappings, err
+}
+
+// GetSKUMappings returns the mappings of the given SKUs of a channel by SKU.
+func (r *MarketplaceRepositoryImpl) GetSKUMappings(ctx context.Context, channelID uuid.ID, skus []string) (map[string]*entities.ChannelSKUMapping, error) {
+	mappings := []*entities.ChannelSKUMapping{}
+	if err := r.db.SelectContext(ctx, &mappings, `SELECT `+skuMappingColumns+`
+		FROM marketplace_sku_mappings m JOIN articles a ON a.id = m.article_id
+		WHERE m.channel_id = $1 AND m.channel_sku = ANY($2)`, channelID, pq.Array(skus)); err != nil {
+		return nil, err
+	}
+	bySKU := make(map[string]*entities.ChannelSKUMapping, len(mappings))
+	for _, m := range mappings {
+		bySKU[m.ChannelSKU] = m
+	}
+	return bySKU, nil
+}
+
+const onlineOrderColumns = `id, marketplace, order_id, order_date, total_amount, status, customer_id::text AS customer_id,
+	channel_id, warehouse_id, buyer_name, buyer_phone, shipping_address, shipping_fee, tracking_number, shipment_id,
+	created_at, updated_at`
+
+// onlineOrderRow scans online orders, whose entity predates db tags.
+type onlineOrderRow struct {
+	ID              uuid.ID   `db:"id"`
+	Marketplace     string    `db:"marketplace"`
+	OrderID         string    `db:"order_id"`
+	OrderDate       time.Time `db:"order_date"`
+	TotalAmount     float64   `db:"total_amount"`
+	Status          string    `db:"status"`
+	CustomerID      string    `db:"customer_id"`
+	ChannelID       *uuid.ID  `db:"channel_id"`
+	WarehouseID     *uuid.ID  `db:"warehouse_id"`
+	BuyerName       string    `db:"buyer_name"`
+	BuyerPhone      string    `db:"buyer_phone"`
+	ShippingAddress string    `db:"shipping_address"`
+	ShippingFee     float64   `db:"shipping_fee"`
+	TrackingNumber  string    `db:"tracking_number"`
+	ShipmentID      *uuid.ID  `db:"shipment_id"`
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+func (row *onlineOrderRow) order() *entities.OnlineOrder {
+	order := &entities.OnlineOrder{
+		Marketplace:     row.Marketplace,
+		OrderID:         row.OrderID,
+		OrderDate:       row.OrderDate,
+		TotalAmount:     row.TotalAmount,
+		Status:          row.Status,
+		CustomerID:      row.CustomerID,
+		ChannelID:       row.ChannelID,
+		WarehouseID:     row.WarehouseID,
+		BuyerName:       row.BuyerName,
+		BuyerPhone:      row.BuyerPhone,
+		ShippingAddress: row.ShippingAddress,
+		ShippingFee:     row.ShippingFee,
+		TrackingNumber:  row.TrackingNumber,
+		ShipmentID:      row.ShipmentID,
+	}
+	order.ID = row.ID
+	order.CreatedAt, order.UpdatedAt = row.CreatedAt, row.UpdatedAt
+	return order
+}
+
+// GetOrder returns an online order with its items.
+func (r *MarketplaceRepositoryImpl) GetOrder(ctx context.Context, id uuid.ID) (*entities.OnlineOrder, error) {
+	return r.getOrder(ctx, `id = $1`, id)
+}
+
+// GetOrderByExternalID returns the online order imported from a channel's order.
+func (r *MarketplaceRepositoryImpl) GetOrderByExternalID(ctx context.Context, channelID uuid.ID, externalID string) (*entities.OnlineOrder, error) {
+	return r.getOrder(ctx, `channel_id = $1 AND order_id = $2`, channelID, externalID)
+}
+
+func (r *MarketplaceRepositoryImpl) getOrder(ctx context.Context, where string, args ...interface{}) (*entities.OnlineOrder, error) {
+	var row onlineOrderRow
+	if err := r.db.GetContext(ctx, &row, `SELECT `+onlineOrderColumns+` FROM online_orders WHERE `+where, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	order := row.order()
+	if err := r.db.SelectContext(ctx, &order.Items, `SELECT id, online_order_id, article_id, channel_sku, quantity,
+			unit_price, total_price
+		FROM online_order_items WHERE online_order_id = $1 ORDER BY channel_sku, id`, order.ID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CreateOrder stores an online order with its items and reserves their stock.
+func (r *MarketplaceRepositoryImpl) CreateOrder(ctx context.Context, order *entities.OnlineOrder) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO online_orders (id, marketplace, order_id, order_date, total_amount, status,
+			customer_id, channel_id, warehouse_id, buyer_name, buyer_phone, shipping_address, shipping_fee, tracking_number,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::uuid, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (channel_id, order_id) WHERE channel_id IS NOT NULL DO NOTHING`,
+		order.ID, order.Marketplace, order.OrderID, order.OrderDate, order.TotalAmount, order.Status, order.CustomerID,
+		order.ChannelID, order.WarehouseID, order.BuyerName, order.BuyerPhone, order.ShippingAddress, order.ShippingFee,
+		order.TrackingNumber, order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return false, err
+	} else if n == 0 {
+		return false, nil
+	}
+
+	for _, item := range order.Items {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO online_order_items (id, online_order_id, article_id, channel_sku,
+				quantity, unit_price, total_price)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			item.ID, order.ID, item.ArticleID, item.ChannelSKU, item.Quantity, item.UnitPrice, item.TotalPrice); err != nil {
+			return false, err
+		}
+		if order.WarehouseID == nil {
+			continue
+		}
+		if err := checkAvailable(ctx, tx, item.ArticleID, *order.WarehouseID, item.Quantity); err != nil {
+			return false, err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO stock_reservations (id, online_order_item_id, article_id, warehouse_id,
+				quantity, delivered_quantity, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, 0, $6, $7, $7)`,
+			uuid.New(), item.ID, item.ArticleID, *order.WarehouseID, item.Quantity, entities.ReservationOpen, order.CreatedAt); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// lockReservedOrder locks an online order and checks it still holds reserved stock.
+func lockReservedOrder(ctx context.Context, tx *sqlx.Tx, id uuid.ID) error {
+	var status string
+	if err := tx.GetContext(ctx, &status, `SELECT status FROM online_orders WHERE id = $1 FOR UPDATE`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.ErrOnlineOrderNotFound
+		}
+		return err
+	}
+	if status != entities.OnlineOrderReserved {
+		return fmt.Errorf("%w: order is %s", entities.ErrOnlineOrderStatus, status)
+	}
+	return nil
+}
+
+// CancelOrder cancels a reserved order and releases its reservations.
+func (r *MarketplaceRepositoryImpl) CancelOrder(ctx context.Context, id uuid.ID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockReservedOrder(ctx, tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE stock_reservations SET status = $2, updated_at = NOW()
+		WHERE online_order_item_id IN (SELECT id FROM online_order_items WHERE online_order_id = $1) AND status = 'open'`,
+		id, entities.ReservationClosed); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE online_orders SET status = $2, updated_at = NOW() WHERE id = $1`,
+		id, entities.OnlineOrderCancelled); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ShipOrder stores the shipment and goods issue of a reserved order, consumes its
+// reservations and marks it shipped.
+func (r *MarketplaceRepositoryImpl) ShipOrder(ctx context.Context, s *entities.OnlineOrderShipment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockReservedOrder(ctx, tx, s.OnlineOrderID); err != nil {
+		return err
+	}
+	var order struct {
+		OrderID         string   `db:"order_id"`
+		Marketplace     string   `db:"marketplace"`
+		CustomerID      string   `db:"customer_id"`
+		WarehouseID     *uuid.ID `db:"warehouse_id"`
+		ShippingAddress string   `db:"shipping_address"`
+		ShippingFee     float64  `db:"shipping_fee"`
+	}
+	if err := tx.GetContext(ctx, &order, `SELECT order_id, marketplace, customer_id::text AS customer_id, warehouse_id,
+			shipping_address, shipping_fee
+		FROM online_orders WHERE id = $1`, s.OnlineOrderID); err != nil {
+		return err
+	}
+	if order.WarehouseID == nil {
+		return fmt.Errorf("%w: order has no fulfilling warehouse", entities.ErrOnlineOrderStatus)
+	}
+	note := fmt.Sprintf("%s order %s", order.Marketplace, order.OrderID)
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO shipments (id, order_id, customer_id, courier_id, shipment_date, status,
+			tracking_number, destination_address, shipping_cost, notes, created_at, updated_at)
+		VALUES ($1, $2, $3::uuid, $4, $5, 'shipped', $6, $7, $8, $9, $5, $5)`,
+		s.ShipmentID, s.OnlineOrderID, order.CustomerID, s.CourierID, s.ShippedAt, s.TrackingNumber, order.ShippingAddress,
+		order.ShippingFee, note); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return fmt.Errorf("%w: tracking number %s is already in use", entities.ErrInvalidChannelOrder, s.TrackingNumber)
+			case "23503":
+				return fmt.Errorf("%w: courier not found", entities.ErrInvalidChannelOrder)
+			}
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO simple_goods_issues (id, warehouse_id, issue_date, status, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, 'Completed', $4, $3, $3)`, s.GoodsIssueID, *order.WarehouseID, s.ShippedAt, note); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO simple_goods_issue_items (id, goods_issue_id, article_id, quantity, notes)
+		SELECT gen_random_uuid(), $2, article_id, quantity, $3 FROM online_order_items WHERE online_order_id = $1`,
+		s.OnlineOrderID, s.GoodsIssueID, note); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE stock_reservations
+		SET delivered_quantity = quantity, status = $2, updated_at = NOW()
+		WHERE online_order_item_id IN (SELECT id FROM online_order_items WHERE online_order_id = $1) AND status = 'open'`,
+		s.OnlineOrderID, entities.ReservationClosed); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE online_orders
+		SET status = $2, tracking_number = $3, shipment_id = $4, goods_issue_id = $5, updated_at = NOW()
+		WHERE id = $1`, s.OnlineOrderID, entities.OnlineOrderShipped, s.TrackingNumber, s.ShipmentID, s.GoodsIssueID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListStockLevels returns the stock of the channel's warehouse, less open reservations,
+// of its mapped SKUs.
+func (r *MarketplaceRepositoryImpl) ListStockLevels(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelStockLevel, error) {
+	levels := []*entities.ChannelStockLevel{}
+	err := r.db.SelectContext(ctx, &levels, `SELECT m.channel_sku,
+			COALESCE((SELECT SUM(b.quantity) FROM stock_balances b
+				WHERE b.article_id = m.article_id AND b.warehouse_id = c.warehouse_id), 0)
+			- COALESCE((SELECT SUM(rs.quantity - rs.delivered_quantity) FROM stock_reservations rs
+				WHERE rs.article_id = m.article_id AND rs.warehouse_id = c.warehouse_id AND rs.status = 'open'), 0) AS available
+		FROM marketplace_sku_mappings m JOIN marketplace_channels c ON c.id = m.channel_id
+		WHERE m.channel_id = $1 ORDER BY m.channel_sku`, channelID)
+	return levels, err
+}
+
+// ListPrices returns the selling prices of the channel's mapped SKUs.
+func (r *MarketplaceRepositoryImpl) ListPrices(ctx context.Context, channelID uuid.ID) ([]*entities.ChannelPrice, error) {
+	prices := []*entities.ChannelPrice{}
+	err := r.db.SelectContext(ctx, &prices, `SELECT m.channel_sku, COALESCE(a.price, 0) AS price
+		FROM marketplace_sku_mappings m JOIN articles a ON a.id = m.article_id
+		WHERE m.channel_id = $1 ORDER BY m.channel_sku`, channelID)
+	return prices, err
+}
@@ -0,0 +1,527 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+const salesDeliveryColumns = `id, delivery_number, sales_order_id, customer_id::text AS customer_id, warehouse_id, shipment_id,
+	goods_issue_id, courier_id, tracking_number, delivery_date, status, sales_invoice_id,
+	COALESCE(created_by::text, '') AS created_by, created_at, updated_at`
+
+const salesDeliveryItemColumns = `id, delivery_id, sales_order_item_id, article_id, quantity, unit_price, total_price`
+
+// orderDocumentQueries find the sales order of a document of the order-to-cash flow.
+var orderDocumentQueries = map[string]string{
+	entities.DocumentSalesOrder: `SELECT id FROM sales_orders WHERE id = $1`,
+	entities.DocumentQuotation:  `SELECT sales_order_id FROM sales_quotations WHERE id = $1`,
+	entities.DocumentDelivery:   `SELECT sales_order_id FROM sales_deliveries WHERE id = $1`,
+	entities.DocumentShipment:   `SELECT sales_order_id FROM sales_deliveries WHERE shipment_id = $1`,
+	entities.DocumentGoodsIssue: `SELECT sales_order_id FROM sales_deliveries WHERE goods_issue_id = $1`,
+	entities.DocumentInvoice:    `SELECT sales_order_id FROM sales_invoices WHERE id = $1`,
+	entities.DocumentReceivable: `SELECT i.sales_order_id FROM accounts_receivable a
+		JOIN sales_invoices i ON i.id = a.sales_invoice_id WHERE a.id = $1`,
+	entities.DocumentPayment: `SELECT i.sales_order_id FROM sales_payments p
+		JOIN sales_invoices i ON i.id = p.sales_invoice_id WHERE p.id = $1`,
+	entities.DocumentCashReceipt: `SELECT i.sales_order_id FROM sales_payments p
+		JOIN sales_invoices i ON i.id = p.sales_invoice_id WHERE p.cash_receipt_id = $1`,
+}
+
+// orderDocumentsQuery lists all documents of a sales order, parents before children.
+const orderDocumentsQuery = `
+	SELECT 'sales_quotation' AS type, q.id, q.quotation_number || ' v' || q.version AS number, q.status,
+		q.total_amount AS amount, q.quotation_date AS date, '' AS parent_type, NULL::uuid AS parent_id, 1 AS rank
+	FROM sales_quotations q JOIN sales_orders o ON o.quotation_id = q.id WHERE o.id = $1
+	UNION ALL
+	SELECT 'sales_order', o.id, '', o.status, o.total_amount, o.order_date,
+		CASE WHEN o.quotation_id IS NULL THEN '' ELSE 'sales_quotation' END, o.quotation_id, 2
+	FROM sales_orders o WHERE o.id = $1
+	UNION ALL
+	SELECT 'sales_delivery', d.id, d.delivery_number, d.status, (SELECT COALESCE(SUM(total_price), 0) FROM sales_delivery_items WHERE delivery_id = d.id),
+		d.delivery_date, 'sales_order', d.sales_order_id, 3
+	FROM sales_deliveries d WHERE d.sales_order_id = $1
+	UNION ALL
+	SELECT 'shipment', s.id, s.tracking_number, s.status, 0::numeric, s.shipment_date::timestamptz, 'sales_delivery', d.id, 4
+	FROM sales_deliveries d JOIN shipments s ON s.id = d.shipment_id WHERE d.sales_order_id = $1
+	UNION ALL
+	SELECT 'goods_issue', g.id, '', g.status, 0::numeric, g.issue_date::timestamptz, 'sales_delivery', d.id, 4
+	FROM sales_deliveries d JOIN simple_goods_issues g ON g.id = d.goods_issue_id WHERE d.sales_order_id = $1
+	UNION ALL
+	SELECT 'sales_invoice', i.id, COALESCE(i.invoice_number, ''), i.status, i.grand_total, i.invoice_date,
+		CASE WHEN i.delivery_id IS NULL THEN 'sales_order' ELSE 'sales_delivery' END, COALESCE(i.delivery_id, i.sales_order_id), 5
+	FROM sales_invoices i WHERE i.sales_order_id = $1
+	UNION ALL
+	SELECT 'accounts_receivable', a.id, '', a.status, a.balance, a.issue_date::timestamptz, 'sales_invoice', a.sales_invoice_id, 6
+	FROM accounts_receivable a JOIN sales_invoices i ON i.id = a.sales_invoice_id WHERE i.sales_order_id = $1
+	UNION ALL
+	SELECT 'sales_payment', p.id, COALESCE(p.reference, ''), '', p.amount, p.payment_date::timestamptz, 'sales_invoice', p.sales_invoice_id, 6
+	FROM sales_payments p JOIN sales_invoices i ON i.id = p.sales_invoice_id WHERE i.sales_order_id = $1
+	UNION ALL
+	SELECT 'cash_receipt', c.id, '', '', c.amount, c.receipt_date::timestamptz, 'sales_payment', p.id, 7
+	FROM sales_payments p JOIN sales_invoices i ON i.id = p.sales_invoice_id
+		JOIN cash_receipts c ON c.id = p.cash_receipt_id WHERE i.sales_order_id = $1
+	ORDER BY rank, date`
+
+// OrderToCashRepositoryImpl implements repositories.OrderToCashRepository.
+type OrderToCashRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewOrderToCashRepositoryImpl creates a new OrderToCashRepositoryImpl.
+func NewOrderToCashRepositoryImpl(db *sqlx.DB) *OrderToCashRepositoryImpl {
+	return &OrderToCashRepositoryImpl{db: db}
+}
+
+// GetOrderLines returns the items of a sales order with their reserved, delivered and
+// invoiced quantities.
+func (r *OrderToCashRepositoryImpl) GetOrderLines(ctx context.Context, orderID uuid.ID) ([]*entities.SalesOrderLine, error) {
+	query := `SELECT i.id, i.sales_order_id, i.article_id, i.quantity, i.unit_price, i.discount_amount, i.total_price,
+			i.created_at, i.updated_at, r.warehouse_id,
+			CASE WHEN r.status = 'open' THEN r.quantity - r.delivered_quantity ELSE 0 END,
+			COALESCE((SELECT SUM(di.quantity) FROM sales_delivery_items di WHERE di.sales_order_item_id = i.id), 0),
+			COALESCE((SELECT SUM(di.quantity) FROM sales_delivery_items di JOIN sales_deliveries d ON d.id = di.delivery_id
+				WHERE di.sales_order_item_id = i.id AND d.status = 'invoiced'), 0)
+		FROM sales_order_items i LEFT JOIN stock_reservations r ON r.sales_order_item_id = i.id
+		WHERE i.sales_order_id = $1
+		ORDER BY i.created_at, i.id`
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []*entities.SalesOrderLine{}
+	for rows.Next() {
+		line := &entities.SalesOrderLine{}
+		var reserved sql.NullInt64
+		if err := rows.Scan(&line.ID, &line.SalesOrderID, &line.ArticleID, &line.Quantity, &line.UnitPrice, &line.DiscountAmount,
+			&line.TotalPrice, &line.CreatedAt, &line.UpdatedAt, &line.WarehouseID, &reserved, &line.Delivered, &line.Invoiced); err != nil {
+			return nil, err
+		}
+		line.Reserved = int(reserved.Int64)
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// Confirm moves an open order to confirmed and reserves its stock against what is on
+// hand and not yet reserved for other orders.
+func (r *OrderToCashRepositoryImpl) Confirm(ctx context.Context, orderID uuid.ID, reservations []*entities.StockReservation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE sales_orders SET status = 'confirmed', updated_at = NOW()
+		WHERE id = $1 AND status IN ('draft', 'pending')`, orderID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("%w: order is no longer open", entities.ErrSalesOrderStatus)
+	}
+
+	for _, rsv := range reservations {
+		if err := checkAvailable(ctx, tx, rsv.ArticleID, rsv.WarehouseID, rsv.Quantity); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO stock_reservations (id, sales_order_id, sales_order_item_id, article_id,
+				warehouse_id, quantity, delivered_quantity, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9)`,
+			rsv.ID, rsv.SalesOrderID, rsv.SalesOrderItemID, rsv.ArticleID, rsv.WarehouseID, rsv.Quantity, rsv.Status,
+			rsv.CreatedAt, rsv.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// NextDeliveryNumber returns a new delivery number such as DO-2026-000042.
+func (r *OrderToCashRepositoryImpl) NextDeliveryNumber(ctx context.Context, at time.Time) (string, error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, `SELECT nextval('sales_delivery_number_seq')`); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("DO-%d-%06d", at.Year(), seq), nil
+}
+
+// CreateDelivery stores a delivery with its shipment and goods issue and consumes the
+// order's reservations.
+func (r *OrderToCashRepositoryImpl) CreateDelivery(ctx context.Context, d *entities.SalesDelivery) (string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Locking the order serialises deliveries of the same order
+	var status string
+	if err := tx.GetContext(ctx, &status, `SELECT status FROM sales_orders WHERE id = $1 FOR UPDATE`, d.SalesOrderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", entities.ErrSalesOrderNotFound
+		}
+		return "", err
+	}
+	if status != entities.SalesOrderConfirmed && status != entities.SalesOrderPartiallyDelivered {
+		return "", fmt.Errorf("%w: order is %s", entities.ErrSalesOrderStatus, status)
+	}
+
+	shipment := `INSERT INTO shipments (id, sales_order_id, order_id, customer_id, courier_id, shipment_date, status,
+			tracking_number, notes, created_at, updated_at)
+		VALUES ($1, $2, $2, $3::uuid, $4, $5, 'shipped', $6, $7, $8, $8)`
+	if _, err := tx.ExecContext(ctx, shipment, d.ShipmentID, d.SalesOrderID, d.CustomerID, d.CourierID, d.DeliveryDate,
+		d.TrackingNumber, "Delivery "+d.DeliveryNumber, d.CreatedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return "", fmt.Errorf("%w: tracking number %s is already in use", entities.ErrInvalidDelivery, d.TrackingNumber)
+		}
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO simple_goods_issues (id, warehouse_id, issue_date, status, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, 'Completed', $4, $5, $5)`, d.GoodsIssueID, d.WarehouseID, d.DeliveryDate, "Delivery "+d.DeliveryNumber, d.CreatedAt); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sales_deliveries (id, delivery_number, sales_order_id, customer_id, warehouse_id,
+			shipment_id, goods_issue_id, courier_id, tracking_number, delivery_date, status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4::uuid, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid, $13, $14)`,
+		d.ID, d.DeliveryNumber, d.SalesOrderID, d.CustomerID, d.WarehouseID, d.ShipmentID, d.GoodsIssueID, d.CourierID,
+		d.TrackingNumber, d.DeliveryDate, d.Status, d.CreatedBy, d.CreatedAt, d.UpdatedAt); err != nil {
+		return "", err
+	}
+
+	for _, item := range d.Items {
+		var open int
+		if err := tx.GetContext(ctx, &open, `SELECT i.quantity - COALESCE((SELECT SUM(quantity) FROM sales_delivery_items
+				WHERE sales_order_item_id = i.id), 0)
+			FROM sales_order_items i WHERE i.id = $1`, item.SalesOrderItemID); err != nil {
+			return "", err
+		}
+		if item.Quantity > open {
+			return "", fmt.Errorf("%w: only %d of item %s are still to be delivered", entities.ErrInvalidDelivery, open, item.SalesOrderItemID)
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sales_delivery_items (id, delivery_id, sales_order_item_id, article_id,
+				quantity, unit_price, total_price)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			item.ID, d.ID, item.SalesOrderItemID, item.ArticleID, item.Quantity, item.UnitPrice, item.TotalPrice); err != nil {
+			return "", err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO simple_goods_issue_items (id, goods_issue_id, article_id, quantity, notes)
+			VALUES ($1, $2, $3, $4, $5)`, uuid.New(), d.GoodsIssueID, item.ArticleID, item.Quantity, d.DeliveryNumber); err != nil {
+			return "", err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE stock_reservations
+			SET delivered_quantity = delivered_quantity + $2,
+				status = CASE WHEN delivered_quantity + $2 >= quantity THEN 'closed' ELSE status END,
+				updated_at = NOW()
+			WHERE sales_order_item_id = $1 AND status = 'open'`, item.SalesOrderItemID, item.Quantity); err != nil {
+			return "", err
+		}
+	}
+
+	var open int
+	if err := tx.GetContext(ctx, &open, `SELECT COALESCE(SUM(i.quantity), 0) - COALESCE((SELECT SUM(di.quantity)
+			FROM sales_delivery_items di JOIN sales_order_items oi ON oi.id = di.sales_order_item_id
+			WHERE oi.sales_order_id = $1), 0)
+		FROM sales_order_items i WHERE i.sales_order_id = $1`, d.SalesOrderID); err != nil {
+		return "", err
+	}
+	status = entities.SalesOrderPartiallyDelivered
+	if open <= 0 {
+		status = entities.SalesOrderDelivered
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE sales_orders SET status = $2, updated_at = NOW() WHERE id = $1`, d.SalesOrderID, status); err != nil {
+		return "", err
+	}
+	return status, tx.Commit()
+}
+
+// GetDelivery returns a delivery with its items.
+func (r *OrderToCashRepositoryImpl) GetDelivery(ctx context.Context, id uuid.ID) (*entities.SalesDelivery, error) {
+	var d entities.SalesDelivery
+	if err := r.db.GetContext(ctx, &d, `SELECT `+salesDeliveryColumns+` FROM sales_deliveries WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	d.Items = []*entities.SalesDeliveryItem{}
+	if err := r.db.SelectContext(ctx, &d.Items, `SELECT `+salesDeliveryItemColumns+` FROM sales_delivery_items
+		WHERE delivery_id = $1 ORDER BY id`, id); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDeliveries returns the deliveries of a sales order, oldest first.
+func (r *OrderToCashRepositoryImpl) ListDeliveries(ctx context.Context, orderID uuid.ID) ([]*entities.SalesDelivery, error) {
+	deliveries := []*entities.SalesDelivery{}
+	if err := r.db.SelectContext(ctx, &deliveries, `SELECT `+salesDeliveryColumns+` FROM sales_deliveries
+		WHERE sales_order_id = $1 ORDER BY delivery_date, delivery_number`, orderID); err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	ids := make([]uuid.ID, len(deliveries))
+	byID := make(map[uuid.ID]*entities.SalesDelivery, len(deliveries))
+	for i, d := range deliveries {
+		ids[i] = d.ID
+		d.Items = []*entities.SalesDeliveryItem{}
+		byID[d.ID] = d
+	}
+	var items []*entities.SalesDeliveryItem
+	query, args, err := sqlx.In(`SELECT `+salesDeliveryItemColumns+` FROM sales_delivery_items WHERE delivery_id IN (?) ORDER BY id`, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.SelectContext(ctx, &items, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		byID[item.DeliveryID].Items = append(byID[item.DeliveryID].Items, item)
+	}
+	return deliveries, nil
+}
+
+// NextInvoiceNumber returns a new sales invoice number such as INV-2026-000042.
+func (r *OrderToCashRepositoryImpl) NextInvoiceNumber(ctx context.Context, at time.Time) (string, error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, `SELECT nextval('sales_invoice_number_seq')`); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("INV-%d-%06d", at.Year(), seq), nil
+}
+
+// CreateInvoice stores the invoice of a delivery, opens its receivable and books the
+// output VAT against the PPN tax in effect on the invoice date.
+func (r *OrderToCashRepositoryImpl) CreateInvoice(ctx context.Context, p *entities.InvoicePosting) (string, error) {
+	inv := p.Invoice
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sales_invoices (id, sales_order_id, invoice_date, total_amount, tax_amount,
+			grand_total, invoice_number, customer_id, delivery_id, due_date, paid_amount, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::uuid, $9, $10, 0, $11, $12, $13)`,
+		inv.ID, inv.SalesOrderID, inv.InvoiceDate, inv.TotalAmount, inv.TaxAmount, inv.GrandTotal, inv.InvoiceNumber,
+		inv.CustomerID, p.DeliveryID, inv.DueDate, inv.Status, inv.CreatedAt, inv.UpdatedAt); err != nil {
+		return "", err
+	}
+	// Claiming the delivery after the invoice exists keeps the link valid; a delivery
+	// invoiced concurrently is no longer posted and rolls this invoice back
+	res, err := tx.ExecContext(ctx, `UPDATE sales_deliveries SET status = 'invoiced', sales_invoice_id = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'posted'`, p.DeliveryID, inv.ID)
+	if err != nil {
+		return "", err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return "", err
+	} else if n == 0 {
+		return "", entities.ErrDeliveryInvoiced
+	}
+
+	if err := postInvoiceLines(ctx, tx, inv, p.Items, p.ReceivableID, p.PostedBy); err != nil {
+		return "", err
+	}
+
+	// The order is invoiced once it is fully delivered and no delivery is left to bill
+	var status string
+	if err := tx.GetContext(ctx, &status, `UPDATE sales_orders SET status = CASE
+			WHEN status = 'delivered' AND NOT EXISTS (SELECT 1 FROM sales_deliveries WHERE sales_order_id = $1 AND status = 'posted')
+			THEN 'invoiced' ELSE status END, updated_at = NOW()
+		WHERE id = $1 RETURNING status`, inv.SalesOrderID); err != nil {
+		return "", err
+	}
+	return status, tx.Commit()
+}
+
+// postInvoiceLines stores the items of a sales invoice, opens its receivable and books
+// the output VAT against the PPN tax in effect on the invoice date.
+func postInvoiceLines(ctx context.Context, tx *sqlx.Tx, inv *entities.SalesInvoice, items []*entities.SalesInvoiceItem,
+	receivableID uuid.ID, postedBy string) error {
+	for _, item := range items {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sales_invoice_items (id, sales_invoice_id, article_id, quantity, unit_price,
+				total_price, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			item.ID, inv.ID, item.ArticleID, item.Quantity, item.UnitPrice, item.TotalPrice, item.CreatedAt, item.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO accounts_receivable (id, sales_invoice_id, customer_id, issue_date, due_date,
+			amount, paid_amount, balance, status, created_at, updated_at)
+		VALUES ($1, $2, $3::uuid, $4, $5, $6, 0, $6, 'open', $7, $7)`,
+		receivableID, inv.ID, inv.CustomerID, inv.InvoiceDate, inv.DueDate, inv.GrandTotal, inv.CreatedAt); err != nil {
+		return err
+	}
+
+	if inv.TaxAmount <= 0 {
+		return nil
+	}
+	var taxID uuid.ID
+	err := tx.GetContext(ctx, &taxID, `SELECT id FROM taxes
+		WHERE tax_type IN ('PPN', 'VAT') AND is_active AND effective_date <= $1 AND (expiry_date IS NULL OR expiry_date >= $1)
+		ORDER BY tax_type = 'PPN' DESC, effective_date DESC LIMIT 1`, inv.InvoiceDate)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		log.Printf("[Sales] No PPN tax in effect on %s; output VAT of invoice %s not booked",
+			inv.InvoiceDate.Format("2006-01-02"), inv.InvoiceNumber)
+		return nil
+	case err != nil:
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO tax_transactions (id, tax_id, transaction_date, transaction_type,
+			base_amount, tax_amount, total_amount, reference_type, reference_id, reference_number, customer_id, created_by)
+		VALUES ($1, $2, $3, 'SALE', $4, $5, $6, 'SALES_INVOICE', $7, $8, $9, $10)`,
+		uuid.New(), taxID, inv.InvoiceDate, inv.TotalAmount, inv.TaxAmount, inv.GrandTotal, inv.ID.String(),
+		inv.InvoiceNumber, inv.CustomerID, postedBy)
+	return err
+}
+
+// GetInvoice returns a sales invoice.
+func (r *OrderToCashRepositoryImpl) GetInvoice(ctx context.Context, id uuid.ID) (*entities.SalesInvoice, error) {
+	return r.getInvoice(ctx, r.db, id, "")
+}
+
+func (r *OrderToCashRepositoryImpl) getInvoice(ctx context.Context, q sqlx.QueryerContext, id uuid.ID, lock string) (*entities.SalesInvoice, error) {
+	query := `SELECT id, COALESCE(sales_order_id::text, ''), invoice_date, total_amount, tax_amount, grand_total, COALESCE(invoice_number, ''),
+			COALESCE(customer_id::text, ''), delivery_id, due_date, paid_amount, status, created_at, updated_at
+		FROM sales_invoices WHERE id = $1 ` + lock
+	inv := &entities.SalesInvoice{}
+	err := q.QueryRowxContext(ctx, query, id).Scan(&inv.ID, &inv.SalesOrderID, &inv.InvoiceDate, &inv.TotalAmount, &inv.TaxAmount,
+		&inv.GrandTotal, &inv.InvoiceNumber, &inv.CustomerID, &inv.DeliveryID, &inv.DueDate, &inv.PaidAmount, &inv.Status,
+		&inv.CreatedAt, &inv.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return inv, err
+}
+
+// RecordPayment books a payment as cash receipt and settles the invoice and its receivable.
+func (r *OrderToCashRepositoryImpl) RecordPayment(ctx context.Context, p *entities.SalesPayment) (*entities.SalesInvoice, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	inv, err := r.getInvoice(ctx, tx, p.SalesInvoiceID, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, entities.ErrSalesInvoiceNotFound
+	}
+	if balance := inv.Balance(); p.Amount > balance+0.005 {
+		return nil, fmt.Errorf("%w: amount exceeds the open balance of %.2f", entities.ErrInvalidPayment, balance)
+	}
+
+	description := "Payment of sales invoice " + inv.InvoiceNumber
+	if p.Reference != "" {
+		description += " (" + p.Reference + ")"
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO cash_receipts (id, receipt_date, amount, description, cash_bank_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)`, p.CashReceiptID, p.PaymentDate, p.Amount, description, p.CashBankID, p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sales_payments (id, sales_invoice_id, cash_receipt_id, cash_bank_id, payment_date,
+			amount, payment_method, reference, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, '')::uuid, $10, $11)`,
+		p.ID, inv.ID, p.CashReceiptID, p.CashBankID, p.PaymentDate, p.Amount, p.PaymentMethod, p.Reference, p.CreatedBy,
+		p.CreatedAt, p.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	inv.PaidAmount = math.Round((inv.PaidAmount+p.Amount)*100) / 100
+	inv.Status = entities.InvoicePartiallyPaid
+	if inv.Balance() <= 0.005 {
+		inv.Status = entities.InvoicePaid
+	}
+	inv.UpdatedAt = p.UpdatedAt
+	if _, err := tx.ExecContext(ctx, `UPDATE sales_invoices SET paid_amount = $2, status = $3, updated_at = $4 WHERE id = $1`,
+		inv.ID, inv.PaidAmount, inv.Status, inv.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE accounts_receivable
+		SET paid_amount = $2, balance = amount - $2,
+			status = CASE WHEN amount - $2 <= 0.005 THEN 'paid' ELSE status END, updated_at = NOW()
+		WHERE sales_invoice_id = $1`, inv.ID, inv.PaidAmount); err != nil {
+		return nil, err
+	}
+
+	// The order is paid once it is invoiced in full and every invoice is settled;
+	// consignment settlement invoices have no order
+	if inv.SalesOrderID != "" {
+		if _, err := tx.ExecContext(ctx, `UPDATE sales_orders SET status = 'paid', updated_at = NOW()
+			WHERE id = $1::uuid AND status = 'invoiced'
+				AND NOT EXISTS (SELECT 1 FROM sales_invoices WHERE sales_order_id = $1::uuid AND status <> 'paid')`, inv.SalesOrderID); err != nil {
+			return nil, err
+		}
+	}
+	return inv, tx.Commit()
+}
+
+// FindOrder returns the sales order a document of the flow belongs to.
+func (r *OrderToCashRepositoryImpl) FindOrder(ctx context.Context, docType string, id uuid.ID) (*uuid.ID, error) {
+	query, ok := orderDocumentQueries[docType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", entities.ErrUnknownDocument, docType)
+	}
+	var orderID *uuid.ID
+	if err := r.db.GetContext(ctx, &orderID, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return orderID, nil
+}
+
+// GetOrderDocuments returns all documents of a sales order, parents first.
+func (r *OrderToCashRepositoryImpl) GetOrderDocuments(ctx context.Context, orderID uuid.ID) (entities.OrderDocuments, error) {
+	var rows []struct {
+		Type       string    `db:"type"`
+		ID         uuid.ID   `db:"id"`
+		Number     string    `db:"number"`
+		Status     string    `db:"status"`
+		Amount     float64   `db:"amount"`
+		Date       time.Time `db:"date"`
+		ParentType string    `db:"parent_type"`
+		ParentID   *uuid.ID  `db:"parent_id"`
+		Rank       int       `db:"rank"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, orderDocumentsQuery, orderID); err != nil {
+		return nil, err
+	}
+	documents := make(entities.OrderDocuments, 0, len(rows))
+	for _, row := range rows {
+		documents = append(documents, entities.DocumentLink{
+			Type:       row.Type,
+			ID:         row.ID,
+			Number:     row.Number,
+			Status:     row.Status,
+			Amount:     row.Amount,
+			Date:       row.Date,
+			ParentType: row.ParentType,
+			ParentID:   row.ParentID,
+		})
+	}
+	return documents, nil
+}
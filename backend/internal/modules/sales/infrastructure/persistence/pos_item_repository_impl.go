@@ -3,6 +3,7 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 	"malaka/internal/modules/sales/domain/entities"
@@ -21,18 +22,23 @@ func NewPosItemRepositoryImpl(db *sqlx.DB) *PosItemRepositoryImpl {
 
 // Create creates a new POS item in the database.
 func (r *PosItemRepositoryImpl) Create(ctx context.Context, item *entities.PosItem) error {
-	query := `INSERT INTO pos_items (id, pos_transaction_id, article_id, quantity, unit_price, line_total, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err := r.db.ExecContext(ctx, query, item.ID, item.PosTransactionID, item.ArticleID, item.Quantity, item.UnitPrice, item.TotalPrice, item.CreatedAt, item.UpdatedAt)
+	query := `INSERT INTO pos_items (id, pos_transaction_id, article_id, quantity, list_price, unit_price, discount_amount, line_total,
+		voided, void_reason, void_approved_by, original_item_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, '')::uuid, $13, $14)`
+	_, err := r.db.ExecContext(ctx, query, item.ID, item.PosTransactionID, item.ArticleID, item.Quantity, item.ListPrice, item.UnitPrice, item.DiscountAmount, item.TotalPrice,
+		item.Voided, item.VoidReason, item.VoidApprovedBy, item.OriginalItemID, item.CreatedAt, item.UpdatedAt)
 	return err
 }
 
 // GetByID retrieves a POS item by its ID from the database.
 func (r *PosItemRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.PosItem, error) {
-	query := `SELECT id, pos_transaction_id, article_id, quantity, unit_price, line_total, created_at, updated_at FROM pos_items WHERE id = $1`
+	query := `SELECT id, pos_transaction_id, article_id, quantity, COALESCE(list_price, 0), unit_price, COALESCE(discount_amount, 0), line_total,
+		voided, COALESCE(void_reason, ''), COALESCE(void_approved_by, ''), returned_quantity, COALESCE(original_item_id::text, ''), created_at, updated_at FROM pos_items WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	item := &entities.PosItem{}
-	err := row.Scan(&item.ID, &item.PosTransactionID, &item.ArticleID, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt, &item.UpdatedAt)
+	err := row.Scan(&item.ID, &item.PosTransactionID, &item.ArticleID, &item.Quantity, &item.ListPrice, &item.UnitPrice, &item.DiscountAmount, &item.TotalPrice,
+		&item.Voided, &item.VoidReason, &item.VoidApprovedBy, &item.ReturnedQuantity, &item.OriginalItemID, &item.CreatedAt, &item.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // POS item not found
 	}
@@ -41,7 +47,8 @@ func (r *PosItemRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entit
 
 // GetByPosTransactionID retrieves all POS items for a given transaction.
 func (r *PosItemRepositoryImpl) GetByPosTransactionID(ctx context.Context, posTransactionID uuid.ID) ([]*entities.PosItem, error) {
-	query := `SELECT id, pos_transaction_id, article_id, quantity, unit_price, line_total, created_at, updated_at FROM pos_items WHERE pos_transaction_id = $1 ORDER BY created_at ASC`
+	query := `SELECT id, pos_transaction_id, article_id, quantity, COALESCE(list_price, 0), unit_price, COALESCE(discount_amount, 0), line_total,
+		voided, COALESCE(void_reason, ''), COALESCE(void_approved_by, ''), returned_quantity, COALESCE(original_item_id::text, ''), created_at, updated_at FROM pos_items WHERE pos_transaction_id = $1 ORDER BY created_at ASC`
 	rows, err := r.db.QueryContext(ctx, query, posTransactionID)
 	if err != nil {
 		return nil, err
@@ -51,7 +58,8 @@ func (r *PosItemRepositoryImpl) GetByPosTransactionID(ctx context.Context, posTr
 	var items []*entities.PosItem
 	for rows.Next() {
 		item := &entities.PosItem{}
-		err := rows.Scan(&item.ID, &item.PosTransactionID, &item.ArticleID, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt, &item.UpdatedAt)
+		err := rows.Scan(&item.ID, &item.PosTransactionID, &item.ArticleID, &item.Quantity, &item.ListPrice, &item.UnitPrice, &item.DiscountAmount, &item.TotalPrice,
+			&item.Voided, &item.VoidReason, &item.VoidApprovedBy, &item.ReturnedQuantity, &item.OriginalItemID, &item.CreatedAt, &item.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -62,8 +70,8 @@ func (r *PosItemRepositoryImpl) GetByPosTransactionID(ctx context.Context, posTr
 
 // Update updates an existing POS item in the database.
 func (r *PosItemRepositoryImpl) Update(ctx context.Context, item *entities.PosItem) error {
-	query := `UPDATE pos_items SET pos_transaction_id = $1, article_id = $2, quantity = $3, unit_price = $4, line_total = $5, updated_at = $6 WHERE id = $7`
-	_, err := r.db.ExecContext(ctx, query, item.PosTransactionID, item.ArticleID, item.Quantity, item.UnitPrice, item.TotalPrice, item.UpdatedAt, item.ID)
+	query := `UPDATE pos_items SET pos_transaction_id = $1, article_id = $2, quantity = $3, unit_price = $4, discount_amount = $5, line_total = $6, updated_at = $7 WHERE id = $8`
+	_, err := r.db.ExecContext(ctx, query, item.PosTransactionID, item.ArticleID, item.Quantity, item.UnitPrice, item.DiscountAmount, item.TotalPrice, item.UpdatedAt, item.ID)
 	return err
 }
 
@@ -73,3 +81,19 @@ func (r *PosItemRepositoryImpl) Delete(ctx context.Context, id uuid.ID) error {
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// AddReturnedQuantity adds to the quantity returned on a sold line.
+func (r *PosItemRepositoryImpl) AddReturnedQuantity(ctx context.Context, id uuid.ID, quantity int) error {
+	query := `UPDATE pos_items SET returned_quantity = returned_quantity + $1, updated_at = NOW()
+		WHERE id = $2 AND NOT voided AND returned_quantity + $1 <= quantity`
+	result, err := r.db.ExecContext(ctx, query, quantity, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("%w: more would be returned than was sold", entities.ErrInvalidPosRefund)
+	}
+	return nil
+}
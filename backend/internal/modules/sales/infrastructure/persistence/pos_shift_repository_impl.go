@@ -0,0 +1,180 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+const posShiftColumns = `s.id, s.terminal_id, COALESCE(t.code, '') AS terminal_code, s.warehouse_id, COALESCE(t.store_name, '') AS store_name,
+	COALESCE(t.timezone, '') AS store_timezone, s.cashier_id, s.status, s.opened_at, s.opening_float, s.closed_at, COALESCE(s.closed_by, '') AS closed_by,
+	COALESCE(s.z_number, 0) AS z_number, s.expected_cash, s.counted_cash, s.cash_variance, COALESCE(s.notes, '') AS notes,
+	s.z_report, s.created_at, s.updated_at`
+
+const posShiftFrom = ` FROM pos_shifts s LEFT JOIN pos_terminals t ON t.id = s.terminal_id`
+
+// PosShiftRepositoryImpl implements repositories.PosShiftRepository.
+type PosShiftRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewPosShiftRepositoryImpl creates a new PosShiftRepositoryImpl.
+func NewPosShiftRepositoryImpl(db *sqlx.DB) *PosShiftRepositoryImpl {
+	return &PosShiftRepositoryImpl{db: db}
+}
+
+// Create opens a new shift.
+func (r *PosShiftRepositoryImpl) Create(ctx context.Context, shift *entities.PosShift) error {
+	query := `INSERT INTO pos_shifts (id, terminal_id, warehouse_id, cashier_id, status, opened_at, opening_float, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10)`
+	_, err := r.db.ExecContext(ctx, query, shift.ID, shift.TerminalID, shift.WarehouseID, shift.CashierID, shift.Status, shift.OpenedAt,
+		shift.OpeningFloat, shift.Notes, shift.CreatedAt, shift.UpdatedAt)
+	return err
+}
+
+func (r *PosShiftRepositoryImpl) getShift(ctx context.Context, where string, arg interface{}) (*entities.PosShift, error) {
+	var shift entities.PosShift
+	if err := r.db.GetContext(ctx, &shift, `SELECT `+posShiftColumns+posShiftFrom+` WHERE `+where, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// GetByID retrieves a shift by its ID.
+func (r *PosShiftRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.PosShift, error) {
+	return r.getShift(ctx, `s.id = $1`, id)
+}
+
+// GetOpenByTerminal returns the open shift of a terminal.
+func (r *PosShiftRepositoryImpl) GetOpenByTerminal(ctx context.Context, terminalID uuid.ID) (*entities.PosShift, error) {
+	return r.getShift(ctx, `s.terminal_id = $1 AND s.status = 'open'`, terminalID)
+}
+
+// GetOpenByCashier returns the open shift of a cashier.
+func (r *PosShiftRepositoryImpl) GetOpenByCashier(ctx context.Context, cashierID uuid.ID) (*entities.PosShift, error) {
+	return r.getShift(ctx, `s.cashier_id = $1 AND s.status = 'open'`, cashierID)
+}
+
+// List returns shifts newest first, optionally filtered by terminal and status.
+func (r *PosShiftRepositoryImpl) List(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosShift, int, error) {
+	where := `TRUE`
+	args := []interface{}{}
+	if terminalID != nil {
+		args = append(args, *terminalID)
+		where += fmt.Sprintf(` AND s.terminal_id = $%d`, len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(` AND s.status = $%d`, len(args))
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM pos_shifts s WHERE `+where, args...); err != nil {
+		return nil, 0, err
+	}
+	shifts := []*entities.PosShift{}
+	query := fmt.Sprintf(`SELECT %s%s WHERE %s ORDER BY s.opened_at DESC LIMIT $%d OFFSET $%d`,
+		posShiftColumns, posShiftFrom, where, len(args)+1, len(args)+2)
+	if err := r.db.SelectContext(ctx, &shifts, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, err
+	}
+	return shifts, total, nil
+}
+
+// Close closes an open shift and assigns the terminal's next Z number.
+func (r *PosShiftRepositoryImpl) Close(ctx context.Context, shift *entities.PosShift) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the terminal so two shifts cannot take the same Z number
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM pos_terminals WHERE id = $1 FOR UPDATE`, shift.TerminalID); err != nil {
+		return err
+	}
+	var status string
+	if err := tx.GetContext(ctx, &status, `SELECT status FROM pos_shifts WHERE id = $1 FOR UPDATE`, shift.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.ErrPosShiftNotFound
+		}
+		return err
+	}
+	if status != entities.PosShiftOpen {
+		return entities.ErrPosShiftClosed
+	}
+	if err := tx.GetContext(ctx, &shift.ZNumber, `SELECT COALESCE(MAX(z_number), 0) + 1 FROM pos_shifts WHERE terminal_id = $1`, shift.TerminalID); err != nil {
+		return err
+	}
+	if shift.ZReport != nil {
+		shift.ZReport.ZNumber = shift.ZNumber
+	}
+
+	query := `UPDATE pos_shifts SET status = $1, closed_at = $2, closed_by = NULLIF($3, ''), z_number = $4, expected_cash = $5,
+		counted_cash = $6, cash_variance = $7, notes = NULLIF($8, ''), z_report = $9, updated_at = $10 WHERE id = $11`
+	if _, err := tx.ExecContext(ctx, query, shift.Status, shift.ClosedAt, shift.ClosedBy, shift.ZNumber, shift.ExpectedCash,
+		shift.CountedCash, shift.CashVariance, shift.Notes, shift.ZReport, shift.UpdatedAt, shift.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddCashMovement records a cash movement on an open shift.
+func (r *PosShiftRepositoryImpl) AddCashMovement(ctx context.Context, movement *entities.PosCashMovement) error {
+	query := `INSERT INTO pos_cash_movements (id, shift_id, movement_type, amount, reason, created_by, created_at)
+		SELECT $1, $2, $3, $4, $5, NULLIF($6, ''), $7 WHERE EXISTS (SELECT 1 FROM pos_shifts WHERE id = $2 AND status = 'open')`
+	result, err := r.db.ExecContext(ctx, query, movement.ID, movement.ShiftID, movement.MovementType, movement.Amount, movement.Reason,
+		movement.CreatedBy, movement.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return entities.ErrPosShiftClosed
+	}
+	return nil
+}
+
+// GetCashMovements retrieves the cash movements of a shift.
+func (r *PosShiftRepositoryImpl) GetCashMovements(ctx context.Context, shiftID uuid.ID) ([]*entities.PosCashMovement, error) {
+	movements := []*entities.PosCashMovement{}
+	query := `SELECT id, shift_id, movement_type, amount, reason, COALESCE(created_by, '') AS created_by, created_at
+		FROM pos_cash_movements WHERE shift_id = $1 ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &movements, query, shiftID); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// GetSales returns the sales totals of a shift from its completed transactions, with
+// refunds netted off. Payments are the tenders taken, less the refunds paid out.
+func (r *PosShiftRepositoryImpl) GetSales(ctx context.Context, shiftID uuid.ID) (*entities.PosShiftSales, error) {
+	sales := &entities.PosShiftSales{Payments: []entities.PosPaymentTotal{}}
+	query := `SELECT COUNT(*), COALESCE(SUM(total_amount - COALESCE(tax_amount, 0) + COALESCE(discount_amount, 0)), 0),
+			COALESCE(SUM(discount_amount), 0), COALESCE(SUM(tax_amount), 0), COALESCE(SUM(total_amount), 0)
+		FROM pos_transactions WHERE shift_id = $1 AND status = $2`
+	if err := r.db.QueryRowContext(ctx, query, shiftID, entities.PosStatusCompleted).Scan(&sales.TransactionCount, &sales.GrossSales,
+		&sales.DiscountTotal, &sales.TaxTotal, &sales.NetSales); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT LOWER(p.payment_method) AS payment_method, COUNT(DISTINCT p.pos_transaction_id) AS transaction_count,
+			SUM(p.amount) AS amount
+		FROM pos_payments p JOIN pos_transactions t ON t.id = p.pos_transaction_id
+		WHERE t.shift_id = $1 AND t.status = $2
+		GROUP BY LOWER(p.payment_method)
+		ORDER BY payment_method`
+	if err := r.db.SelectContext(ctx, &sales.Payments, query, shiftID, entities.PosStatusCompleted); err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
@@ -0,0 +1,215 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+const posSyncUploadColumns = `client_transaction_id, terminal_id, COALESCE(receipt_number, '') AS receipt_number, status,
+	conflicts, COALESCE(error, '') AS error, attempts, sold_at, received_at`
+
+// PosSyncRepositoryImpl implements repositories.PosSyncRepository.
+type PosSyncRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewPosSyncRepositoryImpl creates a new PosSyncRepositoryImpl.
+func NewPosSyncRepositoryImpl(db *sqlx.DB) *PosSyncRepositoryImpl {
+	return &PosSyncRepositoryImpl{db: db}
+}
+
+// GetChanges returns up to limit catalog changes after a version, oldest first.
+func (r *PosSyncRepositoryImpl) GetChanges(ctx context.Context, since int64, limit int) ([]*entities.PosSyncChange, error) {
+	changes := []*entities.PosSyncChange{}
+	query := `SELECT version, entity_type, entity_id, operation, changed_at FROM pos_sync_changes
+		WHERE version > $1 ORDER BY version LIMIT $2`
+	if err := r.db.SelectContext(ctx, &changes, query, since, limit); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// LatestVersion returns the version of the last catalog change.
+func (r *PosSyncRepositoryImpl) LatestVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := r.db.GetContext(ctx, &version, `SELECT COALESCE(MAX(version), 0) FROM pos_sync_changes`)
+	return version, err
+}
+
+// GetArticles retrieves the catalog articles with the given IDs.
+func (r *PosSyncRepositoryImpl) GetArticles(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogArticle, error) {
+	articles := []*entities.PosCatalogArticle{}
+	err := r.selectIn(ctx, &articles, `SELECT id, COALESCE(code, '') AS code, name, COALESCE(brand, '') AS brand,
+		COALESCE(category, '') AS category, classification_id, color_id, size_id, COALESCE(barcode, '') AS barcode, price,
+		COALESCE(status, 'active') AS status, COALESCE(thumbnail_url, '') AS thumbnail_url, updated_at
+		FROM articles WHERE id IN (?)`, ids)
+	return articles, err
+}
+
+// GetPrices retrieves the dated prices with the given IDs.
+func (r *PosSyncRepositoryImpl) GetPrices(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogPrice, error) {
+	prices := []*entities.PosCatalogPrice{}
+	err := r.selectIn(ctx, &prices, `SELECT id, article_id, amount, currency, effective_date FROM prices WHERE id IN (?)`, ids)
+	return prices, err
+}
+
+// GetBarcodes retrieves the barcodes with the given IDs.
+func (r *PosSyncRepositoryImpl) GetBarcodes(ctx context.Context, ids []uuid.ID) ([]*entities.PosCatalogBarcode, error) {
+	barcodes := []*entities.PosCatalogBarcode{}
+	err := r.selectIn(ctx, &barcodes, `SELECT id, article_id, code FROM barcodes WHERE id IN (?)`, ids)
+	return barcodes, err
+}
+
+// GetPromotions retrieves the promotions with the given IDs.
+func (r *PosSyncRepositoryImpl) GetPromotions(ctx context.Context, ids []uuid.ID) ([]*entities.Promotion, error) {
+	if len(ids) == 0 {
+		return []*entities.Promotion{}, nil
+	}
+	query, args, err := sqlx.In(`SELECT `+promotionColumns+` FROM promotions WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promos := []*entities.Promotion{}
+	for rows.Next() {
+		promo, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promos = append(promos, promo)
+	}
+	return promos, rows.Err()
+}
+
+func (r *PosSyncRepositoryImpl) selectIn(ctx context.Context, dest interface{}, query string, ids []uuid.ID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(query, ids)
+	if err != nil {
+		return err
+	}
+	return r.db.SelectContext(ctx, dest, r.db.Rebind(query), args...)
+}
+
+// CurrentPrice returns the price of an article at a time.
+func (r *PosSyncRepositoryImpl) CurrentPrice(ctx context.Context, articleID uuid.ID, at time.Time) (float64, bool, error) {
+	var price float64
+	query := `SELECT COALESCE(
+			(SELECT p.amount FROM prices p WHERE p.article_id = a.id AND p.effective_date <= $2
+			 ORDER BY p.effective_date DESC LIMIT 1),
+			a.price)
+		FROM articles a WHERE a.id = $1`
+	if err := r.db.GetContext(ctx, &price, query, articleID, at); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return price, true, nil
+}
+
+// GetUpload returns the result of an uploaded transaction.
+func (r *PosSyncRepositoryImpl) GetUpload(ctx context.Context, clientTransactionID uuid.ID) (*entities.PosSyncUpload, error) {
+	var upload entities.PosSyncUpload
+	query := `SELECT ` + posSyncUploadColumns + ` FROM pos_sync_uploads WHERE client_transaction_id = $1`
+	if err := r.db.GetContext(ctx, &upload, query, clientTransactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// SaveUpload stores the result of an upload, counting the attempts.
+func (r *PosSyncRepositoryImpl) SaveUpload(ctx context.Context, upload *entities.PosSyncUpload) error {
+	query := `INSERT INTO pos_sync_uploads (client_transaction_id, terminal_id, receipt_number, status, conflicts, error, attempts, sold_at, received_at)
+			  VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''), 1, $7, $8)
+			  ON CONFLICT (client_transaction_id) DO UPDATE SET
+			  receipt_number = EXCLUDED.receipt_number, status = EXCLUDED.status, conflicts = EXCLUDED.conflicts,
+			  error = EXCLUDED.error, attempts = pos_sync_uploads.attempts + 1, received_at = EXCLUDED.received_at
+			  RETURNING attempts`
+	return r.db.GetContext(ctx, &upload.Attempts, query, upload.ClientTransactionID, upload.TerminalID, upload.ReceiptNumber,
+		upload.Status, upload.Conflicts, upload.Error, upload.SoldAt, upload.ReceivedAt)
+}
+
+// ListUploads returns uploads newest first, optionally filtered by terminal and status.
+func (r *PosSyncRepositoryImpl) ListUploads(ctx context.Context, terminalID *uuid.ID, status string, limit, offset int) ([]*entities.PosSyncUpload, int, error) {
+	where := `TRUE`
+	args := []interface{}{}
+	if terminalID != nil {
+		args = append(args, *terminalID)
+		where += fmt.Sprintf(` AND terminal_id = $%d`, len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(` AND status = $%d`, len(args))
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM pos_sync_uploads WHERE `+where, args...); err != nil {
+		return nil, 0, err
+	}
+	uploads := []*entities.PosSyncUpload{}
+	query := fmt.Sprintf(`SELECT %s FROM pos_sync_uploads WHERE %s ORDER BY received_at DESC LIMIT $%d OFFSET $%d`,
+		posSyncUploadColumns, where, len(args)+1, len(args)+2)
+	if err := r.db.SelectContext(ctx, &uploads, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, err
+	}
+	return uploads, total, nil
+}
+
+// RecordDownload stores the catalog version a terminal downloaded.
+func (r *PosSyncRepositoryImpl) RecordDownload(ctx context.Context, terminalID uuid.ID, version int64, clientVersion string) error {
+	query := `INSERT INTO pos_sync_status (terminal_id, last_download_version, last_download_at, client_version, updated_at)
+			  VALUES ($1, $2, NOW(), NULLIF($3, ''), NOW())
+			  ON CONFLICT (terminal_id) DO UPDATE SET
+			  last_download_version = EXCLUDED.last_download_version, last_download_at = EXCLUDED.last_download_at,
+			  client_version = COALESCE(EXCLUDED.client_version, pos_sync_status.client_version), updated_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, terminalID, version, clientVersion)
+	return err
+}
+
+// RecordUpload stores the time of a terminal's last upload.
+func (r *PosSyncRepositoryImpl) RecordUpload(ctx context.Context, terminalID uuid.ID, clientVersion string) error {
+	query := `INSERT INTO pos_sync_status (terminal_id, last_upload_at, client_version, updated_at)
+			  VALUES ($1, NOW(), NULLIF($2, ''), NOW())
+			  ON CONFLICT (terminal_id) DO UPDATE SET
+			  last_upload_at = EXCLUDED.last_upload_at,
+			  client_version = COALESCE(EXCLUDED.client_version, pos_sync_status.client_version), updated_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, terminalID, clientVersion)
+	return err
+}
+
+// GetStatuses returns the sync status of every terminal with its upload counts.
+func (r *PosSyncRepositoryImpl) GetStatuses(ctx context.Context) ([]*entities.PosTerminalSyncStatus, error) {
+	statuses := []*entities.PosTerminalSyncStatus{}
+	query := `SELECT t.id AS terminal_id, t.code AS terminal_code, t.name AS terminal_name, t.store_name,
+			COALESCE(s.client_version, '') AS client_version, COALESCE(s.last_download_version, 0) AS last_download_version,
+			s.last_download_at, s.last_upload_at,
+			COUNT(u.client_transaction_id) FILTER (WHERE u.status = 'accepted') AS accepted_count,
+			COUNT(u.client_transaction_id) FILTER (WHERE u.status = 'rejected') AS rejected_count,
+			COUNT(u.client_transaction_id) FILTER (WHERE u.conflicts IS NOT NULL) AS conflict_count
+		FROM pos_terminals t
+		LEFT JOIN pos_sync_status s ON s.terminal_id = t.id
+		LEFT JOIN pos_sync_uploads u ON u.terminal_id = t.id
+		GROUP BY t.id, t.code, t.name, t.store_name, s.client_version, s.last_download_version, s.last_download_at, s.last_upload_at
+		ORDER BY COALESCE(s.last_download_version, 0), t.code`
+	if err := r.db.SelectContext(ctx, &statuses, query); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
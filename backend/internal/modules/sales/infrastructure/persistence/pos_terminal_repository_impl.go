@@ -0,0 +1,86 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+const posTerminalColumns = `t.id, t.code, t.name, t.store_name, t.warehouse_id, COALESCE(w.name, '') AS warehouse_name,
+	t.timezone, t.is_active, t.created_at, t.updated_at`
+
+// PosTerminalRepositoryImpl implements repositories.PosTerminalRepository.
+type PosTerminalRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewPosTerminalRepositoryImpl creates a new PosTerminalRepositoryImpl.
+func NewPosTerminalRepositoryImpl(db *sqlx.DB) *PosTerminalRepositoryImpl {
+	return &PosTerminalRepositoryImpl{db: db}
+}
+
+// Create creates a new POS terminal in the database.
+func (r *PosTerminalRepositoryImpl) Create(ctx context.Context, terminal *entities.PosTerminal) error {
+	query := `INSERT INTO pos_terminals (id, code, name, store_name, warehouse_id, timezone, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := r.db.ExecContext(ctx, query, terminal.ID, terminal.Code, terminal.Name, terminal.StoreName, terminal.WarehouseID,
+		terminal.Timezone, terminal.IsActive, terminal.CreatedAt, terminal.UpdatedAt)
+	return err
+}
+
+// GetByID retrieves a POS terminal by its ID from the database.
+func (r *PosTerminalRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.PosTerminal, error) {
+	var terminal entities.PosTerminal
+	query := `SELECT ` + posTerminalColumns + ` FROM pos_terminals t LEFT JOIN warehouses w ON w.id = t.warehouse_id WHERE t.id = $1`
+	if err := r.db.GetContext(ctx, &terminal, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &terminal, nil
+}
+
+// GetAll retrieves all POS terminals from the database.
+func (r *PosTerminalRepositoryImpl) GetAll(ctx context.Context) ([]*entities.PosTerminal, error) {
+	terminals := []*entities.PosTerminal{}
+	query := `SELECT ` + posTerminalColumns + ` FROM pos_terminals t LEFT JOIN warehouses w ON w.id = t.warehouse_id ORDER BY t.store_name, t.code`
+	if err := r.db.SelectContext(ctx, &terminals, query); err != nil {
+		return nil, err
+	}
+	return terminals, nil
+}
+
+// Update updates an existing POS terminal in the database.
+func (r *PosTerminalRepositoryImpl) Update(ctx context.Context, terminal *entities.PosTerminal) error {
+	query := `UPDATE pos_terminals SET code = $1, name = $2, store_name = $3, warehouse_id = $4, timezone = $5, is_active = $6,
+		updated_at = $7 WHERE id = $8`
+	_, err := r.db.ExecContext(ctx, query, terminal.Code, terminal.Name, terminal.StoreName, terminal.WarehouseID, terminal.Timezone,
+		terminal.IsActive, terminal.UpdatedAt, terminal.ID)
+	return err
+}
+
+// Delete deletes a POS terminal by its ID from the database.
+func (r *PosTerminalRepositoryImpl) Delete(ctx context.Context, id uuid.ID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM pos_terminals WHERE id = $1`, id)
+	return err
+}
+
+// NextReceiptNumber takes the next number from the terminal's receipt counter.
+func (r *PosTerminalRepositoryImpl) NextReceiptNumber(ctx context.Context, terminalID uuid.ID) (string, error) {
+	var code string
+	var counter int
+	query := `UPDATE pos_terminals SET receipt_counter = receipt_counter + 1 WHERE id = $1 RETURNING code, receipt_counter`
+	if err := r.db.QueryRowContext(ctx, query, terminalID).Scan(&code, &counter); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", entities.ErrPosTerminalNotFound
+		}
+		return "", err
+	}
+	return fmt.Sprintf("%s-%06d", code, counter), nil
+}
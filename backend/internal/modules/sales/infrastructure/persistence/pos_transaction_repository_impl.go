@@ -21,31 +21,65 @@ func NewPosTransactionRepositoryImpl(db *sqlx.DB) *PosTransactionRepositoryImpl
 
 // Create creates a new POS transaction in the database.
 func (r *PosTransactionRepositoryImpl) Create(ctx context.Context, pt *entities.PosTransaction) error {
-	query := `INSERT INTO pos_transactions (id, transaction_date, total_amount, payment_method, cashier_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query, pt.ID, pt.TransactionDate, pt.TotalAmount, pt.PaymentMethod, pt.CashierID, pt.CreatedAt, pt.UpdatedAt)
+	query := `INSERT INTO pos_transactions (id, transaction_date, total_amount, payment_method, cashier_id, customer_id, location,
+			  subtotal, tax_amount, discount_amount, customer_name, customer_phone, member_id, points_earned, points_redeemed,
+			  points_value, points_redemption_mode, terminal_id, shift_id, receipt_number, status, transaction_type,
+			  original_transaction_id, amount_tendered, change_amount, payment_status, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, NULLIF($7, ''), $8, $9, $10, NULLIF($11, ''), NULLIF($12, ''),
+			  NULLIF($13, '')::uuid, $14, $15, $16, NULLIF($17, ''), NULLIF($18, '')::uuid, NULLIF($19, '')::uuid, NULLIF($20, ''), $21, $22,
+			  NULLIF($23, '')::uuid, $24, $25, COALESCE(NULLIF($26, ''), 'pending'), $27, $28)`
+	_, err := r.db.ExecContext(ctx, query, pt.ID, pt.TransactionDate, pt.TotalAmount, pt.PaymentMethod, pt.CashierID, pt.CustomerID, pt.Location,
+		pt.Subtotal, pt.TaxAmount, pt.DiscountAmount, pt.CustomerName, pt.CustomerPhone, pt.MemberID, pt.PointsEarned, pt.PointsRedeemed,
+		pt.PointsValue, pt.PointsRedemptionMode, pt.TerminalID, pt.ShiftID, pt.ReceiptNumber, pt.Status, pt.TransactionType,
+		pt.OriginalTransactionID, pt.AmountTendered, pt.ChangeAmount, pt.PaymentStatus, pt.CreatedAt, pt.UpdatedAt)
 	return err
 }
 
-// GetByID retrieves a POS transaction by its ID from the database.
-func (r *PosTransactionRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.PosTransaction, error) {
-	query := `SELECT id, transaction_date, total_amount, payment_method, cashier_id,
+// posTransactionSelect selects a single POS transaction; the WHERE condition is appended.
+const posTransactionSelect = `SELECT id, transaction_date, total_amount, payment_method, cashier_id,
 			  COALESCE(sales_person, '') as sales_person, COALESCE(customer_name, '') as customer_name,
 			  COALESCE(customer_phone, '') as customer_phone, COALESCE(customer_address, '') as customer_address,
 			  COALESCE(visit_type, '') as visit_type, COALESCE(location, '') as location,
 			  COALESCE(subtotal, 0) as subtotal, COALESCE(tax_amount, 0) as tax_amount, COALESCE(discount_amount, 0) as discount_amount,
 			  COALESCE(payment_status, '') as payment_status, COALESCE(delivery_method, '') as delivery_method, COALESCE(delivery_status, '') as delivery_status,
 			  COALESCE(commission_rate, 0) as commission_rate, COALESCE(commission_amount, 0) as commission_amount,
-			  COALESCE(notes, '') as notes,
+			  COALESCE(notes, '') as notes, COALESCE(customer_id::text, '') as customer_id,
+			  COALESCE(member_id::text, '') as member_id, points_earned, points_redeemed, points_value,
+			  COALESCE(points_redemption_mode, '') as points_redemption_mode,
+			  COALESCE(terminal_id::text, '') as terminal_id, COALESCE(shift_id::text, '') as shift_id,
+			  ` + posTransactionLifecycleColumns + `,
 			  created_at, updated_at
-			  FROM pos_transactions WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
+			  FROM pos_transactions WHERE `
+
+// posTransactionLifecycleColumns are the receipt, void and refund columns.
+const posTransactionLifecycleColumns = `COALESCE(receipt_number, '') as receipt_number, status, transaction_type,
+			  COALESCE(original_transaction_id::text, '') as original_transaction_id, amount_tendered, change_amount,
+			  voided_at, COALESCE(voided_by, '') as voided_by, COALESCE(void_approved_by, '') as void_approved_by,
+			  COALESCE(void_reason, '') as void_reason`
+
+// GetByID retrieves a POS transaction by its ID from the database.
+func (r *PosTransactionRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.PosTransaction, error) {
+	return r.getTransaction(ctx, `id = $1`, id)
+}
+
+// GetByReceiptNumber retrieves a POS transaction by its receipt number.
+func (r *PosTransactionRepositoryImpl) GetByReceiptNumber(ctx context.Context, receiptNumber string) (*entities.PosTransaction, error) {
+	return r.getTransaction(ctx, `receipt_number = $1`, receiptNumber)
+}
+
+func (r *PosTransactionRepositoryImpl) getTransaction(ctx context.Context, where string, arg interface{}) (*entities.PosTransaction, error) {
+	row := r.db.QueryRowContext(ctx, posTransactionSelect+where, arg)
 
 	pt := &entities.PosTransaction{}
 	err := row.Scan(&pt.ID, &pt.TransactionDate, &pt.TotalAmount, &pt.PaymentMethod, &pt.CashierID,
 		&pt.SalesPerson, &pt.CustomerName, &pt.CustomerPhone, &pt.CustomerAddress,
 		&pt.VisitType, &pt.Location, &pt.Subtotal, &pt.TaxAmount, &pt.DiscountAmount,
 		&pt.PaymentStatus, &pt.DeliveryMethod, &pt.DeliveryStatus,
-		&pt.CommissionRate, &pt.CommissionAmount, &pt.Notes,
+		&pt.CommissionRate, &pt.CommissionAmount, &pt.Notes, &pt.CustomerID,
+		&pt.MemberID, &pt.PointsEarned, &pt.PointsRedeemed, &pt.PointsValue, &pt.PointsRedemptionMode,
+		&pt.TerminalID, &pt.ShiftID,
+		&pt.ReceiptNumber, &pt.Status, &pt.TransactionType, &pt.OriginalTransactionID, &pt.AmountTendered, &pt.ChangeAmount,
+		&pt.VoidedAt, &pt.VoidedBy, &pt.VoidApprovedBy, &pt.VoidReason,
 		&pt.CreatedAt, &pt.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // POS transaction not found
@@ -59,7 +93,10 @@ func (r *PosTransactionRepositoryImpl) GetAll(ctx context.Context) ([]*entities.
 			  sales_person, customer_name, customer_phone, customer_address, 
 			  visit_type, location, subtotal, tax_amount, discount_amount,
 			  payment_status, delivery_method, delivery_status, 
-			  commission_rate, commission_amount, notes,
+			  commission_rate, commission_amount, notes, COALESCE(customer_id::text, ''),
+			  COALESCE(member_id::text, ''), points_earned, points_redeemed, points_value, COALESCE(points_redemption_mode, ''),
+			  COALESCE(terminal_id::text, ''), COALESCE(shift_id::text, ''),
+			  ` + posTransactionLifecycleColumns + `,
 			  created_at, updated_at 
 			  FROM pos_transactions 
 			  ORDER BY transaction_date DESC`
@@ -84,7 +121,11 @@ func (r *PosTransactionRepositoryImpl) GetAll(ctx context.Context) ([]*entities.
 			&salesPerson, &customerName, &customerPhone, &customerAddress,
 			&visitType, &location, &subtotal, &taxAmount, &discountAmount,
 			&paymentStatus, &deliveryMethod, &deliveryStatus,
-			&commissionRate, &commissionAmount, &notes,
+			&commissionRate, &commissionAmount, &notes, &pt.CustomerID,
+			&pt.MemberID, &pt.PointsEarned, &pt.PointsRedeemed, &pt.PointsValue, &pt.PointsRedemptionMode,
+			&pt.TerminalID, &pt.ShiftID,
+			&pt.ReceiptNumber, &pt.Status, &pt.TransactionType, &pt.OriginalTransactionID, &pt.AmountTendered, &pt.ChangeAmount,
+			&pt.VoidedAt, &pt.VoidedBy, &pt.VoidApprovedBy, &pt.VoidReason,
 			&pt.CreatedAt, &pt.UpdatedAt)
 		if err != nil {
 			return nil, err
@@ -126,3 +167,41 @@ func (r *PosTransactionRepositoryImpl) Delete(ctx context.Context, id uuid.ID) e
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// Void marks a completed transaction voided.
+func (r *PosTransactionRepositoryImpl) Void(ctx context.Context, pt *entities.PosTransaction) error {
+	query := `UPDATE pos_transactions SET status = $1, voided_at = $2, voided_by = NULLIF($3, ''), void_approved_by = NULLIF($4, ''),
+			  void_reason = $5, updated_at = $6 WHERE id = $7 AND status = $8`
+	result, err := r.db.ExecContext(ctx, query, entities.PosStatusVoided, pt.VoidedAt, pt.VoidedBy, pt.VoidApprovedBy,
+		pt.VoidReason, pt.UpdatedAt, pt.ID, entities.PosStatusCompleted)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return entities.ErrPosTransactionVoided
+	}
+	pt.Status = entities.PosStatusVoided
+	return nil
+}
+
+// CreatePayment records a tender of a POS transaction.
+func (r *PosTransactionRepositoryImpl) CreatePayment(ctx context.Context, payment *entities.PosPayment) error {
+	query := `INSERT INTO pos_payments (id, pos_transaction_id, payment_method, amount, tendered, change_amount, reference, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)`
+	_, err := r.db.ExecContext(ctx, query, payment.ID, payment.PosTransactionID, payment.PaymentMethod, payment.Amount,
+		payment.Tendered, payment.Change, payment.Reference, payment.CreatedAt)
+	return err
+}
+
+// GetPayments retrieves the tenders of a POS transaction.
+func (r *PosTransactionRepositoryImpl) GetPayments(ctx context.Context, posTransactionID uuid.ID) ([]*entities.PosPayment, error) {
+	payments := []*entities.PosPayment{}
+	query := `SELECT id, pos_transaction_id, payment_method, amount, tendered, change_amount, COALESCE(reference, '') AS reference, created_at
+			  FROM pos_payments WHERE pos_transaction_id = $1 ORDER BY created_at, payment_method`
+	if err := r.db.SelectContext(ctx, &payments, query, posTransactionID); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -3,11 +3,19 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"malaka/internal/modules/sales/domain/entities"
 )
 
+const promotionColumns = `id, name, description, start_date, end_date, discount_rate, min_purchase,
+	COALESCE(code, ''), promotion_type, priority, stackable, is_active, discount_amount, max_discount,
+	buy_quantity, get_quantity, bundle_quantity, bundle_price, tiers, conditions,
+	max_redemptions, redemption_count, budget, budget_used, created_at, updated_at`
+
 // PromotionRepositoryImpl implements repositories.PromotionRepository.
 type PromotionRepositoryImpl struct {
 	db *sqlx.DB
@@ -18,30 +26,21 @@ func NewPromotionRepositoryImpl(db *sqlx.DB) *PromotionRepositoryImpl {
 	return &PromotionRepositoryImpl{db: db}
 }
 
-// Create creates a new promotion in the database.
-func (r *PromotionRepositoryImpl) Create(ctx context.Context, promo *entities.Promotion) error {
-	query := `INSERT INTO promotions (id, name, description, start_date, end_date, discount_rate, min_purchase, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, err := r.db.ExecContext(ctx, query, promo.ID, promo.Name, promo.Description, promo.StartDate, promo.EndDate, promo.DiscountRate, promo.MinPurchase, promo.CreatedAt, promo.UpdatedAt)
-	return err
+type promotionScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// GetByID retrieves a promotion by its ID from the database.
-func (r *PromotionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Promotion, error) {
-	query := `SELECT id, name, description, start_date, end_date, discount_rate, min_purchase, created_at, updated_at FROM promotions WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
-
+func scanPromotion(row promotionScanner) (*entities.Promotion, error) {
 	promo := &entities.Promotion{}
-	err := row.Scan(&promo.ID, &promo.Name, &promo.Description, &promo.StartDate, &promo.EndDate, &promo.DiscountRate, &promo.MinPurchase, &promo.CreatedAt, &promo.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil // Promotion not found
-	}
+	err := row.Scan(&promo.ID, &promo.Name, &promo.Description, &promo.StartDate, &promo.EndDate, &promo.DiscountRate, &promo.MinPurchase,
+		&promo.Code, &promo.PromotionType, &promo.Priority, &promo.Stackable, &promo.IsActive, &promo.DiscountAmount, &promo.MaxDiscount,
+		&promo.BuyQuantity, &promo.GetQuantity, &promo.BundleQuantity, &promo.BundlePrice, &promo.Tiers, &promo.Conditions,
+		&promo.MaxRedemptions, &promo.RedemptionCount, &promo.Budget, &promo.BudgetUsed, &promo.CreatedAt, &promo.UpdatedAt)
 	return promo, err
 }
 
-// GetAll retrieves all promotions from the database.
-func (r *PromotionRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Promotion, error) {
-	query := `SELECT id, name, description, start_date, end_date, discount_rate, min_purchase, created_at, updated_at FROM promotions`
-	rows, err := r.db.QueryContext(ctx, query)
+func (r *PromotionRepositoryImpl) queryPromotions(ctx context.Context, query string, args ...interface{}) ([]*entities.Promotion, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -49,20 +48,72 @@ func (r *PromotionRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Promo
 
 	var promos []*entities.Promotion
 	for rows.Next() {
-		promo := &entities.Promotion{}
-		if err := rows.Scan(&promo.ID, &promo.Name, &promo.Description, &promo.StartDate, &promo.EndDate, &promo.DiscountRate, &promo.MinPurchase, &promo.CreatedAt, &promo.UpdatedAt); err != nil {
+		promo, err := scanPromotion(rows)
+		if err != nil {
 			return nil, err
 		}
 		promos = append(promos, promo)
 	}
 
-	return promos, nil
+	return promos, rows.Err()
+}
+
+// nullableCode stores an empty voucher code as NULL so the unique index ignores it.
+func nullableCode(code string) interface{} {
+	if code == "" {
+		return nil
+	}
+	return code
+}
+
+// Create creates a new promotion in the database.
+func (r *PromotionRepositoryImpl) Create(ctx context.Context, promo *entities.Promotion) error {
+	query := `INSERT INTO promotions (id, name, description, start_date, end_date, discount_rate, min_purchase,
+		code, promotion_type, priority, stackable, is_active, discount_amount, max_discount,
+		buy_quantity, get_quantity, bundle_quantity, bundle_price, tiers, conditions,
+		max_redemptions, budget, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)`
+	_, err := r.db.ExecContext(ctx, query, promo.ID, promo.Name, promo.Description, promo.StartDate, promo.EndDate, promo.DiscountRate, promo.MinPurchase,
+		nullableCode(promo.Code), promo.PromotionType, promo.Priority, promo.Stackable, promo.IsActive, promo.DiscountAmount, promo.MaxDiscount,
+		promo.BuyQuantity, promo.GetQuantity, promo.BundleQuantity, promo.BundlePrice, promo.Tiers, promo.Conditions,
+		promo.MaxRedemptions, promo.Budget, promo.CreatedAt, promo.UpdatedAt)
+	return err
 }
 
-// Update updates an existing promotion in the database.
+// GetByID retrieves a promotion by its ID from the database.
+func (r *PromotionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE id = $1`
+	promo, err := scanPromotion(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil // Promotion not found
+	}
+	return promo, err
+}
+
+// GetAll retrieves all promotions from the database.
+func (r *PromotionRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions ORDER BY priority DESC, start_date DESC`
+	return r.queryPromotions(ctx, query)
+}
+
+// GetActive retrieves the active promotions whose date range includes at.
+func (r *PromotionRepositoryImpl) GetActive(ctx context.Context, at time.Time) ([]*entities.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions
+		WHERE is_active = TRUE AND start_date <= $1 AND end_date >= $1
+		ORDER BY priority DESC`
+	return r.queryPromotions(ctx, query, at)
+}
+
+// Update updates an existing promotion in the database. Redemption counters are left untouched.
 func (r *PromotionRepositoryImpl) Update(ctx context.Context, promo *entities.Promotion) error {
-	query := `UPDATE promotions SET name = $1, description = $2, start_date = $3, end_date = $4, discount_rate = $5, min_purchase = $6, updated_at = $7 WHERE id = $8`
-	_, err := r.db.ExecContext(ctx, query, promo.Name, promo.Description, promo.StartDate, promo.EndDate, promo.DiscountRate, promo.MinPurchase, promo.UpdatedAt, promo.ID)
+	query := `UPDATE promotions SET name = $1, description = $2, start_date = $3, end_date = $4, discount_rate = $5, min_purchase = $6,
+		code = $7, promotion_type = $8, priority = $9, stackable = $10, is_active = $11, discount_amount = $12, max_discount = $13,
+		buy_quantity = $14, get_quantity = $15, bundle_quantity = $16, bundle_price = $17, tiers = $18, conditions = $19,
+		max_redemptions = $20, budget = $21, updated_at = $22 WHERE id = $23`
+	_, err := r.db.ExecContext(ctx, query, promo.Name, promo.Description, promo.StartDate, promo.EndDate, promo.DiscountRate, promo.MinPurchase,
+		nullableCode(promo.Code), promo.PromotionType, promo.Priority, promo.Stackable, promo.IsActive, promo.DiscountAmount, promo.MaxDiscount,
+		promo.BuyQuantity, promo.GetQuantity, promo.BundleQuantity, promo.BundlePrice, promo.Tiers, promo.Conditions,
+		promo.MaxRedemptions, promo.Budget, promo.UpdatedAt, promo.ID)
 	return err
 }
 
@@ -72,3 +123,80 @@ func (r *PromotionRepositoryImpl) Delete(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// RecordRedemption stores a redemption and consumes the promotion's redemption count and budget.
+func (r *PromotionRepositoryImpl) RecordRedemption(ctx context.Context, redemption *entities.PromotionRedemption) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The conditional update keeps concurrent checkouts within the limit and budget
+	result, err := tx.ExecContext(ctx, `UPDATE promotions
+		SET redemption_count = redemption_count + 1, budget_used = budget_used + $2, updated_at = NOW()
+		WHERE id = $1
+		  AND (max_redemptions = 0 OR redemption_count < max_redemptions)
+		  AND (budget = 0 OR budget_used + $2 <= budget)`,
+		redemption.PromotionID, redemption.DiscountAmount)
+	if err != nil {
+		return fmt.Errorf("failed to update promotion usage: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return entities.ErrPromotionExhausted
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO promotion_redemptions
+		(id, promotion_id, source_type, source_id, customer_id, voucher_code, discount_amount, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8)`,
+		redemption.ID, redemption.PromotionID, redemption.SourceType, redemption.SourceID,
+		redemption.CustomerID, redemption.VoucherCode, redemption.DiscountAmount, redemption.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record promotion redemption: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ReleaseRedemptions removes the redemptions of a source document and restores the promotions' usage.
+func (r *PromotionRepositoryImpl) ReleaseRedemptions(ctx context.Context, sourceType, sourceID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE promotions p
+		SET redemption_count = GREATEST(p.redemption_count - r.uses, 0),
+		    budget_used = GREATEST(p.budget_used - r.amount, 0),
+		    updated_at = NOW()
+		FROM (
+			SELECT promotion_id, COUNT(*) AS uses, SUM(discount_amount) AS amount
+			FROM promotion_redemptions
+			WHERE source_type = $1 AND source_id = $2
+			GROUP BY promotion_id
+		) r
+		WHERE p.id = r.promotion_id`, sourceType, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to restore promotion usage: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM promotion_redemptions WHERE source_type = $1 AND source_id = $2`, sourceType, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete promotion redemptions: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetRedemptions retrieves the redemptions of a promotion, newest first.
+func (r *PromotionRepositoryImpl) GetRedemptions(ctx context.Context, promotionID string) ([]*entities.PromotionRedemption, error) {
+	redemptions := []*entities.PromotionRedemption{}
+	query := `SELECT id, promotion_id, source_type, source_id, COALESCE(customer_id, '') AS customer_id,
+		COALESCE(voucher_code, '') AS voucher_code, discount_amount, created_at
+		FROM promotion_redemptions WHERE promotion_id = $1 ORDER BY created_at DESC`
+	if err := r.db.SelectContext(ctx, &redemptions, query, promotionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return redemptions, nil
+		}
+		return nil, err
+	}
+	return redemptions, nil
+}
@@ -20,18 +20,18 @@ func NewSalesInvoiceRepositoryImpl(db *sqlx.DB) *SalesInvoiceRepositoryImpl {
 
 // Create creates a new sales invoice in the database.
 func (r *SalesInvoiceRepositoryImpl) Create(ctx context.Context, invoice *entities.SalesInvoice) error {
-	query := `INSERT INTO sales_invoices (id, sales_order_id, invoice_date, total_amount, tax_amount, grand_total, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err := r.db.ExecContext(ctx, query, invoice.ID, invoice.SalesOrderID, invoice.InvoiceDate, invoice.TotalAmount, invoice.TaxAmount, invoice.GrandTotal, invoice.CreatedAt, invoice.UpdatedAt)
+	query := `INSERT INTO sales_invoices (id, sales_order_id, invoice_date, total_amount, tax_amount, grand_total, invoice_number, customer_id, delivery_id, due_date, paid_amount, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, '')::uuid, $9, $10, $11, COALESCE(NULLIF($12, ''), 'unpaid'), $13, $14)`
+	_, err := r.db.ExecContext(ctx, query, invoice.ID, invoice.SalesOrderID, invoice.InvoiceDate, invoice.TotalAmount, invoice.TaxAmount, invoice.GrandTotal, invoice.InvoiceNumber, invoice.CustomerID, invoice.DeliveryID, invoice.DueDate, invoice.PaidAmount, invoice.Status, invoice.CreatedAt, invoice.UpdatedAt)
 	return err
 }
 
 // GetByID retrieves a sales invoice by its ID from the database.
 func (r *SalesInvoiceRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.SalesInvoice, error) {
-	query := `SELECT id, sales_order_id, invoice_date, total_amount, tax_amount, grand_total, created_at, updated_at FROM sales_invoices WHERE id = $1`
+	query := `SELECT id, COALESCE(sales_order_id::text, ''), invoice_date, total_amount, tax_amount, grand_total, COALESCE(invoice_number, ''), COALESCE(customer_id::text, ''), delivery_id, due_date, paid_amount, status, created_at, updated_at FROM sales_invoices WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	invoice := &entities.SalesInvoice{}
-	err := row.Scan(&invoice.ID, &invoice.SalesOrderID, &invoice.InvoiceDate, &invoice.TotalAmount, &invoice.TaxAmount, &invoice.GrandTotal, &invoice.CreatedAt, &invoice.UpdatedAt)
+	err := row.Scan(&invoice.ID, &invoice.SalesOrderID, &invoice.InvoiceDate, &invoice.TotalAmount, &invoice.TaxAmount, &invoice.GrandTotal, &invoice.InvoiceNumber, &invoice.CustomerID, &invoice.DeliveryID, &invoice.DueDate, &invoice.PaidAmount, &invoice.Status, &invoice.CreatedAt, &invoice.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // Sales invoice not found
 	}
@@ -47,7 +47,7 @@ func (r *SalesInvoiceRepositoryImpl) Update(ctx context.Context, invoice *entiti
 
 // Delete deletes a sales invoice by its ID from the database.
 func (r *SalesInvoiceRepositoryImpl) GetAll(ctx context.Context) ([]*entities.SalesInvoice, error) {
-	query := `SELECT id, sales_order_id, invoice_date, total_amount, tax_amount, grand_total, created_at, updated_at FROM sales_invoices ORDER BY created_at DESC`
+	query := `SELECT id, COALESCE(sales_order_id::text, ''), invoice_date, total_amount, tax_amount, grand_total, COALESCE(invoice_number, ''), COALESCE(customer_id::text, ''), delivery_id, due_date, paid_amount, status, created_at, updated_at FROM sales_invoices ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -57,7 +57,7 @@ func (r *SalesInvoiceRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Sa
 	var salesInvoices []*entities.SalesInvoice
 	for rows.Next() {
 		invoice := &entities.SalesInvoice{}
-		err := rows.Scan(&invoice.ID, &invoice.SalesOrderID, &invoice.InvoiceDate, &invoice.TotalAmount, &invoice.TaxAmount, &invoice.GrandTotal, &invoice.CreatedAt, &invoice.UpdatedAt)
+		err := rows.Scan(&invoice.ID, &invoice.SalesOrderID, &invoice.InvoiceDate, &invoice.TotalAmount, &invoice.TaxAmount, &invoice.GrandTotal, &invoice.InvoiceNumber, &invoice.CustomerID, &invoice.DeliveryID, &invoice.DueDate, &invoice.PaidAmount, &invoice.Status, &invoice.CreatedAt, &invoice.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
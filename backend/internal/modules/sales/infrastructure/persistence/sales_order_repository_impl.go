@@ -20,18 +20,18 @@ func NewSalesOrderRepositoryImpl(db *sqlx.DB) *SalesOrderRepositoryImpl {
 
 // Create creates a new sales order in the database.
 func (r *SalesOrderRepositoryImpl) Create(ctx context.Context, so *entities.SalesOrder) error {
-	query := `INSERT INTO sales_orders (id, customer_id, order_date, status, total_amount, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query, so.ID, so.CustomerID, so.OrderDate, so.Status, so.TotalAmount, so.CreatedAt, so.UpdatedAt)
+	query := `INSERT INTO sales_orders (id, customer_id, order_date, status, subtotal, discount_amount, tax_amount, total_amount, quotation_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := r.db.ExecContext(ctx, query, so.ID, so.CustomerID, so.OrderDate, so.Status, so.Subtotal, so.DiscountAmount, so.TaxAmount, so.TotalAmount, so.QuotationID, so.CreatedAt, so.UpdatedAt)
 	return err
 }
 
 // GetByID retrieves a sales order by its ID from the database.
 func (r *SalesOrderRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.SalesOrder, error) {
-	query := `SELECT id, customer_id, order_date, status, total_amount, created_at, updated_at FROM sales_orders WHERE id = $1`
+	query := `SELECT id, customer_id, order_date, status, subtotal, discount_amount, tax_amount, total_amount, quotation_id, created_at, updated_at FROM sales_orders WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	so := &entities.SalesOrder{}
-	err := row.Scan(&so.ID, &so.CustomerID, &so.OrderDate, &so.Status, &so.TotalAmount, &so.CreatedAt, &so.UpdatedAt)
+	err := row.Scan(&so.ID, &so.CustomerID, &so.OrderDate, &so.Status, &so.Subtotal, &so.DiscountAmount, &so.TaxAmount, &so.TotalAmount, &so.QuotationID, &so.CreatedAt, &so.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // Sales order not found
 	}
@@ -40,8 +40,8 @@ func (r *SalesOrderRepositoryImpl) GetByID(ctx context.Context, id string) (*ent
 
 // Update updates an existing sales order in the database.
 func (r *SalesOrderRepositoryImpl) Update(ctx context.Context, so *entities.SalesOrder) error {
-	query := `UPDATE sales_orders SET customer_id = $1, order_date = $2, status = $3, total_amount = $4, updated_at = $5 WHERE id = $6`
-	_, err := r.db.ExecContext(ctx, query, so.CustomerID, so.OrderDate, so.Status, so.TotalAmount, so.UpdatedAt, so.ID)
+	query := `UPDATE sales_orders SET customer_id = $1, order_date = $2, status = $3, subtotal = $4, discount_amount = $5, total_amount = $6, updated_at = $7 WHERE id = $8`
+	_, err := r.db.ExecContext(ctx, query, so.CustomerID, so.OrderDate, so.Status, so.Subtotal, so.DiscountAmount, so.TotalAmount, so.UpdatedAt, so.ID)
 	return err
 }
 
@@ -54,7 +54,7 @@ func (r *SalesOrderRepositoryImpl) Delete(ctx context.Context, id string) error
 
 // GetAll retrieves all sales orders from the database.
 func (r *SalesOrderRepositoryImpl) GetAll(ctx context.Context) ([]*entities.SalesOrder, error) {
-	query := `SELECT id, customer_id, order_date, status, total_amount, created_at, updated_at FROM sales_orders`
+	query := `SELECT id, customer_id, order_date, status, subtotal, discount_amount, tax_amount, total_amount, quotation_id, created_at, updated_at FROM sales_orders`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -64,7 +64,7 @@ func (r *SalesOrderRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Sale
 	var salesOrders []*entities.SalesOrder
 	for rows.Next() {
 		so := &entities.SalesOrder{}
-		if err := rows.Scan(&so.ID, &so.CustomerID, &so.OrderDate, &so.Status, &so.TotalAmount, &so.CreatedAt, &so.UpdatedAt); err != nil {
+		if err := rows.Scan(&so.ID, &so.CustomerID, &so.OrderDate, &so.Status, &so.Subtotal, &so.DiscountAmount, &so.TaxAmount, &so.TotalAmount, &so.QuotationID, &so.CreatedAt, &so.UpdatedAt); err != nil {
 			return nil, err
 		}
 		salesOrders = append(salesOrders, so)
@@ -0,0 +1,225 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+const salesQuotationColumns = `id, quotation_number, version, previous_version_id, customer_id, quotation_date, valid_until, status,
+	currency, COALESCE(payment_terms, '') AS payment_terms, COALESCE(notes, '') AS notes, subtotal, discount_amount, tax_rate,
+	tax_amount, total_amount, sent_at, COALESCE(sent_to, '') AS sent_to, accepted_at, sales_order_id,
+	COALESCE(created_by::text, '') AS created_by, created_at, updated_at`
+
+const salesQuotationItemColumns = `id, quotation_id, line_number, article_id, COALESCE(description, '') AS description, quantity,
+	unit_price, discount_percent, discount_amount, total_price, created_at, updated_at`
+
+// SalesQuotationRepositoryImpl implements repositories.SalesQuotationRepository.
+type SalesQuotationRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewSalesQuotationRepositoryImpl creates a new SalesQuotationRepositoryImpl.
+func NewSalesQuotationRepositoryImpl(db *sqlx.DB) *SalesQuotationRepositoryImpl {
+	return &SalesQuotationRepositoryImpl{db: db}
+}
+
+// NextNumber returns a new quotation number such as QT-2026-000042.
+func (r *SalesQuotationRepositoryImpl) NextNumber(ctx context.Context, at time.Time) (string, error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, `SELECT nextval('sales_quotation_number_seq')`); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("QT-%d-%06d", at.Year(), seq), nil
+}
+
+// Create stores a quotation version with its items.
+func (r *SalesQuotationRepositoryImpl) Create(ctx context.Context, q *entities.SalesQuotation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.insert(ctx, tx, q); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SalesQuotationRepositoryImpl) insert(ctx context.Context, tx *sqlx.Tx, q *entities.SalesQuotation) error {
+	query := `INSERT INTO sales_quotations (id, quotation_number, version, previous_version_id, customer_id, quotation_date, valid_until,
+			status, currency, payment_terms, notes, subtotal, discount_amount, tax_rate, tax_amount, total_amount, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), $12, $13, $14, $15, $16, NULLIF($17, '')::uuid, $18, $19)`
+	if _, err := tx.ExecContext(ctx, query, q.ID, q.QuotationNumber, q.Version, q.PreviousVersionID, q.CustomerID, q.QuotationDate,
+		q.ValidUntil, q.Status, q.Currency, q.PaymentTerms, q.Notes, q.Subtotal, q.DiscountAmount, q.TaxRate, q.TaxAmount,
+		q.TotalAmount, q.CreatedBy, q.CreatedAt, q.UpdatedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: version %d of %s already exists", entities.ErrQuotationStatus, q.Version, q.QuotationNumber)
+		}
+		return err
+	}
+	return r.insertItems(ctx, tx, q)
+}
+
+func (r *SalesQuotationRepositoryImpl) insertItems(ctx context.Context, tx *sqlx.Tx, q *entities.SalesQuotation) error {
+	query := `INSERT INTO sales_quotation_items (id, quotation_id, line_number, article_id, description, quantity, unit_price,
+			discount_percent, discount_amount, total_price, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12)`
+	for _, item := range q.Items {
+		if _, err := tx.ExecContext(ctx, query, item.ID, q.ID, item.LineNumber, item.ArticleID, item.Description, item.Quantity,
+			item.UnitPrice, item.DiscountPercent, item.DiscountAmount, item.TotalPrice, item.CreatedAt, item.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByID returns a quotation version with its items.
+func (r *SalesQuotationRepositoryImpl) GetByID(ctx context.Context, id uuid.ID) (*entities.SalesQuotation, error) {
+	var q entities.SalesQuotation
+	if err := r.db.GetContext(ctx, &q, `SELECT `+salesQuotationColumns+` FROM sales_quotations WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	q.Items = []*entities.SalesQuotationItem{}
+	query := `SELECT ` + salesQuotationItemColumns + ` FROM sales_quotation_items WHERE quotation_id = $1 ORDER BY line_number`
+	if err := r.db.SelectContext(ctx, &q.Items, query, id); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// List returns quotations newest first.
+func (r *SalesQuotationRepositoryImpl) List(ctx context.Context, filter entities.SalesQuotationFilter, limit, offset int) ([]*entities.SalesQuotation, int, error) {
+	where := `TRUE`
+	args := []interface{}{}
+	if !filter.AllVersions {
+		where += ` AND status <> 'superseded'`
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where += fmt.Sprintf(` AND status = $%d`, len(args))
+	}
+	if filter.CustomerID != "" {
+		args = append(args, filter.CustomerID)
+		where += fmt.Sprintf(` AND customer_id::text = $%d`, len(args))
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		where += fmt.Sprintf(` AND quotation_number ILIKE $%d`, len(args))
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM sales_quotations WHERE `+where, args...); err != nil {
+		return nil, 0, err
+	}
+	quotations := []*entities.SalesQuotation{}
+	query := fmt.Sprintf(`SELECT %s FROM sales_quotations WHERE %s ORDER BY quotation_date DESC, quotation_number DESC, version DESC
+		LIMIT $%d OFFSET $%d`, salesQuotationColumns, where, len(args)+1, len(args)+2)
+	if err := r.db.SelectContext(ctx, &quotations, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, err
+	}
+	return quotations, total, nil
+}
+
+// GetVersions returns every version of a quotation number, oldest first.
+func (r *SalesQuotationRepositoryImpl) GetVersions(ctx context.Context, quotationNumber string) ([]*entities.SalesQuotation, error) {
+	versions := []*entities.SalesQuotation{}
+	query := `SELECT ` + salesQuotationColumns + ` FROM sales_quotations WHERE quotation_number = $1 ORDER BY version`
+	if err := r.db.SelectContext(ctx, &versions, query, quotationNumber); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Update stores the header of a draft and replaces its items.
+func (r *SalesQuotationRepositoryImpl) Update(ctx context.Context, q *entities.SalesQuotation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE sales_quotations SET customer_id = $1, quotation_date = $2, valid_until = $3, currency = $4,
+			payment_terms = NULLIF($5, ''), notes = NULLIF($6, ''), subtotal = $7, discount_amount = $8, tax_rate = $9,
+			tax_amount = $10, total_amount = $11, updated_at = $12
+		WHERE id = $13 AND status = 'draft'`
+	result, err := tx.ExecContext(ctx, query, q.CustomerID, q.QuotationDate, q.ValidUntil, q.Currency, q.PaymentTerms, q.Notes,
+		q.Subtotal, q.DiscountAmount, q.TaxRate, q.TaxAmount, q.TotalAmount, q.UpdatedAt, q.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: only drafts can be edited", entities.ErrQuotationStatus)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sales_quotation_items WHERE quotation_id = $1`, q.ID); err != nil {
+		return err
+	}
+	if err := r.insertItems(ctx, tx, q); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateStatus stores the status fields of a quotation if it is in one of the given statuses.
+func (r *SalesQuotationRepositoryImpl) UpdateStatus(ctx context.Context, q *entities.SalesQuotation, from ...string) (bool, error) {
+	query := `UPDATE sales_quotations SET status = $1, sent_at = $2, sent_to = NULLIF($3, ''), accepted_at = $4, sales_order_id = $5,
+			updated_at = $6
+		WHERE id = $7 AND status = ANY($8)`
+	result, err := r.db.ExecContext(ctx, query, q.Status, q.SentAt, q.SentTo, q.AcceptedAt, q.SalesOrderID, q.UpdatedAt, q.ID,
+		pq.Array(from))
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Revise supersedes a sent or expired quotation with its next version.
+func (r *SalesQuotationRepositoryImpl) Revise(ctx context.Context, previous, next *entities.SalesQuotation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE sales_quotations SET status = 'superseded', updated_at = $1
+		WHERE id = $2 AND status IN ('sent', 'expired')`, next.CreatedAt, previous.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: only sent or expired quotations can be revised", entities.ErrQuotationStatus)
+	}
+	if err := r.insert(ctx, tx, next); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete deletes a quotation version and its items.
+func (r *SalesQuotationRepositoryImpl) Delete(ctx context.Context, id uuid.ID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sales_quotations WHERE id = $1`, id)
+	return err
+}
+
+// ExpireSent marks sent quotations valid until before a date as expired.
+func (r *SalesQuotationRepositoryImpl) ExpireSent(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE sales_quotations SET status = 'expired', updated_at = NOW()
+		WHERE status = 'sent' AND valid_until < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
@@ -0,0 +1,332 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/shared/uuid"
+)
+
+// SalesReconciliationRepositoryImpl implements repositories.SalesReconciliationRepository.
+type SalesReconciliationRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewSalesReconciliationRepositoryImpl creates a new SalesReconciliationRepositoryImpl.
+func NewSalesReconciliationRepositoryImpl(db *sqlx.DB) *SalesReconciliationRepositoryImpl {
+	return &SalesReconciliationRepositoryImpl{db: db}
+}
+
+// dateOnly formats a business day for DATE columns.
+func dateOnly(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+const settlementImportColumns = `id, source, provider, channel_id, file_name, file_hash, line_count, gross_amount, fee_amount,
+	net_amount, imported_by, imported_at`
+
+const settlementLineColumns = `id, import_id, source, channel_id, line_number, transaction_date, settlement_date, reference,
+	external_order_id, gross_amount, fee_amount, net_amount, reconciliation_line_id`
+
+// CreateImport stores a settlement file with its lines.
+func (r *SalesReconciliationRepositoryImpl) CreateImport(ctx context.Context, imp *entities.SettlementImport) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO settlement_imports (`+settlementImportColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		imp.ID, imp.Source, imp.Provider, imp.ChannelID, imp.FileName, imp.FileHash, imp.LineCount, imp.GrossAmount,
+		imp.FeeAmount, imp.NetAmount, imp.ImportedBy, imp.ImportedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: %s", entities.ErrSettlementImportExists, imp.FileName)
+		}
+		return err
+	}
+	for _, l := range imp.Lines {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO settlement_lines (`+settlementLineColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULL)`,
+			l.ID, imp.ID, l.Source, l.ChannelID, l.LineNumber, dateOnly(l.TransactionDate), dateOnly(l.SettlementDate),
+			l.Reference, l.ExternalOrderID, l.GrossAmount, l.FeeAmount, l.NetAmount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetImport returns a settlement import with its lines.
+func (r *SalesReconciliationRepositoryImpl) GetImport(ctx context.Context, id uuid.ID) (*entities.SettlementImport, error) {
+	var imp entities.SettlementImport
+	if err := r.db.GetContext(ctx, &imp, `SELECT `+settlementImportColumns+` FROM settlement_imports WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.db.SelectContext(ctx, &imp.Lines, `SELECT `+settlementLineColumns+`
+		FROM settlement_lines WHERE import_id = $1 ORDER BY line_number`, id); err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// ListImports returns the settlement imports, newest first.
+func (r *SalesReconciliationRepositoryImpl) ListImports(ctx context.Context, source string) ([]*entities.SettlementImport, error) {
+	imports := []*entities.SettlementImport{}
+	err := r.db.SelectContext(ctx, &imports, `SELECT `+settlementImportColumns+` FROM settlement_imports
+		WHERE $1 = '' OR source = $1 ORDER BY imported_at DESC`, source)
+	return imports, err
+}
+
+// ListPosPayments returns the payments of a method on completed POS transactions of the day.
+func (r *SalesReconciliationRepositoryImpl) ListPosPayments(ctx context.Context, method string, day time.Time) ([]*entities.ReconciliationPayment, error) {
+	payments := []*entities.ReconciliationPayment{}
+	err := r.db.SelectContext(ctx, &payments, `SELECT p.id, COALESCE(t.receipt_number, '') AS receipt_number,
+			COALESCE(p.reference, '') AS reference, p.amount, t.transaction_date AS paid_at
+		FROM pos_payments p JOIN pos_transactions t ON t.id = p.pos_transaction_id
+		WHERE LOWER(p.payment_method) = $1 AND t.status = $2 AND t.transaction_date >= $3 AND t.transaction_date < $4
+		ORDER BY t.transaction_date, p.id`, method, entities.PosStatusCompleted, day, day.AddDate(0, 0, 1))
+	return payments, err
+}
+
+// ListSettlementLines returns the settlement lines of a POS source for sales of the day.
+func (r *SalesReconciliationRepositoryImpl) ListSettlementLines(ctx context.Context, source string, day time.Time) ([]*entities.SettlementLine, error) {
+	lines := []*entities.SettlementLine{}
+	err := r.db.SelectContext(ctx, &lines, `SELECT `+settlementLineColumns+` FROM settlement_lines
+		WHERE source = $1 AND transaction_date = $2::date ORDER BY import_id, line_number`, source, dateOnly(day))
+	return lines, err
+}
+
+// ListPayoutLines returns the payout lines of a channel paid out on the day.
+func (r *SalesReconciliationRepositoryImpl) ListPayoutLines(ctx context.Context, channelID uuid.ID, day time.Time) ([]*entities.SettlementLine, error) {
+	lines := []*entities.SettlementLine{}
+	err := r.db.SelectContext(ctx, &lines, `SELECT `+settlementLineColumns+` FROM settlement_lines
+		WHERE source = $1 AND channel_id = $2 AND settlement_date = $3::date ORDER BY import_id, line_number`,
+		entities.SettlementMarketplace, channelID, dateOnly(day))
+	return lines, err
+}
+
+const reconciliationOrderColumns = `id, order_id, status, order_date, total_amount`
+
+// GetChannelOrders returns the channel's orders with the given channel order IDs by ID.
+func (r *SalesReconciliationRepositoryImpl) GetChannelOrders(ctx context.Context, channelID uuid.ID, externalIDs []string) (map[string]*entities.ReconciliationOrder, error) {
+	byID := make(map[string]*entities.ReconciliationOrder, len(externalIDs))
+	if len(externalIDs) == 0 {
+		return byID, nil
+	}
+	orders := []*entities.ReconciliationOrder{}
+	if err := r.db.SelectContext(ctx, &orders, `SELECT `+reconciliationOrderColumns+` FROM online_orders
+		WHERE channel_id = $1 AND order_id = ANY($2)`, channelID, pq.Array(externalIDs)); err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		byID[o.OrderID] = o
+	}
+	return byID, nil
+}
+
+// ListUnpaidChannelOrders returns the shipped orders of a channel ordered on the day
+// that no payout has been reconciled against.
+func (r *SalesReconciliationRepositoryImpl) ListUnpaidChannelOrders(ctx context.Context, channelID uuid.ID, day time.Time) ([]*entities.ReconciliationOrder, error) {
+	orders := []*entities.ReconciliationOrder{}
+	err := r.db.SelectContext(ctx, &orders, `SELECT `+reconciliationOrderColumns+` FROM online_orders o
+		WHERE o.channel_id = $1 AND o.status = $2 AND o.order_date >= $3 AND o.order_date < $4
+		  AND NOT EXISTS (SELECT 1 FROM sales_reconciliation_lines l
+			WHERE l.online_order_id = o.id AND l.match_status IN ($5, $6))
+		ORDER BY o.order_date`, channelID, entities.OnlineOrderShipped, day, day.AddDate(0, 0, 1),
+		entities.ReconMatched, entities.ReconAmountMismatch)
+	return orders, err
+}
+
+const reconciliationColumns = `id, business_date, source, channel_id, expected_amount, settled_amount, fee_amount, net_amount,
+	mdr_rate, difference, matched_count, unmatched_count, status, rekonsiliasi_id, created_by, created_at`
+
+const reconciliationLineColumns = `id, reconciliation_id, match_status, pos_payment_id, online_order_id, reference,
+	expected_amount, settled_amount, fee_amount, net_amount, fee_rate, difference`
+
+const discrepancyColumns = `id, reconciliation_id, line_id, business_date, source, discrepancy_type, reference, amount,
+	status, resolution, resolved_by, resolved_at, created_at`
+
+// SaveReconciliation stores a reconciliation, replacing an earlier one of the same day
+// and source, and keeps its sales_rekonsiliasi summary.
+func (r *SalesReconciliationRepositoryImpl) SaveReconciliation(ctx context.Context, rec *entities.SalesReconciliation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previous struct {
+		ID             uuid.ID  `db:"id"`
+		RekonsiliasiID *uuid.ID `db:"rekonsiliasi_id"`
+	}
+	err = tx.GetContext(ctx, &previous, `SELECT id, rekonsiliasi_id FROM sales_reconciliations
+		WHERE business_date = $1::date AND source = $2 AND channel_id IS NOT DISTINCT FROM $3 FOR UPDATE`,
+		dateOnly(rec.BusinessDate), rec.Source, rec.ChannelID)
+	switch {
+	case err == nil:
+		var resolved bool
+		if err := tx.GetContext(ctx, &resolved, `SELECT EXISTS (SELECT 1 FROM sales_reconciliation_discrepancies
+			WHERE reconciliation_id = $1 AND status = $2)`, previous.ID, entities.DiscrepancyResolved); err != nil {
+			return err
+		}
+		if resolved {
+			return entities.ErrReconciliationLocked
+		}
+		// Lines, discrepancies and the links of settlement lines go with it
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sales_reconciliations WHERE id = $1`, previous.ID); err != nil {
+			return err
+		}
+		if previous.RekonsiliasiID != nil {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM sales_rekonsiliasi WHERE id = $1`, *previous.RekonsiliasiID); err != nil {
+				return err
+			}
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+
+	summaryID := uuid.New()
+	summaryStatus := entities.RekonsiliasiReconciled
+	if rec.Status != entities.ReconciliationReconciled {
+		summaryStatus = entities.RekonsiliasiDisputed
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sales_rekonsiliasi (id, reconciliation_date, sales_amount, payment_amount,
+			discrepancy, status, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)`,
+		summaryID, rec.BusinessDate, rec.ExpectedAmount, rec.SettledAmount, rec.Difference, summaryStatus,
+		fmt.Sprintf("Automatic %s reconciliation: %d matched, %d unmatched, fees %.2f (MDR %.2f%%)",
+			rec.Source, rec.MatchedCount, rec.UnmatchedCount, rec.FeeAmount, rec.MDRRate), rec.CreatedAt); err != nil {
+		return err
+	}
+	rec.RekonsiliasiID = &summaryID
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sales_reconciliations (`+reconciliationColumns+`)
+		VALUES ($1, $2::date, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+		rec.ID, dateOnly(rec.BusinessDate), rec.Source, rec.ChannelID, rec.ExpectedAmount, rec.SettledAmount, rec.FeeAmount,
+		rec.NetAmount, rec.MDRRate, rec.Difference, rec.MatchedCount, rec.UnmatchedCount, rec.Status, rec.RekonsiliasiID,
+		rec.CreatedBy, rec.CreatedAt); err != nil {
+		return err
+	}
+	for _, l := range rec.Lines {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sales_reconciliation_lines (`+reconciliationLineColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			l.ID, rec.ID, l.MatchStatus, l.PosPaymentID, l.OnlineOrderID, l.Reference, l.ExpectedAmount, l.SettledAmount,
+			l.FeeAmount, l.NetAmount, l.FeeRate, l.Difference); err != nil {
+			return err
+		}
+	}
+	for _, d := range rec.Discrepancies {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sales_reconciliation_discrepancies (`+discrepancyColumns+`)
+			VALUES ($1, $2, $3, $4::date, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			d.ID, rec.ID, d.LineID, dateOnly(d.BusinessDate), d.Source, d.Type, d.Reference, d.Amount, d.Status,
+			d.Resolution, d.ResolvedBy, d.ResolvedAt, d.CreatedAt); err != nil {
+			return err
+		}
+	}
+	for settlementLineID, lineID := range rec.SettlementLinks {
+		if _, err := tx.ExecContext(ctx, `UPDATE settlement_lines SET reconciliation_line_id = $2 WHERE id = $1`,
+			settlementLineID, lineID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetReconciliation returns a reconciliation with its lines and discrepancies.
+func (r *SalesReconciliationRepositoryImpl) GetReconciliation(ctx context.Context, id uuid.ID) (*entities.SalesReconciliation, error) {
+	var rec entities.SalesReconciliation
+	if err := r.db.GetContext(ctx, &rec, `SELECT `+reconciliationColumns+` FROM sales_reconciliations WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.db.SelectContext(ctx, &rec.Lines, `SELECT `+reconciliationLineColumns+`
+		FROM sales_reconciliation_lines WHERE reconciliation_id = $1 ORDER BY match_status, reference`, id); err != nil {
+		return nil, err
+	}
+	if err := r.db.SelectContext(ctx, &rec.Discrepancies, `SELECT `+discrepancyColumns+`
+		FROM sales_reconciliation_discrepancies WHERE reconciliation_id = $1 ORDER BY discrepancy_type, reference`, id); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// ListReconciliations returns the reconciliations of the business days in the range.
+func (r *SalesReconciliationRepositoryImpl) ListReconciliations(ctx context.Context, from, to time.Time) ([]*entities.SalesReconciliation, error) {
+	recs := []*entities.SalesReconciliation{}
+	err := r.db.SelectContext(ctx, &recs, `SELECT `+reconciliationColumns+` FROM sales_reconciliations
+		WHERE business_date BETWEEN $1::date AND $2::date ORDER BY business_date DESC, source`, dateOnly(from), dateOnly(to))
+	return recs, err
+}
+
+// GetDiscrepancy returns a reconciliation discrepancy.
+func (r *SalesReconciliationRepositoryImpl) GetDiscrepancy(ctx context.Context, id uuid.ID) (*entities.ReconciliationDiscrepancy, error) {
+	var d entities.ReconciliationDiscrepancy
+	if err := r.db.GetContext(ctx, &d, `SELECT `+discrepancyColumns+` FROM sales_reconciliation_discrepancies WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDiscrepancies returns the discrepancies, of a status when given, newest day first.
+func (r *SalesReconciliationRepositoryImpl) ListDiscrepancies(ctx context.Context, status string) ([]*entities.ReconciliationDiscrepancy, error) {
+	discrepancies := []*entities.ReconciliationDiscrepancy{}
+	err := r.db.SelectContext(ctx, &discrepancies, `SELECT `+discrepancyColumns+` FROM sales_reconciliation_discrepancies
+		WHERE $1 = '' OR status = $1 ORDER BY business_date DESC, source, reference`, status)
+	return discrepancies, err
+}
+
+// ResolveDiscrepancy resolves an open discrepancy and, with the last one resolved,
+// marks its reconciliation and summary reconciled.
+func (r *SalesReconciliationRepositoryImpl) ResolveDiscrepancy(ctx context.Context, d *entities.ReconciliationDiscrepancy) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE sales_reconciliation_discrepancies
+		SET status = $2, resolution = $3, resolved_by = $4, resolved_at = $5
+		WHERE id = $1 AND status = $6`,
+		d.ID, d.Status, d.Resolution, d.ResolvedBy, d.ResolvedAt, entities.DiscrepancyOpen)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return entities.ErrDiscrepancyResolved
+	}
+
+	var open bool
+	if err := tx.GetContext(ctx, &open, `SELECT EXISTS (SELECT 1 FROM sales_reconciliation_discrepancies
+		WHERE reconciliation_id = $1 AND status = $2)`, d.ReconciliationID, entities.DiscrepancyOpen); err != nil {
+		return err
+	}
+	if !open {
+		if _, err := tx.ExecContext(ctx, `UPDATE sales_reconciliations SET status = $2 WHERE id = $1`,
+			d.ReconciliationID, entities.ReconciliationReconciled); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE sales_rekonsiliasi SET status = $2, updated_at = NOW()
+			WHERE id = (SELECT rekonsiliasi_id FROM sales_reconciliations WHERE id = $1)`,
+			d.ReconciliationID, entities.RekonsiliasiReconciled); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
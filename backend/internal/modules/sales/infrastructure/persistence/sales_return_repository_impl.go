@@ -20,18 +20,19 @@ func NewSalesReturnRepositoryImpl(db *sqlx.DB) *SalesReturnRepositoryImpl {
 
 // Create creates a new sales return in the database.
 func (r *SalesReturnRepositoryImpl) Create(ctx context.Context, sr *entities.SalesReturn) error {
-	query := `INSERT INTO sales_returns (id, sales_invoice_id, return_date, reason, total_amount, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query, sr.ID, sr.SalesInvoiceID, sr.ReturnDate, sr.Reason, sr.TotalAmount, sr.CreatedAt, sr.UpdatedAt)
+	query := `INSERT INTO sales_returns (id, sales_invoice_id, pos_transaction_id, return_date, reason, total_amount, created_at, updated_at)
+			  VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8)`
+	_, err := r.db.ExecContext(ctx, query, sr.ID, sr.SalesInvoiceID, sr.PosTransactionID, sr.ReturnDate, sr.Reason, sr.TotalAmount, sr.CreatedAt, sr.UpdatedAt)
 	return err
 }
 
 // GetByID retrieves a sales return by its ID from the database.
 func (r *SalesReturnRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.SalesReturn, error) {
-	query := `SELECT id, sales_invoice_id, return_date, reason, total_amount, created_at, updated_at FROM sales_returns WHERE id = $1`
+	query := `SELECT id, COALESCE(sales_invoice_id::text, ''), COALESCE(pos_transaction_id::text, ''), return_date, reason, total_amount, created_at, updated_at FROM sales_returns WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	sr := &entities.SalesReturn{}
-	err := row.Scan(&sr.ID, &sr.SalesInvoiceID, &sr.ReturnDate, &sr.Reason, &sr.TotalAmount, &sr.CreatedAt, &sr.UpdatedAt)
+	err := row.Scan(&sr.ID, &sr.SalesInvoiceID, &sr.PosTransactionID, &sr.ReturnDate, &sr.Reason, &sr.TotalAmount, &sr.CreatedAt, &sr.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // Sales return not found
 	}
@@ -40,7 +41,7 @@ func (r *SalesReturnRepositoryImpl) GetByID(ctx context.Context, id string) (*en
 
 // GetAll retrieves all sales returns from the database.
 func (r *SalesReturnRepositoryImpl) GetAll(ctx context.Context) ([]*entities.SalesReturn, error) {
-	query := `SELECT id, sales_invoice_id, return_date, reason, total_amount, created_at, updated_at FROM sales_returns`
+	query := `SELECT id, COALESCE(sales_invoice_id::text, ''), COALESCE(pos_transaction_id::text, ''), return_date, reason, total_amount, created_at, updated_at FROM sales_returns`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -50,7 +51,7 @@ func (r *SalesReturnRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Sal
 	var srs []*entities.SalesReturn
 	for rows.Next() {
 		sr := &entities.SalesReturn{}
-		if err := rows.Scan(&sr.ID, &sr.SalesInvoiceID, &sr.ReturnDate, &sr.Reason, &sr.TotalAmount, &sr.CreatedAt, &sr.UpdatedAt); err != nil {
+		if err := rows.Scan(&sr.ID, &sr.SalesInvoiceID, &sr.PosTransactionID, &sr.ReturnDate, &sr.Reason, &sr.TotalAmount, &sr.CreatedAt, &sr.UpdatedAt); err != nil {
 			return nil, err
 		}
 		srs = append(srs, sr)
@@ -61,7 +62,7 @@ func (r *SalesReturnRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Sal
 
 // Update updates an existing sales return in the database.
 func (r *SalesReturnRepositoryImpl) Update(ctx context.Context, sr *entities.SalesReturn) error {
-	query := `UPDATE sales_returns SET sales_invoice_id = $1, return_date = $2, reason = $3, total_amount = $4, updated_at = $5 WHERE id = $6`
+	query := `UPDATE sales_returns SET sales_invoice_id = NULLIF($1, '')::uuid, return_date = $2, reason = $3, total_amount = $4, updated_at = $5 WHERE id = $6`
 	_, err := r.db.ExecContext(ctx, query, sr.SalesInvoiceID, sr.ReturnDate, sr.Reason, sr.TotalAmount, sr.UpdatedAt, sr.ID)
 	return err
 }
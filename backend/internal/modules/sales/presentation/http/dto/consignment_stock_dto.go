@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"time"
+)
+
+// ConsignmentLocationRequest represents the request body for opening a consignment
+// location at a department store. Without a commission rate the depstore's rate applies.
+type ConsignmentLocationRequest struct {
+	DepstoreID     string   `json:"depstore_id" binding:"required"`
+	CustomerID     string   `json:"customer_id" binding:"required"`
+	CommissionRate *float64 `json:"commission_rate" binding:"omitempty,gte=0,lte=100"`
+}
+
+// ConsignmentTransferItemRequest is the quantity of an article to transfer. Transfers
+// out without a price use the article's selling price; returns use the consigned price.
+type ConsignmentTransferItemRequest struct {
+	ArticleID string  `json:"article_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	UnitPrice float64 `json:"unit_price" binding:"gte=0"`
+}
+
+// ConsignmentTransferRequest represents the request body for a transfer to consignment
+// (from a warehouse) or a return to a warehouse.
+type ConsignmentTransferRequest struct {
+	WarehouseID  string                           `json:"warehouse_id" binding:"required"`
+	TransferDate *time.Time                       `json:"transfer_date"`
+	Notes        string                           `json:"notes"`
+	Items        []ConsignmentTransferItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// ConsignmentSettlementRequest represents the request body for settling a location.
+// Without a date everything reported up to today is settled.
+type ConsignmentSettlementRequest struct {
+	Until *time.Time `json:"until"`
+}
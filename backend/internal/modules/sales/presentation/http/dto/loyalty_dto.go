@@ -0,0 +1,73 @@
+package dto
+
+import "malaka/internal/modules/sales/domain/entities"
+
+// UpdateLoyaltyProgramRequest represents the request body for configuring the loyalty program.
+type UpdateLoyaltyProgramRequest struct {
+	EarnSpendUnit       float64            `json:"earn_spend_unit" binding:"required,gt=0"`
+	PointValue          float64            `json:"point_value" binding:"required,gt=0"`
+	PointsExpiryMonths  int                `json:"points_expiry_months" binding:"min=0"`
+	TierReviewMonths    int                `json:"tier_review_months" binding:"min=0"`
+	MinRedeemPoints     int                `json:"min_redeem_points" binding:"min=0"`
+	MaxRedeemRate       float64            `json:"max_redeem_rate" binding:"gt=0,max=1"`
+	CategoryMultipliers map[string]float64 `json:"category_multipliers"`
+}
+
+// LoyaltyTierRequest represents the request body for creating or updating a membership tier.
+type LoyaltyTierRequest struct {
+	Code               string  `json:"code" binding:"required,max=30"`
+	Name               string  `json:"name" binding:"required"`
+	Rank               int     `json:"rank"`
+	MinSpend           float64 `json:"min_spend" binding:"min=0"`
+	EarnMultiplier     float64 `json:"earn_multiplier" binding:"min=0"` // defaults to 1
+	MemberDiscountRate float64 `json:"member_discount_rate" binding:"min=0,max=1"`
+	IsActive           *bool   `json:"is_active"` // defaults to true
+}
+
+// EnrollLoyaltyMemberRequest represents the request body for enrolling a member.
+// A phone number or card barcode is required.
+type EnrollLoyaltyMemberRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Phone       string `json:"phone" binding:"required_without=CardBarcode"`
+	CardBarcode string `json:"card_barcode"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	CustomerID  string `json:"customer_id"`
+}
+
+// UpdateLoyaltyMemberRequest represents the request body for updating a member.
+type UpdateLoyaltyMemberRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Phone       string `json:"phone" binding:"required_without=CardBarcode"`
+	CardBarcode string `json:"card_barcode"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	CustomerID  string `json:"customer_id"`
+	Status      string `json:"status" binding:"omitempty,oneof=active suspended"`
+}
+
+// AdjustLoyaltyPointsRequest represents a manual correction of a member's points.
+type AdjustLoyaltyPointsRequest struct {
+	Points      int    `json:"points" binding:"required"` // negative to deduct
+	Description string `json:"description" binding:"required"`
+}
+
+// SetLoyaltyMemberPriceRequest represents the request body for setting a member price.
+type SetLoyaltyMemberPriceRequest struct {
+	ArticleID string  `json:"article_id" binding:"required"`
+	Price     float64 `json:"price" binding:"min=0"`
+}
+
+// LoyaltyMemberListResponse is a page of loyalty members.
+type LoyaltyMemberListResponse struct {
+	Members []*entities.LoyaltyMember `json:"members"`
+	Total   int                       `json:"total"`
+	Page    int                       `json:"page"`
+	Limit   int                       `json:"limit"`
+}
+
+// LoyaltyLedgerResponse is a page of a member's points ledger.
+type LoyaltyLedgerResponse struct {
+	Entries []*entities.LoyaltyPointsEntry `json:"entries"`
+	Total   int                            `json:"total"`
+	Page    int                            `json:"page"`
+	Limit   int                            `json:"limit"`
+}
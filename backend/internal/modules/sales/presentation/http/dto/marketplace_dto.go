@@ -0,0 +1,31 @@
+package dto
+
+// MarketplaceChannelRequest represents the request body for setting up a marketplace
+// channel. Settings depend on the adapter, e.g. "dir" for the file adapter.
+type MarketplaceChannelRequest struct {
+	Code        string            `json:"code" binding:"required"`
+	Name        string            `json:"name" binding:"required"`
+	Adapter     string            `json:"adapter" binding:"required"`
+	WarehouseID string            `json:"warehouse_id" binding:"required"`
+	CustomerID  string            `json:"customer_id" binding:"required"`
+	Settings    map[string]string `json:"settings"`
+}
+
+// SKUMappingItemRequest maps a channel SKU to the article with the barcode.
+type SKUMappingItemRequest struct {
+	ChannelSKU string `json:"channel_sku" binding:"required"`
+	Barcode    string `json:"barcode" binding:"required"`
+}
+
+// SKUMappingRequest represents the request body for mapping channel SKUs. Mappings of
+// SKUs already mapped are replaced.
+type SKUMappingRequest struct {
+	Items []SKUMappingItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// ShipOnlineOrderRequest represents the request body for handing an online order to a
+// courier. Without a tracking number the channel's airway bill is used.
+type ShipOnlineOrderRequest struct {
+	CourierID      string `json:"courier_id" binding:"required"`
+	TrackingNumber string `json:"tracking_number"`
+}
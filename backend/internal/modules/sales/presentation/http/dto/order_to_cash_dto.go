@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"time"
+)
+
+// ConfirmSalesOrderRequest represents the request body for confirming a sales order.
+type ConfirmSalesOrderRequest struct {
+	WarehouseID string `json:"warehouse_id" binding:"required"`
+}
+
+// SalesDeliveryItemRequest is the quantity of a sales order line to deliver.
+type SalesDeliveryItemRequest struct {
+	SalesOrderItemID string `json:"sales_order_item_id" binding:"required"`
+	Quantity         int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// SalesDeliveryRequest represents the request body for posting a delivery. Without a
+// tracking number the delivery number is used.
+type SalesDeliveryRequest struct {
+	CourierID      string                     `json:"courier_id" binding:"required"`
+	TrackingNumber string                     `json:"tracking_number"`
+	DeliveryDate   *time.Time                 `json:"delivery_date"`
+	Items          []SalesDeliveryItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// InvoiceDeliveryRequest represents the request body for invoicing a delivery.
+type InvoiceDeliveryRequest struct {
+	InvoiceDate *time.Time `json:"invoice_date"`
+	DueDate     *time.Time `json:"due_date"`
+}
+
+// SalesPaymentRequest represents the request body for recording a customer payment.
+type SalesPaymentRequest struct {
+	Amount        float64    `json:"amount" binding:"required,gt=0"`
+	PaymentDate   *time.Time `json:"payment_date"`
+	PaymentMethod string     `json:"payment_method" binding:"omitempty,oneof=cash transfer giro card"`
+	Reference     string     `json:"reference" binding:"max=100"`
+	CashBankID    string     `json:"cash_bank_id"`
+}
@@ -0,0 +1,43 @@
+package dto
+
+import "malaka/internal/modules/sales/domain/entities"
+
+// PosTerminalRequest represents the request body for registering or updating a POS terminal.
+type PosTerminalRequest struct {
+	Code        string `json:"code" binding:"required,max=30"`
+	Name        string `json:"name" binding:"required"`
+	StoreName   string `json:"store_name" binding:"required"`
+	WarehouseID string `json:"warehouse_id" binding:"required"`
+	Timezone    string `json:"timezone"`  // IANA time zone of the store, defaults to Asia/Jakarta
+	IsActive    *bool  `json:"is_active"` // defaults to true
+}
+
+// OpenPosShiftRequest represents the request body for opening a cashier shift.
+// The cashier defaults to the logged-in user.
+type OpenPosShiftRequest struct {
+	TerminalID   string  `json:"terminal_id" binding:"required"`
+	CashierID    string  `json:"cashier_id"`
+	OpeningFloat float64 `json:"opening_float" binding:"gte=0"`
+	Notes        string  `json:"notes"`
+}
+
+// PosCashMovementRequest represents cash put into or taken out of the drawer.
+type PosCashMovementRequest struct {
+	MovementType string  `json:"movement_type" binding:"required,oneof=cash_in cash_out"`
+	Amount       float64 `json:"amount" binding:"required,gt=0"`
+	Reason       string  `json:"reason" binding:"required"`
+}
+
+// ClosePosShiftRequest represents the blind cash count entered at shift close.
+type ClosePosShiftRequest struct {
+	CountedCash *float64 `json:"counted_cash" binding:"required,gte=0"`
+	Notes       string   `json:"notes"`
+}
+
+// PosShiftListResponse is a page of cashier shifts.
+type PosShiftListResponse struct {
+	Shifts []*entities.PosShift `json:"shifts"`
+	Total  int                  `json:"total"`
+	Page   int                  `json:"page"`
+	Limit  int                  `json:"limit"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+)
+
+// PosOfflineItemRequest is a line of a sale made offline, at the price the terminal charged.
+type PosOfflineItemRequest struct {
+	ArticleID      string  `json:"article_id" binding:"required"`
+	Quantity       int     `json:"quantity" binding:"required,gt=0"`
+	ListPrice      float64 `json:"list_price" binding:"gte=0"`
+	UnitPrice      float64 `json:"unit_price" binding:"required,gt=0"`
+	DiscountAmount float64 `json:"discount_amount" binding:"gte=0"`
+	TotalPrice     float64 `json:"total_price" binding:"gte=0"`
+	Voided         bool    `json:"voided"`
+	VoidReason     string  `json:"void_reason" binding:"required_if=Voided true"`
+	VoidApprovedBy string  `json:"void_approved_by"`
+}
+
+// PosOfflineTransactionRequest is a sale made while the terminal was offline. The
+// client transaction ID is generated by the terminal and makes the upload idempotent.
+type PosOfflineTransactionRequest struct {
+	ClientTransactionID string                  `json:"client_transaction_id" binding:"required"`
+	ReceiptNumber       string                  `json:"receipt_number"`
+	ShiftID             string                  `json:"shift_id"`
+	CashierID           string                  `json:"cashier_id" binding:"required"`
+	SoldAt              time.Time               `json:"sold_at" binding:"required"`
+	Subtotal            float64                 `json:"subtotal" binding:"gte=0"`
+	DiscountAmount      float64                 `json:"discount_amount" binding:"gte=0"`
+	TaxAmount           float64                 `json:"tax_amount" binding:"gte=0"`
+	TotalAmount         float64                 `json:"total_amount" binding:"required,gt=0"`
+	PaymentMethod       string                  `json:"payment_method" binding:"required_without=Payments"`
+	Payments            []PosPaymentRequest     `json:"payments" binding:"omitempty,dive"`
+	CustomerID          string                  `json:"customer_id"`
+	MemberID            string                  `json:"member_id"`
+	MemberIdentifier    string                  `json:"member_identifier"`
+	Items               []PosOfflineItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// UploadPosTransactionsRequest represents a batch of offline sales uploaded by a terminal.
+type UploadPosTransactionsRequest struct {
+	TerminalID    string                         `json:"terminal_id" binding:"required"`
+	ClientVersion string                         `json:"client_version"`
+	Transactions  []PosOfflineTransactionRequest `json:"transactions" binding:"required,min=1,dive"`
+}
+
+// PosSyncUploadListResponse represents a page of uploaded offline sales.
+type PosSyncUploadListResponse struct {
+	Uploads []*entities.PosSyncUpload `json:"uploads"`
+	Total   int                       `json:"total"`
+	Page    int                       `json:"page"`
+	Limit   int                       `json:"limit"`
+}
@@ -2,18 +2,47 @@ package dto
 
 // CreatePosItemRequest represents a single item in a POS transaction creation request.
 type CreatePosItemRequest struct {
-	ArticleID string  `json:"article_id" binding:"required"`
-	Quantity  int     `json:"quantity" binding:"required,gt=0"`
-	UnitPrice float64 `json:"unit_price" binding:"required,gt=0"`
+	ArticleID  string  `json:"article_id" binding:"required"`
+	Quantity   int     `json:"quantity" binding:"required,gt=0"`
+	UnitPrice  float64 `json:"unit_price" binding:"required,gt=0"`
 	TotalPrice float64 `json:"total_price" binding:"required,gt=0"`
+	// Voided lines were scanned and taken off before payment; they need a supervisor override.
+	Voided     bool   `json:"voided"`
+	VoidReason string `json:"void_reason" binding:"required_if=Voided true"`
+}
+
+// PosPaymentRequest is one tender of a POS payment.
+type PosPaymentRequest struct {
+	PaymentMethod string  `json:"payment_method" binding:"required,oneof=cash card qris e_wallet transfer gift_voucher"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Reference     string  `json:"reference"`
+}
+
+// SupervisorOverrideRequest is the sign-in of the supervisor approving a void at the till.
+type SupervisorOverrideRequest struct {
+	SupervisorEmail    string `json:"supervisor_email" binding:"required,email"`
+	SupervisorPassword string `json:"supervisor_password" binding:"required"`
 }
 
 // CreatePosTransactionRequest represents the request body for creating a new POS transaction.
+// The sale is booked on the cashier's open shift on the terminal. The loyalty member is
+// given by MemberID or by the phone or card scanned at checkout. Payments split the total
+// over several tenders; without them the total is paid with PaymentMethod.
 type CreatePosTransactionRequest struct {
-	TotalAmount   float64                `json:"total_amount" binding:"required,gt=0"`
-	PaymentMethod string                 `json:"payment_method" binding:"required"`
-	CashierID     string                 `json:"cashier_id" binding:"required"`
-	Items         []CreatePosItemRequest `json:"items" binding:"required,min=1"`
+	TotalAmount          float64                    `json:"total_amount" binding:"required,gt=0"`
+	PaymentMethod        string                     `json:"payment_method" binding:"required_without=Payments"`
+	Payments             []PosPaymentRequest        `json:"payments" binding:"omitempty,dive"`
+	Override             *SupervisorOverrideRequest `json:"override"`
+	CashierID            string                     `json:"cashier_id" binding:"required"`
+	TerminalID           string                     `json:"terminal_id" binding:"required"`
+	CustomerID           string                     `json:"customer_id"`
+	Location             string                     `json:"location"`
+	VoucherCodes         []string                   `json:"voucher_codes"`
+	MemberID             string                     `json:"member_id"`
+	MemberIdentifier     string                     `json:"member_identifier"`
+	PointsRedeemed       int                        `json:"points_redeemed" binding:"gte=0"`
+	PointsRedemptionMode string                     `json:"points_redemption_mode" binding:"omitempty,oneof=discount payment"`
+	Items                []CreatePosItemRequest     `json:"items" binding:"required,min=1"`
 }
 
 // UpdatePosTransactionRequest represents the request body for updating an existing POS transaction.
@@ -22,3 +51,36 @@ type UpdatePosTransactionRequest struct {
 	PaymentMethod string  `json:"payment_method" binding:"required"`
 	CashierID     string  `json:"cashier_id" binding:"required"`
 }
+
+// VoidPosTransactionRequest represents the request body for voiding a POS transaction.
+// The override is needed when the user voiding may not approve voids.
+type VoidPosTransactionRequest struct {
+	Reason   string                     `json:"reason" binding:"required"`
+	Override *SupervisorOverrideRequest `json:"override"`
+}
+
+// PosReturnItemRequest is a line of the original receipt brought back.
+type PosReturnItemRequest struct {
+	ItemID   string `json:"item_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// PosExchangeItemRequest is an item taken in exchange for returned items.
+type PosExchangeItemRequest struct {
+	ArticleID string  `json:"article_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	UnitPrice float64 `json:"unit_price" binding:"required,gt=0"`
+}
+
+// RefundPosTransactionRequest represents the request body for a refund or exchange
+// against an original receipt. Payments are what the customer pays for new items over
+// the returned items, or how the refund is paid back; refunds are paid in cash by default.
+type RefundPosTransactionRequest struct {
+	ReceiptNumber string                   `json:"receipt_number" binding:"required"`
+	TerminalID    string                   `json:"terminal_id" binding:"required"`
+	CashierID     string                   `json:"cashier_id" binding:"required"`
+	Reason        string                   `json:"reason" binding:"required"`
+	ReturnItems   []PosReturnItemRequest   `json:"return_items" binding:"required,min=1,dive"`
+	NewItems      []PosExchangeItemRequest `json:"new_items" binding:"omitempty,dive"`
+	Payments      []PosPaymentRequest      `json:"payments" binding:"omitempty,dive"`
+}
@@ -1,21 +1,70 @@
 package dto
 
+import "malaka/internal/modules/sales/domain/entities"
+
 // CreatePromotionRequest represents the request body for creating a new promotion.
 type CreatePromotionRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	StartDate   string  `json:"start_date" binding:"required"`
-	EndDate     string  `json:"end_date" binding:"required"`
-	DiscountRate float64 `json:"discount_rate" binding:"required,min=0,max=1"`
-	MinPurchase float64 `json:"min_purchase" binding:"required,min=0"`
+	Name           string                       `json:"name" binding:"required"`
+	Description    string                       `json:"description"`
+	StartDate      string                       `json:"start_date" binding:"required"`
+	EndDate        string                       `json:"end_date" binding:"required"`
+	DiscountRate   float64                      `json:"discount_rate" binding:"min=0,max=1"`
+	MinPurchase    float64                      `json:"min_purchase" binding:"min=0"`
+	Code           string                       `json:"code" binding:"max=50"`
+	PromotionType  string                       `json:"promotion_type" binding:"omitempty,oneof=percentage fixed_amount buy_x_get_y bundle tiered_spend"`
+	Priority       int                          `json:"priority"`
+	Stackable      *bool                        `json:"stackable"` // defaults to true
+	IsActive       *bool                        `json:"is_active"` // defaults to true
+	DiscountAmount float64                      `json:"discount_amount" binding:"min=0"`
+	MaxDiscount    float64                      `json:"max_discount" binding:"min=0"`
+	BuyQuantity    int                          `json:"buy_quantity" binding:"min=0"`
+	GetQuantity    int                          `json:"get_quantity" binding:"min=0"`
+	BundleQuantity int                          `json:"bundle_quantity" binding:"min=0"`
+	BundlePrice    float64                      `json:"bundle_price" binding:"min=0"`
+	Tiers          []entities.PromotionTier     `json:"tiers"`
+	Conditions     entities.PromotionConditions `json:"conditions"`
+	MaxRedemptions int                          `json:"max_redemptions" binding:"min=0"`
+	Budget         float64                      `json:"budget" binding:"min=0"`
 }
 
 // UpdatePromotionRequest represents the request body for updating an existing promotion.
 type UpdatePromotionRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	StartDate   string  `json:"start_date" binding:"required"`
-	EndDate     string  `json:"end_date" binding:"required"`
-	DiscountRate float64 `json:"discount_rate" binding:"required,min=0,max=1"`
-	MinPurchase float64 `json:"min_purchase" binding:"required,min=0"`
+	Name           string                       `json:"name" binding:"required"`
+	Description    string                       `json:"description"`
+	StartDate      string                       `json:"start_date" binding:"required"`
+	EndDate        string                       `json:"end_date" binding:"required"`
+	DiscountRate   float64                      `json:"discount_rate" binding:"min=0,max=1"`
+	MinPurchase    float64                      `json:"min_purchase" binding:"min=0"`
+	Code           string                       `json:"code" binding:"max=50"`
+	PromotionType  string                       `json:"promotion_type" binding:"omitempty,oneof=percentage fixed_amount buy_x_get_y bundle tiered_spend"`
+	Priority       int                          `json:"priority"`
+	Stackable      *bool                        `json:"stackable"`
+	IsActive       *bool                        `json:"is_active"`
+	DiscountAmount float64                      `json:"discount_amount" binding:"min=0"`
+	MaxDiscount    float64                      `json:"max_discount" binding:"min=0"`
+	BuyQuantity    int                          `json:"buy_quantity" binding:"min=0"`
+	GetQuantity    int                          `json:"get_quantity" binding:"min=0"`
+	BundleQuantity int                          `json:"bundle_quantity" binding:"min=0"`
+	BundlePrice    float64                      `json:"bundle_price" binding:"min=0"`
+	Tiers          []entities.PromotionTier     `json:"tiers"`
+	Conditions     entities.PromotionConditions `json:"conditions"`
+	MaxRedemptions int                          `json:"max_redemptions" binding:"min=0"`
+	Budget         float64                      `json:"budget" binding:"min=0"`
+}
+
+// EvaluatePromotionsRequest represents a cart to preview promotions for.
+type EvaluatePromotionsRequest struct {
+	CustomerID    string                         `json:"customer_id"`
+	StoreID       string                         `json:"store_id"`
+	PaymentMethod string                         `json:"payment_method"`
+	VoucherCodes  []string                       `json:"voucher_codes"`
+	At            string                         `json:"at"` // RFC3339, defaults to now
+	Items         []EvaluatePromotionItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// EvaluatePromotionItemRequest represents a cart line to preview promotions for.
+type EvaluatePromotionItemRequest struct {
+	ArticleID string  `json:"article_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	UnitPrice float64 `json:"unit_price" binding:"min=0"`
 }
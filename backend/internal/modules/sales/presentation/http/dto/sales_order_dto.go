@@ -12,6 +12,7 @@ type CreateSalesOrderItemRequest struct {
 type CreateSalesOrderRequest struct {
 	CustomerID  string                            `json:"customer_id" binding:"required"`
 	TotalAmount float64                           `json:"total_amount" binding:"required,gt=0"`
+	VoucherCodes []string                     `json:"voucher_codes"`
 	Items       []CreateSalesOrderItemRequest `json:"items" binding:"required,min=1"`
 }
 
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"time"
+
+	"malaka/internal/modules/sales/domain/entities"
+)
+
+// SalesQuotationItemRequest is a quotation line. Without a unit price the line is
+// priced from the article's price in effect on the quotation date.
+type SalesQuotationItemRequest struct {
+	ArticleID       string  `json:"article_id" binding:"required"`
+	Description     string  `json:"description"`
+	Quantity        int     `json:"quantity" binding:"required,gt=0"`
+	UnitPrice       float64 `json:"unit_price" binding:"gte=0"`
+	DiscountPercent float64 `json:"discount_percent" binding:"gte=0,lte=100"`
+	DiscountAmount  float64 `json:"discount_amount" binding:"gte=0"`
+}
+
+// SalesQuotationRequest represents the request body for creating or editing a quotation.
+// Without a tax rate the current PPN rate is used.
+type SalesQuotationRequest struct {
+	CustomerID    string                      `json:"customer_id" binding:"required"`
+	QuotationDate *time.Time                  `json:"quotation_date"`
+	ValidUntil    *time.Time                  `json:"valid_until"`
+	Currency      string                      `json:"currency" binding:"omitempty,len=3"`
+	PaymentTerms  string                      `json:"payment_terms"`
+	Notes         string                      `json:"notes"`
+	TaxRate       *float64                    `json:"tax_rate" binding:"omitempty,gte=0,lte=100"`
+	Items         []SalesQuotationItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// SendSalesQuotationRequest represents the request body for emailing a quotation. The
+// customer's email address is used without one.
+type SendSalesQuotationRequest struct {
+	To string `json:"to" binding:"omitempty,email"`
+}
+
+// SalesQuotationListResponse represents a page of quotations.
+type SalesQuotationListResponse struct {
+	Quotations []*entities.SalesQuotation `json:"quotations"`
+	Total      int                        `json:"total"`
+	Page       int                        `json:"page"`
+	Limit      int                        `json:"limit"`
+}
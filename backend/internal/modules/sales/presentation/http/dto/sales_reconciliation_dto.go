@@ -0,0 +1,20 @@
+package dto
+
+// ReconcileRequest represents the request body for reconciling a business day
+// (YYYY-MM-DD) of a source: card, qris, e_wallet or marketplace with its channel.
+type ReconcileRequest struct {
+	BusinessDate string `json:"business_date" binding:"required"`
+	Source       string `json:"source" binding:"required"`
+	ChannelID    string `json:"channel_id"`
+}
+
+// ReconcileDayRequest represents the request body for reconciling every source of a
+// business day (YYYY-MM-DD).
+type ReconcileDayRequest struct {
+	BusinessDate string `json:"business_date" binding:"required"`
+}
+
+// ResolveDiscrepancyRequest represents the request body for resolving a discrepancy.
+type ResolveDiscrepancyRequest struct {
+	Resolution string `json:"resolution" binding:"required"`
+}
@@ -1,15 +1,18 @@
 package dto
 
 // CreateSalesReturnRequest represents the request body for creating a new sales return.
+// One of SalesInvoiceID and PosTransactionID is required.
 type CreateSalesReturnRequest struct {
-	SalesInvoiceID string  `json:"sales_invoice_id" binding:"required"`
-	Reason         string  `json:"reason" binding:"required"`
-	TotalAmount    float64 `json:"total_amount" binding:"required,gt=0"`
+	SalesInvoiceID   string  `json:"sales_invoice_id" binding:"required_without=PosTransactionID"`
+	PosTransactionID string  `json:"pos_transaction_id"`
+	Reason           string  `json:"reason" binding:"required"`
+	TotalAmount      float64 `json:"total_amount" binding:"required,gt=0"`
 }
 
 // UpdateSalesReturnRequest represents the request body for updating an existing sales return.
+// SalesInvoiceID is left empty for returns of POS sales.
 type UpdateSalesReturnRequest struct {
-	SalesInvoiceID string  `json:"sales_invoice_id" binding:"required"`
+	SalesInvoiceID string  `json:"sales_invoice_id"`
 	Reason         string  `json:"reason" binding:"required"`
 	TotalAmount    float64 `json:"total_amount" binding:"required,gt=0"`
 }
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// maxSellThroughImportSize limits uploaded sell-through files (5 MB is well over a month of a busy store)
+const maxSellThroughImportSize = 5 << 20
+
+// ConsignmentStockHandler handles HTTP requests for consignment stock at department stores.
+type ConsignmentStockHandler struct {
+	service *services.ConsignmentService
+}
+
+// NewConsignmentStockHandler creates a new ConsignmentStockHandler.
+func NewConsignmentStockHandler(service *services.ConsignmentService) *ConsignmentStockHandler {
+	return &ConsignmentStockHandler{service: service}
+}
+
+// CreateLocation handles opening a consignment location at a department store.
+func (h *ConsignmentStockHandler) CreateLocation(c *gin.Context) {
+	var req dto.ConsignmentLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	depstoreID, err := uuid.Parse(req.DepstoreID)
+	if err != nil {
+		response.BadRequest(c, "Invalid department store ID format", nil)
+		return
+	}
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		response.BadRequest(c, "Invalid customer ID format", nil)
+		return
+	}
+
+	loc, err := h.service.CreateLocation(c.Request.Context(), &entities.ConsignmentLocation{
+		DepstoreID:     depstoreID,
+		CustomerID:     customerID,
+		CommissionRate: req.CommissionRate,
+	})
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.Created(c, "Consignment location created successfully", loc)
+}
+
+// ListLocations handles listing the consignment locations.
+func (h *ConsignmentStockHandler) ListLocations(c *gin.Context) {
+	locations, err := h.service.ListLocations(c.Request.Context())
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Consignment locations retrieved successfully", locations)
+}
+
+// GetLocation handles retrieving a consignment location.
+func (h *ConsignmentStockHandler) GetLocation(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid consignment location ID format")
+	if !ok {
+		return
+	}
+	loc, err := h.service.GetLocation(c.Request.Context(), id)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Consignment location retrieved successfully", loc)
+}
+
+// TransferOut handles consigning stock of a warehouse to a location.
+func (h *ConsignmentStockHandler) TransferOut(c *gin.Context) {
+	t, ok := h.bindTransfer(c)
+	if !ok {
+		return
+	}
+	t.FromWarehouseID, t.ToWarehouseID = t.ToWarehouseID, uuid.Nil
+	t, err := h.service.TransferOut(c.Request.Context(), t)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.Created(c, "Stock transferred to consignment successfully", t)
+}
+
+// ReturnToWarehouse handles returning consigned stock of a location to a warehouse.
+func (h *ConsignmentStockHandler) ReturnToWarehouse(c *gin.Context) {
+	t, ok := h.bindTransfer(c)
+	if !ok {
+		return
+	}
+	t, err := h.service.ReturnToWarehouse(c.Request.Context(), t)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.Created(c, "Consignment stock returned successfully", t)
+}
+
+// bindTransfer reads a transfer request; the warehouse is returned as destination.
+func (h *ConsignmentStockHandler) bindTransfer(c *gin.Context) (*entities.ConsignmentTransfer, bool) {
+	locationID, ok := consignmentID(c, "Invalid consignment location ID format")
+	if !ok {
+		return nil, false
+	}
+	var req dto.ConsignmentTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return nil, false
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		response.BadRequest(c, "Invalid warehouse ID format", nil)
+		return nil, false
+	}
+
+	t := &entities.ConsignmentTransfer{
+		LocationID:    locationID,
+		ToWarehouseID: warehouseID,
+		Notes:         req.Notes,
+		CreatedBy:     c.GetString("user_id"),
+	}
+	if req.TransferDate != nil {
+		t.TransferDate = *req.TransferDate
+	}
+	for _, itemReq := range req.Items {
+		articleID, err := uuid.Parse(itemReq.ArticleID)
+		if err != nil {
+			response.BadRequest(c, "Invalid article ID format", nil)
+			return nil, false
+		}
+		t.Items = append(t.Items, &entities.ConsignmentTransferItem{
+			ArticleID: articleID,
+			Quantity:  itemReq.Quantity,
+			UnitPrice: itemReq.UnitPrice,
+		})
+	}
+	return t, true
+}
+
+// ListTransfers handles listing the transfers of a location.
+func (h *ConsignmentStockHandler) ListTransfers(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid consignment location ID format")
+	if !ok {
+		return
+	}
+	transfers, err := h.service.ListTransfers(c.Request.Context(), id)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Consignment transfers retrieved successfully", transfers)
+}
+
+// GetTransfer handles retrieving a consignment transfer with its items.
+func (h *ConsignmentStockHandler) GetTransfer(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid consignment transfer ID format")
+	if !ok {
+		return
+	}
+	t, err := h.service.GetTransfer(c.Request.Context(), id)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Consignment transfer retrieved successfully", t)
+}
+
+// ImportSellThrough handles uploading a department store's sell-through report (CSV with
+// article or barcode, quantity and optionally price) for a period.
+func (h *ConsignmentStockHandler) ImportSellThrough(c *gin.Context) {
+	locationID, ok := consignmentID(c, "Invalid consignment location ID format")
+	if !ok {
+		return
+	}
+	periodStart, err := time.Parse("2006-01-02", c.PostForm("period_start"))
+	if err != nil {
+		response.BadRequest(c, "Invalid period start, expected YYYY-MM-DD", nil)
+		return
+	}
+	periodEnd, err := time.Parse("2006-01-02", c.PostForm("period_end"))
+	if err != nil {
+		response.BadRequest(c, "Invalid period end, expected YYYY-MM-DD", nil)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "Sell-through file is required", nil)
+		return
+	}
+	if fileHeader.Size > maxSellThroughImportSize {
+		response.BadRequest(c, "Sell-through file is too large", nil)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Failed to read sell-through file", nil)
+		return
+	}
+	defer file.Close()
+
+	st, err := h.service.ImportSellThrough(c.Request.Context(), locationID, periodStart, periodEnd, file,
+		fileHeader.Filename, c.GetString("user_id"))
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.Created(c, "Sell-through report imported successfully", st)
+}
+
+// ListSellThroughs handles listing the sell-through reports of a location.
+func (h *ConsignmentStockHandler) ListSellThroughs(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid consignment location ID format")
+	if !ok {
+		return
+	}
+	reports, err := h.service.ListSellThroughs(c.Request.Context(), id)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Sell-through reports retrieved successfully", reports)
+}
+
+// GetSellThrough handles retrieving a sell-through report with its items.
+func (h *ConsignmentStockHandler) GetSellThrough(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid sell-through report ID format")
+	if !ok {
+		return
+	}
+	st, err := h.service.GetSellThrough(c.Request.Context(), id)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Sell-through report retrieved successfully", st)
+}
+
+// Settle handles invoicing the unsettled sell-through of a location.
+func (h *ConsignmentStockHandler) Settle(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid consignment location ID format")
+	if !ok {
+		return
+	}
+	var req dto.ConsignmentSettlementRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+	}
+	var until time.Time
+	if req.Until != nil {
+		until = *req.Until
+	}
+	settlement, err := h.service.Settle(c.Request.Context(), id, until, c.GetString("user_id"))
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.Created(c, "Consignment settlement invoiced successfully", settlement)
+}
+
+// ListSettlements handles listing the settlements of a location.
+func (h *ConsignmentStockHandler) ListSettlements(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid consignment location ID format")
+	if !ok {
+		return
+	}
+	settlements, err := h.service.ListSettlements(c.Request.Context(), id)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Consignment settlements retrieved successfully", settlements)
+}
+
+// GetSettlement handles retrieving a consignment settlement.
+func (h *ConsignmentStockHandler) GetSettlement(c *gin.Context) {
+	id, ok := consignmentID(c, "Invalid consignment settlement ID format")
+	if !ok {
+		return
+	}
+	settlement, err := h.service.GetSettlement(c.Request.Context(), id)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Consignment settlement retrieved successfully", settlement)
+}
+
+// GetStockAging handles reporting consigned stock by age, of all locations or of the
+// location given as location_id, as of the optional as_of date.
+func (h *ConsignmentStockHandler) GetStockAging(c *gin.Context) {
+	var locationID *uuid.ID
+	if v := c.Query("location_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid consignment location ID format", nil)
+			return
+		}
+		locationID = &id
+	}
+	var asOf time.Time
+	if v := c.Query("as_of"); v != "" {
+		var err error
+		if asOf, err = time.Parse("2006-01-02", v); err != nil {
+			response.BadRequest(c, "Invalid as_of date, expected YYYY-MM-DD", nil)
+			return
+		}
+	}
+	aging, err := h.service.StockAging(c.Request.Context(), locationID, asOf)
+	if err != nil {
+		consignmentError(c, err)
+		return
+	}
+	response.OK(c, "Consignment stock aging retrieved successfully", gin.H{
+		"bucket_days": entities.ConsignmentAgingBuckets,
+		"articles":    aging,
+	})
+}
+
+func consignmentID(c *gin.Context, message string) (uuid.ID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// consignmentError maps consignment errors to HTTP responses.
+func consignmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrConsignmentLocationNotFound), errors.Is(err, entities.ErrConsignmentTransferNotFound),
+		errors.Is(err, entities.ErrSellThroughNotFound), errors.Is(err, entities.ErrSettlementNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidConsignmentLocation), errors.Is(err, entities.ErrConsignmentLocationExists),
+		errors.Is(err, entities.ErrInvalidConsignmentTransfer), errors.Is(err, entities.ErrInsufficientConsignmentStock),
+		errors.Is(err, entities.ErrInsufficientStock), errors.Is(err, entities.ErrInvalidSellThrough),
+		errors.Is(err, entities.ErrNothingToSettle):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
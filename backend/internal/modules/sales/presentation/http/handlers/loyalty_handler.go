@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// LoyaltyHandler handles HTTP requests for the customer loyalty program.
+type LoyaltyHandler struct {
+	service *services.LoyaltyService
+}
+
+// NewLoyaltyHandler creates a new LoyaltyHandler.
+func NewLoyaltyHandler(service *services.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{service: service}
+}
+
+// GetProgram handles retrieving the loyalty program rules.
+func (h *LoyaltyHandler) GetProgram(c *gin.Context) {
+	program, err := h.service.GetProgram(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Loyalty program retrieved successfully", program)
+}
+
+// UpdateProgram handles configuring the loyalty program rules.
+func (h *LoyaltyHandler) UpdateProgram(c *gin.Context) {
+	var req dto.UpdateLoyaltyProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	program := &entities.LoyaltyProgram{
+		EarnSpendUnit:       req.EarnSpendUnit,
+		PointValue:          req.PointValue,
+		PointsExpiryMonths:  req.PointsExpiryMonths,
+		TierReviewMonths:    req.TierReviewMonths,
+		MinRedeemPoints:     req.MinRedeemPoints,
+		MaxRedeemRate:       req.MaxRedeemRate,
+		CategoryMultipliers: entities.LoyaltyMultipliers(req.CategoryMultipliers),
+	}
+	if err := h.service.UpdateProgram(c.Request.Context(), program); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Loyalty program updated successfully", program)
+}
+
+// GetTiers handles retrieving all membership tiers.
+func (h *LoyaltyHandler) GetTiers(c *gin.Context) {
+	tiers, err := h.service.GetTiers(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Loyalty tiers retrieved successfully", tiers)
+}
+
+// CreateTier handles the creation of a new membership tier.
+func (h *LoyaltyHandler) CreateTier(c *gin.Context) {
+	var req dto.LoyaltyTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	tier := newLoyaltyTierFromRequest(req)
+	if err := h.service.CreateTier(c.Request.Context(), tier); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.Created(c, "Loyalty tier created successfully", tier)
+}
+
+// UpdateTier handles updating an existing membership tier.
+func (h *LoyaltyHandler) UpdateTier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.LoyaltyTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	tier := newLoyaltyTierFromRequest(req)
+	tier.ID = id
+	if tier.EarnMultiplier == 0 {
+		tier.EarnMultiplier = 1
+	}
+	if err := h.service.UpdateTier(c.Request.Context(), tier); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Loyalty tier updated successfully", tier)
+}
+
+// DeleteTier handles deleting a membership tier.
+func (h *LoyaltyHandler) DeleteTier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	if err := h.service.DeleteTier(c.Request.Context(), id); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Loyalty tier deleted successfully", nil)
+}
+
+// GetMemberPrices handles retrieving the member prices of a tier.
+func (h *LoyaltyHandler) GetMemberPrices(c *gin.Context) {
+	tierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	prices, err := h.service.GetMemberPrices(c.Request.Context(), tierID)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Member prices retrieved successfully", prices)
+}
+
+// SetMemberPrice handles setting the member price of an article for a tier.
+func (h *LoyaltyHandler) SetMemberPrice(c *gin.Context) {
+	tierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.SetLoyaltyMemberPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	articleID, err := uuid.Parse(req.ArticleID)
+	if err != nil {
+		response.BadRequest(c, "Invalid article ID format", nil)
+		return
+	}
+
+	price := &entities.LoyaltyMemberPrice{TierID: tierID, ArticleID: articleID, Price: req.Price}
+	if err := h.service.SetMemberPrice(c.Request.Context(), price); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Member price saved successfully", price)
+}
+
+// DeleteMemberPrice handles removing a member price.
+func (h *LoyaltyHandler) DeleteMemberPrice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("priceId"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	if err := h.service.DeleteMemberPrice(c.Request.Context(), id); err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Member price deleted successfully", nil)
+}
+
+// EnrollMember handles enrolling a new loyalty member.
+func (h *LoyaltyHandler) EnrollMember(c *gin.Context) {
+	var req dto.EnrollLoyaltyMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	if req.CustomerID != "" {
+		if _, err := uuid.Parse(req.CustomerID); err != nil {
+			response.BadRequest(c, "Invalid customer ID format", nil)
+			return
+		}
+	}
+
+	member := &entities.LoyaltyMember{
+		Name:        req.Name,
+		Phone:       req.Phone,
+		CardBarcode: req.CardBarcode,
+		Email:       req.Email,
+		CustomerID:  req.CustomerID,
+	}
+	if err := h.service.EnrollMember(c.Request.Context(), member); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.Created(c, "Loyalty member enrolled successfully", member)
+}
+
+// ListMembers handles listing loyalty members.
+func (h *LoyaltyHandler) ListMembers(c *gin.Context) {
+	page, limit := loyaltyPage(c)
+	members, total, err := h.service.ListMembers(c.Request.Context(), c.Query("search"), limit, (page-1)*limit)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Loyalty members retrieved successfully", dto.LoyaltyMemberListResponse{
+		Members: members,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	})
+}
+
+// LookupMember handles finding a member by the phone, card barcode or member number
+// entered at checkout.
+func (h *LoyaltyHandler) LookupMember(c *gin.Context) {
+	member, err := h.service.FindMember(c.Request.Context(), c.Query("q"))
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Loyalty member retrieved successfully", member)
+}
+
+// GetMember handles retrieving a loyalty member by its ID.
+func (h *LoyaltyHandler) GetMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	member, err := h.service.GetMember(c.Request.Context(), id)
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Loyalty member retrieved successfully", member)
+}
+
+// UpdateMember handles updating a loyalty member.
+func (h *LoyaltyHandler) UpdateMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.UpdateLoyaltyMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	if req.CustomerID != "" {
+		if _, err := uuid.Parse(req.CustomerID); err != nil {
+			response.BadRequest(c, "Invalid customer ID format", nil)
+			return
+		}
+	}
+
+	member := &entities.LoyaltyMember{
+		Name:        req.Name,
+		Phone:       req.Phone,
+		CardBarcode: req.CardBarcode,
+		Email:       req.Email,
+		CustomerID:  req.CustomerID,
+		Status:      req.Status,
+	}
+	member.ID = id
+	if err := h.service.UpdateMember(c.Request.Context(), member); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	updated, err := h.service.GetMember(c.Request.Context(), id)
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Loyalty member updated successfully", updated)
+}
+
+// GetLedger handles retrieving the points ledger of a member.
+func (h *LoyaltyHandler) GetLedger(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	page, limit := loyaltyPage(c)
+	entries, total, err := h.service.GetLedger(c.Request.Context(), id, limit, (page-1)*limit)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Points ledger retrieved successfully", dto.LoyaltyLedgerResponse{
+		Entries: entries,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	})
+}
+
+// AdjustPoints handles a manual correction of a member's points.
+func (h *LoyaltyHandler) AdjustPoints(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.AdjustLoyaltyPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	entry, err := h.service.AdjustPoints(c.Request.Context(), id, req.Points, req.Description, c.GetString("user_id"))
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+	response.OK(c, "Loyalty points adjusted successfully", entry)
+}
+
+func newLoyaltyTierFromRequest(req dto.LoyaltyTierRequest) *entities.LoyaltyTier {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return &entities.LoyaltyTier{
+		Code:               req.Code,
+		Name:               req.Name,
+		Rank:               req.Rank,
+		MinSpend:           req.MinSpend,
+		EarnMultiplier:     req.EarnMultiplier,
+		MemberDiscountRate: req.MemberDiscountRate,
+		IsActive:           isActive,
+	}
+}
+
+func loyaltyPage(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// loyaltyError maps loyalty errors to HTTP responses.
+func loyaltyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrLoyaltyMemberNotFound), errors.Is(err, entities.ErrLoyaltyTierNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidLoyaltySetup), errors.Is(err, entities.ErrLoyaltyMemberExists),
+		errors.Is(err, entities.ErrLoyaltyMemberInactive), errors.Is(err, entities.ErrInsufficientPoints),
+		errors.Is(err, entities.ErrInvalidPointsRedemption):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// maxWebhookSize limits marketplace webhook notifications (a batch of orders is far below 1 MB)
+const maxWebhookSize = 1 << 20
+
+// MarketplaceHandler handles HTTP requests for marketplace channels and their orders.
+type MarketplaceHandler struct {
+	service *services.MarketplaceService
+}
+
+// NewMarketplaceHandler creates a new MarketplaceHandler.
+func NewMarketplaceHandler(service *services.MarketplaceService) *MarketplaceHandler {
+	return &MarketplaceHandler{service: service}
+}
+
+// CreateChannel handles setting up a marketplace channel.
+func (h *MarketplaceHandler) CreateChannel(c *gin.Context) {
+	var req dto.MarketplaceChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		response.BadRequest(c, "Invalid warehouse ID format", nil)
+		return
+	}
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		response.BadRequest(c, "Invalid customer ID format", nil)
+		return
+	}
+
+	ch, err := h.service.CreateChannel(c.Request.Context(), &entities.MarketplaceChannel{
+		Code:        req.Code,
+		Name:        req.Name,
+		Adapter:     req.Adapter,
+		WarehouseID: warehouseID,
+		CustomerID:  customerID,
+		Settings:    req.Settings,
+	})
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.Created(c, "Marketplace channel created successfully", ch)
+}
+
+// ListChannels handles listing the marketplace channels and the available adapters.
+func (h *MarketplaceHandler) ListChannels(c *gin.Context) {
+	channels, err := h.service.ListChannels(c.Request.Context())
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Marketplace channels retrieved successfully", gin.H{
+		"adapters": h.service.Adapters(),
+		"channels": channels,
+	})
+}
+
+// GetChannel handles retrieving a marketplace channel.
+func (h *MarketplaceHandler) GetChannel(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid marketplace channel ID format")
+	if !ok {
+		return
+	}
+	ch, err := h.service.GetChannel(c.Request.Context(), id)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Marketplace channel retrieved successfully", ch)
+}
+
+// SaveSKUMappings handles mapping channel SKUs to articles by barcode.
+func (h *MarketplaceHandler) SaveSKUMappings(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid marketplace channel ID format")
+	if !ok {
+		return
+	}
+	var req dto.SKUMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	mappings := make([]*entities.ChannelSKUMapping, 0, len(req.Items))
+	for _, item := range req.Items {
+		mappings = append(mappings, &entities.ChannelSKUMapping{ChannelSKU: item.ChannelSKU, Barcode: item.Barcode})
+	}
+
+	saved, err := h.service.SaveSKUMappings(c.Request.Context(), id, mappings)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "SKU mappings saved successfully", saved)
+}
+
+// ListSKUMappings handles listing the SKU mappings of a channel.
+func (h *MarketplaceHandler) ListSKUMappings(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid marketplace channel ID format")
+	if !ok {
+		return
+	}
+	mappings, err := h.service.ListSKUMappings(c.Request.Context(), id)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "SKU mappings retrieved successfully", mappings)
+}
+
+// PullOrders handles importing the new and changed orders of a channel.
+func (h *MarketplaceHandler) PullOrders(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid marketplace channel ID format")
+	if !ok {
+		return
+	}
+	result, err := h.service.PullOrders(c.Request.Context(), id)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Channel orders imported", result)
+}
+
+// PushStock handles sending the available stock of the mapped SKUs to a channel.
+func (h *MarketplaceHandler) PushStock(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid marketplace channel ID format")
+	if !ok {
+		return
+	}
+	count, err := h.service.PushStock(c.Request.Context(), id)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Stock pushed to channel", gin.H{"skus": count})
+}
+
+// PushPrices handles sending the prices of the mapped SKUs to a channel.
+func (h *MarketplaceHandler) PushPrices(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid marketplace channel ID format")
+	if !ok {
+		return
+	}
+	count, err := h.service.PushPrices(c.Request.Context(), id)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Prices pushed to channel", gin.H{"skus": count})
+}
+
+// ReceiveWebhook handles an order notification sent by a channel. The adapter verifies
+// that it comes from the channel, so the route needs no user authentication.
+func (h *MarketplaceHandler) ReceiveWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize+1))
+	if err != nil {
+		response.BadRequest(c, "Failed to read notification", nil)
+		return
+	}
+	if len(body) > maxWebhookSize {
+		response.BadRequest(c, "Notification is too large", nil)
+		return
+	}
+	headers := make(map[string]string, len(c.Request.Header))
+	for name := range c.Request.Header {
+		headers[name] = c.Request.Header.Get(name)
+	}
+
+	result, err := h.service.ReceiveWebhook(c.Request.Context(), c.Param("code"), headers, body)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Channel orders imported", result)
+}
+
+// GetOrder handles retrieving an online order with its items.
+func (h *MarketplaceHandler) GetOrder(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid online order ID format")
+	if !ok {
+		return
+	}
+	order, err := h.service.GetOrder(c.Request.Context(), id)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Online order retrieved successfully", order)
+}
+
+// ShipOrder handles handing a reserved online order to a courier.
+func (h *MarketplaceHandler) ShipOrder(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid online order ID format")
+	if !ok {
+		return
+	}
+	var req dto.ShipOnlineOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	courierID, err := uuid.Parse(req.CourierID)
+	if err != nil {
+		response.BadRequest(c, "Invalid courier ID format", nil)
+		return
+	}
+
+	order, err := h.service.ShipOrder(c.Request.Context(), id, courierID, req.TrackingNumber)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Online order shipped successfully", order)
+}
+
+// CancelOrder handles cancelling a reserved online order, releasing its stock.
+func (h *MarketplaceHandler) CancelOrder(c *gin.Context) {
+	id, ok := marketplaceID(c, "Invalid online order ID format")
+	if !ok {
+		return
+	}
+	order, err := h.service.CancelOrder(c.Request.Context(), id)
+	if err != nil {
+		marketplaceError(c, err)
+		return
+	}
+	response.OK(c, "Online order cancelled successfully", order)
+}
+
+func marketplaceID(c *gin.Context, message string) (uuid.ID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// marketplaceError maps marketplace errors to HTTP responses.
+func marketplaceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrChannelNotFound), errors.Is(err, entities.ErrOnlineOrderNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidWebhook):
+		response.Error(c, http.StatusUnauthorized, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidChannel), errors.Is(err, entities.ErrChannelExists),
+		errors.Is(err, entities.ErrUnknownAdapter), errors.Is(err, entities.ErrInvalidChannelOrder),
+		errors.Is(err, entities.ErrUnmappedSKU), errors.Is(err, entities.ErrOnlineOrderStatus),
+		errors.Is(err, entities.ErrInsufficientStock):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// OrderToCashHandler handles HTTP requests for the order-to-cash flow.
+type OrderToCashHandler struct {
+	service *services.OrderToCashService
+}
+
+// NewOrderToCashHandler creates a new OrderToCashHandler.
+func NewOrderToCashHandler(service *services.OrderToCashService) *OrderToCashHandler {
+	return &OrderToCashHandler{service: service}
+}
+
+// ConfirmOrder handles confirming a sales order and reserving its stock.
+func (h *OrderToCashHandler) ConfirmOrder(c *gin.Context) {
+	orderID, ok := orderToCashID(c, "Invalid sales order ID format")
+	if !ok {
+		return
+	}
+	var req dto.ConfirmSalesOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		response.BadRequest(c, "Invalid warehouse ID format", nil)
+		return
+	}
+	so, err := h.service.ConfirmOrder(c.Request.Context(), orderID, warehouseID, c.GetString("user_id"))
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.OK(c, "Sales order confirmed successfully", so)
+}
+
+// GetOrderLines handles listing the lines of a sales order with their progress.
+func (h *OrderToCashHandler) GetOrderLines(c *gin.Context) {
+	orderID, ok := orderToCashID(c, "Invalid sales order ID format")
+	if !ok {
+		return
+	}
+	lines, err := h.service.GetOrderLines(c.Request.Context(), orderID)
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.OK(c, "Sales order lines retrieved successfully", lines)
+}
+
+// DeliverOrder handles posting a delivery of a sales order.
+func (h *OrderToCashHandler) DeliverOrder(c *gin.Context) {
+	orderID, ok := orderToCashID(c, "Invalid sales order ID format")
+	if !ok {
+		return
+	}
+	var req dto.SalesDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	courierID, err := uuid.Parse(req.CourierID)
+	if err != nil {
+		response.BadRequest(c, "Invalid courier ID format", nil)
+		return
+	}
+
+	delivery := &entities.SalesDelivery{
+		SalesOrderID:   orderID,
+		CourierID:      courierID,
+		TrackingNumber: req.TrackingNumber,
+		CreatedBy:      c.GetString("user_id"),
+	}
+	if req.DeliveryDate != nil {
+		delivery.DeliveryDate = *req.DeliveryDate
+	}
+	for _, itemReq := range req.Items {
+		itemID, err := uuid.Parse(itemReq.SalesOrderItemID)
+		if err != nil {
+			response.BadRequest(c, "Invalid sales order item ID format", nil)
+			return
+		}
+		delivery.Items = append(delivery.Items, &entities.SalesDeliveryItem{
+			SalesOrderItemID: itemID,
+			Quantity:         itemReq.Quantity,
+		})
+	}
+
+	delivery, err = h.service.DeliverOrder(c.Request.Context(), delivery)
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.Created(c, "Delivery posted successfully", delivery)
+}
+
+// ListDeliveries handles listing the deliveries of a sales order.
+func (h *OrderToCashHandler) ListDeliveries(c *gin.Context) {
+	orderID, ok := orderToCashID(c, "Invalid sales order ID format")
+	if !ok {
+		return
+	}
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), orderID)
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.OK(c, "Deliveries retrieved successfully", deliveries)
+}
+
+// GetDelivery handles retrieving a delivery with its items.
+func (h *OrderToCashHandler) GetDelivery(c *gin.Context) {
+	id, ok := orderToCashID(c, "Invalid delivery ID format")
+	if !ok {
+		return
+	}
+	delivery, err := h.service.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.OK(c, "Delivery retrieved successfully", delivery)
+}
+
+// InvoiceDelivery handles invoicing a delivery.
+func (h *OrderToCashHandler) InvoiceDelivery(c *gin.Context) {
+	id, ok := orderToCashID(c, "Invalid delivery ID format")
+	if !ok {
+		return
+	}
+	var req dto.InvoiceDeliveryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+	}
+	var invoiceDate time.Time
+	if req.InvoiceDate != nil {
+		invoiceDate = *req.InvoiceDate
+	}
+	invoice, err := h.service.InvoiceDelivery(c.Request.Context(), id, invoiceDate, req.DueDate, c.GetString("user_id"))
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.Created(c, "Sales invoice posted successfully", invoice)
+}
+
+// ReceivePayment handles recording a customer payment of a sales invoice.
+func (h *OrderToCashHandler) ReceivePayment(c *gin.Context) {
+	id, ok := orderToCashID(c, "Invalid sales invoice ID format")
+	if !ok {
+		return
+	}
+	var req dto.SalesPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	payment := &entities.SalesPayment{
+		SalesInvoiceID: id,
+		Amount:         req.Amount,
+		PaymentMethod:  req.PaymentMethod,
+		Reference:      req.Reference,
+		CreatedBy:      c.GetString("user_id"),
+	}
+	if payment.PaymentMethod == "" {
+		payment.PaymentMethod = "transfer"
+	}
+	if req.PaymentDate != nil {
+		payment.PaymentDate = *req.PaymentDate
+	}
+	if req.CashBankID != "" {
+		cashBankID, err := uuid.Parse(req.CashBankID)
+		if err != nil {
+			response.BadRequest(c, "Invalid cash bank ID format", nil)
+			return
+		}
+		payment.CashBankID = &cashBankID
+	}
+
+	invoice, err := h.service.ReceivePayment(c.Request.Context(), payment)
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.Created(c, "Payment recorded successfully", gin.H{"payment": payment, "invoice": invoice})
+}
+
+// GetDocumentFlow handles showing the upstream and downstream documents of a document.
+func (h *OrderToCashHandler) GetDocumentFlow(c *gin.Context) {
+	id, ok := orderToCashID(c, "Invalid document ID format")
+	if !ok {
+		return
+	}
+	flow, err := h.service.GetDocumentFlow(c.Request.Context(), c.Param("type"), id)
+	if err != nil {
+		orderToCashError(c, err)
+		return
+	}
+	response.OK(c, "Document flow retrieved successfully", flow)
+}
+
+func orderToCashID(c *gin.Context, message string) (uuid.ID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// orderToCashError maps order-to-cash errors to HTTP responses.
+func orderToCashError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrSalesOrderNotFound), errors.Is(err, entities.ErrDeliveryNotFound),
+		errors.Is(err, entities.ErrSalesInvoiceNotFound), errors.Is(err, entities.ErrDocumentNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidSalesOrder), errors.Is(err, entities.ErrSalesOrderStatus),
+		errors.Is(err, entities.ErrInsufficientStock), errors.Is(err, entities.ErrInvalidDelivery),
+		errors.Is(err, entities.ErrDeliveryInvoiced), errors.Is(err, entities.ErrInvalidInvoice),
+		errors.Is(err, entities.ErrInvalidPayment), errors.Is(err, entities.ErrUnknownDocument):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
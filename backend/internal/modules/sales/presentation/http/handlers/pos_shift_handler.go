@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// PosShiftHandler handles HTTP requests for POS terminals and cashier shifts.
+type PosShiftHandler struct {
+	service *services.PosShiftService
+}
+
+// NewPosShiftHandler creates a new PosShiftHandler.
+func NewPosShiftHandler(service *services.PosShiftService) *PosShiftHandler {
+	return &PosShiftHandler{service: service}
+}
+
+// CreateTerminal handles registering a new POS terminal.
+func (h *PosShiftHandler) CreateTerminal(c *gin.Context) {
+	var req dto.PosTerminalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	terminal, ok := newPosTerminalFromRequest(c, req)
+	if !ok {
+		return
+	}
+	if err := h.service.CreateTerminal(c.Request.Context(), terminal); err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.Created(c, "POS terminal created successfully", terminal)
+}
+
+// GetTerminals handles retrieving all POS terminals.
+func (h *PosShiftHandler) GetTerminals(c *gin.Context) {
+	terminals, err := h.service.GetTerminals(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "POS terminals retrieved successfully", terminals)
+}
+
+// GetTerminal handles retrieving a POS terminal by its ID.
+func (h *PosShiftHandler) GetTerminal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	terminal, err := h.service.GetTerminal(c.Request.Context(), id)
+	if err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.OK(c, "POS terminal retrieved successfully", terminal)
+}
+
+// UpdateTerminal handles updating a POS terminal.
+func (h *PosShiftHandler) UpdateTerminal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.PosTerminalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	terminal, ok := newPosTerminalFromRequest(c, req)
+	if !ok {
+		return
+	}
+	terminal.ID = id
+	if err := h.service.UpdateTerminal(c.Request.Context(), terminal); err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.OK(c, "POS terminal updated successfully", terminal)
+}
+
+// DeleteTerminal handles deleting a POS terminal.
+func (h *PosShiftHandler) DeleteTerminal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	if err := h.service.DeleteTerminal(c.Request.Context(), id); err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.OK(c, "POS terminal deleted successfully", nil)
+}
+
+// OpenShift handles opening a cashier shift on a terminal.
+func (h *PosShiftHandler) OpenShift(c *gin.Context) {
+	var req dto.OpenPosShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	terminalID, err := uuid.Parse(req.TerminalID)
+	if err != nil {
+		response.BadRequest(c, "Invalid terminal ID format", nil)
+		return
+	}
+	if req.CashierID == "" {
+		req.CashierID = c.GetString("user_id")
+	}
+	cashierID, err := uuid.Parse(req.CashierID)
+	if err != nil {
+		response.BadRequest(c, "Invalid cashier ID format", nil)
+		return
+	}
+
+	shift, err := h.service.OpenShift(c.Request.Context(), terminalID, cashierID, req.OpeningFloat, req.Notes)
+	if err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.Created(c, "POS shift opened successfully", shift)
+}
+
+// ListShifts handles listing cashier shifts, optionally filtered by terminal and status.
+func (h *PosShiftHandler) ListShifts(c *gin.Context) {
+	var terminalID *uuid.ID
+	if raw := c.Query("terminal_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "Invalid terminal ID format", nil)
+			return
+		}
+		terminalID = &id
+	}
+	page, limit := loyaltyPage(c)
+	shifts, total, err := h.service.ListShifts(c.Request.Context(), terminalID, c.Query("status"), limit, (page-1)*limit)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "POS shifts retrieved successfully", dto.PosShiftListResponse{
+		Shifts: shifts,
+		Total:  total,
+		Page:   page,
+		Limit:  limit,
+	})
+}
+
+// GetShift handles retrieving a cashier shift by its ID.
+func (h *PosShiftHandler) GetShift(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	shift, err := h.service.GetShift(c.Request.Context(), id)
+	if err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.OK(c, "POS shift retrieved successfully", shift)
+}
+
+// RecordCashMovement handles a cash-in or cash-out on an open shift.
+func (h *PosShiftHandler) RecordCashMovement(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.PosCashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	movement, err := h.service.RecordCashMovement(c.Request.Context(), id, req.MovementType, req.Amount, req.Reason, c.GetString("user_id"))
+	if err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.Created(c, "Cash movement recorded successfully", movement)
+}
+
+// GetCashMovements handles retrieving the cash movements of a shift.
+func (h *PosShiftHandler) GetCashMovements(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	movements, err := h.service.GetCashMovements(c.Request.Context(), id)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Cash movements retrieved successfully", movements)
+}
+
+// XReport handles printing the running totals of a shift.
+func (h *PosShiftHandler) XReport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	report, err := h.service.XReport(c.Request.Context(), id)
+	if err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.OK(c, "Shift report retrieved successfully", report)
+}
+
+// CloseShift handles closing a shift with the blind cash count and returns the Z report.
+func (h *PosShiftHandler) CloseShift(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.ClosePosShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	shift, err := h.service.CloseShift(c.Request.Context(), id, *req.CountedCash, req.Notes, c.GetString("user_id"))
+	if err != nil {
+		posShiftError(c, err)
+		return
+	}
+	response.OK(c, "POS shift closed successfully", shift)
+}
+
+func newPosTerminalFromRequest(c *gin.Context, req dto.PosTerminalRequest) (*entities.PosTerminal, bool) {
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		response.BadRequest(c, "Invalid warehouse ID format", nil)
+		return nil, false
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return &entities.PosTerminal{
+		Code:        req.Code,
+		Name:        req.Name,
+		StoreName:   req.StoreName,
+		WarehouseID: warehouseID,
+		Timezone:    req.Timezone,
+		IsActive:    isActive,
+	}, true
+}
+
+// posShiftError maps POS terminal and shift errors to HTTP responses.
+func posShiftError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrPosTerminalNotFound), errors.Is(err, entities.ErrPosShiftNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidPosShift), errors.Is(err, entities.ErrPosTerminalInactive),
+		errors.Is(err, entities.ErrPosShiftAlreadyOpen), errors.Is(err, entities.ErrPosShiftClosed),
+		errors.Is(err, entities.ErrNoOpenPosShift), errors.Is(err, entities.ErrPosShiftCashierMismatch):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// PosSyncHandler handles HTTP requests of the offline POS sync.
+type PosSyncHandler struct {
+	service *services.PosSyncService
+}
+
+// NewPosSyncHandler creates a new PosSyncHandler.
+func NewPosSyncHandler(service *services.PosSyncService) *PosSyncHandler {
+	return &PosSyncHandler{service: service}
+}
+
+// DownloadCatalog handles downloading the catalog changes after the version a terminal has.
+func (h *PosSyncHandler) DownloadCatalog(c *gin.Context) {
+	terminalID, err := uuid.Parse(c.Query("terminal_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid terminal ID format", nil)
+		return
+	}
+	since, err := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid version cursor", nil)
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	delta, err := h.service.DownloadCatalog(c.Request.Context(), terminalID, since, limit, c.Query("client_version"))
+	if err != nil {
+		posSyncError(c, err)
+		return
+	}
+	response.OK(c, "POS catalog changes retrieved successfully", delta)
+}
+
+// UploadTransactions handles a batch of sales a terminal made while offline.
+func (h *PosSyncHandler) UploadTransactions(c *gin.Context) {
+	var req dto.UploadPosTransactionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	terminalID, err := uuid.Parse(req.TerminalID)
+	if err != nil {
+		response.BadRequest(c, "Invalid terminal ID format", nil)
+		return
+	}
+
+	sales := make([]*services.PosOfflineSale, 0, len(req.Transactions))
+	for _, txReq := range req.Transactions {
+		sale, ok := newPosOfflineSale(c, txReq)
+		if !ok {
+			return
+		}
+		sales = append(sales, sale)
+	}
+
+	results, err := h.service.UploadTransactions(c.Request.Context(), terminalID, sales, req.ClientVersion)
+	if err != nil {
+		posSyncError(c, err)
+		return
+	}
+	response.OK(c, "POS transactions uploaded successfully", results)
+}
+
+// ListUploads handles listing uploaded offline sales, optionally filtered by terminal and status.
+func (h *PosSyncHandler) ListUploads(c *gin.Context) {
+	var terminalID *uuid.ID
+	if raw := c.Query("terminal_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "Invalid terminal ID format", nil)
+			return
+		}
+		terminalID = &id
+	}
+	page, limit := loyaltyPage(c)
+	uploads, total, err := h.service.ListUploads(c.Request.Context(), terminalID, c.Query("status"), limit, (page-1)*limit)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "POS uploads retrieved successfully", dto.PosSyncUploadListResponse{
+		Uploads: uploads,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	})
+}
+
+// GetSyncStatuses handles showing how far every terminal is behind.
+func (h *PosSyncHandler) GetSyncStatuses(c *gin.Context) {
+	statuses, err := h.service.GetSyncStatuses(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "POS sync status retrieved successfully", statuses)
+}
+
+func newPosOfflineSale(c *gin.Context, req dto.PosOfflineTransactionRequest) (*services.PosOfflineSale, bool) {
+	id, err := uuid.Parse(req.ClientTransactionID)
+	if err != nil {
+		response.BadRequest(c, "Invalid client transaction ID format", nil)
+		return nil, false
+	}
+	cashierID, err := uuid.Parse(req.CashierID)
+	if err != nil {
+		response.BadRequest(c, "Invalid cashier ID format", nil)
+		return nil, false
+	}
+	if req.ShiftID != "" {
+		if _, err := uuid.Parse(req.ShiftID); err != nil {
+			response.BadRequest(c, "Invalid shift ID format", nil)
+			return nil, false
+		}
+	}
+
+	pt := &entities.PosTransaction{
+		TransactionDate:  req.SoldAt,
+		ReceiptNumber:    req.ReceiptNumber,
+		ShiftID:          req.ShiftID,
+		CashierID:        cashierID,
+		Subtotal:         req.Subtotal,
+		DiscountAmount:   req.DiscountAmount,
+		TaxAmount:        req.TaxAmount,
+		TotalAmount:      req.TotalAmount,
+		PaymentMethod:    req.PaymentMethod,
+		Tenders:          posTenders(req.Payments),
+		CustomerID:       req.CustomerID,
+		MemberID:         req.MemberID,
+		MemberIdentifier: req.MemberIdentifier,
+	}
+	pt.ID = id
+
+	sale := &services.PosOfflineSale{Transaction: pt}
+	for _, itemReq := range req.Items {
+		articleID, err := uuid.Parse(itemReq.ArticleID)
+		if err != nil {
+			response.BadRequest(c, "Invalid article ID format", nil)
+			return nil, false
+		}
+		item := &entities.PosItem{
+			ArticleID:      articleID,
+			Quantity:       itemReq.Quantity,
+			ListPrice:      itemReq.ListPrice,
+			UnitPrice:      itemReq.UnitPrice,
+			DiscountAmount: itemReq.DiscountAmount,
+			TotalPrice:     itemReq.TotalPrice,
+			Voided:         itemReq.Voided,
+			VoidReason:     itemReq.VoidReason,
+			VoidApprovedBy: itemReq.VoidApprovedBy,
+		}
+		if item.TotalPrice == 0 {
+			item.TotalPrice = item.UnitPrice*float64(item.Quantity) - item.DiscountAmount
+		}
+		sale.Items = append(sale.Items, item)
+	}
+	return sale, true
+}
+
+// posSyncError maps sync errors to HTTP responses.
+func posSyncError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrPosTerminalNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidPosSync), errors.Is(err, entities.ErrPosTerminalInactive):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
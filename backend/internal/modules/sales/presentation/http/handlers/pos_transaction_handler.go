@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 
 	"malaka/internal/modules/sales/domain/entities"
@@ -35,11 +37,32 @@ func (h *PosTransactionHandler) CreatePosTransaction(c *gin.Context) {
 		return
 	}
 
+	if _, err := uuid.Parse(req.TerminalID); err != nil {
+		response.BadRequest(c, "Invalid terminal ID format", nil)
+		return
+	}
+
+	if req.CustomerID != "" {
+		if _, err := uuid.Parse(req.CustomerID); err != nil {
+			response.BadRequest(c, "Invalid customer ID format", nil)
+			return
+		}
+	}
+
 	pt := &entities.PosTransaction{
-		TransactionDate: utils.Now(),
-		TotalAmount:     req.TotalAmount,
-		PaymentMethod:   req.PaymentMethod,
-		CashierID:       cashierID,
+		TransactionDate:      utils.Now(),
+		TotalAmount:          req.TotalAmount,
+		PaymentMethod:        req.PaymentMethod,
+		CashierID:            cashierID,
+		TerminalID:           req.TerminalID,
+		CustomerID:           req.CustomerID,
+		Location:             req.Location,
+		VoucherCodes:         req.VoucherCodes,
+		MemberID:             req.MemberID,
+		MemberIdentifier:     req.MemberIdentifier,
+		PointsRedeemed:       req.PointsRedeemed,
+		PointsRedemptionMode: req.PointsRedemptionMode,
+		Tenders:              posTenders(req.Payments),
 	}
 
 	var items []*entities.PosItem
@@ -54,11 +77,13 @@ func (h *PosTransactionHandler) CreatePosTransaction(c *gin.Context) {
 			Quantity:   itemReq.Quantity,
 			UnitPrice:  itemReq.UnitPrice,
 			TotalPrice: itemReq.TotalPrice,
+			Voided:     itemReq.Voided,
+			VoidReason: itemReq.VoidReason,
 		})
 	}
 
-	if err := h.service.CreatePosTransaction(c.Request.Context(), pt, items); err != nil {
-		response.InternalServerError(c, err.Error(), nil)
+	if err := h.service.CreatePosTransaction(c.Request.Context(), pt, items, supervisorOverride(req.Override)); err != nil {
+		posTransactionError(c, err)
 		return
 	}
 
@@ -143,9 +168,141 @@ func (h *PosTransactionHandler) DeletePosTransaction(c *gin.Context) {
 		return
 	}
 	if err := h.service.DeletePosTransaction(c.Request.Context(), parsedID); err != nil {
-		response.InternalServerError(c, err.Error(), nil)
+		posTransactionError(c, err)
 		return
 	}
 
 	response.OK(c, "POS transaction deleted successfully", nil)
 }
+
+// GetPosTransactionByReceiptNumber handles looking up a receipt, as when a customer
+// brings items back.
+func (h *PosTransactionHandler) GetPosTransactionByReceiptNumber(c *gin.Context) {
+	detail, err := h.service.GetPosTransactionByReceiptNumber(c.Request.Context(), c.Param("receiptNumber"))
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	if detail == nil {
+		response.NotFound(c, "POS transaction not found", nil)
+		return
+	}
+
+	response.OK(c, "POS transaction retrieved successfully", detail)
+}
+
+// VoidPosTransaction handles voiding a sale during its shift.
+func (h *PosTransactionHandler) VoidPosTransaction(c *gin.Context) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ID format", nil)
+		return
+	}
+	var req dto.VoidPosTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	pt, err := h.service.VoidPosTransaction(c.Request.Context(), parsedID, req.Reason, c.GetString("user_id"), supervisorOverride(req.Override))
+	if err != nil {
+		posTransactionError(c, err)
+		return
+	}
+
+	response.OK(c, "POS transaction voided successfully", pt)
+}
+
+// RefundPosTransaction handles a refund or exchange against an original receipt.
+func (h *PosTransactionHandler) RefundPosTransaction(c *gin.Context) {
+	var req dto.RefundPosTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	terminalID, err := uuid.Parse(req.TerminalID)
+	if err != nil {
+		response.BadRequest(c, "Invalid terminal ID format", nil)
+		return
+	}
+	cashierID, err := uuid.Parse(req.CashierID)
+	if err != nil {
+		response.BadRequest(c, "Invalid cashier ID format", nil)
+		return
+	}
+
+	refund := &services.PosRefundRequest{
+		ReceiptNumber: req.ReceiptNumber,
+		TerminalID:    terminalID,
+		CashierID:     cashierID,
+		Reason:        req.Reason,
+		Tenders:       posTenders(req.Payments),
+	}
+	for _, itemReq := range req.ReturnItems {
+		itemID, err := uuid.Parse(itemReq.ItemID)
+		if err != nil {
+			response.BadRequest(c, "Invalid item ID format", nil)
+			return
+		}
+		refund.ReturnLines = append(refund.ReturnLines, services.PosReturnLine{ItemID: itemID, Quantity: itemReq.Quantity})
+	}
+	for _, itemReq := range req.NewItems {
+		articleID, err := uuid.Parse(itemReq.ArticleID)
+		if err != nil {
+			response.BadRequest(c, "Invalid article ID format", nil)
+			return
+		}
+		refund.NewItems = append(refund.NewItems, &entities.PosItem{
+			ArticleID: articleID,
+			Quantity:  itemReq.Quantity,
+			UnitPrice: itemReq.UnitPrice,
+		})
+	}
+
+	detail, err := h.service.RefundPosTransaction(c.Request.Context(), refund)
+	if err != nil {
+		posTransactionError(c, err)
+		return
+	}
+
+	response.Created(c, "POS refund created successfully", detail)
+}
+
+func posTenders(payments []dto.PosPaymentRequest) []entities.PosTender {
+	var tenders []entities.PosTender
+	for _, payment := range payments {
+		tenders = append(tenders, entities.PosTender{
+			PaymentMethod: payment.PaymentMethod,
+			Amount:        payment.Amount,
+			Reference:     payment.Reference,
+		})
+	}
+	return tenders
+}
+
+func supervisorOverride(req *dto.SupervisorOverrideRequest) *entities.SupervisorOverride {
+	if req == nil {
+		return nil
+	}
+	return &entities.SupervisorOverride{Email: req.SupervisorEmail, Password: req.SupervisorPassword}
+}
+
+// posTransactionError maps checkout, void and refund errors to a response.
+func posTransactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrPosTransactionNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrSupervisorOverrideRequired), errors.Is(err, entities.ErrInvalidSupervisorOverride):
+		response.Forbidden(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrVoucherNotApplicable), errors.Is(err, entities.ErrPromotionExhausted),
+		errors.Is(err, entities.ErrLoyaltyMemberNotFound), errors.Is(err, entities.ErrLoyaltyMemberInactive),
+		errors.Is(err, entities.ErrInsufficientPoints), errors.Is(err, entities.ErrInvalidPointsRedemption),
+		errors.Is(err, entities.ErrNoOpenPosShift), errors.Is(err, entities.ErrPosShiftCashierMismatch),
+		errors.Is(err, entities.ErrInvalidPosShift), errors.Is(err, entities.ErrPosTransactionVoided),
+		errors.Is(err, entities.ErrPosTransactionLocked), errors.Is(err, entities.ErrInvalidTender),
+		errors.Is(err, entities.ErrInvalidPosVoid), errors.Is(err, entities.ErrInvalidPosRefund):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
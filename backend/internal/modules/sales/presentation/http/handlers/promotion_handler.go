@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -41,16 +42,13 @@ func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
 		return
 	}
 
-	promo := &entities.Promotion{
-		Name:        req.Name,
-		Description: req.Description,
-		StartDate:   startDate,
-		EndDate:     endDate,
-		DiscountRate: req.DiscountRate,
-		MinPurchase: req.MinPurchase,
-	}
+	promo := newPromotionFromRequest(req, startDate, endDate)
 
 	if err := h.service.CreatePromotion(c.Request.Context(), promo); err != nil {
+		if errors.Is(err, entities.ErrInvalidPromotion) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
@@ -111,17 +109,14 @@ func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
 		return
 	}
 
-	promo := &entities.Promotion{
-		Name:        req.Name,
-		Description: req.Description,
-		StartDate:   startDate,
-		EndDate:     endDate,
-		DiscountRate: req.DiscountRate,
-		MinPurchase: req.MinPurchase,
-	}
+	promo := newPromotionFromRequest(dto.CreatePromotionRequest(req), startDate, endDate)
 	promo.ID = parsedID // Set the ID from the URL parameter
 
 	if err := h.service.UpdatePromotion(c.Request.Context(), promo); err != nil {
+		if errors.Is(err, entities.ErrInvalidPromotion) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
@@ -139,3 +134,86 @@ func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
 
 	response.OK(c, "Promotion deleted successfully", nil)
 }
+
+// GetPromotionRedemptions handles retrieving the redemptions of a promotion.
+func (h *PromotionHandler) GetPromotionRedemptions(c *gin.Context) {
+	redemptions, err := h.service.GetPromotionRedemptions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+
+	response.OK(c, "Promotion redemptions retrieved successfully", redemptions)
+}
+
+// EvaluatePromotions handles previewing the promotions that apply to a cart. Nothing is redeemed.
+func (h *PromotionHandler) EvaluatePromotions(c *gin.Context) {
+	var req dto.EvaluatePromotionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	cart := &entities.PromotionCart{
+		CustomerID:    req.CustomerID,
+		StoreID:       req.StoreID,
+		PaymentMethod: req.PaymentMethod,
+		VoucherCodes:  req.VoucherCodes,
+	}
+	if req.At != "" {
+		at, err := time.Parse(time.RFC3339, req.At)
+		if err != nil {
+			response.BadRequest(c, "Invalid at format", nil)
+			return
+		}
+		cart.At = at
+	}
+	for _, item := range req.Items {
+		cart.Lines = append(cart.Lines, entities.PromotionCartLine{
+			ArticleID: item.ArticleID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	result, err := h.service.Evaluate(c.Request.Context(), cart)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+
+	response.OK(c, "Promotions evaluated successfully", result)
+}
+
+func newPromotionFromRequest(req dto.CreatePromotionRequest, startDate, endDate time.Time) *entities.Promotion {
+	promo := &entities.Promotion{
+		Name:           req.Name,
+		Description:    req.Description,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		DiscountRate:   req.DiscountRate,
+		MinPurchase:    req.MinPurchase,
+		Code:           req.Code,
+		PromotionType:  req.PromotionType,
+		Priority:       req.Priority,
+		Stackable:      true,
+		IsActive:       true,
+		DiscountAmount: req.DiscountAmount,
+		MaxDiscount:    req.MaxDiscount,
+		BuyQuantity:    req.BuyQuantity,
+		GetQuantity:    req.GetQuantity,
+		BundleQuantity: req.BundleQuantity,
+		BundlePrice:    req.BundlePrice,
+		Tiers:          req.Tiers,
+		Conditions:     req.Conditions,
+		MaxRedemptions: req.MaxRedemptions,
+		Budget:         req.Budget,
+	}
+	if req.Stackable != nil {
+		promo.Stackable = *req.Stackable
+	}
+	if req.IsActive != nil {
+		promo.IsActive = *req.IsActive
+	}
+	return promo
+}
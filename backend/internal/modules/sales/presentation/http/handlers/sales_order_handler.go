@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 
 	"malaka/internal/modules/sales/domain/entities"
@@ -34,6 +36,7 @@ func (h *SalesOrderHandler) CreateSalesOrder(c *gin.Context) {
 		OrderDate:   utils.Now(),
 		Status:      "pending",
 		TotalAmount: req.TotalAmount,
+		VoucherCodes: req.VoucherCodes,
 	}
 
 	var items []*entities.SalesOrderItem
@@ -47,6 +50,10 @@ func (h *SalesOrderHandler) CreateSalesOrder(c *gin.Context) {
 	}
 
 	if err := h.service.CreateSalesOrder(c.Request.Context(), so, items); err != nil {
+		if errors.Is(err, entities.ErrVoucherNotApplicable) || errors.Is(err, entities.ErrPromotionExhausted) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
 		response.InternalServerError(c, err.Error(), nil)
 		return
 	}
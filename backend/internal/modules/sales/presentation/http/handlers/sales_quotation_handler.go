@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// SalesQuotationHandler handles HTTP requests for sales quotations.
+type SalesQuotationHandler struct {
+	service *services.SalesQuotationService
+}
+
+// NewSalesQuotationHandler creates a new SalesQuotationHandler.
+func NewSalesQuotationHandler(service *services.SalesQuotationService) *SalesQuotationHandler {
+	return &SalesQuotationHandler{service: service}
+}
+
+// CreateQuotation handles creating a draft quotation.
+func (h *SalesQuotationHandler) CreateQuotation(c *gin.Context) {
+	q, ok := bindSalesQuotation(c)
+	if !ok {
+		return
+	}
+	q.CreatedBy = c.GetString("user_id")
+	if err := h.service.CreateQuotation(c.Request.Context(), q); err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.Created(c, "Sales quotation created successfully", q)
+}
+
+// ListQuotations handles listing quotations, filtered by status, customer and number.
+func (h *SalesQuotationHandler) ListQuotations(c *gin.Context) {
+	page, limit := loyaltyPage(c)
+	filter := entities.SalesQuotationFilter{
+		Status:      c.Query("status"),
+		CustomerID:  c.Query("customer_id"),
+		Search:      c.Query("search"),
+		AllVersions: c.Query("all_versions") == "true",
+	}
+	quotations, total, err := h.service.ListQuotations(c.Request.Context(), filter, limit, (page-1)*limit)
+	if err != nil {
+		response.InternalServerError(c, err.Error(), nil)
+		return
+	}
+	response.OK(c, "Sales quotations retrieved successfully", dto.SalesQuotationListResponse{
+		Quotations: quotations,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+	})
+}
+
+// GetQuotation handles retrieving a quotation with its lines.
+func (h *SalesQuotationHandler) GetQuotation(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	q, err := h.service.GetQuotation(c.Request.Context(), id)
+	if err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.OK(c, "Sales quotation retrieved successfully", q)
+}
+
+// GetVersions handles listing every version of a quotation.
+func (h *SalesQuotationHandler) GetVersions(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	versions, err := h.service.GetVersions(c.Request.Context(), id)
+	if err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.OK(c, "Sales quotation versions retrieved successfully", versions)
+}
+
+// UpdateQuotation handles editing a draft quotation.
+func (h *SalesQuotationHandler) UpdateQuotation(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	q, ok := bindSalesQuotation(c)
+	if !ok {
+		return
+	}
+	q.ID = id
+	if err := h.service.UpdateQuotation(c.Request.Context(), q); err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.OK(c, "Sales quotation updated successfully", q)
+}
+
+// DeleteQuotation handles deleting a draft quotation.
+func (h *SalesQuotationHandler) DeleteQuotation(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteQuotation(c.Request.Context(), id); err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.OK(c, "Sales quotation deleted successfully", nil)
+}
+
+// SendQuotation handles emailing a quotation PDF to the customer.
+func (h *SalesQuotationHandler) SendQuotation(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	var req dto.SendSalesQuotationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+	}
+	q, err := h.service.SendQuotation(c.Request.Context(), id, req.To)
+	if err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.OK(c, "Sales quotation sent successfully", q)
+}
+
+// DownloadPDF handles downloading a quotation as PDF.
+func (h *SalesQuotationHandler) DownloadPDF(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	q, pdf, err := h.service.RenderPDF(c.Request.Context(), id)
+	if err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-v%d.pdf", q.QuotationNumber, q.Version))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// AcceptQuotation handles recording the customer's acceptance of a quotation.
+func (h *SalesQuotationHandler) AcceptQuotation(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	q, err := h.service.AcceptQuotation(c.Request.Context(), id)
+	if err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.OK(c, "Sales quotation accepted successfully", q)
+}
+
+// ReviseQuotation handles creating the next version of a quotation.
+func (h *SalesQuotationHandler) ReviseQuotation(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	q, err := h.service.ReviseQuotation(c.Request.Context(), id, c.GetString("user_id"))
+	if err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.Created(c, "Sales quotation revised successfully", q)
+}
+
+// ConvertToSalesOrder handles converting an accepted quotation into a sales order.
+func (h *SalesQuotationHandler) ConvertToSalesOrder(c *gin.Context) {
+	id, ok := salesQuotationID(c)
+	if !ok {
+		return
+	}
+	so, err := h.service.ConvertToSalesOrder(c.Request.Context(), id)
+	if err != nil {
+		salesQuotationError(c, err)
+		return
+	}
+	response.Created(c, "Sales order created from quotation successfully", so)
+}
+
+func salesQuotationID(c *gin.Context) (uuid.ID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid quotation ID format", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func bindSalesQuotation(c *gin.Context) (*entities.SalesQuotation, bool) {
+	var req dto.SalesQuotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return nil, false
+	}
+
+	q := &entities.SalesQuotation{
+		CustomerID:   req.CustomerID,
+		Currency:     req.Currency,
+		PaymentTerms: req.PaymentTerms,
+		Notes:        req.Notes,
+		TaxRate:      entities.QuotationDefaultTaxRate,
+	}
+	if req.QuotationDate != nil {
+		q.QuotationDate = *req.QuotationDate
+	}
+	if req.ValidUntil != nil {
+		q.ValidUntil = *req.ValidUntil
+	}
+	if req.TaxRate != nil {
+		q.TaxRate = *req.TaxRate
+	}
+	for _, itemReq := range req.Items {
+		articleID, err := uuid.Parse(itemReq.ArticleID)
+		if err != nil {
+			response.BadRequest(c, "Invalid article ID format", nil)
+			return nil, false
+		}
+		q.Items = append(q.Items, &entities.SalesQuotationItem{
+			ArticleID:       articleID,
+			Description:     itemReq.Description,
+			Quantity:        itemReq.Quantity,
+			UnitPrice:       itemReq.UnitPrice,
+			DiscountPercent: itemReq.DiscountPercent,
+			DiscountAmount:  itemReq.DiscountAmount,
+		})
+	}
+	return q, true
+}
+
+// salesQuotationError maps quotation errors to HTTP responses.
+func salesQuotationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrQuotationNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidQuotation), errors.Is(err, entities.ErrQuotationStatus),
+		errors.Is(err, entities.ErrQuotationExpired), errors.Is(err, entities.ErrQuotationNoPrice):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
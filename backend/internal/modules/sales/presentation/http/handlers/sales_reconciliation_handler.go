@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"malaka/internal/modules/sales/domain/entities"
+	"malaka/internal/modules/sales/domain/services"
+	"malaka/internal/modules/sales/presentation/http/dto"
+	"malaka/internal/shared/response"
+	"malaka/internal/shared/uuid"
+)
+
+// maxSettlementImportSize limits uploaded settlement files (a month of card settlements of every store fits in 20 MB)
+const maxSettlementImportSize = 20 << 20
+
+// SalesReconciliationHandler handles HTTP requests for settlement imports and the
+// reconciliation of sales against them.
+type SalesReconciliationHandler struct {
+	service *services.SalesReconciliationService
+}
+
+// NewSalesReconciliationHandler creates a new SalesReconciliationHandler.
+func NewSalesReconciliationHandler(service *services.SalesReconciliationService) *SalesReconciliationHandler {
+	return &SalesReconciliationHandler{service: service}
+}
+
+// ImportSettlement handles uploading a settlement or payout file (CSV) of a source:
+// card, qris, e_wallet, or marketplace with the channel_id of the channel.
+func (h *SalesReconciliationHandler) ImportSettlement(c *gin.Context) {
+	var channelID *uuid.ID
+	if value := c.PostForm("channel_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			response.BadRequest(c, "Invalid channel ID format", nil)
+			return
+		}
+		channelID = &id
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "Settlement file is required", nil)
+		return
+	}
+	if fileHeader.Size > maxSettlementImportSize {
+		response.BadRequest(c, "Settlement file is too large", nil)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Failed to read settlement file", nil)
+		return
+	}
+	defer file.Close()
+
+	imp, err := h.service.ImportSettlement(c.Request.Context(), c.PostForm("source"), c.PostForm("provider"), channelID,
+		file, fileHeader.Filename, c.GetString("user_id"))
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.Created(c, "Settlement file imported successfully", imp)
+}
+
+// ListImports handles listing the settlement imports, of a source when given.
+func (h *SalesReconciliationHandler) ListImports(c *gin.Context) {
+	imports, err := h.service.ListImports(c.Request.Context(), c.Query("source"))
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.OK(c, "Settlement imports retrieved successfully", imports)
+}
+
+// GetImport handles retrieving a settlement import with its lines.
+func (h *SalesReconciliationHandler) GetImport(c *gin.Context) {
+	id, ok := reconciliationID(c, "Invalid settlement import ID format")
+	if !ok {
+		return
+	}
+	imp, err := h.service.GetImport(c.Request.Context(), id)
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.OK(c, "Settlement import retrieved successfully", imp)
+}
+
+// Reconcile handles reconciling a business day of a source.
+func (h *SalesReconciliationHandler) Reconcile(c *gin.Context) {
+	var req dto.ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	day, err := time.ParseInLocation("2006-01-02", req.BusinessDate, time.Local)
+	if err != nil {
+		response.BadRequest(c, "Invalid business date, expected YYYY-MM-DD", nil)
+		return
+	}
+	var channelID *uuid.ID
+	if req.ChannelID != "" {
+		id, err := uuid.Parse(req.ChannelID)
+		if err != nil {
+			response.BadRequest(c, "Invalid channel ID format", nil)
+			return
+		}
+		channelID = &id
+	}
+
+	rec, err := h.service.Reconcile(c.Request.Context(), day, req.Source, channelID, c.GetString("user_id"))
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.Created(c, "Sales reconciled successfully", rec)
+}
+
+// ReconcileDay handles reconciling every source of a business day.
+func (h *SalesReconciliationHandler) ReconcileDay(c *gin.Context) {
+	var req dto.ReconcileDayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+	day, err := time.ParseInLocation("2006-01-02", req.BusinessDate, time.Local)
+	if err != nil {
+		response.BadRequest(c, "Invalid business date, expected YYYY-MM-DD", nil)
+		return
+	}
+
+	recs, err := h.service.ReconcileDay(c.Request.Context(), day, c.GetString("user_id"))
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.OK(c, "Sales reconciled successfully", recs)
+}
+
+// ListReconciliations handles listing the reconciliations of a date range
+// (from and to as YYYY-MM-DD, the last 30 days by default).
+func (h *SalesReconciliationHandler) ListReconciliations(c *gin.Context) {
+	var from, to time.Time
+	var err error
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			response.BadRequest(c, "Invalid from date, expected YYYY-MM-DD", nil)
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			response.BadRequest(c, "Invalid to date, expected YYYY-MM-DD", nil)
+			return
+		}
+	}
+
+	recs, err := h.service.ListReconciliations(c.Request.Context(), from, to)
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.OK(c, "Sales reconciliations retrieved successfully", recs)
+}
+
+// GetReconciliation handles retrieving a reconciliation with its lines and discrepancies.
+func (h *SalesReconciliationHandler) GetReconciliation(c *gin.Context) {
+	id, ok := reconciliationID(c, "Invalid reconciliation ID format")
+	if !ok {
+		return
+	}
+	rec, err := h.service.GetReconciliation(c.Request.Context(), id)
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.OK(c, "Sales reconciliation retrieved successfully", rec)
+}
+
+// ListDiscrepancies handles listing the discrepancies, of a status when given.
+func (h *SalesReconciliationHandler) ListDiscrepancies(c *gin.Context) {
+	discrepancies, err := h.service.ListDiscrepancies(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.OK(c, "Reconciliation discrepancies retrieved successfully", discrepancies)
+}
+
+// ResolveDiscrepancy handles recording how a discrepancy was resolved.
+func (h *SalesReconciliationHandler) ResolveDiscrepancy(c *gin.Context) {
+	id, ok := reconciliationID(c, "Invalid discrepancy ID format")
+	if !ok {
+		return
+	}
+	var req dto.ResolveDiscrepancyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	d, err := h.service.ResolveDiscrepancy(c.Request.Context(), id, req.Resolution, c.GetString("user_id"))
+	if err != nil {
+		reconciliationError(c, err)
+		return
+	}
+	response.OK(c, "Reconciliation discrepancy resolved successfully", d)
+}
+
+func reconciliationID(c *gin.Context, message string) (uuid.ID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// reconciliationError maps reconciliation errors to HTTP responses.
+func reconciliationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrSettlementImportNotFound), errors.Is(err, entities.ErrReconciliationNotFound),
+		errors.Is(err, entities.ErrDiscrepancyNotFound), errors.Is(err, entities.ErrChannelNotFound):
+		response.NotFound(c, err.Error(), nil)
+	case errors.Is(err, entities.ErrInvalidSettlementImport), errors.Is(err, entities.ErrSettlementImportExists),
+		errors.Is(err, entities.ErrInvalidReconciliation), errors.Is(err, entities.ErrReconciliationLocked),
+		errors.Is(err, entities.ErrDiscrepancyResolved):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalServerError(c, err.Error(), nil)
+	}
+}
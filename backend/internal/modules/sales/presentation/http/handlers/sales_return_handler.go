@@ -29,11 +29,22 @@ func (h *SalesReturnHandler) CreateSalesReturn(c *gin.Context) {
 		return
 	}
 
+	for _, id := range []string{req.SalesInvoiceID, req.PosTransactionID} {
+		if id == "" {
+			continue
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			response.BadRequest(c, "Invalid sales invoice or POS transaction ID format", nil)
+			return
+		}
+	}
+
 	sr := &entities.SalesReturn{
-		SalesInvoiceID: req.SalesInvoiceID,
-		ReturnDate:     utils.Now(),
-		Reason:         req.Reason,
-		TotalAmount:    req.TotalAmount,
+		SalesInvoiceID:   req.SalesInvoiceID,
+		PosTransactionID: req.PosTransactionID,
+		ReturnDate:       utils.Now(),
+		Reason:           req.Reason,
+		TotalAmount:      req.TotalAmount,
 	}
 
 	if err := h.service.CreateSalesReturn(c.Request.Context(), sr); err != nil {
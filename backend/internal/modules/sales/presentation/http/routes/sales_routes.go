@@ -8,7 +8,7 @@ import (
 )
 
 // RegisterSalesRoutes registers the sales routes.
-func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandler, siHandler *handlers.SalesInvoiceHandler, ptHandler *handlers.PosTransactionHandler, ooHandler *handlers.OnlineOrderHandler, csHandler *handlers.ConsignmentSalesHandler, srHandler *handlers.SalesReturnHandler, promoHandler *handlers.PromotionHandler, stHandler *handlers.SalesTargetHandler, skHandler *handlers.SalesKompetitorHandler, pmHandler *handlers.ProsesMarginHandler, srekHandler *handlers.SalesRekonsiliasiHandler, rbacSvc *auth.RBACService) {
+func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandler, siHandler *handlers.SalesInvoiceHandler, ptHandler *handlers.PosTransactionHandler, ooHandler *handlers.OnlineOrderHandler, csHandler *handlers.ConsignmentSalesHandler, srHandler *handlers.SalesReturnHandler, promoHandler *handlers.PromotionHandler, stHandler *handlers.SalesTargetHandler, skHandler *handlers.SalesKompetitorHandler, pmHandler *handlers.ProsesMarginHandler, srekHandler *handlers.SalesRekonsiliasiHandler, loyaltyHandler *handlers.LoyaltyHandler, shiftHandler *handlers.PosShiftHandler, syncHandler *handlers.PosSyncHandler, quotationHandler *handlers.SalesQuotationHandler, o2cHandler *handlers.OrderToCashHandler, consignmentHandler *handlers.ConsignmentStockHandler, marketplaceHandler *handlers.MarketplaceHandler, reconciliationHandler *handlers.SalesReconciliationHandler, rbacSvc *auth.RBACService) {
 	sales := router.Group("/sales")
 	{
 		// Sales Order routes
@@ -19,6 +19,39 @@ func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandl
 			so.GET("/:id", auth.RequirePermission(rbacSvc, "sales.order.read"), soHandler.GetSalesOrderByID)
 			so.PUT("/:id", auth.RequirePermission(rbacSvc, "sales.order.update"), soHandler.UpdateSalesOrder)
 			so.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.order.delete"), soHandler.DeleteSalesOrder)
+
+			// Order-to-cash: confirming reserves the stock, deliveries ship and issue it
+			so.POST("/:id/confirm", auth.RequirePermission(rbacSvc, "sales.order.confirm"), o2cHandler.ConfirmOrder)
+			so.GET("/:id/lines", auth.RequirePermission(rbacSvc, "sales.order.read"), o2cHandler.GetOrderLines)
+			so.POST("/:id/deliveries", auth.RequirePermission(rbacSvc, "sales.delivery.create"), o2cHandler.DeliverOrder)
+			so.GET("/:id/deliveries", auth.RequirePermission(rbacSvc, "sales.delivery.read"), o2cHandler.ListDeliveries)
+		}
+
+		// Sales Delivery routes. Each delivery is invoiced once.
+		sd := sales.Group("/deliveries")
+		{
+			sd.GET("/:id", auth.RequirePermission(rbacSvc, "sales.delivery.read"), o2cHandler.GetDelivery)
+			sd.POST("/:id/invoice", auth.RequirePermission(rbacSvc, "sales.invoice.post"), o2cHandler.InvoiceDelivery)
+		}
+
+		// Document flow of any document from quotation to cash receipt
+		sales.GET("/document-flow/:type/:id", auth.RequirePermission(rbacSvc, "sales.order.read"), o2cHandler.GetDocumentFlow)
+
+		// Sales Quotation routes. Revisions are new versions of a quotation; an accepted
+		// quotation is converted into a sales order once.
+		qt := sales.Group("/quotations")
+		{
+			qt.POST("/", auth.RequirePermission(rbacSvc, "sales.quotation.create"), quotationHandler.CreateQuotation)
+			qt.GET("/", auth.RequirePermission(rbacSvc, "sales.quotation.list"), quotationHandler.ListQuotations)
+			qt.GET("/:id", auth.RequirePermission(rbacSvc, "sales.quotation.read"), quotationHandler.GetQuotation)
+			qt.PUT("/:id", auth.RequirePermission(rbacSvc, "sales.quotation.update"), quotationHandler.UpdateQuotation)
+			qt.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.quotation.delete"), quotationHandler.DeleteQuotation)
+			qt.GET("/:id/versions", auth.RequirePermission(rbacSvc, "sales.quotation.read"), quotationHandler.GetVersions)
+			qt.GET("/:id/pdf", auth.RequirePermission(rbacSvc, "sales.quotation.read"), quotationHandler.DownloadPDF)
+			qt.POST("/:id/send", auth.RequirePermission(rbacSvc, "sales.quotation.send"), quotationHandler.SendQuotation)
+			qt.POST("/:id/revisions", auth.RequirePermission(rbacSvc, "sales.quotation.update"), quotationHandler.ReviseQuotation)
+			qt.POST("/:id/accept", auth.RequirePermission(rbacSvc, "sales.quotation.approve"), quotationHandler.AcceptQuotation)
+			qt.POST("/:id/convert", auth.RequirePermission(rbacSvc, "sales.quotation.approve"), quotationHandler.ConvertToSalesOrder)
 		}
 
 		// Sales Invoice routes
@@ -29,6 +62,7 @@ func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandl
 			si.GET("/:id", auth.RequirePermission(rbacSvc, "sales.invoice.read"), siHandler.GetSalesInvoiceByID)
 			si.PUT("/:id", auth.RequirePermission(rbacSvc, "sales.invoice.update"), siHandler.UpdateSalesInvoice)
 			si.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.invoice.delete"), siHandler.DeleteSalesInvoice)
+			si.POST("/:id/payments", auth.RequirePermission(rbacSvc, "sales.payment.create"), o2cHandler.ReceivePayment)
 		}
 
 		// POS Transaction routes
@@ -39,6 +73,9 @@ func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandl
 			pt.GET("/:id", auth.RequirePermission(rbacSvc, "sales.pos-transaction.read"), ptHandler.GetPosTransactionByID)
 			pt.PUT("/:id", auth.RequirePermission(rbacSvc, "sales.pos-transaction.update"), ptHandler.UpdatePosTransaction)
 			pt.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.pos-transaction.delete"), ptHandler.DeletePosTransaction)
+			pt.GET("/receipts/:receiptNumber", auth.RequirePermission(rbacSvc, "sales.pos-transaction.read"), ptHandler.GetPosTransactionByReceiptNumber)
+			pt.POST("/:id/void", auth.RequirePermission(rbacSvc, "sales.pos-transaction.void"), ptHandler.VoidPosTransaction)
+			pt.POST("/refunds", auth.RequirePermission(rbacSvc, "sales.pos-transaction.refund"), ptHandler.RefundPosTransaction)
 		}
 
 		// Online Order routes
@@ -49,6 +86,36 @@ func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandl
 			oo.GET("/:id", auth.RequirePermission(rbacSvc, "sales.online-order.read"), ooHandler.GetOnlineOrderByID)
 			oo.PUT("/:id", auth.RequirePermission(rbacSvc, "sales.online-order.update"), ooHandler.UpdateOnlineOrder)
 			oo.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.online-order.delete"), ooHandler.DeleteOnlineOrder)
+			oo.GET("/:id/items", auth.RequirePermission(rbacSvc, "sales.online-order.read"), marketplaceHandler.GetOrder)
+			oo.POST("/:id/ship", auth.RequirePermission(rbacSvc, "sales.online-order.ship"), marketplaceHandler.ShipOrder)
+			oo.POST("/:id/cancel", auth.RequirePermission(rbacSvc, "sales.online-order.ship"), marketplaceHandler.CancelOrder)
+		}
+
+		// Marketplace channel routes; channels send webhooks to the public /api/v1/webhooks/marketplace/:code
+		mc := sales.Group("/channels")
+		{
+			mc.POST("/", auth.RequirePermission(rbacSvc, "sales.channel.manage"), marketplaceHandler.CreateChannel)
+			mc.GET("/", auth.RequirePermission(rbacSvc, "sales.channel.list"), marketplaceHandler.ListChannels)
+			mc.GET("/:id", auth.RequirePermission(rbacSvc, "sales.channel.read"), marketplaceHandler.GetChannel)
+			mc.PUT("/:id/sku-mappings", auth.RequirePermission(rbacSvc, "sales.channel.manage"), marketplaceHandler.SaveSKUMappings)
+			mc.GET("/:id/sku-mappings", auth.RequirePermission(rbacSvc, "sales.channel.read"), marketplaceHandler.ListSKUMappings)
+			mc.POST("/:id/pull", auth.RequirePermission(rbacSvc, "sales.channel.sync"), marketplaceHandler.PullOrders)
+			mc.POST("/:id/push-stock", auth.RequirePermission(rbacSvc, "sales.channel.sync"), marketplaceHandler.PushStock)
+			mc.POST("/:id/push-prices", auth.RequirePermission(rbacSvc, "sales.channel.sync"), marketplaceHandler.PushPrices)
+		}
+
+		// Sales reconciliation routes: settlement/payout imports, daily runs and discrepancies
+		rc := sales.Group("/reconciliation")
+		{
+			rc.POST("/imports", auth.RequirePermission(rbacSvc, "sales.reconciliation.import"), reconciliationHandler.ImportSettlement)
+			rc.GET("/imports", auth.RequirePermission(rbacSvc, "sales.reconciliation.list"), reconciliationHandler.ListImports)
+			rc.GET("/imports/:id", auth.RequirePermission(rbacSvc, "sales.reconciliation.read"), reconciliationHandler.GetImport)
+			rc.POST("/runs", auth.RequirePermission(rbacSvc, "sales.reconciliation.run"), reconciliationHandler.Reconcile)
+			rc.POST("/runs/day", auth.RequirePermission(rbacSvc, "sales.reconciliation.run"), reconciliationHandler.ReconcileDay)
+			rc.GET("/runs", auth.RequirePermission(rbacSvc, "sales.reconciliation.list"), reconciliationHandler.ListReconciliations)
+			rc.GET("/runs/:id", auth.RequirePermission(rbacSvc, "sales.reconciliation.read"), reconciliationHandler.GetReconciliation)
+			rc.GET("/discrepancies", auth.RequirePermission(rbacSvc, "sales.reconciliation.list"), reconciliationHandler.ListDiscrepancies)
+			rc.POST("/discrepancies/:id/resolve", auth.RequirePermission(rbacSvc, "sales.reconciliation.resolve"), reconciliationHandler.ResolveDiscrepancy)
 		}
 
 		// Consignment Sales routes
@@ -61,6 +128,25 @@ func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandl
 			cs.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.consignment.delete"), csHandler.DeleteConsignmentSales)
 		}
 
+		// Consignment stock at department stores
+		csg := sales.Group("/consignment")
+		{
+			csg.POST("/locations", auth.RequirePermission(rbacSvc, "sales.consignment.location"), consignmentHandler.CreateLocation)
+			csg.GET("/locations", auth.RequirePermission(rbacSvc, "sales.consignment.list"), consignmentHandler.ListLocations)
+			csg.GET("/locations/:id", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.GetLocation)
+			csg.POST("/locations/:id/transfers-out", auth.RequirePermission(rbacSvc, "sales.consignment.transfer"), consignmentHandler.TransferOut)
+			csg.POST("/locations/:id/returns", auth.RequirePermission(rbacSvc, "sales.consignment.transfer"), consignmentHandler.ReturnToWarehouse)
+			csg.GET("/locations/:id/transfers", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.ListTransfers)
+			csg.POST("/locations/:id/sell-through", auth.RequirePermission(rbacSvc, "sales.consignment.import"), consignmentHandler.ImportSellThrough)
+			csg.GET("/locations/:id/sell-through", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.ListSellThroughs)
+			csg.POST("/locations/:id/settlements", auth.RequirePermission(rbacSvc, "sales.consignment.settle"), consignmentHandler.Settle)
+			csg.GET("/locations/:id/settlements", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.ListSettlements)
+			csg.GET("/transfers/:id", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.GetTransfer)
+			csg.GET("/sell-through/:id", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.GetSellThrough)
+			csg.GET("/settlements/:id", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.GetSettlement)
+			csg.GET("/aging", auth.RequirePermission(rbacSvc, "sales.consignment.read"), consignmentHandler.GetStockAging)
+		}
+
 		// Sales Return routes
 		sr := sales.Group("/returns")
 		{
@@ -76,11 +162,69 @@ func RegisterSalesRoutes(router gin.IRouter, soHandler *handlers.SalesOrderHandl
 		{
 			promo.POST("/", auth.RequirePermission(rbacSvc, "sales.promotion.create"), promoHandler.CreatePromotion)
 			promo.GET("/", auth.RequirePermission(rbacSvc, "sales.promotion.list"), promoHandler.GetAllPromotions)
+			promo.POST("/evaluate", auth.RequirePermission(rbacSvc, "sales.promotion.read"), promoHandler.EvaluatePromotions)
+			promo.GET("/:id/redemptions", auth.RequirePermission(rbacSvc, "sales.promotion.read"), promoHandler.GetPromotionRedemptions)
 			promo.GET("/:id", auth.RequirePermission(rbacSvc, "sales.promotion.read"), promoHandler.GetPromotionByID)
 			promo.PUT("/:id", auth.RequirePermission(rbacSvc, "sales.promotion.update"), promoHandler.UpdatePromotion)
 			promo.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.promotion.delete"), promoHandler.DeletePromotion)
 		}
 
+		// Loyalty program routes
+		loyalty := sales.Group("/loyalty")
+		{
+			loyalty.GET("/program", auth.RequirePermission(rbacSvc, "sales.loyalty.read"), loyaltyHandler.GetProgram)
+			loyalty.PUT("/program", auth.RequirePermission(rbacSvc, "sales.loyalty.manage"), loyaltyHandler.UpdateProgram)
+
+			loyalty.GET("/tiers", auth.RequirePermission(rbacSvc, "sales.loyalty.read"), loyaltyHandler.GetTiers)
+			loyalty.POST("/tiers", auth.RequirePermission(rbacSvc, "sales.loyalty.manage"), loyaltyHandler.CreateTier)
+			loyalty.PUT("/tiers/:id", auth.RequirePermission(rbacSvc, "sales.loyalty.manage"), loyaltyHandler.UpdateTier)
+			loyalty.DELETE("/tiers/:id", auth.RequirePermission(rbacSvc, "sales.loyalty.delete"), loyaltyHandler.DeleteTier)
+			loyalty.GET("/tiers/:id/prices", auth.RequirePermission(rbacSvc, "sales.loyalty.read"), loyaltyHandler.GetMemberPrices)
+			loyalty.PUT("/tiers/:id/prices", auth.RequirePermission(rbacSvc, "sales.loyalty.manage"), loyaltyHandler.SetMemberPrice)
+			loyalty.DELETE("/tiers/:id/prices/:priceId", auth.RequirePermission(rbacSvc, "sales.loyalty.delete"), loyaltyHandler.DeleteMemberPrice)
+
+			loyalty.POST("/members", auth.RequirePermission(rbacSvc, "sales.loyalty.create"), loyaltyHandler.EnrollMember)
+			loyalty.GET("/members", auth.RequirePermission(rbacSvc, "sales.loyalty.list"), loyaltyHandler.ListMembers)
+			loyalty.GET("/members/lookup", auth.RequirePermission(rbacSvc, "sales.loyalty.read"), loyaltyHandler.LookupMember)
+			loyalty.GET("/members/:id", auth.RequirePermission(rbacSvc, "sales.loyalty.read"), loyaltyHandler.GetMember)
+			loyalty.PUT("/members/:id", auth.RequirePermission(rbacSvc, "sales.loyalty.update"), loyaltyHandler.UpdateMember)
+			loyalty.GET("/members/:id/ledger", auth.RequirePermission(rbacSvc, "sales.loyalty.read"), loyaltyHandler.GetLedger)
+			loyalty.POST("/members/:id/adjustments", auth.RequirePermission(rbacSvc, "sales.loyalty.manage"), loyaltyHandler.AdjustPoints)
+		}
+
+		// POS terminal routes
+		terminals := sales.Group("/pos-terminals")
+		{
+			terminals.POST("/", auth.RequirePermission(rbacSvc, "sales.pos-terminal.create"), shiftHandler.CreateTerminal)
+			terminals.GET("/", auth.RequirePermission(rbacSvc, "sales.pos-terminal.list"), shiftHandler.GetTerminals)
+			terminals.GET("/:id", auth.RequirePermission(rbacSvc, "sales.pos-terminal.read"), shiftHandler.GetTerminal)
+			terminals.PUT("/:id", auth.RequirePermission(rbacSvc, "sales.pos-terminal.update"), shiftHandler.UpdateTerminal)
+			terminals.DELETE("/:id", auth.RequirePermission(rbacSvc, "sales.pos-terminal.delete"), shiftHandler.DeleteTerminal)
+		}
+
+		// POS shift routes. The X report shows the expected cash, so it is kept from
+		// cashiers who must close their shift with a blind count.
+		shifts := sales.Group("/pos-shifts")
+		{
+			shifts.POST("/", auth.RequirePermission(rbacSvc, "sales.pos-shift.open"), shiftHandler.OpenShift)
+			shifts.GET("/", auth.RequirePermission(rbacSvc, "sales.pos-shift.list"), shiftHandler.ListShifts)
+			shifts.GET("/:id", auth.RequirePermission(rbacSvc, "sales.pos-shift.read"), shiftHandler.GetShift)
+			shifts.POST("/:id/cash-movements", auth.RequirePermission(rbacSvc, "sales.pos-shift.cash"), shiftHandler.RecordCashMovement)
+			shifts.GET("/:id/cash-movements", auth.RequirePermission(rbacSvc, "sales.pos-shift.read"), shiftHandler.GetCashMovements)
+			shifts.GET("/:id/x-report", auth.RequirePermission(rbacSvc, "sales.pos-shift.manage"), shiftHandler.XReport)
+			shifts.POST("/:id/close", auth.RequirePermission(rbacSvc, "sales.pos-shift.close"), shiftHandler.CloseShift)
+		}
+
+		// POS sync routes for terminals selling offline. The catalog is downloaded as
+		// the changes after the version the terminal has.
+		sync := sales.Group("/pos-sync")
+		{
+			sync.GET("/catalog", auth.RequirePermission(rbacSvc, "sales.pos-sync.download"), syncHandler.DownloadCatalog)
+			sync.POST("/transactions", auth.RequirePermission(rbacSvc, "sales.pos-sync.upload"), syncHandler.UploadTransactions)
+			sync.GET("/uploads", auth.RequirePermission(rbacSvc, "sales.pos-sync.read"), syncHandler.ListUploads)
+			sync.GET("/status", auth.RequirePermission(rbacSvc, "sales.pos-sync.read"), syncHandler.GetSyncStatuses)
+		}
+
 		// Sales Target routes
 		st := sales.Group("/targets")
 		{
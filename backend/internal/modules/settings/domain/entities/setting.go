@@ -102,6 +102,20 @@ const (
 	CategoryLocalization = "localization"
 )
 
+// Security setting keys of the password policy
+const (
+	KeyPasswordMinLength        = "password_min_length"
+	KeyPasswordRequireUppercase = "password_require_uppercase"
+	KeyPasswordRequireLowercase = "password_require_lowercase"
+	KeyPasswordRequireNumber    = "password_require_number"
+	KeyPasswordRequireSymbol    = "password_require_symbol"
+	KeyPasswordCheckBreached    = "password_check_breached"
+	KeyPasswordHistoryCount     = "password_history_count"
+	KeyLoginMaxFailedAttempts   = "login_max_failed_attempts"
+	KeyLoginLockoutMinutes      = "login_lockout_minutes"
+	KeyPasswordResetTokenTTL    = "password_reset_token_ttl_minutes"
+)
+
 // GetDisplayValue returns the appropriate value for display (encrypted values return asterisks)
 func (s *Setting) GetDisplayValue() *string {
 	if s.DataType == DataTypeEncrypted && s.SettingValue != nil && *s.SettingValue != "" {
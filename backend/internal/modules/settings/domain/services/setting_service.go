@@ -12,6 +12,7 @@ import (
 
 	"malaka/internal/modules/settings/domain/entities"
 	"malaka/internal/modules/settings/domain/repositories"
+	"malaka/internal/shared/auth"
 	"malaka/internal/shared/uuid"
 )
 
@@ -215,6 +216,63 @@ func (s *SettingService) GetSettingsForCategory(ctx context.Context, category st
 	return result, nil
 }
 
+// GetPasswordPolicy builds the password policy from the security settings.
+// Settings that are missing or invalid keep their default value.
+func (s *SettingService) GetPasswordPolicy(ctx context.Context) (*auth.PasswordPolicy, error) {
+	policy := auth.DefaultPasswordPolicy()
+
+	ints := map[string]*int{
+		entities.KeyPasswordMinLength:      &policy.MinLength,
+		entities.KeyPasswordHistoryCount:   &policy.HistoryCount,
+		entities.KeyLoginMaxFailedAttempts: &policy.MaxFailedLogins,
+		entities.KeyLoginLockoutMinutes:    &policy.LockoutMinutes,
+		entities.KeyPasswordResetTokenTTL:  &policy.ResetTokenTTLMinutes,
+	}
+	bools := map[string]*bool{
+		entities.KeyPasswordRequireUppercase: &policy.RequireUppercase,
+		entities.KeyPasswordRequireLowercase: &policy.RequireLowercase,
+		entities.KeyPasswordRequireNumber:    &policy.RequireNumber,
+		entities.KeyPasswordRequireSymbol:    &policy.RequireSymbol,
+		entities.KeyPasswordCheckBreached:    &policy.CheckBreached,
+	}
+
+	for key, target := range ints {
+		value, err := s.securityValue(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			*target = n
+		}
+	}
+	for key, target := range bools {
+		value, err := s.securityValue(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if b, err := strconv.ParseBool(value); err == nil {
+			*target = b
+		}
+	}
+
+	return policy, nil
+}
+
+// securityValue returns the value of a top-level security setting, or "" when it is not set
+func (s *SettingService) securityValue(ctx context.Context, key string) (string, error) {
+	setting, err := s.repo.GetByKey(ctx, entities.CategorySecurity, "", key)
+	if err != nil {
+		return "", err
+	}
+	if setting == nil {
+		return "", nil
+	}
+	if value := setting.GetValueOrDefault(); value != nil {
+		return *value, nil
+	}
+	return "", nil
+}
+
 // encrypt encrypts a string value
 func (s *SettingService) encrypt(plaintext string) (string, error) {
 	block, err := aes.NewCipher(s.encryptionKey)
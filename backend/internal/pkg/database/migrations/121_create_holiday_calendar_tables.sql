@@ -0,0 +1,113 @@
+-- +goose Up
+-- Company holiday calendar: per-company and per-region holiday sets that
+-- leave, attendance, payroll working days and the calendar module read from.
+
+CREATE TABLE IF NOT EXISTS holiday_calendars (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    company_id UUID REFERENCES companies(id) ON DELETE CASCADE, -- NULL = applies to every company
+    region_code VARCHAR(20) NOT NULL DEFAULT '',                -- '' = applies to every region
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT holiday_calendars_code_unique UNIQUE (code)
+);
+
+CREATE TABLE IF NOT EXISTS holidays (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    calendar_id UUID NOT NULL REFERENCES holiday_calendars(id) ON DELETE CASCADE,
+    holiday_date DATE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    holiday_type VARCHAR(30) NOT NULL DEFAULT 'national',
+    is_collective_leave BOOLEAN NOT NULL DEFAULT FALSE,
+    leave_type_code VARCHAR(20) NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT holidays_type_check CHECK (holiday_type IN ('national', 'regional', 'company', 'collective_leave')),
+    CONSTRAINT holidays_source_check CHECK (source IN ('manual', 'ical', 'csv')),
+    CONSTRAINT holidays_unique UNIQUE (calendar_id, holiday_date, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_holidays_date ON holidays(holiday_date);
+CREATE INDEX IF NOT EXISTS idx_holidays_calendar_date ON holidays(calendar_id, holiday_date);
+CREATE INDEX IF NOT EXISTS idx_holiday_calendars_scope ON holiday_calendars(company_id, region_code);
+
+-- Employees are scoped to a company and a region so the right holiday set applies
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS company_id UUID REFERENCES companies(id);
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS region_code VARCHAR(20) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_employees_company_id ON employees(company_id);
+
+UPDATE employees e SET company_id = u.company_id
+FROM users u
+WHERE e.user_id = u.id AND e.company_id IS NULL;
+
+-- Leave deducted for cuti bersama, one row per holiday per employee so
+-- deductions are idempotent and can be reverted when a holiday is removed
+CREATE TABLE IF NOT EXISTS leave_collective_deductions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    holiday_id UUID NOT NULL,
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    leave_balance_id UUID NOT NULL REFERENCES leave_balances(id) ON DELETE CASCADE,
+    days INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT leave_collective_deductions_unique UNIQUE (holiday_id, employee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leave_collective_deductions_holiday ON leave_collective_deductions(holiday_id);
+
+-- National calendar shared by every company
+INSERT INTO holiday_calendars (id, code, name, description, region_code) VALUES
+    ('00000000-0000-0000-0000-0000000000c1', 'ID-NATIONAL', 'Indonesia National Holidays', 'Hari libur nasional dan cuti bersama', '')
+ON CONFLICT (code) DO NOTHING;
+
+-- Move the holiday events seeded by the calendar module into the holiday calendar
+INSERT INTO holidays (calendar_id, holiday_date, name, description, holiday_type, source, created_by)
+SELECT '00000000-0000-0000-0000-0000000000c1', (start_datetime AT TIME ZONE 'Asia/Jakarta')::date, title, description, 'national', 'manual', NULL
+FROM events
+WHERE event_type = 'holiday'
+ON CONFLICT (calendar_id, holiday_date, name) DO NOTHING;
+
+DELETE FROM events WHERE event_type = 'holiday';
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'calendar.holiday.create', 'calendar', 'holiday', 'create', 'Create holidays and holiday calendars'),
+    (gen_random_uuid(), 'calendar.holiday.read', 'calendar', 'holiday', 'read', 'View holidays and holiday calendars'),
+    (gen_random_uuid(), 'calendar.holiday.list', 'calendar', 'holiday', 'list', 'List holidays and holiday calendars'),
+    (gen_random_uuid(), 'calendar.holiday.update', 'calendar', 'holiday', 'update', 'Update holidays and holiday calendars'),
+    (gen_random_uuid(), 'calendar.holiday.delete', 'calendar', 'holiday', 'delete', 'Delete holidays and holiday calendars'),
+    (gen_random_uuid(), 'calendar.holiday.import', 'calendar', 'holiday', 'import', 'Import holidays from iCalendar or CSV files')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director') AND p.module = 'calendar' AND p.resource = 'holiday'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Viewer') AND p.code IN ('calendar.holiday.read', 'calendar.holiday.list')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE module = 'calendar' AND resource = 'holiday');
+DELETE FROM permissions WHERE module = 'calendar' AND resource = 'holiday';
+
+INSERT INTO events (title, description, start_datetime, event_type, priority, is_all_day, created_by)
+SELECT h.name, h.description, (h.holiday_date::timestamp AT TIME ZONE 'Asia/Jakarta'), 'holiday', 'high', true, '00000000-0000-0000-0000-000000000000'
+FROM holidays h
+WHERE h.calendar_id = '00000000-0000-0000-0000-0000000000c1';
+
+DROP TABLE IF EXISTS leave_collective_deductions;
+DROP INDEX IF EXISTS idx_employees_company_id;
+ALTER TABLE employees DROP COLUMN IF EXISTS region_code;
+ALTER TABLE employees DROP COLUMN IF EXISTS company_id;
+DROP TABLE IF EXISTS holidays;
+DROP TABLE IF EXISTS holiday_calendars;
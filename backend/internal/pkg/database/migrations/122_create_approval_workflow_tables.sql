@@ -0,0 +1,150 @@
+-- +goose Up
+-- Configurable multi-level approval workflows shared by all modules
+
+CREATE TABLE approval_workflows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID REFERENCES companies(id) ON DELETE CASCADE,
+    document_type VARCHAR(50) NOT NULL CHECK (document_type IN ('purchase_request', 'purchase_order', 'leave_request', 'payroll_period', 'transfer_order')),
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- One active workflow per document type and company (NULL company = default for all companies)
+CREATE UNIQUE INDEX uq_approval_workflows_active
+    ON approval_workflows(document_type, COALESCE(company_id, '00000000-0000-0000-0000-000000000000'::uuid))
+    WHERE is_active;
+
+CREATE TABLE approval_workflow_steps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL REFERENCES approval_workflows(id) ON DELETE CASCADE,
+    step_order INTEGER NOT NULL CHECK (step_order > 0),
+    name VARCHAR(255) NOT NULL,
+    approval_mode VARCHAR(10) NOT NULL DEFAULT 'any' CHECK (approval_mode IN ('any', 'all')),
+    approver_type VARCHAR(20) NOT NULL CHECK (approver_type IN ('user', 'role', 'department', 'supervisor')),
+    approver_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    approver_role VARCHAR(100) NOT NULL DEFAULT '',
+    approver_department VARCHAR(100) NOT NULL DEFAULT '',
+    min_amount NUMERIC(18,2),
+    max_amount NUMERIC(18,2),
+    condition_department VARCHAR(100) NOT NULL DEFAULT '',
+    escalation_hours INTEGER NOT NULL DEFAULT 0 CHECK (escalation_hours >= 0),
+    escalate_to_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    escalate_to_role VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_approval_workflow_steps_workflow ON approval_workflow_steps(workflow_id, step_order);
+
+CREATE TABLE approval_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID REFERENCES approval_workflows(id) ON DELETE SET NULL,
+    company_id UUID REFERENCES companies(id) ON DELETE SET NULL,
+    document_type VARCHAR(50) NOT NULL,
+    document_id VARCHAR(100) NOT NULL,
+    document_number VARCHAR(100) NOT NULL DEFAULT '',
+    requester_id UUID,
+    requester_employee_id UUID,
+    department VARCHAR(100) NOT NULL DEFAULT '',
+    amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    current_step_order INTEGER NOT NULL DEFAULT 0,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- A document can be resubmitted after rejection, but only one run may be pending
+CREATE UNIQUE INDEX uq_approval_requests_pending ON approval_requests(document_type, document_id) WHERE status = 'pending';
+CREATE INDEX idx_approval_requests_document ON approval_requests(document_type, document_id, submitted_at DESC);
+CREATE INDEX idx_approval_requests_status ON approval_requests(status);
+
+CREATE TABLE approval_tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id UUID NOT NULL REFERENCES approval_requests(id) ON DELETE CASCADE,
+    step_id UUID REFERENCES approval_workflow_steps(id) ON DELETE SET NULL,
+    step_order INTEGER NOT NULL,
+    step_name VARCHAR(255) NOT NULL DEFAULT '',
+    approval_mode VARCHAR(10) NOT NULL DEFAULT 'any',
+    approver_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    original_approver_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'skipped', 'escalated')),
+    due_at TIMESTAMP WITH TIME ZONE,
+    acted_at TIMESTAMP WITH TIME ZONE,
+    comments TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_approval_tasks_request ON approval_tasks(request_id);
+CREATE INDEX idx_approval_tasks_approver_pending ON approval_tasks(approver_id) WHERE status = 'pending';
+CREATE INDEX idx_approval_tasks_due ON approval_tasks(due_at) WHERE status = 'pending' AND due_at IS NOT NULL;
+
+CREATE TABLE approval_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id UUID NOT NULL REFERENCES approval_requests(id) ON DELETE CASCADE,
+    task_id UUID REFERENCES approval_tasks(id) ON DELETE SET NULL,
+    step_order INTEGER NOT NULL DEFAULT 0,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('submitted', 'assigned', 'approved', 'rejected', 'delegated', 'escalated', 'cancelled', 'completed')),
+    comments TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_approval_history_request ON approval_history(request_id, created_at);
+
+CREATE TABLE approval_delegations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delegator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    document_type VARCHAR(50),
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (delegator_id <> delegate_id),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_approval_delegations_delegator ON approval_delegations(delegator_id, start_date, end_date) WHERE is_active;
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'approval.workflow.create', 'approval', 'workflow', 'create', 'Create approval workflows'),
+    (gen_random_uuid(), 'approval.workflow.read', 'approval', 'workflow', 'read', 'View approval workflows'),
+    (gen_random_uuid(), 'approval.workflow.list', 'approval', 'workflow', 'list', 'List approval workflows'),
+    (gen_random_uuid(), 'approval.workflow.update', 'approval', 'workflow', 'update', 'Update approval workflows'),
+    (gen_random_uuid(), 'approval.workflow.delete', 'approval', 'workflow', 'delete', 'Delete approval workflows'),
+    (gen_random_uuid(), 'approval.request.read', 'approval', 'request', 'read', 'View approval requests and history'),
+    (gen_random_uuid(), 'approval.request.list', 'approval', 'request', 'list', 'List approval requests and the approval inbox'),
+    (gen_random_uuid(), 'approval.delegation.manage', 'approval', 'delegation', 'manage', 'Delegate own approvals to another user')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin') AND p.module = 'approval'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Finance Manager', 'Finance Staff', 'HR Manager', 'Inventory Manager', 'Procurement Manager')
+  AND p.code IN ('approval.workflow.read', 'approval.workflow.list', 'approval.request.read', 'approval.request.list', 'approval.delegation.manage')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE module = 'approval');
+DELETE FROM permissions WHERE module = 'approval';
+
+DROP TABLE IF EXISTS approval_delegations;
+DROP TABLE IF EXISTS approval_history;
+DROP TABLE IF EXISTS approval_tasks;
+DROP TABLE IF EXISTS approval_requests;
+DROP TABLE IF EXISTS approval_workflow_steps;
+DROP TABLE IF EXISTS approval_workflows;
@@ -0,0 +1,66 @@
+-- +goose Up
+-- Password policy enforcement: failed login lockout, password history and
+-- single-use reset tokens. Reset tokens and old passwords are stored as hashes only.
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS failed_login_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    requested_ip VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user ON password_reset_tokens(user_id) WHERE used_at IS NULL;
+
+-- Default password policy, editable under the security settings category
+INSERT INTO settings (category, setting_key, setting_value, data_type, is_public, default_value, validation_rules, description)
+SELECT 'security', v.setting_key, v.setting_value, v.data_type, TRUE, v.setting_value, v.validation_rules::json, v.description
+FROM (VALUES
+    ('password_min_length', '8', 'number', '{"min": 6, "max": 128}', 'Minimum password length'),
+    ('password_require_uppercase', 'true', 'boolean', NULL, 'Passwords must contain an uppercase letter'),
+    ('password_require_lowercase', 'true', 'boolean', NULL, 'Passwords must contain a lowercase letter'),
+    ('password_require_number', 'true', 'boolean', NULL, 'Passwords must contain a number'),
+    ('password_require_symbol', 'false', 'boolean', NULL, 'Passwords must contain a symbol'),
+    ('password_check_breached', 'true', 'boolean', NULL, 'Reject passwords found on the list of common and breached passwords'),
+    ('password_history_count', '5', 'number', '{"min": 0, "max": 24}', 'Number of recent passwords that cannot be reused'),
+    ('login_max_failed_attempts', '5', 'number', '{"min": 0, "max": 100}', 'Failed logins before the account is locked (0 disables lockout)'),
+    ('login_lockout_minutes', '15', 'number', '{"min": 1, "max": 1440}', 'Minutes an account stays locked after too many failed logins'),
+    ('password_reset_token_ttl_minutes', '60', 'number', '{"min": 5, "max": 1440}', 'Minutes a password reset link stays valid')
+) AS v(setting_key, setting_value, data_type, validation_rules, description)
+WHERE NOT EXISTS (
+    SELECT 1 FROM settings s
+    WHERE s.category = 'security' AND s.sub_category IS NULL AND s.setting_key = v.setting_key
+);
+
+-- +goose Down
+DELETE FROM settings
+WHERE category = 'security' AND sub_category IS NULL AND setting_key IN (
+    'password_min_length', 'password_require_uppercase', 'password_require_lowercase',
+    'password_require_number', 'password_require_symbol', 'password_check_breached',
+    'password_history_count', 'login_max_failed_attempts', 'login_lockout_minutes',
+    'password_reset_token_ttl_minutes'
+);
+
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS password_history;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS password_changed_at,
+DROP COLUMN IF EXISTS locked_until,
+DROP COLUMN IF EXISTS failed_login_attempts;
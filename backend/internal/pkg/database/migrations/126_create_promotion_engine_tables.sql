@@ -0,0 +1,78 @@
+-- +goose Up
+-- Rule-based promotions: promotion types, scope conditions, stacking and
+-- redemption limits, plus line-level discounts on POS and sales order items.
+
+ALTER TABLE promotions
+ADD COLUMN IF NOT EXISTS code VARCHAR(50),
+ADD COLUMN IF NOT EXISTS promotion_type VARCHAR(30) NOT NULL DEFAULT 'percentage', -- percentage, fixed_amount, buy_x_get_y, bundle, tiered_spend
+ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS stackable BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS max_discount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS buy_quantity INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS get_quantity INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS bundle_quantity INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS bundle_price NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS tiers JSONB,
+ADD COLUMN IF NOT EXISTS conditions JSONB NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS max_redemptions INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS redemption_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS budget NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS budget_used NUMERIC(15, 2) NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotions_code ON promotions(UPPER(code)) WHERE code IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_promotions_active_dates ON promotions(start_date, end_date) WHERE is_active = TRUE;
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    source_type VARCHAR(30) NOT NULL, -- pos_transaction, sales_order
+    source_id UUID NOT NULL,
+    customer_id VARCHAR(36),
+    voucher_code VARCHAR(50),
+    discount_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_promotion ON promotion_redemptions(promotion_id);
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_source ON promotion_redemptions(source_type, source_id);
+
+ALTER TABLE pos_transactions ADD COLUMN IF NOT EXISTS customer_id UUID;
+ALTER TABLE pos_items ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE sales_orders
+ADD COLUMN IF NOT EXISTS subtotal NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE sales_order_items ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE sales_order_items DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE sales_orders
+DROP COLUMN IF EXISTS discount_amount,
+DROP COLUMN IF EXISTS subtotal;
+ALTER TABLE pos_items DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE pos_transactions DROP COLUMN IF EXISTS customer_id;
+
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP INDEX IF EXISTS idx_promotions_active_dates;
+DROP INDEX IF EXISTS idx_promotions_code;
+
+ALTER TABLE promotions
+DROP COLUMN IF EXISTS budget_used,
+DROP COLUMN IF EXISTS budget,
+DROP COLUMN IF EXISTS redemption_count,
+DROP COLUMN IF EXISTS max_redemptions,
+DROP COLUMN IF EXISTS conditions,
+DROP COLUMN IF EXISTS tiers,
+DROP COLUMN IF EXISTS bundle_price,
+DROP COLUMN IF EXISTS bundle_quantity,
+DROP COLUMN IF EXISTS get_quantity,
+DROP COLUMN IF EXISTS buy_quantity,
+DROP COLUMN IF EXISTS max_discount,
+DROP COLUMN IF EXISTS discount_amount,
+DROP COLUMN IF EXISTS is_active,
+DROP COLUMN IF EXISTS stackable,
+DROP COLUMN IF EXISTS priority,
+DROP COLUMN IF EXISTS promotion_type,
+DROP COLUMN IF EXISTS code;
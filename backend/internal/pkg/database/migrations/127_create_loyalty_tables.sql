@@ -0,0 +1,149 @@
+-- +goose Up
+-- Customer loyalty program: members identified by phone or card barcode, tiers with
+-- member-only prices, and a points ledger for earning, redemption, expiry and reversals.
+
+CREATE TABLE IF NOT EXISTS loyalty_program (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    earn_spend_unit NUMERIC(15, 2) NOT NULL DEFAULT 10000,
+    point_value NUMERIC(15, 2) NOT NULL DEFAULT 100,
+    points_expiry_months INTEGER NOT NULL DEFAULT 12,
+    tier_review_months INTEGER NOT NULL DEFAULT 12,
+    min_redeem_points INTEGER NOT NULL DEFAULT 100,
+    max_redeem_rate NUMERIC(5, 4) NOT NULL DEFAULT 0.5,
+    category_multipliers JSONB NOT NULL DEFAULT '{}', -- classification id -> multiplier
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO loyalty_program (id) VALUES (1) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS loyalty_tiers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(30) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    rank INTEGER NOT NULL DEFAULT 0,
+    min_spend NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    earn_multiplier NUMERIC(6, 3) NOT NULL DEFAULT 1,
+    member_discount_rate NUMERIC(5, 4) NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO loyalty_tiers (code, name, rank, min_spend, earn_multiplier, member_discount_rate) VALUES
+    ('MEMBER', 'Member', 0, 0, 1, 0),
+    ('SILVER', 'Silver', 1, 5000000, 1.25, 0.02),
+    ('GOLD', 'Gold', 2, 15000000, 1.5, 0.05)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS loyalty_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    member_number VARCHAR(30) NOT NULL UNIQUE,
+    card_barcode VARCHAR(50) UNIQUE,
+    phone VARCHAR(30) UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    customer_id UUID,
+    tier_id UUID NOT NULL REFERENCES loyalty_tiers(id),
+    tier_since TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    points_balance INTEGER NOT NULL DEFAULT 0 CHECK (points_balance >= 0),
+    lifetime_points INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, suspended
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_activity_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_members_customer ON loyalty_members(customer_id) WHERE customer_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_loyalty_members_tier ON loyalty_members(tier_id);
+
+CREATE TABLE IF NOT EXISTS loyalty_member_prices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tier_id UUID NOT NULL REFERENCES loyalty_tiers(id) ON DELETE CASCADE,
+    article_id UUID NOT NULL,
+    price NUMERIC(15, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tier_id, article_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_member_prices_article ON loyalty_member_prices(article_id);
+
+CREATE TABLE IF NOT EXISTS loyalty_points_ledger (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    member_id UUID NOT NULL REFERENCES loyalty_members(id) ON DELETE CASCADE,
+    entry_type VARCHAR(20) NOT NULL, -- earn, redeem, expire, reverse, refund, adjust
+    points INTEGER NOT NULL,
+    remaining_points INTEGER NOT NULL DEFAULT 0,
+    source_type VARCHAR(30) NOT NULL, -- pos_transaction, sales_return, manual, expiry
+    source_id VARCHAR(64),
+    reference_id VARCHAR(64),
+    source_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    spend_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    description TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_member ON loyalty_points_ledger(member_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_source ON loyalty_points_ledger(source_id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_reference ON loyalty_points_ledger(reference_id) WHERE reference_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_open ON loyalty_points_ledger(expires_at) WHERE remaining_points > 0;
+
+ALTER TABLE pos_transactions
+ADD COLUMN IF NOT EXISTS member_id UUID REFERENCES loyalty_members(id),
+ADD COLUMN IF NOT EXISTS points_earned INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS points_redeemed INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS points_value NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS points_redemption_mode VARCHAR(20); -- discount, payment
+CREATE INDEX IF NOT EXISTS idx_pos_transactions_member ON pos_transactions(member_id) WHERE member_id IS NOT NULL;
+
+ALTER TABLE pos_items ADD COLUMN IF NOT EXISTS list_price DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+-- Returns can now refer to a POS transaction instead of an invoice
+ALTER TABLE sales_returns ALTER COLUMN sales_invoice_id DROP NOT NULL;
+ALTER TABLE sales_returns ADD COLUMN IF NOT EXISTS pos_transaction_id UUID;
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.loyalty.create', 'sales', 'loyalty', 'create', 'Enroll loyalty members'),
+    (gen_random_uuid(), 'sales.loyalty.read', 'sales', 'loyalty', 'read', 'View loyalty members, tiers and ledgers'),
+    (gen_random_uuid(), 'sales.loyalty.list', 'sales', 'loyalty', 'list', 'List loyalty members'),
+    (gen_random_uuid(), 'sales.loyalty.update', 'sales', 'loyalty', 'update', 'Update loyalty members'),
+    (gen_random_uuid(), 'sales.loyalty.delete', 'sales', 'loyalty', 'delete', 'Delete loyalty tiers and member prices'),
+    (gen_random_uuid(), 'sales.loyalty.manage', 'sales', 'loyalty', 'manage', 'Configure the loyalty program, tiers, member prices and point adjustments')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager') AND p.module = 'sales' AND p.resource = 'loyalty'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Sales Staff')
+  AND p.code IN ('sales.loyalty.create', 'sales.loyalty.read', 'sales.loyalty.list', 'sales.loyalty.update')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE module = 'sales' AND resource = 'loyalty');
+DELETE FROM permissions WHERE module = 'sales' AND resource = 'loyalty';
+
+ALTER TABLE sales_returns DROP COLUMN IF EXISTS pos_transaction_id;
+ALTER TABLE pos_items DROP COLUMN IF EXISTS list_price;
+
+DROP INDEX IF EXISTS idx_pos_transactions_member;
+ALTER TABLE pos_transactions
+DROP COLUMN IF EXISTS points_redemption_mode,
+DROP COLUMN IF EXISTS points_value,
+DROP COLUMN IF EXISTS points_redeemed,
+DROP COLUMN IF EXISTS points_earned,
+DROP COLUMN IF EXISTS member_id;
+
+DROP TABLE IF EXISTS loyalty_points_ledger;
+DROP TABLE IF EXISTS loyalty_member_prices;
+DROP TABLE IF EXISTS loyalty_members;
+DROP TABLE IF EXISTS loyalty_tiers;
+DROP TABLE IF EXISTS loyalty_program;
@@ -0,0 +1,104 @@
+-- +goose Up
+-- Store-aware POS: terminals registered to a store warehouse, cashier shifts with an
+-- opening float and blind close count, and cash drawer movements. Each POS transaction
+-- is tied to the terminal and shift it was rung up on.
+
+CREATE TABLE IF NOT EXISTS pos_terminals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(30) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    store_name VARCHAR(255) NOT NULL,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pos_shifts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    terminal_id UUID NOT NULL REFERENCES pos_terminals(id),
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    cashier_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, closed
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    opening_float NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    closed_by VARCHAR(100),
+    z_number INTEGER,
+    expected_cash NUMERIC(15, 2),
+    counted_cash NUMERIC(15, 2),
+    cash_variance NUMERIC(15, 2),
+    notes TEXT,
+    z_report JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (terminal_id, z_number)
+);
+-- One open shift per terminal and per cashier
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pos_shifts_open_terminal ON pos_shifts(terminal_id) WHERE status = 'open';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pos_shifts_open_cashier ON pos_shifts(cashier_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_pos_shifts_opened_at ON pos_shifts(opened_at);
+
+CREATE TABLE IF NOT EXISTS pos_cash_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    shift_id UUID NOT NULL REFERENCES pos_shifts(id) ON DELETE CASCADE,
+    movement_type VARCHAR(20) NOT NULL, -- cash_in, cash_out
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL,
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pos_cash_movements_shift ON pos_cash_movements(shift_id);
+
+ALTER TABLE pos_transactions
+ADD COLUMN IF NOT EXISTS terminal_id UUID REFERENCES pos_terminals(id),
+ADD COLUMN IF NOT EXISTS shift_id UUID REFERENCES pos_shifts(id);
+CREATE INDEX IF NOT EXISTS idx_pos_transactions_shift ON pos_transactions(shift_id) WHERE shift_id IS NOT NULL;
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.pos-terminal.create', 'sales', 'pos-terminal', 'create', 'Register POS terminals'),
+    (gen_random_uuid(), 'sales.pos-terminal.read', 'sales', 'pos-terminal', 'read', 'View POS terminals'),
+    (gen_random_uuid(), 'sales.pos-terminal.list', 'sales', 'pos-terminal', 'list', 'List POS terminals'),
+    (gen_random_uuid(), 'sales.pos-terminal.update', 'sales', 'pos-terminal', 'update', 'Update POS terminals'),
+    (gen_random_uuid(), 'sales.pos-terminal.delete', 'sales', 'pos-terminal', 'delete', 'Delete POS terminals'),
+    (gen_random_uuid(), 'sales.pos-shift.open', 'sales', 'pos-shift', 'open', 'Open cashier shifts'),
+    (gen_random_uuid(), 'sales.pos-shift.read', 'sales', 'pos-shift', 'read', 'View cashier shifts and Z reports'),
+    (gen_random_uuid(), 'sales.pos-shift.list', 'sales', 'pos-shift', 'list', 'List cashier shifts'),
+    (gen_random_uuid(), 'sales.pos-shift.cash', 'sales', 'pos-shift', 'cash', 'Record cash-in and cash-out on a shift'),
+    (gen_random_uuid(), 'sales.pos-shift.close', 'sales', 'pos-shift', 'close', 'Close cashier shifts with a blind count'),
+    (gen_random_uuid(), 'sales.pos-shift.manage', 'sales', 'pos-shift', 'manage', 'Print X reports of open shifts')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager') AND p.module = 'sales' AND p.resource IN ('pos-terminal', 'pos-shift')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Sales Staff')
+  AND p.code IN ('sales.pos-terminal.read', 'sales.pos-terminal.list', 'sales.pos-shift.open', 'sales.pos-shift.read',
+                 'sales.pos-shift.list', 'sales.pos-shift.cash', 'sales.pos-shift.close')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Supervisor' AND p.code = 'sales.pos-shift.manage'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE module = 'sales' AND resource IN ('pos-terminal', 'pos-shift'));
+DELETE FROM permissions WHERE module = 'sales' AND resource IN ('pos-terminal', 'pos-shift');
+
+DROP INDEX IF EXISTS idx_pos_transactions_shift;
+ALTER TABLE pos_transactions
+DROP COLUMN IF EXISTS shift_id,
+DROP COLUMN IF EXISTS terminal_id;
+
+DROP TABLE IF EXISTS pos_cash_movements;
+DROP TABLE IF EXISTS pos_shifts;
+DROP TABLE IF EXISTS pos_terminals;
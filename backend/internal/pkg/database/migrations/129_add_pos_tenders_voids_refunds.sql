@@ -0,0 +1,102 @@
+-- +goose Up
+-- POS split tenders, voids and refunds: every sale gets a receipt number and one payment
+-- row per tender with the change given on cash. Lines voided before payment and
+-- receipts voided during the shift are kept with the supervisor who approved them.
+-- Refunds and exchanges are receipts of their own that point to the original sale.
+
+ALTER TABLE pos_terminals ADD COLUMN IF NOT EXISTS receipt_counter INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE pos_transactions
+ADD COLUMN IF NOT EXISTS receipt_number VARCHAR(50),
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed', -- completed, voided
+ADD COLUMN IF NOT EXISTS transaction_type VARCHAR(20) NOT NULL DEFAULT 'sale', -- sale, refund, exchange
+ADD COLUMN IF NOT EXISTS original_transaction_id UUID REFERENCES pos_transactions(id),
+ADD COLUMN IF NOT EXISTS amount_tendered NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS change_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS voided_by VARCHAR(100),
+ADD COLUMN IF NOT EXISTS void_approved_by VARCHAR(100),
+ADD COLUMN IF NOT EXISTS void_reason TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pos_transactions_receipt ON pos_transactions(receipt_number) WHERE receipt_number IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pos_transactions_original ON pos_transactions(original_transaction_id) WHERE original_transaction_id IS NOT NULL;
+
+ALTER TABLE pos_items
+ADD COLUMN IF NOT EXISTS voided BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS void_reason TEXT,
+ADD COLUMN IF NOT EXISTS void_approved_by VARCHAR(100),
+ADD COLUMN IF NOT EXISTS returned_quantity INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS original_item_id UUID REFERENCES pos_items(id);
+
+CREATE TABLE IF NOT EXISTS pos_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pos_transaction_id UUID NOT NULL REFERENCES pos_transactions(id) ON DELETE CASCADE,
+    payment_method VARCHAR(30) NOT NULL, -- cash, card, qris, e_wallet, transfer, gift_voucher, loyalty_points
+    amount NUMERIC(15, 2) NOT NULL,
+    tendered NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    change_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    reference VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pos_payments_transaction ON pos_payments(pos_transaction_id);
+
+-- Existing transactions were paid with a single tender
+INSERT INTO pos_payments (pos_transaction_id, payment_method, amount, created_at)
+SELECT id, LOWER(payment_method), total_amount - CASE WHEN points_redemption_mode = 'payment' THEN points_value ELSE 0 END, created_at
+FROM pos_transactions
+WHERE NOT EXISTS (SELECT 1 FROM pos_payments p WHERE p.pos_transaction_id = pos_transactions.id);
+
+INSERT INTO pos_payments (pos_transaction_id, payment_method, amount, created_at)
+SELECT id, 'loyalty_points', points_value, created_at
+FROM pos_transactions
+WHERE points_redemption_mode = 'payment' AND points_value > 0;
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.pos-transaction.void', 'sales', 'pos-transaction', 'void', 'Void POS receipts'),
+    (gen_random_uuid(), 'sales.pos-transaction.refund', 'sales', 'pos-transaction', 'refund', 'Refund and exchange POS receipts'),
+    (gen_random_uuid(), 'sales.pos-transaction.override', 'sales', 'pos-transaction', 'override', 'Approve POS voids as supervisor')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager', 'Supervisor')
+  AND p.code IN ('sales.pos-transaction.void', 'sales.pos-transaction.refund', 'sales.pos-transaction.override')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Staff', 'Sales Staff')
+  AND p.code IN ('sales.pos-transaction.void', 'sales.pos-transaction.refund')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN
+    ('sales.pos-transaction.void', 'sales.pos-transaction.refund', 'sales.pos-transaction.override'));
+DELETE FROM permissions WHERE code IN ('sales.pos-transaction.void', 'sales.pos-transaction.refund', 'sales.pos-transaction.override');
+
+DROP TABLE IF EXISTS pos_payments;
+
+ALTER TABLE pos_items
+DROP COLUMN IF EXISTS original_item_id,
+DROP COLUMN IF EXISTS returned_quantity,
+DROP COLUMN IF EXISTS void_approved_by,
+DROP COLUMN IF EXISTS void_reason,
+DROP COLUMN IF EXISTS voided;
+
+DROP INDEX IF EXISTS idx_pos_transactions_original;
+DROP INDEX IF EXISTS idx_pos_transactions_receipt;
+ALTER TABLE pos_transactions
+DROP COLUMN IF EXISTS void_reason,
+DROP COLUMN IF EXISTS void_approved_by,
+DROP COLUMN IF EXISTS voided_by,
+DROP COLUMN IF EXISTS voided_at,
+DROP COLUMN IF EXISTS change_amount,
+DROP COLUMN IF EXISTS amount_tendered,
+DROP COLUMN IF EXISTS original_transaction_id,
+DROP COLUMN IF EXISTS transaction_type,
+DROP COLUMN IF EXISTS status,
+DROP COLUMN IF EXISTS receipt_number;
+
+ALTER TABLE pos_terminals DROP COLUMN IF EXISTS receipt_counter;
@@ -0,0 +1,116 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Offline POS sync: every change to articles, prices, barcodes and promotions gets a
+-- version, so terminals download the catalog changes after the version they have.
+-- Sales made offline are uploaded under the ID the terminal gave them; the result of
+-- each upload is kept so a sale sent twice is booked once.
+
+CREATE TABLE IF NOT EXISTS pos_sync_changes (
+    version BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL, -- article, price, barcode, promotion
+    entity_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL, -- upsert, delete
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION pos_sync_record_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO pos_sync_changes (entity_type, entity_id, operation) VALUES (TG_ARGV[0], OLD.id, 'delete');
+        RETURN OLD;
+    END IF;
+    INSERT INTO pos_sync_changes (entity_type, entity_id, operation) VALUES (TG_ARGV[0], NEW.id, 'upsert');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_pos_sync_articles ON articles;
+CREATE TRIGGER trg_pos_sync_articles AFTER INSERT OR UPDATE OR DELETE ON articles
+    FOR EACH ROW EXECUTE FUNCTION pos_sync_record_change('article');
+
+DROP TRIGGER IF EXISTS trg_pos_sync_prices ON prices;
+CREATE TRIGGER trg_pos_sync_prices AFTER INSERT OR UPDATE OR DELETE ON prices
+    FOR EACH ROW EXECUTE FUNCTION pos_sync_record_change('price');
+
+DROP TRIGGER IF EXISTS trg_pos_sync_barcodes ON barcodes;
+CREATE TRIGGER trg_pos_sync_barcodes AFTER INSERT OR UPDATE OR DELETE ON barcodes
+    FOR EACH ROW EXECUTE FUNCTION pos_sync_record_change('barcode');
+
+-- Redemption counters change with every sale; only changes to the promotion's settings
+-- are sent to the terminals
+DROP TRIGGER IF EXISTS trg_pos_sync_promotions ON promotions;
+CREATE TRIGGER trg_pos_sync_promotions AFTER INSERT OR DELETE ON promotions
+    FOR EACH ROW EXECUTE FUNCTION pos_sync_record_change('promotion');
+DROP TRIGGER IF EXISTS trg_pos_sync_promotions_update ON promotions;
+CREATE TRIGGER trg_pos_sync_promotions_update
+    AFTER UPDATE OF name, description, start_date, end_date, discount_rate, min_purchase, code, promotion_type, priority,
+        stackable, is_active, discount_amount, max_discount, buy_quantity, get_quantity, bundle_quantity, bundle_price,
+        tiers, conditions, max_redemptions, budget ON promotions
+    FOR EACH ROW EXECUTE FUNCTION pos_sync_record_change('promotion');
+
+-- The current catalog is the first version terminals download
+INSERT INTO pos_sync_changes (entity_type, entity_id, operation) SELECT 'article', id, 'upsert' FROM articles;
+INSERT INTO pos_sync_changes (entity_type, entity_id, operation) SELECT 'price', id, 'upsert' FROM prices;
+INSERT INTO pos_sync_changes (entity_type, entity_id, operation) SELECT 'barcode', id, 'upsert' FROM barcodes;
+INSERT INTO pos_sync_changes (entity_type, entity_id, operation) SELECT 'promotion', id, 'upsert' FROM promotions;
+
+CREATE TABLE IF NOT EXISTS pos_sync_uploads (
+    client_transaction_id UUID PRIMARY KEY,
+    terminal_id UUID NOT NULL REFERENCES pos_terminals(id),
+    receipt_number VARCHAR(50),
+    status VARCHAR(20) NOT NULL, -- accepted, rejected
+    conflicts JSONB, -- price and stock differences of accepted sales
+    error TEXT, -- why a rejected sale could not be booked
+    attempts INTEGER NOT NULL DEFAULT 1,
+    sold_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pos_sync_uploads_terminal ON pos_sync_uploads(terminal_id, received_at DESC);
+CREATE INDEX IF NOT EXISTS idx_pos_sync_uploads_status ON pos_sync_uploads(status);
+
+CREATE TABLE IF NOT EXISTS pos_sync_status (
+    terminal_id UUID PRIMARY KEY REFERENCES pos_terminals(id) ON DELETE CASCADE,
+    last_download_version BIGINT NOT NULL DEFAULT 0,
+    last_download_at TIMESTAMP WITH TIME ZONE,
+    last_upload_at TIMESTAMP WITH TIME ZONE,
+    client_version VARCHAR(50),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.pos-sync.download', 'sales', 'pos-sync', 'download', 'Download the POS catalog for offline selling'),
+    (gen_random_uuid(), 'sales.pos-sync.upload', 'sales', 'pos-sync', 'upload', 'Upload offline POS transactions'),
+    (gen_random_uuid(), 'sales.pos-sync.read', 'sales', 'pos-sync', 'read', 'View POS sync status and offline uploads')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager', 'Supervisor') AND p.module = 'sales' AND p.resource = 'pos-sync'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Staff', 'Sales Staff') AND p.code IN ('sales.pos-sync.download', 'sales.pos-sync.upload')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE module = 'sales' AND resource = 'pos-sync');
+DELETE FROM permissions WHERE module = 'sales' AND resource = 'pos-sync';
+
+DROP TABLE IF EXISTS pos_sync_status;
+DROP TABLE IF EXISTS pos_sync_uploads;
+
+DROP TRIGGER IF EXISTS trg_pos_sync_promotions_update ON promotions;
+DROP TRIGGER IF EXISTS trg_pos_sync_promotions ON promotions;
+DROP TRIGGER IF EXISTS trg_pos_sync_barcodes ON barcodes;
+DROP TRIGGER IF EXISTS trg_pos_sync_prices ON prices;
+DROP TRIGGER IF EXISTS trg_pos_sync_articles ON articles;
+DROP FUNCTION IF EXISTS pos_sync_record_change();
+DROP TABLE IF EXISTS pos_sync_changes;
+-- +goose StatementEnd
@@ -0,0 +1,91 @@
+-- +goose Up
+-- Sales quotations. Every revision of a quotation is a row of its own sharing the
+-- quotation number; the previous version is kept as superseded.
+
+CREATE SEQUENCE IF NOT EXISTS sales_quotation_number_seq;
+
+CREATE TABLE IF NOT EXISTS sales_quotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    quotation_number VARCHAR(50) NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    previous_version_id UUID REFERENCES sales_quotations(id),
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    quotation_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    valid_until DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft', -- draft, sent, accepted, expired, superseded, converted
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    payment_terms VARCHAR(255),
+    notes TEXT,
+    subtotal NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    discount_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    tax_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    tax_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    total_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    sent_to VARCHAR(255),
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    sales_order_id UUID REFERENCES sales_orders(id) ON DELETE SET NULL,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (quotation_number, version)
+);
+CREATE INDEX IF NOT EXISTS idx_sales_quotations_customer ON sales_quotations(customer_id);
+CREATE INDEX IF NOT EXISTS idx_sales_quotations_status_valid ON sales_quotations(status, valid_until);
+
+CREATE TABLE IF NOT EXISTS sales_quotation_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    quotation_id UUID NOT NULL REFERENCES sales_quotations(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    article_id UUID NOT NULL REFERENCES articles(id),
+    description VARCHAR(255),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(15, 2) NOT NULL,
+    discount_percent NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    discount_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    total_price NUMERIC(15, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sales_quotation_items_quotation ON sales_quotation_items(quotation_id);
+
+-- Orders converted from a quotation link back to it; the total includes tax
+ALTER TABLE sales_orders
+ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS quotation_id UUID REFERENCES sales_quotations(id);
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.quotation.create', 'sales', 'quotation', 'create', 'Create sales quotations'),
+    (gen_random_uuid(), 'sales.quotation.list', 'sales', 'quotation', 'list', 'List sales quotations'),
+    (gen_random_uuid(), 'sales.quotation.read', 'sales', 'quotation', 'read', 'View sales quotations'),
+    (gen_random_uuid(), 'sales.quotation.update', 'sales', 'quotation', 'update', 'Edit and revise sales quotations'),
+    (gen_random_uuid(), 'sales.quotation.delete', 'sales', 'quotation', 'delete', 'Delete draft sales quotations'),
+    (gen_random_uuid(), 'sales.quotation.send', 'sales', 'quotation', 'send', 'Email sales quotations to customers'),
+    (gen_random_uuid(), 'sales.quotation.approve', 'sales', 'quotation', 'approve', 'Record customer acceptance and convert quotations to sales orders')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager') AND p.module = 'sales' AND p.resource = 'quotation'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Staff', 'Sales Staff') AND p.code IN ('sales.quotation.create', 'sales.quotation.list', 'sales.quotation.read',
+    'sales.quotation.update', 'sales.quotation.send')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE module = 'sales' AND resource = 'quotation');
+DELETE FROM permissions WHERE module = 'sales' AND resource = 'quotation';
+
+ALTER TABLE sales_orders
+DROP COLUMN IF EXISTS quotation_id,
+DROP COLUMN IF EXISTS tax_amount;
+
+DROP TABLE IF EXISTS sales_quotation_items;
+DROP TABLE IF EXISTS sales_quotations;
+DROP SEQUENCE IF EXISTS sales_quotation_number_seq;
@@ -0,0 +1,139 @@
+-- +goose Up
+-- Order-to-cash: confirming a sales order reserves its stock, each delivery ships and
+-- issues goods, each delivery is invoiced with a receivable and output VAT, and
+-- customer payments are booked as cash receipts settling the receivable.
+
+CREATE SEQUENCE IF NOT EXISTS sales_delivery_number_seq;
+CREATE SEQUENCE IF NOT EXISTS sales_invoice_number_seq;
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sales_order_id UUID NOT NULL REFERENCES sales_orders(id) ON DELETE CASCADE,
+    sales_order_item_id UUID NOT NULL REFERENCES sales_order_items(id) ON DELETE CASCADE,
+    article_id UUID NOT NULL REFERENCES articles(id),
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    delivered_quantity INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, closed
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (sales_order_item_id)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_open ON stock_reservations(article_id, warehouse_id) WHERE status = 'open';
+
+-- The shipment repository has always written sales_order_id; the table only had order_id
+ALTER TABLE shipments ADD COLUMN IF NOT EXISTS sales_order_id UUID REFERENCES sales_orders(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS sales_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_number VARCHAR(50) NOT NULL UNIQUE,
+    sales_order_id UUID NOT NULL REFERENCES sales_orders(id),
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    shipment_id UUID NOT NULL REFERENCES shipments(id),
+    goods_issue_id UUID NOT NULL REFERENCES simple_goods_issues(id),
+    courier_id UUID NOT NULL REFERENCES couriers(id),
+    tracking_number VARCHAR(255) NOT NULL,
+    delivery_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'posted', -- posted, invoiced
+    sales_invoice_id UUID REFERENCES sales_invoices(id) ON DELETE SET NULL,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sales_deliveries_order ON sales_deliveries(sales_order_id);
+CREATE INDEX IF NOT EXISTS idx_sales_deliveries_shipment ON sales_deliveries(shipment_id);
+CREATE INDEX IF NOT EXISTS idx_sales_deliveries_goods_issue ON sales_deliveries(goods_issue_id);
+
+CREATE TABLE IF NOT EXISTS sales_delivery_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id UUID NOT NULL REFERENCES sales_deliveries(id) ON DELETE CASCADE,
+    sales_order_item_id UUID NOT NULL REFERENCES sales_order_items(id),
+    article_id UUID NOT NULL REFERENCES articles(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(15, 2) NOT NULL,
+    total_price NUMERIC(15, 2) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sales_delivery_items_delivery ON sales_delivery_items(delivery_id);
+CREATE INDEX IF NOT EXISTS idx_sales_delivery_items_order_item ON sales_delivery_items(sales_order_item_id);
+
+-- Invoices of the flow bill one delivery and track their payment
+ALTER TABLE sales_invoices
+ADD COLUMN IF NOT EXISTS invoice_number VARCHAR(50) UNIQUE,
+ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customers(id),
+ADD COLUMN IF NOT EXISTS delivery_id UUID REFERENCES sales_deliveries(id),
+ADD COLUMN IF NOT EXISTS due_date DATE,
+ADD COLUMN IF NOT EXISTS paid_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'unpaid'; -- unpaid, partially_paid, paid
+CREATE INDEX IF NOT EXISTS idx_sales_invoices_order ON sales_invoices(sales_order_id);
+
+-- Receivables of sales invoices; invoice_id keeps pointing at finance invoices
+ALTER TABLE accounts_receivable ADD COLUMN IF NOT EXISTS sales_invoice_id UUID REFERENCES sales_invoices(id);
+CREATE INDEX IF NOT EXISTS idx_accounts_receivable_sales_invoice ON accounts_receivable(sales_invoice_id);
+
+CREATE TABLE IF NOT EXISTS sales_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sales_invoice_id UUID NOT NULL REFERENCES sales_invoices(id),
+    cash_receipt_id UUID NOT NULL REFERENCES cash_receipts(id),
+    cash_bank_id UUID REFERENCES cash_banks(id),
+    payment_date DATE NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    payment_method VARCHAR(50) NOT NULL DEFAULT '',
+    reference VARCHAR(100),
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sales_payments_invoice ON sales_payments(sales_invoice_id);
+CREATE INDEX IF NOT EXISTS idx_sales_payments_cash_receipt ON sales_payments(cash_receipt_id);
+
+-- Output VAT of sales invoices is booked against the PPN tax in effect
+INSERT INTO taxes (tax_code, tax_name, tax_type, tax_rate, description, effective_date)
+VALUES ('PPN-OUT', 'PPN Keluaran', 'PPN', 11, 'Output VAT on sales invoices', '2022-04-01')
+ON CONFLICT (tax_code) DO NOTHING;
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.order.confirm', 'sales', 'order', 'confirm', 'Confirm sales orders and reserve their stock'),
+    (gen_random_uuid(), 'sales.delivery.create', 'sales', 'delivery', 'create', 'Post deliveries of sales orders'),
+    (gen_random_uuid(), 'sales.delivery.read', 'sales', 'delivery', 'read', 'View deliveries of sales orders'),
+    (gen_random_uuid(), 'sales.invoice.post', 'sales', 'invoice', 'post', 'Invoice deliveries of sales orders'),
+    (gen_random_uuid(), 'sales.payment.create', 'sales', 'payment', 'create', 'Record customer payments of sales invoices')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager') AND p.code IN ('sales.order.confirm', 'sales.delivery.create',
+    'sales.delivery.read', 'sales.invoice.post', 'sales.payment.create')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Sales Staff') AND p.code IN ('sales.delivery.create', 'sales.delivery.read')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('sales.order.confirm',
+    'sales.delivery.create', 'sales.delivery.read', 'sales.invoice.post', 'sales.payment.create'));
+DELETE FROM permissions WHERE code IN ('sales.order.confirm', 'sales.delivery.create', 'sales.delivery.read',
+    'sales.invoice.post', 'sales.payment.create');
+
+DELETE FROM taxes WHERE tax_code = 'PPN-OUT' AND NOT EXISTS (SELECT 1 FROM tax_transactions t WHERE t.tax_id = taxes.id);
+
+DROP TABLE IF EXISTS sales_payments;
+ALTER TABLE accounts_receivable DROP COLUMN IF EXISTS sales_invoice_id;
+ALTER TABLE sales_invoices
+DROP COLUMN IF EXISTS status,
+DROP COLUMN IF EXISTS paid_amount,
+DROP COLUMN IF EXISTS due_date,
+DROP COLUMN IF EXISTS delivery_id,
+DROP COLUMN IF EXISTS customer_id,
+DROP COLUMN IF EXISTS invoice_number;
+DROP TABLE IF EXISTS sales_delivery_items;
+DROP TABLE IF EXISTS sales_deliveries;
+ALTER TABLE shipments DROP COLUMN IF EXISTS sales_order_id;
+DROP TABLE IF EXISTS stock_reservations;
+DROP SEQUENCE IF EXISTS sales_invoice_number_seq;
+DROP SEQUENCE IF EXISTS sales_delivery_number_seq;
@@ -0,0 +1,163 @@
+-- +goose Up
+-- Consignment stock at department stores: each store gets a consignment warehouse our
+-- stock is transferred to and returned from, its sell-through reports are booked as
+-- consignment sales, and settlements invoice the sales less the store's commission.
+-- Consigned stock is kept in lots to age it and consume it first in, first out.
+
+CREATE SEQUENCE IF NOT EXISTS consignment_transfer_number_seq;
+CREATE SEQUENCE IF NOT EXISTS consignment_sell_through_number_seq;
+CREATE SEQUENCE IF NOT EXISTS consignment_settlement_number_seq;
+
+ALTER TABLE warehouses DROP CONSTRAINT IF EXISTS chk_warehouses_type;
+ALTER TABLE warehouses ADD CONSTRAINT chk_warehouses_type
+CHECK (type IN ('main', 'satellite', 'transit', 'quarantine', 'distribution', 'retail', 'consignment'));
+
+CREATE TABLE IF NOT EXISTS consignment_locations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    depstore_id UUID NOT NULL UNIQUE REFERENCES depstores(id),
+    warehouse_id UUID NOT NULL UNIQUE REFERENCES warehouses(id),
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    commission_rate NUMERIC(5, 2), -- overrides the depstore's commission rate
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, inactive
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS consignment_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transfer_number VARCHAR(50) NOT NULL UNIQUE,
+    location_id UUID NOT NULL REFERENCES consignment_locations(id),
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('out', 'return')),
+    from_warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    to_warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    transfer_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_consignment_transfers_location ON consignment_transfers(location_id, transfer_date);
+
+CREATE TABLE IF NOT EXISTS consignment_transfer_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transfer_id UUID NOT NULL REFERENCES consignment_transfers(id) ON DELETE CASCADE,
+    article_id UUID NOT NULL REFERENCES articles(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(15, 2) NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_consignment_transfer_items_transfer ON consignment_transfer_items(transfer_id);
+
+CREATE TABLE IF NOT EXISTS consignment_lots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    location_id UUID NOT NULL REFERENCES consignment_locations(id),
+    article_id UUID NOT NULL REFERENCES articles(id),
+    transfer_item_id UUID NOT NULL UNIQUE REFERENCES consignment_transfer_items(id),
+    received_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    quantity INTEGER NOT NULL,
+    remaining_quantity INTEGER NOT NULL CHECK (remaining_quantity >= 0),
+    unit_price NUMERIC(15, 2) NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_consignment_lots_open ON consignment_lots(location_id, article_id, received_date)
+    WHERE remaining_quantity > 0;
+
+CREATE TABLE IF NOT EXISTS consignment_settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    settlement_number VARCHAR(50) NOT NULL UNIQUE,
+    location_id UUID NOT NULL REFERENCES consignment_locations(id),
+    sales_invoice_id UUID NOT NULL REFERENCES sales_invoices(id),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    gross_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    commission_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    net_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    tax_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    grand_total NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'invoiced',
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_consignment_settlements_location ON consignment_settlements(location_id, period_end);
+
+-- Sell-through reports are the consignment sales of a location; consignee_id is the depstore
+ALTER TABLE consignment_sales
+ADD COLUMN IF NOT EXISTS location_id UUID REFERENCES consignment_locations(id),
+ADD COLUMN IF NOT EXISTS report_number VARCHAR(50) UNIQUE,
+ADD COLUMN IF NOT EXISTS period_start DATE,
+ADD COLUMN IF NOT EXISTS period_end DATE,
+ADD COLUMN IF NOT EXISTS commission_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS commission_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS net_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS settlement_id UUID REFERENCES consignment_settlements(id),
+ADD COLUMN IF NOT EXISTS source_file VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_consignment_sales_location ON consignment_sales(location_id, status, period_end);
+
+CREATE TABLE IF NOT EXISTS consignment_sales_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sell_through_id UUID NOT NULL REFERENCES consignment_sales(id) ON DELETE CASCADE,
+    article_id UUID NOT NULL REFERENCES articles(id),
+    barcode VARCHAR(255) NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    gross_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    commission_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    net_amount NUMERIC(15, 2) NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_consignment_sales_items_report ON consignment_sales_items(sell_through_id);
+
+-- Settlement invoices bill a department store rather than a sales order
+ALTER TABLE sales_invoices ALTER COLUMN sales_order_id DROP NOT NULL;
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.consignment.location', 'sales', 'consignment', 'location', 'Manage consignment locations at department stores'),
+    (gen_random_uuid(), 'sales.consignment.transfer', 'sales', 'consignment', 'transfer', 'Transfer stock to and from consignment'),
+    (gen_random_uuid(), 'sales.consignment.import', 'sales', 'consignment', 'import', 'Import sell-through reports of department stores'),
+    (gen_random_uuid(), 'sales.consignment.settle', 'sales', 'consignment', 'settle', 'Settle consignment sales with department stores')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager') AND p.code IN ('sales.consignment.location',
+    'sales.consignment.transfer', 'sales.consignment.import', 'sales.consignment.settle')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Sales Staff') AND p.code IN ('sales.consignment.transfer', 'sales.consignment.import')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('sales.consignment.location',
+    'sales.consignment.transfer', 'sales.consignment.import', 'sales.consignment.settle'));
+DELETE FROM permissions WHERE code IN ('sales.consignment.location', 'sales.consignment.transfer',
+    'sales.consignment.import', 'sales.consignment.settle');
+
+-- sales_invoices.sales_order_id stays nullable, as settlement invoices outlive the settlements
+DROP TABLE IF EXISTS consignment_sales_items;
+DROP INDEX IF EXISTS idx_consignment_sales_location;
+ALTER TABLE consignment_sales
+DROP COLUMN IF EXISTS created_by,
+DROP COLUMN IF EXISTS source_file,
+DROP COLUMN IF EXISTS settlement_id,
+DROP COLUMN IF EXISTS net_amount,
+DROP COLUMN IF EXISTS commission_amount,
+DROP COLUMN IF EXISTS commission_rate,
+DROP COLUMN IF EXISTS period_end,
+DROP COLUMN IF EXISTS period_start,
+DROP COLUMN IF EXISTS report_number,
+DROP COLUMN IF EXISTS location_id;
+DROP TABLE IF EXISTS consignment_settlements;
+DROP TABLE IF EXISTS consignment_lots;
+DROP TABLE IF EXISTS consignment_transfer_items;
+DROP TABLE IF EXISTS consignment_transfers;
+DROP TABLE IF EXISTS consignment_locations;
+
+ALTER TABLE warehouses DROP CONSTRAINT IF EXISTS chk_warehouses_type;
+ALTER TABLE warehouses ADD CONSTRAINT chk_warehouses_type
+CHECK (type IN ('main', 'satellite', 'transit', 'quarantine', 'distribution', 'retail'));
+
+DROP SEQUENCE IF EXISTS consignment_settlement_number_seq;
+DROP SEQUENCE IF EXISTS consignment_sell_through_number_seq;
+DROP SEQUENCE IF EXISTS consignment_transfer_number_seq;
@@ -0,0 +1,113 @@
+-- +goose Up
+-- Marketplace channels: each shop on a marketplace is served by a channel adapter. Its
+-- orders are imported once, keyed by the channel's order ID, as online orders with
+-- items whose stock is reserved in the channel's warehouse until they are shipped.
+
+CREATE TABLE IF NOT EXISTS marketplace_channels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    adapter VARCHAR(50) NOT NULL,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    settings JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, inactive
+    last_pulled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS marketplace_sku_mappings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    channel_id UUID NOT NULL REFERENCES marketplace_channels(id) ON DELETE CASCADE,
+    channel_sku VARCHAR(255) NOT NULL,
+    barcode VARCHAR(255) NOT NULL,
+    article_id UUID NOT NULL REFERENCES articles(id),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (channel_id, channel_sku)
+);
+CREATE INDEX IF NOT EXISTS idx_marketplace_sku_mappings_article ON marketplace_sku_mappings(article_id);
+
+ALTER TABLE online_orders
+ADD COLUMN IF NOT EXISTS channel_id UUID REFERENCES marketplace_channels(id),
+ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(id),
+ADD COLUMN IF NOT EXISTS buyer_name VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS buyer_phone VARCHAR(50) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS shipping_address TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS shipping_fee NUMERIC(15, 2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS tracking_number VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS shipment_id UUID REFERENCES shipments(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS goods_issue_id UUID REFERENCES simple_goods_issues(id) ON DELETE SET NULL;
+-- A channel's order is imported only once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_online_orders_channel_order ON online_orders(channel_id, order_id)
+    WHERE channel_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS online_order_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    online_order_id UUID NOT NULL REFERENCES online_orders(id) ON DELETE CASCADE,
+    article_id UUID NOT NULL REFERENCES articles(id),
+    channel_sku VARCHAR(255) NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(15, 2) NOT NULL,
+    total_price NUMERIC(15, 2) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_online_order_items_order ON online_order_items(online_order_id);
+
+-- Online order items reserve stock like sales order items
+ALTER TABLE stock_reservations ALTER COLUMN sales_order_id DROP NOT NULL;
+ALTER TABLE stock_reservations ALTER COLUMN sales_order_item_id DROP NOT NULL;
+ALTER TABLE stock_reservations
+ADD COLUMN IF NOT EXISTS online_order_item_id UUID UNIQUE REFERENCES online_order_items(id) ON DELETE CASCADE;
+ALTER TABLE stock_reservations ADD CONSTRAINT chk_stock_reservations_item
+CHECK ((sales_order_item_id IS NULL) <> (online_order_item_id IS NULL));
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.channel.list', 'sales', 'channel', 'list', 'List marketplace channels'),
+    (gen_random_uuid(), 'sales.channel.read', 'sales', 'channel', 'read', 'View marketplace channels and their SKU mappings'),
+    (gen_random_uuid(), 'sales.channel.manage', 'sales', 'channel', 'manage', 'Set up marketplace channels and map their SKUs'),
+    (gen_random_uuid(), 'sales.channel.sync', 'sales', 'channel', 'sync', 'Pull orders from and push stock and prices to marketplace channels'),
+    (gen_random_uuid(), 'sales.online-order.ship', 'sales', 'online-order', 'ship', 'Hand marketplace orders to shipping or cancel them')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager') AND p.code IN ('sales.channel.list', 'sales.channel.read',
+    'sales.channel.manage', 'sales.channel.sync', 'sales.online-order.ship')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Sales Staff') AND p.code IN ('sales.channel.list', 'sales.channel.read',
+    'sales.channel.sync', 'sales.online-order.ship')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('sales.channel.list',
+    'sales.channel.read', 'sales.channel.manage', 'sales.channel.sync', 'sales.online-order.ship'));
+DELETE FROM permissions WHERE code IN ('sales.channel.list', 'sales.channel.read', 'sales.channel.manage',
+    'sales.channel.sync', 'sales.online-order.ship');
+
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS chk_stock_reservations_item;
+DELETE FROM stock_reservations WHERE online_order_item_id IS NOT NULL;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS online_order_item_id;
+ALTER TABLE stock_reservations ALTER COLUMN sales_order_item_id SET NOT NULL;
+ALTER TABLE stock_reservations ALTER COLUMN sales_order_id SET NOT NULL;
+
+DROP TABLE IF EXISTS online_order_items;
+DROP INDEX IF EXISTS idx_online_orders_channel_order;
+ALTER TABLE online_orders
+DROP COLUMN IF EXISTS goods_issue_id,
+DROP COLUMN IF EXISTS shipment_id,
+DROP COLUMN IF EXISTS tracking_number,
+DROP COLUMN IF EXISTS shipping_fee,
+DROP COLUMN IF EXISTS shipping_address,
+DROP COLUMN IF EXISTS buyer_phone,
+DROP COLUMN IF EXISTS buyer_name,
+DROP COLUMN IF EXISTS warehouse_id,
+DROP COLUMN IF EXISTS channel_id;
+
+DROP TABLE IF EXISTS marketplace_sku_mappings;
+DROP TABLE IF EXISTS marketplace_channels;
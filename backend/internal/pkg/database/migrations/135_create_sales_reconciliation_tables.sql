@@ -0,0 +1,134 @@
+-- +goose Up
+-- Daily sales reconciliation: settlement files of banks (EDC), QRIS and e-wallet
+-- providers and marketplace payout reports are imported line by line, then each day's
+-- POS payments and channel orders are matched against them. Matched and unmatched lines
+-- are kept with their fees; unmatched ones become discrepancies for finance, and each
+-- reconciliation keeps a sales_rekonsiliasi summary.
+
+CREATE TABLE IF NOT EXISTS settlement_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source VARCHAR(20) NOT NULL, -- card, qris, e_wallet, marketplace
+    provider VARCHAR(100) NOT NULL,
+    channel_id UUID REFERENCES marketplace_channels(id),
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    file_hash VARCHAR(64) NOT NULL UNIQUE,
+    line_count INTEGER NOT NULL DEFAULT 0,
+    gross_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    fee_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    net_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    imported_by VARCHAR(100) NOT NULL DEFAULT '',
+    imported_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sales_reconciliations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    business_date DATE NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    channel_id UUID REFERENCES marketplace_channels(id),
+    expected_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    settled_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    fee_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    net_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    mdr_rate NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    difference NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    matched_count INTEGER NOT NULL DEFAULT 0,
+    unmatched_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL, -- reconciled, discrepancies
+    rekonsiliasi_id UUID REFERENCES sales_rekonsiliasi(id) ON DELETE SET NULL,
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- One reconciliation per day and source (and channel for marketplace payouts)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sales_reconciliations_day ON sales_reconciliations(business_date, source,
+    COALESCE(channel_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+CREATE TABLE IF NOT EXISTS sales_reconciliation_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reconciliation_id UUID NOT NULL REFERENCES sales_reconciliations(id) ON DELETE CASCADE,
+    match_status VARCHAR(30) NOT NULL, -- matched, amount_mismatch, missing_settlement, unexpected_settlement, missing_payout
+    pos_payment_id UUID REFERENCES pos_payments(id) ON DELETE SET NULL,
+    online_order_id UUID REFERENCES online_orders(id) ON DELETE SET NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    expected_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    settled_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    fee_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    net_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    fee_rate NUMERIC(7, 2) NOT NULL DEFAULT 0,
+    difference NUMERIC(15, 2) NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_sales_reconciliation_lines_rec ON sales_reconciliation_lines(reconciliation_id);
+CREATE INDEX IF NOT EXISTS idx_sales_reconciliation_lines_order ON sales_reconciliation_lines(online_order_id)
+    WHERE online_order_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS settlement_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    import_id UUID NOT NULL REFERENCES settlement_imports(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    channel_id UUID REFERENCES marketplace_channels(id),
+    line_number INTEGER NOT NULL,
+    transaction_date DATE NOT NULL,
+    settlement_date DATE NOT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    external_order_id VARCHAR(255) NOT NULL DEFAULT '',
+    gross_amount NUMERIC(15, 2) NOT NULL,
+    fee_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    net_amount NUMERIC(15, 2) NOT NULL,
+    reconciliation_line_id UUID REFERENCES sales_reconciliation_lines(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_source_date ON settlement_lines(source, transaction_date);
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_channel_payout ON settlement_lines(channel_id, settlement_date)
+    WHERE channel_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS sales_reconciliation_discrepancies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reconciliation_id UUID NOT NULL REFERENCES sales_reconciliations(id) ON DELETE CASCADE,
+    line_id UUID NOT NULL REFERENCES sales_reconciliation_lines(id) ON DELETE CASCADE,
+    business_date DATE NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    discrepancy_type VARCHAR(30) NOT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    amount NUMERIC(15, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, resolved
+    resolution TEXT NOT NULL DEFAULT '',
+    resolved_by VARCHAR(100) NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sales_reconciliation_discrepancies_status ON sales_reconciliation_discrepancies(status, business_date);
+CREATE INDEX IF NOT EXISTS idx_sales_reconciliation_discrepancies_rec ON sales_reconciliation_discrepancies(reconciliation_id);
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'sales.reconciliation.list', 'sales', 'reconciliation', 'list', 'List settlement imports, reconciliations and discrepancies'),
+    (gen_random_uuid(), 'sales.reconciliation.read', 'sales', 'reconciliation', 'read', 'View settlement imports and reconciliations'),
+    (gen_random_uuid(), 'sales.reconciliation.import', 'sales', 'reconciliation', 'import', 'Import settlement and payout files'),
+    (gen_random_uuid(), 'sales.reconciliation.run', 'sales', 'reconciliation', 'run', 'Reconcile sales against settlements'),
+    (gen_random_uuid(), 'sales.reconciliation.resolve', 'sales', 'reconciliation', 'resolve', 'Resolve reconciliation discrepancies')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Manager', 'Director', 'Admin', 'Sales Manager') AND p.code IN ('sales.reconciliation.list',
+    'sales.reconciliation.read', 'sales.reconciliation.import', 'sales.reconciliation.run', 'sales.reconciliation.resolve')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Supervisor', 'Staff', 'Sales Staff') AND p.code IN ('sales.reconciliation.list',
+    'sales.reconciliation.read', 'sales.reconciliation.import')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('sales.reconciliation.list',
+    'sales.reconciliation.read', 'sales.reconciliation.import', 'sales.reconciliation.run', 'sales.reconciliation.resolve'));
+DELETE FROM permissions WHERE code IN ('sales.reconciliation.list', 'sales.reconciliation.read', 'sales.reconciliation.import',
+    'sales.reconciliation.run', 'sales.reconciliation.resolve');
+
+DELETE FROM sales_rekonsiliasi WHERE id IN (SELECT rekonsiliasi_id FROM sales_reconciliations);
+DROP TABLE IF EXISTS sales_reconciliation_discrepancies;
+DROP TABLE IF EXISTS settlement_lines;
+DROP TABLE IF EXISTS sales_reconciliation_lines;
+DROP TABLE IF EXISTS sales_reconciliations;
+DROP TABLE IF EXISTS settlement_imports;
@@ -0,0 +1,137 @@
+-- +goose Up
+-- e-Faktur: tax invoices (faktur pajak keluaran) numbered from the NSFP ranges the tax
+-- office allocates, replacement (pengganti) and cancelled (batal) fakturs, and input
+-- tax invoices (faktur pajak masukan) imported from supplier faktur files.
+
+-- Buyer identity printed on fakturs
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tax_id VARCHAR(20) NOT NULL DEFAULT ''; -- NPWP (15 or 16 digits) or NIK
+
+CREATE TABLE IF NOT EXISTS nsfp_ranges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    branch_code VARCHAR(3) NOT NULL,
+    tax_year INTEGER NOT NULL,
+    start_serial BIGINT NOT NULL,
+    end_serial BIGINT NOT NULL,
+    next_serial BIGINT NOT NULL,
+    letter_number VARCHAR(100) NOT NULL DEFAULT '', -- allocation letter of the tax office
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, exhausted
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (start_serial > 0 AND start_serial <= end_serial AND end_serial <= 99999999),
+    CHECK (next_serial BETWEEN start_serial AND end_serial + 1)
+);
+CREATE INDEX IF NOT EXISTS idx_nsfp_ranges_year ON nsfp_ranges(tax_year, status);
+
+CREATE TABLE IF NOT EXISTS tax_invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    faktur_number VARCHAR(25) NOT NULL UNIQUE, -- 010.000-24.00000001
+    nsfp VARCHAR(20) NOT NULL,
+    nsfp_range_id UUID NOT NULL REFERENCES nsfp_ranges(id),
+    transaction_code VARCHAR(2) NOT NULL DEFAULT '01',
+    kind VARCHAR(20) NOT NULL DEFAULT 'normal', -- normal, replacement
+    replaces_id UUID REFERENCES tax_invoices(id),
+    sales_invoice_id UUID NOT NULL REFERENCES sales_invoices(id),
+    invoice_number VARCHAR(50) NOT NULL DEFAULT '',
+    faktur_date DATE NOT NULL,
+    customer_id UUID REFERENCES customers(id),
+    buyer_tax_id VARCHAR(20) NOT NULL DEFAULT '',
+    buyer_name VARCHAR(255) NOT NULL DEFAULT '',
+    buyer_address TEXT NOT NULL DEFAULT '',
+    tax_base NUMERIC(15, 2) NOT NULL,
+    vat_amount NUMERIC(15, 2) NOT NULL,
+    luxury_tax_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, replaced, cancelled
+    cancel_reason TEXT NOT NULL DEFAULT '',
+    cancelled_by VARCHAR(100) NOT NULL DEFAULT '',
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    exported_at TIMESTAMP WITH TIME ZONE,
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- An NSFP is used by one normal faktur (its replacements keep the number) and an
+-- invoice has one faktur in force
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_invoices_nsfp ON tax_invoices(nsfp) WHERE kind = 'normal';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_invoices_active_invoice ON tax_invoices(sales_invoice_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_tax_invoices_date ON tax_invoices(faktur_date, status);
+
+CREATE TABLE IF NOT EXISTS tax_invoice_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tax_invoice_id UUID NOT NULL REFERENCES tax_invoices(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    item_code VARCHAR(100) NOT NULL DEFAULT '',
+    item_name VARCHAR(255) NOT NULL,
+    unit_price NUMERIC(15, 2) NOT NULL,
+    quantity NUMERIC(15, 2) NOT NULL,
+    total_price NUMERIC(15, 2) NOT NULL,
+    discount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    tax_base NUMERIC(15, 2) NOT NULL,
+    vat_amount NUMERIC(15, 2) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tax_invoice_items_invoice ON tax_invoice_items(tax_invoice_id);
+
+CREATE TABLE IF NOT EXISTS input_tax_invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    faktur_number VARCHAR(25) NOT NULL,
+    nsfp VARCHAR(20) NOT NULL,
+    transaction_code VARCHAR(2) NOT NULL,
+    replacement BOOLEAN NOT NULL DEFAULT false,
+    faktur_date DATE NOT NULL,
+    tax_period INTEGER NOT NULL,
+    tax_year INTEGER NOT NULL,
+    supplier_tax_id VARCHAR(20) NOT NULL,
+    supplier_name VARCHAR(255) NOT NULL DEFAULT '',
+    supplier_address TEXT NOT NULL DEFAULT '',
+    supplier_id UUID REFERENCES suppliers(id),
+    tax_base NUMERIC(15, 2) NOT NULL,
+    vat_amount NUMERIC(15, 2) NOT NULL,
+    luxury_tax_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    creditable BOOLEAN NOT NULL DEFAULT true,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, replaced
+    tax_transaction_id UUID REFERENCES tax_transactions(id) ON DELETE SET NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    imported_by VARCHAR(100) NOT NULL DEFAULT '',
+    imported_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_input_tax_invoices_nsfp ON input_tax_invoices(nsfp) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_input_tax_invoices_period ON input_tax_invoices(tax_year, tax_period);
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'accounting.tax-invoice.list', 'accounting', 'tax-invoice', 'list', 'List tax invoices and NSFP ranges'),
+    (gen_random_uuid(), 'accounting.tax-invoice.read', 'accounting', 'tax-invoice', 'read', 'View tax invoices'),
+    (gen_random_uuid(), 'accounting.tax-invoice.issue', 'accounting', 'tax-invoice', 'issue', 'Issue and replace tax invoices'),
+    (gen_random_uuid(), 'accounting.tax-invoice.cancel', 'accounting', 'tax-invoice', 'cancel', 'Cancel tax invoices'),
+    (gen_random_uuid(), 'accounting.tax-invoice.export', 'accounting', 'tax-invoice', 'export', 'Export tax invoices to e-Faktur and Coretax'),
+    (gen_random_uuid(), 'accounting.tax-invoice.import', 'accounting', 'tax-invoice', 'import', 'Import supplier input tax invoices'),
+    (gen_random_uuid(), 'accounting.nsfp.manage', 'accounting', 'nsfp', 'manage', 'Register NSFP ranges allocated by the tax office')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Finance Manager', 'Manager', 'Director', 'Admin') AND p.code IN ('accounting.tax-invoice.list',
+    'accounting.tax-invoice.read', 'accounting.tax-invoice.issue', 'accounting.tax-invoice.cancel',
+    'accounting.tax-invoice.export', 'accounting.tax-invoice.import', 'accounting.nsfp.manage')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Finance Staff' AND p.code IN ('accounting.tax-invoice.list', 'accounting.tax-invoice.read',
+    'accounting.tax-invoice.issue', 'accounting.tax-invoice.export', 'accounting.tax-invoice.import')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('accounting.tax-invoice.list',
+    'accounting.tax-invoice.read', 'accounting.tax-invoice.issue', 'accounting.tax-invoice.cancel',
+    'accounting.tax-invoice.export', 'accounting.tax-invoice.import', 'accounting.nsfp.manage'));
+DELETE FROM permissions WHERE code IN ('accounting.tax-invoice.list', 'accounting.tax-invoice.read', 'accounting.tax-invoice.issue',
+    'accounting.tax-invoice.cancel', 'accounting.tax-invoice.export', 'accounting.tax-invoice.import', 'accounting.nsfp.manage');
+
+DELETE FROM tax_transactions WHERE id IN (SELECT tax_transaction_id FROM input_tax_invoices);
+DROP TABLE IF EXISTS input_tax_invoices;
+DROP TABLE IF EXISTS tax_invoice_items;
+DROP TABLE IF EXISTS tax_invoices;
+DROP TABLE IF EXISTS nsfp_ranges;
+ALTER TABLE customers DROP COLUMN IF EXISTS tax_id;
@@ -0,0 +1,105 @@
+-- +goose Up
+-- Withholding tax on purchases: PPh 23 and PPh 4(2) withheld from supplier payments by
+-- supplier and service type, with the withholding slips (bukti potong) reported in e-Bupot.
+
+CREATE TABLE IF NOT EXISTS withholding_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    supplier_id UUID REFERENCES suppliers(id), -- NULL for the default of the service type
+    service_type VARCHAR(50) NOT NULL, -- rental, consulting, construction, ...
+    tax_article VARCHAR(10) NOT NULL CHECK (tax_article IN ('PPh23', 'PPh4(2)')),
+    tax_object_code VARCHAR(20) NOT NULL, -- kode objek pajak, e.g. 24-104-14
+    rate NUMERIC(6, 3) NOT NULL CHECK (rate > 0 AND rate <= 100), -- percent of the gross amount
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- One active rule per supplier and service type, and one default per service type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_withholding_rules_supplier ON withholding_rules(supplier_id, service_type)
+    WHERE is_active AND supplier_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_withholding_rules_default ON withholding_rules(service_type)
+    WHERE is_active AND supplier_id IS NULL;
+
+-- Supplier payments settle the gross payable; the cash paid is net of the tax withheld
+ALTER TABLE payments
+ADD COLUMN IF NOT EXISTS accounts_payable_id UUID REFERENCES accounts_payable(id),
+ADD COLUMN IF NOT EXISTS gross_amount DECIMAL(19,4) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS withholding_amount DECIMAL(19,4) NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_payments_accounts_payable ON payments(accounts_payable_id);
+
+CREATE TABLE IF NOT EXISTS withholding_slips (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slip_number VARCHAR(30) NOT NULL UNIQUE, -- BP23/2025/03/0001
+    tax_article VARCHAR(10) NOT NULL,
+    tax_year INTEGER NOT NULL,
+    tax_period INTEGER NOT NULL CHECK (tax_period BETWEEN 1 AND 12),
+    sequence INTEGER NOT NULL,
+    tax_object_code VARCHAR(20) NOT NULL,
+    withholding_rule_id UUID REFERENCES withholding_rules(id),
+    supplier_id UUID REFERENCES suppliers(id),
+    supplier_name VARCHAR(255) NOT NULL DEFAULT '',
+    supplier_tax_id VARCHAR(20) NOT NULL DEFAULT '', -- NPWP digits, empty when the supplier has none
+    supplier_address TEXT NOT NULL DEFAULT '',
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    accounts_payable_id UUID REFERENCES accounts_payable(id),
+    document_number VARCHAR(100) NOT NULL DEFAULT '', -- supplier invoice the tax is withheld on
+    document_date DATE,
+    slip_date DATE NOT NULL,
+    gross_amount NUMERIC(15, 2) NOT NULL CHECK (gross_amount > 0),
+    rate NUMERIC(6, 3) NOT NULL,
+    withheld_amount NUMERIC(15, 2) NOT NULL CHECK (withheld_amount > 0),
+    tax_transaction_id UUID REFERENCES tax_transactions(id),
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tax_article, tax_year, tax_period, sequence)
+);
+CREATE INDEX IF NOT EXISTS idx_withholding_slips_period ON withholding_slips(tax_year, tax_period, tax_article);
+CREATE INDEX IF NOT EXISTS idx_withholding_slips_supplier ON withholding_slips(supplier_id);
+
+-- The tax withheld is payable to the state against these taxes
+INSERT INTO taxes (tax_code, tax_name, tax_type, tax_rate, description, effective_date) VALUES
+    ('PPH23', 'PPh Pasal 23', 'WITHHOLDING', 2, 'Income tax withheld on services, rent and royalties paid to suppliers', '2009-01-01'),
+    ('PPH4-2', 'PPh Pasal 4 ayat 2', 'WITHHOLDING', 10, 'Final income tax withheld on construction and land and building rent', '2009-01-01')
+ON CONFLICT (tax_code) DO NOTHING;
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'finance.withholding.list', 'finance', 'withholding', 'list', 'List withholding rules, slips and summaries'),
+    (gen_random_uuid(), 'finance.withholding.read', 'finance', 'withholding', 'read', 'View and print withholding slips'),
+    (gen_random_uuid(), 'finance.withholding.create', 'finance', 'withholding', 'create', 'Pay supplier payables net of withholding tax'),
+    (gen_random_uuid(), 'finance.withholding.manage', 'finance', 'withholding', 'manage', 'Manage withholding rules of suppliers and service types'),
+    (gen_random_uuid(), 'finance.withholding.export', 'finance', 'withholding', 'export', 'Export withholding slips to e-Bupot')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Finance Manager', 'Manager', 'Director', 'Admin') AND p.code IN ('finance.withholding.list',
+    'finance.withholding.read', 'finance.withholding.create', 'finance.withholding.manage', 'finance.withholding.export')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Finance Staff' AND p.code IN ('finance.withholding.list', 'finance.withholding.read',
+    'finance.withholding.create', 'finance.withholding.export')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('finance.withholding.list',
+    'finance.withholding.read', 'finance.withholding.create', 'finance.withholding.manage', 'finance.withholding.export'));
+DELETE FROM permissions WHERE code IN ('finance.withholding.list', 'finance.withholding.read', 'finance.withholding.create',
+    'finance.withholding.manage', 'finance.withholding.export');
+
+DELETE FROM tax_transactions WHERE id IN (SELECT tax_transaction_id FROM withholding_slips);
+DROP TABLE IF EXISTS withholding_slips;
+DELETE FROM taxes WHERE tax_code IN ('PPH23', 'PPH4-2') AND NOT EXISTS (SELECT 1 FROM tax_transactions t WHERE t.tax_id = taxes.id);
+
+DROP INDEX IF EXISTS idx_payments_accounts_payable;
+ALTER TABLE payments
+DROP COLUMN IF EXISTS withholding_amount,
+DROP COLUMN IF EXISTS gross_amount,
+DROP COLUMN IF EXISTS accounts_payable_id;
+
+DROP TABLE IF EXISTS withholding_rules;
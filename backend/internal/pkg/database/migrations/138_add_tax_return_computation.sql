@@ -0,0 +1,47 @@
+-- +goose Up
+-- Monthly tax returns (SPT Masa) computed from the tax transactions of the period: the
+-- overpayment (lebih bayar) of the previous month is brought forward, late filing and
+-- late payment are charged from the due dates, and filing locks the transactions.
+
+ALTER TABLE tax_returns
+ADD COLUMN IF NOT EXISTS payment_due_date DATE,
+ADD COLUMN IF NOT EXISTS overpayment_brought_forward DECIMAL(18,2) NOT NULL DEFAULT 0, -- kompensasi of the previous month
+ADD COLUMN IF NOT EXISTS overpayment_carried_forward DECIMAL(18,2) NOT NULL DEFAULT 0, -- lebih bayar to the next month
+ADD COLUMN IF NOT EXISTS transaction_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS journal_entry_id UUID; -- settlement journal of the payment
+
+UPDATE tax_returns SET payment_due_date = due_date WHERE payment_due_date IS NULL;
+
+-- One return per tax type and period
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_returns_type_period ON tax_returns(tax_type, period_start);
+
+-- The return a transaction was filed in; filed transactions can no longer change
+ALTER TABLE tax_transactions ADD COLUMN IF NOT EXISTS tax_return_id UUID REFERENCES tax_returns(id);
+CREATE INDEX IF NOT EXISTS idx_tax_transactions_tax_return ON tax_transactions(tax_return_id);
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'accounting.tax-return.generate', 'accounting', 'tax-return', 'generate', 'Compute tax returns from the tax transactions of a period')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Finance Manager', 'Finance Staff', 'Manager', 'Director', 'Admin')
+    AND p.code = 'accounting.tax-return.generate'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'accounting.tax-return.generate');
+DELETE FROM permissions WHERE code = 'accounting.tax-return.generate';
+
+DROP INDEX IF EXISTS idx_tax_transactions_tax_return;
+ALTER TABLE tax_transactions DROP COLUMN IF EXISTS tax_return_id;
+
+DROP INDEX IF EXISTS idx_tax_returns_type_period;
+ALTER TABLE tax_returns
+DROP COLUMN IF EXISTS journal_entry_id,
+DROP COLUMN IF EXISTS transaction_count,
+DROP COLUMN IF EXISTS overpayment_carried_forward,
+DROP COLUMN IF EXISTS overpayment_brought_forward,
+DROP COLUMN IF EXISTS payment_due_date;
@@ -0,0 +1,115 @@
+-- +goose Up
+-- Effective-dated tax rates and tax determination. A tax keeps every rate it had with the
+-- dates it was in force, so documents are taxed at the rate of their own date, and the
+-- tax base (DPP) may be a fraction of the price: from 2025 PPN is 12% of 11/12 of the
+-- price (DPP nilai lain). Determination rules pick the tax of a sale, POS sale or
+-- purchase from the tax status of the customer or supplier and the article classification.
+
+-- Tax status of the party: PKP (registered for VAT), NON_PKP, COLLECTOR (government VAT
+-- collector, wapu) or EXEMPT; empty when not set
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tax_status VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE suppliers ADD COLUMN IF NOT EXISTS tax_status VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS tax_rate_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tax_id UUID NOT NULL REFERENCES taxes(id) ON DELETE CASCADE,
+    rate DECIMAL(8,4) NOT NULL,
+    -- The tax base is numerator/denominator of the amount (1/1 for the full amount)
+    tax_base_numerator INTEGER NOT NULL DEFAULT 1,
+    tax_base_denominator INTEGER NOT NULL DEFAULT 1,
+    valid_from DATE NOT NULL,
+    valid_to DATE, -- open-ended when NULL
+    description TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (tax_id, valid_from),
+    CHECK (rate >= 0),
+    CHECK (tax_base_numerator > 0 AND tax_base_denominator > 0 AND tax_base_numerator <= tax_base_denominator),
+    CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_rate_versions_tax ON tax_rate_versions(tax_id, valid_from);
+
+-- Input VAT on purchases, credited against the output VAT
+INSERT INTO taxes (tax_code, tax_name, tax_type, tax_rate, description, effective_date)
+VALUES ('PPN-IN', 'PPN Masukan', 'PPN', 11, 'Input VAT on purchases', '2022-04-01')
+ON CONFLICT (tax_code) DO NOTHING;
+
+-- The current rate of every tax becomes its first version
+INSERT INTO tax_rate_versions (tax_id, rate, valid_from, valid_to, description)
+SELECT id, tax_rate, effective_date, expiry_date, 'Rate of the tax master'
+FROM taxes
+ON CONFLICT (tax_id, valid_from) DO NOTHING;
+
+-- PPN 11% ends with 2024; from 2025 it is 12% of a tax base of 11/12 of the price
+UPDATE tax_rate_versions v SET valid_to = '2024-12-31'
+FROM taxes t
+WHERE t.id = v.tax_id AND t.tax_type = 'PPN' AND v.rate = 11 AND v.valid_to IS NULL AND v.valid_from < '2025-01-01';
+
+INSERT INTO tax_rate_versions (tax_id, rate, tax_base_numerator, tax_base_denominator, valid_from, description)
+SELECT t.id, 12, 11, 12, '2025-01-01', 'PPN 12% on DPP nilai lain 11/12'
+FROM taxes t
+WHERE t.tax_type = 'PPN' AND t.tax_rate = 11
+ON CONFLICT (tax_id, valid_from) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS tax_determination_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_type VARCHAR(20) NOT NULL CHECK (transaction_type IN ('SALE', 'POS', 'PURCHASE')),
+    party_tax_status VARCHAR(20) NOT NULL DEFAULT '', -- any status when empty
+    classification_id UUID REFERENCES classifications(id) ON DELETE CASCADE, -- any article when NULL
+    tax_id UUID REFERENCES taxes(id), -- not taxed when NULL
+    priority INTEGER NOT NULL DEFAULT 0,
+    description TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_determination_rules_type ON tax_determination_rules(transaction_type, is_active);
+
+-- Default rules: sales and POS sales carry output VAT, purchases input VAT; suppliers not
+-- registered for VAT charge none and exempt customers are not charged
+INSERT INTO tax_determination_rules (transaction_type, party_tax_status, tax_id, priority, description)
+SELECT v.transaction_type, v.party_tax_status, t.id, v.priority, v.description
+FROM (VALUES
+    ('SALE', '', 'PPN-OUT', 0, 'Output VAT on sales'),
+    ('POS', '', 'PPN-OUT', 0, 'Output VAT on POS sales'),
+    ('PURCHASE', '', 'PPN-IN', 0, 'Input VAT on purchases')
+) AS v(transaction_type, party_tax_status, tax_code, priority, description)
+JOIN taxes t ON t.tax_code = v.tax_code;
+
+INSERT INTO tax_determination_rules (transaction_type, party_tax_status, tax_id, priority, description) VALUES
+    ('PURCHASE', 'NON_PKP', NULL, 10, 'Suppliers not registered for VAT charge no VAT'),
+    ('SALE', 'EXEMPT', NULL, 10, 'Customers exempt from VAT');
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'accounting.tax-rule.list', 'accounting', 'tax-rule', 'list', 'List tax determination rules and determine taxes'),
+    (gen_random_uuid(), 'accounting.tax-rule.manage', 'accounting', 'tax-rule', 'manage', 'Create and update tax determination rules')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Finance Manager', 'Finance Staff', 'Manager', 'Director', 'Admin')
+    AND p.code = 'accounting.tax-rule.list'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Finance Manager', 'Director', 'Admin')
+    AND p.code = 'accounting.tax-rule.manage'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('accounting.tax-rule.list', 'accounting.tax-rule.manage'));
+DELETE FROM permissions WHERE code IN ('accounting.tax-rule.list', 'accounting.tax-rule.manage');
+
+DROP TABLE IF EXISTS tax_determination_rules;
+DROP TABLE IF EXISTS tax_rate_versions;
+DELETE FROM taxes WHERE tax_code = 'PPN-IN' AND NOT EXISTS (SELECT 1 FROM tax_transactions WHERE tax_id = taxes.id);
+
+ALTER TABLE suppliers DROP COLUMN IF EXISTS tax_status;
+ALTER TABLE customers DROP COLUMN IF EXISTS tax_status;
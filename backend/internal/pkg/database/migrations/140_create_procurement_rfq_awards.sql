@@ -0,0 +1,42 @@
+-- +goose Up
+-- Migration: RFQ awards
+-- Records which supplier response won each RFQ item and the purchase order raised for it
+
+CREATE TABLE IF NOT EXISTS procurement_rfq_awards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rfq_id UUID NOT NULL REFERENCES procurement_rfqs(id) ON DELETE CASCADE,
+    rfq_item_id UUID NOT NULL REFERENCES procurement_rfq_items(id) ON DELETE CASCADE,
+    rfq_response_id UUID NOT NULL REFERENCES procurement_rfq_responses(id) ON DELETE CASCADE,
+    supplier_id UUID NOT NULL REFERENCES suppliers(id),
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL(20, 2) NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT 'IDR',
+    score DECIMAL(6, 2) NOT NULL DEFAULT 0,
+    purchase_order_id UUID REFERENCES procurement_purchase_orders(id) ON DELETE SET NULL,
+    awarded_by UUID NOT NULL REFERENCES users(id),
+    awarded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(rfq_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_procurement_rfq_awards_rfq_id ON procurement_rfq_awards(rfq_id);
+CREATE INDEX IF NOT EXISTS idx_procurement_rfq_awards_supplier_id ON procurement_rfq_awards(supplier_id);
+CREATE INDEX IF NOT EXISTS idx_procurement_rfq_awards_purchase_order_id ON procurement_rfq_awards(purchase_order_id);
+
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'procurement.rfq.award', 'procurement', 'rfq', 'award', 'Award procurement RFQ and raise purchase orders')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Procurement Manager', 'Director', 'Admin')
+    AND p.code = 'procurement.rfq.award'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'procurement.rfq.award');
+DELETE FROM permissions WHERE code = 'procurement.rfq.award';
+
+DROP TABLE IF EXISTS procurement_rfq_awards;
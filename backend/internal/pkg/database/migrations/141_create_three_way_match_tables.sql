@@ -0,0 +1,111 @@
+-- +goose Up
+-- Three-way match of supplier invoices (purchase vouchers) against the purchase order and
+-- the posted goods receipts. Mismatches beyond the company's tolerances raise exceptions
+-- that block approval and payment until they are resolved.
+
+-- Tolerances per company; the row without a company is the default of every company
+CREATE TABLE IF NOT EXISTS three_way_match_tolerances (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID REFERENCES companies(id) ON DELETE CASCADE,
+    quantity_tolerance NUMERIC(6, 3) NOT NULL DEFAULT 0 CHECK (quantity_tolerance BETWEEN 0 AND 100), -- percent over the quantity received
+    price_tolerance NUMERIC(6, 3) NOT NULL DEFAULT 0 CHECK (price_tolerance BETWEEN 0 AND 100), -- percent off the PO unit price
+    updated_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_three_way_match_tolerances_company ON three_way_match_tolerances(company_id)
+    WHERE company_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_three_way_match_tolerances_default ON three_way_match_tolerances((company_id IS NULL))
+    WHERE company_id IS NULL;
+
+INSERT INTO three_way_match_tolerances (company_id, quantity_tolerance, price_tolerance, updated_by)
+SELECT NULL, 0, 0, 'system'
+WHERE NOT EXISTS (SELECT 1 FROM three_way_match_tolerances WHERE company_id IS NULL);
+
+-- The supplier invoice of a voucher: the PO it bills and the payable it is paid through
+ALTER TABLE purchase_vouchers
+ADD COLUMN IF NOT EXISTS company_id UUID REFERENCES companies(id),
+ADD COLUMN IF NOT EXISTS purchase_order_id UUID REFERENCES procurement_purchase_orders(id),
+ADD COLUMN IF NOT EXISTS accounts_payable_id UUID REFERENCES accounts_payable(id),
+ADD COLUMN IF NOT EXISTS supplier_invoice_number VARCHAR(100) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS match_status VARCHAR(20) NOT NULL DEFAULT 'UNMATCHED'
+    CHECK (match_status IN ('UNMATCHED', 'MATCHED', 'EXCEPTION', 'RESOLVED')),
+ADD COLUMN IF NOT EXISTS matched_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_purchase_vouchers_purchase_order ON purchase_vouchers(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_vouchers_accounts_payable ON purchase_vouchers(accounts_payable_id);
+
+-- Invoiced lines, one per PO item; partial invoices bill part of the quantity received
+CREATE TABLE IF NOT EXISTS purchase_voucher_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_voucher_id UUID NOT NULL REFERENCES purchase_vouchers(id) ON DELETE CASCADE,
+    po_item_id UUID NOT NULL REFERENCES procurement_purchase_order_items(id),
+    item_name VARCHAR(255) NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(15, 2) NOT NULL CHECK (unit_price >= 0),
+    line_total NUMERIC(15, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (purchase_voucher_id, po_item_id)
+);
+CREATE INDEX IF NOT EXISTS idx_purchase_voucher_items_po_item ON purchase_voucher_items(po_item_id);
+
+CREATE TABLE IF NOT EXISTS three_way_match_exceptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_voucher_id UUID NOT NULL REFERENCES purchase_vouchers(id) ON DELETE CASCADE,
+    voucher_item_id UUID NOT NULL REFERENCES purchase_voucher_items(id) ON DELETE CASCADE,
+    po_item_id UUID NOT NULL REFERENCES procurement_purchase_order_items(id),
+    exception_type VARCHAR(20) NOT NULL CHECK (exception_type IN ('QUANTITY', 'PRICE')),
+    expected_value NUMERIC(15, 2) NOT NULL, -- quantity left to invoice, or the PO unit price
+    actual_value NUMERIC(15, 2) NOT NULL, -- quantity or unit price invoiced
+    tolerance NUMERIC(6, 3) NOT NULL,
+    variance_amount NUMERIC(15, 2) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'RESOLVED')),
+    resolution_notes TEXT NOT NULL DEFAULT '',
+    resolved_by VARCHAR(100),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_three_way_match_exceptions_voucher ON three_way_match_exceptions(purchase_voucher_id, status);
+CREATE INDEX IF NOT EXISTS idx_three_way_match_exceptions_open ON three_way_match_exceptions(created_at) WHERE status = 'OPEN';
+
+-- Permissions
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'finance.three-way-match.read', 'finance', 'three-way-match', 'read', 'View invoice matches, match exceptions and the GR/IR report'),
+    (gen_random_uuid(), 'finance.three-way-match.create', 'finance', 'three-way-match', 'create', 'Match supplier invoices against purchase orders and goods receipts'),
+    (gen_random_uuid(), 'finance.three-way-match.resolve', 'finance', 'three-way-match', 'resolve', 'Resolve three-way match exceptions'),
+    (gen_random_uuid(), 'finance.three-way-match.manage', 'finance', 'three-way-match', 'manage', 'Manage three-way match tolerances')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Finance Manager', 'Manager', 'Director', 'Admin') AND p.code IN ('finance.three-way-match.read',
+    'finance.three-way-match.create', 'finance.three-way-match.resolve', 'finance.three-way-match.manage')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Finance Staff' AND p.code IN ('finance.three-way-match.read', 'finance.three-way-match.create')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('finance.three-way-match.read',
+    'finance.three-way-match.create', 'finance.three-way-match.resolve', 'finance.three-way-match.manage'));
+DELETE FROM permissions WHERE code IN ('finance.three-way-match.read', 'finance.three-way-match.create',
+    'finance.three-way-match.resolve', 'finance.three-way-match.manage');
+
+DROP TABLE IF EXISTS three_way_match_exceptions;
+DROP TABLE IF EXISTS purchase_voucher_items;
+
+DROP INDEX IF EXISTS idx_purchase_vouchers_accounts_payable;
+DROP INDEX IF EXISTS idx_purchase_vouchers_purchase_order;
+ALTER TABLE purchase_vouchers
+DROP COLUMN IF EXISTS matched_at,
+DROP COLUMN IF EXISTS match_status,
+DROP COLUMN IF EXISTS supplier_invoice_number,
+DROP COLUMN IF EXISTS accounts_payable_id,
+DROP COLUMN IF EXISTS purchase_order_id,
+DROP COLUMN IF EXISTS company_id;
+
+DROP TABLE IF EXISTS three_way_match_tolerances;
@@ -0,0 +1,75 @@
+-- +goose Up
+-- Migration: contract line items and call-offs
+-- Contract items carry the agreed unit price and optional quantity and value caps. Purchase
+-- orders raised against a framework contract record a call-off line per contract item, and
+-- the lines of orders that are not cancelled count towards the caps and the contract value.
+
+ALTER TABLE contracts
+    ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS contract_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    contract_id UUID NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    item_name VARCHAR(255) NOT NULL,
+    description TEXT,
+    unit VARCHAR(50) NOT NULL DEFAULT 'pcs',
+    unit_price DECIMAL(20, 2) NOT NULL CHECK (unit_price >= 0),
+    quantity_cap INTEGER CHECK (quantity_cap > 0),
+    value_cap DECIMAL(20, 2) CHECK (value_cap > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE procurement_purchase_orders
+    ADD COLUMN IF NOT EXISTS contract_id UUID REFERENCES contracts(id) ON DELETE RESTRICT;
+
+ALTER TABLE procurement_purchase_order_items
+    ADD COLUMN IF NOT EXISTS contract_item_id UUID REFERENCES contract_items(id) ON DELETE RESTRICT;
+
+CREATE TABLE IF NOT EXISTS contract_call_off_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    contract_id UUID NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    contract_item_id UUID NOT NULL REFERENCES contract_items(id) ON DELETE RESTRICT,
+    purchase_order_id UUID NOT NULL REFERENCES procurement_purchase_orders(id) ON DELETE CASCADE,
+    po_item_id UUID NOT NULL REFERENCES procurement_purchase_order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(20, 2) NOT NULL,
+    amount DECIMAL(20, 2) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(po_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_items_contract_id ON contract_items(contract_id);
+CREATE INDEX IF NOT EXISTS idx_contracts_owner_id ON contracts(owner_id);
+CREATE INDEX IF NOT EXISTS idx_procurement_purchase_orders_contract_id ON procurement_purchase_orders(contract_id);
+CREATE INDEX IF NOT EXISTS idx_contract_call_off_lines_contract_id ON contract_call_off_lines(contract_id);
+CREATE INDEX IF NOT EXISTS idx_contract_call_off_lines_contract_item_id ON contract_call_off_lines(contract_item_id);
+CREATE INDEX IF NOT EXISTS idx_contract_call_off_lines_purchase_order_id ON contract_call_off_lines(purchase_order_id);
+
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'procurement.contract.call-off', 'procurement', 'contract', 'call-off', 'Raise purchase orders as call-offs against a framework contract')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Procurement Manager', 'Procurement Staff', 'Director', 'Admin')
+    AND p.code = 'procurement.contract.call-off'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'procurement.contract.call-off');
+DELETE FROM permissions WHERE code = 'procurement.contract.call-off';
+
+DROP INDEX IF EXISTS idx_procurement_purchase_orders_contract_id;
+DROP INDEX IF EXISTS idx_contracts_owner_id;
+
+DROP TABLE IF EXISTS contract_call_off_lines;
+ALTER TABLE procurement_purchase_order_items DROP COLUMN IF EXISTS contract_item_id;
+ALTER TABLE procurement_purchase_orders DROP COLUMN IF EXISTS contract_id;
+DROP TABLE IF EXISTS contract_items;
+ALTER TABLE contracts
+    DROP COLUMN IF EXISTS expiry_notified_at,
+    DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,79 @@
+-- +goose Up
+-- Migration: automated vendor scoring
+-- Vendor evaluations can be generated per period from the supplier's deliveries, incoming
+-- QC results, returns, price variance against contract and RFQ prices and invoice matches.
+-- Generated evaluations start as drafts for review, carry the KPIs they were scored from
+-- and the weights in force when they were generated.
+
+-- Weights of the scoring criteria; a single row, seeded with the standard weights
+CREATE TABLE IF NOT EXISTS vendor_scoring_weights (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    quality_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.25 CHECK (quality_weight BETWEEN 0 AND 1),
+    delivery_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.25 CHECK (delivery_weight BETWEEN 0 AND 1),
+    price_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.20 CHECK (price_weight BETWEEN 0 AND 1),
+    service_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.15 CHECK (service_weight BETWEEN 0 AND 1),
+    compliance_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.15 CHECK (compliance_weight BETWEEN 0 AND 1),
+    updated_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vendor_scoring_weights_single ON vendor_scoring_weights((TRUE));
+
+INSERT INTO vendor_scoring_weights (updated_by)
+SELECT 'system'
+WHERE NOT EXISTS (SELECT 1 FROM vendor_scoring_weights);
+
+-- Generated evaluations have no evaluator until someone reviews them
+ALTER TABLE vendor_evaluations
+    ALTER COLUMN evaluator_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS generated BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS kpis JSONB,
+    ADD COLUMN IF NOT EXISTS quality_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.25,
+    ADD COLUMN IF NOT EXISTS delivery_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.25,
+    ADD COLUMN IF NOT EXISTS price_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.20,
+    ADD COLUMN IF NOT EXISTS service_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.15,
+    ADD COLUMN IF NOT EXISTS compliance_weight NUMERIC(5, 4) NOT NULL DEFAULT 0.15;
+
+-- One generated evaluation per supplier and period
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vendor_evaluations_generated_period
+    ON vendor_evaluations(supplier_id, evaluation_period_start, evaluation_period_end)
+    WHERE generated;
+CREATE INDEX IF NOT EXISTS idx_vendor_evaluations_supplier_period
+    ON vendor_evaluations(supplier_id, evaluation_period_start);
+
+-- Incoming QC is looked up by the purchase order it inspected
+CREATE INDEX IF NOT EXISTS idx_quality_controls_reference
+    ON quality_controls(reference_type, reference_id);
+
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'procurement.vendor-evaluation.generate', 'procurement', 'vendor-evaluation', 'generate', 'Generate draft vendor evaluations from supplier KPIs'),
+    (gen_random_uuid(), 'procurement.vendor-evaluation.configure', 'procurement', 'vendor-evaluation', 'configure', 'Configure vendor scoring weights')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Procurement Manager', 'Director', 'Admin')
+    AND p.code IN ('procurement.vendor-evaluation.generate', 'procurement.vendor-evaluation.configure')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code IN ('procurement.vendor-evaluation.generate', 'procurement.vendor-evaluation.configure'));
+DELETE FROM permissions WHERE code IN ('procurement.vendor-evaluation.generate', 'procurement.vendor-evaluation.configure');
+
+DROP INDEX IF EXISTS idx_quality_controls_reference;
+DROP INDEX IF EXISTS idx_vendor_evaluations_supplier_period;
+DROP INDEX IF EXISTS idx_vendor_evaluations_generated_period;
+
+DELETE FROM vendor_evaluations WHERE evaluator_id IS NULL;
+ALTER TABLE vendor_evaluations
+    DROP COLUMN IF EXISTS compliance_weight,
+    DROP COLUMN IF EXISTS service_weight,
+    DROP COLUMN IF EXISTS price_weight,
+    DROP COLUMN IF EXISTS delivery_weight,
+    DROP COLUMN IF EXISTS quality_weight,
+    DROP COLUMN IF EXISTS kpis,
+    DROP COLUMN IF EXISTS generated,
+    ALTER COLUMN evaluator_id SET NOT NULL;
+
+DROP TABLE IF EXISTS vendor_scoring_weights;
@@ -0,0 +1,166 @@
+-- +goose Up
+-- Migration: supplier portal
+-- Supplier portal accounts are users with the Supplier role, each scoped to one supplier.
+-- Suppliers acknowledge and confirm their purchase orders, send advance shipping notices
+-- that pre-fill goods receipts, answer RFQs and submit invoices for Finance to book.
+
+INSERT INTO roles (id, name, description, level, is_system, is_active)
+VALUES (gen_random_uuid(), 'Supplier', 'External supplier with access to the supplier portal only', 0, TRUE, TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+-- The supplier a portal user acts for; a user belongs to at most one supplier
+CREATE TABLE IF NOT EXISTS supplier_portal_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    supplier_id UUID NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'disabled')),
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_supplier_portal_accounts_supplier ON supplier_portal_accounts(supplier_id);
+
+-- Supplier acknowledgements of the purchase orders sent to them
+CREATE TABLE IF NOT EXISTS supplier_po_acknowledgements (
+    purchase_order_id UUID PRIMARY KEY REFERENCES procurement_purchase_orders(id) ON DELETE CASCADE,
+    supplier_id UUID NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+    acknowledged_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    acknowledged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+-- Advance shipping notices; receiving one creates a draft goods receipt from its lines
+CREATE TABLE IF NOT EXISTS advance_shipping_notices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asn_number VARCHAR(50) NOT NULL UNIQUE,
+    supplier_id UUID NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+    purchase_order_id UUID NOT NULL REFERENCES procurement_purchase_orders(id) ON DELETE CASCADE,
+    ship_date DATE NOT NULL,
+    expected_arrival DATE,
+    carrier VARCHAR(100) NOT NULL DEFAULT '',
+    tracking_number VARCHAR(100) NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'submitted' CHECK (status IN ('submitted', 'received', 'cancelled')),
+    goods_receipt_id UUID REFERENCES goods_receipts(id) ON DELETE SET NULL,
+    submitted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_advance_shipping_notices_supplier ON advance_shipping_notices(supplier_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_advance_shipping_notices_po ON advance_shipping_notices(purchase_order_id);
+
+CREATE TABLE IF NOT EXISTS advance_shipping_notice_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asn_id UUID NOT NULL REFERENCES advance_shipping_notices(id) ON DELETE CASCADE,
+    po_item_id UUID NOT NULL REFERENCES procurement_purchase_order_items(id),
+    item_name VARCHAR(255) NOT NULL DEFAULT '',
+    unit VARCHAR(20) NOT NULL DEFAULT '',
+    quantity INT NOT NULL CHECK (quantity > 0),
+    UNIQUE (asn_id, po_item_id)
+);
+
+-- Invoices submitted by suppliers; registering one books it as a matched purchase voucher
+CREATE TABLE IF NOT EXISTS supplier_invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    supplier_id UUID NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+    purchase_order_id UUID NOT NULL REFERENCES procurement_purchase_orders(id),
+    invoice_number VARCHAR(100) NOT NULL,
+    invoice_date DATE NOT NULL,
+    due_date DATE,
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    subtotal DECIMAL(18, 2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(18, 2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(18, 2) NOT NULL DEFAULT 0,
+    attachment_key VARCHAR(500) NOT NULL DEFAULT '',
+    attachment_name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'submitted' CHECK (status IN ('submitted', 'registered', 'rejected')),
+    purchase_voucher_id UUID REFERENCES purchase_vouchers(id) ON DELETE SET NULL,
+    rejection_reason TEXT NOT NULL DEFAULT '',
+    submitted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (supplier_id, invoice_number)
+);
+CREATE INDEX IF NOT EXISTS idx_supplier_invoices_status ON supplier_invoices(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_supplier_invoices_po ON supplier_invoices(purchase_order_id);
+
+CREATE TABLE IF NOT EXISTS supplier_invoice_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    supplier_invoice_id UUID NOT NULL REFERENCES supplier_invoices(id) ON DELETE CASCADE,
+    po_item_id UUID NOT NULL REFERENCES procurement_purchase_order_items(id),
+    item_name VARCHAR(255) NOT NULL DEFAULT '',
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(18, 2) NOT NULL CHECK (unit_price >= 0),
+    line_total DECIMAL(18, 2) NOT NULL,
+    UNIQUE (supplier_invoice_id, po_item_id)
+);
+
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'supplier-portal.purchase-order.list', 'supplier-portal', 'purchase-order', 'list', 'List own purchase orders in the supplier portal'),
+    (gen_random_uuid(), 'supplier-portal.purchase-order.read', 'supplier-portal', 'purchase-order', 'read', 'View own purchase orders in the supplier portal'),
+    (gen_random_uuid(), 'supplier-portal.purchase-order.confirm', 'supplier-portal', 'purchase-order', 'confirm', 'Acknowledge and confirm own purchase orders'),
+    (gen_random_uuid(), 'supplier-portal.shipping-notice.list', 'supplier-portal', 'shipping-notice', 'list', 'List own advance shipping notices'),
+    (gen_random_uuid(), 'supplier-portal.shipping-notice.create', 'supplier-portal', 'shipping-notice', 'create', 'Submit advance shipping notices'),
+    (gen_random_uuid(), 'supplier-portal.rfq.list', 'supplier-portal', 'rfq', 'list', 'List RFQs the supplier is invited to'),
+    (gen_random_uuid(), 'supplier-portal.rfq.respond', 'supplier-portal', 'rfq', 'respond', 'Answer RFQs the supplier is invited to'),
+    (gen_random_uuid(), 'supplier-portal.invoice.list', 'supplier-portal', 'invoice', 'list', 'List own invoices and their payment status'),
+    (gen_random_uuid(), 'supplier-portal.invoice.create', 'supplier-portal', 'invoice', 'create', 'Submit and upload invoices'),
+    (gen_random_uuid(), 'procurement.supplier-account.manage', 'procurement', 'supplier-account', 'manage', 'Create and disable supplier portal accounts'),
+    (gen_random_uuid(), 'procurement.shipping-notice.receive', 'procurement', 'shipping-notice', 'receive', 'Create goods receipts from advance shipping notices'),
+    (gen_random_uuid(), 'procurement.supplier-invoice.review', 'procurement', 'supplier-invoice', 'review', 'Register or reject invoices submitted by suppliers')
+ON CONFLICT (code) DO NOTHING;
+
+-- Suppliers only get the portal, their notifications and their own profile
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Supplier'
+    AND (p.code LIKE 'supplier-portal.%'
+        OR p.code IN ('notifications.notification.list', 'notifications.notification.read', 'notifications.notification.update',
+                      'profile.profile.read', 'profile.profile.update'))
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Procurement Manager', 'Director', 'Admin')
+    AND p.code = 'procurement.supplier-account.manage'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Procurement Manager', 'Procurement Staff', 'Inventory Manager', 'Inventory Staff', 'Director', 'Admin')
+    AND p.code = 'procurement.shipping-notice.receive'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Finance Manager', 'Finance Staff', 'Procurement Manager', 'Director', 'Admin')
+    AND p.code = 'procurement.supplier-invoice.review'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE role_id IN (SELECT id FROM roles WHERE name = 'Supplier');
+DELETE FROM role_permissions WHERE permission_id IN (
+    SELECT id FROM permissions
+    WHERE code LIKE 'supplier-portal.%'
+        OR code IN ('procurement.supplier-account.manage', 'procurement.shipping-notice.receive', 'procurement.supplier-invoice.review')
+);
+DELETE FROM permissions
+WHERE code LIKE 'supplier-portal.%'
+    OR code IN ('procurement.supplier-account.manage', 'procurement.shipping-notice.receive', 'procurement.supplier-invoice.review');
+
+DROP TABLE IF EXISTS supplier_invoice_lines;
+DROP TABLE IF EXISTS supplier_invoices;
+DROP TABLE IF EXISTS advance_shipping_notice_items;
+DROP TABLE IF EXISTS advance_shipping_notices;
+DROP TABLE IF EXISTS supplier_po_acknowledgements;
+DROP TABLE IF EXISTS supplier_portal_accounts;
+
+UPDATE users SET status = 'inactive' WHERE role = 'supplier';
+DELETE FROM user_roles WHERE role_id IN (SELECT id FROM roles WHERE name = 'Supplier');
+DELETE FROM roles WHERE name = 'Supplier';
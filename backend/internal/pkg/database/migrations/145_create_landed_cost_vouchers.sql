@@ -0,0 +1,129 @@
+-- +goose Up
+-- Migration: landed cost vouchers
+-- Every posted goods receipt line becomes an inventory cost layer at its purchase price.
+-- Landed cost vouchers attach freight, insurance, import duty and clearance charges to
+-- one or more posted receipts and allocate them over the layers by value, quantity,
+-- weight or volume. The share of goods already gone from stock is expensed to COGS.
+
+CREATE TABLE IF NOT EXISTS inventory_cost_layers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    goods_receipt_id UUID NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    goods_receipt_item_id UUID NOT NULL UNIQUE REFERENCES goods_receipt_items(id) ON DELETE CASCADE,
+    article_id UUID REFERENCES articles(id) ON DELETE SET NULL,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    item_name VARCHAR(255) NOT NULL DEFAULT '',
+    received_quantity INT NOT NULL CHECK (received_quantity > 0),
+    unit_cost NUMERIC(18, 2) NOT NULL DEFAULT 0, -- purchase price
+    landed_cost NUMERIC(18, 2) NOT NULL DEFAULT 0, -- charges allocated to the whole layer
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_cost_layers_receipt ON inventory_cost_layers(goods_receipt_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_cost_layers_stock ON inventory_cost_layers(article_id, warehouse_id, received_at);
+
+-- Layers of the receipts posted so far
+INSERT INTO inventory_cost_layers (goods_receipt_id, goods_receipt_item_id, article_id, warehouse_id, item_name,
+                                   received_quantity, unit_cost, received_at)
+SELECT gr.id, gri.id, gri.article_id, gr.warehouse_id, COALESCE(gri.item_name, ''),
+       gri.quantity, COALESCE(gri.unit_price, 0), COALESCE(gr.posted_at, gr.receipt_date, gr.created_at)
+FROM goods_receipt_items gri
+JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id
+WHERE gr.status = 'POSTED' AND gr.warehouse_id IS NOT NULL AND gri.quantity > 0
+ON CONFLICT (goods_receipt_item_id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS landed_cost_vouchers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    voucher_number VARCHAR(50) NOT NULL UNIQUE,
+    voucher_date DATE NOT NULL,
+    allocation_method VARCHAR(20) NOT NULL CHECK (allocation_method IN ('value', 'quantity', 'weight', 'volume')),
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    total_amount NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    inventory_amount NUMERIC(18, 2) NOT NULL DEFAULT 0, -- capitalised on goods still on hand
+    cogs_amount NUMERIC(18, 2) NOT NULL DEFAULT 0, -- expensed for goods already gone
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'posted', 'cancelled')),
+    notes TEXT NOT NULL DEFAULT '',
+    journal_entry_id UUID,
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    posted_by VARCHAR(100),
+    posted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_landed_cost_vouchers_status ON landed_cost_vouchers(status, voucher_date);
+
+-- The charge invoices a voucher carries
+CREATE TABLE IF NOT EXISTS landed_cost_charges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    voucher_id UUID NOT NULL REFERENCES landed_cost_vouchers(id) ON DELETE CASCADE,
+    charge_type VARCHAR(20) NOT NULL CHECK (charge_type IN ('freight', 'insurance', 'duty', 'clearance', 'other')),
+    supplier_id UUID REFERENCES suppliers(id) ON DELETE SET NULL,
+    invoice_number VARCHAR(100) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    amount NUMERIC(18, 2) NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_landed_cost_charges_voucher ON landed_cost_charges(voucher_id);
+
+CREATE TABLE IF NOT EXISTS landed_cost_voucher_receipts (
+    voucher_id UUID NOT NULL REFERENCES landed_cost_vouchers(id) ON DELETE CASCADE,
+    goods_receipt_id UUID NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    PRIMARY KEY (voucher_id, goods_receipt_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_landed_cost_voucher_receipts_receipt ON landed_cost_voucher_receipts(goods_receipt_id);
+
+-- What each cost layer receives; the on-hand split is fixed when the voucher is posted
+CREATE TABLE IF NOT EXISTS landed_cost_allocations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    voucher_id UUID NOT NULL REFERENCES landed_cost_vouchers(id) ON DELETE CASCADE,
+    cost_layer_id UUID NOT NULL REFERENCES inventory_cost_layers(id) ON DELETE CASCADE,
+    goods_receipt_id UUID NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    item_name VARCHAR(255) NOT NULL DEFAULT '',
+    quantity INT NOT NULL,
+    line_value NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    weight NUMERIC(14, 3) NOT NULL DEFAULT 0,
+    volume NUMERIC(14, 3) NOT NULL DEFAULT 0,
+    allocated_amount NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    on_hand_quantity INT NOT NULL DEFAULT 0,
+    inventory_amount NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    cogs_amount NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    UNIQUE (voucher_id, cost_layer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_landed_cost_allocations_layer ON landed_cost_allocations(cost_layer_id);
+
+INSERT INTO permissions (id, code, module, resource, action, description) VALUES
+    (gen_random_uuid(), 'inventory.landed-cost.list', 'inventory', 'landed-cost', 'list', 'List landed cost vouchers'),
+    (gen_random_uuid(), 'inventory.landed-cost.read', 'inventory', 'landed-cost', 'read', 'View landed cost vouchers, cost layers and valuation'),
+    (gen_random_uuid(), 'inventory.landed-cost.create', 'inventory', 'landed-cost', 'create', 'Create landed cost vouchers'),
+    (gen_random_uuid(), 'inventory.landed-cost.update', 'inventory', 'landed-cost', 'update', 'Edit and cancel draft landed cost vouchers'),
+    (gen_random_uuid(), 'inventory.landed-cost.delete', 'inventory', 'landed-cost', 'delete', 'Delete draft landed cost vouchers'),
+    (gen_random_uuid(), 'inventory.landed-cost.post', 'inventory', 'landed-cost', 'post', 'Post landed cost vouchers to cost layers and the ledger')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Inventory Manager', 'Finance Manager', 'Director', 'Admin')
+    AND p.code LIKE 'inventory.landed-cost.%'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT gen_random_uuid(), r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('Inventory Staff', 'Finance Staff', 'Procurement Manager')
+    AND p.code IN ('inventory.landed-cost.list', 'inventory.landed-cost.read', 'inventory.landed-cost.create', 'inventory.landed-cost.update')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE code LIKE 'inventory.landed-cost.%');
+DELETE FROM permissions WHERE code LIKE 'inventory.landed-cost.%';
+
+DROP TABLE IF EXISTS landed_cost_allocations;
+DROP TABLE IF EXISTS landed_cost_voucher_receipts;
+DROP TABLE IF EXISTS landed_cost_charges;
+DROP TABLE IF EXISTS landed_cost_vouchers;
+DROP TABLE IF EXISTS inventory_cost_layers;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Time zone of the store a POS terminal belongs to. Happy-hour promotions are checked
+-- against the store's local clock, so stores in WITA and WIT are not an hour or two off.
+
+ALTER TABLE pos_terminals ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta';
+
+-- +goose Down
+ALTER TABLE pos_terminals DROP COLUMN IF EXISTS timezone;
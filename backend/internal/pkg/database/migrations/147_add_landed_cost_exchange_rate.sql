@@ -0,0 +1,10 @@
+-- +goose Up
+-- Exchange rate of a landed cost voucher to IDR. Charges invoiced in a foreign currency
+-- are converted at this rate before they are allocated to the IDR cost layers and
+-- journalled.
+
+ALTER TABLE landed_cost_vouchers ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18, 6) NOT NULL DEFAULT 1
+    CHECK (exchange_rate > 0);
+
+-- +goose Down
+ALTER TABLE landed_cost_vouchers DROP COLUMN IF EXISTS exchange_rate;
@@ -3,6 +3,7 @@ package container
 import (
 	"context"
 	"database/sql"
+	"os"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,6 +14,7 @@ import (
 	"malaka/internal/modules/shipping/domain"
 	"malaka/internal/modules/shipping/domain/services"
 	"malaka/internal/modules/shipping/infrastructure/persistence"
+	"malaka/internal/shared/audit"
 	"malaka/internal/shared/auth"
 	"malaka/internal/shared/cache"
 	analytics_services "malaka/internal/modules/analytics/domain/services"
@@ -34,6 +36,7 @@ import (
 
 	// Sales imports
 	sales_services "malaka/internal/modules/sales/domain/services"
+	sales_external "malaka/internal/modules/sales/infrastructure/external"
 	sales_persistence "malaka/internal/modules/sales/infrastructure/persistence"
 
 	// Finance imports
@@ -43,6 +46,11 @@ import (
 	// HR imports
 	hr_services "malaka/internal/modules/hr/domain/services"
 	hr_persistence "malaka/internal/modules/hr/infrastructure/persistence"
+	hr_app "malaka/internal/modules/hr/application"
+
+	// Approval imports
+	approval_services "malaka/internal/modules/approval/domain/services"
+	approval_persistence "malaka/internal/modules/approval/infrastructure/persistence"
 
 	// Calendar imports
 	calendar_services "malaka/internal/modules/calendar/domain/services"
@@ -152,6 +160,7 @@ type Container struct {
 	GoodsIssueService         inventory_services.GoodsIssueService
 	InventoryValuationService *inventory_services.InventoryValuationService
 	RFQService                *inventory_services.RFQService
+	LandedCostService         *inventory_services.LandedCostService
 
 	// Shipping services
 	CourierService         *shipping_services.CourierService
@@ -162,17 +171,25 @@ type Container struct {
 	ShippingInvoiceService domain.ShippingInvoiceService
 
 	// Sales services
-	SalesOrderService        *sales_services.SalesOrderService
-	SalesInvoiceService      sales_services.SalesInvoiceService
-	PosTransactionService    *sales_services.PosTransactionService
-	OnlineOrderService       *sales_services.OnlineOrderService
-	ConsignmentSalesService  *sales_services.ConsignmentSalesService
-	SalesReturnService       *sales_services.SalesReturnService
-	PromotionService         *sales_services.PromotionService
-	SalesTargetService       *sales_services.SalesTargetService
-	SalesKompetitorService   sales_services.SalesKompetitorService
-	ProsesMarginService      sales_services.ProsesMarginService
-	SalesRekonsiliasiService sales_services.SalesRekonsiliasiService
+	SalesOrderService          *sales_services.SalesOrderService
+	SalesInvoiceService        sales_services.SalesInvoiceService
+	PosTransactionService      *sales_services.PosTransactionService
+	OnlineOrderService         *sales_services.OnlineOrderService
+	ConsignmentSalesService    *sales_services.ConsignmentSalesService
+	SalesReturnService         *sales_services.SalesReturnService
+	PromotionService           *sales_services.PromotionService
+	LoyaltyService             *sales_services.LoyaltyService
+	PosShiftService            *sales_services.PosShiftService
+	PosSyncService             *sales_services.PosSyncService
+	SalesQuotationService      *sales_services.SalesQuotationService
+	OrderToCashService         *sales_services.OrderToCashService
+	ConsignmentService         *sales_services.ConsignmentService
+	MarketplaceService         *sales_services.MarketplaceService
+	SalesReconciliationService *sales_services.SalesReconciliationService
+	SalesTargetService         *sales_services.SalesTargetService
+	SalesKompetitorService     sales_services.SalesKompetitorService
+	ProsesMarginService        sales_services.ProsesMarginService
+	SalesRekonsiliasiService   sales_services.SalesRekonsiliasiService
 
 	// Finance services
 	CashBankService           *finance_services.CashBankService
@@ -194,6 +211,8 @@ type Container struct {
 	LoanFacilityService       *finance_services.LoanFacilityService
 	FinancialForecastService  *finance_services.FinancialForecastService
 	FinanceReportService      *finance_services.FinanceReportService
+	WithholdingTaxService     *finance_services.WithholdingTaxService
+	ThreeWayMatchService      *finance_services.ThreeWayMatchService
 
 	// HR services
 	EmployeeService          *hr_services.EmployeeService
@@ -203,7 +222,11 @@ type Container struct {
 	TrainingService          hr_services.TrainingService
 
 	// Calendar services
-	EventService calendar_services.EventService
+	EventService   calendar_services.EventService
+	HolidayService calendar_services.HolidayService
+
+	// Approval services
+	ApprovalService approval_services.ApprovalService
 
 	// Settings services
 	SettingService *settings_services.SettingService
@@ -224,6 +247,8 @@ type Container struct {
 	FinancialPeriodService     accounting_services.FinancialPeriodService
 	FixedAssetService          accounting_services.FixedAssetService
 	TaxService                 *accounting_services.TaxService
+	TaxInvoiceService          *accounting_services.TaxInvoiceService
+	TaxDeterminationService    *accounting_services.TaxDeterminationService
 	// TrialBalanceService     accounting_services.TrialBalanceService
 
 	// Procurement services
@@ -233,6 +258,7 @@ type Container struct {
 	VendorEvaluationService         *procurement_services.VendorEvaluationService
 	ProcurementAnalyticsService     *procurement_services.AnalyticsService
 	ProcurementRFQService           *procurement_services.RFQService
+	SupplierPortalService           *procurement_services.SupplierPortalService
 
 	// Notification services
 	NotificationService *notifications_services.NotificationService
@@ -258,6 +284,11 @@ type Container struct {
 	// RBAC services
 	RBACService *auth.RBACService
 
+	// Login sessions and token revocation
+	SessionService  *auth.SessionService
+	MFAService      *auth.MFAService
+	PasswordService *auth.PasswordService
+
 	// Analytics services
 	AnalyticsQueryService *analytics_services.AnalyticsQueryService
 
@@ -336,6 +367,7 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	// Initialize inventory repositories
 	purchaseOrderRepo := inventory_persistence.NewPurchaseOrderRepositoryImpl(sqlxDB)
 	goodsReceiptRepo := inventory_persistence.NewGoodsReceiptRepositoryImpl(sqlxDB)
+	landedCostRepo := inventory_persistence.NewLandedCostRepositoryImpl(sqlxDB)
 	stockMovementRepo := inventory_persistence.NewStockMovementRepositoryImpl(sqlxDB)
 	stockBalanceRepo := inventory_persistence.NewStockBalanceRepositoryImpl(sqlxDB)
 	transferOrderRepo := inventory_persistence.NewTransferOrderRepositoryImpl(sqlxDB)
@@ -414,6 +446,9 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	goodsIssueService := inventory_services.NewGoodsIssueService(goodsIssueRepo)
 	inventoryValuationService := inventory_services.NewInventoryValuationService(stockMovementRepo)
 	rfqService := inventory_services.NewRFQService(rfqRepo)
+	landedCostService := inventory_services.NewLandedCostService(landedCostRepo, goodsReceiptRepo)
+	goodsReceiptService.SetCostLayerRepository(landedCostRepo)
+	inventoryValuationService.SetCostLayerRepository(landedCostRepo)
 
 	// Initialize shipping services
 	courierService := shipping_services.NewCourierService(courierRepo)
@@ -434,6 +469,15 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	consignmentSalesRepo := sales_persistence.NewConsignmentSalesRepositoryImpl(sqlxDB)
 	salesReturnRepo := sales_persistence.NewSalesReturnRepositoryImpl(sqlxDB)
 	promotionRepo := sales_persistence.NewPromotionRepositoryImpl(sqlxDB)
+	loyaltyRepo := sales_persistence.NewLoyaltyRepositoryImpl(sqlxDB)
+	posTerminalRepo := sales_persistence.NewPosTerminalRepositoryImpl(sqlxDB)
+	posShiftRepo := sales_persistence.NewPosShiftRepositoryImpl(sqlxDB)
+	posSyncRepo := sales_persistence.NewPosSyncRepositoryImpl(sqlxDB)
+	salesQuotationRepo := sales_persistence.NewSalesQuotationRepositoryImpl(sqlxDB)
+	orderToCashRepo := sales_persistence.NewOrderToCashRepositoryImpl(sqlxDB)
+	consignmentRepo := sales_persistence.NewConsignmentRepositoryImpl(sqlxDB)
+	marketplaceRepo := sales_persistence.NewMarketplaceRepositoryImpl(sqlxDB)
+	salesReconciliationRepo := sales_persistence.NewSalesReconciliationRepositoryImpl(sqlxDB)
 	salesTargetRepo := sales_persistence.NewSalesTargetRepositoryImpl(sqlxDB)
 	salesKompetitorRepo := sales_persistence.NewSalesKompetitorRepositoryImpl(sqlxDB)
 	prosesMarginRepo := sales_persistence.NewProsesMarginRepositoryImpl(sqlxDB)
@@ -442,11 +486,24 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	// Initialize sales services
 	salesOrderService := sales_services.NewSalesOrderService(salesOrderRepo, salesOrderItemRepo, stockService)
 	salesInvoiceService := sales_services.NewSalesInvoiceService(salesInvoiceRepo, salesInvoiceItemRepo)
-	posTransactionService := sales_services.NewPosTransactionService(posTransactionRepo, posItemRepo, stockService)
+	posShiftService := sales_services.NewPosShiftService(posTerminalRepo, posShiftRepo, warehouseService)
+	posTransactionService := sales_services.NewPosTransactionService(posTransactionRepo, posItemRepo, stockService, posShiftService)
+	posSyncService := sales_services.NewPosSyncService(posSyncRepo, posShiftService, posTransactionService, stockService)
 	onlineOrderService := sales_services.NewOnlineOrderService(onlineOrderRepo)
 	consignmentSalesService := sales_services.NewConsignmentSalesService(consignmentSalesRepo)
 	salesReturnService := sales_services.NewSalesReturnService(salesReturnRepo)
-	promotionService := sales_services.NewPromotionService(promotionRepo)
+	promotionService := sales_services.NewPromotionService(promotionRepo, articleService)
+	salesOrderService.SetPromotionService(promotionService)
+	salesQuotationService := sales_services.NewSalesQuotationService(salesQuotationRepo, salesOrderService, priceService, articleService)
+	orderToCashService := sales_services.NewOrderToCashService(orderToCashRepo, salesOrderRepo, stockService)
+	consignmentService := sales_services.NewConsignmentService(consignmentRepo, stockService)
+	marketplaceService := sales_services.NewMarketplaceService(marketplaceRepo, stockService)
+	marketplaceService.RegisterAdapter(sales_external.FileAdapterName, sales_external.NewFileChannelAdapter)
+	salesReconciliationService := sales_services.NewSalesReconciliationService(salesReconciliationRepo, marketplaceRepo)
+	posTransactionService.SetPromotionService(promotionService)
+	loyaltyService := sales_services.NewLoyaltyService(loyaltyRepo, articleService)
+	posTransactionService.SetLoyaltyService(loyaltyService)
+	salesReturnService.SetLoyaltyService(loyaltyService)
 	salesTargetService := sales_services.NewSalesTargetService(salesTargetRepo)
 	salesKompetitorService := sales_services.NewSalesKompetitorService(salesKompetitorRepo)
 	prosesMarginService := sales_services.NewProsesMarginService(prosesMarginRepo)
@@ -472,6 +529,8 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	loanFacilityRepo := finance_persistence.NewLoanFacilityRepositoryImpl(sqlxDB)
 	financialForecastRepo := finance_persistence.NewFinancialForecastRepositoryImpl(sqlxDB)
 	financeReportRepo := finance_persistence.NewFinanceReportRepositoryImpl(sqlxDB)
+	withholdingTaxRepo := finance_persistence.NewWithholdingTaxRepositoryImpl(sqlxDB)
+	threeWayMatchRepo := finance_persistence.NewThreeWayMatchRepositoryImpl(sqlxDB)
 
 	// Initialize accounting repositories
 	journalEntryRepo := accounting_persistence.NewJournalEntryRepository(db)
@@ -510,6 +569,53 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	loanFacilityService := finance_services.NewLoanFacilityService(loanFacilityRepo)
 	financialForecastService := finance_services.NewFinancialForecastService(financialForecastRepo)
 	financeReportService := finance_services.NewFinanceReportService(financeReportRepo)
+	withholdingTaxService := finance_services.NewWithholdingTaxService(withholdingTaxRepo, cfg.CompanyNPWP)
+	threeWayMatchService := finance_services.NewThreeWayMatchService(threeWayMatchRepo)
+	purchaseVoucherService.SetMatchGate(threeWayMatchService)
+	accountsPayableService.SetMatchGate(threeWayMatchService)
+
+	// Initialize event bus for cross-module communication
+	eventBus := events.NewInMemoryEventBus()
+	logger.Info("Event bus initialized for cross-module communication")
+
+	// Initialize RBAC services
+	rbacRepo := auth.NewRBACRepositoryImpl(sqlxDB)
+	var rbacCache auth.RBACCache
+	if redisCache != nil {
+		rbacCache = auth.NewRedisRBACCache(redisCache.(*cache.RedisCache).Client())
+	} else {
+		rbacCache = auth.NewNoOpRBACCache()
+	}
+	rbacService := auth.NewRBACService(sqlxDB, rbacRepo, rbacCache)
+
+	// Initialize login sessions: short-lived access tokens, rotating refresh tokens and
+	// a revocation list shared through Redis when available
+	var revocationList auth.RevocationList
+	if redisCache != nil {
+		revocationList = auth.NewRedisRevocationList(redisCache.(*cache.RedisCache).Client())
+	} else {
+		revocationList = auth.NewMemoryRevocationList()
+	}
+	sessionRepo := auth.NewSessionRepositoryImpl(sqlxDB)
+	sessionService := auth.NewSessionService(sessionRepo, revocationList, cfg.JWTSecret, cfg.GetAccessTokenTTL(), cfg.GetRefreshTokenTTL())
+	sessionService.SetLegacyTokenTTL(time.Duration(cfg.GetJWTExpiryHours()) * time.Hour)
+	userService.SetSessionService(sessionService)
+
+	// Initialize TOTP two-factor authentication; companies can enforce it per role
+	mfaRepo := auth.NewMFARepositoryImpl(sqlxDB)
+	mfaService := auth.NewMFAService(mfaRepo, cfg.EncryptionKey, cfg.JWTSecret, "Malaka ERP")
+	userService.SetMFAService(mfaService)
+
+	// Initialize approval workflow engine (used by procurement, inventory transfers, leave and payroll);
+	// documents without a configured workflow fall back to the RBAC approval policy
+	approvalRepo := approval_persistence.NewApprovalRepository(sqlxDB)
+	approverDirectory := approval_persistence.NewApproverDirectory(sqlxDB)
+	approvalService := approval_services.NewApprovalService(approvalRepo, approverDirectory, rbacService)
+	transferService.SetApprovalEngine(approvalService)
+
+	// Initialize holiday calendar (read by HR leave, attendance and payroll)
+	holidayRepo := calendar_persistence.NewHolidayRepository(sqlxDB)
+	holidayService := calendar_services.NewHolidayService(holidayRepo, eventBus)
 
 	// Initialize HR repositories
 	employeeRepo := hr_persistence.NewPostgreSQLEmployeeRepository(sqlxDB)
@@ -521,8 +627,8 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 
 	// Initialize HR services
 	employeeService := hr_services.NewEmployeeService(employeeRepo)
-	payrollService := hr_services.NewPayrollService(payrollPeriodRepo, salaryCalculationRepo, employeeRepo)
-	leaveService := hr_services.NewLeaveService(leaveRepo)
+	payrollService := hr_services.NewPayrollService(payrollPeriodRepo, salaryCalculationRepo, employeeRepo, holidayService, approvalService)
+	leaveService := hr_services.NewLeaveService(leaveRepo, employeeRepo, holidayService, approvalService)
 	performanceReviewService := hr_services.NewPerformanceReviewService(performanceReviewRepo)
 	trainingService := hr_services.NewTrainingService(trainingRepo, employeeRepo)
 
@@ -530,7 +636,7 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	eventRepo := calendar_persistence.NewEventRepository(sqlxDB)
 
 	// Initialize calendar services
-	eventService := calendar_services.NewEventService(eventRepo)
+	eventService := calendar_services.NewEventService(eventRepo, holidayService)
 
 	// Initialize settings repositories
 	settingRepo := settings_persistence.NewSettingRepositoryImpl(sqlxDB)
@@ -552,6 +658,9 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	generalLedgerService := accounting_services.NewGeneralLedgerServiceImpl(generalLedgerRepo, journalEntryRepo)
 	journalEntryService := accounting_services.NewJournalEntryService(journalEntryRepo, generalLedgerService)
 	autoJournalService := accounting_services.NewAutoJournalService(journalEntryRepo, autoJournalConfigRepo, journalEntryService)
+	posTransactionService.SetJournalPoster(autoJournalService)
+	withholdingTaxService.SetJournalPoster(autoJournalService)
+	landedCostService.SetJournalPoster(autoJournalService)
 	costCenterService := accounting_services.NewCostCenterService(costCenterRepo)
 	chartOfAccountService := accounting_services.NewChartOfAccountService(chartOfAccountRepo)
 	// Initialize budget commitment and realization repositories
@@ -566,6 +675,15 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	// Initialize tax repository and service
 	taxRepo := accounting_persistence.NewTaxRepositoryImpl(sqlxDB)
 	taxService := accounting_services.NewTaxService(taxRepo)
+	taxService.SetJournalPoster(autoJournalService)
+	taxInvoiceRepo := accounting_persistence.NewTaxInvoiceRepositoryImpl(sqlxDB)
+	taxInvoiceService := accounting_services.NewTaxInvoiceService(taxInvoiceRepo, cfg.CompanyNPWP)
+	taxDeterminationRepo := accounting_persistence.NewTaxDeterminationRepositoryImpl(sqlxDB)
+	taxDeterminationService := accounting_services.NewTaxDeterminationService(taxDeterminationRepo)
+	orderToCashService.SetTaxCalculator(taxDeterminationService)
+	salesQuotationService.SetTaxCalculator(taxDeterminationService)
+	consignmentService.SetTaxCalculator(taxDeterminationService)
+	posTransactionService.SetTaxCalculator(taxDeterminationService)
 
 	// Initialize exchange rate service
 	var exchangeRateService *accounting_services.ExchangeRateService
@@ -574,10 +692,6 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	}
 	// trialBalanceService := accounting_services.NewTrialBalanceServiceImpl(trialBalanceRepo, generalLedgerRepo)
 
-	// Initialize event bus for cross-module communication
-	eventBus := events.NewInMemoryEventBus()
-	logger.Info("Event bus initialized for cross-module communication")
-
 	// Initialize budget integration service
 	budgetIntegrationService := accounting_infra_services.NewBudgetIntegrationService(sqlxDB)
 	logger.Info("Budget integration service initialized")
@@ -589,27 +703,29 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	vendorEvaluationRepo := procurement_persistence.NewVendorEvaluationRepositoryImpl(sqlxDB)
 	procurementRFQRepo := procurement_persistence.NewRFQRepository(sqlxDB)
 
-	// Initialize RBAC services
-	rbacRepo := auth.NewRBACRepositoryImpl(sqlxDB)
-	var rbacCache auth.RBACCache
-	if redisCache != nil {
-		rbacCache = auth.NewRedisRBACCache(redisCache.(*cache.RedisCache).Client())
-	} else {
-		rbacCache = auth.NewNoOpRBACCache()
-	}
-	rbacService := auth.NewRBACService(sqlxDB, rbacRepo, rbacCache)
-
 	// Initialize procurement services
 	purchaseRequestService := procurement_services.NewPurchaseRequestService(purchaseRequestRepo)
 	purchaseRequestService.SetPurchaseOrderRepository(procurementPurchaseOrderRepo) // Enable PR to PO conversion
+	purchaseRequestService.SetApprovalEngine(approvalService)
 	procurementPurchaseOrderService := procurement_services.NewPurchaseOrderService(procurementPurchaseOrderRepo, rbacService)
 	// Wire budget integration and event bus to PO service
-	procurementPurchaseOrderService.WithBudgetIntegration(budgetIntegrationService, budgetIntegrationService).WithEventBus(eventBus)
+	procurementPurchaseOrderService.WithBudgetIntegration(budgetIntegrationService, budgetIntegrationService).WithEventBus(eventBus).WithApprovalEngine(approvalService).WithTaxCalculator(taxDeterminationService)
+	orderToCashService.WithEventBus(eventBus)
 	contractService := procurement_services.NewContractService(contractRepo)
+	contractService.SetPurchaseOrderCreator(procurementPurchaseOrderService) // Enable call-offs against framework contracts
 	vendorEvaluationService := procurement_services.NewVendorEvaluationService(vendorEvaluationRepo)
 	procurementAnalyticsService := procurement_services.NewAnalyticsService(sqlxDB)
 	procurementRFQService := procurement_services.NewRFQService(procurementRFQRepo)
 	procurementRFQService.SetPurchaseOrderRepository(procurementPurchaseOrderRepo) // Enable RFQ to PO conversion
+	procurementRFQService.SetVendorScoreSource(vendorEvaluationService)
+	procurementRFQService.SetEventBus(eventBus)
+	if exchangeRateService != nil {
+		procurementRFQService.SetExchangeRateSource(exchangeRateService)
+	}
+	supplierPortalRepo := procurement_persistence.NewSupplierPortalRepositoryImpl(sqlxDB)
+	supplierPortalService := procurement_services.NewSupplierPortalService(supplierPortalRepo, procurementPurchaseOrderService, procurementRFQService)
+	supplierPortalService.SetInvoiceRegistrar(finance_services.NewSupplierInvoiceRegistrar(purchaseVoucherService, threeWayMatchService))
+	procurementPurchaseOrderService.WithSupplierNotifier(supplierPortalService) // Notify supplier portal accounts of sent POs
 
 	// Register event handlers for cross-module communication
 	// Inventory event handlers - handle PO approved, AP created events
@@ -622,6 +738,11 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	financeEventHandler.RegisterHandlers(eventBus)
 	logger.Info("Finance event handlers registered")
 
+	// HR event handlers - handle collective leave (cuti bersama) declared/revoked events
+	hrEventHandler := hr_app.NewHREventHandler(leaveService)
+	hrEventHandler.RegisterHandlers(eventBus)
+	logger.Info("HR event handlers registered")
+
 	// Initialize notification repository and service
 	notificationRepo := notifications_persistence.NewPostgresNotificationRepository(sqlxDB)
 	notificationService := notifications_services.NewNotificationService(notificationRepo)
@@ -630,6 +751,8 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	wsHub := ws.NewHub(logger)
 	go wsHub.Run()
 	notificationService.SetNotifier(ws.NewRealtimeNotifier(wsHub))
+	contractService.SetNotifier(notificationService)
+	supplierPortalService.SetNotifier(notificationService)
 	logger.Info("WebSocket hub initialized")
 
 	// Initialize messaging module
@@ -642,13 +765,32 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 	// Initialize email service
 	emailService := email.NewEmailServiceFromEnv()
 
+	// Initialize password policy, self-service reset and login lockout; the policy is read
+	// from the security settings and every event is written to the audit log
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:3000"
+	}
+	logoURL := os.Getenv("LOGO_URL")
+	if logoURL == "" {
+		logoURL = frontendURL + "/logo.png"
+	}
+	passwordRepo := auth.NewPasswordRepositoryImpl(sqlxDB)
+	passwordService := auth.NewPasswordService(passwordRepo, settingService, emailService, audit.NewSecurityLogger(sqlxDB), sessionService, frontendURL, logoURL)
+	salesQuotationService.SetMailer(emailService, customerService, logoURL)
+	userService.SetPasswordService(passwordService)
+	posTransactionService.SetSupervisorOverride(userService, rbacService)
+
 	// Initialize invitation repository and service
 	invitationRepo := invitations_persistence.NewInvitationRepository(sqlxDB)
 	invitationService := invitations_services.NewInvitationService(invitationRepo, userRepo, emailService)
+	invitationService.SetPasswordValidator(passwordService)
+	supplierPortalService.SetPasswordValidator(passwordService)
+	supplierPortalService.SetUserRevoker(sessionService)
 
 	// Initialize profile repository and service
 	profileRepo := profile_persistence.NewProfileRepositoryImpl(sqlxDB)
-	profileService := profile_services.NewProfileService(profileRepo)
+	profileService := profile_services.NewProfileService(profileRepo, sessionService, mfaService, passwordService)
 
 	return &Container{
 		Config:              cfg,
@@ -698,6 +840,7 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 		GoodsIssueService:         goodsIssueService,
 		InventoryValuationService: inventoryValuationService,
 		RFQService:                rfqService,
+		LandedCostService:         landedCostService,
 
 		// Shipping services
 		CourierService:         courierService,
@@ -708,17 +851,25 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 		ShippingInvoiceService: shippingInvoiceService,
 
 		// Sales services
-		SalesOrderService:        salesOrderService,
-		SalesInvoiceService:      salesInvoiceService,
-		PosTransactionService:    posTransactionService,
-		OnlineOrderService:       onlineOrderService,
-		ConsignmentSalesService:  consignmentSalesService,
-		SalesReturnService:       salesReturnService,
-		PromotionService:         promotionService,
-		SalesTargetService:       salesTargetService,
-		SalesKompetitorService:   salesKompetitorService,
-		ProsesMarginService:      prosesMarginService,
-		SalesRekonsiliasiService: salesRekonsiliasiService,
+		SalesOrderService:          salesOrderService,
+		SalesInvoiceService:        salesInvoiceService,
+		PosTransactionService:      posTransactionService,
+		OnlineOrderService:         onlineOrderService,
+		ConsignmentSalesService:    consignmentSalesService,
+		SalesReturnService:         salesReturnService,
+		PromotionService:           promotionService,
+		LoyaltyService:             loyaltyService,
+		PosShiftService:            posShiftService,
+		PosSyncService:             posSyncService,
+		SalesQuotationService:      salesQuotationService,
+		OrderToCashService:         orderToCashService,
+		ConsignmentService:         consignmentService,
+		MarketplaceService:         marketplaceService,
+		SalesReconciliationService: salesReconciliationService,
+		SalesTargetService:         salesTargetService,
+		SalesKompetitorService:     salesKompetitorService,
+		ProsesMarginService:        prosesMarginService,
+		SalesRekonsiliasiService:   salesRekonsiliasiService,
 
 		// Finance services
 		CashBankService:           cashBankService,
@@ -740,6 +891,8 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 		LoanFacilityService:       loanFacilityService,
 		FinancialForecastService:  financialForecastService,
 		FinanceReportService:      financeReportService,
+		WithholdingTaxService:     withholdingTaxService,
+		ThreeWayMatchService:      threeWayMatchService,
 
 		// HR services
 		EmployeeService:          employeeService,
@@ -749,7 +902,11 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 		TrainingService:          trainingService,
 
 		// Calendar services
-		EventService: eventService,
+		EventService:   eventService,
+		HolidayService: holidayService,
+
+		// Approval services
+		ApprovalService: approvalService,
 
 		// Settings services
 		SettingService: settingService,
@@ -770,6 +927,8 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 		FinancialPeriodService: financialPeriodService,
 		FixedAssetService:      fixedAssetService,
 		TaxService:             taxService,
+		TaxInvoiceService:      taxInvoiceService,
+		TaxDeterminationService: taxDeterminationService,
 		// TrialBalanceService:   trialBalanceService,
 
 		// Procurement services
@@ -779,6 +938,7 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 		VendorEvaluationService:         vendorEvaluationService,
 		ProcurementAnalyticsService:     procurementAnalyticsService,
 		ProcurementRFQService:           procurementRFQService,
+		SupplierPortalService:           supplierPortalService,
 
 		// Notification services
 		NotificationService: notificationService,
@@ -803,6 +963,11 @@ func NewContainer(cfg *config.Config, logger *zap.Logger, db *sql.DB, gormDB *go
 
 		// RBAC services
 		RBACService: rbacService,
+
+		// Login sessions and token revocation
+		SessionService:  sessionService,
+		MFAService:      mfaService,
+		PasswordService: passwordService,
 	}
 }
 
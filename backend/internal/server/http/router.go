@@ -76,6 +76,12 @@ func SetupRouter(router *gin.Engine, c *container.Container) {
 				media.HEAD("/*objectKey", c.MediaHandler.GetFileInfo)
 			}
 		}
+
+		// Marketplace webhooks (public, the channel adapter verifies the sender)
+		if c.MarketplaceService != nil {
+			marketplaceHandler := sales_handlers.NewMarketplaceHandler(c.MarketplaceService)
+			api.POST("/v1/webhooks/marketplace/:code", marketplaceHandler.ReceiveWebhook)
+		}
 	}
 
 	// Redoc documentation page
@@ -101,9 +107,17 @@ func SetupProtectedRoutes(protectedAPI *gin.RouterGroup, c *container.Container,
 	salesKompetitorHandler := sales_handlers.NewSalesKompetitorHandler(c.SalesKompetitorService)
 		prosesMarginHandler := sales_handlers.NewProsesMarginHandler(c.ProsesMarginService)
 	salesRekonsiliasiHandler := sales_handlers.NewSalesRekonsiliasiHandler(c.SalesRekonsiliasiService)
+	loyaltyHandler := sales_handlers.NewLoyaltyHandler(c.LoyaltyService)
+	posShiftHandler := sales_handlers.NewPosShiftHandler(c.PosShiftService)
+	posSyncHandler := sales_handlers.NewPosSyncHandler(c.PosSyncService)
+	salesQuotationHandler := sales_handlers.NewSalesQuotationHandler(c.SalesQuotationService)
+	orderToCashHandler := sales_handlers.NewOrderToCashHandler(c.OrderToCashService)
+	consignmentStockHandler := sales_handlers.NewConsignmentStockHandler(c.ConsignmentService)
+	marketplaceHandler := sales_handlers.NewMarketplaceHandler(c.MarketplaceService)
+	salesReconciliationHandler := sales_handlers.NewSalesReconciliationHandler(c.SalesReconciliationService)
 
 	// Register sales routes under v1 API (protected)
-	sales_routes.RegisterSalesRoutes(protectedAPI, salesOrderHandler, salesInvoiceHandler, posTransactionHandler, onlineOrderHandler, consignmentSalesHandler, salesReturnHandler, promotionHandler, salesTargetHandler, salesKompetitorHandler, prosesMarginHandler, salesRekonsiliasiHandler, rbacSvc)
+	sales_routes.RegisterSalesRoutes(protectedAPI, salesOrderHandler, salesInvoiceHandler, posTransactionHandler, onlineOrderHandler, consignmentSalesHandler, salesReturnHandler, promotionHandler, salesTargetHandler, salesKompetitorHandler, prosesMarginHandler, salesRekonsiliasiHandler, loyaltyHandler, posShiftHandler, posSyncHandler, salesQuotationHandler, orderToCashHandler, consignmentStockHandler, marketplaceHandler, salesReconciliationHandler, rbacSvc)
 	
 	// Initialize accounting handlers
 	generalLedgerHandler := accounting_handlers.NewGeneralLedgerHandler(c.GeneralLedgerService)
@@ -141,7 +155,9 @@ func SetupProtectedRoutes(protectedAPI *gin.RouterGroup, c *container.Container,
 
 	// Initialize tax handler and register routes
 	taxHandler := accounting_handlers.NewTaxHandler(c.TaxService)
-	accounting_routes.RegisterTaxRoutes(accountingGroup, taxHandler, rbacSvc)
+	taxInvoiceHandler := accounting_handlers.NewTaxInvoiceHandler(c.TaxInvoiceService)
+	taxDeterminationHandler := accounting_handlers.NewTaxDeterminationHandler(c.TaxDeterminationService)
+	accounting_routes.RegisterTaxRoutes(accountingGroup, taxHandler, taxInvoiceHandler, taxDeterminationHandler, rbacSvc)
 
 	// Initialize finance handlers
 	cashBankHandler := finance_handlers.NewCashBankHandler(c.CashBankService)
@@ -163,9 +179,11 @@ func SetupProtectedRoutes(protectedAPI *gin.RouterGroup, c *container.Container,
 	loanFacilityHandler := finance_handlers.NewLoanFacilityHandler(c.LoanFacilityService)
 	financialForecastHandler := finance_handlers.NewFinancialForecastHandler(c.FinancialForecastService)
 	financeReportHandler := finance_handlers.NewFinanceReportHandler(c.FinanceReportService)
+	withholdingTaxHandler := finance_handlers.NewWithholdingTaxHandler(c.WithholdingTaxService)
+	threeWayMatchHandler := finance_handlers.NewThreeWayMatchHandler(c.ThreeWayMatchService)
 
 	// Register finance routes under v1 API (protected)
-	finance_routes.RegisterFinanceRoutes(protectedAPI, cashBankHandler, paymentHandler, financeInvoiceHandler, accountsPayableHandler, accountsReceivableHandler, cashDisbursementHandler, cashReceiptHandler, bankTransferHandler, cashOpeningBalanceHandler, purchaseVoucherHandler, expenditureRequestHandler, checkClearanceHandler, monthlyClosingHandler, cashBookHandler, financeBudgetHandler, capexProjectHandler, loanFacilityHandler, financialForecastHandler, financeReportHandler, rbacSvc)
+	finance_routes.RegisterFinanceRoutes(protectedAPI, cashBankHandler, paymentHandler, financeInvoiceHandler, accountsPayableHandler, accountsReceivableHandler, cashDisbursementHandler, cashReceiptHandler, bankTransferHandler, cashOpeningBalanceHandler, purchaseVoucherHandler, expenditureRequestHandler, checkClearanceHandler, monthlyClosingHandler, cashBookHandler, financeBudgetHandler, capexProjectHandler, loanFacilityHandler, financialForecastHandler, financeReportHandler, withholdingTaxHandler, threeWayMatchHandler, rbacSvc)
 
 	// Initialize inventory handlers
 	purchaseOrderHandler := inventory_handlers.NewPurchaseOrderHandler(c.PurchaseOrderService)
@@ -191,9 +209,11 @@ func SetupProtectedRoutes(protectedAPI *gin.RouterGroup, c *container.Container,
 	simpleGoodsIssueHandler := inventory_handlers.NewSimpleGoodsIssueHandler(c.SimpleGoodsIssueService)
 	simpleGoodsIssueHandler.SetDB(c.SqlxDB)
 	rfqHandler := inventory_handlers.NewRFQHandler(c.RFQService)
+	landedCostHandler := inventory_handlers.NewLandedCostHandler(c.LandedCostService)
 
 	// Register inventory routes under v1 API (protected)
 	inventory_routes.RegisterInventoryRoutes(protectedAPI, purchaseOrderHandler, goodsReceiptHandler, stockHandler, transferHandler, draftOrderHandler, stockAdjustmentHandler, stockOpnameHandler, returnSupplierHandler, simpleGoodsIssueHandler, rfqHandler, rbacSvc)
+	inventory_routes.RegisterLandedCostRoutes(protectedAPI, landedCostHandler, rbacSvc)
 
 	// Raw Materials routes (standalone handler using sqlx)
 	rawMaterialsHandler := NewRawMaterialsHandler(c.SqlxDB)
@@ -233,6 +253,10 @@ func SetupProtectedRoutes(protectedAPI *gin.RouterGroup, c *container.Container,
 	// Register procurement routes under v1 API (protected)
 	procurement_routes.RegisterProcurementRoutes(protectedAPI, purchaseRequestHandler, procurementPurchaseOrderHandler, contractHandler, vendorEvaluationHandler, analyticsHandler, procurementRFQHandler, rbacSvc)
 
+	// Register supplier portal routes and the internal review of supplier submissions
+	supplierPortalHandler := procurement_handlers.NewSupplierPortalHandler(c.SupplierPortalService, c.StorageService)
+	procurement_routes.RegisterSupplierPortalRoutes(protectedAPI, supplierPortalHandler, rbacSvc)
+
 	// Initialize notification handlers
 	notificationHandler := notifications_handlers.NewNotificationHandler(c.NotificationService)
 
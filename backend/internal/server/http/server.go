@@ -24,6 +24,8 @@ import (
 
 	calendar_handlers "malaka/internal/modules/calendar/presentation/http/handlers"
 	calendar_routes "malaka/internal/modules/calendar/presentation/http/routes"
+	approval_handlers "malaka/internal/modules/approval/presentation/http/handlers"
+	approval_routes "malaka/internal/modules/approval/presentation/http/routes"
 
 	settings_handlers "malaka/internal/modules/settings/presentation/http/handlers"
 
@@ -42,6 +44,9 @@ import (
 	// Profile imports
 	profile_handlers "malaka/internal/modules/profile/presentation/http/handlers"
 
+	// Procurement imports (handlers initialized in router.go)
+	procurement_entities "malaka/internal/modules/procurement/domain/entities"
+
 	// Accounting imports (handlers now initialized in router.go)
 	// accounting_handlers "malaka/internal/modules/accounting/presentation/http/handlers"
 	// accounting_routes "malaka/internal/modules/accounting/presentation/http/routes"
@@ -205,8 +210,8 @@ func (server *Server) setupRouter() {
 	// Create API v1 group for consistent versioning
 	apiV1 := router.Group("/api/v1")
 
-	// JWT authentication middleware
-	authMiddleware := auth.Middleware(server.config.JWTSecret)
+	// JWT authentication middleware; rejects tokens of terminated sessions and disabled users
+	authMiddleware := auth.MiddlewareWithVerifier(server.config.JWTSecret, server.container.SessionService)
 
 	// ============================================================
 	// PUBLIC ROUTES (no authentication required)
@@ -216,6 +221,18 @@ func (server *Server) setupRouter() {
 	publicUsers := apiV1.Group("/masterdata/users")
 	{
 		publicUsers.POST("/login", userHandler.Login)
+		publicUsers.POST("/refresh", userHandler.RefreshToken)
+
+		// Second login step for users with 2FA, authenticated by the MFA challenge token
+		publicUsers.POST("/login/mfa", userHandler.VerifyMFA)
+		publicUsers.POST("/login/mfa/enroll", userHandler.BeginMFAEnrollment)
+		publicUsers.POST("/login/mfa/enroll/confirm", userHandler.ConfirmMFAEnrollment)
+
+		// Self-service password reset with single-use emailed tokens
+		passwordHandler := auth.NewPasswordHandler(server.container.PasswordService)
+		publicUsers.GET("/password/policy", passwordHandler.GetPolicy)
+		publicUsers.POST("/password/forgot", passwordHandler.ForgotPassword)
+		publicUsers.POST("/password/reset", passwordHandler.ResetPassword)
 	}
 
 	// Initialize invitation handler
@@ -249,11 +266,22 @@ func (server *Server) setupRouter() {
 	protectedAPI := apiV1.Group("")
 	protectedAPI.Use(authMiddleware)
 	protectedAPI.Use(auth.LoadPermissions(server.container.RBACService))
+	// Supplier portal users only reach the portal and their own session, profile and notifications
+	protectedAPI.Use(auth.RestrictRoleToPaths(procurement_entities.SupplierRole,
+		"/api/v1/supplier-portal", "/api/v1/notifications", "/api/v1/profile",
+		"/api/v1/auth/permissions", "/api/v1/masterdata/users/logout", "/api/v1/masterdata/users/logout-all"))
 	protectedAPI.Use(audit.Middleware(server.container.SqlxDB))
 
 	// RBAC service reference for route-level permission middleware
 	rbacSvc := server.container.RBACService
 
+	// Session routes for the signed-in user (no module permission required)
+	userSession := protectedAPI.Group("/masterdata/users")
+	{
+		userSession.POST("/logout", userHandler.Logout)
+		userSession.POST("/logout-all", userHandler.LogoutAll)
+	}
+
 	// Register protected masterdata routes
 	masterdata := protectedAPI.Group("/masterdata")
 	masterdata.Use(auth.RequireModuleAccess(rbacSvc, "masterdata"))
@@ -276,6 +304,7 @@ func (server *Server) setupRouter() {
 			user.GET("/:id", auth.RequirePermission(rbacSvc, "masterdata.user.read"), userHandler.GetUserByID)
 			user.PUT("/:id", auth.RequirePermission(rbacSvc, "masterdata.user.update"), userHandler.UpdateUser)
 			user.DELETE("/:id", auth.RequirePermission(rbacSvc, "masterdata.user.delete"), userHandler.DeleteUser)
+			user.POST("/:id/unlock", auth.RequirePermission(rbacSvc, "masterdata.user.update"), userHandler.UnlockUser)
 		}
 
 		// Classification routes
@@ -451,6 +480,7 @@ func (server *Server) setupRouter() {
 	employeeHandler := hr_handlers.NewEmployeeHandler(server.container.EmployeeService)
 	payrollHandler := hr_handlers.NewPayrollHandler(server.container.PayrollService)
 	attendanceHandler := hr_handlers.NewAttendanceHandler(server.container.DB)
+	attendanceHandler.SetHolidayReader(server.container.HolidayService)
 	leaveHandler := hr_handlers.NewLeaveHandler(server.container.LeaveService)
 	performanceReviewHandler := hr_handlers.NewPerformanceReviewHandler(server.container.PerformanceReviewService)
 	trainingHandler := hr_handlers.NewTrainingHandler(server.container.TrainingService)
@@ -461,9 +491,16 @@ func (server *Server) setupRouter() {
 	// Initialize calendar handlers
 	eventHandler := calendar_handlers.NewEventHandler(server.container.EventService)
 	attendeeHandler := calendar_handlers.NewAttendeeHandler(server.container.EventService)
+	holidayHandler := calendar_handlers.NewHolidayHandler(server.container.HolidayService)
 
 	// Register calendar routes with authentication (already handles its own auth internally)
-	calendar_routes.RegisterCalendarRoutes(apiV1, eventHandler, attendeeHandler, server.config.JWTSecret, rbacSvc)
+	calendar_routes.RegisterCalendarRoutes(apiV1, eventHandler, attendeeHandler, holidayHandler, authMiddleware, rbacSvc)
+
+	// Initialize approval handlers
+	approvalHandler := approval_handlers.NewApprovalHandler(server.container.ApprovalService)
+
+	// Register approval routes (protected)
+	approval_routes.RegisterApprovalRoutes(protectedAPI, approvalHandler, rbacSvc)
 
 	// Initialize settings handlers
 	settingHandler := settings_handlers.NewSettingHandler(server.container.SettingService)
@@ -551,7 +588,10 @@ func (server *Server) setupRouter() {
 			security := profile.Group("/security")
 			{
 				security.POST("/2fa/enable", auth.RequirePermission(rbacSvc, "profile.profile.update"), profileHandler.EnableTwoFactorAuth)
+				security.POST("/2fa/confirm", auth.RequirePermission(rbacSvc, "profile.profile.update"), profileHandler.ConfirmTwoFactorAuth)
+				security.POST("/2fa/recovery-codes", auth.RequirePermission(rbacSvc, "profile.profile.update"), profileHandler.RegenerateRecoveryCodes)
 				security.POST("/2fa/disable", auth.RequirePermission(rbacSvc, "profile.profile.update"), profileHandler.DisableTwoFactorAuth)
+				security.GET("/sessions", auth.RequirePermission(rbacSvc, "profile.profile.read"), profileHandler.GetLoginSessions)
 				security.DELETE("/sessions/:sessionId", auth.RequirePermission(rbacSvc, "profile.profile.update"), profileHandler.TerminateSession)
 			}
 
@@ -596,6 +636,11 @@ func (server *Server) setupRouter() {
 
 		// Audit log
 		adminRBAC.GET("/audit-log", auth.RequirePermission(rbacSvc, "admin.audit.list"), rbacHandler.GetAuditLog)
+
+		// Roles for which the company requires two-factor authentication
+		mfaHandler := auth.NewMFAHandler(server.container.MFAService)
+		adminRBAC.GET("/mfa-policies", auth.RequirePermission(rbacSvc, "admin.role.list"), mfaHandler.ListRolePolicies)
+		adminRBAC.PUT("/mfa-policies", auth.RequirePermission(rbacSvc, "admin.role.update"), mfaHandler.SetRolePolicies)
 	}
 
 	// Register admin audit log routes (general activity audit)
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+
+	"malaka/internal/shared/auth"
+)
+
+// SecurityLogger writes login and password events to the audit log. These events
+// come from unauthenticated endpoints that the audit middleware does not record.
+type SecurityLogger struct {
+	repo *Repository
+}
+
+// NewSecurityLogger creates a new SecurityLogger.
+func NewSecurityLogger(db *sqlx.DB) *SecurityLogger {
+	return &SecurityLogger{repo: NewRepository(db)}
+}
+
+// RecordSecurityEvent implements auth.SecurityAuditor.
+func (l *SecurityLogger) RecordSecurityEvent(ctx context.Context, event *auth.SecurityEvent) error {
+	status := http.StatusOK
+	if !event.Success {
+		status = http.StatusUnauthorized
+	}
+
+	entry := &AuditLogEntry{
+		Method:     "EVENT",
+		Path:       "/auth/" + event.Action,
+		Module:     "auth",
+		Resource:   "security",
+		Action:     event.Action,
+		StatusCode: status,
+		IPAddress:  truncateString(event.IPAddress, 45),
+		UserAgent:  truncateString(event.UserAgent, 500),
+	}
+	if event.UserID != "" {
+		entry.UserID = &event.UserID
+	}
+	if len(event.Details) > 0 {
+		details, err := json.Marshal(event.Details)
+		if err != nil {
+			return err
+		}
+		body := string(details)
+		entry.RequestBody = &body
+	}
+	return l.repo.Insert(ctx, entry)
+}
@@ -11,18 +11,39 @@ type Claims struct {
 	Role      string `json:"role"`
 	CompanyID string `json:"company_id,omitempty"`
 	Email     string `json:"email,omitempty"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.StandardClaims
 }
 
 // NewJWT creates a new JWT token with user info.
 func NewJWT(userID, companyID, email, role, secret string, expiry int) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		Role:      role,
 		CompanyID: companyID,
 		Email:     email,
 		StandardClaims: jwt.StandardClaims{
 			Subject:   userID,
-			ExpiresAt: time.Now().Add(time.Hour * time.Duration(expiry)).Unix(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Hour * time.Duration(expiry)).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// NewAccessToken creates a short-lived JWT bound to a login session.
+func NewAccessToken(subject *TokenSubject, sessionID, secret string, issuedAt time.Time, ttl time.Duration) (string, error) {
+	claims := &Claims{
+		Role:      subject.Role,
+		CompanyID: subject.CompanyID,
+		Email:     subject.Email,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   subject.UserID,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: issuedAt.Add(ttl).Unix(),
 		},
 	}
 
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MFAMethodAuthenticator is the only second factor currently supported: an RFC 6238 TOTP app.
+const MFAMethodAuthenticator = "authenticator"
+
+var (
+	// ErrMFANotEnrolled is returned when a user has not started or confirmed TOTP enrollment.
+	ErrMFANotEnrolled = errors.New("two-factor authentication is not set up")
+	// ErrMFAAlreadyEnabled is returned when enrolling a user who already has 2FA enabled.
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrInvalidMFACode is returned for wrong, expired or replayed codes and used recovery codes.
+	ErrInvalidMFACode = errors.New("invalid verification code")
+	// ErrMFALocked is returned after too many failed verification attempts.
+	ErrMFALocked = errors.New("too many failed verification attempts, try again later")
+	// ErrInvalidMFAChallenge is returned for unknown or expired login challenge tokens.
+	ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor challenge")
+	// ErrMFARequiredByPolicy is returned when a user tries to disable 2FA enforced for their role.
+	ErrMFARequiredByPolicy = errors.New("two-factor authentication is required for your role")
+)
+
+// UserMFA holds a user's TOTP enrollment. The secret is stored encrypted; the
+// enrollment only becomes active once a first code has been confirmed.
+type UserMFA struct {
+	UserID          string     `db:"user_id"`
+	SecretEncrypted string     `db:"secret_encrypted"`
+	EnabledAt       *time.Time `db:"enabled_at"`
+	LastUsedStep    int64      `db:"last_used_step"`
+	FailedAttempts  int        `db:"failed_attempts"`
+	LockedUntil     *time.Time `db:"locked_until"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+}
+
+// IsEnabled reports whether enrollment was confirmed.
+func (m *UserMFA) IsEnabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
+
+// MFAStatus summarises the 2FA state of a user.
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Method                 string     `json:"method"`
+	Required               bool       `json:"required"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFAEnrollment is returned when TOTP enrollment starts.
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"` // PNG data URL of OTPAuthURL
+}
+
+// MFAChallenge is issued after a correct password when a second factor is needed.
+type MFAChallenge struct {
+	Token              string    `json:"mfa_token"`
+	ExpiresIn          int64     `json:"expires_in"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+}
+
+// MFARolePolicy makes 2FA mandatory for holders of a role within a company.
+type MFARolePolicy struct {
+	CompanyID string    `json:"company_id" db:"company_id"`
+	RoleID    string    `json:"role_id" db:"role_id"`
+	RoleName  string    `json:"role_name" db:"role_name"`
+	CreatedBy *string   `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MFARepository defines persistence for TOTP enrollments, recovery codes and role policies.
+type MFARepository interface {
+	GetUserMFA(ctx context.Context, userID string) (*UserMFA, error)
+	SaveUserMFA(ctx context.Context, mfa *UserMFA) error
+	// DeleteUserMFA removes the enrollment and all recovery codes of the user.
+	DeleteUserMFA(ctx context.Context, userID string) error
+	// ClaimStep records a used time step and reports false when it was not newer than the last one.
+	ClaimStep(ctx context.Context, userID string, step int64) (bool, error)
+	RecordFailedAttempt(ctx context.Context, userID string, maxAttempts int, lockUntil time.Time) error
+	ResetFailedAttempts(ctx context.Context, userID string) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string, at time.Time) error
+	// UseRecoveryCode marks an unused code as used and reports false when none matched.
+	UseRecoveryCode(ctx context.Context, userID, codeHash string, at time.Time) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+
+	IsMFARequired(ctx context.Context, userID string) (bool, error)
+	ListRolePolicies(ctx context.Context, companyID string) ([]MFARolePolicy, error)
+	SetRolePolicies(ctx context.Context, companyID string, roleIDs []string, createdBy string) error
+}
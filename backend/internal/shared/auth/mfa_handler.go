@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler handles admin API endpoints for company 2FA enforcement.
+type MFAHandler struct {
+	svc *MFAService
+}
+
+// NewMFAHandler creates a new MFAHandler.
+func NewMFAHandler(svc *MFAService) *MFAHandler {
+	return &MFAHandler{svc: svc}
+}
+
+// ListRolePolicies returns the roles for which the caller's company requires 2FA.
+func (h *MFAHandler) ListRolePolicies(c *gin.Context) {
+	policies, err := h.svc.ListRolePolicies(c.Request.Context(), c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to list 2FA policies", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": policies})
+}
+
+// SetRolePolicies replaces the roles for which the caller's company requires 2FA.
+// Holders of these roles must enroll on their next login.
+func (h *MFAHandler) SetRolePolicies(c *gin.Context) {
+	var req struct {
+		RoleIDs []string `json:"role_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request", "error": err.Error()})
+		return
+	}
+
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Company context is required"})
+		return
+	}
+
+	policies, err := h.svc.SetRolePolicies(c.Request.Context(), companyID, req.RoleIDs, GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to update 2FA policies", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "2FA policies updated", "data": policies})
+}
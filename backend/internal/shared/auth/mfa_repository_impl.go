@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"malaka/internal/shared/uuid"
+)
+
+const userMFAColumns = `user_id::text, secret_encrypted, enabled_at, last_used_step, failed_attempts, locked_until, created_at, updated_at`
+
+// MFARepositoryImpl implements MFARepository using sqlx.
+type MFARepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewMFARepositoryImpl creates a new MFARepositoryImpl.
+func NewMFARepositoryImpl(db *sqlx.DB) *MFARepositoryImpl {
+	return &MFARepositoryImpl{db: db}
+}
+
+// GetUserMFA retrieves the TOTP enrollment of a user. Returns nil when there is none.
+func (r *MFARepositoryImpl) GetUserMFA(ctx context.Context, userID string) (*UserMFA, error) {
+	var mfa UserMFA
+	query := `SELECT ` + userMFAColumns + ` FROM user_mfa WHERE user_id::text = $1`
+	if err := r.db.GetContext(ctx, &mfa, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user mfa: %w", err)
+	}
+	return &mfa, nil
+}
+
+// SaveUserMFA inserts or replaces the TOTP enrollment of a user.
+func (r *MFARepositoryImpl) SaveUserMFA(ctx context.Context, mfa *UserMFA) error {
+	query := `
+		INSERT INTO user_mfa (user_id, secret_encrypted, enabled_at, last_used_step, failed_attempts, locked_until, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret_encrypted = EXCLUDED.secret_encrypted,
+			enabled_at = EXCLUDED.enabled_at,
+			last_used_step = EXCLUDED.last_used_step,
+			failed_attempts = EXCLUDED.failed_attempts,
+			locked_until = EXCLUDED.locked_until,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		mfa.UserID, mfa.SecretEncrypted, mfa.EnabledAt, mfa.LastUsedStep,
+		mfa.FailedAttempts, mfa.LockedUntil, mfa.CreatedAt, mfa.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save user mfa: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserMFA removes the enrollment and recovery codes of a user.
+func (r *MFARepositoryImpl) DeleteUserMFA(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa_recovery_codes WHERE user_id::text = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id::text = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user mfa: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ClaimStep records the time step of an accepted code. The conditional update makes
+// a code unusable a second time, even by concurrent requests.
+func (r *MFARepositoryImpl) ClaimStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_mfa SET last_used_step = $2, failed_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE user_id::text = $1 AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim mfa step: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim mfa step: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// RecordFailedAttempt counts a failed verification and locks the enrollment once maxAttempts is reached.
+func (r *MFARepositoryImpl) RecordFailedAttempt(ctx context.Context, userID string, maxAttempts int, lockUntil time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_mfa
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END,
+			updated_at = NOW()
+		WHERE user_id::text = $1
+	`, userID, maxAttempts, lockUntil)
+	if err != nil {
+		return fmt.Errorf("failed to record mfa attempt: %w", err)
+	}
+	return nil
+}
+
+// ResetFailedAttempts clears the failed attempt counter and any lock.
+func (r *MFARepositoryImpl) ResetFailedAttempts(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_mfa SET failed_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE user_id::text = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reset mfa attempts: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes discards all recovery codes of the user and stores the new hashes.
+func (r *MFARepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string, at time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa_recovery_codes WHERE user_id::text = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_mfa_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES ($1, $2, $3, $4)
+		`, uuid.New().String(), userID, hash, at); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks a matching unused recovery code as used.
+func (r *MFARepositoryImpl) UseRecoveryCode(ctx context.Context, userID, codeHash string, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_mfa_recovery_codes SET used_at = $3
+		WHERE user_id::text = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes.
+func (r *MFARepositoryImpl) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM user_mfa_recovery_codes WHERE user_id::text = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// IsMFARequired reports whether the user's company enforces 2FA for any role the user holds,
+// either as primary role or through an unexpired role assignment.
+func (r *MFARepositoryImpl) IsMFARequired(ctx context.Context, userID string) (bool, error) {
+	var required bool
+	err := r.db.GetContext(ctx, &required, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM users u
+			JOIN mfa_role_policies p ON p.company_id = u.company_id
+			WHERE u.id::text = $1
+			  AND (p.role_id = u.role_id OR p.role_id IN (
+				SELECT ur.role_id FROM user_roles ur
+				WHERE ur.user_id = u.id AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+			  ))
+		)
+	`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check mfa policy: %w", err)
+	}
+	return required, nil
+}
+
+// ListRolePolicies returns the roles for which the company enforces 2FA.
+func (r *MFARepositoryImpl) ListRolePolicies(ctx context.Context, companyID string) ([]MFARolePolicy, error) {
+	policies := []MFARolePolicy{}
+	err := r.db.SelectContext(ctx, &policies, `
+		SELECT p.company_id::text, p.role_id::text, ro.name AS role_name, p.created_by::text, p.created_at
+		FROM mfa_role_policies p
+		JOIN roles ro ON ro.id = p.role_id
+		WHERE p.company_id::text = $1
+		ORDER BY ro.level DESC, ro.name
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mfa policies: %w", err)
+	}
+	return policies, nil
+}
+
+// SetRolePolicies replaces the set of roles for which the company enforces 2FA.
+func (r *MFARepositoryImpl) SetRolePolicies(ctx context.Context, companyID string, roleIDs []string, createdBy string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_role_policies WHERE company_id::text = $1`, companyID); err != nil {
+		return fmt.Errorf("failed to clear mfa policies: %w", err)
+	}
+	var actor *string
+	if createdBy != "" {
+		actor = &createdBy
+	}
+	for _, roleID := range roleIDs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO mfa_role_policies (id, company_id, role_id, created_by, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (company_id, role_id) DO NOTHING
+		`, uuid.New().String(), companyID, roleID, actor); err != nil {
+			return fmt.Errorf("failed to store mfa policy: %w", err)
+		}
+	}
+	return tx.Commit()
+}
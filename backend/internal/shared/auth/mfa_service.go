@@ -0,0 +1,415 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	mfaChallengeTTL       = 5 * time.Minute
+	mfaChallengePurpose   = "mfa_challenge"
+	mfaChallengeKeySuffix = ":mfa-challenge"
+	mfaMaxFailedAttempts  = 5
+	mfaLockDuration       = 15 * time.Minute
+	mfaRecoveryCodeCount  = 10
+	mfaRecoveryCodeBytes  = 5
+	mfaQRCodeSize         = 256
+)
+
+// mfaChallengeClaims are signed with a key derived from the JWT secret, so a
+// challenge token is never accepted as an access token.
+type mfaChallengeClaims struct {
+	Purpose string `json:"purpose"`
+	Enroll  bool   `json:"enroll,omitempty"`
+	jwt.StandardClaims
+}
+
+// MFAService manages TOTP enrollment, recovery codes, role enforcement and login challenges.
+type MFAService struct {
+	repo          MFARepository
+	encryptionKey []byte
+	challengeKey  []byte
+	issuer        string
+	now           func() time.Time
+}
+
+// NewMFAService creates a new MFAService. TOTP secrets are encrypted with encryptionKey;
+// login challenges are signed with a key derived from jwtSecret.
+func NewMFAService(repo MFARepository, encryptionKey, jwtSecret, issuer string) *MFAService {
+	key := make([]byte, 32)
+	copy(key, encryptionKey)
+	if issuer == "" {
+		issuer = "Malaka ERP"
+	}
+	return &MFAService{
+		repo:          repo,
+		encryptionKey: key,
+		challengeKey:  []byte(jwtSecret + mfaChallengeKeySuffix),
+		issuer:        issuer,
+		now:           time.Now,
+	}
+}
+
+// Status returns the 2FA state of a user.
+func (s *MFAService) Status(ctx context.Context, userID string) (*MFAStatus, error) {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.repo.IsMFARequired(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Required: required}
+	if mfa.IsEnabled() {
+		remaining, err := s.repo.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		status.Enabled = true
+		status.Method = MFAMethodAuthenticator
+		status.EnabledAt = mfa.EnabledAt
+		status.RecoveryCodesRemaining = remaining
+	}
+	return status, nil
+}
+
+// IsEnabled reports whether the user has confirmed a TOTP enrollment.
+func (s *MFAService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return mfa.IsEnabled(), nil
+}
+
+// IsRequired reports whether the user's company enforces 2FA for one of the user's roles.
+func (s *MFAService) IsRequired(ctx context.Context, userID string) (bool, error) {
+	return s.repo.IsMFARequired(ctx, userID)
+}
+
+// BeginEnrollment generates a new TOTP secret for the user. It stays inactive until
+// ConfirmEnrollment verifies a code from the authenticator app; starting again replaces it.
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID, accountName string) (*MFAEnrollment, error) {
+	existing, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.IsEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	now := s.now()
+	if err := s.repo.SaveUserMFA(ctx, &UserMFA{
+		UserID:          userID,
+		SecretEncrypted: encrypted,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}); err != nil {
+		return nil, err
+	}
+
+	if accountName == "" {
+		accountName = userID
+	}
+	otpauthURL := TOTPProvisioningURI(s.issuer, accountName, secret)
+	png, err := qrcode.Encode(otpauthURL, qrcode.Medium, mfaQRCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURL: otpauthURL,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmEnrollment activates a pending enrollment with a code from the authenticator
+// app and returns a fresh set of one-time recovery codes.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if mfa.IsEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err := s.checkLock(mfa); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.verifyTOTP(ctx, mfa, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.recordFailure(ctx, userID)
+	}
+
+	now := s.now()
+	mfa.EnabledAt = &now
+	mfa.FailedAttempts = 0
+	mfa.LockedUntil = nil
+	mfa.UpdatedAt = now
+	if err := s.repo.SaveUserMFA(ctx, mfa); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// Verify checks a TOTP code or an unused recovery code of a user with 2FA enabled.
+// Accepted TOTP codes and recovery codes cannot be used again.
+func (s *MFAService) Verify(ctx context.Context, userID, code string) error {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !mfa.IsEnabled() {
+		return ErrMFANotEnrolled
+	}
+	if err := s.checkLock(mfa); err != nil {
+		return err
+	}
+
+	if recovery := normalizeRecoveryCode(code); len(recovery) == mfaRecoveryCodeBytes*8/5 {
+		used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(recovery), s.now())
+		if err != nil {
+			return err
+		}
+		if used {
+			return s.repo.ResetFailedAttempts(ctx, userID)
+		}
+		return s.recordFailure(ctx, userID)
+	}
+
+	ok, err := s.verifyTOTP(ctx, mfa, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.recordFailure(ctx, userID)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a current code.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// Disable removes the user's enrollment and recovery codes. Users whose role requires
+// 2FA cannot turn it off.
+func (s *MFAService) Disable(ctx context.Context, userID string) error {
+	required, err := s.repo.IsMFARequired(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByPolicy
+	}
+	return s.repo.DeleteUserMFA(ctx, userID)
+}
+
+// IssueChallenge signs a short-lived token proving the user passed the password step.
+// With enrollmentRequired the token can only be used to enroll, not to verify a code.
+func (s *MFAService) IssueChallenge(userID string, enrollmentRequired bool) (*MFAChallenge, error) {
+	now := s.now()
+	expiresAt := now.Add(mfaChallengeTTL)
+	claims := &mfaChallengeClaims{
+		Purpose: mfaChallengePurpose,
+		Enroll:  enrollmentRequired,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   userID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.challengeKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign mfa challenge: %w", err)
+	}
+	return &MFAChallenge{
+		Token:              token,
+		ExpiresIn:          int64(mfaChallengeTTL / time.Second),
+		ExpiresAt:          expiresAt,
+		EnrollmentRequired: enrollmentRequired,
+	}, nil
+}
+
+// ParseChallenge validates a challenge token and returns the user it was issued to.
+func (s *MFAService) ParseChallenge(token string) (userID string, enrollmentRequired bool, err error) {
+	claims := &mfaChallengeClaims{}
+	// Expiry is checked against the service clock below
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	parsed, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return s.challengeKey, nil
+	})
+	if err != nil || !parsed.Valid || claims.Purpose != mfaChallengePurpose || claims.Subject == "" {
+		return "", false, ErrInvalidMFAChallenge
+	}
+	if !claims.VerifyExpiresAt(s.now().Unix(), true) {
+		return "", false, ErrInvalidMFAChallenge
+	}
+	return claims.Subject, claims.Enroll, nil
+}
+
+// ListRolePolicies returns the roles for which the company enforces 2FA.
+func (s *MFAService) ListRolePolicies(ctx context.Context, companyID string) ([]MFARolePolicy, error) {
+	return s.repo.ListRolePolicies(ctx, companyID)
+}
+
+// SetRolePolicies replaces the roles for which the company enforces 2FA.
+func (s *MFAService) SetRolePolicies(ctx context.Context, companyID string, roleIDs []string, actorID string) ([]MFARolePolicy, error) {
+	if companyID == "" {
+		return nil, errors.New("company ID is required")
+	}
+	if err := s.repo.SetRolePolicies(ctx, companyID, roleIDs, actorID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRolePolicies(ctx, companyID)
+}
+
+// verifyTOTP validates a code against the stored secret and claims its time step so
+// the same code cannot be replayed within its validity window.
+func (s *MFAService) verifyTOTP(ctx context.Context, mfa *UserMFA, code string) (bool, error) {
+	secret, err := s.decrypt(mfa.SecretEncrypted)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	step, ok := ValidateTOTP(secret, code, s.now())
+	if !ok || step <= mfa.LastUsedStep {
+		return false, nil
+	}
+	claimed, err := s.repo.ClaimStep(ctx, mfa.UserID, step)
+	if err != nil {
+		return false, err
+	}
+	if claimed {
+		mfa.LastUsedStep = step
+	}
+	return claimed, nil
+}
+
+func (s *MFAService) checkLock(mfa *UserMFA) error {
+	if mfa.LockedUntil != nil && s.now().Before(*mfa.LockedUntil) {
+		return ErrMFALocked
+	}
+	return nil
+}
+
+func (s *MFAService) recordFailure(ctx context.Context, userID string) error {
+	if err := s.repo.RecordFailedAttempt(ctx, userID, mfaMaxFailedAttempts, s.now().Add(mfaLockDuration)); err != nil {
+		return err
+	}
+	return ErrInvalidMFACode
+}
+
+func (s *MFAService) issueRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, mfaRecoveryCodeCount)
+	hashes := make([]string, mfaRecoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(normalizeRecoveryCode(code))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes, s.now()); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *MFAService) encrypt(plaintext string) (string, error) {
+	block, err := aes.NewCipher(s.encryptionKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func (s *MFAService) decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(s.encryptionKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// generateRecoveryCode returns a code such as "K7QF-M2XA".
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, mfaRecoveryCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	code := totpEncoding.EncodeToString(buf)
+	return code[:4] + "-" + code[4:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMFARepository is a mock implementation of MFARepository.
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetUserMFA(ctx context.Context, userID string) (*UserMFA, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserMFA), args.Error(1)
+}
+
+func (m *MockMFARepository) SaveUserMFA(ctx context.Context, mfa *UserMFA) error {
+	args := m.Called(ctx, mfa)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) DeleteUserMFA(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ClaimStep(ctx context.Context, userID string, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) RecordFailedAttempt(ctx context.Context, userID string, maxAttempts int, lockUntil time.Time) error {
+	args := m.Called(ctx, userID, maxAttempts, lockUntil)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ResetFailedAttempts(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string, at time.Time) error {
+	args := m.Called(ctx, userID, codeHashes, at)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID, codeHash string, at time.Time) (bool, error) {
+	args := m.Called(ctx, userID, codeHash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockMFARepository) IsMFARequired(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) ListRolePolicies(ctx context.Context, companyID string) ([]MFARolePolicy, error) {
+	args := m.Called(ctx, companyID)
+	return args.Get(0).([]MFARolePolicy), args.Error(1)
+}
+
+func (m *MockMFARepository) SetRolePolicies(ctx context.Context, companyID string, roleIDs []string, createdBy string) error {
+	args := m.Called(ctx, companyID, roleIDs, createdBy)
+	return args.Error(0)
+}
+
+// testClock lets tests move to the next TOTP time step.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestMFAService() (*MFAService, *MockMFARepository, *testClock) {
+	repo := new(MockMFARepository)
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	svc := NewMFAService(repo, "encryption-key", testSecret, "Malaka ERP")
+	svc.now = clock.Now
+	return svc, repo, clock
+}
+
+// enrolledMFA returns a confirmed enrollment of user-1 and its TOTP secret.
+func enrolledMFA(t *testing.T, svc *MFAService) (*UserMFA, string) {
+	t.Helper()
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	encrypted, err := svc.encrypt(secret)
+	require.NoError(t, err)
+	enabledAt := svc.now().Add(-time.Hour)
+	return &UserMFA{UserID: "user-1", SecretEncrypted: encrypted, EnabledAt: &enabledAt}, secret
+}
+
+// currentStep returns a valid TOTP code for the clock and the time step it claims.
+func currentStep(t *testing.T, secret string, clock *testClock) (string, int64) {
+	t.Helper()
+	code, err := TOTPCode(secret, clock.now)
+	require.NoError(t, err)
+	step, ok := ValidateTOTP(secret, code, clock.now)
+	require.True(t, ok)
+	return code, step
+}
+
+func TestMFAService_EnrollmentRequiresConfirmation(t *testing.T) {
+	svc, repo, clock := newTestMFAService()
+	ctx := context.Background()
+	repo.On("GetUserMFA", ctx, "user-1").Return(nil, nil).Once()
+	repo.On("SaveUserMFA", ctx, mock.AnythingOfType("*auth.UserMFA")).Return(nil).Once()
+
+	enrollment, err := svc.BeginEnrollment(ctx, "user-1", "user@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.OTPAuthURL, "secret="+enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.QRCode, "data:image/png;base64,"))
+
+	// The secret is stored encrypted and the enrollment is not active yet
+	pending := repo.Calls[1].Arguments.Get(1).(*UserMFA)
+	assert.NotContains(t, pending.SecretEncrypted, enrollment.Secret)
+	assert.Nil(t, pending.EnabledAt)
+
+	repo.On("GetUserMFA", ctx, "user-1").Return(pending, nil).Twice()
+	repo.On("RecordFailedAttempt", ctx, "user-1", mfaMaxFailedAttempts, clock.now.Add(mfaLockDuration)).Return(nil).Once()
+	_, err = svc.ConfirmEnrollment(ctx, "user-1", "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	code, step := currentStep(t, enrollment.Secret, clock)
+	repo.On("ClaimStep", ctx, "user-1", step).Return(true, nil).Once()
+	repo.On("SaveUserMFA", ctx, pending).Return(nil).Once()
+	repo.On("ReplaceRecoveryCodes", ctx, "user-1", mock.AnythingOfType("[]string"), clock.now).Return(nil).Once()
+	recoveryCodes, err := svc.ConfirmEnrollment(ctx, "user-1", code)
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, mfaRecoveryCodeCount)
+	assert.True(t, pending.IsEnabled())
+
+	// Only the hashes of the recovery codes are stored
+	hashes := repo.Calls[len(repo.Calls)-1].Arguments.Get(2).([]string)
+	require.Len(t, hashes, mfaRecoveryCodeCount)
+	for i, recoveryCode := range recoveryCodes {
+		assert.Equal(t, hashRecoveryCode(normalizeRecoveryCode(recoveryCode)), hashes[i])
+	}
+
+	repo.On("GetUserMFA", ctx, "user-1").Return(pending, nil).Twice()
+	repo.On("IsMFARequired", ctx, "user-1").Return(false, nil).Once()
+	repo.On("CountRecoveryCodes", ctx, "user-1").Return(mfaRecoveryCodeCount, nil).Once()
+	status, err := svc.Status(ctx, "user-1")
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, MFAMethodAuthenticator, status.Method)
+	assert.Equal(t, mfaRecoveryCodeCount, status.RecoveryCodesRemaining)
+
+	_, err = svc.BeginEnrollment(ctx, "user-1", "user@example.com")
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_VerifyRejectsReplayedCode(t *testing.T) {
+	svc, repo, clock := newTestMFAService()
+	ctx := context.Background()
+	mfa, secret := enrolledMFA(t, svc)
+	repo.On("GetUserMFA", ctx, "user-1").Return(mfa, nil).Times(3)
+	repo.On("RecordFailedAttempt", ctx, "user-1", mfaMaxFailedAttempts, mock.AnythingOfType("time.Time")).Return(nil).Twice()
+
+	code, step := currentStep(t, secret, clock)
+	repo.On("ClaimStep", ctx, "user-1", step).Return(true, nil).Once()
+	require.NoError(t, svc.Verify(ctx, "user-1", code))
+	assert.Equal(t, step, mfa.LastUsedStep)
+	assert.ErrorIs(t, svc.Verify(ctx, "user-1", code), ErrInvalidMFACode)
+
+	// A step claimed concurrently by another login is rejected as well
+	clock.advance(totpPeriod * time.Second)
+	next, nextStep := currentStep(t, secret, clock)
+	repo.On("ClaimStep", ctx, "user-1", nextStep).Return(false, nil).Once()
+	assert.ErrorIs(t, svc.Verify(ctx, "user-1", next), ErrInvalidMFACode)
+
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_RecoveryCodesAreSingleUse(t *testing.T) {
+	svc, repo, _ := newTestMFAService()
+	ctx := context.Background()
+	mfa, _ := enrolledMFA(t, svc)
+	recoveryCode, err := generateRecoveryCode()
+	require.NoError(t, err)
+	hash := hashRecoveryCode(normalizeRecoveryCode(recoveryCode))
+
+	repo.On("GetUserMFA", ctx, "user-1").Return(mfa, nil).Twice()
+	repo.On("UseRecoveryCode", ctx, "user-1", hash, mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+	repo.On("ResetFailedAttempts", ctx, "user-1").Return(nil).Once()
+	repo.On("UseRecoveryCode", ctx, "user-1", hash, mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+	repo.On("RecordFailedAttempt", ctx, "user-1", mfaMaxFailedAttempts, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	// Recovery codes are accepted regardless of case and separators
+	require.NoError(t, svc.Verify(ctx, "user-1", strings.ToLower(strings.ReplaceAll(recoveryCode, "-", ""))))
+	assert.ErrorIs(t, svc.Verify(ctx, "user-1", recoveryCode), ErrInvalidMFACode)
+	repo.AssertNotCalled(t, "ClaimStep", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_LocksAfterRepeatedFailures(t *testing.T) {
+	svc, repo, clock := newTestMFAService()
+	ctx := context.Background()
+	mfa, secret := enrolledMFA(t, svc)
+	repo.On("GetUserMFA", ctx, "user-1").Return(mfa, nil).Times(3)
+
+	// The repository locks the enrollment once the failures reach the limit
+	lockUntil := clock.now.Add(mfaLockDuration)
+	repo.On("RecordFailedAttempt", ctx, "user-1", mfaMaxFailedAttempts, lockUntil).Return(nil).Once()
+	assert.ErrorIs(t, svc.Verify(ctx, "user-1", "000000"), ErrInvalidMFACode)
+
+	mfa.LockedUntil = &lockUntil
+	code, _ := currentStep(t, secret, clock)
+	assert.ErrorIs(t, svc.Verify(ctx, "user-1", code), ErrMFALocked)
+
+	clock.advance(mfaLockDuration)
+	code, step := currentStep(t, secret, clock)
+	repo.On("ClaimStep", ctx, "user-1", step).Return(true, nil).Once()
+	assert.NoError(t, svc.Verify(ctx, "user-1", code))
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_DisableBlockedByRolePolicy(t *testing.T) {
+	svc, repo, _ := newTestMFAService()
+	ctx := context.Background()
+	repo.On("IsMFARequired", ctx, "user-1").Return(true, nil).Once()
+	repo.On("IsMFARequired", ctx, "user-1").Return(false, nil).Once()
+	repo.On("DeleteUserMFA", ctx, "user-1").Return(nil).Once()
+
+	assert.ErrorIs(t, svc.Disable(ctx, "user-1"), ErrMFARequiredByPolicy)
+	require.NoError(t, svc.Disable(ctx, "user-1"))
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_ChallengeTokenIsNotAnAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc, _, clock := newTestMFAService()
+
+	challenge, err := svc.IssueChallenge("user-1", true)
+	require.NoError(t, err)
+	userID, enroll, err := svc.ParseChallenge(challenge.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userID)
+	assert.True(t, enroll)
+
+	// A challenge must not pass the access token middleware
+	r := gin.New()
+	r.GET("/me", Middleware(testSecret), func(c *gin.Context) { c.Status(http.StatusOK) })
+	req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+challenge.Token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	clock.advance(mfaChallengeTTL + time.Second)
+	_, _, err = svc.ParseChallenge(challenge.Token)
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+
+	// Nor can an access token be used as a challenge
+	accessToken, err := NewAccessToken(testSubject(), "session-1", testSecret, clock.now, time.Minute)
+	require.NoError(t, err)
+	_, _, err = svc.ParseChallenge(accessToken)
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
@@ -1,15 +1,29 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// TokenVerifier performs checks on a parsed access token beyond its signature and
+// expiry, such as rejecting tokens of revoked sessions.
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, claims *Claims) error
+}
+
 // Middleware is a Gin middleware for authentication.
 // Returns proper JSON error responses for authentication failures.
 func Middleware(secret string) gin.HandlerFunc {
+	return MiddlewareWithVerifier(secret, nil)
+}
+
+// MiddlewareWithVerifier is Middleware with an additional token verifier, used to
+// honour session termination and user revocation before tokens expire.
+func MiddlewareWithVerifier(secret string, verifier TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -52,11 +66,31 @@ func Middleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		if verifier != nil {
+			if err := verifier.VerifyToken(c.Request.Context(), claims); err != nil {
+				if errors.Is(err, ErrSessionRevoked) {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+						"success": false,
+						"message": "Session has been revoked",
+						"code":    "UNAUTHORIZED",
+					})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"success": false,
+					"message": "Unable to verify session",
+					"code":    "SERVICE_UNAVAILABLE",
+				})
+				return
+			}
+		}
+
 		// Set user info in context for downstream handlers
 		c.Set("user_id", claims.Subject)
 		c.Set("user_role", claims.Role)
 		c.Set("company_id", claims.CompanyID)
 		c.Set("user_email", claims.Email)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	}
 }
@@ -130,6 +164,33 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
+// RestrictRoleToPaths confines users of the given role to the routes under the given
+// path prefixes; users of other roles pass through. It keeps external users, such as
+// supplier portal accounts, off internal routes that have no permission checks.
+// Must be used after Middleware().
+func RestrictRoleToPaths(role string, prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserRole(c) != role {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		for _, prefix := range prefixes {
+			if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "Access denied",
+			"code":    "FORBIDDEN",
+		})
+	}
+}
+
 // GetUserID extracts user ID from context. Returns empty string if not authenticated.
 func GetUserID(c *gin.Context) string {
 	userID, _ := c.Get("user_id")
@@ -139,6 +200,15 @@ func GetUserID(c *gin.Context) string {
 	return ""
 }
 
+// GetSessionID extracts the login session ID from context. Returns empty string for tokens without a session.
+func GetSessionID(c *gin.Context) string {
+	sessionID, _ := c.Get("session_id")
+	if id, ok := sessionID.(string); ok {
+		return id
+	}
+	return ""
+}
+
 // GetUserRole extracts user role from context. Returns empty string if not set.
 func GetUserRole(c *gin.Context) string {
 	role, _ := c.Get("user_role")
@@ -1,9 +1,34 @@
 package auth
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMiddleware(t *testing.T) {
 	// Placeholder for middleware tests
 }
+
+func TestRestrictRoleToPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(role, path string) int {
+		r := gin.New()
+		r.Use(func(c *gin.Context) { c.Set("user_role", role) })
+		r.Use(RestrictRoleToPaths("supplier", "/api/v1/supplier-portal", "/api/v1/notifications"))
+		r.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("supplier", "/api/v1/supplier-portal/purchase-orders"))
+	assert.Equal(t, http.StatusOK, serve("supplier", "/api/v1/notifications"))
+	assert.Equal(t, http.StatusForbidden, serve("supplier", "/api/v1/action-items"))
+	assert.Equal(t, http.StatusForbidden, serve("supplier", "/api/v1/supplier-portal-admin"))
+	assert.Equal(t, http.StatusOK, serve("admin", "/api/v1/action-items"))
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Security audit actions for password and login events.
+const (
+	SecurityEventLoginFailed           = "login_failed"
+	SecurityEventAccountLocked         = "account_locked"
+	SecurityEventAccountUnlocked       = "account_unlocked"
+	SecurityEventPasswordChanged       = "password_changed"
+	SecurityEventPasswordResetRequest  = "password_reset_requested"
+	SecurityEventPasswordResetComplete = "password_reset_completed"
+	SecurityEventPasswordResetInvalid  = "password_reset_invalid_token"
+)
+
+// SessionRevokedPasswordReset is the revocation reason of sessions ended by a password reset.
+const SessionRevokedPasswordReset = "password_reset"
+
+var (
+	// ErrAccountLocked is returned for logins while the account is locked after failed attempts.
+	ErrAccountLocked = errors.New("account is temporarily locked after too many failed login attempts")
+	// ErrPasswordReused is returned when a new password matches one of the recent passwords.
+	ErrPasswordReused = errors.New("password was used recently, choose a different one")
+	// ErrInvalidResetToken is returned for unknown, used or expired password reset tokens.
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+)
+
+// PasswordUser is the credential state of a user.
+type PasswordUser struct {
+	ID                  string     `db:"id"`
+	Username            string     `db:"username"`
+	Email               string     `db:"email"`
+	FullName            *string    `db:"full_name"`
+	PasswordHash        string     `db:"password"`
+	Status              *string    `db:"status"`
+	FailedLoginAttempts int        `db:"failed_login_attempts"`
+	LockedUntil         *time.Time `db:"locked_until"`
+}
+
+// IsLocked reports whether the account is locked at the given time.
+func (u *PasswordUser) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// PasswordResetToken is a single-use reset token. Only the hash is stored.
+type PasswordResetToken struct {
+	ID          string     `db:"id"`
+	UserID      string     `db:"user_id"`
+	TokenHash   string     `db:"token_hash"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	UsedAt      *time.Time `db:"used_at"`
+	RequestedIP string     `db:"requested_ip"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+// SecurityEvent is an audit record of a password or login event.
+type SecurityEvent struct {
+	UserID    string
+	Action    string
+	Success   bool
+	IPAddress string
+	UserAgent string
+	Details   map[string]interface{}
+}
+
+// SecurityAuditor records security events in the audit log.
+type SecurityAuditor interface {
+	RecordSecurityEvent(ctx context.Context, event *SecurityEvent) error
+}
+
+// PasswordRepository defines persistence for passwords, password history, reset tokens and lockouts.
+type PasswordRepository interface {
+	GetPasswordUser(ctx context.Context, userID string) (*PasswordUser, error)
+	FindPasswordUserByEmail(ctx context.Context, email string) (*PasswordUser, error)
+	// UpdatePassword stores the new hash, moves the old one into the history (keeping
+	// historyLimit entries) and clears any lockout.
+	UpdatePassword(ctx context.Context, userID, passwordHash string, at time.Time, historyLimit int) error
+	GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error)
+
+	// CreateResetToken stores a reset token and invalidates the user's older unused tokens.
+	CreateResetToken(ctx context.Context, token *PasswordResetToken) error
+	GetResetTokenByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	// MarkResetTokenUsed marks an unused token as used and reports false when it was already used.
+	MarkResetTokenUsed(ctx context.Context, id string, at time.Time) (bool, error)
+
+	// RecordFailedLogin counts a failed login and reports whether it locked the account.
+	RecordFailedLogin(ctx context.Context, userID string, maxAttempts int, lockUntil time.Time) (bool, error)
+	ResetFailedLogins(ctx context.Context, userID string) error
+}
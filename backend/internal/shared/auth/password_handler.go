@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordHandler handles the public forgot-password and reset endpoints.
+type PasswordHandler struct {
+	svc *PasswordService
+}
+
+// NewPasswordHandler creates a new PasswordHandler.
+func NewPasswordHandler(svc *PasswordService) *PasswordHandler {
+	return &PasswordHandler{svc: svc}
+}
+
+// GetPolicy returns the password rules so clients can show them before submitting.
+func (h *PasswordHandler) GetPolicy(c *gin.Context) {
+	policy := h.svc.Policy(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"min_length":        policy.MinLength,
+		"require_uppercase": policy.RequireUppercase,
+		"require_lowercase": policy.RequireLowercase,
+		"require_number":    policy.RequireNumber,
+		"require_symbol":    policy.RequireSymbol,
+		"check_breached":    policy.CheckBreached,
+		"history_count":     policy.HistoryCount,
+	}})
+}
+
+// ForgotPassword emails a reset link. The response is the same whether or not the
+// address belongs to an account.
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request", "error": err.Error()})
+		return
+	}
+
+	meta := SessionMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.svc.RequestReset(c.Request.Context(), req.Email, meta); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to process password reset request"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "If an account exists for this email, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out everywhere.
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token           string `json:"token" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+		ConfirmPassword string `json:"confirm_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request", "error": err.Error()})
+		return
+	}
+	if req.NewPassword != req.ConfirmPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "New password and confirmation do not match"})
+		return
+	}
+
+	meta := SessionMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.svc.ResetPassword(c.Request.Context(), req.Token, req.NewPassword, meta); err != nil {
+		PasswordError(c, err, "Failed to reset password")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password has been reset, please sign in with your new password"})
+}
+
+// PasswordError writes the response for password policy, history and reset token errors.
+func PasswordError(c *gin.Context, err error, fallback string) {
+	var policyErr *PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": policyErr.Error(), "violations": policyErr.Violations})
+	case errors.Is(err, ErrPasswordReused):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	case errors.Is(err, ErrInvalidResetToken):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fallback})
+	}
+}
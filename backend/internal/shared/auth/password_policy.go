@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed breached_passwords.txt
+var breachedPasswordList string
+
+var (
+	breachedOnce sync.Once
+	breachedSet  map[string]struct{}
+)
+
+// PasswordPolicy describes the rules new passwords must satisfy and how failed
+// logins are handled. It is configured through the security settings.
+type PasswordPolicy struct {
+	MinLength            int  `json:"min_length"`
+	RequireUppercase     bool `json:"require_uppercase"`
+	RequireLowercase     bool `json:"require_lowercase"`
+	RequireNumber        bool `json:"require_number"`
+	RequireSymbol        bool `json:"require_symbol"`
+	CheckBreached        bool `json:"check_breached"`
+	HistoryCount         int  `json:"history_count"`     // recent passwords, including the current one, that cannot be reused
+	MaxFailedLogins      int  `json:"max_failed_logins"` // 0 disables lockout
+	LockoutMinutes       int  `json:"lockout_minutes"`
+	ResetTokenTTLMinutes int  `json:"reset_token_ttl_minutes"`
+}
+
+// DefaultPasswordPolicy is used when the security settings are missing.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:            8,
+		RequireUppercase:     true,
+		RequireLowercase:     true,
+		RequireNumber:        true,
+		RequireSymbol:        false,
+		CheckBreached:        true,
+		HistoryCount:         5,
+		MaxFailedLogins:      5,
+		LockoutMinutes:       15,
+		ResetTokenTTLMinutes: 60,
+	}
+}
+
+// PasswordPolicyProvider loads the current password policy.
+type PasswordPolicyProvider interface {
+	GetPasswordPolicy(ctx context.Context) (*PasswordPolicy, error)
+}
+
+// PasswordPolicyError lists every rule a rejected password violates.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the policy: " + strings.Join(e.Violations, "; ")
+}
+
+// Validate checks a password against the policy. personalInfo holds values such as the
+// username or email that must not appear in the password.
+func (p *PasswordPolicy) Validate(password string, personalInfo ...string) error {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+
+	var hasUpper, hasLower, hasNumber, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasNumber = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireNumber && !hasNumber {
+		violations = append(violations, "must contain a number")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	lower := strings.ToLower(password)
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if at := strings.Index(info, "@"); at > 0 {
+			info = info[:at]
+		}
+		if len(info) >= 3 && strings.Contains(lower, info) {
+			violations = append(violations, "must not contain your username or email")
+			break
+		}
+	}
+
+	if p.CheckBreached && IsBreachedPassword(password) {
+		violations = append(violations, "is too common and appears in known data breaches")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// IsBreachedPassword reports whether the password is on the bundled list of
+// commonly used and breached passwords. The check is case-insensitive.
+func IsBreachedPassword(password string) bool {
+	breachedOnce.Do(func() {
+		breachedSet = make(map[string]struct{})
+		scanner := bufio.NewScanner(strings.NewReader(breachedPasswordList))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			breachedSet[strings.ToLower(line)] = struct{}{}
+		}
+	})
+	_, found := breachedSet[strings.ToLower(password)]
+	return found
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_ValidateListsEveryViolation(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	policy.RequireSymbol = true
+
+	err := policy.Validate("short")
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.ElementsMatch(t, []string{
+		"must be at least 8 characters",
+		"must contain an uppercase letter",
+		"must contain a number",
+		"must contain a symbol",
+	}, policyErr.Violations)
+
+	assert.NoError(t, policy.Validate("Sepatu#Baru2024"))
+}
+
+func TestPasswordPolicy_RejectsBreachedPasswords(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	assert.True(t, IsBreachedPassword("PASSWORD123"))
+	assert.True(t, IsBreachedPassword("bismillah"))
+	assert.False(t, IsBreachedPassword("Sepatu#Baru2024"))
+
+	// Password1 satisfies the character rules but is on the breach list
+	err := policy.Validate("Password1")
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, []string{"is too common and appears in known data breaches"}, policyErr.Violations)
+
+	policy.CheckBreached = false
+	assert.NoError(t, policy.Validate("Password1"))
+}
+
+func TestPasswordPolicy_RejectsPersonalInfo(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	assert.Error(t, policy.Validate("Budiman2024!", "budiman@example.com"))
+	assert.Error(t, policy.Validate("XxBudiXx99", "budi"))
+	assert.NoError(t, policy.Validate("Sepatu#Baru2024", "budiman@example.com"))
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const passwordUserColumns = `id::text, username, email, full_name, password, status, failed_login_attempts, locked_until`
+
+// PasswordRepositoryImpl implements PasswordRepository using sqlx.
+type PasswordRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewPasswordRepositoryImpl creates a new PasswordRepositoryImpl.
+func NewPasswordRepositoryImpl(db *sqlx.DB) *PasswordRepositoryImpl {
+	return &PasswordRepositoryImpl{db: db}
+}
+
+// GetPasswordUser retrieves the credential state of a user. Returns nil when it does not exist.
+func (r *PasswordRepositoryImpl) GetPasswordUser(ctx context.Context, userID string) (*PasswordUser, error) {
+	return r.getPasswordUser(ctx, `SELECT `+passwordUserColumns+` FROM users WHERE id::text = $1`, userID)
+}
+
+// FindPasswordUserByEmail retrieves a user by email, ignoring case. Returns nil when it does not exist.
+func (r *PasswordRepositoryImpl) FindPasswordUserByEmail(ctx context.Context, email string) (*PasswordUser, error) {
+	return r.getPasswordUser(ctx, `SELECT `+passwordUserColumns+` FROM users WHERE LOWER(email) = LOWER($1)`, email)
+}
+
+func (r *PasswordRepositoryImpl) getPasswordUser(ctx context.Context, query string, arg string) (*PasswordUser, error) {
+	var user PasswordUser
+	if err := r.db.GetContext(ctx, &user, query, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user credentials: %w", err)
+	}
+	return &user, nil
+}
+
+// UpdatePassword stores the new hash, keeps the old one in the history and clears any lockout.
+func (r *PasswordRepositoryImpl) UpdatePassword(ctx context.Context, userID, passwordHash string, at time.Time, historyLimit int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if historyLimit > 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO password_history (user_id, password_hash, created_at)
+			SELECT id, password, $2 FROM users WHERE id::text = $1
+		`, userID, at)
+		if err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM password_history
+		WHERE user_id::text = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id::text = $1
+			ORDER BY created_at DESC LIMIT $2
+		)
+	`, userID, historyLimit)
+	if err != nil {
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET password = $2, password_changed_at = $3, failed_login_attempts = 0, locked_until = NULL, updated_at = $3
+		WHERE id::text = $1
+	`, userID, passwordHash, at)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("user not found")
+	}
+	return tx.Commit()
+}
+
+// GetPasswordHistory returns the most recent previous password hashes, newest first.
+func (r *PasswordRepositoryImpl) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	hashes := []string{}
+	query := `
+		SELECT password_hash FROM password_history
+		WHERE user_id::text = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+	if err := r.db.SelectContext(ctx, &hashes, query, userID, limit); err != nil {
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
+	return hashes, nil
+}
+
+// CreateResetToken stores a reset token and invalidates the user's older unused tokens.
+func (r *PasswordRepositoryImpl) CreateResetToken(ctx context.Context, token *PasswordResetToken) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = $2
+		WHERE user_id::text = $1 AND used_at IS NULL
+	`, token.UserID, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, requested_ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.RequestedIP, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetResetTokenByHash retrieves a reset token by its hash. Returns nil when it does not exist.
+func (r *PasswordRepositoryImpl) GetResetTokenByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	query := `
+		SELECT id::text, user_id::text, token_hash, expires_at, used_at, COALESCE(requested_ip, '') AS requested_ip, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+	`
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reset token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkResetTokenUsed marks an unused token as used and reports false when it was already used.
+func (r *PasswordRepositoryImpl) MarkResetTokenUsed(ctx context.Context, id string, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = $2
+		WHERE id::text = $1 AND used_at IS NULL
+	`, id, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark reset token used: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// RecordFailedLogin counts a failed login and locks the account once maxAttempts is reached.
+func (r *PasswordRepositoryImpl) RecordFailedLogin(ctx context.Context, userID string, maxAttempts int, lockUntil time.Time) (bool, error) {
+	var locked bool
+	query := `
+		UPDATE users
+		SET failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= $2 THEN 0 ELSE failed_login_attempts + 1 END,
+		    locked_until = CASE WHEN failed_login_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE id::text = $1
+		RETURNING failed_login_attempts = 0
+	`
+	if err := r.db.GetContext(ctx, &locked, query, userID, maxAttempts, lockUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	return locked, nil
+}
+
+// ResetFailedLogins clears the failed login counter and any lockout.
+func (r *PasswordRepositoryImpl) ResetFailedLogins(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users SET failed_login_attempts = 0, locked_until = NULL
+		WHERE id::text = $1 AND (failed_login_attempts <> 0 OR locked_until IS NOT NULL)
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"malaka/internal/shared/email"
+	"malaka/internal/shared/uuid"
+)
+
+const (
+	passwordPolicyCacheTTL = time.Minute
+	resetTokenBytes        = 32
+)
+
+// PasswordResetMailer delivers password reset links.
+type PasswordResetMailer interface {
+	SendPasswordResetEmail(to string, data email.PasswordResetEmailData) error
+}
+
+// UserSessionRevoker ends every session of a user.
+type UserSessionRevoker interface {
+	RevokeUserSessions(ctx context.Context, userID, reason string) error
+}
+
+// PasswordService enforces the password policy and handles password changes,
+// self-service resets and account lockout after failed logins.
+type PasswordService struct {
+	repo        PasswordRepository
+	policies    PasswordPolicyProvider
+	mailer      PasswordResetMailer
+	auditor     SecurityAuditor
+	sessions    UserSessionRevoker
+	frontendURL string
+	logoURL     string
+	hash        func(password string) (string, error)
+	now         func() time.Time
+
+	mu             sync.Mutex
+	cachedPolicy   *PasswordPolicy
+	policyCachedAt time.Time
+}
+
+// NewPasswordService creates a new PasswordService. Reset links point to frontendURL.
+// policies, mailer, auditor and sessions may be nil.
+func NewPasswordService(repo PasswordRepository, policies PasswordPolicyProvider, mailer PasswordResetMailer, auditor SecurityAuditor, sessions UserSessionRevoker, frontendURL, logoURL string) *PasswordService {
+	return &PasswordService{
+		repo:        repo,
+		policies:    policies,
+		mailer:      mailer,
+		auditor:     auditor,
+		sessions:    sessions,
+		frontendURL: strings.TrimRight(frontendURL, "/"),
+		logoURL:     logoURL,
+		hash:        hashPassword,
+		now:         time.Now,
+	}
+}
+
+// Policy returns the current password policy, falling back to the defaults when
+// the security settings cannot be loaded.
+func (s *PasswordService) Policy(ctx context.Context) *PasswordPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cachedPolicy != nil && s.now().Sub(s.policyCachedAt) < passwordPolicyCacheTTL {
+		return s.cachedPolicy
+	}
+	policy := DefaultPasswordPolicy()
+	if s.policies != nil {
+		loaded, err := s.policies.GetPasswordPolicy(ctx)
+		if err != nil {
+			log.Printf("Warning: failed to load password policy, using defaults: %v", err)
+		} else if loaded != nil {
+			policy = loaded
+		}
+	}
+	s.cachedPolicy = policy
+	s.policyCachedAt = s.now()
+	return policy
+}
+
+// ValidatePassword checks a new password against the policy.
+func (s *PasswordService) ValidatePassword(ctx context.Context, password string, personalInfo ...string) error {
+	return s.Policy(ctx).Validate(password, personalInfo...)
+}
+
+// HashPassword validates a new password for a user that does not exist yet and hashes it.
+func (s *PasswordService) HashPassword(ctx context.Context, password string, personalInfo ...string) (string, error) {
+	if err := s.ValidatePassword(ctx, password, personalInfo...); err != nil {
+		return "", err
+	}
+	return s.hash(password)
+}
+
+// ChangePassword sets a new password for a user after checking the policy and the
+// password history. The caller is responsible for verifying the current password.
+func (s *PasswordService) ChangePassword(ctx context.Context, userID, newPassword string, meta SessionMetadata) error {
+	user, err := s.repo.GetPasswordUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	s.record(ctx, &SecurityEvent{UserID: userID, Action: SecurityEventPasswordChanged, Success: true, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent})
+	return nil
+}
+
+// RequestReset emails a single-use reset link when the address belongs to an active
+// user. It returns nil for unknown addresses so callers cannot probe for accounts.
+func (s *PasswordService) RequestReset(ctx context.Context, emailAddress string, meta SessionMetadata) error {
+	user, err := s.repo.FindPasswordUserByEmail(ctx, strings.TrimSpace(emailAddress))
+	if err != nil {
+		return err
+	}
+	if user == nil || !isActiveStatus(user.Status) {
+		s.record(ctx, &SecurityEvent{Action: SecurityEventPasswordResetRequest, Success: false, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent,
+			Details: map[string]interface{}{"email": emailAddress, "reason": "unknown or inactive account"}})
+		return nil
+	}
+
+	policy := s.Policy(ctx)
+	token, err := generateResetToken()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	record := &PasswordResetToken{
+		ID:          uuid.New().String(),
+		UserID:      user.ID,
+		TokenHash:   hashResetToken(token),
+		ExpiresAt:   now.Add(time.Duration(policy.ResetTokenTTLMinutes) * time.Minute),
+		RequestedIP: meta.IPAddress,
+		CreatedAt:   now,
+	}
+	if err := s.repo.CreateResetToken(ctx, record); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	if s.mailer != nil {
+		data := email.PasswordResetEmailData{
+			RecipientName: user.Username,
+			ResetLink:     fmt.Sprintf("%s/auth/reset-password?token=%s", s.frontendURL, token),
+			ExpiryMinutes: policy.ResetTokenTTLMinutes,
+			LogoURL:       s.logoURL,
+		}
+		if user.FullName != nil && *user.FullName != "" {
+			data.RecipientName = *user.FullName
+		}
+		if err := s.mailer.SendPasswordResetEmail(user.Email, data); err != nil {
+			log.Printf("Warning: failed to send password reset email to %s: %v", user.Email, err)
+		}
+	}
+
+	s.record(ctx, &SecurityEvent{UserID: user.ID, Action: SecurityEventPasswordResetRequest, Success: true, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent})
+	return nil
+}
+
+// ResetPassword consumes a reset token, sets the new password, clears any lockout and
+// signs the user out everywhere.
+func (s *PasswordService) ResetPassword(ctx context.Context, token, newPassword string, meta SessionMetadata) error {
+	record, err := s.repo.GetResetTokenByHash(ctx, hashResetToken(token))
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if record == nil || record.UsedAt != nil || !now.Before(record.ExpiresAt) {
+		s.invalidToken(ctx, record, meta)
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.repo.GetPasswordUser(ctx, record.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil || !isActiveStatus(user.Status) {
+		s.invalidToken(ctx, record, meta)
+		return ErrInvalidResetToken
+	}
+
+	// Validate before consuming the token so a rejected password can be retried
+	if err := s.checkNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	claimed, err := s.repo.MarkResetTokenUsed(ctx, record.ID, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		s.invalidToken(ctx, record, meta)
+		return ErrInvalidResetToken
+	}
+	if err := s.storePassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	if s.sessions != nil {
+		if err := s.sessions.RevokeUserSessions(ctx, user.ID, SessionRevokedPasswordReset); err != nil {
+			log.Printf("Warning: failed to revoke sessions after password reset for user %s: %v", user.ID, err)
+		}
+	}
+	s.record(ctx, &SecurityEvent{UserID: user.ID, Action: SecurityEventPasswordResetComplete, Success: true, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent})
+	return nil
+}
+
+// CheckLockout returns ErrAccountLocked while the account is locked.
+func (s *PasswordService) CheckLockout(ctx context.Context, userID string) error {
+	user, err := s.repo.GetPasswordUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user != nil && user.IsLocked(s.now()) {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+// RecordFailedLogin counts a failed login and locks the account once the policy
+// limit is reached. userID is empty for unknown accounts.
+func (s *PasswordService) RecordFailedLogin(ctx context.Context, userID, identifier string, meta SessionMetadata) error {
+	details := map[string]interface{}{"identifier": identifier}
+	if userID == "" {
+		s.record(ctx, &SecurityEvent{Action: SecurityEventLoginFailed, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent, Details: details})
+		return nil
+	}
+
+	policy := s.Policy(ctx)
+	locked := false
+	if policy.MaxFailedLogins > 0 {
+		lockUntil := s.now().Add(time.Duration(policy.LockoutMinutes) * time.Minute)
+		var err error
+		locked, err = s.repo.RecordFailedLogin(ctx, userID, policy.MaxFailedLogins, lockUntil)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.record(ctx, &SecurityEvent{UserID: userID, Action: SecurityEventLoginFailed, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent, Details: details})
+	if locked {
+		s.record(ctx, &SecurityEvent{UserID: userID, Action: SecurityEventAccountLocked, Success: true, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent,
+			Details: map[string]interface{}{"failed_attempts": policy.MaxFailedLogins, "lockout_minutes": policy.LockoutMinutes}})
+	}
+	return nil
+}
+
+// RecordSuccessfulLogin clears the failed login counter.
+func (s *PasswordService) RecordSuccessfulLogin(ctx context.Context, userID string) error {
+	return s.repo.ResetFailedLogins(ctx, userID)
+}
+
+// UnlockAccount clears a lockout before it expires.
+func (s *PasswordService) UnlockAccount(ctx context.Context, userID, actorID string) error {
+	if err := s.repo.ResetFailedLogins(ctx, userID); err != nil {
+		return err
+	}
+	s.record(ctx, &SecurityEvent{UserID: userID, Action: SecurityEventAccountUnlocked, Success: true,
+		Details: map[string]interface{}{"unlocked_by": actorID}})
+	return nil
+}
+
+func (s *PasswordService) setPassword(ctx context.Context, user *PasswordUser, newPassword string) error {
+	if err := s.checkNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	return s.storePassword(ctx, user, newPassword)
+}
+
+// checkNewPassword applies the policy and rejects the current and recent passwords.
+func (s *PasswordService) checkNewPassword(ctx context.Context, user *PasswordUser, newPassword string) error {
+	policy := s.Policy(ctx)
+	if err := policy.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+	if policy.HistoryCount <= 0 {
+		return nil
+	}
+
+	hashes := []string{user.PasswordHash}
+	if policy.HistoryCount > 1 {
+		history, err := s.repo.GetPasswordHistory(ctx, user.ID, policy.HistoryCount-1)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, history...)
+	}
+	for _, hash := range hashes {
+		if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(newPassword)) == nil {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+func (s *PasswordService) storePassword(ctx context.Context, user *PasswordUser, newPassword string) error {
+	hashed, err := s.hash(newPassword)
+	if err != nil {
+		return err
+	}
+	historyLimit := s.Policy(ctx).HistoryCount - 1
+	if historyLimit < 0 {
+		historyLimit = 0
+	}
+	return s.repo.UpdatePassword(ctx, user.ID, hashed, s.now(), historyLimit)
+}
+
+func (s *PasswordService) invalidToken(ctx context.Context, record *PasswordResetToken, meta SessionMetadata) {
+	event := &SecurityEvent{Action: SecurityEventPasswordResetInvalid, IPAddress: meta.IPAddress, UserAgent: meta.UserAgent}
+	if record != nil {
+		event.UserID = record.UserID
+	}
+	s.record(ctx, event)
+}
+
+// record writes a security event; audit failures never block authentication.
+func (s *PasswordService) record(ctx context.Context, event *SecurityEvent) {
+	if s.auditor == nil {
+		return
+	}
+	if err := s.auditor.RecordSecurityEvent(ctx, event); err != nil {
+		log.Printf("Warning: failed to record security event %s: %v", event.Action, err)
+	}
+}
+
+func isActiveStatus(status *string) bool {
+	return status == nil || *status == "" || *status == "active"
+}
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+func generateResetToken() (string, error) {
+	b := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"malaka/internal/shared/email"
+)
+
+// MockPasswordRepository is a mock implementation of PasswordRepository.
+type MockPasswordRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordRepository) GetPasswordUser(ctx context.Context, userID string) (*PasswordUser, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PasswordUser), args.Error(1)
+}
+
+func (m *MockPasswordRepository) FindPasswordUserByEmail(ctx context.Context, address string) (*PasswordUser, error) {
+	args := m.Called(ctx, address)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PasswordUser), args.Error(1)
+}
+
+func (m *MockPasswordRepository) UpdatePassword(ctx context.Context, userID, passwordHash string, at time.Time, historyLimit int) error {
+	args := m.Called(ctx, userID, passwordHash, at, historyLimit)
+	return args.Error(0)
+}
+
+func (m *MockPasswordRepository) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	args := m.Called(ctx, userID, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockPasswordRepository) CreateResetToken(ctx context.Context, token *PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordRepository) GetResetTokenByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordRepository) MarkResetTokenUsed(ctx context.Context, id string, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordRepository) RecordFailedLogin(ctx context.Context, userID string, maxAttempts int, lockUntil time.Time) (bool, error) {
+	args := m.Called(ctx, userID, maxAttempts, lockUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordRepository) ResetFailedLogins(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockPasswordResetMailer is a mock implementation of PasswordResetMailer.
+type MockPasswordResetMailer struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetMailer) SendPasswordResetEmail(to string, data email.PasswordResetEmailData) error {
+	args := m.Called(to, data)
+	return args.Error(0)
+}
+
+// MockSecurityAuditor is a mock implementation of SecurityAuditor.
+type MockSecurityAuditor struct {
+	mock.Mock
+}
+
+func (m *MockSecurityAuditor) RecordSecurityEvent(ctx context.Context, event *SecurityEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// events returns the security events that were recorded, in order.
+func (m *MockSecurityAuditor) events() []*SecurityEvent {
+	var events []*SecurityEvent
+	for _, call := range m.Calls {
+		events = append(events, call.Arguments.Get(1).(*SecurityEvent))
+	}
+	return events
+}
+
+func (m *MockSecurityAuditor) actions() []string {
+	actions := []string{}
+	for _, event := range m.events() {
+		actions = append(actions, event.Action)
+	}
+	return actions
+}
+
+// MockUserSessionRevoker is a mock implementation of UserSessionRevoker.
+type MockUserSessionRevoker struct {
+	mock.Mock
+}
+
+func (m *MockUserSessionRevoker) RevokeUserSessions(ctx context.Context, userID, reason string) error {
+	args := m.Called(ctx, userID, reason)
+	return args.Error(0)
+}
+
+// MockPasswordPolicyProvider is a mock implementation of PasswordPolicyProvider.
+type MockPasswordPolicyProvider struct {
+	mock.Mock
+}
+
+func (m *MockPasswordPolicyProvider) GetPasswordPolicy(ctx context.Context) (*PasswordPolicy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PasswordPolicy), args.Error(1)
+}
+
+var passwordTestNow = time.Unix(1700000000, 0)
+
+func newTestPasswordService(repo *MockPasswordRepository, policies *MockPasswordPolicyProvider, mailer *MockPasswordResetMailer, auditor *MockSecurityAuditor, sessions *MockUserSessionRevoker) (*PasswordService, *testClock) {
+	clock := &testClock{now: passwordTestNow}
+	svc := NewPasswordService(repo, policies, mailer, auditor, sessions, "https://erp.example.com/", "")
+	svc.now = clock.Now
+	svc.hash = testPasswordHash
+	return svc, clock
+}
+
+// testPasswordHash hashes at the lowest bcrypt cost to keep the tests fast.
+func testPasswordHash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return string(hashed), err
+}
+
+func mustHash(t *testing.T, password string) string {
+	t.Helper()
+	hashed, err := testPasswordHash(password)
+	require.NoError(t, err)
+	return hashed
+}
+
+func testPasswordUser(t *testing.T) *PasswordUser {
+	return &PasswordUser{ID: "user-1", Username: "budi", Email: "budi@example.com", PasswordHash: mustHash(t, "Initial#Pass1")}
+}
+
+// hashOf matches a bcrypt hash of the password.
+func hashOf(password string) interface{} {
+	return mock.MatchedBy(func(hashed string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)) == nil
+	})
+}
+
+func resetTokenFromLink(t *testing.T, link string) string {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "/auth/reset-password", parsed.Path)
+	return parsed.Query().Get("token")
+}
+
+func TestPasswordService_ChangePasswordEnforcesHistory(t *testing.T) {
+	repo := new(MockPasswordRepository)
+	policies := new(MockPasswordPolicyProvider)
+	auditor := new(MockSecurityAuditor)
+	svc, clock := newTestPasswordService(repo, policies, new(MockPasswordResetMailer), auditor, new(MockUserSessionRevoker))
+	policy := DefaultPasswordPolicy()
+	policy.HistoryCount = 3
+	ctx := context.Background()
+
+	// The current password plus HistoryCount-1 earlier ones are checked
+	user := testPasswordUser(t)
+	user.PasswordHash = mustHash(t, "Third#Pass3")
+	policies.On("GetPasswordPolicy", ctx).Return(policy, nil).Once()
+	repo.On("GetPasswordUser", ctx, "user-1").Return(user, nil).Times(4)
+	repo.On("GetPasswordHistory", ctx, "user-1", 2).Return([]string{mustHash(t, "Second#Pass2"), mustHash(t, "Initial#Pass1")}, nil).Times(3)
+
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "user-1", "Third#Pass3", SessionMetadata{}), ErrPasswordReused)
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "user-1", "Initial#Pass1", SessionMetadata{}), ErrPasswordReused)
+
+	var policyErr *PasswordPolicyError
+	assert.ErrorAs(t, svc.ChangePassword(ctx, "user-1", "weak", SessionMetadata{}), &policyErr)
+	repo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// The repository keeps HistoryCount-1 old hashes next to the new one
+	repo.On("UpdatePassword", ctx, "user-1", hashOf("Fourth#Pass4"), clock.now, 2).Return(nil).Once()
+	auditor.On("RecordSecurityEvent", ctx, mock.AnythingOfType("*auth.SecurityEvent")).Return(nil).Once()
+	require.NoError(t, svc.ChangePassword(ctx, "user-1", "Fourth#Pass4", SessionMetadata{}))
+	assert.Equal(t, []string{SecurityEventPasswordChanged}, auditor.actions())
+	repo.AssertExpectations(t)
+	policies.AssertExpectations(t)
+	auditor.AssertExpectations(t)
+}
+
+func TestPasswordService_ResetTokenIsSingleUse(t *testing.T) {
+	repo := new(MockPasswordRepository)
+	policies := new(MockPasswordPolicyProvider)
+	mailer := new(MockPasswordResetMailer)
+	auditor := new(MockSecurityAuditor)
+	sessions := new(MockUserSessionRevoker)
+	svc, clock := newTestPasswordService(repo, policies, mailer, auditor, sessions)
+	user := testPasswordUser(t)
+	ctx := context.Background()
+	meta := SessionMetadata{IPAddress: "10.0.0.1"}
+
+	policies.On("GetPasswordPolicy", ctx).Return(DefaultPasswordPolicy(), nil).Once()
+	auditor.On("RecordSecurityEvent", ctx, mock.AnythingOfType("*auth.SecurityEvent")).Return(nil).Times(3)
+	repo.On("FindPasswordUserByEmail", ctx, "budi@example.com").Return(user, nil).Once()
+	repo.On("CreateResetToken", ctx, mock.AnythingOfType("*auth.PasswordResetToken")).Return(nil).Once()
+	mailer.On("SendPasswordResetEmail", "budi@example.com", mock.AnythingOfType("email.PasswordResetEmailData")).Return(nil).Once()
+
+	require.NoError(t, svc.RequestReset(ctx, "budi@example.com", meta))
+	sent := mailer.Calls[0].Arguments.Get(1).(email.PasswordResetEmailData)
+	assert.Equal(t, 60, sent.ExpiryMinutes)
+	token := resetTokenFromLink(t, sent.ResetLink)
+	require.NotEmpty(t, token)
+
+	// Only the hash is stored
+	record := repo.Calls[1].Arguments.Get(1).(*PasswordResetToken)
+	assert.Equal(t, hashResetToken(token), record.TokenHash)
+	assert.NotEqual(t, token, record.TokenHash)
+	assert.Equal(t, clock.now.Add(60*time.Minute), record.ExpiresAt)
+	assert.Equal(t, "10.0.0.1", record.RequestedIP)
+
+	repo.On("GetResetTokenByHash", ctx, record.TokenHash).Return(record, nil).Times(3)
+	repo.On("GetPasswordUser", ctx, "user-1").Return(user, nil).Times(3)
+
+	// A rejected password does not consume the token
+	var policyErr *PasswordPolicyError
+	assert.ErrorAs(t, svc.ResetPassword(ctx, token, "password1", meta), &policyErr)
+	repo.AssertNotCalled(t, "MarkResetTokenUsed", mock.Anything, mock.Anything, mock.Anything)
+
+	repo.On("GetPasswordHistory", ctx, "user-1", 4).Return([]string{}, nil).Twice()
+	repo.On("MarkResetTokenUsed", ctx, record.ID, clock.now).Return(true, nil).Once()
+	repo.On("UpdatePassword", ctx, "user-1", hashOf("Brand#New2024"), clock.now, 4).Return(nil).Once()
+	sessions.On("RevokeUserSessions", ctx, "user-1", SessionRevokedPasswordReset).Return(nil).Once()
+	require.NoError(t, svc.ResetPassword(ctx, token, "Brand#New2024", meta))
+
+	// Losing the race to consume the token rejects it
+	repo.On("MarkResetTokenUsed", ctx, record.ID, clock.now).Return(false, nil).Once()
+	assert.ErrorIs(t, svc.ResetPassword(ctx, token, "Another#Pass2024", meta), ErrInvalidResetToken)
+	assert.Equal(t, []string{
+		SecurityEventPasswordResetRequest,
+		SecurityEventPasswordResetComplete,
+		SecurityEventPasswordResetInvalid,
+	}, auditor.actions())
+	repo.AssertExpectations(t)
+	policies.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+	auditor.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
+
+func TestPasswordService_ResetTokenExpiresAndIsReplacedByNewerRequest(t *testing.T) {
+	repo := new(MockPasswordRepository)
+	auditor := new(MockSecurityAuditor)
+	sessions := new(MockUserSessionRevoker)
+	svc, clock := newTestPasswordService(repo, new(MockPasswordPolicyProvider), new(MockPasswordResetMailer), auditor, sessions)
+	ctx := context.Background()
+
+	// A newer request marks the older unused tokens as used
+	replacedAt := clock.now.Add(-time.Minute)
+	replaced := &PasswordResetToken{ID: "token-1", UserID: "user-1", TokenHash: hashResetToken("first"), ExpiresAt: clock.now.Add(time.Hour), UsedAt: &replacedAt}
+	expired := &PasswordResetToken{ID: "token-2", UserID: "user-1", TokenHash: hashResetToken("second"), ExpiresAt: clock.now}
+	repo.On("GetResetTokenByHash", ctx, replaced.TokenHash).Return(replaced, nil).Once()
+	repo.On("GetResetTokenByHash", ctx, expired.TokenHash).Return(expired, nil).Once()
+	repo.On("GetResetTokenByHash", ctx, hashResetToken("unknown")).Return(nil, nil).Once()
+	auditor.On("RecordSecurityEvent", ctx, mock.AnythingOfType("*auth.SecurityEvent")).Return(nil).Times(3)
+
+	assert.ErrorIs(t, svc.ResetPassword(ctx, "first", "Brand#New2024", SessionMetadata{}), ErrInvalidResetToken)
+	assert.ErrorIs(t, svc.ResetPassword(ctx, "second", "Brand#New2024", SessionMetadata{}), ErrInvalidResetToken)
+	assert.ErrorIs(t, svc.ResetPassword(ctx, "unknown", "Brand#New2024", SessionMetadata{}), ErrInvalidResetToken)
+
+	assert.Equal(t, []string{
+		SecurityEventPasswordResetInvalid,
+		SecurityEventPasswordResetInvalid,
+		SecurityEventPasswordResetInvalid,
+	}, auditor.actions())
+	repo.AssertNotCalled(t, "GetPasswordUser", mock.Anything, mock.Anything)
+	sessions.AssertNotCalled(t, "RevokeUserSessions", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	auditor.AssertExpectations(t)
+}
+
+func TestPasswordService_RequestResetIsSilentForUnknownEmail(t *testing.T) {
+	repo := new(MockPasswordRepository)
+	mailer := new(MockPasswordResetMailer)
+	auditor := new(MockSecurityAuditor)
+	svc, _ := newTestPasswordService(repo, new(MockPasswordPolicyProvider), mailer, auditor, new(MockUserSessionRevoker))
+	ctx := context.Background()
+
+	disabled := "inactive"
+	user := testPasswordUser(t)
+	user.Status = &disabled
+	repo.On("FindPasswordUserByEmail", ctx, "nobody@example.com").Return(nil, nil).Once()
+	repo.On("FindPasswordUserByEmail", ctx, "budi@example.com").Return(user, nil).Once()
+	auditor.On("RecordSecurityEvent", ctx, mock.AnythingOfType("*auth.SecurityEvent")).Return(nil).Twice()
+
+	assert.NoError(t, svc.RequestReset(ctx, "nobody@example.com", SessionMetadata{}))
+	assert.NoError(t, svc.RequestReset(ctx, "budi@example.com", SessionMetadata{}))
+
+	mailer.AssertNotCalled(t, "SendPasswordResetEmail", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "CreateResetToken", mock.Anything, mock.Anything)
+	events := auditor.events()
+	require.Len(t, events, 2)
+	assert.False(t, events[0].Success)
+	repo.AssertExpectations(t)
+	auditor.AssertExpectations(t)
+}
+
+func TestPasswordService_LocksAccountAfterFailedLogins(t *testing.T) {
+	repo := new(MockPasswordRepository)
+	policies := new(MockPasswordPolicyProvider)
+	auditor := new(MockSecurityAuditor)
+	svc, clock := newTestPasswordService(repo, policies, new(MockPasswordResetMailer), auditor, new(MockUserSessionRevoker))
+	policy := DefaultPasswordPolicy()
+	policy.MaxFailedLogins = 3
+	policy.LockoutMinutes = 10
+	ctx := context.Background()
+
+	lockUntil := clock.now.Add(10 * time.Minute)
+	policies.On("GetPasswordPolicy", ctx).Return(policy, nil).Once()
+	repo.On("RecordFailedLogin", ctx, "user-1", 3, lockUntil).Return(false, nil).Twice()
+	repo.On("RecordFailedLogin", ctx, "user-1", 3, lockUntil).Return(true, nil).Once()
+	auditor.On("RecordSecurityEvent", ctx, mock.AnythingOfType("*auth.SecurityEvent")).Return(nil).Times(4)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, svc.RecordFailedLogin(ctx, "user-1", "budi@example.com", SessionMetadata{}))
+	}
+	assert.Equal(t, []string{
+		SecurityEventLoginFailed,
+		SecurityEventLoginFailed,
+		SecurityEventLoginFailed,
+		SecurityEventAccountLocked,
+	}, auditor.actions())
+
+	user := testPasswordUser(t)
+	user.LockedUntil = &lockUntil
+	repo.On("GetPasswordUser", ctx, "user-1").Return(user, nil).Twice()
+	assert.ErrorIs(t, svc.CheckLockout(ctx, "user-1"), ErrAccountLocked)
+
+	clock.advance(10 * time.Minute)
+	assert.NoError(t, svc.CheckLockout(ctx, "user-1"))
+
+	// An administrator can unlock before the lockout expires
+	repo.On("ResetFailedLogins", ctx, "user-1").Return(nil).Once()
+	auditor.On("RecordSecurityEvent", ctx, mock.AnythingOfType("*auth.SecurityEvent")).Return(nil).Once()
+	require.NoError(t, svc.UnlockAccount(ctx, "user-1", "admin-1"))
+	assert.Contains(t, auditor.actions(), SecurityEventAccountUnlocked)
+	repo.AssertExpectations(t)
+	policies.AssertExpectations(t)
+	auditor.AssertExpectations(t)
+}
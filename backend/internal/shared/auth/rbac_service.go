@@ -70,7 +70,8 @@ func (s *RBACService) CheckPermission(ctx context.Context, userID, resource, act
 	return false, nil
 }
 
-// CanApprove checks if the approver has a higher role level than the requester.
+// CanApprove checks if the approver may approve a document raised by the requester.
+// This is the default policy for documents without a configured approval workflow.
 func (s *RBACService) CanApprove(ctx context.Context, approverID, requesterID string) (bool, error) {
 	approverPS, err := s.GetUserPermissions(ctx, approverID)
 	if err != nil {
@@ -82,11 +83,24 @@ func (s *RBACService) CanApprove(ctx context.Context, approverID, requesterID st
 		return true, nil
 	}
 
+	// Nobody approves their own documents
+	if requesterID != "" && approverID == requesterID {
+		return false, errors.New("approver cannot approve their own document")
+	}
+
 	// Approver must be at least Level 2 (Supervisor)
 	if approverPS.MaxLevel < 2 {
 		return false, errors.New("approver must be at least a Supervisor (Level 2)")
 	}
 
+	// Approver must not rank below the requester
+	if requesterID != "" {
+		requesterPS, err := s.GetUserPermissions(ctx, requesterID)
+		if err == nil && requesterPS.MaxLevel > approverPS.MaxLevel {
+			return false, errors.New("approver must not have a lower role level than the requester")
+		}
+	}
+
 	return true, nil
 }
 
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationList tracks access tokens that must be rejected before they expire.
+// Entries only need to live as long as the longest access token lifetime.
+type RevocationList interface {
+	RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error
+	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+	// RevokeUser rejects every token of the user issued at or before the given time.
+	RevokeUser(ctx context.Context, userID string, at time.Time, ttl time.Duration) error
+	UserRevokedAt(ctx context.Context, userID string) (time.Time, bool, error)
+}
+
+// IsTokenRevoked checks the claims of an access token against a revocation list.
+func IsTokenRevoked(ctx context.Context, list RevocationList, claims *Claims) (bool, error) {
+	if list == nil {
+		return false, nil
+	}
+	if claims.SessionID != "" {
+		revoked, err := list.IsSessionRevoked(ctx, claims.SessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	revokedAt, ok, err := list.UserRevokedAt(ctx, claims.Subject)
+	if err != nil || !ok {
+		return false, err
+	}
+	return claims.IssuedAt <= revokedAt.Unix(), nil
+}
+
+type revocationEntry struct {
+	at        time.Time
+	expiresAt time.Time
+}
+
+// MemoryRevocationList is a process-local revocation list (fallback when Redis is unavailable).
+type MemoryRevocationList struct {
+	mu       sync.RWMutex
+	sessions map[string]revocationEntry
+	users    map[string]revocationEntry
+	now      func() time.Time
+}
+
+// NewMemoryRevocationList creates an in-memory revocation list.
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{
+		sessions: make(map[string]revocationEntry),
+		users:    make(map[string]revocationEntry),
+		now:      time.Now,
+	}
+}
+
+// RevokeSession records a revoked session.
+func (l *MemoryRevocationList) RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.purge(now)
+	l.sessions[sessionID] = revocationEntry{at: now, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// IsSessionRevoked reports whether the session was revoked.
+func (l *MemoryRevocationList) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	entry, ok := l.sessions[sessionID]
+	return ok && l.now().Before(entry.expiresAt), nil
+}
+
+// RevokeUser records the time before which all tokens of the user are rejected.
+func (l *MemoryRevocationList) RevokeUser(ctx context.Context, userID string, at time.Time, ttl time.Duration) error {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.purge(now)
+	l.users[userID] = revocationEntry{at: at, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// UserRevokedAt returns the revocation time recorded for the user.
+func (l *MemoryRevocationList) UserRevokedAt(ctx context.Context, userID string) (time.Time, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	entry, ok := l.users[userID]
+	if !ok || !l.now().Before(entry.expiresAt) {
+		return time.Time{}, false, nil
+	}
+	return entry.at, true, nil
+}
+
+// purge drops expired entries. Callers must hold the write lock.
+func (l *MemoryRevocationList) purge(now time.Time) {
+	for id, entry := range l.sessions {
+		if !now.Before(entry.expiresAt) {
+			delete(l.sessions, id)
+		}
+	}
+	for id, entry := range l.users {
+		if !now.Before(entry.expiresAt) {
+			delete(l.users, id)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	revokedSessionPrefix = "malaka:auth:revoked:session:"
+	revokedUserPrefix    = "malaka:auth:revoked:user:"
+)
+
+// RedisRevocationList implements RevocationList using Redis so revocations are
+// shared by every API instance.
+type RedisRevocationList struct {
+	client *redis.Client
+}
+
+// NewRedisRevocationList creates a new Redis-backed revocation list.
+func NewRedisRevocationList(client *redis.Client) *RedisRevocationList {
+	return &RedisRevocationList{client: client}
+}
+
+// RevokeSession records a revoked session.
+func (l *RedisRevocationList) RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	return l.client.Set(ctx, revokedSessionPrefix+sessionID, "1", ttl).Err()
+}
+
+// IsSessionRevoked reports whether the session was revoked.
+func (l *RedisRevocationList) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	n, err := l.client.Exists(ctx, revokedSessionPrefix+sessionID).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis exists error: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RevokeUser records the time before which all tokens of the user are rejected.
+func (l *RedisRevocationList) RevokeUser(ctx context.Context, userID string, at time.Time, ttl time.Duration) error {
+	return l.client.Set(ctx, revokedUserPrefix+userID, strconv.FormatInt(at.Unix(), 10), ttl).Err()
+}
+
+// UserRevokedAt returns the revocation time recorded for the user.
+func (l *RedisRevocationList) UserRevokedAt(ctx context.Context, userID string) (time.Time, bool, error) {
+	value, err := l.client.Get(ctx, revokedUserPrefix+userID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("redis get error: %w", err)
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		// Corrupted entry - treat as revoked now rather than letting tokens through
+		return time.Now(), true, nil
+	}
+	return time.Unix(unix, 0), true, nil
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Session revocation reasons.
+const (
+	SessionRevokedLogout       = "logout"
+	SessionRevokedTerminated   = "terminated"
+	SessionRevokedTokenReuse   = "refresh_token_reuse"
+	SessionRevokedUserDisabled = "user_disabled"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a rotated refresh token is presented again.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+	// ErrSessionNotFound is returned when a session does not exist or belongs to another user.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionRevoked is returned for access tokens of a revoked session or user.
+	ErrSessionRevoked = errors.New("session has been revoked")
+)
+
+// Session represents a server-side login session.
+type Session struct {
+	ID            string     `json:"id" db:"id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	Device        string     `json:"device" db:"device"`
+	IPAddress     string     `json:"ip_address" db:"ip_address"`
+	UserAgent     string     `json:"user_agent" db:"user_agent"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason *string    `json:"revoked_reason,omitempty" db:"revoked_reason"`
+}
+
+// IsActive reports whether the session can still be used at the given time.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken represents a single-use refresh token of a session. Only the hash is stored.
+type RefreshToken struct {
+	ID        string     `db:"id"`
+	SessionID string     `db:"session_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// SessionMetadata describes the client that opened or refreshed a session.
+type SessionMetadata struct {
+	IPAddress string
+	UserAgent string
+}
+
+// TokenSubject is the user identity embedded in access tokens.
+type TokenSubject struct {
+	UserID    string
+	CompanyID string
+	Email     string
+	Role      string
+}
+
+// TokenPair is issued on login and on every refresh.
+type TokenPair struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"` // access token lifetime in seconds
+	ExpiresAt    time.Time `json:"expires_at"`
+	SessionID    string    `json:"session_id"`
+}
+
+// SubjectLoader reloads the current identity of a user when a session is refreshed,
+// so role changes apply and disabled users cannot refresh.
+type SubjectLoader interface {
+	LoadTokenSubject(ctx context.Context, userID string) (*TokenSubject, error)
+}
+
+// SessionRepository defines persistence for sessions and refresh tokens.
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *Session) error
+	GetSession(ctx context.Context, id string) (*Session, error)
+	ListActiveSessions(ctx context.Context, userID string, now time.Time) ([]Session, error)
+	TouchSession(ctx context.Context, id string, seenAt time.Time, ipAddress string, expiresAt *time.Time) error
+	RevokeSession(ctx context.Context, id, reason string, at time.Time) error
+	RevokeUserSessions(ctx context.Context, userID, reason string, at time.Time) ([]string, error)
+
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// MarkRefreshTokenUsed marks an unused token as used and reports false when it was already used.
+	MarkRefreshTokenUsed(ctx context.Context, id string, at time.Time) (bool, error)
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const sessionColumns = `id::text, user_id::text, device, ip_address, user_agent, created_at, last_seen_at, expires_at, revoked_at, revoked_reason`
+
+// SessionRepositoryImpl implements SessionRepository using sqlx.
+type SessionRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewSessionRepositoryImpl creates a new SessionRepositoryImpl.
+func NewSessionRepositoryImpl(db *sqlx.DB) *SessionRepositoryImpl {
+	return &SessionRepositoryImpl{db: db}
+}
+
+// CreateSession inserts a new session.
+func (r *SessionRepositoryImpl) CreateSession(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO user_sessions (id, user_id, device, ip_address, user_agent, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.UserID, session.Device, session.IPAddress, session.UserAgent,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// GetSession retrieves a session by ID. Returns nil when it does not exist.
+func (r *SessionRepositoryImpl) GetSession(ctx context.Context, id string) (*Session, error) {
+	var session Session
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE id::text = $1`
+	if err := r.db.GetContext(ctx, &session, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &session, nil
+}
+
+// ListActiveSessions returns the user's sessions that are neither revoked nor expired.
+func (r *SessionRepositoryImpl) ListActiveSessions(ctx context.Context, userID string, now time.Time) ([]Session, error) {
+	sessions := []Session{}
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM user_sessions
+		WHERE user_id::text = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC
+	`
+	if err := r.db.SelectContext(ctx, &sessions, query, userID, now); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// TouchSession updates the last-seen time and, when given, the client IP and session expiry.
+func (r *SessionRepositoryImpl) TouchSession(ctx context.Context, id string, seenAt time.Time, ipAddress string, expiresAt *time.Time) error {
+	query := `
+		UPDATE user_sessions
+		SET last_seen_at = $2,
+			ip_address = COALESCE(NULLIF($3, ''), ip_address),
+			expires_at = COALESCE($4, expires_at)
+		WHERE id::text = $1 AND revoked_at IS NULL
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, seenAt, ipAddress, expiresAt); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession marks a session as revoked.
+func (r *SessionRepositoryImpl) RevokeSession(ctx context.Context, id, reason string, at time.Time) error {
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = $2, revoked_reason = $3
+		WHERE id::text = $1 AND revoked_at IS NULL
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, at, reason); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every open session of a user and returns their IDs.
+func (r *SessionRepositoryImpl) RevokeUserSessions(ctx context.Context, userID, reason string, at time.Time) ([]string, error) {
+	ids := []string{}
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = $2, revoked_reason = $3
+		WHERE user_id::text = $1 AND revoked_at IS NULL
+		RETURNING id::text
+	`
+	if err := r.db.SelectContext(ctx, &ids, query, userID, at, reason); err != nil {
+		return nil, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return ids, nil
+}
+
+// CreateRefreshToken stores a refresh token hash.
+func (r *SessionRepositoryImpl) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, session_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := r.db.ExecContext(ctx, query, token.ID, token.SessionID, token.TokenHash, token.ExpiresAt, token.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash. Returns nil when it does not exist.
+func (r *SessionRepositoryImpl) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	query := `
+		SELECT id::text, session_id::text, token_hash, expires_at, used_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed marks an unused token as used. The conditional update makes
+// concurrent refreshes with the same token race-safe: only one of them wins.
+func (r *SessionRepositoryImpl) MarkRefreshTokenUsed(ctx context.Context, id string, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET used_at = $2 WHERE id::text = $1 AND used_at IS NULL`, id, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	return rows == 1, nil
+}
@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"malaka/internal/shared/uuid"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	sessionTouchInterval   = time.Minute
+	maxTrackedSessions     = 10000
+)
+
+// SessionService issues access/refresh token pairs and manages server-side sessions.
+type SessionService struct {
+	repo        SessionRepository
+	revocations RevocationList
+	secret      string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	legacyTTL   time.Duration
+	now         func() time.Time
+
+	touchMu     sync.Mutex
+	lastTouched map[string]time.Time
+}
+
+// NewSessionService creates a new SessionService.
+func NewSessionService(repo SessionRepository, revocations RevocationList, secret string, accessTTL, refreshTTL time.Duration) *SessionService {
+	if revocations == nil {
+		revocations = NewMemoryRevocationList()
+	}
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+	return &SessionService{
+		repo:        repo,
+		revocations: revocations,
+		secret:      secret,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+		now:         time.Now,
+		lastTouched: make(map[string]time.Time),
+	}
+}
+
+// SetLegacyTokenTTL sets the lifetime of legacy access tokens issued without a session,
+// so user revocations outlive them.
+func (s *SessionService) SetLegacyTokenTTL(ttl time.Duration) {
+	s.legacyTTL = ttl
+}
+
+// StartSession opens a session for an authenticated user and issues the first token pair.
+func (s *SessionService) StartSession(ctx context.Context, subject *TokenSubject, meta SessionMetadata) (*TokenPair, error) {
+	now := s.now()
+	session := &Session{
+		ID:         uuid.New().String(),
+		UserID:     subject.UserID,
+		Device:     DeviceFromUserAgent(meta.UserAgent),
+		IPAddress:  meta.IPAddress,
+		UserAgent:  meta.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshTTL),
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, subject, session.ID, now)
+}
+
+// Refresh rotates a refresh token and issues a new token pair. Presenting a refresh
+// token that was already rotated revokes the whole session.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string, meta SessionMetadata, loader SubjectLoader) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	now := s.now()
+
+	token, err := s.repo.GetRefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := s.repo.GetSession(ctx, token.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !session.IsActive(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if token.UsedAt != nil {
+		return nil, s.revokeReusedSession(ctx, session.ID)
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	claimed, err := s.repo.MarkRefreshTokenUsed(ctx, token.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		// Lost a race against another refresh with the same token
+		return nil, s.revokeReusedSession(ctx, session.ID)
+	}
+
+	subject, err := loader.LoadTokenSubject(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if subject == nil {
+		// User was deleted or disabled
+		if err := s.revokeSession(ctx, session.ID, SessionRevokedUserDisabled); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	expiresAt := now.Add(s.refreshTTL)
+	if err := s.repo.TouchSession(ctx, session.ID, now, meta.IPAddress, &expiresAt); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, subject, session.ID, now)
+}
+
+// ListSessions returns the user's active sessions.
+func (s *SessionService) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	return s.repo.ListActiveSessions(ctx, userID, s.now())
+}
+
+// RevokeSession terminates one of the user's sessions. Its access tokens are rejected immediately.
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID, reason string) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	return s.revokeSession(ctx, sessionID, reason)
+}
+
+// RevokeUserSessions terminates every session of the user.
+func (s *SessionService) RevokeUserSessions(ctx context.Context, userID, reason string) error {
+	ids, err := s.repo.RevokeUserSessions(ctx, userID, reason, s.now())
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.revocations.RevokeSession(ctx, id, s.accessTTL); err != nil {
+			return fmt.Errorf("failed to record session revocation: %w", err)
+		}
+	}
+	return nil
+}
+
+// RevokeUser terminates every session of a disabled or deleted user and rejects
+// all access tokens issued to the user so far, including legacy tokens without a session.
+func (s *SessionService) RevokeUser(ctx context.Context, userID string) error {
+	if err := s.RevokeUserSessions(ctx, userID, SessionRevokedUserDisabled); err != nil {
+		return err
+	}
+	// Keep the marker until the longest-lived token issued to the user has expired
+	ttl := s.accessTTL
+	if s.legacyTTL > ttl {
+		ttl = s.legacyTTL
+	}
+	if err := s.revocations.RevokeUser(ctx, userID, s.now(), ttl); err != nil {
+		return fmt.Errorf("failed to record user revocation: %w", err)
+	}
+	return nil
+}
+
+// VerifyToken rejects access tokens of revoked sessions or users and records session activity.
+func (s *SessionService) VerifyToken(ctx context.Context, claims *Claims) error {
+	revoked, err := IsTokenRevoked(ctx, s.revocations, claims)
+	if err != nil {
+		// Revocation list unavailable - fall back to the session record
+		revoked, err = s.isSessionRevokedInStore(ctx, claims.SessionID)
+		if err != nil {
+			return fmt.Errorf("failed to check token revocation: %w", err)
+		}
+	}
+	if revoked {
+		return ErrSessionRevoked
+	}
+	if claims.SessionID != "" {
+		s.touch(claims.SessionID)
+	}
+	return nil
+}
+
+func (s *SessionService) isSessionRevokedInStore(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	return session == nil || session.RevokedAt != nil, nil
+}
+
+// AccessTokenTTL returns the lifetime of issued access tokens.
+func (s *SessionService) AccessTokenTTL() time.Duration {
+	return s.accessTTL
+}
+
+func (s *SessionService) issueTokens(ctx context.Context, subject *TokenSubject, sessionID string, now time.Time) (*TokenPair, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRefreshToken(ctx, &RefreshToken{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := NewAccessToken(subject, sessionID, s.secret, now, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+		ExpiresAt:    now.Add(s.accessTTL),
+		SessionID:    sessionID,
+	}, nil
+}
+
+func (s *SessionService) revokeSession(ctx context.Context, sessionID, reason string) error {
+	if err := s.repo.RevokeSession(ctx, sessionID, reason, s.now()); err != nil {
+		return err
+	}
+	if err := s.revocations.RevokeSession(ctx, sessionID, s.accessTTL); err != nil {
+		return fmt.Errorf("failed to record session revocation: %w", err)
+	}
+	return nil
+}
+
+func (s *SessionService) revokeReusedSession(ctx context.Context, sessionID string) error {
+	if err := s.revokeSession(ctx, sessionID, SessionRevokedTokenReuse); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// touch updates last_seen_at in the background, at most once per interval per session.
+func (s *SessionService) touch(sessionID string) {
+	now := s.now()
+	s.touchMu.Lock()
+	if last, ok := s.lastTouched[sessionID]; ok && now.Sub(last) < sessionTouchInterval {
+		s.touchMu.Unlock()
+		return
+	}
+	if len(s.lastTouched) >= maxTrackedSessions {
+		s.lastTouched = make(map[string]time.Time)
+	}
+	s.lastTouched[sessionID] = now
+	s.touchMu.Unlock()
+
+	go func() {
+		_ = s.repo.TouchSession(context.Background(), sessionID, now, "", nil)
+	}()
+}
+
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeviceFromUserAgent returns a short "Browser on OS" description of a user agent.
+func DeviceFromUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	switch {
+	case strings.Contains(userAgent, "Electron/"):
+		browser = "Desktop app"
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	case strings.Contains(userAgent, "okhttp"), strings.Contains(userAgent, "Expo"), strings.Contains(userAgent, "Dart/"):
+		browser = "Mobile app"
+	}
+
+	platform := ""
+	switch {
+	case strings.Contains(userAgent, "Android"):
+		platform = "Android"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		platform = "iOS"
+	case strings.Contains(userAgent, "Windows"):
+		platform = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"), strings.Contains(userAgent, "Macintosh"):
+		platform = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		platform = "Linux"
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	if len(userAgent) > 100 {
+		return userAgent[:100]
+	}
+	return userAgent
+}
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+// MockSessionRepository is a mock implementation of SessionRepository.
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateSession(ctx context.Context, session *Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetSession(ctx context.Context, id string) (*Session, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) ListActiveSessions(ctx context.Context, userID string, now time.Time) ([]Session, error) {
+	args := m.Called(ctx, userID, now)
+	return args.Get(0).([]Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) TouchSession(ctx context.Context, id string, seenAt time.Time, ipAddress string, expiresAt *time.Time) error {
+	args := m.Called(ctx, id, seenAt, ipAddress, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeSession(ctx context.Context, id, reason string, at time.Time) error {
+	args := m.Called(ctx, id, reason, at)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeUserSessions(ctx context.Context, userID, reason string, at time.Time) ([]string, error) {
+	args := m.Called(ctx, userID, reason, at)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSessionRepository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefreshToken), args.Error(1)
+}
+
+func (m *MockSessionRepository) MarkRefreshTokenUsed(ctx context.Context, id string, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockSubjectLoader is a mock implementation of SubjectLoader.
+type MockSubjectLoader struct {
+	mock.Mock
+}
+
+func (m *MockSubjectLoader) LoadTokenSubject(ctx context.Context, userID string) (*TokenSubject, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenSubject), args.Error(1)
+}
+
+func newTestSessionService() (*SessionService, *MockSessionRepository) {
+	repo := new(MockSessionRepository)
+	return NewSessionService(repo, NewMemoryRevocationList(), testSecret, 15*time.Minute, 24*time.Hour), repo
+}
+
+func testSubject() *TokenSubject {
+	return &TokenSubject{UserID: "user-1", CompanyID: "company-1", Email: "user@example.com", Role: "admin"}
+}
+
+func testSession() *Session {
+	now := time.Now()
+	return &Session{ID: "session-1", UserID: "user-1", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(24 * time.Hour)}
+}
+
+func testRefreshToken(token string) *RefreshToken {
+	return &RefreshToken{ID: "token-1", SessionID: "session-1", TokenHash: hashRefreshToken(token), ExpiresAt: time.Now().Add(24 * time.Hour)}
+}
+
+// sessionClaims returns the claims of an access token issued for the test session.
+func sessionClaims(t *testing.T) *Claims {
+	token, err := NewAccessToken(testSubject(), "session-1", testSecret, time.Now(), 15*time.Minute)
+	require.NoError(t, err)
+	claims, err := ParseJWT(token, testSecret)
+	require.NoError(t, err)
+	return claims
+}
+
+func TestSessionService_StartSessionIssuesSessionBoundToken(t *testing.T) {
+	svc, repo := newTestSessionService()
+	ctx := context.Background()
+	repo.On("CreateSession", ctx, mock.AnythingOfType("*auth.Session")).Return(nil).Once()
+	repo.On("CreateRefreshToken", ctx, mock.AnythingOfType("*auth.RefreshToken")).Return(nil).Once()
+
+	tokens, err := svc.StartSession(ctx, testSubject(), SessionMetadata{
+		IPAddress: "10.0.0.1",
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, int64(900), tokens.ExpiresIn)
+
+	claims, err := ParseJWT(tokens.AccessToken, testSecret)
+	require.NoError(t, err)
+	assert.Equal(t, tokens.SessionID, claims.SessionID)
+	assert.Equal(t, "user-1", claims.Subject)
+
+	session := repo.Calls[0].Arguments.Get(1).(*Session)
+	assert.Equal(t, tokens.SessionID, session.ID)
+	assert.Equal(t, "Chrome on Windows", session.Device)
+	assert.Equal(t, "10.0.0.1", session.IPAddress)
+
+	// Only the hash of the refresh token is stored
+	stored := repo.Calls[1].Arguments.Get(1).(*RefreshToken)
+	assert.Equal(t, tokens.SessionID, stored.SessionID)
+	assert.Equal(t, hashRefreshToken(tokens.RefreshToken), stored.TokenHash)
+	repo.AssertExpectations(t)
+}
+
+func TestSessionService_RefreshRotatesToken(t *testing.T) {
+	svc, repo := newTestSessionService()
+	loader := new(MockSubjectLoader)
+	ctx := context.Background()
+
+	repo.On("GetRefreshTokenByHash", ctx, hashRefreshToken("refresh-1")).Return(testRefreshToken("refresh-1"), nil).Once()
+	repo.On("GetSession", ctx, "session-1").Return(testSession(), nil).Once()
+	repo.On("MarkRefreshTokenUsed", ctx, "token-1", mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+	loader.On("LoadTokenSubject", ctx, "user-1").Return(testSubject(), nil).Once()
+	repo.On("TouchSession", ctx, "session-1", mock.AnythingOfType("time.Time"), "10.0.0.2", mock.AnythingOfType("*time.Time")).Return(nil).Once()
+	repo.On("CreateRefreshToken", ctx, mock.AnythingOfType("*auth.RefreshToken")).Return(nil).Once()
+
+	tokens, err := svc.Refresh(ctx, "refresh-1", SessionMetadata{IPAddress: "10.0.0.2"}, loader)
+	require.NoError(t, err)
+	assert.NotEqual(t, "refresh-1", tokens.RefreshToken)
+	assert.Equal(t, "session-1", tokens.SessionID)
+	repo.AssertExpectations(t)
+	loader.AssertExpectations(t)
+}
+
+func TestSessionService_RefreshTokenReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+
+	// Replaying a rotated token, or losing the race to rotate it, kills the session for
+	// everyone holding it
+	t.Run("replayed token", func(t *testing.T) {
+		svc, repo := newTestSessionService()
+		used := testRefreshToken("refresh-1")
+		usedAt := time.Now().Add(-time.Minute)
+		used.UsedAt = &usedAt
+		repo.On("GetRefreshTokenByHash", ctx, used.TokenHash).Return(used, nil).Once()
+		repo.On("GetSession", ctx, "session-1").Return(testSession(), nil).Once()
+		repo.On("RevokeSession", ctx, "session-1", SessionRevokedTokenReuse, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		_, err := svc.Refresh(ctx, "refresh-1", SessionMetadata{}, new(MockSubjectLoader))
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		assert.ErrorIs(t, svc.VerifyToken(ctx, sessionClaims(t)), ErrSessionRevoked)
+		repo.AssertNotCalled(t, "MarkRefreshTokenUsed", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("concurrent rotation", func(t *testing.T) {
+		svc, repo := newTestSessionService()
+		repo.On("GetRefreshTokenByHash", ctx, hashRefreshToken("refresh-1")).Return(testRefreshToken("refresh-1"), nil).Once()
+		repo.On("GetSession", ctx, "session-1").Return(testSession(), nil).Once()
+		repo.On("MarkRefreshTokenUsed", ctx, "token-1", mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+		repo.On("RevokeSession", ctx, "session-1", SessionRevokedTokenReuse, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		_, err := svc.Refresh(ctx, "refresh-1", SessionMetadata{}, new(MockSubjectLoader))
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		repo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSessionService_RefreshRejectsDisabledUser(t *testing.T) {
+	svc, repo := newTestSessionService()
+	loader := new(MockSubjectLoader)
+	ctx := context.Background()
+
+	repo.On("GetRefreshTokenByHash", ctx, hashRefreshToken("refresh-1")).Return(testRefreshToken("refresh-1"), nil).Once()
+	repo.On("GetSession", ctx, "session-1").Return(testSession(), nil).Once()
+	repo.On("MarkRefreshTokenUsed", ctx, "token-1", mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+	loader.On("LoadTokenSubject", ctx, "user-1").Return(nil, nil).Once()
+	repo.On("RevokeSession", ctx, "session-1", SessionRevokedUserDisabled, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	_, err := svc.Refresh(ctx, "refresh-1", SessionMetadata{}, loader)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	repo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	loader.AssertExpectations(t)
+}
+
+func TestSessionService_RevokeSession(t *testing.T) {
+	svc, repo := newTestSessionService()
+	ctx := context.Background()
+	// Verified tokens record session activity in the background
+	touched := make(chan struct{})
+	repo.On("TouchSession", context.Background(), "session-1", mock.AnythingOfType("time.Time"), "", (*time.Time)(nil)).
+		Run(func(mock.Arguments) { close(touched) }).Return(nil).Once()
+	repo.On("GetSession", ctx, "session-1").Return(testSession(), nil).Twice()
+	repo.On("RevokeSession", ctx, "session-1", SessionRevokedTerminated, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	claims := sessionClaims(t)
+	assert.NoError(t, svc.VerifyToken(ctx, claims))
+	<-touched
+
+	assert.ErrorIs(t, svc.RevokeSession(ctx, "another-user", "session-1", SessionRevokedTerminated), ErrSessionNotFound)
+	require.NoError(t, svc.RevokeSession(ctx, "user-1", "session-1", SessionRevokedTerminated))
+	assert.ErrorIs(t, svc.VerifyToken(ctx, claims), ErrSessionRevoked)
+	repo.AssertExpectations(t)
+}
+
+func TestSessionService_RevokeUserRejectsLegacyTokens(t *testing.T) {
+	repo := new(MockSessionRepository)
+	revocations := NewMemoryRevocationList()
+	svc := NewSessionService(repo, revocations, testSecret, 15*time.Minute, 24*time.Hour)
+	svc.SetLegacyTokenTTL(48 * time.Hour)
+	ctx := context.Background()
+	repo.On("RevokeUserSessions", ctx, "user-1", SessionRevokedUserDisabled, mock.AnythingOfType("time.Time")).Return([]string{}, nil).Once()
+
+	legacy, err := NewJWT("user-1", "company-1", "user@example.com", "admin", testSecret, 48)
+	require.NoError(t, err)
+	claims, err := ParseJWT(legacy, testSecret)
+	require.NoError(t, err)
+	assert.NoError(t, svc.VerifyToken(ctx, claims))
+
+	require.NoError(t, svc.RevokeUser(ctx, "user-1"))
+	assert.ErrorIs(t, svc.VerifyToken(ctx, claims), ErrSessionRevoked)
+
+	// Still rejected once session-bound access tokens would have expired
+	revocations.now = func() time.Time { return time.Now().Add(time.Hour) }
+	assert.ErrorIs(t, svc.VerifyToken(ctx, claims), ErrSessionRevoked)
+	repo.AssertExpectations(t)
+}
+
+func TestMiddlewareWithVerifier_RejectsRevokedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc, repo := newTestSessionService()
+	ctx := context.Background()
+	touched := make(chan struct{})
+	repo.On("TouchSession", context.Background(), "session-1", mock.AnythingOfType("time.Time"), "", (*time.Time)(nil)).
+		Run(func(mock.Arguments) { close(touched) }).Return(nil).Once()
+	repo.On("GetSession", ctx, "session-1").Return(testSession(), nil).Once()
+	repo.On("RevokeSession", ctx, "session-1", SessionRevokedLogout, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	accessToken, err := NewAccessToken(testSubject(), "session-1", testSecret, time.Now(), 15*time.Minute)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/me", MiddlewareWithVerifier(testSecret, svc), func(c *gin.Context) {
+		c.String(http.StatusOK, GetSessionID(c))
+	})
+	call := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "session-1", w.Body.String())
+	<-touched
+
+	require.NoError(t, svc.RevokeSession(ctx, "user-1", "session-1", SessionRevokedLogout))
+	assert.Equal(t, http.StatusUnauthorized, call().Code)
+	repo.AssertExpectations(t)
+}
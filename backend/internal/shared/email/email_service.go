@@ -3,11 +3,14 @@ package email
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"html/template"
+	"mime"
 	"net/smtp"
 	"os"
 	"strconv"
+	"time"
 )
 
 // EmailService handles sending emails via SMTP
@@ -87,6 +90,11 @@ func (s *EmailService) sendMail(to, subject, body, contentType string) error {
 	message.WriteString("\r\n")
 	message.WriteString(body)
 
+	return s.deliver(to, message.Bytes())
+}
+
+// deliver sends a complete message through the SMTP server
+func (s *EmailService) deliver(to string, message []byte) error {
 	// Create authentication
 	auth := smtp.PlainAuth("", s.username, s.password, s.host)
 
@@ -129,7 +137,7 @@ func (s *EmailService) sendMail(to, subject, body, contentType string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get data writer: %w", err)
 	}
-	_, err = w.Write(message.Bytes())
+	_, err = w.Write(message)
 	if err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
@@ -141,6 +149,48 @@ func (s *EmailService) sendMail(to, subject, body, contentType string) error {
 	return conn.Quit()
 }
 
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// SendHTMLEmailWithAttachments sends an HTML email with files attached
+func (s *EmailService) SendHTMLEmailWithAttachments(to, subject, htmlBody string, attachments ...Attachment) error {
+	boundary := fmt.Sprintf("malaka-%d", time.Now().UnixNano())
+
+	var message bytes.Buffer
+	message.WriteString(fmt.Sprintf("From: %s <%s>\r\n", s.fromName, s.fromEmail))
+	message.WriteString(fmt.Sprintf("To: %s\r\n", to))
+	message.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject)))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\r\n\r\n", boundary))
+
+	message.WriteString(fmt.Sprintf("--%s\r\n", boundary))
+	message.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n\r\n")
+	message.WriteString(htmlBody)
+	message.WriteString("\r\n")
+
+	for _, attachment := range attachments {
+		message.WriteString(fmt.Sprintf("--%s\r\n", boundary))
+		message.WriteString(fmt.Sprintf("Content-Type: %s; name=\"%s\"\r\n", attachment.ContentType, attachment.Filename))
+		message.WriteString("Content-Transfer-Encoding: base64\r\n")
+		message.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=\"%s\"\r\n\r\n", attachment.Filename))
+
+		// Base64 lines must not be longer than 76 characters
+		encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+		for len(encoded) > 76 {
+			message.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		message.WriteString(encoded + "\r\n")
+	}
+	message.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
+
+	return s.deliver(to, message.Bytes())
+}
+
 // InvitationEmailData holds data for invitation email template
 type InvitationEmailData struct {
 	RecipientName   string
@@ -269,3 +319,237 @@ func (s *EmailService) renderInvitationTemplate(data InvitationEmailData) (strin
 
 	return buf.String(), nil
 }
+
+// PasswordResetEmailData holds data for password reset email template
+type PasswordResetEmailData struct {
+	RecipientName string
+	ResetLink     string
+	ExpiryMinutes int
+	LogoURL       string
+}
+
+// SendPasswordResetEmail sends a password reset link to a user
+func (s *EmailService) SendPasswordResetEmail(to string, data PasswordResetEmailData) error {
+	subject := "Reset your Malaka ERP password"
+
+	htmlBody, err := s.renderPasswordResetTemplate(data)
+	if err != nil {
+		return fmt.Errorf("failed to render password reset template: %w", err)
+	}
+
+	return s.SendHTMLEmail(to, subject, htmlBody)
+}
+
+// renderPasswordResetTemplate renders the password reset email HTML template
+func (s *EmailService) renderPasswordResetTemplate(data PasswordResetEmailData) (string, error) {
+	tmpl := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Reset your Malaka ERP password</title>
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f5f5f5;">
+    <table width="100%" cellpadding="0" cellspacing="0" style="background-color: #f5f5f5; padding: 40px 0;">
+        <tr>
+            <td align="center">
+                <table width="600" cellpadding="0" cellspacing="0" style="background-color: #ffffff; border-radius: 8px; box-shadow: 0 2px 8px rgba(0,0,0,0.1);">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background-color: #0099ff; padding: 30px 40px; border-radius: 8px 8px 0 0; text-align: center;">
+                            {{if .LogoURL}}
+                            <img src="{{.LogoURL}}" alt="Malaka ERP" style="max-height: 60px; max-width: 200px; margin-bottom: 15px;">
+                            {{else}}
+                            <h1 style="margin: 0; color: #ffffff; font-size: 28px; font-weight: 600;">
+                                Malaka<span style="font-weight: 400;">ERP</span>
+                            </h1>
+                            {{end}}
+                        </td>
+                    </tr>
+
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px;">
+                            <h2 style="margin: 0 0 20px 0; color: #333333; font-size: 24px; font-weight: 600;">
+                                Reset Your Password
+                            </h2>
+
+                            <p style="margin: 0 0 20px 0; color: #555555; font-size: 16px; line-height: 1.6;">
+                                Hi{{if .RecipientName}} {{.RecipientName}}{{end}},
+                            </p>
+
+                            <p style="margin: 0 0 30px 0; color: #555555; font-size: 16px; line-height: 1.6;">
+                                We received a request to reset the password of your Malaka ERP account. Click the button below to choose a new password.
+                            </p>
+
+                            <!-- CTA Button -->
+                            <table cellpadding="0" cellspacing="0" style="margin: 0 0 30px 0;">
+                                <tr>
+                                    <td style="background-color: #0099ff; border-radius: 6px;">
+                                        <a href="{{.ResetLink}}" style="display: inline-block; padding: 16px 32px; color: #ffffff; text-decoration: none; font-size: 16px; font-weight: 600;">
+                                            Reset Password
+                                        </a>
+                                    </td>
+                                </tr>
+                            </table>
+
+                            <p style="margin: 0 0 20px 0; color: #888888; font-size: 14px; line-height: 1.6;">
+                                Or copy and paste this link into your browser:
+                            </p>
+                            <p style="margin: 0 0 30px 0; color: #0099ff; font-size: 14px; word-break: break-all;">
+                                {{.ResetLink}}
+                            </p>
+
+                            <div style="padding: 20px; background-color: #fff8e1; border-radius: 6px; border-left: 4px solid #ffc107;">
+                                <p style="margin: 0; color: #856404; font-size: 14px;">
+                                    <strong>⏰ This link expires in {{.ExpiryMinutes}} minutes and can only be used once.</strong><br>
+                                    All signed-in sessions will be logged out after the reset.
+                                </p>
+                            </div>
+                        </td>
+                    </tr>
+
+                    <!-- Footer -->
+                    <tr>
+                        <td style="padding: 30px 40px; background-color: #f9f9f9; border-radius: 0 0 8px 8px; border-top: 1px solid #eeeeee;">
+                            <p style="margin: 0 0 10px 0; color: #888888; font-size: 14px;">
+                                If you didn't request a password reset, you can safely ignore this email. Your password will not change.
+                            </p>
+                            <p style="margin: 0; color: #888888; font-size: 12px;">
+                                © 2024 Malaka ERP. All rights reserved.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`
+
+	t, err := template.New("password_reset").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// QuotationEmailData holds data for quotation email template
+type QuotationEmailData struct {
+	RecipientName   string
+	QuotationNumber string
+	Version         int
+	TotalAmount     string
+	ValidUntil      string
+	LogoURL         string
+}
+
+// SendQuotationEmail sends a quotation to a customer with the quotation PDF attached
+func (s *EmailService) SendQuotationEmail(to string, data QuotationEmailData, pdf []byte) error {
+	subject := fmt.Sprintf("Quotation %s", data.QuotationNumber)
+	if data.Version > 1 {
+		subject = fmt.Sprintf("Quotation %s (revision %d)", data.QuotationNumber, data.Version)
+	}
+
+	htmlBody, err := s.renderQuotationTemplate(data)
+	if err != nil {
+		return fmt.Errorf("failed to render quotation template: %w", err)
+	}
+
+	return s.SendHTMLEmailWithAttachments(to, subject, htmlBody, Attachment{
+		Filename:    fmt.Sprintf("%s-v%d.pdf", data.QuotationNumber, data.Version),
+		ContentType: "application/pdf",
+		Content:     pdf,
+	})
+}
+
+// renderQuotationTemplate renders the quotation email HTML template
+func (s *EmailService) renderQuotationTemplate(data QuotationEmailData) (string, error) {
+	tmpl := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Quotation {{.QuotationNumber}}</title>
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f5f5f5;">
+    <table width="100%" cellpadding="0" cellspacing="0" style="background-color: #f5f5f5; padding: 40px 0;">
+        <tr>
+            <td align="center">
+                <table width="600" cellpadding="0" cellspacing="0" style="background-color: #ffffff; border-radius: 8px; box-shadow: 0 2px 8px rgba(0,0,0,0.1);">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background-color: #0099ff; padding: 30px 40px; border-radius: 8px 8px 0 0; text-align: center;">
+                            {{if .LogoURL}}
+                            <img src="{{.LogoURL}}" alt="Malaka ERP" style="max-height: 60px; max-width: 200px; margin-bottom: 15px;">
+                            {{else}}
+                            <h1 style="margin: 0; color: #ffffff; font-size: 28px; font-weight: 600;">
+                                Malaka<span style="font-weight: 400;">ERP</span>
+                            </h1>
+                            {{end}}
+                        </td>
+                    </tr>
+
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px;">
+                            <h2 style="margin: 0 0 20px 0; color: #333333; font-size: 24px; font-weight: 600;">
+                                Quotation {{.QuotationNumber}}{{if gt .Version 1}} (revision {{.Version}}){{end}}
+                            </h2>
+
+                            <p style="margin: 0 0 20px 0; color: #555555; font-size: 16px; line-height: 1.6;">
+                                Dear{{if .RecipientName}} {{.RecipientName}}{{else}} customer{{end}},
+                            </p>
+
+                            <p style="margin: 0 0 20px 0; color: #555555; font-size: 16px; line-height: 1.6;">
+                                Thank you for your interest. Please find our quotation attached, for a total of <strong>{{.TotalAmount}}</strong>.
+                            </p>
+
+                            <div style="padding: 20px; background-color: #fff8e1; border-radius: 6px; border-left: 4px solid #ffc107;">
+                                <p style="margin: 0; color: #856404; font-size: 14px;">
+                                    <strong>This quotation is valid until {{.ValidUntil}}.</strong>
+                                </p>
+                            </div>
+                        </td>
+                    </tr>
+
+                    <!-- Footer -->
+                    <tr>
+                        <td style="padding: 30px 40px; background-color: #f9f9f9; border-radius: 0 0 8px 8px; border-top: 1px solid #eeeeee;">
+                            <p style="margin: 0 0 10px 0; color: #888888; font-size: 14px;">
+                                Reply to this email if you have any questions about the quotation.
+                            </p>
+                            <p style="margin: 0; color: #888888; font-size: 12px;">
+                                © 2024 Malaka ERP. All rights reserved.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`
+
+	t, err := template.New("quotation").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,56 @@
+package events
+
+import "time"
+
+// Event type constants for Calendar module
+const (
+	// Collective leave (cuti bersama) events
+	EventTypeCollectiveLeaveDeclared = "calendar.collective_leave_declared"
+	EventTypeCollectiveLeaveRevoked  = "calendar.collective_leave_revoked"
+)
+
+// CollectiveLeaveDeclaredEvent is emitted when a cuti bersama day is added to a holiday calendar
+// Subscribers: HR (deduct annual leave balances of employees covered by the calendar)
+type CollectiveLeaveDeclaredEvent struct {
+	BaseEvent
+	HolidayID     string    `json:"holiday_id"`
+	CalendarID    string    `json:"calendar_id"`
+	CompanyID     string    `json:"company_id,omitempty"`
+	RegionCode    string    `json:"region_code,omitempty"`
+	Date          time.Time `json:"date"`
+	Name          string    `json:"name"`
+	LeaveTypeCode string    `json:"leave_type_code"`
+}
+
+// NewCollectiveLeaveDeclaredEvent creates a new collective leave declared event
+func NewCollectiveLeaveDeclaredEvent(holidayID, calendarID, companyID, regionCode string, date time.Time, name, leaveTypeCode string) *CollectiveLeaveDeclaredEvent {
+	return &CollectiveLeaveDeclaredEvent{
+		BaseEvent:     NewBaseEvent(EventTypeCollectiveLeaveDeclared, holidayID, "Holiday"),
+		HolidayID:     holidayID,
+		CalendarID:    calendarID,
+		CompanyID:     companyID,
+		RegionCode:    regionCode,
+		Date:          date,
+		Name:          name,
+		LeaveTypeCode: leaveTypeCode,
+	}
+}
+
+// CollectiveLeaveRevokedEvent is emitted when a cuti bersama day is removed
+// Subscribers: HR (restore the leave deducted for the holiday)
+type CollectiveLeaveRevokedEvent struct {
+	BaseEvent
+	HolidayID string    `json:"holiday_id"`
+	Date      time.Time `json:"date"`
+	Name      string    `json:"name"`
+}
+
+// NewCollectiveLeaveRevokedEvent creates a new collective leave revoked event
+func NewCollectiveLeaveRevokedEvent(holidayID string, date time.Time, name string) *CollectiveLeaveRevokedEvent {
+	return &CollectiveLeaveRevokedEvent{
+		BaseEvent: NewBaseEvent(EventTypeCollectiveLeaveRevoked, holidayID, "Holiday"),
+		HolidayID: holidayID,
+		Date:      date,
+		Name:      name,
+	}
+}
@@ -142,3 +142,40 @@ func NewPurchaseRequestApprovedEvent(prID, requestNumber, requesterID string, to
 		ApprovedBy:        approvedBy,
 	}
 }
+
+// RFQClosedEvent is emitted when an RFQ is awarded and closed
+// Subscribers: Notifications (inform awarded and unsuccessful bidders)
+type RFQClosedEvent struct {
+	BaseEvent
+	RFQID            string              `json:"rfq_id"`
+	RFQNumber        string              `json:"rfq_number"`
+	AwardedBy        string              `json:"awarded_by"`
+	Awards           []RFQAwardEventData `json:"awards"`
+	PurchaseOrderIDs []string            `json:"purchase_order_ids"`
+	ClosedAt         time.Time           `json:"closed_at"`
+}
+
+// RFQAwardEventData represents an awarded RFQ item in events
+type RFQAwardEventData struct {
+	RFQItemID       string  `json:"rfq_item_id"`
+	SupplierID      string  `json:"supplier_id"`
+	ResponseID      string  `json:"response_id"`
+	PurchaseOrderID string  `json:"purchase_order_id"`
+	Quantity        int     `json:"quantity"`
+	UnitPrice       float64 `json:"unit_price"`
+	Currency        string  `json:"currency"`
+	Score           float64 `json:"score"`
+}
+
+// NewRFQClosedEvent creates a new RFQ closed event
+func NewRFQClosedEvent(rfqID, rfqNumber, awardedBy string, awards []RFQAwardEventData, purchaseOrderIDs []string) *RFQClosedEvent {
+	return &RFQClosedEvent{
+		BaseEvent:        NewBaseEvent(EventTypeRFQClosed, rfqID, "RFQ"),
+		RFQID:            rfqID,
+		RFQNumber:        rfqNumber,
+		AwardedBy:        awardedBy,
+		Awards:           awards,
+		PurchaseOrderIDs: purchaseOrderIDs,
+		ClosedAt:         time.Now(),
+	}
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"time"
+)
+
+// Event type constants for Sales module
+const (
+	// Order-to-cash events
+	EventTypeSalesOrderConfirmed  = "sales.order_confirmed"
+	EventTypeSalesDeliveryPosted  = "sales.delivery_posted"
+	EventTypeSalesInvoicePosted   = "sales.invoice_posted"
+	EventTypeSalesPaymentReceived = "sales.payment_received"
+)
+
+// SalesItemEventData represents an order or delivery line in sales events
+type SalesItemEventData struct {
+	SalesOrderItemID string  `json:"sales_order_item_id"`
+	ArticleID        string  `json:"article_id"`
+	Quantity         int     `json:"quantity"`
+	UnitPrice        float64 `json:"unit_price"`
+	LineTotal        float64 `json:"line_total"`
+}
+
+// SalesOrderConfirmedEvent is emitted when a sales order is confirmed and its stock reserved
+// Subscribers: Inventory (reserved stock), Shipping (delivery planning)
+type SalesOrderConfirmedEvent struct {
+	BaseEvent
+	SalesOrderID string               `json:"sales_order_id"`
+	CustomerID   string               `json:"customer_id"`
+	WarehouseID  string               `json:"warehouse_id"`
+	TotalAmount  float64              `json:"total_amount"`
+	ConfirmedBy  string               `json:"confirmed_by"`
+	Items        []SalesItemEventData `json:"items"`
+}
+
+// NewSalesOrderConfirmedEvent creates a new sales order confirmed event
+func NewSalesOrderConfirmedEvent(orderID, customerID, warehouseID string, totalAmount float64, confirmedBy string, items []SalesItemEventData) *SalesOrderConfirmedEvent {
+	return &SalesOrderConfirmedEvent{
+		BaseEvent:    NewBaseEvent(EventTypeSalesOrderConfirmed, orderID, "SalesOrder"),
+		SalesOrderID: orderID,
+		CustomerID:   customerID,
+		WarehouseID:  warehouseID,
+		TotalAmount:  totalAmount,
+		ConfirmedBy:  confirmedBy,
+		Items:        items,
+	}
+}
+
+// SalesDeliveryPostedEvent is emitted when (part of) a sales order is delivered
+// Subscribers: Inventory (goods issued), Finance (uninvoiced deliveries)
+type SalesDeliveryPostedEvent struct {
+	BaseEvent
+	DeliveryID     string               `json:"delivery_id"`
+	DeliveryNumber string               `json:"delivery_number"`
+	SalesOrderID   string               `json:"sales_order_id"`
+	CustomerID     string               `json:"customer_id"`
+	ShipmentID     string               `json:"shipment_id"`
+	GoodsIssueID   string               `json:"goods_issue_id"`
+	WarehouseID    string               `json:"warehouse_id"`
+	DeliveryDate   time.Time            `json:"delivery_date"`
+	OrderStatus    string               `json:"order_status"`
+	Items          []SalesItemEventData `json:"items"`
+}
+
+// NewSalesDeliveryPostedEvent creates a new sales delivery posted event
+func NewSalesDeliveryPostedEvent(deliveryID, deliveryNumber, orderID, customerID, shipmentID, goodsIssueID, warehouseID string, deliveryDate time.Time, orderStatus string, items []SalesItemEventData) *SalesDeliveryPostedEvent {
+	return &SalesDeliveryPostedEvent{
+		BaseEvent:      NewBaseEvent(EventTypeSalesDeliveryPosted, deliveryID, "SalesDelivery"),
+		DeliveryID:     deliveryID,
+		DeliveryNumber: deliveryNumber,
+		SalesOrderID:   orderID,
+		CustomerID:     customerID,
+		ShipmentID:     shipmentID,
+		GoodsIssueID:   goodsIssueID,
+		WarehouseID:    warehouseID,
+		DeliveryDate:   deliveryDate,
+		OrderStatus:    orderStatus,
+		Items:          items,
+	}
+}
+
+// SalesInvoicePostedEvent is emitted when a delivery is invoiced
+// Subscribers: Finance (accounts receivable), Accounting (output VAT)
+type SalesInvoicePostedEvent struct {
+	BaseEvent
+	InvoiceID     string    `json:"invoice_id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	SalesOrderID  string    `json:"sales_order_id"`
+	DeliveryID    string    `json:"delivery_id"`
+	CustomerID    string    `json:"customer_id"`
+	ReceivableID  string    `json:"receivable_id"`
+	TotalAmount   float64   `json:"total_amount"`
+	TaxAmount     float64   `json:"tax_amount"`
+	GrandTotal    float64   `json:"grand_total"`
+	DueDate       time.Time `json:"due_date"`
+	PostedBy      string    `json:"posted_by"`
+}
+
+// NewSalesInvoicePostedEvent creates a new sales invoice posted event
+func NewSalesInvoicePostedEvent(invoiceID, invoiceNumber, orderID, deliveryID, customerID, receivableID string, totalAmount, taxAmount, grandTotal float64, dueDate time.Time, postedBy string) *SalesInvoicePostedEvent {
+	return &SalesInvoicePostedEvent{
+		BaseEvent:     NewBaseEvent(EventTypeSalesInvoicePosted, invoiceID, "SalesInvoice"),
+		InvoiceID:     invoiceID,
+		InvoiceNumber: invoiceNumber,
+		SalesOrderID:  orderID,
+		DeliveryID:    deliveryID,
+		CustomerID:    customerID,
+		ReceivableID:  receivableID,
+		TotalAmount:   totalAmount,
+		TaxAmount:     taxAmount,
+		GrandTotal:    grandTotal,
+		DueDate:       dueDate,
+		PostedBy:      postedBy,
+	}
+}
+
+// SalesPaymentReceivedEvent is emitted when a customer pays a sales invoice
+// Subscribers: Finance (cash position), Sales (order payment status)
+type SalesPaymentReceivedEvent struct {
+	BaseEvent
+	PaymentID     string    `json:"payment_id"`
+	InvoiceID     string    `json:"invoice_id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	SalesOrderID  string    `json:"sales_order_id"`
+	CustomerID    string    `json:"customer_id"`
+	CashReceiptID string    `json:"cash_receipt_id"`
+	Amount        float64   `json:"amount"`
+	Balance       float64   `json:"balance"`
+	PaymentDate   time.Time `json:"payment_date"`
+	ReceivedBy    string    `json:"received_by"`
+}
+
+// NewSalesPaymentReceivedEvent creates a new sales payment received event
+func NewSalesPaymentReceivedEvent(paymentID, invoiceID, invoiceNumber, orderID, customerID, cashReceiptID string, amount, balance float64, paymentDate time.Time, receivedBy string) *SalesPaymentReceivedEvent {
+	return &SalesPaymentReceivedEvent{
+		BaseEvent:     NewBaseEvent(EventTypeSalesPaymentReceived, paymentID, "SalesPayment"),
+		PaymentID:     paymentID,
+		InvoiceID:     invoiceID,
+		InvoiceNumber: invoiceNumber,
+		SalesOrderID:  orderID,
+		CustomerID:    customerID,
+		CashReceiptID: cashReceiptID,
+		Amount:        amount,
+		Balance:       balance,
+		PaymentDate:   paymentDate,
+		ReceivedBy:    receivedBy,
+	}
+}
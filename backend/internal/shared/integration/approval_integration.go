@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"context"
+	"errors"
+)
+
+// ApprovalDocumentType identifies the kind of document routed through the approval engine
+type ApprovalDocumentType string
+
+const (
+	ApprovalDocPurchaseRequest ApprovalDocumentType = "purchase_request"
+	ApprovalDocPurchaseOrder   ApprovalDocumentType = "purchase_order"
+	ApprovalDocLeaveRequest    ApprovalDocumentType = "leave_request"
+	ApprovalDocPayrollPeriod   ApprovalDocumentType = "payroll_period"
+	ApprovalDocTransferOrder   ApprovalDocumentType = "transfer_order"
+)
+
+// ApprovalStatus represents the overall status of an approval request
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending   ApprovalStatus = "pending"
+	ApprovalStatusApproved  ApprovalStatus = "approved"
+	ApprovalStatusRejected  ApprovalStatus = "rejected"
+	ApprovalStatusCancelled ApprovalStatus = "cancelled"
+)
+
+var (
+	// ErrNotApprover is returned when a user acts on a document they are not an approver for
+	ErrNotApprover = errors.New("user is not an approver for the current approval step")
+	// ErrApprovalNotPending is returned when acting on an approval that is already decided
+	ErrApprovalNotPending = errors.New("approval request is not pending")
+	// ErrNoApprovers is returned when a step running in parallel with others has nobody to
+	// route to, so the workflow cannot be followed
+	ErrNoApprovers = errors.New("no approvers could be resolved for an approval step")
+)
+
+// ApprovalDocument describes the document being approved. Amount and Department are
+// used to pick the workflow steps that apply (amount thresholds, department conditions).
+type ApprovalDocument struct {
+	DocumentType        ApprovalDocumentType `json:"document_type"`
+	DocumentID          string               `json:"document_id"`
+	DocumentNumber      string               `json:"document_number"`
+	CompanyID           string               `json:"company_id,omitempty"`
+	RequesterID         string               `json:"requester_id,omitempty"`          // User who raised the document
+	RequesterEmployeeID string               `json:"requester_employee_id,omitempty"` // Used for reporting-line steps
+	Department          string               `json:"department,omitempty"`
+	Amount              float64              `json:"amount"`
+}
+
+// ApprovalOutcome is the state of a document's approval after an engine call
+type ApprovalOutcome struct {
+	RequestID        string         `json:"request_id"`
+	Status           ApprovalStatus `json:"status"`
+	CurrentStep      int            `json:"current_step"`
+	PendingApprovers []string       `json:"pending_approvers,omitempty"`
+}
+
+// IsApproved reports whether every applicable step has been approved
+func (o *ApprovalOutcome) IsApproved() bool {
+	return o != nil && o.Status == ApprovalStatusApproved
+}
+
+// ApprovalEngine routes documents through the configured approval chains.
+// Modules call it instead of flipping their document status directly: the document
+// moves to approved only once the outcome reports ApprovalStatusApproved.
+type ApprovalEngine interface {
+	// Submit starts the approval chain for a document (no-op if one is already pending)
+	Submit(ctx context.Context, doc *ApprovalDocument) (*ApprovalOutcome, error)
+
+	// Approve records an approval for the current step, submitting the document first if needed
+	Approve(ctx context.Context, doc *ApprovalDocument, approverID, comments string) (*ApprovalOutcome, error)
+
+	// Reject rejects the document, ending its approval chain
+	Reject(ctx context.Context, doc *ApprovalDocument, approverID, reason string) (*ApprovalOutcome, error)
+
+	// Cancel withdraws a pending approval (e.g. when the document is cancelled)
+	Cancel(ctx context.Context, documentType ApprovalDocumentType, documentID, actorID, reason string) error
+}
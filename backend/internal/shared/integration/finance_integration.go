@@ -92,3 +92,33 @@ type PaymentNotification struct {
 	PaymentMethod   string    `json:"payment_method"`
 	ReferenceNumber string    `json:"reference_number"`
 }
+
+// SupplierInvoiceDTO is an invoice a supplier submitted through the supplier portal,
+// for Finance to book as a purchase voucher
+type SupplierInvoiceDTO struct {
+	ID              string                   `json:"id"`
+	SupplierID      string                   `json:"supplier_id"`
+	PurchaseOrderID string                   `json:"purchase_order_id"`
+	InvoiceNumber   string                   `json:"invoice_number"`
+	InvoiceDate     time.Time                `json:"invoice_date"`
+	DueDate         *time.Time               `json:"due_date,omitempty"`
+	Currency        string                   `json:"currency"`
+	Subtotal        float64                  `json:"subtotal"`
+	TaxAmount       float64                  `json:"tax_amount"`
+	TotalAmount     float64                  `json:"total_amount"`
+	Lines           []SupplierInvoiceLineDTO `json:"lines"`
+}
+
+// SupplierInvoiceLineDTO is an invoiced purchase order line
+type SupplierInvoiceLineDTO struct {
+	POItemID  string  `json:"po_item_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// SupplierInvoiceRegistrar books supplier invoices in Finance.
+type SupplierInvoiceRegistrar interface {
+	// RegisterSupplierInvoice creates the purchase voucher of an invoice, matched against
+	// its purchase order, and returns the voucher ID
+	RegisterSupplierInvoice(ctx context.Context, invoice *SupplierInvoiceDTO) (string, error)
+}
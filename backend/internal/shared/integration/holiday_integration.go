@@ -0,0 +1,32 @@
+package integration
+
+import (
+	"context"
+	"time"
+)
+
+// HolidayDTO is the holiday data shared with other modules (HR leave, attendance, payroll)
+type HolidayDTO struct {
+	ID                string    `json:"id"`
+	CalendarID        string    `json:"calendar_id"`
+	Date              time.Time `json:"date"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description,omitempty"`
+	HolidayType       string    `json:"holiday_type"`
+	IsCollectiveLeave bool      `json:"is_collective_leave"`
+	RegionCode        string    `json:"region_code,omitempty"`
+}
+
+// HolidayReader exposes the company holiday calendar to other modules.
+// companyID and regionCode may be empty; national calendars (no company, no region)
+// always apply, company and regional calendars only apply when they match.
+type HolidayReader interface {
+	// IsHoliday reports whether the date is a holiday for the given company/region
+	IsHoliday(ctx context.Context, companyID, regionCode string, date time.Time) (bool, *HolidayDTO, error)
+
+	// GetHolidays returns the holidays between start and end (inclusive)
+	GetHolidays(ctx context.Context, companyID, regionCode string, start, end time.Time) ([]HolidayDTO, error)
+
+	// CountWorkingDays counts Monday-Friday days between start and end (inclusive) that are not holidays
+	CountWorkingDays(ctx context.Context, companyID, regionCode string, start, end time.Time) (int, error)
+}
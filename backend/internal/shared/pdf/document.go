@@ -0,0 +1,199 @@
+// Package pdf writes simple single-column business documents (quotations, notes and
+// the like) as PDF without external dependencies. Text uses the standard Helvetica
+// and Courier fonts in WinAnsi encoding; characters outside Latin-1 print as "?".
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size and margins in points.
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+
+	// ContentWidth is the width available between the margins.
+	ContentWidth = pageWidth - 2*margin
+
+	// courierAdvance is the advance of every Courier glyph per point of font size.
+	courierAdvance = 0.6
+)
+
+// Font resources of every page.
+const (
+	fontRegular     = "F1"
+	fontBold        = "F2"
+	fontMono        = "F3"
+	fontMonoBold    = "F4"
+	textSize        = 10.0
+	titleSize       = 16.0
+	rowSize         = 9.0
+	lineSpacing     = 1.4
+	fontDefinitions = "<< /F1 3 0 R /F2 4 0 R /F3 5 0 R /F4 6 0 R >>"
+)
+
+// Column is a cell of a table row. Rows are set in Courier so the columns line up;
+// Width is in characters.
+type Column struct {
+	Text  string
+	Width int
+	Right bool
+}
+
+// Document is a PDF document being written top to bottom. Text that does not fit on
+// the page continues on a new one.
+type Document struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// New creates an empty document with one page.
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// advance moves down by the height of a line, starting a new page when it does not fit.
+func (d *Document) advance(height float64) {
+	if d.y-height < margin {
+		d.newPage()
+	}
+	d.y -= height
+}
+
+func (d *Document) write(font string, size, x float64, text string) {
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, escape(text))
+}
+
+// Title writes a line in large bold type.
+func (d *Document) Title(text string) {
+	d.advance(titleSize * lineSpacing)
+	d.write(fontBold, titleSize, margin, text)
+}
+
+// Text writes a line of regular text.
+func (d *Document) Text(text string) {
+	d.advance(textSize * lineSpacing)
+	d.write(fontRegular, textSize, margin, text)
+}
+
+// Bold writes a line of bold text.
+func (d *Document) Bold(text string) {
+	d.advance(textSize * lineSpacing)
+	d.write(fontBold, textSize, margin, text)
+}
+
+// Row writes a table row. Text longer than its column is cut off.
+func (d *Document) Row(bold bool, columns ...Column) {
+	var line strings.Builder
+	for i, column := range columns {
+		text := []rune(column.Text)
+		if len(text) > column.Width {
+			text = text[:column.Width]
+		}
+		pad := strings.Repeat(" ", column.Width-len(text))
+		if column.Right {
+			line.WriteString(pad + string(text))
+		} else {
+			line.WriteString(string(text) + pad)
+		}
+		if i < len(columns)-1 {
+			line.WriteString(" ")
+		}
+	}
+
+	font := fontMono
+	if bold {
+		font = fontMonoBold
+	}
+	d.advance(rowSize * lineSpacing)
+	d.write(font, rowSize, margin, line.String())
+}
+
+// RowWidth returns the number of Courier characters that fit between the margins.
+func RowWidth() int {
+	width := ContentWidth / (rowSize * courierAdvance)
+	return int(width)
+}
+
+// Rule draws a horizontal line across the page.
+func (d *Document) Rule() {
+	d.advance(textSize * 0.6)
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", margin, d.y, pageWidth-margin, d.y)
+}
+
+// Space leaves empty space of the given height in points.
+func (d *Document) Space(height float64) {
+	d.advance(height)
+}
+
+// Bytes returns the document as a PDF file.
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	// Objects 1-6 are the catalog, the page tree and the fonts; each page takes two
+	// objects after them, the page and its content stream.
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 7+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font %s >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontDefinitions, 8+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// escape encodes text as the body of a PDF string literal in WinAnsi encoding.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Bytes(t *testing.T) {
+	doc := New()
+	doc.Title("Quotation QT-2026-000001")
+	doc.Text("Customer: PT Sepatu (Jakarta)")
+	doc.Rule()
+	doc.Row(true, Column{Text: "Item", Width: 20}, Column{Text: "Total", Width: 12, Right: true})
+	doc.Row(false, Column{Text: "Sneaker Putih", Width: 20}, Column{Text: "450.000", Width: 12, Right: true})
+
+	out := doc.Bytes()
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), `(Customer: PT Sepatu \(Jakarta\)) Tj`)
+	assert.Contains(t, string(out), "(Sneaker Putih             450.000) Tj")
+
+	// Every object offset in the cross-reference table points at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	require.Len(t, entries, 8)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))))
+	}
+}
+
+func TestDocument_PageBreak(t *testing.T) {
+	doc := New()
+	for i := 0; i < 100; i++ {
+		doc.Text(fmt.Sprintf("Line %d", i))
+	}
+	assert.Len(t, doc.pages, 2)
+	assert.Contains(t, string(doc.Bytes()), "/Count 2")
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\\b \(c\)`, escape(`a\b (c)`))
+	assert.Equal(t, `Caf\351 ?`, escape("Café 日"))
+	assert.Equal(t, "one two", escape("one\ntwo"))
+}